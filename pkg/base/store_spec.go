@@ -155,6 +155,40 @@ func (ss *SizeSpec) Set(value string) error {
 	return nil
 }
 
+// EngineType identifies the storage engine implementation used by a store.
+type EngineType int
+
+const (
+	// EngineTypeRocksDB is the RocksDB storage engine. It is the default.
+	EngineTypeRocksDB EngineType = iota
+	// EngineTypeGoLSM is the pure-Go LSM storage engine. Its on-disk format is
+	// not compatible with RocksDB.
+	EngineTypeGoLSM
+)
+
+var engineTypeNames = map[EngineType]string{
+	EngineTypeRocksDB: "rocksdb",
+	EngineTypeGoLSM:   "golsm",
+}
+
+// String implements the fmt.Stringer interface.
+func (e EngineType) String() string {
+	if name, ok := engineTypeNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("EngineType(%d)", int(e))
+}
+
+// parseEngineType parses the value of the "engine" field of a store spec.
+func parseEngineType(s string) (EngineType, error) {
+	for t, name := range engineTypeNames {
+		if strings.EqualFold(s, name) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("%s is not a valid storage engine (expected rocksdb or golsm)", s)
+}
+
 // StoreSpec contains the details that can be specified in the cli pertaining
 // to the --store flag.
 type StoreSpec struct {
@@ -162,6 +196,8 @@ type StoreSpec struct {
 	Size       SizeSpec
 	InMemory   bool
 	Attributes roachpb.Attributes
+	// Engine is the storage engine used by the store.
+	Engine EngineType
 	// UseFileRegistry is true if the "file registry" store version is desired.
 	// This is set by CCL code when encryption-at-rest is in use.
 	UseFileRegistry bool
@@ -182,6 +218,9 @@ func (ss StoreSpec) String() string {
 	if ss.InMemory {
 		fmt.Fprint(&buffer, "type=mem,")
 	}
+	if ss.Engine != EngineTypeRocksDB {
+		fmt.Fprintf(&buffer, "engine=%s,", ss.Engine)
+	}
 	if ss.Size.InBytes > 0 {
 		fmt.Fprintf(&buffer, "size=%s,", humanizeutil.IBytes(ss.Size.InBytes))
 	}
@@ -235,6 +274,8 @@ var fractionRegex = regexp.MustCompile(`^([-]?([0-9]+\.[0-9]*|[0-9]*\.[0-9]+|[0-
 //   - 20%             -> 20% of the available space
 //   - 0.2             -> 20% of the available space
 // - attrs=xxx:yyy:zzz A colon separated list of optional attributes.
+// - engine=xxx The storage engine of the store, rocksdb (the default) or
+//   golsm.
 // Note that commas are forbidden within any field name or value.
 func NewStoreSpec(value string) (StoreSpec, error) {
 	const pathField = "path"
@@ -308,6 +349,11 @@ func NewStoreSpec(value string) (StoreSpec, error) {
 			} else {
 				return StoreSpec{}, fmt.Errorf("%s is not a valid store type", value)
 			}
+		case "engine":
+			var err error
+			if ss.Engine, err = parseEngineType(value); err != nil {
+				return StoreSpec{}, err
+			}
 		case "rocksdb":
 			ss.RocksDBOptions = value
 		default:
@@ -325,6 +371,9 @@ func NewStoreSpec(value string) (StoreSpec, error) {
 	} else if ss.Path == "" {
 		return StoreSpec{}, fmt.Errorf("no path specified")
 	}
+	if ss.Engine != EngineTypeRocksDB && ss.RocksDBOptions != "" {
+		return StoreSpec{}, fmt.Errorf("rocksdb options specified for a %s store", ss.Engine)
+	}
 	return ss, nil
 }
 
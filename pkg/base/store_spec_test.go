@@ -117,6 +117,17 @@ func TestNewStoreSpec(t *testing.T) {
 		// RocksDB
 		{"path=/,rocksdb=key1=val1;key2=val2", "", StoreSpec{Path: "/", RocksDBOptions: "key1=val1;key2=val2"}},
 
+		// engine
+		{"path=/mnt/hda1,engine=rocksdb", "", StoreSpec{Path: "/mnt/hda1"}},
+		{"path=/mnt/hda1,engine=golsm", "", StoreSpec{Path: "/mnt/hda1", Engine: EngineTypeGoLSM}},
+		{"type=mem,size=20GiB,engine=GoLSM", "", StoreSpec{
+			Size:     SizeSpec{InBytes: 21474836480},
+			InMemory: true,
+			Engine:   EngineTypeGoLSM,
+		}},
+		{"path=/mnt/hda1,engine=leveldb", "leveldb is not a valid storage engine (expected rocksdb or golsm)", StoreSpec{}},
+		{"path=/,engine=golsm,rocksdb=key1=val1", "rocksdb options specified for a golsm store", StoreSpec{}},
+
 		// all together
 		{"path=/mnt/hda1,attrs=hdd:ssd,size=20GiB", "", StoreSpec{
 			Path:       "/mnt/hda1",
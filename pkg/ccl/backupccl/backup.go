@@ -12,6 +12,7 @@ import (
 	"bytes"
 	"context"
 	"io/ioutil"
+	"net/url"
 	"sort"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprotectedts"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
@@ -43,6 +45,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 )
 
@@ -53,16 +56,30 @@ const (
 	// BackupDescriptorCheckpointName is the file name used to store the
 	// serialized BackupDescriptor proto while the backup is in progress.
 	BackupDescriptorCheckpointName = "BACKUP-CHECKPOINT"
+	// BackupEncryptionInfoName is the file name used to store the serialized
+	// EncryptionInfo proto of an encrypted backup.
+	BackupEncryptionInfoName = "ENCRYPTION-INFO"
 	// BackupFormatDescriptorTrackingVersion added tracking of complete DBs.
 	BackupFormatDescriptorTrackingVersion uint32 = 1
 )
 
 const (
 	backupOptRevisionHistory = "revision_history"
+	backupOptEncPassphrase   = "encryption_passphrase"
+)
+
+const (
+	// localityURLParam is the URI parameter that gives the locality tier
+	// ("key=value") that a URI of a partitioned backup is for.
+	localityURLParam = "COCKROACH_LOCALITY"
+	// defaultLocalityValue may be given as the localityURLParam of the first URI
+	// of a partitioned backup, which is always the default location.
+	defaultLocalityValue = "default"
 )
 
 var backupOptionExpectValues = map[string]sql.KVStringOptValidate{
 	backupOptRevisionHistory: sql.KVStringOptRequireNoValue,
+	backupOptEncPassphrase:   sql.KVStringOptRequireValue,
 }
 
 // BackupCheckpointInterval is the interval at which backup progress is saved
@@ -71,16 +88,20 @@ var BackupCheckpointInterval = time.Minute
 
 // ReadBackupDescriptorFromURI creates an export store from the given URI, then
 // reads and unmarshals a BackupDescriptor at the standard location in the
-// export storage.
+// export storage. The encryption options must be set if the backup is
+// encrypted.
 func ReadBackupDescriptorFromURI(
-	ctx context.Context, uri string, settings *cluster.Settings,
+	ctx context.Context,
+	uri string,
+	settings *cluster.Settings,
+	encryption *roachpb.FileEncryptionOptions,
 ) (BackupDescriptor, error) {
 	exportStore, err := storageccl.ExportStorageFromURI(ctx, uri, settings)
 	if err != nil {
 		return BackupDescriptor{}, err
 	}
 	defer exportStore.Close()
-	backupDesc, err := readBackupDescriptor(ctx, exportStore, BackupDescriptorName)
+	backupDesc, err := readBackupDescriptor(ctx, exportStore, BackupDescriptorName, encryption)
 	if err != nil {
 		return BackupDescriptor{}, err
 	}
@@ -91,9 +112,12 @@ func ReadBackupDescriptorFromURI(
 }
 
 // readBackupDescriptor reads and unmarshals a BackupDescriptor from filename in
-// the provided export store.
+// the provided export store, decrypting it if encryption is set.
 func readBackupDescriptor(
-	ctx context.Context, exportStore storageccl.ExportStorage, filename string,
+	ctx context.Context,
+	exportStore storageccl.ExportStorage,
+	filename string,
+	encryption *roachpb.FileEncryptionOptions,
 ) (BackupDescriptor, error) {
 	r, err := exportStore.ReadFile(ctx, filename)
 	if err != nil {
@@ -104,6 +128,14 @@ func readBackupDescriptor(
 	if err != nil {
 		return BackupDescriptor{}, err
 	}
+	if encryption != nil {
+		if descBytes, err = storageccl.DecryptFile(descBytes, encryption.Key); err != nil {
+			return BackupDescriptor{}, err
+		}
+	} else if storageccl.AppearsEncrypted(descBytes) {
+		return BackupDescriptor{}, pgerror.Newf(pgcode.InvalidParameterValue,
+			"file appears encrypted -- try specifying %s", backupOptEncPassphrase)
+	}
 	var backupDesc BackupDescriptor
 	if err := protoutil.Unmarshal(descBytes, &backupDesc); err != nil {
 		return BackupDescriptor{}, err
@@ -435,6 +467,9 @@ func splitAndFilterSpans(
 	return out
 }
 
+// optsToKVOptions turns options back into KVOptions for a job description.
+// The encryption passphrase is redacted since the description is visible to
+// anyone who can see the job.
 func optsToKVOptions(opts map[string]string) tree.KVOptions {
 	if len(opts) == 0 {
 		return nil
@@ -448,6 +483,9 @@ func optsToKVOptions(opts map[string]string) tree.KVOptions {
 	for _, k := range sortedOpts {
 		opt := tree.KVOption{Key: tree.Name(k)}
 		if v := opts[k]; v != "" {
+			if k == backupOptEncPassphrase {
+				v = "redacted"
+			}
 			opt.Value = tree.NewDString(v)
 		}
 		kvopts = append(kvopts, opt)
@@ -458,7 +496,7 @@ func optsToKVOptions(opts map[string]string) tree.KVOptions {
 func backupJobDescription(
 	p sql.PlanHookState,
 	backup *tree.Backup,
-	to string,
+	to []string,
 	incrementalFrom []string,
 	opts map[string]string,
 ) (string, error) {
@@ -468,11 +506,13 @@ func backupJobDescription(
 		Targets: backup.Targets,
 	}
 
-	to, err := storageccl.SanitizeExportStorageURI(to)
-	if err != nil {
-		return "", err
+	for _, uri := range to {
+		sanitizedTo, err := storageccl.SanitizeExportStorageURI(uri)
+		if err != nil {
+			return "", err
+		}
+		b.To = append(b.To, tree.NewDString(sanitizedTo))
 	}
-	b.To = tree.NewDString(to)
 
 	for _, from := range incrementalFrom {
 		sanitizedFrom, err := storageccl.SanitizeExportStorageURI(from)
@@ -513,6 +553,7 @@ func writeBackupDescriptor(
 	exportStore storageccl.ExportStorage,
 	filename string,
 	desc *BackupDescriptor,
+	encryption *roachpb.FileEncryptionOptions,
 ) error {
 	sort.Sort(BackupFileDescriptors(desc.Files))
 
@@ -520,10 +561,130 @@ func writeBackupDescriptor(
 	if err != nil {
 		return err
 	}
+	if encryption != nil {
+		if descBuf, err = storageccl.EncryptFile(descBuf, encryption.Key); err != nil {
+			return err
+		}
+	}
 
 	return exportStore.WriteFile(ctx, filename, bytes.NewReader(descBuf))
 }
 
+// writeEncryptionInfo writes the EncryptionInfo of an encrypted backup to the
+// provided export store.
+func writeEncryptionInfo(
+	ctx context.Context, exportStore storageccl.ExportStorage, info *EncryptionInfo,
+) error {
+	buf, err := protoutil.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return exportStore.WriteFile(ctx, BackupEncryptionInfoName, bytes.NewReader(buf))
+}
+
+// readEncryptionInfo reads the EncryptionInfo of the backup at uri.
+func readEncryptionInfo(
+	ctx context.Context, uri string, settings *cluster.Settings,
+) (EncryptionInfo, error) {
+	exportStore, err := storageccl.ExportStorageFromURI(ctx, uri, settings)
+	if err != nil {
+		return EncryptionInfo{}, err
+	}
+	defer exportStore.Close()
+	r, err := exportStore.ReadFile(ctx, BackupEncryptionInfoName)
+	if err != nil {
+		return EncryptionInfo{}, errors.Wrapf(err,
+			"could not find %s, is %q an encrypted backup?", BackupEncryptionInfoName, uri)
+	}
+	defer r.Close()
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return EncryptionInfo{}, err
+	}
+	var info EncryptionInfo
+	if err := protoutil.Unmarshal(buf, &info); err != nil {
+		return EncryptionInfo{}, err
+	}
+	return info, nil
+}
+
+// encryptionOptionsFromPassphrase derives the key of the encrypted backup at
+// uri from the passphrase it was taken with.
+func encryptionOptionsFromPassphrase(
+	ctx context.Context, uri string, passphrase string, settings *cluster.Settings,
+) (*roachpb.FileEncryptionOptions, error) {
+	info, err := readEncryptionInfo(ctx, uri, settings)
+	if err != nil {
+		return nil, err
+	}
+	return &roachpb.FileEncryptionOptions{
+		Key: storageccl.GenerateKey([]byte(passphrase), info.Salt),
+	}, nil
+}
+
+// getURIsByLocalityKV splits the URIs of a possibly partitioned backup into
+// the default URI and the URIs for each locality tier. The backup descriptor,
+// and the files of any node that doesn't have one of the given locality tiers,
+// are written to the default URI, which is always the first one. It may also be
+// used for a locality tier, but every other URI must have one. The
+// localityURLParam is removed from the returned URIs.
+func getURIsByLocalityKV(uris []string) (string, map[string]string, error) {
+	var defaultURI string
+	urisByLocalityKV := make(map[string]string)
+	for i, uri := range uris {
+		parsed, err := url.Parse(uri)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "parsing %q", uri)
+		}
+		q := parsed.Query()
+		localityKV := q.Get(localityURLParam)
+		if _, ok := q[localityURLParam]; ok {
+			q.Del(localityURLParam)
+			parsed.RawQuery = q.Encode()
+			uri = parsed.String()
+		}
+
+		if i == 0 {
+			defaultURI = uri
+			if localityKV == "" || localityKV == defaultLocalityValue {
+				continue
+			}
+		} else if localityKV == "" || localityKV == defaultLocalityValue {
+			return "", nil, errors.Errorf(
+				"%s must be set to a locality tier for all but the first location", localityURLParam)
+		}
+
+		var tier roachpb.Tier
+		if err := tier.FromString(localityKV); err != nil {
+			return "", nil, errors.Wrapf(err, "invalid %s", localityURLParam)
+		}
+		if _, ok := urisByLocalityKV[tier.String()]; ok {
+			return "", nil, errors.Errorf("more than one location for locality %s", tier)
+		}
+		urisByLocalityKV[tier.String()] = uri
+	}
+	return defaultURI, urisByLocalityKV, nil
+}
+
+// makeStorageByLocalityKV returns the ExportStorage confs of the URIs
+// returned by getURIsByLocalityKV.
+func makeStorageByLocalityKV(
+	urisByLocalityKV map[string]string,
+) (map[string]*roachpb.ExportStorage, error) {
+	if len(urisByLocalityKV) == 0 {
+		return nil, nil
+	}
+	storage := make(map[string]*roachpb.ExportStorage, len(urisByLocalityKV))
+	for kv, uri := range urisByLocalityKV {
+		conf, err := storageccl.ExportStorageConfFromURI(uri)
+		if err != nil {
+			return nil, errors.Wrapf(err, "export configuration for locality %s", kv)
+		}
+		storage[kv] = &conf
+	}
+	return storage, nil
+}
+
 func loadAllDescs(
 	ctx context.Context, db *client.DB, asOf hlc.Timestamp,
 ) ([]sqlbase.Descriptor, error) {
@@ -581,10 +742,12 @@ func backup(
 	gossip *gossip.Gossip,
 	settings *cluster.Settings,
 	exportStore storageccl.ExportStorage,
+	storageByLocalityKV map[string]*roachpb.ExportStorage,
 	job *jobs.Job,
 	backupDesc *BackupDescriptor,
 	checkpointDesc *BackupDescriptor,
 	resultsCh chan<- tree.Datums,
+	encryption *roachpb.FileEncryptionOptions,
 ) (roachpb.BulkOpSummary, error) {
 	// TODO(dan): Figure out how permissions should work. #6713 is tracking this
 	// for grpc.
@@ -692,10 +855,12 @@ func backup(
 				defer func() { <-exportsSem }()
 				header := roachpb.Header{Timestamp: span.end}
 				req := &roachpb.ExportRequest{
-					RequestHeader: roachpb.RequestHeaderFromSpan(span.span),
-					Storage:       exportStore.Conf(),
-					StartTime:     span.start,
-					MVCCFilter:    roachpb.MVCCFilter(backupDesc.MVCCFilter),
+					RequestHeader:       roachpb.RequestHeaderFromSpan(span.span),
+					Storage:             exportStore.Conf(),
+					StorageByLocalityKV: storageByLocalityKV,
+					StartTime:           span.start,
+					MVCCFilter:          roachpb.MVCCFilter(backupDesc.MVCCFilter),
+					Encryption:          encryption,
 				}
 				rawRes, pErr := client.SendWrappedWith(ctx, db.NonTransactionalSender(), header, req)
 				if pErr != nil {
@@ -713,6 +878,7 @@ func backup(
 						Path:        file.Path,
 						Sha512:      file.Sha512,
 						EntryCounts: file.Exported,
+						LocalityKV:  file.LocalityKV,
 					}
 					if span.start != backupDesc.StartTime {
 						f.StartTime = span.start
@@ -736,7 +902,7 @@ func backup(
 					checkpointMu.Lock()
 					backupDesc.Files = checkpointFiles
 					err := writeBackupDescriptor(
-						ctx, exportStore, BackupDescriptorCheckpointName, backupDesc,
+						ctx, exportStore, BackupDescriptorCheckpointName, backupDesc, encryption,
 					)
 					checkpointMu.Unlock()
 					if err != nil {
@@ -758,7 +924,9 @@ func backup(
 	backupDesc.Files = mu.files
 	backupDesc.EntryCounts = mu.exported
 
-	if err := writeBackupDescriptor(ctx, exportStore, BackupDescriptorName, backupDesc); err != nil {
+	if err := writeBackupDescriptor(
+		ctx, exportStore, BackupDescriptorName, backupDesc, encryption,
+	); err != nil {
 		return mu.exported, err
 	}
 
@@ -770,9 +938,13 @@ func backup(
 // that the location is writable and locking out accidental concurrent
 // operations on that location if subsequently try this check. Callers must
 // clean up the written checkpoint file (BackupDescriptorCheckpointName) only
-// after writing to the backup file location (BackupDescriptorName).
+// after writing to the backup file location (BackupDescriptorName). The
+// checkpoint is encrypted if encryption is set.
 func VerifyUsableExportTarget(
-	ctx context.Context, exportStore storageccl.ExportStorage, readable string,
+	ctx context.Context,
+	exportStore storageccl.ExportStorage,
+	readable string,
+	encryption *roachpb.FileEncryptionOptions,
 ) error {
 	if r, err := exportStore.ReadFile(ctx, BackupDescriptorName); err == nil {
 		// TODO(dt): If we audit exactly what not-exists error each ExportStorage
@@ -789,7 +961,7 @@ func VerifyUsableExportTarget(
 			readable, BackupDescriptorCheckpointName)
 	}
 	if err := writeBackupDescriptor(
-		ctx, exportStore, BackupDescriptorCheckpointName, &BackupDescriptor{},
+		ctx, exportStore, BackupDescriptorCheckpointName, &BackupDescriptor{}, encryption,
 	); err != nil {
 		return errors.Wrapf(err, "cannot write to %s", readable)
 	}
@@ -805,7 +977,7 @@ func backupPlanHook(
 		return nil, nil, nil, false, nil
 	}
 
-	toFn, err := p.TypeAsString(backupStmt.To, "BACKUP")
+	toFn, err := p.TypeAsStringArray(tree.Exprs(backupStmt.To), "BACKUP")
 	if err != nil {
 		return nil, nil, nil, false, err
 	}
@@ -856,6 +1028,20 @@ func backupPlanHook(
 			return err
 		}
 
+		defaultURI, urisByLocalityKV, err := getURIsByLocalityKV(to)
+		if err != nil {
+			return err
+		}
+		if len(urisByLocalityKV) > 0 &&
+			!p.ExecCfg().Settings.Version.IsActive(cluster.VersionPartitionedBackup) {
+			return errors.Errorf("partitioned BACKUP requires all nodes to be upgraded to %s",
+				cluster.VersionByKey(cluster.VersionPartitionedBackup))
+		}
+		// Check that every location is valid before doing any work.
+		if _, err := makeStorageByLocalityKV(urisByLocalityKV); err != nil {
+			return err
+		}
+
 		endTime := p.ExecCfg().Clock.Now()
 		if backupStmt.AsOf.Expr != nil {
 			var err error
@@ -864,7 +1050,7 @@ func backupPlanHook(
 			}
 		}
 
-		exportStore, err := storageccl.ExportStorageFromURI(ctx, to, p.ExecCfg().Settings)
+		exportStore, err := storageccl.ExportStorageFromURI(ctx, defaultURI, p.ExecCfg().Settings)
 		if err != nil {
 			return err
 		}
@@ -880,6 +1066,34 @@ func backupPlanHook(
 			mvccFilter = MVCCFilter_All
 		}
 
+		// An encrypted backup's key is derived from the passphrase and a random
+		// salt, which incremental backups reuse from the full backup they build
+		// on so that the whole chain can be restored with one key.
+		var encryption *roachpb.FileEncryptionOptions
+		var encryptionInfo *EncryptionInfo
+		if passphrase, ok := opts[backupOptEncPassphrase]; ok {
+			if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionBackupEncryption) {
+				return errors.Errorf("BACKUP with %s requires all nodes to be upgraded to %s",
+					backupOptEncPassphrase, cluster.VersionByKey(cluster.VersionBackupEncryption))
+			}
+			if len(incrementalFrom) > 0 {
+				info, err := readEncryptionInfo(ctx, incrementalFrom[0], p.ExecCfg().Settings)
+				if err != nil {
+					return err
+				}
+				encryptionInfo = &info
+			} else {
+				salt, err := storageccl.GenerateSalt()
+				if err != nil {
+					return err
+				}
+				encryptionInfo = &EncryptionInfo{Salt: salt}
+			}
+			encryption = &roachpb.FileEncryptionOptions{
+				Key: storageccl.GenerateKey([]byte(passphrase), encryptionInfo.Salt),
+			}
+		}
+
 		targetDescs, completeDBs, err := ResolveTargetsToDescriptors(ctx, p, endTime, backupStmt.Targets)
 		if err != nil {
 			return err
@@ -909,7 +1123,7 @@ func backupPlanHook(
 			clusterID := p.ExecCfg().ClusterID()
 			prevBackups = make([]BackupDescriptor, len(incrementalFrom))
 			for i, uri := range incrementalFrom {
-				desc, err := ReadBackupDescriptorFromURI(ctx, uri, p.ExecCfg().Settings)
+				desc, err := ReadBackupDescriptorFromURI(ctx, uri, p.ExecCfg().Settings, encryption)
 				if err != nil {
 					return errors.Wrapf(err, "failed to read backup from %q", uri)
 				}
@@ -992,7 +1206,8 @@ func backupPlanHook(
 			}
 
 			var err error
-			_, coveredTime, err := makeImportSpans(spans, prevBackups, keys.MinKey,
+			_, coveredTime, err := makeImportSpans(
+				spans, prevBackups, nil /* backupLocalityInfo */, keys.MinKey,
 				func(span intervalccl.Range, start, end hlc.Timestamp) error {
 					if (start == hlc.Timestamp{}) {
 						newSpans = append(newSpans, roachpb.Span{Key: span.Start, EndKey: span.End})
@@ -1034,7 +1249,8 @@ func backupPlanHook(
 		// including this backup, to ensure that the this backup plus any previous
 		// backups does cover the interval expected.
 		if _, coveredEnd, err := makeImportSpans(
-			spans, append(prevBackups, backupDesc), keys.MinKey, errOnMissingRange,
+			spans, append(prevBackups, backupDesc), nil /* backupLocalityInfo */, keys.MinKey,
+			errOnMissingRange,
 		); err != nil {
 			return err
 		} else if coveredEnd != endTime {
@@ -1051,10 +1267,24 @@ func backupPlanHook(
 			return err
 		}
 
-		if err := VerifyUsableExportTarget(ctx, exportStore, to); err != nil {
+		if err := VerifyUsableExportTarget(ctx, exportStore, defaultURI, encryption); err != nil {
 			return err
 		}
+		if encryptionInfo != nil {
+			if err := writeEncryptionInfo(ctx, exportStore, encryptionInfo); err != nil {
+				return err
+			}
+		}
 
+		details := jobspb.BackupDetails{
+			StartTime:        startTime,
+			EndTime:          endTime,
+			URI:              defaultURI,
+			URIsByLocalityKV: urisByLocalityKV,
+			BackupDescriptor: descBytes,
+			Encryption:       encryption,
+		}
+		onCreate := protectTimestampOnCreate(p, &details, backupDesc.Spans)
 		_, errCh, err := p.ExecCfg().JobRegistry.StartJob(ctx, resultsCh, jobs.Record{
 			Description: description,
 			Username:    p.User(),
@@ -1064,13 +1294,9 @@ func backupPlanHook(
 				}
 				return sqlDescIDs
 			}(),
-			Details: jobspb.BackupDetails{
-				StartTime:        startTime,
-				EndTime:          endTime,
-				URI:              to,
-				BackupDescriptor: descBytes,
-			},
+			Details:  details,
 			Progress: jobspb.BackupProgress{},
+			OnCreate: onCreate,
 		})
 		if err != nil {
 			return err
@@ -1110,8 +1336,14 @@ func (b *backupResumer) Resume(
 	if err != nil {
 		return errors.Wrapf(err, "make storage")
 	}
+	storageByLocalityKV, err := makeStorageByLocalityKV(details.URIsByLocalityKV)
+	if err != nil {
+		return err
+	}
 	var checkpointDesc *BackupDescriptor
-	if desc, err := readBackupDescriptor(ctx, exportStore, BackupDescriptorCheckpointName); err == nil {
+	if desc, err := readBackupDescriptor(
+		ctx, exportStore, BackupDescriptorCheckpointName, details.Encryption,
+	); err == nil {
 		// If the checkpoint is from a different cluster, it's meaningless to us.
 		// More likely though are dummy/lock-out checkpoints with no ClusterID.
 		if desc.ClusterID.Equal(p.ExecCfg().ClusterID()) {
@@ -1125,26 +1357,68 @@ func (b *backupResumer) Resume(
 		// implementations.
 		log.Warningf(ctx, "unable to load backup checkpoint while resuming job %d: %v", *b.job.ID(), err)
 	}
+	if err := jobsprotectedts.Verify(
+		ctx, b.job.ProtectedTimestamps(), details.ProtectedTimestampRecord,
+	); err != nil {
+		return err
+	}
 	res, err := backup(
 		ctx,
 		p.ExecCfg().DB,
 		p.ExecCfg().Gossip,
 		p.ExecCfg().Settings,
 		exportStore,
+		storageByLocalityKV,
 		b.job,
 		&backupDesc,
 		checkpointDesc,
 		resultsCh,
+		details.Encryption,
 	)
 	b.res = res
 	return err
 }
 
+// protectTimestampOnCreate returns a jobs.Record OnCreate hook which writes a
+// protected timestamp record, keeping the data read by the backup from being
+// garbage collected while it runs, in the transaction which creates the job.
+// It stores the ID of the record in details. It returns nil if protected
+// timestamps are not available.
+func protectTimestampOnCreate(
+	p sql.PlanHookState, details *jobspb.BackupDetails, spans []roachpb.Span,
+) func(context.Context, *client.Txn, int64) error {
+	pts := p.ExecCfg().JobRegistry.ProtectedTimestamps()
+	if pts == nil || !p.ExecCfg().Settings.Version.IsActive(cluster.VersionProtectedTimestamps) {
+		return nil
+	}
+	// Incremental backups read the revisions since the previous backup.
+	ts := details.EndTime
+	if !details.StartTime.IsEmpty() {
+		ts = details.StartTime
+	}
+	id := uuid.MakeV4()
+	details.ProtectedTimestampRecord = &id
+	return func(ctx context.Context, txn *client.Txn, jobID int64) error {
+		return pts.Protect(ctx, txn, jobsprotectedts.MakeRecord(id, jobID, ts, spans))
+	}
+}
+
+// releaseProtectedTimestamp releases the job's protected timestamp record, if
+// any.
+func (b *backupResumer) releaseProtectedTimestamp(ctx context.Context, txn *client.Txn) error {
+	details := b.job.Details().(jobspb.BackupDetails)
+	return jobsprotectedts.Release(ctx, txn, b.job.ProtectedTimestamps(), details.ProtectedTimestampRecord)
+}
+
 // OnFailOrCancel is part of the jobs.Resumer interface.
-func (b *backupResumer) OnFailOrCancel(context.Context, *client.Txn) error { return nil }
+func (b *backupResumer) OnFailOrCancel(ctx context.Context, txn *client.Txn) error {
+	return b.releaseProtectedTimestamp(ctx, txn)
+}
 
 // OnSuccess is part of the jobs.Resumer interface.
-func (b *backupResumer) OnSuccess(context.Context, *client.Txn) error { return nil }
+func (b *backupResumer) OnSuccess(ctx context.Context, txn *client.Txn) error {
+	return b.releaseProtectedTimestamp(ctx, txn)
+}
 
 // OnTerminal is part of the jobs.Resumer interface.
 func (b *backupResumer) OnTerminal(
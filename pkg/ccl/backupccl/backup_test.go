@@ -29,6 +29,7 @@ import (
 	"github.com/cockroachdb/cockroach-go/crdb"
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
 	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl/sampledataccl"
 	"github.com/cockroachdb/cockroach/pkg/config"
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
@@ -86,6 +87,12 @@ func backupRestoreTestSetupWithParams(
 	dir, dirCleanupFn := testutils.TempDir(t)
 	params.ServerArgs.ExternalIODir = dir
 	params.ServerArgs.UseDatabase = "data"
+	for i := range params.ServerArgsPerNode {
+		serverArgs := params.ServerArgsPerNode[i]
+		serverArgs.ExternalIODir = dir
+		serverArgs.UseDatabase = "data"
+		params.ServerArgsPerNode[i] = serverArgs
+	}
 	tc = testcluster.StartTestCluster(t, clusterSize, params)
 	init(tc)
 
@@ -2571,6 +2578,132 @@ func TestBackupRestoreIncrementalAddTableMissing(t *testing.T) {
 	)
 }
 
+func TestBackupRestoreEncrypted(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numAccounts = 10
+	_, _, sqlDB, _, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts, initNone)
+	defer cleanupFn()
+	full, inc := filepath.Join(localFoo, "full"), filepath.Join(localFoo, "inc")
+
+	sqlDB.Exec(t, `BACKUP DATABASE data TO $1 WITH encryption_passphrase = 'abc'`, full)
+	sqlDB.Exec(t, `UPDATE data.bank SET balance = balance + 1`)
+	sqlDB.ExpectErr(
+		t, "file appears encrypted",
+		`BACKUP DATABASE data TO $1 INCREMENTAL FROM $2`, inc, full,
+	)
+	sqlDB.Exec(t,
+		`BACKUP DATABASE data TO $1 INCREMENTAL FROM $2 WITH encryption_passphrase = 'abc'`,
+		inc, full,
+	)
+
+	// Nothing written to the backup should be readable without the key.
+	for _, dir := range []string{full, inc} {
+		files, err := filepath.Glob(filepath.Join(dir, "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			if filepath.Base(file) == backupccl.BackupEncryptionInfoName {
+				continue
+			}
+			contents, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !storageccl.AppearsEncrypted(contents) {
+				t.Errorf("%s is not encrypted", file)
+			}
+		}
+	}
+
+	sqlDB.ExpectErr(t, "file appears encrypted", `SHOW BACKUP $1`, full)
+	sqlDB.ExpectErr(
+		t, "failed to decrypt", `SHOW BACKUP $1 WITH encryption_passphrase = 'wrong'`, full,
+	)
+	sqlDB.CheckQueryResults(t,
+		`SELECT table_name FROM [SHOW BACKUP $1 WITH encryption_passphrase = 'abc']`,
+		[][]string{{"bank"}},
+	)
+
+	sqlDB.Exec(t, `CREATE DATABASE data2`)
+	sqlDB.ExpectErr(
+		t, "file appears encrypted",
+		`RESTORE data.* FROM $1, $2 WITH into_db = 'data2'`, full, inc,
+	)
+	sqlDB.ExpectErr(
+		t, "failed to decrypt",
+		`RESTORE data.* FROM $1, $2 WITH into_db = 'data2', encryption_passphrase = 'wrong'`,
+		full, inc,
+	)
+	sqlDB.Exec(t,
+		`RESTORE data.* FROM $1, $2 WITH into_db = 'data2', encryption_passphrase = 'abc'`,
+		full, inc,
+	)
+
+	expected := sqlDB.QueryStr(t, `SELECT * FROM data.bank`)
+	sqlDB.CheckQueryResults(t, `SELECT * FROM data2.bank`, expected)
+}
+
+func TestBackupRestorePartitioned(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numAccounts = 1000
+	args := base.TestClusterArgs{ServerArgsPerNode: make(map[int]base.TestServerArgs)}
+	for i, region := range []string{"west", "east", "central"} {
+		args.ServerArgsPerNode[i] = base.TestServerArgs{
+			Locality: roachpb.Locality{Tiers: []roachpb.Tier{{Key: "region", Value: region}}},
+		}
+	}
+	_, _, sqlDB, dir, cleanupFn := backupRestoreTestSetupWithParams(
+		t, multiNode, numAccounts, initNone, args,
+	)
+	defer cleanupFn()
+
+	// Spread the leases over the nodes so that each location gets some files.
+	sqlDB.Exec(t, `ALTER TABLE data.bank EXPERIMENTAL_RELOCATE LEASE
+		SELECT (i % 3) + 1, i * 100 FROM generate_series(0, 9) AS g(i)`)
+
+	locations := []string{
+		localFoo + "/default?COCKROACH_LOCALITY=default",
+		localFoo + "/west?COCKROACH_LOCALITY=region=west",
+		localFoo + "/east?COCKROACH_LOCALITY=region=east",
+	}
+	sqlDB.Exec(t, `BACKUP DATABASE data TO ($1, $2, $3)`,
+		locations[0], locations[1], locations[2])
+
+	// The descriptor is only written to the default location, and the files of
+	// the node in "central", which has no location, go there too.
+	var localityFiles int
+	for _, subdir := range []string{"default", "west", "east"} {
+		files, err := filepath.Glob(filepath.Join(dir, "foo", subdir, "*.sst"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if subdir != "default" {
+			localityFiles += len(files)
+		}
+		_, err = os.Stat(filepath.Join(dir, "foo", subdir, backupccl.BackupDescriptorName))
+		if hasDesc := err == nil; hasDesc != (subdir == "default") {
+			t.Errorf("%s: expected descriptor %t got %t", subdir, subdir == "default", hasDesc)
+		}
+	}
+	if localityFiles == 0 {
+		t.Fatal("expected some files to be written to a locality-specific location")
+	}
+
+	sqlDB.Exec(t, `CREATE DATABASE data2`)
+	sqlDB.ExpectErr(t, "no location was given",
+		`RESTORE data.* FROM $1 WITH into_db = 'data2'`, locations[0])
+	sqlDB.ExpectErr(t, "must be set to a locality tier",
+		`RESTORE data.* FROM ($1, $2) WITH into_db = 'data2'`, locations[0], localFoo+"/west")
+	sqlDB.Exec(t, `RESTORE data.* FROM ($1, $2, $3) WITH into_db = 'data2'`,
+		locations[0], locations[1], locations[2])
+
+	expected := sqlDB.QueryStr(t, `SELECT * FROM data.bank ORDER BY id`)
+	sqlDB.CheckQueryResults(t, `SELECT * FROM data2.bank ORDER BY id`, expected)
+}
+
 func TestBackupRestoreIncrementalTrucateTable(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -2784,6 +2917,49 @@ func TestBackupRestoreSequence(t *testing.T) {
 	})
 }
 
+func TestBackupRestoreUserDefinedTypes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	const numAccounts = 1
+	_, _, origDB, dir, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts, initNone)
+	defer cleanupFn()
+	args := base.TestServerArgs{ExternalIODir: dir, UseDatabase: "data"}
+
+	origDB.Exec(t, `CREATE DOMAIN posint AS INT CHECK (VALUE > 0)`)
+	origDB.Exec(t, `CREATE TYPE pair AS (x INT, y INT)`)
+	origDB.Exec(t, `CREATE TABLE data.t (id INT PRIMARY KEY, v posint, p pair)`)
+	origDB.Exec(t, `INSERT INTO data.t VALUES (1, 1, (2, 3))`)
+	origDB.Exec(t, `BACKUP TABLE data.t TO $1`, localFoo)
+
+	tc := testcluster.StartTestCluster(t, singleNode, base.TestClusterArgs{ServerArgs: args})
+	defer tc.Stopper().Stop(context.TODO())
+	newDB := sqlutils.MakeSQLRunner(tc.Conns[0])
+
+	// Create a table first, so that the restored descriptors get new IDs.
+	newDB.Exec(t, `CREATE DATABASE data`)
+	newDB.Exec(t, `CREATE TABLE data.other (a INT)`)
+	newDB.Exec(t, `RESTORE TABLE data.t FROM $1`, localFoo)
+
+	// The types were restored along with the table, and the columns refer to
+	// them under their new IDs.
+	newDB.CheckQueryResults(t, `SELECT id, v, (p).y FROM data.t`, [][]string{{"1", "1", "3"}})
+	newDB.ExpectErr(
+		t, `cannot drop type pair because column p of table t depends on it`, `DROP TYPE pair`,
+	)
+	newDB.ExpectErr(t, `violates check constraint`, `SELECT 0::posint`)
+	newDB.ExpectErr(
+		t, `cannot drop domain posint because column v of table t depends on it`,
+		`DROP DOMAIN posint`,
+	)
+	newDB.Exec(t, `ALTER DOMAIN posint ADD CONSTRAINT small CHECK (VALUE < 10)`)
+	newDB.ExpectErr(
+		t, `failed to satisfy CHECK constraint`, `INSERT INTO data.t VALUES (2, 20, (1, 1))`,
+	)
+
+	// Restoring the table again fails, as the domain exists already.
+	newDB.Exec(t, `DROP TABLE data.t`)
+	newDB.ExpectErr(t, `relation "posint" already exists`, `RESTORE TABLE data.t FROM $1`, localFoo)
+}
+
 func TestBackupRestoreShowJob(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -0,0 +1,91 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestGetURIsByLocalityKV(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	tests := []struct {
+		uris       []string
+		defaultURI string
+		byLocality map[string]string
+		err        string
+	}{
+		{
+			uris:       []string{"nodelocal:///foo"},
+			defaultURI: "nodelocal:///foo",
+			byLocality: map[string]string{},
+		},
+		{
+			uris:       []string{"nodelocal:///foo?COCKROACH_LOCALITY=default"},
+			defaultURI: "nodelocal:///foo",
+			byLocality: map[string]string{},
+		},
+		{
+			uris: []string{
+				"s3://us/a?AWS_ACCESS_KEY_ID=x&COCKROACH_LOCALITY=default",
+				"s3://eu/b?COCKROACH_LOCALITY=region%3Deu&AWS_ACCESS_KEY_ID=x",
+			},
+			defaultURI: "s3://us/a?AWS_ACCESS_KEY_ID=x",
+			byLocality: map[string]string{"region=eu": "s3://eu/b?AWS_ACCESS_KEY_ID=x"},
+		},
+		{
+			uris: []string{
+				"s3://us?COCKROACH_LOCALITY=region=us",
+				"s3://eu?COCKROACH_LOCALITY=region=eu",
+			},
+			defaultURI: "s3://us",
+			byLocality: map[string]string{"region=us": "s3://us", "region=eu": "s3://eu"},
+		},
+		{
+			uris: []string{"nodelocal:///foo", "nodelocal:///bar"},
+			err:  "must be set to a locality tier",
+		},
+		{
+			uris: []string{"nodelocal:///foo", "nodelocal:///bar?COCKROACH_LOCALITY=default"},
+			err:  "must be set to a locality tier",
+		},
+		{
+			uris: []string{"nodelocal:///foo", "nodelocal:///bar?COCKROACH_LOCALITY=region"},
+			err:  "tier must be in the form",
+		},
+		{
+			uris: []string{
+				"nodelocal:///foo",
+				"nodelocal:///bar?COCKROACH_LOCALITY=region=a",
+				"nodelocal:///baz?COCKROACH_LOCALITY=region=a",
+			},
+			err: "more than one location for locality region=a",
+		},
+	}
+	for _, test := range tests {
+		defaultURI, byLocality, err := getURIsByLocalityKV(test.uris)
+		if !testutils.IsError(err, test.err) {
+			t.Errorf("%v: expected error %q got %v", test.uris, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if defaultURI != test.defaultURI {
+			t.Errorf("%v: expected default %s got %s", test.uris, test.defaultURI, defaultURI)
+		}
+		if !reflect.DeepEqual(byLocality, test.byLocality) {
+			t.Errorf("%v: expected %v got %v", test.uris, test.byLocality, byLocality)
+		}
+	}
+}
@@ -56,18 +56,22 @@ const (
 )
 
 var restoreOptionExpectValues = map[string]sql.KVStringOptValidate{
+	backupOptEncPassphrase:         sql.KVStringOptRequireValue,
 	restoreOptIntoDB:               sql.KVStringOptRequireValue,
 	restoreOptSkipMissingFKs:       sql.KVStringOptRequireNoValue,
 	restoreOptSkipMissingSequences: sql.KVStringOptRequireNoValue,
 }
 
 func loadBackupDescs(
-	ctx context.Context, uris []string, settings *cluster.Settings,
+	ctx context.Context,
+	uris []string,
+	settings *cluster.Settings,
+	encryption *roachpb.FileEncryptionOptions,
 ) ([]BackupDescriptor, error) {
 	backupDescs := make([]BackupDescriptor, len(uris))
 
 	for i, uri := range uris {
-		desc, err := ReadBackupDescriptorFromURI(ctx, uri, settings)
+		desc, err := ReadBackupDescriptorFromURI(ctx, uri, settings, encryption)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to read backup descriptor")
 		}
@@ -119,6 +123,9 @@ func loadSQLDescsFromBackupsAtTime(
 				continue
 			}
 		}
+		if t := desc.GetType(); t != nil && byID[t.ParentID] == nil {
+			continue
+		}
 		allDescs = append(allDescs, *desc)
 	}
 	return allDescs, lastBackupDesc
@@ -210,11 +217,14 @@ func allocateTableRewrites(
 
 	databasesByID := make(map[sqlbase.ID]*sqlbase.DatabaseDescriptor)
 	tablesByID := make(map[sqlbase.ID]*sqlbase.TableDescriptor)
+	typesByID := make(map[sqlbase.ID]*sqlbase.TypeDescriptor)
 	for _, desc := range sqlDescs {
 		if dbDesc := desc.GetDatabase(); dbDesc != nil {
 			databasesByID[dbDesc.ID] = dbDesc
 		} else if tableDesc := desc.GetTable(); tableDesc != nil {
 			tablesByID[tableDesc.ID] = tableDesc
+		} else if typDesc := desc.GetType(); typDesc != nil {
+			typesByID[typDesc.ID] = typDesc
 		}
 	}
 
@@ -332,6 +342,44 @@ func allocateTableRewrites(
 				tableRewrites[table.ID] = &jobspb.RestoreDetails_TableRewrite{ParentID: parentID}
 			}
 		}
+
+		// User-defined types are restored into the database of the tables using
+		// them. Their privileges are checked when they are written, like those of
+		// tables.
+		for _, typ := range typesByID {
+			targetDB := overrideDB
+			if !renaming {
+				database, ok := databasesByID[typ.ParentID]
+				if !ok {
+					return errors.Errorf("no database with ID %d in backup for type %q",
+						typ.ParentID, typ.Name)
+				}
+				targetDB = database.Name
+			}
+
+			if _, ok := restoreDBNames[targetDB]; ok {
+				needsNewParentIDs[targetDB] = append(needsNewParentIDs[targetDB], typ.ID)
+				continue
+			}
+			dbNameKey := sqlbase.MakeNameMetadataKey(keys.RootNamespaceID, targetDB)
+			existingDatabaseID, err := txn.Get(ctx, dbNameKey)
+			if err != nil {
+				return err
+			}
+			if existingDatabaseID.Value == nil {
+				return errors.Errorf("a database named %q needs to exist to restore type %q",
+					targetDB, typ.Name)
+			}
+			newParentID, err := existingDatabaseID.Value.GetInt()
+			if err != nil {
+				return err
+			}
+			// Types and tables share system.namespace.
+			if err := CheckTableExists(ctx, txn, sqlbase.ID(newParentID), typ.Name); err != nil {
+				return err
+			}
+			tableRewrites[typ.ID] = &jobspb.RestoreDetails_TableRewrite{ParentID: sqlbase.ID(newParentID)}
+		}
 		return nil
 	}); err != nil {
 		return nil, err
@@ -374,6 +422,19 @@ func allocateTableRewrites(
 		tableRewrites[table.ID].TableID = newTableID
 	}
 
+	typeIDs := make([]sqlbase.ID, 0, len(typesByID))
+	for id := range typesByID {
+		typeIDs = append(typeIDs, id)
+	}
+	sort.Slice(typeIDs, func(i, j int) bool { return typeIDs[i] < typeIDs[j] })
+	for _, id := range typeIDs {
+		newTypeID, err := sql.GenerateUniqueDescID(ctx, p.ExecCfg().DB)
+		if err != nil {
+			return nil, err
+		}
+		tableRewrites[id].TableID = newTypeID
+	}
+
 	return tableRewrites, nil
 }
 
@@ -491,6 +552,36 @@ func RewriteTableDescs(
 			}
 		}
 
+		// Rewrite user-defined type references in column descriptors and in the
+		// check constraints derived from domains.
+		for idx := range table.Columns {
+			col := &table.Columns[idx]
+			for _, typID := range []*sqlbase.ID{&col.DomainID, &col.CompositeTypeID} {
+				if *typID == sqlbase.InvalidID {
+					continue
+				}
+				typRewrite, ok := tableRewrites[*typID]
+				if !ok {
+					return errors.Errorf(
+						"cannot restore %q without restoring referenced type %d in same operation",
+						table.Name, *typID)
+				}
+				*typID = typRewrite.TableID
+			}
+		}
+		for _, ck := range table.Checks {
+			if ck.DomainID == sqlbase.InvalidID {
+				continue
+			}
+			typRewrite, ok := tableRewrites[ck.DomainID]
+			if !ok {
+				return errors.Errorf(
+					"cannot restore %q without restoring referenced type %d in same operation",
+					table.Name, ck.DomainID)
+			}
+			ck.DomainID = typRewrite.TableID
+		}
+
 		// Rewrite sequence references in column descriptors.
 		for idx := range table.Columns {
 			var newSeqRefs []sqlbase.ID
@@ -518,6 +609,20 @@ func RewriteTableDescs(
 	return nil
 }
 
+// rewriteTypeDescs mutates types to match the ID and parent ID specified in
+// tableRewrites.
+func rewriteTypeDescs(typs []*sqlbase.TypeDescriptor, tableRewrites TableRewriteMap) error {
+	for _, typ := range typs {
+		typRewrite, ok := tableRewrites[typ.ID]
+		if !ok {
+			return errors.Errorf("missing rewrite for type %d", typ.ID)
+		}
+		typ.ID = typRewrite.TableID
+		typ.ParentID = typRewrite.ParentID
+	}
+	return nil
+}
+
 type intervalSpan roachpb.Span
 
 var _ interval.Interface = intervalSpan{}
@@ -595,9 +700,15 @@ func errOnMissingRange(span intervalccl.Range, start, end hlc.Timestamp) error {
 //
 // If a span is not covered, the onMissing function is called with the span and
 // time missing to determine what error, if any, should be returned.
+//
+// If backupLocalityInfo is non-nil, it has an entry for each backup that is
+// used to find the location of files that were written to a locality-specific
+// location by a partitioned backup. Callers that don't read the files may pass
+// nil.
 func makeImportSpans(
 	tableSpans []roachpb.Span,
 	backups []BackupDescriptor,
+	backupLocalityInfo []jobspb.RestoreDetails_BackupLocalityInfo,
 	lowWaterMark roachpb.Key,
 	onMissing func(span intervalccl.Range, start, end hlc.Timestamp) error,
 ) ([]importEntry, hlc.Timestamp, error) {
@@ -637,7 +748,7 @@ func makeImportSpans(
 	// backup2 files) so they will retain that alternation in the output of
 	// OverlapCoveringMerge.
 	var maxEndTime hlc.Timestamp
-	for _, b := range backups {
+	for i, b := range backups {
 		if maxEndTime.Less(b.EndTime) {
 			maxEndTime = b.EndTime
 		}
@@ -661,15 +772,36 @@ func makeImportSpans(
 			})
 		}
 		backupCoverings = append(backupCoverings, backupSpanCovering)
+		var storesByLocalityKV map[string]roachpb.ExportStorage
+		if backupLocalityInfo != nil {
+			storesByLocalityKV = make(map[string]roachpb.ExportStorage)
+			for kv, uri := range backupLocalityInfo[i].URIsByOriginalLocalityKV {
+				conf, err := storageccl.ExportStorageConfFromURI(uri)
+				if err != nil {
+					return nil, hlc.Timestamp{}, err
+				}
+				storesByLocalityKV[kv] = conf
+			}
+		}
+
 		var backupFileCovering intervalccl.Covering
 		for _, f := range b.Files {
+			dir := b.Dir
+			if f.LocalityKV != "" && backupLocalityInfo != nil {
+				var ok bool
+				if dir, ok = storesByLocalityKV[f.LocalityKV]; !ok {
+					return nil, hlc.Timestamp{}, errors.Errorf(
+						"backup file %s was written to locality %s but no location was given for it",
+						f.Path, f.LocalityKV)
+				}
+			}
 			backupFileCovering = append(backupFileCovering, intervalccl.Range{
 				Start: f.Span.Key,
 				End:   f.Span.EndKey,
 				Payload: importEntry{
 					Span:      f.Span,
 					entryType: backupFile,
-					dir:       b.Dir,
+					dir:       dir,
 					file:      f,
 				},
 			})
@@ -882,14 +1014,15 @@ func splitAndScatter(
 
 // WriteTableDescs writes all the the new descriptors: First the ID ->
 // TableDescriptor for the new table, then flip (or initialize) the name -> ID
-// entry so any new queries will use the new one. The tables are assigned the
-// permissions of their parent database and the user must have CREATE permission
-// on that database at the time this function is called.
+// entry so any new queries will use the new one. The tables and types are
+// assigned the permissions of their parent database and the user must have
+// CREATE permission on that database at the time this function is called.
 func WriteTableDescs(
 	ctx context.Context,
 	txn *client.Txn,
 	databases []*sqlbase.DatabaseDescriptor,
 	tables []*sqlbase.TableDescriptor,
+	typs []*sqlbase.TypeDescriptor,
 	user string,
 	settings *cluster.Settings,
 	extra []roachpb.KeyValue,
@@ -926,6 +1059,23 @@ func WriteTableDescs(
 			b.CPut(tables[i].GetDescMetadataKey(), sqlbase.WrapDescriptor(tables[i]), nil)
 			b.CPut(tables[i].GetNameMetadataKey(), tables[i].ID, nil)
 		}
+		for _, typ := range typs {
+			if wrote, ok := wroteDBs[typ.ParentID]; ok {
+				typ.Privileges = wrote.GetPrivileges()
+			} else {
+				parentDB, err := sqlbase.GetDatabaseDescFromID(ctx, txn, typ.ParentID)
+				if err != nil {
+					return errors.NewAssertionErrorWithWrappedErrf(err,
+						"failed to lookup parent DB %d", errors.Safe(typ.ParentID))
+				}
+				if err := sql.CheckPrivilegeForUser(ctx, user, parentDB, privilege.CREATE); err != nil {
+					return err
+				}
+				typ.Privileges = parentDB.GetPrivileges()
+			}
+			b.CPut(sqlbase.MakeDescMetadataKey(typ.ID), sqlbase.WrapDescriptor(typ), nil)
+			b.CPut(sqlbase.NewTableKey(typ.ParentID, typ.Name).Key(), typ.ID, nil)
+		}
 		for _, kv := range extra {
 			b.InitPut(kv.Key, &kv.Value, false)
 		}
@@ -948,21 +1098,23 @@ func WriteTableDescs(
 }
 
 func restoreJobDescription(
-	p sql.PlanHookState, restore *tree.Restore, from []string, opts map[string]string,
+	p sql.PlanHookState, restore *tree.Restore, from [][]string, opts map[string]string,
 ) (string, error) {
 	r := &tree.Restore{
 		AsOf:    restore.AsOf,
 		Options: optsToKVOptions(opts),
 		Targets: restore.Targets,
-		From:    make(tree.Exprs, len(restore.From)),
+		From:    make([]tree.PartitionedBackup, len(restore.From)),
 	}
 
-	for i, f := range from {
-		sf, err := storageccl.SanitizeExportStorageURI(f)
-		if err != nil {
-			return "", err
+	for i, backup := range from {
+		for _, f := range backup {
+			sf, err := storageccl.SanitizeExportStorageURI(f)
+			if err != nil {
+				return "", err
+			}
+			r.From[i] = append(r.From[i], tree.NewDString(sf))
 		}
-		r.From[i] = tree.NewDString(sf)
 	}
 
 	ann := p.ExtendedEvalContext().Annotations
@@ -1025,6 +1177,8 @@ func restore(
 	overrideDB string,
 	job *jobs.Job,
 	resultsCh chan<- tree.Datums,
+	encryption *roachpb.FileEncryptionOptions,
+	backupLocalityInfo []jobspb.RestoreDetails_BackupLocalityInfo,
 ) (roachpb.BulkOpSummary, []*sqlbase.DatabaseDescriptor, []*sqlbase.TableDescriptor, error) {
 	// A note about contexts and spans in this method: the top-level context
 	// `restoreCtx` is used for orchestration logging. All operations that carry
@@ -1100,7 +1254,9 @@ func restore(
 	// Pivot the backups, which are grouped by time, into requests for import,
 	// which are grouped by keyrange.
 	highWaterMark := job.Progress().Details.(*jobspb.Progress_Restore).Restore.HighWater
-	importSpans, _, err := makeImportSpans(spans, backupDescs, highWaterMark, errOnMissingRange)
+	importSpans, _, err := makeImportSpans(
+		spans, backupDescs, backupLocalityInfo, highWaterMark, errOnMissingRange,
+	)
 	if err != nil {
 		return mu.res, nil, nil, errors.Wrapf(err, "making import requests for %d backups", len(backupDescs))
 	}
@@ -1184,6 +1340,7 @@ func restore(
 				Files:         readyForImportSpan.files,
 				EndTime:       endTime,
 				Rekeys:        rekeys,
+				Encryption:    encryption,
 			}
 
 			log.VEventf(restoreCtx, 1, "importing %d of %d", idx, len(importSpans))
@@ -1260,9 +1417,13 @@ func restorePlanHook(
 		return nil, nil, nil, false, nil
 	}
 
-	fromFn, err := p.TypeAsStringArray(restoreStmt.From, "RESTORE")
-	if err != nil {
-		return nil, nil, nil, false, err
+	fromFns := make([]func() ([]string, error), len(restoreStmt.From))
+	for i := range restoreStmt.From {
+		fromFn, err := p.TypeAsStringArray(tree.Exprs(restoreStmt.From[i]), "RESTORE")
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		fromFns[i] = fromFn
 	}
 
 	optsFn, err := p.TypeAsStringOpts(restoreStmt.Options, restoreOptionExpectValues)
@@ -1289,9 +1450,12 @@ func restorePlanHook(
 			return errors.Errorf("RESTORE cannot be used inside a transaction")
 		}
 
-		from, err := fromFn()
-		if err != nil {
-			return err
+		from := make([][]string, len(fromFns))
+		for i := range fromFns {
+			var err error
+			if from[i], err = fromFns[i](); err != nil {
+				return err
+			}
 		}
 		var endTime hlc.Timestamp
 		if restoreStmt.AsOf.Expr != nil {
@@ -1315,15 +1479,56 @@ func doRestorePlan(
 	ctx context.Context,
 	restoreStmt *tree.Restore,
 	p sql.PlanHookState,
-	from []string,
+	from [][]string,
 	endTime hlc.Timestamp,
 	opts map[string]string,
 	resultsCh chan<- tree.Datums,
 ) error {
-	backupDescs, err := loadBackupDescs(ctx, from, p.ExecCfg().Settings)
+	// The descriptor of each backup is read from its default URI. The files of
+	// a partitioned backup are found in the location given for the locality
+	// they were written to.
+	defaultURIs := make([]string, len(from))
+	localityInfo := make([]jobspb.RestoreDetails_BackupLocalityInfo, len(from))
+	for i, uris := range from {
+		var err error
+		defaultURIs[i], localityInfo[i].URIsByOriginalLocalityKV, err = getURIsByLocalityKV(uris)
+		if err != nil {
+			return err
+		}
+	}
+
+	var encryption *roachpb.FileEncryptionOptions
+	if passphrase, ok := opts[backupOptEncPassphrase]; ok {
+		if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionBackupEncryption) {
+			return errors.Errorf("RESTORE with %s requires all nodes to be upgraded to %s",
+				backupOptEncPassphrase, cluster.VersionByKey(cluster.VersionBackupEncryption))
+		}
+		// Every backup in the chain shares the full backup's key.
+		var err error
+		encryption, err = encryptionOptionsFromPassphrase(
+			ctx, defaultURIs[0], passphrase, p.ExecCfg().Settings,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	backupDescs, err := loadBackupDescs(ctx, defaultURIs, p.ExecCfg().Settings, encryption)
 	if err != nil {
 		return err
 	}
+	for i := range backupDescs {
+		for _, f := range backupDescs[i].Files {
+			if f.LocalityKV == "" {
+				continue
+			}
+			if _, ok := localityInfo[i].URIsByOriginalLocalityKV[f.LocalityKV]; !ok {
+				return errors.Errorf(
+					"backup %q has files in locality %s but no location was given for it",
+					defaultURIs[i], f.LocalityKV)
+			}
+		}
+	}
 
 	if !endTime.IsEmpty() {
 		ok := false
@@ -1390,11 +1595,13 @@ func doRestorePlan(
 			return sqlDescIDs
 		}(),
 		Details: jobspb.RestoreDetails{
-			EndTime:       endTime,
-			TableRewrites: tableRewrites,
-			URIs:          from,
-			TableDescs:    tables,
-			OverrideDB:    opts[restoreOptIntoDB],
+			EndTime:            endTime,
+			TableRewrites:      tableRewrites,
+			URIs:               defaultURIs,
+			BackupLocalityInfo: localityInfo,
+			TableDescs:         tables,
+			OverrideDB:         opts[restoreOptIntoDB],
+			Encryption:         encryption,
 		},
 		Progress: jobspb.RestoreProgress{},
 	})
@@ -1407,7 +1614,7 @@ func doRestorePlan(
 func loadBackupSQLDescs(
 	ctx context.Context, details jobspb.RestoreDetails, settings *cluster.Settings,
 ) ([]BackupDescriptor, []sqlbase.Descriptor, error) {
-	backupDescs, err := loadBackupDescs(ctx, details.URIs, settings)
+	backupDescs, err := loadBackupDescs(ctx, details.URIs, settings, details.Encryption)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1429,6 +1636,7 @@ type restoreResumer struct {
 	res            roachpb.BulkOpSummary
 	databases      []*sqlbase.DatabaseDescriptor
 	tables         []*sqlbase.TableDescriptor
+	types          []*sqlbase.TypeDescriptor
 	statsRefresher *stats.Refresher
 }
 
@@ -1444,6 +1652,18 @@ func (r *restoreResumer) Resume(
 		return err
 	}
 
+	// User-defined types have no data to restore; they are only written
+	// along with the tables once the data is restored.
+	var typs []*sqlbase.TypeDescriptor
+	for _, desc := range sqlDescs {
+		if typ := desc.GetType(); typ != nil {
+			typs = append(typs, typ)
+		}
+	}
+	if err := rewriteTypeDescs(typs, details.TableRewrites); err != nil {
+		return err
+	}
+
 	res, databases, tables, err := restore(
 		ctx,
 		p.ExecCfg().DB,
@@ -1455,10 +1675,13 @@ func (r *restoreResumer) Resume(
 		details.OverrideDB,
 		r.job,
 		resultsCh,
+		details.Encryption,
+		details.BackupLocalityInfo,
 	)
 	r.res = res
 	r.databases = databases
 	r.tables = tables
+	r.types = typs
 	r.statsRefresher = p.ExecCfg().StatsRefresher
 	return err
 }
@@ -1489,7 +1712,9 @@ func (r *restoreResumer) OnSuccess(ctx context.Context, txn *client.Txn) error {
 	// Write the new TableDescriptors and flip the namespace entries over to
 	// them. After this call, any queries on a table will be served by the newly
 	// restored data.
-	if err := WriteTableDescs(ctx, txn, r.databases, r.tables, r.job.Payload().Username, r.settings, nil); err != nil {
+	if err := WriteTableDescs(
+		ctx, txn, r.databases, r.tables, r.types, r.job.Payload().Username, r.settings, nil,
+	); err != nil {
 		return errors.Wrapf(err, "restoring %d TableDescriptors", len(r.tables))
 	}
 
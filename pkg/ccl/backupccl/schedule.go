@@ -0,0 +1,295 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+// scheduledBackupExecutorType is the executor_type of the schedules created by
+// CREATE SCHEDULE FOR BACKUP.
+const scheduledBackupExecutorType = "scheduled-backup"
+
+// scheduledBackupPlanHook implements PlanHookFn.
+func scheduledBackupPlanHook(
+	_ context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (sql.PlanHookRowFn, sqlbase.ResultColumns, []sql.PlanNode, bool, error) {
+	schedule, ok := stmt.(*tree.ScheduledBackup)
+	if !ok {
+		return nil, nil, nil, false, nil
+	}
+
+	const op = "CREATE SCHEDULE FOR BACKUP"
+	nameFn := func() (string, error) { return "", nil }
+	if schedule.ScheduleName != nil {
+		var err error
+		if nameFn, err = p.TypeAsString(schedule.ScheduleName, op); err != nil {
+			return nil, nil, nil, false, err
+		}
+	}
+	toFn, err := p.TypeAsString(schedule.To, op)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	recurrenceFn, err := p.TypeAsString(schedule.Recurrence, op)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	fullBackupFn := func() (string, error) { return "", nil }
+	if schedule.FullBackup != nil {
+		if fullBackupFn, err = p.TypeAsString(schedule.FullBackup, op); err != nil {
+			return nil, nil, nil, false, err
+		}
+	}
+	optsFn, err := p.TypeAsStringOpts(schedule.BackupOptions, backupOptionExpectValues)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+
+	header := sqlbase.ResultColumns{
+		{Name: "schedule_id", Typ: types.Int},
+		{Name: "label", Typ: types.String},
+		{Name: "next_run", Typ: types.Timestamp},
+		{Name: "recurrence", Typ: types.String},
+		{Name: "full_backup_recurrence", Typ: types.String},
+		{Name: "backup_stmt", Typ: types.String},
+	}
+
+	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
+		ctx, span := tracing.ChildSpan(ctx, stmt.StatementTag())
+		defer tracing.FinishSpan(span)
+
+		if err := utilccl.CheckEnterpriseEnabled(
+			p.ExecCfg().Settings, p.ExecCfg().ClusterID(), p.ExecCfg().Organization(), op,
+		); err != nil {
+			return err
+		}
+
+		if err := p.RequireSuperUser(ctx, op); err != nil {
+			return err
+		}
+
+		if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionScheduledJobs) {
+			return errors.Errorf("%s requires all nodes to be upgraded to %s",
+				op, cluster.VersionByKey(cluster.VersionScheduledJobs))
+		}
+
+		name, err := nameFn()
+		if err != nil {
+			return err
+		}
+		location, err := toFn()
+		if err != nil {
+			return err
+		}
+		recurrence, err := recurrenceFn()
+		if err != nil {
+			return err
+		}
+		fullBackup, err := fullBackupFn()
+		if err != nil {
+			return err
+		}
+		opts, err := optsFn()
+		if err != nil {
+			return err
+		}
+
+		// Check that the location and the cron expressions are valid before
+		// storing the schedule, rather than failing every one of its runs.
+		if _, err := storageccl.ExportStorageConfFromURI(location); err != nil {
+			return err
+		}
+		if _, err := jobs.ParseCronExpr(recurrence); err != nil {
+			return err
+		}
+		if fullBackup != "" {
+			if _, err := jobs.ParseCronExpr(fullBackup); err != nil {
+				return err
+			}
+		}
+
+		// The options are stored with their values evaluated, since
+		// placeholders are not available to the runs of the schedule.
+		backupStmt := &tree.Backup{
+			Targets: schedule.Targets,
+			To:      tree.PartitionedBackup{tree.NewDString(location)},
+			Options: makeKVOptions(opts),
+		}
+		if name == "" {
+			name = "BACKUP " + tree.AsString(&backupStmt.Targets)
+		}
+
+		args, err := protoutil.Marshal(&ScheduledBackupExecutionArgs{
+			BackupStatement: tree.AsString(backupStmt),
+			Location:        location,
+			FullBackupExpr:  fullBackup,
+		})
+		if err != nil {
+			return err
+		}
+		sj := &jobs.ScheduledJob{
+			Name:          name,
+			Owner:         p.User(),
+			ScheduleExpr:  recurrence,
+			ExecutorType:  scheduledBackupExecutorType,
+			ExecutionArgs: args,
+		}
+		if err := p.ExecCfg().JobRegistry.CreateSchedule(ctx, p.Txn(), sj); err != nil {
+			return err
+		}
+
+		fullBackupDatum := tree.DNull
+		if fullBackup != "" {
+			fullBackupDatum = tree.NewDString(fullBackup)
+		}
+		resultsCh <- tree.Datums{
+			tree.NewDInt(tree.DInt(sj.ID)),
+			tree.NewDString(sj.Name),
+			tree.MakeDTimestamp(sj.NextRun, time.Microsecond),
+			tree.NewDString(recurrence),
+			fullBackupDatum,
+			tree.NewDString(tree.AsString(backupStmt)),
+		}
+		return nil
+	}
+	return fn, header, nil, false, nil
+}
+
+// makeKVOptions turns evaluated options back into KVOptions, sorted by key.
+func makeKVOptions(opts map[string]string) tree.KVOptions {
+	if len(opts) == 0 {
+		return nil
+	}
+	res := make(tree.KVOptions, 0, len(opts))
+	for k, v := range opts {
+		opt := tree.KVOption{Key: tree.Name(k)}
+		if v != "" {
+			opt.Value = tree.NewDString(v)
+		}
+		res = append(res, opt)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Key < res[j].Key })
+	return res
+}
+
+// scheduledBackupExecutor runs the schedules created by CREATE SCHEDULE FOR
+// BACKUP. Each run takes either a full backup or an incremental backup on top
+// of the backups taken since the most recent full backup.
+type scheduledBackupExecutor struct{}
+
+var _ jobs.ScheduledJobExecutor = scheduledBackupExecutor{}
+
+// ExecuteJob implements the jobs.ScheduledJobExecutor interface.
+func (scheduledBackupExecutor) ExecuteJob(
+	ctx context.Context, ex sqlutil.InternalExecutor, schedule *jobs.ScheduledJob,
+) (string, error) {
+	var args ScheduledBackupExecutionArgs
+	if err := protoutil.Unmarshal(schedule.ExecutionArgs, &args); err != nil {
+		return "", errors.Wrap(err, "unmarshaling execution args")
+	}
+	var state ScheduledBackupState
+	if err := protoutil.Unmarshal(schedule.State, &state); err != nil {
+		return "", errors.Wrap(err, "unmarshaling schedule state")
+	}
+	parsed, err := parser.ParseOne(args.BackupStatement)
+	if err != nil {
+		return "", err
+	}
+	backupStmt, ok := parsed.AST.(*tree.Backup)
+	if !ok {
+		return "", errors.AssertionFailedf("expected BACKUP statement, found %s", parsed.SQL)
+	}
+
+	now := timeutil.Now()
+	full := len(state.Chain) == 0 || args.FullBackupExpr == "" ||
+		!now.Before(state.NextFullBackup.GoTime())
+	dest, err := scheduledBackupDestination(args.Location, full, now)
+	if err != nil {
+		return "", err
+	}
+	backupStmt.To = tree.PartitionedBackup{tree.NewDString(dest)}
+	backupStmt.IncrementalFrom = nil
+	if !full {
+		for _, uri := range state.Chain {
+			backupStmt.IncrementalFrom = append(backupStmt.IncrementalFrom, tree.NewDString(uri))
+		}
+	}
+
+	row, err := ex.QueryRow(ctx, "scheduled-backup", nil /* txn */, tree.AsString(backupStmt))
+	if err != nil {
+		return "", err
+	}
+	jobID := tree.MustBeDInt(row[0])
+
+	if full {
+		state.Chain = []string{dest}
+		state.NextFullBackup = hlc.Timestamp{}
+		if args.FullBackupExpr != "" {
+			expr, err := jobs.ParseCronExpr(args.FullBackupExpr)
+			if err != nil {
+				return "", err
+			}
+			state.NextFullBackup = hlc.Timestamp{WallTime: expr.Next(now).UnixNano()}
+		}
+	} else {
+		state.Chain = append(state.Chain, dest)
+	}
+	if schedule.State, err = protoutil.Marshal(&state); err != nil {
+		return "", err
+	}
+
+	kind := "incremental"
+	if full {
+		kind = "full"
+	}
+	return fmt.Sprintf("succeeded: %s backup job %d to %s", kind, jobID, dest), nil
+}
+
+// scheduledBackupDestination returns the URI that a run of a backup schedule
+// started at the given time writes to: a directory under the schedule's
+// location, named after the kind of backup and the time.
+func scheduledBackupDestination(location string, full bool, now time.Time) (string, error) {
+	uri, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	prefix := "inc-"
+	if full {
+		prefix = "full-"
+	}
+	uri.Path = path.Join(uri.Path, prefix+now.UTC().Format("20060102-150405"))
+	return uri.String(), nil
+}
+
+func init() {
+	sql.AddPlanHook(scheduledBackupPlanHook)
+	jobs.RegisterScheduledJobExecutor(scheduledBackupExecutorType, scheduledBackupExecutor{})
+}
@@ -0,0 +1,124 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+)
+
+func TestScheduledBackup(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numAccounts = 10
+	ctx, tc, sqlDB, _, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts, initNone)
+	defer cleanupFn()
+
+	registry := tc.Server(0).JobRegistry().(*jobs.Registry)
+
+	// The runs of the schedules are performed by the test.
+	sqlDB.Exec(t, `SET CLUSTER SETTING jobs.scheduler.enabled = false`)
+
+	sqlDB.ExpectErr(t, `invalid cron expression "bogus"`,
+		`CREATE SCHEDULE FOR BACKUP DATABASE data TO $1 RECURRING 'bogus'`, localFoo)
+	sqlDB.ExpectErr(t, `invalid cron expression "@fortnightly"`,
+		`CREATE SCHEDULE FOR BACKUP DATABASE data TO $1
+		RECURRING '@daily' FULL BACKUP '@fortnightly'`, localFoo)
+
+	var id int64
+	var label, recurrence, fullRecurrence, backupStmt string
+	var nextRun time.Time
+	sqlDB.QueryRow(t,
+		`CREATE SCHEDULE 'nightly' FOR BACKUP DATABASE data TO $1
+		RECURRING '@daily' FULL BACKUP '@weekly'`, localFoo,
+	).Scan(&id, &label, &nextRun, &recurrence, &fullRecurrence, &backupStmt)
+	if label != "nightly" || recurrence != "@daily" || fullRecurrence != "@weekly" {
+		t.Fatalf("unexpected schedule %q: %q, %q", label, recurrence, fullRecurrence)
+	}
+	if expected := `BACKUP DATABASE data TO 'nodelocal:///foo'`; backupStmt != expected {
+		t.Fatalf("expected backup statement %q, got %q", expected, backupStmt)
+	}
+	if nextRun.Hour() != 0 || nextRun.Minute() != 0 {
+		t.Fatalf("expected next run at midnight, got %s", nextRun)
+	}
+
+	sqlDB.CheckQueryResults(t,
+		`SELECT id, label, schedule_status, recurrence FROM [SHOW SCHEDULES]`,
+		[][]string{{strconv.FormatInt(id, 10), "nightly", "ACTIVE", "@daily"}},
+	)
+	sqlDB.Exec(t, `PAUSE SCHEDULE $1`, id)
+	sqlDB.CheckQueryResults(t,
+		`SELECT schedule_status, next_run FROM [SHOW SCHEDULES]`, [][]string{{"PAUSED", "NULL"}},
+	)
+	sqlDB.Exec(t, `RESUME SCHEDULES SELECT id FROM [SHOW SCHEDULES]`)
+	sqlDB.CheckQueryResults(t,
+		`SELECT schedule_status FROM [SHOW SCHEDULES]`, [][]string{{"ACTIVE"}},
+	)
+
+	// run performs a run of the schedule and returns the resulting backup
+	// chain.
+	run := func(expectedKind string) []string {
+		t.Helper()
+		if err := registry.TestingRunSchedule(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		sj, err := registry.LoadSchedule(ctx, nil /* txn */, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(sj.LastRunStatus, "succeeded: "+expectedKind+" backup job") {
+			t.Fatalf("expected %s backup, got status %q", expectedKind, sj.LastRunStatus)
+		}
+		var state backupccl.ScheduledBackupState
+		if err := protoutil.Unmarshal(sj.State, &state); err != nil {
+			t.Fatal(err)
+		}
+		return state.Chain
+	}
+
+	if chain := run("full"); len(chain) != 1 || !strings.HasPrefix(chain[0], localFoo+"/full-") {
+		t.Fatalf("expected a full backup to start the chain, got %v", chain)
+	}
+	sqlDB.Exec(t, `UPDATE data.bank SET balance = balance + 1`)
+	// Backup directories are named after the second they were taken in.
+	time.Sleep(time.Second)
+	chain := run("incremental")
+	if len(chain) != 2 || !strings.HasPrefix(chain[1], localFoo+"/inc-") {
+		t.Fatalf("expected an incremental backup to be appended to the chain, got %v", chain)
+	}
+
+	sqlDB.Exec(t, `CREATE DATABASE restored`)
+	sqlDB.Exec(t, `RESTORE data.bank FROM $1, $2 WITH into_db = 'restored'`, chain[0], chain[1])
+	sqlDB.CheckQueryResults(t,
+		`SELECT count(*), sum(balance) FROM restored.bank`,
+		sqlDB.QueryStr(t, `SELECT count(*), sum(balance) FROM data.bank`),
+	)
+
+	// A schedule without FULL BACKUP only takes full backups.
+	var everyRunFull int64
+	sqlDB.QueryRow(t,
+		`CREATE SCHEDULE FOR BACKUP TABLE data.bank TO $1 RECURRING '@hourly'`, localFoo+"/full-only",
+	).Scan(&everyRunFull, &label, &nextRun, &recurrence, &fullRecurrence, &backupStmt)
+	if label != "BACKUP TABLE data.bank" {
+		t.Fatalf("expected default label, got %q", label)
+	}
+	id = everyRunFull
+	run("full")
+	time.Sleep(time.Second)
+	if chain := run("full"); len(chain) != 1 {
+		t.Fatalf("expected a single full backup in the chain, got %v", chain)
+	}
+}
@@ -47,6 +47,14 @@ func showBackupPlanHook(
 		return nil, nil, nil, false, err
 	}
 
+	expected := map[string]sql.KVStringOptValidate{
+		backupOptEncPassphrase: sql.KVStringOptRequireValue,
+	}
+	optsFn, err := p.TypeAsStringOpts(backup.Options, expected)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+
 	var shower backupShower
 	switch backup.Details {
 	case tree.BackupRangeDetails:
@@ -66,7 +74,22 @@ func showBackupPlanHook(
 		if err != nil {
 			return err
 		}
-		desc, err := ReadBackupDescriptorFromURI(ctx, str, p.ExecCfg().Settings)
+		opts, err := optsFn()
+		if err != nil {
+			return err
+		}
+
+		var encryption *roachpb.FileEncryptionOptions
+		if passphrase, ok := opts[backupOptEncPassphrase]; ok {
+			encryption, err = encryptionOptionsFromPassphrase(
+				ctx, str, passphrase, p.ExecCfg().Settings,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		desc, err := ReadBackupDescriptorFromURI(ctx, str, p.ExecCfg().Settings, encryption)
 		if err != nil {
 			return err
 		}
@@ -18,7 +18,8 @@ import (
 )
 
 type descriptorsMatched struct {
-	// all tables that match targets plus their parent databases.
+	// all tables that match targets plus their parent databases and the
+	// user-defined types of their columns.
 	descs []sqlbase.Descriptor
 
 	// the databases from which all tables were matched (eg a.* or DATABASE a).
@@ -263,5 +264,38 @@ func descriptorsMatchingTargets(
 		}
 	}
 
+	// Finally, pull in the user-defined types which columns of the tables are
+	// declared with, and their databases.
+	alreadyRequestedTypes := make(map[sqlbase.ID]struct{})
+	for _, desc := range ret.descs {
+		tbDesc := desc.GetTable()
+		if tbDesc == nil {
+			continue
+		}
+		for i := range tbDesc.Columns {
+			typID := tbDesc.Columns[i].DomainID
+			if typID == sqlbase.InvalidID {
+				typID = tbDesc.Columns[i].CompositeTypeID
+			}
+			if typID == sqlbase.InvalidID {
+				continue
+			}
+			if _, ok := alreadyRequestedTypes[typID]; ok {
+				continue
+			}
+			typDesc, ok := resolver.descByID[typID]
+			if !ok {
+				return ret, errors.Errorf("table %q has unknown type ID %d", tbDesc.Name, typID)
+			}
+			parentID := typDesc.GetType().ParentID
+			if _, ok := alreadyRequestedDBs[parentID]; !ok {
+				ret.descs = append(ret.descs, resolver.descByID[parentID])
+				alreadyRequestedDBs[parentID] = struct{}{}
+			}
+			ret.descs = append(ret.descs, typDesc)
+			alreadyRequestedTypes[typID] = struct{}{}
+		}
+	}
+
 	return ret, nil
 }
@@ -125,7 +125,7 @@ type avroMetadata map[string]interface{}
 // avroEnvelopeOpts controls which fields in avroEnvelopeRecord are set.
 type avroEnvelopeOpts struct {
 	updatedField, resolvedField bool
-	afterField                  bool
+	beforeField, afterField     bool
 }
 
 // avroEnvelopeRecord is an `avroRecord` that wraps a changed SQL row and some
@@ -133,8 +133,8 @@ type avroEnvelopeOpts struct {
 type avroEnvelopeRecord struct {
 	avroRecord
 
-	opts  avroEnvelopeOpts
-	after *avroDataRecord
+	opts          avroEnvelopeOpts
+	before, after *avroDataRecord
 }
 
 // columnDescToAvroSchema converts a column descriptor into its corresponding
@@ -375,12 +375,24 @@ func indexToAvroSchema(
 	return schema, nil
 }
 
+// avroSchemaNoSuffix can be passed to tableToAvroSchema to indicate that the
+// record name shouldn't be given a suffix.
+const avroSchemaNoSuffix = ``
+
 // tableToAvroSchema converts a column descriptor into its corresponding avro
 // record schema. The fields are kept in the same order as `tableDesc.Columns`.
-func tableToAvroSchema(tableDesc *sqlbase.TableDescriptor) (*avroDataRecord, error) {
+// If a name suffix is provided, it's appended to the name of the record, which
+// is needed when more than one record for the same table is used in a schema.
+func tableToAvroSchema(
+	tableDesc *sqlbase.TableDescriptor, nameSuffix string,
+) (*avroDataRecord, error) {
+	name := SQLNameToAvroName(tableDesc.Name)
+	if nameSuffix != avroSchemaNoSuffix {
+		name = name + `_` + nameSuffix
+	}
 	schema := &avroDataRecord{
 		avroRecord: avroRecord{
-			Name:       SQLNameToAvroName(tableDesc.Name),
+			Name:       name,
 			SchemaType: `record`,
 		},
 		fieldIdxByName:   make(map[string]int),
@@ -489,7 +501,7 @@ func (r *avroDataRecord) rowFromNative(native interface{}) (sqlbase.EncDatumRow,
 // envelopeToAvroSchema creates an avro record schema for an envelope containing
 // before and after versions of a row change and metadata about that row change.
 func envelopeToAvroSchema(
-	topic string, opts avroEnvelopeOpts, after *avroDataRecord,
+	topic string, opts avroEnvelopeOpts, before, after *avroDataRecord,
 ) (*avroEnvelopeRecord, error) {
 	schema := &avroEnvelopeRecord{
 		avroRecord: avroRecord{
@@ -515,6 +527,15 @@ func envelopeToAvroSchema(
 		}
 		schema.Fields = append(schema.Fields, resolvedField)
 	}
+	if opts.beforeField {
+		schema.before = before
+		beforeField := &avroSchemaField{
+			Name:       `before`,
+			SchemaType: []avroSchemaType{avroSchemaNull, before},
+			Default:    nil,
+		}
+		schema.Fields = append(schema.Fields, beforeField)
+	}
 	if opts.afterField {
 		schema.after = after
 		afterField := &avroSchemaField{
@@ -539,7 +560,7 @@ func envelopeToAvroSchema(
 // BinaryFromRow encodes the given metadata and row data into avro's defined
 // binary format.
 func (r *avroEnvelopeRecord) BinaryFromRow(
-	buf []byte, meta avroMetadata, row sqlbase.EncDatumRow,
+	buf []byte, meta avroMetadata, beforeRow, afterRow sqlbase.EncDatumRow,
 ) ([]byte, error) {
 	native := map[string]interface{}{
 		`after`: nil,
@@ -567,11 +588,22 @@ func (r *avroEnvelopeRecord) BinaryFromRow(
 		}
 	}
 	// WIP verify that meta is now empty
+	if r.opts.beforeField {
+		if beforeRow == nil {
+			native[`before`] = nil
+		} else {
+			beforeNative, err := r.before.nativeFromRow(beforeRow)
+			if err != nil {
+				return nil, err
+			}
+			native[`before`] = goavro.Union(avroUnionKey(&r.before.avroRecord), beforeNative)
+		}
+	}
 	if r.opts.afterField {
-		if row == nil {
+		if afterRow == nil {
 			native[`after`] = nil
 		} else {
-			afterNative, err := r.after.nativeFromRow(row)
+			afterNative, err := r.after.nativeFromRow(afterRow)
 			if err != nil {
 				return nil, err
 			}
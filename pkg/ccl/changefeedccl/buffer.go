@@ -26,7 +26,10 @@ import (
 )
 
 type bufferEntry struct {
-	kv       roachpb.KeyValue
+	kv roachpb.KeyValue
+	// prevVal is the value of kv.Key that kv overwrote. It is only set if the
+	// changefeed requested diffs and the key had a value before kv.
+	prevVal  roachpb.Value
 	resolved *jobspb.ResolvedSpan
 	// Timestamp of the schema that should be used to read this KV.
 	// If unset (zero-valued), the value's timestamp will be used instead.
@@ -45,12 +48,12 @@ func makeBuffer() *buffer {
 	return &buffer{entriesCh: make(chan bufferEntry)}
 }
 
-// AddKV inserts a changed kv into the buffer. Individual keys must be added in
-// increasing mvcc order.
+// AddKV inserts a changed kv into the buffer, along with the value it
+// overwrote, if known. Individual keys must be added in increasing mvcc order.
 func (b *buffer) AddKV(
-	ctx context.Context, kv roachpb.KeyValue, schemaTimestamp hlc.Timestamp,
+	ctx context.Context, kv roachpb.KeyValue, prevVal roachpb.Value, schemaTimestamp hlc.Timestamp,
 ) error {
-	return b.addEntry(ctx, bufferEntry{kv: kv, schemaTimestamp: schemaTimestamp})
+	return b.addEntry(ctx, bufferEntry{kv: kv, prevVal: prevVal, schemaTimestamp: schemaTimestamp})
 }
 
 // AddResolved inserts a resolved timestamp notification in the buffer.
@@ -96,6 +99,7 @@ var memBufferColTypes = []types.T{
 	*types.Int,   // ts.Logical
 	*types.Int,   // schemaTimestamp.WallTime
 	*types.Int,   // schemaTimestamp.Logical
+	*types.Bytes, // prevVal
 }
 
 // memBuffer is an in-memory buffer for changed KV and resolved timestamp
@@ -133,12 +137,16 @@ func (b *memBuffer) Close(ctx context.Context) {
 	b.mu.Unlock()
 }
 
-// AddKV inserts a changed kv into the buffer. Individual keys must be added in
-// increasing mvcc order.
+// AddKV inserts a changed kv into the buffer, along with the value it
+// overwrote, if known. Individual keys must be added in increasing mvcc order.
 func (b *memBuffer) AddKV(
-	ctx context.Context, kv roachpb.KeyValue, schemaTimestamp hlc.Timestamp,
+	ctx context.Context, kv roachpb.KeyValue, prevVal roachpb.Value, schemaTimestamp hlc.Timestamp,
 ) error {
 	b.allocMu.Lock()
+	var prevValDatum tree.Datum = tree.DNull
+	if prevVal.IsPresent() {
+		prevValDatum = b.allocMu.a.NewDBytes(tree.DBytes(prevVal.RawBytes))
+	}
 	row := tree.Datums{
 		b.allocMu.a.NewDBytes(tree.DBytes(kv.Key)),
 		b.allocMu.a.NewDBytes(tree.DBytes(kv.Value.RawBytes)),
@@ -148,6 +156,7 @@ func (b *memBuffer) AddKV(
 		b.allocMu.a.NewDInt(tree.DInt(kv.Value.Timestamp.Logical)),
 		b.allocMu.a.NewDInt(tree.DInt(schemaTimestamp.WallTime)),
 		b.allocMu.a.NewDInt(tree.DInt(schemaTimestamp.Logical)),
+		prevValDatum,
 	}
 	b.allocMu.Unlock()
 	return b.addRow(ctx, row)
@@ -165,6 +174,7 @@ func (b *memBuffer) AddResolved(ctx context.Context, span roachpb.Span, ts hlc.T
 		b.allocMu.a.NewDInt(tree.DInt(ts.Logical)),
 		tree.DNull,
 		tree.DNull,
+		tree.DNull,
 	}
 	b.allocMu.Unlock()
 	return b.addRow(ctx, row)
@@ -194,6 +204,11 @@ func (b *memBuffer) Get(ctx context.Context) (bufferEntry, error) {
 			WallTime: int64(*row[6].(*tree.DInt)),
 			Logical:  int32(*row[7].(*tree.DInt)),
 		}
+		if row[8] != tree.DNull {
+			e.prevVal = roachpb.Value{
+				RawBytes: []byte(*row[8].(*tree.DBytes)),
+			}
+		}
 		return e, nil
 	}
 	e.resolved = &jobspb.ResolvedSpan{
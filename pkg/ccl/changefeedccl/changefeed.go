@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -21,6 +22,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/row"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/util/bufalloc"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -48,6 +50,16 @@ var PushEnabled = settings.RegisterBoolSetting(
 	true,
 )
 
+// protectTimestampInterval controls how far a changefeed's high-water mark may
+// advance past the timestamp protected by its protected timestamp record
+// before the record is advanced.
+var protectTimestampInterval = settings.RegisterNonNegativeDurationSetting(
+	"changefeed.protect_timestamp_interval",
+	"the high-water mark advance after which a changefeed advances the timestamp "+
+		"protected by its protected timestamp record",
+	10*time.Minute,
+)
+
 const (
 	jsonMetaSentinel = `__crdb__`
 )
@@ -77,8 +89,8 @@ func kvsToRows(
 
 	var kvs row.SpanKVFetcher
 	appendEmitEntryForKV := func(
-		ctx context.Context, output []emitEntry, kv roachpb.KeyValue, schemaTimestamp hlc.Timestamp,
-		bufferGetTimestamp time.Time,
+		ctx context.Context, output []emitEntry, kv roachpb.KeyValue, prevVal roachpb.Value,
+		schemaTimestamp hlc.Timestamp, bufferGetTimestamp time.Time,
 	) ([]emitEntry, error) {
 		// Reuse kvs to save allocations.
 		kvs.KVs = kvs.KVs[:0]
@@ -105,6 +117,7 @@ func kvsToRows(
 			return nil, err
 		}
 
+		numOutput := len(output)
 		for {
 			var r emitEntry
 			r.bufferGetTimestamp = bufferGetTimestamp
@@ -120,6 +133,25 @@ func kvsToRows(
 			r.row.updated = schemaTimestamp
 			output = append(output, r)
 		}
+
+		// Decode the value that the kv overwrote, which is only present if the
+		// changefeed requested diffs. It's interpreted using the same table
+		// descriptor as the kv.
+		if !prevVal.IsPresent() || len(output) == numOutput {
+			return output, nil
+		}
+		kvs.KVs = kvs.KVs[:0]
+		kvs.KVs = append(kvs.KVs, roachpb.KeyValue{Key: kv.Key, Value: prevVal})
+		if err := rf.StartScanFrom(ctx, &kvs); err != nil {
+			return nil, err
+		}
+		prevDatums, _, _, err := rf.NextRow(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if prevDatums != nil && !rf.RowIsDeleted() {
+			output[len(output)-1].row.prevDatums = append(sqlbase.EncDatumRow(nil), prevDatums...)
+		}
 		return output, nil
 	}
 
@@ -141,7 +173,7 @@ func kvsToRows(
 					schemaTimestamp = input.schemaTimestamp
 				}
 				output, err = appendEmitEntryForKV(
-					ctx, output, input.kv, schemaTimestamp, input.bufferGetTimestamp)
+					ctx, output, input.kv, input.prevVal, schemaTimestamp, input.bufferGetTimestamp)
 				if err != nil {
 					return nil, err
 				}
@@ -293,6 +325,8 @@ func checkpointResolvedTimestamp(
 	ctx context.Context,
 	jobProgressedFn func(context.Context, jobs.HighWaterProgressedFn) error,
 	sf *spanFrontier,
+	pts protectedts.Storage,
+	protectInterval time.Duration,
 ) error {
 	resolved := sf.Frontier()
 	var resolvedSpans []jobspb.ResolvedSpan
@@ -309,13 +343,21 @@ func checkpointResolvedTimestamp(
 	// this resolved timestamp, keep this update of the high-water mark
 	// before emitting the resolved timestamp to the sink.
 	if jobProgressedFn != nil {
-		progressedClosure := func(ctx context.Context, d jobspb.ProgressDetails) hlc.Timestamp {
+		progressedClosure := func(
+			ctx context.Context, txn *client.Txn, d jobspb.ProgressDetails,
+		) (hlc.Timestamp, error) {
 			// TODO(dan): This was making enormous jobs rows, especially in
 			// combination with how many mvcc versions there are. Cut down on
 			// the amount of data used here dramatically and re-enable.
 			//
 			// d.(*jobspb.Progress_Changefeed).Changefeed.ResolvedSpans = resolvedSpans
-			return resolved
+			progress := d.(*jobspb.Progress_Changefeed).Changefeed
+			if err := advanceProtectedTimestamp(
+				ctx, txn, pts, progress, resolved, protectInterval,
+			); err != nil {
+				return hlc.Timestamp{}, err
+			}
+			return resolved, nil
 		}
 		if err := jobProgressedFn(ctx, progressedClosure); err != nil {
 			return err
@@ -324,6 +366,31 @@ func checkpointResolvedTimestamp(
 	return nil
 }
 
+// advanceProtectedTimestamp moves the changefeed's protected timestamp record
+// up to resolved if resolved is at least protectInterval past the currently
+// protected timestamp. Data above resolved is still needed should the
+// changefeed restart, while data below it is not.
+func advanceProtectedTimestamp(
+	ctx context.Context,
+	txn *client.Txn,
+	pts protectedts.Storage,
+	progress *jobspb.ChangefeedProgress,
+	resolved hlc.Timestamp,
+	protectInterval time.Duration,
+) error {
+	if pts == nil || progress == nil || progress.ProtectedTimestampRecord == nil {
+		return nil
+	}
+	rec, err := pts.GetRecord(ctx, txn, *progress.ProtectedTimestampRecord)
+	if err != nil {
+		return err
+	}
+	if resolved.GoTime().Sub(rec.Timestamp.GoTime()) < protectInterval {
+		return nil
+	}
+	return pts.UpdateTimestamp(ctx, txn, rec.ID, resolved)
+}
+
 // emitResolvedTimestamp emits a changefeed-level resolved timestamp to the
 // sink.
 func emitResolvedTimestamp(
@@ -24,6 +24,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/storage/closedts"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
@@ -179,6 +180,15 @@ func (ca *changeAggregator) Start(ctx context.Context) context.Context {
 		spans, ca.spec.Feed, initialHighWater, buf, leaseMgr, metrics, ca.pollerMemMon,
 	)
 	rowsFn := kvsToRows(leaseMgr, ca.spec.Feed, buf.Get)
+	if ca.spec.Feed.Select != `` {
+		sel, err := parseChangefeedSelect(ca.spec.Feed.Select)
+		if err != nil {
+			ca.MoveToDraining(err)
+			ca.cancel()
+			return ctx
+		}
+		rowsFn = newRowEvaluator(sel, ca.flowCtx.NewEvalCtx()).filterAndProjectRows(rowsFn)
+	}
 
 	ca.tickFn = emitEntries(
 		ca.flowCtx.Settings, ca.spec.Feed, spans, ca.encoder, ca.sink, rowsFn, knobs, metrics)
@@ -337,10 +347,17 @@ type changeFrontier struct {
 	// jobProgressedFn, if non-nil, is called to checkpoint the changefeed's
 	// progress in the corresponding system job entry.
 	jobProgressedFn func(context.Context, jobs.HighWaterProgressedFn) error
+	// protectedTimestamps, if non-nil, is used to advance the changefeed's
+	// protected timestamp record along with its high-water mark.
+	protectedTimestamps protectedts.Storage
 	// highWaterAtStart is the greater of the job high-water and the timestamp the
 	// CHANGEFEED statement was run at. It's used in an assertion that we never
 	// regress the job high-water.
 	highWaterAtStart hlc.Timestamp
+	// initialScanDone is set once the frontier of an initial_scan_only
+	// changefeed reaches the statement time, at which point the changeFrontier
+	// finishes the flow.
+	initialScanDone bool
 	// passthroughBuf, in some but not all flows, contains changed row data to
 	// pass through unchanged to the gateway node.
 	passthroughBuf encDatumRowBuffer
@@ -446,6 +463,14 @@ func (cf *changeFrontier) Start(ctx context.Context) context.Context {
 	cf.sink = &errorWrapperSink{wrapped: cf.sink}
 
 	cf.highWaterAtStart = cf.spec.Feed.StatementTime
+	// The frontier starts out at the high-water the changefeed resumes from,
+	// which is the statement time if it skips the initial scan. Otherwise, it
+	// would be reported as arbitrarily far behind until every span has been
+	// resolved once.
+	var initialHighWater hlc.Timestamp
+	if !initialScanFromOptions(cf.spec.Feed.Opts) {
+		initialHighWater = cf.spec.Feed.StatementTime
+	}
 	if cf.spec.JobID != 0 {
 		job, err := cf.flowCtx.JobRegistry.LoadJob(ctx, cf.spec.JobID)
 		if err != nil {
@@ -453,12 +478,17 @@ func (cf *changeFrontier) Start(ctx context.Context) context.Context {
 			return ctx
 		}
 		cf.jobProgressedFn = job.HighWaterProgressed
+		cf.protectedTimestamps = job.ProtectedTimestamps()
 
 		p := job.Progress()
 		if ts := p.GetHighWater(); ts != nil {
 			cf.highWaterAtStart.Forward(*ts)
+			initialHighWater.Forward(*ts)
 		}
 	}
+	for _, span := range cf.spec.TrackedSpans {
+		cf.sf.Forward(span, initialHighWater)
+	}
 
 	cf.metrics.mu.Lock()
 	cf.metricsID = cf.metrics.mu.id
@@ -514,6 +544,11 @@ func (cf *changeFrontier) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetada
 			return cf.resolvedBuf.Pop(), nil
 		}
 
+		if cf.initialScanDone {
+			cf.MoveToDraining(nil /* err */)
+			break
+		}
+
 		row, meta := cf.input.Next()
 		if meta != nil {
 			if meta.Err != nil {
@@ -579,7 +614,10 @@ func (cf *changeFrontier) noteResolvedSpan(d sqlbase.EncDatum) error {
 			cf.metrics.mu.resolved[cf.metricsID] = newResolved
 		}
 		cf.metrics.mu.Unlock()
-		if err := checkpointResolvedTimestamp(cf.Ctx, cf.jobProgressedFn, cf.sf); err != nil {
+		protectInterval := protectTimestampInterval.Get(&cf.flowCtx.Settings.SV)
+		if err := checkpointResolvedTimestamp(
+			cf.Ctx, cf.jobProgressedFn, cf.sf, cf.protectedTimestamps, protectInterval,
+		); err != nil {
 			return err
 		}
 		sinceEmitted := newResolved.GoTime().Sub(cf.lastEmitResolved)
@@ -591,6 +629,10 @@ func (cf *changeFrontier) noteResolvedSpan(d sqlbase.EncDatum) error {
 			}
 			cf.lastEmitResolved = newResolved.GoTime()
 		}
+		if _, ok := cf.spec.Feed.Opts[optInitialScanOnly]; ok &&
+			!newResolved.Less(cf.spec.Feed.StatementTime) {
+			cf.initialScanDone = true
+		}
 	}
 
 	// Potentially log the most behind span in the frontier for debugging. These
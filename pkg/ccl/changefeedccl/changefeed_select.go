@@ -0,0 +1,381 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/pkg/errors"
+)
+
+// changefeedSelect is the query of a CREATE CHANGEFEED ... AS SELECT. It's
+// restricted to projecting and filtering the rows of a single table, so that
+// it can be evaluated on each changed row in isolation.
+type changefeedSelect struct {
+	clause *tree.SelectClause
+	// table is the table in the FROM clause, as written.
+	table tree.TableName
+	// sourceName is the name that columns can be qualified with in the
+	// expressions, which is the alias of the table if it has one.
+	sourceName tree.TableName
+}
+
+// makeChangefeedSelect checks that the given query is supported by changefeeds
+// and returns its parts.
+func makeChangefeedSelect(sel *tree.Select) (*changefeedSelect, error) {
+	if sel.With != nil || len(sel.OrderBy) > 0 || sel.Limit != nil {
+		return nil, errors.Errorf(
+			`CHANGEFEED AS SELECT does not support WITH, ORDER BY or LIMIT`)
+	}
+	clause, ok := sel.Select.(*tree.SelectClause)
+	if !ok {
+		return nil, errors.Errorf(`CHANGEFEED AS SELECT must be a simple SELECT: %s`, tree.AsString(sel))
+	}
+	if clause.Distinct || clause.DistinctOn != nil || clause.GroupBy != nil ||
+		clause.Having != nil || clause.Window != nil {
+		return nil, errors.Errorf(
+			`CHANGEFEED AS SELECT does not support DISTINCT, GROUP BY, HAVING or WINDOW`)
+	}
+	if clause.From == nil || len(clause.From.Tables) != 1 {
+		return nil, errors.Errorf(`CHANGEFEED AS SELECT must select from exactly one table`)
+	}
+	if clause.From.AsOf.Expr != nil {
+		return nil, errors.Errorf(`CHANGEFEED AS SELECT does not support AS OF SYSTEM TIME`)
+	}
+	source, ok := clause.From.Tables[0].(*tree.AliasedTableExpr)
+	if !ok {
+		return nil, errors.Errorf(`CHANGEFEED AS SELECT must select from exactly one table`)
+	}
+	table, ok := source.Expr.(*tree.TableName)
+	if !ok || source.IndexFlags != nil || source.Ordinality || len(source.As.Cols) > 0 {
+		return nil, errors.Errorf(`CHANGEFEED AS SELECT must select from exactly one table`)
+	}
+	s := &changefeedSelect{clause: clause, table: *table, sourceName: *table}
+	if source.As.Alias != `` {
+		s.sourceName = tree.MakeUnqualifiedTableName(source.As.Alias)
+	}
+	return s, nil
+}
+
+// parseChangefeedSelect parses the query stored in the job details of a
+// CREATE CHANGEFEED ... AS SELECT.
+func parseChangefeedSelect(query string) (*changefeedSelect, error) {
+	stmt, err := parser.ParseOne(query)
+	if err != nil {
+		return nil, err
+	}
+	sel, ok := stmt.AST.(*tree.Select)
+	if !ok {
+		return nil, errors.Errorf(`expected a SELECT: %s`, query)
+	}
+	return makeChangefeedSelect(sel)
+}
+
+// validateChangefeedSelect checks that the query of the changefeed, if it has
+// one, can be evaluated on rows of the given version of its table. A schema
+// change that drops a column referenced by the query fails it.
+func validateChangefeedSelect(
+	ctx context.Context, details jobspb.ChangefeedDetails, tableDesc *sqlbase.TableDescriptor,
+) error {
+	if details.Select == `` {
+		return nil
+	}
+	s, err := parseChangefeedSelect(details.Select)
+	if err != nil {
+		return err
+	}
+	_, err = s.compile(ctx, tableDesc)
+	return err
+}
+
+// compiledSelect is a changefeedSelect with its expressions resolved against
+// and type checked for one version of the table descriptor.
+type compiledSelect struct {
+	cols  []sqlbase.ColumnDescriptor
+	where tree.TypedExpr
+	exprs []tree.TypedExpr
+	// projectedDesc describes the output columns of the query, so the encoders
+	// can encode the projected rows as they would rows of a table.
+	projectedDesc *sqlbase.TableDescriptor
+
+	// curRow is the row the IndexedVars currently evaluate to.
+	curRow tree.Datums
+}
+
+var _ tree.IndexedVarContainer = &compiledSelect{}
+
+// IndexedVarEval implements the tree.IndexedVarContainer interface.
+func (c *compiledSelect) IndexedVarEval(idx int, _ *tree.EvalContext) (tree.Datum, error) {
+	return c.curRow[idx], nil
+}
+
+// IndexedVarResolvedType implements the tree.IndexedVarContainer interface.
+func (c *compiledSelect) IndexedVarResolvedType(idx int) *types.T {
+	return &c.cols[idx].Type
+}
+
+// IndexedVarNodeFormatter implements the tree.IndexedVarContainer interface.
+func (c *compiledSelect) IndexedVarNodeFormatter(idx int) tree.NodeFormatter {
+	n := tree.Name(c.cols[idx].Name)
+	return &n
+}
+
+func (s *changefeedSelect) compile(
+	ctx context.Context, tableDesc *sqlbase.TableDescriptor,
+) (*compiledSelect, error) {
+	c := &compiledSelect{
+		cols:   tableDesc.Columns,
+		curRow: make(tree.Datums, len(tableDesc.Columns)),
+	}
+	sources := sqlbase.MakeMultiSourceInfo(sqlbase.NewSourceInfoForSingleTable(
+		s.sourceName, sqlbase.ResultColumnsFromColDescs(tableDesc.Columns),
+	))
+	ivarHelper := tree.MakeIndexedVarHelper(c, len(tableDesc.Columns))
+	semaCtx := tree.MakeSemaContext()
+	semaCtx.IVarContainer = c
+	analyzeExpr := func(
+		ctx context.Context,
+		raw tree.Expr,
+		sources sqlbase.MultiSourceInfo,
+		ivarHelper tree.IndexedVarHelper,
+		expectedType *types.T,
+		requireType bool,
+		typingContext string,
+	) (tree.TypedExpr, error) {
+		resolved, _, _, err := sqlbase.ResolveNames(
+			raw, sources, ivarHelper, sessiondata.SearchPath{})
+		if err != nil {
+			return nil, err
+		}
+		semaCtx.Properties.Require(`CHANGEFEED`, tree.RejectSpecial|tree.RejectSubqueries)
+		if requireType {
+			return tree.TypeCheckAndRequire(resolved, &semaCtx, expectedType, typingContext)
+		}
+		return tree.TypeCheck(resolved, &semaCtx, expectedType)
+	}
+	wrapErr := func(err error) error {
+		return errors.Wrapf(err, `CHANGEFEED AS SELECT on table %s at version %d`,
+			tableDesc.Name, tableDesc.Version)
+	}
+
+	if s.clause.Where != nil {
+		var err error
+		c.where, err = analyzeExpr(
+			ctx, s.clause.Where.Expr, sources, ivarHelper, types.Bool, true, `WHERE`)
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+	}
+
+	c.projectedDesc = &sqlbase.TableDescriptor{
+		ID:       tableDesc.ID,
+		ParentID: tableDesc.ParentID,
+		Name:     tableDesc.Name,
+		Version:  tableDesc.Version,
+	}
+	addColumn := func(name string, typ *types.T, expr tree.TypedExpr) {
+		c.exprs = append(c.exprs, expr)
+		c.projectedDesc.Columns = append(c.projectedDesc.Columns, sqlbase.ColumnDescriptor{
+			Name:     name,
+			ID:       sqlbase.ColumnID(len(c.exprs)),
+			Type:     *typ,
+			Nullable: true,
+		})
+	}
+	for _, target := range s.clause.Exprs {
+		if v, ok := target.Expr.(tree.VarName); ok {
+			normalized, err := v.NormalizeVarName()
+			if err != nil {
+				return nil, wrapErr(err)
+			}
+			target.Expr = normalized
+		}
+		isStar, cols, exprs, err := sqlbase.CheckRenderStar(
+			ctx, analyzeExpr, target, sources, ivarHelper)
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+		if isStar {
+			for i := range cols {
+				addColumn(cols[i].Name, cols[i].Typ, exprs[i])
+			}
+			continue
+		}
+		typedExpr, err := analyzeExpr(
+			ctx, target.Expr, sources, ivarHelper, types.Any, false, ``)
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+		name, err := tree.GetRenderColName(sessiondata.SearchPath{}, target)
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+		addColumn(name, typedExpr.ResolvedType(), typedExpr)
+	}
+	return c, nil
+}
+
+// rowEvaluator evaluates the query of a CREATE CHANGEFEED ... AS SELECT on
+// changed rows. Rows that don't match the WHERE clause are dropped. The rest
+// are emitted with their projection as the value, while the key is still made
+// of the primary key of the table.
+type rowEvaluator struct {
+	sel     *changefeedSelect
+	evalCtx *tree.EvalContext
+	alloc   sqlbase.DatumAlloc
+
+	// compiled caches the compiled query for each table descriptor version
+	// seen so far.
+	compiled map[tableIDAndVersion]*compiledSelect
+}
+
+func newRowEvaluator(sel *changefeedSelect, evalCtx *tree.EvalContext) *rowEvaluator {
+	return &rowEvaluator{
+		sel:      sel,
+		evalCtx:  evalCtx,
+		compiled: make(map[tableIDAndVersion]*compiledSelect),
+	}
+}
+
+// filterAndProjectRows wraps a closure returning changed rows, as returned by
+// kvsToRows, so that the rows it returns are evaluated by the query.
+func (e *rowEvaluator) filterAndProjectRows(
+	inputFn func(context.Context) ([]emitEntry, error),
+) func(context.Context) ([]emitEntry, error) {
+	return func(ctx context.Context) ([]emitEntry, error) {
+		entries, err := inputFn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		output := entries[:0]
+		for _, entry := range entries {
+			if entry.row.datums != nil {
+				keep, err := e.evalRow(ctx, &entry.row)
+				if err != nil {
+					return nil, err
+				}
+				if !keep {
+					continue
+				}
+			}
+			output = append(output, entry)
+		}
+		return output, nil
+	}
+}
+
+// evalRow returns whether the change to the row is emitted and, if it is, sets
+// its projection. A change is emitted if the row matches the WHERE clause
+// after the change. If the changefeed has the previous value of the row, a
+// change that makes the row stop matching is emitted as a deletion of the row,
+// and other changes to rows that match neither before nor after the change are
+// dropped. Without the previous value, deletions are always emitted, since
+// only their primary key is known.
+func (e *rowEvaluator) evalRow(ctx context.Context, row *encodeRow) (bool, error) {
+	cacheKey := makeTableIDAndVersion(row.tableDesc.ID, row.tableDesc.Version)
+	c, ok := e.compiled[cacheKey]
+	if !ok {
+		var err error
+		if c, err = e.sel.compile(ctx, row.tableDesc); err != nil {
+			return false, err
+		}
+		e.compiled[cacheKey] = c
+	}
+	e.evalCtx.IVarContainer = c
+
+	var matches, prevMatches bool
+	if !row.deleted {
+		var err error
+		if matches, err = e.matches(c, row.datums); err != nil {
+			return false, err
+		}
+	}
+	if row.prevDatums != nil {
+		var err error
+		if prevMatches, err = e.matches(c, row.prevDatums); err != nil {
+			return false, err
+		}
+	}
+	switch {
+	case matches:
+	case prevMatches:
+		row.deleted = true
+	case row.deleted && row.prevDatums == nil:
+	default:
+		return false, nil
+	}
+
+	projection := &encodeRow{
+		updated:   row.updated,
+		deleted:   row.deleted,
+		tableDesc: c.projectedDesc,
+	}
+	if !row.deleted {
+		var err error
+		if projection.datums, err = e.project(c, row.datums); err != nil {
+			return false, err
+		}
+	}
+	if prevMatches {
+		var err error
+		if projection.prevDatums, err = e.project(c, row.prevDatums); err != nil {
+			return false, err
+		}
+	}
+	row.projection = projection
+	return true, nil
+}
+
+// matches returns whether the given row matches the WHERE clause.
+func (e *rowEvaluator) matches(c *compiledSelect, datums sqlbase.EncDatumRow) (bool, error) {
+	if c.where == nil {
+		return true, nil
+	}
+	if err := e.loadRow(c, datums); err != nil {
+		return false, err
+	}
+	matches, err := c.where.Eval(e.evalCtx)
+	if err != nil {
+		return false, err
+	}
+	return matches == tree.DBoolTrue, nil
+}
+
+func (e *rowEvaluator) loadRow(c *compiledSelect, datums sqlbase.EncDatumRow) error {
+	for i := range c.cols {
+		if err := datums[i].EnsureDecoded(&c.cols[i].Type, &e.alloc); err != nil {
+			return err
+		}
+		c.curRow[i] = datums[i].Datum
+	}
+	return nil
+}
+
+func (e *rowEvaluator) project(
+	c *compiledSelect, datums sqlbase.EncDatumRow,
+) (sqlbase.EncDatumRow, error) {
+	if err := e.loadRow(c, datums); err != nil {
+		return nil, err
+	}
+	projected := make(sqlbase.EncDatumRow, len(c.exprs))
+	for i, expr := range c.exprs {
+		d, err := expr.Eval(e.evalCtx)
+		if err != nil {
+			return nil, err
+		}
+		projected[i] = sqlbase.DatumToEncDatum(&c.projectedDesc.Columns[i].Type, d)
+	}
+	return projected, nil
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprotectedts"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
@@ -31,6 +32,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/retry"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/pkg/errors"
 )
 
@@ -50,9 +52,13 @@ type formatType string
 const (
 	optConfluentSchemaRegistry = `confluent_schema_registry`
 	optCursor                  = `cursor`
+	optDiff                    = `diff`
 	optEnvelope                = `envelope`
 	optFormat                  = `format`
+	optInitialScan             = `initial_scan`
+	optInitialScanOnly         = `initial_scan_only`
 	optKeyInValue              = `key_in_value`
+	optNoInitialScan           = `no_initial_scan`
 	optResolvedTimestamps      = `resolved`
 	optUpdatedTimestamps       = `updated`
 
@@ -61,29 +67,41 @@ const (
 	optEnvelopeDeprecatedRow envelopeType = `deprecated_row`
 	optEnvelopeWrapped       envelopeType = `wrapped`
 
-	optFormatJSON formatType = `json`
-	optFormatAvro formatType = `experimental_avro`
-
-	sinkParamCACert           = `ca_cert`
-	sinkParamFileSize         = `file_size`
-	sinkParamSchemaTopic      = `schema_topic`
-	sinkParamTLSEnabled       = `tls_enabled`
-	sinkParamTopicPrefix      = `topic_prefix`
-	sinkSchemeBuffer          = ``
-	sinkSchemeExperimentalSQL = `experimental-sql`
-	sinkSchemeKafka           = `kafka`
-	sinkParamSASLEnabled      = `sasl_enabled`
-	sinkParamSASLHandshake    = `sasl_handshake`
-	sinkParamSASLUser         = `sasl_user`
-	sinkParamSASLPassword     = `sasl_password`
+	optFormatJSON    formatType = `json`
+	optFormatAvro    formatType = `experimental_avro`
+	optFormatParquet formatType = `experimental_parquet`
+
+	sinkParamCACert            = `ca_cert`
+	sinkParamClientCert        = `client_cert`
+	sinkParamClientKey         = `client_key`
+	sinkParamFileSize          = `file_size`
+	sinkParamParquetCompress   = `parquet_compression`
+	sinkParamParquetRowGroup   = `parquet_row_group_size`
+	sinkParamSchemaTopic       = `schema_topic`
+	sinkParamTLSEnabled        = `tls_enabled`
+	sinkParamTopicPrefix       = `topic_prefix`
+	sinkParamWebhookAuthHeader = `webhook_auth_header`
+	sinkParamWebhookBatchSize  = `webhook_batch_size`
+	sinkSchemeBuffer           = ``
+	sinkSchemeExperimentalSQL  = `experimental-sql`
+	sinkSchemeKafka            = `kafka`
+	sinkSchemeWebhookHTTPS     = `webhook-https`
+	sinkParamSASLEnabled       = `sasl_enabled`
+	sinkParamSASLHandshake     = `sasl_handshake`
+	sinkParamSASLUser          = `sasl_user`
+	sinkParamSASLPassword      = `sasl_password`
 )
 
 var changefeedOptionExpectValues = map[string]sql.KVStringOptValidate{
 	optConfluentSchemaRegistry: sql.KVStringOptRequireValue,
 	optCursor:                  sql.KVStringOptRequireValue,
+	optDiff:                    sql.KVStringOptRequireNoValue,
 	optEnvelope:                sql.KVStringOptRequireValue,
 	optFormat:                  sql.KVStringOptRequireValue,
+	optInitialScan:             sql.KVStringOptRequireNoValue,
+	optInitialScanOnly:         sql.KVStringOptRequireNoValue,
 	optKeyInValue:              sql.KVStringOptRequireNoValue,
+	optNoInitialScan:           sql.KVStringOptRequireNoValue,
 	optResolvedTimestamps:      sql.KVStringOptAny,
 	optUpdatedTimestamps:       sql.KVStringOptRequireNoValue,
 }
@@ -163,24 +181,40 @@ func changefeedPlanHook(
 		statementTime := hlc.Timestamp{
 			WallTime: p.ExtendedEvalContext().GetStmtTimestamp().UnixNano(),
 		}
-		var initialHighWater hlc.Timestamp
 		if cursor, ok := opts[optCursor]; ok {
 			asOf := tree.AsOfClause{Expr: tree.NewStrVal(cursor)}
 			var err error
-			if initialHighWater, err = p.EvalAsOfTimestamp(asOf); err != nil {
+			if statementTime, err = p.EvalAsOfTimestamp(asOf); err != nil {
 				return err
 			}
-			statementTime = initialHighWater
+		}
+		// A changefeed that skips the initial scan starts out with its
+		// high-water at the statement time, which is also what keeps it from
+		// scanning when it's restarted.
+		var initialHighWater hlc.Timestamp
+		if !initialScanFromOptions(opts) {
+			initialHighWater = statementTime
+		}
+
+		// A CREATE CHANGEFEED ... AS SELECT watches the table it selects from.
+		targetList := changefeedStmt.Targets
+		var sel *changefeedSelect
+		if changefeedStmt.Select != nil {
+			var err error
+			if sel, err = makeChangefeedSelect(changefeedStmt.Select); err != nil {
+				return err
+			}
+			targetList = tree.TargetList{Tables: tree.TablePatterns{&sel.table}}
 		}
 
 		// For now, disallow targeting a database or wildcard table selection.
 		// Getting it right as tables enter and leave the set over time is
 		// tricky.
-		if len(changefeedStmt.Targets.Databases) > 0 {
+		if len(targetList.Databases) > 0 {
 			return errors.Errorf(`CHANGEFEED cannot target %s`,
-				tree.AsString(&changefeedStmt.Targets))
+				tree.AsString(&targetList))
 		}
-		for _, t := range changefeedStmt.Targets.Tables {
+		for _, t := range targetList.Tables {
 			p, err := t.NormalizeTablePattern()
 			if err != nil {
 				return err
@@ -192,7 +226,7 @@ func changefeedPlanHook(
 
 		// This grabs table descriptors once to get their ids.
 		targetDescs, _, err := backupccl.ResolveTargetsToDescriptors(
-			ctx, p, statementTime, changefeedStmt.Targets)
+			ctx, p, statementTime, targetList)
 		if err != nil {
 			return err
 		}
@@ -214,6 +248,16 @@ func changefeedPlanHook(
 			SinkURI:       sinkURI,
 			StatementTime: statementTime,
 		}
+		if sel != nil {
+			details.Select = tree.AsString(changefeedStmt.Select)
+			for _, desc := range targetDescs {
+				if tableDesc := desc.GetTable(); tableDesc != nil {
+					if err := validateChangefeedSelect(ctx, details, tableDesc); err != nil {
+						return err
+					}
+				}
+			}
+		}
 		progress := jobspb.Progress{
 			Progress: &jobspb.Progress_HighWater{HighWater: &initialHighWater},
 			Details: &jobspb.Progress_Changefeed{
@@ -259,6 +303,9 @@ func changefeedPlanHook(
 		}
 		if isCloudStorageSink(parsedSink) {
 			details.Opts[optKeyInValue] = ``
+		} else if formatType(details.Opts[optFormat]) == optFormatParquet {
+			return errors.Errorf(`%s=%s is only supported by cloud storage sinks`,
+				optFormat, optFormatParquet)
 		}
 
 		// Feature telemetry
@@ -344,6 +391,7 @@ func changefeedJobDescription(
 	c := &tree.CreateChangefeed{
 		Targets: changefeed.Targets,
 		SinkURI: tree.NewDString(cleanedSinkURI),
+		Select:  changefeed.Select,
 	}
 	for k, v := range opts {
 		opt := tree.KVOption{Key: tree.Name(k)}
@@ -386,12 +434,33 @@ func validateDetails(details jobspb.ChangefeedDetails) (jobspb.ChangefeedDetails
 		return jobspb.ChangefeedDetails{}, errors.Errorf(
 			`unknown %s: %s`, optEnvelope, details.Opts[optEnvelope])
 	}
+	if _, ok := details.Opts[optDiff]; ok &&
+		envelopeType(details.Opts[optEnvelope]) != optEnvelopeWrapped {
+		return jobspb.ChangefeedDetails{}, errors.Errorf(
+			`%s is only usable with %s=%s`, optDiff, optEnvelope, optEnvelopeWrapped)
+	}
+
+	var initialScanOpts []string
+	for _, opt := range []string{optInitialScan, optInitialScanOnly, optNoInitialScan} {
+		if _, ok := details.Opts[opt]; ok {
+			initialScanOpts = append(initialScanOpts, opt)
+		}
+	}
+	if len(initialScanOpts) > 1 {
+		return jobspb.ChangefeedDetails{}, errors.Errorf(
+			`cannot specify both %s and %s`, initialScanOpts[0], initialScanOpts[1])
+	}
 
 	switch formatType(details.Opts[optFormat]) {
 	case ``, optFormatJSON:
 		details.Opts[optFormat] = string(optFormatJSON)
 	case optFormatAvro:
 		// No-op.
+	case optFormatParquet:
+		if details.Select != `` {
+			return jobspb.ChangefeedDetails{}, errors.Errorf(
+				`%s=%s does not support AS SELECT`, optFormat, optFormatParquet)
+		}
 	default:
 		return jobspb.ChangefeedDetails{}, errors.Errorf(
 			`unknown %s: %s`, optFormat, details.Opts[optFormat])
@@ -400,6 +469,30 @@ func validateDetails(details jobspb.ChangefeedDetails) (jobspb.ChangefeedDetails
 	return details, nil
 }
 
+// initialScanFromOptions returns whether a changefeed with the given options
+// starts by emitting the current value of every watched row. By default, this
+// happens unless a cursor was given.
+func initialScanFromOptions(opts map[string]string) bool {
+	_, initialScan := opts[optInitialScan]
+	_, initialScanOnly := opts[optInitialScanOnly]
+	_, noInitialScan := opts[optNoInitialScan]
+	_, cursor := opts[optCursor]
+	if initialScan || initialScanOnly {
+		return true
+	}
+	return !noInitialScan && !cursor
+}
+
+// initialScanOnlyDone returns whether the changefeed is an initial_scan_only
+// one whose scan has already been checkpointed in the job progress.
+func initialScanOnlyDone(details jobspb.ChangefeedDetails, progress jobspb.Progress) bool {
+	if _, ok := details.Opts[optInitialScanOnly]; !ok {
+		return false
+	}
+	h := progress.GetHighWater()
+	return h != nil && *h != (hlc.Timestamp{}) && !h.Less(details.StatementTime)
+}
+
 func validateChangefeedTable(
 	targets jobspb.ChangefeedTargets, tableDesc *sqlbase.TableDescriptor,
 ) error {
@@ -464,12 +557,16 @@ func (b *changefeedResumer) Resume(
 	// progress high-water when creating a job (currently only the progress
 	// details can be set). I didn't want to pick off the refactor to get this
 	// fix in, but it'd be nice to remove this hack.
-	if _, ok := details.Opts[optCursor]; ok {
+	if !initialScanFromOptions(details.Opts) {
 		if h := progress.GetHighWater(); h == nil || *h == (hlc.Timestamp{}) {
 			progress.Progress = &jobspb.Progress_HighWater{HighWater: &details.StatementTime}
 		}
 	}
 
+	if err := b.protectTimestamp(ctx, execCfg, details, &progress); err != nil {
+		return err
+	}
+
 	// We'd like to avoid failing a changefeed unnecessarily, so when an error
 	// bubbles up to this level, we'd like to "retry" the flow if possible. This
 	// could be because the sink is down or because a cockroach node has crashed
@@ -481,6 +578,11 @@ func (b *changefeedResumer) Resume(
 	}
 	var err error
 	for r := retry.StartWithCtx(ctx, opts); r.Next(); {
+		if initialScanOnlyDone(details, progress) {
+			// The scan finished and was checkpointed, but the job was
+			// interrupted before it could be marked as succeeded.
+			return nil
+		}
 		if err = distChangefeedFlow(ctx, phs, jobID, details, progress, startedCh); err == nil {
 			return nil
 		}
@@ -516,10 +618,74 @@ func (b *changefeedResumer) Resume(
 }
 
 // OnFailOrCancel is part of the jobs.Resumer interface.
-func (b *changefeedResumer) OnFailOrCancel(context.Context, *client.Txn) error { return nil }
+func (b *changefeedResumer) OnFailOrCancel(ctx context.Context, txn *client.Txn) error {
+	return b.releaseProtectedTimestamp(ctx, txn)
+}
 
 // OnSuccess is part of the jobs.Resumer interface.
-func (b *changefeedResumer) OnSuccess(context.Context, *client.Txn) error { return nil }
+func (b *changefeedResumer) OnSuccess(ctx context.Context, txn *client.Txn) error {
+	return b.releaseProtectedTimestamp(ctx, txn)
+}
+
+// protectTimestamp writes a protected timestamp record which keeps the data
+// above the changefeed's high-water mark, or above its statement time if it
+// has no high-water mark yet, from being garbage collected. The changeFrontier
+// advances the record as the high-water mark advances. The record is verified
+// once it has been written. It is a no-op if the job already owns a record.
+func (b *changefeedResumer) protectTimestamp(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	details jobspb.ChangefeedDetails,
+	progress *jobspb.Progress,
+) error {
+	pts := b.job.ProtectedTimestamps()
+	cfProgress := progress.GetChangefeed()
+	if pts == nil || cfProgress == nil || cfProgress.ProtectedTimestampRecord != nil ||
+		!execCfg.Settings.Version.IsActive(cluster.VersionProtectedTimestamps) {
+		return nil
+	}
+	ts := details.StatementTime
+	if h := progress.GetHighWater(); h != nil && *h != (hlc.Timestamp{}) {
+		ts = *h
+	}
+	spans, err := fetchSpansForTargets(ctx, execCfg.DB, details.Targets, ts)
+	if err != nil {
+		return err
+	}
+	id := uuid.MakeV4()
+	rec := jobsprotectedts.MakeRecord(id, *b.job.ID(), ts, spans)
+	if err := execCfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		if err := pts.Protect(ctx, txn, rec); err != nil {
+			return err
+		}
+		return b.job.WithTxn(txn).Update(ctx, func(
+			_ *client.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater,
+		) error {
+			p := md.Progress.GetChangefeed()
+			if p == nil {
+				return errors.Errorf("job %d has no changefeed progress", *b.job.ID())
+			}
+			p.ProtectedTimestampRecord = &id
+			ju.UpdateProgress(md.Progress)
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+	cfProgress.ProtectedTimestampRecord = &id
+	return pts.Verify(ctx, id)
+}
+
+// releaseProtectedTimestamp releases the job's protected timestamp record, if
+// any.
+func (b *changefeedResumer) releaseProtectedTimestamp(ctx context.Context, txn *client.Txn) error {
+	progress := b.job.Progress()
+	cfProgress := progress.GetChangefeed()
+	if cfProgress == nil {
+		return nil
+	}
+	return jobsprotectedts.Release(ctx, txn, b.job.ProtectedTimestamps(), cfProgress.ProtectedTimestampRecord)
+}
 
 // OnTerminal is part of the jobs.Resumer interface.
 func (b *changefeedResumer) OnTerminal(context.Context, jobs.Status, chan<- tree.Datums) {}
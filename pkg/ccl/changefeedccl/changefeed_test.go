@@ -129,6 +129,50 @@ func TestChangefeedEnvelope(t *testing.T) {
 	t.Run(`poller`, pollerTest(sinklessTest, testFn))
 }
 
+func TestChangefeedDiff(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testFn := func(t *testing.T, db *gosql.DB, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(db)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (0, 'initial')`)
+		sqlDB.Exec(t, `UPSERT INTO foo VALUES (0, 'updated')`)
+
+		foo := feed(t, f, `CREATE CHANGEFEED FOR foo WITH diff`)
+		defer closeFeed(t, foo)
+
+		// The initial scan doesn't know the previous values of rows.
+		assertPayloads(t, foo, []string{
+			`foo: [0]->{"after": {"a": 0, "b": "updated"}, "before": null}`,
+		})
+
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1, 'a'), (2, 'b')`)
+		assertPayloads(t, foo, []string{
+			`foo: [1]->{"after": {"a": 1, "b": "a"}, "before": null}`,
+			`foo: [2]->{"after": {"a": 2, "b": "b"}, "before": null}`,
+		})
+
+		sqlDB.Exec(t, `UPSERT INTO foo VALUES (2, 'c'), (3, 'd')`)
+		assertPayloads(t, foo, []string{
+			`foo: [2]->{"after": {"a": 2, "b": "c"}, "before": {"a": 2, "b": "b"}}`,
+			`foo: [3]->{"after": {"a": 3, "b": "d"}, "before": null}`,
+		})
+
+		sqlDB.Exec(t, `DELETE FROM foo WHERE a = 1`)
+		assertPayloads(t, foo, []string{
+			`foo: [1]->{"after": null, "before": {"a": 1, "b": "a"}}`,
+		})
+
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1, 'new a')`)
+		assertPayloads(t, foo, []string{
+			`foo: [1]->{"after": {"a": 1, "b": "new a"}, "before": null}`,
+		})
+	}
+
+	t.Run(`sinkless`, sinklessTest(testFn))
+	t.Run(`enterprise`, enterpriseTest(testFn))
+}
+
 func TestChangefeedMultiTable(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -212,6 +256,182 @@ func TestChangefeedCursor(t *testing.T) {
 	t.Run(`poller`, pollerTest(sinklessTest, testFn))
 }
 
+func TestChangefeedInitialScan(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testFn := func(t *testing.T, db *gosql.DB, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(db)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1, 'before')`)
+
+		var tsLogical string
+		sqlDB.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&tsLogical)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (2, 'after')`)
+
+		noScan := feed(t, f, `CREATE CHANGEFEED FOR foo WITH no_initial_scan`)
+		defer closeFeed(t, noScan)
+
+		// With a cursor, the scan is of the rows as of the cursor, so it
+		// doesn't include the row written after it.
+		cursorScan := feed(t, f, `CREATE CHANGEFEED FOR foo WITH initial_scan, cursor=$1`, tsLogical)
+		defer closeFeed(t, cursorScan)
+		assertPayloads(t, cursorScan, []string{
+			`foo: [1]->{"after": {"a": 1, "b": "before"}}`,
+			`foo: [2]->{"after": {"a": 2, "b": "after"}}`,
+		})
+
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (3, 'new')`)
+		assertPayloads(t, noScan, []string{
+			`foo: [3]->{"after": {"a": 3, "b": "new"}}`,
+		})
+		assertPayloads(t, cursorScan, []string{
+			`foo: [3]->{"after": {"a": 3, "b": "new"}}`,
+		})
+	}
+
+	t.Run(`sinkless`, sinklessTest(testFn))
+	t.Run(`enterprise`, enterpriseTest(testFn))
+	t.Run(`poller`, pollerTest(sinklessTest, testFn))
+}
+
+func TestChangefeedInitialScanOnly(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testFn := func(t *testing.T, db *gosql.DB, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(db)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1, 'a'), (2, 'b')`)
+
+		foo := feed(t, f, `CREATE CHANGEFEED FOR foo WITH initial_scan_only`)
+		defer closeFeed(t, foo)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (3, 'c')`)
+		assertPayloads(t, foo, []string{
+			`foo: [1]->{"after": {"a": 1, "b": "a"}}`,
+			`foo: [2]->{"after": {"a": 2, "b": "b"}}`,
+		})
+
+		// The changefeed finishes after the scan instead of emitting the later
+		// insert.
+		if e, ok := foo.(*cdctest.TableFeed); ok {
+			testutils.SucceedsSoon(t, func() error {
+				var status string
+				sqlDB.QueryRow(t, `SELECT status FROM [SHOW JOBS] WHERE job_id = $1`, e.JobID).Scan(&status)
+				if status != `succeeded` {
+					return errors.Errorf(`job %d had status %s, wanted succeeded`, e.JobID, status)
+				}
+				return nil
+			})
+			return
+		}
+		m, err := foo.Next()
+		require.NoError(t, err)
+		require.Nil(t, m)
+	}
+
+	t.Run(`sinkless`, sinklessTest(testFn))
+	t.Run(`enterprise`, enterpriseTest(testFn))
+	t.Run(`poller`, pollerTest(sinklessTest, testFn))
+}
+
+func TestChangefeedSelect(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testFn := func(t *testing.T, db *gosql.DB, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(db)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING, c INT)`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1, 'one', 10), (2, 'two', 20)`)
+
+		foo := feed(t, f,
+			`CREATE CHANGEFEED AS SELECT f.a, upper(b) AS b_upper FROM foo AS f WHERE c > 15`)
+		defer closeFeed(t, foo)
+		assertPayloads(t, foo, []string{
+			`foo: [2]->{"after": {"a": 2, "b_upper": "TWO"}}`,
+		})
+
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (3, 'three', 30), (4, 'four', 5)`)
+		sqlDB.Exec(t, `UPDATE foo SET c = 40 WHERE a = 1`)
+		assertPayloads(t, foo, []string{
+			`foo: [1]->{"after": {"a": 1, "b_upper": "ONE"}}`,
+			`foo: [3]->{"after": {"a": 3, "b_upper": "THREE"}}`,
+		})
+
+		// Without the previous value of a deleted row, the WHERE clause can't
+		// be evaluated on it, so deletions are always emitted.
+		sqlDB.Exec(t, `DELETE FROM foo WHERE a IN (3, 4)`)
+		assertPayloads(t, foo, []string{
+			`foo: [3]->{"after": null}`,
+			`foo: [4]->{"after": null}`,
+		})
+
+		star := feed(t, f, `CREATE CHANGEFEED WITH diff AS SELECT * FROM foo WHERE a = 2`)
+		defer closeFeed(t, star)
+		assertPayloads(t, star, []string{
+			`foo: [2]->{"after": {"a": 2, "b": "two", "c": 20}, "before": null}`,
+		})
+		sqlDB.Exec(t, `UPDATE foo SET b = 'deux' WHERE a = 2`)
+		sqlDB.Exec(t, `DELETE FROM foo WHERE a IN (1, 2)`)
+		assertPayloads(t, star, []string{
+			`foo: [2]->{"after": {"a": 2, "b": "deux", "c": 20}, "before": {"a": 2, "b": "two", "c": 20}}`,
+			`foo: [2]->{"after": null, "before": {"a": 2, "b": "deux", "c": 20}}`,
+		})
+
+		// With the previous value, rows that stop matching the WHERE clause are
+		// emitted as deletions, and rows that start matching it have no
+		// previous value.
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (5, 'five', 20), (6, 'six', 5)`)
+		diff := feed(t, f, `CREATE CHANGEFEED WITH diff AS SELECT a, c FROM foo WHERE c > 15`)
+		defer closeFeed(t, diff)
+		assertPayloads(t, diff, []string{
+			`foo: [5]->{"after": {"a": 5, "c": 20}, "before": null}`,
+		})
+		sqlDB.Exec(t, `UPDATE foo SET c = 10 WHERE a = 5`)
+		sqlDB.Exec(t, `UPDATE foo SET c = 30 WHERE a = 6`)
+		assertPayloads(t, diff, []string{
+			`foo: [5]->{"after": null, "before": {"a": 5, "c": 20}}`,
+			`foo: [6]->{"after": {"a": 6, "c": 30}, "before": null}`,
+		})
+	}
+
+	t.Run(`sinkless`, sinklessTest(testFn))
+	t.Run(`enterprise`, enterpriseTest(testFn))
+}
+
+func TestChangefeedSelectSchemaChange(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testFn := func(t *testing.T, db *gosql.DB, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(db)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING, c INT)`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1, 'one', 10)`)
+
+		foo := feed(t, f, `CREATE CHANGEFEED AS SELECT a, b FROM foo WHERE c > 5`)
+		defer closeFeed(t, foo)
+		assertPayloads(t, foo, []string{
+			`foo: [1]->{"after": {"a": 1, "b": "one"}}`,
+		})
+
+		// Columns that aren't referenced by the query can come and go.
+		sqlDB.Exec(t, `ALTER TABLE foo ADD COLUMN d INT`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (2, 'two', 20, 2)`)
+		assertPayloads(t, foo, []string{
+			`foo: [2]->{"after": {"a": 2, "b": "two"}}`,
+		})
+
+		// Dropping a referenced column fails the changefeed.
+		sqlDB.Exec(t, `ALTER TABLE foo DROP COLUMN c`)
+		for {
+			_, err := foo.Next()
+			if err != nil {
+				require.Regexp(t, `column "c" does not exist`, err)
+				break
+			}
+		}
+	}
+
+	t.Run(`sinkless`, sinklessTest(testFn))
+	t.Run(`enterprise`, enterpriseTest(testFn))
+}
+
 func TestChangefeedTimestamps(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -1312,6 +1532,36 @@ func TestChangefeedErrors(t *testing.T) {
 		`EXPERIMENTAL CHANGEFEED FOR foo WITH cursor=$1`, timeutil.Now().Add(time.Hour),
 	)
 
+	sqlDB.ExpectErr(
+		t, `diff is only usable with envelope=wrapped`,
+		`EXPERIMENTAL CHANGEFEED FOR foo WITH diff, envelope='key_only'`,
+	)
+	sqlDB.ExpectErr(
+		t, `CHANGEFEED AS SELECT must select from exactly one table`,
+		`EXPERIMENTAL CHANGEFEED AS SELECT foo.a FROM foo, rangefeed_off`,
+	)
+	sqlDB.ExpectErr(
+		t, `CHANGEFEED AS SELECT does not support DISTINCT, GROUP BY, HAVING or WINDOW`,
+		`EXPERIMENTAL CHANGEFEED AS SELECT a FROM foo GROUP BY a`,
+	)
+	sqlDB.ExpectErr(
+		t, `column "nope" does not exist`,
+		`EXPERIMENTAL CHANGEFEED AS SELECT a FROM foo WHERE nope > 1`,
+	)
+	sqlDB.ExpectErr(
+		t, `aggregate functions are not allowed in CHANGEFEED`,
+		`EXPERIMENTAL CHANGEFEED AS SELECT max(a) FROM foo`,
+	)
+
+	sqlDB.ExpectErr(
+		t, `cannot specify both initial_scan and no_initial_scan`,
+		`EXPERIMENTAL CHANGEFEED FOR foo WITH initial_scan, no_initial_scan`,
+	)
+	sqlDB.ExpectErr(
+		t, `cannot specify both initial_scan_only and no_initial_scan`,
+		`EXPERIMENTAL CHANGEFEED FOR foo WITH initial_scan_only, no_initial_scan`,
+	)
+
 	sqlDB.ExpectErr(
 		t, `omit the SINK clause`,
 		`CREATE CHANGEFEED FOR foo INTO ''`,
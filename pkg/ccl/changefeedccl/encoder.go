@@ -44,9 +44,25 @@ type encodeRow struct {
 	// deleted is true if row is a deletion. In this case, only the primary
 	// key columns are guaranteed to be set in `datums`.
 	deleted bool
+	// prevDatums is the value of the row before this change. It's only set if
+	// the changefeed requested diffs and the row existed before the change.
+	// It's interpreted using `tableDesc`, like `datums`.
+	prevDatums sqlbase.EncDatumRow
 	// tableDesc is a TableDescriptor for the table containing `datums`.
 	// It's valid for interpreting the row at `updated`.
 	tableDesc *sqlbase.TableDescriptor
+	// projection, if set, is the result of the changefeed's SELECT on this
+	// row. It's encoded as the value instead of the row itself, while the key
+	// is still made of the primary key columns in `datums`.
+	projection *encodeRow
+}
+
+// valueRow returns the row to encode as the value.
+func (r encodeRow) valueRow() encodeRow {
+	if r.projection != nil {
+		return *r.projection
+	}
+	return r
 }
 
 // Encoder turns a row into a serialized changefeed key, value, or resolved
@@ -59,8 +75,8 @@ type Encoder interface {
 	EncodeKey(encodeRow) ([]byte, error)
 	// EncodeValue encodes the primary key of the given row. The columns of the
 	// datums are expected to match 1:1 with the `Columns` field of the
-	// `TableDescriptor`. The returned bytes are only valid until the next call
-	// to Encode*.
+	// `TableDescriptor`. If the row has a projection, it's encoded instead. The
+	// returned bytes are only valid until the next call to Encode*.
 	EncodeValue(encodeRow) ([]byte, error)
 	// EncodeResolvedTimestamp encodes a resolved timestamp payload for the
 	// given topic name. The returned bytes are only valid until the next call
@@ -74,6 +90,8 @@ func getEncoder(opts map[string]string) (Encoder, error) {
 		return makeJSONEncoder(opts)
 	case optFormatAvro:
 		return newConfluentAvroEncoder(opts)
+	case optFormatParquet:
+		return makeParquetEncoder(opts)
 	default:
 		return nil, errors.Errorf(`unknown %s: %s`, optFormat, opts[optFormat])
 	}
@@ -84,7 +102,7 @@ func getEncoder(opts map[string]string) (Encoder, error) {
 // to its value. Updated timestamps in rows and resolved timestamp payloads are
 // stored in a sub-object under the `__crdb__` key in the top-level JSON object.
 type jsonEncoder struct {
-	updatedField, wrapped, keyOnly, keyInValue bool
+	updatedField, beforeField, wrapped, keyOnly, keyInValue bool
 
 	alloc sqlbase.DatumAlloc
 	buf   bytes.Buffer
@@ -98,6 +116,11 @@ func makeJSONEncoder(opts map[string]string) (*jsonEncoder, error) {
 		wrapped: envelopeType(opts[optEnvelope]) == optEnvelopeWrapped,
 	}
 	_, e.updatedField = opts[optUpdatedTimestamps]
+	_, e.beforeField = opts[optDiff]
+	if e.beforeField && !e.wrapped {
+		return nil, errors.Errorf(`%s is only usable with %s=%s`,
+			optDiff, optEnvelope, optEnvelopeWrapped)
+	}
 	_, e.keyInValue = opts[optKeyInValue]
 	if e.keyInValue && !e.wrapped {
 		return nil, errors.Errorf(`%s is only usable with %s=%s`,
@@ -148,21 +171,12 @@ func (e *jsonEncoder) EncodeValue(row encodeRow) ([]byte, error) {
 		return nil, nil
 	}
 
+	valueRow := row.valueRow()
 	var after map[string]interface{}
 	if !row.deleted {
-		columns := row.tableDesc.Columns
-		after = make(map[string]interface{}, len(columns))
-		for i := range columns {
-			col := &columns[i]
-			datum := row.datums[i]
-			if err := datum.EnsureDecoded(&col.Type, &e.alloc); err != nil {
-				return nil, err
-			}
-			var err error
-			after[col.Name], err = tree.AsJSON(datum.Datum)
-			if err != nil {
-				return nil, err
-			}
+		var err error
+		if after, err = e.encodeRowRaw(valueRow.tableDesc, valueRow.datums); err != nil {
+			return nil, err
 		}
 	}
 
@@ -173,6 +187,17 @@ func (e *jsonEncoder) EncodeValue(row encodeRow) ([]byte, error) {
 		} else {
 			jsonEntries = map[string]interface{}{`after`: nil}
 		}
+		if e.beforeField {
+			if valueRow.prevDatums != nil {
+				before, err := e.encodeRowRaw(valueRow.tableDesc, valueRow.prevDatums)
+				if err != nil {
+					return nil, err
+				}
+				jsonEntries[`before`] = before
+			} else {
+				jsonEntries[`before`] = nil
+			}
+		}
 		if e.keyInValue {
 			keyEntries, err := e.encodeKeyRaw(row)
 			if err != nil {
@@ -204,6 +229,28 @@ func (e *jsonEncoder) EncodeValue(row encodeRow) ([]byte, error) {
 	return e.buf.Bytes(), nil
 }
 
+// encodeRowRaw maps the name of every column of the table to its value in the
+// given datums.
+func (e *jsonEncoder) encodeRowRaw(
+	tableDesc *sqlbase.TableDescriptor, datums sqlbase.EncDatumRow,
+) (map[string]interface{}, error) {
+	columns := tableDesc.Columns
+	jsonEntries := make(map[string]interface{}, len(columns))
+	for i := range columns {
+		col := &columns[i]
+		datum := datums[i]
+		if err := datum.EnsureDecoded(&col.Type, &e.alloc); err != nil {
+			return nil, err
+		}
+		var err error
+		jsonEntries[col.Name], err = tree.AsJSON(datum.Datum)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return jsonEntries, nil
+}
+
 // EncodeResolvedTimestamp implements the Encoder interface.
 func (e *jsonEncoder) EncodeResolvedTimestamp(_ string, resolved hlc.Timestamp) ([]byte, error) {
 	meta := map[string]interface{}{
@@ -224,8 +271,8 @@ func (e *jsonEncoder) EncodeResolvedTimestamp(_ string, resolved hlc.Timestamp)
 // JSON format. Keys are the primary key columns in a record. Values are all
 // columns in a record.
 type confluentAvroEncoder struct {
-	registryURL           string
-	updatedField, keyOnly bool
+	registryURL                        string
+	updatedField, beforeField, keyOnly bool
 
 	keyCache      map[tableIDAndVersion]confluentRegisteredKeySchema
 	valueCache    map[tableIDAndVersion]confluentRegisteredEnvelopeSchema
@@ -262,6 +309,11 @@ func newConfluentAvroEncoder(opts map[string]string) (*confluentAvroEncoder, err
 			optEnvelope, opts[optEnvelope], optFormat, optFormatAvro)
 	}
 	_, e.updatedField = opts[optUpdatedTimestamps]
+	_, e.beforeField = opts[optDiff]
+	if e.beforeField && e.keyOnly {
+		return nil, errors.Errorf(`%s is only usable with %s=%s`,
+			optDiff, optEnvelope, optEnvelopeWrapped)
+	}
 
 	if _, ok := opts[optKeyInValue]; ok {
 		return nil, errors.Errorf(`%s is not supported with %s=%s`,
@@ -316,16 +368,29 @@ func (e *confluentAvroEncoder) EncodeValue(row encodeRow) ([]byte, error) {
 		return nil, nil
 	}
 
+	row = row.valueRow()
 	cacheKey := makeTableIDAndVersion(row.tableDesc.ID, row.tableDesc.Version)
 	registered, ok := e.valueCache[cacheKey]
 	if !ok {
-		afterDataSchema, err := tableToAvroSchema(row.tableDesc)
+		afterDataSchema, err := tableToAvroSchema(row.tableDesc, avroSchemaNoSuffix)
 		if err != nil {
 			return nil, err
 		}
+		// The before and after records must have different names to be used in
+		// the same schema.
+		var beforeDataSchema *avroDataRecord
+		if e.beforeField {
+			beforeDataSchema, err = tableToAvroSchema(row.tableDesc, `before`)
+			if err != nil {
+				return nil, err
+			}
+		}
 
-		opts := avroEnvelopeOpts{afterField: true, updatedField: e.updatedField}
-		registered.schema, err = envelopeToAvroSchema(row.tableDesc.Name, opts, afterDataSchema)
+		opts := avroEnvelopeOpts{
+			beforeField: e.beforeField, afterField: true, updatedField: e.updatedField,
+		}
+		registered.schema, err = envelopeToAvroSchema(
+			row.tableDesc.Name, opts, beforeDataSchema, afterDataSchema)
 		if err != nil {
 			return nil, err
 		}
@@ -346,9 +411,12 @@ func (e *confluentAvroEncoder) EncodeValue(row encodeRow) ([]byte, error) {
 			`updated`: row.updated,
 		}
 	}
-	var datums sqlbase.EncDatumRow
+	var beforeDatums, afterDatums sqlbase.EncDatumRow
+	if registered.schema.opts.beforeField {
+		beforeDatums = row.prevDatums
+	}
 	if !row.deleted {
-		datums = row.datums
+		afterDatums = row.datums
 	}
 	// https://docs.confluent.io/current/schema-registry/docs/serializer-formatter.html#wire-format
 	header := []byte{
@@ -356,7 +424,7 @@ func (e *confluentAvroEncoder) EncodeValue(row encodeRow) ([]byte, error) {
 		0, 0, 0, 0, // Placeholder for the ID.
 	}
 	binary.BigEndian.PutUint32(header[1:5], uint32(registered.registryID))
-	return registered.schema.BinaryFromRow(header, meta, datums)
+	return registered.schema.BinaryFromRow(header, meta, beforeDatums, afterDatums)
 }
 
 // EncodeResolvedTimestamp implements the Encoder interface.
@@ -367,7 +435,7 @@ func (e *confluentAvroEncoder) EncodeResolvedTimestamp(
 	if !ok {
 		opts := avroEnvelopeOpts{resolvedField: true}
 		var err error
-		registered.schema, err = envelopeToAvroSchema(topic, opts, nil /* after */)
+		registered.schema, err = envelopeToAvroSchema(topic, opts, nil /* before */, nil /* after */)
 		if err != nil {
 			return nil, err
 		}
@@ -394,7 +462,7 @@ func (e *confluentAvroEncoder) EncodeResolvedTimestamp(
 		0, 0, 0, 0, // Placeholder for the ID.
 	}
 	binary.BigEndian.PutUint32(header[1:5], uint32(registered.registryID))
-	return registered.schema.BinaryFromRow(header, meta, nil /* row */)
+	return registered.schema.BinaryFromRow(header, meta, nil /* beforeRow */, nil /* afterRow */)
 }
 
 func (e *confluentAvroEncoder) register(schema *avroRecord, subject string) (int32, error) {
@@ -438,3 +506,97 @@ func (e *confluentAvroEncoder) register(schema *avroRecord, subject string) (int
 
 	return res.ID, nil
 }
+
+// parquetEncoder encodes changefeed values for the Parquet files written by
+// the cloud storage sink. A value is a byte that is 1 if the row was deleted,
+// followed by the value encoding of every column in the row, which the sink
+// decodes with the row's TableDescriptor and adds to the file for its table
+// version. The columns of a deleted row other than the primary key are encoded
+// as NULL. Keys are never encoded because the primary key columns are already
+// in the value. Resolved timestamp payloads are the same as the wrapped JSON
+// ones.
+type parquetEncoder struct {
+	alloc sqlbase.DatumAlloc
+	buf   []byte
+}
+
+var _ Encoder = &parquetEncoder{}
+
+func makeParquetEncoder(opts map[string]string) (*parquetEncoder, error) {
+	if _, ok := opts[optDiff]; ok {
+		return nil, errors.Errorf(`%s is not supported with %s=%s`,
+			optDiff, optFormat, optFormatParquet)
+	}
+	switch envelopeType(opts[optEnvelope]) {
+	case optEnvelopeWrapped, optEnvelopeRow:
+	default:
+		return nil, errors.Errorf(`%s=%s is not supported with %s=%s`,
+			optEnvelope, opts[optEnvelope], optFormat, optFormatParquet)
+	}
+	return &parquetEncoder{}, nil
+}
+
+// EncodeKey implements the Encoder interface.
+func (e *parquetEncoder) EncodeKey(encodeRow) ([]byte, error) {
+	return nil, nil
+}
+
+// EncodeValue implements the Encoder interface.
+func (e *parquetEncoder) EncodeValue(row encodeRow) ([]byte, error) {
+	if row.projection != nil {
+		return nil, errors.Errorf(`%s=%s does not support AS SELECT`, optFormat, optFormatParquet)
+	}
+	e.buf = e.buf[:0]
+	if row.deleted {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+	for i := range row.datums {
+		col := &row.tableDesc.Columns[i]
+		datum := row.datums[i]
+		if row.deleted && !row.tableDesc.PrimaryIndex.ContainsColumnID(col.ID) {
+			datum = sqlbase.DatumToEncDatum(&col.Type, tree.DNull)
+		}
+		var err error
+		e.buf, err = datum.Encode(&col.Type, &e.alloc, sqlbase.DatumEncoding_VALUE, e.buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return e.buf, nil
+}
+
+// decodeParquetValue decodes a value written by parquetEncoder into datums,
+// which are returned along with whether the row was deleted.
+func decodeParquetValue(
+	table *sqlbase.TableDescriptor, alloc *sqlbase.DatumAlloc, value []byte, datums tree.Datums,
+) (deleted bool, _ error) {
+	if len(value) == 0 {
+		return false, errors.New(`empty parquet value`)
+	}
+	deleted, value = value[0] == 1, value[1:]
+	for i := range table.Columns {
+		typ := &table.Columns[i].Type
+		ed, rest, err := sqlbase.EncDatumFromBuffer(typ, sqlbase.DatumEncoding_VALUE, value)
+		if err != nil {
+			return false, err
+		}
+		value = rest
+		if err := ed.EnsureDecoded(typ, alloc); err != nil {
+			return false, err
+		}
+		datums[i] = ed.Datum
+	}
+	if len(value) > 0 {
+		return false, errors.Errorf(`%d trailing bytes in parquet value`, len(value))
+	}
+	return deleted, nil
+}
+
+// EncodeResolvedTimestamp implements the Encoder interface.
+func (e *parquetEncoder) EncodeResolvedTimestamp(_ string, resolved hlc.Timestamp) ([]byte, error) {
+	return gojson.Marshal(map[string]interface{}{
+		`resolved`: tree.TimestampToDecimal(resolved).Decimal.String(),
+	})
+}
@@ -193,6 +193,128 @@ func TestEncoders(t *testing.T) {
 	}
 }
 
+func TestEncodersWithDiff(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	tableDesc, err := parseTableDesc(`CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+	require.NoError(t, err)
+	row := sqlbase.EncDatumRow{
+		sqlbase.EncDatum{Datum: tree.NewDInt(1)},
+		sqlbase.EncDatum{Datum: tree.NewDString(`bar`)},
+	}
+	prevRow := sqlbase.EncDatumRow{
+		sqlbase.EncDatum{Datum: tree.NewDInt(1)},
+		sqlbase.EncDatum{Datum: tree.NewDString(`baz`)},
+	}
+	ts := hlc.Timestamp{WallTime: 1, Logical: 2}
+
+	tests := []struct {
+		format   formatType
+		envelope envelopeType
+		// Either err is set or all of insert, update, and delete are.
+		err    string
+		insert string
+		update string
+		delete string
+	}{
+		{
+			format:   optFormatJSON,
+			envelope: optEnvelopeKeyOnly,
+			err:      `diff is only usable with envelope=wrapped`,
+		},
+		{
+			format:   optFormatJSON,
+			envelope: optEnvelopeRow,
+			err:      `diff is only usable with envelope=wrapped`,
+		},
+		{
+			format:   optFormatJSON,
+			envelope: optEnvelopeWrapped,
+			insert:   `[1]->{"after": {"a": 1, "b": "bar"}, "before": null}`,
+			update:   `[1]->{"after": {"a": 1, "b": "bar"}, "before": {"a": 1, "b": "baz"}}`,
+			delete:   `[1]->{"after": null, "before": {"a": 1, "b": "baz"}}`,
+		},
+		{
+			format:   optFormatAvro,
+			envelope: optEnvelopeKeyOnly,
+			err:      `diff is only usable with envelope=wrapped`,
+		},
+		{
+			format:   optFormatAvro,
+			envelope: optEnvelopeWrapped,
+			insert: `{"a":{"long":1}}->` +
+				`{"after":{"foo":{"a":{"long":1},"b":{"string":"bar"}}},"before":null}`,
+			update: `{"a":{"long":1}}->` +
+				`{"after":{"foo":{"a":{"long":1},"b":{"string":"bar"}}},` +
+				`"before":{"foo_before":{"a":{"long":1},"b":{"string":"baz"}}}}`,
+			delete: `{"a":{"long":1}}->` +
+				`{"after":null,` +
+				`"before":{"foo_before":{"a":{"long":1},"b":{"string":"baz"}}}}`,
+		},
+	}
+
+	for _, test := range tests {
+		name := fmt.Sprintf("format=%s,envelope=%s", test.format, test.envelope)
+		t.Run(name, func(t *testing.T) {
+			o := map[string]string{
+				optFormat:   string(test.format),
+				optEnvelope: string(test.envelope),
+				optDiff:     ``,
+			}
+
+			var rowStringFn func([]byte, []byte) string
+			switch o[optFormat] {
+			case string(optFormatJSON):
+				rowStringFn = func(k, v []byte) string { return fmt.Sprintf(`%s->%s`, k, v) }
+			case string(optFormatAvro):
+				reg := makeTestSchemaRegistry()
+				defer reg.Close()
+				o[optConfluentSchemaRegistry] = reg.server.URL
+				rowStringFn = func(k, v []byte) string {
+					key, value := avroToJSON(t, reg, k), avroToJSON(t, reg, v)
+					return fmt.Sprintf(`%s->%s`, key, value)
+				}
+			default:
+				t.Fatalf(`unknown format: %s`, o[optFormat])
+			}
+
+			e, err := getEncoder(o)
+			if len(test.err) > 0 {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, tc := range []struct {
+				row      encodeRow
+				expected string
+			}{
+				{
+					row:      encodeRow{datums: row, updated: ts, tableDesc: tableDesc},
+					expected: test.insert,
+				},
+				{
+					row:      encodeRow{datums: row, prevDatums: prevRow, updated: ts, tableDesc: tableDesc},
+					expected: test.update,
+				},
+				{
+					row: encodeRow{
+						datums: row, deleted: true, prevDatums: prevRow, updated: ts, tableDesc: tableDesc,
+					},
+					expected: test.delete,
+				},
+			} {
+				key, err := e.EncodeKey(tc.row)
+				require.NoError(t, err)
+				key = append([]byte(nil), key...)
+				value, err := e.EncodeValue(tc.row)
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, rowStringFn(key, value))
+			}
+		})
+	}
+}
+
 type testSchemaRegistry struct {
 	server *httptest.Server
 	mu     struct {
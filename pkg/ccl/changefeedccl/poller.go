@@ -123,6 +123,10 @@ func makePoller(
 // number are inflight or being inserted into the buffer. Finally, after each
 // poll completes, a resolved timestamp notification is added to the buffer.
 func (p *poller) Run(ctx context.Context) error {
+	if _, ok := p.details.Opts[optDiff]; ok {
+		// ExportRequests don't return the value that each change overwrote.
+		return errors.Errorf(`%s requires the changefeed.push.enabled setting`, optDiff)
+	}
 	for {
 		// Wait for polling interval
 		p.mu.Lock()
@@ -187,6 +191,10 @@ func (p *poller) Run(ctx context.Context) error {
 		p.mu.Lock()
 		p.mu.highWater = nextHighWater
 		p.mu.Unlock()
+
+		if isFullScan && p.initialScanOnly() {
+			return p.waitAfterInitialScan(ctx)
+		}
 	}
 }
 
@@ -235,6 +243,9 @@ func (p *poller) rangefeedImpl(ctx context.Context) error {
 			); err != nil {
 				return err
 			}
+			if p.initialScanOnly() {
+				return p.waitAfterInitialScan(ctx)
+			}
 		}
 
 		// Start rangefeeds, exit polling if we hit a resolved timestamp beyond
@@ -279,11 +290,12 @@ func (p *poller) rangefeedImpl(ctx context.Context) error {
 		frontier := makeSpanFrontier(spans...)
 
 		rangeFeedStartTS := lastHighwater
+		_, withDiff := p.details.Opts[optDiff]
 		for _, span := range p.spans {
 			span := span
 			frontier.Forward(span, rangeFeedStartTS)
 			g.GoCtx(func(ctx context.Context) error {
-				return ds.RangeFeed(ctx, span, rangeFeedStartTS, eventC)
+				return ds.RangeFeed(ctx, span, rangeFeedStartTS, withDiff, eventC)
 			})
 		}
 		g.GoCtx(func(ctx context.Context) error {
@@ -293,7 +305,7 @@ func (p *poller) rangefeedImpl(ctx context.Context) error {
 					switch t := e.GetValue().(type) {
 					case *roachpb.RangeFeedValue:
 						kv := roachpb.KeyValue{Key: t.Key, Value: t.Value}
-						if err := memBuf.AddKV(ctx, kv, hlc.Timestamp{}); err != nil {
+						if err := memBuf.AddKV(ctx, kv, t.PrevValue, hlc.Timestamp{}); err != nil {
 							return err
 						}
 					case *roachpb.RangeFeedCheckpoint:
@@ -306,6 +318,10 @@ func (p *poller) rangefeedImpl(ctx context.Context) error {
 						if err := memBuf.AddResolved(ctx, t.Span, t.ResolvedTS); err != nil {
 							return err
 						}
+					case *roachpb.RangeFeedDeleteRange:
+						// The keys deleted by a range tombstone can't be emitted without
+						// scanning for them, which the rangefeed doesn't do.
+						return errors.Errorf("unsupported range deletion of %s at %s", t.Span, t.Timestamp)
 					default:
 						log.Fatalf(ctx, "unexpected RangeFeedEvent variant %v", t)
 					}
@@ -335,7 +351,7 @@ func (p *poller) rangefeedImpl(ctx context.Context) error {
 					if pastBoundary {
 						continue
 					}
-					if err := p.buf.AddKV(ctx, e.kv, e.schemaTimestamp); err != nil {
+					if err := p.buf.AddKV(ctx, e.kv, e.prevVal, e.schemaTimestamp); err != nil {
 						return err
 					}
 				} else if e.resolved != nil {
@@ -375,6 +391,21 @@ func (p *poller) rangefeedImpl(ctx context.Context) error {
 	}
 }
 
+// initialScanOnly returns whether the changefeed stops after its initial scan.
+func (p *poller) initialScanOnly() bool {
+	_, ok := p.details.Opts[optInitialScanOnly]
+	return ok
+}
+
+// waitAfterInitialScan is used in place of watching for changes once the
+// initial scan of an initial_scan_only changefeed has been buffered. The
+// resolved timestamps added by the scan let the changeFrontier finish the
+// changefeed, which in turn shuts down the poller.
+func (p *poller) waitAfterInitialScan(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
 func getSpansToProcess(
 	ctx context.Context, db *client.DB, targetSpans []roachpb.Span,
 ) ([]roachpb.Span, error) {
@@ -559,7 +590,9 @@ func (p *poller) slurpSST(ctx context.Context, sst []byte, schemaTimestamp hlc.T
 	slurpKVs := func() error {
 		sort.Sort(byValueTimestamp(kvs))
 		for _, kv := range kvs {
-			if err := p.buf.AddKV(ctx, kv, schemaTimestamp); err != nil {
+			// The previous values of keys aren't known when exporting. Full scans
+			// don't need them and polling doesn't support diffs.
+			if err := p.buf.AddKV(ctx, kv, roachpb.Value{}, schemaTimestamp); err != nil {
 				return err
 			}
 		}
@@ -645,6 +678,9 @@ func (p *poller) validateTable(ctx context.Context, desc *sqlbase.TableDescripto
 	if err := validateChangefeedTable(p.details.Targets, desc); err != nil {
 		return err
 	}
+	if err := validateChangefeedSelect(ctx, p.details, desc); err != nil {
+		return err
+	}
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	if lastVersion, ok := p.mu.previousTableVersion[desc.ID]; ok {
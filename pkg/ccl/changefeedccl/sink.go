@@ -27,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/parquet"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
@@ -157,6 +158,47 @@ func getSink(
 		makeSink = func() (Sink, error) {
 			return makeKafkaSink(cfg, u.Host, targets)
 		}
+	case u.Scheme == sinkSchemeWebhookHTTPS:
+		cfg := webhookSinkConfig{
+			batchSize: webhookSinkDefaultBatchSize,
+			retryOpts: webhookSinkDefaultRetryOpts,
+		}
+		for param, dest := range map[string]*[]byte{
+			sinkParamCACert:     &cfg.caCert,
+			sinkParamClientCert: &cfg.clientCert,
+			sinkParamClientKey:  &cfg.clientKey,
+		} {
+			if encoded := q.Get(param); encoded != `` {
+				if *dest, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+					return nil, errors.Errorf(`param %s must be base 64 encoded: %s`, param, err)
+				}
+			}
+			q.Del(param)
+		}
+		if (cfg.clientCert == nil) != (cfg.clientKey == nil) {
+			return nil, errors.Errorf(`%s and %s must be provided together`,
+				sinkParamClientCert, sinkParamClientKey)
+		}
+		cfg.authHeader = q.Get(sinkParamWebhookAuthHeader)
+		q.Del(sinkParamWebhookAuthHeader)
+		if batchSize := q.Get(sinkParamWebhookBatchSize); batchSize != `` {
+			if cfg.batchSize, err = strconv.Atoi(batchSize); err != nil {
+				return nil, errors.Errorf(`param %s must be an integer: %s`, sinkParamWebhookBatchSize, err)
+			} else if cfg.batchSize <= 0 {
+				return nil, errors.Errorf(`param %s must be positive: %d`,
+					sinkParamWebhookBatchSize, cfg.batchSize)
+			}
+		}
+		q.Del(sinkParamWebhookBatchSize)
+
+		// The remaining URL (minus the query parameters we just consumed) is where
+		// the messages are POSTed.
+		endpoint := *u
+		endpoint.Scheme = `https`
+		endpoint.RawQuery = ``
+		makeSink = func() (Sink, error) {
+			return makeWebhookSink(cfg, endpoint.String(), opts, targets)
+		}
 	case isCloudStorageSink(u):
 		fileSizeParam := q.Get(sinkParamFileSize)
 		q.Del(sinkParamFileSize)
@@ -166,13 +208,30 @@ func getSink(
 				return nil, pgerror.Wrapf(err, pgcode.Syntax, `parsing %s`, fileSizeParam)
 			}
 		}
+		var parquetOpts parquet.WriterOptions
+		if compression := q.Get(sinkParamParquetCompress); compression != `` {
+			if parquetOpts.Compression, err = parquet.ParseCompression(compression); err != nil {
+				return nil, err
+			}
+		}
+		q.Del(sinkParamParquetCompress)
+		if rowGroupSize := q.Get(sinkParamParquetRowGroup); rowGroupSize != `` {
+			if parquetOpts.RowGroupSize, err = strconv.Atoi(rowGroupSize); err != nil {
+				return nil, pgerror.Wrapf(err, pgcode.Syntax, `parsing %s`, rowGroupSize)
+			}
+			if parquetOpts.RowGroupSize <= 0 {
+				return nil, errors.Errorf(`param %s must be positive: %d`,
+					sinkParamParquetRowGroup, parquetOpts.RowGroupSize)
+			}
+		}
+		q.Del(sinkParamParquetRowGroup)
 		u.Scheme = strings.TrimPrefix(u.Scheme, `experimental-`)
 		// Transfer "ownership" of validating all remaining query parameters to
 		// ExportStorage.
 		u.RawQuery = q.Encode()
 		q = url.Values{}
 		makeSink = func() (Sink, error) {
-			return makeCloudStorageSink(u.String(), nodeID, fileSize, settings, opts)
+			return makeCloudStorageSink(u.String(), nodeID, fileSize, settings, opts, parquetOpts)
 		}
 	case u.Scheme == sinkSchemeExperimentalSQL:
 		// Swap the changefeed prefix for the sql connection one that sqlSink
@@ -20,7 +20,10 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/parquet"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/pkg/errors"
@@ -46,6 +49,13 @@ func cloudStorageFormatTime(ts hlc.Timestamp) string {
 	return fmt.Sprintf(`%s%09d%010d`, t.Format(f), t.Nanosecond(), ts.Logical)
 }
 
+// The names of the extra columns in the Parquet files written by
+// cloudStorageSink.
+const (
+	parquetDeletedColumn = `__crdb__deleted`
+	parquetUpdatedColumn = `__crdb__updated`
+)
+
 type cloudStorageSinkKey struct {
 	Topic    string
 	SchemaID sqlbase.DescriptorVersion
@@ -54,6 +64,13 @@ type cloudStorageSinkKey struct {
 type cloudStorageSinkFile struct {
 	earliestTs hlc.Timestamp
 	buf        bytes.Buffer
+
+	// parquet, if set, writes the file's rows to buf. A Parquet file is
+	// buffered a row group at a time, so size is used to decide when the file
+	// is full instead of the length of buf. It's the total size of the values
+	// written to the file.
+	parquet *parquet.Writer
+	size    int64
 }
 
 // cloudStorageSink emits to files on cloud storage.
@@ -78,12 +95,15 @@ type cloudStorageSinkFile struct {
 // cloudStorageSink in a running process and `<file_id>` is a unique id for each
 // file written by a given `<sink_id>`.
 //
-// `<ext>` implies the format of the file: currently the only option is
-// `ndjson`, which means a text file conforming to the "Newline Delimited JSON"
-// spec.
+// `<ext>` implies the format of the file: `ndjson` means a text file conforming
+// to the "Newline Delimited JSON" spec and `parquet` means an Apache Parquet
+// file.
 //
 // Each record in the data files is a value, keys are not included, so the
-// `envelope` option must be set to `value_only`. Within a file, records are not
+// `envelope` option must be set to `value_only`. A Parquet file has a column
+// for every column in the table, plus a `__crdb__deleted` BOOL column and, if
+// the `updated` option is set, a `__crdb__updated` STRING column with the same
+// contents as the JSON `updated` field. Within a file, records are not
 // guaranteed to be sorted by timestamp. A duplicate of some records might exist
 // in a different file or even in the same file.
 //
@@ -103,9 +123,16 @@ type cloudStorageSink struct {
 	settings          *cluster.Settings
 	partitionFormat   string
 
+	format        formatType
 	ext           string
 	recordDelimFn func(io.Writer) error
 
+	// parquetOpts and updatedField are only used by the Parquet format.
+	parquetOpts   parquet.WriterOptions
+	updatedField  bool
+	parquetAlloc  sqlbase.DatumAlloc
+	parquetDatums tree.Datums
+
 	es     storageccl.ExportStorage
 	fileID int64
 	files  map[cloudStorageSinkKey]*cloudStorageSinkFile
@@ -119,6 +146,7 @@ func makeCloudStorageSink(
 	targetMaxFileSize int64,
 	settings *cluster.Settings,
 	opts map[string]string,
+	parquetOpts parquet.WriterOptions,
 ) (Sink, error) {
 	// Date partitioning is pretty standard, so no override for now, but we could
 	// plumb one down if someone needs it.
@@ -134,7 +162,8 @@ func makeCloudStorageSink(
 		partitionFormat:   defaultPartitionFormat,
 	}
 
-	switch formatType(opts[optFormat]) {
+	s.format = formatType(opts[optFormat])
+	switch s.format {
 	case optFormatJSON:
 		// TODO(dan): It seems like these should be on the encoder, but that
 		// would require a bit of refactoring.
@@ -143,18 +172,21 @@ func makeCloudStorageSink(
 			_, err := w.Write([]byte{'\n'})
 			return err
 		}
+		switch envelopeType(opts[optEnvelope]) {
+		case optEnvelopeWrapped:
+		default:
+			return nil, errors.Errorf(`this sink is incompatible with %s=%s`,
+				optEnvelope, opts[optEnvelope])
+		}
+	case optFormatParquet:
+		s.ext = `.parquet`
+		s.parquetOpts = parquetOpts
+		_, s.updatedField = opts[optUpdatedTimestamps]
 	default:
 		return nil, errors.Errorf(`this sink is incompatible with %s=%s`,
 			optFormat, opts[optFormat])
 	}
 
-	switch envelopeType(opts[optEnvelope]) {
-	case optEnvelopeWrapped:
-	default:
-		return nil, errors.Errorf(`this sink is incompatible with %s=%s`,
-			optEnvelope, opts[optEnvelope])
-	}
-
 	if _, ok := opts[optKeyInValue]; !ok {
 		return nil, errors.Errorf(`this sink requires the WITH %s option`, optKeyInValue)
 	}
@@ -192,14 +224,21 @@ func (s *cloudStorageSink) EmitRow(
 	}
 
 	// TODO(dan): Memory monitoring for this
-	if _, err := file.buf.Write(value); err != nil {
-		return err
-	}
-	if err := s.recordDelimFn(&file.buf); err != nil {
-		return err
+	if s.format == optFormatParquet {
+		if err := s.addParquetRow(table, file, value, updated); err != nil {
+			return err
+		}
+	} else {
+		if _, err := file.buf.Write(value); err != nil {
+			return err
+		}
+		if err := s.recordDelimFn(&file.buf); err != nil {
+			return err
+		}
+		file.size = int64(file.buf.Len())
 	}
 
-	if int64(file.buf.Len()) > s.targetMaxFileSize {
+	if file.size > s.targetMaxFileSize {
 		if err := s.flushFile(ctx, key, file); err != nil {
 			return err
 		}
@@ -208,6 +247,45 @@ func (s *cloudStorageSink) EmitRow(
 	return nil
 }
 
+// addParquetRow decodes a value written by parquetEncoder and adds it to the
+// file, starting the file's Parquet writer if this is its first row.
+func (s *cloudStorageSink) addParquetRow(
+	table *sqlbase.TableDescriptor, file *cloudStorageSinkFile, value []byte, updated hlc.Timestamp,
+) error {
+	if file.parquet == nil {
+		names := make([]string, 0, len(table.Columns)+2)
+		typs := make([]types.T, 0, len(table.Columns)+2)
+		for i := range table.Columns {
+			names = append(names, table.Columns[i].Name)
+			typs = append(typs, table.Columns[i].Type)
+		}
+		names = append(names, parquetDeletedColumn)
+		typs = append(typs, *types.Bool)
+		if s.updatedField {
+			names = append(names, parquetUpdatedColumn)
+			typs = append(typs, *types.String)
+		}
+		var err error
+		if file.parquet, err = parquet.NewWriter(&file.buf, names, typs, s.parquetOpts); err != nil {
+			return err
+		}
+		s.parquetDatums = make(tree.Datums, len(names))
+	}
+
+	datums := s.parquetDatums
+	deleted, err := decodeParquetValue(table, &s.parquetAlloc, value, datums)
+	if err != nil {
+		return err
+	}
+	datums[len(table.Columns)] = tree.MakeDBool(tree.DBool(deleted))
+	if s.updatedField {
+		datums[len(table.Columns)+1] = tree.NewDString(
+			tree.TimestampToDecimal(updated).Decimal.String())
+	}
+	file.size += int64(len(value))
+	return file.parquet.AddRow(datums)
+}
+
 // EmitResolvedTimestamp implements the Sink interface.
 func (s *cloudStorageSink) EmitResolvedTimestamp(
 	ctx context.Context, encoder Encoder, resolved hlc.Timestamp,
@@ -251,6 +329,11 @@ func (s *cloudStorageSink) Flush(ctx context.Context) error {
 func (s *cloudStorageSink) flushFile(
 	ctx context.Context, key cloudStorageSinkKey, file *cloudStorageSinkFile,
 ) error {
+	if file.parquet != nil {
+		if err := file.parquet.Close(); err != nil {
+			return err
+		}
+	}
 	if file.buf.Len() == 0 {
 		// This method shouldn't be called with an empty file, but be defensive
 		// about not writing empty files anyway.
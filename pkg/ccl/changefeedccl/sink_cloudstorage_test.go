@@ -9,6 +9,7 @@
 package changefeedccl
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io/ioutil"
@@ -18,7 +19,10 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/parquet"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
@@ -58,6 +62,7 @@ func TestCloudStorageSink(t *testing.T) {
 
 	const unlimitedFileSize = math.MaxInt64
 	var noKey []byte
+	var noParquet parquet.WriterOptions
 	settings := cluster.MakeTestingClusterSettings()
 	settings.ExternalIODir = dir
 	opts := map[string]string{
@@ -73,7 +78,8 @@ func TestCloudStorageSink(t *testing.T) {
 		t1 := &sqlbase.TableDescriptor{Name: `t1`}
 
 		sinkDir := `golden`
-		s, err := makeCloudStorageSink(`nodelocal:///`+sinkDir, 1, unlimitedFileSize, settings, opts)
+		s, err := makeCloudStorageSink(
+			`nodelocal:///`+sinkDir, 1, unlimitedFileSize, settings, opts, noParquet)
 		require.NoError(t, err)
 		s.(*cloudStorageSink).sinkID = 7 // Force a deterministic sinkID.
 
@@ -96,7 +102,8 @@ func TestCloudStorageSink(t *testing.T) {
 		t2 := &sqlbase.TableDescriptor{Name: `t2`}
 
 		dir := `single-node`
-		s, err := makeCloudStorageSink(`nodelocal:///`+dir, 1, unlimitedFileSize, settings, opts)
+		s, err := makeCloudStorageSink(
+			`nodelocal:///`+dir, 1, unlimitedFileSize, settings, opts, noParquet)
 		require.NoError(t, err)
 		s.(*cloudStorageSink).sinkID = 7 // Force a deterministic sinkID.
 
@@ -156,9 +163,11 @@ func TestCloudStorageSink(t *testing.T) {
 		t1 := &sqlbase.TableDescriptor{Name: `t1`}
 
 		dir := `multi-node`
-		s1, err := makeCloudStorageSink(`nodelocal:///`+dir, 1, unlimitedFileSize, settings, opts)
+		s1, err := makeCloudStorageSink(
+			`nodelocal:///`+dir, 1, unlimitedFileSize, settings, opts, noParquet)
 		require.NoError(t, err)
-		s2, err := makeCloudStorageSink(`nodelocal:///`+dir, 2, unlimitedFileSize, settings, opts)
+		s2, err := makeCloudStorageSink(
+			`nodelocal:///`+dir, 2, unlimitedFileSize, settings, opts, noParquet)
 		require.NoError(t, err)
 		// Hack into the sinks to pretend each is the first sink created on two
 		// different nodes, which is the worst case for them conflicting.
@@ -181,9 +190,11 @@ func TestCloudStorageSink(t *testing.T) {
 		// this is unavoidable. It may overwrite the old data if the sink id and
 		// file id line up just so, but it's much more likely that they don't.
 		// Either way is fine.
-		s1R, err := makeCloudStorageSink(`nodelocal:///`+dir, 1, unlimitedFileSize, settings, opts)
+		s1R, err := makeCloudStorageSink(
+			`nodelocal:///`+dir, 1, unlimitedFileSize, settings, opts, noParquet)
 		require.NoError(t, err)
-		s2R, err := makeCloudStorageSink(`nodelocal:///`+dir, 2, unlimitedFileSize, settings, opts)
+		s2R, err := makeCloudStorageSink(
+			`nodelocal:///`+dir, 2, unlimitedFileSize, settings, opts, noParquet)
 		require.NoError(t, err)
 		// Nodes restart. s1 gets the same sink id it had last time but s2
 		// doesn't.
@@ -213,10 +224,12 @@ func TestCloudStorageSink(t *testing.T) {
 		t1 := &sqlbase.TableDescriptor{Name: `t1`}
 
 		dir := `zombie`
-		s1, err := makeCloudStorageSink(`nodelocal:///`+dir, 1, unlimitedFileSize, settings, opts)
+		s1, err := makeCloudStorageSink(
+			`nodelocal:///`+dir, 1, unlimitedFileSize, settings, opts, noParquet)
 		require.NoError(t, err)
 		s1.(*cloudStorageSink).sinkID = 7 // Force a deterministic sinkID.
-		s2, err := makeCloudStorageSink(`nodelocal:///`+dir, 1, unlimitedFileSize, settings, opts)
+		s2, err := makeCloudStorageSink(
+			`nodelocal:///`+dir, 1, unlimitedFileSize, settings, opts, noParquet)
 		require.NoError(t, err)
 		s2.(*cloudStorageSink).sinkID = 8 // Force a deterministic sinkID.
 
@@ -245,7 +258,8 @@ func TestCloudStorageSink(t *testing.T) {
 
 		dir := `bucketing`
 		const targetMaxFileSize = 6
-		s, err := makeCloudStorageSink(`nodelocal:///`+dir, 1, targetMaxFileSize, settings, opts)
+		s, err := makeCloudStorageSink(
+			`nodelocal:///`+dir, 1, targetMaxFileSize, settings, opts, noParquet)
 		require.NoError(t, err)
 		s.(*cloudStorageSink).sinkID = 7 // Force a deterministic sinkID.
 
@@ -306,7 +320,8 @@ func TestCloudStorageSink(t *testing.T) {
 		t1 := &sqlbase.TableDescriptor{Name: `t1`}
 
 		dir := `file-ordering`
-		s, err := makeCloudStorageSink(`nodelocal:///`+dir, 1, unlimitedFileSize, settings, opts)
+		s, err := makeCloudStorageSink(
+			`nodelocal:///`+dir, 1, unlimitedFileSize, settings, opts, noParquet)
 		require.NoError(t, err)
 		s.(*cloudStorageSink).sinkID = 7 // Force a deterministic sinkID.
 
@@ -336,4 +351,62 @@ func TestCloudStorageSink(t *testing.T) {
 			`{"resolved":"4.0000000000"}`,
 		}, slurpDir(t, dir))
 	})
+
+	t.Run(`parquet`, func(t *testing.T) {
+		t1 := &sqlbase.TableDescriptor{
+			Name: `t1`,
+			Columns: []sqlbase.ColumnDescriptor{
+				{ID: 1, Name: `a`, Type: *types.Int},
+				{ID: 2, Name: `b`, Type: *types.String, Nullable: true},
+			},
+			PrimaryIndex: sqlbase.IndexDescriptor{ColumnIDs: []sqlbase.ColumnID{1}},
+		}
+		parquetOpts := map[string]string{
+			optFormat:            string(optFormatParquet),
+			optEnvelope:          string(optEnvelopeWrapped),
+			optKeyInValue:        ``,
+			optUpdatedTimestamps: ``,
+		}
+		pe, err := makeParquetEncoder(parquetOpts)
+		require.NoError(t, err)
+		encode := func(deleted bool, datums ...sqlbase.EncDatum) []byte {
+			value, err := pe.EncodeValue(encodeRow{datums: datums, deleted: deleted, tableDesc: t1})
+			require.NoError(t, err)
+			// The encoder reuses its buffer.
+			return append([]byte(nil), value...)
+		}
+		a := func(i int) sqlbase.EncDatum {
+			return sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(tree.DInt(i)))
+		}
+		b := sqlbase.DatumToEncDatum(types.String, tree.NewDString(`bbbbbbbb`))
+
+		sinkDir := `parquet`
+		s, err := makeCloudStorageSink(`nodelocal:///`+sinkDir, 1, unlimitedFileSize, settings,
+			parquetOpts, parquet.WriterOptions{RowGroupSize: 1})
+		require.NoError(t, err)
+		s.(*cloudStorageSink).sinkID = 7 // Force a deterministic sinkID.
+
+		require.NoError(t, s.EmitRow(ctx, t1, noKey, encode(false, a(1), b), ts(1)))
+		require.NoError(t, s.EmitRow(ctx, t1, noKey, encode(true, a(2), sqlbase.EncDatum{}), ts(2)))
+		require.NoError(t, s.Flush(ctx))
+		require.NoError(t, s.EmitResolvedTimestamp(ctx, pe, ts(3)))
+
+		dataFile, err := ioutil.ReadFile(filepath.Join(
+			dir, sinkDir, `1970-01-01`, `197001010000000000000010000000000-t1-0-1-7-0.parquet`))
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(dataFile, []byte(`PAR1`)))
+		require.True(t, bytes.HasSuffix(dataFile, []byte(`PAR1`)))
+		for _, expected := range []string{
+			`bbbbbbbb`, parquetDeletedColumn, parquetUpdatedColumn, `2.0000000000`,
+		} {
+			require.Contains(t, string(dataFile), expected)
+		}
+		require.Equal(t, []string{`{"resolved":"3.0000000000"}`}, slurpDir(t, sinkDir)[1:])
+
+		// Only the cloud storage sink knows what to do with these values.
+		_, err = makeParquetEncoder(map[string]string{
+			optFormat: string(optFormatParquet), optEnvelope: string(optEnvelopeKeyOnly),
+		})
+		require.EqualError(t, err, `envelope=key_only is not supported with format=experimental_parquet`)
+	})
 }
@@ -0,0 +1,241 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	gojson "encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/pkg/errors"
+)
+
+const (
+	webhookSinkDefaultBatchSize = 100
+	webhookSinkRequestTimeout   = 30 * time.Second
+	// webhookSinkMaxErrorBody bounds how much of a non-2xx response body is
+	// included in the returned error.
+	webhookSinkMaxErrorBody = 1 << 10
+)
+
+// webhookSinkDefaultRetryOpts is deliberately short. Once it's exhausted, the
+// error is returned and the changefeed machinery retries from the last
+// checkpoint, so there's no point in blocking here for a long time.
+var webhookSinkDefaultRetryOpts = retry.Options{
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	MaxRetries:     3,
+}
+
+type webhookSinkConfig struct {
+	caCert     []byte
+	clientCert []byte
+	clientKey  []byte
+	authHeader string
+	batchSize  int
+	retryOpts  retry.Options
+}
+
+// webhookMessage is the JSON representation of one row in a webhook sink
+// request. Key and value are the already encoded JSON produced by the encoder.
+type webhookMessage struct {
+	Topic string            `json:"topic"`
+	Key   gojson.RawMessage `json:"key"`
+	Value gojson.RawMessage `json:"value"`
+}
+
+// webhookSink emits to an HTTPS endpoint. It is not concurrency-safe; all
+// calls to Emit and Flush should be from the same goroutine.
+//
+// Rows are buffered and POSTed as a JSON array of webhookMessages once
+// batchSize of them have accumulated or when Flush is called. A request is
+// retried with backoff on network errors and on 5xx or 429 responses; any
+// other non-2xx response is an error. Resolved timestamps are POSTed on their
+// own, as the JSON object produced by the encoder, and only after every row
+// emitted before them has been acknowledged. This means that, as with the
+// other sinks, when a consumer sees a resolved timestamp, it has already seen
+// every row with an updated timestamp less than or equal to it.
+type webhookSink struct {
+	cfg    webhookSinkConfig
+	url    string
+	client *http.Client
+	topics map[string]struct{}
+
+	batch [][]byte
+}
+
+func makeWebhookSink(
+	cfg webhookSinkConfig, url string, opts map[string]string, targets jobspb.ChangefeedTargets,
+) (Sink, error) {
+	switch formatType(opts[optFormat]) {
+	case optFormatJSON:
+	default:
+		return nil, errors.Errorf(`this sink is incompatible with %s=%s`,
+			optFormat, opts[optFormat])
+	}
+
+	tlsConfig := &tls.Config{}
+	if cfg.caCert != nil {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(cfg.caCert) {
+			return nil, errors.Errorf(`param %s does not contain a valid PEM certificate`,
+				sinkParamCACert)
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+	if cfg.clientCert != nil {
+		cert, err := tls.X509KeyPair(cfg.clientCert, cfg.clientKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, `invalid %s or %s`, sinkParamClientCert, sinkParamClientKey)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	s := &webhookSink{
+		cfg: cfg,
+		url: url,
+		client: &http.Client{
+			Timeout:   webhookSinkRequestTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+		topics: make(map[string]struct{}),
+	}
+	for _, t := range targets {
+		s.topics[t.StatementTimeName] = struct{}{}
+	}
+	return s, nil
+}
+
+// EmitRow implements the Sink interface.
+func (s *webhookSink) EmitRow(
+	ctx context.Context, table *sqlbase.TableDescriptor, key, value []byte, _ hlc.Timestamp,
+) error {
+	topic := table.Name
+	if _, ok := s.topics[topic]; !ok {
+		return errors.Errorf(`cannot emit to undeclared topic: %s`, topic)
+	}
+
+	// Marshalling copies key and value, so it's safe for the caller to reuse
+	// them once we return.
+	msg, err := gojson.Marshal(webhookMessage{Topic: topic, Key: key, Value: value})
+	if err != nil {
+		return err
+	}
+	s.batch = append(s.batch, msg)
+	if len(s.batch) >= s.cfg.batchSize {
+		return s.Flush(ctx)
+	}
+	return nil
+}
+
+// EmitResolvedTimestamp implements the Sink interface.
+func (s *webhookSink) EmitResolvedTimestamp(
+	ctx context.Context, encoder Encoder, resolved hlc.Timestamp,
+) error {
+	// Every row buffered so far must be delivered before the resolved timestamp
+	// that covers it.
+	if err := s.Flush(ctx); err != nil {
+		return err
+	}
+	var noTopic string
+	payload, err := encoder.EncodeResolvedTimestamp(noTopic, resolved)
+	if err != nil {
+		return err
+	}
+	return s.sendWithRetries(ctx, payload)
+}
+
+// Flush implements the Sink interface.
+func (s *webhookSink) Flush(ctx context.Context) error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	body.WriteByte('[')
+	for i, msg := range s.batch {
+		if i > 0 {
+			body.WriteByte(',')
+		}
+		body.Write(msg)
+	}
+	body.WriteByte(']')
+	if err := s.sendWithRetries(ctx, body.Bytes()); err != nil {
+		return err
+	}
+	if log.V(2) {
+		log.Infof(ctx, "flushed %d messages to webhook", len(s.batch))
+	}
+	s.batch = s.batch[:0]
+	return nil
+}
+
+func (s *webhookSink) sendWithRetries(ctx context.Context, body []byte) error {
+	var err error
+	for r := retry.StartWithCtx(ctx, s.cfg.retryOpts); r.Next(); {
+		var retryable bool
+		if retryable, err = s.send(ctx, body); err == nil || !retryable {
+			return err
+		}
+		if log.V(1) {
+			log.Infof(ctx, "retrying webhook request: %v", err)
+		}
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// send POSTs one request and returns whether a failure is worth retrying.
+func (s *webhookSink) send(ctx context.Context, body []byte) (retryable bool, _ error) {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set(`Content-Type`, `application/json`)
+	if s.cfg.authHeader != `` {
+		req.Header.Set(`Authorization`, s.cfg.authHeader)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		// Drain the body so the connection can be reused. The messages have
+		// already been acknowledged, so an error here doesn't matter.
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return false, nil
+	}
+	respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, webhookSinkMaxErrorBody))
+	retryable = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retryable, errors.Errorf(`webhook sink responded with %s: %s`, resp.Status, respBody)
+}
+
+// Close implements the Sink interface.
+func (s *webhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,287 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/security/securitytest"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookTestServer is an HTTPS server that requires a client certificate
+// signed by the embedded test CA and records the body of every request it
+// accepts.
+type webhookTestServer struct {
+	*httptest.Server
+
+	mu struct {
+		syncutil.Mutex
+		// statuses, if non-empty, are returned (and popped) instead of 200 OK.
+		statuses []int
+		attempts int
+		bodies   []string
+		auth     []string
+	}
+}
+
+func makeWebhookTestServer(t *testing.T) *webhookTestServer {
+	loadAsset := func(name string) []byte {
+		t.Helper()
+		asset, err := securitytest.Asset(filepath.Join(security.EmbeddedCertsDir, name))
+		require.NoError(t, err)
+		return asset
+	}
+	cert, err := tls.X509KeyPair(
+		loadAsset(security.EmbeddedNodeCert), loadAsset(security.EmbeddedNodeKey))
+	require.NoError(t, err)
+	caCertPool := x509.NewCertPool()
+	require.True(t, caCertPool.AppendCertsFromPEM(loadAsset(security.EmbeddedCACert)))
+
+	s := &webhookTestServer{}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.handle))
+	s.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    caCertPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	s.StartTLS()
+	return s
+}
+
+func (s *webhookTestServer) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mu.attempts++
+	if len(s.mu.statuses) > 0 {
+		status := s.mu.statuses[0]
+		s.mu.statuses = s.mu.statuses[1:]
+		http.Error(w, `nope`, status)
+		return
+	}
+	s.mu.bodies = append(s.mu.bodies, string(body))
+	s.mu.auth = append(s.mu.auth, r.Header.Get(`Authorization`))
+}
+
+// reset returns the bodies received since the last reset along with the
+// number of attempts it took to receive them.
+func (s *webhookTestServer) reset() (bodies []string, attempts int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bodies, attempts = s.mu.bodies, s.mu.attempts
+	s.mu.bodies, s.mu.attempts, s.mu.auth = nil, 0, nil
+	return bodies, attempts
+}
+
+// sinkURI returns a webhook sink URI for the server with the given query
+// parameters. The CA certificate is always included.
+func (s *webhookTestServer) sinkURI(t *testing.T, params url.Values) string {
+	serverURL, err := url.Parse(s.URL)
+	require.NoError(t, err)
+	caCert, err := securitytest.Asset(
+		filepath.Join(security.EmbeddedCertsDir, security.EmbeddedCACert))
+	require.NoError(t, err)
+	params.Set(sinkParamCACert, base64.StdEncoding.EncodeToString(caCert))
+	return (&url.URL{
+		Scheme:   sinkSchemeWebhookHTTPS,
+		Host:     serverURL.Host,
+		Path:     `/changefeed`,
+		RawQuery: params.Encode(),
+	}).String()
+}
+
+func clientCertParams(t *testing.T) url.Values {
+	params := url.Values{}
+	for param, name := range map[string]string{
+		sinkParamClientCert: security.EmbeddedRootCert,
+		sinkParamClientKey:  security.EmbeddedRootKey,
+	} {
+		asset, err := securitytest.Asset(filepath.Join(security.EmbeddedCertsDir, name))
+		require.NoError(t, err)
+		params.Set(param, base64.StdEncoding.EncodeToString(asset))
+	}
+	return params
+}
+
+func TestWebhookSink(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	table := func(name string) *sqlbase.TableDescriptor {
+		return &sqlbase.TableDescriptor{Name: name}
+	}
+
+	ctx := context.Background()
+	srv := makeWebhookTestServer(t)
+	defer srv.Close()
+
+	opts := map[string]string{
+		optFormat:   string(optFormatJSON),
+		optEnvelope: string(optEnvelopeWrapped),
+	}
+	targets := jobspb.ChangefeedTargets{
+		0: jobspb.ChangefeedTarget{StatementTimeName: `foo`},
+		1: jobspb.ChangefeedTarget{StatementTimeName: `bar`},
+	}
+	params := clientCertParams(t)
+	params.Set(sinkParamWebhookAuthHeader, `Bearer hunter2`)
+	params.Set(sinkParamWebhookBatchSize, `2`)
+	s, err := getSink(srv.sinkURI(t, params), 0 /* nodeID */, opts, targets, nil /* settings */)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, s.Close()) }()
+	sink := s.(*webhookSink)
+	sink.cfg.retryOpts = retry.Options{
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		MaxRetries:     2,
+	}
+
+	// Empty
+	require.NoError(t, sink.Flush(ctx))
+	bodies, _ := srv.reset()
+	require.Empty(t, bodies)
+
+	// Undeclared topic
+	require.EqualError(t,
+		sink.EmitRow(ctx, table(`nope`), nil, nil, zeroTS), `cannot emit to undeclared topic: nope`)
+
+	// With one row, nothing is sent until Flush is called.
+	require.NoError(t, sink.EmitRow(ctx, table(`foo`), []byte(`[1]`), []byte(`{"after": 0}`), zeroTS))
+	bodies, _ = srv.reset()
+	require.Empty(t, bodies)
+	require.NoError(t, sink.Flush(ctx))
+	srv.mu.Lock()
+	require.Equal(t, []string{`Bearer hunter2`}, srv.mu.auth)
+	srv.mu.Unlock()
+	bodies, _ = srv.reset()
+	require.Equal(t, []string{`[{"topic":"foo","key":[1],"value":{"after":0}}]`}, bodies)
+
+	// Verify the implicit flushing once the batch is full.
+	require.NoError(t, sink.EmitRow(ctx, table(`foo`), []byte(`[1]`), []byte(`{"after": 1}`), zeroTS))
+	require.NoError(t, sink.EmitRow(ctx, table(`bar`), []byte(`[2]`), []byte(`{"after": 2}`), zeroTS))
+	require.NoError(t, sink.EmitRow(ctx, table(`foo`), []byte(`[1]`), []byte(`{"after": 3}`), zeroTS))
+	bodies, _ = srv.reset()
+	require.Equal(t, []string{
+		`[{"topic":"foo","key":[1],"value":{"after":1}},{"topic":"bar","key":[2],"value":{"after":2}}]`,
+	}, bodies)
+
+	// A resolved timestamp is only sent after every buffered row.
+	encoder, err := getEncoder(opts)
+	require.NoError(t, err)
+	resolved := hlc.Timestamp{WallTime: 4}
+	require.NoError(t, sink.EmitResolvedTimestamp(ctx, encoder, resolved))
+	bodies, _ = srv.reset()
+	require.Equal(t, []string{
+		`[{"topic":"foo","key":[1],"value":{"after":3}}]`,
+		`{"resolved":"4.0000000000"}`,
+	}, bodies)
+
+	// Retryable errors are retried.
+	srv.mu.Lock()
+	srv.mu.statuses = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+	srv.mu.Unlock()
+	require.NoError(t, sink.EmitRow(ctx, table(`foo`), []byte(`[1]`), []byte(`{"after": 5}`), zeroTS))
+	require.NoError(t, sink.Flush(ctx))
+	bodies, attempts := srv.reset()
+	require.Equal(t, []string{`[{"topic":"foo","key":[1],"value":{"after":5}}]`}, bodies)
+	require.Equal(t, 3, attempts)
+
+	// Until they aren't.
+	srv.mu.Lock()
+	srv.mu.statuses = []int{
+		http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError,
+	}
+	srv.mu.Unlock()
+	require.NoError(t, sink.EmitRow(ctx, table(`foo`), []byte(`[1]`), []byte(`{"after": 6}`), zeroTS))
+	require.Regexp(t, `500 Internal Server Error: nope`, sink.Flush(ctx))
+	_, attempts = srv.reset()
+	require.Equal(t, 3, attempts)
+
+	// Other errors are not retried. The failed rows are kept and sent on the
+	// next successful flush.
+	srv.mu.Lock()
+	srv.mu.statuses = []int{http.StatusBadRequest}
+	srv.mu.Unlock()
+	require.Regexp(t, `400 Bad Request: nope`, sink.Flush(ctx))
+	_, attempts = srv.reset()
+	require.Equal(t, 1, attempts)
+	require.NoError(t, sink.Flush(ctx))
+	bodies, _ = srv.reset()
+	require.Equal(t, []string{`[{"topic":"foo","key":[1],"value":{"after":6}}]`}, bodies)
+}
+
+func TestWebhookSinkConfig(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	srv := makeWebhookTestServer(t)
+	defer srv.Close()
+
+	opts := map[string]string{
+		optFormat:   string(optFormatJSON),
+		optEnvelope: string(optEnvelopeWrapped),
+	}
+	targets := jobspb.ChangefeedTargets{0: jobspb.ChangefeedTarget{StatementTimeName: `foo`}}
+	makeSink := func(params url.Values, opts map[string]string) (Sink, error) {
+		return getSink(srv.sinkURI(t, params), 0 /* nodeID */, opts, targets, nil /* settings */)
+	}
+
+	t.Run(`client cert without key`, func(t *testing.T) {
+		params := clientCertParams(t)
+		params.Del(sinkParamClientKey)
+		_, err := makeSink(params, opts)
+		require.EqualError(t, err, `client_cert and client_key must be provided together`)
+	})
+	t.Run(`bad batch size`, func(t *testing.T) {
+		params := clientCertParams(t)
+		params.Set(sinkParamWebhookBatchSize, `0`)
+		_, err := makeSink(params, opts)
+		require.EqualError(t, err, `param webhook_batch_size must be positive: 0`)
+	})
+	t.Run(`unknown param`, func(t *testing.T) {
+		params := clientCertParams(t)
+		params.Set(`nope`, `1`)
+		_, err := makeSink(params, opts)
+		require.EqualError(t, err, `unknown sink query parameter: nope`)
+	})
+	t.Run(`avro`, func(t *testing.T) {
+		avroOpts := map[string]string{optFormat: string(optFormatAvro)}
+		_, err := makeSink(clientCertParams(t), avroOpts)
+		require.EqualError(t, err, `this sink is incompatible with format=experimental_avro`)
+	})
+	t.Run(`missing client cert`, func(t *testing.T) {
+		s, err := makeSink(url.Values{}, opts)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, s.Close()) }()
+		s.(*webhookSink).cfg.retryOpts = retry.Options{MaxRetries: 1}
+		require.NoError(t, s.EmitRow(ctx, &sqlbase.TableDescriptor{Name: `foo`}, nil, nil, zeroTS))
+		require.Regexp(t, `tls`, s.Flush(ctx))
+	})
+}
@@ -58,7 +58,9 @@ func runLoadShow(cmd *cobra.Command, args []string) error {
 			return err
 		}
 	}
-	desc, err := backupccl.ReadBackupDescriptorFromURI(ctx, basepath, cluster.NoSettings)
+	desc, err := backupccl.ReadBackupDescriptorFromURI(
+		ctx, basepath, cluster.NoSettings, nil, /* encryption */
+	)
 	if err != nil {
 		return err
 	}
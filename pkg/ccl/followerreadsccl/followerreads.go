@@ -71,6 +71,11 @@ func checkEnterpriseEnabled(clusterID uuid.UUID, st *cluster.Settings) error {
 	return utilccl.CheckEnterpriseEnabled(st, clusterID, org, "follower reads")
 }
 
+func checkBoundedStalenessEnabled(clusterID uuid.UUID, st *cluster.Settings) error {
+	return utilccl.CheckEnterpriseEnabled(
+		st, clusterID, sql.ClusterOrganization.Get(&st.SV), "bounded staleness reads")
+}
+
 func evalFollowerReadOffset(clusterID uuid.UUID, st *cluster.Settings) (time.Duration, error) {
 	if err := checkEnterpriseEnabled(clusterID, st); err != nil {
 		return 0, err
@@ -102,12 +107,25 @@ func canUseFollowerRead(clusterID uuid.UUID, st *cluster.Settings, ts hlc.Timest
 	return checkEnterpriseEnabled(clusterID, st) == nil
 }
 
+// canUseNearestRead determines if a query whose timestamp was negotiated to be
+// servable by the nearest replicas, i.e. a bounded staleness read, can be sent
+// to a follower. Unlike canUseFollowerRead, it doesn't second-guess the
+// timestamp of the query.
+func canUseNearestRead(clusterID uuid.UUID, st *cluster.Settings) bool {
+	return storage.FollowerReadsEnabled.Get(&st.SV) &&
+		checkEnterpriseEnabled(clusterID, st) == nil
+}
+
 // canSendToFollower implements the logic for checking whether a batch request
 // may be sent to a follower.
 func canSendToFollower(clusterID uuid.UUID, st *cluster.Settings, ba roachpb.BatchRequest) bool {
-	return batchCanBeEvaluatedOnFollower(ba) &&
-		txnCanPerformFollowerRead(ba.Txn) &&
-		canUseFollowerRead(clusterID, st, forward(ba.Txn.OrigTimestamp, ba.Txn.MaxTimestamp))
+	if !batchCanBeEvaluatedOnFollower(ba) || !txnCanPerformFollowerRead(ba.Txn) {
+		return false
+	}
+	if ba.RoutingPolicy == roachpb.NEAREST {
+		return canUseNearestRead(clusterID, st)
+	}
+	return canUseFollowerRead(clusterID, st, forward(ba.Txn.OrigTimestamp, ba.Txn.MaxTimestamp))
 }
 
 func forward(ts hlc.Timestamp, to hlc.Timestamp) hlc.Timestamp {
@@ -133,6 +151,10 @@ func newOracleFactory(cfg replicaoracle.Config) replicaoracle.OracleFactory {
 }
 
 func (f oracleFactory) Oracle(txn *client.Txn) replicaoracle.Oracle {
+	if txn != nil && txn.RoutingPolicy() == roachpb.NEAREST &&
+		canUseNearestRead(f.clusterID.Get(), f.st) {
+		return f.closest.Oracle(txn)
+	}
 	if txn != nil && canUseFollowerRead(f.clusterID.Get(), f.st, txn.OrigTimestamp()) {
 		return f.closest.Oracle(txn)
 	}
@@ -146,5 +168,6 @@ var followerReadAwareChoice = replicaoracle.RegisterPolicy(newOracleFactory)
 func init() {
 	sql.ReplicaOraclePolicy = followerReadAwareChoice
 	builtins.EvalFollowerReadOffset = evalFollowerReadOffset
+	builtins.CheckBoundedStalenessEnabled = checkBoundedStalenessEnabled
 	kv.CanSendToFollower = canSendToFollower
 }
@@ -117,10 +117,35 @@ func TestCanSendToFollower(t *testing.T) {
 	if canSendToFollower(uuid.MakeV4(), st, roNew) {
 		t.Fatalf("should not be able to send a ro batch with new MaxTimestamp to a follower")
 	}
+	roNewNearest := roachpb.BatchRequest{Header: roachpb.Header{
+		Txn: &roachpb.Transaction{
+			OrigTimestamp: hlc.Timestamp{WallTime: timeutil.Now().UnixNano()},
+		},
+		RoutingPolicy: roachpb.NEAREST,
+	}}
+	roNewNearest.Add(&roachpb.GetRequest{})
+	if !canSendToFollower(uuid.MakeV4(), st, roNewNearest) {
+		t.Fatalf("should be able to send a new ro batch routed to the nearest replica to a follower")
+	}
+	rwNearest := roachpb.BatchRequest{Header: roNewNearest.Header}
+	rwNearest.Add(&roachpb.PutRequest{})
+	if canSendToFollower(uuid.MakeV4(), st, rwNearest) {
+		t.Fatalf("should not be able to send a rw batch routed to the nearest replica to a follower")
+	}
+	storage.FollowerReadsEnabled.Override(&st.SV, false)
+	if canSendToFollower(uuid.MakeV4(), st, roNewNearest) {
+		t.Fatalf("should not be able to send a batch routed to the nearest replica to a follower " +
+			"when follower reads are disabled")
+	}
+	storage.FollowerReadsEnabled.Override(&st.SV, true)
 	disableEnterprise()
 	if canSendToFollower(uuid.MakeV4(), st, roOld) {
 		t.Fatalf("should not be able to send an old ro batch to a follower without enterprise enabled")
 	}
+	if canSendToFollower(uuid.MakeV4(), st, roNewNearest) {
+		t.Fatalf("should not be able to send a batch routed to the nearest replica to a follower " +
+			"without enterprise enabled")
+	}
 }
 
 func TestFollowerReadMultipleValidation(t *testing.T) {
@@ -18,9 +18,11 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
 	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlrun"
+	"github.com/cockroachdb/cockroach/pkg/sql/parquet"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowcontainer"
@@ -41,22 +43,35 @@ var exportHeader = sqlbase.ResultColumns{
 }
 
 const (
-	exportOptionDelimiter = "delimiter"
-	exportOptionNullAs    = "nullas"
-	exportOptionChunkSize = "chunk_rows"
-	exportOptionFileName  = "filename"
+	exportOptionDelimiter    = "delimiter"
+	exportOptionNullAs       = "nullas"
+	exportOptionChunkSize    = "chunk_rows"
+	exportOptionFileName     = "filename"
+	exportOptionCompression  = "compression"
+	exportOptionRowGroupSize = "row_group_size"
 )
 
 var exportOptionExpectValues = map[string]sql.KVStringOptValidate{
-	exportOptionChunkSize: sql.KVStringOptRequireValue,
-	exportOptionDelimiter: sql.KVStringOptRequireValue,
-	exportOptionFileName:  sql.KVStringOptRequireValue,
-	exportOptionNullAs:    sql.KVStringOptRequireValue,
+	exportOptionChunkSize:    sql.KVStringOptRequireValue,
+	exportOptionDelimiter:    sql.KVStringOptRequireValue,
+	exportOptionFileName:     sql.KVStringOptRequireValue,
+	exportOptionNullAs:       sql.KVStringOptRequireValue,
+	exportOptionCompression:  sql.KVStringOptRequireValue,
+	exportOptionRowGroupSize: sql.KVStringOptRequireValue,
+}
+
+// exportFormatOptions are the options that only apply to one format.
+var exportFormatOptions = map[string]roachpb.IOFileFormat_FileFormat{
+	exportOptionDelimiter:    roachpb.IOFileFormat_CSV,
+	exportOptionNullAs:       roachpb.IOFileFormat_CSV,
+	exportOptionCompression:  roachpb.IOFileFormat_Parquet,
+	exportOptionRowGroupSize: roachpb.IOFileFormat_Parquet,
 }
 
 const exportChunkSizeDefault = 100000
 const exportFilePatternPart = "%part%"
 const exportFilePatternDefault = exportFilePatternPart + ".csv"
+const exportParquetFilePatternDefault = exportFilePatternPart + ".parquet"
 
 // exportPlanHook implements sql.PlanHook.
 func exportPlanHook(
@@ -72,7 +87,13 @@ func exportPlanHook(
 		return nil, nil, nil, false, err
 	}
 
-	if exportStmt.FileFormat != "CSV" {
+	var format roachpb.IOFileFormat_FileFormat
+	switch exportStmt.FileFormat {
+	case "CSV":
+		format = roachpb.IOFileFormat_CSV
+	case "PARQUET":
+		format = roachpb.IOFileFormat_Parquet
+	default:
 		return nil, nil, nil, false, errors.Errorf("unsupported export format: %q", exportStmt.FileFormat)
 	}
 
@@ -109,10 +130,22 @@ func exportPlanHook(
 			return err
 		}
 
+		if format == roachpb.IOFileFormat_Parquet &&
+			!p.ExecCfg().Settings.Version.IsActive(cluster.VersionExportParquet) {
+			return errors.Errorf("EXPORT INTO PARQUET requires all nodes to be upgraded to %s",
+				cluster.VersionByKey(cluster.VersionExportParquet))
+		}
+
 		opts, err := optsFn()
 		if err != nil {
 			return err
 		}
+		for opt := range opts {
+			if optFormat, ok := exportFormatOptions[opt]; ok && optFormat != format {
+				return pgerror.Newf(pgcode.InvalidParameterValue,
+					"%s is not supported for %s", opt, exportStmt.FileFormat)
+			}
+		}
 
 		csvOpts := roachpb.CSVOptions{}
 
@@ -138,12 +171,35 @@ func exportPlanHook(
 			}
 		}
 
-		out := distsqlpb.ProcessorCoreUnion{CSVWriter: &distsqlpb.CSVWriterSpec{
+		spec := &distsqlpb.CSVWriterSpec{
 			Destination: file,
 			NamePattern: exportFilePatternDefault,
 			Options:     csvOpts,
 			ChunkRows:   int64(chunk),
-		}}
+		}
+		if format == roachpb.IOFileFormat_Parquet {
+			spec.Format = format
+			spec.NamePattern = exportParquetFilePatternDefault
+			if override, ok := opts[exportOptionCompression]; ok {
+				compression, err := parquet.ParseCompression(override)
+				if err != nil {
+					return pgerror.New(pgcode.InvalidParameterValue, err.Error())
+				}
+				spec.ParquetOptions.Compression = roachpb.ParquetOptions_Compression(compression)
+			}
+			if override, ok := opts[exportOptionRowGroupSize]; ok {
+				rowGroupSize, err := strconv.Atoi(override)
+				if err != nil {
+					return pgerror.New(pgcode.InvalidParameterValue, err.Error())
+				}
+				if rowGroupSize < 1 {
+					return pgerror.New(pgcode.InvalidParameterValue, "invalid parquet row group size")
+				}
+				spec.ParquetOptions.RowGroupSize = int64(rowGroupSize)
+			}
+			spec.ColumnNames = exportColumnNames(sql.PlanColumns(plans[0]))
+		}
+		out := distsqlpb.ProcessorCoreUnion{CSVWriter: spec}
 
 		rows := rowcontainer.NewRowContainer(
 			p.ExtendedEvalContext().Mon.MakeBoundAccount(), sqlbase.ColTypeInfoFromColTypes(sql.ExportPlanResultTypes), 0,
@@ -165,6 +221,24 @@ func exportPlanHook(
 	return fn, exportHeader, []sql.PlanNode{sel}, false, nil
 }
 
+// exportColumnNames returns the names of the exported columns, made unique by
+// appending a suffix to repeated names since a Parquet schema can't contain
+// the same name twice.
+func exportColumnNames(cols sqlbase.ResultColumns) []string {
+	names := make([]string, len(cols))
+	seen := make(map[string]int, len(cols))
+	for i, col := range cols {
+		name := col.Name
+		for seen[name] > 0 {
+			name = fmt.Sprintf("%s_%d", col.Name, seen[col.Name])
+			seen[col.Name]++
+		}
+		seen[name]++
+		names[i] = name
+	}
+	return names
+}
+
 func newCSVWriterProcessor(
 	flowCtx *distsqlrun.FlowCtx,
 	processorID int32,
@@ -230,11 +304,29 @@ func (sp *csvWriter) Run(ctx context.Context) {
 
 		csvRow := make([]string, len(typs))
 
+		isParquet := sp.spec.Format == roachpb.IOFileFormat_Parquet
+		parquetOpts := parquet.WriterOptions{
+			RowGroupSize: int(sp.spec.ParquetOptions.RowGroupSize),
+			Compression:  parquet.Compression(sp.spec.ParquetOptions.Compression),
+		}
+		var parquetRow tree.Datums
+		if isParquet {
+			parquetRow = make(tree.Datums, len(typs))
+		}
+
 		chunk := 0
 		done := false
 		for {
 			var rows int64
 			buf.Reset()
+			var parquetWriter *parquet.Writer
+			if isParquet {
+				var err error
+				parquetWriter, err = parquet.NewWriter(&buf, sp.spec.ColumnNames, typs, parquetOpts)
+				if err != nil {
+					return err
+				}
+			}
 			for {
 				if sp.spec.ChunkRows > 0 && rows >= sp.spec.ChunkRows {
 					break
@@ -249,6 +341,19 @@ func (sp *csvWriter) Run(ctx context.Context) {
 				}
 				rows++
 
+				if isParquet {
+					for i, ed := range row {
+						if err := ed.EnsureDecoded(&typs[i], alloc); err != nil {
+							return err
+						}
+						parquetRow[i] = ed.Datum
+					}
+					if err := parquetWriter.AddRow(parquetRow); err != nil {
+						return err
+					}
+					continue
+				}
+
 				for i, ed := range row {
 					if ed.IsNull() {
 						csvRow[i] = nullsAs
@@ -268,7 +373,13 @@ func (sp *csvWriter) Run(ctx context.Context) {
 			if rows < 1 {
 				break
 			}
-			writer.Flush()
+			if isParquet {
+				if err := parquetWriter.Close(); err != nil {
+					return err
+				}
+			} else {
+				writer.Flush()
+			}
 
 			conf, err := storageccl.ExportStorageConfFromURI(sp.spec.Destination)
 			if err != nil {
@@ -219,3 +219,47 @@ func TestExportShow(t *testing.T) {
 		t.Fatalf("expected %q, got %q", expected, got)
 	}
 }
+
+func TestExportParquet(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	dir, cleanupDir := testutils.TempDir(t)
+	defer cleanupDir()
+
+	srv, db, _ := serverutils.StartServer(t, base.TestServerArgs{ExternalIODir: dir})
+	defer srv.Stopper().Stop(context.Background())
+	sqlDB := sqlutils.MakeSQLRunner(db)
+
+	sqlDB.Exec(t, `CREATE TABLE foo (i INT PRIMARY KEY, d DECIMAL(10,2), s STRING, a INT[], j JSONB)`)
+	sqlDB.Exec(t, `INSERT INTO foo VALUES
+		(1, 1.5, 'a', ARRAY[1, NULL], '{"a": 1}'),
+		(2, NULL, NULL, ARRAY[], NULL),
+		(3, -2.25, 'c', NULL, '[]')`)
+
+	rows := sqlDB.QueryStr(t, `EXPORT INTO PARQUET 'nodelocal:///parquet'
+		WITH chunk_rows = '2', row_group_size = '1', compression = 'snappy'
+		FROM SELECT * FROM foo ORDER BY i`)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 files, got %v", rows)
+	}
+	for i, row := range rows {
+		if expected := fmt.Sprintf("n1.%d.parquet", i); row[0] != expected {
+			t.Fatalf("expected %s, got %s", expected, row[0])
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, "parquet", row[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(content), "PAR1") || !strings.HasSuffix(string(content), "PAR1") {
+			t.Fatalf("%s is not a parquet file", row[0])
+		}
+	}
+
+	sqlDB.ExpectErr(t, `delimiter is not supported for PARQUET`,
+		`EXPORT INTO PARQUET 'nodelocal:///parquet' WITH delimiter = '|' FROM SELECT * FROM foo`)
+	sqlDB.ExpectErr(t, `compression is not supported for CSV`,
+		`EXPORT INTO CSV 'nodelocal:///parquet' WITH compression = 'gzip' FROM SELECT * FROM foo`)
+	sqlDB.ExpectErr(t, `unknown parquet compression: lz4`,
+		`EXPORT INTO PARQUET 'nodelocal:///parquet' WITH compression = 'lz4' FROM SELECT * FROM foo`)
+	sqlDB.ExpectErr(t, `unsupported export format: "AVRO"`,
+		`EXPORT INTO AVRO 'nodelocal:///parquet' FROM SELECT * FROM foo`)
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprotectedts"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
@@ -34,11 +35,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/retry"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -60,7 +63,10 @@ const (
 	importOptionOversample = "oversample"
 	importOptionSkipFKs    = "skip_foreign_keys"
 
+	importOptionStrictValidation = "strict_validation"
+
 	importOptionDirectIngest = "experimental_direct_ingestion"
+	importOptionOnline       = "online"
 
 	pgCopyDelimiter = "delimiter"
 	pgCopyNull      = "nullif"
@@ -85,7 +91,10 @@ var importOptionExpectValues = map[string]sql.KVStringOptValidate{
 
 	importOptionSkipFKs: sql.KVStringOptRequireNoValue,
 
+	importOptionStrictValidation: sql.KVStringOptRequireNoValue,
+
 	importOptionDirectIngest: sql.KVStringOptRequireNoValue,
+	importOptionOnline:       sql.KVStringOptRequireNoValue,
 
 	pgMaxRowSize: sql.KVStringOptRequireValue,
 }
@@ -327,6 +336,30 @@ func importPlanHook(
 				maxRowSize = int32(sz)
 			}
 			format.PgDump.MaxRowSize = maxRowSize
+		case "AVRO":
+			telemetry.Count("import.format.avro")
+			format.Format = roachpb.IOFileFormat_Avro
+			_, format.Avro.StrictMode = opts[importOptionStrictValidation]
+		case "JSON":
+			telemetry.Count("import.format.json")
+			format.Format = roachpb.IOFileFormat_JSON
+			_, format.Json.StrictMode = opts[importOptionStrictValidation]
+			maxRowSize := int32(defaultScanBuffer)
+			if override, ok := opts[pgMaxRowSize]; ok {
+				sz, err := humanizeutil.ParseBytes(override)
+				if err != nil {
+					return err
+				}
+				if sz < 1 || sz > math.MaxInt32 {
+					return errors.Errorf("%s out of range: %d", pgMaxRowSize, sz)
+				}
+				maxRowSize = int32(sz)
+			}
+			format.Json.MaxRowSize = maxRowSize
+		case "PARQUET":
+			telemetry.Count("import.format.parquet")
+			format.Format = roachpb.IOFileFormat_Parquet
+			_, format.Parquet.StrictMode = opts[importOptionStrictValidation]
 		default:
 			return unimplemented.Newf("import.format", "unsupported import format: %q", importStmt.FileFormat)
 		}
@@ -371,6 +404,19 @@ func importPlanHook(
 		}
 
 		_, ingestDirectly := opts[importOptionDirectIngest]
+		_, online := opts[importOptionOnline]
+		if online {
+			if !importStmt.Into {
+				return errors.Errorf("%q can only be used with IMPORT INTO", importOptionOnline)
+			}
+			if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionOnlineImport) {
+				return errors.Errorf("Using %q requires all nodes to be upgraded to %s",
+					importOptionOnline, cluster.VersionByKey(cluster.VersionOnlineImport))
+			}
+			// Online imports ingest the data as it is read, so that each key is
+			// checked against the existing data of the table when it's ingested.
+			ingestDirectly = true
+		}
 		if ingestDirectly {
 			if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionDirectImport) {
 				return errors.Errorf("Using %q requires all nodes to be upgraded to %s",
@@ -409,16 +455,25 @@ func importPlanHook(
 			if len(found.Mutations) > 0 {
 				return errors.Errorf("cannot IMPORT INTO a table with schema changes in progress -- try again later (pending mutation %s)", found.Mutations[0].String())
 			}
+			if found.OnlineImport {
+				return errors.Errorf("table %q is already being imported into", found.Name)
+			}
 			if err := p.CheckPrivilege(ctx, found, privilege.CREATE); err != nil {
 				return err
 			}
-			// TODO(dt): Ensure no other schema changes can start during ingest.
 			importing := found.TableDescriptor
 			importing.Version++
-			// Take the table offline for import.
-			// TODO(dt): audit everywhere we get table descs (leases or otherwise) to
-			// ensure that filtering by state handles IMPORTING correctly.
-			importing.State = sqlbase.TableDescriptor_IMPORTING
+			if online {
+				// Keep the table online, but prevent any change to its descriptor
+				// until the import is done.
+				importing.OnlineImport = true
+			} else {
+				// TODO(dt): Ensure no other schema changes can start during ingest.
+				// Take the table offline for import.
+				// TODO(dt): audit everywhere we get table descs (leases or otherwise) to
+				// ensure that filtering by state handles IMPORTING correctly.
+				importing.State = sqlbase.TableDescriptor_IMPORTING
+			}
 			// TODO(dt): de-validate all the FKs.
 
 			if err := p.ExecCfg().DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
@@ -572,7 +627,9 @@ func importPlanHook(
 				// Write the new TableDescriptors and flip the namespace entries over to
 				// them. After this call, any queries on a table will be served by the newly
 				// imported data.
-				if err := backupccl.WriteTableDescs(ctx, txn, nil, tableDescs, p.User(), p.ExecCfg().Settings, seqValKVs); err != nil {
+				if err := backupccl.WriteTableDescs(
+					ctx, txn, nil, tableDescs, nil, p.User(), p.ExecCfg().Settings, seqValKVs,
+				); err != nil {
 					return errors.Wrapf(err, "creating tables")
 				}
 
@@ -596,21 +653,25 @@ func importPlanHook(
 
 		telemetry.CountBucketed("import.files", int64(len(files)))
 
+		details := jobspb.ImportDetails{
+			URIs:           files,
+			Format:         format,
+			ParentID:       parentID,
+			Tables:         tableDetails,
+			SSTSize:        sstSize,
+			Oversample:     oversample,
+			Walltime:       walltime,
+			SkipFKs:        skipFKs,
+			IngestDirectly: ingestDirectly,
+			Online:         online,
+		}
+		onCreate := protectTimestampOnCreate(p, &details)
 		_, errCh, err := p.ExecCfg().JobRegistry.StartJob(ctx, resultsCh, jobs.Record{
 			Description: jobDesc,
 			Username:    p.User(),
-			Details: jobspb.ImportDetails{
-				URIs:           files,
-				Format:         format,
-				ParentID:       parentID,
-				Tables:         tableDetails,
-				SSTSize:        sstSize,
-				Oversample:     oversample,
-				Walltime:       walltime,
-				SkipFKs:        skipFKs,
-				IngestDirectly: ingestDirectly,
-			},
-			Progress: jobspb.ImportProgress{},
+			Details:     details,
+			Progress:    jobspb.ImportProgress{},
+			OnCreate:    onCreate,
 		})
 		if err != nil {
 			return err
@@ -632,9 +693,10 @@ func doDistributedCSVTransform(
 	sstSize int64,
 	oversample int64,
 	ingestDirectly bool,
+	online bool,
 ) (roachpb.BulkOpSummary, error) {
 	if ingestDirectly {
-		return sql.DistIngest(ctx, p, job, tables, files, format, walltime)
+		return sql.DistIngest(ctx, p, job, tables, files, format, walltime, online)
 		// TODO(dt): check for errors in job records as is done below.
 	}
 
@@ -732,6 +794,7 @@ func (r *importResumer) Resume(
 	format := details.Format
 	oversample := details.Oversample
 	ingestDirectly := details.IngestDirectly
+	online := details.Online
 
 	if sstSize == 0 {
 		// The distributed importer will correctly chunk up large ranges into
@@ -771,6 +834,12 @@ func (r *importResumer) Resume(
 		}
 	}
 
+	if err := jobsprotectedts.Verify(
+		ctx, r.job.ProtectedTimestamps(), details.ProtectedTimestampRecord,
+	); err != nil {
+		return err
+	}
+
 	{
 		// Disable merging for the table IDs being imported into. We don't want the
 		// merge queue undoing the splits performed during IMPORT.
@@ -785,6 +854,7 @@ func (r *importResumer) Resume(
 
 	res, err := doDistributedCSVTransform(
 		ctx, r.job, files, p, parentID, tables, format, walltime, sstSize, oversample, ingestDirectly,
+		online,
 	)
 	if err != nil {
 		return err
@@ -794,12 +864,59 @@ func (r *importResumer) Resume(
 	return nil
 }
 
+// protectTimestampOnCreate returns a jobs.Record OnCreate hook which writes a
+// protected timestamp record, keeping the data in the existing tables being
+// imported into, as of just before the import, from being garbage collected
+// while the import runs, in the transaction which creates the job. It stores
+// the ID of the record in details. It returns nil if protected timestamps are
+// not available or if all of the tables are new.
+func protectTimestampOnCreate(
+	p sql.PlanHookState, details *jobspb.ImportDetails,
+) func(context.Context, *client.Txn, int64) error {
+	pts := p.ExecCfg().JobRegistry.ProtectedTimestamps()
+	if pts == nil || details.Walltime == 0 ||
+		!p.ExecCfg().Settings.Version.IsActive(cluster.VersionProtectedTimestamps) {
+		return nil
+	}
+	var spans []roachpb.Span
+	for _, tbl := range details.Tables {
+		if !tbl.IsNew {
+			spans = append(spans, tbl.Desc.TableSpan())
+		}
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+	id := uuid.MakeV4()
+	ts := hlc.Timestamp{WallTime: details.Walltime}.Prev()
+	details.ProtectedTimestampRecord = &id
+	return func(ctx context.Context, txn *client.Txn, jobID int64) error {
+		return pts.Protect(ctx, txn, jobsprotectedts.MakeRecord(id, jobID, ts, spans))
+	}
+}
+
+// releaseProtectedTimestamp releases the job's protected timestamp record, if
+// any.
+func (r *importResumer) releaseProtectedTimestamp(ctx context.Context, txn *client.Txn) error {
+	details := r.job.Details().(jobspb.ImportDetails)
+	return jobsprotectedts.Release(ctx, txn, r.job.ProtectedTimestamps(), details.ProtectedTimestampRecord)
+}
+
 // OnFailOrCancel is part of the jobs.Resumer interface. Removes data that has
 // been committed from a import that has failed or been canceled. It does this
 // by adding the table descriptors in DROP state, which causes the schema change
-// stuff to delete the keys in the background.
+// stuff to delete the keys in the background. The keys ingested by an online
+// import into an existing table are also deleted by the schema changer, see
+// SchemaChanger.maybeRollbackOnlineImport.
 func (r *importResumer) OnFailOrCancel(ctx context.Context, txn *client.Txn) error {
 	details := r.job.Details().(jobspb.ImportDetails)
+	// The rollback of an online import needs the versions below the import's
+	// timestamp, so it releases the protected timestamp itself once it's done.
+	if !details.Online {
+		if err := r.releaseProtectedTimestamp(ctx, txn); err != nil {
+			return err
+		}
+	}
 
 	// Needed to trigger the schema change manager.
 	if err := txn.SetSystemConfigTrigger(); err != nil {
@@ -819,6 +936,11 @@ func (r *importResumer) OnFailOrCancel(ctx context.Context, txn *client.Txn) err
 			// and so we don't need to preserve MVCC semantics.
 			tableDesc.DropTime = 1
 			b.CPut(sqlbase.MakeNameMetadataKey(tableDesc.ParentID, tableDesc.Name), nil, tableDesc.ID)
+		} else if details.Online {
+			// The table stayed online, so rather than reverting it, only the keys
+			// ingested into it are deleted, outside of this transaction. The table
+			// stays locked until then.
+			tableDesc.OnlineImportRollbackJob = *r.job.ID()
 		} else {
 			// IMPORT did not create this table, so we should not drop it.
 			// TODO(dt): consider trying to delete whatever was ingested before
@@ -838,6 +960,9 @@ func (r *importResumer) OnFailOrCancel(ctx context.Context, txn *client.Txn) err
 func (r *importResumer) OnSuccess(ctx context.Context, txn *client.Txn) error {
 	log.Event(ctx, "making tables live")
 	details := r.job.Details().(jobspb.ImportDetails)
+	if err := r.releaseProtectedTimestamp(ctx, txn); err != nil {
+		return err
+	}
 
 	// Needed to trigger the schema change manager.
 	if err := txn.SetSystemConfigTrigger(); err != nil {
@@ -848,6 +973,7 @@ func (r *importResumer) OnSuccess(ctx context.Context, txn *client.Txn) error {
 		tableDesc := *tbl.Desc
 		tableDesc.Version++
 		tableDesc.State = sqlbase.TableDescriptor_PUBLIC
+		tableDesc.OnlineImport = false
 		// TODO(dt): re-validate any FKs?
 		b.CPut(sqlbase.MakeDescMetadataKey(tableDesc.ID), sqlbase.WrapDescriptor(&tableDesc), sqlbase.WrapDescriptor(tbl.Desc))
 	}
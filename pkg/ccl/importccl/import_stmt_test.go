@@ -26,6 +26,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cockroachdb/apd"
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
@@ -39,6 +40,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/jobutils"
@@ -49,6 +51,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 )
@@ -616,6 +619,164 @@ COPY t (a, b, c) FROM stdin;
 			}(),
 		},
 
+		// JSON
+		{
+			name: "json coercion",
+			create: `
+				i int8 primary key, s string, d decimal(10,2), ts timestamp, b bool, j jsonb, a int8[]
+			`,
+			typ: "JSON",
+			data: `{"i": 1, "s": "a", "d": 12.5, "ts": "2019-01-02 03:04:05", "b": true, "j": {"k": [1, 2]}, "a": [1, 2]}
+{"i": 2, "S": "b", "extra": 3}
+
+{"i": 3, "s": null, "d": "7", "b": "false", "j": "x", "a": null}`,
+			query: map[string][][]string{
+				`SELECT i, s, d, ts::string, b, j::string, a::string FROM t ORDER BY i`: {
+					{"1", "a", "12.50", "2019-01-02 03:04:05+00:00", "true", `{"k": [1, 2]}`, "{1,2}"},
+					{"2", "b", "NULL", "NULL", "NULL", "NULL", "NULL"},
+					{"3", "NULL", "7.00", "NULL", "false", `"x"`, "NULL"},
+				},
+			},
+		},
+		{
+			name:   "json strict",
+			create: `i int8`,
+			with:   `WITH strict_validation`,
+			typ:    "JSON",
+			data:   `{"i": 1, "x": 2}`,
+			err:    `row 1: field "x" does not match any column of table t`,
+		},
+		{
+			name:   "json not an object",
+			create: `i int8`,
+			typ:    "JSON",
+			data:   `[1]`,
+			err:    "row 1: decoding JSON object",
+		},
+		{
+			name:   "json several objects",
+			create: `i int8`,
+			typ:    "JSON",
+			data:   `{"i": 1} {"i": 2}`,
+			err:    "row 1: expected a single JSON object per line",
+		},
+		{
+			name:   "json bad value",
+			create: `i int8`,
+			typ:    "JSON",
+			data:   "{\"i\": 1}\n{\"i\": \"abc\"}",
+			err:    `row 2: converting "i" to INT8`,
+		},
+
+		// Avro
+		{
+			name: "avro logical types",
+			create: `
+				id int8 primary key, name string, amount decimal(10,2), ts timestamp, tags string[]
+			`,
+			typ: "AVRO",
+			data: makeAvroTestData(t, testAvroSchema,
+				map[string]interface{}{
+					"id":     int64(1),
+					"name":   map[string]interface{}{"string": "a"},
+					"amount": []byte{0x30, 0x39},
+					"ts":     int64(1546398245000000),
+					"tags":   []interface{}{"x", "y"},
+					"extra":  int64(7),
+				},
+				map[string]interface{}{
+					"id":     int64(2),
+					"name":   nil,
+					"amount": []byte{0xff},
+					"ts":     int64(0),
+					"tags":   []interface{}{},
+					"extra":  int64(8),
+				},
+			),
+			query: map[string][][]string{
+				`SELECT id, name, amount, ts::string, tags::string FROM t ORDER BY id`: {
+					{"1", "a", "123.45", "2019-01-02 03:04:05+00:00", "{x,y}"},
+					{"2", "NULL", "-0.01", "1970-01-01 00:00:00+00:00", "{}"},
+				},
+			},
+		},
+		{
+			name:   "avro strict",
+			create: `id int8`,
+			with:   `WITH strict_validation`,
+			typ:    "AVRO",
+			data: makeAvroTestData(t, `{"type": "record", "name": "r", "fields": [
+				{"name": "id", "type": "long"}, {"name": "extra", "type": "long"}
+			]}`, map[string]interface{}{"id": int64(1), "extra": int64(2)}),
+			err: `row 1: field "extra" does not match any column of table t`,
+		},
+		{
+			name:   "avro not a record",
+			create: `id int8`,
+			typ:    "AVRO",
+			data:   makeAvroTestData(t, `"long"`, int64(1)),
+			err:    "expected Avro file with a record schema",
+		},
+		{
+			name:   "avro garbage",
+			create: `id int8`,
+			typ:    "AVRO",
+			data:   "not avro",
+			err:    "reading Avro file",
+		},
+
+		// Parquet
+		{
+			name: "parquet",
+			create: `
+				id int8 primary key, name string, amount decimal(10,2), ts timestamp, tags string[]
+			`,
+			typ: "PARQUET",
+			data: makeParquetTestData(t,
+				[]string{"id", "Name", "amount", "ts", "tags", "extra"},
+				[]types.T{
+					*types.Int, *types.String, *types.MakeDecimal(10, 2), *types.Timestamp,
+					*types.MakeArray(types.String), *types.Int,
+				},
+				tree.Datums{
+					tree.NewDInt(1), tree.NewDString("a"), &tree.DDecimal{Decimal: *apd.New(12345, -2)},
+					tree.MakeDTimestamp(timeutil.Unix(1546398245, 0), time.Microsecond),
+					&tree.DArray{
+						ParamTyp:    types.String,
+						Array:       tree.Datums{tree.NewDString("x"), tree.DNull},
+						HasNulls:    true,
+						HasNonNulls: true,
+					},
+					tree.NewDInt(7),
+				},
+				tree.Datums{
+					tree.NewDInt(2), tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull,
+				},
+			),
+			query: map[string][][]string{
+				`SELECT id, name, amount, ts::string, tags::string FROM t ORDER BY id`: {
+					{"1", "a", "123.45", "2019-01-02 03:04:05+00:00", "{x,NULL}"},
+					{"2", "NULL", "NULL", "NULL", "NULL"},
+				},
+			},
+		},
+		{
+			name:   "parquet strict",
+			create: `id int8`,
+			with:   `WITH strict_validation`,
+			typ:    "PARQUET",
+			data: makeParquetTestData(t, []string{"id", "extra"}, []types.T{*types.Int, *types.Int},
+				tree.Datums{tree.NewDInt(1), tree.NewDInt(2)}),
+			err: `field "extra" does not match any column of table t`,
+		},
+		{
+			name:   "parquet garbage",
+			create: `id int8`,
+			typ:    "PARQUET",
+			data:   "not parquet",
+			err:    "reading Parquet file",
+		},
+
 		// Error
 		{
 			name:   "unsupported import format",
@@ -686,6 +847,19 @@ COPY t (a, b, c) FROM stdin;
 	})
 }
 
+const testAvroSchema = `{
+	"type": "record",
+	"name": "row",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "name", "type": ["null", "string"]},
+		{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+		{"name": "ts", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "extra", "type": "long"}
+	]
+}`
+
 const (
 	testPgdumpCreateCities = `CREATE TABLE cities (
 	city VARCHAR(80) NOT NULL,
@@ -1928,6 +2102,98 @@ func TestImportMVCCChecksums(t *testing.T) {
 	sqlDB.Exec(t, `UPDATE d.t SET c = 2 WHERE a = 1`)
 }
 
+func TestImportIntoOnline(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, db, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	ctx := context.Background()
+	defer s.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+
+	files := map[string]string{
+		"/new":        "3,30\n4,40\n",
+		"/primary":    "5,50\n1,11\n",
+		"/secondary":  "6,60\n7,10\n",
+		"/identical":  "1,10\n8,80\n",
+		"/duplicates": "9,90\n9,91\n",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, files[r.URL.Path])
+		}
+	}))
+	defer srv.Close()
+
+	sqlDB.Exec(t, `CREATE DATABASE d`)
+	sqlDB.Exec(t, `CREATE TABLE d.t (a INT8 PRIMARY KEY, b INT8, UNIQUE INDEX (b))`)
+	sqlDB.Exec(t, `INSERT INTO d.t VALUES (1, 10), (2, 20)`)
+
+	const stmt = `IMPORT INTO d.t (a, b) CSV DATA ($1) WITH online`
+	expectRows := func(expected [][]string) {
+		t.Helper()
+		sqlDB.CheckQueryResults(t, `SELECT a, b FROM d.t@primary ORDER BY a`, expected)
+		sqlDB.CheckQueryResults(t, `SELECT a, b FROM d.t@t_b_key ORDER BY a`, expected)
+	}
+	// A failed import deletes the rows it imported in the background, and the
+	// table stays locked until then.
+	waitForRollback := func() {
+		t.Helper()
+		testutils.SucceedsSoon(t, func() error {
+			if desc := sqlbase.GetTableDescriptor(kvDB, "d", "t"); desc.OnlineImport {
+				return errors.Errorf("table %q is still being imported into", desc.Name)
+			}
+			return nil
+		})
+	}
+
+	t.Run("new rows", func(t *testing.T) {
+		var before string
+		sqlDB.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&before)
+		sqlDB.Exec(t, stmt, srv.URL+"/new")
+		expectRows([][]string{{"1", "10"}, {"2", "20"}, {"3", "30"}, {"4", "40"}})
+		// The rows are written above the reads that were served before.
+		sqlDB.CheckQueryResults(t,
+			fmt.Sprintf(`SELECT a, b FROM d.t AS OF SYSTEM TIME %s ORDER BY a`, before),
+			[][]string{{"1", "10"}, {"2", "20"}},
+		)
+	})
+
+	// Rows that collide with existing rows or index entries fail the import,
+	// which deletes the rows it imported, but none of the existing ones.
+	t.Run("primary key collision", func(t *testing.T) {
+		sqlDB.ExpectErr(t, "ingested key collides with an existing one", stmt, srv.URL+"/primary")
+		waitForRollback()
+		expectRows([][]string{{"1", "10"}, {"2", "20"}, {"3", "30"}, {"4", "40"}})
+	})
+	t.Run("unique index collision", func(t *testing.T) {
+		sqlDB.ExpectErr(t, "ingested key collides with an existing one", stmt, srv.URL+"/secondary")
+		waitForRollback()
+		expectRows([][]string{{"1", "10"}, {"2", "20"}, {"3", "30"}, {"4", "40"}})
+	})
+	t.Run("duplicate rows", func(t *testing.T) {
+		sqlDB.ExpectErr(t, "duplicate key", stmt, srv.URL+"/duplicates")
+		waitForRollback()
+		expectRows([][]string{{"1", "10"}, {"2", "20"}, {"3", "30"}, {"4", "40"}})
+	})
+
+	// Rows identical to existing ones don't collide.
+	t.Run("identical rows", func(t *testing.T) {
+		sqlDB.Exec(t, stmt, srv.URL+"/identical")
+		expectRows([][]string{{"1", "10"}, {"2", "20"}, {"3", "30"}, {"4", "40"}, {"8", "80"}})
+	})
+
+	t.Run("schema changes", func(t *testing.T) {
+		// The table can be changed again once the imports are done.
+		sqlDB.Exec(t, `ALTER TABLE d.t ADD COLUMN c INT8`)
+		sqlDB.Exec(t, `ALTER TABLE d.t DROP COLUMN c`)
+	})
+
+	t.Run("requires IMPORT INTO", func(t *testing.T) {
+		sqlDB.ExpectErr(t, `"online" can only be used with IMPORT INTO`,
+			`IMPORT TABLE d.u (a INT8 PRIMARY KEY, b INT8) CSV DATA ($1) WITH online`, srv.URL+"/new")
+	})
+}
+
 func TestImportMysql(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
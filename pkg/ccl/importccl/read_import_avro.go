@@ -0,0 +1,344 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package importccl
+
+import (
+	"bufio"
+	"context"
+	gojson "encoding/json"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/cockroachdb/errors"
+	"github.com/linkedin/goavro"
+)
+
+// avroInputReader reads Avro object container files, whose schema must be a
+// record. Each field of the records is imported into the column of the same
+// name.
+type avroInputReader struct {
+	conv *recordConverter
+	opts roachpb.AvroOptions
+}
+
+var _ inputConverter = &avroInputReader{}
+
+func newAvroInputReader(
+	kvCh chan []roachpb.KeyValue,
+	opts roachpb.AvroOptions,
+	tableDesc *sqlbase.TableDescriptor,
+	evalCtx *tree.EvalContext,
+) (*avroInputReader, error) {
+	conv, err := newRecordConverter(kvCh, tableDesc, evalCtx, opts.StrictMode)
+	if err != nil {
+		return nil, err
+	}
+	conv.parseJSONStrings = true
+	return &avroInputReader{
+		conv: conv,
+		opts: opts,
+	}, nil
+}
+
+func (a *avroInputReader) start(group ctxgroup.Group) {
+}
+
+func (a *avroInputReader) inputFinished(ctx context.Context) {
+	close(a.conv.conv.kvCh)
+}
+
+func (a *avroInputReader) readFiles(
+	ctx context.Context,
+	dataFiles map[int32]string,
+	format roachpb.IOFileFormat,
+	progressFn func(float32) error,
+	settings *cluster.Settings,
+) error {
+	return readInputFiles(ctx, dataFiles, format, a.readFile, progressFn, settings)
+}
+
+func (a *avroInputReader) readFile(
+	ctx context.Context, input io.Reader, inputIdx int32, inputName string, progressFn progressFn,
+) error {
+	ocf, err := goavro.NewOCFReader(bufio.NewReader(input))
+	if err != nil {
+		return pgerror.Wrap(err, pgcode.Syntax, "reading Avro file")
+	}
+	schema, err := parseAvroSchema(ocf.Codec().Schema())
+	if err != nil {
+		return err
+	}
+	if schema.typ != "record" {
+		return pgerror.Newf(pgcode.Syntax,
+			"expected Avro file with a record schema, found %s", schema.typ)
+	}
+
+	count := int64(1)
+	for ; ocf.Scan(); count++ {
+		datum, err := ocf.Read()
+		if err != nil {
+			return wrapRowErr(err, inputName, count, pgcode.Syntax, "decoding Avro record")
+		}
+		record, ok := schema.native(datum).(map[string]interface{})
+		if !ok {
+			return makeRowErr(inputName, count, pgcode.Syntax,
+				"expected Avro record, found %T", datum)
+		}
+		if err := a.conv.convertRecord(ctx, inputIdx, count, record); err != nil {
+			return wrapRowErr(err, inputName, count, pgcode.Uncategorized, "")
+		}
+	}
+	if err := ocf.Err(); err != nil {
+		return wrapRowErr(err, inputName, count, pgcode.Syntax, "decoding Avro record")
+	}
+	return a.conv.conv.sendBatch(ctx)
+}
+
+// avroSchema is the part of an Avro schema needed to turn the values decoded
+// by goavro into the values understood by recordConverter: unions are unwrapped
+// and logical types, which goavro decodes as their underlying type, are
+// converted.
+type avroSchema struct {
+	// typ is the name of a primitive type or one of record, enum, array, map,
+	// fixed and union.
+	typ string
+	// name is the full name of a named type (record, enum or fixed).
+	name    string
+	logical string
+	scale   int32
+	// fields holds the fields of a record.
+	fields map[string]*avroSchema
+	// items holds the items of an array or the values of a map.
+	items *avroSchema
+	// branches holds the branches of a union, keyed the way goavro keys them
+	// when decoding: by the name of the type.
+	branches map[string]*avroSchema
+}
+
+// parseAvroSchema parses the JSON form of an Avro schema.
+func parseAvroSchema(schemaJSON string) (*avroSchema, error) {
+	var raw interface{}
+	if err := gojson.Unmarshal([]byte(schemaJSON), &raw); err != nil {
+		return nil, errors.Wrap(err, "parsing Avro schema")
+	}
+	p := avroSchemaParser{named: make(map[string]*avroSchema)}
+	return p.parse(raw, "" /* namespace */)
+}
+
+type avroSchemaParser struct {
+	// named holds the named types (records, enums and fixeds) by full name.
+	named map[string]*avroSchema
+}
+
+func (p *avroSchemaParser) parse(raw interface{}, namespace string) (*avroSchema, error) {
+	switch raw := raw.(type) {
+	case string:
+		if s, ok := p.named[raw]; ok {
+			return s, nil
+		}
+		if s, ok := p.named[namespace+"."+raw]; ok {
+			return s, nil
+		}
+		return &avroSchema{typ: raw}, nil
+	case []interface{}:
+		s := &avroSchema{typ: "union", branches: make(map[string]*avroSchema, len(raw))}
+		for _, b := range raw {
+			branch, err := p.parse(b, namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.branches[avroUnionKey(b, branch)] = branch
+			if branch.logical != "" {
+				// Decoders that understand logical types key them by both names.
+				s.branches[branch.typ+"."+branch.logical] = branch
+			}
+		}
+		return s, nil
+	case map[string]interface{}:
+		typ, _ := raw["type"].(string)
+		s := &avroSchema{typ: typ}
+		if typ != "record" && typ != "enum" && typ != "fixed" && typ != "array" && typ != "map" {
+			// A primitive, possibly annotated with a logical type, or a nested
+			// schema such as {"type": {"type": "array", ...}}.
+			if typ == "" {
+				return p.parse(raw["type"], namespace)
+			}
+			inner, err := p.parse(typ, namespace)
+			if err != nil {
+				return nil, err
+			}
+			if inner.typ != typ {
+				// A reference to a named type.
+				return inner, nil
+			}
+		}
+		s.logical, _ = raw["logicalType"].(string)
+		if scale, ok := raw["scale"].(float64); ok {
+			s.scale = int32(scale)
+		}
+
+		switch typ {
+		case "record", "enum", "fixed":
+			s.name = avroFullName(raw, namespace)
+			p.named[s.name] = s
+			if i := strings.LastIndexByte(s.name, '.'); i >= 0 {
+				namespace = s.name[:i]
+			}
+		}
+		switch typ {
+		case "record":
+			fields, _ := raw["fields"].([]interface{})
+			s.fields = make(map[string]*avroSchema, len(fields))
+			for _, f := range fields {
+				field, ok := f.(map[string]interface{})
+				if !ok {
+					return nil, errors.Errorf("invalid Avro record field: %v", f)
+				}
+				name, _ := field["name"].(string)
+				fs, err := p.parse(field["type"], namespace)
+				if err != nil {
+					return nil, err
+				}
+				s.fields[name] = fs
+			}
+		case "array":
+			items, err := p.parse(raw["items"], namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.items = items
+		case "map":
+			values, err := p.parse(raw["values"], namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.items = values
+		}
+		return s, nil
+	}
+	return nil, errors.Errorf("invalid Avro schema: %v", raw)
+}
+
+// avroFullName returns the full name of a named type defined in the given
+// namespace.
+func avroFullName(raw map[string]interface{}, namespace string) string {
+	name, _ := raw["name"].(string)
+	if strings.ContainsRune(name, '.') {
+		return name
+	}
+	if ns, ok := raw["namespace"].(string); ok {
+		namespace = ns
+	}
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// avroUnionKey returns the key of a union branch in the values decoded by goavro.
+func avroUnionKey(raw interface{}, branch *avroSchema) string {
+	if branch.name != "" {
+		return branch.name
+	}
+	if s, ok := raw.(string); ok {
+		return s
+	}
+	return branch.typ
+}
+
+// native converts a value decoded by goavro with this schema.
+func (s *avroSchema) native(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch s.typ {
+	case "union":
+		if m, ok := v.(map[string]interface{}); ok && len(m) == 1 {
+			for k, inner := range m {
+				if b, ok := s.branches[k]; ok {
+					return b.native(inner)
+				}
+				return inner
+			}
+		}
+	case "record":
+		if m, ok := v.(map[string]interface{}); ok {
+			for k, fv := range m {
+				if f, ok := s.fields[k]; ok {
+					m[k] = f.native(fv)
+				}
+			}
+		}
+	case "map":
+		if m, ok := v.(map[string]interface{}); ok {
+			for k, mv := range m {
+				m[k] = s.items.native(mv)
+			}
+		}
+	case "array":
+		if a, ok := v.([]interface{}); ok {
+			for i := range a {
+				a[i] = s.items.native(a[i])
+			}
+		}
+	case "int", "long":
+		var i int64
+		switch v := v.(type) {
+		case int32:
+			i = int64(v)
+		case int64:
+			i = v
+		default:
+			return v
+		}
+		switch s.logical {
+		case "date":
+			return time.Unix(i*24*60*60, 0).UTC()
+		case "time-millis":
+			return timeofday.FromInt(i * 1000)
+		case "time-micros":
+			return timeofday.FromInt(i)
+		case "timestamp-millis":
+			return time.Unix(i/1e3, i%1e3*1e6).UTC()
+		case "timestamp-micros":
+			return time.Unix(i/1e6, i%1e6*1e3).UTC()
+		}
+	case "bytes", "fixed":
+		if b, ok := v.([]byte); ok && s.logical == "decimal" {
+			return decimalFromTwosComplement(b, s.scale)
+		}
+	}
+	return v
+}
+
+// decimalFromTwosComplement returns the decimal whose unscaled value has the
+// given big-endian two's complement encoding.
+func decimalFromTwosComplement(b []byte, scale int32) *apd.Decimal {
+	var d apd.Decimal
+	d.Coeff.SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		var max big.Int
+		max.Lsh(big.NewInt(1), uint(len(b)*8))
+		d.Coeff.Sub(&max, &d.Coeff)
+		d.Negative = true
+	}
+	d.Exponent = -scale
+	return &d
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package importccl
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+)
+
+func TestAvroSchemaNative(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const schemaJSON = `{
+		"type": "record",
+		"name": "r",
+		"namespace": "ns",
+		"fields": [
+			{"name": "d", "type": {"type": "int", "logicalType": "date"}},
+			{"name": "t", "type": {"type": "int", "logicalType": "time-millis"}},
+			{"name": "ts", "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}]},
+			{"name": "dec", "type": {
+				"type": "fixed", "name": "money", "size": 2, "logicalType": "decimal", "scale": 1
+			}},
+			{"name": "other", "type": ["null", "money"]},
+			{"name": "nested", "type": {"type": "map", "values": {"type": "array", "items": "money"}}}
+		]
+	}`
+	schema, err := parseAvroSchema(schemaJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := schema.native(map[string]interface{}{
+		"d":      int32(1),
+		"t":      int32(1500),
+		"ts":     map[string]interface{}{"long.timestamp-millis": int64(-1)},
+		"dec":    []byte{0x00, 0x0f},
+		"other":  map[string]interface{}{"ns.money": []byte{0xff, 0xf1}},
+		"nested": map[string]interface{}{"k": []interface{}{[]byte{0x01}}},
+	})
+	want := map[string]interface{}{
+		"d":      time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC),
+		"t":      timeofday.New(0, 0, 1, 500000),
+		"ts":     time.Date(1969, 12, 31, 23, 59, 59, 999000000, time.UTC),
+		"dec":    apd.New(15, -1),
+		"other":  apd.New(-15, -1),
+		"nested": map[string]interface{}{"k": []interface{}{apd.New(1, -1)}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package importccl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	gojson "encoding/json"
+	"io"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+)
+
+// jsonInputReader reads newline-delimited JSON, where each non-empty line is
+// an object. Each key of the objects is imported into the column of the same
+// name.
+type jsonInputReader struct {
+	conv *recordConverter
+	opts roachpb.JSONOptions
+}
+
+var _ inputConverter = &jsonInputReader{}
+
+func newJSONInputReader(
+	kvCh chan []roachpb.KeyValue,
+	opts roachpb.JSONOptions,
+	tableDesc *sqlbase.TableDescriptor,
+	evalCtx *tree.EvalContext,
+) (*jsonInputReader, error) {
+	conv, err := newRecordConverter(kvCh, tableDesc, evalCtx, opts.StrictMode)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonInputReader{
+		conv: conv,
+		opts: opts,
+	}, nil
+}
+
+func (j *jsonInputReader) start(group ctxgroup.Group) {
+}
+
+func (j *jsonInputReader) inputFinished(ctx context.Context) {
+	close(j.conv.conv.kvCh)
+}
+
+func (j *jsonInputReader) readFiles(
+	ctx context.Context,
+	dataFiles map[int32]string,
+	format roachpb.IOFileFormat,
+	progressFn func(float32) error,
+	settings *cluster.Settings,
+) error {
+	return readInputFiles(ctx, dataFiles, format, j.readFile, progressFn, settings)
+}
+
+func (j *jsonInputReader) readFile(
+	ctx context.Context, input io.Reader, inputIdx int32, inputName string, progressFn progressFn,
+) error {
+	maxRowSize := int(j.opts.MaxRowSize)
+	if maxRowSize == 0 {
+		maxRowSize = defaultScanBuffer
+	}
+	s := bufio.NewScanner(input)
+	s.Split(bufio.ScanLines)
+	s.Buffer(nil, maxRowSize)
+
+	count := int64(1)
+	for ; s.Scan(); count++ {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		// Numbers are decoded as gojson.Number so that integers and decimals
+		// keep their precision until they are coerced to the column types.
+		dec := gojson.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+		var record map[string]interface{}
+		if err := dec.Decode(&record); err != nil {
+			return wrapRowErr(err, inputName, count, pgcode.Syntax, "decoding JSON object")
+		}
+		if dec.More() {
+			return makeRowErr(inputName, count, pgcode.Syntax,
+				"expected a single JSON object per line")
+		}
+		if record == nil {
+			return makeRowErr(inputName, count, pgcode.Syntax, "expected a JSON object, found null")
+		}
+		if err := j.conv.convertRecord(ctx, inputIdx, count, record); err != nil {
+			return wrapRowErr(err, inputName, count, pgcode.Uncategorized, "")
+		}
+	}
+	if err := s.Err(); err != nil {
+		return wrapRowErr(err, inputName, count, pgcode.Uncategorized, "")
+	}
+	return j.conv.conv.sendBatch(ctx)
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package importccl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/parquet"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+)
+
+// parquetInputReader reads Parquet files. Each top-level column of the files
+// is imported into the column of the table with the same name.
+type parquetInputReader struct {
+	conv *recordConverter
+	opts roachpb.ParquetOptions
+}
+
+var _ inputConverter = &parquetInputReader{}
+
+func newParquetInputReader(
+	kvCh chan []roachpb.KeyValue,
+	opts roachpb.ParquetOptions,
+	tableDesc *sqlbase.TableDescriptor,
+	evalCtx *tree.EvalContext,
+) (*parquetInputReader, error) {
+	conv, err := newRecordConverter(kvCh, tableDesc, evalCtx, opts.StrictMode)
+	if err != nil {
+		return nil, err
+	}
+	conv.parseJSONStrings = true
+	return &parquetInputReader{
+		conv: conv,
+		opts: opts,
+	}, nil
+}
+
+func (p *parquetInputReader) start(group ctxgroup.Group) {
+}
+
+func (p *parquetInputReader) inputFinished(ctx context.Context) {
+	close(p.conv.conv.kvCh)
+}
+
+func (p *parquetInputReader) readFiles(
+	ctx context.Context,
+	dataFiles map[int32]string,
+	format roachpb.IOFileFormat,
+	progressFn func(float32) error,
+	settings *cluster.Settings,
+) error {
+	return readInputFiles(ctx, dataFiles, format, p.readFile, progressFn, settings)
+}
+
+func (p *parquetInputReader) readFile(
+	ctx context.Context, input io.Reader, inputIdx int32, inputName string, progressFn progressFn,
+) error {
+	// The metadata of a Parquet file is at its end, so unlike the other formats
+	// it can't be read as a stream and the whole file is buffered instead.
+	// TODO(import): read ranges of the file from the ExportStorage instead.
+	data, err := ioutil.ReadAll(input)
+	if err != nil {
+		return err
+	}
+	r, err := parquet.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return pgerror.Wrap(err, pgcode.Syntax, "reading Parquet file")
+	}
+
+	// Map the columns of the file to the columns of the table once, rather
+	// than for every row.
+	names := r.Columns()
+	cols := make([]int, len(names))
+	for i, name := range names {
+		if cols[i], err = p.conv.column(name); err != nil {
+			return pgerror.Wrap(err, pgcode.Syntax, "reading Parquet file")
+		}
+	}
+
+	for count := int64(1); ; count++ {
+		row, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return wrapRowErr(err, inputName, count, pgcode.Syntax, "decoding Parquet row")
+		}
+		p.conv.startRecord()
+		for i, v := range row {
+			if cols[i] < 0 {
+				continue
+			}
+			if err := p.conv.set(cols[i], v); err != nil {
+				return wrapRowErr(err, inputName, count, pgcode.Uncategorized, "")
+			}
+		}
+		if err := p.conv.finishRecord(ctx, inputIdx, count); err != nil {
+			return wrapRowErr(err, inputName, count, pgcode.Uncategorized, "")
+		}
+	}
+	return p.conv.conv.sendBatch(ctx)
+}
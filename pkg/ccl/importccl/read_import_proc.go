@@ -19,6 +19,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -449,6 +450,12 @@ func (cp *readImportDataProcessor) doRun(ctx context.Context) error {
 		conv, err = newPgCopyReader(kvCh, cp.spec.Format.PgCopy, singleTable, evalCtx)
 	case roachpb.IOFileFormat_PgDump:
 		conv, err = newPgDumpReader(kvCh, cp.spec.Format.PgDump, cp.spec.Tables, evalCtx)
+	case roachpb.IOFileFormat_Avro:
+		conv, err = newAvroInputReader(kvCh, cp.spec.Format.Avro, singleTable, evalCtx)
+	case roachpb.IOFileFormat_JSON:
+		conv, err = newJSONInputReader(kvCh, cp.spec.Format.Json, singleTable, evalCtx)
+	case roachpb.IOFileFormat_Parquet:
+		conv, err = newParquetInputReader(kvCh, cp.spec.Format.Parquet, singleTable, evalCtx)
 	default:
 		err = errors.Errorf("Requested IMPORT format (%d) not supported by this node", cp.spec.Format.Format)
 	}
@@ -514,6 +521,22 @@ func (cp *readImportDataProcessor) doRun(ctx context.Context) error {
 				return err
 			}
 			defer adder.Close(ctx)
+			if cp.spec.Online {
+				// The tables are online, so the KVs must be written above the reads
+				// that were served from them, and must not clobber their existing
+				// rows or index entries.
+				adder.WriteAtRequestTimestamp(true)
+				adder.DisallowShadowing(true)
+				// The spans ingested into the tables are recorded in the job, so
+				// that a failed import can find the keys it inserted again.
+				job, err := cp.flowCtx.JobRegistry.LoadJob(ctx, cp.spec.Progress.JobID)
+				if err != nil {
+					return err
+				}
+				adder.SetIngestRecorder(&onlineImportRecorder{
+					job: job, writtenAfter: writeTS.Prev(),
+				})
+			}
 
 			// Drain the kvCh using the BulkAdder until it closes.
 			if err := ingestKvs(ctx, adder, kvCh); err != nil {
@@ -609,6 +632,61 @@ func (cp *readImportDataProcessor) doRun(ctx context.Context) error {
 	return group.Wait()
 }
 
+// onlineImportRecorder records the spans ingested by an online import in the
+// progress of its job, along with bounds on the timestamps at which their
+// keys were written.
+type onlineImportRecorder struct {
+	job *jobs.Job
+	// the ingested keys are all written after this timestamp.
+	writtenAfter hlc.Timestamp
+}
+
+var _ storagebase.IngestRecorder = &onlineImportRecorder{}
+
+func (r *onlineImportRecorder) update(
+	ctx context.Context, fn func(d *jobspb.ImportProgress),
+) error {
+	return r.job.FractionProgressed(ctx,
+		func(ctx context.Context, details jobspb.ProgressDetails) float32 {
+			d := details.(*jobspb.Progress_Import).Import
+			fn(d)
+			return d.Completed()
+		},
+	)
+}
+
+// Ingesting implements the storagebase.IngestRecorder interface.
+func (r *onlineImportRecorder) Ingesting(ctx context.Context, span roachpb.Span) error {
+	return r.update(ctx, func(d *jobspb.ImportProgress) {
+		d.IngestedSpans = append(d.IngestedSpans, jobspb.IngestedSpan{
+			Span: span, WrittenAfter: r.writtenAfter,
+		})
+	})
+}
+
+// Ingested implements the storagebase.IngestRecorder interface.
+func (r *onlineImportRecorder) Ingested(
+	ctx context.Context, span roachpb.Span, ingested []storagebase.IngestedSpan,
+) error {
+	return r.update(ctx, func(d *jobspb.ImportProgress) {
+		// Replace the span recorded by Ingesting with the ingested ones, whose
+		// timestamps are known unless their ingestion was ambiguous.
+		for i := range d.IngestedSpans {
+			if s := d.IngestedSpans[i]; s.WrittenUntil.IsEmpty() && s.Span.Equal(span) {
+				d.IngestedSpans = append(d.IngestedSpans[:i], d.IngestedSpans[i+1:]...)
+				break
+			}
+		}
+		for _, sp := range ingested {
+			s := jobspb.IngestedSpan{Span: sp.Span, WrittenAfter: r.writtenAfter}
+			if !sp.Timestamp.IsEmpty() {
+				s.WrittenAfter, s.WrittenUntil = sp.Timestamp.Prev(), sp.Timestamp
+			}
+			d.IngestedSpans = append(d.IngestedSpans, s)
+		}
+	})
+}
+
 type sampleFunc func(roachpb.KeyValue) bool
 
 // sampleRate is a sampleFunc that samples a row with a probability of the
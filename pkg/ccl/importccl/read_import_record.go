@@ -0,0 +1,286 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package importccl
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/cockroachdb/errors"
+)
+
+// recordConverter converts records made of named fields, as read from the
+// self-describing formats (Avro, JSON and Parquet), to rows. Fields are matched
+// to the visible columns of the table by name and their values are coerced to
+// the types of the columns. Columns without a matching field are NULL.
+//
+// Field values are the Go values produced by the decoders of those formats:
+// nil, bool, int32, int64, uint64, float32, float64, gojson.Number, string,
+// []byte, time.Time, timeofday.TimeOfDay, *apd.Decimal, []interface{} and
+// map[string]interface{}.
+type recordConverter struct {
+	conv *rowConverter
+	// colIdx maps the name of each visible column to its index in
+	// conv.visibleCols.
+	colIdx map[string]int
+	// strict makes fields that don't match a column an error, rather than
+	// ignoring them.
+	strict bool
+	// parseJSONStrings makes strings destined for JSONB columns be parsed as
+	// JSON text. Formats that have no JSON type of their own set it, since a
+	// string is the only way for them to carry a JSON document.
+	parseJSONStrings bool
+}
+
+func newRecordConverter(
+	kvCh chan []roachpb.KeyValue,
+	tableDesc *sqlbase.TableDescriptor,
+	evalCtx *tree.EvalContext,
+	strict bool,
+) (*recordConverter, error) {
+	conv, err := newRowConverter(tableDesc, evalCtx, kvCh)
+	if err != nil {
+		return nil, err
+	}
+	c := &recordConverter{
+		conv:   conv,
+		colIdx: make(map[string]int, len(conv.visibleCols)),
+		strict: strict,
+	}
+	for i := range conv.visibleCols {
+		c.colIdx[conv.visibleCols[i].Name] = i
+	}
+	return c, nil
+}
+
+// column returns the index of the visible column matching a field name, or -1
+// if there is none. Names are matched exactly first and then, since unquoted
+// SQL identifiers are lower case, case-insensitively.
+func (c *recordConverter) column(field string) (int, error) {
+	if i, ok := c.colIdx[field]; ok {
+		return i, nil
+	}
+	if i, ok := c.colIdx[strings.ToLower(field)]; ok {
+		return i, nil
+	}
+	if c.strict {
+		return -1, errors.Errorf("field %q does not match any column of table %s",
+			field, c.conv.tableDesc.Name)
+	}
+	return -1, nil
+}
+
+// startRecord resets the datums of the row being built to NULL.
+func (c *recordConverter) startRecord() {
+	for i := range c.conv.visibleCols {
+		c.conv.datums[i] = tree.DNull
+	}
+}
+
+// set coerces the value of a field to the type of the visible column with the
+// given index and stores it in the row being built.
+func (c *recordConverter) set(col int, v interface{}) error {
+	typ := c.conv.visibleColTypes[col]
+	name := &c.conv.visibleCols[col].Name
+	d, err := nativeToDatum(v, typ, c.conv.evalCtx, c.parseJSONStrings)
+	if err == nil {
+		d, err = sqlbase.LimitValueWidth(typ, d, name)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "converting %q to %s", *name, typ.SQLString())
+	}
+	c.conv.datums[col] = d
+	return nil
+}
+
+// finishRecord converts the row that was built to KVs.
+func (c *recordConverter) finishRecord(
+	ctx context.Context, fileIndex int32, rowIndex int64,
+) error {
+	return c.conv.row(ctx, fileIndex, rowIndex)
+}
+
+// convertRecord converts a record whose fields are keyed by name.
+func (c *recordConverter) convertRecord(
+	ctx context.Context, fileIndex int32, rowIndex int64, record map[string]interface{},
+) error {
+	c.startRecord()
+	for field, v := range record {
+		col, err := c.column(field)
+		if err != nil {
+			return err
+		}
+		if col < 0 {
+			continue
+		}
+		if err := c.set(col, v); err != nil {
+			return err
+		}
+	}
+	return c.finishRecord(ctx, fileIndex, rowIndex)
+}
+
+// nativeToDatum converts a value decoded from a record to a datum of the given
+// type. Values that can't be converted directly are formatted as text and
+// parsed the same way CSV fields are.
+func nativeToDatum(
+	v interface{}, typ *types.T, evalCtx *tree.EvalContext, parseJSONStrings bool,
+) (tree.Datum, error) {
+	if v == nil {
+		return tree.DNull, nil
+	}
+
+	switch typ.Family() {
+	case types.JsonFamily:
+		if s, ok := v.(string); ok && parseJSONStrings {
+			return tree.ParseDJSON(s)
+		}
+		j, err := json.MakeJSON(jsonCompatible(v))
+		if err != nil {
+			return nil, err
+		}
+		return tree.NewDJSON(j), nil
+	case types.ArrayFamily:
+		elems, ok := v.([]interface{})
+		if !ok {
+			break
+		}
+		arr := tree.NewDArray(typ.ArrayContents())
+		for _, elem := range elems {
+			d, err := nativeToDatum(elem, typ.ArrayContents(), evalCtx, parseJSONStrings)
+			if err != nil {
+				return nil, err
+			}
+			if err := arr.Append(d); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+
+	var s string
+	switch v := v.(type) {
+	case bool:
+		if typ.Family() == types.BoolFamily {
+			return tree.MakeDBool(tree.DBool(v)), nil
+		}
+		s = strconv.FormatBool(v)
+	case int32:
+		return intToDatum(int64(v), typ, evalCtx)
+	case int64:
+		return intToDatum(v, typ, evalCtx)
+	case float32:
+		if typ.Family() == types.FloatFamily {
+			return tree.NewDFloat(tree.DFloat(v)), nil
+		}
+		s = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		if typ.Family() == types.FloatFamily {
+			return tree.NewDFloat(tree.DFloat(v)), nil
+		}
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	case gojson.Number:
+		s = v.String()
+	case string:
+		s = v
+	case []byte:
+		if typ.Family() == types.BytesFamily {
+			return tree.NewDBytes(tree.DBytes(v)), nil
+		}
+		s = string(v)
+	case time.Time:
+		switch typ.Family() {
+		case types.TimestampFamily:
+			return tree.MakeDTimestamp(v, time.Microsecond), nil
+		case types.TimestampTZFamily:
+			return tree.MakeDTimestampTZ(v, time.Microsecond), nil
+		case types.DateFamily:
+			return tree.NewDDateFromTime(v)
+		}
+		s = v.Format(time.RFC3339Nano)
+	case timeofday.TimeOfDay:
+		if typ.Family() == types.TimeFamily {
+			return tree.MakeDTime(v), nil
+		}
+		s = v.String()
+	case *apd.Decimal:
+		if typ.Family() == types.DecimalFamily {
+			d := &tree.DDecimal{}
+			d.Set(v)
+			return d, nil
+		}
+		s = v.String()
+	case []interface{}:
+		return nil, errors.Errorf("cannot convert array to %s", typ.SQLString())
+	case map[string]interface{}:
+		return nil, errors.Errorf("cannot convert object to %s", typ.SQLString())
+	default:
+		s = fmt.Sprint(v)
+	}
+	return tree.ParseDatumStringAs(typ, s, evalCtx)
+}
+
+func intToDatum(v int64, typ *types.T, evalCtx *tree.EvalContext) (tree.Datum, error) {
+	switch typ.Family() {
+	case types.IntFamily:
+		return tree.NewDInt(tree.DInt(v)), nil
+	case types.FloatFamily:
+		return tree.NewDFloat(tree.DFloat(v)), nil
+	case types.DecimalFamily:
+		d := &tree.DDecimal{}
+		d.SetFinite(v, 0)
+		return d, nil
+	}
+	return tree.ParseDatumStringAs(typ, strconv.FormatInt(v, 10), evalCtx)
+}
+
+// jsonCompatible converts a value decoded from a record to the types accepted
+// by json.MakeJSON.
+func jsonCompatible(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int32:
+		return int64(v)
+	case float32:
+		return gojson.Number(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	case []byte:
+		return string(v)
+	case uint64:
+		return gojson.Number(strconv.FormatUint(v, 10))
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case timeofday.TimeOfDay:
+		return v.String()
+	case *apd.Decimal:
+		return gojson.Number(v.String())
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i := range v {
+			res[i] = jsonCompatible(v[i])
+		}
+		return res
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k := range v {
+			res[k] = jsonCompatible(v[k])
+		}
+		return res
+	}
+	return v
+}
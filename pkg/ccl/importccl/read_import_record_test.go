@@ -0,0 +1,82 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package importccl
+
+import (
+	gojson "encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+)
+
+func TestNativeToDatum(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 6000, time.UTC)
+	tests := []struct {
+		v         interface{}
+		typ       *types.T
+		parseJSON bool
+		want      string
+		err       string
+	}{
+		{v: nil, typ: types.Int, want: "NULL"},
+		{v: true, typ: types.Bool, want: "true"},
+		{v: true, typ: types.String, want: "'true'"},
+		{v: int32(7), typ: types.Int, want: "7"},
+		{v: int64(7), typ: types.Float, want: "7.0"},
+		{v: int64(7), typ: types.Decimal, want: "7"},
+		{v: int64(7), typ: types.String, want: "'7'"},
+		{v: float64(1.5), typ: types.Float, want: "1.5"},
+		{v: float32(1.5), typ: types.Decimal, want: "1.5"},
+		{v: gojson.Number("12345678901234567890.5"), typ: types.Decimal,
+			want: "12345678901234567890.5"},
+		{v: gojson.Number("1.5"), typ: types.Int, err: "could not parse"},
+		{v: "2019-01-02", typ: types.Date, want: "'2019-01-02'"},
+		{v: []byte("ab"), typ: types.Bytes, want: `'\x6162'`},
+		{v: []byte("ab"), typ: types.String, want: "'ab'"},
+		{v: ts, typ: types.Timestamp, want: "'2019-01-02 03:04:05.000006+00:00'"},
+		{v: ts, typ: types.Date, want: "'2019-01-02'"},
+		{v: timeofday.New(1, 2, 3, 0), typ: types.Time, want: "'01:02:03'"},
+		{v: apd.New(15, -1), typ: types.Decimal, want: "1.5"},
+		{v: apd.New(15, -1), typ: types.String, want: "'1.5'"},
+		{v: uint64(1 << 63), typ: types.Decimal, want: "9223372036854775808"},
+		{v: []interface{}{int64(1), nil}, typ: types.IntArray, want: "ARRAY[1,NULL]"},
+		{v: []interface{}{int64(1)}, typ: types.Int, err: "cannot convert array to INT8"},
+		{v: map[string]interface{}{}, typ: types.String, err: "cannot convert object to STRING"},
+		{v: map[string]interface{}{"a": []interface{}{int32(1), []byte("b")}}, typ: types.Jsonb,
+			want: `'{"a": [1, "b"]}'`},
+		{v: `{"a": 1}`, typ: types.Jsonb, want: `e'"{\\"a\\": 1}"'`},
+		{v: `{"a": 1}`, typ: types.Jsonb, parseJSON: true, want: `'{"a": 1}'`},
+	}
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%v/%s", tc.v, tc.typ.SQLString()), func(t *testing.T) {
+			got, err := nativeToDatum(tc.v, tc.typ, testEvalCtx, tc.parseJSON)
+			if tc.err != "" {
+				if !testutils.IsError(err, tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := tree.AsString(got); s != tc.want {
+				t.Errorf("got %s, want %s", s, tc.want)
+			}
+		})
+	}
+}
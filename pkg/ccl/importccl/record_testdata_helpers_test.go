@@ -0,0 +1,56 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package importccl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parquet"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/linkedin/goavro"
+)
+
+// makeAvroTestData returns an Avro object container file with the given
+// schema and records, as the goavro codec expects them.
+func makeAvroTestData(t *testing.T, schema string, records ...interface{}) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := goavro.NewOCFWriter(goavro.OCFConfig{W: &buf, Schema: schema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Append(records); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// makeParquetTestData returns a Parquet file with columns of the given names
+// and types holding the given rows.
+func makeParquetTestData(
+	t *testing.T, names []string, typs []types.T, rows ...tree.Datums,
+) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := parquet.NewWriter(&buf, names, typs, parquet.WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := w.AddRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
@@ -202,7 +202,10 @@ func (sp *sstWriter) Run(ctx context.Context) {
 						// throughput.
 						log.Errorf(ctx, "failed to scatter span %s: %s", roachpb.PrettyPrintKey(nil, end), pErr)
 					}
-					if err := bulk.AddSSTable(ctx, sp.db, sst.span.Key, sst.span.EndKey, sst.data); err != nil {
+					if _, err := bulk.AddSSTable(
+						ctx, sp.db, sst.span.Key, sst.span.EndKey, sst.data,
+						false /* disallowShadowing */, false, /* writeAtRequestTimestamp */
+					); err != nil {
 						return err
 					}
 
@@ -36,7 +36,7 @@ func createRolePlanHook(
 	}
 
 	// Call directly into the OSS code.
-	return p.CreateUserNode(ctx, createRole.Name, nil /* password */, createRole.IfNotExists, true /* isRole */, "CREATE ROLE")
+	return p.CreateUserNode(ctx, createRole.Name, createRole.IfNotExists, true /* isRole */, "CREATE ROLE", createRole.Options)
 }
 
 func dropRolePlanHook(
@@ -77,7 +77,7 @@ func BenchmarkAddSSTable(b *testing.B) {
 				totalLen += int64(len(data))
 
 				b.StartTimer()
-				if err := kvDB.AddSSTable(ctx, span.Key, span.EndKey, data); err != nil {
+				if err := kvDB.AddSSTable(ctx, span.Key, span.EndKey, data, false, false); err != nil {
 					b.Fatalf("%+v", err)
 				}
 				b.StopTimer()
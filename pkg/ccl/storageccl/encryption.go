@@ -0,0 +1,114 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package storageccl
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Encrypted files start with encryptionPreamble followed by a version byte,
+// the nonce and then the AES-GCM sealed contents of the file. The whole file is
+// sealed at once: the files written by BACKUP are SSTs and descriptors that are
+// already held in memory in full, so there's nothing to gain from streaming.
+const (
+	encryptionPreamble       = "encrypt"
+	encryptionVersionIVPlain = 1
+	encryptionSaltSize       = 16
+	encryptionKeySize        = 32 // AES-256
+	encryptionNonceSize      = 12 // GCM standard nonce
+	// kdfIterations is the number of PBKDF2 iterations used to derive a key
+	// from a passphrase. Deriving a key only happens once per BACKUP or RESTORE
+	// so this can be generous.
+	kdfIterations = 64000
+)
+
+// ErrDecryptionFailed is returned by DecryptFile when a file can't be
+// authenticated with the given key, which almost always means the key is wrong.
+var ErrDecryptionFailed = errors.New(
+	"failed to decrypt file: the passphrase or key is incorrect or the file is corrupted")
+
+// GenerateSalt returns a new random salt to derive a key with GenerateKey.
+func GenerateSalt() ([]byte, error) {
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// GenerateKey derives an AES-256 key from a passphrase and salt.
+func GenerateKey(passphrase, salt []byte) []byte {
+	return pbkdf2.Key(passphrase, salt, kdfIterations, encryptionKeySize, sha256.New)
+}
+
+// AppearsEncrypted returns whether the contents of a file look like they were
+// written by EncryptFile.
+func AppearsEncrypted(text []byte) bool {
+	return bytes.HasPrefix(text, []byte(encryptionPreamble))
+}
+
+func aesGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptFile encrypts the contents of a file with key.
+func EncryptFile(plaintext, key []byte) ([]byte, error) {
+	gcm, err := aesGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, encryptionNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	headerLen := len(encryptionPreamble) + 1 + len(nonce)
+	ciphertext := make([]byte, headerLen, headerLen+len(plaintext)+gcm.Overhead())
+	copy(ciphertext, encryptionPreamble)
+	ciphertext[len(encryptionPreamble)] = encryptionVersionIVPlain
+	copy(ciphertext[len(encryptionPreamble)+1:], nonce)
+	return gcm.Seal(ciphertext, nonce, plaintext, nil), nil
+}
+
+// DecryptFile decrypts a file written by EncryptFile with the same key.
+func DecryptFile(ciphertext, key []byte) ([]byte, error) {
+	if !AppearsEncrypted(ciphertext) {
+		return nil, errors.New("file does not appear to be encrypted")
+	}
+	ciphertext = ciphertext[len(encryptionPreamble):]
+	if len(ciphertext) < 1+encryptionNonceSize {
+		return nil, errors.New("invalid encryption header")
+	}
+	if version := ciphertext[0]; version != encryptionVersionIVPlain {
+		return nil, errors.Errorf("unexpected encryption scheme/config version %d", version)
+	}
+	nonce := ciphertext[1 : 1+encryptionNonceSize]
+	ciphertext = ciphertext[1+encryptionNonceSize:]
+
+	gcm, err := aesGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package storageccl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	salt, err := GenerateSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := GenerateKey([]byte("passphrase"), salt)
+	if again := GenerateKey([]byte("passphrase"), salt); !bytes.Equal(key, again) {
+		t.Fatal("expected the same passphrase and salt to derive the same key")
+	}
+	otherSalt, err := GenerateSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other := GenerateKey([]byte("passphrase"), otherSalt); bytes.Equal(key, other) {
+		t.Fatal("expected a different salt to derive a different key")
+	}
+
+	for _, plaintext := range [][]byte{
+		nil,
+		[]byte("a"),
+		bytes.Repeat([]byte("0123456789"), 10000),
+	} {
+		ciphertext, err := EncryptFile(plaintext, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !AppearsEncrypted(ciphertext) {
+			t.Fatal("expected encrypted file to appear encrypted")
+		}
+		if len(plaintext) > 0 && bytes.Contains(ciphertext, plaintext) {
+			t.Fatal("expected plaintext to not appear in encrypted file")
+		}
+
+		decrypted, err := DecryptFile(ciphertext, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(plaintext, decrypted) {
+			t.Fatalf("expected %q got %q", plaintext, decrypted)
+		}
+
+		t.Run("wrong key", func(t *testing.T) {
+			wrong := GenerateKey([]byte("wrong"), salt)
+			if _, err := DecryptFile(ciphertext, wrong); err != ErrDecryptionFailed {
+				t.Fatalf("expected %v got %v", ErrDecryptionFailed, err)
+			}
+		})
+
+		t.Run("tampered", func(t *testing.T) {
+			tampered := append([]byte(nil), ciphertext...)
+			tampered[len(tampered)-1] ^= 1
+			if _, err := DecryptFile(tampered, key); err != ErrDecryptionFailed {
+				t.Fatalf("expected %v got %v", ErrDecryptionFailed, err)
+			}
+		})
+
+		t.Run("truncated", func(t *testing.T) {
+			truncated := ciphertext[:len(encryptionPreamble)+1]
+			if _, err := DecryptFile(truncated, key); !testutils.IsError(
+				err, "invalid encryption header",
+			) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	t.Run("not encrypted", func(t *testing.T) {
+		plaintext := []byte("some file contents")
+		if AppearsEncrypted(plaintext) {
+			t.Fatal("expected plaintext to not appear encrypted")
+		}
+		if _, err := DecryptFile(plaintext, key); !testutils.IsError(
+			err, "does not appear to be encrypted",
+		) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
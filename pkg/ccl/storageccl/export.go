@@ -91,10 +91,22 @@ func evalExport(
 		log.Eventf(ctx, "export [%s,%s)", args.Key, args.EndKey)
 	}
 
+	// If the backup is partitioned by locality, write to the storage for the
+	// first of this node's locality tiers that has one.
+	storage, localityKV := args.Storage, ""
+	if len(args.StorageByLocalityKV) > 0 {
+		for _, tier := range cArgs.EvalCtx.GetNodeLocality().Tiers {
+			if dest, ok := args.StorageByLocalityKV[tier.String()]; ok {
+				storage, localityKV = *dest, tier.String()
+				break
+			}
+		}
+	}
+
 	var exportStore ExportStorage
 	if makeExportStorage {
 		var err error
-		exportStore, err = MakeExportStorage(ctx, args.Storage, cArgs.EvalCtx.ClusterSettings())
+		exportStore, err = MakeExportStorage(ctx, storage, cArgs.EvalCtx.ClusterSettings())
 		if err != nil {
 			return result.Result{}, err
 		}
@@ -174,14 +186,21 @@ func evalExport(
 	}
 
 	exported := roachpb.ExportResponse_File{
-		Span:     args.Span(),
-		Exported: rows.BulkOpSummary,
-		Sha512:   checksum,
+		Span:       args.Span(),
+		Exported:   rows.BulkOpSummary,
+		Sha512:     checksum,
+		LocalityKV: localityKV,
 	}
 
 	if exportStore != nil {
 		exported.Path = fmt.Sprintf("%d.sst", builtins.GenerateUniqueInt(cArgs.EvalCtx.NodeID()))
-		if err := exportStore.WriteFile(ctx, exported.Path, bytes.NewReader(data)); err != nil {
+		payload := data
+		if args.Encryption != nil {
+			if payload, err = EncryptFile(data, args.Encryption.Key); err != nil {
+				return result.Result{}, err
+			}
+		}
+		if err := exportStore.WriteFile(ctx, exported.Path, bytes.NewReader(payload)); err != nil {
 			return result.Result{}, err
 		}
 	}
@@ -113,6 +113,13 @@ func evalImport(ctx context.Context, cArgs batcheval.CommandArgs) (*roachpb.Impo
 		dataSize := int64(len(fileContents))
 		log.Eventf(ctx, "fetched file (%s)", humanizeutil.IBytes(dataSize))
 
+		if args.Encryption != nil {
+			fileContents, err = DecryptFile(fileContents, args.Encryption.Key)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decrypting %q", file.Path)
+			}
+		}
+
 		if len(file.Sha512) > 0 {
 			checksum, err := SHA512ChecksumData(fileContents)
 			if err != nil {
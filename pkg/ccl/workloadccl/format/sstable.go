@@ -104,7 +104,15 @@ func ToSSTable(t workload.Table, tableID sqlbase.ID, ts time.Time) ([]byte, erro
 
 type addSSTableSender [][]byte
 
-func (s *addSSTableSender) AddSSTable(_ context.Context, _, _ interface{}, data []byte) error {
+func (s *addSSTableSender) AddSSTable(
+	_ context.Context, _, _ interface{}, data []byte, _, _ bool,
+) error {
 	*s = append(*s, data)
 	return nil
 }
+
+func (s *addSSTableSender) AddSSTableAtRequestTimestamp(
+	_ context.Context, _, _ interface{}, _ []byte, _ bool,
+) (hlc.Timestamp, error) {
+	return hlc.Timestamp{}, errors.New(`expected SSTs to be written at their own timestamps`)
+}
@@ -588,6 +588,15 @@ memory that the store may consume, for example:
   --store=type=mem,size=20GiB
   --store=type=mem,size=90%
 
+</PRE>
+The "engine" field selects the storage engine of the store: "rocksdb" (the
+default) or "golsm", a pure-Go log-structured merge tree. The on-disk formats
+of the two engines are not compatible, so the engine of an existing store
+cannot be changed, for example:
+<PRE>
+
+  --store=path=/mnt/ssd01,engine=golsm
+
 </PRE>
 Commas are forbidden in all values, since they are used to separate fields.
 Also, if you use equal signs in the file path to a store, you must use the
@@ -539,6 +539,7 @@ func runDebugGCCmd(cmd *cobra.Command, args []string) error {
 			&desc,
 			snap,
 			hlc.Timestamp{WallTime: timeutil.Now().UnixNano()},
+			hlc.Timestamp{}, /* protectedTS */
 			config.GCPolicy{TTLSeconds: int32(gcTTLInSeconds)},
 			storage.NoopGCer{},
 			func(_ context.Context, _ []roachpb.Intent) error { return nil },
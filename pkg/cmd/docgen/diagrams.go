@@ -352,9 +352,20 @@ var specs = []stmtSpec{
 		unlink: []string{"table_name"},
 	},
 	{
-		name:    "alter_user_password_stmt",
-		replace: map[string]string{"string_or_placeholder 'WITH'": "name 'WITH'", "'PASSWORD' string_or_placeholder": "'PASSWORD' password"},
-		unlink:  []string{"name", "password"},
+		name:    "alter_role_stmt",
+		inline:  []string{"role_or_group", "opt_with"},
+		replace: map[string]string{"string_or_placeholder": "name"},
+		unlink:  []string{"name"},
+	},
+	{
+		name:    "alter_user_stmt",
+		inline:  []string{"opt_with"},
+		replace: map[string]string{"string_or_placeholder": "name"},
+		unlink:  []string{"name"},
+	},
+	{
+		name:   "alter_domain_stmt",
+		unlink: []string{"constraint_name"},
 	},
 	{
 		name:    "alter_sequence_options_stmt",
@@ -539,6 +550,23 @@ var specs = []stmtSpec{
 		match:  []*regexp.Regexp{regexp.MustCompile("'CREATE' 'INVERTED'")},
 		inline: []string{"opt_storing", "storing", "opt_unique", "opt_name", "index_params", "index_elem", "opt_asc_desc"},
 	},
+	{
+		name:    "create_domain_stmt",
+		inline:  []string{"opt_as", "opt_domain_constraint_list", "domain_constraint", "domain_check"},
+		replace: map[string]string{"a_expr": "check_expr", "b_expr": "default_expr"},
+		unlink:  []string{"check_expr", "default_expr", "constraint_name"},
+	},
+	{
+		name:   "create_schedule_for_backup_stmt",
+		inline: []string{"opt_schedule_label", "opt_full_backup_clause"},
+		replace: map[string]string{
+			"'SCHEDULE' string_or_placeholder":  "'SCHEDULE' label",
+			"'TO' string_or_placeholder":        "'TO' location",
+			"'RECURRING' string_or_placeholder": "'RECURRING' cron_expr",
+			"'BACKUP' string_or_placeholder":    "'BACKUP' cron_expr",
+		},
+		unlink: []string{"label", "location", "cron_expr"},
+	},
 	{
 		name:    "create_sequence_stmt",
 		inline:  []string{"opt_sequence_option_list", "sequence_option_list", "sequence_option_elem"},
@@ -559,26 +587,29 @@ var specs = []stmtSpec{
 		name:   "create_table_stmt",
 		inline: []string{"opt_table_elem_list", "table_elem_list", "table_elem"},
 	},
+	{
+		name:   "create_type_stmt",
+		inline: []string{"opt_composite_type_field_list", "composite_type_field_list", "composite_type_field"},
+		match:  []*regexp.Regexp{regexp.MustCompile("'AS' '\\('")},
+	},
 	{
 		name:   "create_view_stmt",
 		inline: []string{"opt_column_list"},
 	},
 	{
 		name:   "create_role_stmt",
-		inline: []string{"role_or_group"},
+		inline: []string{"role_or_group", "opt_role_options", "opt_with"},
 		replace: map[string]string{
 			"string_or_placeholder": "name",
 		},
 	},
 	{
 		name:   "create_user_stmt",
-		inline: []string{"opt_with", "opt_password"},
+		inline: []string{"opt_role_options", "opt_with"},
 		replace: map[string]string{
-			"'PASSWORD' string_or_placeholder": "'PASSWORD' password",
-			"'USER' string_or_placeholder":     "'USER' name",
-			"'EXISTS' string_or_placeholder":   "'EXISTS' name",
+			"'USER' string_or_placeholder":   "'USER' name",
+			"'EXISTS' string_or_placeholder": "'EXISTS' name",
 		},
-		unlink: []string{"password"},
 	},
 	{
 		name: "default_value_column_level",
@@ -656,6 +687,10 @@ var specs = []stmtSpec{
 		name:    "drop_role_stmt",
 		replace: map[string]string{"string_or_placeholder_list": "name"},
 	},
+	{
+		name:   "drop_domain_stmt",
+		inline: []string{"opt_drop_behavior"},
+	},
 	{
 		name:   "drop_sequence_stmt",
 		inline: []string{"table_name_list", "opt_drop_behavior"},
@@ -671,6 +706,10 @@ var specs = []stmtSpec{
 		inline: []string{"opt_drop_behavior", "table_name_list"},
 		match:  []*regexp.Regexp{regexp.MustCompile("'DROP' 'TABLE'")},
 	},
+	{
+		name:   "drop_type_stmt",
+		inline: []string{"opt_drop_behavior"},
+	},
 	{
 		name:   "drop_view",
 		stmt:   "drop_view_stmt",
@@ -758,9 +797,10 @@ var specs = []stmtSpec{
 		name: "grant_roles",
 		stmt: "grant_stmt",
 		replace: map[string]string{
-			"'GRANT' privileges 'ON' targets 'TO' name_list":                "",
-			"'GRANT' privilege_list 'TO' name_list 'WITH' 'ADMIN' 'OPTION'": "'GRANT' ( role_name ) ( ( ',' role_name ) )* 'TO' ( user_name ) ( ( ',' user_name ) )* 'WITH' 'ADMIN' 'OPTION'",
-			"| 'GRANT' privilege_list 'TO' name_list":                       "'GRANT' ( role_name ) ( ( ',' role_name ) )* 'TO' ( user_name ) ( ( ',' user_name ) )*",
+			"'GRANT' privileges 'ON' targets 'TO' name_list":                     "",
+			"| 'GRANT' privileges '(' name_list ')' 'ON' targets 'TO' name_list": "",
+			"'GRANT' privilege_list 'TO' name_list 'WITH' 'ADMIN' 'OPTION'":      "'GRANT' ( role_name ) ( ( ',' role_name ) )* 'TO' ( user_name ) ( ( ',' user_name ) )* 'WITH' 'ADMIN' 'OPTION'",
+			"| 'GRANT' privilege_list 'TO' name_list":                            "'GRANT' ( role_name ) ( ( ',' role_name ) )* 'TO' ( user_name ) ( ( ',' user_name ) )*",
 		},
 		unlink: []string{"role_name", "user_name"},
 	},
@@ -843,10 +883,16 @@ var specs = []stmtSpec{
 	},
 	{
 		name:    "pause_job",
-		stmt:    "pause_stmt",
+		stmt:    "pause_jobs_stmt",
 		replace: map[string]string{"a_expr": "job_id"},
 		unlink:  []string{"job_id"},
 	},
+	{
+		name:    "pause_schedule",
+		stmt:    "pause_schedules_stmt",
+		replace: map[string]string{"a_expr": "schedule_id"},
+		unlink:  []string{"schedule_id"},
+	},
 	{
 		name: "primary_key_column_level",
 		stmt: "stmt_block",
@@ -916,10 +962,16 @@ var specs = []stmtSpec{
 	},
 	{
 		name:    "resume_job",
-		stmt:    "resume_stmt",
+		stmt:    "resume_jobs_stmt",
 		replace: map[string]string{"a_expr": "job_id"},
 		unlink:  []string{"job_id"},
 	},
+	{
+		name:    "resume_schedule",
+		stmt:    "resume_schedules_stmt",
+		replace: map[string]string{"a_expr": "schedule_id"},
+		unlink:  []string{"schedule_id"},
+	},
 	{
 		name:   "revoke_privileges",
 		stmt:   "revoke_stmt",
@@ -940,6 +992,7 @@ var specs = []stmtSpec{
 		stmt: "revoke_stmt",
 		replace: map[string]string{
 			"'REVOKE' privileges 'ON' targets 'FROM' name_list":               "",
+			"| 'REVOKE' privileges '(' name_list ')' 'ON' targets 'FROM' name_list": "",
 			"'REVOKE' 'ADMIN' 'OPTION' 'FOR' privilege_list 'FROM' name_list": "'REVOKE' 'ADMIN' 'OPTION' 'FOR' ( role_name ) ( ( ',' role_name ) )* 'FROM' ( user_name ) ( ( ',' user_name ) )*",
 			"| 'REVOKE' privilege_list 'FROM' name_list":                      "'REVOKE' ( role_name ) ( ( ',' role_name ) )* 'FROM' ( user_name ) ( ( ',' user_name ) )*",
 		},
@@ -1036,13 +1089,14 @@ var specs = []stmtSpec{
 			regexp.MustCompile(`'SET' . 'TRANSACTION'`),
 			regexp.MustCompile(`'SET' 'TRANSACTION'`),
 			regexp.MustCompile(`'SET' 'SESSION' var_name`),
+			regexp.MustCompile(`'SET' 'LOCAL' var_name`),
 			regexp.MustCompile(`'SET' 'SESSION' 'TRANSACTION'`),
 			regexp.MustCompile(`'SET' 'SESSION' 'CHARACTERISTICS'`),
 			regexp.MustCompile("'SET' 'CLUSTER'"),
 		},
 		replace: map[string]string{
 			"'=' 'DEFAULT'":  "'=' 'DEFAULT' | 'SET' 'TIME' 'ZONE' ( var_value | 'DEFAULT' | 'LOCAL' )",
-			"'SET' var_name": "'SET' ( 'SESSION' | ) var_name",
+			"'SET' var_name": "'SET' ( 'SESSION' | 'LOCAL' | ) var_name",
 		},
 	},
 	{
@@ -1134,6 +1188,10 @@ var specs = []stmtSpec{
 		stmt:   "show_jobs_stmt",
 		inline: []string{"opt_automatic"},
 	},
+	{
+		name: "show_schedules",
+		stmt: "show_schedules_stmt",
+	},
 	{
 		name:  "show_keys",
 		stmt:  "show_stmt",
@@ -494,6 +494,7 @@ var inputs = map[string][]string{
 		`array[1,NULL]::numeric[]`,
 		`array['test',NULL]::text[]`,
 		`array['test',NULL]::name[]`,
+		`(1::int8,null::int8)`,
 	},
 
 	"(%s,null)": {
@@ -351,6 +351,15 @@ func (z *ZoneConfig) IsComplete() bool {
 		(!z.InheritedConstraints) && (!z.InheritedLeasePreferences))
 }
 
+// GetNumVoters returns the number of voting replicas a range in this zone
+// should have. If NumVoters is unset, every replica is a voter.
+func (z *ZoneConfig) GetNumVoters() int32 {
+	if z.NumVoters != nil {
+		return *z.NumVoters
+	}
+	return *z.NumReplicas
+}
+
 // ValidateTandemFields returns an error if the ZoneConfig to be written
 // specifies a configuration that could cause problems with the introduction
 // of cascading zone configs.
@@ -398,6 +407,18 @@ func (z *ZoneConfig) Validate() error {
 		}
 	}
 
+	if z.NumVoters != nil {
+		switch {
+		case *z.NumVoters <= 0:
+			return fmt.Errorf("at least one voting replica is required")
+		case *z.NumVoters == 2:
+			return fmt.Errorf("at least 3 voting replicas are required for multi-replica configurations")
+		case z.NumReplicas != nil && *z.NumVoters > *z.NumReplicas:
+			return fmt.Errorf("num_voters (%d) cannot be greater than num_replicas (%d)",
+				*z.NumVoters, *z.NumReplicas)
+		}
+	}
+
 	if z.RangeMaxBytes != nil && *z.RangeMaxBytes < minRangeMaxBytes {
 		return fmt.Errorf("RangeMaxBytes %d less than minimum allowed %d",
 			*z.RangeMaxBytes, minRangeMaxBytes)
@@ -474,6 +495,11 @@ func (z *ZoneConfig) InheritFromParent(parent *ZoneConfig) {
 			z.NumReplicas = proto.Int32(*parent.NumReplicas)
 		}
 	}
+	if z.NumVoters == nil {
+		if parent.NumVoters != nil {
+			z.NumVoters = proto.Int32(*parent.NumVoters)
+		}
+	}
 	if z.RangeMinBytes == nil {
 		if parent.RangeMinBytes != nil {
 			z.RangeMinBytes = proto.Int64(*parent.RangeMinBytes)
@@ -513,6 +539,12 @@ func (z *ZoneConfig) CopyFromZone(other ZoneConfig, fieldList []tree.Name) {
 				z.NumReplicas = proto.Int32(*other.NumReplicas)
 			}
 		}
+		if fieldName == "num_voters" {
+			z.NumVoters = nil
+			if other.NumVoters != nil {
+				z.NumVoters = proto.Int32(*other.NumVoters)
+			}
+		}
 		if fieldName == "range_min_bytes" {
 			z.RangeMinBytes = nil
 			if other.RangeMinBytes != nil {
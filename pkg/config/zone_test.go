@@ -50,6 +50,35 @@ func TestZoneConfigValidate(t *testing.T) {
 			},
 			"at least 3 replicas are required for multi-replica configurations",
 		},
+		{
+			ZoneConfig{
+				NumReplicas: proto.Int32(3),
+				NumVoters:   proto.Int32(0),
+			},
+			"at least one voting replica is required",
+		},
+		{
+			ZoneConfig{
+				NumReplicas: proto.Int32(5),
+				NumVoters:   proto.Int32(2),
+			},
+			"at least 3 voting replicas are required for multi-replica configurations",
+		},
+		{
+			ZoneConfig{
+				NumReplicas: proto.Int32(3),
+				NumVoters:   proto.Int32(5),
+			},
+			"num_voters \\(5\\) cannot be greater than num_replicas \\(3\\)",
+		},
+		{
+			ZoneConfig{
+				NumReplicas:   proto.Int32(5),
+				NumVoters:     proto.Int32(3),
+				RangeMaxBytes: DefaultZoneConfig().RangeMaxBytes,
+			},
+			"",
+		},
 		{
 			ZoneConfig{
 				NumReplicas:   proto.Int32(1),
@@ -351,12 +351,29 @@ func (b *Batch) AddRawRequest(reqs ...roachpb.Request) {
 //
 // key can be either a byte slice or a string.
 func (b *Batch) Get(key interface{}) {
+	b.get(key, false /* forUpdate */)
+}
+
+// GetForUpdate retrieves the value for a key like Get. If the batch is run in
+// a transaction, it also acquires an exclusive, unreplicated lock on the key
+// if the key exists. The lock is held until the transaction finishes.
+//
+// key can be either a byte slice or a string.
+func (b *Batch) GetForUpdate(key interface{}) {
+	b.get(key, true /* forUpdate */)
+}
+
+func (b *Batch) get(key interface{}, forUpdate bool) {
 	k, err := marshalKey(key)
 	if err != nil {
 		b.initResult(0, 1, notRaw, err)
 		return
 	}
-	b.appendReqs(roachpb.NewGet(k))
+	get := roachpb.NewGet(k).(*roachpb.GetRequest)
+	if forUpdate {
+		get.KeyLocking = roachpb.FOR_UPDATE
+	}
+	b.appendReqs(get)
 	b.initResult(1, 1, notRaw, nil)
 }
 
@@ -486,7 +503,7 @@ func (b *Batch) Inc(key interface{}, value int64) {
 	b.initResult(1, 1, notRaw, nil)
 }
 
-func (b *Batch) scan(s, e interface{}, isReverse bool) {
+func (b *Batch) scan(s, e interface{}, isReverse, forUpdate bool) {
 	begin, err := marshalKey(s)
 	if err != nil {
 		b.initResult(0, 0, notRaw, err)
@@ -497,10 +514,18 @@ func (b *Batch) scan(s, e interface{}, isReverse bool) {
 		b.initResult(0, 0, notRaw, err)
 		return
 	}
+	keyLocking := roachpb.NON_LOCKING
+	if forUpdate {
+		keyLocking = roachpb.FOR_UPDATE
+	}
 	if !isReverse {
-		b.appendReqs(roachpb.NewScan(begin, end))
+		scan := roachpb.NewScan(begin, end).(*roachpb.ScanRequest)
+		scan.KeyLocking = keyLocking
+		b.appendReqs(scan)
 	} else {
-		b.appendReqs(roachpb.NewReverseScan(begin, end))
+		scan := roachpb.NewReverseScan(begin, end).(*roachpb.ReverseScanRequest)
+		scan.KeyLocking = keyLocking
+		b.appendReqs(scan)
 	}
 	b.initResult(1, 0, notRaw, nil)
 }
@@ -513,7 +538,17 @@ func (b *Batch) scan(s, e interface{}, isReverse bool) {
 //
 // key can be either a byte slice or a string.
 func (b *Batch) Scan(s, e interface{}) {
-	b.scan(s, e, false)
+	b.scan(s, e, false /* isReverse */, false /* forUpdate */)
+}
+
+// ScanForUpdate retrieves the key/values between begin (inclusive) and end
+// (exclusive) in ascending order like Scan. If the batch is run in a
+// transaction, it also acquires exclusive, unreplicated locks on all of the
+// returned keys. The locks are held until the transaction finishes.
+//
+// key can be either a byte slice or a string.
+func (b *Batch) ScanForUpdate(s, e interface{}) {
+	b.scan(s, e, false /* isReverse */, true /* forUpdate */)
 }
 
 // ReverseScan retrieves the rows between begin (inclusive) and end (exclusive)
@@ -524,7 +559,17 @@ func (b *Batch) Scan(s, e interface{}) {
 //
 // key can be either a byte slice or a string.
 func (b *Batch) ReverseScan(s, e interface{}) {
-	b.scan(s, e, true)
+	b.scan(s, e, true /* isReverse */, false /* forUpdate */)
+}
+
+// ReverseScanForUpdate retrieves the rows between begin (inclusive) and end
+// (exclusive) in descending order like ReverseScan. If the batch is run in a
+// transaction, it also acquires exclusive, unreplicated locks on all of the
+// returned keys. The locks are held until the transaction finishes.
+//
+// key can be either a byte slice or a string.
+func (b *Batch) ReverseScanForUpdate(s, e interface{}) {
+	b.scan(s, e, true /* isReverse */, true /* forUpdate */)
 }
 
 // Del deletes one or more keys.
@@ -568,6 +613,69 @@ func (b *Batch) DelRange(s, e interface{}, returnKeys bool) {
 	b.initResult(1, 0, notRaw, nil)
 }
 
+// DelRangeUsingTombstone deletes the rows between begin (inclusive) and end
+// (exclusive) by writing an MVCC range tombstone, whose cost doesn't depend on
+// the number of rows deleted. It cannot be used within a transaction, and
+// requires the cluster version VersionMVCCRangeTombstones.
+//
+// A new result will be appended to the batch which will contain 0 rows and
+// Result.Err will indicate success or failure.
+//
+// key can be either a byte slice or a string.
+func (b *Batch) DelRangeUsingTombstone(s, e interface{}) {
+	begin, err := marshalKey(s)
+	if err != nil {
+		b.initResult(0, 0, notRaw, err)
+		return
+	}
+	end, err := marshalKey(e)
+	if err != nil {
+		b.initResult(0, 0, notRaw, err)
+		return
+	}
+	b.appendReqs(&roachpb.DeleteRangeRequest{
+		RequestHeader: roachpb.RequestHeader{
+			Key:    begin,
+			EndKey: end,
+		},
+		UseRangeTombstone: true,
+	})
+	b.initResult(1, 0, notRaw, nil)
+}
+
+// DelRangeInsertedAfter deletes the rows between begin (inclusive) and end
+// (exclusive) which were inserted after the given timestamp, i.e. the rows
+// which didn't exist at that timestamp. Rows which existed at the timestamp
+// are left untouched, even if they were updated since. If until is set, rows
+// last written above it are left untouched as well. It cannot be used within
+// a transaction, and requires the cluster version VersionOnlineImport.
+//
+// A new result will be appended to the batch which will contain 0 rows and
+// Result.Err will indicate success or failure.
+//
+// key can be either a byte slice or a string.
+func (b *Batch) DelRangeInsertedAfter(s, e interface{}, ts, until hlc.Timestamp) {
+	begin, err := marshalKey(s)
+	if err != nil {
+		b.initResult(0, 0, notRaw, err)
+		return
+	}
+	end, err := marshalKey(e)
+	if err != nil {
+		b.initResult(0, 0, notRaw, err)
+		return
+	}
+	b.appendReqs(&roachpb.DeleteRangeRequest{
+		RequestHeader: roachpb.RequestHeader{
+			Key:    begin,
+			EndKey: end,
+		},
+		OnlyInsertedAfter: ts,
+		OnlyInsertedUntil: until,
+	})
+	b.initResult(1, 0, notRaw, nil)
+}
+
 // adminMerge is only exported on DB. It is here for symmetry with the
 // other operations.
 func (b *Batch) adminMerge(key interface{}) {
@@ -707,7 +815,9 @@ func (b *Batch) writeBatch(s, e interface{}, data []byte) {
 }
 
 // addSSTable is only exported on DB.
-func (b *Batch) addSSTable(s, e interface{}, data []byte) {
+func (b *Batch) addSSTable(
+	s, e interface{}, data []byte, disallowShadowing, writeAtRequestTimestamp bool,
+) {
 	begin, err := marshalKey(s)
 	if err != nil {
 		b.initResult(0, 0, notRaw, err)
@@ -723,7 +833,9 @@ func (b *Batch) addSSTable(s, e interface{}, data []byte) {
 			Key:    begin,
 			EndKey: end,
 		},
-		Data: data,
+		Data:                    data,
+		DisallowShadowing:       disallowShadowing,
+		WriteAtRequestTimestamp: writeAtRequestTimestamp,
 	}
 	b.appendReqs(req)
 	b.initResult(1, 0, notRaw, nil)
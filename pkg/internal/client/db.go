@@ -453,6 +453,16 @@ func (db *DB) DelRange(ctx context.Context, begin, end interface{}) error {
 	return getOneErr(db.Run(ctx, b), b)
 }
 
+// DelRangeUsingTombstone deletes the rows between begin (inclusive) and end
+// (exclusive) with an MVCC range tombstone. See Batch.DelRangeUsingTombstone.
+//
+// key can be either a byte slice or a string.
+func (db *DB) DelRangeUsingTombstone(ctx context.Context, begin, end interface{}) error {
+	b := &Batch{}
+	b.DelRangeUsingTombstone(begin, end)
+	return getOneErr(db.Run(ctx, b), b)
+}
+
 // AdminMerge merges the range containing key and the subsequent
 // range. After the merge operation is complete, the range containing
 // key will contain all of the key/value pairs of the subsequent range
@@ -567,12 +577,35 @@ func (db *DB) WriteBatch(ctx context.Context, begin, end interface{}, data []byt
 
 // AddSSTable links a file into the RocksDB log-structured merge-tree. Existing
 // data in the range is cleared.
-func (db *DB) AddSSTable(ctx context.Context, begin, end interface{}, data []byte) error {
+//
+// If disallowShadowing is set, the file must not shadow existing live values
+// unless they are identical. If writeAtRequestTimestamp is set, the keys of
+// the file are written at the timestamp of the request rather than their own.
+func (db *DB) AddSSTable(
+	ctx context.Context,
+	begin, end interface{},
+	data []byte,
+	disallowShadowing, writeAtRequestTimestamp bool,
+) error {
 	b := &Batch{}
-	b.addSSTable(begin, end, data)
+	b.addSSTable(begin, end, data, disallowShadowing, writeAtRequestTimestamp)
 	return getOneErr(db.Run(ctx, b), b)
 }
 
+// AddSSTableAtRequestTimestamp is like AddSSTable with writeAtRequestTimestamp
+// set, but additionally returns the timestamp at which the keys of the file
+// were written.
+func (db *DB) AddSSTableAtRequestTimestamp(
+	ctx context.Context, begin, end interface{}, data []byte, disallowShadowing bool,
+) (hlc.Timestamp, error) {
+	b := &Batch{}
+	b.addSSTable(begin, end, data, disallowShadowing, true /* writeAtRequestTimestamp */)
+	if err := getOneErr(db.Run(ctx, b), b); err != nil {
+		return hlc.Timestamp{}, err
+	}
+	return b.RawResponse().Timestamp, nil
+}
+
 // sendAndFill is a helper which sends the given batch and fills its results,
 // returning the appropriate error which is either from the first failing call,
 // or an "internal" error.
@@ -77,6 +77,10 @@ type Txn struct {
 		// The txn has to be committed by this deadline. A nil value indicates no
 		// deadline.
 		deadline *hlc.Timestamp
+
+		// routingPolicy is attached to all requests sent through this
+		// transaction. See SetRoutingPolicy.
+		routingPolicy roachpb.RoutingPolicy
 	}
 }
 
@@ -311,6 +315,18 @@ func (txn *Txn) Get(ctx context.Context, key interface{}) (KeyValue, error) {
 	return getOneRow(txn.Run(ctx, b), b)
 }
 
+// GetForUpdate retrieves the value for a key like Get and acquires an
+// exclusive, unreplicated lock on the key if it exists. The lock blocks
+// writes and locking reads by other transactions until this transaction
+// finishes.
+//
+// key can be either a byte slice or a string.
+func (txn *Txn) GetForUpdate(ctx context.Context, key interface{}) (KeyValue, error) {
+	b := txn.NewBatch()
+	b.GetForUpdate(key)
+	return getOneRow(txn.Run(ctx, b), b)
+}
+
 // GetProto retrieves the value for a key and decodes the result as a proto
 // message. If the key doesn't exist, the proto will simply be reset.
 //
@@ -377,17 +393,13 @@ func (txn *Txn) Inc(ctx context.Context, key interface{}, value int64) (KeyValue
 }
 
 func (txn *Txn) scan(
-	ctx context.Context, begin, end interface{}, maxRows int64, isReverse bool,
+	ctx context.Context, begin, end interface{}, maxRows int64, isReverse, forUpdate bool,
 ) ([]KeyValue, error) {
 	b := txn.NewBatch()
 	if maxRows > 0 {
 		b.Header.MaxSpanRequestKeys = maxRows
 	}
-	if !isReverse {
-		b.Scan(begin, end)
-	} else {
-		b.ReverseScan(begin, end)
-	}
+	b.scan(begin, end, isReverse, forUpdate)
 	r, err := getOneResult(txn.Run(ctx, b), b)
 	return r.Rows, err
 }
@@ -402,7 +414,22 @@ func (txn *Txn) scan(
 func (txn *Txn) Scan(
 	ctx context.Context, begin, end interface{}, maxRows int64,
 ) ([]KeyValue, error) {
-	return txn.scan(ctx, begin, end, maxRows, false)
+	return txn.scan(ctx, begin, end, maxRows, false /* isReverse */, false /* forUpdate */)
+}
+
+// ScanForUpdate retrieves the rows between begin (inclusive) and end
+// (exclusive) in ascending order like Scan and acquires exclusive,
+// unreplicated locks on all of the returned keys. The locks block writes and
+// locking reads by other transactions until this transaction finishes.
+//
+// The returned []KeyValue will contain up to maxRows elements (or all results
+// when zero is supplied).
+//
+// key can be either a byte slice or a string.
+func (txn *Txn) ScanForUpdate(
+	ctx context.Context, begin, end interface{}, maxRows int64,
+) ([]KeyValue, error) {
+	return txn.scan(ctx, begin, end, maxRows, false /* isReverse */, true /* forUpdate */)
 }
 
 // ReverseScan retrieves the rows between begin (inclusive) and end (exclusive)
@@ -415,7 +442,7 @@ func (txn *Txn) Scan(
 func (txn *Txn) ReverseScan(
 	ctx context.Context, begin, end interface{}, maxRows int64,
 ) ([]KeyValue, error) {
-	return txn.scan(ctx, begin, end, maxRows, true)
+	return txn.scan(ctx, begin, end, maxRows, true /* isReverse */, false /* forUpdate */)
 }
 
 // Iterate performs a paginated scan and applying the function f to every page.
@@ -787,6 +814,9 @@ func (txn *Txn) Send(
 	txn.mu.Lock()
 	requestTxnID := txn.mu.ID
 	sender := txn.mu.sender
+	if txn.mu.routingPolicy != roachpb.LEASEHOLDER {
+		ba.Header.RoutingPolicy = txn.mu.routingPolicy
+	}
 	txn.mu.Unlock()
 	br, pErr := txn.db.sendUsingSender(ctx, ba, sender)
 	if pErr == nil {
@@ -937,6 +967,23 @@ func (txn *Txn) SetFixedTimestamp(ctx context.Context, ts hlc.Timestamp) {
 	txn.mu.sender.SetFixedTimestamp(ctx, ts)
 }
 
+// SetRoutingPolicy sets the routing policy attached to all requests sent
+// through the transaction. It is used by bounded staleness reads, which fix
+// the transaction's timestamp to one that the nearest replicas are known to be
+// able to serve, and so set it to roachpb.NEAREST.
+func (txn *Txn) SetRoutingPolicy(policy roachpb.RoutingPolicy) {
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	txn.mu.routingPolicy = policy
+}
+
+// RoutingPolicy returns the routing policy of the transaction.
+func (txn *Txn) RoutingPolicy() roachpb.RoutingPolicy {
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	return txn.mu.routingPolicy
+}
+
 // GenerateForcedRetryableError returns a TransactionRetryWithProtoRefreshError that will
 // cause the txn to be retried.
 //
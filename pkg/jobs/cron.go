@@ -0,0 +1,193 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package jobs
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CronExpr is a parsed cron expression, as used by system.scheduled_jobs to
+// describe when a schedule runs. The standard five field form (minute, hour,
+// day of month, month, day of week) is supported, along with the @yearly,
+// @annually, @monthly, @weekly, @daily, @midnight and @hourly shorthands. All
+// times are interpreted in UTC.
+type CronExpr struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record whether the day of month and day of week
+	// fields were unrestricted. As in cron(8), when both are restricted a time
+	// matches if either of them does.
+	domStar, dowStar bool
+}
+
+var cronShorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronDayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// cronSearchLimit bounds how far into the future Next looks for a matching
+// time, so that expressions which can never match (e.g. February 30th) do not
+// loop forever.
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// ParseCronExpr parses a cron expression. An error is returned if the
+// expression is malformed or never matches any time.
+func ParseCronExpr(expr string) (*CronExpr, error) {
+	spec := strings.TrimSpace(expr)
+	if full, ok := cronShorthands[strings.ToLower(spec)]; ok {
+		spec = full
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.Errorf(
+			"invalid cron expression %q: expected 5 fields, found %d", expr, len(fields))
+	}
+
+	var e CronExpr
+	var err error
+	if e.minute, _, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression %q: minute", expr)
+	}
+	if e.hour, _, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression %q: hour", expr)
+	}
+	if e.dom, e.domStar, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression %q: day of month", expr)
+	}
+	if e.month, _, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression %q: month", expr)
+	}
+	// Both 0 and 7 mean Sunday.
+	if e.dow, e.dowStar, err = parseCronField(fields[4], 0, 7, cronDayNames); err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression %q: day of week", expr)
+	}
+	if e.dow&(1<<7) != 0 {
+		e.dow |= 1
+	}
+
+	if e.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, errors.Errorf("invalid cron expression %q: never matches", expr)
+	}
+	return &e, nil
+}
+
+// parseCronField parses a single, comma separated, cron field into a bitset
+// of the values it matches. It also reports whether the field was "*".
+func parseCronField(
+	field string, min, max int, names map[string]int,
+) (bits uint64, star bool, err error) {
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			rangePart = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, false, errors.Errorf("invalid step in %q", part)
+			}
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+			star = true
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			if lo, err = parseCronValue(bounds[0], names); err != nil {
+				return 0, false, err
+			}
+			if hi, err = parseCronValue(bounds[1], names); err != nil {
+				return 0, false, err
+			}
+		default:
+			if lo, err = parseCronValue(rangePart, names); err != nil {
+				return 0, false, err
+			}
+			hi = lo
+			// "5/10" means every 10th value starting at 5.
+			if step != 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, false, errors.Errorf("%q is out of range [%d, %d]", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, star, nil
+}
+
+func parseCronValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+func (e *CronExpr) matchesDay(t time.Time) bool {
+	domMatch := e.dom&(1<<uint(t.Day())) != 0
+	dowMatch := e.dow&(1<<uint(t.Weekday())) != 0
+	if e.domStar || e.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next returns the first time strictly after the given one at which the
+// expression matches, or the zero time if there is no such time in the
+// foreseeable future. The returned time is in UTC and has no seconds.
+func (e *CronExpr) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+	for t.Before(limit) {
+		if e.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !e.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if e.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if e.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
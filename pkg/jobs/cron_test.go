@@ -0,0 +1,84 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestCronExpr(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// 2019-10-16 was a Wednesday.
+	start := time.Date(2019, 10, 16, 10, 30, 15, 0, time.UTC)
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2019, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		expr string
+		next []time.Time
+		err  string
+	}{
+		{expr: "@hourly", next: []time.Time{at(10, 16, 11, 0), at(10, 16, 12, 0)}},
+		{expr: "@daily", next: []time.Time{at(10, 17, 0, 0), at(10, 18, 0, 0)}},
+		{expr: "@weekly", next: []time.Time{at(10, 20, 0, 0), at(10, 27, 0, 0)}},
+		{expr: "@monthly", next: []time.Time{at(11, 1, 0, 0), at(12, 1, 0, 0)}},
+		{
+			expr: "@yearly",
+			next: []time.Time{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{expr: "*/20 * * * *", next: []time.Time{at(10, 16, 10, 40), at(10, 16, 11, 0)}},
+		{expr: "15,45 9-11 * * *", next: []time.Time{at(10, 16, 10, 45), at(10, 16, 11, 15)}},
+		{expr: "0 22 * * mon-fri", next: []time.Time{at(10, 16, 22, 0), at(10, 17, 22, 0)}},
+		{expr: "0 0 * * 7", next: []time.Time{at(10, 20, 0, 0)}},
+		{expr: "0 3 1 dec *", next: []time.Time{at(12, 1, 3, 0)}},
+		// When both day fields are restricted, either may match.
+		{expr: "0 0 1 * fri", next: []time.Time{at(10, 18, 0, 0), at(10, 25, 0, 0)}},
+		{expr: "0 0 5/10 * *", next: []time.Time{at(10, 25, 0, 0), at(11, 5, 0, 0)}},
+		{expr: "0 0 29 2 *", next: []time.Time{time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)}},
+
+		{expr: "", err: "expected 5 fields"},
+		{expr: "@fortnightly", err: "expected 5 fields"},
+		{expr: "* * * *", err: "expected 5 fields"},
+		{expr: "60 * * * *", err: "out of range"},
+		{expr: "* 5-2 * * *", err: "out of range"},
+		{expr: "* * 0 * *", err: "out of range"},
+		{expr: "*/0 * * * *", err: "invalid step"},
+		{expr: "* * * foo *", err: "invalid value"},
+		{expr: "0 0 30 2 *", err: "never matches"},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			e, err := ParseCronExpr(test.expr)
+			if !testutils.IsError(err, test.err) {
+				t.Fatalf("expected error %q got %v", test.err, err)
+			}
+			if err != nil {
+				return
+			}
+			prev := start
+			for _, expected := range test.next {
+				next := e.Next(prev)
+				if !next.Equal(expected) {
+					t.Fatalf("expected next run after %s to be %s, got %s", prev, expected, next)
+				}
+				prev = next
+			}
+		})
+	}
+}
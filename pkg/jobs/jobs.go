@@ -21,6 +21,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
@@ -40,7 +41,9 @@ type Job struct {
 
 	id  *int64
 	txn *client.Txn
-	mu  struct {
+	// onCreate is the OnCreate hook of the Record the job was created from.
+	onCreate func(ctx context.Context, txn *client.Txn, jobID int64) error
+	mu       struct {
 		syncutil.Mutex
 		payload  jobspb.Payload
 		progress jobspb.Progress
@@ -56,6 +59,10 @@ type Record struct {
 	Details       jobspb.Details
 	Progress      jobspb.ProgressDetails
 	RunningStatus RunningStatus
+	// OnCreate, if set, is called with the ID of the job in the transaction
+	// which inserts it into the system.jobs table, which allows writes that
+	// the job relies upon to be made atomically with its creation.
+	OnCreate func(ctx context.Context, txn *client.Txn, jobID int64) error
 }
 
 // Status represents the status of a job in the system.jobs table.
@@ -207,8 +214,12 @@ func FractionUpdater(f float32) FractionProgressedFn {
 
 // HighWaterProgressedFn is a callback that computes a job's high-water mark
 // given its details. It is safe to modify details in the callback; those
-// modifications will be automatically persisted to the database record.
-type HighWaterProgressedFn func(ctx context.Context, details jobspb.ProgressDetails) hlc.Timestamp
+// modifications will be automatically persisted to the database record. The
+// callback runs in the transaction which updates the job record and may use
+// txn to make other writes atomically with the update.
+type HighWaterProgressedFn func(
+	ctx context.Context, txn *client.Txn, details jobspb.ProgressDetails,
+) (hlc.Timestamp, error)
 
 // FractionProgressed updates the progress of the tracked job. It sets the job's
 // FractionCompleted field to the value returned by progressedFn and persists
@@ -248,7 +259,10 @@ func (j *Job) HighWaterProgressed(ctx context.Context, progressedFn HighWaterPro
 		if err := md.CheckRunning(); err != nil {
 			return err
 		}
-		highWater := progressedFn(ctx, md.Progress.Details)
+		highWater, err := progressedFn(ctx, txn, md.Progress.Details)
+		if err != nil {
+			return err
+		}
 		if highWater.Less(hlc.Timestamp{}) {
 			return errors.Errorf(
 				"Job: high-water %s is outside allowable range > 0.0 (job %d)",
@@ -409,6 +423,13 @@ func (j *Job) Progress() jobspb.Progress {
 	return j.mu.progress
 }
 
+// ProtectedTimestamps returns the protected timestamp provider which the job
+// may use to protect the data it reads from garbage collection. It is nil if
+// the registry was created without one.
+func (j *Job) ProtectedTimestamps() protectedts.Provider {
+	return j.registry.protectedTimestamps
+}
+
 // Details returns the details from the most recently sent Payload for this Job.
 func (j *Job) Details() jobspb.Details {
 	j.mu.Lock()
@@ -492,8 +513,15 @@ func (j *Job) insert(ctx context.Context, id int64, lease *jobspb.Lease) error {
 		}
 
 		const stmt = "INSERT INTO system.jobs (id, status, payload, progress) VALUES ($1, $2, $3, $4)"
-		_, err = j.registry.ex.Exec(ctx, "job-insert", txn, stmt, id, StatusPending, payloadBytes, progressBytes)
-		return err
+		if _, err := j.registry.ex.Exec(
+			ctx, "job-insert", txn, stmt, id, StatusPending, payloadBytes, progressBytes,
+		); err != nil {
+			return err
+		}
+		if j.onCreate != nil {
+			return j.onCreate(ctx, txn, id)
+		}
+		return nil
 	}); err != nil {
 		return err
 	}
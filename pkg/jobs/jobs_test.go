@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -966,7 +967,9 @@ func TestJobLifecycle(t *testing.T) {
 		}
 		for _, ts := range highWaters {
 			if err := job.HighWaterProgressed(
-				ctx, func(context.Context, jobspb.ProgressDetails) hlc.Timestamp { return ts },
+				ctx, func(context.Context, *client.Txn, jobspb.ProgressDetails) (hlc.Timestamp, error) {
+					return ts, nil
+				},
 			); err != nil {
 				t.Fatal(err)
 			}
@@ -989,8 +992,8 @@ func TestJobLifecycle(t *testing.T) {
 			t.Fatalf("expected 'outside allowable range' error, but got %v", err)
 		}
 		if err := job.HighWaterProgressed(
-			ctx, func(context.Context, jobspb.ProgressDetails) hlc.Timestamp {
-				return hlc.Timestamp{WallTime: -1}
+			ctx, func(context.Context, *client.Txn, jobspb.ProgressDetails) (hlc.Timestamp, error) {
+				return hlc.Timestamp{WallTime: -1}, nil
 			},
 		); !testutils.IsError(err, "outside allowable range") {
 			t.Fatalf("expected 'outside allowable range' error, but got %v", err)
@@ -1213,3 +1216,55 @@ func TestRunAndWaitForTerminalState(t *testing.T) {
 		})
 	}
 }
+
+func TestJobOnCreate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+	registry := s.JobRegistry().(*jobs.Registry)
+
+	key := roachpb.Key("on-create")
+	var createdID int64
+	record := jobs.Record{
+		Details:  jobspb.BackupDetails{},
+		Progress: jobspb.BackupProgress{},
+		OnCreate: func(ctx context.Context, txn *client.Txn, jobID int64) error {
+			createdID = jobID
+			return txn.Put(ctx, key, jobID)
+		},
+	}
+
+	// The hook's writes are committed along with the job.
+	job := registry.NewJob(record)
+	if err := job.Created(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if createdID != *job.ID() {
+		t.Fatalf("expected hook to be called with job %d, got %d", *job.ID(), createdID)
+	}
+	if kv, err := kvDB.Get(ctx, key); err != nil {
+		t.Fatal(err)
+	} else if kv.ValueInt() != createdID {
+		t.Fatalf("expected %d, got %d", createdID, kv.ValueInt())
+	}
+
+	// An error from the hook prevents the job from being created.
+	record.OnCreate = func(ctx context.Context, txn *client.Txn, jobID int64) error {
+		createdID = jobID
+		return errors.New("hook failed")
+	}
+	if err := registry.NewJob(record).Created(ctx); !testutils.IsError(err, "hook failed") {
+		t.Fatalf("expected hook error, got %v", err)
+	}
+	var count int
+	if err := sqlDB.QueryRow(
+		`SELECT count(*) FROM system.jobs WHERE id = $1`, createdID,
+	).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("expected job %d not to exist", createdID)
+	}
+}
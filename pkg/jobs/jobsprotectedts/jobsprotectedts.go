@@ -0,0 +1,78 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+// Package jobsprotectedts contains utilities for creating and releasing
+// protected timestamp records which are owned by jobs.
+package jobsprotectedts
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+)
+
+// MetaType is the value used in the MetaType field of records created for
+// jobs. The Meta field of such records holds the encoded job ID.
+const MetaType = "jobs"
+
+// MakeRecord makes a protected timestamp record for the job with the given
+// ID which protects spans at ts.
+func MakeRecord(id uuid.UUID, jobID int64, ts hlc.Timestamp, spans []roachpb.Span) *ptpb.Record {
+	return &ptpb.Record{
+		ID:        id,
+		Timestamp: ts,
+		MetaType:  MetaType,
+		Meta:      encoding.EncodeVarintAscending(nil, jobID),
+		Spans:     spans,
+	}
+}
+
+// DecodeJobID decodes the job ID stored in the Meta field of a record created
+// by MakeRecord.
+func DecodeJobID(meta []byte) (int64, error) {
+	_, jobID, err := encoding.DecodeVarintAscending(meta)
+	if err != nil {
+		return 0, errors.Wrap(err, "decoding job ID")
+	}
+	return jobID, nil
+}
+
+// Release releases the record with the given ID. It is a no-op if id or pts
+// is nil or if the record has already been released, which makes it safe to
+// call from both the success and failure paths of a job.
+func Release(ctx context.Context, txn *client.Txn, pts protectedts.Storage, id *uuid.UUID) error {
+	if id == nil || pts == nil {
+		return nil
+	}
+	if err := pts.Release(ctx, txn, *id); err != nil && !errors.Is(err, protectedts.ErrNotExists) {
+		return err
+	}
+	return nil
+}
+
+// Verify verifies the record with the given ID. It is a no-op if id or pts is
+// nil. Jobs which write their record in the transaction that creates them call
+// it once that transaction has committed, so that they fail if the data they
+// protect may already have been garbage collected.
+func Verify(ctx context.Context, pts protectedts.Verifier, id *uuid.UUID) error {
+	if id == nil || pts == nil {
+		return nil
+	}
+	return pts.Verify(ctx, *id)
+}
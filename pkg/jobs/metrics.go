@@ -21,6 +21,7 @@ import (
 // Metrics are for production monitoring of each job type.
 type Metrics struct {
 	Changefeed metric.Struct
+	Scheduler  SchedulerMetrics
 }
 
 // MetricStruct implements the metric.Struct interface.
@@ -28,6 +29,7 @@ func (Metrics) MetricStruct() {}
 
 // InitHooks initializes the metrics for job monitoring.
 func (m *Metrics) InitHooks(histogramWindowInterval time.Duration) {
+	m.Scheduler = makeSchedulerMetrics()
 	if MakeChangefeedMetricsHook != nil {
 		m.Changefeed = MakeChangefeedMetricsHook(histogramWindowInterval)
 	}
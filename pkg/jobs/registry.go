@@ -28,6 +28,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -98,6 +99,10 @@ type Registry struct {
 	planFn   planHookMaker
 	metrics  Metrics
 
+	// protectedTimestamps is used by jobs to protect the data they read from
+	// garbage collection. It may be nil.
+	protectedTimestamps protectedts.Provider
+
 	mu struct {
 		syncutil.Mutex
 		// epoch is present to support older nodes that are not using
@@ -143,16 +148,18 @@ func MakeRegistry(
 	settings *cluster.Settings,
 	histogramWindowInterval time.Duration,
 	planFn planHookMaker,
+	protectedTimestamps protectedts.Provider,
 ) *Registry {
 	r := &Registry{
-		ac:       ac,
-		stopper:  stopper,
-		clock:    clock,
-		db:       db,
-		ex:       ex,
-		nodeID:   nodeID,
-		settings: settings,
-		planFn:   planFn,
+		ac:                  ac,
+		stopper:             stopper,
+		clock:               clock,
+		db:                  db,
+		ex:                  ex,
+		nodeID:              nodeID,
+		settings:            settings,
+		planFn:              planFn,
+		protectedTimestamps: protectedTimestamps,
 	}
 	r.mu.epoch = 1
 	r.mu.jobs = make(map[int64]context.CancelFunc)
@@ -230,6 +237,7 @@ func (r *Registry) StartJob(
 func (r *Registry) NewJob(record Record) *Job {
 	job := &Job{
 		registry: r,
+		onCreate: record.OnCreate,
 	}
 	job.mu.payload = jobspb.Payload{
 		Description:   record.Description,
@@ -245,6 +253,12 @@ func (r *Registry) NewJob(record Record) *Job {
 	return job
 }
 
+// ProtectedTimestamps returns the protected timestamp provider which the
+// registry's jobs use. It is nil if the registry was created without one.
+func (r *Registry) ProtectedTimestamps() protectedts.Provider {
+	return r.protectedTimestamps
+}
+
 // LoadJob loads an existing job with the given jobID from the system.jobs
 // table.
 func (r *Registry) LoadJob(ctx context.Context, jobID int64) (*Job, error) {
@@ -281,7 +295,8 @@ var DefaultAdoptInterval = 30 * time.Second
 const gcInterval = 1 * time.Hour
 
 // Start polls the current node for liveness failures and cancels all registered
-// jobs if it observes a failure.
+// jobs if it observes a failure. It also starts the job scheduler, which runs
+// the schedules stored in system.scheduled_jobs.
 func (r *Registry) Start(
 	ctx context.Context,
 	stopper *stop.Stopper,
@@ -329,6 +344,8 @@ func (r *Registry) Start(
 			}
 		}
 	})
+
+	r.startScheduler(stopper)
 	return nil
 }
 
@@ -0,0 +1,213 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/pkg/errors"
+)
+
+// ScheduledJob is a schedule stored in the system.scheduled_jobs table. Each
+// time a schedule is due, the job scheduler hands it to the
+// ScheduledJobExecutor registered for its ExecutorType.
+type ScheduledJob struct {
+	ID      int64
+	Name    string
+	Owner   string
+	Created time.Time
+	// NextRun is the time at which the schedule runs next. It is the zero time
+	// if the schedule is paused.
+	NextRun time.Time
+	// ScheduleExpr is the cron expression describing when the schedule runs.
+	ScheduleExpr string
+	ExecutorType string
+	// ExecutionArgs holds the executor specific arguments of the schedule.
+	ExecutionArgs []byte
+	// LastRunStatus is a human readable description of the outcome of the most
+	// recent run.
+	LastRunStatus string
+	// State holds executor specific state carried from one run to the next.
+	State []byte
+}
+
+// IsPaused returns whether the schedule is paused.
+func (s *ScheduledJob) IsPaused() bool {
+	return s.NextRun.IsZero()
+}
+
+const scheduledJobColumns = `schedule_id, schedule_name, owner, created, next_run,
+schedule_expr, executor_type, execution_args, last_run_status, schedule_state`
+
+func scheduledJobFromRow(row tree.Datums) (*ScheduledJob, error) {
+	if len(row) != 10 {
+		return nil, errors.Errorf("expected 10 columns for a schedule, got %d", len(row))
+	}
+	s := &ScheduledJob{
+		ID:            int64(tree.MustBeDInt(row[0])),
+		Name:          string(tree.MustBeDString(row[1])),
+		Owner:         string(tree.MustBeDString(row[2])),
+		Created:       tree.MustBeDTimestamp(row[3]).Time,
+		ScheduleExpr:  string(tree.MustBeDString(row[5])),
+		ExecutorType:  string(tree.MustBeDString(row[6])),
+		ExecutionArgs: []byte(tree.MustBeDBytes(row[7])),
+	}
+	if row[4] != tree.DNull {
+		s.NextRun = tree.MustBeDTimestamp(row[4]).Time
+	}
+	if row[8] != tree.DNull {
+		s.LastRunStatus = string(tree.MustBeDString(row[8]))
+	}
+	if row[9] != tree.DNull {
+		s.State = []byte(tree.MustBeDBytes(row[9]))
+	}
+	return s, nil
+}
+
+// nullIfZero returns nil for a zero time so that it is stored as NULL.
+func nullIfZero(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// nullIfEmpty returns nil for an empty string or byte slice so that it is
+// stored as NULL.
+func nullIfEmpty(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		if t == "" {
+			return nil
+		}
+	case []byte:
+		if len(t) == 0 {
+			return nil
+		}
+	}
+	return v
+}
+
+// CreateSchedule inserts a new schedule into system.scheduled_jobs using the
+// specified txn (may be nil) and sets its ID and creation time. If the
+// schedule's NextRun is unset, it is computed from its schedule expression.
+func (r *Registry) CreateSchedule(ctx context.Context, txn *client.Txn, s *ScheduledJob) error {
+	expr, err := ParseCronExpr(s.ScheduleExpr)
+	if err != nil {
+		return err
+	}
+	if s.NextRun.IsZero() {
+		s.NextRun = expr.Next(r.clock.PhysicalTime())
+	}
+	const stmt = `INSERT INTO system.scheduled_jobs (schedule_name, owner, next_run,
+schedule_expr, executor_type, execution_args, last_run_status, schedule_state)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING schedule_id, created`
+	row, err := r.ex.QueryRow(
+		ctx, "create-schedule", txn, stmt, s.Name, s.Owner, s.NextRun, s.ScheduleExpr,
+		s.ExecutorType, s.ExecutionArgs, nil /* last_run_status */, nullIfEmpty(s.State),
+	)
+	if err != nil {
+		return err
+	}
+	s.ID = int64(tree.MustBeDInt(row[0]))
+	s.Created = tree.MustBeDTimestamp(row[1]).Time
+	return nil
+}
+
+// LoadSchedule loads the schedule with the given ID from
+// system.scheduled_jobs using the specified txn (may be nil).
+func (r *Registry) LoadSchedule(
+	ctx context.Context, txn *client.Txn, id int64,
+) (*ScheduledJob, error) {
+	row, err := r.ex.QueryRow(
+		ctx, "load-schedule", txn,
+		`SELECT `+scheduledJobColumns+` FROM system.scheduled_jobs WHERE schedule_id = $1`, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, errors.Errorf("schedule with ID %d does not exist", id)
+	}
+	return scheduledJobFromRow(row)
+}
+
+// updateSchedule persists the mutable fields of the schedule.
+func (r *Registry) updateSchedule(ctx context.Context, txn *client.Txn, s *ScheduledJob) error {
+	const stmt = `UPDATE system.scheduled_jobs
+SET next_run = $2, last_run_status = $3, schedule_state = $4 WHERE schedule_id = $1`
+	n, err := r.ex.Exec(
+		ctx, "update-schedule", txn, stmt,
+		s.ID, nullIfZero(s.NextRun), nullIfEmpty(s.LastRunStatus), nullIfEmpty(s.State),
+	)
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return errors.Errorf("schedule with ID %d does not exist", s.ID)
+	}
+	return nil
+}
+
+// PauseSchedule pauses the schedule with the given ID using the specified txn
+// (may be nil). A paused schedule does not run until it is resumed; runs that
+// are already in progress are not affected.
+func (r *Registry) PauseSchedule(ctx context.Context, txn *client.Txn, id int64) error {
+	return r.updateScheduleInTxn(ctx, txn, id, func(s *ScheduledJob) error {
+		s.NextRun = time.Time{}
+		return nil
+	})
+}
+
+// ResumeSchedule resumes the paused schedule with the given ID using the
+// specified txn (may be nil). The schedule next runs at the first time after
+// now that its schedule expression matches. Resuming a schedule that is not
+// paused is a no-op.
+func (r *Registry) ResumeSchedule(ctx context.Context, txn *client.Txn, id int64) error {
+	return r.updateScheduleInTxn(ctx, txn, id, func(s *ScheduledJob) error {
+		if !s.IsPaused() {
+			return nil
+		}
+		expr, err := ParseCronExpr(s.ScheduleExpr)
+		if err != nil {
+			return err
+		}
+		s.NextRun = expr.Next(r.clock.PhysicalTime())
+		return nil
+	})
+}
+
+// updateScheduleInTxn loads the schedule with the given ID, applies fn to it
+// and persists the result, all in the specified txn. If txn is nil, a new
+// transaction is used.
+func (r *Registry) updateScheduleInTxn(
+	ctx context.Context, txn *client.Txn, id int64, fn func(s *ScheduledJob) error,
+) error {
+	update := func(ctx context.Context, txn *client.Txn) error {
+		s, err := r.LoadSchedule(ctx, txn, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+		return r.updateSchedule(ctx, txn, s)
+	}
+	if txn != nil {
+		return update(ctx, txn)
+	}
+	return r.db.Txn(ctx, update)
+}
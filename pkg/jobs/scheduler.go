@@ -0,0 +1,228 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+)
+
+var (
+	schedulerEnabledSetting = settings.RegisterBoolSetting(
+		"jobs.scheduler.enabled",
+		"enable the execution of schedules stored in system.scheduled_jobs",
+		true,
+	)
+	schedulerPaceSetting = settings.RegisterNonNegativeDurationSetting(
+		"jobs.scheduler.pace",
+		"how often to check for schedules that are due to run",
+		time.Minute,
+	)
+)
+
+// maxSchedulesPerPoll bounds the number of schedules claimed by a single poll
+// of system.scheduled_jobs; any remaining due schedules are claimed by the
+// next poll.
+const maxSchedulesPerPoll = 10
+
+// ScheduledJobExecutor performs the runs of schedules of a certain executor
+// type.
+type ScheduledJobExecutor interface {
+	// ExecuteJob performs a single run of the schedule. It is called once the
+	// run has been claimed by this node, outside of any transaction, and may
+	// block for as long as the run takes. The returned status is recorded as
+	// the schedule's last_run_status; a non-nil error marks the run as failed.
+	//
+	// ExecuteJob may modify schedule.State to carry state over to the next run.
+	// The new state is only persisted if no other run of the schedule persisted
+	// a new state since this run was claimed, so runs that overlap never
+	// overwrite a state they did not observe.
+	ExecuteJob(
+		ctx context.Context, ex sqlutil.InternalExecutor, schedule *ScheduledJob,
+	) (status string, err error)
+}
+
+var scheduledJobExecutors = make(map[string]ScheduledJobExecutor)
+
+// RegisterScheduledJobExecutor registers the executor used to run schedules of
+// the given executor type.
+func RegisterScheduledJobExecutor(executorType string, executor ScheduledJobExecutor) {
+	scheduledJobExecutors[executorType] = executor
+}
+
+var (
+	metaSchedulesStarted = metric.Metadata{
+		Name:        "schedules.started",
+		Help:        "Number of runs of scheduled jobs started",
+		Measurement: "Runs",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaSchedulesSucceeded = metric.Metadata{
+		Name:        "schedules.succeeded",
+		Help:        "Number of runs of scheduled jobs that succeeded",
+		Measurement: "Runs",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaSchedulesFailed = metric.Metadata{
+		Name:        "schedules.failed",
+		Help:        "Number of runs of scheduled jobs that failed",
+		Measurement: "Runs",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// SchedulerMetrics are the metrics of the job scheduler.
+type SchedulerMetrics struct {
+	Started   *metric.Counter
+	Succeeded *metric.Counter
+	Failed    *metric.Counter
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (SchedulerMetrics) MetricStruct() {}
+
+func makeSchedulerMetrics() SchedulerMetrics {
+	return SchedulerMetrics{
+		Started:   metric.NewCounter(metaSchedulesStarted),
+		Succeeded: metric.NewCounter(metaSchedulesSucceeded),
+		Failed:    metric.NewCounter(metaSchedulesFailed),
+	}
+}
+
+// startScheduler starts the worker that periodically claims the schedules in
+// system.scheduled_jobs that are due and runs them.
+func (r *Registry) startScheduler(stopper *stop.Stopper) {
+	stopper.RunWorker(context.Background(), func(ctx context.Context) {
+		for {
+			select {
+			case <-time.After(schedulerPaceSetting.Get(&r.settings.SV)):
+				if err := r.maybeRunSchedules(ctx, stopper); err != nil {
+					log.Errorf(ctx, "error while running schedules: %s", err)
+				}
+			case <-stopper.ShouldStop():
+				return
+			}
+		}
+	})
+}
+
+// maybeRunSchedules claims the schedules that are due and runs each of them
+// asynchronously. A run is claimed by advancing the schedule's next_run in the
+// same transaction that found it due, so that each run is performed by only
+// one node.
+func (r *Registry) maybeRunSchedules(ctx context.Context, stopper *stop.Stopper) error {
+	if !schedulerEnabledSetting.Get(&r.settings.SV) ||
+		!r.settings.Version.IsActive(cluster.VersionScheduledJobs) {
+		return nil
+	}
+
+	var claimed []*ScheduledJob
+	if err := r.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		claimed = claimed[:0]
+		now := r.clock.PhysicalTime()
+		rows, err := r.ex.Query(
+			ctx, "find-schedules", txn,
+			`SELECT `+scheduledJobColumns+` FROM system.scheduled_jobs
+WHERE next_run <= $1 ORDER BY next_run LIMIT $2`,
+			now, maxSchedulesPerPoll,
+		)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			s, err := scheduledJobFromRow(row)
+			if err != nil {
+				return err
+			}
+			run := *s
+			if expr, err := ParseCronExpr(s.ScheduleExpr); err != nil {
+				// The schedule can never run again; pause it so that it does not
+				// keep coming up.
+				s.NextRun = time.Time{}
+				s.LastRunStatus = fmt.Sprintf("paused: %v", err)
+			} else {
+				s.NextRun = expr.Next(now)
+				claimed = append(claimed, &run)
+			}
+			if err := r.updateSchedule(ctx, txn, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, s := range claimed {
+		s := s
+		if err := stopper.RunAsyncTask(ctx, "run-schedule", func(ctx context.Context) {
+			r.runSchedule(ctx, s)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runSchedule performs a claimed run of the schedule and records its outcome.
+func (r *Registry) runSchedule(ctx context.Context, s *ScheduledJob) {
+	r.metrics.Scheduler.Started.Inc(1)
+	claimedState := s.State
+
+	var status string
+	var err error
+	if executor, ok := scheduledJobExecutors[s.ExecutorType]; !ok {
+		err = fmt.Errorf("no executor is available for %q", s.ExecutorType)
+	} else {
+		status, err = executor.ExecuteJob(ctx, r.ex, s)
+	}
+	if err != nil {
+		r.metrics.Scheduler.Failed.Inc(1)
+		log.Warningf(ctx, "schedule %d (%s) failed: %s", s.ID, s.Name, err)
+		status = fmt.Sprintf("failed: %v", err)
+	} else {
+		r.metrics.Scheduler.Succeeded.Inc(1)
+	}
+
+	if err := r.updateScheduleInTxn(ctx, nil /* txn */, s.ID, func(current *ScheduledJob) error {
+		current.LastRunStatus = status
+		if bytes.Equal(current.State, claimedState) {
+			current.State = s.State
+		}
+		return nil
+	}); err != nil {
+		log.Warningf(ctx, "unable to record outcome of schedule %d: %s", s.ID, err)
+	}
+}
+
+// TestingRunSchedule performs a run of the schedule with the given ID as if it
+// had been claimed by the scheduler, and returns once its outcome has been
+// recorded. The schedule's next run is left unchanged.
+func (r *Registry) TestingRunSchedule(ctx context.Context, id int64) error {
+	s, err := r.LoadSchedule(ctx, nil /* txn */, id)
+	if err != nil {
+		return err
+	}
+	r.runSchedule(ctx, s)
+	return nil
+}
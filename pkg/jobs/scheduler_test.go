@@ -0,0 +1,172 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/pkg/errors"
+)
+
+type scheduledJobExecutorFunc func(
+	ctx context.Context, ex sqlutil.InternalExecutor, schedule *ScheduledJob,
+) (string, error)
+
+func (f scheduledJobExecutorFunc) ExecuteJob(
+	ctx context.Context, ex sqlutil.InternalExecutor, schedule *ScheduledJob,
+) (string, error) {
+	return f(ctx, ex, schedule)
+}
+
+func TestScheduler(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+	registry := s.JobRegistry().(*Registry)
+
+	const executorType = "test-executor"
+	var fail bool
+	runs := make(chan int64, 10)
+	RegisterScheduledJobExecutor(executorType, scheduledJobExecutorFunc(
+		func(_ context.Context, _ sqlutil.InternalExecutor, schedule *ScheduledJob) (string, error) {
+			// Read fail before signaling the run, so that the test only changes it
+			// once the run is done with it.
+			shouldFail := fail
+			runs <- schedule.ID
+			if shouldFail {
+				return "", errors.New("boom")
+			}
+			schedule.State = append(schedule.State, 'x')
+			return fmt.Sprintf("run %d", len(schedule.State)), nil
+		}))
+	defer delete(scheduledJobExecutors, executorType)
+
+	due := &ScheduledJob{
+		Name:         "due",
+		Owner:        "root",
+		NextRun:      timeutil.Now().Add(-time.Minute),
+		ScheduleExpr: "@hourly",
+		ExecutorType: executorType,
+	}
+	notDue := &ScheduledJob{
+		Name:         "not due",
+		Owner:        "root",
+		ScheduleExpr: "@yearly",
+		ExecutorType: executorType,
+	}
+	for _, sj := range []*ScheduledJob{due, notDue} {
+		if err := registry.CreateSchedule(ctx, nil /* txn */, sj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// makeDue moves the next run of the schedule into the past.
+	makeDue := func(id int64) {
+		t.Helper()
+		if err := registry.updateScheduleInTxn(ctx, nil /* txn */, id, func(s *ScheduledJob) error {
+			s.NextRun = timeutil.Now().Add(-time.Minute)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// runSchedules runs the due schedules and waits for the run of the schedule
+	// with the given ID to be recorded with the expected status.
+	runSchedules := func(id int64, expectedStatus string) *ScheduledJob {
+		t.Helper()
+		if err := registry.maybeRunSchedules(ctx, s.Stopper()); err != nil {
+			t.Fatal(err)
+		}
+		if ran := <-runs; ran != id {
+			t.Fatalf("expected schedule %d to run, got %d", id, ran)
+		}
+		var sj *ScheduledJob
+		testutils.SucceedsSoon(t, func() error {
+			var err error
+			if sj, err = registry.LoadSchedule(ctx, nil /* txn */, id); err != nil {
+				return err
+			}
+			if sj.LastRunStatus != expectedStatus {
+				return fmt.Errorf("expected status %q, got %q", expectedStatus, sj.LastRunStatus)
+			}
+			return nil
+		})
+		return sj
+	}
+
+	sj := runSchedules(due.ID, "run 1")
+	if !sj.NextRun.After(timeutil.Now()) {
+		t.Fatalf("expected next run to be in the future, got %s", sj.NextRun)
+	}
+	if string(sj.State) != "x" {
+		t.Fatalf("expected state to be persisted, got %q", sj.State)
+	}
+
+	// The run was claimed, so polling again does not run the schedule again.
+	if err := registry.maybeRunSchedules(ctx, s.Stopper()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case id := <-runs:
+		t.Fatalf("unexpected run of schedule %d", id)
+	default:
+	}
+
+	makeDue(due.ID)
+	sj = runSchedules(due.ID, "run 2")
+	if string(sj.State) != "xx" {
+		t.Fatalf("expected state of the previous run to be carried over, got %q", sj.State)
+	}
+
+	fail = true
+	makeDue(due.ID)
+	sj = runSchedules(due.ID, "failed: boom")
+	if string(sj.State) != "xx" {
+		t.Fatalf("expected state to be unchanged by a failed run, got %q", sj.State)
+	}
+
+	if err := registry.PauseSchedule(ctx, nil /* txn */, due.ID); err != nil {
+		t.Fatal(err)
+	}
+	if sj, err := registry.LoadSchedule(ctx, nil /* txn */, due.ID); err != nil {
+		t.Fatal(err)
+	} else if !sj.IsPaused() {
+		t.Fatal("expected schedule to be paused")
+	}
+	if err := registry.ResumeSchedule(ctx, nil /* txn */, due.ID); err != nil {
+		t.Fatal(err)
+	}
+	if sj, err := registry.LoadSchedule(ctx, nil /* txn */, due.ID); err != nil {
+		t.Fatal(err)
+	} else if sj.IsPaused() {
+		t.Fatal("expected schedule to be resumed")
+	}
+
+	m := registry.metrics.Scheduler
+	started, succeeded, failed := m.Started.Count(), m.Succeeded.Count(), m.Failed.Count()
+	if started != 3 || succeeded != 2 || failed != 1 {
+		t.Fatalf("expected 3 started, 2 succeeded and 1 failed runs, got %d, %d and %d",
+			started, succeeded, failed)
+	}
+}
@@ -173,6 +173,16 @@ var (
 	// LocalQueueLastProcessedSuffix is the suffix for replica queue state keys.
 	LocalQueueLastProcessedSuffix = roachpb.RKey("qlpt")
 
+	// LocalRangeTombstonePrefix is the prefix identifying MVCC range
+	// tombstones, indexed by the tombstone's start key. The start key is
+	// appended to this prefix, encoded using EncodeBytes, followed by the
+	// tombstone's timestamp in descending order. A range tombstone never
+	// extends past the end of the range containing its start key, so like
+	// range-local keys, these keys are addressed by their start key.
+	LocalRangeTombstonePrefix = roachpb.Key(makeKey(localPrefix, roachpb.RKey("t")))
+	// LocalRangeTombstoneMax is the end of the range tombstone keyspace.
+	LocalRangeTombstoneMax = LocalRangeTombstonePrefix.PrefixEnd()
+
 	// Meta1Prefix is the first level of key addressing. It is selected such that
 	// all range addressing records sort before any system tables which they
 	// might describe. The value is a RangeDescriptor struct.
@@ -307,20 +317,23 @@ const (
 	// to "Ranges" instead of a Table - these IDs are needed to store custom
 	// configuration for non-table ranges (e.g. Zone Configs).
 	// NOTE: IDs must be <= MaxReservedDescID.
-	LeaseTableID           = 11
-	EventLogTableID        = 12
-	RangeEventTableID      = 13
-	UITableID              = 14
-	JobsTableID            = 15
-	MetaRangesID           = 16
-	SystemRangesID         = 17
-	TimeseriesRangesID     = 18
-	WebSessionsTableID     = 19
-	TableStatisticsTableID = 20
-	LocationsTableID       = 21
-	LivenessRangesID       = 22
-	RoleMembersTableID     = 23
-	CommentsTableID        = 24
+	LeaseTableID                      = 11
+	EventLogTableID                   = 12
+	RangeEventTableID                 = 13
+	UITableID                         = 14
+	JobsTableID                       = 15
+	MetaRangesID                      = 16
+	SystemRangesID                    = 17
+	TimeseriesRangesID                = 18
+	WebSessionsTableID                = 19
+	TableStatisticsTableID            = 20
+	LocationsTableID                  = 21
+	LivenessRangesID                  = 22
+	RoleMembersTableID                = 23
+	CommentsTableID                   = 24
+	RoleOptionsTableID                = 25
+	ProtectedTimestampsRecordsTableID = 26
+	ScheduledJobsTableID              = 27
 
 	// CommentType is type for system.comments
 	DatabaseCommentType = 0
@@ -18,6 +18,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/pkg/errors"
 )
@@ -421,6 +422,59 @@ func QueueLastProcessedKey(key roachpb.RKey, queue string) roachpb.Key {
 	return MakeRangeKey(key, LocalQueueLastProcessedSuffix, roachpb.RKey(queue))
 }
 
+// RangeTombstoneKey returns the key under which the MVCC range tombstone
+// starting at the given key and written at the given timestamp is stored.
+func RangeTombstoneKey(startKey roachpb.Key, ts hlc.Timestamp) roachpb.Key {
+	buf := RangeTombstoneKeyPrefix(roachpb.RKey(startKey))
+	buf = encoding.EncodeUvarintDescending(buf, uint64(ts.WallTime))
+	buf = encoding.EncodeUvarintDescending(buf, uint64(ts.Logical))
+	return buf
+}
+
+// RangeTombstoneKeyPrefix returns the prefix of the keys under which the MVCC
+// range tombstones starting at the given key are stored. Since the key is
+// encoded using EncodeBytes, these prefixes sort in the same order as the
+// keys they are derived from.
+func RangeTombstoneKeyPrefix(startKey roachpb.RKey) roachpb.Key {
+	buf := make(roachpb.Key, 0, len(LocalRangeTombstonePrefix)+len(startKey)+20)
+	buf = append(buf, LocalRangeTombstonePrefix...)
+	return encoding.EncodeBytesAscending(buf, startKey)
+}
+
+// RangeTombstoneSpan returns the span of the keys under which the MVCC range
+// tombstones starting in [startKey, endKey) are stored.
+func RangeTombstoneSpan(startKey, endKey roachpb.RKey) roachpb.Span {
+	return roachpb.Span{
+		Key:    RangeTombstoneKeyPrefix(startKey),
+		EndKey: RangeTombstoneKeyPrefix(endKey),
+	}
+}
+
+// DecodeRangeTombstoneKey decodes the start key and timestamp of the MVCC
+// range tombstone stored under the given key.
+func DecodeRangeTombstoneKey(key roachpb.Key) (roachpb.Key, hlc.Timestamp, error) {
+	if !bytes.HasPrefix(key, LocalRangeTombstonePrefix) {
+		return nil, hlc.Timestamp{}, errors.Errorf("key %q does not have %q prefix",
+			key, LocalRangeTombstonePrefix)
+	}
+	b, startKey, err := encoding.DecodeBytesAscending(key[len(LocalRangeTombstonePrefix):], nil)
+	if err != nil {
+		return nil, hlc.Timestamp{}, err
+	}
+	b, wallTime, err := encoding.DecodeUvarintDescending(b)
+	if err != nil {
+		return nil, hlc.Timestamp{}, err
+	}
+	b, logical, err := encoding.DecodeUvarintDescending(b)
+	if err != nil {
+		return nil, hlc.Timestamp{}, err
+	}
+	if len(b) != 0 {
+		return nil, hlc.Timestamp{}, errors.Errorf("key %q has %d trailing bytes", key, len(b))
+	}
+	return startKey, hlc.Timestamp{WallTime: int64(wallTime), Logical: int32(logical)}, nil
+}
+
 // IsLocal performs a cheap check that returns true iff a range-local key is
 // passed, that is, a key for which `Addr` would return a non-identical RKey
 // (or a decoding error).
@@ -465,6 +519,13 @@ func Addr(k roachpb.Key) (roachpb.RKey, error) {
 		if bytes.HasPrefix(k, LocalRangeIDPrefix) {
 			return nil, errors.Errorf("local range ID key %q is not addressable", k)
 		}
+		if bytes.HasPrefix(k, LocalRangeTombstonePrefix) {
+			startKey, _, err := DecodeRangeTombstoneKey(k)
+			if err != nil {
+				return nil, err
+			}
+			return roachpb.RKey(startKey), nil
+		}
 		if !bytes.HasPrefix(k, LocalRangePrefix) {
 			return nil, errors.Errorf("local key %q malformed; should contain prefix %q",
 				k, LocalRangePrefix)
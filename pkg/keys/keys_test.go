@@ -21,6 +21,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 )
@@ -112,6 +113,35 @@ func TestAbortSpanEncodeDecode(t *testing.T) {
 	}
 }
 
+func TestRangeTombstoneKeyEncodeDecode(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ts1 := hlc.Timestamp{WallTime: 10, Logical: 1}
+	ts2 := hlc.Timestamp{WallTime: 10, Logical: 2}
+	key := RangeTombstoneKey(roachpb.Key("a"), ts1)
+	startKey, ts, err := DecodeRangeTombstoneKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !startKey.Equal(roachpb.Key("a")) || ts != ts1 {
+		t.Fatalf("expected %s@%s, got %s@%s", roachpb.Key("a"), ts1, startKey, ts)
+	}
+
+	// Keys sort by start key and then by descending timestamp.
+	sorted := []roachpb.Key{
+		RangeTombstoneKeyPrefix(roachpb.RKey("a")),
+		RangeTombstoneKey(roachpb.Key("a"), ts2),
+		RangeTombstoneKey(roachpb.Key("a"), ts1),
+		RangeTombstoneKey(roachpb.Key("a\x00"), ts2),
+		RangeTombstoneKeyPrefix(roachpb.RKey("b")),
+		RangeTombstoneKey(roachpb.Key("b"), ts1),
+	}
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i-1].Compare(sorted[i]) >= 0 {
+			t.Errorf("%d: expected %s < %s", i, sorted[i-1], sorted[i])
+		}
+	}
+}
+
 func TestKeyAddress(t *testing.T) {
 	testCases := []struct {
 		key        roachpb.Key
@@ -123,6 +153,7 @@ func TestKeyAddress(t *testing.T) {
 		{TransactionKey(roachpb.Key("baz"), uuid.MakeV4()), roachpb.RKey("baz")},
 		{TransactionKey(roachpb.KeyMax, uuid.MakeV4()), roachpb.RKeyMax},
 		{RangeDescriptorKey(roachpb.RKey(TransactionKey(roachpb.Key("doubleBaz"), uuid.MakeV4()))), roachpb.RKey("doubleBaz")},
+		{RangeTombstoneKey(roachpb.Key("qux"), hlc.Timestamp{WallTime: 1}), roachpb.RKey("qux")},
 		{nil, nil},
 	}
 	for i, test := range testCases {
@@ -67,6 +67,8 @@ var (
 				ppFunc: localRangeIDKeyPrint, psFunc: localRangeIDKeyParse},
 			{name: "/Range", prefix: LocalRangePrefix, ppFunc: localRangeKeyPrint,
 				psFunc: parseUnsupported},
+			{name: "/RangeTombstone", prefix: LocalRangeTombstonePrefix,
+				ppFunc: localRangeTombstoneKeyPrint, psFunc: parseUnsupported},
 		}},
 		{name: "/Meta1", start: Meta1Prefix, end: Meta1KeyMax, entries: []dictEntry{
 			{name: "", prefix: Meta1Prefix, ppFunc: print,
@@ -394,6 +396,16 @@ func localRangeIDKeyPrint(valDirs []encoding.Direction, key roachpb.Key) string
 	return buf.String()
 }
 
+func localRangeTombstoneKeyPrint(valDirs []encoding.Direction, key roachpb.Key) string {
+	fullKey := make(roachpb.Key, 0, len(LocalRangeTombstonePrefix)+len(key))
+	fullKey = append(append(fullKey, LocalRangeTombstonePrefix...), key...)
+	startKey, ts, err := DecodeRangeTombstoneKey(fullKey)
+	if err != nil {
+		return fmt.Sprintf("/%q/err:%v", []byte(key), err)
+	}
+	return fmt.Sprintf("%s/%s", startKey, ts)
+}
+
 func localRangeKeyPrint(valDirs []encoding.Direction, key roachpb.Key) string {
 	var buf bytes.Buffer
 
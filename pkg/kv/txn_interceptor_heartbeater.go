@@ -494,8 +494,9 @@ func (h *txnHeartbeater) abortTxnAsyncLocked(ctx context.Context) {
 	}
 }
 
-// firstWriteIndex returns the index of the first transactional write in the
-// BatchRequest. Returns -1 if the batch has not intention to write. It also
+// firstWriteIndex returns the index of the first transactional write or
+// locking read in the BatchRequest. Returns -1 if the batch has not intention
+// to write or lock. It also
 // verifies that if an EndTransactionRequest is included, then it is the last
 // request in the batch.
 func firstWriteIndex(ba *roachpb.BatchRequest) (int, *roachpb.Error) {
@@ -506,7 +507,7 @@ func firstWriteIndex(ba *roachpb.BatchRequest) (int, *roachpb.Error) {
 				return -1, roachpb.NewErrorf("%s sent as non-terminal call", args.Method())
 			}
 		}
-		if roachpb.IsTransactionWrite(args) {
+		if roachpb.IsTransactionWrite(args) || roachpb.IsLocking(args) {
 			return i, nil
 		}
 	}
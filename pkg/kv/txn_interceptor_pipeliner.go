@@ -504,6 +504,13 @@ func (tp *txnPipeliner) updateWriteTracking(
 					tp.footprint.insert(sp)
 				}
 			}
+		} else if roachpb.IsLocking(req) {
+			// If the request was a locking read, track the span of the
+			// unreplicated locks it acquired so that they are released when
+			// the transaction finishes.
+			if sp, ok := roachpb.ActualSpan(req, resp); ok {
+				tp.footprint.insert(sp)
+			}
 		}
 	}
 }
@@ -353,6 +353,61 @@ func TestTxnPipelinerReads(t *testing.T) {
 	require.Equal(t, 0, tp.ifWrites.len())
 }
 
+// TestTxnPipelinerLockingReads tests that txnPipeliner adds the spans of the
+// keys returned by locking reads to the transaction's footprint so that the
+// locks they acquire are released when the transaction finishes, while
+// non-locking reads are not tracked.
+func TestTxnPipelinerLockingReads(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	tp, mockSender := makeMockTxnPipeliner()
+
+	txn := makeTxnProto()
+	keyA, keyB, keyC := roachpb.Key("a"), roachpb.Key("b"), roachpb.Key("c")
+
+	var ba roachpb.BatchRequest
+	ba.Header = roachpb.Header{Txn: &txn}
+	ba.Add(&roachpb.GetRequest{RequestHeader: roachpb.RequestHeader{Key: keyA}})
+	ba.Add(&roachpb.ScanRequest{RequestHeader: roachpb.RequestHeader{Key: keyA, EndKey: keyC}})
+
+	mockSender.MockSend(func(ba roachpb.BatchRequest) (*roachpb.BatchResponse, *roachpb.Error) {
+		require.Len(t, ba.Requests, 2)
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		return br, nil
+	})
+
+	br, pErr := tp.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+	require.NotNil(t, br)
+	require.Equal(t, 0, len(tp.footprint.asSlice()))
+
+	ba.Requests = nil
+	ba.Add(&roachpb.GetRequest{
+		RequestHeader: roachpb.RequestHeader{Key: keyA}, KeyLocking: roachpb.FOR_UPDATE,
+	})
+	ba.Add(&roachpb.ScanRequest{
+		RequestHeader: roachpb.RequestHeader{Key: keyB, EndKey: keyC}, KeyLocking: roachpb.FOR_UPDATE,
+	})
+
+	mockSender.MockSend(func(ba roachpb.BatchRequest) (*roachpb.BatchResponse, *roachpb.Error) {
+		require.Len(t, ba.Requests, 2)
+		require.False(t, ba.AsyncConsensus)
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		return br, nil
+	})
+
+	br, pErr = tp.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+	require.NotNil(t, br)
+	require.Equal(t, 0, tp.ifWrites.len())
+	require.Equal(t, []roachpb.Span{
+		{Key: keyA},
+		{Key: keyB, EndKey: keyC},
+	}, tp.footprint.asSlice())
+}
+
 // TestTxnPipelinerRangedWrites tests that txnPipeliner will never perform
 // ranged write operations using async consensus. It also tests that ranged
 // writes will correctly chain on to existing in-flight writes.
@@ -74,7 +74,7 @@ func (rc ReadConsistencyType) SupportsBatch(ba BatchRequest) error {
 		for _, ru := range ba.Requests {
 			m := ru.GetInner().Method()
 			switch m {
-			case Get, Scan, ReverseScan:
+			case Get, Scan, ReverseScan, QueryResolvedTimestamp:
 			default:
 				return errors.Errorf("method %s not allowed with %s batch", m, rc)
 			}
@@ -122,6 +122,21 @@ func IsTransactionWrite(args Request) bool {
 	return (args.flags() & isTxnWrite) != 0
 }
 
+// IsLocking returns true if the request acquires unreplicated locks on the
+// keys that it reads.
+func IsLocking(args Request) bool {
+	switch t := args.(type) {
+	case *GetRequest:
+		return t.KeyLocking != NON_LOCKING
+	case *ScanRequest:
+		return t.KeyLocking != NON_LOCKING
+	case *ReverseScanRequest:
+		return t.KeyLocking != NON_LOCKING
+	default:
+		return false
+	}
+}
+
 // IsRange returns true if the command is range-based and must include
 // a start and an end key.
 func IsRange(args Request) bool {
@@ -336,6 +351,38 @@ func (r *AdminScatterResponse) combine(c combinable) error {
 
 var _ combinable = &AdminScatterResponse{}
 
+// Combine implements the combinable interface. The combined resolved timestamp
+// is the minimum of the resolved timestamps of the individual ranges.
+func (r *QueryResolvedTimestampResponse) combine(c combinable) error {
+	if r != nil {
+		otherR := c.(*QueryResolvedTimestampResponse)
+		if err := r.ResponseHeader.combine(otherR.Header()); err != nil {
+			return err
+		}
+		if otherR.ResolvedTS.Less(r.ResolvedTS) {
+			r.ResolvedTS = otherR.ResolvedTS
+		}
+	}
+	return nil
+}
+
+var _ combinable = &QueryResolvedTimestampResponse{}
+
+// Combine implements the combinable interface. The combined response lists
+// the failed ranges of both responses.
+func (r *AdminVerifyProtectedTimestampResponse) combine(c combinable) error {
+	if r != nil {
+		otherR := c.(*AdminVerifyProtectedTimestampResponse)
+		if err := r.ResponseHeader.combine(otherR.Header()); err != nil {
+			return err
+		}
+		r.FailedRanges = append(r.FailedRanges, otherR.FailedRanges...)
+	}
+	return nil
+}
+
+var _ combinable = &AdminVerifyProtectedTimestampResponse{}
+
 // Header implements the Request interface.
 func (rh RequestHeader) Header() RequestHeader {
 	return rh
@@ -577,6 +624,14 @@ func (*SubsumeRequest) Method() Method { return Subsume }
 // Method implements the Request interface.
 func (*RangeStatsRequest) Method() Method { return RangeStats }
 
+// Method implements the Request interface.
+func (*QueryResolvedTimestampRequest) Method() Method { return QueryResolvedTimestamp }
+
+// Method implements the Request interface.
+func (*AdminVerifyProtectedTimestampRequest) Method() Method {
+	return AdminVerifyProtectedTimestamp
+}
+
 // ShallowCopy implements the Request interface.
 func (gr *GetRequest) ShallowCopy() Request {
 	shallowCopy := *gr
@@ -835,6 +890,18 @@ func (r *RangeStatsRequest) ShallowCopy() Request {
 	return &shallowCopy
 }
 
+// ShallowCopy implements the Request interface.
+func (r *QueryResolvedTimestampRequest) ShallowCopy() Request {
+	shallowCopy := *r
+	return &shallowCopy
+}
+
+// ShallowCopy implements the Request interface.
+func (r *AdminVerifyProtectedTimestampRequest) ShallowCopy() Request {
+	shallowCopy := *r
+	return &shallowCopy
+}
+
 // NewGet returns a Request initialized to get the value at key.
 func NewGet(key Key) Request {
 	return &GetRequest{
@@ -1012,6 +1079,13 @@ func (drr *DeleteRangeRequest) flags() int {
 	if drr.Inline {
 		return isWrite | isRange | isAlone
 	}
+	// Similarly, a DeleteRange using a range tombstone or deleting only the
+	// keys inserted after a timestamp can't be executed as part of a
+	// transaction, but it must be ordered after the reads of the keys it
+	// deletes.
+	if drr.UseRangeTombstone || !drr.OnlyInsertedAfter.IsEmpty() {
+		return isWrite | isRange | isAlone | consultsTSCache | canBackpressure
+	}
 	// DeleteRange updates the timestamp cache as it doesn't leave
 	// intents or tombstones for keys which don't yet exist. By updating
 	// the write timestamp cache, it forces subsequent writes to get a
@@ -1086,7 +1160,12 @@ func (*WriteBatchRequest) flags() int       { return isWrite | isRange }
 func (*ExportRequest) flags() int           { return isRead | isRange | updatesReadTSCache }
 func (*ImportRequest) flags() int           { return isAdmin | isAlone }
 func (*AdminScatterRequest) flags() int     { return isAdmin | isRange | isAlone }
-func (*AddSSTableRequest) flags() int {
+func (r *AddSSTableRequest) flags() int {
+	// An AddSSTable writing at its request timestamp is ordered after the reads
+	// of the span it writes to, like any other write.
+	if r.WriteAtRequestTimestamp {
+		return isWrite | isRange | isAlone | isUnsplittable | consultsTSCache | canBackpressure
+	}
 	return isWrite | isRange | isAlone | isUnsplittable | canBackpressure
 }
 
@@ -1105,8 +1184,12 @@ func (r *RefreshRangeRequest) flags() int {
 	return isRead | isTxn | isRange | updatesReadTSCache
 }
 
-func (*SubsumeRequest) flags() int    { return isRead | isAlone | updatesReadTSCache }
-func (*RangeStatsRequest) flags() int { return isRead }
+func (*SubsumeRequest) flags() int                { return isRead | isAlone | updatesReadTSCache }
+func (*RangeStatsRequest) flags() int             { return isRead }
+func (*QueryResolvedTimestampRequest) flags() int { return isRead | isRange }
+func (*AdminVerifyProtectedTimestampRequest) flags() int {
+	return isAdmin | isRange | isAlone
+}
 
 // IsParallelCommit returns whether the EndTransaction request is attempting to
 // perform a parallel commit. See txn_interceptor_committer.go for a discussion
@@ -15,6 +15,8 @@ package roachpb
 import (
 	"reflect"
 	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 )
 
 // TestCombinable tests the correct behavior of some types that implement
@@ -89,6 +91,47 @@ func TestCombinable(t *testing.T) {
 	if !reflect.DeepEqual(dr1, wantedDR) {
 		t.Errorf("wanted %v, got %v", wantedDR, dr1)
 	}
+
+	// The resolved timestamp of a QueryResolvedTimestampResponse spanning
+	// several ranges is the minimum over all of them.
+	rr1 := &QueryResolvedTimestampResponse{ResolvedTS: hlc.Timestamp{WallTime: 3}}
+	if _, ok := interface{}(rr1).(combinable); !ok {
+		t.Fatalf("QueryResolvedTimestampResponse does not implement combinable")
+	}
+	rr2 := &QueryResolvedTimestampResponse{ResolvedTS: hlc.Timestamp{WallTime: 1}}
+	rr3 := &QueryResolvedTimestampResponse{ResolvedTS: hlc.Timestamp{WallTime: 2}}
+	if err := rr1.combine(rr2); err != nil {
+		t.Fatal(err)
+	}
+	if err := rr1.combine(rr3); err != nil {
+		t.Fatal(err)
+	}
+	if wantedTS := (hlc.Timestamp{WallTime: 1}); rr1.ResolvedTS != wantedTS {
+		t.Errorf("wanted %s, got %s", wantedTS, rr1.ResolvedTS)
+	}
+
+	// The failed ranges of an AdminVerifyProtectedTimestampResponse spanning
+	// several ranges are those of all of them.
+	vr1 := &AdminVerifyProtectedTimestampResponse{}
+	if _, ok := interface{}(vr1).(combinable); !ok {
+		t.Fatalf("AdminVerifyProtectedTimestampResponse does not implement combinable")
+	}
+	vr2 := &AdminVerifyProtectedTimestampResponse{
+		FailedRanges: []RangeDescriptor{{RangeID: 2}},
+	}
+	vr3 := &AdminVerifyProtectedTimestampResponse{
+		FailedRanges: []RangeDescriptor{{RangeID: 3}},
+	}
+	if err := vr1.combine(vr2); err != nil {
+		t.Fatal(err)
+	}
+	if err := vr1.combine(vr3); err != nil {
+		t.Fatal(err)
+	}
+	if len(vr1.FailedRanges) != 2 || vr1.FailedRanges[0].RangeID != 2 ||
+		vr1.FailedRanges[1].RangeID != 3 {
+		t.Errorf("wanted failed ranges r2 and r3, got %v", vr1.FailedRanges)
+	}
 }
 
 // TestMustSetInner makes sure that calls to MustSetInner correctly reset the
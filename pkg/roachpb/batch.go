@@ -348,14 +348,14 @@ func (br *BatchResponse) String() string {
 }
 
 // IntentSpanIterate calls the passed method with the key ranges of the
-// transactional writes contained in the batch. Usually the key spans
+// transactional writes and locking reads contained in the batch. Usually the key spans
 // contained in the requests are used, but when a response contains a
 // ResumeSpan the ResumeSpan is subtracted from the request span to provide a
 // more minimal span of keys affected by the request.
 func (ba *BatchRequest) IntentSpanIterate(br *BatchResponse, fn func(Span)) {
 	for i, arg := range ba.Requests {
 		req := arg.GetInner()
-		if !IsTransactionWrite(req) {
+		if !IsTransactionWrite(req) && !IsLocking(req) {
 			continue
 		}
 		var resp Response
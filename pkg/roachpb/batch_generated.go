@@ -166,6 +166,10 @@ func (ru RequestUnion) GetInner() Request {
 		return t.Subsume
 	case *RequestUnion_RangeStats:
 		return t.RangeStats
+	case *RequestUnion_QueryResolvedTimestamp:
+		return t.QueryResolvedTimestamp
+	case *RequestUnion_AdminVerifyProtectedTimestamp:
+		return t.AdminVerifyProtectedTimestamp
 	default:
 		return nil
 	}
@@ -258,6 +262,10 @@ func (ru ResponseUnion) GetInner() Response {
 		return t.Subsume
 	case *ResponseUnion_RangeStats:
 		return t.RangeStats
+	case *ResponseUnion_QueryResolvedTimestamp:
+		return t.QueryResolvedTimestamp
+	case *ResponseUnion_AdminVerifyProtectedTimestamp:
+		return t.AdminVerifyProtectedTimestamp
 	default:
 		return nil
 	}
@@ -424,6 +432,10 @@ func (ru *RequestUnion) SetInner(r Request) bool {
 		union = &RequestUnion_Subsume{t}
 	case *RangeStatsRequest:
 		union = &RequestUnion_RangeStats{t}
+	case *QueryResolvedTimestampRequest:
+		union = &RequestUnion_QueryResolvedTimestamp{t}
+	case *AdminVerifyProtectedTimestampRequest:
+		union = &RequestUnion_AdminVerifyProtectedTimestamp{t}
 	default:
 		return false
 	}
@@ -519,6 +531,10 @@ func (ru *ResponseUnion) SetInner(r Response) bool {
 		union = &ResponseUnion_Subsume{t}
 	case *RangeStatsResponse:
 		union = &ResponseUnion_RangeStats{t}
+	case *QueryResolvedTimestampResponse:
+		union = &ResponseUnion_QueryResolvedTimestamp{t}
+	case *AdminVerifyProtectedTimestampResponse:
+		union = &ResponseUnion_AdminVerifyProtectedTimestamp{t}
 	default:
 		return false
 	}
@@ -526,7 +542,7 @@ func (ru *ResponseUnion) SetInner(r Response) bool {
 	return true
 }
 
-type reqCounts [43]int32
+type reqCounts [45]int32
 
 // getReqCounts returns the number of times each
 // request type appears in the batch.
@@ -620,6 +636,10 @@ func (ba *BatchRequest) getReqCounts() reqCounts {
 			counts[41]++
 		case *RequestUnion_RangeStats:
 			counts[42]++
+		case *RequestUnion_QueryResolvedTimestamp:
+			counts[43]++
+		case *RequestUnion_AdminVerifyProtectedTimestamp:
+			counts[44]++
 		default:
 			panic(fmt.Sprintf("unsupported request: %+v", ru))
 		}
@@ -671,6 +691,8 @@ var requestNames = []string{
 	"RefreshRng",
 	"Subsume",
 	"RngStats",
+	"QueryResolvedTimestamp",
+	"AdmVerifyProtectedTimestamp",
 }
 
 // Summary prints a short summary of the requests in a batch.
@@ -874,6 +896,14 @@ type rangeStatsResponseAlloc struct {
 	union ResponseUnion_RangeStats
 	resp  RangeStatsResponse
 }
+type queryResolvedTimestampResponseAlloc struct {
+	union ResponseUnion_QueryResolvedTimestamp
+	resp  QueryResolvedTimestampResponse
+}
+type adminVerifyProtectedTimestampResponseAlloc struct {
+	union ResponseUnion_AdminVerifyProtectedTimestamp
+	resp  AdminVerifyProtectedTimestampResponse
+}
 
 // CreateReply creates replies for each of the contained requests, wrapped in a
 // BatchResponse. The response objects are batch allocated to minimize
@@ -927,6 +957,8 @@ func (ba *BatchRequest) CreateReply() *BatchResponse {
 	var buf40 []refreshRangeResponseAlloc
 	var buf41 []subsumeResponseAlloc
 	var buf42 []rangeStatsResponseAlloc
+	var buf43 []queryResolvedTimestampResponseAlloc
+	var buf44 []adminVerifyProtectedTimestampResponseAlloc
 
 	for i, r := range ba.Requests {
 		switch r.GetValue().(type) {
@@ -1231,6 +1263,20 @@ func (ba *BatchRequest) CreateReply() *BatchResponse {
 			buf42[0].union.RangeStats = &buf42[0].resp
 			br.Responses[i].Value = &buf42[0].union
 			buf42 = buf42[1:]
+		case *RequestUnion_QueryResolvedTimestamp:
+			if buf43 == nil {
+				buf43 = make([]queryResolvedTimestampResponseAlloc, counts[43])
+			}
+			buf43[0].union.QueryResolvedTimestamp = &buf43[0].resp
+			br.Responses[i].Value = &buf43[0].union
+			buf43 = buf43[1:]
+		case *RequestUnion_AdminVerifyProtectedTimestamp:
+			if buf44 == nil {
+				buf44 = make([]adminVerifyProtectedTimestampResponseAlloc, counts[44])
+			}
+			buf44[0].union.AdminVerifyProtectedTimestamp = &buf44[0].resp
+			br.Responses[i].Value = &buf44[0].union
+			buf44 = buf44[1:]
 		default:
 			panic(fmt.Sprintf("unsupported request: %+v", r))
 		}
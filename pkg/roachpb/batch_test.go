@@ -283,6 +283,22 @@ func TestIntentSpanIterate(t *testing.T) {
 	}
 }
 
+func TestIntentSpanIterateLockingRead(t *testing.T) {
+	ba := BatchRequest{}
+	ba.Add(&ScanRequest{RequestHeader: RequestHeaderFromSpan(sp("a", "c"))})
+	ba.Add(&ScanRequest{RequestHeader: RequestHeaderFromSpan(sp("d", "f")), KeyLocking: FOR_UPDATE})
+	ba.Add(&GetRequest{RequestHeader: RequestHeader{Key: Key("g")}, KeyLocking: FOR_UPDATE})
+
+	var spans []Span
+	ba.IntentSpanIterate(nil, func(span Span) {
+		spans = append(spans, span)
+	})
+	// Only the locking reads are returned.
+	if e := []Span{sp("d", "f"), {Key: Key("g")}}; !reflect.DeepEqual(e, spans) {
+		t.Fatalf("unexpected spans: e = %+v, found = %+v", e, spans)
+	}
+}
+
 func TestRefreshSpanIterate(t *testing.T) {
 	testCases := []struct {
 		req    Request
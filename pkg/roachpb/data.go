@@ -1288,9 +1288,53 @@ func writeTooOldRetryTimestamp(txn *Transaction, err *WriteTooOldError) hlc.Time
 var _ fmt.Stringer = &ChangeReplicasTrigger{}
 
 func (crt ChangeReplicasTrigger) String() string {
+	if crt.LeaveJoint {
+		return fmt.Sprintf("LEAVE_JOINT: updated=%s next=%d", crt.UpdatedReplicas, crt.NextReplicaID)
+	}
+	if crt.IsAtomic() {
+		return fmt.Sprintf("ENTER_JOINT(added=%s removed=%s): updated=%s next=%d",
+			crt.InternalAddedReplicas, crt.InternalRemovedReplicas, crt.UpdatedReplicas, crt.NextReplicaID)
+	}
 	return fmt.Sprintf("%s(%s): updated=%s next=%d", crt.ChangeType, crt.Replica, crt.UpdatedReplicas, crt.NextReplicaID)
 }
 
+// IsAtomic returns true if the trigger carries out an atomic replication
+// change, i.e. enters a joint configuration.
+func (crt ChangeReplicasTrigger) IsAtomic() bool {
+	return len(crt.InternalAddedReplicas)+len(crt.InternalRemovedReplicas) > 0
+}
+
+// Added returns the replicas added by this change.
+func (crt ChangeReplicasTrigger) Added() []ReplicaDescriptor {
+	if crt.LeaveJoint {
+		return nil
+	}
+	if crt.IsAtomic() {
+		return crt.InternalAddedReplicas
+	}
+	switch crt.ChangeType {
+	case ADD_REPLICA, ADD_NON_VOTER:
+		return []ReplicaDescriptor{crt.Replica}
+	}
+	return nil
+}
+
+// Removed returns the replicas removed by this change. Note that when entering
+// a joint configuration, removed voters remain part of the range as outgoing
+// voters until the joint configuration is left.
+func (crt ChangeReplicasTrigger) Removed() []ReplicaDescriptor {
+	if crt.LeaveJoint {
+		return nil
+	}
+	if crt.IsAtomic() {
+		return crt.InternalRemovedReplicas
+	}
+	if crt.ChangeType == REMOVE_REPLICA {
+		return []ReplicaDescriptor{crt.Replica}
+	}
+	return nil
+}
+
 // LeaseSequence is a custom type for a lease sequence number.
 type LeaseSequence int64
 
@@ -18,6 +18,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/gogo/protobuf/proto"
@@ -259,8 +260,8 @@ func (r ReplicaDescriptor) String() string {
 	} else {
 		fmt.Fprintf(&buf, "%d", r.ReplicaID)
 	}
-	if r.Type == ReplicaType_LEARNER {
-		buf.WriteString("LEARNER")
+	if r.Type != ReplicaType_VOTER {
+		buf.WriteString(r.Type.String())
 	}
 	return buf.String()
 }
@@ -318,11 +319,13 @@ func (p Percentiles) String() string {
 // String returns a string representation of the StoreCapacity.
 func (sc StoreCapacity) String() string {
 	return fmt.Sprintf("disk (capacity=%s, available=%s, used=%s, logicalBytes=%s), "+
-		"ranges=%d, leases=%d, queries=%.2f, writes=%.2f, "+
+		"ranges=%d, leases=%d, queries=%.2f, writes=%.2f, writeBytes=%s/s, cpu=%s/s, "+
 		"bytesPerReplica={%s}, writesPerReplica={%s}",
 		humanizeutil.IBytes(sc.Capacity), humanizeutil.IBytes(sc.Available),
 		humanizeutil.IBytes(sc.Used), humanizeutil.IBytes(sc.LogicalBytes),
 		sc.RangeCount, sc.LeaseCount, sc.QueriesPerSecond, sc.WritesPerSecond,
+		humanizeutil.IBytes(int64(sc.WriteBytesPerSecond)),
+		time.Duration(sc.CPUPerSecond),
 		sc.BytesPerReplica, sc.WritesPerReplica)
 }
 
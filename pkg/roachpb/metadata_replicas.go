@@ -38,17 +38,19 @@ func (d ReplicaDescriptors) Unwrap() []ReplicaDescriptor {
 	return d.wrapped
 }
 
-// All returns every replica in the set, including both voter replicas and
-// learner replicas.
+// All returns every replica in the set, including voter, learner and
+// non-voter replicas.
 func (d ReplicaDescriptors) All() []ReplicaDescriptor {
 	return d.wrapped
 }
 
-// Voters returns the voter replicas in the set.
+// Voters returns the voter replicas in the set. While the range is in a joint
+// configuration, this includes the voters of both the incoming and the
+// outgoing configuration.
 func (d ReplicaDescriptors) Voters() []ReplicaDescriptor {
 	// Note that the wrapped replicas are sorted first by type.
 	for i := range d.wrapped {
-		if d.wrapped[i].Type == ReplicaType_LEARNER {
+		if !d.wrapped[i].Type.IsVoter() {
 			return d.wrapped[:i]
 		}
 	}
@@ -57,17 +59,48 @@ func (d ReplicaDescriptors) Voters() []ReplicaDescriptor {
 
 // Learners returns the learner replicas in the set.
 func (d ReplicaDescriptors) Learners() []ReplicaDescriptor {
+	return d.filterType(ReplicaType_LEARNER)
+}
+
+// NonVoters returns the non-voter replicas in the set.
+func (d ReplicaDescriptors) NonVoters() []ReplicaDescriptor {
+	return d.filterType(ReplicaType_NON_VOTER)
+}
+
+// filterType returns the contiguous run of replicas of the given type.
+func (d ReplicaDescriptors) filterType(typ ReplicaType) []ReplicaDescriptor {
 	// Note that the wrapped replicas are sorted first by type.
+	start := -1
 	for i := range d.wrapped {
-		if d.wrapped[i].Type == ReplicaType_LEARNER {
-			return d.wrapped[i:]
+		if d.wrapped[i].Type == typ {
+			if start == -1 {
+				start = i
+			}
+		} else if start != -1 {
+			return d.wrapped[start:i]
 		}
 	}
-	return nil
+	if start == -1 {
+		return nil
+	}
+	return d.wrapped[start:]
 }
 
 var _, _ = ReplicaDescriptors.All, ReplicaDescriptors.Learners
 
+// InAtomicReplicationChange returns true if the set of replicas describes a
+// joint configuration, i.e. if an atomic replication change has been entered
+// but not yet left.
+func (d ReplicaDescriptors) InAtomicReplicationChange() bool {
+	for _, rDesc := range d.Voters() {
+		switch rDesc.Type {
+		case ReplicaType_VOTER_INCOMING, ReplicaType_VOTER_OUTGOING:
+			return true
+		}
+	}
+	return false
+}
+
 // AsProto returns the protobuf representation of these replicas, suitable for
 // setting the InternalReplicas field of a RangeDescriptor. When possible the
 // SetReplicas method of RangeDescriptor should be used instead, this is only
@@ -87,6 +120,8 @@ func (d ReplicaDescriptors) DeepCopy() ReplicaDescriptors {
 // AddReplica adds the given replica to this set.
 func (d *ReplicaDescriptors) AddReplica(r ReplicaDescriptor) {
 	d.wrapped = append(d.wrapped, r)
+	// Appending may have broken our sortedness invariant, so re-sort.
+	sort.Sort(byTypeThenReplicaID(d.wrapped))
 }
 
 // RemoveReplica removes the matching replica from this set. If it wasn't found
@@ -119,13 +154,36 @@ func (d ReplicaDescriptors) QuorumSize() int {
 	return (len(d.Voters()) / 2) + 1
 }
 
+// IsVoter returns true if replicas of this type vote in the incoming or the
+// outgoing configuration of the range.
+func (t ReplicaType) IsVoter() bool {
+	switch t {
+	case ReplicaType_VOTER, ReplicaType_VOTER_INCOMING, ReplicaType_VOTER_OUTGOING:
+		return true
+	}
+	return false
+}
+
+// sortRank orders replica types such that all voters, including those in a
+// joint configuration, sort before learners and non-voters.
+func (t ReplicaType) sortRank() int {
+	switch t {
+	case ReplicaType_LEARNER:
+		return 1
+	case ReplicaType_NON_VOTER:
+		return 2
+	default:
+		return 0
+	}
+}
+
 type byTypeThenReplicaID []ReplicaDescriptor
 
 func (x byTypeThenReplicaID) Len() int      { return len(x) }
 func (x byTypeThenReplicaID) Swap(i, j int) { x[i], x[j] = x[j], x[i] }
 func (x byTypeThenReplicaID) Less(i, j int) bool {
-	if x[i].Type == x[j].Type {
+	if x[i].Type.sortRank() == x[j].Type.sortRank() {
 		return x[i].ReplicaID < x[j].ReplicaID
 	}
-	return x[i].Type < x[j].Type
+	return x[i].Type.sortRank() < x[j].Type.sortRank()
 }
@@ -25,16 +25,56 @@ func TestVotersLearnersAll(t *testing.T) {
 		{{Type: ReplicaType_LEARNER}},
 		{{Type: ReplicaType_VOTER}, {Type: ReplicaType_LEARNER}, {Type: ReplicaType_VOTER}},
 		{{Type: ReplicaType_LEARNER}, {Type: ReplicaType_VOTER}, {Type: ReplicaType_LEARNER}},
+		{{Type: ReplicaType_NON_VOTER}},
+		{{Type: ReplicaType_NON_VOTER}, {Type: ReplicaType_VOTER}, {Type: ReplicaType_LEARNER}},
+		{{Type: ReplicaType_VOTER}, {Type: ReplicaType_NON_VOTER}, {Type: ReplicaType_NON_VOTER}},
+		{{Type: ReplicaType_VOTER_OUTGOING}, {Type: ReplicaType_LEARNER}, {Type: ReplicaType_VOTER_INCOMING}},
+		{{Type: ReplicaType_NON_VOTER}, {Type: ReplicaType_VOTER_INCOMING}, {Type: ReplicaType_VOTER}},
 	}
 	for i, test := range tests {
 		r := MakeReplicaDescriptors(test)
 		for _, voter := range r.Voters() {
-			assert.Equal(t, ReplicaType_VOTER, voter.Type, "testcase %d", i)
+			assert.True(t, voter.Type.IsVoter(), "testcase %d", i)
 		}
 		for _, learner := range r.Learners() {
 			assert.Equal(t, ReplicaType_LEARNER, learner.Type, "testcase %d", i)
 		}
+		for _, nonVoter := range r.NonVoters() {
+			assert.Equal(t, ReplicaType_NON_VOTER, nonVoter.Type, "testcase %d", i)
+		}
 		assert.Equal(t, len(test), len(r.All()), "testcase %d", i)
+		assert.Equal(t, len(test), len(r.Voters())+len(r.Learners())+len(r.NonVoters()), "testcase %d", i)
+	}
+}
+
+func TestInAtomicReplicationChange(t *testing.T) {
+	tests := []struct {
+		replicas []ReplicaDescriptor
+		expected bool
+	}{
+		{
+			expected: false,
+		},
+		{
+			replicas: []ReplicaDescriptor{{Type: ReplicaType_VOTER}, {Type: ReplicaType_LEARNER}},
+			expected: false,
+		},
+		{
+			replicas: []ReplicaDescriptor{{Type: ReplicaType_VOTER}, {Type: ReplicaType_NON_VOTER}},
+			expected: false,
+		},
+		{
+			replicas: []ReplicaDescriptor{{Type: ReplicaType_VOTER}, {Type: ReplicaType_VOTER_INCOMING}},
+			expected: true,
+		},
+		{
+			replicas: []ReplicaDescriptor{{Type: ReplicaType_NON_VOTER}, {Type: ReplicaType_VOTER_OUTGOING}},
+			expected: true,
+		},
+	}
+	for i, test := range tests {
+		r := MakeReplicaDescriptors(test.replicas)
+		assert.Equal(t, test.expected, r.InAtomicReplicationChange(), "testcase %d", i)
 	}
 }
 
@@ -156,4 +156,10 @@ const (
 	Subsume
 	// RangeStats returns the MVCC statistics for a range.
 	RangeStats
+	// QueryResolvedTimestamp returns the timestamp below which a replica can
+	// serve consistent reads without consulting the leaseholder.
+	QueryResolvedTimestamp
+	// AdminVerifyProtectedTimestamp verifies that a protected timestamp record
+	// applies to a set of ranges.
+	AdminVerifyProtectedTimestamp
 )
@@ -466,7 +466,11 @@ func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
 			}
 			details = append(details, fmt.Sprintf("store %d: in-memory, size %s",
 				i, humanizeutil.IBytes(sizeInBytes)))
-			engines = append(engines, engine.NewInMem(spec.Attributes, sizeInBytes))
+			if spec.Engine == base.EngineTypeGoLSM {
+				engines = append(engines, engine.NewInMemGoLSM(spec.Attributes, sizeInBytes))
+			} else {
+				engines = append(engines, engine.NewInMem(spec.Attributes, sizeInBytes))
+			}
 		} else {
 			if spec.Size.Percent > 0 {
 				fileSystemUsage := gosigar.FileSystemUsage{}
@@ -480,6 +484,27 @@ func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
 					spec.Size.Percent, spec.Path, humanizeutil.IBytes(sizeInBytes), humanizeutil.IBytes(base.MinimumStoreSize))
 			}
 
+			if spec.Engine == base.EngineTypeGoLSM {
+				if spec.UseFileRegistry || len(spec.ExtraOptions) > 0 {
+					return Engines{}, errors.Errorf(
+						"store %d: encryption at rest is not supported by the %s storage engine", i, spec.Engine)
+				}
+				details = append(details, fmt.Sprintf("store %d: GoLSM, max size %s",
+					i, humanizeutil.IBytes(sizeInBytes)))
+				eng, err := engine.NewGoLSM(engine.GoLSMConfig{
+					Attrs:        spec.Attributes,
+					Dir:          spec.Path,
+					MaxSizeBytes: sizeInBytes,
+					CacheSize:    cfg.CacheSize,
+					Settings:     cfg.Settings,
+				})
+				if err != nil {
+					return Engines{}, err
+				}
+				engines = append(engines, eng)
+				continue
+			}
+
 			details = append(details, fmt.Sprintf("store %d: RocksDB, max size %s, max open file limit %d",
 				i, humanizeutil.IBytes(sizeInBytes), openFileLimitPerStore))
 			rocksDBConfig := engine.RocksDBConfig{
@@ -60,6 +60,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/bulk"
 	"github.com/cockroachdb/cockroach/pkg/storage/closedts/container"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptprovider"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/ts"
 	"github.com/cockroachdb/cockroach/pkg/ui"
@@ -193,6 +195,9 @@ type Server struct {
 	adminMemMetrics    sql.MemoryMetrics
 	// sqlMemMetrics are used to track memory usage of sql sessions.
 	sqlMemMetrics sql.MemoryMetrics
+	// protectedtsProvider manages the protected timestamp records used by jobs
+	// and consulted by the GC queue.
+	protectedtsProvider protectedts.Provider
 }
 
 // NewServer creates a Server from a server.Config.
@@ -415,6 +420,12 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 	// Similarly for execCfg.
 	var execCfg sql.ExecutorConfig
 
+	s.protectedtsProvider = ptprovider.New(ptprovider.Config{
+		Settings:         st,
+		DB:               s.db,
+		InternalExecutor: internalExecutor,
+	})
+
 	// TODO(bdarnell): make StoreConfig configurable.
 	storeCfg := storage.StoreConfig{
 		DefaultZoneConfig:       &s.cfg.DefaultZoneConfig,
@@ -438,6 +449,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		LogRangeEvents:          s.cfg.EventLogEnabled,
 		RangeDescriptorCache:    s.distSender.RangeDescriptorCache(),
 		TimeSeriesDataStore:     s.tsDB,
+		ProtectedTimestampCache: s.protectedtsProvider,
 
 		// Initialize the closed timestamp subsystem. Note that it won't
 		// be ready until it is .Start()ed, but the grpc server can be
@@ -494,6 +506,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 			// in sql/jobs/registry.go on planHookMaker.
 			return sql.NewInternalPlanner(opName, nil, user, &sql.MemoryMetrics{}, &execCfg)
 		},
+		s.protectedtsProvider,
 	)
 	s.registry.AddMetricStruct(s.jobRegistry.MetricsStruct())
 
@@ -1662,6 +1675,12 @@ func (s *Server) Start(ctx context.Context) error {
 	log.Infof(ctx, "done ensuring all necessary migrations have run")
 	close(serveSQL)
 
+	// Start polling the protected timestamp records now that the table which
+	// holds them is guaranteed to exist.
+	if err := s.protectedtsProvider.Start(ctx, s.stopper); err != nil {
+		return err
+	}
+
 	log.Info(ctx, "serving sql connections")
 	// Start servicing SQL connections.
 
@@ -406,6 +406,9 @@ func anonymizeZoneConfig(dst *config.ZoneConfig, src config.ZoneConfig, secret s
 	if src.NumReplicas != nil {
 		dst.NumReplicas = proto.Int32(*src.NumReplicas)
 	}
+	if src.NumVoters != nil {
+		dst.NumVoters = proto.Int32(*src.NumVoters)
+	}
 	dst.Constraints = make([]config.Constraints, len(src.Constraints))
 	for i := range src.Constraints {
 		dst.Constraints[i].NumReplicas = src.Constraints[i].NumReplicas
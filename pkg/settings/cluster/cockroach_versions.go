@@ -50,6 +50,16 @@ const (
 	VersionQueryTxnTimestamp
 	VersionStickyBit
 	VersionParallelCommits
+	VersionProtectedTimestamps
+	VersionNonVoters
+	VersionAtomicChangeReplicas
+	VersionQueryResolvedTimestamp
+	VersionMVCCRangeTombstones
+	VersionExportParquet
+	VersionBackupEncryption
+	VersionPartitionedBackup
+	VersionScheduledJobs
+	VersionOnlineImport
 
 	// Add new versions here (step one of two).
 
@@ -479,6 +489,70 @@ var versionsSingleton = keyedVersions([]keyedVersion{
 		Key:     VersionParallelCommits,
 		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 4},
 	},
+	{
+		// VersionProtectedTimestamps introduces the system.protected_ts_records
+		// table and the GC queue's respect for the records in it.
+		Key:     VersionProtectedTimestamps,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 5},
+	},
+	{
+		// VersionNonVoters introduces replicas of type NON_VOTER, which are
+		// configured through the num_voters field of zone configs.
+		Key:     VersionNonVoters,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 6},
+	},
+	{
+		// VersionAtomicChangeReplicas allows replication changes that add and
+		// remove several replicas at once by passing through a joint raft
+		// configuration.
+		Key:     VersionAtomicChangeReplicas,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 7},
+	},
+	{
+		// VersionQueryResolvedTimestamp is the QueryResolvedTimestamp request,
+		// which bounded staleness reads use to negotiate their timestamp.
+		Key:     VersionQueryResolvedTimestamp,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 8},
+	},
+	{
+		// VersionMVCCRangeTombstones is the use_range_tombstone flag on
+		// DeleteRange, which deletes a span of keys with an MVCC range tombstone.
+		Key:     VersionMVCCRangeTombstones,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 9},
+	},
+	{
+		// VersionExportParquet is the format field on CSVWriterSpec, which EXPORT
+		// uses to write Parquet files.
+		Key:     VersionExportParquet,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 10},
+	},
+	{
+		// VersionBackupEncryption is the encryption field on Export and Import
+		// requests, which BACKUP and RESTORE use to encrypt files.
+		Key:     VersionBackupEncryption,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 11},
+	},
+	{
+		// VersionPartitionedBackup is the storage_by_locality_kv field on Export
+		// requests, which BACKUP uses to write files to the location matching the
+		// exporting node's locality.
+		Key:     VersionPartitionedBackup,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 12},
+	},
+	{
+		// VersionScheduledJobs is the system.scheduled_jobs table, which holds the
+		// schedules run by the job scheduler.
+		Key:     VersionScheduledJobs,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 13},
+	},
+	{
+		// VersionOnlineImport is the AddSSTable options to write at the request
+		// timestamp without shadowing existing values, and the DeleteRange option
+		// to delete only the keys inserted after a timestamp, which are used by
+		// IMPORT INTO to ingest into a table without taking it offline.
+		Key:     VersionOnlineImport,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 14},
+	},
 
 	// Add new versions here (step two of two).
 
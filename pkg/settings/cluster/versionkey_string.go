@@ -25,11 +25,21 @@ func _() {
 	_ = x[VersionQueryTxnTimestamp-14]
 	_ = x[VersionStickyBit-15]
 	_ = x[VersionParallelCommits-16]
+	_ = x[VersionProtectedTimestamps-17]
+	_ = x[VersionNonVoters-18]
+	_ = x[VersionAtomicChangeReplicas-19]
+	_ = x[VersionQueryResolvedTimestamp-20]
+	_ = x[VersionMVCCRangeTombstones-21]
+	_ = x[VersionExportParquet-22]
+	_ = x[VersionBackupEncryption-23]
+	_ = x[VersionPartitionedBackup-24]
+	_ = x[VersionScheduledJobs-25]
+	_ = x[VersionOnlineImport-26]
 }
 
-const _VersionKey_name = "Version2_1VersionCascadingZoneConfigsVersionLoadSplitsVersionExportStorageWorkloadVersionLazyTxnRecordVersionSequencedReadsVersionUnreplicatedRaftTruncatedStateVersionCreateStatsVersionDirectImportVersionSideloadedStorageNoReplicaIDVersionPushTxnToInclusiveVersionSnapshotsWithoutLogVersion19_1VersionStart19_2VersionQueryTxnTimestampVersionStickyBitVersionParallelCommits"
+const _VersionKey_name = "Version2_1VersionCascadingZoneConfigsVersionLoadSplitsVersionExportStorageWorkloadVersionLazyTxnRecordVersionSequencedReadsVersionUnreplicatedRaftTruncatedStateVersionCreateStatsVersionDirectImportVersionSideloadedStorageNoReplicaIDVersionPushTxnToInclusiveVersionSnapshotsWithoutLogVersion19_1VersionStart19_2VersionQueryTxnTimestampVersionStickyBitVersionParallelCommitsVersionProtectedTimestampsVersionNonVotersVersionAtomicChangeReplicasVersionQueryResolvedTimestampVersionMVCCRangeTombstonesVersionExportParquetVersionBackupEncryptionVersionPartitionedBackupVersionScheduledJobsVersionOnlineImport"
 
-var _VersionKey_index = [...]uint16{0, 10, 37, 54, 82, 102, 123, 160, 178, 197, 232, 257, 283, 294, 310, 334, 350, 372}
+var _VersionKey_index = [...]uint16{0, 10, 37, 54, 82, 102, 123, 160, 178, 197, 232, 257, 283, 294, 310, 334, 350, 372, 398, 414, 441, 470, 496, 516, 539, 563, 583, 602}
 
 func (i VersionKey) String() string {
 	if i < 0 || i >= VersionKey(len(_VersionKey_index)-1) {
@@ -118,12 +118,20 @@ func (n *alterTableNode) startExec(params runParams) error {
 					return err
 				}
 			}
-			d = newDef
+			d, userType, err := params.p.processUserDefinedTypeInColumnDef(
+				params.ctx, newDef, n.tableDesc.ParentID,
+			)
+			if err != nil {
+				return err
+			}
 
 			col, idx, expr, err := sqlbase.MakeColumnDefDescs(d, &params.p.semaCtx)
 			if err != nil {
 				return err
 			}
+			if userType != nil {
+				setColumnUserDefinedType(col, userType)
+			}
 			// If the new column has a DEFAULT expression that uses a sequence, add references between
 			// its descriptor and this column descriptor.
 			if d.HasDefaultExpr() {
@@ -166,6 +174,13 @@ func (n *alterTableNode) startExec(params runParams) error {
 					return err
 				}
 			}
+			if userType != nil && userType.Kind == sqlbase.TypeDescriptor_DOMAIN {
+				if err := addDomainColumnCheckMutations(
+					params.ctx, n.tableDesc, col.Name, userType, &params.p.semaCtx, *tn,
+				); err != nil {
+					return err
+				}
+			}
 			if d.HasColumnFamily() {
 				err := n.tableDesc.AddColumnToFamilyMaybeCreate(
 					col.Name, string(d.Family.Name), d.Family.Create,
@@ -443,6 +458,9 @@ func (n *alterTableNode) startExec(params runParams) error {
 				return err
 			}
 
+			// Privileges granted on the column go away along with it.
+			n.tableDesc.Privileges.RemoveColumn(col.ID)
+
 			found := false
 			for i := range n.tableDesc.Columns {
 				if n.tableDesc.Columns[i].ID == col.ID {
@@ -473,6 +491,11 @@ func (n *alterTableNode) startExec(params runParams) error {
 				return pgerror.Newf(pgcode.UndefinedObject,
 					"constraint %q does not exist", t.Constraint)
 			}
+			if details.CheckConstraint != nil && details.CheckConstraint.DomainID != sqlbase.InvalidID {
+				return pgerror.Newf(pgcode.DependentObjectsStillExist,
+					"constraint %q is enforced by the domain of a column and cannot be dropped",
+					t.Constraint)
+			}
 			if err := n.tableDesc.DropConstraint(
 				name, details,
 				func(desc *sqlbase.MutableTableDescriptor, idx *sqlbase.IndexDescriptor) error {
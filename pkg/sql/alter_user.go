@@ -19,87 +19,132 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/roleoption"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
 )
 
-// alterUserSetPasswordNode represents an ALTER USER ... WITH PASSWORD statement.
-type alterUserSetPasswordNode struct {
+// alterRoleNode represents an ALTER USER or ALTER ROLE statement.
+type alterRoleNode struct {
 	userAuthInfo
-	ifExists bool
-
-	run alterUserSetPasswordRun
+	ifExists    bool
+	isRole      bool
+	hasPassword bool
+	roleOptions roleoption.List
+	// viaRoleOption is true if the session user is only allowed to alter
+	// users through the CREATEROLE role option.
+	viaRoleOption bool
+
+	run alterRoleRun
 }
 
-// AlterUserSetPassword changes a user's password.
-// Privileges: UPDATE on the users table.
-func (p *planner) AlterUserSetPassword(
-	ctx context.Context, n *tree.AlterUserSetPassword,
-) (planNode, error) {
-	tDesc, err := ResolveExistingObject(ctx, p, userTableName, true /*required*/, ResolveRequireTableDesc)
+// AlterRole changes a user's or role's password and role options.
+// Privileges: UPDATE on the users table, or the CREATEROLE role option.
+func (p *planner) AlterRole(ctx context.Context, n *tree.AlterRole) (planNode, error) {
+	viaRoleOption, err := p.checkUserManagementPrivilege(ctx, privilege.UPDATE)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(ctx, tDesc, privilege.UPDATE); err != nil {
+	opName := n.StatementTag()
+	roleOptions, passwordE, err := p.makeRoleOptions(n.Options, opName)
+	if err != nil {
 		return nil, err
 	}
 
-	ua, err := p.getUserAuthInfo(n.Name, n.Password, "ALTER USER")
+	ua, err := p.getUserAuthInfo(n.Name, passwordE, opName)
 	if err != nil {
 		return nil, err
 	}
 
-	return &alterUserSetPasswordNode{
-		userAuthInfo: ua,
-		ifExists:     n.IfExists,
+	return &alterRoleNode{
+		userAuthInfo:  ua,
+		ifExists:      n.IfExists,
+		isRole:        n.IsRole,
+		hasPassword:   passwordE != nil,
+		roleOptions:   roleOptions,
+		viaRoleOption: viaRoleOption,
 	}, nil
 }
 
-// alterUserSetPasswordRun is the run-time state of
-// alterUserSetPasswordNode for local execution.
-type alterUserSetPasswordRun struct {
+// alterRoleRun is the run-time state of alterRoleNode for local execution.
+type alterRoleRun struct {
 	rowsAffected int
 }
 
-func (n *alterUserSetPasswordNode) startExec(params runParams) error {
+func (n *alterRoleNode) startExec(params runParams) error {
 	normalizedUsername, hashedPassword, err := n.userAuthInfo.resolve()
 	if err != nil {
 		return err
 	}
 
-	// The root user is not allowed a password.
-	if normalizedUsername == security.RootUser {
-		return pgerror.Newf(pgcode.InvalidPassword,
-			"user %s cannot use password authentication", security.RootUser)
+	var opName, entryType string
+	if n.isRole {
+		opName, entryType = "alter-role", "role"
+	} else {
+		opName, entryType = "alter-user", "user"
 	}
 
-	if len(hashedPassword) > 0 && params.extendedEvalCtx.ExecCfg.RPCContext.Insecure {
-		return pgerror.New(pgcode.InvalidPassword,
-			"cluster in insecure mode; user cannot use password authentication")
+	// ALTER USER only applies to users; ALTER ROLE applies to both.
+	query := `SELECT "isRole" FROM system.users WHERE username = $1`
+	if !n.isRole {
+		query += ` AND "isRole" = false`
 	}
-
-	n.run.rowsAffected, err = params.extendedEvalCtx.ExecCfg.InternalExecutor.Exec(
-		params.ctx,
-		"update-user",
-		params.p.txn,
-		`UPDATE system.users SET "hashedPassword" = $2 WHERE username = $1 AND "isRole" = false`,
-		normalizedUsername,
-		hashedPassword,
+	row, err := params.extendedEvalCtx.ExecCfg.InternalExecutor.QueryRow(
+		params.ctx, opName, params.p.txn, query, normalizedUsername,
 	)
 	if err != nil {
-		return err
+		return errors.Wrapf(err, "error looking up user")
 	}
-	if n.run.rowsAffected == 0 && !n.ifExists {
+	if row == nil {
+		if n.ifExists {
+			return nil
+		}
 		return pgerror.Newf(pgcode.UndefinedObject,
-			"user %s does not exist", normalizedUsername)
+			"%s %s does not exist", entryType, normalizedUsername)
+	}
+
+	if n.viaRoleOption {
+		if err := params.p.checkNotSuperuser(params.ctx, normalizedUsername, "alter"); err != nil {
+			return err
+		}
+	}
+
+	if n.hasPassword {
+		// The root user is not allowed a password.
+		if normalizedUsername == security.RootUser {
+			return pgerror.Newf(pgcode.InvalidPassword,
+				"user %s cannot use password authentication", security.RootUser)
+		}
+
+		if len(hashedPassword) > 0 && params.extendedEvalCtx.ExecCfg.RPCContext.Insecure {
+			return pgerror.New(pgcode.InvalidPassword,
+				"cluster in insecure mode; user cannot use password authentication")
+		}
+
+		if _, err := params.extendedEvalCtx.ExecCfg.InternalExecutor.Exec(
+			params.ctx,
+			opName,
+			params.p.txn,
+			`UPDATE system.users SET "hashedPassword" = $2 WHERE username = $1`,
+			normalizedUsername,
+			hashedPassword,
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := params.p.applyRoleOptions(params.ctx, opName, normalizedUsername, n.roleOptions); err != nil {
+		return err
 	}
-	return err
+	n.run.rowsAffected = 1
+	return nil
 }
 
-func (*alterUserSetPasswordNode) Next(runParams) (bool, error) { return false, nil }
-func (*alterUserSetPasswordNode) Values() tree.Datums          { return tree.Datums{} }
-func (*alterUserSetPasswordNode) Close(context.Context)        {}
+func (*alterRoleNode) Next(runParams) (bool, error) { return false, nil }
+func (*alterRoleNode) Values() tree.Datums          { return tree.Datums{} }
+func (*alterRoleNode) Close(context.Context)        {}
 
-func (n *alterUserSetPasswordNode) FastPathResults() (int, bool) {
+func (n *alterRoleNode) FastPathResults() (int, bool) {
 	return n.run.rowsAffected, true
 }
@@ -14,11 +14,13 @@ package sql
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/roleoption"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
@@ -53,6 +55,10 @@ type AuthorizationAccessor interface {
 	// MemberOfWithAdminOption looks up all the roles (direct and indirect) that 'member' is a member
 	// of and returns a map of role -> isAdmin.
 	MemberOfWithAdminOption(ctx context.Context, member string) (map[string]bool, error)
+
+	// HasRoleOption returns true if the session user is a super-user or has
+	// the given role option, e.g. CREATEROLE.
+	HasRoleOption(ctx context.Context, option roleoption.Option) (bool, error)
 }
 
 var _ AuthorizationAccessor = &planner{}
@@ -112,6 +118,52 @@ func (p *planner) CheckPrivilege(
 		user, privilege, descriptor.TypeName(), descriptor.GetName())
 }
 
+// CheckColumnPrivilege verifies that the user has `privilege` on the column
+// with the given ID of `descriptor`, either because it was granted on the
+// table as a whole or on the column itself.
+func (p *planner) CheckColumnPrivilege(
+	ctx context.Context,
+	descriptor *sqlbase.TableDescriptor,
+	colID sqlbase.ColumnID,
+	privilege privilege.Kind,
+) error {
+	p.maybeAudit(descriptor, privilege)
+
+	user := p.SessionData().User
+	privs := descriptor.GetPrivileges()
+
+	// Check if 'user' itself has privileges.
+	if privs.CheckColumnPrivilege(user, privilege, colID) {
+		return nil
+	}
+
+	// Check if the 'public' pseudo-role has privileges.
+	if privs.CheckColumnPrivilege(sqlbase.PublicRole, privilege, colID) {
+		return nil
+	}
+
+	// Expand role memberships.
+	memberOf, err := p.MemberOfWithAdminOption(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	// Iterate over the roles that 'user' is a member of. We don't care about the admin option.
+	for role := range memberOf {
+		if privs.CheckColumnPrivilege(role, privilege, colID) {
+			return nil
+		}
+	}
+
+	colName := "[" + strconv.Itoa(int(colID)) + "]"
+	if col, err := descriptor.FindColumnByID(colID); err == nil {
+		colName = col.Name
+	}
+	return pgerror.Newf(pgcode.InsufficientPrivilege,
+		"user %s does not have %s privilege on column %s of %s %s",
+		user, privilege, colName, descriptor.TypeName(), descriptor.GetName())
+}
+
 // CheckAnyPrivilege implements the AuthorizationAccessor interface.
 func (p *planner) CheckAnyPrivilege(ctx context.Context, descriptor sqlbase.DescriptorProto) error {
 	user := p.SessionData().User
@@ -169,6 +221,32 @@ func (p *planner) RequireSuperUser(ctx context.Context, action string) error {
 		"only superusers are allowed to %s", action)
 }
 
+// HasRoleOption implements the AuthorizationAccessor interface.
+func (p *planner) HasRoleOption(ctx context.Context, option roleoption.Option) (bool, error) {
+	user := p.SessionData().User
+	if user == security.RootUser || user == security.NodeUser {
+		return true, nil
+	}
+
+	memberOf, err := p.MemberOfWithAdminOption(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := memberOf[sqlbase.AdminRole]; ok {
+		return true, nil
+	}
+
+	row, err := p.ExecCfg().InternalExecutor.QueryRow(
+		ctx, "has-role-option", p.txn,
+		`SELECT 1 FROM system.role_options WHERE username = $1 AND option = $2`,
+		user, option.String(),
+	)
+	if err != nil {
+		return false, err
+	}
+	return row != nil, nil
+}
+
 // MemberOfWithAdminOption looks up all the roles 'member' belongs to (direct and indirect) and
 // returns a map of "role" -> "isAdmin".
 // The "isAdmin" flag applies to both direct and indirect members.
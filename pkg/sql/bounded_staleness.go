@@ -0,0 +1,235 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// negotiateBoundedStaleness determines the timestamp at which a bounded
+// staleness read is performed. It asks the nearest replica of every range of
+// the tables read by the statement for its resolved (closed) timestamp and
+// picks the minimum, which is the most recent timestamp at which the whole
+// read can be served locally without consulting any leaseholder.
+//
+// If that timestamp is older than the minimum timestamp admitted by the AS OF
+// SYSTEM TIME clause, the read is performed at the minimum timestamp and may
+// be served by the leaseholders, unless the clause asked for nearest_only, in
+// which case an error is returned. The returned boolean indicates whether the
+// read can be routed to the nearest replicas.
+func (p *planner) negotiateBoundedStaleness(
+	ctx context.Context, stmt tree.Statement, asOf tree.AsOfSystemTime,
+) (_ hlc.Timestamp, nearest bool, _ error) {
+	if !p.execCfg.Settings.Version.IsActive(cluster.VersionQueryResolvedTimestamp) {
+		return hlc.Timestamp{}, false, pgerror.Newf(pgcode.FeatureNotSupported,
+			"bounded staleness reads require all nodes to be upgraded to %s",
+			cluster.VersionByKey(cluster.VersionQueryResolvedTimestamp))
+	}
+
+	tables, err := boundedStalenessTables(stmt)
+	if err != nil {
+		return hlc.Timestamp{}, false, err
+	}
+
+	b := &client.Batch{}
+	b.Header.ReadConsistency = roachpb.INCONSISTENT
+	numRequests := 0
+	for _, name := range tables {
+		// Name resolution modifies the name in place, so resolve a copy to
+		// leave the statement untouched for planning.
+		tn := *name
+		desc, err := ResolveExistingObject(ctx, p, &tn, true /* required */, ResolveAnyDescType)
+		if err != nil {
+			return hlc.Timestamp{}, false, err
+		}
+		if desc.IsVirtualTable() {
+			// Virtual tables are not stored in KV and can be read at any
+			// timestamp.
+			continue
+		}
+		if desc.IsView() {
+			return hlc.Timestamp{}, false, unimplemented.New("bounded staleness views",
+				"bounded staleness reads of views are not supported")
+		}
+		b.AddRawRequest(&roachpb.QueryResolvedTimestampRequest{
+			RequestHeader: roachpb.RequestHeaderFromSpan(desc.TableSpan()),
+		})
+		numRequests++
+	}
+
+	// Without any tables to read, the statement can be served at the current
+	// time.
+	resolved := p.execCfg.Clock.Now()
+	if numRequests > 0 {
+		if err := p.execCfg.DB.Run(ctx, b); err != nil {
+			return hlc.Timestamp{}, false, err
+		}
+		for _, ru := range b.RawResponse().Responses {
+			resp := ru.GetInner().(*roachpb.QueryResolvedTimestampResponse)
+			if resp.ResolvedTS.Less(resolved) {
+				resolved = resp.ResolvedTS
+			}
+		}
+	}
+	if log.V(2) {
+		log.Infof(ctx, "negotiated bounded staleness timestamp %s (minimum %s)", resolved, asOf.Timestamp)
+	}
+
+	if resolved.Less(asOf.Timestamp) {
+		if asOf.NearestOnly {
+			return hlc.Timestamp{}, false, pgerror.Newf(pgcode.UnsatisfiableBoundedStaleness,
+				"bounded staleness read with minimum timestamp %s could not be satisfied by a local "+
+					"resolved timestamp of %s", asOf.Timestamp, resolved)
+		}
+		return asOf.Timestamp, false, nil
+	}
+	return resolved, true, nil
+}
+
+// boundedStalenessTables returns the names of the tables read by a statement
+// performing a bounded staleness read. Only SELECT statements which read
+// directly from tables (possibly joined together) are supported. Statements
+// containing subqueries, which may read other tables, are rejected.
+func boundedStalenessTables(stmt tree.Statement) ([]*tree.TableName, error) {
+	errUnsupported := unimplemented.New("bounded staleness queries",
+		"bounded staleness reads are only supported for SELECT statements reading from tables")
+
+	sel, ok := stmt.(*tree.Select)
+	if !ok || sel.With != nil {
+		return nil, errUnsupported
+	}
+	var v subqueryFinder
+	v.walkSelect(sel)
+	selStmt := sel.Select
+	for parenSel, ok := selStmt.(*tree.ParenSelect); ok; parenSel, ok = selStmt.(*tree.ParenSelect) {
+		if parenSel.Select.With != nil {
+			return nil, errUnsupported
+		}
+		v.walkSelect(parenSel.Select)
+		selStmt = parenSel.Select.Select
+	}
+	sc, ok := selStmt.(*tree.SelectClause)
+	if !ok {
+		return nil, errUnsupported
+	}
+	v.walkSelectClause(sc)
+
+	var tables []*tree.TableName
+	var walk func(tree.TableExpr) bool
+	walk = func(expr tree.TableExpr) bool {
+		switch t := expr.(type) {
+		case *tree.TableName:
+			tables = append(tables, t)
+			return true
+		case *tree.AliasedTableExpr:
+			return walk(t.Expr)
+		case *tree.ParenTableExpr:
+			return walk(t.Expr)
+		case *tree.JoinTableExpr:
+			if cond, ok := t.Cond.(*tree.OnJoinCond); ok {
+				v.walk(cond.Expr)
+			}
+			return walk(t.Left) && walk(t.Right)
+		default:
+			return false
+		}
+	}
+	for _, expr := range sc.From.Tables {
+		if !walk(expr) {
+			return nil, errUnsupported
+		}
+	}
+	if v.found {
+		return nil, errUnsupported
+	}
+	return tables, nil
+}
+
+// subqueryFinder is a tree.Visitor which records whether any of the
+// expressions it walks contain a subquery.
+type subqueryFinder struct {
+	found bool
+}
+
+var _ tree.Visitor = &subqueryFinder{}
+
+// VisitPre is part of the tree.Visitor interface.
+func (v *subqueryFinder) VisitPre(expr tree.Expr) (recurse bool, newExpr tree.Expr) {
+	if _, ok := expr.(*tree.Subquery); ok {
+		v.found = true
+	}
+	return !v.found, expr
+}
+
+// VisitPost is part of the tree.Visitor interface.
+func (v *subqueryFinder) VisitPost(expr tree.Expr) tree.Expr { return expr }
+
+func (v *subqueryFinder) walk(expr tree.Expr) {
+	if expr != nil {
+		tree.WalkExprConst(v, expr)
+	}
+}
+
+// walkSelect walks the ORDER BY and LIMIT expressions of sel.
+func (v *subqueryFinder) walkSelect(sel *tree.Select) {
+	for _, o := range sel.OrderBy {
+		v.walk(o.Expr)
+	}
+	if sel.Limit != nil {
+		v.walk(sel.Limit.Offset)
+		v.walk(sel.Limit.Count)
+	}
+}
+
+// walkSelectClause walks the expressions of sc other than those in its FROM
+// clause.
+func (v *subqueryFinder) walkSelectClause(sc *tree.SelectClause) {
+	for _, expr := range sc.Exprs {
+		v.walk(expr.Expr)
+	}
+	if sc.Where != nil {
+		v.walk(sc.Where.Expr)
+	}
+	for _, expr := range sc.GroupBy {
+		v.walk(expr)
+	}
+	if sc.Having != nil {
+		v.walk(sc.Having.Expr)
+	}
+	for _, w := range sc.Window {
+		for _, expr := range w.Partitions {
+			v.walk(expr)
+		}
+		for _, o := range w.OrderBy {
+			v.walk(o.Expr)
+		}
+		if w.Frame != nil {
+			if b := w.Frame.Bounds.StartBound; b != nil {
+				v.walk(b.OffsetExpr)
+			}
+			if b := w.Frame.Bounds.EndBound; b != nil {
+				v.walk(b.OffsetExpr)
+			}
+		}
+	}
+}
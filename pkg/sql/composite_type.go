@@ -0,0 +1,155 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
+	"github.com/cockroachdb/errors"
+)
+
+// Composite types are stored as type descriptors, like domains. The type
+// descriptor holds the row type of the composite type: a labeled tuple type
+// which also carries the name of the composite type. Columns declared with a
+// composite type are stored with its row type, which is value encoded, and
+// their fields are accessed with (col).field like those of any labeled tuple.
+
+// rejectUserDefinedType returns an error if typ refers to a user-defined type.
+// User-defined types cannot be used to define other user-defined types.
+func (p *planner) rejectUserDefinedType(ctx context.Context, typ *types.T) error {
+	name, ok := typ.DomainReference()
+	if !ok {
+		return nil
+	}
+	if _, err := p.findTypeReference(ctx, typ); err != nil {
+		return err
+	}
+	return unimplemented.NewWithIssueDetailf(27792, "udt",
+		"user-defined type %s cannot be used to define another type", name)
+}
+
+type createTypeNode struct {
+	n       *tree.CreateType
+	dbDesc  *sqlbase.DatabaseDescriptor
+	rowType *types.T
+}
+
+// CreateType creates a composite type.
+// Privileges: CREATE on database.
+func (p *planner) CreateType(ctx context.Context, n *tree.CreateType) (planNode, error) {
+	dbDesc, err := p.ResolveUncachedDatabase(ctx, &n.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.CheckPrivilege(ctx, dbDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+
+	contents := make([]types.T, len(n.Fields))
+	labels := make([]string, len(n.Fields))
+	for i := range n.Fields {
+		f := &n.Fields[i]
+		for j := 0; j < i; j++ {
+			if n.Fields[j].Name == f.Name {
+				return nil, pgerror.Newf(pgcode.DuplicateColumn,
+					"column %q specified more than once", f.Name)
+			}
+		}
+		if err := p.rejectUserDefinedType(ctx, f.Type); err != nil {
+			return nil, err
+		}
+		if err := sqlbase.ValidateColumnDefType(f.Type); err != nil {
+			return nil, err
+		}
+		contents[i] = *f.Type
+		labels[i] = string(f.Name)
+	}
+	rowType := types.MakeCompositeType(n.Name.Table(), contents, labels)
+	return &createTypeNode{n: n, dbDesc: dbDesc, rowType: rowType}, nil
+}
+
+func (n *createTypeNode) startExec(params runParams) error {
+	return params.p.createTypeDesc(params.ctx, &sqlbase.TypeDescriptor{
+		Name:     n.n.Name.Table(),
+		ParentID: n.dbDesc.ID,
+		// Inherit permissions from the database descriptor.
+		Privileges: n.dbDesc.GetPrivileges(),
+		Kind:       sqlbase.TypeDescriptor_COMPOSITE,
+		BaseType:   *n.rowType,
+	})
+}
+
+func (*createTypeNode) Next(runParams) (bool, error) { return false, nil }
+func (*createTypeNode) Values() tree.Datums          { return tree.Datums{} }
+func (*createTypeNode) Close(context.Context)        {}
+
+type dropTypeNode struct {
+	td []*sqlbase.TypeDescriptor
+}
+
+// DropType drops composite types.
+// Privileges: DROP on type.
+func (p *planner) DropType(ctx context.Context, n *tree.DropType) (planNode, error) {
+	if n.DropBehavior == tree.DropCascade {
+		return nil, unimplemented.NewWithIssue(27792, "DROP TYPE CASCADE")
+	}
+	td := make([]*sqlbase.TypeDescriptor, 0, len(n.Names))
+	for i := range n.Names {
+		typ, err := p.findUncachedType(ctx, &n.Names[i], !n.IfExists)
+		if err != nil {
+			return nil, err
+		}
+		if typ == nil {
+			// IfExists specified and the type does not exist.
+			continue
+		}
+		if typ.Kind == sqlbase.TypeDescriptor_DOMAIN {
+			return nil, errors.WithHint(
+				pgerror.Newf(pgcode.WrongObjectType, "%q is a domain", typ.Name),
+				"Use DROP DOMAIN to remove a domain.")
+		}
+		if err := p.CheckPrivilege(ctx, typ, privilege.DROP); err != nil {
+			return nil, err
+		}
+		cols, err := findTypeColumns(ctx, p.txn, typ)
+		if err != nil {
+			return nil, err
+		}
+		if len(cols) > 0 {
+			return nil, pgerror.Newf(pgcode.DependentObjectsStillExist,
+				"cannot drop type %s because column %s of table %s depends on it",
+				tree.ErrNameString(typ.Name), tree.ErrNameString(cols[0].col.Name),
+				tree.ErrNameString(cols[0].table.Name))
+		}
+		td = append(td, typ)
+	}
+	if len(td) == 0 {
+		return newZeroNode(nil /* columns */), nil
+	}
+	return &dropTypeNode{td: td}, nil
+}
+
+func (n *dropTypeNode) startExec(params runParams) error {
+	return params.p.deleteTypeDescs(params.ctx, n.td)
+}
+
+func (*dropTypeNode) Next(runParams) (bool, error) { return false, nil }
+func (*dropTypeNode) Values() tree.Datums          { return tree.Datums{} }
+func (*dropTypeNode) Close(context.Context)        {}
@@ -1887,6 +1887,7 @@ func (ex *connExecutor) resetPlanner(
 	p.semaCtx = tree.MakeSemaContext()
 	p.semaCtx.Location = &ex.sessionData.DataConversion.Location
 	p.semaCtx.SearchPath = ex.sessionData.SearchPath
+	p.semaCtx.TypeResolver = p
 	p.semaCtx.AsOfTimestamp = nil
 	p.semaCtx.Annotations = tree.MakeAnnotations(numAnnotations)
 
@@ -1895,6 +1896,7 @@ func (ex *connExecutor) resetPlanner(
 	p.autoCommit = false
 	p.isPreparing = false
 	p.avoidCachedDescriptors = false
+	p.domainCheckers = &domainCheckerCache{}
 }
 
 // txnStateTransitionsApplyWrapper is a wrapper on top of Machine built with the
@@ -1989,14 +1991,27 @@ func (ex *connExecutor) txnStateTransitionsApplyWrapper(
 			}
 		}
 
-		// Wait for the cache to reflect the dropped databases if any.
+		// Wait for the cache to reflect the dropped databases and modified
+		// types if any.
 		ex.extraTxnState.tables.waitForCacheToDropDatabases(ex.Ctx())
+		ex.extraTxnState.tables.waitForCacheToUpdateTypes(ex.Ctx())
 
 		fallthrough
 	case txnRestart, txnAborted:
 		if err := ex.resetExtraTxnState(ex.Ctx(), ex.server.dbCache); err != nil {
 			return advanceInfo{}, err
 		}
+		// SET LOCAL statements are reverted when the transaction commits or is
+		// finally rolled back. They survive errors moving the transaction to the
+		// Aborted state, but a ROLLBACK TO SAVEPOINT reverts those executed since
+		// the savepoint, whose statements the client re-runs. Automatic retries
+		// rewind to and re-execute the statements themselves instead.
+		if ex.dataMutator != nil && ex.txnFinished(advInfo.txnEvent) {
+			ex.dataMutator.endLocal()
+		}
+		if ex.dataMutator != nil && advInfo.txnEvent == txnRestart && advInfo.code != rewind {
+			ex.dataMutator.rollbackToSavepoint()
+		}
 	default:
 		return advanceInfo{}, errors.AssertionFailedf(
 			"unexpected event: %v", errors.Safe(advInfo.txnEvent))
@@ -2005,6 +2020,22 @@ func (ex *connExecutor) txnStateTransitionsApplyWrapper(
 	return advInfo, nil
 }
 
+// txnFinished returns whether the given transaction event, produced by the
+// last state transition, finished the SQL transaction.
+func (ex *connExecutor) txnFinished(ev txnEvent) bool {
+	switch ev {
+	case txnCommit:
+		return true
+	case txnAborted:
+		// The event is also produced when an explicit transaction moves to the
+		// Aborted state, from which it can still be resumed.
+		_, aborted := ex.machine.CurState().(stateAborted)
+		return !aborted
+	default:
+		return false
+	}
+}
+
 // initStatementResult initializes res according to a query.
 //
 // cols represents the columns of the result rows. Should be nil if
@@ -275,6 +275,9 @@ func (ex *connExecutor) execStmtInOpenState(
 			return makeErrEvent(err)
 		}
 		ex.state.activeSavepointName = s.Name
+		if ex.dataMutator != nil {
+			ex.dataMutator.beginSavepoint()
+		}
 		// Note that Savepoint doesn't have a corresponding plan node.
 		// This here is all the execution there is.
 		return eventRetryIntentSet{}, nil /* payload */, nil
@@ -310,6 +313,9 @@ func (ex *connExecutor) execStmtInOpenState(
 			}
 			typeHints = make(tree.PlaceholderTypes, stmt.NumPlaceholders)
 			for i, t := range s.Types {
+				if name, ok := t.DomainReference(); ok {
+					return makeErrEvent(tree.NewUndefinedTypeError(name))
+				}
 				typeHints[i] = t
 			}
 		}
@@ -368,25 +374,40 @@ func (ex *connExecutor) execStmtInOpenState(
 	ex.resetPlanner(ctx, p, ex.state.mu.txn, stmtTS, stmt.NumAnnotations)
 
 	if os.ImplicitTxn.Get() {
-		asOfTs, err := p.isAsOf(stmt.AST)
+		asOf, err := p.isAsOf(stmt.AST)
 		if err != nil {
 			return makeErrEvent(err)
 		}
-		if asOfTs != nil {
-			p.semaCtx.AsOfTimestamp = asOfTs
+		if asOf != nil {
+			asOfTs := asOf.Timestamp
+			if asOf.BoundedStaleness {
+				var nearest bool
+				asOfTs, nearest, err = p.negotiateBoundedStaleness(ctx, stmt.AST, *asOf)
+				if err != nil {
+					return makeErrEvent(err)
+				}
+				if nearest {
+					p.txn.SetRoutingPolicy(roachpb.NEAREST)
+				}
+			}
+			p.semaCtx.AsOfTimestamp = &asOfTs
 			p.extendedEvalCtx.SetTxnTimestamp(asOfTs.GoTime())
-			ex.state.setHistoricalTimestamp(ctx, *asOfTs)
+			ex.state.setHistoricalTimestamp(ctx, asOfTs)
 		}
 	} else {
 		// If we're in an explicit txn, we allow AOST but only if it matches with
 		// the transaction's timestamp. This is useful for running AOST statements
 		// using the InternalExecutor inside an external transaction; one might want
 		// to do that to force p.avoidCachedDescriptors to be set below.
-		ts, err := p.isAsOf(stmt.AST)
+		asOf, err := p.isAsOf(stmt.AST)
 		if err != nil {
 			return makeErrEvent(err)
 		}
-		if ts != nil {
+		if asOf != nil {
+			if asOf.BoundedStaleness {
+				return makeErrEvent(tree.ErrBoundedStalenessNotAllowed)
+			}
+			ts := &asOf.Timestamp
 			if origTs := ex.state.getOrigTimestamp(); *ts != origTs {
 				err = pgerror.Newf(pgcode.Syntax,
 					"inconsistent AS OF SYSTEM TIME timestamp; expected: %s", origTs)
@@ -688,7 +709,12 @@ func (ex *connExecutor) dispatchToExecutionEngine(
 	distributePlan := false
 	// If we use the optimizer and we are in "local" mode, don't try to
 	// distribute.
-	if ex.sessionData.OptimizerMode != sessiondata.OptimizerLocal {
+	//
+	// Bounded staleness reads are not distributed either: their requests must be
+	// routed to the nearest replicas, which the leaf transactions of remote
+	// flows would not do.
+	if ex.sessionData.OptimizerMode != sessiondata.OptimizerLocal &&
+		planner.txn.RoutingPolicy() != roachpb.NEAREST {
 		planner.prepareForDistSQLSupportCheck()
 		distributePlan = shouldDistributePlan(
 			ctx, ex.sessionData.DistSQLMode, ex.server.cfg.DistSQLPlanner, planner.curPlan.plan)
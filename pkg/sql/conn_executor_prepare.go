@@ -188,13 +188,16 @@ func (ex *connExecutor) populatePrepared(
 
 	p.extendedEvalCtx.PrepareOnly = true
 
-	protoTS, err := p.isAsOf(stmt.AST)
+	asOf, err := p.isAsOf(stmt.AST)
 	if err != nil {
 		return 0, err
 	}
-	if protoTS != nil {
-		p.semaCtx.AsOfTimestamp = protoTS
-		txn.SetFixedTimestamp(ctx, *protoTS)
+	if asOf != nil {
+		// For bounded staleness reads, the statement is prepared at the minimum
+		// timestamp. The actual timestamp is negotiated when it is executed.
+		protoTS := asOf.Timestamp
+		p.semaCtx.AsOfTimestamp = &protoTS
+		txn.SetFixedTimestamp(ctx, protoTS)
 	}
 
 	// PREPARE has a limited subset of statements it can be run with. Postgres
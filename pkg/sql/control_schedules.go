@@ -0,0 +1,111 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+type controlSchedulesNode struct {
+	rows    planNode
+	command tree.ScheduleCommand
+	numRows int
+}
+
+func (p *planner) ControlSchedules(
+	ctx context.Context, n *tree.ControlSchedules,
+) (planNode, error) {
+	if err := p.RequireSuperUser(
+		ctx, tree.ScheduleCommandToStatement[n.Command]+" SCHEDULES",
+	); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.newPlan(ctx, n.Schedules, []*types.T{types.Int})
+	if err != nil {
+		return nil, err
+	}
+	cols := planColumns(rows)
+	if len(cols) != 1 {
+		return nil, pgerror.Newf(pgcode.Syntax,
+			"%s SCHEDULES expects a single column source, got %d columns",
+			tree.ScheduleCommandToStatement[n.Command], len(cols))
+	}
+	if cols[0].Typ.Family() != types.IntFamily {
+		return nil, pgerror.Newf(pgcode.DatatypeMismatch,
+			"%s SCHEDULES requires int values, not type %s",
+			tree.ScheduleCommandToStatement[n.Command], cols[0].Typ)
+	}
+
+	return &controlSchedulesNode{
+		rows:    rows,
+		command: n.Command,
+	}, nil
+}
+
+// FastPathResults implements the planNodeFastPath inteface.
+func (n *controlSchedulesNode) FastPathResults() (int, bool) {
+	return n.numRows, true
+}
+
+func (n *controlSchedulesNode) startExec(params runParams) error {
+	reg := params.p.ExecCfg().JobRegistry
+	for {
+		ok, err := n.rows.Next(params)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		scheduleIDDatum := n.rows.Values()[0]
+		if scheduleIDDatum == tree.DNull {
+			continue
+		}
+
+		scheduleID, ok := tree.AsDInt(scheduleIDDatum)
+		if !ok {
+			return errors.AssertionFailedf("%q: expected *DInt, found %T",
+				scheduleIDDatum, scheduleIDDatum)
+		}
+
+		switch n.command {
+		case tree.PauseSchedule:
+			err = reg.PauseSchedule(params.ctx, params.p.txn, int64(scheduleID))
+		case tree.ResumeSchedule:
+			err = reg.ResumeSchedule(params.ctx, params.p.txn, int64(scheduleID))
+		default:
+			err = errors.AssertionFailedf("unhandled command %v", n.command)
+		}
+		if err != nil {
+			return err
+		}
+		n.numRows++
+	}
+	return nil
+}
+
+func (*controlSchedulesNode) Next(runParams) (bool, error) { return false, nil }
+
+func (*controlSchedulesNode) Values() tree.Datums { return nil }
+
+func (n *controlSchedulesNode) Close(ctx context.Context) {
+	n.rows.Close(ctx)
+}
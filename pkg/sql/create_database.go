@@ -16,16 +16,21 @@ import (
 	"context"
 	"strings"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/roleoption"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
 )
 
 type createDatabaseNode struct {
 	n *tree.CreateDatabase
+	// grantCreator is set if the database is created by a non-superuser with
+	// the CREATEDB role option.
+	grantCreator bool
 }
 
 // CreateDatabase creates a database.
-// Privileges: superuser.
+// Privileges: superuser or the CREATEDB role option.
 //   Notes: postgres requires superuser or "CREATEDB".
 //          mysql uses the mysqladmin command.
 func (p *planner) CreateDatabase(ctx context.Context, n *tree.CreateDatabase) (planNode, error) {
@@ -67,15 +72,28 @@ func (p *planner) CreateDatabase(ctx context.Context, n *tree.CreateDatabase) (p
 		}
 	}
 
+	grantCreator := false
 	if err := p.RequireSuperUser(ctx, "CREATE DATABASE"); err != nil {
-		return nil, err
+		// Users with the CREATEDB role option can create databases, and are
+		// granted ALL on the databases they create.
+		hasCreateDB, hasErr := p.HasRoleOption(ctx, roleoption.CREATEDB)
+		if hasErr != nil {
+			return nil, hasErr
+		}
+		if !hasCreateDB {
+			return nil, err
+		}
+		grantCreator = true
 	}
 
-	return &createDatabaseNode{n: n}, nil
+	return &createDatabaseNode{n: n, grantCreator: grantCreator}, nil
 }
 
 func (n *createDatabaseNode) startExec(params runParams) error {
 	desc := makeDatabaseDesc(n.n)
+	if n.grantCreator {
+		desc.Privileges.Grant(params.SessionData().User, privilege.List{privilege.ALL})
+	}
 
 	created, err := params.p.createDatabase(params.ctx, &desc, n.n.IfNotExists)
 	if err != nil {
@@ -1260,16 +1260,19 @@ func makeTableDesc(
 	privileges *sqlbase.PrivilegeDescriptor,
 	affected map[sqlbase.ID]*sqlbase.MutableTableDescriptor,
 ) (ret sqlbase.MutableTableDescriptor, err error) {
-	// Process any SERIAL columns to remove the SERIAL type,
-	// as required by MakeTableDesc.
+	// Process any SERIAL columns to remove the SERIAL type, and any columns
+	// declared with a user-defined type to replace it by the type the column
+	// is stored with, as required by MakeTableDesc. The user-defined types and
+	// the constraints of the domains are added once the descriptor is made.
 	createStmt := n
 	ensureCopy := func() {
 		if createStmt == n {
 			newCreateStmt := *n
-			n.Defs = append(tree.TableDefs(nil), n.Defs...)
+			newCreateStmt.Defs = append(tree.TableDefs(nil), n.Defs...)
 			createStmt = &newCreateStmt
 		}
 	}
+	userTypes := make(map[string]*sqlbase.TypeDescriptor)
 	for i, def := range n.Defs {
 		d, ok := def.(*tree.ColumnTableDef)
 		if !ok {
@@ -1284,9 +1287,16 @@ func makeTableDesc(
 				return ret, err
 			}
 		}
+		newDef, typ, err := params.p.processUserDefinedTypeInColumnDef(params.ctx, newDef, parentID)
+		if err != nil {
+			return ret, err
+		}
+		if typ != nil {
+			userTypes[string(d.Name)] = typ
+		}
 		if d != newDef {
 			ensureCopy()
-			n.Defs[i] = newDef
+			createStmt.Defs[i] = newDef
 		}
 	}
 
@@ -1300,7 +1310,7 @@ func makeTableDesc(
 			params.p.txn,
 			params.p,
 			params.p.ExecCfg().Settings,
-			n,
+			createStmt,
 			parentID,
 			id,
 			creationTime,
@@ -1310,6 +1320,10 @@ func makeTableDesc(
 			params.EvalContext(),
 		)
 	})
+	if err != nil || len(userTypes) == 0 {
+		return ret, err
+	}
+	err = addUserDefinedTypesToTableDesc(params.ctx, &ret, userTypes, &params.p.semaCtx, n.Table)
 	return ret, err
 }
 
@@ -15,13 +15,17 @@ package sql
 import (
 	"context"
 	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/roleoption"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -30,6 +34,7 @@ import (
 type CreateUserNode struct {
 	ifNotExists bool
 	isRole      bool
+	roleOptions roleoption.List
 	userAuthInfo
 
 	run createUserRun
@@ -38,23 +43,28 @@ type CreateUserNode struct {
 var userTableName = tree.NewTableName("system", "users")
 
 // CreateUser creates a user.
-// Privileges: INSERT on system.users.
+// Privileges: INSERT on system.users, or the CREATEROLE role option.
 //   notes: postgres allows the creation of users with an empty password. We do
 //          as well, but disallow password authentication for these users.
 func (p *planner) CreateUser(ctx context.Context, n *tree.CreateUser) (planNode, error) {
-	return p.CreateUserNode(ctx, n.Name, n.Password, n.IfNotExists, false /* isRole */, "CREATE USER")
+	return p.CreateUserNode(ctx, n.Name, n.IfNotExists, false /* isRole */, "CREATE USER", n.Options)
 }
 
 // CreateUserNode creates a "create user" plan node. This can be called from CREATE USER or CREATE ROLE.
 func (p *planner) CreateUserNode(
-	ctx context.Context, nameE, passwordE tree.Expr, ifNotExists bool, isRole bool, opName string,
+	ctx context.Context,
+	nameE tree.Expr,
+	ifNotExists bool,
+	isRole bool,
+	opName string,
+	opts tree.RoleOptions,
 ) (*CreateUserNode, error) {
-	tDesc, err := ResolveExistingObject(ctx, p, userTableName, true /*required*/, ResolveRequireTableDesc)
-	if err != nil {
+	if _, err := p.checkUserManagementPrivilege(ctx, privilege.INSERT); err != nil {
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(ctx, tDesc, privilege.INSERT); err != nil {
+	roleOptions, passwordE, err := p.makeRoleOptions(opts, opName)
+	if err != nil {
 		return nil, err
 	}
 
@@ -67,6 +77,7 @@ func (p *planner) CreateUserNode(
 		userAuthInfo: ua,
 		ifNotExists:  ifNotExists,
 		isRole:       isRole,
+		roleOptions:  roleOptions,
 	}, nil
 }
 
@@ -136,7 +147,7 @@ func (n *CreateUserNode) startExec(params runParams) error {
 		)
 	}
 
-	return nil
+	return params.p.applyRoleOptions(params.ctx, opName, normalizedUsername, n.roleOptions)
 }
 
 type createUserRun struct {
@@ -210,6 +221,173 @@ func (p *planner) getUserAuthInfo(nameE, passwordE tree.Expr, ctx string) (userA
 	return userAuthInfo{name: name, password: password}, nil
 }
 
+// checkUserManagementPrivilege verifies that the session user may create,
+// alter or drop users and roles, either through the given privilege on
+// system.users or through the CREATEROLE role option. viaRoleOption is true in
+// the latter case.
+func (p *planner) checkUserManagementPrivilege(
+	ctx context.Context, priv privilege.Kind,
+) (viaRoleOption bool, _ error) {
+	tDesc, err := ResolveExistingObject(ctx, p, userTableName, true /*required*/, ResolveRequireTableDesc)
+	if err != nil {
+		return false, err
+	}
+
+	if err := p.CheckPrivilege(ctx, tDesc, priv); err != nil {
+		hasCreateRole, hasErr := p.HasRoleOption(ctx, roleoption.CREATEROLE)
+		if hasErr != nil {
+			return false, hasErr
+		}
+		if !hasCreateRole {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// checkNotSuperuser returns an error if the given user or role is a
+// superuser. Users with the CREATEROLE role option may only alter or drop
+// non-superusers.
+func (p *planner) checkNotSuperuser(ctx context.Context, username string, action string) error {
+	isSuperuser := username == security.RootUser || username == sqlbase.AdminRole
+	if !isSuperuser {
+		memberOf, err := p.MemberOfWithAdminOption(ctx, username)
+		if err != nil {
+			return err
+		}
+		_, isSuperuser = memberOf[sqlbase.AdminRole]
+	}
+	if isSuperuser {
+		return pgerror.Newf(pgcode.InsufficientPrivilege,
+			"only superusers are allowed to %s superuser %s", action, username)
+	}
+	return nil
+}
+
+// makeRoleOptions type checks the role options of a CREATE/ALTER USER or ROLE
+// statement. The PASSWORD option, if any, is returned separately as it is
+// stored in system.users.
+func (p *planner) makeRoleOptions(
+	opts tree.RoleOptions, op string,
+) (roleoption.List, tree.Expr, error) {
+	var passwordE tree.Expr
+	roleOptions := make(roleoption.List, 0, len(opts))
+	for i := range opts {
+		option, err := roleoption.ToOption(opts[i].Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		ro := roleoption.RoleOption{Option: option}
+		switch option {
+		case roleoption.PASSWORD:
+			passwordE = opts[i].Value
+
+		case roleoption.VALIDUNTIL:
+			if opts[i].Value == tree.DNull {
+				ro.Value = func() (bool, string, error) { return true, "", nil }
+				break
+			}
+			strFn, err := p.TypeAsString(opts[i].Value, op)
+			if err != nil {
+				return nil, nil, err
+			}
+			ro.Value = func() (bool, string, error) {
+				str, err := strFn()
+				if err != nil {
+					return false, "", err
+				}
+				// Resolve the timestamp now so that relative values such as
+				// 'tomorrow' are stored as absolute times.
+				ts, err := tree.ParseDTimestampTZ(p.EvalContext(), str, time.Microsecond)
+				if err != nil {
+					return false, "", err
+				}
+				return false, tree.AsStringWithFlags(ts, tree.FmtBareStrings), nil
+			}
+
+		case roleoption.CONNECTIONLIMIT:
+			typedE, err := tree.TypeCheckAndRequire(opts[i].Value, &p.semaCtx, types.Int, op)
+			if err != nil {
+				return nil, nil, err
+			}
+			ro.Value = func() (bool, string, error) {
+				d, err := typedE.Eval(p.EvalContext())
+				if err != nil {
+					return false, "", err
+				}
+				limit := int64(tree.MustBeDInt(d))
+				if limit < -1 {
+					return false, "", pgerror.Newf(pgcode.InvalidParameterValue,
+						"invalid connection limit %d", limit)
+				}
+				return false, strconv.FormatInt(limit, 10), nil
+			}
+		}
+		roleOptions = append(roleOptions, ro)
+	}
+	if err := roleOptions.CheckConflicts(); err != nil {
+		return nil, nil, err
+	}
+	return roleOptions, passwordE, nil
+}
+
+// applyRoleOptions stores the given role options for the user or role in
+// system.role_options, replacing any previous setting of the same attributes.
+// The absence of an option means the attribute has its default value, so
+// NOCREATEROLE, NOCREATEDB, VALID UNTIL NULL and CONNECTION LIMIT -1 only
+// clear the previous setting.
+func (p *planner) applyRoleOptions(
+	ctx context.Context, opName string, username string, roleOptions roleoption.List,
+) error {
+	ie := p.ExecCfg().InternalExecutor
+	for _, ro := range roleOptions {
+		if ro.Option == roleoption.PASSWORD {
+			// The password is stored in system.users.
+			continue
+		}
+		value := tree.DNull
+		if ro.Value != nil {
+			isNull, str, err := ro.Value()
+			if err != nil {
+				return err
+			}
+			if !isNull {
+				value = tree.NewDString(str)
+			}
+		}
+
+		if _, err := ie.Exec(
+			ctx, opName, p.txn,
+			`DELETE FROM system.role_options WHERE username = $1 AND option IN ($2, $3)`,
+			username, ro.Option.String(), ro.Option.Opposite().String(),
+		); err != nil {
+			return err
+		}
+
+		switch ro.Option {
+		case roleoption.NOCREATEROLE, roleoption.NOCREATEDB:
+			continue
+		case roleoption.VALIDUNTIL:
+			if value == tree.DNull {
+				continue
+			}
+		case roleoption.CONNECTIONLIMIT:
+			if *value.(*tree.DString) == "-1" {
+				continue
+			}
+		}
+		if _, err := ie.Exec(
+			ctx, opName, p.txn,
+			`INSERT INTO system.role_options (username, option, value) VALUES ($1, $2, $3)`,
+			username, ro.Option.String(), value,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // resolve returns the actual user name and (hashed) password.
 func (ua *userAuthInfo) resolve() (string, []byte, error) {
 	name, err := ua.name()
@@ -45,6 +45,10 @@ type databaseCache struct {
 	// databases is really a map of string -> sqlbase.ID
 	databases sync.Map
 
+	// types is really a map of sqlbase.ID -> *sqlbase.TypeDescriptor, holding
+	// the user-defined types decoded from systemConfig.
+	types sync.Map
+
 	// systemConfig holds a copy of the latest system config since the last
 	// call to resetForBatch.
 	systemConfig *config.SystemConfig
@@ -77,6 +77,9 @@ func TryDelegate(
 	case *tree.ShowRoles:
 		return d.delegateShowRoles(t)
 
+	case *tree.ShowSchedules:
+		return d.delegateShowSchedules(t)
+
 	case *tree.ShowSchemas:
 		return d.delegateShowSchemas(t)
 
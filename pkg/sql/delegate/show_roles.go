@@ -14,8 +14,24 @@ package delegate
 
 import "github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 
-// delegateShowRoles implements SHOW ROLES which returns all the roles.
-// Privileges: SELECT on system.users.
+// delegateShowRoles implements SHOW ROLES which returns all the roles and
+// their role options.
+// Privileges: SELECT on system.users and system.role_options.
 func (d *delegator) delegateShowRoles(n *tree.ShowRoles) (tree.Statement, error) {
-	return parse(`SELECT username AS role_name FROM system.users WHERE "isRole" = true ORDER BY 1`)
+	return parse(`
+SELECT
+	u.username AS role_name,
+	concat_ws(
+		', ',
+		CASE WHEN bool_or(o.option = 'LOGIN') THEN 'LOGIN' END,
+		CASE WHEN bool_or(o.option = 'NOLOGIN') THEN 'NOLOGIN' END,
+		CASE WHEN bool_or(o.option = 'CREATEROLE') THEN 'CREATEROLE' END,
+		CASE WHEN bool_or(o.option = 'CREATEDB') THEN 'CREATEDB' END,
+		max(CASE WHEN o.option = 'VALID UNTIL' THEN 'VALID UNTIL=' || o.value END),
+		max(CASE WHEN o.option = 'CONNECTION LIMIT' THEN 'CONNECTION LIMIT=' || o.value END)
+	) AS options
+FROM system.users AS u LEFT JOIN system.role_options AS o ON u.username = o.username
+WHERE u."isRole" = true
+GROUP BY u.username
+ORDER BY 1`)
 }
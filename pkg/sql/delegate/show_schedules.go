@@ -0,0 +1,35 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package delegate
+
+import "github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+
+// delegateShowSchedules implements SHOW SCHEDULES which returns all the
+// schedules stored in system.scheduled_jobs. A schedule is paused when it has
+// no next run.
+// Privileges: SELECT on system.scheduled_jobs.
+func (d *delegator) delegateShowSchedules(n *tree.ShowSchedules) (tree.Statement, error) {
+	return parse(`
+SELECT
+	schedule_id AS id,
+	schedule_name AS label,
+	CASE WHEN next_run IS NULL THEN 'PAUSED' ELSE 'ACTIVE' END AS schedule_status,
+	next_run,
+	last_run_status,
+	schedule_expr AS recurrence,
+	executor_type,
+	owner,
+	created
+FROM system.scheduled_jobs
+ORDER BY created`)
+}
@@ -157,6 +157,12 @@ func getDescriptorID(
 	return sqlbase.ID(gr.ValueInt()), nil
 }
 
+// errDescriptorIsType is returned by getDescriptorByID when a table
+// descriptor is requested and the descriptor is that of a user-defined type.
+// Types have system.namespace entries like tables, so a name resolved through
+// system.namespace can refer to either.
+var errDescriptorIsType = pgerror.New(pgcode.WrongObjectType, "descriptor is a type")
+
 // getDescriptorByID looks up the descriptor for `id`, validates it,
 // and unmarshals it into `descriptor`.
 //
@@ -176,6 +182,9 @@ func getDescriptorByID(
 	case *sqlbase.TableDescriptor:
 		table := desc.GetTable()
 		if table == nil {
+			if desc.GetType() != nil {
+				return errDescriptorIsType
+			}
 			return pgerror.Newf(pgcode.WrongObjectType,
 				"%q is not a table", desc.String())
 		}
@@ -196,6 +205,17 @@ func getDescriptorByID(
 			return err
 		}
 		*t = *database
+	case *sqlbase.TypeDescriptor:
+		typ := desc.GetType()
+		if typ == nil {
+			return pgerror.Newf(pgcode.WrongObjectType,
+				"%q is not a type", desc.String())
+		}
+
+		if err := typ.Validate(); err != nil {
+			return err
+		}
+		*t = *typ
 	}
 	return nil
 }
@@ -220,6 +240,8 @@ func GetAllDescriptors(ctx context.Context, txn *client.Txn) ([]sqlbase.Descript
 			descs[i] = desc.GetTable()
 		case *sqlbase.Descriptor_Database:
 			descs[i] = desc.GetDatabase()
+		case *sqlbase.Descriptor_Type:
+			descs[i] = desc.GetType()
 		default:
 			return nil, errors.AssertionFailedf("Descriptor.Union has unexpected type %T", t)
 		}
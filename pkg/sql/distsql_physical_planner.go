@@ -220,7 +220,7 @@ func (v *distSQLExprCheckVisitor) VisitPre(expr tree.Expr) (recurse bool, newExp
 		v.err = newQueryNotSupportedError("OID expressions are not supported by distsql")
 		return false, expr
 	case *tree.CastExpr:
-		if t.Type.Family() == types.OidFamily {
+		if t.Type.Family() == types.OidFamily || t.Type.CompositeTypeName() != "" {
 			v.err = newQueryNotSupportedErrorf("cast to %s is not supported by distsql", t.Type)
 			return false, expr
 		}
@@ -382,6 +382,11 @@ func (dsp *DistSQLPlanner) checkSupportForNode(node planNode) (distRecommendatio
 		return rec, nil
 
 	case *scanNode:
+		if n.lockForUpdate {
+			// The locks acquired by the scan must be tracked by the root
+			// transaction, so the scan has to run on the gateway.
+			return cannotDistribute, nil
+		}
 		rec := canDistribute
 		if n.softLimit != 0 {
 			// We don't yet recommend distributing plans where soft limits propagate
@@ -868,10 +873,11 @@ func initTableReaderSpec(
 ) (*distsqlpb.TableReaderSpec, distsqlpb.PostProcessSpec, error) {
 	s := distsqlplan.NewTableReaderSpec()
 	*s = distsqlpb.TableReaderSpec{
-		Table:      *n.desc.TableDesc(),
-		Reverse:    n.reverse,
-		IsCheck:    n.isCheck,
-		Visibility: n.colCfg.visibility.toDistSQLScanVisibility(),
+		Table:         *n.desc.TableDesc(),
+		Reverse:       n.reverse,
+		IsCheck:       n.isCheck,
+		Visibility:    n.colCfg.visibility.toDistSQLScanVisibility(),
+		LockForUpdate: n.lockForUpdate,
 
 		// Retain the capacity of the spans slice.
 		Spans: s.Spans[:0],
@@ -662,7 +662,8 @@ func (dsp *DistSQLPlanner) loadCSVSamplingPlan(
 // DistIngest is used by IMPORT to run a DistSQL flow to ingest data by starting
 // reader processes on many nodes that each read and ingest their assigned files
 // and then send back a summary of what they ingested. The combined summary is
-// returned.
+// returned. If online is set, the data is ingested into tables that remain
+// online, see ReadImportDataSpec.Online.
 func DistIngest(
 	ctx context.Context,
 	phs PlanHookState,
@@ -671,6 +672,7 @@ func DistIngest(
 	from []string,
 	format roachpb.IOFileFormat,
 	walltime int64,
+	online bool,
 ) (roachpb.BulkOpSummary, error) {
 	ctx = logtags.AddTag(ctx, "import-distsql-ingest", nil)
 
@@ -686,6 +688,7 @@ func DistIngest(
 
 	for i := range inputSpecs {
 		inputSpecs[i].IngestDirectly = true
+		inputSpecs[i].Online = online
 	}
 
 	var p PhysicalPlan
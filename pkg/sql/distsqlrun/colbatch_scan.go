@@ -103,6 +103,7 @@ func newColBatchScan(
 	); err != nil {
 		return nil, err
 	}
+	fetcher.SetLockForUpdate(spec.LockForUpdate)
 
 	nSpans := len(spec.Spans)
 	spans := make(roachpb.Spans, nSpans)
@@ -126,6 +126,7 @@ func newTableReader(
 	); err != nil {
 		return nil, err
 	}
+	tr.fetcher.SetLockForUpdate(spec.LockForUpdate)
 
 	nSpans := len(spec.Spans)
 	if cap(tr.spans) >= nSpans {
@@ -0,0 +1,1071 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+)
+
+// Domains are stored as type descriptors in system.descriptor. Like tables,
+// they have an entry in system.namespace under their database, so that types
+// and relations cannot share a name; name resolution of relations skips the
+// entries of types. Columns declared with a domain are stored with the base type of
+// the domain, and the constraints of the domain are materialized on the table:
+// the column is made NOT NULL if the domain is, inherits the default of the
+// domain, and receives a copy of every CHECK constraint of the domain with
+// VALUE replaced by the column. Casts to a domain are checked by the
+// crdb_internal.check_domain_value built-in function.
+
+// domainValueName is the name by which domain CHECK constraints refer to the
+// value being checked.
+const domainValueName = "value"
+
+// replaceDomainValue replaces the references to VALUE in a domain CHECK
+// constraint by the given expression.
+func replaceDomainValue(expr tree.Expr, value tree.Expr) (tree.Expr, error) {
+	return tree.SimpleVisit(expr, func(expr tree.Expr) (recurse bool, newExpr tree.Expr, err error) {
+		if n, ok := expr.(*tree.UnresolvedName); ok && n.NumParts == 1 && n.Parts[0] == domainValueName {
+			return false, value, nil
+		}
+		return true, expr, nil
+	})
+}
+
+// readDescs reads the descriptors with the given IDs. Missing descriptors are
+// skipped.
+func readDescs(
+	ctx context.Context, txn *client.Txn, ids []sqlbase.ID,
+) ([]*sqlbase.Descriptor, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	b := txn.NewBatch()
+	for _, id := range ids {
+		b.Get(sqlbase.MakeDescMetadataKey(id))
+	}
+	if err := txn.Run(ctx, b); err != nil {
+		return nil, err
+	}
+	res := make([]*sqlbase.Descriptor, 0, len(ids))
+	for _, r := range b.Results {
+		if len(r.Rows) == 0 || r.Rows[0].Value == nil {
+			continue
+		}
+		desc := &sqlbase.Descriptor{}
+		if err := r.Rows[0].ValueProto(desc); err != nil {
+			return nil, err
+		}
+		res = append(res, desc)
+	}
+	return res, nil
+}
+
+// readTypeDescs reads the descriptors with the given IDs and returns those
+// which are type descriptors.
+func readTypeDescs(
+	ctx context.Context, txn *client.Txn, ids []sqlbase.ID,
+) ([]*sqlbase.TypeDescriptor, error) {
+	descs, err := readDescs(ctx, txn, ids)
+	if err != nil {
+		return nil, err
+	}
+	var res []*sqlbase.TypeDescriptor
+	for _, desc := range descs {
+		if typ := desc.GetType(); typ != nil {
+			res = append(res, typ)
+		}
+	}
+	return res, nil
+}
+
+// getDescsInDatabase returns the descriptors of the objects of the database
+// with the given ID: its relations and user-defined types.
+func getDescsInDatabase(
+	ctx context.Context, txn *client.Txn, dbID sqlbase.ID,
+) ([]*sqlbase.Descriptor, error) {
+	prefix := sqlbase.MakeNameMetadataKey(dbID, "")
+	kvs, err := txn.Scan(ctx, prefix, prefix.PrefixEnd(), 0)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]sqlbase.ID, len(kvs))
+	for i, kv := range kvs {
+		ids[i] = sqlbase.ID(kv.ValueInt())
+	}
+	return readDescs(ctx, txn, ids)
+}
+
+// getTypesInDatabase returns the user-defined types of the database with the
+// given ID.
+func getTypesInDatabase(
+	ctx context.Context, txn *client.Txn, dbID sqlbase.ID,
+) ([]*sqlbase.TypeDescriptor, error) {
+	descs, err := getDescsInDatabase(ctx, txn, dbID)
+	if err != nil {
+		return nil, err
+	}
+	var res []*sqlbase.TypeDescriptor
+	for _, desc := range descs {
+		if typ := desc.GetType(); typ != nil {
+			res = append(res, typ)
+		}
+	}
+	return res, nil
+}
+
+// getTypeDescByName looks up the user-defined type with the given name in the
+// database with the given ID. nil is returned if the name is not in use, or is
+// the name of a relation.
+func getTypeDescByName(
+	ctx context.Context, txn *client.Txn, dbID sqlbase.ID, name string,
+) (*sqlbase.TypeDescriptor, error) {
+	id, err := getDescriptorID(ctx, txn, sqlbase.NewTableKey(dbID, name))
+	if err != nil || id == sqlbase.InvalidID {
+		return nil, err
+	}
+	typs, err := readTypeDescs(ctx, txn, []sqlbase.ID{id})
+	if err != nil || len(typs) == 0 {
+		return nil, err
+	}
+	if err := typs[0].Validate(); err != nil {
+		return nil, err
+	}
+	return typs[0], nil
+}
+
+// getCachedTypeDesc looks up the user-defined type with the given name in the
+// database with the given ID in the system config. nil is returned if the
+// name is not present in the cache, or is the name of a relation.
+func (dc *databaseCache) getCachedTypeDesc(
+	dbID sqlbase.ID, name string,
+) (*sqlbase.TypeDescriptor, error) {
+	nameVal := dc.systemConfig.GetValue(sqlbase.NewTableKey(dbID, name).Key())
+	if nameVal == nil {
+		return nil, nil
+	}
+	id, err := nameVal.GetInt()
+	if err != nil {
+		return nil, err
+	}
+	return dc.getCachedTypeDescByID(sqlbase.ID(id))
+}
+
+// getCachedTypeDescByID looks up the type descriptor with the given ID in the
+// system config. nil is returned if the descriptor is not present in the
+// cache, or is not a type descriptor. The descriptor is shared by the
+// sessions of the node and must not be modified.
+func (dc *databaseCache) getCachedTypeDescByID(
+	id sqlbase.ID,
+) (*sqlbase.TypeDescriptor, error) {
+	if typ, ok := dc.types.Load(id); ok {
+		return typ.(*sqlbase.TypeDescriptor), nil
+	}
+	descVal := dc.systemConfig.GetValue(sqlbase.MakeDescMetadataKey(id))
+	if descVal == nil {
+		return nil, nil
+	}
+	desc := &sqlbase.Descriptor{}
+	if err := descVal.GetProto(desc); err != nil {
+		return nil, err
+	}
+	typ := desc.GetType()
+	if typ == nil {
+		return nil, nil
+	}
+	if err := typ.Validate(); err != nil {
+		return nil, err
+	}
+	dc.types.Store(id, typ)
+	return typ, nil
+}
+
+// useCachedTypes returns whether user-defined types can be looked up in the
+// database cache. Like databases, types are not leased: the cache holds the
+// types of the last system config gossiped to the node, which does not
+// reflect the types modified by the current transaction.
+func (p *planner) useCachedTypes() bool {
+	tc := p.Tables()
+	return tc.databaseCache != nil && !p.avoidCachedDescriptors && len(tc.uncommittedTypes) == 0
+}
+
+// runWithTxn runs fn in the transaction of the planner, or in a new
+// transaction if the planner has none.
+func (p *planner) runWithTxn(
+	ctx context.Context, fn func(ctx context.Context, txn *client.Txn) error,
+) error {
+	if p.txn != nil {
+		return fn(ctx, p.txn)
+	}
+	return p.ExecCfg().DB.Txn(ctx, fn)
+}
+
+// getTypeDesc looks up the user-defined type with the given name in the
+// database with the given ID, in the database cache if possible. nil is
+// returned if the name is not in use, or is the name of a relation.
+func (p *planner) getTypeDesc(
+	ctx context.Context, dbID sqlbase.ID, name string,
+) (*sqlbase.TypeDescriptor, error) {
+	if p.useCachedTypes() {
+		typ, err := p.Tables().databaseCache.getCachedTypeDesc(dbID, name)
+		if err != nil || typ != nil {
+			return typ, err
+		}
+		// The type may have been created since the last system config was
+		// gossiped.
+	}
+	var typ *sqlbase.TypeDescriptor
+	err := p.runWithTxn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		var err error
+		typ, err = getTypeDescByName(ctx, txn, dbID, name)
+		return err
+	})
+	return typ, err
+}
+
+// getTypeDescByID looks up the type descriptor given its ID, in the database
+// cache if possible.
+func (p *planner) getTypeDescByID(
+	ctx context.Context, id sqlbase.ID,
+) (*sqlbase.TypeDescriptor, error) {
+	if p.useCachedTypes() {
+		typ, err := p.Tables().databaseCache.getCachedTypeDescByID(id)
+		if err != nil || typ != nil {
+			return typ, err
+		}
+	}
+	typ := &sqlbase.TypeDescriptor{}
+	err := p.runWithTxn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		return getDescriptorByID(ctx, txn, id, typ)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return typ, nil
+}
+
+// typeNameResolver implements the tree.TableNameExistingResolver interface
+// for the names of user-defined types. Types are created in the public schema
+// of their database.
+type typeNameResolver struct {
+	p *planner
+}
+
+var _ tree.TableNameExistingResolver = typeNameResolver{}
+
+// LookupObject implements the tree.TableNameExistingResolver interface.
+func (r typeNameResolver) LookupObject(
+	ctx context.Context, _ bool, dbName, scName, obName string,
+) (found bool, objMeta tree.NameResolutionResult, err error) {
+	if dbName == "" || scName != tree.PublicSchema {
+		return false, nil, nil
+	}
+	p := r.p
+	dbDesc, err := p.LogicalSchemaAccessor().GetDatabaseDesc(
+		ctx, p.txn, dbName, p.CommonLookupFlags(false /* required */),
+	)
+	if err != nil || dbDesc == nil {
+		return false, nil, err
+	}
+	typ, err := p.getTypeDesc(ctx, dbDesc.ID, obName)
+	if err != nil || typ == nil {
+		return false, nil, err
+	}
+	return true, typ, nil
+}
+
+// findType resolves the name of a user-defined type, which is qualified in
+// place on success. If required is false and the type does not exist, nil is
+// returned. The returned descriptor may be shared and must not be modified;
+// see findUncachedType.
+func (p *planner) findType(
+	ctx context.Context, tn *tree.TableName, required bool,
+) (*sqlbase.TypeDescriptor, error) {
+	found, typ, err := tn.ResolveExisting(ctx, typeNameResolver{p: p},
+		false /* requireMutable */, p.CurrentDatabase(), p.CurrentSearchPath())
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		if required {
+			return nil, tree.NewUndefinedTypeError(tree.ErrString(tn))
+		}
+		return nil, nil
+	}
+	return typ.(*sqlbase.TypeDescriptor), nil
+}
+
+// findUncachedType is like findType, but reads the type descriptor within the
+// transaction. The returned descriptor can be modified.
+func (p *planner) findUncachedType(
+	ctx context.Context, tn *tree.TableName, required bool,
+) (typ *sqlbase.TypeDescriptor, err error) {
+	p.runWithOptions(resolveFlags{skipCache: true}, func() {
+		typ, err = p.findType(ctx, tn, required)
+	})
+	return typ, err
+}
+
+// findDomain is like findUncachedType, but returns an error if the type is
+// not a domain.
+func (p *planner) findDomain(
+	ctx context.Context, tn *tree.TableName, required bool,
+) (*sqlbase.TypeDescriptor, error) {
+	typ, err := p.findUncachedType(ctx, tn, required)
+	if err != nil || typ == nil {
+		return nil, err
+	}
+	if typ.Kind != sqlbase.TypeDescriptor_DOMAIN {
+		return nil, pgerror.Newf(pgcode.WrongObjectType, "%q is not a domain", tree.ErrString(tn))
+	}
+	return typ, nil
+}
+
+// typeReferenceName returns the name of the user-defined type referred to by
+// ref, a placeholder created by types.MakeDomainReference.
+func typeReferenceName(ref *types.T) (tree.TableName, error) {
+	prefix, name := ref.DomainReferenceParts()
+	parts := [3]string{name}
+	for i := range prefix {
+		parts[len(prefix)-i] = prefix[i]
+	}
+	un, err := tree.NewUnresolvedObjectName(len(prefix)+1, parts, 0 /* annotationIdx */)
+	if err != nil {
+		return tree.TableName{}, err
+	}
+	return un.ToTableName(), nil
+}
+
+// findTypeReference returns the user-defined type referred to by ref, a
+// placeholder created by types.MakeDomainReference.
+func (p *planner) findTypeReference(
+	ctx context.Context, ref *types.T,
+) (*sqlbase.TypeDescriptor, error) {
+	tn, err := typeReferenceName(ref)
+	if err != nil {
+		return nil, err
+	}
+	return p.findType(ctx, &tn, true /* required */)
+}
+
+// ResolveTypeReference implements the tree.TypeReferenceResolver interface.
+func (p *planner) ResolveTypeReference(ref *types.T) (*types.T, int64, error) {
+	typ, err := p.findTypeReference(p.EvalContext().Ctx(), ref)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Copy the type, as the descriptor may be shared.
+	base := typ.BaseType
+	if typ.Kind == sqlbase.TypeDescriptor_COMPOSITE {
+		return &base, 0, nil
+	}
+	return &base, int64(typ.ID), nil
+}
+
+// domainChecker holds the NOT NULL and CHECK constraints of a domain, with
+// the checks type checked once with VALUE replaced by an indexed variable.
+type domainChecker struct {
+	typ   *sqlbase.TypeDescriptor
+	exprs []tree.TypedExpr
+}
+
+// domainValue is the tree.IndexedVarContainer binding VALUE in the checks of
+// a domainChecker.
+type domainValue struct {
+	typ   *types.T
+	value tree.Datum
+}
+
+var _ tree.IndexedVarContainer = &domainValue{}
+
+// IndexedVarEval implements the tree.IndexedVarContainer interface.
+func (v *domainValue) IndexedVarEval(idx int, ctx *tree.EvalContext) (tree.Datum, error) {
+	return v.value, nil
+}
+
+// IndexedVarResolvedType implements the tree.IndexedVarContainer interface.
+func (v *domainValue) IndexedVarResolvedType(idx int) *types.T {
+	return v.typ
+}
+
+// IndexedVarNodeFormatter implements the tree.IndexedVarContainer interface.
+func (v *domainValue) IndexedVarNodeFormatter(idx int) tree.NodeFormatter {
+	n := tree.Name(domainValueName)
+	return &n
+}
+
+// makeDomainChecker type checks the CHECK constraints of a domain.
+func makeDomainChecker(
+	typ *sqlbase.TypeDescriptor, semaCtx *tree.SemaContext,
+) (*domainChecker, error) {
+	c := &domainChecker{typ: typ, exprs: make([]tree.TypedExpr, len(typ.Checks))}
+	ctx := *semaCtx
+	ctx.IVarContainer = &domainValue{typ: &typ.BaseType}
+	for i := range typ.Checks {
+		expr, err := parser.ParseExpr(typ.Checks[i].Expr)
+		if err != nil {
+			return nil, err
+		}
+		expr, err = replaceDomainValue(expr, tree.NewOrdinalReference(0))
+		if err != nil {
+			return nil, err
+		}
+		c.exprs[i], err = tree.TypeCheckAndRequire(expr, &ctx, types.Bool, "CHECK")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// check returns an error if value does not satisfy the constraints of the
+// domain.
+func (c *domainChecker) check(evalCtx *tree.EvalContext, value tree.Datum) error {
+	if value == tree.DNull && c.typ.NotNull {
+		return pgerror.Newf(pgcode.NotNullViolation,
+			"domain %s does not allow null values", tree.ErrNameString(c.typ.Name))
+	}
+	// Bind VALUE in a copy of the context, as values of a statement may be
+	// checked concurrently.
+	ctx := *evalCtx
+	ctx.IVarContainer = &domainValue{typ: &c.typ.BaseType, value: value}
+	for i, expr := range c.exprs {
+		res, err := expr.Eval(&ctx)
+		if err != nil {
+			return err
+		}
+		if res == tree.DBoolFalse {
+			return pgerror.Newf(pgcode.CheckViolation,
+				"value for domain %s violates check constraint %q",
+				tree.ErrNameString(c.typ.Name), c.typ.Checks[i].Name)
+		}
+	}
+	return nil
+}
+
+// domainCheckerCache holds the domainCheckers of a statement.
+type domainCheckerCache struct {
+	syncutil.Mutex
+	m map[sqlbase.ID]*domainChecker
+}
+
+// CheckDomainValue implements the tree.EvalPlanner interface. The constraints
+// of each domain are read and type checked once per statement.
+func (p *planner) CheckDomainValue(ctx context.Context, id int64, value tree.Datum) error {
+	c, err := p.getDomainChecker(ctx, sqlbase.ID(id))
+	if err != nil {
+		return err
+	}
+	return c.check(p.EvalContext(), value)
+}
+
+func (p *planner) getDomainChecker(ctx context.Context, id sqlbase.ID) (*domainChecker, error) {
+	cache := p.domainCheckers
+	if cache != nil {
+		cache.Lock()
+		defer cache.Unlock()
+		if c, ok := cache.m[id]; ok {
+			return c, nil
+		}
+	}
+	typ, err := p.getTypeDescByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c, err := makeDomainChecker(typ, &p.semaCtx)
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		if cache.m == nil {
+			cache.m = make(map[sqlbase.ID]*domainChecker)
+		}
+		cache.m[id] = c
+	}
+	return c, nil
+}
+
+// processUserDefinedTypeInColumnDef replaces the user-defined type of a
+// column definition, if any, by the type the column is stored with: the row
+// type of a composite type, or the base type of a domain. For domains, the NOT
+// NULL constraint and the default of the domain are applied too. It returns
+// the new column definition and the user-defined type, which is nil if the
+// column is not declared with one. parentID is the ID of the database of the
+// table; columns can only be declared with the types of that database.
+func (p *planner) processUserDefinedTypeInColumnDef(
+	ctx context.Context, d *tree.ColumnTableDef, parentID sqlbase.ID,
+) (*tree.ColumnTableDef, *sqlbase.TypeDescriptor, error) {
+	if _, ok := d.Type.DomainReference(); !ok {
+		return d, nil, nil
+	}
+	typ, err := p.findTypeReference(ctx, d.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	if typ.ParentID != parentID {
+		return nil, nil, unimplemented.NewWithIssueDetailf(27796, "cross-database type",
+			"column %s cannot be declared with type %s of another database",
+			tree.ErrNameString(string(d.Name)), d.Type.SQLString())
+	}
+
+	newSpec := *d
+	base := typ.BaseType
+	newSpec.Type = &base
+	if typ.Kind == sqlbase.TypeDescriptor_COMPOSITE {
+		return &newSpec, typ, nil
+	}
+	if typ.NotNull {
+		newSpec.Nullable.Nullability = tree.NotNull
+	}
+	if !d.HasDefaultExpr() && typ.DefaultExpr != nil {
+		expr, err := parser.ParseExpr(*typ.DefaultExpr)
+		if err != nil {
+			return nil, nil, err
+		}
+		newSpec.DefaultExpr.Expr = expr
+	}
+	return &newSpec, typ, nil
+}
+
+// makeDomainColumnChecks makes the check constraints that enforce the CHECK
+// constraints of a domain on a column declared with it. inuseNames is updated
+// with the names of the new constraints.
+func makeDomainColumnChecks(
+	ctx context.Context,
+	desc *sqlbase.MutableTableDescriptor,
+	colName string,
+	typ *sqlbase.TypeDescriptor,
+	checks []sqlbase.TypeDescriptor_CheckConstraint,
+	inuseNames map[string]struct{},
+	semaCtx *tree.SemaContext,
+	tableName tree.TableName,
+) ([]*sqlbase.TableDescriptor_CheckConstraint, error) {
+	res := make([]*sqlbase.TableDescriptor_CheckConstraint, 0, len(checks))
+	for i := range checks {
+		expr, err := parser.ParseExpr(checks[i].Expr)
+		if err != nil {
+			return nil, err
+		}
+		expr, err = replaceDomainValue(expr, tree.NewUnresolvedName(colName))
+		if err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("%s_%s", colName, checks[i].Name)
+		for j := 1; ; j++ {
+			if _, ok := inuseNames[name]; !ok {
+				break
+			}
+			name = fmt.Sprintf("%s_%s%d", colName, checks[i].Name, j)
+		}
+		ck, err := MakeCheckConstraint(ctx, desc,
+			&tree.CheckConstraintTableDef{Name: tree.Name(name), Expr: expr},
+			inuseNames, semaCtx, tableName)
+		if err != nil {
+			return nil, err
+		}
+		ck.DomainID = typ.ID
+		ck.DomainConstraint = checks[i].Name
+		inuseNames[name] = struct{}{}
+		res = append(res, ck)
+	}
+	return res, nil
+}
+
+// constraintNamesInUse returns the names of the constraints of a table.
+func constraintNamesInUse(
+	ctx context.Context, desc *sqlbase.MutableTableDescriptor,
+) (map[string]struct{}, error) {
+	info, err := desc.GetConstraintInfo(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	inuseNames := make(map[string]struct{}, len(info))
+	for k := range info {
+		inuseNames[k] = struct{}{}
+	}
+	return inuseNames, nil
+}
+
+// setColumnUserDefinedType records on a column the user-defined type it is
+// declared with.
+func setColumnUserDefinedType(col *sqlbase.ColumnDescriptor, typ *sqlbase.TypeDescriptor) {
+	if typ.Kind == sqlbase.TypeDescriptor_COMPOSITE {
+		col.CompositeTypeID = typ.ID
+	} else {
+		col.DomainID = typ.ID
+	}
+}
+
+// addUserDefinedTypesToTableDesc records the user-defined types of the
+// columns of a new table, keyed by column name, and adds the CHECK constraints
+// of the domains among them to it.
+func addUserDefinedTypesToTableDesc(
+	ctx context.Context,
+	desc *sqlbase.MutableTableDescriptor,
+	userTypes map[string]*sqlbase.TypeDescriptor,
+	semaCtx *tree.SemaContext,
+	tableName tree.TableName,
+) error {
+	inuseNames, err := constraintNamesInUse(ctx, desc)
+	if err != nil {
+		return err
+	}
+	for i := range desc.Columns {
+		col := &desc.Columns[i]
+		typ, ok := userTypes[col.Name]
+		if !ok {
+			continue
+		}
+		setColumnUserDefinedType(col, typ)
+		if typ.Kind != sqlbase.TypeDescriptor_DOMAIN {
+			continue
+		}
+		checks, err := makeDomainColumnChecks(
+			ctx, desc, col.Name, typ, typ.Checks, inuseNames, semaCtx, tableName,
+		)
+		if err != nil {
+			return err
+		}
+		desc.Checks = append(desc.Checks, checks...)
+	}
+	return nil
+}
+
+// addDomainColumnCheckMutations adds mutations for the CHECK constraints of
+// the domain of a column being added to an existing table.
+func addDomainColumnCheckMutations(
+	ctx context.Context,
+	desc *sqlbase.MutableTableDescriptor,
+	colName string,
+	typ *sqlbase.TypeDescriptor,
+	semaCtx *tree.SemaContext,
+	tableName tree.TableName,
+) error {
+	inuseNames, err := constraintNamesInUse(ctx, desc)
+	if err != nil {
+		return err
+	}
+	checks, err := makeDomainColumnChecks(
+		ctx, desc, colName, typ, typ.Checks, inuseNames, semaCtx, tableName,
+	)
+	if err != nil {
+		return err
+	}
+	for _, ck := range checks {
+		ck.Validity = sqlbase.ConstraintValidity_Validating
+		desc.AddCheckValidationMutation(ck)
+	}
+	return nil
+}
+
+// typeColumn identifies a column declared with a user-defined type.
+type typeColumn struct {
+	table *sqlbase.MutableTableDescriptor
+	col   *sqlbase.ColumnDescriptor
+}
+
+// findTypeColumns returns the columns of live tables which are declared with
+// the given user-defined type. Only the tables of the database of the type
+// are read, as columns cannot be declared with the types of other databases.
+func findTypeColumns(
+	ctx context.Context, txn *client.Txn, typ *sqlbase.TypeDescriptor,
+) ([]typeColumn, error) {
+	descs, err := getDescsInDatabase(ctx, txn, typ.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	id := typ.ID
+	var res []typeColumn
+	for _, desc := range descs {
+		table := desc.GetTable()
+		if table == nil || table.Dropped() {
+			continue
+		}
+		var mut *sqlbase.MutableTableDescriptor
+		for _, col := range table.AllNonDropColumns() {
+			if col.DomainID != id && col.CompositeTypeID != id {
+				continue
+			}
+			if mut == nil {
+				mut = sqlbase.NewMutableExistingTableDescriptor(*table)
+			}
+			c, _, err := mut.FindColumnByName(tree.Name(col.Name))
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, typeColumn{table: mut, col: c})
+		}
+	}
+	return res, nil
+}
+
+// writeTypeDesc writes a new version of a type descriptor within the current
+// transaction.
+func (p *planner) writeTypeDesc(ctx context.Context, typ *sqlbase.TypeDescriptor) error {
+	typ.Version++
+	if err := typ.Validate(); err != nil {
+		return err
+	}
+	descKey := sqlbase.MakeDescMetadataKey(typ.ID)
+	descDesc := sqlbase.WrapDescriptor(typ)
+	if p.ExtendedEvalContext().Tracing.KVTracingEnabled() {
+		log.VEventf(ctx, 2, "Put %s -> %s", descKey, descDesc)
+	}
+	p.Tables().addUncommittedType(typ, false /* dropped */)
+	return p.txn.Put(ctx, descKey, descDesc)
+}
+
+// createTypeDesc allocates an ID for a new type descriptor and writes it,
+// along with its system.namespace entry, within the current transaction.
+func (p *planner) createTypeDesc(ctx context.Context, typ *sqlbase.TypeDescriptor) error {
+	key := sqlbase.NewTableKey(typ.ParentID, typ.Name).Key()
+	if exists, err := descExists(ctx, p.txn, key); err == nil && exists {
+		return pgerror.Newf(pgcode.DuplicateObject, "type %q already exists", typ.Name)
+	} else if err != nil {
+		return err
+	}
+	id, err := GenerateUniqueDescID(ctx, p.ExecCfg().DB)
+	if err != nil {
+		return err
+	}
+	typ.ID = id
+	typ.Version = 1
+	if err := typ.Validate(); err != nil {
+		return err
+	}
+	p.Tables().addUncommittedType(typ, false /* dropped */)
+	return p.createDescriptorWithID(ctx, key, id, typ, nil /* st */)
+}
+
+// deleteTypeDescs deletes type descriptors and their system.namespace entries
+// within the current transaction.
+func (p *planner) deleteTypeDescs(ctx context.Context, typs []*sqlbase.TypeDescriptor) error {
+	b := &client.Batch{}
+	for _, typ := range typs {
+		descKey := sqlbase.MakeDescMetadataKey(typ.ID)
+		nameKey := sqlbase.NewTableKey(typ.ParentID, typ.Name).Key()
+		if p.ExtendedEvalContext().Tracing.KVTracingEnabled() {
+			log.VEventf(ctx, 2, "Del %s", descKey)
+			log.VEventf(ctx, 2, "Del %s", nameKey)
+		}
+		b.Del(descKey)
+		b.Del(nameKey)
+		p.Tables().addUncommittedType(typ, true /* dropped */)
+	}
+	return p.txn.Run(ctx, b)
+}
+
+// validateDomainConstraints checks that the constraints of a domain are
+// well-formed for its base type.
+func validateDomainConstraints(
+	base *types.T,
+	defaultExpr tree.Expr,
+	checks []tree.DomainCheck,
+	semaCtx *tree.SemaContext,
+) error {
+	if defaultExpr != nil {
+		if _, err := sqlbase.SanitizeVarFreeExpr(
+			defaultExpr, base, "DEFAULT", semaCtx, true, /* allowImpure */
+		); err != nil {
+			return err
+		}
+	}
+	for i := range checks {
+		expr, err := replaceDomainValue(checks[i].Expr, &tree.CastExpr{Expr: tree.DNull, Type: base})
+		if err != nil {
+			return err
+		}
+		if _, err := sqlbase.SanitizeVarFreeExpr(
+			expr, types.Bool, "CHECK", semaCtx, true, /* allowImpure */
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// makeDomainCheckName returns the name of a CHECK constraint of a domain
+// which is not in use yet.
+func makeDomainCheckName(typ *sqlbase.TypeDescriptor, name tree.Name) (string, error) {
+	if name != "" {
+		if typ.FindCheck(string(name)) != -1 {
+			return "", pgerror.Newf(pgcode.DuplicateObject,
+				"constraint %q for domain %s already exists", name, tree.ErrNameString(typ.Name))
+		}
+		return string(name), nil
+	}
+	base := typ.Name + "_check"
+	res := base
+	for i := 1; typ.FindCheck(res) != -1; i++ {
+		res = fmt.Sprintf("%s%d", base, i)
+	}
+	return res, nil
+}
+
+type createDomainNode struct {
+	n      *tree.CreateDomain
+	dbDesc *sqlbase.DatabaseDescriptor
+}
+
+// CreateDomain creates a domain.
+// Privileges: CREATE on database.
+func (p *planner) CreateDomain(ctx context.Context, n *tree.CreateDomain) (planNode, error) {
+	dbDesc, err := p.ResolveUncachedDatabase(ctx, &n.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.CheckPrivilege(ctx, dbDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+	if err := p.rejectUserDefinedType(ctx, n.Type); err != nil {
+		return nil, err
+	}
+	if err := sqlbase.ValidateColumnDefType(n.Type); err != nil {
+		return nil, err
+	}
+	if err := validateDomainConstraints(n.Type, n.Default, n.Checks, &p.semaCtx); err != nil {
+		return nil, err
+	}
+	return &createDomainNode{n: n, dbDesc: dbDesc}, nil
+}
+
+func (n *createDomainNode) startExec(params runParams) error {
+	ctx := params.ctx
+	p := params.p
+	typ := &sqlbase.TypeDescriptor{
+		Name:     n.n.Name.Table(),
+		ParentID: n.dbDesc.ID,
+		// Inherit permissions from the database descriptor.
+		Privileges: n.dbDesc.GetPrivileges(),
+		BaseType:   *n.n.Type,
+		NotNull:    n.n.NotNull,
+	}
+	if n.n.Default != nil {
+		s := tree.Serialize(n.n.Default)
+		typ.DefaultExpr = &s
+	}
+	for i := range n.n.Checks {
+		ckName, err := makeDomainCheckName(typ, n.n.Checks[i].Name)
+		if err != nil {
+			return err
+		}
+		typ.Checks = append(typ.Checks, sqlbase.TypeDescriptor_CheckConstraint{
+			Name: ckName,
+			Expr: tree.Serialize(n.n.Checks[i].Expr),
+		})
+	}
+	return p.createTypeDesc(ctx, typ)
+}
+
+func (*createDomainNode) Next(runParams) (bool, error) { return false, nil }
+func (*createDomainNode) Values() tree.Datums          { return tree.Datums{} }
+func (*createDomainNode) Close(context.Context)        {}
+
+type dropDomainNode struct {
+	n  *tree.DropDomain
+	td []*sqlbase.TypeDescriptor
+}
+
+// DropDomain drops domains.
+// Privileges: DROP on domain.
+func (p *planner) DropDomain(ctx context.Context, n *tree.DropDomain) (planNode, error) {
+	if n.DropBehavior == tree.DropCascade {
+		return nil, unimplemented.NewWithIssue(27796, "DROP DOMAIN CASCADE")
+	}
+	td := make([]*sqlbase.TypeDescriptor, 0, len(n.Names))
+	for i := range n.Names {
+		typ, err := p.findDomain(ctx, &n.Names[i], !n.IfExists)
+		if err != nil {
+			return nil, err
+		}
+		if typ == nil {
+			// IfExists specified and the domain does not exist.
+			continue
+		}
+		if err := p.CheckPrivilege(ctx, typ, privilege.DROP); err != nil {
+			return nil, err
+		}
+		cols, err := findTypeColumns(ctx, p.txn, typ)
+		if err != nil {
+			return nil, err
+		}
+		if len(cols) > 0 {
+			return nil, pgerror.Newf(pgcode.DependentObjectsStillExist,
+				"cannot drop domain %s because column %s of table %s depends on it",
+				tree.ErrNameString(typ.Name), tree.ErrNameString(cols[0].col.Name),
+				tree.ErrNameString(cols[0].table.Name))
+		}
+		td = append(td, typ)
+	}
+	if len(td) == 0 {
+		return newZeroNode(nil /* columns */), nil
+	}
+	return &dropDomainNode{n: n, td: td}, nil
+}
+
+func (n *dropDomainNode) startExec(params runParams) error {
+	return params.p.deleteTypeDescs(params.ctx, n.td)
+}
+
+func (*dropDomainNode) Next(runParams) (bool, error) { return false, nil }
+func (*dropDomainNode) Values() tree.Datums          { return tree.Datums{} }
+func (*dropDomainNode) Close(context.Context)        {}
+
+type alterDomainNode struct {
+	n   *tree.AlterDomain
+	typ *sqlbase.TypeDescriptor
+}
+
+// AlterDomain alters a domain.
+// Privileges: CREATE on domain.
+func (p *planner) AlterDomain(ctx context.Context, n *tree.AlterDomain) (planNode, error) {
+	typ, err := p.findDomain(ctx, &n.Name, true /* required */)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.CheckPrivilege(ctx, typ, privilege.CREATE); err != nil {
+		return nil, err
+	}
+	return &alterDomainNode{n: n, typ: typ}, nil
+}
+
+func (n *alterDomainNode) startExec(params runParams) error {
+	ctx := params.ctx
+	p := params.p
+	cols, err := findTypeColumns(ctx, p.txn, n.typ)
+	if err != nil {
+		return err
+	}
+	addedMutations := make(map[sqlbase.ID]bool)
+
+	switch t := n.n.Cmd.(type) {
+	case *tree.AlterDomainAddConstraint:
+		if err := validateDomainConstraints(
+			&n.typ.BaseType, nil /* defaultExpr */, []tree.DomainCheck{t.Check}, &p.semaCtx,
+		); err != nil {
+			return err
+		}
+		name, err := makeDomainCheckName(n.typ, t.Check.Name)
+		if err != nil {
+			return err
+		}
+		ck := sqlbase.TypeDescriptor_CheckConstraint{
+			Name: name,
+			Expr: tree.Serialize(t.Check.Expr),
+		}
+
+		// Validate the constraint against the existing data right away, so that
+		// the statement fails if it does not hold. The constraints added to the
+		// tables are validated again by the schema changer once every node
+		// enforces them.
+		for _, c := range cols {
+			inuseNames, err := constraintNamesInUse(ctx, c.table)
+			if err != nil {
+				return err
+			}
+			tn := tree.MakeUnqualifiedTableName(tree.Name(c.table.Name))
+			tableChecks, err := makeDomainColumnChecks(ctx, c.table, c.col.Name, n.typ,
+				[]sqlbase.TypeDescriptor_CheckConstraint{ck}, inuseNames, &p.semaCtx, tn)
+			if err != nil {
+				return err
+			}
+			for _, tableCheck := range tableChecks {
+				if err := validateCheckExpr(
+					ctx, tableCheck.Expr, c.table.TableDesc(), p.ExtendedEvalContext().InternalExecutor, p.txn,
+				); err != nil {
+					if pgerror.GetPGCode(err) == pgcode.CheckViolation {
+						return pgerror.Newf(pgcode.CheckViolation,
+							"column %s of table %s contains values that violate the new constraint",
+							tree.ErrNameString(c.col.Name), tree.ErrNameString(c.table.Name))
+					}
+					return err
+				}
+				tableCheck.Validity = sqlbase.ConstraintValidity_Validating
+				c.table.AddCheckValidationMutation(tableCheck)
+				addedMutations[c.table.ID] = true
+			}
+		}
+		n.typ.Checks = append(n.typ.Checks, ck)
+
+	case *tree.AlterDomainDropConstraint:
+		name := string(t.Constraint)
+		idx := n.typ.FindCheck(name)
+		if idx == -1 {
+			if t.IfExists {
+				return nil
+			}
+			return pgerror.Newf(pgcode.UndefinedObject,
+				"constraint %q of domain %s does not exist", name, tree.ErrNameString(n.typ.Name))
+		}
+		n.typ.Checks = append(n.typ.Checks[:idx], n.typ.Checks[idx+1:]...)
+
+		for _, c := range cols {
+			info, err := c.table.GetConstraintInfo(ctx, nil)
+			if err != nil {
+				return err
+			}
+			for ckName, detail := range info {
+				if detail.Kind != sqlbase.ConstraintTypeCheck ||
+					detail.CheckConstraint.DomainID != n.typ.ID ||
+					detail.CheckConstraint.DomainConstraint != name {
+					continue
+				}
+				if err := c.table.DropConstraint(ckName, detail, nil /* removeFK */); err != nil {
+					return err
+				}
+			}
+		}
+
+	default:
+		return errors.AssertionFailedf("unsupported alter domain cmd: %T", t)
+	}
+
+	// A table may have several columns declared with the domain; write it once.
+	written := make(map[sqlbase.ID]struct{})
+	for _, c := range cols {
+		if _, ok := written[c.table.ID]; ok {
+			continue
+		}
+		written[c.table.ID] = struct{}{}
+		mutationID := sqlbase.InvalidMutationID
+		if addedMutations[c.table.ID] {
+			mutationID, err = p.createOrUpdateSchemaChangeJob(
+				ctx, c.table, tree.AsStringWithFQNames(n.n, params.Ann()),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		if err := p.writeSchemaChange(ctx, c.table, mutationID); err != nil {
+			return err
+		}
+	}
+	return p.writeTypeDesc(ctx, n.typ)
+}
+
+func (*alterDomainNode) Next(runParams) (bool, error) { return false, nil }
+func (*alterDomainNode) Values() tree.Datums          { return tree.Datums{} }
+func (*alterDomainNode) Close(context.Context)        {}
@@ -171,6 +171,25 @@ func (n *dropDatabaseNode) startExec(params runParams) error {
 	b.Del(descKey)
 	b.Del(nameKey)
 
+	// Delete the user-defined types of the database. Columns declared with
+	// them keep the type they are stored with, and the constraints
+	// materialized from domains.
+	typs, err := getTypesInDatabase(ctx, p.txn, n.dbDesc.ID)
+	if err != nil {
+		return err
+	}
+	for _, typ := range typs {
+		typKey := sqlbase.MakeDescMetadataKey(typ.ID)
+		typNameKey := sqlbase.NewTableKey(typ.ParentID, typ.Name).Key()
+		if p.ExtendedEvalContext().Tracing.KVTracingEnabled() {
+			log.VEventf(ctx, 2, "Del %s", typKey)
+			log.VEventf(ctx, 2, "Del %s", typNameKey)
+		}
+		b.Del(typKey)
+		b.Del(typNameKey)
+		p.Tables().addUncommittedType(typ, true /* dropped */)
+	}
+
 	// No job was created because no tables were dropped, so zone config can be
 	// immediately removed.
 	if jobID == 0 {
@@ -31,12 +31,15 @@ type DropUserNode struct {
 	ifExists bool
 	isRole   bool
 	names    func() ([]string, error)
+	// viaRoleOption is true if the session user is only allowed to drop users
+	// through the CREATEROLE role option.
+	viaRoleOption bool
 
 	run dropUserRun
 }
 
 // DropUser drops a list of users.
-// Privileges: DELETE on system.users.
+// Privileges: DELETE on system.users, or the CREATEROLE role option.
 func (p *planner) DropUser(ctx context.Context, n *tree.DropUser) (planNode, error) {
 	return p.DropUserNode(ctx, n.Names, n.IfExists, false /* isRole */, "DROP USER")
 }
@@ -45,24 +48,21 @@ func (p *planner) DropUser(ctx context.Context, n *tree.DropUser) (planNode, err
 func (p *planner) DropUserNode(
 	ctx context.Context, namesE tree.Exprs, ifExists bool, isRole bool, opName string,
 ) (*DropUserNode, error) {
-	tDesc, err := ResolveExistingObject(ctx, p, userTableName, true /*required*/, ResolveRequireTableDesc)
+	viaRoleOption, err := p.checkUserManagementPrivilege(ctx, privilege.DELETE)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(ctx, tDesc, privilege.DELETE); err != nil {
-		return nil, err
-	}
-
 	names, err := p.TypeAsStringArray(namesE, opName)
 	if err != nil {
 		return nil, err
 	}
 
 	return &DropUserNode{
-		ifExists: ifExists,
-		isRole:   isRole,
-		names:    names,
+		ifExists:      ifExists,
+		isRole:        isRole,
+		names:         names,
+		viaRoleOption: viaRoleOption,
 	}, nil
 }
 
@@ -132,16 +132,13 @@ func (n *DropUserNode) startExec(params runParams) error {
 		if !tableIsVisible(table, true /*allowAdding*/) {
 			continue
 		}
-		for _, u := range table.GetPrivileges().Users {
-			if _, ok := userNames[u.User]; ok {
-				if f.Len() > 0 {
-					f.WriteString(", ")
-				}
-				parentName := lCtx.getParentName(table)
-				tn := tree.MakeTableName(tree.Name(parentName), tree.Name(table.Name))
-				f.FormatNode(&tn)
-				break
+		if hasTableGrants(table.GetPrivileges(), userNames) {
+			if f.Len() > 0 {
+				f.WriteString(", ")
 			}
+			parentName := lCtx.getParentName(table)
+			tn := tree.MakeTableName(tree.Name(parentName), tree.Name(table.Name))
+			f.FormatNode(&tn)
 		}
 	}
 
@@ -175,6 +172,11 @@ func (n *DropUserNode) startExec(params runParams) error {
 			return pgerror.Newf(
 				pgcode.InvalidParameterValue, "cannot drop special user %s", normalizedUsername)
 		}
+		if n.viaRoleOption {
+			if err := params.p.checkNotSuperuser(params.ctx, normalizedUsername, "drop"); err != nil {
+				return err
+			}
+		}
 
 		rowsAffected, err := params.extendedEvalCtx.ExecCfg.InternalExecutor.Exec(
 			params.ctx,
@@ -206,6 +208,17 @@ func (n *DropUserNode) startExec(params runParams) error {
 		}
 
 		numRoleMembershipsDeleted += rowsAffected
+
+		// Drop the role options of the user/role.
+		if _, err := params.extendedEvalCtx.ExecCfg.InternalExecutor.Exec(
+			params.ctx,
+			"drop-role-options",
+			params.p.txn,
+			`DELETE FROM system.role_options WHERE username = $1`,
+			normalizedUsername,
+		); err != nil {
+			return err
+		}
 	}
 
 	if numRoleMembershipsDeleted > 0 {
@@ -232,3 +245,22 @@ func (*DropUserNode) Close(context.Context) {}
 
 // FastPathResults implements the planNodeFastPath interface.
 func (n *DropUserNode) FastPathResults() (int, bool) { return n.run.numDeleted, true }
+
+// hasTableGrants returns whether any of the given users was granted
+// privileges on a table or on any of its columns. Column grants count too,
+// as a user created later with the same name would otherwise inherit them.
+func hasTableGrants(privs *sqlbase.PrivilegeDescriptor, userNames map[string]struct{}) bool {
+	for _, u := range privs.Users {
+		if _, ok := userNames[u.User]; ok {
+			return true
+		}
+	}
+	for _, c := range privs.Columns {
+		for _, u := range c.Users {
+			if _, ok := userNames[u.User]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -813,11 +813,18 @@ func (p *planner) EvalAsOfTimestamp(asOf tree.AsOfClause) (_ hlc.Timestamp, err
 	if err != nil {
 		return hlc.Timestamp{}, err
 	}
+	if err := p.checkAsOfTimestampNotInFuture(ts); err != nil {
+		return hlc.Timestamp{}, err
+	}
+	return ts, nil
+}
+
+func (p *planner) checkAsOfTimestampNotInFuture(ts hlc.Timestamp) error {
 	if now := p.execCfg.Clock.Now(); now.Less(ts) {
-		return hlc.Timestamp{}, errors.Errorf(
+		return errors.Errorf(
 			"AS OF SYSTEM TIME: cannot specify timestamp in the future (%s > %s)", ts, now)
 	}
-	return ts, nil
+	return nil
 }
 
 // ParseHLC parses a string representation of an `hlc.Timestamp`.
@@ -831,11 +838,14 @@ func ParseHLC(s string) (hlc.Timestamp, error) {
 
 // isAsOf analyzes a statement to bypass the logic in newPlan(), since
 // that requires the transaction to be started already. If the returned
-// timestamp is not nil, it is the timestamp to which a transaction
-// should be set. The statements that will be checked are Select,
-// ShowTrace (of a Select statement), Scrub, Export, and CreateStats.
-func (p *planner) isAsOf(stmt tree.Statement) (*hlc.Timestamp, error) {
+// AsOfSystemTime is not nil, its timestamp is the timestamp to which a
+// transaction should be set, or, for bounded staleness reads, the minimum
+// such timestamp. The statements that will be checked are Select,
+// ShowTrace (of a Select statement), Scrub, Export, and CreateStats. Only
+// Select statements may perform bounded staleness reads.
+func (p *planner) isAsOf(stmt tree.Statement) (*tree.AsOfSystemTime, error) {
 	var asOf tree.AsOfClause
+	allowBoundedStaleness := false
 	switch s := stmt.(type) {
 	case *tree.Select:
 		selStmt := s.Select
@@ -854,13 +864,18 @@ func (p *planner) isAsOf(stmt tree.Statement) (*hlc.Timestamp, error) {
 		}
 
 		asOf = sc.From.AsOf
+		allowBoundedStaleness = true
 	case *tree.Scrub:
 		if s.AsOf.Expr == nil {
 			return nil, nil
 		}
 		asOf = s.AsOf
 	case *tree.Export:
-		return p.isAsOf(s.Query)
+		res, err := p.isAsOf(s.Query)
+		if err == nil && res != nil && res.BoundedStaleness {
+			return nil, tree.ErrBoundedStalenessNotAllowed
+		}
+		return res, err
 	case *tree.CreateStats:
 		if s.Options.AsOf.Expr == nil {
 			return nil, nil
@@ -869,8 +884,18 @@ func (p *planner) isAsOf(stmt tree.Statement) (*hlc.Timestamp, error) {
 	default:
 		return nil, nil
 	}
-	ts, err := p.EvalAsOfTimestamp(asOf)
-	return &ts, err
+	if !allowBoundedStaleness {
+		ts, err := p.EvalAsOfTimestamp(asOf)
+		return &tree.AsOfSystemTime{Timestamp: ts}, err
+	}
+	res, err := tree.EvalAsOfSystemTime(asOf, &p.semaCtx, p.EvalContext())
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkAsOfTimestampNotInFuture(res.Timestamp); err != nil {
+		return nil, err
+	}
+	return &res, nil
 }
 
 // isSavepoint returns true if stmt is a SAVEPOINT statement.
@@ -1751,6 +1776,79 @@ type sessionDataMutator struct {
 	// applicationNamedChanged, if set, is called when the "application name"
 	// variable is updated.
 	applicationNameChanged func(newName string)
+	// preLocalData, if set, is a copy of the session data taken before the
+	// first SET LOCAL of the current transaction. The connExecutor restores it
+	// when the transaction commits or rolls back.
+	preLocalData *sessiondata.SessionData
+	// savepointData, if set, is a copy of the session data taken when the
+	// restart savepoint of the current transaction was established. ROLLBACK TO
+	// SAVEPOINT restores it, which reverts the SET LOCAL statements executed
+	// since, like in PostgreSQL.
+	savepointData *sessiondata.SessionData
+}
+
+// beginLocal snapshots the session data, unless that was already done in the
+// current transaction, so that it can be restored by endLocal.
+func (m *sessionDataMutator) beginLocal() {
+	if m.preLocalData == nil {
+		sd := *m.data
+		m.preLocalData = &sd
+	}
+}
+
+// beginSavepoint snapshots the session data when the restart savepoint is
+// established, so that it can be restored by rollbackToSavepoint.
+func (m *sessionDataMutator) beginSavepoint() {
+	sd := *m.data
+	m.savepointData = &sd
+}
+
+// rollbackToSavepoint reverts the effects of the SET LOCAL statements executed
+// since the restart savepoint was established, if any.
+func (m *sessionDataMutator) rollbackToSavepoint() {
+	if m.savepointData != nil {
+		m.restore(*m.savepointData)
+	}
+}
+
+// snapshotMutators returns mutators operating on the session data snapshots
+// taken by beginLocal and beginSavepoint in the current transaction. Regular
+// SET statements are applied to them as well so that they outlive the
+// transaction and the rollbacks to its savepoint.
+func (m *sessionDataMutator) snapshotMutators() []*sessionDataMutator {
+	var ms []*sessionDataMutator
+	for _, sd := range []*sessiondata.SessionData{m.preLocalData, m.savepointData} {
+		if sd == nil {
+			continue
+		}
+		ms = append(ms, &sessionDataMutator{
+			data:              sd,
+			defaults:          m.defaults,
+			settings:          m.settings,
+			setCurTxnReadOnly: func(bool) {},
+		})
+	}
+	return ms
+}
+
+// endLocal reverts the effects of the SET LOCAL statements executed in the
+// current transaction, if any.
+func (m *sessionDataMutator) endLocal() {
+	m.savepointData = nil
+	if m.preLocalData == nil {
+		return
+	}
+	m.restore(*m.preLocalData)
+	m.preLocalData = nil
+}
+
+// restore replaces the session data with the given snapshot.
+func (m *sessionDataMutator) restore(sd sessiondata.SessionData) {
+	appName := m.data.ApplicationName
+	*m.data = sd
+	if appName != m.data.ApplicationName && m.applicationNameChanged != nil {
+		m.applicationNameChanged(m.data.ApplicationName)
+	}
 }
 
 // SetApplicationName sets the application name.
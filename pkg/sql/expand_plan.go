@@ -344,6 +344,9 @@ func doExpandPlan(
 	case *controlJobsNode:
 		n.rows, err = doExpandPlan(ctx, p, noParams, n.rows)
 
+	case *controlSchedulesNode:
+		n.rows, err = doExpandPlan(ctx, p, noParams, n.rows)
+
 	case *projectSetNode:
 		n.source, err = doExpandPlan(ctx, p, noParams, n.source)
 
@@ -354,8 +357,9 @@ func doExpandPlan(
 	case *virtualTableNode:
 	case *alterIndexNode:
 	case *alterTableNode:
+	case *alterDomainNode:
 	case *alterSequenceNode:
-	case *alterUserSetPasswordNode:
+	case *alterRoleNode:
 	case *commentOnColumnNode:
 	case *commentOnDatabaseNode:
 	case *commentOnTableNode:
@@ -369,13 +373,17 @@ func doExpandPlan(
 	case *createIndexNode:
 	case *CreateUserNode:
 	case *createViewNode:
+	case *createDomainNode:
 	case *createSequenceNode:
 	case *createStatsNode:
+	case *createTypeNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropDomainNode:
 	case *dropSequenceNode:
+	case *dropTypeNode:
 	case *DropUserNode:
 	case *zeroNode:
 	case *unaryNode:
@@ -866,6 +874,9 @@ func (p *planner) simplifyOrderings(plan planNode, usefulOrdering sqlbase.Column
 	case *controlJobsNode:
 		n.rows = p.simplifyOrderings(n.rows, nil)
 
+	case *controlSchedulesNode:
+		n.rows = p.simplifyOrderings(n.rows, nil)
+
 	case *errorIfRowsNode:
 		n.plan = p.simplifyOrderings(n.plan, nil)
 
@@ -876,8 +887,9 @@ func (p *planner) simplifyOrderings(plan planNode, usefulOrdering sqlbase.Column
 	case *virtualTableNode:
 	case *alterIndexNode:
 	case *alterTableNode:
+	case *alterDomainNode:
 	case *alterSequenceNode:
-	case *alterUserSetPasswordNode:
+	case *alterRoleNode:
 	case *commentOnColumnNode:
 	case *commentOnDatabaseNode:
 	case *commentOnTableNode:
@@ -891,13 +903,17 @@ func (p *planner) simplifyOrderings(plan planNode, usefulOrdering sqlbase.Column
 	case *createIndexNode:
 	case *CreateUserNode:
 	case *createViewNode:
+	case *createDomainNode:
 	case *createSequenceNode:
 	case *createStatsNode:
+	case *createTypeNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropDomainNode:
 	case *dropSequenceNode:
+	case *dropTypeNode:
 	case *DropUserNode:
 	case *zeroNode:
 	case *unaryNode:
@@ -15,6 +15,8 @@ package sql
 import (
 	"context"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -31,9 +33,17 @@ import (
 //   Notes: postgres requires the object owner.
 //          mysql requires the "grant option" and the same privileges, and sometimes superuser.
 func (p *planner) Grant(ctx context.Context, n *tree.Grant) (planNode, error) {
-	return p.changePrivileges(ctx, n.Targets, n.Grantees, func(privDesc *sqlbase.PrivilegeDescriptor, grantee string) {
-		privDesc.Grant(grantee, n.Privileges)
-	})
+	if len(n.Columns) > 0 {
+		return p.changeColumnPrivileges(ctx, n.Targets, n.Columns, n.Grantees, n.Privileges,
+			func(privDesc *sqlbase.PrivilegeDescriptor, grantee string, colIDs []sqlbase.ColumnID) {
+				privDesc.GrantColumns(grantee, n.Privileges, colIDs)
+			})
+	}
+	return p.changePrivileges(ctx, n.Targets, n.Grantees,
+		func(_ sqlbase.DescriptorProto, privDesc *sqlbase.PrivilegeDescriptor, grantee string) error {
+			privDesc.Grant(grantee, n.Privileges)
+			return nil
+		})
 }
 
 // Revoke removes privileges from users.
@@ -46,16 +56,67 @@ func (p *planner) Grant(ctx context.Context, n *tree.Grant) (planNode, error) {
 //   Notes: postgres requires the object owner.
 //          mysql requires the "grant option" and the same privileges, and sometimes superuser.
 func (p *planner) Revoke(ctx context.Context, n *tree.Revoke) (planNode, error) {
-	return p.changePrivileges(ctx, n.Targets, n.Grantees, func(privDesc *sqlbase.PrivilegeDescriptor, grantee string) {
-		privDesc.Revoke(grantee, n.Privileges)
-	})
+	if len(n.Columns) > 0 {
+		return p.changeColumnPrivileges(ctx, n.Targets, n.Columns, n.Grantees, n.Privileges,
+			func(privDesc *sqlbase.PrivilegeDescriptor, grantee string, colIDs []sqlbase.ColumnID) {
+				privDesc.RevokeColumns(grantee, n.Privileges, colIDs)
+			})
+	}
+	return p.changePrivileges(ctx, n.Targets, n.Grantees,
+		func(_ sqlbase.DescriptorProto, privDesc *sqlbase.PrivilegeDescriptor, grantee string) error {
+			privDesc.Revoke(grantee, n.Privileges)
+			return nil
+		})
+}
+
+// changeColumnPrivileges is the variant of changePrivileges used when a list
+// of columns is specified, e.g. GRANT SELECT (a, b) ON t TO u. The column
+// names are resolved separately for every target table, since the targets
+// may be a pattern matching several tables.
+func (p *planner) changeColumnPrivileges(
+	ctx context.Context,
+	targets tree.TargetList,
+	columns tree.NameList,
+	grantees tree.NameList,
+	privs privilege.List,
+	changePrivilege func(*sqlbase.PrivilegeDescriptor, string, []sqlbase.ColumnID),
+) (planNode, error) {
+	if targets.Databases != nil {
+		return nil, pgerror.New(pgcode.InvalidGrantOperation,
+			"column privileges can only be granted on tables")
+	}
+	bits := privs.ToBitField()
+	if invalid := bits &^ sqlbase.ColumnPrivilegeKinds.ToBitField(); bits&privilege.ALL.Mask() == 0 && invalid != 0 {
+		return nil, pgerror.Newf(pgcode.InvalidGrantOperation,
+			"invalid privilege type %s for column", privilege.ListFromBitField(invalid))
+	}
+
+	return p.changePrivileges(ctx, targets, grantees,
+		func(desc sqlbase.DescriptorProto, privDesc *sqlbase.PrivilegeDescriptor, grantee string) error {
+			tableDesc, ok := desc.(*sqlbase.MutableTableDescriptor)
+			if !ok || !tableDesc.IsTable() {
+				return pgerror.Newf(pgcode.InvalidGrantOperation,
+					"column privileges can only be granted on tables, %q is a %s",
+					desc.GetName(), desc.TypeName())
+			}
+			colIDs := make([]sqlbase.ColumnID, len(columns))
+			for i := range columns {
+				col, err := tableDesc.FindActiveColumnByName(string(columns[i]))
+				if err != nil {
+					return err
+				}
+				colIDs[i] = col.ID
+			}
+			changePrivilege(privDesc, grantee, colIDs)
+			return nil
+		})
 }
 
 func (p *planner) changePrivileges(
 	ctx context.Context,
 	targets tree.TargetList,
 	grantees tree.NameList,
-	changePrivilege func(*sqlbase.PrivilegeDescriptor, string),
+	changePrivilege func(sqlbase.DescriptorProto, *sqlbase.PrivilegeDescriptor, string) error,
 ) (planNode, error) {
 	// Check whether grantees exists
 	users, err := p.GetAllUsersAndRoles(ctx)
@@ -92,7 +153,9 @@ func (p *planner) changePrivileges(
 		}
 		privileges := descriptor.GetPrivileges()
 		for _, grantee := range grantees {
-			changePrivilege(privileges, string(grantee))
+			if err := changePrivilege(descriptor, privileges, string(grantee)); err != nil {
+				return nil, err
+			}
 		}
 
 		// Validate privilege descriptors directly as the db/table level Validate
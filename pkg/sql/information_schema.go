@@ -16,11 +16,13 @@ import (
 	"context"
 	"sort"
 	"strconv"
+	"time"
 	"unicode/utf8"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/roleoption"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -271,7 +273,7 @@ https://www.postgresql.org/docs/9.5/infoschema-column-privileges.html`,
 		return forEachTableDesc(ctx, p, dbContext, virtualMany, func(db *sqlbase.DatabaseDescriptor, scName string, table *sqlbase.TableDescriptor) error {
 			dbNameStr := tree.NewDString(db.Name)
 			scNameStr := tree.NewDString(scName)
-			columndata := privilege.List{privilege.SELECT, privilege.INSERT, privilege.UPDATE} // privileges for column level granularity
+			columndata := sqlbase.ColumnPrivilegeKinds // privileges for column level granularity
 			for _, u := range table.Privileges.Users {
 				for _, priv := range columndata {
 					if priv.Mask()&u.Privileges != 0 {
@@ -293,6 +295,31 @@ https://www.postgresql.org/docs/9.5/infoschema-column-privileges.html`,
 					}
 				}
 			}
+			// Add the privileges granted on individual columns.
+			for _, c := range table.Privileges.Columns {
+				cd, err := table.FindColumnByID(c.ColumnID)
+				if err != nil {
+					return err
+				}
+				for _, u := range c.Users {
+					for _, priv := range columndata {
+						if priv.Mask()&u.Privileges != 0 {
+							if err := addRow(
+								tree.DNull,                     // grantor
+								tree.NewDString(u.User),        // grantee
+								dbNameStr,                      // table_catalog
+								scNameStr,                      // table_schema
+								tree.NewDString(table.Name),    // table_name
+								tree.NewDString(cd.Name),       // column_name
+								tree.NewDString(priv.String()), // privilege_type
+								tree.DNull,                     // is_grantable
+							); err != nil {
+								return err
+							}
+						}
+					}
+				}
+			}
 			return nil
 		})
 	},
@@ -1603,6 +1630,82 @@ func forEachRole(
 	return nil
 }
 
+// roleAttributes are the attributes of a user or role that can be set with
+// role options.
+type roleAttributes struct {
+	canLogin   bool
+	createRole bool
+	createDB   bool
+	// validUntil is a TIMESTAMPTZ or NULL.
+	validUntil tree.Datum
+	// connLimit is -1 if unlimited.
+	connLimit int64
+}
+
+// forEachRoleWithAttributes is like forEachRole, but also passes the
+// attributes of each user or role based on system.role_options.
+func forEachRoleWithAttributes(
+	ctx context.Context,
+	p *planner,
+	fn func(username string, isRole bool, attrs roleAttributes) error,
+) error {
+	query := `SELECT username, option, value FROM system.role_options`
+	rows, err := p.ExtendedEvalContext().ExecCfg.InternalExecutor.Query(
+		ctx, "read-role-options", p.txn, query,
+	)
+	if err != nil {
+		return err
+	}
+	options := make(map[string][]tree.Datums)
+	for _, row := range rows {
+		username := string(tree.MustBeDString(row[0]))
+		options[username] = append(options[username], row[1:])
+	}
+
+	return forEachRole(ctx, p, func(username string, isRole bool) error {
+		isSuper := username == security.RootUser || username == sqlbase.AdminRole
+		attrs := roleAttributes{
+			canLogin:   !isRole,
+			createRole: isSuper,
+			createDB:   isSuper,
+			validUntil: tree.DNull,
+			connLimit:  -1,
+		}
+		for _, opt := range options[username] {
+			option, err := roleoption.ToOption(string(tree.MustBeDString(opt[0])))
+			if err != nil {
+				return err
+			}
+			switch option {
+			case roleoption.LOGIN:
+				attrs.canLogin = true
+			case roleoption.NOLOGIN:
+				attrs.canLogin = false
+			case roleoption.CREATEROLE:
+				attrs.createRole = true
+			case roleoption.CREATEDB:
+				attrs.createDB = true
+			case roleoption.VALIDUNTIL:
+				attrs.validUntil, err = tree.ParseDTimestampTZ(
+					p.EvalContext(), string(tree.MustBeDString(opt[1])), time.Microsecond)
+				if err != nil {
+					return err
+				}
+			case roleoption.CONNECTIONLIMIT:
+				attrs.connLimit, err = strconv.ParseInt(string(tree.MustBeDString(opt[1])), 10, 64)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if username == security.RootUser {
+			// Role options can't prevent root from logging in.
+			attrs.canLogin = true
+		}
+		return fn(username, isRole, attrs)
+	})
+}
+
 func forEachRoleMembership(
 	ctx context.Context, p *planner, fn func(role, member string, isAdmin bool) error,
 ) error {
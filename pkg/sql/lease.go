@@ -1495,8 +1495,8 @@ func (m *LeaseManager) AcquireByName(
 }
 
 // resolveName resolves a table name to a descriptor ID at a particular
-// timestamp by looking in the database. If the mapping is not found,
-// sqlbase.ErrDescriptorNotFound is returned.
+// timestamp by looking in the database. If the mapping is not found, or is
+// that of a user-defined type, sqlbase.ErrDescriptorNotFound is returned.
 func (m *LeaseManager) resolveName(
 	ctx context.Context, timestamp hlc.Timestamp, dbID sqlbase.ID, tableName string,
 ) (sqlbase.ID, error) {
@@ -1511,7 +1511,17 @@ func (m *LeaseManager) resolveName(
 		if !gr.Exists() {
 			return nil
 		}
-		id = sqlbase.ID(gr.ValueInt())
+		// User-defined types have system.namespace entries like tables, but
+		// cannot be leased. Skip them rather than acquiring a lease on them.
+		descID := sqlbase.ID(gr.ValueInt())
+		var desc sqlbase.Descriptor
+		if err := txn.GetProto(ctx, sqlbase.MakeDescMetadataKey(descID), &desc); err != nil {
+			return err
+		}
+		if desc.GetType() != nil {
+			return nil
+		}
+		id = descID
 		return nil
 	}); err != nil {
 		return id, err
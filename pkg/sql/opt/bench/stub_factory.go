@@ -41,6 +41,7 @@ func (f *stubFactory) ConstructScan(
 	reverse bool,
 	maxResults uint64,
 	reqOrdering exec.OutputOrdering,
+	lockForUpdate bool,
 ) (exec.Node, error) {
 	return struct{}{}, nil
 }
@@ -119,6 +119,12 @@ type Catalog interface {
 	// the given catalog object. If not, then CheckAnyPrivilege returns an error.
 	CheckAnyPrivilege(ctx context.Context, o Object) error
 
+	// CheckColumnPrivilege verifies that the current user has the given
+	// privilege on the column with the given ordinal in the given table, either
+	// because the privilege was granted on the whole table or on the column
+	// itself. If not, then CheckColumnPrivilege returns an error.
+	CheckColumnPrivilege(ctx context.Context, tab Table, ord int, priv privilege.Kind) error
+
 	// RequireSuperUser checks that the current user has admin privileges. If not,
 	// returns an error.
 	RequireSuperUser(ctx context.Context, action string) error
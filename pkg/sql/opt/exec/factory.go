@@ -56,6 +56,8 @@ type Factory interface {
 	//     the scan.
 	//   - If maxResults > 0, the scan is guaranteed to return at most maxResults
 	//     rows.
+	//   - If lockForUpdate is set, the scan locks the rows it reads until the
+	//     end of the transaction.
 	ConstructScan(
 		table cat.Table,
 		index cat.Index,
@@ -65,6 +67,7 @@ type Factory interface {
 		reverse bool,
 		maxResults uint64,
 		reqOrdering OutputOrdering,
+		lockForUpdate bool,
 	) (Node, error)
 
 	// ConstructVirtualScan returns a node that represents the scan of a virtual
@@ -275,6 +275,9 @@ func (f *ExprFmtCtx) formatRelational(e RelExpr, tp treeprinter.Node) {
 		if t.HardLimit.IsSet() {
 			tp.Childf("limit: %s", t.HardLimit)
 		}
+		if t.Locking {
+			tp.Childf("locking: for-update")
+		}
 		if !t.Flags.Empty() {
 			if t.Flags.NoIndexJoin {
 				tp.Childf("flags: no-index-join")
@@ -95,9 +95,19 @@ type Builder struct {
 	// are referenced multiple times in the same query.
 	views map[cat.View]*tree.Select
 
+	// columnPrivs contains the tables that the current user can only access
+	// through privileges granted on individual columns, mapped to the mask of
+	// the privileges that must be checked column by column.
+	columnPrivs map[cat.StableID]uint32
+
 	// subquery contains a pointer to the subquery which is currently being built
 	// (if any).
 	subquery *subquery
+
+	// forUpdate is set while building the FROM clause of a SELECT ... FOR
+	// UPDATE statement (including any views and subqueries in it). Table scans
+	// built while it is set lock the rows they read.
+	forUpdate bool
 }
 
 // New creates a new Builder structure initialized with the given
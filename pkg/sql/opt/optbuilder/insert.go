@@ -374,6 +374,7 @@ func (mb *mutationBuilder) addTargetNamedColsForInsert(names tree.NameList) {
 
 	// Add target table columns by the names specified in the Insert statement.
 	mb.addTargetColsByName(names)
+	mb.checkTargetColPrivilegesForInsert()
 
 	// Ensure that primary key columns are in the target column list, or that
 	// they have default values.
@@ -384,6 +385,16 @@ func (mb *mutationBuilder) addTargetNamedColsForInsert(names tree.NameList) {
 	mb.checkForeignKeysForInsert()
 }
 
+// checkTargetColPrivilegesForInsert ensures that the current user may insert
+// into each of the target columns. An UPSERT may also overwrite the values of
+// those columns, so it requires the UPDATE privilege as well.
+func (mb *mutationBuilder) checkTargetColPrivilegesForInsert() {
+	mb.checkTargetColPrivileges(privilege.INSERT)
+	if mb.op == opt.UpsertOp {
+		mb.checkTargetColPrivileges(privilege.UPDATE)
+	}
+}
+
 // checkPrimaryKeyForInsert ensures that the columns of the primary key are
 // either assigned values by the INSERT statement, or else have default/computed
 // values. If neither condition is true, checkPrimaryKeyForInsert raises an
@@ -502,6 +513,7 @@ func (mb *mutationBuilder) addTargetTableColsForInsert(maxCols int) {
 		mb.addTargetCol(i)
 		numCols++
 	}
+	mb.checkTargetColPrivilegesForInsert()
 
 	// Ensure that the number of input columns does not exceed the number of
 	// target columns.
@@ -424,6 +424,10 @@ func (jb *usingJoinBuilder) addEqualityCondition(leftCol, rightCol *scopeColumn)
 		}
 	}
 
+	// The predicate reads both columns, even if only one of them is projected.
+	jb.b.checkColumnAccess(leftCol)
+	jb.b.checkColumnAccess(rightCol)
+
 	// Construct the predicate.
 	leftVar := jb.b.factory.ConstructVariable(leftCol.id)
 	rightVar := jb.b.factory.ConstructVariable(rightCol.id)
@@ -455,6 +459,10 @@ func (jb *usingJoinBuilder) addEqualityCondition(leftCol, rightCol *scopeColumn)
 		texpr := tree.NewTypedCoalesceExpr(tree.TypedExprs{leftCol, rightCol}, typ)
 		merged := jb.b.factory.ConstructCoalesce(memo.ScalarListExpr{leftVar, rightVar})
 		col := jb.b.synthesizeColumn(jb.outScope, string(leftCol.name), typ, texpr, merged)
+		col.denyErr = leftCol.denyErr
+		if col.denyErr == nil {
+			col.denyErr = rightCol.denyErr
+		}
 		jb.ifNullCols.Add(col.id)
 		jb.hideCols.Add(leftCol.id)
 		jb.hideCols.Add(rightCol.id)
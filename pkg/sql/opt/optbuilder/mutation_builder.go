@@ -21,6 +21,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -259,6 +260,18 @@ func (mb *mutationBuilder) addTargetCol(ord int) {
 	mb.targetColList = append(mb.targetColList, colID)
 }
 
+// checkTargetColPrivileges raises an error if the current user does not hold
+// the given privilege on each of the target columns. This only has an effect if
+// the privilege was granted on individual columns rather than on the table.
+func (mb *mutationBuilder) checkTargetColPrivileges(priv privilege.Kind) {
+	for _, colID := range mb.targetColList {
+		ord := mb.tabID.ColumnOrdinal(colID)
+		if err := mb.b.checkColumnPrivilege(mb.tab, ord, priv); err != nil {
+			panic(builderError{err})
+		}
+	}
+}
+
 // extractValuesInput tests whether the given input is a VALUES clause with no
 // WITH, ORDER BY, or LIMIT modifier. If so, it's returned, otherwise nil is
 // returned.
@@ -360,6 +373,13 @@ func (mb *mutationBuilder) addSynthesizedCols(
 ) {
 	var projectionsScope *scope
 
+	// Default and computed expressions are part of the schema, so they may
+	// reference columns that the current user is not allowed to read.
+	if !mb.b.skipSelectPrivilegeChecks {
+		mb.b.skipSelectPrivilegeChecks = true
+		defer func() { mb.b.skipSelectPrivilegeChecks = false }()
+	}
+
 	// Skip delete-only mutation columns, since they are ignored by all mutation
 	// operators that synthesize columns.
 	for i, n := 0, mb.tab.WritableColumnCount(); i < n; i++ {
@@ -511,6 +531,13 @@ func findRoundingFunction(typ *types.T, precision int) (*tree.FunctionProperties
 // a constraint violation error if the value of the column is false.
 func (mb *mutationBuilder) addCheckConstraintCols() {
 	if mb.tab.CheckCount() > 0 {
+		// Check constraints are part of the schema, so they may reference columns
+		// that the current user is not allowed to read.
+		if !mb.b.skipSelectPrivilegeChecks {
+			mb.b.skipSelectPrivilegeChecks = true
+			defer func() { mb.b.skipSelectPrivilegeChecks = false }()
+		}
+
 		// Disambiguate names so that references in the constraint expression refer
 		// to the correct columns.
 		mb.disambiguateColumns()
@@ -181,6 +181,7 @@ func (b *Builder) resolveColRef(e tree.Expr, inScope *scope) tree.TypedExpr {
 		if err != nil {
 			panic(builderError{err})
 		}
+		b.checkColumnAccess(srcMeta.(*scopeColumn))
 		return srcMeta.(tree.TypedExpr)
 	}
 	return nil
@@ -848,7 +848,9 @@ func (s *scope) VisitPre(expr tree.Expr) (recurse bool, newExpr tree.Expr) {
 		if err != nil {
 			panic(builderError{err})
 		}
-		return false, colI.(*scopeColumn)
+		col := colI.(*scopeColumn)
+		s.builder.checkColumnAccess(col)
+		return false, col
 
 	case *tree.FuncExpr:
 		def, err := t.Func.Resolve(s.builder.semaCtx.SearchPath)
@@ -43,6 +43,10 @@ type scopeColumn struct {
 	// to the table. It should not be visible to variable references.
 	mutation bool
 
+	// denyErr is set if the current user lacks the privilege to read this
+	// column, in which case it is raised when the column is referenced.
+	denyErr error
+
 	// descending indicates whether this column is sorted in descending order.
 	// This field is only used for ordering columns.
 	descending bool
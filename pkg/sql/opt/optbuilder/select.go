@@ -342,6 +342,10 @@ func (b *Builder) buildScan(
 		tabColIDs.Add(colID)
 		name := col.ColName()
 		isMutation := cat.IsMutationColumn(tab, ord)
+		var denyErr error
+		if !isMutation && !b.skipSelectPrivilegeChecks {
+			denyErr = b.checkColumnPrivilege(tab, ord, privilege.SELECT)
+		}
 		outScope.cols = append(outScope.cols, scopeColumn{
 			id:       colID,
 			name:     name,
@@ -349,6 +353,7 @@ func (b *Builder) buildScan(
 			typ:      col.DatumType(),
 			hidden:   col.IsHidden() || isMutation,
 			mutation: isMutation,
+			denyErr:  denyErr,
 		})
 	}
 
@@ -387,6 +392,13 @@ func (b *Builder) buildScan(
 				private.Flags.Direction = indexFlags.Direction
 			}
 		}
+		if b.forUpdate {
+			if private.Flags.ForceIndex && private.Flags.Index != cat.PrimaryIndex {
+				panic(pgerror.New(pgcode.FeatureNotSupported,
+					"FOR UPDATE is not allowed with a secondary index hint"))
+			}
+			private.Locking = true
+		}
 		outScope.expr = b.factory.ConstructScan(&private)
 		b.addCheckConstraintsToScan(outScope, tabID)
 	}
@@ -575,9 +587,11 @@ func (b *Builder) buildSelect(
 	orderBy := stmt.OrderBy
 	limit := stmt.Limit
 	with := stmt.With
+	forUpdate := stmt.ForUpdate
 
 	for s, ok := wrapped.(*tree.ParenSelect); ok; s, ok = wrapped.(*tree.ParenSelect) {
 		stmt = s.Select
+		forUpdate = forUpdate || stmt.ForUpdate
 		if stmt.With != nil {
 			if with != nil {
 				// (WITH ... (WITH ...))
@@ -611,6 +625,15 @@ func (b *Builder) buildSelect(
 		defer b.checkCTEUsage(inScope)
 	}
 
+	if forUpdate {
+		defer func(forUpdate bool) { b.forUpdate = forUpdate }(b.forUpdate)
+		b.forUpdate = true
+	}
+	if _, ok := stmt.Select.(*tree.SelectClause); b.forUpdate && !ok {
+		panic(pgerror.New(pgcode.FeatureNotSupported,
+			"FOR UPDATE is not allowed with UNION/INTERSECT/EXCEPT or VALUES"))
+	}
+
 	// NB: The case statements are sorted lexicographically.
 	switch t := stmt.Select.(type) {
 	case *tree.SelectClause:
@@ -660,7 +683,25 @@ func (b *Builder) buildSelect(
 func (b *Builder) buildSelectClause(
 	sel *tree.SelectClause, orderBy tree.OrderBy, desiredTypes []*types.T, inScope *scope,
 ) (outScope *scope) {
+	if b.forUpdate {
+		switch {
+		case sel.GroupBy != nil:
+			panic(pgerror.New(pgcode.FeatureNotSupported,
+				"FOR UPDATE is not allowed with GROUP BY clause"))
+		case sel.Having != nil:
+			panic(pgerror.New(pgcode.FeatureNotSupported,
+				"FOR UPDATE is not allowed with HAVING clause"))
+		case sel.Distinct:
+			panic(pgerror.New(pgcode.FeatureNotSupported,
+				"FOR UPDATE is not allowed with DISTINCT clause"))
+		}
+	}
 	fromScope := b.buildFrom(sel.From, inScope)
+
+	// Only the tables in the FROM clause are locked by FOR UPDATE, not those
+	// read by subqueries in the rest of the statement.
+	defer func(forUpdate bool) { b.forUpdate = forUpdate }(b.forUpdate)
+	b.forUpdate = false
 	b.processWindowDefs(sel, fromScope)
 	b.buildWhere(sel.Where, fromScope)
 
@@ -903,9 +944,10 @@ func (b *Builder) buildFromWithLateral(tables tree.TableExprs, inScope *scope) (
 }
 
 // validateAsOf ensures that any AS OF SYSTEM TIME timestamp is consistent with
-// that of the root statement.
+// that of the root statement. A bounded staleness clause only needs to admit
+// the timestamp negotiated for the root statement.
 func (b *Builder) validateAsOf(asOf tree.AsOfClause) {
-	ts, err := tree.EvalAsOfTimestamp(asOf, b.semaCtx, b.evalCtx)
+	res, err := tree.EvalAsOfSystemTime(asOf, b.semaCtx, b.evalCtx)
 	if err != nil {
 		panic(builderError{err})
 	}
@@ -915,7 +957,9 @@ func (b *Builder) validateAsOf(asOf tree.AsOfClause) {
 			"AS OF SYSTEM TIME must be provided on a top-level statement"))
 	}
 
-	if *b.semaCtx.AsOfTimestamp != ts {
+	ts := *b.semaCtx.AsOfTimestamp
+	if res.BoundedStaleness && ts.Less(res.Timestamp) ||
+		!res.BoundedStaleness && res.Timestamp != ts {
 		panic(unimplementedWithIssueDetailf(35712, "",
 			"cannot specify AS OF SYSTEM TIME with different timestamps"))
 	}
@@ -171,6 +171,9 @@ func (mb *mutationBuilder) addTargetColsForUpdate(exprs tree.UpdateExprs) {
 			}
 		}
 	}
+
+	// Ensure that the current user may update each of the target columns.
+	mb.checkTargetColPrivileges(privilege.UPDATE)
 }
 
 // addUpdateCols builds nested Project and LeftOuterJoin expressions that
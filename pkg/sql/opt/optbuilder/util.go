@@ -107,6 +107,7 @@ func (b *Builder) expandStar(
 		for i := range inScope.cols {
 			col := &inScope.cols[i]
 			if col.table == *src && !col.hidden {
+				b.checkColumnAccess(col)
 				exprs = append(exprs, col)
 				aliases = append(aliases, string(col.name))
 			}
@@ -119,6 +120,7 @@ func (b *Builder) expandStar(
 		for i := range inScope.cols {
 			col := &inScope.cols[i]
 			if !col.hidden {
+				b.checkColumnAccess(col)
 				exprs = append(exprs, col)
 				aliases = append(aliases, string(col.name))
 			}
@@ -498,7 +500,14 @@ func (b *Builder) checkPrivilege(
 	if !(priv == privilege.SELECT && b.skipSelectPrivilegeChecks) {
 		err := b.catalog.CheckPrivilege(b.ctx, ds, priv)
 		if err != nil {
-			panic(builderError{err})
+			if !b.checkAnyColumnPrivilege(ds, priv, err) {
+				panic(builderError{err})
+			}
+			// Access is granted only to some of the columns, which are checked
+			// individually as they are referenced. Column references are not
+			// recorded in the metadata, so the memo cannot be reused.
+			b.DisableMemoReuse = true
+			priv = 0
 		}
 	} else {
 		// The check is skipped, so don't recheck when dependencies are checked.
@@ -509,3 +518,52 @@ func (b *Builder) checkPrivilege(
 	// cached and later checked for freshness.
 	b.factory.Metadata().AddDataSourceDependency(origName, ds, priv)
 }
+
+// checkAnyColumnPrivilege returns true if the given table-level privilege
+// check failed, but the current user holds the privilege on at least one
+// column of the table. In that case, the privilege is recorded so that
+// references to individual columns can be checked later on.
+func (b *Builder) checkAnyColumnPrivilege(
+	ds cat.DataSource, priv privilege.Kind, err error,
+) bool {
+	switch priv {
+	case privilege.SELECT, privilege.INSERT, privilege.UPDATE:
+	default:
+		return false
+	}
+	if pgerror.GetPGCode(err) != pgcode.InsufficientPrivilege {
+		return false
+	}
+	tab, ok := ds.(cat.Table)
+	if !ok {
+		return false
+	}
+	for i, n := 0, tab.ColumnCount(); i < n; i++ {
+		if b.catalog.CheckColumnPrivilege(b.ctx, tab, i, priv) == nil {
+			if b.columnPrivs == nil {
+				b.columnPrivs = make(map[cat.StableID]uint32)
+			}
+			b.columnPrivs[tab.ID()] |= priv.Mask()
+			return true
+		}
+	}
+	return false
+}
+
+// checkColumnAccess raises an error if the given column was produced by a scan
+// of a table column that the current user is not allowed to read.
+func (b *Builder) checkColumnAccess(col *scopeColumn) {
+	if col.denyErr != nil && !b.skipSelectPrivilegeChecks {
+		panic(builderError{col.denyErr})
+	}
+}
+
+// checkColumnPrivilege returns an error if the given privilege on the table
+// is only granted for individual columns, and the column with the given
+// ordinal is not one of them.
+func (b *Builder) checkColumnPrivilege(tab cat.Table, ord int, priv privilege.Kind) error {
+	if b.columnPrivs[tab.ID()]&priv.Mask() == 0 {
+		return nil
+	}
+	return b.catalog.CheckColumnPrivilege(b.ctx, tab, ord, priv)
+}
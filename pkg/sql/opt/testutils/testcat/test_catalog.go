@@ -168,6 +168,13 @@ func (tc *Catalog) CheckPrivilege(ctx context.Context, o cat.Object, priv privil
 	return tc.CheckAnyPrivilege(ctx, o)
 }
 
+// CheckColumnPrivilege is part of the cat.Catalog interface.
+func (tc *Catalog) CheckColumnPrivilege(
+	ctx context.Context, tab cat.Table, ord int, priv privilege.Kind,
+) error {
+	return tc.CheckAnyPrivilege(ctx, tab)
+}
+
 // CheckAnyPrivilege is part of the cat.Catalog interface.
 func (tc *Catalog) CheckAnyPrivilege(ctx context.Context, o cat.Object) error {
 	switch t := o.(type) {
@@ -790,6 +790,10 @@ func (c *CustomFuncs) GenerateLookupJoins(
 	if joinPrivate.Flags.DisallowLookupJoin {
 		return
 	}
+	if scanPrivate.Locking {
+		// Lookup joins don't lock the rows they read.
+		return
+	}
 	inputProps := input.Relational()
 
 	leftEq, rightEq := memo.ExtractJoinEqualityColumns(inputProps.OutputCols, scanPrivate.Cols, on)
@@ -1582,13 +1586,15 @@ func (it *scanIndexIter) init(mem *memo.Memo, scanPrivate *memo.ScanPrivate) {
 // next advances iteration to the next index of the Scan operator's table. This
 // is the primary index if it's the first time next is called, or a secondary
 // index thereafter. Inverted index are skipped. If the ForceIndex flag is set,
-// then all indexes except the forced index are skipped. When there are no more
-// indexes to enumerate, next returns false. The current index is accessible via
-// the iterator's "index" field.
+// then all indexes except the forced index are skipped. If the scan is locking,
+// then only the primary index is enumerated. When there are no more indexes to
+// enumerate, next returns false. The current index is accessible via the
+// iterator's "index" field.
 func (it *scanIndexIter) next() bool {
 	for {
 		it.indexOrdinal++
-		if it.indexOrdinal >= it.tab.IndexCount() {
+		if it.indexOrdinal >= it.tab.IndexCount() ||
+			(it.scanPrivate.Locking && it.indexOrdinal != cat.PrimaryIndex) {
 			it.index = nil
 			return false
 		}
@@ -1607,12 +1613,12 @@ func (it *scanIndexIter) next() bool {
 
 // nextInverted advances iteration to the next inverted index of the Scan
 // operator's table. It returns false when there are no more inverted indexes to
-// enumerate (or if there were none to begin with). The current index is
-// accessible via the iterator's "index" field.
+// enumerate (or if there were none to begin with, or the scan is locking). The
+// current index is accessible via the iterator's "index" field.
 func (it *scanIndexIter) nextInverted() bool {
 	for {
 		it.indexOrdinal++
-		if it.indexOrdinal >= it.tab.IndexCount() {
+		if it.indexOrdinal >= it.tab.IndexCount() || it.scanPrivate.Locking {
 			it.index = nil
 			return false
 		}
@@ -286,6 +286,9 @@ func (mf *memoFormatter) formatPrivate(e opt.Expr, physProps *physical.Required)
 		if t.HardLimit.IsSet() {
 			fmt.Fprintf(mf.buf, ",lim=%s", t.HardLimit)
 		}
+		if t.Locking {
+			fmt.Fprintf(mf.buf, ",locking")
+		}
 
 	case *memo.IndexJoinExpr:
 		fmt.Fprintf(mf.buf, ",cols=%s", t.Cols)
@@ -234,6 +234,19 @@ func (oc *optCatalog) CheckAnyPrivilege(ctx context.Context, o cat.Object) error
 	}
 }
 
+// CheckColumnPrivilege is part of the cat.Catalog interface.
+func (oc *optCatalog) CheckColumnPrivilege(
+	ctx context.Context, tab cat.Table, ord int, priv privilege.Kind,
+) error {
+	t, ok := tab.(*optTable)
+	if !ok {
+		// Virtual tables don't support column privileges.
+		return oc.CheckPrivilege(ctx, tab, priv)
+	}
+	colID := sqlbase.ColumnID(tab.Column(ord).ColID())
+	return oc.planner.CheckColumnPrivilege(ctx, &t.desc.TableDescriptor, colID, priv)
+}
+
 // RequireSuperUser is part of the cat.Catalog interface.
 func (oc *optCatalog) RequireSuperUser(ctx context.Context, action string) error {
 	return oc.planner.RequireSuperUser(ctx, action)
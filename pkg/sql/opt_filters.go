@@ -357,6 +357,11 @@ func (p *planner) propagateFilters(
 			return plan, extraFilter, err
 		}
 
+	case *controlSchedulesNode:
+		if n.rows, err = p.triggerFilterPropagation(ctx, n.rows); err != nil {
+			return plan, extraFilter, err
+		}
+
 	case *projectSetNode:
 		// TODO(knz): we can propagate the part of the filter that applies
 		// to the source columns.
@@ -376,8 +381,9 @@ func (p *planner) propagateFilters(
 
 	case *alterIndexNode:
 	case *alterTableNode:
+	case *alterDomainNode:
 	case *alterSequenceNode:
-	case *alterUserSetPasswordNode:
+	case *alterRoleNode:
 	case *renameColumnNode:
 	case *renameDatabaseNode:
 	case *renameIndexNode:
@@ -391,14 +397,18 @@ func (p *planner) propagateFilters(
 	case *createIndexNode:
 	case *CreateUserNode:
 	case *createViewNode:
+	case *createDomainNode:
 	case *createSequenceNode:
 	case *createStatsNode:
+	case *createTypeNode:
 	case *deleteRangeNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropDomainNode:
 	case *dropSequenceNode:
+	case *dropTypeNode:
 	case *DropUserNode:
 	case *hookFnNode:
 	case *valuesNode:
@@ -197,6 +197,9 @@ func (p *planner) applyLimit(plan planNode, numRows int64, soft bool) {
 	case *controlJobsNode:
 		p.setUnlimited(n.rows)
 
+	case *controlSchedulesNode:
+		p.setUnlimited(n.rows)
+
 	case *errorIfRowsNode:
 		p.setUnlimited(n.plan)
 
@@ -207,8 +210,9 @@ func (p *planner) applyLimit(plan planNode, numRows int64, soft bool) {
 	case *virtualTableNode:
 	case *alterIndexNode:
 	case *alterTableNode:
+	case *alterDomainNode:
 	case *alterSequenceNode:
-	case *alterUserSetPasswordNode:
+	case *alterRoleNode:
 	case *deleteRangeNode:
 	case *renameColumnNode:
 	case *renameDatabaseNode:
@@ -223,13 +227,17 @@ func (p *planner) applyLimit(plan planNode, numRows int64, soft bool) {
 	case *createIndexNode:
 	case *CreateUserNode:
 	case *createViewNode:
+	case *createDomainNode:
 	case *createSequenceNode:
 	case *createStatsNode:
+	case *createTypeNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropDomainNode:
 	case *dropSequenceNode:
+	case *dropTypeNode:
 	case *DropUserNode:
 	case *zeroNode:
 	case *unaryNode:
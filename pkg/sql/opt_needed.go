@@ -255,6 +255,9 @@ func setNeededColumns(plan planNode, needed []bool) {
 	case *controlJobsNode:
 		setNeededColumns(n.rows, allColumns(n.rows))
 
+	case *controlSchedulesNode:
+		setNeededColumns(n.rows, allColumns(n.rows))
+
 	case *errorIfRowsNode:
 		setNeededColumns(n.plan, allColumns(n.plan))
 
@@ -263,8 +266,9 @@ func setNeededColumns(plan planNode, needed []bool) {
 
 	case *alterIndexNode:
 	case *alterTableNode:
+	case *alterDomainNode:
 	case *alterSequenceNode:
-	case *alterUserSetPasswordNode:
+	case *alterRoleNode:
 	case *deleteRangeNode:
 	case *renameColumnNode:
 	case *renameDatabaseNode:
@@ -279,13 +283,17 @@ func setNeededColumns(plan planNode, needed []bool) {
 	case *createIndexNode:
 	case *CreateUserNode:
 	case *createViewNode:
+	case *createDomainNode:
 	case *createSequenceNode:
 	case *createStatsNode:
+	case *createTypeNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropDomainNode:
 	case *dropSequenceNode:
+	case *dropTypeNode:
 	case *DropUserNode:
 	case *zeroNode:
 	case *unaryNode:
@@ -0,0 +1,792 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/big"
+	"math/bits"
+	"time"
+
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+)
+
+// Reader reads the rows of a Parquet file.
+//
+// Only the subset of the format that flat tables are written with is
+// supported: every top-level column must be a primitive (REQUIRED, OPTIONAL or
+// REPEATED) or a LIST of primitives. Pages may be v1 or v2 data pages, PLAIN or
+// dictionary encoded, and uncompressed or compressed with snappy or gzip. The
+// values of one row group are decoded into memory at a time.
+type Reader struct {
+	r    io.ReaderAt
+	meta fileMetaData
+	cols []*readColumn
+
+	// rowGroup is the index of the next row group to decode.
+	rowGroup int
+	// values holds the decoded values of each column of the current row group,
+	// and row is the index in it of the next row to return.
+	values [][]interface{}
+	row    int
+	// current is returned by Next.
+	current []interface{}
+}
+
+// readColumn is a top-level column of a file being read.
+type readColumn struct {
+	name string
+	// leaf is the schema element of the primitive values of the column, which
+	// for a LIST are its elements.
+	leaf schemaElement
+	list bool
+	// maxDef and maxRep are the maximum definition and repetition levels of
+	// the leaf. For a LIST, a definition level below listDef means the list is
+	// NULL, listDef that it is empty, and between listDef and maxDef that an
+	// element is NULL.
+	maxDef  int32
+	maxRep  int32
+	listDef int32
+	convert convertFn
+}
+
+// convertFn converts a decoded physical value to the value returned by Next.
+type convertFn func(v interface{}) (interface{}, error)
+
+// NewReader returns a Reader of the Parquet file of the given size read from r.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < int64(2*len(magic)+4) {
+		return nil, errors.New(`parquet: file too short`)
+	}
+	var tail [8]byte
+	if _, err := r.ReadAt(tail[:], size-8); err != nil {
+		return nil, err
+	}
+	var head [4]byte
+	if _, err := r.ReadAt(head[:], 0); err != nil {
+		return nil, err
+	}
+	if string(head[:]) != magic || string(tail[4:]) != magic {
+		return nil, errors.New(`parquet: not a parquet file`)
+	}
+	footerLen := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if footerLen > size-int64(2*len(magic)+4) {
+		return nil, errors.New(`parquet: invalid footer length`)
+	}
+	footer := make([]byte, footerLen)
+	if _, err := r.ReadAt(footer, size-8-footerLen); err != nil {
+		return nil, err
+	}
+
+	pr := &Reader{r: r}
+	d := thriftDecoder{b: footer}
+	pr.meta.read(&d)
+	if d.err != nil {
+		return nil, errors.Wrap(d.err, `parquet: reading file metadata`)
+	}
+	if len(pr.meta.schema) == 0 {
+		return nil, errors.New(`parquet: file has no schema`)
+	}
+	var err error
+	if pr.cols, err = makeReadColumns(pr.meta.schema); err != nil {
+		return nil, err
+	}
+	for i := range pr.meta.rowGroups {
+		if n := len(pr.meta.rowGroups[i].columns); n != len(pr.cols) {
+			return nil, errors.Errorf(`parquet: row group %d has %d columns, expected %d`,
+				i, n, len(pr.cols))
+		}
+	}
+	pr.values = make([][]interface{}, len(pr.cols))
+	pr.current = make([]interface{}, len(pr.cols))
+	return pr, nil
+}
+
+// makeReadColumns returns the top-level columns described by a schema.
+func makeReadColumns(schema []schemaElement) ([]*readColumn, error) {
+	var cols []*readColumn
+	i := 1
+	for c := int32(0); c < schema[0].numChildren; c++ {
+		if i >= len(schema) {
+			return nil, errors.New(`parquet: truncated schema`)
+		}
+		e := &schema[i]
+		col := &readColumn{name: e.name}
+		var outer int32
+		if e.repetition == repetitionOptional {
+			outer = 1
+		}
+
+		switch {
+		case e.isLeaf && e.repetition == repetitionRepeated:
+			// A repeated primitive is a list of non-NULL elements that can't
+			// itself be NULL.
+			col.leaf, col.list = *e, true
+			col.listDef, col.maxDef, col.maxRep = 0, 1, 1
+			i++
+		case e.isLeaf:
+			col.leaf = *e
+			col.maxDef = outer
+			i++
+		case (e.converted == convertedList || e.logical == logicalList) && e.numChildren == 1 &&
+			i+1 < len(schema) && schema[i+1].repetition == repetitionRepeated:
+			// A LIST is an outer group with a repeated child. In the standard
+			// three-level layout that child is a group with a single element
+			// child. In the legacy two-level layout it is the element itself.
+			rep := &schema[i+1]
+			elem := rep
+			if !rep.isLeaf {
+				if rep.numChildren != 1 || i+2 >= len(schema) || !schema[i+2].isLeaf {
+					return nil, errors.Errorf(`parquet: unsupported nested column %q`, e.name)
+				}
+				elem = &schema[i+2]
+			}
+			col.leaf, col.list = *elem, true
+			col.listDef = outer
+			col.maxDef, col.maxRep = outer+1, 1
+			if elem.repetition == repetitionOptional {
+				col.maxDef++
+			}
+			i += 2
+			if elem != rep {
+				i++
+			}
+		default:
+			return nil, errors.Errorf(`parquet: unsupported nested column %q`, e.name)
+		}
+
+		col.convert = converterFor(&col.leaf)
+		cols = append(cols, col)
+	}
+	if i != len(schema) {
+		return nil, errors.New(`parquet: schema has unexpected elements`)
+	}
+	return cols, nil
+}
+
+// Columns returns the names of the top-level columns of the file.
+func (r *Reader) Columns() []string {
+	names := make([]string, len(r.cols))
+	for i, col := range r.cols {
+		names[i] = col.name
+	}
+	return names
+}
+
+// NumRows returns the number of rows in the file.
+func (r *Reader) NumRows() int64 {
+	return r.meta.numRows
+}
+
+// Next returns the values of the top-level columns of the next row, or io.EOF
+// after the last row. The returned slice is only valid until the next call.
+//
+// Values are returned as follows:
+//
+//	NULL                                  nil
+//	BOOLEAN                               bool
+//	INT32, INT64                          int32, int64
+//	UINT_8, UINT_16, UINT_32              int64
+//	UINT_64                               uint64
+//	FLOAT, DOUBLE                         float32, float64
+//	BYTE_ARRAY, FIXED_LEN_BYTE_ARRAY      []byte
+//	UTF8, ENUM, JSON, UUID                string
+//	DECIMAL                               *apd.Decimal
+//	DATE, TIMESTAMP_*, INT96              time.Time (UTC)
+//	TIME_*                                timeofday.TimeOfDay
+//	LIST                                  []interface{} of the above
+func (r *Reader) Next() ([]interface{}, error) {
+	for len(r.values) == 0 || r.row >= len(r.values[0]) {
+		if r.rowGroup >= len(r.meta.rowGroups) {
+			return nil, io.EOF
+		}
+		if err := r.readRowGroup(); err != nil {
+			return nil, err
+		}
+	}
+	for i := range r.cols {
+		r.current[i] = r.values[i][r.row]
+	}
+	r.row++
+	return r.current, nil
+}
+
+func (r *Reader) readRowGroup() error {
+	g := &r.meta.rowGroups[r.rowGroup]
+	r.rowGroup++
+	r.row = 0
+	for i, col := range r.cols {
+		values, err := r.readChunk(col, &g.columns[i])
+		if err != nil {
+			return errors.Wrapf(err, `column %q`, col.name)
+		}
+		if int64(len(values)) != g.numRows {
+			return errors.Errorf(`parquet: column %q has %d rows, expected %d`,
+				col.name, len(values), g.numRows)
+		}
+		r.values[i] = values
+	}
+	if len(r.cols) == 0 {
+		r.values = r.values[:0]
+	}
+	return nil
+}
+
+// readChunk decodes the pages of a column chunk and returns the value of the
+// column for each row.
+func (r *Reader) readChunk(col *readColumn, m *columnMetaData) ([]interface{}, error) {
+	if m.filePath != "" {
+		return nil, errors.New(`parquet: column chunks in other files are not supported`)
+	}
+	start := m.dataPageOffset
+	if m.dictionaryPageOffset > 0 && m.dictionaryPageOffset < start {
+		start = m.dictionaryPageOffset
+	}
+	if m.totalCompressedSize < 0 || m.totalCompressedSize > math.MaxInt32 || start < 0 {
+		return nil, errors.New(`parquet: invalid column chunk`)
+	}
+	buf := make([]byte, m.totalCompressedSize)
+	if _, err := r.r.ReadAt(buf, start); err != nil {
+		return nil, err
+	}
+
+	var dict, values []interface{}
+	var defLevels, repLevels []int32
+	for read := int64(0); read < m.numValues; {
+		d := thriftDecoder{b: buf}
+		var h pageHeader
+		h.read(&d)
+		if d.err != nil {
+			return nil, errors.Wrap(d.err, `parquet: reading page header`)
+		}
+		if h.compressedSize < 0 || int(h.compressedSize) > len(d.b) || h.numValues < 0 {
+			return nil, errors.New(`parquet: invalid page header`)
+		}
+		page, rest := d.b[:h.compressedSize], d.b[h.compressedSize:]
+		buf = rest
+
+		switch h.typ {
+		case pageTypeDictionary:
+			data, err := decompress(m.codec, page, h.uncompressedSize)
+			if err != nil {
+				return nil, err
+			}
+			if h.encoding != encodingPlain && h.encoding != encodingPlainDictionary {
+				return nil, errors.Errorf(`parquet: unsupported dictionary encoding %d`, h.encoding)
+			}
+			if dict, err = decodePlain(&col.leaf, data, int(h.numValues)); err != nil {
+				return nil, err
+			}
+			for i := range dict {
+				if dict[i], err = col.convert(dict[i]); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		case pageTypeData, pageTypeDataV2:
+		default:
+			// Index pages and anything newer carry no values.
+			continue
+		}
+
+		n := int(h.numValues)
+		var reps, defs []int32
+		var data []byte
+		var err error
+		if h.typ == pageTypeData {
+			if data, err = decompress(m.codec, page, h.uncompressedSize); err != nil {
+				return nil, err
+			}
+			if reps, data, err = readLevelsV1(data, col.maxRep, encodingRLE, n); err != nil {
+				return nil, err
+			}
+			if defs, data, err = readLevelsV1(data, col.maxDef, h.defLevelEncoding, n); err != nil {
+				return nil, err
+			}
+		} else {
+			repLen, defLen := int(h.repLevelsLength), int(h.defLevelsLength)
+			if repLen < 0 || defLen < 0 || repLen+defLen > len(page) {
+				return nil, errors.New(`parquet: invalid page header`)
+			}
+			if reps, err = decodeLevels(page[:repLen], col.maxRep, n); err != nil {
+				return nil, err
+			}
+			if defs, err = decodeLevels(page[repLen:repLen+defLen], col.maxDef, n); err != nil {
+				return nil, err
+			}
+			data = page[repLen+defLen:]
+			if h.compressed {
+				size := h.uncompressedSize - int32(repLen+defLen)
+				if data, err = decompress(m.codec, data, size); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		numPresent := 0
+		for _, def := range defs {
+			if def == col.maxDef {
+				numPresent++
+			}
+		}
+		pageValues, err := decodeValues(col, h.encoding, data, numPresent, dict)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, pageValues...)
+		defLevels = append(defLevels, defs...)
+		repLevels = append(repLevels, reps...)
+		read += int64(n)
+	}
+
+	if !col.list {
+		return assembleColumn(col, defLevels, values), nil
+	}
+	return assembleList(col, defLevels, repLevels, values)
+}
+
+// assembleColumn returns the value of a column that isn't a LIST for each row.
+func assembleColumn(col *readColumn, defLevels []int32, values []interface{}) []interface{} {
+	rows := make([]interface{}, len(defLevels))
+	for i, def := range defLevels {
+		if def == col.maxDef {
+			rows[i], values = values[0], values[1:]
+		}
+	}
+	return rows
+}
+
+// assembleList returns the value of a LIST column for each row.
+func assembleList(
+	col *readColumn, defLevels, repLevels []int32, values []interface{},
+) ([]interface{}, error) {
+	var rows []interface{}
+	// list is the list of the current row, unless it is NULL.
+	var list []interface{}
+	var null bool
+	finishRow := func() {
+		if null {
+			rows = append(rows, nil)
+		} else {
+			rows = append(rows, list)
+		}
+	}
+	for i, def := range defLevels {
+		if repLevels[i] == 0 {
+			if i > 0 {
+				finishRow()
+			}
+			list, null = []interface{}{}, def < col.listDef
+			if def <= col.listDef {
+				continue
+			}
+		} else if def <= col.listDef {
+			return nil, errors.New(`parquet: invalid repetition levels`)
+		}
+		if def == col.maxDef {
+			list, values = append(list, values[0]), values[1:]
+		} else {
+			list = append(list, nil)
+		}
+	}
+	if len(defLevels) > 0 {
+		finishRow()
+	}
+	return rows, nil
+}
+
+func decompress(codec Compression, data []byte, uncompressedSize int32) ([]byte, error) {
+	if uncompressedSize < 0 {
+		return nil, errors.New(`parquet: invalid page size`)
+	}
+	switch codec {
+	case CompressionNone:
+		return data, nil
+	case CompressionSnappy:
+		return snappy.Decode(make([]byte, uncompressedSize), data)
+	case CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		out := make([]byte, uncompressedSize)
+		if _, err := io.ReadFull(zr, out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	default:
+		return nil, errors.Errorf(`parquet: unsupported compression codec %d`, codec)
+	}
+}
+
+// readLevelsV1 reads the levels at the start of a v1 data page, which unlike
+// those of v2 pages are prefixed with their length, and returns them and the
+// rest of the page.
+func readLevelsV1(data []byte, maxLevel int32, encoding int32, n int) ([]int32, []byte, error) {
+	if maxLevel == 0 {
+		return make([]int32, n), data, nil
+	}
+	if encoding != encodingRLE {
+		return nil, nil, errors.Errorf(`parquet: unsupported level encoding %d`, encoding)
+	}
+	if len(data) < 4 {
+		return nil, nil, errors.New(`parquet: truncated page`)
+	}
+	size := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+	if uint64(size) > uint64(len(data)) {
+		return nil, nil, errors.New(`parquet: truncated page`)
+	}
+	levels, err := decodeLevels(data[:size], maxLevel, n)
+	return levels, data[size:], err
+}
+
+func decodeLevels(data []byte, maxLevel int32, n int) ([]int32, error) {
+	if maxLevel == 0 {
+		return make([]int32, n), nil
+	}
+	levels, err := decodeHybrid(data, bits.Len32(uint32(maxLevel)), n)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range levels {
+		if l < 0 || l > maxLevel {
+			return nil, errors.Errorf(`parquet: invalid level %d`, l)
+		}
+	}
+	return levels, nil
+}
+
+// decodeHybrid decodes n values encoded with the RLE/bit-packing hybrid
+// encoding, which is used for levels, dictionary indices and booleans.
+func decodeHybrid(data []byte, bitWidth int, n int) ([]int32, error) {
+	if bitWidth > 32 {
+		return nil, errors.Errorf(`parquet: invalid bit width %d`, bitWidth)
+	}
+	byteWidth := (bitWidth + 7) / 8
+	mask := uint64(1)<<uint(bitWidth) - 1
+	out := make([]int32, 0, n)
+	for len(out) < n {
+		header, k := binary.Uvarint(data)
+		if k <= 0 {
+			return nil, errors.New(`parquet: truncated RLE data`)
+		}
+		data = data[k:]
+		if header&1 == 0 {
+			// A run of a single value.
+			if len(data) < byteWidth {
+				return nil, errors.New(`parquet: truncated RLE data`)
+			}
+			var v uint64
+			for i := 0; i < byteWidth; i++ {
+				v |= uint64(data[i]) << (8 * uint(i))
+			}
+			data = data[byteWidth:]
+			for count := header >> 1; count > 0 && len(out) < n; count-- {
+				out = append(out, int32(v))
+			}
+			continue
+		}
+		// Groups of 8 bit-packed values, least significant bit first. Writers
+		// are supposed to pad the last group but not all of them do.
+		size := int(header>>1) * bitWidth
+		if size > len(data) {
+			size = len(data)
+		}
+		packed := data[:size]
+		data = data[size:]
+		count := int(header>>1) * 8
+		if bitWidth > 0 && size*8/bitWidth < count {
+			count = size * 8 / bitWidth
+		}
+		for i := 0; i < count && len(out) < n; i++ {
+			pos := uint(i * bitWidth)
+			var w uint64
+			for j := uint(0); j < 5 && int(pos/8+j) < len(packed); j++ {
+				w |= uint64(packed[pos/8+j]) << (8 * j)
+			}
+			out = append(out, int32(w>>(pos%8)&mask))
+		}
+	}
+	return out, nil
+}
+
+// decodeValues decodes and converts the n non-NULL values of a data page.
+func decodeValues(
+	col *readColumn, encoding int32, data []byte, n int, dict []interface{},
+) ([]interface{}, error) {
+	switch encoding {
+	case encodingPlain:
+		values, err := decodePlain(&col.leaf, data, n)
+		if err != nil {
+			return nil, err
+		}
+		for i := range values {
+			if values[i], err = col.convert(values[i]); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case encodingPlainDictionary, encodingRLEDictionary:
+		if len(data) == 0 {
+			if n == 0 {
+				return nil, nil
+			}
+			return nil, errors.New(`parquet: truncated page`)
+		}
+		indexes, err := decodeHybrid(data[1:], int(data[0]), n)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i, idx := range indexes {
+			if idx < 0 || int(idx) >= len(dict) {
+				return nil, errors.Errorf(`parquet: invalid dictionary index %d`, idx)
+			}
+			values[i] = dict[idx]
+		}
+		return values, nil
+	case encodingRLE:
+		if col.leaf.typ != physicalBoolean || len(data) < 4 {
+			break
+		}
+		bools, err := decodeHybrid(data[4:], 1, n)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i, b := range bools {
+			values[i] = b == 1
+		}
+		return values, nil
+	}
+	return nil, errors.Errorf(`parquet: unsupported encoding %d`, encoding)
+}
+
+var errTruncatedValues = errors.New(`parquet: truncated values`)
+
+// decodePlain decodes n PLAIN encoded physical values.
+func decodePlain(e *schemaElement, data []byte, n int) ([]interface{}, error) {
+	values := make([]interface{}, n)
+	fixed := func(size int) ([]byte, error) {
+		if len(data) < size {
+			return nil, errTruncatedValues
+		}
+		b := data[:size]
+		data = data[size:]
+		return b, nil
+	}
+	for i := range values {
+		switch e.typ {
+		case physicalBoolean:
+			if i/8 >= len(data) {
+				return nil, errTruncatedValues
+			}
+			values[i] = data[i/8]>>uint(i%8)&1 == 1
+		case physicalInt32:
+			b, err := fixed(4)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = int32(binary.LittleEndian.Uint32(b))
+		case physicalInt64:
+			b, err := fixed(8)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = int64(binary.LittleEndian.Uint64(b))
+		case physicalInt96:
+			b, err := fixed(12)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = b
+		case physicalFloat:
+			b, err := fixed(4)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = math.Float32frombits(binary.LittleEndian.Uint32(b))
+		case physicalDouble:
+			b, err := fixed(8)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		case physicalByteArray:
+			b, err := fixed(4)
+			if err != nil {
+				return nil, err
+			}
+			if b, err = fixed(int(binary.LittleEndian.Uint32(b))); err != nil {
+				return nil, err
+			}
+			values[i] = b
+		case physicalFixedLenByteArray:
+			b, err := fixed(int(e.typeLength))
+			if err != nil {
+				return nil, err
+			}
+			values[i] = b
+		default:
+			return nil, errors.Errorf(`parquet: unknown physical type %d`, e.typ)
+		}
+	}
+	return values, nil
+}
+
+// julianDayOfUnixEpoch is the Julian day number of 1970-01-01, which INT96
+// timestamps count days from.
+const julianDayOfUnixEpoch = 2440588
+
+// converterFor returns the function that converts the physical values of a
+// leaf to the values returned by Reader.Next.
+func converterFor(e *schemaElement) convertFn {
+	identity := func(v interface{}) (interface{}, error) { return v, nil }
+
+	unit := e.unit
+	switch e.converted {
+	case convertedTimeMillis, convertedTimestampMillis:
+		unit = timeUnitMillis
+	case convertedTimeMicros, convertedTimestampMicros:
+		unit = timeUnitMicros
+	}
+	// toMicros converts a time or timestamp to microseconds.
+	toMicros := func(v interface{}) int64 {
+		switch v := v.(type) {
+		case int32:
+			return int64(v) * 1000
+		case int64:
+			switch unit {
+			case timeUnitMillis:
+				return v * 1000
+			case timeUnitNanos:
+				return v / 1000
+			}
+			return v
+		}
+		return 0
+	}
+
+	switch {
+	case e.typ == physicalInt96:
+		return func(v interface{}) (interface{}, error) {
+			b := v.([]byte)
+			nanos := int64(binary.LittleEndian.Uint64(b[:8]))
+			days := int64(binary.LittleEndian.Uint32(b[8:])) - julianDayOfUnixEpoch
+			return time.Unix(days*24*60*60, nanos).UTC(), nil
+		}
+
+	case e.converted == convertedUTF8 || e.converted == convertedEnum ||
+		e.converted == convertedJSON || e.logical == logicalString ||
+		e.logical == logicalEnum || e.logical == logicalJSON:
+		if e.typ != physicalByteArray && e.typ != physicalFixedLenByteArray {
+			return identity
+		}
+		return func(v interface{}) (interface{}, error) { return string(v.([]byte)), nil }
+
+	case e.logical == logicalUUID:
+		if e.typ != physicalFixedLenByteArray || e.typeLength != uuid.Size {
+			return identity
+		}
+		return func(v interface{}) (interface{}, error) {
+			u, err := uuid.FromBytes(v.([]byte))
+			return u.String(), err
+		}
+
+	case e.converted == convertedDecimal || e.logical == logicalDecimal:
+		scale := e.scale
+		return func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case int32:
+				return apd.New(int64(v), -scale), nil
+			case int64:
+				return apd.New(v, -scale), nil
+			case []byte:
+				return fromTwosComplement(v, scale), nil
+			}
+			return v, nil
+		}
+
+	case e.converted == convertedDate || e.logical == logicalDate:
+		if e.typ != physicalInt32 {
+			return identity
+		}
+		return func(v interface{}) (interface{}, error) {
+			return time.Unix(int64(v.(int32))*24*60*60, 0).UTC(), nil
+		}
+
+	case e.converted == convertedTimeMillis || e.converted == convertedTimeMicros ||
+		e.logical == logicalTime:
+		if e.typ != physicalInt32 && e.typ != physicalInt64 {
+			return identity
+		}
+		return func(v interface{}) (interface{}, error) {
+			return timeofday.FromInt(toMicros(v)), nil
+		}
+
+	case e.converted == convertedTimestampMillis || e.converted == convertedTimestampMicros ||
+		e.logical == logicalTimestamp:
+		if e.typ != physicalInt64 {
+			return identity
+		}
+		return func(v interface{}) (interface{}, error) {
+			i := v.(int64)
+			switch unit {
+			case timeUnitMillis:
+				return time.Unix(i/1e3, i%1e3*1e6).UTC(), nil
+			case timeUnitNanos:
+				return time.Unix(i/1e9, i%1e9).UTC(), nil
+			}
+			return time.Unix(i/1e6, i%1e6*1e3).UTC(), nil
+		}
+
+	case e.converted == convertedUint8 || e.converted == convertedUint16 ||
+		e.converted == convertedUint32 || e.converted == convertedUint64 ||
+		(e.logical == logicalInteger && e.unsigned):
+		return func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case int32:
+				return int64(uint32(v)), nil
+			case int64:
+				return uint64(v), nil
+			}
+			return v, nil
+		}
+	}
+	return identity
+}
+
+// fromTwosComplement returns the decimal with the given scale whose unscaled
+// value has the given big-endian two's complement encoding. It is the inverse
+// of twosComplement.
+func fromTwosComplement(b []byte, scale int32) *apd.Decimal {
+	d := &apd.Decimal{Exponent: -scale}
+	d.Coeff.SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		// The magnitude of a negative value x is 2^(8*len(b)) - x.
+		var m big.Int
+		m.Lsh(big.NewInt(1), uint(len(b)*8))
+		d.Coeff.Sub(&m, &d.Coeff)
+		d.Negative = true
+	}
+	return d
+}
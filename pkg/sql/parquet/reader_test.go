@@ -0,0 +1,323 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll reads every row of a Parquet file. Decimals are returned as strings
+// to make them easy to compare.
+func readAll(t *testing.T, data []byte) ([]string, [][]interface{}) {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	var rows [][]interface{}
+	for {
+		row, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		copied := make([]interface{}, len(row))
+		for i, v := range row {
+			if d, ok := v.(*apd.Decimal); ok {
+				v = d.String()
+			}
+			copied[i] = v
+		}
+		rows = append(rows, copied)
+	}
+	require.Equal(t, r.NumRows(), int64(len(rows)))
+	return r.Columns(), rows
+}
+
+func TestReaderRoundTrip(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := time.Date(2019, 10, 2, 3, 4, 5, 123456000, time.UTC)
+	date, err := tree.NewDDateFromTime(ts)
+	require.NoError(t, err)
+	dec, err := tree.ParseDDecimal(`-12.5`)
+	require.NoError(t, err)
+	j, err := json.ParseJSON(`{"a": [1, "b"]}`)
+	require.NoError(t, err)
+	intArray := tree.NewDArray(types.Int)
+	require.NoError(t, intArray.Append(tree.NewDInt(1)))
+	require.NoError(t, intArray.Append(tree.DNull))
+	require.NoError(t, intArray.Append(tree.NewDInt(3)))
+	emptyArray := tree.NewDArray(types.Int)
+	interval, err := tree.ParseDInterval(`1h`)
+	require.NoError(t, err)
+
+	names := []string{
+		`b`, `i2`, `i`, `f4`, `f`, `dec`, `dec_unconstrained`, `d`, `tm`, `ts`, `bytes`, `s`,
+		`j`, `interval`, `ints`,
+	}
+	typs := []types.T{
+		*types.Bool, *types.Int2, *types.Int, *types.Float4, *types.Float,
+		*types.MakeDecimal(20, 2), *types.Decimal, *types.Date, *types.Time, *types.Timestamp,
+		*types.Bytes, *types.String, *types.Jsonb, *types.Interval, *types.MakeArray(types.Int),
+	}
+	rows := []tree.Datums{
+		{
+			tree.DBoolTrue, tree.NewDInt(-2), tree.NewDInt(math.MaxInt64), tree.NewDFloat(1.5),
+			tree.NewDFloat(-2.25), dec, dec, date, tree.MakeDTime(timeofday.New(3, 4, 5, 6)),
+			tree.MakeDTimestamp(ts, time.Microsecond), tree.NewDBytes("\x00"), tree.NewDString(`☃`),
+			tree.NewDJSON(j), interval, intArray,
+		},
+		{
+			tree.DBoolFalse, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull,
+			tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull,
+			emptyArray,
+		},
+		{
+			tree.DNull, tree.NewDInt(2), tree.NewDInt(3), tree.NewDFloat(0), tree.NewDFloat(0),
+			tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.NewDBytes(``),
+			tree.NewDString(``), tree.DNull, tree.DNull, tree.DNull,
+		},
+	}
+	midnight := time.Date(2019, 10, 2, 0, 0, 0, 0, time.UTC)
+	expected := [][]interface{}{
+		{
+			true, int32(-2), int64(math.MaxInt64), float32(1.5), float64(-2.25), `-12.50`, `-12.5`,
+			midnight, timeofday.New(3, 4, 5, 6), ts, []byte("\x00"), `☃`, `{"a": [1, "b"]}`,
+			`01:00:00`, []interface{}{int64(1), nil, int64(3)},
+		},
+		{
+			false, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, []interface{}{},
+		},
+		{
+			nil, int32(2), int64(3), float32(0), float64(0), nil, nil, nil, nil, nil, []byte{},
+			``, nil, nil, nil,
+		},
+	}
+
+	for _, compression := range []Compression{CompressionNone, CompressionSnappy, CompressionGzip} {
+		for _, rowGroupSize := range []int{1, 2, 10} {
+			t.Run(fmt.Sprintf(`%s/%d`, compression, rowGroupSize), func(t *testing.T) {
+				var buf bytes.Buffer
+				w, err := NewWriter(&buf, names, typs, WriterOptions{
+					RowGroupSize: rowGroupSize, Compression: compression,
+				})
+				require.NoError(t, err)
+				for _, row := range rows {
+					require.NoError(t, w.AddRow(row))
+				}
+				require.NoError(t, w.Close())
+
+				cols, actual := readAll(t, buf.Bytes())
+				require.Equal(t, names, cols)
+				require.Equal(t, expected, actual)
+			})
+		}
+	}
+}
+
+// TestReaderDictionary reads a file with a dictionary encoded v2 data page,
+// which the Writer never produces.
+func TestReaderDictionary(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var chunk bytes.Buffer
+	// The dictionary: "a", "b".
+	dict := []byte{1, 0, 0, 0, 'a', 1, 0, 0, 0, 'b'}
+	var w thriftWriter
+	w.structBegin()
+	w.i32Field(1, pageTypeDictionary)
+	w.i32Field(2, int32(len(dict)))
+	w.i32Field(3, int32(len(dict)))
+	w.structField(7)
+	w.i32Field(1, 2 /* num_values */)
+	w.i32Field(2, encodingPlain)
+	w.structEnd()
+	w.structEnd()
+	chunk.Write(w.buf.Bytes())
+	chunk.Write(dict)
+
+	// The rows are "b", NULL, "a", "b". The definition levels are 1, 0, 1, 1 and
+	// the dictionary indexes 1, 0, 1, both as a single bit-packed group.
+	defLevels := []byte{3, 0x0d}
+	values := []byte{1 /* bit width */, 3, 0x05}
+	w = thriftWriter{}
+	w.structBegin()
+	w.i32Field(1, pageTypeDataV2)
+	w.i32Field(2, int32(len(defLevels)+len(values)))
+	w.i32Field(3, int32(len(defLevels)+len(values)))
+	w.structField(8)
+	w.i32Field(1, 4 /* num_values */)
+	w.i32Field(2, 1 /* num_nulls */)
+	w.i32Field(3, 4 /* num_rows */)
+	w.i32Field(4, encodingRLEDictionary)
+	w.i32Field(5, int32(len(defLevels)))
+	w.i32Field(6, 0 /* repetition_levels_byte_length */)
+	w.fieldHeader(7, thriftFalse /* is_compressed */)
+	w.structEnd()
+	w.structEnd()
+	chunk.Write(w.buf.Bytes())
+	chunk.Write(defLevels)
+	chunk.Write(values)
+
+	meta := fileMetaData{
+		schema: []schemaElement{
+			{name: `schema`, root: true, numChildren: 1, converted: convertedNone},
+			{
+				name: `s`, isLeaf: true, typ: physicalByteArray, repetition: repetitionOptional,
+				converted: convertedUTF8,
+			},
+		},
+		numRows: 4,
+		rowGroups: []rowGroupMetaData{{
+			columns: []columnMetaData{{
+				typ:                   physicalByteArray,
+				path:                  []string{`s`},
+				numValues:             4,
+				totalUncompressedSize: int64(chunk.Len()),
+				totalCompressedSize:   int64(chunk.Len()),
+				dataPageOffset:        int64(len(magic)),
+			}},
+			totalByteSize: int64(chunk.Len()),
+			numRows:       4,
+		}},
+	}
+	w = thriftWriter{}
+	meta.write(&w)
+
+	var file bytes.Buffer
+	file.WriteString(magic)
+	file.Write(chunk.Bytes())
+	file.Write(w.buf.Bytes())
+	var footerLen [4]byte
+	binary.LittleEndian.PutUint32(footerLen[:], uint32(w.buf.Len()))
+	file.Write(footerLen[:])
+	file.WriteString(magic)
+
+	cols, rows := readAll(t, file.Bytes())
+	require.Equal(t, []string{`s`}, cols)
+	require.Equal(t, [][]interface{}{{`b`}, {nil}, {`a`}, {`b`}}, rows)
+}
+
+func TestDecodeHybrid(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// The bit-packed example from the Parquet encodings spec: 0 to 7 with a bit
+	// width of 3, followed by a run of five 4s.
+	data := []byte{3, 0x88, 0xc6, 0xfa, 10, 4}
+	values, err := decodeHybrid(data, 3, 13)
+	require.NoError(t, err)
+	require.Equal(t, []int32{0, 1, 2, 3, 4, 5, 6, 7, 4, 4, 4, 4, 4}, values)
+
+	_, err = decodeHybrid(data, 3, 14)
+	require.EqualError(t, err, `parquet: truncated RLE data`)
+}
+
+func TestConverters(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := time.Date(2019, 10, 2, 3, 4, 5, 123456789, time.UTC)
+	int96 := make([]byte, 12)
+	midnight := time.Date(2019, 10, 2, 0, 0, 0, 0, time.UTC)
+	binary.LittleEndian.PutUint64(int96, uint64(ts.Sub(midnight)))
+	binary.LittleEndian.PutUint32(int96[8:], uint32(midnight.Unix()/86400+julianDayOfUnixEpoch))
+
+	const none = convertedNone
+	for i, tc := range []struct {
+		e        schemaElement
+		in       interface{}
+		expected interface{}
+	}{
+		{schemaElement{typ: physicalInt32, converted: none}, int32(-1), int32(-1)},
+		{schemaElement{typ: physicalInt32, converted: convertedUint32},
+			int32(-1), int64(math.MaxUint32)},
+		{schemaElement{typ: physicalInt64, logical: logicalInteger, unsigned: true, converted: none},
+			int64(-1), uint64(math.MaxUint64)},
+		{schemaElement{typ: physicalByteArray, logical: logicalString, converted: none},
+			[]byte(`x`), `x`},
+		{schemaElement{typ: physicalByteArray, converted: convertedEnum}, []byte(`x`), `x`},
+		{schemaElement{
+			typ: physicalFixedLenByteArray, typeLength: 16, logical: logicalUUID, converted: none,
+		},
+			[]byte("\x12\x34\x56\x78\x9a\xbc\xde\xf0\x12\x34\x56\x78\x9a\xbc\xde\xf0"),
+			`12345678-9abc-def0-1234-56789abcdef0`},
+		{schemaElement{typ: physicalInt32, converted: convertedDecimal, scale: 2},
+			int32(-1234), `-12.34`},
+		{schemaElement{typ: physicalInt64, logical: logicalDecimal, scale: 1, converted: none},
+			int64(5), `0.5`},
+		{schemaElement{typ: physicalFixedLenByteArray, converted: convertedDecimal, scale: 0},
+			[]byte{0xff, 0x00}, `-256`},
+		{schemaElement{typ: physicalInt32, converted: convertedDate},
+			int32(-1), time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{schemaElement{typ: physicalInt32, converted: convertedTimeMillis},
+			int32(1500), timeofday.New(0, 0, 1, 500000)},
+		{schemaElement{typ: physicalInt64, logical: logicalTime, unit: timeUnitNanos, converted: none},
+			int64(1500), timeofday.New(0, 0, 0, 1)},
+		{schemaElement{typ: physicalInt64, converted: convertedTimestampMillis},
+			ts.UnixNano() / 1e6, ts.Truncate(time.Millisecond)},
+		{schemaElement{
+			typ: physicalInt64, logical: logicalTimestamp, unit: timeUnitNanos, converted: none,
+		},
+			ts.UnixNano(), ts},
+		{schemaElement{
+			typ: physicalInt64, logical: logicalTimestamp, unit: timeUnitMicros, converted: none,
+		},
+			int64(-1), time.Date(1969, 12, 31, 23, 59, 59, 999999000, time.UTC)},
+		{schemaElement{typ: physicalInt96, converted: none}, int96, ts},
+	} {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			actual, err := converterFor(&tc.e)(tc.in)
+			require.NoError(t, err)
+			if d, ok := actual.(*apd.Decimal); ok {
+				actual = d.String()
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []string{`a`}, []types.T{*types.Int}, WriterOptions{})
+	require.NoError(t, err)
+	require.NoError(t, w.AddRow(tree.Datums{tree.NewDInt(1)}))
+	require.NoError(t, w.Close())
+	data := buf.Bytes()
+
+	for _, tc := range []struct {
+		data []byte
+		err  string
+	}{
+		{data[:4], `parquet: file too short`},
+		{append([]byte(`PAR2`), data[4:]...), `parquet: not a parquet file`},
+		{append(append([]byte{}, data[:len(data)-8]...), 0xff, 0xff, 0, 0, 'P', 'A', 'R', '1'),
+			`parquet: invalid footer length`},
+	} {
+		_, err := NewReader(bytes.NewReader(tc.data), int64(len(tc.data)))
+		require.EqualError(t, err, tc.err)
+	}
+}
@@ -0,0 +1,344 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/pkg/errors"
+)
+
+// SQL types are mapped to Parquet types as follows:
+//
+//   BOOL                   BOOLEAN
+//   INT2, INT4             INT32 (INT_16, INT_32)
+//   INT8                   INT64 (INT_64)
+//   FLOAT4                 FLOAT
+//   FLOAT8                 DOUBLE
+//   DECIMAL(p,s)           BYTE_ARRAY (DECIMAL(p,s))
+//   DATE                   INT32 (DATE)
+//   TIME                   INT64 (TIME_MICROS)
+//   TIMESTAMP, TIMESTAMPTZ INT64 (TIMESTAMP_MICROS)
+//   BYTES                  BYTE_ARRAY
+//   JSONB                  BYTE_ARRAY (JSON)
+//   T[]                    LIST of the mapping of T
+//
+// Everything else, including a DECIMAL with no precision, is written as a
+// BYTE_ARRAY (UTF8) containing the same text that EXPORT would write to a CSV.
+
+// encodeFn appends the PLAIN encoding of a non-NULL datum to a column.
+type encodeFn func(c *column, d tree.Datum) error
+
+// column buffers the values of one leaf column for the row group being
+// written.
+type column struct {
+	path   []string
+	typ    physicalType
+	list   bool
+	maxDef int32
+	maxRep int32
+	encode encodeFn
+
+	defLevels []int32
+	repLevels []int32
+	values    bytes.Buffer
+	// numBools is the number of values in a BOOLEAN column, which are bit
+	// packed.
+	numBools int
+}
+
+// makeColumn returns the schema elements and leaf column for a SQL column.
+func makeColumn(name string, typ *types.T) ([]schemaElement, *column) {
+	if typ.Family() != types.ArrayFamily {
+		leaf, encode := leafForType(typ)
+		leaf.name = name
+		col := &column{path: []string{name}, typ: leaf.typ, maxDef: 1, encode: encode}
+		return []schemaElement{leaf}, col
+	}
+
+	leaf, encode := leafForType(typ.ArrayContents())
+	leaf.name = `element`
+	elements := []schemaElement{
+		{name: name, repetition: repetitionOptional, numChildren: 1, converted: convertedList},
+		{name: `list`, repetition: repetitionRepeated, numChildren: 1, converted: convertedNone},
+		leaf,
+	}
+	col := &column{
+		path:   []string{name, `list`, `element`},
+		typ:    leaf.typ,
+		list:   true,
+		maxDef: 3,
+		maxRep: 1,
+		encode: encode,
+	}
+	return elements, col
+}
+
+// leafForType returns the leaf schema element (minus its name) and the value
+// encoder for a non-array SQL type.
+func leafForType(typ *types.T) (schemaElement, encodeFn) {
+	leaf := schemaElement{isLeaf: true, repetition: repetitionOptional, converted: convertedNone}
+	switch typ.Family() {
+	case types.BoolFamily:
+		leaf.typ = physicalBoolean
+		return leaf, func(c *column, d tree.Datum) error {
+			c.appendBool(bool(*d.(*tree.DBool)))
+			return nil
+		}
+	case types.IntFamily:
+		switch typ.Width() {
+		case 16, 32:
+			leaf.typ = physicalInt32
+			leaf.converted = convertedInt32
+			if typ.Width() == 16 {
+				leaf.converted = convertedInt16
+			}
+			return leaf, func(c *column, d tree.Datum) error {
+				c.appendInt32(int32(*d.(*tree.DInt)))
+				return nil
+			}
+		default:
+			leaf.typ = physicalInt64
+			leaf.converted = convertedInt64
+			return leaf, func(c *column, d tree.Datum) error {
+				c.appendInt64(int64(*d.(*tree.DInt)))
+				return nil
+			}
+		}
+	case types.FloatFamily:
+		if typ.Width() == 32 {
+			leaf.typ = physicalFloat
+			return leaf, func(c *column, d tree.Datum) error {
+				c.appendInt32(int32(math.Float32bits(float32(*d.(*tree.DFloat)))))
+				return nil
+			}
+		}
+		leaf.typ = physicalDouble
+		return leaf, func(c *column, d tree.Datum) error {
+			c.appendInt64(int64(math.Float64bits(float64(*d.(*tree.DFloat)))))
+			return nil
+		}
+	case types.DecimalFamily:
+		if typ.Precision() == 0 {
+			break
+		}
+		leaf.typ = physicalByteArray
+		leaf.converted = convertedDecimal
+		leaf.precision = typ.Precision()
+		leaf.scale = typ.Scale()
+		ctx := apd.BaseContext.WithPrecision(uint32(typ.Precision()))
+		return leaf, func(c *column, d tree.Datum) error {
+			dec := &d.(*tree.DDecimal).Decimal
+			if dec.Form != apd.Finite {
+				return errors.Errorf(`cannot write %s decimal`, dec)
+			}
+			var scaled apd.Decimal
+			if _, err := ctx.Quantize(&scaled, dec, -typ.Scale()); err != nil {
+				return errors.Wrapf(err, `cannot write %s as DECIMAL(%d,%d)`,
+					dec, typ.Precision(), typ.Scale())
+			}
+			c.appendByteArray(twosComplement(&scaled.Coeff, scaled.Negative))
+			return nil
+		}
+	case types.DateFamily:
+		leaf.typ = physicalInt32
+		leaf.converted = convertedDate
+		return leaf, func(c *column, d tree.Datum) error {
+			date := d.(*tree.DDate).Date
+			if !date.IsFinite() {
+				return errors.Errorf(`cannot write infinite date`)
+			}
+			c.appendInt32(int32(date.UnixEpochDays()))
+			return nil
+		}
+	case types.TimeFamily:
+		leaf.typ = physicalInt64
+		leaf.converted = convertedTimeMicros
+		return leaf, func(c *column, d tree.Datum) error {
+			c.appendInt64(int64(*d.(*tree.DTime)))
+			return nil
+		}
+	case types.TimestampFamily:
+		leaf.typ = physicalInt64
+		leaf.converted = convertedTimestampMicros
+		return leaf, func(c *column, d tree.Datum) error {
+			c.appendInt64(unixMicros(d.(*tree.DTimestamp).Time))
+			return nil
+		}
+	case types.TimestampTZFamily:
+		leaf.typ = physicalInt64
+		leaf.converted = convertedTimestampMicros
+		return leaf, func(c *column, d tree.Datum) error {
+			c.appendInt64(unixMicros(d.(*tree.DTimestampTZ).Time))
+			return nil
+		}
+	case types.BytesFamily:
+		leaf.typ = physicalByteArray
+		return leaf, func(c *column, d tree.Datum) error {
+			c.appendByteArray([]byte(*d.(*tree.DBytes)))
+			return nil
+		}
+	case types.StringFamily:
+		leaf.typ = physicalByteArray
+		leaf.converted = convertedUTF8
+		return leaf, func(c *column, d tree.Datum) error {
+			c.appendByteArray([]byte(*d.(*tree.DString)))
+			return nil
+		}
+	case types.CollatedStringFamily:
+		leaf.typ = physicalByteArray
+		leaf.converted = convertedUTF8
+		return leaf, func(c *column, d tree.Datum) error {
+			c.appendByteArray([]byte(d.(*tree.DCollatedString).Contents))
+			return nil
+		}
+	case types.JsonFamily:
+		leaf.typ = physicalByteArray
+		leaf.converted = convertedJSON
+		return leaf, func(c *column, d tree.Datum) error {
+			c.appendByteArray([]byte(d.(*tree.DJSON).JSON.String()))
+			return nil
+		}
+	}
+
+	leaf.typ = physicalByteArray
+	leaf.converted = convertedUTF8
+	return leaf, func(c *column, d tree.Datum) error {
+		c.appendByteArray([]byte(tree.AsStringWithFlags(d, tree.FmtExport)))
+		return nil
+	}
+}
+
+// add appends a datum for the next row.
+func (c *column) add(d tree.Datum) error {
+	d = tree.UnwrapDatum(nil /* evalCtx */, d)
+	if !c.list {
+		if d == tree.DNull {
+			c.addLevels(0 /* rep */, 0 /* def */)
+			return nil
+		}
+		c.addLevels(0 /* rep */, 1 /* def */)
+		return c.encode(c, d)
+	}
+
+	// For a LIST, a definition level of 0 means the array is NULL, 1 that it is
+	// empty, 2 that an element is NULL, and 3 that an element is present. A
+	// repetition level of 0 starts a new row, 1 continues the current array.
+	if d == tree.DNull {
+		c.addLevels(0 /* rep */, 0 /* def */)
+		return nil
+	}
+	arr, ok := d.(*tree.DArray)
+	if !ok {
+		return errors.Errorf(`expected array got %T`, d)
+	}
+	if arr.Len() == 0 {
+		c.addLevels(0 /* rep */, 1 /* def */)
+		return nil
+	}
+	for i, elem := range arr.Array {
+		var rep int32
+		if i > 0 {
+			rep = 1
+		}
+		if elem == tree.DNull {
+			c.addLevels(rep, 2 /* def */)
+			continue
+		}
+		c.addLevels(rep, 3 /* def */)
+		if err := c.encode(c, tree.UnwrapDatum(nil /* evalCtx */, elem)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *column) addLevels(rep, def int32) {
+	if c.maxRep > 0 {
+		c.repLevels = append(c.repLevels, rep)
+	}
+	c.defLevels = append(c.defLevels, def)
+}
+
+func (c *column) reset() {
+	c.defLevels = c.defLevels[:0]
+	c.repLevels = c.repLevels[:0]
+	c.values.Reset()
+	c.numBools = 0
+}
+
+func (c *column) appendBool(b bool) {
+	if c.numBools%8 == 0 {
+		c.values.WriteByte(0)
+	}
+	if b {
+		buf := c.values.Bytes()
+		buf[len(buf)-1] |= 1 << uint(c.numBools%8)
+	}
+	c.numBools++
+}
+
+func (c *column) appendInt32(v int32) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(v))
+	c.values.Write(buf[:])
+}
+
+func (c *column) appendInt64(v int64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(v))
+	c.values.Write(buf[:])
+}
+
+func (c *column) appendByteArray(v []byte) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(len(v)))
+	c.values.Write(buf[:])
+	c.values.Write(v)
+}
+
+// unixMicros is like t.UnixNano()/1000 but doesn't overflow for times far from
+// the epoch.
+func unixMicros(t time.Time) int64 {
+	return t.Unix()*int64(time.Second/time.Microsecond) + int64(t.Nanosecond())/1000
+}
+
+// twosComplement returns the minimal big-endian two's complement encoding of
+// the integer with the given magnitude and sign, which is how Parquet stores
+// the unscaled value of a DECIMAL.
+func twosComplement(magnitude *big.Int, negative bool) []byte {
+	if !negative || magnitude.Sign() == 0 {
+		b := magnitude.Bytes()
+		if len(b) == 0 || b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+		return b
+	}
+	// -x is the bitwise complement of x-1.
+	var m big.Int
+	b := m.Sub(magnitude, big.NewInt(1)).Bytes()
+	for i := range b {
+		b[i] = ^b[i]
+	}
+	if len(b) == 0 || b[0]&0x80 == 0 {
+		b = append([]byte{0xff}, b...)
+	}
+	return b
+}
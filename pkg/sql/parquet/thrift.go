@@ -0,0 +1,773 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// Parquet file and page metadata is serialized with the Thrift compact
+// protocol. We only ever read and write a handful of structs, so instead of
+// pulling in a Thrift library and the generated code for all of parquet.thrift,
+// this file contains just enough of the protocol to handle the structs we need.
+// The field ids and enum values below come from parquet.thrift in the
+// apache/parquet-format repo.
+
+// Compact protocol type ids.
+const (
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftByte   = 3
+	thriftI16    = 4
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftDouble = 7
+	thriftBinary = 8
+	thriftList   = 9
+	thriftSet    = 10
+	thriftMap    = 11
+	thriftStruct = 12
+)
+
+// physicalType is the parquet.thrift Type enum.
+type physicalType int32
+
+const (
+	physicalBoolean           physicalType = 0
+	physicalInt32             physicalType = 1
+	physicalInt64             physicalType = 2
+	physicalInt96             physicalType = 3
+	physicalFloat             physicalType = 4
+	physicalDouble            physicalType = 5
+	physicalByteArray         physicalType = 6
+	physicalFixedLenByteArray physicalType = 7
+)
+
+// convertedType is the parquet.thrift ConvertedType enum. convertedNone is not
+// part of the enum; it means the field is omitted.
+type convertedType int32
+
+const (
+	convertedNone            convertedType = -1
+	convertedUTF8            convertedType = 0
+	convertedList            convertedType = 3
+	convertedEnum            convertedType = 4
+	convertedDecimal         convertedType = 5
+	convertedDate            convertedType = 6
+	convertedTimeMillis      convertedType = 7
+	convertedTimeMicros      convertedType = 8
+	convertedTimestampMillis convertedType = 9
+	convertedTimestampMicros convertedType = 10
+	convertedUint8           convertedType = 11
+	convertedUint16          convertedType = 12
+	convertedUint32          convertedType = 13
+	convertedUint64          convertedType = 14
+	convertedInt16           convertedType = 16
+	convertedInt32           convertedType = 17
+	convertedInt64           convertedType = 18
+	convertedJSON            convertedType = 19
+)
+
+// logicalKind is the field id of the set member of parquet.thrift's
+// LogicalType union, which newer writers use alongside (or, for nanosecond
+// times, instead of) ConvertedType. logicalNone means the field is omitted.
+type logicalKind int16
+
+const (
+	logicalNone      logicalKind = 0
+	logicalString    logicalKind = 1
+	logicalList      logicalKind = 3
+	logicalEnum      logicalKind = 4
+	logicalDecimal   logicalKind = 5
+	logicalDate      logicalKind = 6
+	logicalTime      logicalKind = 7
+	logicalTimestamp logicalKind = 8
+	logicalInteger   logicalKind = 10
+	logicalJSON      logicalKind = 12
+	logicalUUID      logicalKind = 14
+)
+
+// timeUnit is the field id of the set member of parquet.thrift's TimeUnit
+// union.
+type timeUnit int16
+
+const (
+	timeUnitMillis timeUnit = 1
+	timeUnitMicros timeUnit = 2
+	timeUnitNanos  timeUnit = 3
+)
+
+// repetitionType is the parquet.thrift FieldRepetitionType enum.
+type repetitionType int32
+
+const (
+	repetitionRequired repetitionType = 0
+	repetitionOptional repetitionType = 1
+	repetitionRepeated repetitionType = 2
+)
+
+// Values of the parquet.thrift Encoding and PageType enums.
+const (
+	encodingPlain           = 0
+	encodingPlainDictionary = 2
+	encodingRLE             = 3
+	encodingRLEDictionary   = 8
+
+	pageTypeData       = 0
+	pageTypeDictionary = 2
+	pageTypeDataV2     = 3
+)
+
+// thriftWriter serializes structs with the Thrift compact protocol.
+type thriftWriter struct {
+	buf bytes.Buffer
+	// lastFieldID is the id of the last field written in the current struct.
+	// Compact protocol field headers are delta encoded against it.
+	lastFieldID int16
+	stack       []int16
+	scratch     [binary.MaxVarintLen64]byte
+}
+
+func (w *thriftWriter) uvarint(v uint64) {
+	n := binary.PutUvarint(w.scratch[:], v)
+	w.buf.Write(w.scratch[:n])
+}
+
+func (w *thriftWriter) varint(v int64) {
+	n := binary.PutVarint(w.scratch[:], v)
+	w.buf.Write(w.scratch[:n])
+}
+
+func (w *thriftWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - w.lastFieldID; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(int64(id))
+	}
+	w.lastFieldID = id
+}
+
+func (w *thriftWriter) i32Field(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.varint(int64(v))
+}
+
+func (w *thriftWriter) i64Field(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.varint(v)
+}
+
+func (w *thriftWriter) stringField(id int16, v string) {
+	w.fieldHeader(id, thriftBinary)
+	w.uvarint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+func (w *thriftWriter) listField(id int16, elemType byte, n int) {
+	w.fieldHeader(id, thriftList)
+	if n < 15 {
+		w.buf.WriteByte(byte(n)<<4 | elemType)
+	} else {
+		w.buf.WriteByte(0xf0 | elemType)
+		w.uvarint(uint64(n))
+	}
+}
+
+func (w *thriftWriter) i32Elem(v int32) {
+	w.varint(int64(v))
+}
+
+func (w *thriftWriter) stringElem(v string) {
+	w.uvarint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+// structBegin starts a nested struct. The caller is responsible for writing
+// the field or list header that precedes it, if any.
+func (w *thriftWriter) structBegin() {
+	w.stack = append(w.stack, w.lastFieldID)
+	w.lastFieldID = 0
+}
+
+func (w *thriftWriter) structField(id int16) {
+	w.fieldHeader(id, thriftStruct)
+	w.structBegin()
+}
+
+func (w *thriftWriter) structEnd() {
+	w.buf.WriteByte(0)
+	w.lastFieldID = w.stack[len(w.stack)-1]
+	w.stack = w.stack[:len(w.stack)-1]
+}
+
+// schemaElement is parquet.thrift's SchemaElement.
+type schemaElement struct {
+	name string
+	typ  physicalType
+	// typeLength is the length of FIXED_LEN_BYTE_ARRAY values.
+	typeLength int32
+	// root is set for the first element, which is the schema itself and has no
+	// repetition.
+	root        bool
+	isLeaf      bool
+	repetition  repetitionType
+	numChildren int32
+	converted   convertedType
+	scale       int32
+	precision   int32
+
+	// The LogicalType of the element, which is only read, never written.
+	logical  logicalKind
+	unit     timeUnit
+	unsigned bool
+}
+
+func (e *schemaElement) write(w *thriftWriter) {
+	w.structBegin()
+	if e.isLeaf {
+		w.i32Field(1, int32(e.typ))
+	}
+	if !e.root {
+		w.i32Field(3, int32(e.repetition))
+	}
+	w.stringField(4, e.name)
+	if !e.isLeaf {
+		w.i32Field(5, e.numChildren)
+	}
+	if e.converted != convertedNone {
+		w.i32Field(6, int32(e.converted))
+		if e.converted == convertedDecimal {
+			w.i32Field(7, e.scale)
+			w.i32Field(8, e.precision)
+		}
+	}
+	w.structEnd()
+}
+
+// columnMetaData is parquet.thrift's ColumnMetaData.
+type columnMetaData struct {
+	typ                   physicalType
+	path                  []string
+	codec                 Compression
+	numValues             int64
+	totalUncompressedSize int64
+	totalCompressedSize   int64
+	dataPageOffset        int64
+	// dictionaryPageOffset is only read; the Writer doesn't use dictionaries.
+	dictionaryPageOffset int64
+	// filePath is set if the column chunk is stored in another file, which the
+	// Reader doesn't support. It is only read.
+	filePath string
+}
+
+func (m *columnMetaData) write(w *thriftWriter) {
+	w.i32Field(1, int32(m.typ))
+	w.listField(2, thriftI32, 2)
+	w.i32Elem(encodingPlain)
+	w.i32Elem(encodingRLE)
+	w.listField(3, thriftBinary, len(m.path))
+	for _, p := range m.path {
+		w.stringElem(p)
+	}
+	w.i32Field(4, int32(m.codec))
+	w.i64Field(5, m.numValues)
+	w.i64Field(6, m.totalUncompressedSize)
+	w.i64Field(7, m.totalCompressedSize)
+	w.i64Field(9, m.dataPageOffset)
+}
+
+// rowGroupMetaData is parquet.thrift's RowGroup.
+type rowGroupMetaData struct {
+	columns       []columnMetaData
+	totalByteSize int64
+	numRows       int64
+}
+
+func (g *rowGroupMetaData) write(w *thriftWriter) {
+	w.structBegin()
+	w.listField(1, thriftStruct, len(g.columns))
+	for i := range g.columns {
+		// ColumnChunk, which wraps the ColumnMetaData.
+		w.structBegin()
+		w.i64Field(2, g.columns[i].dataPageOffset)
+		w.structField(3)
+		g.columns[i].write(w)
+		w.structEnd()
+		w.structEnd()
+	}
+	w.i64Field(2, g.totalByteSize)
+	w.i64Field(3, g.numRows)
+	w.structEnd()
+}
+
+// fileMetaData is parquet.thrift's FileMetaData.
+type fileMetaData struct {
+	schema    []schemaElement
+	numRows   int64
+	rowGroups []rowGroupMetaData
+}
+
+const createdBy = `CockroachDB`
+
+func (m *fileMetaData) write(w *thriftWriter) {
+	w.structBegin()
+	w.i32Field(1, 1 /* version */)
+	w.listField(2, thriftStruct, len(m.schema))
+	for i := range m.schema {
+		m.schema[i].write(w)
+	}
+	w.i64Field(3, m.numRows)
+	w.listField(4, thriftStruct, len(m.rowGroups))
+	for i := range m.rowGroups {
+		m.rowGroups[i].write(w)
+	}
+	w.stringField(6, createdBy)
+	w.structEnd()
+}
+
+// dataPageHeader is parquet.thrift's PageHeader with a DataPageHeader.
+type dataPageHeader struct {
+	uncompressedSize int32
+	compressedSize   int32
+	numValues        int32
+}
+
+func (h *dataPageHeader) write(w *thriftWriter) {
+	w.structBegin()
+	w.i32Field(1, pageTypeData)
+	w.i32Field(2, h.uncompressedSize)
+	w.i32Field(3, h.compressedSize)
+	w.structField(5)
+	w.i32Field(1, h.numValues)
+	w.i32Field(2, encodingPlain)
+	w.i32Field(3, encodingRLE)
+	w.i32Field(4, encodingRLE)
+	w.structEnd()
+	w.structEnd()
+}
+
+// thriftDecoder deserializes structs written with the Thrift compact protocol.
+// The first error encountered is sticky: once it is set, every method returns
+// zero values.
+type thriftDecoder struct {
+	b     []byte
+	err   error
+	depth int
+}
+
+// maxThriftDepth bounds the nesting of structs and lists, so that a corrupt
+// file can't exhaust the stack.
+const maxThriftDepth = 64
+
+var errThriftTruncated = errors.New(`parquet: truncated thrift data`)
+
+func (d *thriftDecoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+	d.b = nil
+}
+
+func (d *thriftDecoder) byte() byte {
+	if len(d.b) == 0 {
+		d.fail(errThriftTruncated)
+		return 0
+	}
+	b := d.b[0]
+	d.b = d.b[1:]
+	return b
+}
+
+func (d *thriftDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.fail(errThriftTruncated)
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+// varint reads a zigzag encoded integer, which is how every integer type is
+// encoded by the compact protocol.
+func (d *thriftDecoder) varint() int64 {
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.fail(errThriftTruncated)
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *thriftDecoder) i32() int32 {
+	return int32(d.varint())
+}
+
+func (d *thriftDecoder) i64() int64 {
+	return d.varint()
+}
+
+func (d *thriftDecoder) binary() []byte {
+	n := d.uvarint()
+	if n > uint64(len(d.b)) {
+		d.fail(errThriftTruncated)
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *thriftDecoder) listHeader() (elemType byte, n int) {
+	header := d.byte()
+	size := uint64(header >> 4)
+	if size == 15 {
+		size = d.uvarint()
+	}
+	// Every element takes at least one byte.
+	if size > uint64(len(d.b)) {
+		d.fail(errThriftTruncated)
+		return 0, 0
+	}
+	return header & 0x0f, int(size)
+}
+
+// readStruct calls field for each field of a struct, which must either read
+// the value of the field or skip it. The value of a bool field is its type:
+// thriftTrue or thriftFalse.
+func (d *thriftDecoder) readStruct(field func(id int16, typ byte)) {
+	if d.depth++; d.depth > maxThriftDepth {
+		d.fail(errors.New(`parquet: thrift structs nested too deeply`))
+	}
+	var lastID int16
+	for d.err == nil {
+		header := d.byte()
+		if header == 0 {
+			break
+		}
+		id := lastID + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(d.varint())
+		}
+		field(id, header&0x0f)
+		lastID = id
+	}
+	d.depth--
+}
+
+// skip skips the value of a struct field of the given type.
+func (d *thriftDecoder) skip(typ byte) {
+	switch typ {
+	case thriftTrue, thriftFalse:
+	case thriftByte:
+		d.byte()
+	case thriftI16, thriftI32, thriftI64:
+		d.varint()
+	case thriftDouble:
+		if len(d.b) < 8 {
+			d.fail(errThriftTruncated)
+			return
+		}
+		d.b = d.b[8:]
+	case thriftBinary:
+		d.binary()
+	case thriftList, thriftSet:
+		elemType, n := d.listHeader()
+		for i := 0; i < n && d.err == nil; i++ {
+			d.skipElem(elemType)
+		}
+	case thriftMap:
+		n := d.uvarint()
+		if n == 0 {
+			return
+		}
+		types := d.byte()
+		for i := uint64(0); i < n && d.err == nil; i++ {
+			d.skipElem(types >> 4)
+			d.skipElem(types & 0x0f)
+		}
+	case thriftStruct:
+		d.readStruct(func(_ int16, typ byte) { d.skip(typ) })
+	default:
+		d.fail(errors.Errorf(`parquet: unknown thrift type %d`, typ))
+	}
+}
+
+// skipElem skips a list, set or map element of the given type. Unlike struct
+// fields, bool elements take a byte.
+func (d *thriftDecoder) skipElem(typ byte) {
+	if typ == thriftTrue || typ == thriftFalse {
+		d.byte()
+		return
+	}
+	d.skip(typ)
+}
+
+func (e *schemaElement) read(d *thriftDecoder) {
+	e.converted = convertedNone
+	d.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == thriftI32:
+			e.typ = physicalType(d.i32())
+			e.isLeaf = true
+		case id == 2 && typ == thriftI32:
+			e.typeLength = d.i32()
+		case id == 3 && typ == thriftI32:
+			e.repetition = repetitionType(d.i32())
+		case id == 4 && typ == thriftBinary:
+			e.name = string(d.binary())
+		case id == 5 && typ == thriftI32:
+			e.numChildren = d.i32()
+		case id == 6 && typ == thriftI32:
+			e.converted = convertedType(d.i32())
+		case id == 7 && typ == thriftI32:
+			e.scale = d.i32()
+		case id == 8 && typ == thriftI32:
+			e.precision = d.i32()
+		case id == 10 && typ == thriftStruct:
+			e.readLogicalType(d)
+		default:
+			d.skip(typ)
+		}
+	})
+}
+
+// readLogicalType reads parquet.thrift's LogicalType union.
+func (e *schemaElement) readLogicalType(d *thriftDecoder) {
+	d.readStruct(func(id int16, typ byte) {
+		if typ != thriftStruct {
+			d.skip(typ)
+			return
+		}
+		e.logical = logicalKind(id)
+		switch e.logical {
+		case logicalDecimal:
+			d.readStruct(func(id int16, typ byte) {
+				switch {
+				case id == 1 && typ == thriftI32:
+					e.scale = d.i32()
+				case id == 2 && typ == thriftI32:
+					e.precision = d.i32()
+				default:
+					d.skip(typ)
+				}
+			})
+		case logicalTime, logicalTimestamp:
+			d.readStruct(func(id int16, typ byte) {
+				if id != 2 || typ != thriftStruct {
+					d.skip(typ)
+					return
+				}
+				d.readStruct(func(id int16, typ byte) {
+					e.unit = timeUnit(id)
+					d.skip(typ)
+				})
+			})
+		case logicalInteger:
+			d.readStruct(func(id int16, typ byte) {
+				if id == 2 {
+					e.unsigned = typ == thriftFalse
+				}
+				d.skip(typ)
+			})
+		default:
+			d.skip(typ)
+		}
+	})
+}
+
+func (m *columnMetaData) read(d *thriftDecoder) {
+	d.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == thriftI32:
+			m.typ = physicalType(d.i32())
+		case id == 3 && typ == thriftList:
+			elemType, n := d.listHeader()
+			for i := 0; i < n && d.err == nil; i++ {
+				if elemType != thriftBinary {
+					d.skipElem(elemType)
+					continue
+				}
+				m.path = append(m.path, string(d.binary()))
+			}
+		case id == 4 && typ == thriftI32:
+			m.codec = Compression(d.i32())
+		case id == 5 && typ == thriftI64:
+			m.numValues = d.i64()
+		case id == 6 && typ == thriftI64:
+			m.totalUncompressedSize = d.i64()
+		case id == 7 && typ == thriftI64:
+			m.totalCompressedSize = d.i64()
+		case id == 9 && typ == thriftI64:
+			m.dataPageOffset = d.i64()
+		case id == 11 && typ == thriftI64:
+			m.dictionaryPageOffset = d.i64()
+		default:
+			d.skip(typ)
+		}
+	})
+}
+
+func (g *rowGroupMetaData) read(d *thriftDecoder) {
+	d.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == thriftList:
+			elemType, n := d.listHeader()
+			for i := 0; i < n && d.err == nil; i++ {
+				if elemType != thriftStruct {
+					d.skipElem(elemType)
+					continue
+				}
+				// ColumnChunk, which wraps the ColumnMetaData.
+				var m columnMetaData
+				d.readStruct(func(id int16, typ byte) {
+					switch {
+					case id == 1 && typ == thriftBinary:
+						m.filePath = string(d.binary())
+					case id == 3 && typ == thriftStruct:
+						m.read(d)
+					default:
+						d.skip(typ)
+					}
+				})
+				g.columns = append(g.columns, m)
+			}
+		case id == 2 && typ == thriftI64:
+			g.totalByteSize = d.i64()
+		case id == 3 && typ == thriftI64:
+			g.numRows = d.i64()
+		default:
+			d.skip(typ)
+		}
+	})
+}
+
+func (m *fileMetaData) read(d *thriftDecoder) {
+	d.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 2 && typ == thriftList:
+			elemType, n := d.listHeader()
+			for i := 0; i < n && d.err == nil; i++ {
+				if elemType != thriftStruct {
+					d.skipElem(elemType)
+					continue
+				}
+				var e schemaElement
+				e.read(d)
+				e.root = i == 0
+				m.schema = append(m.schema, e)
+			}
+		case id == 3 && typ == thriftI64:
+			m.numRows = d.i64()
+		case id == 4 && typ == thriftList:
+			elemType, n := d.listHeader()
+			for i := 0; i < n && d.err == nil; i++ {
+				if elemType != thriftStruct {
+					d.skipElem(elemType)
+					continue
+				}
+				var g rowGroupMetaData
+				g.read(d)
+				m.rowGroups = append(m.rowGroups, g)
+			}
+		default:
+			d.skip(typ)
+		}
+	})
+}
+
+// pageHeader is parquet.thrift's PageHeader, as read from a file. The fields
+// of whichever of the DataPageHeader, DataPageHeaderV2 and
+// DictionaryPageHeader it contains are flattened into it.
+type pageHeader struct {
+	typ              int32
+	uncompressedSize int32
+	compressedSize   int32
+	numValues        int32
+	encoding         int32
+	// defLevelEncoding is the encoding of the definition levels of a v1 data
+	// page.
+	defLevelEncoding int32
+	// defLevelsLength and repLevelsLength are the sizes of the levels, which
+	// are never compressed, at the start of a v2 data page.
+	defLevelsLength int32
+	repLevelsLength int32
+	// compressed is false for v2 data pages whose values are not compressed.
+	compressed bool
+}
+
+func (h *pageHeader) read(d *thriftDecoder) {
+	h.compressed = true
+	d.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == thriftI32:
+			h.typ = d.i32()
+		case id == 2 && typ == thriftI32:
+			h.uncompressedSize = d.i32()
+		case id == 3 && typ == thriftI32:
+			h.compressedSize = d.i32()
+		case id == 5 && typ == thriftStruct:
+			// DataPageHeader.
+			h.defLevelEncoding = encodingRLE
+			d.readStruct(func(id int16, typ byte) {
+				switch {
+				case id == 1 && typ == thriftI32:
+					h.numValues = d.i32()
+				case id == 2 && typ == thriftI32:
+					h.encoding = d.i32()
+				case id == 3 && typ == thriftI32:
+					h.defLevelEncoding = d.i32()
+				default:
+					d.skip(typ)
+				}
+			})
+		case id == 7 && typ == thriftStruct:
+			// DictionaryPageHeader.
+			d.readStruct(func(id int16, typ byte) {
+				switch {
+				case id == 1 && typ == thriftI32:
+					h.numValues = d.i32()
+				case id == 2 && typ == thriftI32:
+					h.encoding = d.i32()
+				default:
+					d.skip(typ)
+				}
+			})
+		case id == 8 && typ == thriftStruct:
+			// DataPageHeaderV2.
+			d.readStruct(func(id int16, typ byte) {
+				switch {
+				case id == 1 && typ == thriftI32:
+					h.numValues = d.i32()
+				case id == 4 && typ == thriftI32:
+					h.encoding = d.i32()
+				case id == 5 && typ == thriftI32:
+					h.defLevelsLength = d.i32()
+				case id == 6 && typ == thriftI32:
+					h.repLevelsLength = d.i32()
+				case id == 7 && (typ == thriftTrue || typ == thriftFalse):
+					h.compressed = typ == thriftTrue
+				default:
+					d.skip(typ)
+				}
+			})
+		default:
+			d.skip(typ)
+		}
+	})
+}
@@ -0,0 +1,292 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+// Package parquet writes SQL rows as Apache Parquet files and reads the rows of
+// Parquet files back.
+//
+// Only a subset of the format is implemented. Files are written so that every
+// column is OPTIONAL (so that NULLs and schema changes are easy to handle
+// downstream), each row group has exactly one PLAIN encoded data page per
+// column, and definition and repetition levels are RLE encoded. Arrays are
+// written with the standard three-level LIST structure. See schema.go for how
+// SQL types are mapped to Parquet types, and Reader for what can be read.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+)
+
+// magic begins and ends every Parquet file.
+const magic = `PAR1`
+
+// Compression is a Parquet compression codec. The values match parquet.thrift's
+// CompressionCodec.
+type Compression int32
+
+const (
+	// CompressionNone leaves pages uncompressed.
+	CompressionNone Compression = 0
+	// CompressionSnappy compresses pages with snappy.
+	CompressionSnappy Compression = 1
+	// CompressionGzip compresses pages with gzip.
+	CompressionGzip Compression = 2
+)
+
+// ParseCompression parses the name of a Compression, as returned by its String
+// method.
+func ParseCompression(s string) (Compression, error) {
+	switch strings.ToLower(s) {
+	case `none`, `uncompressed`:
+		return CompressionNone, nil
+	case `snappy`:
+		return CompressionSnappy, nil
+	case `gzip`:
+		return CompressionGzip, nil
+	default:
+		return 0, errors.Errorf(`unknown parquet compression: %s`, s)
+	}
+}
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return `none`
+	case CompressionSnappy:
+		return `snappy`
+	case CompressionGzip:
+		return `gzip`
+	default:
+		return `unknown`
+	}
+}
+
+// DefaultRowGroupSize is the number of rows in a row group if
+// WriterOptions.RowGroupSize is unset.
+const DefaultRowGroupSize = 10000
+
+// WriterOptions configures a Writer.
+type WriterOptions struct {
+	// RowGroupSize is the number of rows in each row group. A Writer buffers one
+	// row group in memory at a time.
+	RowGroupSize int
+	// Compression is the codec used for every page.
+	Compression Compression
+}
+
+// Writer writes rows with a fixed schema as a Parquet file.
+type Writer struct {
+	w      io.Writer
+	offset int64
+	opts   WriterOptions
+
+	cols []*column
+	meta fileMetaData
+	// rows is the number of rows in the row group being buffered.
+	rows int
+}
+
+// NewWriter returns a Writer of rows with the given column names and types to
+// w. Close must be called to finish the file.
+func NewWriter(
+	w io.Writer, names []string, typs []types.T, opts WriterOptions,
+) (*Writer, error) {
+	if len(names) != len(typs) {
+		return nil, errors.Errorf(`got %d column names for %d types`, len(names), len(typs))
+	}
+	if opts.RowGroupSize <= 0 {
+		opts.RowGroupSize = DefaultRowGroupSize
+	}
+	switch opts.Compression {
+	case CompressionNone, CompressionSnappy, CompressionGzip:
+	default:
+		return nil, errors.Errorf(`unknown parquet compression: %d`, opts.Compression)
+	}
+
+	pw := &Writer{w: w, opts: opts}
+	pw.meta.schema = append(pw.meta.schema, schemaElement{
+		name: `schema`, root: true, numChildren: int32(len(names)), converted: convertedNone,
+	})
+	for i := range typs {
+		elements, col := makeColumn(names[i], &typs[i])
+		pw.meta.schema = append(pw.meta.schema, elements...)
+		pw.cols = append(pw.cols, col)
+	}
+	if err := pw.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	return err
+}
+
+// AddRow buffers a row, writing out a row group if one is full. The datums
+// must match the types given to NewWriter.
+func (w *Writer) AddRow(row tree.Datums) error {
+	if len(row) != len(w.cols) {
+		return errors.Errorf(`expected %d datums, got %d`, len(w.cols), len(row))
+	}
+	for i, d := range row {
+		if err := w.cols[i].add(d); err != nil {
+			return errors.Wrapf(err, `column %s`, w.cols[i].path[0])
+		}
+	}
+	w.rows++
+	if w.rows >= w.opts.RowGroupSize {
+		return w.flushRowGroup()
+	}
+	return nil
+}
+
+// NumRows returns the number of rows added so far.
+func (w *Writer) NumRows() int64 {
+	return w.meta.numRows + int64(w.rows)
+}
+
+// Close writes out any buffered rows and the file footer. It does not close
+// the underlying io.Writer.
+func (w *Writer) Close() error {
+	if w.rows > 0 {
+		if err := w.flushRowGroup(); err != nil {
+			return err
+		}
+	}
+	var tw thriftWriter
+	w.meta.write(&tw)
+	footerLen := tw.buf.Len()
+	var lenBuf [4]byte
+	binary.LittleEndian.PutUint32(lenBuf[:], uint32(footerLen))
+	tw.buf.Write(lenBuf[:])
+	tw.buf.WriteString(magic)
+	return w.write(tw.buf.Bytes())
+}
+
+func (w *Writer) flushRowGroup() error {
+	rowGroup := rowGroupMetaData{numRows: int64(w.rows)}
+	for _, col := range w.cols {
+		chunk, err := w.writeColumnChunk(col)
+		if err != nil {
+			return err
+		}
+		rowGroup.columns = append(rowGroup.columns, chunk)
+		rowGroup.totalByteSize += chunk.totalUncompressedSize
+	}
+	w.meta.rowGroups = append(w.meta.rowGroups, rowGroup)
+	w.meta.numRows += int64(w.rows)
+	w.rows = 0
+	return nil
+}
+
+// writeColumnChunk writes everything buffered for a column as a single data
+// page and resets the column's buffers.
+func (w *Writer) writeColumnChunk(col *column) (columnMetaData, error) {
+	var page bytes.Buffer
+	if col.maxRep > 0 {
+		writeLevels(&page, col.repLevels, col.maxRep)
+	}
+	writeLevels(&page, col.defLevels, col.maxDef)
+	page.Write(col.values.Bytes())
+
+	compressed, err := compress(w.opts.Compression, page.Bytes())
+	if err != nil {
+		return columnMetaData{}, err
+	}
+	if len(compressed) > math.MaxInt32 || page.Len() > math.MaxInt32 {
+		return columnMetaData{}, errors.Errorf(
+			`column %s: page too large, use a smaller row group size`, col.path[0])
+	}
+	header := dataPageHeader{
+		uncompressedSize: int32(page.Len()),
+		compressedSize:   int32(len(compressed)),
+		numValues:        int32(len(col.defLevels)),
+	}
+	var tw thriftWriter
+	header.write(&tw)
+
+	chunk := columnMetaData{
+		typ:                   col.typ,
+		path:                  col.path,
+		codec:                 w.opts.Compression,
+		numValues:             int64(len(col.defLevels)),
+		totalUncompressedSize: int64(tw.buf.Len() + page.Len()),
+		totalCompressedSize:   int64(tw.buf.Len() + len(compressed)),
+		dataPageOffset:        w.offset,
+	}
+	if err := w.write(tw.buf.Bytes()); err != nil {
+		return columnMetaData{}, err
+	}
+	if err := w.write(compressed); err != nil {
+		return columnMetaData{}, err
+	}
+	col.reset()
+	return chunk, nil
+}
+
+func compress(c Compression, src []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return src, nil
+	case CompressionSnappy:
+		return snappy.Encode(nil, src), nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(src); err != nil {
+			return nil, err
+		}
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, errors.Errorf(`unknown parquet compression: %d`, c)
+	}
+}
+
+// writeLevels writes definition or repetition levels with the RLE/bit-packing
+// hybrid encoding, prefixed by its length as the data page format requires.
+// Only RLE runs are used, which is simple and compact for the long runs of
+// identical levels that are typical for our schemas.
+func writeLevels(buf *bytes.Buffer, levels []int32, maxLevel int32) {
+	byteWidth := (bits.Len32(uint32(maxLevel)) + 7) / 8
+
+	lenPos := buf.Len()
+	buf.Write([]byte{0, 0, 0, 0})
+	var scratch [binary.MaxVarintLen64]byte
+	for i := 0; i < len(levels); {
+		run := 1
+		for i+run < len(levels) && levels[i+run] == levels[i] {
+			run++
+		}
+		n := binary.PutUvarint(scratch[:], uint64(run)<<1)
+		buf.Write(scratch[:n])
+		for b := 0; b < byteWidth; b++ {
+			buf.WriteByte(byte(levels[i] >> (8 * uint(b))))
+		}
+		i += run
+	}
+	binary.LittleEndian.PutUint32(buf.Bytes()[lenPos:], uint32(buf.Len()-lenPos-4))
+}
@@ -0,0 +1,397 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+)
+
+// thriftReader is a minimal Thrift compact protocol decoder, used to check
+// what thriftWriter produces. Structs are decoded into a map of field id to
+// value, where values are int64, []byte, []interface{} or (nested structs)
+// decodedStruct.
+type thriftReader struct {
+	b []byte
+}
+
+type decodedStruct map[int16]interface{}
+
+func (r *thriftReader) byte() byte {
+	b := r.b[0]
+	r.b = r.b[1:]
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.b)
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := r.uvarint()
+		v := r.b[:n]
+		r.b = r.b[n:]
+		return v
+	case thriftList:
+		header := r.byte()
+		n, elemType := uint64(header>>4), header&0x0f
+		if n == 15 {
+			n = r.uvarint()
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.value(elemType)
+		}
+		return list
+	case thriftStruct:
+		s := decodedStruct{}
+		var lastID int16
+		for {
+			header := r.byte()
+			if header == 0 {
+				return s
+			}
+			id := lastID + int16(header>>4)
+			if header>>4 == 0 {
+				id = int16(r.varint())
+			}
+			s[id] = r.value(header & 0x0f)
+			lastID = id
+		}
+	default:
+		panic(fmt.Sprintf(`unexpected thrift type %d`, typ))
+	}
+}
+
+// readFile decodes every column of a Parquet file written by Writer. Each
+// column is returned as a slice with one entry per row: nil for NULL, the
+// decoded physical value otherwise, or for LIST columns a []interface{} of
+// the elements.
+func readFile(t *testing.T, data []byte) (decodedStruct, [][]interface{}) {
+	t.Helper()
+	require.Equal(t, magic, string(data[:4]))
+	require.Equal(t, magic, string(data[len(data)-4:]))
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := &thriftReader{b: data[len(data)-8-footerLen : len(data)-8]}
+	meta := footer.value(thriftStruct).(decodedStruct)
+	require.Empty(t, footer.b)
+
+	var cols [][]interface{}
+	for _, rg := range meta[4].([]interface{}) {
+		rowGroup := rg.(decodedStruct)
+		for i, cc := range rowGroup[1].([]interface{}) {
+			colMeta := cc.(decodedStruct)[3].(decodedStruct)
+			if len(cols) <= i {
+				cols = append(cols, nil)
+			}
+			path := colMeta[3].([]interface{})
+			cols[i] = append(cols[i], readChunk(t, data, colMeta, len(path) > 1)...)
+		}
+	}
+	return meta, cols
+}
+
+func readChunk(t *testing.T, data []byte, colMeta decodedStruct, list bool) []interface{} {
+	t.Helper()
+	r := &thriftReader{b: data[colMeta[9].(int64):]}
+	header := r.value(thriftStruct).(decodedStruct)
+	require.Equal(t, int64(pageTypeData), header[1])
+	page := r.b[:header[3].(int64)]
+	switch Compression(colMeta[4].(int64)) {
+	case CompressionSnappy:
+		var err error
+		page, err = snappy.Decode(nil, page)
+		require.NoError(t, err)
+	case CompressionGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(page))
+		require.NoError(t, err)
+		page, err = ioutil.ReadAll(gz)
+		require.NoError(t, err)
+	}
+	require.Len(t, page, int(header[2].(int64)))
+
+	numValues := int(header[5].(decodedStruct)[1].(int64))
+	var repLevels []int32
+	if list {
+		repLevels, page = readLevels(t, page, numValues)
+	}
+	defLevels, page := readLevels(t, page, numValues)
+
+	maxDef := int32(1)
+	if list {
+		maxDef = 3
+	}
+	var values []interface{}
+	var boolIdx int
+	for _, def := range defLevels {
+		if def != maxDef {
+			values = append(values, nil)
+			continue
+		}
+		switch physicalType(colMeta[1].(int64)) {
+		case physicalBoolean:
+			values = append(values, page[boolIdx/8]&(1<<uint(boolIdx%8)) != 0)
+			boolIdx++
+		case physicalInt32:
+			values = append(values, int32(binary.LittleEndian.Uint32(page)))
+			page = page[4:]
+		case physicalInt64:
+			values = append(values, int64(binary.LittleEndian.Uint64(page)))
+			page = page[8:]
+		case physicalFloat:
+			values = append(values, math.Float32frombits(binary.LittleEndian.Uint32(page)))
+			page = page[4:]
+		case physicalDouble:
+			values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(page)))
+			page = page[8:]
+		case physicalByteArray:
+			n := binary.LittleEndian.Uint32(page)
+			values = append(values, string(page[4:4+n]))
+			page = page[4+n:]
+		}
+	}
+	if physicalType(colMeta[1].(int64)) == physicalBoolean {
+		page = page[(boolIdx+7)/8:]
+	}
+	require.Empty(t, page)
+
+	if !list {
+		return values
+	}
+	var rows []interface{}
+	for i, def := range defLevels {
+		switch {
+		case repLevels[i] == 1:
+			rows[len(rows)-1] = append(rows[len(rows)-1].([]interface{}), values[i])
+		case def == 0:
+			rows = append(rows, nil)
+		case def == 1:
+			rows = append(rows, []interface{}{})
+		default:
+			rows = append(rows, []interface{}{values[i]})
+		}
+	}
+	return rows
+}
+
+func readLevels(t *testing.T, page []byte, numValues int) ([]int32, []byte) {
+	t.Helper()
+	n := binary.LittleEndian.Uint32(page)
+	r := &thriftReader{b: page[4 : 4+n]}
+	var levels []int32
+	for len(r.b) > 0 {
+		header := r.uvarint()
+		require.Equal(t, uint64(0), header&1, `expected only RLE runs`)
+		level := int32(r.byte())
+		for i := uint64(0); i < header>>1; i++ {
+			levels = append(levels, level)
+		}
+	}
+	require.Len(t, levels, numValues)
+	return levels, page[4+n:]
+}
+
+func TestWriter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := time.Date(2019, 10, 2, 3, 4, 5, 123456000, time.UTC)
+	date, err := tree.NewDDateFromTime(ts)
+	require.NoError(t, err)
+	dec, err := tree.ParseDDecimal(`-12.5`)
+	require.NoError(t, err)
+	bigDec, err := tree.ParseDDecimal(`123456789012345678.9`)
+	require.NoError(t, err)
+	j, err := json.ParseJSON(`{"a": [1, "b"]}`)
+	require.NoError(t, err)
+	intArray := tree.NewDArray(types.Int)
+	require.NoError(t, intArray.Append(tree.NewDInt(1)))
+	require.NoError(t, intArray.Append(tree.DNull))
+	require.NoError(t, intArray.Append(tree.NewDInt(3)))
+	emptyArray := tree.NewDArray(types.Int)
+	interval, err := tree.ParseDInterval(`1h`)
+	require.NoError(t, err)
+
+	names := []string{
+		`b`, `i2`, `i`, `f4`, `f`, `dec`, `dec_unconstrained`, `d`, `tm`, `ts`, `tstz`,
+		`bytes`, `s`, `j`, `interval`, `ints`,
+	}
+	typs := []types.T{
+		*types.Bool, *types.Int2, *types.Int, *types.Float4, *types.Float,
+		*types.MakeDecimal(20, 2), *types.Decimal, *types.Date, *types.Time, *types.Timestamp,
+		*types.TimestampTZ, *types.Bytes, *types.String, *types.Jsonb, *types.Interval,
+		*types.MakeArray(types.Int),
+	}
+	rows := []tree.Datums{
+		{
+			tree.DBoolTrue, tree.NewDInt(-2), tree.NewDInt(math.MaxInt64), tree.NewDFloat(1.5),
+			tree.NewDFloat(-2.25), dec, bigDec, date, tree.MakeDTime(timeofday.New(3, 4, 5, 6)),
+			tree.MakeDTimestamp(ts, time.Microsecond), tree.MakeDTimestampTZ(ts, time.Microsecond),
+			tree.NewDBytes("\x00"), tree.NewDString(`☃`), tree.NewDJSON(j),
+			interval, intArray,
+		},
+		{
+			tree.DBoolFalse, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull,
+			tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull,
+			tree.DNull, emptyArray,
+		},
+		{
+			tree.DNull, tree.NewDInt(2), tree.NewDInt(3), tree.NewDFloat(0), tree.NewDFloat(0),
+			dec, dec, date, tree.MakeDTime(timeofday.Min), tree.MakeDTimestamp(ts, time.Microsecond),
+			tree.MakeDTimestampTZ(ts, time.Microsecond), tree.NewDBytes(``), tree.NewDString(``),
+			tree.NewDJSON(j), tree.DNull, tree.DNull,
+		},
+	}
+	tsMicros := ts.UnixNano() / 1000
+	expected := [][]interface{}{
+		{true, false, nil},
+		{int32(-2), nil, int32(2)},
+		{int64(math.MaxInt64), nil, int64(3)},
+		{float32(1.5), nil, float32(0)},
+		{float64(-2.25), nil, float64(0)},
+		// -1250 in two's complement.
+		{"\xfb\x1e", nil, "\xfb\x1e"},
+		{`123456789012345678.9`, nil, `-12.5`},
+		{int32(ts.Unix() / 86400), nil, int32(ts.Unix() / 86400)},
+		{int64(11045000006), nil, int64(0)},
+		{tsMicros, nil, tsMicros},
+		{tsMicros, nil, tsMicros},
+		{"\x00", nil, ``},
+		{`☃`, nil, ``},
+		{`{"a": [1, "b"]}`, nil, `{"a": [1, "b"]}`},
+		{`01:00:00`, nil, nil},
+		{[]interface{}{int64(1), nil, int64(3)}, []interface{}{}, nil},
+	}
+
+	for _, compression := range []Compression{CompressionNone, CompressionSnappy, CompressionGzip} {
+		for _, rowGroupSize := range []int{1, 2, 10} {
+			t.Run(fmt.Sprintf(`%s/%d`, compression, rowGroupSize), func(t *testing.T) {
+				var buf bytes.Buffer
+				w, err := NewWriter(&buf, names, typs, WriterOptions{
+					RowGroupSize: rowGroupSize, Compression: compression,
+				})
+				require.NoError(t, err)
+				for _, row := range rows {
+					require.NoError(t, w.AddRow(row))
+				}
+				require.Equal(t, int64(len(rows)), w.NumRows())
+				require.NoError(t, w.Close())
+
+				meta, cols := readFile(t, buf.Bytes())
+				require.Equal(t, int64(len(rows)), meta[3])
+				require.Len(t, meta[4], (len(rows)+rowGroupSize-1)/rowGroupSize)
+				require.Equal(t, expected, cols)
+			})
+		}
+	}
+}
+
+func TestWriterSchema(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	names := []string{`a`, `b`, `c`}
+	typs := []types.T{*types.MakeDecimal(10, 3), *types.String, *types.MakeArray(types.Bool)}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, names, typs, WriterOptions{})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	meta, cols := readFile(t, buf.Bytes())
+	require.Empty(t, cols)
+	require.Equal(t, int64(0), meta[3])
+
+	// Field ids are from SchemaElement in parquet.thrift.
+	require.Equal(t, []interface{}{
+		decodedStruct{4: []byte(`schema`), 5: int64(3)},
+		decodedStruct{
+			1: int64(physicalByteArray), 3: int64(repetitionOptional), 4: []byte(`a`),
+			6: int64(convertedDecimal), 7: int64(3), 8: int64(10),
+		},
+		decodedStruct{
+			1: int64(physicalByteArray), 3: int64(repetitionOptional), 4: []byte(`b`),
+			6: int64(convertedUTF8),
+		},
+		decodedStruct{
+			3: int64(repetitionOptional), 4: []byte(`c`), 5: int64(1), 6: int64(convertedList),
+		},
+		decodedStruct{3: int64(repetitionRepeated), 4: []byte(`list`), 5: int64(1)},
+		decodedStruct{
+			1: int64(physicalBoolean), 3: int64(repetitionOptional), 4: []byte(`element`),
+		},
+	}, meta[2])
+
+	_, err = NewWriter(&buf, names, typs[:1], WriterOptions{})
+	require.EqualError(t, err, `got 3 column names for 1 types`)
+}
+
+func TestWriterErrors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []string{`a`}, []types.T{*types.MakeDecimal(3, 1)}, WriterOptions{})
+	require.NoError(t, err)
+	dec, err := tree.ParseDDecimal(`1000`)
+	require.NoError(t, err)
+	require.Regexp(t, `column a: cannot write 1000 as DECIMAL\(3,1\)`, w.AddRow(tree.Datums{dec}))
+	require.EqualError(t, w.AddRow(tree.Datums{}), `expected 1 datums, got 0`)
+
+	_, err = ParseCompression(`lzo`)
+	require.EqualError(t, err, `unknown parquet compression: lzo`)
+}
+
+func TestTwosComplement(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	for _, tc := range []struct {
+		v        int64
+		expected []byte
+	}{
+		{0, []byte{0x00}},
+		{1, []byte{0x01}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x00, 0x80}},
+		{-1, []byte{0xff}},
+		{-128, []byte{0x80}},
+		{-129, []byte{0xff, 0x7f}},
+		{-256, []byte{0xff, 0x00}},
+	} {
+		magnitude := big.NewInt(tc.v)
+		magnitude.Abs(magnitude)
+		require.Equal(t, tc.expected, twosComplement(magnitude, tc.v < 0), `%d`, tc.v)
+	}
+}
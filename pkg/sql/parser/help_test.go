@@ -60,6 +60,10 @@ func TestContextualHelp(t *testing.T) {
 		{`ALTER VIEW blah RENAME ??`, `ALTER VIEW`},
 		{`ALTER VIEW blah RENAME TO blih ??`, `ALTER VIEW`},
 
+		{`ALTER DOMAIN blah ??`, `ALTER DOMAIN`},
+		{`ALTER DOMAIN blah ADD ??`, `ALTER DOMAIN`},
+		{`ALTER DOMAIN blah DROP ??`, `ALTER DOMAIN`},
+
 		{`ALTER SEQUENCE IF ??`, `ALTER SEQUENCE`},
 		{`ALTER SEQUENCE blah ??`, `ALTER SEQUENCE`},
 		{`ALTER SEQUENCE blah RENAME ??`, `ALTER SEQUENCE`},
@@ -67,6 +71,10 @@ func TestContextualHelp(t *testing.T) {
 
 		{`ALTER USER IF ??`, `ALTER USER`},
 		{`ALTER USER foo WITH PASSWORD ??`, `ALTER USER`},
+		{`ALTER USER foo LOGIN ??`, `ALTER USER`},
+
+		{`ALTER ROLE ??`, `ALTER ROLE`},
+		{`ALTER ROLE foo WITH ??`, `ALTER ROLE`},
 
 		{`ALTER RANGE foo CONFIGURE ??`, `ALTER RANGE`},
 		{`ALTER RANGE ??`, `ALTER RANGE`},
@@ -109,6 +117,12 @@ func TestContextualHelp(t *testing.T) {
 		{`CREATE VIEW blah AS SELECT c FROM x ??`, `SELECT`},
 		{`CREATE VIEW blah AS (??`, `<SELECTCLAUSE>`},
 
+		{`CREATE DOMAIN ??`, `CREATE DOMAIN`},
+		{`CREATE DOMAIN blah AS INT8 ??`, `CREATE DOMAIN`},
+
+		{`CREATE TYPE ??`, `CREATE TYPE`},
+		{`CREATE TYPE blah AS ( ??`, `CREATE TYPE`},
+
 		{`CREATE SEQUENCE ??`, `CREATE SEQUENCE`},
 
 		{`CREATE STATISTICS ??`, `CREATE STATISTICS`},
@@ -141,6 +155,12 @@ func TestContextualHelp(t *testing.T) {
 		{`DROP ROLE IF ??`, `DROP ROLE`},
 		{`DROP ROLE IF EXISTS bluh ??`, `DROP ROLE`},
 
+		{`DROP DOMAIN ??`, `DROP DOMAIN`},
+		{`DROP DOMAIN IF ??`, `DROP DOMAIN`},
+
+		{`DROP TYPE ??`, `DROP TYPE`},
+		{`DROP TYPE IF ??`, `DROP TYPE`},
+
 		{`DROP SEQUENCE blah ??`, `DROP SEQUENCE`},
 		{`DROP SEQUENCE IF ??`, `DROP SEQUENCE`},
 		{`DROP SEQUENCE IF EXISTS blih, bloh ??`, `DROP SEQUENCE`},
@@ -206,8 +226,14 @@ func TestContextualHelp(t *testing.T) {
 		{`GRANT ALL ON foo TO bar ??`, `GRANT`},
 
 		{`PAUSE ??`, `PAUSE JOBS`},
+		{`PAUSE JOB ??`, `PAUSE JOBS`},
+		{`PAUSE SCHEDULE ??`, `PAUSE SCHEDULES`},
+		{`PAUSE SCHEDULES ??`, `PAUSE SCHEDULES`},
 
 		{`RESUME ??`, `RESUME JOBS`},
+		{`RESUME JOB ??`, `RESUME JOBS`},
+		{`RESUME SCHEDULE ??`, `RESUME SCHEDULES`},
+		{`RESUME SCHEDULES ??`, `RESUME SCHEDULES`},
 
 		{`REVOKE ALL ??`, `REVOKE`},
 		{`REVOKE ALL ON foo FROM ??`, `REVOKE`},
@@ -246,6 +272,8 @@ func TestContextualHelp(t *testing.T) {
 		{`SHOW JOBS ??`, `SHOW JOBS`},
 		{`SHOW AUTOMATIC JOBS ??`, `SHOW JOBS`},
 
+		{`SHOW SCHEDULES ??`, `SHOW SCHEDULES`},
+
 		{`SHOW BACKUP 'foo' ??`, `SHOW BACKUP`},
 
 		{`SHOW CLUSTER SETTING all ??`, `SHOW CLUSTER SETTING`},
@@ -365,6 +393,9 @@ func TestContextualHelp(t *testing.T) {
 		{`BACKUP DATABASE ??`, `BACKUP`},
 		{`BACKUP foo TO 'bar' AS OF ??`, `BACKUP`},
 
+		{`CREATE SCHEDULE ??`, `CREATE SCHEDULE FOR BACKUP`},
+		{`CREATE SCHEDULE FOR BACKUP foo TO 'bar' RECURRING '@daily' ??`, `CREATE SCHEDULE FOR BACKUP`},
+
 		{`RESTORE foo FROM 'bar' ??`, `RESTORE`},
 		{`RESTORE DATABASE ??`, `RESTORE`},
 
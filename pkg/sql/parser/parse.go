@@ -312,6 +312,9 @@ func ParseType(sql string) (*types.T, error) {
 	if !ok {
 		return nil, errors.AssertionFailedf("expected a tree.CastExpr, but found %T", expr)
 	}
+	if name, ok := cast.Type.DomainReference(); ok {
+		return nil, tree.NewUndefinedTypeError(name)
+	}
 
 	return cast.Type, nil
 }
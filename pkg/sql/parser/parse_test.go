@@ -24,6 +24,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	_ "github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	_ "github.com/cockroachdb/cockroach/pkg/util/log" // for flags
@@ -269,6 +270,35 @@ func TestParse(t *testing.T) {
 		{`CREATE VIEW a (x, y) AS VALUES (1, 'one'), (2, 'two')`},
 		{`CREATE VIEW a AS TABLE b`},
 
+		{`CREATE DOMAIN a AS INT8`},
+		{`CREATE DOMAIN a AS STRING DEFAULT 'x' NOT NULL CHECK (length(value) > 2)`},
+		{`CREATE DOMAIN a AS INT8 CONSTRAINT positive CHECK (value > 0) CHECK (value < 100)`},
+		{`CREATE DOMAIN a.b AS INT8`},
+		{`CREATE DOMAIN a.b.c AS INT8`},
+		{`EXPLAIN CREATE DOMAIN a AS INT8`},
+		{`ALTER DOMAIN a ADD CHECK (value > 0)`},
+		{`ALTER DOMAIN a ADD CONSTRAINT positive CHECK (value > 0)`},
+		{`ALTER DOMAIN a DROP CONSTRAINT positive`},
+		{`ALTER DOMAIN a DROP CONSTRAINT IF EXISTS positive`},
+		{`ALTER DOMAIN a.b DROP CONSTRAINT positive`},
+		{`DROP DOMAIN a`},
+		{`DROP DOMAIN IF EXISTS a, b CASCADE`},
+		{`DROP DOMAIN a.b, c.d.e`},
+
+		{`CREATE TYPE a AS ()`},
+		{`CREATE TYPE a AS (b INT8)`},
+		{`CREATE TYPE a AS (b INT8, c STRING, "d e" DECIMAL(10,2))`},
+		{`CREATE TYPE a AS (b INT8[], c d)`},
+		{`CREATE TYPE a.b AS (c d.e, f g.h.i)`},
+		{`EXPLAIN CREATE TYPE a AS (b INT8)`},
+		{`DROP TYPE a`},
+		{`DROP TYPE IF EXISTS a, b CASCADE`},
+		{`DROP TYPE a.b.c`},
+		{`SELECT (a).b FROM t`},
+		{`SELECT ((1, 'x')::a).b`},
+		{`SELECT 'a'::email, CAST(1 AS posint)`},
+		{`SELECT 'a'::db.email, CAST(1 AS db.public.posint), 'b'::"select".email`},
+
 		{`CREATE SEQUENCE a`},
 		{`EXPLAIN CREATE SEQUENCE a`},
 		{`CREATE SEQUENCE IF NOT EXISTS a`},
@@ -370,6 +400,10 @@ func TestParse(t *testing.T) {
 		{`EXPLAIN RESUME JOBS SELECT a`},
 		{`PAUSE JOBS SELECT a`},
 		{`EXPLAIN PAUSE JOBS SELECT a`},
+		{`RESUME SCHEDULES SELECT a`},
+		{`EXPLAIN RESUME SCHEDULES SELECT a`},
+		{`PAUSE SCHEDULES SELECT a`},
+		{`EXPLAIN PAUSE SCHEDULES SELECT a`},
 
 		{`EXPLAIN SELECT 1`},
 		{`EXPLAIN EXPLAIN SELECT 1`},
@@ -421,6 +455,8 @@ func TestParse(t *testing.T) {
 		{`EXPLAIN SHOW USERS`},
 		{`SHOW JOBS`},
 		{`EXPLAIN SHOW JOBS`},
+		{`SHOW SCHEDULES`},
+		{`EXPLAIN SHOW SCHEDULES`},
 		{`SHOW AUTOMATIC JOBS`},
 		{`EXPLAIN SHOW AUTOMATIC JOBS`},
 		{`SHOW CLUSTER QUERIES`},
@@ -542,6 +578,8 @@ func TestParse(t *testing.T) {
 		// Tables are the default, but can also be specified with
 		// GRANT x ON TABLE y. However, the stringer does not output TABLE.
 		{`GRANT SELECT ON TABLE foo TO root`},
+		{`GRANT SELECT (a, b) ON TABLE foo TO root`},
+		{`GRANT SELECT, UPDATE (a) ON TABLE foo, db.foo TO root, bar`},
 		{`GRANT SELECT, DELETE, UPDATE ON TABLE foo, db.foo TO root, bar`},
 		{`GRANT DROP ON DATABASE foo TO root`},
 		{`GRANT ALL ON DATABASE foo TO root, test`},
@@ -554,6 +592,8 @@ func TestParse(t *testing.T) {
 		// Tables are the default, but can also be specified with
 		// REVOKE x ON TABLE y. However, the stringer does not output TABLE.
 		{`REVOKE SELECT ON TABLE foo FROM root`},
+		{`REVOKE SELECT (a, b) ON TABLE foo FROM root`},
+		{`REVOKE ALL (a) ON TABLE foo, db.foo FROM root, bar`},
 		{`REVOKE UPDATE, DELETE ON TABLE foo, db.foo FROM root, bar`},
 		{`REVOKE INSERT ON DATABASE foo FROM root`},
 		{`REVOKE ALL ON DATABASE foo FROM root, test`},
@@ -961,6 +1001,9 @@ func TestParse(t *testing.T) {
 		{`SELECT a FROM t LIMIT a`},
 		{`SELECT a FROM t OFFSET b`},
 		{`SELECT a FROM t LIMIT a OFFSET b`},
+		{`SELECT a FROM t FOR UPDATE`},
+		{`SELECT a FROM t ORDER BY a LIMIT 1 FOR UPDATE`},
+		{`WITH a AS (SELECT 1) SELECT * FROM a FOR UPDATE`},
 		{`SELECT DISTINCT * FROM t`},
 		{`SELECT DISTINCT a, b FROM t`},
 		{`SELECT DISTINCT ON (a, b) c FROM t`},
@@ -972,6 +1015,8 @@ func TestParse(t *testing.T) {
 		{`SET a = 3.0`},
 		{`SET a = $1`},
 		{`SET a = off`},
+		{`SET LOCAL a = 3`},
+		{`SET LOCAL a = 3, 4`},
 		{`SET TRANSACTION READ ONLY`},
 		{`SET TRANSACTION READ WRITE`},
 		{`SET TRANSACTION ISOLATION LEVEL SERIALIZABLE`},
@@ -1254,9 +1299,13 @@ func TestParse(t *testing.T) {
 		{`EXPLAIN SHOW BACKUP 'bar'`},
 		{`SHOW BACKUP RANGES 'bar'`},
 		{`SHOW BACKUP FILES 'bar'`},
+		{`SHOW BACKUP 'bar' WITH foo = 'bar'`},
+		{`SHOW BACKUP RANGES 'bar' WITH foo`},
 
 		{`BACKUP TABLE foo TO 'bar' AS OF SYSTEM TIME '1' INCREMENTAL FROM 'baz'`},
 		{`BACKUP TABLE foo TO $1 INCREMENTAL FROM 'bar', $2, 'baz'`},
+		{`BACKUP TABLE foo TO ('bar', 'baz')`},
+		{`BACKUP DATABASE foo TO ($1, $2) INCREMENTAL FROM 'baz'`},
 
 		{`BACKUP DATABASE foo TO 'bar'`},
 		{`EXPLAIN BACKUP DATABASE foo TO 'bar'`},
@@ -1267,6 +1316,8 @@ func TestParse(t *testing.T) {
 		{`EXPLAIN RESTORE TABLE foo FROM 'bar'`},
 		{`RESTORE TABLE foo FROM $1`},
 		{`RESTORE TABLE foo FROM $1, $2, 'bar'`},
+		{`RESTORE TABLE foo FROM ('bar', 'baz'), ($1, $2)`},
+		{`RESTORE DATABASE foo FROM 'bar', ('baz', $1) AS OF SYSTEM TIME '1'`},
 		{`RESTORE TABLE foo, baz FROM 'bar'`},
 		{`RESTORE TABLE foo, baz FROM 'bar' AS OF SYSTEM TIME '1'`},
 
@@ -1278,6 +1329,11 @@ func TestParse(t *testing.T) {
 		{`BACKUP TABLE foo TO 'bar' WITH key1, key2 = 'value'`},
 		{`RESTORE TABLE foo FROM 'bar' WITH key1, key2 = 'value'`},
 
+		{`CREATE SCHEDULE FOR BACKUP TABLE foo TO 'bar' RECURRING '@hourly'`},
+		{`CREATE SCHEDULE 'my schedule' FOR BACKUP DATABASE foo TO 'bar' RECURRING '@daily' FULL BACKUP '@weekly'`},
+		{`CREATE SCHEDULE $1 FOR BACKUP TABLE foo, baz TO $2 WITH key1, key2 = 'value' RECURRING $3 FULL BACKUP $4`},
+		{`EXPLAIN CREATE SCHEDULE FOR BACKUP TABLE foo TO 'bar' RECURRING '@hourly'`},
+
 		{`IMPORT TABLE foo CREATE USING 'nodelocal:///some/file' CSV DATA ('path/to/some/file', $1) WITH temp = 'path/to/temp'`},
 		{`EXPLAIN IMPORT TABLE foo CREATE USING 'nodelocal:///some/file' CSV DATA ('path/to/some/file', $1) WITH temp = 'path/to/temp'`},
 		{`IMPORT TABLE foo CREATE USING 'nodelocal:///some/file' MYSQLOUTFILE DATA ('path/to/some/file', $1)`},
@@ -1305,6 +1361,10 @@ func TestParse(t *testing.T) {
 		// {`CREATE CHANGEFEED FOR TABLE foo PARTITION bar, baz INTO 'sink'`},
 		// {`CREATE CHANGEFEED FOR DATABASE foo INTO 'sink'`},
 		{`CREATE CHANGEFEED FOR TABLE foo INTO 'sink' WITH bar = 'baz'`},
+		{`CREATE CHANGEFEED INTO 'sink' AS SELECT a, b FROM foo WHERE c > 1`},
+		{`CREATE CHANGEFEED INTO 'sink' WITH bar = 'baz' AS SELECT * FROM foo`},
+		{`EXPERIMENTAL CHANGEFEED AS SELECT a FROM foo`},
+		{`EXPERIMENTAL CHANGEFEED WITH bar = 'baz' AS SELECT a FROM foo`},
 
 		// Regression for #15926
 		{`SELECT * FROM ((t1 NATURAL JOIN t2 WITH ORDINALITY AS o1)) WITH ORDINALITY AS o2`},
@@ -1384,6 +1444,9 @@ func TestParse2(t *testing.T) {
 		{`SELECT CAST('foo' AS TIMESTAMP WITHOUT TIME ZONE)`, `SELECT CAST('foo' AS TIMESTAMP)`},
 		{`SELECT CAST(1 AS "timestamp")`, `SELECT CAST(1 AS TIMESTAMP)`},
 		{`SELECT CAST(1 AS _int8)`, `SELECT CAST(1 AS INT8[])`},
+		{`SELECT 'f'::"blah"`, `SELECT 'f'::blah`},
+		{`SELECT foo'bar'`, `SELECT foo 'bar'`},
+		{`CREATE DOMAIN a INT8 NULL`, `CREATE DOMAIN a AS INT8`},
 		{`SELECT CAST(1 AS "_int8")`, `SELECT CAST(1 AS INT8[])`},
 		{`SELECT SERIAL8 'foo', 'foo'::SERIAL8`, `SELECT INT8 'foo', 'foo'::INT8`},
 
@@ -1590,6 +1653,8 @@ func TestParse2(t *testing.T) {
 			`SET timezone = '-07:00:00'`},
 		{`SET TIME ZONE INTERVAL '-7h0m5s' HOUR TO MINUTE`,
 			`SET timezone = '-06:59:00'`},
+		{`SET LOCAL TIME ZONE 'Europe/Rome'`,
+			`SET LOCAL timezone = 'Europe/Rome'`},
 		{`SET CLUSTER SETTING a = on`,
 			`SET CLUSTER SETTING a = "on"`},
 		{`SET a = on`,
@@ -1725,6 +1790,8 @@ func TestParse2(t *testing.T) {
 		{`CANCEL JOB a`, `CANCEL JOBS VALUES (a)`},
 		{`RESUME JOB a`, `RESUME JOBS VALUES (a)`},
 		{`PAUSE JOB a`, `PAUSE JOBS VALUES (a)`},
+		{`RESUME SCHEDULE a`, `RESUME SCHEDULES VALUES (a)`},
+		{`PAUSE SCHEDULE a`, `PAUSE SCHEDULES VALUES (a)`},
 		{`CANCEL QUERY a`, `CANCEL QUERIES VALUES (a)`},
 		{`CANCEL QUERY IF EXISTS a`, `CANCEL QUERIES IF EXISTS VALUES (a)`},
 		{`CANCEL SESSION a`, `CANCEL SESSIONS VALUES (a)`},
@@ -1736,6 +1803,8 @@ func TestParse2(t *testing.T) {
 			`BACKUP DATABASE foo TO 'bar.12' INCREMENTAL FROM 'baz.34'`},
 		{`RESTORE DATABASE foo FROM bar`,
 			`RESTORE DATABASE foo FROM 'bar'`},
+		{`BACKUP DATABASE foo TO ('bar')`,
+			`BACKUP DATABASE foo TO 'bar'`},
 
 		{`CREATE CHANGEFEED FOR TABLE foo INTO sink`,
 			`CREATE CHANGEFEED FOR TABLE foo INTO 'sink'`},
@@ -1776,6 +1845,14 @@ func TestParse2(t *testing.T) {
 			`DROP USER IF EXISTS 'foo', 'bar'`},
 		{`ALTER USER foo WITH PASSWORD bar`,
 			`ALTER USER 'foo' WITH PASSWORD 'bar'`},
+		{`ALTER USER foo PASSWORD bar VALID UNTIL '2020-01-01'`,
+			`ALTER USER 'foo' WITH PASSWORD 'bar' VALID UNTIL '2020-01-01'`},
+		{`ALTER USER IF EXISTS foo NOLOGIN`,
+			`ALTER USER IF EXISTS 'foo' WITH NOLOGIN`},
+		{`CREATE USER foo WITH LOGIN CREATEROLE NOCREATEDB CONNECTION LIMIT 5`,
+			`CREATE USER 'foo' WITH LOGIN CREATEROLE NOCREATEDB CONNECTION LIMIT 5`},
+		{`CREATE USER foo VALID UNTIL NULL CONNECTION LIMIT -1`,
+			`CREATE USER 'foo' WITH VALID UNTIL NULL CONNECTION LIMIT -1`},
 
 		{`ALTER TABLE a RENAME b TO c`,
 			`ALTER TABLE a RENAME COLUMN b TO c`},
@@ -1852,6 +1929,10 @@ func TestParse2(t *testing.T) {
 			`GRANT SELECT ON TABLE role TO root`},
 		{`REVOKE SELECT ON foo FROM root`,
 			`REVOKE SELECT ON TABLE foo FROM root`},
+		{`GRANT SELECT (a) ON foo TO root`,
+			`GRANT SELECT (a) ON TABLE foo TO root`},
+		{`REVOKE SELECT (a) ON foo FROM root`,
+			`REVOKE SELECT (a) ON TABLE foo FROM root`},
 		{`REVOKE UPDATE, DELETE ON foo, db.foo FROM root, bar`,
 			`REVOKE UPDATE, DELETE ON TABLE foo, db.foo FROM root, bar`},
 
@@ -1861,6 +1942,16 @@ func TestParse2(t *testing.T) {
 			`CREATE ROLE 'foo'`},
 		{`CREATE ROLE IF NOT EXISTS foo`,
 			`CREATE ROLE IF NOT EXISTS 'foo'`},
+		{`CREATE ROLE foo LOGIN CREATEDB`,
+			`CREATE ROLE 'foo' WITH LOGIN CREATEDB`},
+		{`CREATE ROLE IF NOT EXISTS foo WITH NOLOGIN NOCREATEROLE`,
+			`CREATE ROLE IF NOT EXISTS 'foo' WITH NOLOGIN NOCREATEROLE`},
+		{`ALTER ROLE foo WITH CREATEROLE`,
+			`ALTER ROLE 'foo' WITH CREATEROLE`},
+		{`ALTER ROLE IF EXISTS foo CONNECTION LIMIT 10`,
+			`ALTER ROLE IF EXISTS 'foo' WITH CONNECTION LIMIT 10`},
+		{`ALTER GROUP foo LOGIN`,
+			`ALTER ROLE 'foo' WITH LOGIN`},
 		{`DROP ROLE foo, bar`,
 			`DROP ROLE 'foo', 'bar'`},
 		{`DROP ROLE IF EXISTS foo, bar`,
@@ -2285,11 +2376,6 @@ DETAIL: source SQL:
 SELECT 1e-
        ^
 HINT: try \h SELECT`},
-		{"SELECT foo''",
-			`at or near "": syntax error: type does not exist
-DETAIL: source SQL:
-SELECT foo''
-          ^`},
 		{
 			`SELECT 0x FROM t`,
 			`lexical error: invalid hexadecimal numeric literal
@@ -2396,20 +2482,6 @@ ALTER TABLE t RENAME COLUMN x TO family
                                  ^
 HINT: try \h ALTER TABLE`,
 		},
-		{
-			`SELECT CAST(1.2+2.3 AS notatype)`,
-			`at or near "notatype": syntax error: type does not exist
-DETAIL: source SQL:
-SELECT CAST(1.2+2.3 AS notatype)
-                       ^`,
-		},
-		{
-			`SELECT ANNOTATE_TYPE(1.2+2.3, notatype)`,
-			`at or near "notatype": syntax error: type does not exist
-DETAIL: source SQL:
-SELECT ANNOTATE_TYPE(1.2+2.3, notatype)
-                              ^`,
-		},
 		{
 			`CREATE USER foo WITH PASSWORD`,
 			`at or near "EOF": syntax error
@@ -2476,13 +2548,6 @@ DETAIL: source SQL:
 SELECT 1 + ANY ARRAY[1, 2, 3]
                              ^`,
 		},
-		{
-			`SELECT 'f'::"blah"`,
-			`at or near "blah": syntax error: type does not exist
-DETAIL: source SQL:
-SELECT 'f'::"blah"
-            ^`,
-		},
 		// Ensure that the support for ON ROLE <namelist> doesn't leak
 		// where it should not be recognized.
 		{
@@ -2659,6 +2724,35 @@ CREATE STATISTICS a ON col1 FROM t WITH OPTIONS AS OF SYSTEM TIME '-1s' THROTTLI
 	}
 }
 
+// TestParseUndefinedType verifies that names which are not known types parse
+// as user-defined type references and are rejected during type checking.
+func TestParseUndefinedType(t *testing.T) {
+	testData := []struct {
+		sql      string
+		expected string
+	}{
+		{`SELECT foo''`, `type "foo" does not exist`},
+		{`SELECT CAST(1.2+2.3 AS notatype)`, `type "notatype" does not exist`},
+		{`SELECT ANNOTATE_TYPE(1.2+2.3, notatype)`, `type "notatype" does not exist`},
+		{`SELECT 'f'::"blah"`, `type "blah" does not exist`},
+		{`SELECT 'f'::db.blah`, `type "db.blah" does not exist`},
+		{`SELECT CAST('f' AS db.public.blah)`, `type "db.public.blah" does not exist`},
+	}
+	for _, d := range testData {
+		t.Run(d.sql, func(t *testing.T) {
+			stmt, err := parser.ParseOne(d.sql)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sel := stmt.AST.(*tree.Select).Select.(*tree.SelectClause)
+			_, err = tree.TypeCheck(sel.Exprs[0].Expr, nil, types.Any)
+			if !testutils.IsError(err, regexp.QuoteMeta(d.expected)) {
+				t.Errorf("%s: expected %q, but found %v", d.sql, d.expected, err)
+			}
+		})
+	}
+}
+
 func TestParsePanic(t *testing.T) {
 	// Replicates #1801.
 	defer func() {
@@ -2908,7 +3002,6 @@ func TestUnimplementedSyntax(t *testing.T) {
 		{`DROP CAST a`, 0, `drop cast`},
 		{`DROP COLLATION a`, 0, `drop collation`},
 		{`DROP CONVERSION a`, 0, `drop conversion`},
-		{`DROP DOMAIN a`, 27796, `drop`},
 		{`DROP EXTENSION a`, 0, `drop extension a`},
 		{`DROP FOREIGN TABLE a`, 0, `drop foreign table`},
 		{`DROP FOREIGN DATA WRAPPER a`, 0, `drop fdw`},
@@ -2922,7 +3015,6 @@ func TestUnimplementedSyntax(t *testing.T) {
 		{`DROP SUBSCRIPTION a`, 0, `drop subscription`},
 		{`DROP TEXT SEARCH a`, 7821, `drop text`},
 		{`DROP TRIGGER a`, 28296, `drop`},
-		{`DROP TYPE a`, 27793, `drop type`},
 
 		{`DISCARD PLANS`, 0, `discard plans`},
 		{`DISCARD SEQUENCES`, 0, `discard sequences`},
@@ -2930,7 +3022,7 @@ func TestUnimplementedSyntax(t *testing.T) {
 		{`DISCARD TEMPORARY`, 0, `discard temp`},
 
 		{`SET CONSTRAINTS foo`, 0, `set constraints`},
-		{`SET LOCAL foo = bar`, 32562, ``},
+		{`SET LOCAL TRACING = on`, 0, `set local tracing`},
 		{`SET foo FROM CURRENT`, 0, `set from current`},
 
 		{`CREATE TEMP TABLE a(b INT8)`, 5807, ``},
@@ -2967,12 +3059,10 @@ func TestUnimplementedSyntax(t *testing.T) {
 		{`CREATE OR REPLACE VIEW a AS SELECT b`, 24897, ``},
 		{`CREATE RECURSIVE VIEW a AS SELECT b`, 0, `create recursive view`},
 
-		{`CREATE TYPE a AS (b)`, 27792, ``},
 		{`CREATE TYPE a AS ENUM (b)`, 24873, ``},
 		{`CREATE TYPE a AS RANGE b`, 27791, ``},
 		{`CREATE TYPE a (b)`, 27793, `base`},
 		{`CREATE TYPE a`, 27793, `shell`},
-		{`CREATE DOMAIN a`, 27796, `create`},
 
 		{`CREATE INDEX a ON b(c) WHERE d > 0`, 9683, ``},
 		{`CREATE INDEX a ON b USING HASH (c)`, 0, `index using hash`},
@@ -2987,7 +3077,7 @@ func TestUnimplementedSyntax(t *testing.T) {
 		{`INSERT INTO foo(a, a.b) VALUES (1,2)`, 27792, ``},
 		{`INSERT INTO foo VALUES (1,2) ON CONFLICT ON CONSTRAINT a DO NOTHING`, 28161, ``},
 
-		{`SELECT * FROM a FOR UPDATE`, 6583, ``},
+		{`SELECT * FROM a FOR SHARE`, 6583, ``},
 		{`SELECT * FROM ROWS FROM (a(b) AS (d))`, 0, `ROWS FROM with col_def_list`},
 
 		{`SELECT 123 AT TIME ZONE 'b'`, 32005, ``},
@@ -218,6 +218,12 @@ func (u *sqlSymUnion) colQualElem() tree.ColumnQualification {
 func (u *sqlSymUnion) colQuals() []tree.NamedColumnQualification {
     return u.val.([]tree.NamedColumnQualification)
 }
+func (u *sqlSymUnion) compositeTypeFields() []tree.CompositeTypeField {
+    return u.val.([]tree.CompositeTypeField)
+}
+func (u *sqlSymUnion) compositeTypeField() tree.CompositeTypeField {
+    return u.val.(tree.CompositeTypeField)
+}
 func (u *sqlSymUnion) colType() *types.T {
     if colType, ok := u.val.(*types.T); ok && colType != nil {
         return colType
@@ -245,6 +251,12 @@ func (u *sqlSymUnion) seqOpt() tree.SequenceOption {
 func (u *sqlSymUnion) seqOpts() []tree.SequenceOption {
     return u.val.([]tree.SequenceOption)
 }
+func (u *sqlSymUnion) roleOption() tree.RoleOption {
+    return u.val.(tree.RoleOption)
+}
+func (u *sqlSymUnion) roleOptions() tree.RoleOptions {
+    return u.val.(tree.RoleOptions)
+}
 func (u *sqlSymUnion) expr() tree.Expr {
     if expr, ok := u.val.(tree.Expr); ok {
         return expr
@@ -254,6 +266,12 @@ func (u *sqlSymUnion) expr() tree.Expr {
 func (u *sqlSymUnion) exprs() tree.Exprs {
     return u.val.(tree.Exprs)
 }
+func (u *sqlSymUnion) partitionedBackup() tree.PartitionedBackup {
+    return u.val.(tree.PartitionedBackup)
+}
+func (u *sqlSymUnion) partitionedBackups() []tree.PartitionedBackup {
+    return u.val.([]tree.PartitionedBackup)
+}
 func (u *sqlSymUnion) selExpr() tree.SelectExpr {
     return u.val.(tree.SelectExpr)
 }
@@ -485,7 +503,8 @@ func newNameFromStr(s string) *tree.Name {
 %token <str> CHARACTER CHARACTERISTICS CHECK
 %token <str> CLUSTER COALESCE COLLATE COLLATION COLUMN COLUMNS COMMENT COMMIT
 %token <str> COMMITTED COMPACT CONCAT CONFIGURATION CONFIGURATIONS CONFIGURE
-%token <str> CONFLICT CONSTRAINT CONSTRAINTS CONTAINS CONVERSION COPY COVERING CREATE
+%token <str> CONFLICT CONNECTION CONSTRAINT CONSTRAINTS CONTAINS CONVERSION COPY COVERING CREATE
+%token <str> CREATEDB CREATEROLE
 %token <str> CROSS CUBE CURRENT CURRENT_CATALOG CURRENT_DATE CURRENT_SCHEMA
 %token <str> CURRENT_ROLE CURRENT_TIME CURRENT_TIMESTAMP
 %token <str> CURRENT_USER CYCLE
@@ -520,11 +539,11 @@ func newNameFromStr(s string) *tree.Name {
 
 %token <str> LANGUAGE LATERAL LC_CTYPE LC_COLLATE
 %token <str> LEADING LEASE LEAST LEFT LESS LEVEL LIKE LIMIT LIST LOCAL
-%token <str> LOCALTIME LOCALTIMESTAMP LOOKUP LOW LSHIFT
+%token <str> LOCALTIME LOCALTIMESTAMP LOGIN LOOKUP LOW LSHIFT
 
 %token <str> MATCH MATERIALIZED MERGE MINVALUE MAXVALUE MINUTE MONTH
 
-%token <str> NAN NAME NAMES NATURAL NEXT NO NO_INDEX_JOIN NORMAL
+%token <str> NAN NAME NAMES NATURAL NEXT NO NOCREATEDB NOCREATEROLE NOLOGIN NO_INDEX_JOIN NORMAL
 %token <str> NOT NOTHING NOTNULL NULL NULLIF NUMERIC
 
 %token <str> OF OFF OFFSET OID OIDS OIDVECTOR ON ONLY OPT OPTION OPTIONS OR
@@ -536,13 +555,13 @@ func newNameFromStr(s string) *tree.Name {
 
 %token <str> QUERIES QUERY
 
-%token <str> RANGE RANGES READ REAL RECURSIVE REF REFERENCES
+%token <str> RANGE RANGES READ REAL RECURRING RECURSIVE REF REFERENCES
 %token <str> REGCLASS REGPROC REGPROCEDURE REGNAMESPACE REGTYPE
 %token <str> REMOVE_PATH RENAME REPEATABLE REPLACE
 %token <str> RELEASE RESET RESTORE RESTRICT RESUME RETURNING REVOKE RIGHT
 %token <str> ROLE ROLES ROLLBACK ROLLUP ROW ROWS RSHIFT RULE
 
-%token <str> SAVEPOINT SCATTER SCHEMA SCHEMAS SCRUB SEARCH SECOND SELECT SEQUENCE SEQUENCES
+%token <str> SAVEPOINT SCATTER SCHEDULE SCHEDULES SCHEMA SCHEMAS SCRUB SEARCH SECOND SELECT SEQUENCE SEQUENCES
 %token <str> SERIAL SERIAL2 SERIAL4 SERIAL8
 %token <str> SERIALIZABLE SERVER SESSION SESSIONS SESSION_USER SET SETTING SETTINGS
 %token <str> SHOW SIMILAR SIMPLE SMALLINT SMALLSERIAL SNAPSHOT SOME SPLIT SQL
@@ -555,7 +574,7 @@ func newNameFromStr(s string) *tree.Name {
 %token <str> TRUNCATE TRUSTED TYPE
 %token <str> TRACING
 
-%token <str> UNBOUNDED UNCOMMITTED UNION UNIQUE UNKNOWN UNLOGGED UNSPLIT
+%token <str> UNBOUNDED UNCOMMITTED UNION UNIQUE UNKNOWN UNLOGGED UNSPLIT UNTIL
 %token <str> UPDATE UPSERT USE USER USERS USING UUID
 
 %token <str> VALID VALIDATE VALUE VALUES VARBIT VARCHAR VARIADIC VIEW VARYING VIRTUAL
@@ -594,7 +613,9 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> alter_sequence_stmt
 %type <tree.Statement> alter_database_stmt
 %type <tree.Statement> alter_user_stmt
+%type <tree.Statement> alter_role_stmt
 %type <tree.Statement> alter_range_stmt
+%type <tree.Statement> alter_domain_stmt
 
 // ALTER RANGE
 %type <tree.Statement> alter_zone_range_stmt
@@ -614,7 +635,6 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> alter_zone_database_stmt
 
 // ALTER USER
-%type <tree.Statement> alter_user_password_stmt
 
 // ALTER INDEX
 %type <tree.Statement> alter_oneindex_stmt
@@ -655,6 +675,7 @@ func newNameFromStr(s string) *tree.Name {
 
 %type <tree.Statement> create_stmt
 %type <tree.Statement> create_changefeed_stmt
+%type <tree.Statement> create_schedule_for_backup_stmt
 %type <tree.Statement> create_ddl_stmt
 %type <tree.Statement> create_database_stmt
 %type <tree.Statement> create_index_stmt
@@ -671,6 +692,7 @@ func newNameFromStr(s string) *tree.Name {
 %type <*tree.CreateStatsOptions> create_stats_option
 
 %type <tree.Statement> create_type_stmt
+%type <tree.Statement> create_domain_stmt
 %type <tree.Statement> delete_stmt
 %type <tree.Statement> discard_stmt
 
@@ -683,6 +705,8 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> drop_user_stmt
 %type <tree.Statement> drop_view_stmt
 %type <tree.Statement> drop_sequence_stmt
+%type <tree.Statement> drop_domain_stmt
+%type <tree.Statement> drop_type_stmt
 
 %type <tree.Statement> explain_stmt
 %type <tree.Statement> prepare_stmt
@@ -694,10 +718,10 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> grant_stmt
 %type <tree.Statement> insert_stmt
 %type <tree.Statement> import_stmt
-%type <tree.Statement> pause_stmt
+%type <tree.Statement> pause_stmt pause_jobs_stmt pause_schedules_stmt
 %type <tree.Statement> release_stmt
 %type <tree.Statement> reset_stmt reset_session_stmt reset_csetting_stmt
-%type <tree.Statement> resume_stmt
+%type <tree.Statement> resume_stmt resume_jobs_stmt resume_schedules_stmt
 %type <tree.Statement> restore_stmt
 %type <tree.Statement> revoke_stmt
 %type <*tree.Select> select_stmt
@@ -726,6 +750,7 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> show_histogram_stmt
 %type <tree.Statement> show_indexes_stmt
 %type <tree.Statement> show_jobs_stmt
+%type <tree.Statement> show_schedules_stmt
 %type <tree.Statement> show_queries_stmt
 %type <tree.Statement> show_ranges_stmt
 %type <tree.Statement> show_roles_stmt
@@ -773,7 +798,8 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.ValidationBehavior> opt_validate_behavior
 
 %type <str> opt_template_clause opt_encoding_clause opt_lc_collate_clause opt_lc_ctype_clause
-%type <tree.Expr> opt_password
+%type <tree.RoleOption> role_option
+%type <tree.RoleOptions> role_options opt_role_options
 
 %type <tree.IsolationLevel> transaction_iso_level
 %type <tree.UserPriority> transaction_user_priority
@@ -812,6 +838,7 @@ func newNameFromStr(s string) *tree.Name {
 %type <[]tree.RangePartition> range_partitions
 %type <empty> opt_all_clause
 %type <bool> distinct_clause
+%type <bool> opt_for
 %type <tree.DistinctOn> distinct_on_clause
 %type <tree.NameList> opt_column_list insert_column_list opt_stats_columns
 %type <tree.OrderBy> sort_clause opt_sort_clause
@@ -907,6 +934,7 @@ func newNameFromStr(s string) *tree.Name {
 %type <*tree.UpdateExpr> single_set_clause
 %type <tree.AsOfClause> as_of_clause opt_as_of_clause
 %type <tree.Expr> opt_changefeed_sink
+%type <tree.Expr> opt_schedule_label opt_full_backup_clause
 
 %type <str> explain_option_name
 %type <[]string> explain_option_list
@@ -937,6 +965,8 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Expr> zone_value
 %type <tree.Expr> string_or_placeholder
 %type <tree.Expr> string_or_placeholder_list
+%type <tree.PartitionedBackup> partitioned_backup
+%type <[]tree.PartitionedBackup> partitioned_backup_list
 
 %type <str> unreserved_keyword type_func_name_keyword cockroachdb_extra_type_func_name_keyword
 %type <str> col_name_keyword reserved_keyword cockroachdb_extra_reserved_keyword extra_var_value
@@ -947,6 +977,11 @@ func newNameFromStr(s string) *tree.Name {
 %type <[]tree.NamedColumnQualification> col_qual_list
 %type <tree.NamedColumnQualification> col_qualification
 %type <tree.ColumnQualification> col_qualification_elem
+%type <[]tree.CompositeTypeField> opt_composite_type_field_list composite_type_field_list
+%type <tree.CompositeTypeField> composite_type_field
+%type <[]tree.NamedColumnQualification> opt_domain_constraint_list
+%type <tree.NamedColumnQualification> domain_constraint
+%type <tree.ColumnQualification> domain_check
 %type <tree.CompositeKeyMatchMethod> key_match
 %type <tree.ReferenceActions> reference_actions
 %type <tree.ReferenceAction> reference_action reference_on_delete reference_on_update
@@ -954,6 +989,7 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Expr> func_application func_expr_common_subexpr special_function
 %type <tree.Expr> func_expr func_expr_windowless
 %type <empty> opt_with
+%type <empty> opt_as
 %type <*tree.With> with_clause opt_with_clause
 %type <[]*tree.CTE> cte_list
 %type <*tree.CTE> common_table_expr
@@ -1077,10 +1113,12 @@ stmt:
 
 // %Help: ALTER
 // %Category: Group
-// %Text: ALTER TABLE, ALTER INDEX, ALTER VIEW, ALTER SEQUENCE, ALTER DATABASE, ALTER USER
+// %Text: ALTER TABLE, ALTER INDEX, ALTER VIEW, ALTER SEQUENCE, ALTER DATABASE, ALTER DOMAIN,
+// ALTER USER, ALTER ROLE
 alter_stmt:
   alter_ddl_stmt      // help texts in sub-rule
 | alter_user_stmt     // EXTEND WITH HELP: ALTER USER
+| alter_role_stmt     // EXTEND WITH HELP: ALTER ROLE
 | ALTER error         // SHOW HELP: ALTER
 
 alter_ddl_stmt:
@@ -1090,6 +1128,7 @@ alter_ddl_stmt:
 | alter_sequence_stmt // EXTEND WITH HELP: ALTER SEQUENCE
 | alter_database_stmt // EXTEND WITH HELP: ALTER DATABASE
 | alter_range_stmt    // EXTEND WITH HELP: ALTER RANGE
+| alter_domain_stmt   // EXTEND WITH HELP: ALTER DOMAIN
 
 // %Help: ALTER TABLE - change the definition of a table
 // %Category: DDL
@@ -1185,12 +1224,47 @@ alter_sequence_options_stmt:
 // %Help: ALTER USER - change user properties
 // %Category: Priv
 // %Text:
-// ALTER USER [IF EXISTS] <name> WITH PASSWORD <password>
-// %SeeAlso: CREATE USER
+// ALTER USER [IF EXISTS] <name> [WITH] <option> [<option>...]
+//
+// Options:
+//    LOGIN | NOLOGIN
+//    CREATEROLE | NOCREATEROLE
+//    CREATEDB | NOCREATEDB
+//    PASSWORD <passwd>
+//    VALID UNTIL { <timestamp> | NULL }
+//    CONNECTION LIMIT <limit>
+//
+// %SeeAlso: CREATE USER, ALTER ROLE
 alter_user_stmt:
-  alter_user_password_stmt
+  ALTER USER string_or_placeholder opt_with role_options
+  {
+    $$.val = &tree.AlterRole{Name: $3.expr(), Options: $5.roleOptions()}
+  }
+| ALTER USER IF EXISTS string_or_placeholder opt_with role_options
+  {
+    $$.val = &tree.AlterRole{Name: $5.expr(), Options: $7.roleOptions(), IfExists: true}
+  }
 | ALTER USER error // SHOW HELP: ALTER USER
 
+// %Help: ALTER ROLE - change role properties
+// %Category: Priv
+// %Text:
+// ALTER ROLE [IF EXISTS] <name> [WITH] <option> [<option>...]
+//
+// Options: see ALTER USER.
+//
+// %SeeAlso: CREATE ROLE, ALTER USER
+alter_role_stmt:
+  ALTER role_or_group string_or_placeholder opt_with role_options
+  {
+    $$.val = &tree.AlterRole{Name: $3.expr(), Options: $5.roleOptions(), IsRole: true}
+  }
+| ALTER role_or_group IF EXISTS string_or_placeholder opt_with role_options
+  {
+    $$.val = &tree.AlterRole{Name: $5.expr(), Options: $7.roleOptions(), IfExists: true, IsRole: true}
+  }
+| ALTER role_or_group error // SHOW HELP: ALTER ROLE
+
 // %Help: ALTER DATABASE - change the definition of a database
 // %Category: DDL
 // %Text:
@@ -1720,6 +1794,7 @@ opt_validate_behavior:
 //
 // Location:
 //    "[scheme]://[host]/[path to backup]?[parameters]"
+//    ( "[scheme]://[host]/[path to backup]?COCKROACH_LOCALITY=[key=value]" [, ...] )
 //
 // Options:
 //    INTO_DB
@@ -1727,12 +1802,72 @@ opt_validate_behavior:
 //
 // %SeeAlso: RESTORE, WEBDOCS/backup.html
 backup_stmt:
-  BACKUP targets TO string_or_placeholder opt_as_of_clause opt_incremental opt_with_options
+  BACKUP targets TO partitioned_backup opt_as_of_clause opt_incremental opt_with_options
   {
-    $$.val = &tree.Backup{Targets: $2.targetList(), To: $4.expr(), IncrementalFrom: $6.exprs(), AsOf: $5.asOfClause(), Options: $7.kvOptions()}
+    $$.val = &tree.Backup{Targets: $2.targetList(), To: $4.partitionedBackup(), IncrementalFrom: $6.exprs(), AsOf: $5.asOfClause(), Options: $7.kvOptions()}
   }
 | BACKUP error // SHOW HELP: BACKUP
 
+// %Help: CREATE SCHEDULE FOR BACKUP - backup data periodically
+// %Category: CCL
+// %Text:
+// CREATE SCHEDULE [<label>]
+// FOR BACKUP <targets...> TO <location>
+// [ WITH <option> [= <value>] [, ...] ]
+// RECURRING <cronexpr>
+// [ FULL BACKUP <cronexpr> ]
+//
+// Targets:
+//    TABLE <pattern> [, ...]
+//    DATABASE <databasename> [, ...]
+//
+// Location:
+//    "[scheme]://[host]/[path to backups]?[parameters]"
+//    Each backup is written to its own directory under the location.
+//
+// Cron expressions:
+//    "[minute] [hour] [day of month] [month] [day of week]"
+//    @yearly, @monthly, @weekly, @daily or @hourly
+//
+//    RECURRING describes when backups are taken. If FULL BACKUP is specified,
+//    backups taken between two full backups are incremental backups on top of
+//    the most recent full backup; otherwise every backup is a full backup.
+//
+// %SeeAlso: BACKUP, SHOW SCHEDULES, PAUSE SCHEDULES, RESUME SCHEDULES
+create_schedule_for_backup_stmt:
+  CREATE SCHEDULE opt_schedule_label FOR BACKUP targets TO string_or_placeholder opt_with_options RECURRING string_or_placeholder opt_full_backup_clause
+  {
+    $$.val = &tree.ScheduledBackup{
+      ScheduleName: $3.expr(),
+      Targets: $6.targetList(),
+      To: $8.expr(),
+      BackupOptions: $9.kvOptions(),
+      Recurrence: $11.expr(),
+      FullBackup: $12.expr(),
+    }
+  }
+| CREATE SCHEDULE error // SHOW HELP: CREATE SCHEDULE FOR BACKUP
+
+opt_schedule_label:
+  string_or_placeholder
+  {
+    $$.val = $1.expr()
+  }
+| /* EMPTY */
+  {
+    $$.val = nil
+  }
+
+opt_full_backup_clause:
+  FULL BACKUP string_or_placeholder
+  {
+    $$.val = $3.expr()
+  }
+| /* EMPTY */
+  {
+    $$.val = nil
+  }
+
 // %Help: RESTORE - restore data from external storage
 // %Category: CCL
 // %Text:
@@ -1746,6 +1881,7 @@ backup_stmt:
 //
 // Locations:
 //    "[scheme]://[host]/[path to backup]?[parameters]"
+//    ( "[scheme]://[host]/[path to backup]?COCKROACH_LOCALITY=[key=value]" [, ...] )
 //
 // Options:
 //    INTO_DB
@@ -1753,13 +1889,13 @@ backup_stmt:
 //
 // %SeeAlso: BACKUP, WEBDOCS/restore.html
 restore_stmt:
-  RESTORE targets FROM string_or_placeholder_list opt_with_options
+  RESTORE targets FROM partitioned_backup_list opt_with_options
   {
-    $$.val = &tree.Restore{Targets: $2.targetList(), From: $4.exprs(), Options: $5.kvOptions()}
+    $$.val = &tree.Restore{Targets: $2.targetList(), From: $4.partitionedBackups(), Options: $5.kvOptions()}
   }
-| RESTORE targets FROM string_or_placeholder_list as_of_clause opt_with_options
+| RESTORE targets FROM partitioned_backup_list as_of_clause opt_with_options
   {
-    $$.val = &tree.Restore{Targets: $2.targetList(), From: $4.exprs(), AsOf: $5.asOfClause(), Options: $6.kvOptions()}
+    $$.val = &tree.Restore{Targets: $2.targetList(), From: $4.partitionedBackups(), AsOf: $5.asOfClause(), Options: $6.kvOptions()}
   }
 | RESTORE error // SHOW HELP: RESTORE
 
@@ -1790,6 +1926,9 @@ import_format:
 //    MYSQLDUMP
 //    PGCOPY
 //    PGDUMP
+//    AVRO
+//    JSON
+//    PARQUET
 //
 // Options:
 //    distributed = '...'
@@ -1798,6 +1937,8 @@ import_format:
 //    delimiter = '...'      [CSV, PGCOPY-specific]
 //    nullif = '...'         [CSV, PGCOPY-specific]
 //    comment = '...'        [CSV-specific]
+//    strict_validation      [AVRO, JSON, PARQUET-specific]
+//    online                 [IMPORT INTO-specific]
 //
 // %SeeAlso: CREATE TABLE
 import_stmt:
@@ -1845,9 +1986,13 @@ import_stmt:
 //
 // Formats:
 //    CSV
+//    PARQUET
 //
 // Options:
-//    delimiter = '...'   [CSV-specific]
+//    delimiter = '...'       [CSV-specific]
+//    nullas = '...'          [CSV-specific]
+//    compression = '...'     [PARQUET-specific]
+//    row_group_size = '...'  [PARQUET-specific]
 //
 // %SeeAlso: SELECT
 export_stmt:
@@ -1879,6 +2024,26 @@ string_or_placeholder_list:
     $$.val = append($1.exprs(), $3.expr())
   }
 
+partitioned_backup:
+  string_or_placeholder
+  {
+    $$.val = tree.PartitionedBackup{$1.expr()}
+  }
+| '(' string_or_placeholder_list ')'
+  {
+    $$.val = tree.PartitionedBackup($2.exprs())
+  }
+
+partitioned_backup_list:
+  partitioned_backup
+  {
+    $$.val = []tree.PartitionedBackup{$1.partitionedBackup()}
+  }
+| partitioned_backup_list ',' partitioned_backup
+  {
+    $$.val = append($1.partitionedBackups(), $3.partitionedBackup())
+  }
+
 opt_incremental:
   INCREMENTAL FROM string_or_placeholder_list
   {
@@ -2092,12 +2257,13 @@ comment_text:
 // %Text:
 // CREATE DATABASE, CREATE TABLE, CREATE INDEX, CREATE TABLE AS,
 // CREATE USER, CREATE VIEW, CREATE SEQUENCE, CREATE STATISTICS,
-// CREATE ROLE
+// CREATE ROLE, CREATE DOMAIN, CREATE SCHEDULE FOR BACKUP
 create_stmt:
   create_user_stmt     // EXTEND WITH HELP: CREATE USER
 | create_role_stmt     // EXTEND WITH HELP: CREATE ROLE
 | create_ddl_stmt      // help texts in sub-rule
 | create_stats_stmt    // EXTEND WITH HELP: CREATE STATISTICS
+| create_schedule_for_backup_stmt // EXTEND WITH HELP: CREATE SCHEDULE FOR BACKUP
 | create_unsupported   {}
 | CREATE error         // SHOW HELP: CREATE
 
@@ -2141,7 +2307,6 @@ drop_unsupported:
 | DROP CAST error { return unimplemented(sqllex, "drop cast") }
 | DROP COLLATION error { return unimplemented(sqllex, "drop collation") }
 | DROP CONVERSION error { return unimplemented(sqllex, "drop conversion") }
-| DROP DOMAIN error { return unimplementedWithIssueDetail(sqllex, 27796, "drop") }
 | DROP EXTENSION IF EXISTS name error { return unimplemented(sqllex, "drop extension " + $5) }
 | DROP EXTENSION name error { return unimplemented(sqllex, "drop extension " + $3) }
 | DROP FOREIGN TABLE error { return unimplemented(sqllex, "drop foreign table") }
@@ -2155,7 +2320,6 @@ drop_unsupported:
 | DROP SERVER error { return unimplemented(sqllex, "drop server") }
 | DROP SUBSCRIPTION error { return unimplemented(sqllex, "drop subscription") }
 | DROP TEXT error { return unimplementedWithIssueDetail(sqllex, 7821, "drop text") }
-| DROP TYPE error { return unimplementedWithIssueDetail(sqllex, 27793, "drop type") }
 | DROP TRIGGER error { return unimplementedWithIssueDetail(sqllex, 28296, "drop") }
 
 create_ddl_stmt:
@@ -2166,9 +2330,10 @@ create_ddl_stmt:
 | create_table_as_stmt // EXTEND WITH HELP: CREATE TABLE
 // Error case for both CREATE TABLE and CREATE TABLE ... AS in one
 | CREATE opt_temp TABLE error   // SHOW HELP: CREATE TABLE
-| create_type_stmt     { /* SKIP DOC */ }
+| create_type_stmt     // EXTEND WITH HELP: CREATE TYPE
 | create_view_stmt     // EXTEND WITH HELP: CREATE VIEW
 | create_sequence_stmt // EXTEND WITH HELP: CREATE SEQUENCE
+| create_domain_stmt   // EXTEND WITH HELP: CREATE DOMAIN
 
 // %Help: CREATE STATISTICS - create a new table statistic
 // %Category: Misc
@@ -2274,6 +2439,14 @@ create_changefeed_stmt:
       Options: $6.kvOptions(),
     }
   }
+| CREATE CHANGEFEED opt_changefeed_sink opt_with_options AS select_stmt
+  {
+    $$.val = &tree.CreateChangefeed{
+      SinkURI: $3.expr(),
+      Options: $4.kvOptions(),
+      Select:  $6.slct(),
+    }
+  }
 | EXPERIMENTAL CHANGEFEED FOR changefeed_targets opt_with_options
   {
     /* SKIP DOC */
@@ -2282,6 +2455,14 @@ create_changefeed_stmt:
       Options: $5.kvOptions(),
     }
   }
+| EXPERIMENTAL CHANGEFEED opt_with_options AS select_stmt
+  {
+    /* SKIP DOC */
+    $$.val = &tree.CreateChangefeed{
+      Options: $3.kvOptions(),
+      Select:  $5.slct(),
+    }
+  }
 
 changefeed_targets:
   single_table_pattern_list
@@ -2354,7 +2535,7 @@ discard_stmt:
 // %Category: Group
 // %Text:
 // DROP DATABASE, DROP INDEX, DROP TABLE, DROP VIEW, DROP SEQUENCE,
-// DROP USER, DROP ROLE
+// DROP DOMAIN, DROP TYPE, DROP USER, DROP ROLE
 drop_stmt:
   drop_ddl_stmt      // help texts in sub-rule
 | drop_role_stmt     // EXTEND WITH HELP: DROP ROLE
@@ -2368,6 +2549,8 @@ drop_ddl_stmt:
 | drop_table_stmt    // EXTEND WITH HELP: DROP TABLE
 | drop_view_stmt     // EXTEND WITH HELP: DROP VIEW
 | drop_sequence_stmt // EXTEND WITH HELP: DROP SEQUENCE
+| drop_domain_stmt   // EXTEND WITH HELP: DROP DOMAIN
+| drop_type_stmt     // EXTEND WITH HELP: DROP TYPE
 
 // %Help: DROP VIEW - remove a view
 // %Category: DDL
@@ -2384,6 +2567,36 @@ drop_view_stmt:
   }
 | DROP VIEW error // SHOW HELP: DROP VIEW
 
+// %Help: DROP DOMAIN - remove a domain
+// %Category: DDL
+// %Text: DROP DOMAIN [IF EXISTS] <name> [, ...] [CASCADE | RESTRICT]
+// %SeeAlso: CREATE DOMAIN, ALTER DOMAIN
+drop_domain_stmt:
+  DROP DOMAIN table_name_list opt_drop_behavior
+  {
+    $$.val = &tree.DropDomain{Names: $3.tableNames(), IfExists: false, DropBehavior: $4.dropBehavior()}
+  }
+| DROP DOMAIN IF EXISTS table_name_list opt_drop_behavior
+  {
+    $$.val = &tree.DropDomain{Names: $5.tableNames(), IfExists: true, DropBehavior: $6.dropBehavior()}
+  }
+| DROP DOMAIN error // SHOW HELP: DROP DOMAIN
+
+// %Help: DROP TYPE - remove a type
+// %Category: DDL
+// %Text: DROP TYPE [IF EXISTS] <name> [, ...] [CASCADE | RESTRICT]
+// %SeeAlso: CREATE TYPE
+drop_type_stmt:
+  DROP TYPE table_name_list opt_drop_behavior
+  {
+    $$.val = &tree.DropType{Names: $3.tableNames(), IfExists: false, DropBehavior: $4.dropBehavior()}
+  }
+| DROP TYPE IF EXISTS table_name_list opt_drop_behavior
+  {
+    $$.val = &tree.DropType{Names: $5.tableNames(), IfExists: true, DropBehavior: $6.dropBehavior()}
+  }
+| DROP TYPE error // SHOW HELP: DROP TYPE
+
 // %Help: DROP SEQUENCE - remove a sequence
 // %Category: DDL
 // %Text: DROP SEQUENCE [IF EXISTS] <sequenceName> [, ...] [CASCADE | RESTRICT]
@@ -2552,10 +2765,10 @@ preparable_stmt:
 | explain_stmt      // EXTEND WITH HELP: EXPLAIN
 | import_stmt       // EXTEND WITH HELP: IMPORT
 | insert_stmt       // EXTEND WITH HELP: INSERT
-| pause_stmt        // EXTEND WITH HELP: PAUSE JOBS
+| pause_stmt        // help texts in sub-rule
 | reset_stmt        // help texts in sub-rule
 | restore_stmt      // EXTEND WITH HELP: RESTORE
-| resume_stmt       // EXTEND WITH HELP: RESUME JOBS
+| resume_stmt       // help texts in sub-rule
 | scrub_stmt        // help texts in sub-rule
 | select_stmt       // help texts in sub-rule
   {
@@ -2686,6 +2899,8 @@ deallocate_stmt:
 // %Text:
 // Grant privileges:
 //   GRANT {ALL | <privileges...> } ON <targets...> TO <grantees...>
+// Grant column privileges:
+//   GRANT {ALL | <privileges...> } ( <colnames...> ) ON [TABLE] <tablenames...> TO <grantees...>
 // Grant role membership (CCL only):
 //   GRANT <roles...> TO <grantees...> [WITH ADMIN OPTION]
 //
@@ -2702,6 +2917,10 @@ grant_stmt:
   {
     $$.val = &tree.Grant{Privileges: $2.privilegeList(), Grantees: $6.nameList(), Targets: $4.targetList()}
   }
+| GRANT privileges '(' name_list ')' ON targets TO name_list
+  {
+    $$.val = &tree.Grant{Privileges: $2.privilegeList(), Columns: $4.nameList(), Grantees: $9.nameList(), Targets: $7.targetList()}
+  }
 | GRANT privilege_list TO name_list
   {
     $$.val = &tree.GrantRole{Roles: $2.nameList(), Members: $4.nameList(), AdminOption: false}
@@ -2717,6 +2936,8 @@ grant_stmt:
 // %Text:
 // Revoke privileges:
 //   REVOKE {ALL | <privileges...> } ON <targets...> FROM <grantees...>
+// Revoke column privileges:
+//   REVOKE {ALL | <privileges...> } ( <colnames...> ) ON [TABLE] <tablenames...> FROM <grantees...>
 // Revoke role membership (CCL only):
 //   REVOKE [ADMIN OPTION FOR] <roles...> FROM <grantees...>
 //
@@ -2733,6 +2954,10 @@ revoke_stmt:
   {
     $$.val = &tree.Revoke{Privileges: $2.privilegeList(), Grantees: $6.nameList(), Targets: $4.targetList()}
   }
+| REVOKE privileges '(' name_list ')' ON targets FROM name_list
+  {
+    $$.val = &tree.Revoke{Privileges: $2.privilegeList(), Columns: $4.nameList(), Grantees: $9.nameList(), Targets: $7.targetList()}
+  }
 | REVOKE privilege_list FROM name_list
   {
     $$.val = &tree.RevokeRole{Roles: $2.nameList(), Members: $4.nameList(), AdminOption: false }
@@ -2826,7 +3051,6 @@ nonpreparable_set_stmt:
   set_transaction_stmt // EXTEND WITH HELP: SET TRANSACTION
 | set_exprs_internal   { /* SKIP DOC */ }
 | SET CONSTRAINTS error { return unimplemented(sqllex, "set constraints") }
-| SET LOCAL error { return unimplementedWithIssue(sqllex, 32562) }
 
 // SET SESSION / SET CLUSTER SETTING
 preparable_set_stmt:
@@ -2963,11 +3187,13 @@ set_exprs_internal:
 // %Help: SET SESSION - change a session variable
 // %Category: Cfg
 // %Text:
-// SET [SESSION] <var> { TO | = } <values...>
-// SET [SESSION] TIME ZONE <tz>
+// SET [SESSION | LOCAL] <var> { TO | = } <values...>
+// SET [SESSION | LOCAL] TIME ZONE <tz>
 // SET [SESSION] CHARACTERISTICS AS TRANSACTION ISOLATION LEVEL { SNAPSHOT | SERIALIZABLE }
 // SET [SESSION] TRACING { TO | = } { on | off | cluster | local | kv | results } [,...]
 //
+// SET LOCAL only changes the variable until the end of the current transaction.
+//
 // %SeeAlso: SHOW SESSION, RESET, DISCARD, SHOW, SET CLUSTER SETTING, SET TRANSACTION,
 // WEBDOCS/set-vars.html
 set_session_stmt:
@@ -2979,6 +3205,16 @@ set_session_stmt:
   {
     $$.val = $2.stmt()
   }
+| SET LOCAL set_rest_more
+  {
+    /* FORCE DOC */
+    sv, ok := $3.stmt().(*tree.SetVar)
+    if !ok {
+      return unimplemented(sqllex, "set local tracing")
+    }
+    sv.Local = true
+    $$.val = sv
+  }
 // Special form for pg compatibility:
 | SET SESSION CHARACTERISTICS AS TRANSACTION transaction_mode_list
   {
@@ -3182,9 +3418,9 @@ zone_value:
 // %Text:
 // SHOW BACKUP, SHOW CLUSTER SETTING, SHOW COLUMNS, SHOW CONSTRAINTS,
 // SHOW CREATE, SHOW DATABASES, SHOW HISTOGRAM, SHOW INDEXES, SHOW
-// JOBS, SHOW QUERIES, SHOW ROLES, SHOW SCHEMAS, SHOW SEQUENCES, SHOW
-// SESSION, SHOW SESSIONS, SHOW STATISTICS, SHOW SYNTAX, SHOW TABLES,
-// SHOW TRACE SHOW TRANSACTION, SHOW USERS
+// JOBS, SHOW QUERIES, SHOW ROLES, SHOW SCHEDULES, SHOW SCHEMAS, SHOW
+// SEQUENCES, SHOW SESSION, SHOW SESSIONS, SHOW STATISTICS, SHOW SYNTAX,
+// SHOW TABLES, SHOW TRACE SHOW TRANSACTION, SHOW USERS
 show_stmt:
   show_backup_stmt          // EXTEND WITH HELP: SHOW BACKUP
 | show_columns_stmt         // EXTEND WITH HELP: SHOW COLUMNS
@@ -3200,6 +3436,7 @@ show_stmt:
 | show_queries_stmt         // EXTEND WITH HELP: SHOW QUERIES
 | show_ranges_stmt          // EXTEND WITH HELP: SHOW RANGES
 | show_roles_stmt           // EXTEND WITH HELP: SHOW ROLES
+| show_schedules_stmt       // EXTEND WITH HELP: SHOW SCHEDULES
 | show_schemas_stmt         // EXTEND WITH HELP: SHOW SCHEMAS
 | show_sequences_stmt       // EXTEND WITH HELP: SHOW SEQUENCES
 | show_session_stmt         // EXTEND WITH HELP: SHOW SESSION
@@ -3280,30 +3517,33 @@ show_histogram_stmt:
 
 // %Help: SHOW BACKUP - list backup contents
 // %Category: CCL
-// %Text: SHOW BACKUP [FILES|RANGES] <location>
+// %Text: SHOW BACKUP [FILES|RANGES] <location> [WITH <option> [= <value>] [, ...]]
 // %SeeAlso: WEBDOCS/show-backup.html
 show_backup_stmt:
-  SHOW BACKUP string_or_placeholder
+  SHOW BACKUP string_or_placeholder opt_with_options
   {
     $$.val = &tree.ShowBackup{
       Details: tree.BackupDefaultDetails,
       Path:    $3.expr(),
+      Options: $4.kvOptions(),
     }
   }
-| SHOW BACKUP RANGES string_or_placeholder
+| SHOW BACKUP RANGES string_or_placeholder opt_with_options
   {
     /* SKIP DOC */
     $$.val = &tree.ShowBackup{
       Details: tree.BackupRangeDetails,
       Path:    $4.expr(),
+      Options: $5.kvOptions(),
     }
   }
-| SHOW BACKUP FILES string_or_placeholder
+| SHOW BACKUP FILES string_or_placeholder opt_with_options
   {
     /* SKIP DOC */
     $$.val = &tree.ShowBackup{
       Details: tree.BackupFileDetails,
       Path:    $4.expr(),
+      Options: $5.kvOptions(),
     }
   }
 | SHOW BACKUP error // SHOW HELP: SHOW BACKUP
@@ -3457,6 +3697,17 @@ show_jobs_stmt:
   }
 | SHOW opt_automatic JOBS error // SHOW HELP: SHOW JOBS
 
+// %Help: SHOW SCHEDULES - list schedules
+// %Category: Misc
+// %Text: SHOW SCHEDULES
+// %SeeAlso: CREATE SCHEDULE FOR BACKUP, PAUSE SCHEDULES, RESUME SCHEDULES
+show_schedules_stmt:
+  SHOW SCHEDULES
+  {
+    $$.val = &tree.ShowSchedules{}
+  }
+| SHOW SCHEDULES error // SHOW HELP: SHOW SCHEDULES
+
 opt_automatic:
   AUTOMATIC { $$.val = true }
 | /* EMPTY */ { $$.val = false }
@@ -3930,13 +4181,18 @@ for_grantee_clause:
     $$.val = tree.NameList(nil)
   }
 
+pause_stmt:
+  pause_jobs_stmt      // EXTEND WITH HELP: PAUSE JOBS
+| pause_schedules_stmt // EXTEND WITH HELP: PAUSE SCHEDULES
+| PAUSE error          // SHOW HELP: PAUSE JOBS
+
 // %Help: PAUSE JOBS - pause background jobs
 // %Category: Misc
 // %Text:
 // PAUSE JOBS <selectclause>
 // PAUSE JOB <jobid>
 // %SeeAlso: SHOW JOBS, CANCEL JOBS, RESUME JOBS
-pause_stmt:
+pause_jobs_stmt:
   PAUSE JOB a_expr
   {
     $$.val = &tree.ControlJobs{
@@ -3946,11 +4202,35 @@ pause_stmt:
       Command: tree.PauseJob,
     }
   }
+| PAUSE JOB error // SHOW HELP: PAUSE JOBS
 | PAUSE JOBS select_stmt
   {
     $$.val = &tree.ControlJobs{Jobs: $3.slct(), Command: tree.PauseJob}
   }
-| PAUSE error // SHOW HELP: PAUSE JOBS
+| PAUSE JOBS error // SHOW HELP: PAUSE JOBS
+
+// %Help: PAUSE SCHEDULES - stop executing schedules
+// %Category: Misc
+// %Text:
+// PAUSE SCHEDULES <selectclause>
+// PAUSE SCHEDULE <scheduleid>
+// %SeeAlso: SHOW SCHEDULES, RESUME SCHEDULES, CREATE SCHEDULE FOR BACKUP
+pause_schedules_stmt:
+  PAUSE SCHEDULE a_expr
+  {
+    $$.val = &tree.ControlSchedules{
+      Schedules: &tree.Select{
+        Select: &tree.ValuesClause{Rows: []tree.Exprs{tree.Exprs{$3.expr()}}},
+      },
+      Command: tree.PauseSchedule,
+    }
+  }
+| PAUSE SCHEDULE error // SHOW HELP: PAUSE SCHEDULES
+| PAUSE SCHEDULES select_stmt
+  {
+    $$.val = &tree.ControlSchedules{Schedules: $3.slct(), Command: tree.PauseSchedule}
+  }
+| PAUSE SCHEDULES error // SHOW HELP: PAUSE SCHEDULES
 
 // %Help: CREATE TABLE - create a new table
 // %Category: DDL
@@ -4644,41 +4924,105 @@ truncate_stmt:
 
 // %Help: CREATE USER - define a new user
 // %Category: Priv
-// %Text: CREATE USER [IF NOT EXISTS] <name> [ [WITH] PASSWORD <passwd> ]
-// %SeeAlso: DROP USER, SHOW USERS, WEBDOCS/create-user.html
+// %Text: CREATE USER [IF NOT EXISTS] <name> [ [WITH] <option> [<option>...] ]
+//
+// Options:
+//    LOGIN | NOLOGIN
+//    CREATEROLE | NOCREATEROLE
+//    CREATEDB | NOCREATEDB
+//    PASSWORD <passwd>
+//    VALID UNTIL { <timestamp> | NULL }
+//    CONNECTION LIMIT <limit>
+//
+// %SeeAlso: DROP USER, SHOW USERS, ALTER USER, WEBDOCS/create-user.html
 create_user_stmt:
-  CREATE USER string_or_placeholder opt_password
+  CREATE USER string_or_placeholder opt_role_options
   {
-    $$.val = &tree.CreateUser{Name: $3.expr(), Password: $4.expr()}
+    $$.val = &tree.CreateUser{Name: $3.expr(), Options: $4.roleOptions()}
   }
-| CREATE USER IF NOT EXISTS string_or_placeholder opt_password
+| CREATE USER IF NOT EXISTS string_or_placeholder opt_role_options
   {
-    $$.val = &tree.CreateUser{Name: $6.expr(), Password: $7.expr(), IfNotExists: true}
+    $$.val = &tree.CreateUser{Name: $6.expr(), Options: $7.roleOptions(), IfNotExists: true}
   }
 | CREATE USER error // SHOW HELP: CREATE USER
 
-opt_password:
-  opt_with PASSWORD string_or_placeholder
+opt_role_options:
+  opt_with role_options
   {
-    $$.val = $3.expr()
+    $$.val = $2.roleOptions()
   }
 | /* EMPTY */
   {
-    $$.val = nil
+    $$.val = tree.RoleOptions(nil)
+  }
+
+role_options:
+  role_option
+  {
+    $$.val = tree.RoleOptions{$1.roleOption()}
+  }
+| role_options role_option
+  {
+    $$.val = append($1.roleOptions(), $2.roleOption())
+  }
+
+role_option:
+  LOGIN
+  {
+    $$.val = tree.RoleOption{Name: "LOGIN"}
+  }
+| NOLOGIN
+  {
+    $$.val = tree.RoleOption{Name: "NOLOGIN"}
+  }
+| CREATEROLE
+  {
+    $$.val = tree.RoleOption{Name: "CREATEROLE"}
+  }
+| NOCREATEROLE
+  {
+    $$.val = tree.RoleOption{Name: "NOCREATEROLE"}
+  }
+| CREATEDB
+  {
+    $$.val = tree.RoleOption{Name: "CREATEDB"}
+  }
+| NOCREATEDB
+  {
+    $$.val = tree.RoleOption{Name: "NOCREATEDB"}
+  }
+| PASSWORD string_or_placeholder
+  {
+    $$.val = tree.RoleOption{Name: "PASSWORD", Value: $2.expr()}
+  }
+| VALID UNTIL string_or_placeholder
+  {
+    $$.val = tree.RoleOption{Name: "VALID UNTIL", Value: $3.expr()}
+  }
+| VALID UNTIL NULL
+  {
+    $$.val = tree.RoleOption{Name: "VALID UNTIL", Value: tree.DNull}
+  }
+| CONNECTION LIMIT signed_iconst
+  {
+    $$.val = tree.RoleOption{Name: "CONNECTION LIMIT", Value: $3.expr()}
   }
 
 // %Help: CREATE ROLE - define a new role
 // %Category: Priv
-// %Text: CREATE ROLE [IF NOT EXISTS] <name>
-// %SeeAlso: DROP ROLE, SHOW ROLES
+// %Text: CREATE ROLE [IF NOT EXISTS] <name> [ [WITH] <option> [<option>...] ]
+//
+// Options: see CREATE USER. Roles cannot log in unless LOGIN is specified.
+//
+// %SeeAlso: DROP ROLE, SHOW ROLES, ALTER ROLE
 create_role_stmt:
-  CREATE role_or_group string_or_placeholder
+  CREATE role_or_group string_or_placeholder opt_role_options
   {
-    $$.val = &tree.CreateRole{Name: $3.expr()}
+    $$.val = &tree.CreateRole{Name: $3.expr(), Options: $4.roleOptions()}
   }
-| CREATE role_or_group IF NOT EXISTS string_or_placeholder
+| CREATE role_or_group IF NOT EXISTS string_or_placeholder opt_role_options
   {
-    $$.val = &tree.CreateRole{Name: $6.expr(), IfNotExists: true}
+    $$.val = &tree.CreateRole{Name: $6.expr(), Options: $7.roleOptions(), IfNotExists: true}
   }
 | CREATE role_or_group error // SHOW HELP: CREATE ROLE
 
@@ -4709,12 +5053,22 @@ opt_view_recursive:
   /* EMPTY */ { /* no error */ }
 | RECURSIVE { return unimplemented(sqllex, "create recursive view") }
 
-// CREATE TYPE/DOMAIN is not yet supported by CockroachDB but we
-// want to report it with the right issue number.
+// %Help: CREATE TYPE - create a new composite type
+// %Category: DDL
+// %Text:
+// CREATE TYPE <name> AS ( [<fieldname> <type> [, ...]] )
+//
+// The fields of a value of the type are accessed with (<expr>).<fieldname>.
+//
+// %SeeAlso: DROP TYPE
 create_type_stmt:
   // Record/Composite types.
-  CREATE TYPE type_name AS '(' error      { return unimplementedWithIssue(sqllex, 27792) }
-  // Enum types.
+  CREATE TYPE type_name AS '(' opt_composite_type_field_list ')'
+  {
+    $$.val = &tree.CreateType{Name: $3.unresolvedObjectName().ToTableName(), Fields: $6.compositeTypeFields()}
+  }
+  // Enum types, not yet supported by CockroachDB; we want to report them
+  // and the types below with the right issue number.
 | CREATE TYPE type_name AS ENUM '(' error { return unimplementedWithIssue(sqllex, 24873) }
   // Range types.
 | CREATE TYPE type_name AS RANGE error    { return unimplementedWithIssue(sqllex, 27791) }
@@ -4722,8 +5076,133 @@ create_type_stmt:
 | CREATE TYPE type_name '(' error         { return unimplementedWithIssueDetail(sqllex, 27793, "base") }
   // Shell types, gateway to define base types using the previous syntax.
 | CREATE TYPE type_name                   { return unimplementedWithIssueDetail(sqllex, 27793, "shell") }
-  // Domain types.
-| CREATE DOMAIN type_name error           { return unimplementedWithIssueDetail(sqllex, 27796, "create") }
+| CREATE TYPE error                       // SHOW HELP: CREATE TYPE
+
+opt_composite_type_field_list:
+  composite_type_field_list
+| /* EMPTY */
+  {
+    $$.val = []tree.CompositeTypeField(nil)
+  }
+
+composite_type_field_list:
+  composite_type_field
+  {
+    $$.val = []tree.CompositeTypeField{$1.compositeTypeField()}
+  }
+| composite_type_field_list ',' composite_type_field
+  {
+    $$.val = append($1.compositeTypeFields(), $3.compositeTypeField())
+  }
+
+composite_type_field:
+  name typename
+  {
+    $$.val = tree.CompositeTypeField{Name: tree.Name($1), Type: $2.colType()}
+  }
+
+// %Help: CREATE DOMAIN - create a new domain
+// %Category: DDL
+// %Text:
+// CREATE DOMAIN <name> [AS] <type> [<constraint> ...]
+//
+// Constraints:
+//    DEFAULT <expr>
+//    NULL | NOT NULL
+//    [CONSTRAINT <constraintname>] CHECK (<expr>)
+//
+// Check expressions refer to the value being checked as VALUE.
+//
+// %SeeAlso: ALTER DOMAIN, DROP DOMAIN
+create_domain_stmt:
+  CREATE DOMAIN type_name opt_as typename opt_domain_constraint_list
+  {
+    d, err := tree.NewCreateDomain($3.unresolvedObjectName().ToTableName(), $5.colType(), $6.colQuals())
+    if err != nil {
+      return setErr(sqllex, err)
+    }
+    $$.val = d
+  }
+| CREATE DOMAIN error // SHOW HELP: CREATE DOMAIN
+
+opt_domain_constraint_list:
+  opt_domain_constraint_list domain_constraint
+  {
+    $$.val = append($1.colQuals(), $2.colQual())
+  }
+| /* EMPTY */
+  {
+    $$.val = []tree.NamedColumnQualification(nil)
+  }
+
+domain_constraint:
+  CONSTRAINT constraint_name domain_check
+  {
+    $$.val = tree.NamedColumnQualification{Name: tree.Name($2), Qualification: $3.colQualElem()}
+  }
+| domain_check
+  {
+    $$.val = tree.NamedColumnQualification{Qualification: $1.colQualElem()}
+  }
+| NOT NULL
+  {
+    $$.val = tree.NamedColumnQualification{Qualification: tree.NotNullConstraint{}}
+  }
+| NULL
+  {
+    $$.val = tree.NamedColumnQualification{Qualification: tree.NullConstraint{}}
+  }
+| DEFAULT b_expr
+  {
+    $$.val = tree.NamedColumnQualification{Qualification: &tree.ColumnDefault{Expr: $2.expr()}}
+  }
+
+domain_check:
+  CHECK '(' a_expr ')'
+  {
+    $$.val = &tree.ColumnCheckConstraint{Expr: $3.expr()}
+  }
+
+// %Help: ALTER DOMAIN - change the definition of a domain
+// %Category: DDL
+// %Text:
+// ALTER DOMAIN <name> ADD [CONSTRAINT <constraintname>] CHECK (<expr>)
+// ALTER DOMAIN <name> DROP CONSTRAINT [IF EXISTS] <constraintname>
+//
+// Adding a constraint validates it against the existing values of all
+// columns of the domain.
+//
+// %SeeAlso: CREATE DOMAIN, DROP DOMAIN
+alter_domain_stmt:
+  ALTER DOMAIN type_name ADD CONSTRAINT constraint_name CHECK '(' a_expr ')'
+  {
+    $$.val = &tree.AlterDomain{
+      Name: $3.unresolvedObjectName().ToTableName(),
+      Cmd: &tree.AlterDomainAddConstraint{Check: tree.DomainCheck{Name: tree.Name($6), Expr: $9.expr()}},
+    }
+  }
+| ALTER DOMAIN type_name ADD CHECK '(' a_expr ')'
+  {
+    $$.val = &tree.AlterDomain{
+      Name: $3.unresolvedObjectName().ToTableName(),
+      Cmd: &tree.AlterDomainAddConstraint{Check: tree.DomainCheck{Expr: $7.expr()}},
+    }
+  }
+| ALTER DOMAIN type_name DROP CONSTRAINT constraint_name
+  {
+    $$.val = &tree.AlterDomain{
+      Name: $3.unresolvedObjectName().ToTableName(),
+      Cmd: &tree.AlterDomainDropConstraint{Constraint: tree.Name($6)},
+    }
+  }
+| ALTER DOMAIN type_name DROP CONSTRAINT IF EXISTS constraint_name
+  {
+    $$.val = &tree.AlterDomain{
+      Name: $3.unresolvedObjectName().ToTableName(),
+      Cmd: &tree.AlterDomainDropConstraint{IfExists: true, Constraint: tree.Name($8)},
+    }
+  }
+| ALTER DOMAIN error // SHOW HELP: ALTER DOMAIN
 
 // %Help: CREATE INDEX - create a new index
 // %Category: DDL
@@ -4882,17 +5361,6 @@ alter_rename_database_stmt:
     $$.val = &tree.RenameDatabase{Name: tree.Name($3), NewName: tree.Name($6)}
   }
 
-// https://www.postgresql.org/docs/10/static/sql-alteruser.html
-alter_user_password_stmt:
-  ALTER USER string_or_placeholder WITH PASSWORD string_or_placeholder
-  {
-    $$.val = &tree.AlterUserSetPassword{Name: $3.expr(), Password: $6.expr()}
-  }
-| ALTER USER IF EXISTS string_or_placeholder WITH PASSWORD string_or_placeholder
-  {
-    $$.val = &tree.AlterUserSetPassword{Name: $5.expr(), Password: $8.expr(), IfExists: true}
-  }
-
 alter_rename_table_stmt:
   ALTER TABLE relation_expr RENAME TO table_name
   {
@@ -4964,13 +5432,18 @@ release_stmt:
   }
 | RELEASE error // SHOW HELP: RELEASE
 
+resume_stmt:
+  resume_jobs_stmt      // EXTEND WITH HELP: RESUME JOBS
+| resume_schedules_stmt // EXTEND WITH HELP: RESUME SCHEDULES
+| RESUME error          // SHOW HELP: RESUME JOBS
+
 // %Help: RESUME JOBS - resume background jobs
 // %Category: Misc
 // %Text:
 // RESUME JOBS <selectclause>
 // RESUME JOB <jobid>
 // %SeeAlso: SHOW JOBS, CANCEL JOBS, PAUSE JOBS
-resume_stmt:
+resume_jobs_stmt:
   RESUME JOB a_expr
   {
     $$.val = &tree.ControlJobs{
@@ -4980,11 +5453,35 @@ resume_stmt:
       Command: tree.ResumeJob,
     }
   }
+| RESUME JOB error // SHOW HELP: RESUME JOBS
 | RESUME JOBS select_stmt
   {
     $$.val = &tree.ControlJobs{Jobs: $3.slct(), Command: tree.ResumeJob}
   }
-| RESUME error // SHOW HELP: RESUME JOBS
+| RESUME JOBS error // SHOW HELP: RESUME JOBS
+
+// %Help: RESUME SCHEDULES - resume executing schedules
+// %Category: Misc
+// %Text:
+// RESUME SCHEDULES <selectclause>
+// RESUME SCHEDULE <scheduleid>
+// %SeeAlso: SHOW SCHEDULES, PAUSE SCHEDULES, CREATE SCHEDULE FOR BACKUP
+resume_schedules_stmt:
+  RESUME SCHEDULE a_expr
+  {
+    $$.val = &tree.ControlSchedules{
+      Schedules: &tree.Select{
+        Select: &tree.ValuesClause{Rows: []tree.Exprs{tree.Exprs{$3.expr()}}},
+      },
+      Command: tree.ResumeSchedule,
+    }
+  }
+| RESUME SCHEDULE error // SHOW HELP: RESUME SCHEDULES
+| RESUME SCHEDULES select_stmt
+  {
+    $$.val = &tree.ControlSchedules{Schedules: $3.slct(), Command: tree.ResumeSchedule}
+  }
+| RESUME SCHEDULES error // SHOW HELP: RESUME SCHEDULES
 
 // %Help: SAVEPOINT - start a retryable block
 // %Category: Txn
@@ -5514,31 +6011,39 @@ select_with_parens:
 select_no_parens:
   simple_select opt_for
   {
-    $$.val = &tree.Select{Select: $1.selectStmt()}
+    $$.val = &tree.Select{Select: $1.selectStmt(), ForUpdate: $2.bool()}
   }
 | select_clause sort_clause opt_for
   {
-    $$.val = &tree.Select{Select: $1.selectStmt(), OrderBy: $2.orderBy()}
+    $$.val = &tree.Select{Select: $1.selectStmt(), OrderBy: $2.orderBy(), ForUpdate: $3.bool()}
   }
 | select_clause opt_sort_clause select_limit opt_for
   {
-    $$.val = &tree.Select{Select: $1.selectStmt(), OrderBy: $2.orderBy(), Limit: $3.limit()}
+    $$.val = &tree.Select{Select: $1.selectStmt(), OrderBy: $2.orderBy(), Limit: $3.limit(), ForUpdate: $4.bool()}
   }
 | with_clause select_clause opt_for
   {
-    $$.val = &tree.Select{With: $1.with(), Select: $2.selectStmt()}
+    $$.val = &tree.Select{With: $1.with(), Select: $2.selectStmt(), ForUpdate: $3.bool()}
   }
 | with_clause select_clause sort_clause opt_for
   {
-    $$.val = &tree.Select{With: $1.with(), Select: $2.selectStmt(), OrderBy: $3.orderBy()}
+    $$.val = &tree.Select{With: $1.with(), Select: $2.selectStmt(), OrderBy: $3.orderBy(), ForUpdate: $4.bool()}
   }
 | with_clause select_clause opt_sort_clause select_limit opt_for
   {
-    $$.val = &tree.Select{With: $1.with(), Select: $2.selectStmt(), OrderBy: $3.orderBy(), Limit: $4.limit()}
+    $$.val = &tree.Select{With: $1.with(), Select: $2.selectStmt(), OrderBy: $3.orderBy(), Limit: $4.limit(), ForUpdate: $5.bool()}
   }
 
+// Only FOR UPDATE without a table list or wait policy is supported.
 opt_for:
-  /* EMPTY */ { /* no error */ }
+  /* EMPTY */
+  {
+    $$.val = false
+  }
+| FOR UPDATE
+  {
+    $$.val = true
+  }
 | FOR error { return unimplementedWithIssue(sqllex, 6583) }
 
 select_clause:
@@ -5731,6 +6236,10 @@ opt_with:
   WITH {}
 | /* EMPTY */ {}
 
+opt_as:
+  AS {}
+| /* EMPTY */ {}
+
 opt_with_clause:
   with_clause
   {
@@ -6519,6 +7028,15 @@ simple_typename:
 | const_interval
 | const_interval interval_qualifier { return unimplemented(sqllex, "interval with unit qualifier") }
 | const_interval '(' ICONST ')' { return unimplementedWithIssue(sqllex, 32564) }
+  // Qualified names can only refer to domains and composite types.
+| IDENT '.' unrestricted_name
+  {
+    $$.val = types.MakeQualifiedDomainReference([]string{$1}, $3)
+  }
+| IDENT '.' unrestricted_name '.' unrestricted_name
+  {
+    $$.val = types.MakeQualifiedDomainReference([]string{$1, $3}, $5)
+  }
 
 // We have a separate const_typename to allow defaulting fixed-length types
 // such as CHAR() and BIT() to an unspecified length. SQL9x requires that these
@@ -6624,10 +7142,9 @@ const_typename:
       if !ok {
           switch unimp {
               case 0:
-                // Note: we can only report an unimplemented error for specific
-                // known type names. Anything else may return PII.
-                sqllex.Error("type does not exist")
-                return 1
+                // The name may refer to a domain, which is resolved during
+                // semantic analysis.
+                $$.val = types.MakeDomainReference($1)
               case -1:
                 return unimplemented(sqllex, "type name " + $1)
               default:
@@ -8917,10 +9434,13 @@ unreserved_keyword:
 | CONFIGURATION
 | CONFIGURATIONS
 | CONFIGURE
+| CONNECTION
 | CONSTRAINTS
 | CONVERSION
 | COPY
 | COVERING
+| CREATEDB
+| CREATEROLE
 | CUBE
 | CURRENT
 | CYCLE
@@ -8996,6 +9516,7 @@ unreserved_keyword:
 | LEVEL
 | LIST
 | LOCAL
+| LOGIN
 | LOOKUP
 | LOW
 | MATCH
@@ -9010,6 +9531,9 @@ unreserved_keyword:
 | NAME
 | NEXT
 | NO
+| NOCREATEDB
+| NOCREATEROLE
+| NOLOGIN
 | NORMAL
 | NO_INDEX_JOIN
 | IGNORE_FOREIGN_KEYS
@@ -9042,6 +9566,7 @@ unreserved_keyword:
 | RANGE
 | RANGES
 | READ
+| RECURRING
 | RECURSIVE
 | REF
 | REGCLASS
@@ -9069,6 +9594,8 @@ unreserved_keyword:
 | STATUS
 | SAVEPOINT
 | SCATTER
+| SCHEDULE
+| SCHEDULES
 | SCHEMA
 | SCHEMAS
 | SCRUB
@@ -9121,6 +9648,7 @@ unreserved_keyword:
 | UNKNOWN
 | UNLOGGED
 | UNSPLIT
+| UNTIL
 | UPDATE
 | UPSERT
 | UUID
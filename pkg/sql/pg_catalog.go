@@ -20,6 +20,7 @@ import (
 	"hash"
 	"hash/fnv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
@@ -1815,25 +1816,25 @@ CREATE TABLE pg_catalog.pg_roles (
 		// need to do the same. This shouldn't be an issue, because pg_roles doesn't
 		// include sensitive information such as password hashes.
 		h := makeOidHasher()
-		return forEachRole(ctx, p,
-			func(username string, isRole bool) error {
+		return forEachRoleWithAttributes(ctx, p,
+			func(username string, isRole bool, attrs roleAttributes) error {
 				isRoot := tree.DBool(username == security.RootUser || username == sqlbase.AdminRole)
 				isRoleDBool := tree.DBool(isRole)
 				return addRow(
-					h.UserOid(username),          // oid
-					tree.NewDName(username),      // rolname
-					tree.MakeDBool(isRoot),       // rolsuper
-					tree.MakeDBool(isRoleDBool),  // rolinherit. Roles inherit by default.
-					tree.MakeDBool(isRoot),       // rolcreaterole
-					tree.MakeDBool(isRoot),       // rolcreatedb
-					tree.DBoolFalse,              // rolcatupdate
-					tree.MakeDBool(!isRoleDBool), // rolcanlogin. Only users can login.
-					tree.DBoolFalse,              // rolreplication
-					negOneVal,                    // rolconnlimit
-					passwdStarString,             // rolpassword
-					tree.DNull,                   // rolvaliduntil
-					tree.DBoolFalse,              // rolbypassrls
-					tree.DNull,                   // rolconfig
+					h.UserOid(username),                          // oid
+					tree.NewDName(username),                      // rolname
+					tree.MakeDBool(isRoot),                       // rolsuper
+					tree.MakeDBool(isRoleDBool),                  // rolinherit. Roles inherit by default.
+					tree.MakeDBool(tree.DBool(attrs.createRole)), // rolcreaterole
+					tree.MakeDBool(tree.DBool(attrs.createDB)),   // rolcreatedb
+					tree.DBoolFalse,                              // rolcatupdate
+					tree.MakeDBool(tree.DBool(attrs.canLogin)),   // rolcanlogin
+					tree.DBoolFalse,                              // rolreplication
+					tree.NewDInt(tree.DInt(attrs.connLimit)),     // rolconnlimit
+					passwdStarString,                             // rolpassword
+					attrs.validUntil,                             // rolvaliduntil
+					tree.DBoolFalse,                              // rolbypassrls
+					tree.DNull,                                   // rolconfig
 				)
 			})
 	},
@@ -2220,22 +2221,26 @@ CREATE TABLE pg_catalog.pg_user (
 )`,
 	populate: func(ctx context.Context, p *planner, _ *DatabaseDescriptor, addRow func(...tree.Datum) error) error {
 		h := makeOidHasher()
-		return forEachRole(ctx, p,
-			func(username string, isRole bool) error {
+		return forEachRoleWithAttributes(ctx, p,
+			func(username string, isRole bool, attrs roleAttributes) error {
 				if isRole {
 					return nil
 				}
 				isRoot := tree.DBool(username == security.RootUser)
+				validUntil := tree.DNull
+				if ts, ok := attrs.validUntil.(*tree.DTimestampTZ); ok {
+					validUntil = tree.MakeDTimestamp(ts.Time, time.Microsecond)
+				}
 				return addRow(
-					tree.NewDName(username), // usename
-					h.UserOid(username),     // usesysid
-					tree.MakeDBool(isRoot),  // usecreatedb
-					tree.MakeDBool(isRoot),  // usesuper
-					tree.DBoolFalse,         // userepl
-					tree.DBoolFalse,         // usebypassrls
-					passwdStarString,        // passwd
-					tree.DNull,              // valuntil
-					tree.DNull,              // useconfig
+					tree.NewDName(username),                    // usename
+					h.UserOid(username),                        // usesysid
+					tree.MakeDBool(tree.DBool(attrs.createDB)), // usecreatedb
+					tree.MakeDBool(isRoot),                     // usesuper
+					tree.DBoolFalse,                            // userepl
+					tree.DBoolFalse,                            // usebypassrls
+					passwdStarString,                           // passwd
+					validUntil,                                 // valuntil
+					tree.DNull,                                 // useconfig
 				)
 			})
 	},
@@ -104,6 +104,8 @@ type authOptions struct {
 	insecure bool
 	auth     *hba.Conf
 	ie       *sql.InternalExecutor
+	// connLimiter enforces the CONNECTION LIMIT role option.
+	connLimiter *userConnLimiter
 }
 
 // serveConn creates a conn that will serve the netConn. It returns once the
@@ -561,12 +563,14 @@ func (c *conn) processCommandsAsync(
 					return
 				}
 			} else {
-				if retErr = c.handleAuthentication(
-					ctx, ac, authOpt.insecure, authOpt.ie, authOpt.auth,
+				var releaseConn func()
+				if releaseConn, retErr = c.handleAuthentication(
+					ctx, ac, authOpt.insecure, authOpt.ie, authOpt.auth, authOpt.connLimiter,
 					sqlServer.GetExecutorConfig(),
 				); retErr != nil {
 					return
 				}
+				defer releaseConn()
 			}
 		}
 
@@ -1460,7 +1464,9 @@ func (r *pgwireReader) ReadByte() (byte, error) {
 }
 
 // handleAuthentication checks the connection's user. Errors are sent to the
-// client and also returned.
+// client and also returned. On success, the returned function must be called
+// once the connection is closed to release its slot in the user's connection
+// limit.
 //
 // TODO(knz): handleAuthentication should discuss with the client to arrange
 // authentication and update c.sessionArgs with the authenticated user's name,
@@ -1471,24 +1477,35 @@ func (c *conn) handleAuthentication(
 	insecure bool,
 	ie *sql.InternalExecutor,
 	auth *hba.Conf,
+	connLimiter *userConnLimiter,
 	execCfg *sql.ExecutorConfig,
-) error {
-	sendError := func(err error) error {
+) (func(), error) {
+	sendError := func(err error) (func(), error) {
 		_ /* err */ = writeErr(ctx, &execCfg.Settings.SV, err, &c.msgBuilder, c.conn)
-		return err
+		return nil, err
 	}
 
 	// Check that the requested user exists and retrieve the hashed
 	// password in case password authentication is needed.
-	exists, hashedPassword, err := sql.GetUserHashedPassword(
+	info, err := sql.GetUserLoginInfo(
 		ctx, ie, &c.metrics.SQLMemMetrics, c.sessionArgs.User,
 	)
 	if err != nil {
 		return sendError(err)
 	}
-	if !exists {
+	if !info.Exists {
 		return sendError(errors.Errorf(security.ErrPasswordUserAuthFailed, c.sessionArgs.User))
 	}
+	if !info.CanLogin {
+		return sendError(pgerror.Newf(pgcode.InvalidAuthorizationSpecification,
+			"%s is not permitted to log in", c.sessionArgs.User))
+	}
+	hashedPassword := info.HashedPassword
+	if info.PasswordExpired(timeutil.Now()) {
+		// As in postgres, an expired password can no longer be used to log in,
+		// but other authentication methods are unaffected.
+		hashedPassword = nil
+	}
 
 	if tlsConn, ok := c.conn.(*readTimeoutConn).Conn.(*tls.Conn); ok {
 		tlsState := tlsConn.ConnectionState()
@@ -1557,9 +1574,19 @@ func (c *conn) handleAuthentication(
 		}
 	}
 
+	release, ok := connLimiter.acquire(c.sessionArgs.User, info.ConnectionLimit)
+	if !ok {
+		return sendError(pgerror.Newf(pgcode.TooManyConnections,
+			"too many connections for role %s", c.sessionArgs.User))
+	}
+
 	c.msgBuilder.initMsg(pgwirebase.ServerMsgAuth)
 	c.msgBuilder.putInt32(authOK)
-	return c.msgBuilder.finishMsg(c.conn)
+	if err := c.msgBuilder.finishMsg(c.conn); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
 }
 
 const serverHBAConfSetting = "server.host_based_authentication.configuration"
@@ -316,6 +316,11 @@ const (
 	// required to complete this task.
 	CCLValidLicenseRequired = "XXC02"
 
+	// UnsatisfiableBoundedStaleness signals that a bounded staleness read
+	// which was only allowed to be served by the nearest replicas could not
+	// be served by them within its staleness bound.
+	UnsatisfiableBoundedStaleness = "XXC03"
+
 	// TransactionCommittedWithSchemaChangeFailure signals that the
 	// non-DDL payload of a transaction was committed successfully but
 	// some DDL operation failed, without rolling back the rest of the
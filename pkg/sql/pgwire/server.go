@@ -156,6 +156,8 @@ type Server struct {
 		conf *hba.Conf
 	}
 
+	connLimiter userConnLimiter
+
 	sqlMemoryPool mon.BytesMonitor
 	connMonitor   mon.BytesMonitor
 
@@ -232,6 +234,8 @@ func MakeServer(
 	server.mu.connCancelMap = make(cancelChanMap)
 	server.mu.Unlock()
 
+	server.connLimiter.conns = make(map[string]int)
+
 	connAuthConf.SetOnChange(&st.SV, func() {
 		val := connAuthConf.Get(&st.SV)
 		server.auth.Lock()
@@ -540,15 +544,46 @@ func (s *Server) ServeConn(ctx context.Context, conn net.Conn) error {
 		&s.metrics, reserved, s.SQLServer,
 		s.IsDraining,
 		authOptions{
-			insecure: s.cfg.Insecure,
-			ie:       s.execCfg.InternalExecutor,
-			auth:     auth,
-			authHook: authHook,
+			insecure:    s.cfg.Insecure,
+			ie:          s.execCfg.InternalExecutor,
+			auth:        auth,
+			authHook:    authHook,
+			connLimiter: &s.connLimiter,
 		},
 		s.stopper)
 	return nil
 }
 
+// userConnLimiter tracks the number of open connections per user on this
+// node, to enforce the CONNECTION LIMIT role option. As the count is per
+// node, the limit applies to each node separately.
+type userConnLimiter struct {
+	syncutil.Mutex
+	conns map[string]int
+}
+
+// acquire registers a new connection for the user, unless the user already
+// has limit connections open. A negative limit means unlimited. The returned
+// function must be called when the connection is closed.
+func (l *userConnLimiter) acquire(user string, limit int) (release func(), ok bool) {
+	if l == nil {
+		return func() {}, true
+	}
+	l.Lock()
+	defer l.Unlock()
+	if limit >= 0 && l.conns[user] >= limit {
+		return nil, false
+	}
+	l.conns[user]++
+	return func() {
+		l.Lock()
+		defer l.Unlock()
+		if l.conns[user]--; l.conns[user] <= 0 {
+			delete(l.conns, user)
+		}
+	}, true
+}
+
 // -1 for the sentinel in case someone wants to set it to 0.
 const connResultsBufferSizeUnsetSentinel = -1
 
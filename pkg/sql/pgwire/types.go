@@ -441,8 +441,14 @@ func (b *writeBuffer) writeBinaryDatum(
 		subWriter := newWriteBuffer(nil /* bytecount */)
 		// Put the number of datums.
 		subWriter.putInt32(int32(len(v.D)))
-		for _, elem := range v.D {
+		fieldTypes := v.ResolvedType().TupleContents()
+		for i, elem := range v.D {
 			oid := elem.ResolvedType().Oid()
+			if elem == tree.DNull && i < len(fieldTypes) {
+				// NULL fields are sent with the type of the field, as the
+				// field types of records are part of their binary encoding.
+				oid = fieldTypes[i].Oid()
+			}
 			subWriter.putInt32(int32(oid))
 			subWriter.writeBinaryDatum(ctx, elem, sessionLoc, oid)
 		}
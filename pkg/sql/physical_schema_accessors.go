@@ -104,8 +104,25 @@ func (a UncachedPhysicalAccessor) GetObjectNames(
 		return nil, err
 	}
 
+	// User-defined types share the namespace of relations; skip them.
+	ids := make([]sqlbase.ID, len(sr))
+	for i, row := range sr {
+		ids[i] = sqlbase.ID(row.ValueInt())
+	}
+	typs, err := readTypeDescs(ctx, txn, ids)
+	if err != nil {
+		return nil, err
+	}
+	typeIDs := make(map[sqlbase.ID]struct{}, len(typs))
+	for _, typ := range typs {
+		typeIDs[typ.ID] = struct{}{}
+	}
+
 	var tableNames tree.TableNames
 	for _, row := range sr {
+		if _, ok := typeIDs[sqlbase.ID(row.ValueInt())]; ok {
+			continue
+		}
 		_, tableName, err := encoding.DecodeUnsafeStringAscending(
 			bytes.TrimPrefix(row.Key, prefix), nil)
 		if err != nil {
@@ -160,6 +177,14 @@ func (a UncachedPhysicalAccessor) GetObjectDesc(
 	// Look up the table using the discovered database descriptor.
 	desc := &sqlbase.TableDescriptor{}
 	err = getDescriptorByID(ctx, txn, descID, desc)
+	if err == errDescriptorIsType {
+		// User-defined types share the namespace of relations, but are not
+		// relations.
+		if flags.required {
+			return nil, sqlbase.NewUndefinedRelationError(name)
+		}
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
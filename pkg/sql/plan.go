@@ -168,6 +168,7 @@ type planNodeFastPath interface {
 	FastPathResults() (int, bool)
 }
 
+var _ planNode = &alterDomainNode{}
 var _ planNode = &alterIndexNode{}
 var _ planNode = &alterSequenceNode{}
 var _ planNode = &alterTableNode{}
@@ -175,10 +176,12 @@ var _ planNode = &bufferNode{}
 var _ planNode = &cancelQueriesNode{}
 var _ planNode = &cancelSessionsNode{}
 var _ planNode = &createDatabaseNode{}
+var _ planNode = &createDomainNode{}
 var _ planNode = &createIndexNode{}
 var _ planNode = &createSequenceNode{}
 var _ planNode = &createStatsNode{}
 var _ planNode = &createTableNode{}
+var _ planNode = &createTypeNode{}
 var _ planNode = &CreateUserNode{}
 var _ planNode = &createViewNode{}
 var _ planNode = &delayedNode{}
@@ -186,9 +189,11 @@ var _ planNode = &deleteNode{}
 var _ planNode = &deleteRangeNode{}
 var _ planNode = &distinctNode{}
 var _ planNode = &dropDatabaseNode{}
+var _ planNode = &dropDomainNode{}
 var _ planNode = &dropIndexNode{}
 var _ planNode = &dropSequenceNode{}
 var _ planNode = &dropTableNode{}
+var _ planNode = &dropTypeNode{}
 var _ planNode = &DropUserNode{}
 var _ planNode = &dropViewNode{}
 var _ planNode = &errorIfRowsNode{}
@@ -233,13 +238,14 @@ var _ planNode = &zeroNode{}
 
 var _ planNodeFastPath = &CreateUserNode{}
 var _ planNodeFastPath = &DropUserNode{}
-var _ planNodeFastPath = &alterUserSetPasswordNode{}
+var _ planNodeFastPath = &alterRoleNode{}
 var _ planNodeFastPath = &createTableNode{}
 var _ planNodeFastPath = &deleteRangeNode{}
 var _ planNodeFastPath = &rowCountNode{}
 var _ planNodeFastPath = &serializeNode{}
 var _ planNodeFastPath = &setZoneConfigNode{}
 var _ planNodeFastPath = &controlJobsNode{}
+var _ planNodeFastPath = &controlSchedulesNode{}
 
 // planNodeRequireSpool serves as marker for nodes whose parent must
 // ensure that the node is fully run to completion (and the results
@@ -607,14 +613,16 @@ func (p *planner) newPlan(
 	}
 
 	switch n := stmt.(type) {
+	case *tree.AlterDomain:
+		return p.AlterDomain(ctx, n)
 	case *tree.AlterIndex:
 		return p.AlterIndex(ctx, n)
 	case *tree.AlterTable:
 		return p.AlterTable(ctx, n)
 	case *tree.AlterSequence:
 		return p.AlterSequence(ctx, n)
-	case *tree.AlterUserSetPassword:
-		return p.AlterUserSetPassword(ctx, n)
+	case *tree.AlterRole:
+		return p.AlterRole(ctx, n)
 	case *tree.CancelQueries:
 		return p.CancelQueries(ctx, n)
 	case *tree.CancelSessions:
@@ -627,10 +635,14 @@ func (p *planner) newPlan(
 		return p.CommentOnTable(ctx, n)
 	case *tree.ControlJobs:
 		return p.ControlJobs(ctx, n)
+	case *tree.ControlSchedules:
+		return p.ControlSchedules(ctx, n)
 	case *tree.Scrub:
 		return p.Scrub(ctx, n)
 	case *tree.CreateDatabase:
 		return p.CreateDatabase(ctx, n)
+	case *tree.CreateDomain:
+		return p.CreateDomain(ctx, n)
 	case *tree.CreateIndex:
 		return p.CreateIndex(ctx, n)
 	case *tree.CreateTable:
@@ -643,6 +655,8 @@ func (p *planner) newPlan(
 		return p.CreateSequence(ctx, n)
 	case *tree.CreateStats:
 		return p.CreateStatistics(ctx, n)
+	case *tree.CreateType:
+		return p.CreateType(ctx, n)
 	case *tree.Deallocate:
 		return p.Deallocate(ctx, n)
 	case *tree.Delete:
@@ -651,6 +665,8 @@ func (p *planner) newPlan(
 		return p.Discard(ctx, n)
 	case *tree.DropDatabase:
 		return p.DropDatabase(ctx, n)
+	case *tree.DropDomain:
+		return p.DropDomain(ctx, n)
 	case *tree.DropIndex:
 		return p.DropIndex(ctx, n)
 	case *tree.DropTable:
@@ -659,6 +675,8 @@ func (p *planner) newPlan(
 		return p.DropView(ctx, n)
 	case *tree.DropSequence:
 		return p.DropSequence(ctx, n)
+	case *tree.DropType:
+		return p.DropType(ctx, n)
 	case *tree.DropUser:
 		return p.DropUser(ctx, n)
 	case *tree.Explain:
@@ -697,7 +715,7 @@ func (p *planner) newPlan(
 	case *tree.SetTransaction:
 		return p.SetTransaction(n)
 	case *tree.SetSessionCharacteristics:
-		return p.SetSessionCharacteristics(n)
+		return p.SetSessionCharacteristics(ctx, n)
 	case *tree.ShowClusterSetting:
 		return p.ShowClusterSetting(ctx, n)
 	case *tree.ShowHistogram:
@@ -770,14 +788,16 @@ func (p *planner) doPrepare(ctx context.Context, stmt tree.Statement) (planNode,
 	p.isPreparing = true
 
 	switch n := stmt.(type) {
-	case *tree.AlterUserSetPassword:
-		return p.AlterUserSetPassword(ctx, n)
+	case *tree.AlterRole:
+		return p.AlterRole(ctx, n)
 	case *tree.CancelQueries:
 		return p.CancelQueries(ctx, n)
 	case *tree.CancelSessions:
 		return p.CancelSessions(ctx, n)
 	case *tree.ControlJobs:
 		return p.ControlJobs(ctx, n)
+	case *tree.ControlSchedules:
+		return p.ControlSchedules(ctx, n)
 	case *tree.CreateUser:
 		return p.CreateUser(ctx, n)
 	case *tree.CreateTable:
@@ -34,6 +34,12 @@ func planColumns(plan planNode) sqlbase.ResultColumns {
 	return getPlanColumns(plan, false)
 }
 
+// PlanColumns is planColumns for users outside this package, such as plan
+// hooks that need the names of the columns of a PlanNode.
+func PlanColumns(plan PlanNode) sqlbase.ResultColumns {
+	return planColumns(plan)
+}
+
 // planMutableColumns is similar to planColumns() but returns a
 // ResultColumns slice that can be modified by the caller.
 func planMutableColumns(plan planNode) sqlbase.ResultColumns {
@@ -98,27 +98,33 @@ func planPhysicalProps(plan planNode) physicalProps {
 	case *CreateUserNode:
 	case *DropUserNode:
 	case *alterIndexNode:
+	case *alterDomainNode:
 	case *alterSequenceNode:
 	case *alterTableNode:
-	case *alterUserSetPasswordNode:
+	case *alterRoleNode:
 	case *cancelQueriesNode:
 	case *cancelSessionsNode:
 	case *commentOnTableNode:
 	case *commentOnColumnNode:
 	case *commentOnDatabaseNode:
 	case *controlJobsNode:
+	case *controlSchedulesNode:
 	case *createDatabaseNode:
 	case *createIndexNode:
+	case *createDomainNode:
 	case *createSequenceNode:
 	case *createStatsNode:
+	case *createTypeNode:
 	case *createTableNode:
 	case *createViewNode:
 	case *delayedNode:
 	case *deleteRangeNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
+	case *dropDomainNode:
 	case *dropSequenceNode:
 	case *dropTableNode:
+	case *dropTypeNode:
 	case *dropViewNode:
 	case *errorIfRowsNode:
 	case *explainDistSQLNode:
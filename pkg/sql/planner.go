@@ -171,6 +171,10 @@ type planner struct {
 	optPlanningCtx optPlanningCtx
 
 	queryCacheSession querycache.Session
+
+	// domainCheckers caches the constraints of the domains whose values are
+	// checked by the current statement.
+	domainCheckers *domainCheckerCache
 }
 
 // noteworthyInternalMemoryUsageBytes is the minimum size tracked by each
@@ -249,6 +253,7 @@ func newInternalPlanner(
 	p.semaCtx = tree.MakeSemaContext()
 	p.semaCtx.Location = &sd.DataConversion.Location
 	p.semaCtx.SearchPath = sd.SearchPath
+	p.semaCtx.TypeResolver = p
 
 	plannerMon := mon.MakeUnlimitedMonitor(ctx,
 		fmt.Sprintf("internal-planner.%s.%s", user, opName),
@@ -276,6 +281,7 @@ func newInternalPlanner(
 	p.extendedEvalCtx.Tables = tables
 
 	p.queryCacheSession.Init()
+	p.domainCheckers = &domainCheckerCache{}
 
 	return p, func() {
 		// Note that we capture ctx here. This is only valid as long as we create
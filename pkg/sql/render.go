@@ -97,7 +97,14 @@ func (p *planner) Select(
 	orderBy := n.OrderBy
 	with := n.With
 
+	if n.ForUpdate {
+		// Locking is only implemented by the cost-based optimizer.
+		return nil, unimplemented.NewWithIssue(6583, "FOR UPDATE")
+	}
 	for s, ok := wrapped.(*tree.ParenSelect); ok; s, ok = wrapped.(*tree.ParenSelect) {
+		if s.Select.ForUpdate {
+			return nil, unimplemented.NewWithIssue(6583, "FOR UPDATE")
+		}
 		wrapped = s.Select.Select
 		if s.Select.With != nil {
 			if with != nil {
@@ -484,12 +491,15 @@ func (p *planner) getTimestamp(asOf tree.AsOfClause) (hlc.Timestamp, bool, error
 		// The Executor found an AS OF SYSTEM TIME clause at the top
 		// level. We accept AS OF SYSTEM TIME in multiple places (e.g. in
 		// subqueries or view queries) but they must all point to the same
-		// timestamp.
-		ts, err := p.EvalAsOfTimestamp(asOf)
+		// timestamp. Bounded staleness clauses only need to admit the
+		// timestamp negotiated for the top-level statement.
+		res, err := tree.EvalAsOfSystemTime(asOf, &p.semaCtx, p.EvalContext())
 		if err != nil {
 			return hlc.MaxTimestamp, false, err
 		}
-		if ts != *p.semaCtx.AsOfTimestamp {
+		ts := *p.semaCtx.AsOfTimestamp
+		if res.BoundedStaleness && ts.Less(res.Timestamp) ||
+			!res.BoundedStaleness && res.Timestamp != ts {
 			return hlc.MaxTimestamp, false,
 				unimplemented.NewWithIssue(35712,
 					"cannot specify AS OF SYSTEM TIME with different timestamps")
@@ -0,0 +1,145 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package roleoption
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+)
+
+// Option defines a role option. This is output by the parser and, for most
+// options, stored in system.role_options.
+type Option uint32
+
+// List of role options.
+const (
+	_ Option = iota
+	LOGIN
+	NOLOGIN
+	CREATEROLE
+	NOCREATEROLE
+	CREATEDB
+	NOCREATEDB
+	PASSWORD
+	VALIDUNTIL
+	CONNECTIONLIMIT
+)
+
+var names = [...]string{
+	LOGIN:           "LOGIN",
+	NOLOGIN:         "NOLOGIN",
+	CREATEROLE:      "CREATEROLE",
+	NOCREATEROLE:    "NOCREATEROLE",
+	CREATEDB:        "CREATEDB",
+	NOCREATEDB:      "NOCREATEDB",
+	PASSWORD:        "PASSWORD",
+	VALIDUNTIL:      "VALID UNTIL",
+	CONNECTIONLIMIT: "CONNECTION LIMIT",
+}
+
+// ByName is a map of string -> option value.
+var ByName = map[string]Option{
+	"LOGIN":            LOGIN,
+	"NOLOGIN":          NOLOGIN,
+	"CREATEROLE":       CREATEROLE,
+	"NOCREATEROLE":     NOCREATEROLE,
+	"CREATEDB":         CREATEDB,
+	"NOCREATEDB":       NOCREATEDB,
+	"PASSWORD":         PASSWORD,
+	"VALID UNTIL":      VALIDUNTIL,
+	"CONNECTION LIMIT": CONNECTIONLIMIT,
+}
+
+// String returns the SQL spelling of the option.
+func (o Option) String() string {
+	if int(o) < len(names) && names[o] != "" {
+		return names[o]
+	}
+	return "Option(" + strconv.FormatInt(int64(o), 10) + ")"
+}
+
+// Opposite returns the option that sets the same attribute to the opposite
+// value, or the option itself for options that carry a value.
+func (o Option) Opposite() Option {
+	switch o {
+	case LOGIN:
+		return NOLOGIN
+	case NOLOGIN:
+		return LOGIN
+	case CREATEROLE:
+		return NOCREATEROLE
+	case NOCREATEROLE:
+		return CREATEROLE
+	case CREATEDB:
+		return NOCREATEDB
+	case NOCREATEDB:
+		return CREATEDB
+	}
+	return o
+}
+
+// HasValue returns true if the option is followed by a value in SQL.
+func (o Option) HasValue() bool {
+	switch o {
+	case PASSWORD, VALIDUNTIL, CONNECTIONLIMIT:
+		return true
+	}
+	return false
+}
+
+// ToOption converts a string to an Option.
+func ToOption(str string) (Option, error) {
+	ret, ok := ByName[strings.ToUpper(str)]
+	if !ok {
+		return 0, pgerror.Newf(pgcode.InvalidParameterValue, "unrecognized role option %s", str)
+	}
+	return ret, nil
+}
+
+// RoleOption represents an Option together with its value, if any.
+type RoleOption struct {
+	Option
+	// Value is resolved at execution time so that placeholders can be used.
+	// It is nil for options that don't carry a value. isNull is true if the
+	// value was specified as NULL.
+	Value func() (isNull bool, value string, err error)
+}
+
+// List is a list of role options.
+type List []RoleOption
+
+// Contains returns true if the list contains the given option.
+func (rol List) Contains(o Option) bool {
+	for _, r := range rol {
+		if r.Option == o {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckConflicts returns an error if the same attribute is specified more than
+// once, e.g. in "CREATE ROLE foo LOGIN NOLOGIN".
+func (rol List) CheckConflicts() error {
+	for i, r := range rol {
+		for _, other := range rol[:i] {
+			if other.Option == r.Option || other.Option == r.Option.Opposite() {
+				return pgerror.New(pgcode.Syntax, "conflicting role options")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package roleoption_test
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/roleoption"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestToOption(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	for name, expected := range roleoption.ByName {
+		o, err := roleoption.ToOption(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if o != expected {
+			t.Errorf("%s: expected %s, got %s", name, expected, o)
+		}
+		if o.String() != name {
+			t.Errorf("expected %s, got %s", name, o.String())
+		}
+	}
+	if _, err := roleoption.ToOption("superuser"); !testutils.IsError(err, "unrecognized role option") {
+		t.Errorf("expected unrecognized role option error, got %v", err)
+	}
+}
+
+func TestCheckConflicts(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	testCases := []struct {
+		options  []roleoption.Option
+		conflict bool
+	}{
+		{[]roleoption.Option{roleoption.LOGIN, roleoption.CREATEROLE}, false},
+		{[]roleoption.Option{roleoption.LOGIN, roleoption.NOLOGIN}, true},
+		{[]roleoption.Option{roleoption.CREATEDB, roleoption.NOCREATEDB}, true},
+		{[]roleoption.Option{roleoption.VALIDUNTIL, roleoption.VALIDUNTIL}, true},
+		{[]roleoption.Option{roleoption.PASSWORD, roleoption.CONNECTIONLIMIT}, false},
+	}
+	for _, tc := range testCases {
+		var l roleoption.List
+		for _, o := range tc.options {
+			l = append(l, roleoption.RoleOption{Option: o})
+		}
+		if err := l.CheckConflicts(); (err != nil) != tc.conflict {
+			t.Errorf("%v: expected conflict %t, got %v", tc.options, tc.conflict, err)
+		}
+	}
+}
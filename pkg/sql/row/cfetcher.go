@@ -181,6 +181,10 @@ type CFetcher struct {
 	// If set, GetRangesInfo() can be used to retrieve the accumulated info.
 	returnRangeInfo bool
 
+	// lockForUpdate, if set, causes the keys read by StartScan to be locked
+	// until the end of the transaction. See SetLockForUpdate.
+	lockForUpdate bool
+
 	// traceKV indicates whether or not session tracing is enabled. It is set
 	// when beginning a new scan.
 	traceKV bool
@@ -398,6 +402,13 @@ func (rf *CFetcher) Init(
 	return nil
 }
 
+// SetLockForUpdate configures the CFetcher to lock the keys read by StartScan
+// until the end of the transaction, as done by SELECT ... FOR UPDATE. It must
+// be called after Init.
+func (rf *CFetcher) SetLockForUpdate(lockForUpdate bool) {
+	rf.lockForUpdate = lockForUpdate
+}
+
 // StartScan initializes and starts the key-value scan. Can be used multiple
 // times.
 func (rf *CFetcher) StartScan(
@@ -428,7 +439,9 @@ func (rf *CFetcher) StartScan(
 		firstBatchLimit++
 	}
 
-	f, err := makeKVBatchFetcher(txn, spans, rf.reverse, limitBatches, firstBatchLimit, rf.returnRangeInfo)
+	f, err := makeKVBatchFetcher(
+		txn, spans, rf.reverse, limitBatches, firstBatchLimit, rf.returnRangeInfo, rf.lockForUpdate,
+	)
 	if err != nil {
 		return err
 	}
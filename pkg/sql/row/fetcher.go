@@ -200,6 +200,10 @@ type Fetcher struct {
 	// If set, GetRangesInfo() can be used to retrieve the accumulated info.
 	returnRangeInfo bool
 
+	// lockForUpdate, if set, causes the keys read by StartScan to be locked
+	// until the end of the transaction. See SetLockForUpdate.
+	lockForUpdate bool
+
 	// traceKV indicates whether or not session tracing is enabled. It is set
 	// when beginning a new scan.
 	traceKV bool
@@ -451,6 +455,7 @@ func (rf *Fetcher) StartScan(
 	rf.traceKV = traceKV
 	f, err := makeKVBatchFetcher(
 		txn, spans, rf.reverse, limitBatches, rf.firstBatchLimit(limitHint), rf.returnRangeInfo,
+		rf.lockForUpdate,
 	)
 	if err != nil {
 		return err
@@ -458,6 +463,13 @@ func (rf *Fetcher) StartScan(
 	return rf.StartScanFrom(ctx, &f)
 }
 
+// SetLockForUpdate configures the Fetcher to lock the keys read by StartScan
+// until the end of the transaction, as done by SELECT ... FOR UPDATE. It must
+// be called after Init.
+func (rf *Fetcher) SetLockForUpdate(lockForUpdate bool) {
+	rf.lockForUpdate = lockForUpdate
+}
+
 // StartInconsistentScan initializes and starts an inconsistent scan, where each
 // KV batch can be read at a different historical timestamp.
 //
@@ -530,6 +542,7 @@ func (rf *Fetcher) StartInconsistentScan(
 		limitBatches,
 		rf.firstBatchLimit(limitHint),
 		rf.returnRangeInfo,
+		false, /* lockForUpdate */
 	)
 	if err != nil {
 		return err
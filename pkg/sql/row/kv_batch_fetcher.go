@@ -57,6 +57,8 @@ type txnKVFetcher struct {
 	// returnRangeInfo, if set, causes the kvBatchFetcher to populate rangeInfos.
 	// See also rowFetcher.returnRangeInfo.
 	returnRangeInfo bool
+	// lockForUpdate, if set, causes the scans to lock the keys they read.
+	lockForUpdate bool
 
 	fetchEnd bool
 	batchIdx int
@@ -147,6 +149,7 @@ func makeKVBatchFetcher(
 	useBatchLimit bool,
 	firstBatchLimit int64,
 	returnRangeInfo bool,
+	lockForUpdate bool,
 ) (txnKVFetcher, error) {
 	sendFn := func(ctx context.Context, ba roachpb.BatchRequest) (*roachpb.BatchResponse, error) {
 		res, err := txn.Send(ctx, ba)
@@ -156,7 +159,7 @@ func makeKVBatchFetcher(
 		return res, nil
 	}
 	return makeKVBatchFetcherWithSendFunc(
-		sendFn, spans, reverse, useBatchLimit, firstBatchLimit, returnRangeInfo,
+		sendFn, spans, reverse, useBatchLimit, firstBatchLimit, returnRangeInfo, lockForUpdate,
 	)
 }
 
@@ -169,6 +172,7 @@ func makeKVBatchFetcherWithSendFunc(
 	useBatchLimit bool,
 	firstBatchLimit int64,
 	returnRangeInfo bool,
+	lockForUpdate bool,
 ) (txnKVFetcher, error) {
 	if firstBatchLimit < 0 || (!useBatchLimit && firstBatchLimit != 0) {
 		return txnKVFetcher{}, errors.Errorf("invalid batch limit %d (useBatchLimit: %t)",
@@ -220,6 +224,7 @@ func makeKVBatchFetcherWithSendFunc(
 		useBatchLimit:   useBatchLimit,
 		firstBatchLimit: firstBatchLimit,
 		returnRangeInfo: returnRangeInfo,
+		lockForUpdate:   lockForUpdate,
 	}, nil
 }
 
@@ -229,10 +234,15 @@ func (f *txnKVFetcher) fetch(ctx context.Context) error {
 	ba.Header.MaxSpanRequestKeys = f.getBatchSize()
 	ba.Header.ReturnRangeInfo = f.returnRangeInfo
 	ba.Requests = make([]roachpb.RequestUnion, len(f.spans))
+	keyLocking := roachpb.NON_LOCKING
+	if f.lockForUpdate {
+		keyLocking = roachpb.FOR_UPDATE
+	}
 	if f.reverse {
 		scans := make([]roachpb.ReverseScanRequest, len(f.spans))
 		for i := range f.spans {
 			scans[i].ScanFormat = roachpb.BATCH_RESPONSE
+			scans[i].KeyLocking = keyLocking
 			scans[i].SetSpan(f.spans[i])
 			ba.Requests[i].MustSetInner(&scans[i])
 		}
@@ -240,6 +250,7 @@ func (f *txnKVFetcher) fetch(ctx context.Context) error {
 		scans := make([]roachpb.ScanRequest, len(f.spans))
 		for i := range f.spans {
 			scans[i].ScanFormat = roachpb.BATCH_RESPONSE
+			scans[i].KeyLocking = keyLocking
 			scans[i].SetSpan(f.spans[i])
 			ba.Requests[i].MustSetInner(&scans[i])
 		}
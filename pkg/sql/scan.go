@@ -121,6 +121,10 @@ type scanNode struct {
 
 	// Indicates if this scan is the source for a delete node.
 	isDeleteSource bool
+
+	// lockForUpdate is set if the scan locks the keys it reads until the end
+	// of the transaction (SELECT ... FOR UPDATE).
+	lockForUpdate bool
 }
 
 // scanVisibility represents which table columns should be included in a scan.
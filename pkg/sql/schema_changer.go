@@ -25,6 +25,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprotectedts"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -601,6 +602,89 @@ func (sc *SchemaChanger) maybeMakeAddTablePublic(
 	return nil
 }
 
+// onlineImportRollbackBatchSize is the maximum number of keys deleted by each
+// request when rolling back an online import.
+const onlineImportRollbackBatchSize = 10000
+
+// maybeRollbackOnlineImport deletes the data ingested into the table by a
+// failed online IMPORT INTO, if any, and then lets the descriptor of the table
+// be changed again.
+//
+// An online import records the spans it ingests in the progress of its job,
+// along with bounds on the timestamps at which their keys were written, and
+// its keys cannot shadow a different live value. So the keys in those spans
+// whose latest version was written within the bounds and which had no live
+// value before are the ones the import inserted. Rows written by other
+// transactions while the import ran, and imported rows updated since, are
+// kept. The protected timestamp of the job keeps the versions written before
+// the import around until then, and is released once they are no longer
+// needed.
+func (sc *SchemaChanger) maybeRollbackOnlineImport(
+	ctx context.Context, table *sqlbase.TableDescriptor,
+) error {
+	if table.OnlineImportRollbackJob == 0 {
+		return nil
+	}
+	job, err := sc.jobRegistry.LoadJob(ctx, table.OnlineImportRollbackJob)
+	if err != nil {
+		return err
+	}
+	details, ok := job.Details().(jobspb.ImportDetails)
+	if !ok {
+		return errors.AssertionFailedf("unexpected details for job %d: %T",
+			errors.Safe(*job.ID()), job.Details())
+	}
+
+	progress, ok := job.Progress().Details.(*jobspb.Progress_Import)
+	if !ok {
+		return errors.AssertionFailedf("unexpected progress for job %d: %T",
+			errors.Safe(*job.ID()), job.Progress().Details)
+	}
+	tableSpan := table.TableSpan()
+	for _, ingested := range progress.Import.IngestedSpans {
+		if !ingested.Span.Overlaps(tableSpan) {
+			continue
+		}
+		span := ingested.Span
+		if span.Key.Compare(tableSpan.Key) < 0 {
+			span.Key = tableSpan.Key
+		}
+		if span.EndKey.Compare(tableSpan.EndKey) > 0 {
+			span.EndKey = tableSpan.EndKey
+		}
+		for span.Key != nil {
+			b := &client.Batch{}
+			b.Header.MaxSpanRequestKeys = onlineImportRollbackBatchSize
+			b.DelRangeInsertedAfter(
+				span.Key, span.EndKey, ingested.WrittenAfter, ingested.WrittenUntil,
+			)
+			if err := sc.db.Run(ctx, b); err != nil {
+				return errors.Wrapf(err, "deleting data imported into %q", table.Name)
+			}
+			span = b.Results[0].ResumeSpanAsValue()
+		}
+	}
+
+	_, err = sc.leaseMgr.Publish(
+		ctx,
+		table.ID,
+		func(tbl *sqlbase.MutableTableDescriptor) error {
+			if tbl.OnlineImportRollbackJob == 0 {
+				return errDidntUpdateDescriptor
+			}
+			tbl.OnlineImport = false
+			tbl.OnlineImportRollbackJob = 0
+			return nil
+		},
+		func(txn *client.Txn) error {
+			return jobsprotectedts.Release(
+				ctx, txn, job.ProtectedTimestamps(), details.ProtectedTimestampRecord,
+			)
+		},
+	)
+	return err
+}
+
 func (sc *SchemaChanger) maybeGCMutations(
 	ctx context.Context, inSession bool, table *sqlbase.TableDescriptor,
 ) error {
@@ -826,6 +910,10 @@ func (sc *SchemaChanger) exec(
 		return err
 	}
 
+	if err := sc.maybeRollbackOnlineImport(ctx, tableDesc); err != nil {
+		return err
+	}
+
 	if err := sc.maybeGCMutations(ctx, inSession, tableDesc); err != nil {
 		return err
 	}
@@ -1942,7 +2030,8 @@ func (s *SchemaChangeManager) Start(stopper *stop.Stopper) {
 
 						// Keep track of outstanding schema changes.
 						pendingChanges := table.Adding() ||
-							table.HasDrainingNames() || len(table.Mutations) > 0
+							table.HasDrainingNames() || len(table.Mutations) > 0 ||
+							table.OnlineImportRollbackJob != 0
 						if pendingChanges {
 							if log.V(2) {
 								log.Infof(ctx, "%s: queue up pending schema change; table: %d, version: %d",
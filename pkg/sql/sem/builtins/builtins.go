@@ -1741,6 +1741,52 @@ return without an error.`,
 		},
 	),
 
+	tree.WithMinTimestampFunctionName: makeBuiltin(
+		tree.FunctionProperties{Impure: true},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"min_timestamp", types.TimestampTZ}},
+			ReturnType: tree.FixedReturnType(types.TimestampTZ),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				return withMinTimestamp(ctx, args[0].(*tree.DTimestampTZ).Time)
+			},
+			Info: withMinTimestampInfo,
+		},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"min_timestamp", types.TimestampTZ}, {"nearest_only", types.Bool}},
+			ReturnType: tree.FixedReturnType(types.TimestampTZ),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				return withMinTimestamp(ctx, args[0].(*tree.DTimestampTZ).Time)
+			},
+			Info: withMinTimestampInfo + `
+
+If nearest_only is true, the query fails instead of being served by the
+leaseholder if the nearest replicas cannot serve it at or above min_timestamp.`,
+		},
+	),
+
+	tree.WithMaxStalenessFunctionName: makeBuiltin(
+		tree.FunctionProperties{Impure: true},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"max_staleness", types.Interval}},
+			ReturnType: tree.FixedReturnType(types.TimestampTZ),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				return withMaxStaleness(ctx, args[0].(*tree.DInterval).Duration)
+			},
+			Info: withMaxStalenessInfo,
+		},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"max_staleness", types.Interval}, {"nearest_only", types.Bool}},
+			ReturnType: tree.FixedReturnType(types.TimestampTZ),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				return withMaxStaleness(ctx, args[0].(*tree.DInterval).Duration)
+			},
+			Info: withMaxStalenessInfo + `
+
+If nearest_only is true, the query fails instead of being served by the
+leaseholder if the nearest replicas cannot serve it within max_staleness.`,
+		},
+	),
+
 	"cluster_logical_timestamp": makeBuiltin(
 		tree.FunctionProperties{
 			Category: categorySystemInfo,
@@ -2953,6 +2999,33 @@ may increase either contention or retry errors, or both.`,
 		},
 	),
 
+	// Checks a value against the constraints of a domain. Casts to domains are
+	// rewritten to calls of this function during type checking.
+	"crdb_internal.check_domain_value": makeBuiltin(
+		tree.FunctionProperties{
+			Category:         categorySystemInfo,
+			DistsqlBlacklist: true,
+			Impure:           true,
+			NullableArgs:     true,
+		},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"val", types.Any}, {"domain_id", types.Int}},
+			ReturnType: tree.IdentityReturnType(0),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				if args[1] == tree.DNull {
+					return nil, pgerror.New(pgcode.InvalidParameterValue, "domain ID cannot be NULL")
+				}
+				id := int64(tree.MustBeDInt(args[1]))
+				if err := ctx.Planner.CheckDomainValue(ctx.Ctx(), id, args[0]); err != nil {
+					return nil, err
+				}
+				return args[0], nil
+			},
+			Info: "Checks that a value satisfies the constraints of the domain with the given ID " +
+				"and returns the value.",
+		},
+	),
+
 	// Return a pretty key for a given raw key, skipping the specified number of
 	// fields.
 	"crdb_internal.pretty_key": makeBuiltin(
@@ -4635,6 +4708,59 @@ func checkPrivilegedUser(ctx *tree.EvalContext) error {
 // if an enterprise license is not installed.
 var EvalFollowerReadOffset func(clusterID uuid.UUID, _ *cluster.Settings) (time.Duration, error)
 
+// CheckBoundedStalenessEnabled returns an error if bounded staleness reads
+// cannot be used in this cluster. It is injected by followerreadsccl.
+var CheckBoundedStalenessEnabled func(clusterID uuid.UUID, _ *cluster.Settings) error
+
+const withMinTimestampInfo = `Returns min_timestamp.
+
+This function is intended to be used with an AS OF SYSTEM TIME clause to perform
+a bounded staleness read. The query is performed at the most recent timestamp
+that the nearest replicas of the data it reads can serve, as long as that
+timestamp is not older than min_timestamp.
+
+Note that this function requires an enterprise license on a CCL distribution to
+return without an error.`
+
+const withMaxStalenessInfo = `Returns the statement timestamp less max_staleness.
+
+This function is intended to be used with an AS OF SYSTEM TIME clause to perform
+a bounded staleness read. The query is performed at the most recent timestamp
+that the nearest replicas of the data it reads can serve, as long as that
+timestamp is no more than max_staleness older than the statement timestamp.
+
+Note that this function requires an enterprise license on a CCL distribution to
+return without an error.`
+
+func checkBoundedStalenessEnabled(ctx *tree.EvalContext, name string) error {
+	if CheckBoundedStalenessEnabled == nil {
+		return pgerror.Newf(pgcode.FeatureNotSupported,
+			"%s is only available in ccl distribution", name)
+	}
+	return CheckBoundedStalenessEnabled(ctx.ClusterID, ctx.Settings)
+}
+
+func withMinTimestamp(ctx *tree.EvalContext, minTS time.Time) (tree.Datum, error) {
+	if err := checkBoundedStalenessEnabled(ctx, tree.WithMinTimestampFunctionName); err != nil {
+		return nil, err
+	}
+	if minTS.After(ctx.StmtTimestamp) {
+		return nil, pgerror.Newf(pgcode.InvalidParameterValue, "timestamp %s is in the future", minTS)
+	}
+	return tree.MakeDTimestampTZ(minTS, time.Microsecond), nil
+}
+
+func withMaxStaleness(ctx *tree.EvalContext, maxStaleness duration.Duration) (tree.Datum, error) {
+	if err := checkBoundedStalenessEnabled(ctx, tree.WithMaxStalenessFunctionName); err != nil {
+		return nil, err
+	}
+	if maxStaleness.Compare(duration.Duration{}) <= 0 {
+		return nil, pgerror.New(pgcode.InvalidParameterValue, "interval must be greater than zero")
+	}
+	minTS := duration.Add(ctx, ctx.StmtTimestamp, maxStaleness.Mul(-1))
+	return tree.MakeDTimestampTZ(minTS, time.Microsecond), nil
+}
+
 func recentTimestamp(ctx *tree.EvalContext) (time.Time, error) {
 	if EvalFollowerReadOffset == nil {
 		return time.Time{}, pgerror.New(pgcode.FeatureNotSupported,
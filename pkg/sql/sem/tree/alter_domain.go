@@ -0,0 +1,66 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package tree
+
+// AlterDomain represents an ALTER DOMAIN statement.
+type AlterDomain struct {
+	Name TableName
+	Cmd  AlterDomainCmd
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterDomain) Format(ctx *FmtCtx) {
+	ctx.WriteString("ALTER DOMAIN ")
+	ctx.FormatNode(&node.Name)
+	ctx.FormatNode(node.Cmd)
+}
+
+// AlterDomainCmd represents a domain modification operation.
+type AlterDomainCmd interface {
+	NodeFormatter
+	// Placeholder function to ensure that only desired types
+	// (AlterDomain*) conform to the AlterDomainCmd interface.
+	alterDomainCmd()
+}
+
+func (*AlterDomainAddConstraint) alterDomainCmd()  {}
+func (*AlterDomainDropConstraint) alterDomainCmd() {}
+
+var _ AlterDomainCmd = &AlterDomainAddConstraint{}
+var _ AlterDomainCmd = &AlterDomainDropConstraint{}
+
+// AlterDomainAddConstraint represents an ADD CONSTRAINT command.
+type AlterDomainAddConstraint struct {
+	Check DomainCheck
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterDomainAddConstraint) Format(ctx *FmtCtx) {
+	ctx.WriteString(" ADD ")
+	ctx.FormatNode(&node.Check)
+}
+
+// AlterDomainDropConstraint represents a DROP CONSTRAINT command.
+type AlterDomainDropConstraint struct {
+	IfExists   bool
+	Constraint Name
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterDomainDropConstraint) Format(ctx *FmtCtx) {
+	ctx.WriteString(" DROP CONSTRAINT ")
+	if node.IfExists {
+		ctx.WriteString("IF EXISTS ")
+	}
+	ctx.FormatNode(&node.Constraint)
+}
@@ -31,13 +31,62 @@ import (
 // reads.
 const FollowerReadTimestampFunctionName = "experimental_follower_read_timestamp"
 
-var errInvalidExprForAsOf = errors.Errorf("AS OF SYSTEM TIME: only constant expressions or " +
+// WithMinTimestampFunctionName is the name of the function which can be used
+// with AOST clauses to perform a bounded staleness read at a timestamp no older
+// than the given one.
+const WithMinTimestampFunctionName = "with_min_timestamp"
+
+// WithMaxStalenessFunctionName is the name of the function which can be used
+// with AOST clauses to perform a bounded staleness read at a timestamp no more
+// stale than the given interval.
+const WithMaxStalenessFunctionName = "with_max_staleness"
+
+var errInvalidExprForAsOf = errors.Errorf("AS OF SYSTEM TIME: only constant expressions, " +
+	WithMinTimestampFunctionName + ", " + WithMaxStalenessFunctionName + ", or " +
 	FollowerReadTimestampFunctionName + " are allowed")
 
-// EvalAsOfTimestamp evaluates the timestamp argument to an AS OF SYSTEM TIME query.
+// ErrBoundedStalenessNotAllowed is returned when a bounded staleness AS OF
+// SYSTEM TIME clause is used in a context that requires an exact timestamp.
+var ErrBoundedStalenessNotAllowed = pgerror.Newf(pgcode.FeatureNotSupported,
+	"AS OF SYSTEM TIME: %s and %s are only allowed in single-statement SELECT queries",
+	WithMinTimestampFunctionName, WithMaxStalenessFunctionName)
+
+// AsOfSystemTime represents the result of evaluating an AS OF SYSTEM TIME
+// clause.
+type AsOfSystemTime struct {
+	// Timestamp is the timestamp evaluated from the clause. For bounded
+	// staleness reads it is the minimum timestamp at which the read may be
+	// performed; the actual timestamp is negotiated before the read runs.
+	Timestamp hlc.Timestamp
+	// BoundedStaleness is set if the clause invoked with_min_timestamp or
+	// with_max_staleness.
+	BoundedStaleness bool
+	// NearestOnly is set if a bounded staleness read must fail rather than be
+	// served by the leaseholder when the nearest replicas cannot serve it at or
+	// above Timestamp.
+	NearestOnly bool
+}
+
+// EvalAsOfTimestamp evaluates the timestamp argument to an AS OF SYSTEM TIME
+// query. Bounded staleness clauses are rejected; see EvalAsOfSystemTime.
 func EvalAsOfTimestamp(
 	asOf AsOfClause, semaCtx *SemaContext, evalCtx *EvalContext,
-) (tsss hlc.Timestamp, err error) {
+) (hlc.Timestamp, error) {
+	res, err := EvalAsOfSystemTime(asOf, semaCtx, evalCtx)
+	if err != nil {
+		return hlc.Timestamp{}, err
+	}
+	if res.BoundedStaleness {
+		return hlc.Timestamp{}, ErrBoundedStalenessNotAllowed
+	}
+	return res.Timestamp, nil
+}
+
+// EvalAsOfSystemTime evaluates an AS OF SYSTEM TIME clause, which may specify
+// either an exact timestamp or a bounded staleness read.
+func EvalAsOfSystemTime(
+	asOf AsOfClause, semaCtx *SemaContext, evalCtx *EvalContext,
+) (AsOfSystemTime, error) {
 	// We need to save and restore the previous value of the field in
 	// semaCtx in case we are recursively called within a subquery
 	// context.
@@ -46,41 +95,63 @@ func EvalAsOfTimestamp(
 	scalarProps.Require("AS OF SYSTEM TIME", RejectSpecial|RejectSubqueries)
 
 	// In order to support the follower reads feature we permit this expression
-	// to be a simple invocation of the `FollowerReadTimestampFunction`.
+	// to be a simple invocation of the `FollowerReadTimestampFunction` or of
+	// one of the bounded staleness functions.
 	// Over time we could expand the set of allowed functions or expressions.
 	// All non-function expressions must be const and must TypeCheck into a
 	// string.
+	var res AsOfSystemTime
 	var te TypedExpr
 	if fe, ok := asOf.Expr.(*FuncExpr); ok {
 		def, err := fe.Func.Resolve(semaCtx.SearchPath)
 		if err != nil {
-			return hlc.Timestamp{}, errInvalidExprForAsOf
+			return AsOfSystemTime{}, errInvalidExprForAsOf
 		}
-		if def.Name != FollowerReadTimestampFunctionName {
-			return hlc.Timestamp{}, errInvalidExprForAsOf
+		switch def.Name {
+		case FollowerReadTimestampFunctionName:
+		case WithMinTimestampFunctionName, WithMaxStalenessFunctionName:
+			res.BoundedStaleness = true
+		default:
+			return AsOfSystemTime{}, errInvalidExprForAsOf
 		}
 		if te, err = fe.TypeCheck(semaCtx, types.TimestampTZ); err != nil {
-			return hlc.Timestamp{}, err
+			return AsOfSystemTime{}, err
+		}
+		if res.BoundedStaleness {
+			// The optional second argument of the bounded staleness functions is
+			// the nearest_only flag.
+			if typedFE := te.(*FuncExpr); len(typedFE.Exprs) > 1 {
+				d, err := typedFE.Exprs[1].(TypedExpr).Eval(evalCtx)
+				if err != nil {
+					return AsOfSystemTime{}, err
+				}
+				if d != DNull {
+					res.NearestOnly = bool(MustBeDBool(d))
+				}
+			}
 		}
 	} else {
 		var err error
 		te, err = asOf.Expr.TypeCheck(semaCtx, types.String)
 		if err != nil {
-			return hlc.Timestamp{}, err
+			return AsOfSystemTime{}, err
 		}
 		if !IsConst(evalCtx, te) {
-			return hlc.Timestamp{}, errInvalidExprForAsOf
+			return AsOfSystemTime{}, errInvalidExprForAsOf
 		}
 	}
 
 	d, err := te.Eval(evalCtx)
 	if err != nil {
-		return hlc.Timestamp{}, err
+		return AsOfSystemTime{}, err
 	}
 
 	stmtTimestamp := evalCtx.GetStmtTimestamp()
-	ts, err := DatumToHLC(evalCtx, stmtTimestamp, d)
-	return ts, errors.Wrap(err, "AS OF SYSTEM TIME")
+	res.Timestamp, err = DatumToHLC(evalCtx, stmtTimestamp, d)
+	if err != nil {
+		return AsOfSystemTime{}, errors.Wrap(err, "AS OF SYSTEM TIME")
+	}
+	return res, nil
 }
 
 // DatumToHLC performs the conversion from a Datum to an HLC timestamp.
@@ -15,7 +15,7 @@ package tree
 // Backup represents a BACKUP statement.
 type Backup struct {
 	Targets         TargetList
-	To              Expr
+	To              PartitionedBackup
 	IncrementalFrom Exprs
 	AsOf            AsOfClause
 	Options         KVOptions
@@ -28,7 +28,7 @@ func (node *Backup) Format(ctx *FmtCtx) {
 	ctx.WriteString("BACKUP ")
 	ctx.FormatNode(&node.Targets)
 	ctx.WriteString(" TO ")
-	ctx.FormatNode(node.To)
+	ctx.FormatNode(&node.To)
 	if node.AsOf.Expr != nil {
 		ctx.WriteString(" ")
 		ctx.FormatNode(&node.AsOf)
@@ -46,7 +46,7 @@ func (node *Backup) Format(ctx *FmtCtx) {
 // Restore represents a RESTORE statement.
 type Restore struct {
 	Targets TargetList
-	From    Exprs
+	From    []PartitionedBackup
 	AsOf    AsOfClause
 	Options KVOptions
 }
@@ -58,7 +58,12 @@ func (node *Restore) Format(ctx *FmtCtx) {
 	ctx.WriteString("RESTORE ")
 	ctx.FormatNode(&node.Targets)
 	ctx.WriteString(" FROM ")
-	ctx.FormatNode(&node.From)
+	for i := range node.From {
+		if i > 0 {
+			ctx.WriteString(", ")
+		}
+		ctx.FormatNode(&node.From[i])
+	}
 	if node.AsOf.Expr != nil {
 		ctx.WriteString(" ")
 		ctx.FormatNode(&node.AsOf)
@@ -69,6 +74,60 @@ func (node *Restore) Format(ctx *FmtCtx) {
 	}
 }
 
+// ScheduledBackup represents a CREATE SCHEDULE FOR BACKUP statement.
+type ScheduledBackup struct {
+	// ScheduleName is nil if the schedule was not given a name.
+	ScheduleName  Expr
+	Targets       TargetList
+	To            Expr
+	BackupOptions KVOptions
+	// Recurrence is the cron expression describing when backups are taken.
+	Recurrence Expr
+	// FullBackup is the cron expression describing when full backups are
+	// taken. It is nil if every backup is a full backup.
+	FullBackup Expr
+}
+
+var _ Statement = &ScheduledBackup{}
+
+// Format implements the NodeFormatter interface.
+func (node *ScheduledBackup) Format(ctx *FmtCtx) {
+	ctx.WriteString("CREATE SCHEDULE ")
+	if node.ScheduleName != nil {
+		ctx.FormatNode(node.ScheduleName)
+		ctx.WriteString(" ")
+	}
+	ctx.WriteString("FOR BACKUP ")
+	ctx.FormatNode(&node.Targets)
+	ctx.WriteString(" TO ")
+	ctx.FormatNode(node.To)
+	if node.BackupOptions != nil {
+		ctx.WriteString(" WITH ")
+		ctx.FormatNode(&node.BackupOptions)
+	}
+	ctx.WriteString(" RECURRING ")
+	ctx.FormatNode(node.Recurrence)
+	if node.FullBackup != nil {
+		ctx.WriteString(" FULL BACKUP ")
+		ctx.FormatNode(node.FullBackup)
+	}
+}
+
+// PartitionedBackup is the list of URIs a single backup is written to or read
+// from. A backup with more than one URI is partitioned by locality.
+type PartitionedBackup []Expr
+
+// Format implements the NodeFormatter interface.
+func (node *PartitionedBackup) Format(ctx *FmtCtx) {
+	if len(*node) > 1 {
+		ctx.WriteString("(")
+	}
+	ctx.FormatNode((*Exprs)(node))
+	if len(*node) > 1 {
+		ctx.WriteString(")")
+	}
+}
+
 // KVOption is a key-value option.
 type KVOption struct {
 	Key   Name
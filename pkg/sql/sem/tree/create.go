@@ -963,7 +963,6 @@ func (node *CreateTable) FormatBody(ctx *FmtCtx) {
 //    CREATE TABLE foo (a INT CHECK (a < b), b INT)
 //
 // Unique constraints are not hoisted.
-//
 func (node *CreateTable) HoistConstraints() {
 	for _, d := range node.Defs {
 		if col, ok := d.(*ColumnTableDef); ok {
@@ -995,6 +994,120 @@ func (node *CreateTable) HoistConstraints() {
 	}
 }
 
+// CreateDomain represents a CREATE DOMAIN statement.
+type CreateDomain struct {
+	Name    TableName
+	Type    *types.T
+	Default Expr
+	NotNull bool
+	Checks  []DomainCheck
+}
+
+// DomainCheck represents a CHECK constraint of a domain. The expression
+// refers to the value being checked as VALUE.
+type DomainCheck struct {
+	Name Name
+	Expr Expr
+}
+
+// Format implements the NodeFormatter interface.
+func (node *DomainCheck) Format(ctx *FmtCtx) {
+	if node.Name != "" {
+		ctx.WriteString("CONSTRAINT ")
+		ctx.FormatNode(&node.Name)
+		ctx.WriteByte(' ')
+	}
+	ctx.WriteString("CHECK (")
+	ctx.FormatNode(node.Expr)
+	ctx.WriteByte(')')
+}
+
+// NewCreateDomain constructs a CreateDomain from the constraints listed in
+// the statement. Only DEFAULT, NULL, NOT NULL and CHECK are valid
+// constraints; the grammar does not produce any other.
+func NewCreateDomain(
+	name TableName, typ *types.T, constraints []NamedColumnQualification,
+) (*CreateDomain, error) {
+	d := &CreateDomain{Name: name, Type: typ}
+	nullability := SilentNull
+	for _, c := range constraints {
+		switch t := c.Qualification.(type) {
+		case *ColumnDefault:
+			if d.Default != nil {
+				return nil, pgerror.Newf(pgcode.Syntax,
+					"multiple default expressions")
+			}
+			d.Default = t.Expr
+		case NotNullConstraint:
+			if nullability == Null {
+				return nil, pgerror.Newf(pgcode.Syntax,
+					"conflicting NULL/NOT NULL constraints")
+			}
+			nullability = NotNull
+			d.NotNull = true
+		case NullConstraint:
+			if nullability == NotNull {
+				return nil, pgerror.Newf(pgcode.Syntax,
+					"conflicting NULL/NOT NULL constraints")
+			}
+			nullability = Null
+		case *ColumnCheckConstraint:
+			d.Checks = append(d.Checks, DomainCheck{Name: c.Name, Expr: t.Expr})
+		default:
+			return nil, errors.AssertionFailedf("unexpected domain constraint: %T", t)
+		}
+	}
+	return d, nil
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateDomain) Format(ctx *FmtCtx) {
+	ctx.WriteString("CREATE DOMAIN ")
+	ctx.FormatNode(&node.Name)
+	ctx.WriteString(" AS ")
+	ctx.WriteString(node.Type.SQLString())
+	if node.Default != nil {
+		ctx.WriteString(" DEFAULT ")
+		ctx.FormatNode(node.Default)
+	}
+	if node.NotNull {
+		ctx.WriteString(" NOT NULL")
+	}
+	for i := range node.Checks {
+		ctx.WriteByte(' ')
+		ctx.FormatNode(&node.Checks[i])
+	}
+}
+
+// CreateType represents a CREATE TYPE ... AS (...) statement, which creates
+// a composite type.
+type CreateType struct {
+	Name   TableName
+	Fields []CompositeTypeField
+}
+
+// CompositeTypeField represents a field of a composite type.
+type CompositeTypeField struct {
+	Name Name
+	Type *types.T
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateType) Format(ctx *FmtCtx) {
+	ctx.WriteString("CREATE TYPE ")
+	ctx.FormatNode(&node.Name)
+	ctx.WriteString(" AS (")
+	for i := range node.Fields {
+		if i > 0 {
+			ctx.WriteString(", ")
+		}
+		ctx.FormatNode(&node.Fields[i].Name)
+		ctx.WriteByte(' ')
+		ctx.WriteString(node.Fields[i].Type.SQLString())
+	}
+	ctx.WriteByte(')')
+}
+
 // CreateSequence represents a CREATE SEQUENCE statement.
 type CreateSequence struct {
 	IfNotExists bool
@@ -1085,16 +1198,66 @@ const (
 	_ = SeqOptOwnedBy
 )
 
+// RoleOption represents a role attribute in a CREATE/ALTER USER or ROLE
+// statement, e.g. LOGIN or VALID UNTIL '2020-01-01'.
+type RoleOption struct {
+	// Name is the option as spelled in SQL, e.g. "LOGIN" or "VALID UNTIL".
+	Name string
+	// Value is nil for options that don't take a value.
+	Value Expr
+}
+
+// IsPassword returns true if the option sets the password.
+func (o *RoleOption) IsPassword() bool {
+	return o.Name == "PASSWORD"
+}
+
+// Format implements the NodeFormatter interface.
+func (o *RoleOption) Format(ctx *FmtCtx) {
+	ctx.WriteString(o.Name)
+	if o.Value != nil {
+		ctx.WriteByte(' ')
+		if o.IsPassword() && !ctx.flags.HasFlags(FmtShowPasswords) {
+			ctx.WriteString("*****")
+		} else {
+			ctx.FormatNode(o.Value)
+		}
+	}
+}
+
+// RoleOptions is a list of role options.
+type RoleOptions []RoleOption
+
+// Format implements the NodeFormatter interface.
+func (l *RoleOptions) Format(ctx *FmtCtx) {
+	for i := range *l {
+		if i > 0 {
+			ctx.WriteByte(' ')
+		}
+		ctx.FormatNode(&(*l)[i])
+	}
+}
+
+// HasPassword returns true if one of the options sets the password.
+func (l RoleOptions) HasPassword() bool {
+	for i := range l {
+		if l[i].IsPassword() {
+			return true
+		}
+	}
+	return false
+}
+
 // CreateUser represents a CREATE USER statement.
 type CreateUser struct {
 	Name        Expr
-	Password    Expr // nil if no password specified
 	IfNotExists bool
+	Options     RoleOptions
 }
 
 // HasPassword returns if the CreateUser has a password.
 func (node *CreateUser) HasPassword() bool {
-	return node.Password != nil
+	return node.Options.HasPassword()
 }
 
 // Format implements the NodeFormatter interface.
@@ -1104,42 +1267,40 @@ func (node *CreateUser) Format(ctx *FmtCtx) {
 		ctx.WriteString("IF NOT EXISTS ")
 	}
 	ctx.FormatNode(node.Name)
-	if node.HasPassword() {
-		ctx.WriteString(" WITH PASSWORD ")
-		if ctx.flags.HasFlags(FmtShowPasswords) {
-			ctx.FormatNode(node.Password)
-		} else {
-			ctx.WriteString("*****")
-		}
+	if len(node.Options) > 0 {
+		ctx.WriteString(" WITH ")
+		ctx.FormatNode(&node.Options)
 	}
 }
 
-// AlterUserSetPassword represents an ALTER USER ... WITH PASSWORD statement.
-type AlterUserSetPassword struct {
+// AlterRole represents an ALTER USER or ALTER ROLE statement.
+type AlterRole struct {
 	Name     Expr
-	Password Expr
 	IfExists bool
+	IsRole   bool
+	Options  RoleOptions
 }
 
 // Format implements the NodeFormatter interface.
-func (node *AlterUserSetPassword) Format(ctx *FmtCtx) {
-	ctx.WriteString("ALTER USER ")
+func (node *AlterRole) Format(ctx *FmtCtx) {
+	if node.IsRole {
+		ctx.WriteString("ALTER ROLE ")
+	} else {
+		ctx.WriteString("ALTER USER ")
+	}
 	if node.IfExists {
 		ctx.WriteString("IF EXISTS ")
 	}
 	ctx.FormatNode(node.Name)
-	ctx.WriteString(" WITH PASSWORD ")
-	if ctx.flags.HasFlags(FmtShowPasswords) {
-		ctx.FormatNode(node.Password)
-	} else {
-		ctx.WriteString("*****")
-	}
+	ctx.WriteString(" WITH ")
+	ctx.FormatNode(&node.Options)
 }
 
 // CreateRole represents a CREATE ROLE statement.
 type CreateRole struct {
 	Name        Expr
 	IfNotExists bool
+	Options     RoleOptions
 }
 
 // Format implements the NodeFormatter interface.
@@ -1149,6 +1310,10 @@ func (node *CreateRole) Format(ctx *FmtCtx) {
 		ctx.WriteString("IF NOT EXISTS ")
 	}
 	ctx.FormatNode(node.Name)
+	if len(node.Options) > 0 {
+		ctx.WriteString(" WITH ")
+		ctx.FormatNode(&node.Options)
+	}
 }
 
 // CreateView represents a CREATE VIEW statement.
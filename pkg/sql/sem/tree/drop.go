@@ -141,6 +141,46 @@ func (node *DropSequence) Format(ctx *FmtCtx) {
 	}
 }
 
+// DropDomain represents a DROP DOMAIN statement.
+type DropDomain struct {
+	Names        TableNames
+	IfExists     bool
+	DropBehavior DropBehavior
+}
+
+// Format implements the NodeFormatter interface.
+func (node *DropDomain) Format(ctx *FmtCtx) {
+	ctx.WriteString("DROP DOMAIN ")
+	if node.IfExists {
+		ctx.WriteString("IF EXISTS ")
+	}
+	ctx.FormatNode(&node.Names)
+	if node.DropBehavior != DropDefault {
+		ctx.WriteByte(' ')
+		ctx.WriteString(node.DropBehavior.String())
+	}
+}
+
+// DropType represents a DROP TYPE statement.
+type DropType struct {
+	Names        TableNames
+	IfExists     bool
+	DropBehavior DropBehavior
+}
+
+// Format implements the NodeFormatter interface.
+func (node *DropType) Format(ctx *FmtCtx) {
+	ctx.WriteString("DROP TYPE ")
+	if node.IfExists {
+		ctx.WriteString("IF EXISTS ")
+	}
+	ctx.FormatNode(&node.Names)
+	if node.DropBehavior != DropDefault {
+		ctx.WriteByte(' ')
+		ctx.WriteString(node.DropBehavior.String())
+	}
+}
+
 // DropUser represents a DROP USER statement
 type DropUser struct {
 	Names    Exprs
@@ -2455,15 +2455,20 @@ type EvalPlanner interface {
 
 	// EvalSubquery returns the Datum for the given subquery node.
 	EvalSubquery(expr *Subquery) (Datum, error)
+
+	// CheckDomainValue checks that a value satisfies the NOT NULL and CHECK
+	// constraints of the domain with the given ID.
+	CheckDomainValue(ctx context.Context, id int64, value Datum) error
 }
 
 // EvalSessionAccessor is a limited interface to access session variables.
 type EvalSessionAccessor interface {
-	// SetConfig sets a session variable to a new value.
+	// SetSessionVar sets a session variable to a new value. If isLocal is
+	// set, the new value only lasts until the end of the current transaction.
 	//
 	// This interface only supports strings as this is sufficient for
 	// pg_catalog.set_config().
-	SetSessionVar(ctx context.Context, settingName, newValue string) error
+	SetSessionVar(ctx context.Context, settingName, newValue string, isLocal bool) error
 
 	// GetSessionVar retrieves the current value of a session variable.
 	GetSessionVar(ctx context.Context, settingName string, missingOk bool) (bool, string, error)
@@ -3470,6 +3475,21 @@ func PerformCast(ctx *EvalContext, d Datum, t *types.T) (Datum, error) {
 			}
 			return dcast, nil
 		}
+	case types.TupleFamily:
+		if v, ok := d.(*DTuple); ok && len(v.D) == len(t.TupleContents()) {
+			dcast := NewDTupleWithLen(t, len(v.D))
+			for i, e := range v.D {
+				dcast.D[i] = DNull
+				if e != DNull {
+					var err error
+					dcast.D[i], err = PerformCast(ctx, e, &t.TupleContents()[i])
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+			return dcast, nil
+		}
 	case types.OidFamily:
 		switch v := d.(type) {
 		case *DOid:
@@ -3748,9 +3768,10 @@ func (expr *FuncExpr) Eval(ctx *EvalContext) (Datum, error) {
 
 	res, err := expr.fn.Fn(ctx, args)
 	if err != nil {
-		// If we are facing an explicit error, propagate it unchanged.
+		// If we are facing an explicit error, or a violation of the constraints
+		// of a domain in a cast, propagate it unchanged.
 		fName := expr.Func.String()
-		if fName == `crdb_internal.force_error` {
+		if fName == `crdb_internal.force_error` || fName == `crdb_internal.check_domain_value` {
 			return nil, err
 		}
 		// Otherwise, wrap it with context.
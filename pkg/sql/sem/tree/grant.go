@@ -26,8 +26,11 @@ import "github.com/cockroachdb/cockroach/pkg/sql/privilege"
 // Grant represents a GRANT statement.
 type Grant struct {
 	Privileges privilege.List
-	Targets    TargetList
-	Grantees   NameList
+	// Columns, if non-empty, restricts the privileges to the given columns
+	// of the target tables.
+	Columns  NameList
+	Targets  TargetList
+	Grantees NameList
 }
 
 // TargetList represents a list of targets.
@@ -58,6 +61,11 @@ func (tl *TargetList) Format(ctx *FmtCtx) {
 func (node *Grant) Format(ctx *FmtCtx) {
 	ctx.WriteString("GRANT ")
 	node.Privileges.Format(&ctx.Buffer)
+	if len(node.Columns) > 0 {
+		ctx.WriteString(" (")
+		ctx.FormatNode(&node.Columns)
+		ctx.WriteByte(')')
+	}
 	ctx.WriteString(" ON ")
 	ctx.FormatNode(&node.Targets)
 	ctx.WriteString(" TO ")
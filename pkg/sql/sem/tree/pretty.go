@@ -531,7 +531,7 @@ func (node *Select) doc(p *PrettyCfg) pretty.Doc {
 }
 
 func (node *Select) docTable(p *PrettyCfg) []pretty.TableRow {
-	items := make([]pretty.TableRow, 0, 9)
+	items := make([]pretty.TableRow, 0, 10)
 	items = append(items, node.With.docRow(p))
 	if s, ok := node.Select.(tableDocer); ok {
 		items = append(items, s.docTable(p)...)
@@ -540,6 +540,9 @@ func (node *Select) docTable(p *PrettyCfg) []pretty.TableRow {
 	}
 	items = append(items, node.OrderBy.docRow(p))
 	items = append(items, node.Limit.docTable(p)...)
+	if node.ForUpdate {
+		items = append(items, p.row("FOR", pretty.Keyword("UPDATE")))
+	}
 	return items
 }
 
@@ -1741,7 +1744,7 @@ func (node *Backup) doc(p *PrettyCfg) pretty.Doc {
 
 	items = append(items, p.row("BACKUP", pretty.Nil))
 	items = append(items, node.Targets.docRow(p))
-	items = append(items, p.row("TO", p.Doc(node.To)))
+	items = append(items, p.row("TO", p.Doc(&node.To)))
 
 	if node.AsOf.Expr != nil {
 		items = append(items, node.AsOf.docRow(p))
@@ -1760,7 +1763,11 @@ func (node *Restore) doc(p *PrettyCfg) pretty.Doc {
 
 	items = append(items, p.row("RESTORE", pretty.Nil))
 	items = append(items, node.Targets.docRow(p))
-	items = append(items, p.row("FROM", p.Doc(&node.From)))
+	from := make([]pretty.Doc, len(node.From))
+	for i := range node.From {
+		from[i] = p.Doc(&node.From[i])
+	}
+	items = append(items, p.row("FROM", p.commaSeparated(from...)))
 
 	if node.AsOf.Expr != nil {
 		items = append(items, node.AsOf.docRow(p))
@@ -27,14 +27,22 @@ import "github.com/cockroachdb/cockroach/pkg/sql/privilege"
 // PrivilegeList and TargetList are defined in grant.go
 type Revoke struct {
 	Privileges privilege.List
-	Targets    TargetList
-	Grantees   NameList
+	// Columns, if non-empty, restricts the privileges to the given columns
+	// of the target tables.
+	Columns  NameList
+	Targets  TargetList
+	Grantees NameList
 }
 
 // Format implements the NodeFormatter interface.
 func (node *Revoke) Format(ctx *FmtCtx) {
 	ctx.WriteString("REVOKE ")
 	node.Privileges.Format(&ctx.Buffer)
+	if len(node.Columns) > 0 {
+		ctx.WriteString(" (")
+		ctx.FormatNode(&node.Columns)
+		ctx.WriteByte(')')
+	}
 	ctx.WriteString(" ON ")
 	ctx.FormatNode(&node.Targets)
 	ctx.WriteString(" FROM ")
@@ -42,6 +42,36 @@ func (n *ControlJobs) Format(ctx *FmtCtx) {
 	ctx.FormatNode(n.Jobs)
 }
 
+// ControlSchedules represents a PAUSE/RESUME SCHEDULES statement.
+type ControlSchedules struct {
+	Schedules *Select
+	Command   ScheduleCommand
+}
+
+// ScheduleCommand determines which type of action to effect on the selected
+// schedule(s).
+type ScheduleCommand int
+
+// ScheduleCommand values
+const (
+	PauseSchedule ScheduleCommand = iota
+	ResumeSchedule
+)
+
+// ScheduleCommandToStatement translates a schedule command integer to a
+// statement prefix.
+var ScheduleCommandToStatement = map[ScheduleCommand]string{
+	PauseSchedule:  "PAUSE",
+	ResumeSchedule: "RESUME",
+}
+
+// Format implements the NodeFormatter interface.
+func (n *ControlSchedules) Format(ctx *FmtCtx) {
+	ctx.WriteString(ScheduleCommandToStatement[n.Command])
+	ctx.WriteString(" SCHEDULES ")
+	ctx.FormatNode(n.Schedules)
+}
+
 // CancelQueries represents a CANCEL QUERIES statement.
 type CancelQueries struct {
 	Queries  *Select
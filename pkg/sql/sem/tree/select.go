@@ -47,6 +47,9 @@ type Select struct {
 	Select  SelectStatement
 	OrderBy OrderBy
 	Limit   *Limit
+	// ForUpdate is set if the statement has a FOR UPDATE locking clause. The
+	// rows read by the statement are locked until the transaction ends.
+	ForUpdate bool
 }
 
 // Format implements the NodeFormatter interface.
@@ -61,6 +64,9 @@ func (node *Select) Format(ctx *FmtCtx) {
 		ctx.WriteByte(' ')
 		ctx.FormatNode(node.Limit)
 	}
+	if node.ForUpdate {
+		ctx.WriteString(" FOR UPDATE")
+	}
 }
 
 // ParenSelect represents a parenthesized SELECT/UNION/VALUES statement.
@@ -166,11 +166,18 @@ type CCLOnlyStatement interface {
 
 var _ CCLOnlyStatement = &Backup{}
 var _ CCLOnlyStatement = &Restore{}
+var _ CCLOnlyStatement = &ScheduledBackup{}
 var _ CCLOnlyStatement = &CreateRole{}
 var _ CCLOnlyStatement = &DropRole{}
 var _ CCLOnlyStatement = &GrantRole{}
 var _ CCLOnlyStatement = &RevokeRole{}
 
+// StatementType implements the Statement interface.
+func (*AlterDomain) StatementType() StatementType { return DDL }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*AlterDomain) StatementTag() string { return "ALTER DOMAIN" }
+
 // StatementType implements the Statement interface.
 func (*AlterIndex) StatementType() StatementType { return DDL }
 
@@ -194,12 +201,17 @@ func (*AlterSequence) StatementType() StatementType { return DDL }
 func (*AlterSequence) StatementTag() string { return "ALTER SEQUENCE" }
 
 // StatementType implements the Statement interface.
-func (*AlterUserSetPassword) StatementType() StatementType { return RowsAffected }
+func (*AlterRole) StatementType() StatementType { return RowsAffected }
 
 // StatementTag returns a short string identifying the type of statement.
-func (*AlterUserSetPassword) StatementTag() string { return "ALTER USER" }
+func (n *AlterRole) StatementTag() string {
+	if n.IsRole {
+		return "ALTER ROLE"
+	}
+	return "ALTER USER"
+}
 
-func (*AlterUserSetPassword) hiddenFromShowQueries() {}
+func (*AlterRole) hiddenFromShowQueries() {}
 
 // StatementType implements the Statement interface.
 func (*Backup) StatementType() StatementType { return Rows }
@@ -225,6 +237,14 @@ func (n *ControlJobs) StatementTag() string {
 	return fmt.Sprintf("%s JOBS", JobCommandToStatement[n.Command])
 }
 
+// StatementType implements the Statement interface.
+func (*ControlSchedules) StatementType() StatementType { return RowsAffected }
+
+// StatementTag returns a short string identifying the type of statement.
+func (n *ControlSchedules) StatementTag() string {
+	return fmt.Sprintf("%s SCHEDULES", ScheduleCommandToStatement[n.Command])
+}
+
 // StatementType implements the Statement interface.
 func (*CancelQueries) StatementType() StatementType { return RowsAffected }
 
@@ -290,6 +310,12 @@ func (*CreateDatabase) StatementType() StatementType { return DDL }
 // StatementTag returns a short string identifying the type of statement.
 func (*CreateDatabase) StatementTag() string { return "CREATE DATABASE" }
 
+// StatementType implements the Statement interface.
+func (*CreateDomain) StatementType() StatementType { return DDL }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*CreateDomain) StatementTag() string { return "CREATE DOMAIN" }
+
 // StatementType implements the Statement interface.
 func (*CreateIndex) StatementType() StatementType { return DDL }
 
@@ -339,6 +365,12 @@ func (*CreateView) StatementType() StatementType { return DDL }
 // StatementTag returns a short string identifying the type of statement.
 func (*CreateView) StatementTag() string { return "CREATE VIEW" }
 
+// StatementType implements the Statement interface.
+func (*CreateType) StatementType() StatementType { return DDL }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*CreateType) StatementTag() string { return "CREATE TYPE" }
+
 // StatementType implements the Statement interface.
 func (*CreateSequence) StatementType() StatementType { return DDL }
 
@@ -381,6 +413,12 @@ func (*DropDatabase) StatementType() StatementType { return DDL }
 // StatementTag returns a short string identifying the type of statement.
 func (*DropDatabase) StatementTag() string { return "DROP DATABASE" }
 
+// StatementType implements the Statement interface.
+func (*DropDomain) StatementType() StatementType { return DDL }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*DropDomain) StatementTag() string { return "DROP DOMAIN" }
+
 // StatementType implements the Statement interface.
 func (*DropIndex) StatementType() StatementType { return DDL }
 
@@ -405,6 +443,12 @@ func (*DropSequence) StatementType() StatementType { return DDL }
 // StatementTag returns a short string identifying the type of statement.
 func (*DropSequence) StatementTag() string { return "DROP SEQUENCE" }
 
+// StatementType implements the Statement interface.
+func (*DropType) StatementType() StatementType { return DDL }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*DropType) StatementTag() string { return "DROP TYPE" }
+
 // StatementType implements the Statement interface.
 func (*DropUser) StatementType() StatementType { return RowsAffected }
 
@@ -571,6 +615,16 @@ func (*Scatter) StatementType() StatementType { return Rows }
 // StatementTag returns a short string identifying the type of statement.
 func (*Scatter) StatementTag() string { return "SCATTER" }
 
+// StatementType implements the Statement interface.
+func (*ScheduledBackup) StatementType() StatementType { return Rows }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*ScheduledBackup) StatementTag() string { return "CREATE SCHEDULE FOR BACKUP" }
+
+func (*ScheduledBackup) cclOnlyStatement() {}
+
+func (*ScheduledBackup) hiddenFromShowQueries() {}
+
 // StatementType implements the Statement interface.
 func (*Scrub) StatementType() StatementType { return Rows }
 
@@ -712,6 +766,12 @@ func (*ShowRoleGrants) StatementType() StatementType { return Rows }
 // StatementTag returns a short string identifying the type of statement.
 func (*ShowRoleGrants) StatementTag() string { return "SHOW GRANTS ON ROLE" }
 
+// StatementType implements the Statement interface.
+func (*ShowSchedules) StatementType() StatementType { return Rows }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*ShowSchedules) StatementTag() string { return "SHOW SCHEDULES" }
+
 // StatementType implements the Statement interface.
 func (*ShowSessions) StatementType() StatementType { return Rows }
 
@@ -839,6 +899,9 @@ func (*ValuesClause) StatementType() StatementType { return Rows }
 // StatementTag returns a short string identifying the type of statement.
 func (*ValuesClause) StatementTag() string { return "VALUES" }
 
+func (n *AlterDomain) String() string               { return AsString(n) }
+func (n *AlterDomainAddConstraint) String() string  { return AsString(n) }
+func (n *AlterDomainDropConstraint) String() string { return AsString(n) }
 func (n *AlterIndex) String() string                { return AsString(n) }
 func (n *AlterTable) String() string                { return AsString(n) }
 func (n *AlterTableCmds) String() string            { return AsString(n) }
@@ -850,11 +913,12 @@ func (n *AlterTableDropConstraint) String() string  { return AsString(n) }
 func (n *AlterTableDropNotNull) String() string     { return AsString(n) }
 func (n *AlterTableDropStored) String() string      { return AsString(n) }
 func (n *AlterTableSetDefault) String() string      { return AsString(n) }
-func (n *AlterUserSetPassword) String() string      { return AsString(n) }
+func (n *AlterRole) String() string                 { return AsString(n) }
 func (n *AlterSequence) String() string             { return AsString(n) }
 func (n *Backup) String() string                    { return AsString(n) }
 func (n *BeginTransaction) String() string          { return AsString(n) }
 func (n *ControlJobs) String() string               { return AsString(n) }
+func (n *ControlSchedules) String() string          { return AsString(n) }
 func (n *CancelQueries) String() string             { return AsString(n) }
 func (n *CancelSessions) String() string            { return AsString(n) }
 func (n *CannedOptPlan) String() string             { return AsString(n) }
@@ -865,19 +929,23 @@ func (n *CommitTransaction) String() string         { return AsString(n) }
 func (n *CopyFrom) String() string                  { return AsString(n) }
 func (n *CreateChangefeed) String() string          { return AsString(n) }
 func (n *CreateDatabase) String() string            { return AsString(n) }
+func (n *CreateDomain) String() string              { return AsString(n) }
 func (n *CreateIndex) String() string               { return AsString(n) }
 func (n *CreateRole) String() string                { return AsString(n) }
 func (n *CreateTable) String() string               { return AsString(n) }
 func (n *CreateSequence) String() string            { return AsString(n) }
 func (n *CreateStats) String() string               { return AsString(n) }
+func (n *CreateType) String() string                { return AsString(n) }
 func (n *CreateUser) String() string                { return AsString(n) }
 func (n *CreateView) String() string                { return AsString(n) }
 func (n *Deallocate) String() string                { return AsString(n) }
 func (n *Delete) String() string                    { return AsString(n) }
 func (n *DropDatabase) String() string              { return AsString(n) }
+func (n *DropDomain) String() string                { return AsString(n) }
 func (n *DropIndex) String() string                 { return AsString(n) }
 func (n *DropRole) String() string                  { return AsString(n) }
 func (n *DropTable) String() string                 { return AsString(n) }
+func (n *DropType) String() string                  { return AsString(n) }
 func (n *DropView) String() string                  { return AsString(n) }
 func (n *DropSequence) String() string              { return AsString(n) }
 func (n *DropUser) String() string                  { return AsString(n) }
@@ -903,6 +971,7 @@ func (n *RollbackToSavepoint) String() string       { return AsString(n) }
 func (n *RollbackTransaction) String() string       { return AsString(n) }
 func (n *Savepoint) String() string                 { return AsString(n) }
 func (n *Scatter) String() string                   { return AsString(n) }
+func (n *ScheduledBackup) String() string           { return AsString(n) }
 func (n *Scrub) String() string                     { return AsString(n) }
 func (n *Select) String() string                    { return AsString(n) }
 func (n *SelectClause) String() string              { return AsString(n) }
@@ -928,6 +997,7 @@ func (n *ShowQueries) String() string               { return AsString(n) }
 func (n *ShowRanges) String() string                { return AsString(n) }
 func (n *ShowRoleGrants) String() string            { return AsString(n) }
 func (n *ShowRoles) String() string                 { return AsString(n) }
+func (n *ShowSchedules) String() string             { return AsString(n) }
 func (n *ShowSchemas) String() string               { return AsString(n) }
 func (n *ShowSequences) String() string             { return AsString(n) }
 func (n *ShowSessions) String() string              { return AsString(n) }
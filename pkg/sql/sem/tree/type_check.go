@@ -61,9 +61,27 @@ type SemaContext struct {
 	// globally for the entire txn and this field would not be needed.
 	AsOfTimestamp *hlc.Timestamp
 
+	// TypeResolver is used to resolve the user-defined types referenced by
+	// casts. If it is nil, casts to user-defined types are rejected.
+	TypeResolver TypeReferenceResolver
+
 	Properties SemaProperties
 }
 
+// TypeReferenceResolver resolves references to user-defined types.
+type TypeReferenceResolver interface {
+	// ResolveTypeReference returns the type referred to by ref, a placeholder
+	// created by types.MakeDomainReference. For a domain, this is the base type
+	// of the domain and domainID is the ID of the domain; for a composite type,
+	// this is its row type and domainID is zero.
+	ResolveTypeReference(ref *types.T) (typ *types.T, domainID int64, err error)
+}
+
+// NewUndefinedTypeError creates an error that represents a missing type.
+func NewUndefinedTypeError(name string) error {
+	return pgerror.Newf(pgcode.UndefinedObject, "type %q does not exist", name)
+}
+
 // SemaProperties is a holder for required and derived properties
 // during semantic analysis. It provides scoping semantics via its
 // Restore() method, see below.
@@ -430,6 +448,9 @@ func isCastDeepValid(castFrom, castTo *types.T) (bool, telemetry.Counter) {
 		}
 		return ok, c
 	}
+	if castTo.Family() == types.TupleFamily && castFrom.Family() == types.TupleFamily {
+		return isTupleCastDeepValid(castFrom, castTo)
+	}
 	for _, t := range validCastTypes(castTo) {
 		if castFrom.Family() == t.fromT.Family() {
 			return true, t.counter
@@ -438,6 +459,24 @@ func isCastDeepValid(castFrom, castTo *types.T) (bool, telemetry.Counter) {
 	return false, nil
 }
 
+// isTupleCastDeepValid returns whether every field of the castFrom tuple type
+// can be cast to the corresponding field of the castTo tuple type.
+func isTupleCastDeepValid(castFrom, castTo *types.T) (bool, telemetry.Counter) {
+	from, to := castFrom.TupleContents(), castTo.TupleContents()
+	if len(from) != len(to) {
+		return false, nil
+	}
+	for i := range from {
+		if from[i].Family() == types.UnknownFamily {
+			continue
+		}
+		if ok, _ := isCastDeepValid(&from[i], &to[i]); !ok {
+			return false, nil
+		}
+	}
+	return true, sqltelemetry.TupleCastCounter
+}
+
 func isEmptyArray(expr Expr) bool {
 	a, ok := expr.(*Array)
 	return ok && len(a.Exprs) == 0
@@ -445,6 +484,10 @@ func isEmptyArray(expr Expr) bool {
 
 // TypeCheck implements the Expr interface.
 func (expr *CastExpr) TypeCheck(ctx *SemaContext, _ *types.T) (TypedExpr, error) {
+	if name, ok := expr.Type.DomainReference(); ok {
+		return typeCheckUserDefinedTypeCast(ctx, expr, name)
+	}
+
 	// The desired type provided to a CastExpr is ignored. Instead,
 	// types.Any is passed to the child of the cast. There are two
 	// exceptions, described below.
@@ -530,8 +573,40 @@ func (expr *IndirectionExpr) TypeCheck(ctx *SemaContext, desired *types.T) (Type
 	return expr, nil
 }
 
+// typeCheckUserDefinedTypeCast type checks a cast to a user-defined type. A
+// cast to a composite type is replaced by a cast to its row type. A cast to a
+// domain is replaced by a cast to the base type of the domain, wrapped in a
+// call to a built-in function which checks the value against the constraints
+// of the domain.
+func typeCheckUserDefinedTypeCast(
+	ctx *SemaContext, expr *CastExpr, name string,
+) (TypedExpr, error) {
+	if ctx == nil || ctx.TypeResolver == nil {
+		return nil, NewUndefinedTypeError(name)
+	}
+	base, id, err := ctx.TypeResolver.ResolveTypeReference(expr.Type)
+	if err != nil {
+		return nil, err
+	}
+	if id == 0 {
+		cast := &CastExpr{Expr: expr.Expr, Type: base, SyntaxMode: expr.SyntaxMode}
+		return cast.TypeCheck(ctx, base)
+	}
+	check := &FuncExpr{
+		Func: WrapFunction("crdb_internal.check_domain_value"),
+		Exprs: Exprs{
+			&CastExpr{Expr: expr.Expr, Type: base, SyntaxMode: expr.SyntaxMode},
+			NewDInt(DInt(id)),
+		},
+	}
+	return check.TypeCheck(ctx, base)
+}
+
 // TypeCheck implements the Expr interface.
 func (expr *AnnotateTypeExpr) TypeCheck(ctx *SemaContext, desired *types.T) (TypedExpr, error) {
+	if name, ok := expr.Type.DomainReference(); ok {
+		return nil, NewUndefinedTypeError(name)
+	}
 	subExpr, err := typeCheckAndRequire(ctx, expr.Expr, expr.Type,
 		fmt.Sprintf("type annotation for %v as %s, found", expr.Expr, expr.Type))
 	if err != nil {
@@ -1089,6 +1164,11 @@ func (expr *IfExpr) TypeCheck(ctx *SemaContext, desired *types.T) (TypedExpr, er
 
 // TypeCheck implements the Expr interface.
 func (expr *IsOfTypeExpr) TypeCheck(ctx *SemaContext, desired *types.T) (TypedExpr, error) {
+	for _, t := range expr.Types {
+		if name, ok := t.DomainReference(); ok {
+			return nil, NewUndefinedTypeError(name)
+		}
+	}
 	exprTyped, err := expr.Expr.TypeCheck(ctx, types.Any)
 	if err != nil {
 		return nil, err
@@ -1303,6 +1383,12 @@ func (expr *Tuple) TypeCheck(ctx *SemaContext, desired *types.T) (TypedExpr, err
 		}
 		expr.Exprs[i] = typedExpr
 		contents[i] = *typedExpr.ResolvedType()
+		if contents[i].Family() == types.UnknownFamily && desired.CompositeTypeName() != "" &&
+			len(desired.TupleContents()) > i {
+			// A NULL field of a value of a composite type takes the type of the
+			// field, so that the value can be stored.
+			contents[i] = desired.TupleContents()[i]
+		}
 	}
 	// Copy the labels if there are any.
 	if len(expr.Labels) > 0 {
@@ -2263,7 +2349,10 @@ func (v *placeholderAnnotationVisitor) VisitPre(expr Expr) (recurse bool, newExp
 		}
 
 	case *CastExpr:
-		if arg, ok := t.Expr.(*Placeholder); ok {
+		// Casts to user-defined types are only resolved during type checking, so
+		// they do not provide a type for the placeholder.
+		_, isUserDefined := t.Type.DomainReference()
+		if arg, ok := t.Expr.(*Placeholder); ok && !isUserDefined {
 			switch v.state[arg.Idx] {
 			case noType:
 				v.types[arg.Idx] = t.Type
@@ -726,6 +726,7 @@ func walkReturningClause(v Visitor, clause ReturningClause) (ReturningClause, bo
 // copyNode makes a copy of this Statement without recursing in any child Statements.
 func (stmt *Backup) copyNode() *Backup {
 	stmtCopy := *stmt
+	stmtCopy.To = append(PartitionedBackup(nil), stmt.To...)
 	stmtCopy.IncrementalFrom = append(Exprs(nil), stmt.IncrementalFrom...)
 	stmtCopy.Options = append(KVOptions(nil), stmt.Options...)
 	return &stmtCopy
@@ -743,13 +744,13 @@ func (stmt *Backup) walkStmt(v Visitor) Statement {
 			ret.AsOf.Expr = e
 		}
 	}
-	{
-		e, changed := WalkExpr(v, stmt.To)
+	for i, expr := range stmt.To {
+		e, changed := WalkExpr(v, expr)
 		if changed {
 			if ret == stmt {
 				ret = stmt.copyNode()
 			}
-			ret.To = e
+			ret.To[i] = e
 		}
 	}
 	for i, expr := range stmt.IncrementalFrom {
@@ -773,6 +774,50 @@ func (stmt *Backup) walkStmt(v Visitor) Statement {
 	return ret
 }
 
+// copyNode makes a copy of this Statement without recursing in any child Statements.
+func (stmt *ScheduledBackup) copyNode() *ScheduledBackup {
+	stmtCopy := *stmt
+	stmtCopy.BackupOptions = append(KVOptions(nil), stmt.BackupOptions...)
+	return &stmtCopy
+}
+
+// walkStmt is part of the walkableStmt interface.
+func (stmt *ScheduledBackup) walkStmt(v Visitor) Statement {
+	ret := stmt
+	for _, expr := range []*Expr{&ret.ScheduleName, &ret.To, &ret.Recurrence, &ret.FullBackup} {
+		if *expr == nil {
+			continue
+		}
+		e, changed := WalkExpr(v, *expr)
+		if changed {
+			if ret == stmt {
+				ret = stmt.copyNode()
+			}
+			// Point at the field of the copy rather than that of the original.
+			switch expr {
+			case &stmt.ScheduleName:
+				ret.ScheduleName = e
+			case &stmt.To:
+				ret.To = e
+			case &stmt.Recurrence:
+				ret.Recurrence = e
+			case &stmt.FullBackup:
+				ret.FullBackup = e
+			}
+		}
+	}
+	{
+		opts, changed := walkKVOptions(v, stmt.BackupOptions)
+		if changed {
+			if ret == stmt {
+				ret = stmt.copyNode()
+			}
+			ret.BackupOptions = opts
+		}
+	}
+	return ret
+}
+
 // copyNode makes a copy of this Statement without recursing in any child Statements.
 func (stmt *Delete) copyNode() *Delete {
 	stmtCopy := *stmt
@@ -915,6 +960,22 @@ func (stmt *ControlJobs) walkStmt(v Visitor) Statement {
 	return stmt
 }
 
+// copyNode makes a copy of this Statement without recursing in any child Statements.
+func (stmt *ControlSchedules) copyNode() *ControlSchedules {
+	stmtCopy := *stmt
+	return &stmtCopy
+}
+
+// walkStmt is part of the walkableStmt interface.
+func (stmt *ControlSchedules) walkStmt(v Visitor) Statement {
+	sel, changed := walkStmt(v, stmt.Schedules)
+	if changed {
+		stmt = stmt.copyNode()
+		stmt.Schedules = sel.(*Select)
+	}
+	return stmt
+}
+
 // copyNode makes a copy of this Statement without recursing in any child Statements.
 func (stmt *Import) copyNode() *Import {
 	stmtCopy := *stmt
@@ -968,7 +1029,10 @@ func (stmt *ParenSelect) walkStmt(v Visitor) Statement {
 // copyNode makes a copy of this Statement without recursing in any child Statements.
 func (stmt *Restore) copyNode() *Restore {
 	stmtCopy := *stmt
-	stmtCopy.From = append(Exprs(nil), stmt.From...)
+	stmtCopy.From = make([]PartitionedBackup, len(stmt.From))
+	for i, backup := range stmt.From {
+		stmtCopy.From[i] = append(PartitionedBackup(nil), backup...)
+	}
 	stmtCopy.Options = append(KVOptions(nil), stmt.Options...)
 	return &stmtCopy
 }
@@ -985,13 +1049,15 @@ func (stmt *Restore) walkStmt(v Visitor) Statement {
 			ret.AsOf.Expr = e
 		}
 	}
-	for i, expr := range stmt.From {
-		e, changed := WalkExpr(v, expr)
-		if changed {
-			if ret == stmt {
-				ret = stmt.copyNode()
+	for i, backup := range stmt.From {
+		for j, expr := range backup {
+			e, changed := WalkExpr(v, expr)
+			if changed {
+				if ret == stmt {
+					ret = stmt.copyNode()
+				}
+				ret.From[i][j] = e
 			}
-			ret.From[i] = e
 		}
 	}
 	{
@@ -1354,6 +1420,7 @@ var _ walkableStmt = &ValuesClause{}
 var _ walkableStmt = &CancelQueries{}
 var _ walkableStmt = &CancelSessions{}
 var _ walkableStmt = &ControlJobs{}
+var _ walkableStmt = &ControlSchedules{}
 var _ walkableStmt = &BeginTransaction{}
 
 // walkStmt walks the entire parsed stmt calling WalkExpr on each
@@ -13,13 +13,16 @@
 package sql
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
 )
 
-func (p *planner) SetSessionCharacteristics(n *tree.SetSessionCharacteristics) (planNode, error) {
+func (p *planner) SetSessionCharacteristics(
+	ctx context.Context, n *tree.SetSessionCharacteristics,
+) (planNode, error) {
 	// Note: We also support SET DEFAULT_TRANSACTION_ISOLATION TO ' .... ' above.
 	// Ensure both versions stay in sync.
 	switch n.Modes.Isolation {
@@ -29,11 +32,12 @@ func (p *planner) SetSessionCharacteristics(n *tree.SetSessionCharacteristics) (
 		return nil, fmt.Errorf("unsupported default isolation level: %s", n.Modes.Isolation)
 	}
 
+	var readOnly string
 	switch n.Modes.ReadWriteMode {
 	case tree.ReadOnly:
-		p.sessionDataMutator.SetDefaultReadOnly(true)
+		readOnly = "on"
 	case tree.ReadWrite:
-		p.sessionDataMutator.SetDefaultReadOnly(false)
+		readOnly = "off"
 	case tree.UnspecifiedReadWriteMode:
 	default:
 		return nil, fmt.Errorf("unsupported default read write mode: %s", n.Modes.ReadWriteMode)
@@ -45,5 +49,14 @@ func (p *planner) SetSessionCharacteristics(n *tree.SetSessionCharacteristics) (
 		return nil, unimplemented.New("default transaction priority",
 			"unsupported session default: transaction priority")
 	}
+
+	if readOnly != "" {
+		// Go through the same path as SET default_transaction_read_only, so that
+		// the change outlives the SET LOCAL statements of the current transaction.
+		v := varGen[`default_transaction_read_only`]
+		if err := p.setSessionVar(ctx, v, readOnly, false /* local */); err != nil {
+			return nil, err
+		}
+	}
 	return newZeroNode(nil /* columns */), nil
 }
@@ -27,10 +27,11 @@ import (
 	"github.com/cockroachdb/errors"
 )
 
-// setVarNode represents a SET SESSION statement.
+// setVarNode represents a SET SESSION or SET LOCAL statement.
 type setVarNode struct {
-	name string
-	v    sessionVar
+	name  string
+	v     sessionVar
+	local bool
 	// typedValues == nil means RESET.
 	typedValues []tree.TypedExpr
 }
@@ -93,7 +94,7 @@ func (p *planner) SetVar(ctx context.Context, n *tree.SetVar) (planNode, error)
 		}
 	}
 
-	return &setVarNode{name: name, v: v, typedValues: typedValues}, nil
+	return &setVarNode{name: name, v: v, local: n.Local, typedValues: typedValues}, nil
 }
 
 // Special rule for SET: because SET doesn't apply in the context
@@ -130,10 +131,32 @@ func (n *setVarNode) startExec(params runParams) error {
 		_, strVal = getSessionVarDefaultString(n.name, n.v, params.p.sessionDataMutator)
 	}
 
-	if n.v.RuntimeSet != nil {
-		return n.v.RuntimeSet(params.ctx, params.extendedEvalCtx, strVal)
+	return params.p.setSessionVar(params.ctx, n.v, strVal, n.local)
+}
+
+// setSessionVar applies a new value to a session variable. If local is set,
+// the change only lasts until the end of the current transaction.
+func (p *planner) setSessionVar(ctx context.Context, v sessionVar, s string, local bool) error {
+	if v.RuntimeSet != nil {
+		// Variables with a RuntimeSet are scoped to the current transaction
+		// already.
+		return v.RuntimeSet(ctx, &p.extendedEvalCtx, s)
+	}
+	m := p.sessionDataMutator
+	if local {
+		// Like in PostgreSQL, outside of a transaction block the change only
+		// lasts until the end of the implicit transaction.
+		m.beginLocal()
+	} else {
+		// The value must survive the revert of the SET LOCAL statements at the
+		// end of the transaction or on ROLLBACK TO SAVEPOINT.
+		for _, sm := range m.snapshotMutators() {
+			if err := v.Set(ctx, sm, s); err != nil {
+				return err
+			}
+		}
 	}
-	return n.v.Set(params.ctx, params.p.sessionDataMutator, strVal)
+	return v.Set(ctx, m, s)
 }
 
 // getSessionVarDefaultString retrieves a string suitable to pass to a
@@ -58,6 +58,7 @@ var supportedZoneConfigOptions = map[tree.Name]struct {
 	"range_min_bytes": {types.Int, func(c *config.ZoneConfig, d tree.Datum) { c.RangeMinBytes = proto.Int64(int64(tree.MustBeDInt(d))) }},
 	"range_max_bytes": {types.Int, func(c *config.ZoneConfig, d tree.Datum) { c.RangeMaxBytes = proto.Int64(int64(tree.MustBeDInt(d))) }},
 	"num_replicas":    {types.Int, func(c *config.ZoneConfig, d tree.Datum) { c.NumReplicas = proto.Int32(int32(tree.MustBeDInt(d))) }},
+	"num_voters":      {types.Int, func(c *config.ZoneConfig, d tree.Datum) { c.NumVoters = proto.Int32(int32(tree.MustBeDInt(d))) }},
 	"gc.ttlseconds": {types.Int, func(c *config.ZoneConfig, d tree.Datum) {
 		c.GC = &config.GCPolicy{TTLSeconds: int32(tree.MustBeDInt(d))}
 	}},
@@ -458,6 +459,14 @@ func (n *setZoneConfigNode) startExec(params runParams) error {
 			return pgerror.Newf(pgcode.CheckViolation,
 				"could not validate zone config: %v", err)
 		}
+
+		if completeZone.NumVoters != nil &&
+			!params.ExecCfg().Settings.Version.IsActive(cluster.VersionNonVoters) {
+			return pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+				`num_voters requires all nodes to be upgraded to %s`,
+				cluster.VersionByKey(cluster.VersionNonVoters),
+			)
+		}
 	}
 
 	// If cluster version is below 2.2, just write the complete zone
@@ -244,6 +244,16 @@ func DecodeTableKey(
 		return tree.NewDCollatedString(r, valType.Locale(), &a.env), rkey, err
 	case types.JsonFamily:
 		return tree.DNull, []byte{}, nil
+	case types.TupleFamily:
+		result := *tree.NewDTuple(valType, a.NewDatums(len(valType.TupleContents()))...)
+		rkey = key
+		for i := range valType.TupleContents() {
+			result.D[i], rkey, err = DecodeTableKey(a, &valType.TupleContents()[i], rkey, dir)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		return a.NewDTuple(result), rkey, nil
 	case types.BytesFamily:
 		var r []byte
 		if dir == encoding.Ascending {
@@ -659,6 +669,18 @@ func MarshalColumnValue(col *ColumnDescriptor, val tree.Datum) (roachpb.Value, e
 			r.SetBytes(b)
 			return r, nil
 		}
+	case types.TupleFamily:
+		if v, ok := val.(*tree.DTuple); ok {
+			if err := checkTupleFieldTypes(v, &col.Type); err != nil {
+				return r, err
+			}
+			b, err := encodeUntaggedTuple(v, nil, nil)
+			if err != nil {
+				return r, err
+			}
+			r.SetBytes(b)
+			return r, nil
+		}
 	case types.CollatedStringFamily:
 		if v, ok := val.(*tree.DCollatedString); ok {
 			if v.Locale == col.Type.Locale() {
@@ -811,6 +833,13 @@ func UnmarshalColumnValue(a *DatumAlloc, typ *types.T, value roachpb.Value) (tre
 		}
 		datum, _, err := decodeArrayNoMarshalColumnValue(a, typ.ArrayContents(), v)
 		return datum, err
+	case types.TupleFamily:
+		v, err := value.GetBytes()
+		if err != nil {
+			return nil, err
+		}
+		datum, _, err := decodeTuple(a, typ, v)
+		return datum, err
 	case types.JsonFamily:
 		v, err := value.GetBytes()
 		if err != nil {
@@ -829,6 +858,12 @@ func UnmarshalColumnValue(a *DatumAlloc, typ *types.T, value roachpb.Value) (tre
 // encodeTuple produces the value encoding for a tuple.
 func encodeTuple(t *tree.DTuple, appendTo []byte, colID uint32, scratch []byte) ([]byte, error) {
 	appendTo = encoding.EncodeValueTag(appendTo, colID, encoding.Tuple)
+	return encodeUntaggedTuple(t, appendTo, scratch)
+}
+
+// encodeUntaggedTuple produces the value encoding for a tuple without a value
+// tag. Each field is encoded with its own value tag.
+func encodeUntaggedTuple(t *tree.DTuple, appendTo []byte, scratch []byte) ([]byte, error) {
 	appendTo = encoding.EncodeNonsortingUvarint(appendTo, uint64(len(t.D)))
 
 	var err error
@@ -849,9 +884,7 @@ func decodeTuple(a *DatumAlloc, tupTyp *types.T, b []byte) (tree.Datum, []byte,
 		return nil, nil, err
 	}
 
-	result := tree.DTuple{
-		D: a.NewDatums(len(tupTyp.TupleContents())),
-	}
+	result := *tree.NewDTuple(tupTyp, a.NewDatums(len(tupTyp.TupleContents()))...)
 
 	var datum tree.Datum
 	for i := range tupTyp.TupleContents() {
@@ -1094,6 +1127,26 @@ func checkElementType(paramType *types.T, elemType *types.T) error {
 	return nil
 }
 
+// checkTupleFieldTypes checks that the fields of a tuple match the row type
+// of a column.
+func checkTupleFieldTypes(t *tree.DTuple, rowType *types.T) error {
+	fieldTypes := rowType.TupleContents()
+	if len(t.D) != len(fieldTypes) {
+		return errors.Errorf("tuple of %d fields doesn't match column type %s",
+			len(t.D), rowType)
+	}
+	for i, d := range t.D {
+		if d == tree.DNull {
+			continue
+		}
+		if typ := d.ResolvedType(); !typ.Equivalent(&fieldTypes[i]) {
+			return errors.Errorf("type of tuple field %d %s doesn't match column type %s",
+				i+1, typ, &fieldTypes[i])
+		}
+	}
+	return nil
+}
+
 // encodeArrayElement appends the encoded form of one array element to
 // the target byte buffer.
 func encodeArrayElement(b []byte, d tree.Datum) ([]byte, error) {
@@ -106,6 +106,11 @@ func (ep *DummyEvalPlanner) EvalSubquery(expr *tree.Subquery) (tree.Datum, error
 	return nil, errEvalPlanner
 }
 
+// CheckDomainValue is part of the tree.EvalPlanner interface.
+func (ep *DummyEvalPlanner) CheckDomainValue(ctx context.Context, id int64, value tree.Datum) error {
+	return errEvalPlanner
+}
+
 // DummySessionAccessor implements the tree.EvalSessionAccessor interface by returning errors.
 type DummySessionAccessor struct{}
 
@@ -121,6 +126,6 @@ func (ep *DummySessionAccessor) GetSessionVar(
 }
 
 // SetSessionVar is part of the tree.EvalSessionAccessor interface.
-func (ep *DummySessionAccessor) SetSessionVar(_ context.Context, _, _ string) error {
+func (ep *DummySessionAccessor) SetSessionVar(_ context.Context, _, _ string, _ bool) error {
 	return errEvalSessionVar
 }
@@ -76,6 +76,63 @@ func (p *PrivilegeDescriptor) removeUser(user string) {
 	p.Users = append(p.Users[:idx], p.Users[idx+1:]...)
 }
 
+// ColumnPrivilegeKinds is the list of privileges that can be granted on
+// individual columns of a table.
+var ColumnPrivilegeKinds = privilege.List{privilege.SELECT, privilege.INSERT, privilege.UPDATE}
+
+// findColumnIndex looks for the privileges of the given column and returns
+// its index in the Columns array if found. Returns -1 otherwise.
+func (p PrivilegeDescriptor) findColumnIndex(colID ColumnID) int {
+	idx := sort.Search(len(p.Columns), func(i int) bool {
+		return p.Columns[i].ColumnID >= colID
+	})
+	if idx < len(p.Columns) && p.Columns[idx].ColumnID == colID {
+		return idx
+	}
+	return -1
+}
+
+// findColumn looks for the privileges of a specific column in the list.
+// Returns (nil, false) if not found, or (obj, true) if found.
+func (p PrivilegeDescriptor) findColumn(colID ColumnID) (*ColumnPrivileges, bool) {
+	idx := p.findColumnIndex(colID)
+	if idx == -1 {
+		return nil, false
+	}
+	return &p.Columns[idx], true
+}
+
+// findOrCreateColumn looks for the privileges of a specific column in the
+// list, creating them if needed.
+func (p *PrivilegeDescriptor) findOrCreateColumn(colID ColumnID) *ColumnPrivileges {
+	idx := sort.Search(len(p.Columns), func(i int) bool {
+		return p.Columns[i].ColumnID >= colID
+	})
+	if idx == len(p.Columns) {
+		// Not found but should be inserted at the end.
+		p.Columns = append(p.Columns, ColumnPrivileges{ColumnID: colID})
+	} else if p.Columns[idx].ColumnID == colID {
+		// Found.
+	} else {
+		// New element to be inserted at idx.
+		p.Columns = append(p.Columns, ColumnPrivileges{})
+		copy(p.Columns[idx+1:], p.Columns[idx:])
+		p.Columns[idx] = ColumnPrivileges{ColumnID: colID}
+	}
+	return &p.Columns[idx]
+}
+
+// RemoveColumn removes all the privileges granted on the given column. It is
+// called when the column is dropped.
+func (p *PrivilegeDescriptor) RemoveColumn(colID ColumnID) {
+	idx := p.findColumnIndex(colID)
+	if idx == -1 {
+		// Not found.
+		return
+	}
+	p.Columns = append(p.Columns[:idx], p.Columns[idx+1:]...)
+}
+
 // NewCustomSuperuserPrivilegeDescriptor returns a privilege descriptor for the root user
 // and the admin role with specified privileges.
 func NewCustomSuperuserPrivilegeDescriptor(priv privilege.List) *PrivilegeDescriptor {
@@ -173,6 +230,50 @@ func (p *PrivilegeDescriptor) Revoke(user string, privList privilege.List) {
 	}
 }
 
+// GrantColumns adds new privileges on the given columns for a given user.
+// Granting ALL on columns grants all the privileges in ColumnPrivilegeKinds.
+func (p *PrivilegeDescriptor) GrantColumns(
+	user string, privList privilege.List, colIDs []ColumnID,
+) {
+	bits := privList.ToBitField()
+	if isPrivilegeSet(bits, privilege.ALL) {
+		bits = ColumnPrivilegeKinds.ToBitField()
+	}
+	for _, colID := range colIDs {
+		col := p.findOrCreateColumn(colID)
+		userPriv := col.findOrCreateUser(user)
+		userPriv.Privileges |= bits
+	}
+}
+
+// RevokeColumns removes privileges on the given columns for a given user.
+// Privileges granted on the table as a whole are not affected.
+func (p *PrivilegeDescriptor) RevokeColumns(
+	user string, privList privilege.List, colIDs []ColumnID,
+) {
+	bits := privList.ToBitField()
+	if isPrivilegeSet(bits, privilege.ALL) {
+		bits = ColumnPrivilegeKinds.ToBitField()
+	}
+	for _, colID := range colIDs {
+		col, ok := p.findColumn(colID)
+		if !ok {
+			continue
+		}
+		userPriv, ok := col.findUser(user)
+		if !ok {
+			continue
+		}
+		userPriv.Privileges &^= bits
+		if userPriv.Privileges == 0 {
+			col.removeUser(user)
+		}
+		if len(col.Users) == 0 {
+			p.RemoveColumn(colID)
+		}
+	}
+}
+
 // MaybeFixPrivileges fixes the privilege descriptor if needed, including:
 // * adding default privileges for the "admin" role
 // * fixing default privileges for the "root" user
@@ -241,6 +342,10 @@ func (p PrivilegeDescriptor) Validate(id ID) error {
 		return err
 	}
 
+	if err := p.validateColumns(id); err != nil {
+		return err
+	}
+
 	// We expect an "admin" role. Check that it has desired superuser permissions.
 	if err := p.validateRequiredSuperuser(id, allowedPrivileges, AdminRole); err != nil {
 		return err
@@ -268,6 +373,27 @@ func (p PrivilegeDescriptor) Validate(id ID) error {
 	return nil
 }
 
+// validateColumns checks that the column-level privileges are sorted by
+// column ID and only contain privileges that can be granted on columns.
+func (p PrivilegeDescriptor) validateColumns(id ID) error {
+	if len(p.Columns) > 0 && IsReservedID(id) {
+		return fmt.Errorf("column privileges are not allowed on system object with ID=%d", id)
+	}
+	allowedBits := ColumnPrivilegeKinds.ToBitField()
+	for i, c := range p.Columns {
+		if i > 0 && p.Columns[i-1].ColumnID >= c.ColumnID {
+			return fmt.Errorf("column privileges are not sorted by column ID on object with ID=%d", id)
+		}
+		for _, u := range c.Users {
+			if remaining := u.Privileges &^ allowedBits; remaining != 0 {
+				return fmt.Errorf("user %s must not have %s privileges on column %d of object with ID=%d",
+					u.User, privilege.ListFromBitField(remaining), c.ColumnID, id)
+			}
+		}
+	}
+	return nil
+}
+
 func (p PrivilegeDescriptor) validateRequiredSuperuser(
 	id ID, allowedPrivileges privilege.List, user string,
 ) error {
@@ -324,11 +450,82 @@ func (p PrivilegeDescriptor) CheckPrivilege(user string, priv privilege.Kind) bo
 	return isPrivilegeSet(userPriv.Privileges, priv)
 }
 
-// AnyPrivilege returns true if 'user' has any privilege on this descriptor.
-func (p PrivilegeDescriptor) AnyPrivilege(user string) bool {
-	userPriv, ok := p.findUser(user)
+// CheckColumnPrivilege returns true if 'user' has 'privilege' on the column
+// with the given ID, either because it was granted on the whole descriptor or
+// on the column itself.
+func (p PrivilegeDescriptor) CheckColumnPrivilege(
+	user string, priv privilege.Kind, colID ColumnID,
+) bool {
+	if p.CheckPrivilege(user, priv) {
+		return true
+	}
+	col, ok := p.findColumn(colID)
+	if !ok {
+		return false
+	}
+	userPriv, ok := col.findUser(user)
 	if !ok {
 		return false
 	}
-	return userPriv.Privileges != 0
+	return isPrivilegeSet(userPriv.Privileges, priv)
+}
+
+// AnyPrivilege returns true if 'user' has any privilege on this descriptor,
+// including privileges granted on individual columns.
+func (p PrivilegeDescriptor) AnyPrivilege(user string) bool {
+	if userPriv, ok := p.findUser(user); ok && userPriv.Privileges != 0 {
+		return true
+	}
+	for i := range p.Columns {
+		if userPriv, ok := p.Columns[i].findUser(user); ok && userPriv.Privileges != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// findUserIndex looks for a given user and returns its index in the User
+// array if found. Returns -1 otherwise.
+func (c ColumnPrivileges) findUserIndex(user string) int {
+	idx := sort.Search(len(c.Users), func(i int) bool {
+		return c.Users[i].User >= user
+	})
+	if idx < len(c.Users) && c.Users[idx].User == user {
+		return idx
+	}
+	return -1
+}
+
+// findUser looks for a specific user in the list.
+// Returns (nil, false) if not found, or (obj, true) if found.
+func (c ColumnPrivileges) findUser(user string) (*UserPrivileges, bool) {
+	idx := c.findUserIndex(user)
+	if idx == -1 {
+		return nil, false
+	}
+	return &c.Users[idx], true
+}
+
+// findOrCreateUser looks for a specific user in the list, creating it if needed.
+func (c *ColumnPrivileges) findOrCreateUser(user string) *UserPrivileges {
+	idx := sort.Search(len(c.Users), func(i int) bool {
+		return c.Users[i].User >= user
+	})
+	if idx == len(c.Users) {
+		c.Users = append(c.Users, UserPrivileges{User: user})
+	} else if c.Users[idx].User != user {
+		c.Users = append(c.Users, UserPrivileges{})
+		copy(c.Users[idx+1:], c.Users[idx:])
+		c.Users[idx] = UserPrivileges{User: user}
+	}
+	return &c.Users[idx]
+}
+
+// removeUser looks for a given user in the list and removes it if present.
+func (c *ColumnPrivileges) removeUser(user string) {
+	idx := c.findUserIndex(user)
+	if idx == -1 {
+		return
+	}
+	c.Users = append(c.Users[:idx], c.Users[idx+1:]...)
 }
@@ -187,6 +187,69 @@ func TestAnyPrivilege(t *testing.T) {
 	}
 }
 
+func TestColumnPrivilege(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	id := ID(keys.MinUserDescID)
+	descriptor := NewDefaultPrivilegeDescriptor()
+
+	descriptor.GrantColumns("foo", privilege.List{privilege.SELECT}, []ColumnID{3, 1})
+	descriptor.GrantColumns("bar", privilege.List{privilege.ALL}, []ColumnID{2})
+	if err := descriptor.Validate(id); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		user  string
+		priv  privilege.Kind
+		colID ColumnID
+		exp   bool
+	}{
+		{"foo", privilege.SELECT, 1, true},
+		{"foo", privilege.SELECT, 2, false},
+		{"foo", privilege.SELECT, 3, true},
+		{"foo", privilege.UPDATE, 1, false},
+		{"bar", privilege.SELECT, 2, true},
+		{"bar", privilege.INSERT, 2, true},
+		{"bar", privilege.UPDATE, 2, true},
+		{"bar", privilege.DELETE, 2, false},
+		{"baz", privilege.SELECT, 1, false},
+		{security.RootUser, privilege.SELECT, 2, true},
+	}
+	for tcNum, tc := range testCases {
+		if found := descriptor.CheckColumnPrivilege(tc.user, tc.priv, tc.colID); found != tc.exp {
+			t.Errorf("#%d: CheckColumnPrivilege(%s, %v, %d) = %t, expected %t",
+				tcNum, tc.user, tc.priv, tc.colID, found, tc.exp)
+		}
+	}
+
+	if descriptor.CheckPrivilege("foo", privilege.SELECT) {
+		t.Error("column privileges must not grant table privileges")
+	}
+	if !descriptor.AnyPrivilege("foo") {
+		t.Error("expected column privileges to count towards AnyPrivilege")
+	}
+
+	descriptor.RevokeColumns("foo", privilege.List{privilege.SELECT}, []ColumnID{1})
+	if descriptor.CheckColumnPrivilege("foo", privilege.SELECT, 1) {
+		t.Error("expected SELECT on column 1 to be revoked")
+	}
+	descriptor.RemoveColumn(3)
+	if descriptor.AnyPrivilege("foo") {
+		t.Errorf("expected no privileges left for foo, got %+v", descriptor.Columns)
+	}
+	expPrivs := privilege.List{privilege.SELECT, privilege.INSERT, privilege.UPDATE}.ToBitField()
+	if cols := descriptor.Columns; len(cols) != 1 || len(cols[0].Users) != 1 ||
+		cols[0].Users[0].User != "bar" || cols[0].Users[0].Privileges != expPrivs {
+		t.Errorf("unexpected column privileges: %+v", cols)
+	}
+
+	// Only SELECT, INSERT and UPDATE can be granted on columns.
+	descriptor.Columns[0].Users[0].Privileges |= privilege.DROP.Mask()
+	if err := descriptor.Validate(id); !testutils.IsError(err, "must not have DROP privileges on column 2") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 // TestPrivilegeValidate exercises validation for non-system descriptors.
 func TestPrivilegeValidate(t *testing.T) {
 	defer leaktest.AfterTest(t)()
@@ -2648,6 +2648,8 @@ func (desc *Descriptor) GetID() ID {
 		return t.Table.ID
 	case *Descriptor_Database:
 		return t.Database.ID
+	case *Descriptor_Type:
+		return t.Type.ID
 	default:
 		return 0
 	}
@@ -2660,6 +2662,8 @@ func (desc *Descriptor) GetName() string {
 		return t.Table.Name
 	case *Descriptor_Database:
 		return t.Database.Name
+	case *Descriptor_Type:
+		return t.Type.Name
 	default:
 		return ""
 	}
@@ -3002,6 +3006,54 @@ func (desc *DatabaseDescriptor) GetAuditMode() TableDescriptor_AuditMode {
 	return TableDescriptor_DISABLED
 }
 
+// SetID implements the DescriptorProto interface.
+func (desc *TypeDescriptor) SetID(id ID) {
+	desc.ID = id
+}
+
+// TypeName returns the plain type of this descriptor.
+func (desc *TypeDescriptor) TypeName() string {
+	return "type"
+}
+
+// SetName implements the DescriptorProto interface.
+func (desc *TypeDescriptor) SetName(name string) {
+	desc.Name = name
+}
+
+// GetAuditMode is part of the DescriptorProto interface.
+func (desc *TypeDescriptor) GetAuditMode() TableDescriptor_AuditMode {
+	return TableDescriptor_DISABLED
+}
+
+// FindCheck returns the check constraint of the domain with the given name,
+// or -1 if there is none.
+func (desc *TypeDescriptor) FindCheck(name string) int {
+	for i := range desc.Checks {
+		if desc.Checks[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Validate validates that the type descriptor is well formed.
+func (desc *TypeDescriptor) Validate() error {
+	if err := validateName(desc.Name, "type"); err != nil {
+		return err
+	}
+	if desc.ID == 0 {
+		return fmt.Errorf("invalid type ID %d", desc.ID)
+	}
+	if desc.ParentID == 0 {
+		return fmt.Errorf("invalid parent ID %d", desc.ParentID)
+	}
+	if desc.Kind == TypeDescriptor_COMPOSITE && desc.BaseType.Family() != types.TupleFamily {
+		return fmt.Errorf("invalid row type %s for composite type %q", desc.BaseType.String(), desc.Name)
+	}
+	return desc.Privileges.Validate(desc.ID)
+}
+
 // FindAllReferences returns all the references from a table.
 func (desc *TableDescriptor) FindAllReferences() (map[ID]struct{}, error) {
 	refs := map[ID]struct{}{}
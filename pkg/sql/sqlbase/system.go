@@ -226,6 +226,52 @@ CREATE TABLE system.comments (
    comment   STRING NOT NULL, -- the comment
    PRIMARY KEY (type, object_id, sub_id)
 );`
+
+	// role_options stores the attributes of users and roles (LOGIN, CREATEROLE,
+	// VALID UNTIL, ...) set with CREATE/ALTER USER or ROLE.
+	RoleOptionsTableSchema = `
+CREATE TABLE system.role_options (
+  username STRING NOT NULL,
+  option   STRING NOT NULL,
+  value    STRING,
+  PRIMARY KEY (username, option),
+  FAMILY "primary" (username, option, value)
+);`
+
+	// protected_ts_records holds the protected timestamp records which keep the
+	// GC queue from removing data that is still needed to read at their
+	// timestamp. The spans column holds an encoded ptpb.Spans.
+	ProtectedTimestampsRecordsTableSchema = `
+CREATE TABLE system.protected_ts_records (
+  id        UUID NOT NULL,
+  ts        DECIMAL NOT NULL,
+  meta_type STRING NOT NULL,
+  meta      BYTES,
+  spans     BYTES NOT NULL,
+  PRIMARY KEY (id),
+  FAMILY "primary" (id, ts, meta_type, meta, spans)
+);`
+
+	// scheduled_jobs holds the schedules run by the job scheduler. A schedule
+	// whose next_run is NULL is paused. The execution_args and schedule_state
+	// columns are opaque to the scheduler and are interpreted by the executor
+	// named by executor_type.
+	ScheduledJobsTableSchema = `
+CREATE TABLE system.scheduled_jobs (
+  schedule_id     INT8 DEFAULT unique_rowid() NOT NULL,
+  schedule_name   STRING NOT NULL,
+  created         TIMESTAMP NOT NULL DEFAULT now(),
+  owner           STRING NOT NULL,
+  next_run        TIMESTAMP,
+  schedule_expr   STRING NOT NULL,
+  executor_type   STRING NOT NULL,
+  execution_args  BYTES NOT NULL,
+  last_run_status STRING,
+  schedule_state  BYTES,
+  PRIMARY KEY (schedule_id),
+  INDEX (next_run),
+  FAMILY "primary" (schedule_id, schedule_name, created, owner, next_run, schedule_expr, executor_type, execution_args, last_run_status, schedule_state)
+);`
 )
 
 func pk(name string) IndexDescriptor {
@@ -260,12 +306,15 @@ var SystemAllowedPrivileges = map[ID]privilege.List{
 	// users will be able to modify system tables' schemas at will. CREATE and
 	// DROP privileges are allowed on the above system tables for backwards
 	// compatibility reasons only!
-	keys.JobsTableID:            privilege.ReadWriteData,
-	keys.WebSessionsTableID:     privilege.ReadWriteData,
-	keys.TableStatisticsTableID: privilege.ReadWriteData,
-	keys.LocationsTableID:       privilege.ReadWriteData,
-	keys.RoleMembersTableID:     privilege.ReadWriteData,
-	keys.CommentsTableID:        privilege.ReadWriteData,
+	keys.JobsTableID:                       privilege.ReadWriteData,
+	keys.WebSessionsTableID:                privilege.ReadWriteData,
+	keys.TableStatisticsTableID:            privilege.ReadWriteData,
+	keys.LocationsTableID:                  privilege.ReadWriteData,
+	keys.RoleMembersTableID:                privilege.ReadWriteData,
+	keys.CommentsTableID:                   privilege.ReadWriteData,
+	keys.RoleOptionsTableID:                privilege.ReadWriteData,
+	keys.ProtectedTimestampsRecordsTableID: privilege.ReadWriteData,
+	keys.ScheduledJobsTableID:              privilege.ReadWriteData,
 }
 
 // Helpers used to make some of the TableDescriptor literals below more concise.
@@ -862,6 +911,122 @@ var (
 		FormatVersion:  InterleavedFormatVersion,
 		NextMutationID: 1,
 	}
+
+	// RoleOptionsTable is the descriptor for the role_options table.
+	RoleOptionsTable = TableDescriptor{
+		Name:     "role_options",
+		ID:       keys.RoleOptionsTableID,
+		ParentID: keys.SystemDatabaseID,
+		Version:  1,
+		Columns: []ColumnDescriptor{
+			{Name: "username", ID: 1, Type: *types.String},
+			{Name: "option", ID: 2, Type: *types.String},
+			{Name: "value", ID: 3, Type: *types.String, Nullable: true},
+		},
+		NextColumnID: 4,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name:        "primary",
+				ID:          0,
+				ColumnNames: []string{"username", "option", "value"},
+				ColumnIDs:   []ColumnID{1, 2, 3},
+			},
+		},
+		NextFamilyID: 1,
+		PrimaryIndex: IndexDescriptor{
+			Name:             "primary",
+			ID:               1,
+			Unique:           true,
+			ColumnNames:      []string{"username", "option"},
+			ColumnDirections: []IndexDescriptor_Direction{IndexDescriptor_ASC, IndexDescriptor_ASC},
+			ColumnIDs:        []ColumnID{1, 2},
+		},
+		NextIndexID:    2,
+		Privileges:     NewCustomSuperuserPrivilegeDescriptor(SystemAllowedPrivileges[keys.RoleOptionsTableID]),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	}
+
+	// ProtectedTimestampsRecordsTable is the descriptor for the
+	// protected_ts_records table.
+	ProtectedTimestampsRecordsTable = TableDescriptor{
+		Name:     "protected_ts_records",
+		ID:       keys.ProtectedTimestampsRecordsTableID,
+		ParentID: keys.SystemDatabaseID,
+		Version:  1,
+		Columns: []ColumnDescriptor{
+			{Name: "id", ID: 1, Type: *types.Uuid},
+			{Name: "ts", ID: 2, Type: *types.Decimal},
+			{Name: "meta_type", ID: 3, Type: *types.String},
+			{Name: "meta", ID: 4, Type: *types.Bytes, Nullable: true},
+			{Name: "spans", ID: 5, Type: *types.Bytes},
+		},
+		NextColumnID: 6,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name:        "primary",
+				ID:          0,
+				ColumnNames: []string{"id", "ts", "meta_type", "meta", "spans"},
+				ColumnIDs:   []ColumnID{1, 2, 3, 4, 5},
+			},
+		},
+		NextFamilyID:   1,
+		PrimaryIndex:   pk("id"),
+		NextIndexID:    2,
+		Privileges:     NewCustomSuperuserPrivilegeDescriptor(SystemAllowedPrivileges[keys.ProtectedTimestampsRecordsTableID]),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	}
+
+	// ScheduledJobsTable is the descriptor for the scheduled_jobs table.
+	ScheduledJobsTable = TableDescriptor{
+		Name:     "scheduled_jobs",
+		ID:       keys.ScheduledJobsTableID,
+		ParentID: keys.SystemDatabaseID,
+		Version:  1,
+		Columns: []ColumnDescriptor{
+			{Name: "schedule_id", ID: 1, Type: *types.Int, DefaultExpr: &uniqueRowIDString},
+			{Name: "schedule_name", ID: 2, Type: *types.String},
+			{Name: "created", ID: 3, Type: *types.Timestamp, DefaultExpr: &nowString},
+			{Name: "owner", ID: 4, Type: *types.String},
+			{Name: "next_run", ID: 5, Type: *types.Timestamp, Nullable: true},
+			{Name: "schedule_expr", ID: 6, Type: *types.String},
+			{Name: "executor_type", ID: 7, Type: *types.String},
+			{Name: "execution_args", ID: 8, Type: *types.Bytes},
+			{Name: "last_run_status", ID: 9, Type: *types.String, Nullable: true},
+			{Name: "schedule_state", ID: 10, Type: *types.Bytes, Nullable: true},
+		},
+		NextColumnID: 11,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name: "primary",
+				ID:   0,
+				ColumnNames: []string{
+					"schedule_id", "schedule_name", "created", "owner", "next_run",
+					"schedule_expr", "executor_type", "execution_args", "last_run_status",
+					"schedule_state",
+				},
+				ColumnIDs: []ColumnID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			},
+		},
+		NextFamilyID: 1,
+		PrimaryIndex: pk("schedule_id"),
+		Indexes: []IndexDescriptor{
+			{
+				Name:             "scheduled_jobs_next_run_idx",
+				ID:               2,
+				Unique:           false,
+				ColumnNames:      []string{"next_run"},
+				ColumnDirections: singleASC,
+				ColumnIDs:        []ColumnID{5},
+				ExtraColumnIDs:   []ColumnID{1},
+			},
+		},
+		NextIndexID:    3,
+		Privileges:     NewCustomSuperuserPrivilegeDescriptor(SystemAllowedPrivileges[keys.ScheduledJobsTableID]),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	}
 )
 
 // Create a kv pair for the zone config for the given key and config value.
@@ -907,6 +1072,18 @@ func addSystemDescriptorsToSchema(target *MetadataSchema) {
 	// The CommentsTable has been introduced in 2.2. It was added here since it
 	// was introduced, but it's also created as a migration for older clusters.
 	target.AddDescriptor(keys.SystemDatabaseID, &CommentsTable)
+
+	// The RoleOptionsTable has been introduced in 19.2. It is also created as a
+	// migration for older clusters.
+	target.AddDescriptor(keys.SystemDatabaseID, &RoleOptionsTable)
+
+	// The ProtectedTimestampsRecordsTable has been introduced in 19.2. It is
+	// also created as a migration for older clusters.
+	target.AddDescriptor(keys.SystemDatabaseID, &ProtectedTimestampsRecordsTable)
+
+	// The ScheduledJobsTable has been introduced in 19.2. It is also created as
+	// a migration for older clusters.
+	target.AddDescriptor(keys.SystemDatabaseID, &ScheduledJobsTable)
 }
 
 // addSystemDatabaseToSchema populates the supplied MetadataSchema with the
@@ -71,6 +71,11 @@ func SanitizeVarFreeExpr(
 // ValidateColumnDefType returns an error if the type of a column definition is
 // not valid. It is checked when a column is created or altered.
 func ValidateColumnDefType(t *types.T) error {
+	if name, ok := t.DomainReference(); ok {
+		// Domains are replaced by their base type before columns are created;
+		// any reference left is to a domain that does not exist.
+		return tree.NewUndefinedTypeError(name)
+	}
 	switch t.Family() {
 	case types.StringFamily, types.CollatedStringFamily:
 		if t.Family() == types.CollatedStringFamily {
@@ -99,6 +104,18 @@ func ValidateColumnDefType(t *types.T) error {
 		}
 		return ValidateColumnDefType(t.ArrayContents())
 
+	case types.TupleFamily:
+		if t.CompositeTypeName() == "" {
+			// Only the row types of composite types can be stored.
+			return pgerror.Newf(pgcode.InvalidTableDefinition,
+				"value type %s cannot be used for table columns", t.String())
+		}
+		for i := range t.TupleContents() {
+			if err := ValidateColumnDefType(&t.TupleContents()[i]); err != nil {
+				return err
+			}
+		}
+
 	case types.BitFamily, types.IntFamily, types.FloatFamily, types.BoolFamily, types.BytesFamily, types.DateFamily,
 		types.INetFamily, types.IntervalFamily, types.JsonFamily, types.OidFamily, types.TimeFamily,
 		types.TimestampFamily, types.TimestampTZFamily, types.UuidFamily:
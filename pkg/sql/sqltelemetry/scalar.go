@@ -54,6 +54,10 @@ func CastOpCounter(ftyp, ttyp string) telemetry.Counter {
 // counter for the element type.
 var ArrayCastCounter = telemetry.GetCounterOnce("sql.plan.ops.cast.arrays")
 
+// TupleCastCounter is to be incremented when type checking all casts
+// between tuples, such as casts to composite types.
+var TupleCastCounter = telemetry.GetCounterOnce("sql.plan.ops.cast.tuples")
+
 // ArrayConstructorCounter is to be incremented upon type checking
 // of ARRAY[...] expressions/
 var ArrayConstructorCounter = telemetry.GetCounterOnce("sql.plan.ops.array.cons")
@@ -21,6 +21,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -116,6 +118,11 @@ type TableCollection struct {
 	// an uncommitted transaction.
 	uncommittedDatabases []uncommittedDatabase
 
+	// User-defined types modified by the uncommitted transaction. While there
+	// are any, types are read within the transaction rather than from the
+	// databaseCache.
+	uncommittedTypes []uncommittedType
+
 	// allDescriptors is a slice of all available descriptors. The descriptors
 	// are cached to avoid repeated lookups by users like virtual tables. The
 	// cache is purged whenever events would cause a scan of all descriptors to
@@ -398,6 +405,7 @@ func (tc *TableCollection) releaseTables(ctx context.Context) {
 	tc.releaseLeases(ctx)
 	tc.uncommittedTables = nil
 	tc.uncommittedDatabases = nil
+	tc.uncommittedTypes = nil
 	tc.releaseAllDescriptors()
 }
 
@@ -434,6 +442,24 @@ func (tc *TableCollection) waitForCacheToDropDatabases(ctx context.Context) {
 	}
 }
 
+// Wait until the database cache has been updated to reflect the user-defined
+// types modified by the transaction, so that future commands on the same
+// gateway node observe them.
+func (tc *TableCollection) waitForCacheToUpdateTypes(ctx context.Context) {
+	for _, ut := range tc.uncommittedTypes {
+		tc.dbCacheSubscriber.waitForCacheState(
+			func(dc *databaseCache) bool {
+				typ, err := dc.getCachedTypeDescByID(ut.id)
+				if err != nil || typ == nil {
+					// Types missing from the cache are read from the store. Like in
+					// waitForCacheToDropDatabases, errors are swallowed.
+					return true
+				}
+				return !ut.dropped && typ.Version >= ut.version
+			})
+	}
+}
+
 func (tc *TableCollection) hasUncommittedTables() bool {
 	return len(tc.uncommittedTables) > 0
 }
@@ -474,6 +500,19 @@ func (tc *TableCollection) getTablesWithNewVersion() []IDVersion {
 	return tables
 }
 
+// uncommittedType identifies a user-defined type written or dropped by the
+// uncommitted transaction.
+type uncommittedType struct {
+	id      sqlbase.ID
+	version sqlbase.DescriptorVersion
+	dropped bool
+}
+
+func (tc *TableCollection) addUncommittedType(typ *sqlbase.TypeDescriptor, dropped bool) {
+	tc.uncommittedTypes = append(tc.uncommittedTypes,
+		uncommittedType{id: typ.ID, version: typ.Version, dropped: dropped})
+}
+
 type dbAction bool
 
 const (
@@ -609,6 +648,7 @@ func (tc *TableCollection) copyModifiedSchema(to *TableCollection) {
 	}
 	to.uncommittedTables = tc.uncommittedTables
 	to.uncommittedDatabases = tc.uncommittedDatabases
+	to.uncommittedTypes = tc.uncommittedTypes
 	// Do not copy the leased descriptors because we do not want
 	// the leased descriptors to be released by the "to" TableCollection.
 	// The "to" TableCollection can re-lease the same descriptors.
@@ -834,6 +874,12 @@ func (p *planner) writeTableDescToBatch(
 			return err
 		}
 	} else {
+		// The descriptor of a table being imported into online is only changed
+		// by the import job, which expects it to be unchanged when it's done.
+		if tableDesc.OnlineImport {
+			return pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+				"table %q is being imported into", tableDesc.Name)
+		}
 		// Only increment the table descriptor version once in this transaction.
 		if err := tableDesc.MaybeIncrementVersion(ctx, p.txn); err != nil {
 			return err
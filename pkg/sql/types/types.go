@@ -159,6 +159,12 @@ type T struct {
 	// string representation of an unexported field. This is a problem when this
 	// struct is embedded in a larger struct (like a ColumnDescriptor).
 	InternalType InternalType
+
+	// domainName is set on the placeholder types created by
+	// MakeDomainReference, along with domainPrefix if the name is qualified.
+	// They are never persisted.
+	domainName   string
+	domainPrefix []string
 }
 
 // Convenience list of pre-constructed types. Caller code can use any of these
@@ -797,6 +803,63 @@ func (t *T) TupleLabels() []string {
 	return t.InternalType.TupleLabels
 }
 
+// MakeCompositeType constructs a new instance of a TupleFamily type which is
+// the row type of the composite type with the given name. The name is only
+// used to display the type.
+func MakeCompositeType(name string, contents []T, labels []string) *T {
+	t := MakeLabeledTuple(contents, labels)
+	t.InternalType.TypeName = &name
+	return t
+}
+
+// CompositeTypeName returns the name of the composite type a TupleFamily type
+// was declared with, or the empty string if the type is not the row type of a
+// composite type.
+func (t *T) CompositeTypeName() string {
+	if t.InternalType.TypeName == nil {
+		return ""
+	}
+	return *t.InternalType.TypeName
+}
+
+// MakeDomainReference returns a placeholder type that refers by name to a
+// user-defined type: a domain or a composite type. The parser produces it for
+// type names that do not match any built-in type. It has the Unknown family and
+// must be resolved by the SQL layer before it can be used.
+func MakeDomainReference(name string) *T {
+	return MakeQualifiedDomainReference(nil /* prefix */, name)
+}
+
+// MakeQualifiedDomainReference is like MakeDomainReference, for a name
+// qualified by the names of a schema and/or a database, listed in prefix in
+// the order they are written.
+func MakeQualifiedDomainReference(prefix []string, name string) *T {
+	return &T{
+		InternalType: InternalType{Family: UnknownFamily, Oid: oid.T_unknown},
+		domainName:   name,
+		domainPrefix: prefix,
+	}
+}
+
+// DomainReference returns the name of the user-defined type, qualified as it
+// was written, if the type is a placeholder created by MakeDomainReference.
+func (t *T) DomainReference() (name string, ok bool) {
+	if t.domainName == "" {
+		return "", false
+	}
+	if len(t.domainPrefix) == 0 {
+		return t.domainName, true
+	}
+	return strings.Join(t.domainPrefix, ".") + "." + t.domainName, true
+}
+
+// DomainReferenceParts returns the unqualified name of the user-defined type
+// and the names qualifying it, if the type is a placeholder created by
+// MakeDomainReference.
+func (t *T) DomainReferenceParts() (prefix []string, name string) {
+	return t.domainPrefix, t.domainName
+}
+
 // Name returns a single word description of the type that describes it
 // succinctly, but without all the details, such as width, locale, etc. The name
 // is sometimes the same as the name returned by SQLStandardName, but is more
@@ -804,6 +867,12 @@ func (t *T) TupleLabels() []string {
 //
 // TODO(andyk): Should these be changed to be the same as SQLStandardName?
 func (t *T) Name() string {
+	if name, ok := t.DomainReference(); ok {
+		return name
+	}
+	if name := t.CompositeTypeName(); name != "" {
+		return name
+	}
 	switch t.Family() {
 	case AnyFamily:
 		return "anyelement"
@@ -1039,6 +1108,25 @@ func (t *T) InformationSchemaName() string {
 // reproduce the type via parsing the string as a type. It is used in error
 // messages and also to produce the output of SHOW CREATE.
 func (t *T) SQLString() string {
+	if t.domainName != "" {
+		if len(t.domainPrefix) == 0 {
+			return userDefinedTypeSQL(t.domainName)
+		}
+		// Only the first part of a qualified name is parsed like an unqualified
+		// name.
+		var buf bytes.Buffer
+		buf.WriteString(userDefinedTypeSQL(t.domainPrefix[0]))
+		for _, part := range t.domainPrefix[1:] {
+			buf.WriteByte('.')
+			lex.EncodeUnrestrictedSQLIdent(&buf, part, lex.EncNoFlags)
+		}
+		buf.WriteByte('.')
+		lex.EncodeUnrestrictedSQLIdent(&buf, t.domainName, lex.EncNoFlags)
+		return buf.String()
+	}
+	if name := t.CompositeTypeName(); name != "" {
+		return userDefinedTypeSQL(name)
+	}
 	switch t.Family() {
 	case BitFamily:
 		o := t.Oid()
@@ -1113,6 +1201,18 @@ func (t *T) SQLString() string {
 	return strings.ToUpper(t.Name())
 }
 
+// userDefinedTypeSQL returns the SQL string that parses as a reference to the
+// user-defined type with the given name.
+func userDefinedTypeSQL(name string) string {
+	if _, isKeyword := lex.KeywordsCategories[name]; isKeyword {
+		// Keywords are only parsed as type names when quoted.
+		return `"` + name + `"`
+	}
+	var buf bytes.Buffer
+	lex.EncodeUnrestrictedSQLIdent(&buf, name, lex.EncNoFlags)
+	return buf.String()
+}
+
 // Equivalent returns true if this type is "equivalent" to the given type.
 // Equivalent types are compatible with one another: they can be compared,
 // assigned, and unioned. Equivalent types must always have the same type family
@@ -1245,6 +1345,13 @@ func (t *InternalType) Identical(other *InternalType) bool {
 			return false
 		}
 	}
+	if t.TypeName != nil && other.TypeName != nil {
+		if *t.TypeName != *other.TypeName {
+			return false
+		}
+	} else if t.TypeName != nil || other.TypeName != nil {
+		return false
+	}
 	return t.Oid == other.Oid
 }
 
@@ -1637,6 +1744,10 @@ func IsValidArrayElementType(t *T) (valid bool, issueNum int) {
 // CheckArrayElementType ensures that the given type can be used as the element
 // type of an ArrayFamily-typed column. If not, it returns an error.
 func CheckArrayElementType(t *T) error {
+	if name, ok := t.DomainReference(); ok {
+		return unimplemented.NewWithIssueDetailf(27796, "array",
+			"arrays of user-defined type %s are not supported", name)
+	}
 	if ok, issueNum := IsValidArrayElementType(t); !ok {
 		return unimplemented.NewWithIssueDetailf(issueNum, t.String(),
 			"arrays of %s not allowed", t)
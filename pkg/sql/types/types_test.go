@@ -23,6 +23,7 @@ import (
 
 func TestTypes(t *testing.T) {
 	enCollate := "en"
+	fooName := "foo"
 
 	testCases := []struct {
 		actual   *T
@@ -245,6 +246,9 @@ func TestTypes(t *testing.T) {
 		{MakeLabeledTuple([]T{*Int, *String}, []string{"foo", "bar"}), &T{InternalType: InternalType{
 			Family: TupleFamily, Oid: oid.T_record, TupleContents: []T{*Int, *String},
 			TupleLabels: []string{"foo", "bar"}, Locale: &emptyLocale}}},
+		{MakeCompositeType("foo", []T{*Int, *String}, []string{"a", "b"}), &T{InternalType: InternalType{
+			Family: TupleFamily, Oid: oid.T_record, TupleContents: []T{*Int, *String},
+			TupleLabels: []string{"a", "b"}, TypeName: &fooName, Locale: &emptyLocale}}},
 
 		// UNKNOWN
 		{Unknown, &T{InternalType: InternalType{
@@ -354,6 +358,8 @@ func TestEquivalent(t *testing.T) {
 		{MakeLabeledTuple([]T{*Int, *String}, []string{"label1", "label2"}),
 			MakeLabeledTuple([]T{*Int4, *VarChar}, []string{"label2", "label1"}), true},
 		{MakeTuple([]T{*String, *Int}), MakeTuple([]T{*Int, *String}), false},
+		{MakeCompositeType("foo", []T{*Int, *String}, []string{"a", "b"}),
+			MakeTuple([]T{*Int4, *VarChar}), true},
 
 		// UNKNOWN
 		{Unknown, &T{InternalType: InternalType{
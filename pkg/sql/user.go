@@ -14,36 +14,130 @@ package sql
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/roleoption"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
 )
 
-// GetUserHashedPassword returns the hashedPassword for the given username if
-// found in system.users.
-func GetUserHashedPassword(
+// UserLoginInfo contains the information from system.users and
+// system.role_options needed to authenticate a user.
+type UserLoginInfo struct {
+	// Exists is true if the user or role exists.
+	Exists bool
+	// CanLogin is true if the user or role is allowed to log in. Users can log
+	// in unless they have the NOLOGIN option, and roles can't log in unless
+	// they have the LOGIN option.
+	CanLogin bool
+	// HashedPassword is the user's hashed password, if any.
+	HashedPassword []byte
+	// ValidUntil is the time after which the password is no longer valid, if
+	// set with the VALID UNTIL option.
+	ValidUntil *time.Time
+	// ConnectionLimit is the maximum number of concurrent connections per
+	// node, or -1 if unlimited.
+	ConnectionLimit int
+}
+
+// PasswordExpired returns true if the password is past its VALID UNTIL time.
+func (info *UserLoginInfo) PasswordExpired(now time.Time) bool {
+	return info.ValidUntil != nil && now.After(*info.ValidUntil)
+}
+
+// GetUserLoginInfo looks up the login information for the given username.
+func GetUserLoginInfo(
 	ctx context.Context, ie *InternalExecutor, metrics *MemoryMetrics, username string,
-) (bool, []byte, error) {
+) (UserLoginInfo, error) {
 	normalizedUsername := tree.Name(username).Normalize()
-	// Always return no password for the root user, even if someone manually inserts one.
+	// Always return no password for the root user, even if someone manually
+	// inserts one, and don't let role options lock root out.
 	if normalizedUsername == security.RootUser {
-		return true, nil, nil
+		return UserLoginInfo{Exists: true, CanLogin: true, ConnectionLimit: -1}, nil
 	}
 
-	const getHashedPassword = `SELECT "hashedPassword" FROM system.users ` +
-		`WHERE username=$1 AND "isRole" = false`
-	values, err := ie.QueryRow(
-		ctx, "get-hashed-pwd", nil /* txn */, getHashedPassword, normalizedUsername)
+	// The role options are looked up along with the password. There is one row
+	// per option, or a single row with NULL options if the user has none.
+	const getLoginInfo = `SELECT "hashedPassword", "isRole", option, value ` +
+		`FROM system.users LEFT JOIN system.role_options USING (username) ` +
+		`WHERE username=$1`
+	rows, err := ie.Query(
+		ctx, "get-login-info", nil /* txn */, getLoginInfo, normalizedUsername)
+	if err != nil && pgerror.GetPGCode(err) == pgcode.UndefinedTable {
+		// The system.role_options table is created by a migration, which may
+		// not have run yet on a cluster upgraded from an older version. Users
+		// don't have role options in that case.
+		const getHashedPassword = `SELECT "hashedPassword", "isRole", NULL, NULL ` +
+			`FROM system.users WHERE username=$1`
+		rows, err = ie.Query(
+			ctx, "get-hashed-pwd", nil /* txn */, getHashedPassword, normalizedUsername)
+	}
 	if err != nil {
-		return false, nil, errors.Wrapf(err, "error looking up user %s", normalizedUsername)
+		return UserLoginInfo{}, errors.Wrapf(err, "error looking up user %s", normalizedUsername)
+	}
+	if len(rows) == 0 {
+		return UserLoginInfo{}, nil
+	}
+	info := UserLoginInfo{
+		Exists:          true,
+		CanLogin:        !bool(tree.MustBeDBool(rows[0][1])),
+		HashedPassword:  []byte(*(rows[0][0].(*tree.DBytes))),
+		ConnectionLimit: -1,
 	}
-	if values == nil {
-		return false, nil, nil
+
+	for _, row := range rows {
+		if row[2] == tree.DNull {
+			continue
+		}
+		option, err := roleoption.ToOption(string(tree.MustBeDString(row[2])))
+		if err != nil {
+			return UserLoginInfo{}, err
+		}
+		switch option {
+		case roleoption.LOGIN:
+			info.CanLogin = true
+		case roleoption.NOLOGIN:
+			info.CanLogin = false
+		case roleoption.VALIDUNTIL:
+			// The value was validated when the option was set.
+			ts, err := tree.ParseDTimestampTZ(
+				nil /* ctx */, string(tree.MustBeDString(row[3])), time.Microsecond,
+			)
+			if err != nil {
+				return UserLoginInfo{}, err
+			}
+			info.ValidUntil = &ts.Time
+		case roleoption.CONNECTIONLIMIT:
+			limit, err := strconv.Atoi(string(tree.MustBeDString(row[3])))
+			if err != nil {
+				return UserLoginInfo{}, err
+			}
+			info.ConnectionLimit = limit
+		}
+	}
+	return info, nil
+}
+
+// GetUserHashedPassword returns the hashedPassword for the given username if
+// found in system.users and allowed to log in. The password is not returned
+// if it has expired.
+func GetUserHashedPassword(
+	ctx context.Context, ie *InternalExecutor, metrics *MemoryMetrics, username string,
+) (bool, []byte, error) {
+	info, err := GetUserLoginInfo(ctx, ie, metrics, username)
+	if err != nil || !info.Exists || !info.CanLogin {
+		return false, nil, err
+	}
+	if info.PasswordExpired(timeutil.Now()) {
+		return true, nil, nil
 	}
-	hashedPassword := []byte(*(values[0].(*tree.DBytes)))
-	return true, hashedPassword, nil
+	return true, info.HashedPassword, nil
 }
 
 // The map value is true if the map key is a role, false if it is a user.
@@ -937,7 +937,7 @@ func (p *planner) GetSessionVar(
 }
 
 // SetSessionVar implements the EvalSessionAccessor interface.
-func (p *planner) SetSessionVar(ctx context.Context, varName, newVal string) error {
+func (p *planner) SetSessionVar(ctx context.Context, varName, newVal string, isLocal bool) error {
 	name := strings.ToLower(varName)
 	_, v, err := getSessionVar(name, false /* missingOk */)
 	if err != nil {
@@ -947,8 +947,5 @@ func (p *planner) SetSessionVar(ctx context.Context, varName, newVal string) err
 	if v.Set == nil && v.RuntimeSet == nil {
 		return newCannotChangeParameterError(name)
 	}
-	if v.RuntimeSet != nil {
-		return v.RuntimeSet(ctx, &p.extendedEvalCtx, newVal)
-	}
-	return v.Set(ctx, p.sessionDataMutator, newVal)
+	return p.setSessionVar(ctx, v, newVal, isLocal)
 }
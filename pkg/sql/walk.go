@@ -599,6 +599,9 @@ func (v *planVisitor) visitInternal(plan planNode, name string) {
 	case *controlJobsNode:
 		n.rows = v.visit(n.rows)
 
+	case *controlSchedulesNode:
+		n.rows = v.visit(n.rows)
+
 	case *setZoneConfigNode:
 		if v.observer.expr != nil {
 			v.metadataExpr(name, "yaml", -1, n.yamlConfig)
@@ -708,82 +711,88 @@ func joinTypeStr(t sqlbase.JoinType) string {
 // strings are constant and not precomputed so that the type names can
 // be changed without changing the output of "EXPLAIN".
 var planNodeNames = map[reflect.Type]string{
-	reflect.TypeOf(&alterIndexNode{}):           "alter index",
-	reflect.TypeOf(&alterSequenceNode{}):        "alter sequence",
-	reflect.TypeOf(&alterTableNode{}):           "alter table",
-	reflect.TypeOf(&alterUserSetPasswordNode{}): "alter user",
-	reflect.TypeOf(&applyJoinNode{}):            "apply-join",
-	reflect.TypeOf(&bufferNode{}):               "buffer node",
-	reflect.TypeOf(&commentOnColumnNode{}):      "comment on column",
-	reflect.TypeOf(&commentOnDatabaseNode{}):    "comment on database",
-	reflect.TypeOf(&commentOnTableNode{}):       "comment on table",
-	reflect.TypeOf(&cancelQueriesNode{}):        "cancel queries",
-	reflect.TypeOf(&cancelSessionsNode{}):       "cancel sessions",
-	reflect.TypeOf(&controlJobsNode{}):          "control jobs",
-	reflect.TypeOf(&createDatabaseNode{}):       "create database",
-	reflect.TypeOf(&createIndexNode{}):          "create index",
-	reflect.TypeOf(&createSequenceNode{}):       "create sequence",
-	reflect.TypeOf(&createStatsNode{}):          "create statistics",
-	reflect.TypeOf(&createTableNode{}):          "create table",
-	reflect.TypeOf(&CreateUserNode{}):           "create user/role",
-	reflect.TypeOf(&createViewNode{}):           "create view",
-	reflect.TypeOf(&delayedNode{}):              "virtual table",
-	reflect.TypeOf(&deleteNode{}):               "delete",
-	reflect.TypeOf(&deleteRangeNode{}):          "delete range",
-	reflect.TypeOf(&distinctNode{}):             "distinct",
-	reflect.TypeOf(&dropDatabaseNode{}):         "drop database",
-	reflect.TypeOf(&dropIndexNode{}):            "drop index",
-	reflect.TypeOf(&dropSequenceNode{}):         "drop sequence",
-	reflect.TypeOf(&dropTableNode{}):            "drop table",
-	reflect.TypeOf(&DropUserNode{}):             "drop user/role",
-	reflect.TypeOf(&dropViewNode{}):             "drop view",
-	reflect.TypeOf(&errorIfRowsNode{}):          "errorIfRows",
-	reflect.TypeOf(&explainDistSQLNode{}):       "explain distsql",
-	reflect.TypeOf(&explainPlanNode{}):          "explain plan",
-	reflect.TypeOf(&filterNode{}):               "filter",
-	reflect.TypeOf(&groupNode{}):                "group",
-	reflect.TypeOf(&hookFnNode{}):               "plugin",
-	reflect.TypeOf(&indexJoinNode{}):            "index-join",
-	reflect.TypeOf(&insertNode{}):               "insert",
-	reflect.TypeOf(&joinNode{}):                 "join",
-	reflect.TypeOf(&limitNode{}):                "limit",
-	reflect.TypeOf(&lookupJoinNode{}):           "lookup-join",
-	reflect.TypeOf(&max1RowNode{}):              "max1row",
-	reflect.TypeOf(&ordinalityNode{}):           "ordinality",
-	reflect.TypeOf(&projectSetNode{}):           "project set",
-	reflect.TypeOf(&relocateNode{}):             "relocate",
-	reflect.TypeOf(&renameColumnNode{}):         "rename column",
-	reflect.TypeOf(&renameDatabaseNode{}):       "rename database",
-	reflect.TypeOf(&renameIndexNode{}):          "rename index",
-	reflect.TypeOf(&renameTableNode{}):          "rename table",
-	reflect.TypeOf(&renderNode{}):               "render",
-	reflect.TypeOf(&rowCountNode{}):             "count",
-	reflect.TypeOf(&rowSourceToPlanNode{}):      "row source to plan node",
-	reflect.TypeOf(&saveTableNode{}):            "save table",
-	reflect.TypeOf(&scanBufferNode{}):           "scan buffer node",
-	reflect.TypeOf(&scanNode{}):                 "scan",
-	reflect.TypeOf(&scatterNode{}):              "scatter",
-	reflect.TypeOf(&scrubNode{}):                "scrub",
-	reflect.TypeOf(&sequenceSelectNode{}):       "sequence select",
-	reflect.TypeOf(&serializeNode{}):            "run",
-	reflect.TypeOf(&setClusterSettingNode{}):    "set cluster setting",
-	reflect.TypeOf(&setVarNode{}):               "set",
-	reflect.TypeOf(&setZoneConfigNode{}):        "configure zone",
-	reflect.TypeOf(&showFingerprintsNode{}):     "showFingerprints",
-	reflect.TypeOf(&showTraceNode{}):            "show trace for",
-	reflect.TypeOf(&showTraceReplicaNode{}):     "replica trace",
-	reflect.TypeOf(&sortNode{}):                 "sort",
-	reflect.TypeOf(&splitNode{}):                "split",
-	reflect.TypeOf(&unsplitNode{}):              "unsplit",
-	reflect.TypeOf(&spoolNode{}):                "spool",
-	reflect.TypeOf(&truncateNode{}):             "truncate",
-	reflect.TypeOf(&unaryNode{}):                "emptyrow",
-	reflect.TypeOf(&unionNode{}):                "union",
-	reflect.TypeOf(&updateNode{}):               "update",
-	reflect.TypeOf(&upsertNode{}):               "upsert",
-	reflect.TypeOf(&valuesNode{}):               "values",
-	reflect.TypeOf(&virtualTableNode{}):         "virtual table values",
-	reflect.TypeOf(&windowNode{}):               "window",
-	reflect.TypeOf(&zeroNode{}):                 "norows",
-	reflect.TypeOf(&zigzagJoinNode{}):           "zigzag-join",
+	reflect.TypeOf(&alterDomainNode{}):       "alter domain",
+	reflect.TypeOf(&alterIndexNode{}):        "alter index",
+	reflect.TypeOf(&alterRoleNode{}):         "alter user/role",
+	reflect.TypeOf(&alterSequenceNode{}):     "alter sequence",
+	reflect.TypeOf(&alterTableNode{}):        "alter table",
+	reflect.TypeOf(&applyJoinNode{}):         "apply-join",
+	reflect.TypeOf(&bufferNode{}):            "buffer node",
+	reflect.TypeOf(&commentOnColumnNode{}):   "comment on column",
+	reflect.TypeOf(&commentOnDatabaseNode{}): "comment on database",
+	reflect.TypeOf(&commentOnTableNode{}):    "comment on table",
+	reflect.TypeOf(&cancelQueriesNode{}):     "cancel queries",
+	reflect.TypeOf(&cancelSessionsNode{}):    "cancel sessions",
+	reflect.TypeOf(&controlJobsNode{}):       "control jobs",
+	reflect.TypeOf(&controlSchedulesNode{}):  "control schedules",
+	reflect.TypeOf(&createDatabaseNode{}):    "create database",
+	reflect.TypeOf(&createIndexNode{}):       "create index",
+	reflect.TypeOf(&createDomainNode{}):      "create domain",
+	reflect.TypeOf(&createSequenceNode{}):    "create sequence",
+	reflect.TypeOf(&createStatsNode{}):       "create statistics",
+	reflect.TypeOf(&createTableNode{}):       "create table",
+	reflect.TypeOf(&createTypeNode{}):        "create type",
+	reflect.TypeOf(&CreateUserNode{}):        "create user/role",
+	reflect.TypeOf(&createViewNode{}):        "create view",
+	reflect.TypeOf(&delayedNode{}):           "virtual table",
+	reflect.TypeOf(&deleteNode{}):            "delete",
+	reflect.TypeOf(&deleteRangeNode{}):       "delete range",
+	reflect.TypeOf(&distinctNode{}):          "distinct",
+	reflect.TypeOf(&dropDatabaseNode{}):      "drop database",
+	reflect.TypeOf(&dropIndexNode{}):         "drop index",
+	reflect.TypeOf(&dropDomainNode{}):        "drop domain",
+	reflect.TypeOf(&dropSequenceNode{}):      "drop sequence",
+	reflect.TypeOf(&dropTableNode{}):         "drop table",
+	reflect.TypeOf(&dropTypeNode{}):          "drop type",
+	reflect.TypeOf(&DropUserNode{}):          "drop user/role",
+	reflect.TypeOf(&dropViewNode{}):          "drop view",
+	reflect.TypeOf(&errorIfRowsNode{}):       "errorIfRows",
+	reflect.TypeOf(&explainDistSQLNode{}):    "explain distsql",
+	reflect.TypeOf(&explainPlanNode{}):       "explain plan",
+	reflect.TypeOf(&filterNode{}):            "filter",
+	reflect.TypeOf(&groupNode{}):             "group",
+	reflect.TypeOf(&hookFnNode{}):            "plugin",
+	reflect.TypeOf(&indexJoinNode{}):         "index-join",
+	reflect.TypeOf(&insertNode{}):            "insert",
+	reflect.TypeOf(&joinNode{}):              "join",
+	reflect.TypeOf(&limitNode{}):             "limit",
+	reflect.TypeOf(&lookupJoinNode{}):        "lookup-join",
+	reflect.TypeOf(&max1RowNode{}):           "max1row",
+	reflect.TypeOf(&ordinalityNode{}):        "ordinality",
+	reflect.TypeOf(&projectSetNode{}):        "project set",
+	reflect.TypeOf(&relocateNode{}):          "relocate",
+	reflect.TypeOf(&renameColumnNode{}):      "rename column",
+	reflect.TypeOf(&renameDatabaseNode{}):    "rename database",
+	reflect.TypeOf(&renameIndexNode{}):       "rename index",
+	reflect.TypeOf(&renameTableNode{}):       "rename table",
+	reflect.TypeOf(&renderNode{}):            "render",
+	reflect.TypeOf(&rowCountNode{}):          "count",
+	reflect.TypeOf(&rowSourceToPlanNode{}):   "row source to plan node",
+	reflect.TypeOf(&saveTableNode{}):         "save table",
+	reflect.TypeOf(&scanBufferNode{}):        "scan buffer node",
+	reflect.TypeOf(&scanNode{}):              "scan",
+	reflect.TypeOf(&scatterNode{}):           "scatter",
+	reflect.TypeOf(&scrubNode{}):             "scrub",
+	reflect.TypeOf(&sequenceSelectNode{}):    "sequence select",
+	reflect.TypeOf(&serializeNode{}):         "run",
+	reflect.TypeOf(&setClusterSettingNode{}): "set cluster setting",
+	reflect.TypeOf(&setVarNode{}):            "set",
+	reflect.TypeOf(&setZoneConfigNode{}):     "configure zone",
+	reflect.TypeOf(&showFingerprintsNode{}):  "showFingerprints",
+	reflect.TypeOf(&showTraceNode{}):         "show trace for",
+	reflect.TypeOf(&showTraceReplicaNode{}):  "replica trace",
+	reflect.TypeOf(&sortNode{}):              "sort",
+	reflect.TypeOf(&splitNode{}):             "split",
+	reflect.TypeOf(&unsplitNode{}):           "unsplit",
+	reflect.TypeOf(&spoolNode{}):             "spool",
+	reflect.TypeOf(&truncateNode{}):          "truncate",
+	reflect.TypeOf(&unaryNode{}):             "emptyrow",
+	reflect.TypeOf(&unionNode{}):             "union",
+	reflect.TypeOf(&updateNode{}):            "update",
+	reflect.TypeOf(&upsertNode{}):            "upsert",
+	reflect.TypeOf(&valuesNode{}):            "values",
+	reflect.TypeOf(&virtualTableNode{}):      "virtual table values",
+	reflect.TypeOf(&windowNode{}):            "window",
+	reflect.TypeOf(&zeroNode{}):              "norows",
+	reflect.TypeOf(&zigzagJoinNode{}):        "zigzag-join",
 }
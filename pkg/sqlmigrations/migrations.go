@@ -208,6 +208,27 @@ var backwardCompatibleMigrations = []migrationDescriptor{
 		name:   "propagate the ts purge interval to the new setting names",
 		workFn: retireOldTsPurgeIntervalSettings,
 	},
+	{
+		// Introduced in v19.2.
+		name:                "create system.role_options table",
+		workFn:              createRoleOptionsTable,
+		includedInBootstrap: true,
+		newDescriptorIDs:    staticIDs(keys.RoleOptionsTableID),
+	},
+	{
+		// Introduced in v19.2.
+		name:                "create system.protected_ts_records table",
+		workFn:              createProtectedTimestampsRecordsTable,
+		includedInBootstrap: true,
+		newDescriptorIDs:    staticIDs(keys.ProtectedTimestampsRecordsTableID),
+	},
+	{
+		// Introduced in v19.2.
+		name:                "create system.scheduled_jobs table",
+		workFn:              createScheduledJobsTable,
+		includedInBootstrap: true,
+		newDescriptorIDs:    staticIDs(keys.ScheduledJobsTableID),
+	},
 }
 
 func staticIDs(ids ...sqlbase.ID) func(ctx context.Context, db db) ([]sqlbase.ID, error) {
@@ -552,6 +573,18 @@ func createCommentTable(ctx context.Context, r runner) error {
 	return createSystemTable(ctx, r, sqlbase.CommentsTable)
 }
 
+func createRoleOptionsTable(ctx context.Context, r runner) error {
+	return createSystemTable(ctx, r, sqlbase.RoleOptionsTable)
+}
+
+func createProtectedTimestampsRecordsTable(ctx context.Context, r runner) error {
+	return createSystemTable(ctx, r, sqlbase.ProtectedTimestampsRecordsTable)
+}
+
+func createScheduledJobsTable(ctx context.Context, r runner) error {
+	return createSystemTable(ctx, r, sqlbase.ScheduledJobsTable)
+}
+
 var reportingOptOut = envutil.EnvOrDefaultBool("COCKROACH_SKIP_ENABLING_DIAGNOSTIC_REPORTING", false)
 
 func runStmtAsRootWithRetry(
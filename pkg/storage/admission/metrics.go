@@ -0,0 +1,83 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package admission
+
+import "github.com/cockroachdb/cockroach/pkg/util/metric"
+
+// Metrics contains the metrics for a WorkQueue.
+type Metrics struct {
+	Overloaded        *metric.Gauge
+	Waiting           *metric.Gauge
+	Admitted          *metric.Counter
+	Queued            *metric.Counter
+	Canceled          *metric.Counter
+	WaitDurationNanos *metric.Counter
+}
+
+func makeMetrics() *Metrics {
+	return &Metrics{
+		Overloaded: metric.NewGauge(
+			metric.Metadata{
+				Name:        "admission.overloaded",
+				Help:        "Whether requests to the store are being throttled because its storage engine is overloaded",
+				Measurement: "Overloaded",
+				Unit:        metric.Unit_COUNT,
+			},
+		),
+
+		Waiting: metric.NewGauge(
+			metric.Metadata{
+				Name:        "admission.waiting",
+				Help:        "Number of requests waiting to be admitted",
+				Measurement: "Requests",
+				Unit:        metric.Unit_COUNT,
+			},
+		),
+
+		Admitted: metric.NewCounter(
+			metric.Metadata{
+				Name:        "admission.admitted",
+				Help:        "Number of requests admitted",
+				Measurement: "Requests",
+				Unit:        metric.Unit_COUNT,
+			},
+		),
+
+		Queued: metric.NewCounter(
+			metric.Metadata{
+				Name:        "admission.queued",
+				Help:        "Number of requests which had to wait to be admitted",
+				Measurement: "Requests",
+				Unit:        metric.Unit_COUNT,
+			},
+		),
+
+		Canceled: metric.NewCounter(
+			metric.Metadata{
+				Name:        "admission.canceled",
+				Help:        "Number of requests canceled while waiting to be admitted",
+				Measurement: "Requests",
+				Unit:        metric.Unit_COUNT,
+			},
+		),
+
+		WaitDurationNanos: metric.NewCounter(
+			metric.Metadata{
+				Name:        "admission.wait_duration",
+				Help:        "Cumulative time spent by requests waiting to be admitted",
+				Measurement: "Wait Time",
+				Unit:        metric.Unit_NANOSECONDS,
+			},
+		),
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+// Package admission implements admission control for the KV work submitted to
+// a store. When the store's storage engine is healthy, work is admitted
+// immediately. When it is overloaded, work is admitted at a limited rate and
+// in priority order, so that foreground traffic is favored over background
+// work such as bulk ingestion and garbage collection while the engine catches
+// up on compactions.
+package admission
+
+import (
+	"container/heap"
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+// Enabled controls whether work is subject to admission control.
+var Enabled = settings.RegisterBoolSetting(
+	"kv.admission.enabled",
+	"whether requests to a store are throttled while its storage engine is overloaded",
+	true,
+)
+
+// L0FileCountThreshold is the number of files in L0 of the storage engine at
+// or above which the engine is considered overloaded.
+var L0FileCountThreshold = settings.RegisterPositiveIntSetting(
+	"kv.admission.l0_file_count_threshold",
+	"number of L0 files at or above which requests to a store are throttled",
+	40,
+)
+
+// PendingCompactionThreshold is the estimated number of bytes pending
+// compaction in the storage engine at or above which the engine is considered
+// overloaded.
+var PendingCompactionThreshold = settings.RegisterByteSizeSetting(
+	"kv.admission.pending_compaction_threshold",
+	"pending compaction estimate at or above which requests to a store are throttled",
+	128<<30,
+)
+
+// OverloadRequestRate is the rate at which work is admitted while the storage
+// engine is overloaded.
+var OverloadRequestRate = settings.RegisterPositiveIntSetting(
+	"kv.admission.overload_request_rate",
+	"the number of requests per second admitted to a store while its storage engine is overloaded",
+	1000,
+)
+
+// WorkPriority is the priority of a unit of work. While the storage engine is
+// overloaded, waiting work is admitted in order of decreasing priority and, for
+// equal priorities, in order of arrival.
+type WorkPriority int8
+
+const (
+	// LowPri is the priority of background work, such as bulk ingestion and
+	// garbage collection, which can tolerate being delayed.
+	LowPri WorkPriority = iota
+	// NormalPri is the priority of foreground user work.
+	NormalPri
+	// HighPri is the priority of work which other work is likely to be waiting
+	// on, such as transaction heartbeats and pushes.
+	HighPri
+)
+
+func (p WorkPriority) String() string {
+	switch p {
+	case LowPri:
+		return "low"
+	case NormalPri:
+		return "normal"
+	case HighPri:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// EngineHealth is the subset of the storage engine's statistics used to
+// determine whether it is overloaded.
+type EngineHealth struct {
+	L0FileCount                    int64
+	PendingCompactionBytesEstimate int64
+}
+
+// maxRefillInterval bounds the number of tokens that can be handed out by a
+// single refresh, so that a late refresh doesn't admit a burst of work.
+const maxRefillInterval = time.Second
+
+// waiter is a unit of work waiting to be admitted.
+type waiter struct {
+	pri     WorkPriority
+	seq     uint64
+	ch      chan struct{}
+	granted bool
+	index   int
+}
+
+// waiterHeap orders waiters by decreasing priority and then by arrival.
+type waiterHeap []*waiter
+
+var _ heap.Interface = (*waiterHeap)(nil)
+
+func (h waiterHeap) Len() int { return len(h) }
+
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].pri != h[j].pri {
+		return h[i].pri > h[j].pri
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *waiterHeap) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+
+func (h *waiterHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*h = old[:n-1]
+	return w
+}
+
+// WorkQueue admits the work submitted to a single store. The queue does not
+// bound the amount of concurrent work: admitted work never has to be returned
+// to the queue, so work that blocks after admission (for instance on a lock
+// held by a transaction whose next request is queued) can't deadlock the
+// queue. Instead, while the storage engine is overloaded, work is admitted at
+// a rate of OverloadRequestRate, which lets the engine work off its backlog.
+//
+// The queue learns about the health of the storage engine through
+// UpdateHealth, which is expected to be called periodically.
+type WorkQueue struct {
+	settings *cluster.Settings
+	metrics  *Metrics
+
+	mu struct {
+		syncutil.Mutex
+		overloaded  bool
+		tokens      float64
+		lastRefresh time.Time
+		seq         uint64
+		waiting     waiterHeap
+	}
+}
+
+// NewWorkQueue creates a WorkQueue.
+func NewWorkQueue(st *cluster.Settings) *WorkQueue {
+	return &WorkQueue{
+		settings: st,
+		metrics:  makeMetrics(),
+	}
+}
+
+// Metrics returns the queue's metrics struct.
+func (q *WorkQueue) Metrics() *Metrics {
+	return q.metrics
+}
+
+// Admit blocks until the work with the given priority may proceed, or until
+// the context is canceled.
+func (q *WorkQueue) Admit(ctx context.Context, pri WorkPriority) error {
+	if !Enabled.Get(&q.settings.SV) {
+		return nil
+	}
+	q.mu.Lock()
+	if len(q.mu.waiting) == 0 {
+		if !q.mu.overloaded {
+			q.mu.Unlock()
+			q.metrics.Admitted.Inc(1)
+			return nil
+		}
+		if q.mu.tokens >= 1 {
+			q.mu.tokens--
+			q.mu.Unlock()
+			q.metrics.Admitted.Inc(1)
+			return nil
+		}
+	}
+	q.mu.seq++
+	w := &waiter{pri: pri, seq: q.mu.seq, ch: make(chan struct{})}
+	heap.Push(&q.mu.waiting, w)
+	q.metrics.Waiting.Update(int64(len(q.mu.waiting)))
+	q.mu.Unlock()
+
+	ctx, span := tracing.ChildSpan(ctx, "admission-queue")
+	defer tracing.FinishSpan(span)
+	start := timeutil.Now()
+	defer func() {
+		q.metrics.WaitDurationNanos.Inc(timeutil.Since(start).Nanoseconds())
+	}()
+	q.metrics.Queued.Inc(1)
+
+	select {
+	case <-w.ch:
+		q.metrics.Admitted.Inc(1)
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if w.granted {
+			// The work was admitted concurrently with the cancellation. Let
+			// it proceed; the caller will notice the cancellation shortly.
+			q.metrics.Admitted.Inc(1)
+			return nil
+		}
+		heap.Remove(&q.mu.waiting, w.index)
+		q.metrics.Waiting.Update(int64(len(q.mu.waiting)))
+		q.metrics.Canceled.Inc(1)
+		return ctx.Err()
+	}
+}
+
+// UpdateHealth informs the queue of the current health of the storage
+// engine. If the engine is overloaded, the queue is granted tokens to admit
+// work proportional to the time elapsed since the last update. If it isn't,
+// all waiting work is admitted.
+func (q *WorkQueue) UpdateHealth(now time.Time, health EngineHealth) {
+	sv := &q.settings.SV
+	overloaded := Enabled.Get(sv) &&
+		(health.L0FileCount >= L0FileCountThreshold.Get(sv) ||
+			health.PendingCompactionBytesEstimate >= PendingCompactionThreshold.Get(sv))
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	elapsed := now.Sub(q.mu.lastRefresh)
+	if elapsed > maxRefillInterval {
+		elapsed = maxRefillInterval
+	}
+	q.mu.lastRefresh = now
+	q.mu.overloaded = overloaded
+	if overloaded {
+		q.metrics.Overloaded.Update(1)
+		// Tokens don't accumulate across refreshes, which would allow a burst of
+		// work to be admitted at once.
+		q.mu.tokens = float64(OverloadRequestRate.Get(sv)) * elapsed.Seconds()
+	} else {
+		q.metrics.Overloaded.Update(0)
+	}
+	for len(q.mu.waiting) > 0 {
+		if overloaded {
+			if q.mu.tokens < 1 {
+				break
+			}
+			q.mu.tokens--
+		}
+		w := heap.Pop(&q.mu.waiting).(*waiter)
+		w.granted = true
+		close(w.ch)
+	}
+	q.metrics.Waiting.Update(int64(len(q.mu.waiting)))
+}
@@ -0,0 +1,140 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+var overloaded = EngineHealth{L0FileCount: 1000}
+
+// admitAsync submits work to the queue and returns a channel on which the
+// result of Admit is delivered.
+func admitAsync(ctx context.Context, q *WorkQueue, pri WorkPriority) <-chan error {
+	ch := make(chan error, 1)
+	go func() { ch <- q.Admit(ctx, pri) }()
+	return ch
+}
+
+// waitForWaiting blocks until the queue has n waiting units of work.
+func waitForWaiting(t *testing.T, q *WorkQueue, n int) {
+	t.Helper()
+	deadline := timeutil.Now().Add(10 * time.Second)
+	for timeutil.Now().Before(deadline) {
+		q.mu.Lock()
+		l := len(q.mu.waiting)
+		q.mu.Unlock()
+		if l == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d waiting", n)
+}
+
+func TestWorkQueueHealthy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	q := NewWorkQueue(cluster.MakeTestingClusterSettings())
+	q.UpdateHealth(timeutil.Now(), EngineHealth{L0FileCount: 1})
+	for _, pri := range []WorkPriority{LowPri, NormalPri, HighPri} {
+		if err := q.Admit(ctx, pri); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if a := q.metrics.Admitted.Count(); a != 3 {
+		t.Fatalf("expected 3 admitted, got %d", a)
+	}
+	if c := q.metrics.Queued.Count(); c != 0 {
+		t.Fatalf("expected nothing queued, got %d", c)
+	}
+}
+
+func TestWorkQueueOverloadedPriorityOrder(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	OverloadRequestRate.Override(&st.SV, 1)
+	q := NewWorkQueue(st)
+	now := timeutil.Now()
+	// The first update hands out a full second's worth of tokens. Use it up,
+	// then refresh without any time passing so that no tokens remain.
+	q.UpdateHealth(now, overloaded)
+	if err := q.Admit(ctx, NormalPri); err != nil {
+		t.Fatal(err)
+	}
+	q.UpdateHealth(now, overloaded)
+
+	low := admitAsync(ctx, q, LowPri)
+	waitForWaiting(t, q, 1)
+	normal := admitAsync(ctx, q, NormalPri)
+	waitForWaiting(t, q, 2)
+	high := admitAsync(ctx, q, HighPri)
+	waitForWaiting(t, q, 3)
+
+	for i, ch := range []<-chan error{high, normal, low} {
+		now = now.Add(time.Second)
+		q.UpdateHealth(now, overloaded)
+		if err := <-ch; err != nil {
+			t.Fatal(err)
+		}
+		waitForWaiting(t, q, 2-i)
+	}
+	if o := q.metrics.Overloaded.Value(); o != 1 {
+		t.Fatalf("expected overloaded, got %d", o)
+	}
+
+	// Once the engine recovers, all waiting work is admitted.
+	a, b := admitAsync(ctx, q, LowPri), admitAsync(ctx, q, NormalPri)
+	waitForWaiting(t, q, 2)
+	q.UpdateHealth(now, EngineHealth{})
+	for _, ch := range []<-chan error{a, b} {
+		if err := <-ch; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWorkQueueCancel(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	st := cluster.MakeTestingClusterSettings()
+	q := NewWorkQueue(st)
+	// Refresh twice without any time passing so that no tokens are available.
+	now := timeutil.Now()
+	q.UpdateHealth(now, EngineHealth{PendingCompactionBytesEstimate: 1 << 40})
+	q.UpdateHealth(now, EngineHealth{PendingCompactionBytesEstimate: 1 << 40})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := admitAsync(ctx, q, NormalPri)
+	waitForWaiting(t, q, 1)
+	cancel()
+	if err := <-ch; err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	waitForWaiting(t, q, 0)
+	if c := q.metrics.Canceled.Count(); c != 1 {
+		t.Fatalf("expected 1 canceled, got %d", c)
+	}
+
+	// Disabling admission control admits work immediately.
+	Enabled.Override(&st.SV, false)
+	if err := q.Admit(context.Background(), LowPri); err != nil {
+		t.Fatal(err)
+	}
+}
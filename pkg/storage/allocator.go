@@ -29,6 +29,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/pkg/errors"
 	"go.etcd.io/etcd/raft"
+	"go.etcd.io/etcd/raft/tracker"
 )
 
 const (
@@ -49,12 +50,16 @@ const (
 	minReplicaWeight = 0.001
 
 	// priorities for various repair operations.
-	addDeadReplacementPriority            float64 = 12000
-	addMissingReplicaPriority             float64 = 10000
-	addDecommissioningReplacementPriority float64 = 5000
-	removeDeadReplicaPriority             float64 = 1000
-	removeDecommissioningReplicaPriority  float64 = 200
-	removeExtraReplicaPriority            float64 = 100
+	finalizeAtomicReplicationChangePriority float64 = 12002
+	addDeadReplacementPriority              float64 = 12000
+	addMissingReplicaPriority               float64 = 10000
+	addDecommissioningReplacementPriority   float64 = 5000
+	removeDeadReplicaPriority               float64 = 1000
+	removeDecommissioningReplicaPriority    float64 = 200
+	removeExtraReplicaPriority              float64 = 100
+	addMissingNonVoterPriority              float64 = 600
+	removeDeadNonVoterPriority              float64 = 500
+	removeExtraNonVoterPriority             float64 = 50
 )
 
 // MinLeaseTransferStatsDuration configures the minimum amount of time a
@@ -101,15 +106,21 @@ const (
 	AllocatorRemoveDead
 	AllocatorRemoveDecommissioning
 	AllocatorConsiderRebalance
+	AllocatorAddNonVoter
+	AllocatorRemoveNonVoter
+	AllocatorFinalizeAtomicReplicationChange
 )
 
 var allocatorActionNames = map[AllocatorAction]string{
-	AllocatorNoop:                  "noop",
-	AllocatorRemove:                "remove",
-	AllocatorAdd:                   "add",
-	AllocatorRemoveDead:            "remove dead",
-	AllocatorRemoveDecommissioning: "remove decommissioning",
-	AllocatorConsiderRebalance:     "consider rebalance",
+	AllocatorNoop:                            "noop",
+	AllocatorRemove:                          "remove",
+	AllocatorAdd:                             "add",
+	AllocatorRemoveDead:                      "remove dead",
+	AllocatorRemoveDecommissioning:           "remove decommissioning",
+	AllocatorConsiderRebalance:               "consider rebalance",
+	AllocatorAddNonVoter:                     "add non-voter",
+	AllocatorRemoveNonVoter:                  "remove non-voter",
+	AllocatorFinalizeAtomicReplicationChange: "finalize conf change",
 }
 
 func (a AllocatorAction) String() string {
@@ -201,10 +212,12 @@ func makeAllocatorRand(source rand.Source) allocatorRand {
 // RangeInfo contains the information needed by the allocator to make
 // rebalancing decisions for a given range.
 type RangeInfo struct {
-	Desc             *roachpb.RangeDescriptor
-	LogicalBytes     int64
-	QueriesPerSecond float64
-	WritesPerSecond  float64
+	Desc                *roachpb.RangeDescriptor
+	LogicalBytes        int64
+	QueriesPerSecond    float64
+	WritesPerSecond     float64
+	WriteBytesPerSecond float64
+	CPUPerSecond        float64
 }
 
 func rangeInfoForRepl(repl *Replica, desc *roachpb.RangeDescriptor) RangeInfo {
@@ -218,6 +231,12 @@ func rangeInfoForRepl(repl *Replica, desc *roachpb.RangeDescriptor) RangeInfo {
 	if writesPerSecond, dur := repl.writeStats.avgQPS(); dur >= MinStatsDuration {
 		info.WritesPerSecond = writesPerSecond
 	}
+	if writeBytesPerSecond, dur := repl.writeBytesStats.avgQPS(); dur >= MinStatsDuration {
+		info.WriteBytesPerSecond = writeBytesPerSecond
+	}
+	if cpuPerSecond, dur := repl.cpuStats.avgQPS(); dur >= MinStatsDuration {
+		info.CPUPerSecond = cpuPerSecond
+	}
 	return info
 }
 
@@ -286,9 +305,35 @@ func GetNeededReplicas(zoneConfigReplicaCount int32, clusterNodes int) int {
 	return need
 }
 
+// GetNeededNonVoters calculates the number of non-voting replicas a range
+// should have given the number of voters it has, the number of non-voters
+// requested by its zone config and the number of nodes available for
+// up-replication. Since a node can hold at most one replica of a range,
+// non-voters only make use of the nodes left over by the voters.
+func GetNeededNonVoters(numVoters, zoneConfigNonVoterCount, clusterNodes int) int {
+	need := zoneConfigNonVoterCount
+	if clusterNodes-numVoters < need {
+		need = clusterNodes - numVoters
+	}
+	if need < 0 {
+		need = 0
+	}
+	return need
+}
+
 // ComputeAction determines the exact operation needed to repair the
 // supplied range, as governed by the supplied zone configuration. It
 // returns the required action that should be taken and a priority.
+//
+// Actions concerning the voting replicas, which determine the availability
+// of the range, always take precedence over actions concerning its
+// non-voting replicas. A missing voter is preferably made up for by
+// promoting a non-voter (see PromoteTarget). Raft does not allow turning a
+// voter into a learner, so a voter is demoted by removing it, after which the
+// range is found to be missing a non-voter.
+//
+// A range left in a joint configuration by an interrupted atomic replication
+// change must leave it before any other action can be taken.
 func (a *Allocator) ComputeAction(
 	ctx context.Context, zone *config.ZoneConfig, rangeInfo RangeInfo,
 ) (AllocatorAction, float64) {
@@ -296,13 +341,19 @@ func (a *Allocator) ComputeAction(
 		// Do nothing if storePool is nil for some unittests.
 		return AllocatorNoop, 0
 	}
+
+	if rangeInfo.Desc.Replicas().InAtomicReplicationChange() {
+		log.VEventf(ctx, 3, "AllocatorFinalizeAtomicReplicationChange - range is in a joint configuration")
+		return AllocatorFinalizeAtomicReplicationChange, finalizeAtomicReplicationChangePriority
+	}
 	// TODO(mrtracy): Handle non-homogeneous and mismatched attribute sets.
 
-	have := len(rangeInfo.Desc.Replicas().Unwrap())
+	voters := rangeInfo.Desc.Replicas().Voters()
+	have := len(voters)
 	decommissioningReplicas := a.storePool.decommissioningReplicas(
-		rangeInfo.Desc.RangeID, rangeInfo.Desc.Replicas().Unwrap())
+		rangeInfo.Desc.RangeID, voters)
 	clusterNodes := a.storePool.ClusterNodeCount()
-	need := GetNeededReplicas(*zone.NumReplicas, clusterNodes)
+	need := GetNeededReplicas(zone.GetNumVoters(), clusterNodes)
 	desiredQuorum := computeQuorum(need)
 	quorum := computeQuorum(have)
 
@@ -326,7 +377,7 @@ func (a *Allocator) ComputeAction(
 	}
 
 	liveReplicas, deadReplicas := a.storePool.liveAndDeadReplicas(
-		rangeInfo.Desc.RangeID, rangeInfo.Desc.Replicas().Unwrap())
+		rangeInfo.Desc.RangeID, voters)
 	if len(liveReplicas) < quorum {
 		// Do not take any removal action if we do not have a quorum of live
 		// replicas.
@@ -380,10 +431,52 @@ func (a *Allocator) ComputeAction(
 		return AllocatorRemove, priority
 	}
 
+	if action, priority := a.computeNonVoterAction(ctx, zone, rangeInfo, clusterNodes); action != AllocatorNoop {
+		return action, priority
+	}
+
 	// Nothing needs to be done, but we may want to rebalance.
 	return AllocatorConsiderRebalance, 0
 }
 
+// computeNonVoterAction determines the operation needed to bring the
+// non-voting replicas of a range in line with its zone configuration. It is
+// only consulted once the voting replicas need no repair.
+func (a *Allocator) computeNonVoterAction(
+	ctx context.Context, zone *config.ZoneConfig, rangeInfo RangeInfo, clusterNodes int,
+) (AllocatorAction, float64) {
+	nonVoters := rangeInfo.Desc.Replicas().NonVoters()
+	have := len(nonVoters)
+	need := GetNeededNonVoters(
+		len(rangeInfo.Desc.Replicas().Voters()), int(*zone.NumReplicas-zone.GetNumVoters()), clusterNodes)
+
+	if have < need {
+		priority := addMissingNonVoterPriority
+		log.VEventf(ctx, 3, "AllocatorAddNonVoter - missing non-voter need=%d, have=%d, priority=%.2f",
+			need, have, priority)
+		return AllocatorAddNonVoter, priority
+	}
+
+	// Non-voters do not contribute to the quorum, so there is no point in
+	// replacing a dead or decommissioning one before removing it.
+	_, deadNonVoters := a.storePool.liveAndDeadReplicas(rangeInfo.Desc.RangeID, nonVoters)
+	decommissioningNonVoters := a.storePool.decommissioningReplicas(rangeInfo.Desc.RangeID, nonVoters)
+	if len(deadNonVoters) > 0 || len(decommissioningNonVoters) > 0 {
+		priority := removeDeadNonVoterPriority
+		log.VEventf(ctx, 3, "AllocatorRemoveNonVoter - dead=%d, decommissioning=%d, priority=%.2f",
+			len(deadNonVoters), len(decommissioningNonVoters), priority)
+		return AllocatorRemoveNonVoter, priority
+	}
+
+	if have > need {
+		priority := removeExtraNonVoterPriority
+		log.VEventf(ctx, 3, "AllocatorRemoveNonVoter - need=%d, have=%d, priority=%.2f", need, have, priority)
+		return AllocatorRemoveNonVoter, priority
+	}
+
+	return AllocatorNoop, 0
+}
+
 type decisionDetails struct {
 	Target   string
 	Existing string `json:",omitempty"`
@@ -424,6 +517,41 @@ func (a *Allocator) AllocateTarget(
 	}
 }
 
+// PromoteTarget returns the non-voting replica among the candidates that is
+// best suited to become a voter. The candidates are scored as if a new voter
+// were being added to a range with the given existing voters. Promoting a
+// non-voter is cheaper than adding a new voter since it already holds a copy
+// of the range's data. The returned boolean is false if none of the
+// candidates is a suitable voter.
+func (a *Allocator) PromoteTarget(
+	ctx context.Context,
+	zone *config.ZoneConfig,
+	existingVoters []roachpb.ReplicaDescriptor,
+	candidates []roachpb.ReplicaDescriptor,
+	rangeInfo RangeInfo,
+) (roachpb.ReplicaDescriptor, string, bool) {
+	if len(candidates) == 0 {
+		return roachpb.ReplicaDescriptor{}, "", false
+	}
+	candidateStoreIDs := make(roachpb.StoreIDSlice, len(candidates))
+	for i, c := range candidates {
+		candidateStoreIDs[i] = c.StoreID
+	}
+	sl, _, _ := a.storePool.getStoreListFromIDs(candidateStoreIDs, rangeInfo.Desc.RangeID, storeFilterNone)
+
+	target, details := a.allocateTargetFromList(
+		ctx, sl, zone, existingVoters, rangeInfo, a.scorerOptions())
+	if target == nil {
+		return roachpb.ReplicaDescriptor{}, "", false
+	}
+	for _, c := range candidates {
+		if c.StoreID == target.StoreID {
+			return c, details, true
+		}
+	}
+	return roachpb.ReplicaDescriptor{}, "", false
+}
+
 func (a *Allocator) allocateTargetFromList(
 	ctx context.Context,
 	sl StoreList,
@@ -531,8 +659,10 @@ func (a Allocator) RemoveTarget(
 // information about the range being considered for rebalancing.
 //
 // The existing replicas modulo any store with dead replicas are candidates for
-// rebalancing. Note that rebalancing is accomplished by first adding a new
-// replica to the range, then removing the most undesirable replica.
+// rebalancing. Note that rebalancing is accomplished by adding a new replica to
+// the range and removing the most undesirable replica, which is returned along
+// with the target. The two changes are carried out either one after the other
+// or, when atomic replication changes are enabled, as a single swap.
 //
 // Simply ignoring a rebalance opportunity in the event that the target chosen
 // by AllocateTarget() doesn't fit balancing criteria is perfectly fine, as
@@ -545,7 +675,34 @@ func (a Allocator) RebalanceTarget(
 	raftStatus *raft.Status,
 	rangeInfo RangeInfo,
 	filter storeFilter,
-) (*roachpb.StoreDescriptor, string) {
+) (*roachpb.StoreDescriptor, roachpb.ReplicaDescriptor, string) {
+	return a.rebalanceTarget(ctx, zone, raftStatus, rangeInfo, filter, roachpb.ReplicaType_VOTER)
+}
+
+// RebalanceNonVoterTarget is like RebalanceTarget, but for the non-voting
+// replicas of the range: the replica that would be removed after adding the
+// returned target is chosen among the non-voters.
+func (a Allocator) RebalanceNonVoterTarget(
+	ctx context.Context,
+	zone *config.ZoneConfig,
+	raftStatus *raft.Status,
+	rangeInfo RangeInfo,
+	filter storeFilter,
+) (*roachpb.StoreDescriptor, roachpb.ReplicaDescriptor, string) {
+	return a.rebalanceTarget(ctx, zone, raftStatus, rangeInfo, filter, roachpb.ReplicaType_NON_VOTER)
+}
+
+func (a Allocator) rebalanceTarget(
+	ctx context.Context,
+	zone *config.ZoneConfig,
+	raftStatus *raft.Status,
+	rangeInfo RangeInfo,
+	filter storeFilter,
+	replicaType roachpb.ReplicaType,
+) (*roachpb.StoreDescriptor, roachpb.ReplicaDescriptor, string) {
+	if replicaType == roachpb.ReplicaType_NON_VOTER && len(rangeInfo.Desc.Replicas().NonVoters()) == 0 {
+		return nil, roachpb.ReplicaDescriptor{}, ""
+	}
 	sl, _, _ := a.storePool.getStoreList(rangeInfo.Desc.RangeID, filter)
 
 	// We're going to add another replica to the range which will change the
@@ -558,21 +715,24 @@ func (a Allocator) RebalanceTarget(
 	// NB: The len(replicas) > 1 check allows rebalancing of ranges with only a
 	// single replica. This is a corner case which could happen in practice and
 	// also affects tests.
-	if len(rangeInfo.Desc.Replicas().Unwrap()) > 1 {
+	//
+	// Adding a non-voter does not change the quorum size.
+	voters := rangeInfo.Desc.Replicas().Voters()
+	if replicaType == roachpb.ReplicaType_VOTER && len(voters) > 1 {
 		var numLiveReplicas int
 		for _, s := range sl.stores {
-			for _, repl := range rangeInfo.Desc.Replicas().Unwrap() {
+			for _, repl := range voters {
 				if s.StoreID == repl.StoreID {
 					numLiveReplicas++
 					break
 				}
 			}
 		}
-		newQuorum := computeQuorum(len(rangeInfo.Desc.Replicas().Unwrap()) + 1)
+		newQuorum := computeQuorum(len(voters) + 1)
 		if numLiveReplicas < newQuorum {
 			// Don't rebalance as we won't be able to make quorum after the rebalance
 			// until the new replica has been caught up.
-			return nil, ""
+			return nil, roachpb.ReplicaDescriptor{}, ""
 		}
 	}
 
@@ -590,7 +750,7 @@ func (a Allocator) RebalanceTarget(
 	)
 
 	if len(results) == 0 {
-		return nil, ""
+		return nil, roachpb.ReplicaDescriptor{}, ""
 	}
 
 	// Deep-copy the Replicas slice since we'll mutate it in the loop below.
@@ -601,11 +761,12 @@ func (a Allocator) RebalanceTarget(
 	// pretty sure we won't want to remove immediately after adding it.
 	// If we would, we don't want to actually rebalance to that target.
 	var target *candidate
+	var removeReplica roachpb.ReplicaDescriptor
 	var existingCandidates candidateList
 	for {
 		target, existingCandidates = bestRebalanceTarget(a.randGen, results)
 		if target == nil {
-			return nil, ""
+			return nil, roachpb.ReplicaDescriptor{}, ""
 		}
 
 		// Add a fake new replica to our copy of the range descriptor so that we can
@@ -615,6 +776,7 @@ func (a Allocator) RebalanceTarget(
 			NodeID:    target.store.Node.NodeID,
 			StoreID:   target.store.StoreID,
 			ReplicaID: rangeInfo.Desc.NextReplicaID,
+			Type:      replicaType,
 		}
 		// Intentionally don't use RangeDescriptor.AddReplica so we can force the
 		// deep copy.
@@ -622,12 +784,19 @@ func (a Allocator) RebalanceTarget(
 		newReplicas.AddReplica(newReplica)
 		rangeInfo.Desc.SetReplicas(newReplicas)
 
+		// Only replicas of the type being rebalanced are candidates for
+		// removal.
+		var replicaCandidates []roachpb.ReplicaDescriptor
+		if replicaType == roachpb.ReplicaType_NON_VOTER {
+			replicaCandidates = newReplicas.NonVoters()
+		} else {
+			replicaCandidates = newReplicas.Voters()
+		}
 		// If we can, filter replicas as we would if we were actually removing one.
 		// If we can't (e.g. because we're the leaseholder but not the raft leader),
 		// it's better to simulate the removal with the info that we do have than to
 		// assume that the rebalance is ok (#20241).
-		replicaCandidates := newReplicas.Unwrap()
-		if raftStatus != nil && raftStatus.Progress != nil {
+		if replicaType == roachpb.ReplicaType_VOTER && raftStatus != nil && raftStatus.Progress != nil {
 			replicaCandidates = simulateFilterUnremovableReplicas(
 				raftStatus, replicaCandidates, newReplica.ReplicaID)
 		}
@@ -635,10 +804,12 @@ func (a Allocator) RebalanceTarget(
 			// No existing replicas are suitable to remove.
 			log.VEventf(ctx, 2, "not rebalancing to s%d because there are no existing "+
 				"replicas that can be removed", target.store.StoreID)
-			return nil, ""
+			return nil, roachpb.ReplicaDescriptor{}, ""
 		}
 
-		removeReplica, removeDetails, err := a.simulateRemoveTarget(
+		var removeDetails string
+		var err error
+		removeReplica, removeDetails, err = a.simulateRemoveTarget(
 			ctx,
 			target.store.StoreID,
 			zone,
@@ -646,7 +817,7 @@ func (a Allocator) RebalanceTarget(
 			rangeInfo)
 		if err != nil {
 			log.Warningf(ctx, "simulating RemoveTarget failed: %s", err)
-			return nil, ""
+			return nil, roachpb.ReplicaDescriptor{}, ""
 		}
 		if target.store.StoreID != removeReplica.StoreID {
 			break
@@ -668,7 +839,7 @@ func (a Allocator) RebalanceTarget(
 		log.Warningf(ctx, "failed to marshal details for choosing rebalance target: %s", err)
 	}
 
-	return &target.store, string(detailsBytes)
+	return &target.store, removeReplica, string(detailsBytes)
 }
 
 func (a *Allocator) scorerOptions() scorerOptions {
@@ -1198,7 +1369,7 @@ func replicaIsBehind(raftStatus *raft.Status, replicaID roachpb.ReplicaID) bool
 	// behind the actual commit index of the range.
 	if progress, ok := raftStatus.Progress[uint64(replicaID)]; ok {
 		if uint64(replicaID) == raftStatus.Lead ||
-			(progress.State == raft.ProgressStateReplicate &&
+			(progress.State == tracker.StateReplicate &&
 				progress.Match >= raftStatus.Commit) {
 			return false
 		}
@@ -1216,8 +1387,8 @@ func simulateFilterUnremovableReplicas(
 	brandNewReplicaID roachpb.ReplicaID,
 ) []roachpb.ReplicaDescriptor {
 	status := *raftStatus
-	status.Progress[uint64(brandNewReplicaID)] = raft.Progress{
-		State: raft.ProgressStateReplicate,
+	status.Progress[uint64(brandNewReplicaID)] = tracker.Progress{
+		State: tracker.StateReplicate,
 		Match: status.Commit,
 	}
 	return filterUnremovableReplicas(&status, replicas, brandNewReplicaID)
@@ -64,7 +64,10 @@ var rangeRebalanceThreshold = settings.RegisterNonNegativeFloatSetting(
 type scorerOptions struct {
 	deterministic           bool
 	rangeRebalanceThreshold float64
-	qpsRebalanceThreshold   float64 // only considered if non-zero
+	// loadRebalanceThresholds holds the fraction away from the mean that a
+	// store's load along each dimension may be before it is considered
+	// overfull or underfull. Each is only considered if non-zero.
+	loadRebalanceThresholds [numLoadDimensions]float64
 }
 
 type balanceDimensions struct {
@@ -402,18 +405,7 @@ func allocateCandidates(
 		}
 		diversityScore := diversityAllocateScore(s, existingNodeLocalities)
 		balanceScore := balanceScore(sl, s.Capacity, rangeInfo, options)
-		var convergesScore int
-		if options.qpsRebalanceThreshold > 0 {
-			if s.Capacity.QueriesPerSecond < underfullThreshold(sl.candidateQueriesPerSecond.mean, options.qpsRebalanceThreshold) {
-				convergesScore = 1
-			} else if s.Capacity.QueriesPerSecond < sl.candidateQueriesPerSecond.mean {
-				convergesScore = 0
-			} else if s.Capacity.QueriesPerSecond < overfullThreshold(sl.candidateQueriesPerSecond.mean, options.qpsRebalanceThreshold) {
-				convergesScore = -1
-			} else {
-				convergesScore = -2
-			}
-		}
+		convergesScore := loadConvergesScore(sl, s.Capacity, options)
 		candidates = append(candidates, candidate{
 			store:          s,
 			valid:          constraintsOK,
@@ -1255,6 +1247,57 @@ func underfullRangeThreshold(options scorerOptions, mean float64) float64 {
 	return underfullThreshold(mean, options.rangeRebalanceThreshold)
 }
 
+// storeLoad returns the store's load along the given dimension.
+func storeLoad(sc roachpb.StoreCapacity, dim loadDimension) float64 {
+	switch dim {
+	case qpsDimension:
+		return sc.QueriesPerSecond
+	case writeBytesDimension:
+		return sc.WriteBytesPerSecond
+	case cpuDimension:
+		return sc.CPUPerSecond
+	default:
+		panic(fmt.Sprintf("unknown load dimension %d", dim))
+	}
+}
+
+// loadConvergesScore scores how adding load to a store would affect the
+// balance of load across the candidate stores. Each dimension with a non-zero
+// threshold in options is scored individually, from 1 for a store that is
+// underfull to -2 for a store that is overfull, and the store's score is its
+// worst score across those dimensions. This keeps a store that is short on
+// QPS from being picked when its disks are already saturated by writes.
+// Dimensions that carry no load on any candidate store are ignored.
+func loadConvergesScore(sl StoreList, sc roachpb.StoreCapacity, options scorerOptions) int {
+	score := 1
+	considered := false
+	for i, threshold := range options.loadRebalanceThresholds {
+		dim := loadDimension(i)
+		load, mean := storeLoad(sc, dim), sl.candidateLoad(dim).mean
+		if threshold <= 0 || mean <= 0 {
+			continue
+		}
+		considered = true
+		var dimScore int
+		if load < underfullThreshold(mean, threshold) {
+			dimScore = 1
+		} else if load < mean {
+			dimScore = 0
+		} else if load < overfullThreshold(mean, threshold) {
+			dimScore = -1
+		} else {
+			dimScore = -2
+		}
+		if dimScore < score {
+			score = dimScore
+		}
+	}
+	if !considered {
+		return 0
+	}
+	return score
+}
+
 func overfullThreshold(mean float64, thresholdFraction float64) float64 {
 	return mean * (1 + thresholdFraction)
 }
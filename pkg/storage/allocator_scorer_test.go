@@ -1507,6 +1507,44 @@ func TestBalanceScore(t *testing.T) {
 	}
 }
 
+func TestLoadConvergesScore(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	storeList := StoreList{
+		candidateQueriesPerSecond:    stat{mean: 1000},
+		candidateWriteBytesPerSecond: stat{mean: 1 << 20},
+	}
+	var options scorerOptions
+	options.loadRebalanceThresholds[qpsDimension] = 0.25
+	options.loadRebalanceThresholds[writeBytesDimension] = 0.25
+	// CPU is enabled but idle across all stores, so it must be ignored.
+	options.loadRebalanceThresholds[cpuDimension] = 0.25
+
+	testCases := []struct {
+		qps, writeBytes float64
+		expected        int
+	}{
+		{500, 500 << 10, 1},
+		{900, 500 << 10, 0},
+		{1100, 500 << 10, -1},
+		{1500, 500 << 10, -2},
+		// A store that is underfull on QPS is still penalized for being
+		// overfull on bytes written.
+		{500, 2 << 20, -2},
+		{500, 1100 << 10, -1},
+	}
+	for i, tc := range testCases {
+		sc := roachpb.StoreCapacity{QueriesPerSecond: tc.qps, WriteBytesPerSecond: tc.writeBytes}
+		if a, e := loadConvergesScore(storeList, sc, options), tc.expected; a != e {
+			t.Errorf("%d: loadConvergesScore(%+v) got %d; want %d", i, sc, a, e)
+		}
+	}
+
+	if a := loadConvergesScore(storeList, roachpb.StoreCapacity{}, scorerOptions{}); a != 0 {
+		t.Errorf("expected a score of 0 with no load thresholds, got %d", a)
+	}
+}
+
 func TestRebalanceConvergesOnMean(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
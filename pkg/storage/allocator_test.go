@@ -46,6 +46,7 @@ import (
 	"github.com/olekukonko/tablewriter"
 	"github.com/pkg/errors"
 	"go.etcd.io/etcd/raft"
+	"go.etcd.io/etcd/raft/tracker"
 )
 
 const firstRange = roachpb.RangeID(1)
@@ -613,7 +614,7 @@ func TestAllocatorMultipleStoresPerNode(t *testing.T) {
 				tc.existing, result, err, tc.expectTarget)
 		}
 
-		result, details := a.RebalanceTarget(
+		result, _, details := a.RebalanceTarget(
 			context.Background(),
 			config.EmptyCompleteZoneConfig(),
 			nil, /* raftStatus */
@@ -683,7 +684,7 @@ func TestAllocatorRebalance(t *testing.T) {
 
 	// Every rebalance target must be either store 1 or 2.
 	for i := 0; i < 10; i++ {
-		result, _ := a.RebalanceTarget(
+		result, _, _ := a.RebalanceTarget(
 			ctx,
 			config.EmptyCompleteZoneConfig(),
 			nil,
@@ -818,6 +819,8 @@ func TestAllocatorRebalanceTarget(t *testing.T) {
 
 	repl.leaseholderStats = newReplicaStats(clock, nil)
 	repl.writeStats = newReplicaStats(clock, nil)
+	repl.writeBytesStats = newReplicaStats(clock, nil)
+	repl.cpuStats = newReplicaStats(clock, nil)
 
 	desc := &roachpb.RangeDescriptor{
 		InternalReplicas: replicas,
@@ -827,15 +830,15 @@ func TestAllocatorRebalanceTarget(t *testing.T) {
 	rangeInfo := rangeInfoForRepl(repl, desc)
 
 	status := &raft.Status{
-		Progress: make(map[uint64]raft.Progress),
+		Progress: make(map[uint64]tracker.Progress),
 	}
 	for _, replica := range replicas {
-		status.Progress[uint64(replica.NodeID)] = raft.Progress{
+		status.Progress[uint64(replica.NodeID)] = tracker.Progress{
 			Match: 10,
 		}
 	}
 	for i := 0; i < 10; i++ {
-		result, details := a.RebalanceTarget(
+		result, _, details := a.RebalanceTarget(
 			context.Background(),
 			config.EmptyCompleteZoneConfig(),
 			status,
@@ -854,7 +857,7 @@ func TestAllocatorRebalanceTarget(t *testing.T) {
 	stores[2].Capacity.RangeCount = 46
 	sg.GossipStores(stores, t)
 	for i := 0; i < 10; i++ {
-		result, details := a.RebalanceTarget(
+		result, _, details := a.RebalanceTarget(
 			context.Background(),
 			config.EmptyCompleteZoneConfig(),
 			status,
@@ -870,7 +873,7 @@ func TestAllocatorRebalanceTarget(t *testing.T) {
 	stores[1].Capacity.RangeCount = 44
 	sg.GossipStores(stores, t)
 	for i := 0; i < 10; i++ {
-		result, details := a.RebalanceTarget(
+		result, _, details := a.RebalanceTarget(
 			context.Background(),
 			config.EmptyCompleteZoneConfig(),
 			status,
@@ -945,7 +948,7 @@ func TestAllocatorRebalanceDeadNodes(t *testing.T) {
 
 	for _, c := range testCases {
 		t.Run("", func(t *testing.T) {
-			result, _ := a.RebalanceTarget(
+			result, _, _ := a.RebalanceTarget(
 				ctx,
 				config.EmptyCompleteZoneConfig(),
 				nil,
@@ -1137,7 +1140,7 @@ func TestAllocatorRebalanceByCount(t *testing.T) {
 
 	// Every rebalance target must be store 4 (or nil for case of missing the only option).
 	for i := 0; i < 10; i++ {
-		result, _ := a.RebalanceTarget(
+		result, _, _ := a.RebalanceTarget(
 			ctx,
 			config.EmptyCompleteZoneConfig(),
 			nil,
@@ -1418,7 +1421,7 @@ func TestAllocatorRebalanceDifferentLocalitySizes(t *testing.T) {
 	}
 
 	for i, tc := range testCases {
-		result, details := a.RebalanceTarget(
+		result, _, details := a.RebalanceTarget(
 			ctx,
 			config.EmptyCompleteZoneConfig(),
 			nil, /* raftStatus */
@@ -1486,7 +1489,7 @@ func TestAllocatorRebalanceDifferentLocalitySizes(t *testing.T) {
 
 	for i, tc := range testCases2 {
 		log.Infof(ctx, "case #%d", i)
-		result, details := a.RebalanceTarget(
+		result, _, details := a.RebalanceTarget(
 			ctx,
 			config.EmptyCompleteZoneConfig(),
 			nil, /* raftStatus */
@@ -2191,7 +2194,7 @@ func TestAllocatorRebalanceTargetLocality(t *testing.T) {
 				StoreID: storeID,
 			}
 		}
-		targetStore, details := a.RebalanceTarget(
+		targetStore, _, details := a.RebalanceTarget(
 			context.Background(),
 			config.EmptyCompleteZoneConfig(),
 			nil,
@@ -3591,7 +3594,7 @@ func TestRebalanceCandidatesNumReplicasConstraints(t *testing.T) {
 		} else {
 			// Also verify that RebalanceTarget picks out one of the best options as
 			// the final rebalance choice.
-			target, details := a.RebalanceTarget(
+			target, _, details := a.RebalanceTarget(
 				context.Background(), zone, nil, rangeInfo, storeFilterThrottled)
 			var found bool
 			if target == nil && len(tc.validTargets) == 0 {
@@ -4549,6 +4552,166 @@ func TestAllocatorComputeActionRemoveDead(t *testing.T) {
 	}
 }
 
+func TestAllocatorComputeActionNonVoters(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	replicas := func(voters, nonVoters []roachpb.StoreID) roachpb.RangeDescriptor {
+		var desc roachpb.RangeDescriptor
+		for _, storeID := range voters {
+			desc.InternalReplicas = append(desc.InternalReplicas, roachpb.ReplicaDescriptor{
+				StoreID:   storeID,
+				NodeID:    roachpb.NodeID(storeID),
+				ReplicaID: roachpb.ReplicaID(storeID),
+			})
+		}
+		for _, storeID := range nonVoters {
+			desc.InternalReplicas = append(desc.InternalReplicas, roachpb.ReplicaDescriptor{
+				StoreID:   storeID,
+				NodeID:    roachpb.NodeID(storeID),
+				ReplicaID: roachpb.ReplicaID(storeID),
+				Type:      roachpb.ReplicaType_NON_VOTER,
+			})
+		}
+		return desc
+	}
+	threeVotersTwoNonVoters := config.ZoneConfig{
+		NumReplicas: proto.Int32(5),
+		NumVoters:   proto.Int32(3),
+	}
+	allVoters := config.ZoneConfig{
+		NumReplicas: proto.Int32(3),
+	}
+
+	testCases := []struct {
+		zone           config.ZoneConfig
+		desc           roachpb.RangeDescriptor
+		dead           []roachpb.StoreID
+		expectedAction AllocatorAction
+	}{
+		{
+			zone:           threeVotersTwoNonVoters,
+			desc:           replicas([]roachpb.StoreID{1, 2, 3}, nil),
+			expectedAction: AllocatorAddNonVoter,
+		},
+		{
+			zone:           threeVotersTwoNonVoters,
+			desc:           replicas([]roachpb.StoreID{1, 2, 3}, []roachpb.StoreID{4}),
+			expectedAction: AllocatorAddNonVoter,
+		},
+		{
+			zone:           threeVotersTwoNonVoters,
+			desc:           replicas([]roachpb.StoreID{1, 2, 3}, []roachpb.StoreID{4, 5}),
+			expectedAction: AllocatorConsiderRebalance,
+		},
+		{
+			zone:           threeVotersTwoNonVoters,
+			desc:           replicas([]roachpb.StoreID{1, 2, 3}, []roachpb.StoreID{4, 5, 6}),
+			expectedAction: AllocatorRemoveNonVoter,
+		},
+		{
+			zone:           threeVotersTwoNonVoters,
+			desc:           replicas([]roachpb.StoreID{1, 2, 3}, []roachpb.StoreID{4, 5}),
+			dead:           []roachpb.StoreID{5},
+			expectedAction: AllocatorRemoveNonVoter,
+		},
+		// Actions on voters take precedence over actions on non-voters.
+		{
+			zone:           threeVotersTwoNonVoters,
+			desc:           replicas([]roachpb.StoreID{1, 2}, []roachpb.StoreID{4, 5, 6}),
+			expectedAction: AllocatorAdd,
+		},
+		{
+			zone:           threeVotersTwoNonVoters,
+			desc:           replicas([]roachpb.StoreID{1, 2, 3, 4}, nil),
+			expectedAction: AllocatorRemove,
+		},
+		// Non-voters are not part of the quorum, so their liveness doesn't keep
+		// the voters from being repaired.
+		{
+			zone:           threeVotersTwoNonVoters,
+			desc:           replicas([]roachpb.StoreID{1, 2, 3}, []roachpb.StoreID{4, 5}),
+			dead:           []roachpb.StoreID{3, 4, 5},
+			expectedAction: AllocatorAdd,
+		},
+		// Without num_voters, all replicas are voters.
+		{
+			zone:           allVoters,
+			desc:           replicas([]roachpb.StoreID{1, 2}, []roachpb.StoreID{3}),
+			expectedAction: AllocatorAdd,
+		},
+		{
+			zone:           allVoters,
+			desc:           replicas([]roachpb.StoreID{1, 2, 3}, []roachpb.StoreID{4}),
+			expectedAction: AllocatorRemoveNonVoter,
+		},
+	}
+
+	stopper, _, sp, a, _ := createTestAllocator(10, false /* deterministic */)
+	ctx := context.Background()
+	defer stopper.Stop(ctx)
+
+	for i, tcase := range testCases {
+		var live []roachpb.StoreID
+		for storeID := roachpb.StoreID(1); storeID <= 10; storeID++ {
+			dead := false
+			for _, deadID := range tcase.dead {
+				dead = dead || deadID == storeID
+			}
+			if !dead {
+				live = append(live, storeID)
+			}
+		}
+		mockStorePool(sp, live, nil, tcase.dead, nil, nil)
+
+		action, _ := a.ComputeAction(ctx, &tcase.zone, RangeInfo{Desc: &tcase.desc})
+		if tcase.expectedAction != action {
+			t.Errorf("%d: expected action %s, got action %s", i, tcase.expectedAction, action)
+		}
+	}
+}
+
+func TestAllocatorPromoteTarget(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	stopper, g, _, a, _ := createTestAllocator(10, false /* deterministic */)
+	ctx := context.Background()
+	defer stopper.Stop(ctx)
+	gossiputil.NewStoreGossiper(g).GossipStores(sameDCStores, t)
+
+	zone := config.ZoneConfig{
+		NumReplicas: proto.Int32(5),
+		NumVoters:   proto.Int32(3),
+		Constraints: []config.Constraints{
+			{Constraints: []config.Constraint{{Value: "ssd", Type: config.Constraint_REQUIRED}}},
+		},
+	}
+	voters := []roachpb.ReplicaDescriptor{{NodeID: 1, StoreID: 1, ReplicaID: 1}}
+	ssdNonVoter := roachpb.ReplicaDescriptor{
+		NodeID: 2, StoreID: 2, ReplicaID: 2, Type: roachpb.ReplicaType_NON_VOTER,
+	}
+	hddNonVoter := roachpb.ReplicaDescriptor{
+		NodeID: 3, StoreID: 3, ReplicaID: 3, Type: roachpb.ReplicaType_NON_VOTER,
+	}
+	rangeInfo := RangeInfo{Desc: &roachpb.RangeDescriptor{
+		InternalReplicas: append([]roachpb.ReplicaDescriptor{ssdNonVoter, hddNonVoter}, voters...),
+	}}
+
+	// Only the non-voter satisfying the constraints can be promoted.
+	target, _, ok := a.PromoteTarget(
+		ctx, &zone, voters, []roachpb.ReplicaDescriptor{hddNonVoter, ssdNonVoter}, rangeInfo)
+	if !ok {
+		t.Fatal("expected a non-voter to promote")
+	}
+	if target != ssdNonVoter {
+		t.Errorf("expected to promote %s, got %s", ssdNonVoter, target)
+	}
+	if _, _, ok := a.PromoteTarget(
+		ctx, &zone, voters, []roachpb.ReplicaDescriptor{hddNonVoter}, rangeInfo,
+	); ok {
+		t.Error("expected no non-voter to be suitable for promotion")
+	}
+}
+
 func TestAllocatorComputeActionDecommission(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -5156,18 +5319,18 @@ func TestFilterBehindReplicas(t *testing.T) {
 	for _, c := range testCases {
 		t.Run("", func(t *testing.T) {
 			status := &raft.Status{
-				Progress: make(map[uint64]raft.Progress),
+				Progress: make(map[uint64]tracker.Progress),
 			}
 			status.Lead = c.leader
 			status.Commit = c.commit
 			var replicas []roachpb.ReplicaDescriptor
 			for j, v := range c.progress {
-				p := raft.Progress{
+				p := tracker.Progress{
 					Match: v,
-					State: raft.ProgressStateReplicate,
+					State: tracker.StateReplicate,
 				}
 				if v == 0 {
-					p.State = raft.ProgressStateProbe
+					p.State = tracker.StateProbe
 				}
 				replicaID := uint64(j + 1)
 				status.Progress[replicaID] = p
@@ -5224,7 +5387,7 @@ func TestFilterUnremovableReplicas(t *testing.T) {
 	for _, c := range testCases {
 		t.Run("", func(t *testing.T) {
 			status := &raft.Status{
-				Progress: make(map[uint64]raft.Progress),
+				Progress: make(map[uint64]tracker.Progress),
 			}
 			// Use an invalid replica ID for the leader. TestFilterBehindReplicas covers
 			// valid replica IDs.
@@ -5232,12 +5395,12 @@ func TestFilterUnremovableReplicas(t *testing.T) {
 			status.Commit = c.commit
 			var replicas []roachpb.ReplicaDescriptor
 			for j, v := range c.progress {
-				p := raft.Progress{
+				p := tracker.Progress{
 					Match: v,
-					State: raft.ProgressStateReplicate,
+					State: tracker.StateReplicate,
 				}
 				if v == 0 {
-					p.State = raft.ProgressStateProbe
+					p.State = tracker.StateProbe
 				}
 				replicaID := uint64(j + 1)
 				status.Progress[replicaID] = p
@@ -5279,7 +5442,7 @@ func TestSimulateFilterUnremovableReplicas(t *testing.T) {
 	for _, c := range testCases {
 		t.Run("", func(t *testing.T) {
 			status := &raft.Status{
-				Progress: make(map[uint64]raft.Progress),
+				Progress: make(map[uint64]tracker.Progress),
 			}
 			// Use an invalid replica ID for the leader. TestFilterBehindReplicas covers
 			// valid replica IDs.
@@ -5287,12 +5450,12 @@ func TestSimulateFilterUnremovableReplicas(t *testing.T) {
 			status.Commit = c.commit
 			var replicas []roachpb.ReplicaDescriptor
 			for j, v := range c.progress {
-				p := raft.Progress{
+				p := tracker.Progress{
 					Match: v,
-					State: raft.ProgressStateReplicate,
+					State: tracker.StateReplicate,
 				}
 				if v == 0 {
-					p.State = raft.ProgressStateProbe
+					p.State = tracker.StateProbe
 				}
 				replicaID := uint64(j + 1)
 				status.Progress[replicaID] = p
@@ -5425,7 +5588,7 @@ func TestAllocatorRebalanceAway(t *testing.T) {
 				},
 			}
 
-			actual, _ := a.RebalanceTarget(
+			actual, _, _ := a.RebalanceTarget(
 				ctx,
 				&config.ZoneConfig{NumReplicas: proto.Int32(0), Constraints: []config.Constraints{constraints}},
 				nil,
@@ -5588,7 +5751,7 @@ func TestAllocatorFullDisks(t *testing.T) {
 				ts := &testStores[k]
 				// Rebalance until there's no more rebalancing to do.
 				if ts.Capacity.RangeCount > 0 {
-					target, details := alloc.RebalanceTarget(
+					target, _, details := alloc.RebalanceTarget(
 						ctx,
 						config.EmptyCompleteZoneConfig(),
 						nil,
@@ -5714,7 +5877,7 @@ func Example_rebalancing() {
 		// Next loop through test stores and maybe rebalance.
 		for j := 0; j < len(testStores); j++ {
 			ts := &testStores[j]
-			target, details := alloc.RebalanceTarget(
+			target, _, details := alloc.RebalanceTarget(
 				context.Background(),
 				config.EmptyCompleteZoneConfig(),
 				nil,
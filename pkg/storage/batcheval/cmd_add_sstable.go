@@ -17,6 +17,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage/batcheval/result"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
@@ -44,11 +45,32 @@ func EvalAddSSTable(
 	// defer tracing.FinishSpan(span)
 	log.Eventf(ctx, "evaluating AddSSTable [%s,%s)", mvccStartKey.Key, mvccEndKey.Key)
 
+	data := args.Data
+	if args.DisallowShadowing || args.WriteAtRequestTimestamp {
+		if !cArgs.EvalCtx.ClusterSettings().Version.IsActive(cluster.VersionOnlineImport) {
+			return result.Result{}, errors.New(
+				"AddSSTable options for online ingestion are not supported by all nodes in the cluster")
+		}
+	}
+	if args.WriteAtRequestTimestamp {
+		var err error
+		if data, err = engine.UpdateSSTTimestamps(data, h.Timestamp); err != nil {
+			return result.Result{}, errors.Wrap(err, "updating sstable timestamps")
+		}
+	}
+	if args.DisallowShadowing {
+		// Intents and newer versions are reported as such, so that they are
+		// resolved or the request is retried at a higher timestamp.
+		if err := engine.CheckSSTConflicts(batch, data, args.Key, args.EndKey); err != nil {
+			return result.Result{}, err
+		}
+	}
+
 	// Verify that the keys in the sstable are within the range specified by the
 	// request header, verify the key-value checksums, and compute the new
 	// MVCCStats.
 	stats, err := verifySSTable(
-		args.Data, mvccStartKey, mvccEndKey, h.Timestamp.WallTime)
+		data, mvccStartKey, mvccEndKey, h.Timestamp.WallTime)
 	if err != nil {
 		return result.Result{}, errors.Wrap(err, "verifying sstable data")
 	}
@@ -100,8 +122,8 @@ func EvalAddSSTable(
 	return result.Result{
 		Replicated: storagepb.ReplicatedEvalResult{
 			AddSSTable: &storagepb.ReplicatedEvalResult_AddSSTable{
-				Data:  args.Data,
-				CRC32: util.CRC32(args.Data),
+				Data:  data,
+				CRC32: util.CRC32(data),
 			},
 		},
 	}, nil
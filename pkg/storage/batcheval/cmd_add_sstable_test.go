@@ -90,13 +90,13 @@ func runTestDBAddSSTable(ctx context.Context, t *testing.T, db *client.DB, store
 
 		// Key is before the range in the request span.
 		if err := db.AddSSTable(
-			ctx, "d", "e", data,
+			ctx, "d", "e", data, false /* disallowShadowing */, false, /* writeAtRequestTimestamp */
 		); !testutils.IsError(err, "not in request range") {
 			t.Fatalf("expected request range error got: %+v", err)
 		}
 		// Key is after the range in the request span.
 		if err := db.AddSSTable(
-			ctx, "a", "b", data,
+			ctx, "a", "b", data, false /* disallowShadowing */, false, /* writeAtRequestTimestamp */
 		); !testutils.IsError(err, "not in request range") {
 			t.Fatalf("expected request range error got: %+v", err)
 		}
@@ -104,7 +104,7 @@ func runTestDBAddSSTable(ctx context.Context, t *testing.T, db *client.DB, store
 		// Do an initial ingest.
 		ingestCtx, collect, cancel := tracing.ContextWithRecordingSpan(ctx, "test-recording")
 		defer cancel()
-		if err := db.AddSSTable(ingestCtx, "b", "c", data); err != nil {
+		if err := db.AddSSTable(ingestCtx, "b", "c", data, false, false); err != nil {
 			t.Fatalf("%+v", err)
 		}
 		formatted := tracing.FormatRecordedSpans(collect())
@@ -145,7 +145,7 @@ func runTestDBAddSSTable(ctx context.Context, t *testing.T, db *client.DB, store
 			t.Fatalf("%+v", err)
 		}
 
-		if err := db.AddSSTable(ctx, "b", "c", data); err != nil {
+		if err := db.AddSSTable(ctx, "b", "c", data, false, false); err != nil {
 			t.Fatalf("%+v", err)
 		}
 		if r, err := db.Get(ctx, "bb"); err != nil {
@@ -180,7 +180,7 @@ func runTestDBAddSSTable(ctx context.Context, t *testing.T, db *client.DB, store
 			ingestCtx, collect, cancel := tracing.ContextWithRecordingSpan(ctx, "test-recording")
 			defer cancel()
 
-			if err := db.AddSSTable(ingestCtx, "b", "c", data); err != nil {
+			if err := db.AddSSTable(ingestCtx, "b", "c", data, false, false); err != nil {
 				t.Fatalf("%+v", err)
 			}
 			if err := testutils.MatchInOrder(tracing.FormatRecordedSpans(collect()),
@@ -225,12 +225,92 @@ func runTestDBAddSSTable(ctx context.Context, t *testing.T, db *client.DB, store
 			t.Fatalf("%+v", err)
 		}
 
-		if err := db.AddSSTable(ctx, "b", "c", data); !testutils.IsError(err, "invalid checksum") {
+		if err := db.AddSSTable(
+			ctx, "b", "c", data, false, false,
+		); !testutils.IsError(err, "invalid checksum") {
 			t.Fatalf("expected 'invalid checksum' error got: %+v", err)
 		}
 	}
 }
 
+func TestDBAddSSTableOnline(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, _, db := serverutils.StartServer(t, base.TestServerArgs{Insecure: true})
+	ctx := context.Background()
+	defer s.Stopper().Stop(ctx)
+
+	sst := func(key, value string) []byte {
+		t.Helper()
+		data, err := singleKVSSTable(
+			engine.MVCCKey{Key: []byte(key), Timestamp: hlc.Timestamp{WallTime: 1}},
+			roachpb.MakeValueFromString(value).RawBytes,
+		)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		return data
+	}
+	expectValue := func(key, expected string) {
+		t.Helper()
+		if r, err := db.Get(ctx, key); err != nil {
+			t.Fatalf("%+v", err)
+		} else if expected == "" && r.Value != nil {
+			t.Errorf("expected %s to be deleted, got %q", key, r.ValueBytes())
+		} else if expected != "" && !bytes.Equal([]byte(expected), r.ValueBytes()) {
+			t.Errorf("expected %q, got %q", expected, r.ValueBytes())
+		}
+	}
+
+	// A key which exists before the online ingestion.
+	if err := db.AddSSTable(ctx, "b", "c", sst("ba", "1"), false, false); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	before := s.Clock().Now()
+
+	// The ingested keys are written at the request timestamp, above the reads
+	// of the keys, and that timestamp is returned.
+	expectValue("bb", "")
+	ingestedAt, err := db.AddSSTableAtRequestTimestamp(ctx, "b", "c", sst("bb", "2"), true)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !before.Less(ingestedAt) {
+		t.Fatalf("expected ingestion above %s, got %s", before, ingestedAt)
+	}
+	if r, err := db.Get(ctx, "bb"); err != nil {
+		t.Fatalf("%+v", err)
+	} else if r.Value.Timestamp != ingestedAt {
+		t.Fatalf("expected ingested value at %s, got %s", ingestedAt, r.Value.Timestamp)
+	}
+
+	// Existing live values can only be shadowed by identical ones.
+	if err := db.AddSSTable(ctx, "b", "c", sst("ba", "1"), true, true); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := db.AddSSTable(
+		ctx, "b", "c", sst("ba", "3"), true, true,
+	); !testutils.IsError(err, "ingested key collides with an existing one") {
+		t.Fatalf("expected collision error, got: %+v", err)
+	}
+	expectValue("ba", "1")
+
+	// A key written concurrently with the ingestion.
+	if err := db.Put(ctx, "bc", "4"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	// Deleting the keys inserted at the timestamp of the ingestion only deletes
+	// the ingested key.
+	b := &client.Batch{}
+	b.DelRangeInsertedAfter("b", "c", ingestedAt.Prev(), ingestedAt)
+	if err := db.Run(ctx, b); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expectValue("ba", "1")
+	expectValue("bb", "")
+	expectValue("bc", "4")
+}
+
 type strKv struct {
 	k  string
 	ts int64
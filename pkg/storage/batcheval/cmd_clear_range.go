@@ -45,6 +45,8 @@ func declareKeysClearRange(
 	// We look up the range descriptor key to check whether the span
 	// is equal to the entire range for fast stats updating.
 	spans.Add(spanset.SpanReadOnly, roachpb.Span{Key: keys.RangeDescriptorKey(desc.StartKey)})
+	// The range tombstones covering the span are cleared along with it.
+	declareKeysRangeTombstones(desc, spanset.SpanReadWrite, spans)
 }
 
 // ClearRange wipes all MVCC versions of keys covered by the specified
@@ -75,6 +77,12 @@ func ClearRange(
 	}
 	cArgs.Stats.Subtract(statsDelta)
 
+	// Remove the range tombstones covering the span, which would otherwise
+	// apply to keys written to it in the future.
+	if err := engine.MVCCClearRangeTombstones(ctx, batch, cArgs.Stats, args.Key, args.EndKey); err != nil {
+		return result.Result{}, err
+	}
+
 	// If the total size of data to be cleared is less than
 	// clearRangeBytesThreshold, clear the individual values manually,
 	// instead of using a range tombstone (inefficient for small ranges).
@@ -131,9 +139,11 @@ func computeStatsDelta(
 		// opposed to the usual method of computing only a localizied
 		// stats delta, because a full-range clear prevents any concurrent
 		// access to the stats. Concurrent changes to range-local keys are
-		// explicitly ignored (i.e. SysCount, SysBytes).
+		// explicitly ignored (i.e. SysCount, SysBytes). The range tombstones,
+		// which are range-local keys as well, are accounted for separately.
 		delta = cArgs.EvalCtx.GetMVCCStats()
 		delta.SysCount, delta.SysBytes = 0, 0 // no change to system stats
+		delta.RangeTombstoneCount = 0
 	}
 
 	// If we can't use the fast stats path, or race test is enabled,
@@ -145,6 +155,11 @@ func computeStatsDelta(
 		if err != nil {
 			return enginepb.MVCCStats{}, err
 		}
+		tombDelta, err := engine.ComputeRangeTombstoneStats(batch, from.Key, to.Key, delta.LastUpdateNanos)
+		if err != nil {
+			return enginepb.MVCCStats{}, err
+		}
+		computed.Add(tombDelta)
 		// If we took the fast path but race is enabled, assert stats were correctly computed.
 		if fast {
 			if !delta.Equal(computed) {
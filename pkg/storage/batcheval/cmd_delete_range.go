@@ -16,13 +16,25 @@ import (
 	"context"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage/batcheval/result"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/pkg/errors"
 )
 
 func init() {
-	RegisterCommand(roachpb.DeleteRange, DefaultDeclareKeys, DeleteRange)
+	RegisterCommand(roachpb.DeleteRange, declareKeysDeleteRange, DeleteRange)
+}
+
+func declareKeysDeleteRange(
+	desc *roachpb.RangeDescriptor, header roachpb.Header, req roachpb.Request, spans *spanset.SpanSet,
+) {
+	DefaultDeclareKeys(desc, header, req, spans)
+	if req.(*roachpb.DeleteRangeRequest).UseRangeTombstone {
+		declareKeysRangeTombstones(desc, spanset.SpanReadWrite, spans)
+	}
 }
 
 // DeleteRange deletes the range of key/value pairs specified by
@@ -34,6 +46,51 @@ func DeleteRange(
 	h := cArgs.Header
 	reply := resp.(*roachpb.DeleteRangeResponse)
 
+	if args.UseRangeTombstone {
+		if !cArgs.EvalCtx.ClusterSettings().Version.IsActive(cluster.VersionMVCCRangeTombstones) {
+			return result.Result{}, errors.New("range tombstones are not supported by all nodes in the cluster")
+		}
+		if h.Txn != nil {
+			return result.Result{}, errors.New("cannot use a range tombstone within a transaction")
+		}
+		if args.Inline || args.ReturnKeys || !args.OnlyInsertedAfter.IsEmpty() {
+			return result.Result{}, errors.New(
+				"cannot use a range tombstone with inline, return_keys or only_inserted_after")
+		}
+		// The range tombstone doesn't depend on the number of keys, so the key
+		// limit doesn't apply.
+		return result.Result{}, engine.MVCCDeleteRangeUsingTombstone(
+			ctx, batch, cArgs.Stats, args.Key, args.EndKey, h.Timestamp,
+		)
+	}
+
+	if !args.OnlyInsertedUntil.IsEmpty() && args.OnlyInsertedAfter.IsEmpty() {
+		return result.Result{}, errors.New("only_inserted_until requires only_inserted_after")
+	}
+	if !args.OnlyInsertedAfter.IsEmpty() {
+		if !cArgs.EvalCtx.ClusterSettings().Version.IsActive(cluster.VersionOnlineImport) {
+			return result.Result{}, errors.New(
+				"deleting only inserted keys is not supported by all nodes in the cluster")
+		}
+		if h.Txn != nil {
+			return result.Result{}, errors.New("cannot delete only inserted keys within a transaction")
+		}
+		if args.Inline || args.ReturnKeys {
+			return result.Result{}, errors.New(
+				"cannot delete only inserted keys with inline or return_keys")
+		}
+		resumeSpan, num, err := engine.MVCCDeleteRangeInsertedAfter(
+			ctx, batch, cArgs.Stats, args.Key, args.EndKey, cArgs.MaxKeys, h.Timestamp,
+			args.OnlyInsertedAfter, args.OnlyInsertedUntil,
+		)
+		reply.NumKeys = num
+		if resumeSpan != nil {
+			reply.ResumeSpan = resumeSpan
+			reply.ResumeReason = roachpb.RESUME_KEY_LIMIT
+		}
+		return result.Result{}, err
+	}
+
 	var timestamp hlc.Timestamp
 	if !args.Inline {
 		timestamp = h.Timestamp
@@ -97,6 +97,9 @@ func declareKeysEndTransaction(
 					Key:    keys.MakeRangeKeyPrefix(st.LeftDesc.StartKey),
 					EndKey: keys.MakeRangeKeyPrefix(st.RightDesc.EndKey).PrefixEnd(),
 				})
+				// Range tombstones straddling the split key are split in two.
+				spans.Add(spanset.SpanReadWrite,
+					keys.RangeTombstoneSpan(st.LeftDesc.StartKey, st.RightDesc.EndKey))
 				leftRangeIDPrefix := keys.MakeRangeIDReplicatedPrefix(header.RangeID)
 				spans.Add(spanset.SpanReadOnly, roachpb.Span{
 					Key:    leftRangeIDPrefix,
@@ -826,6 +829,14 @@ func splitTrigger(
 			split.RightDesc.StartKey, split.RightDesc.EndKey, desc)
 	}
 
+	// Split the range tombstones straddling the split key, which must not
+	// extend past the end of the LHS.
+	if err := engine.MVCCSplitRangeTombstones(
+		ctx, batch, &bothDeltaMS, split.RightDesc.StartKey.AsRawKey(),
+	); err != nil {
+		return enginepb.MVCCStats{}, result.Result{}, errors.Wrap(err, "unable to split range tombstones")
+	}
+
 	// Compute the absolute stats for the (post-split) LHS. No more
 	// modifications to it are allowed after this line.
 
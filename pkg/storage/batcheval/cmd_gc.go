@@ -54,6 +54,9 @@ func declareKeysGC(
 			Key: keys.RangeTxnSpanGCThresholdKey(header.RangeID),
 		})
 	}
+	if len(gcr.Keys) > 0 || gcr.RangeTombstoneThreshold != (hlc.Timestamp{}) {
+		declareKeysRangeTombstones(desc, spanset.SpanReadWrite, spans)
+	}
 	spans.Add(spanset.SpanReadOnly, roachpb.Span{Key: keys.RangeDescriptorKey(desc.StartKey)})
 }
 
@@ -86,6 +89,17 @@ func GC(
 		return result.Result{}, err
 	}
 
+	// Remove the range tombstones which no longer cover any versions.
+	if args.RangeTombstoneThreshold != (hlc.Timestamp{}) {
+		desc := cArgs.EvalCtx.Desc()
+		if err := engine.MVCCGarbageCollectRangeTombstones(
+			ctx, batch, cArgs.Stats, desc.StartKey.AsRawKey(), desc.EndKey.AsRawKey(),
+			args.RangeTombstoneThreshold,
+		); err != nil {
+			return result.Result{}, err
+		}
+	}
+
 	// Protect against multiple GC requests arriving out of order; we track
 	// the maximum timestamps.
 
@@ -84,7 +84,8 @@ func evalNewLease(
 
 	// Verify that requesting replica is part of the current replica set.
 	desc := rec.Desc()
-	if _, ok := desc.GetReplicaDescriptor(lease.Replica.StoreID); !ok {
+	repDesc, ok := desc.GetReplicaDescriptor(lease.Replica.StoreID)
+	if !ok {
 		return newFailedLeaseTrigger(isTransfer),
 			&roachpb.LeaseRejectedError{
 				Existing:  prevLease,
@@ -92,6 +93,16 @@ func evalNewLease(
 				Message:   "replica not found",
 			}
 	}
+	// Only voters may hold the lease; a leaseholder outside of the write quorum
+	// would defeat the purpose of non-voting replicas.
+	if repDesc.Type != roachpb.ReplicaType_VOTER {
+		return newFailedLeaseTrigger(isTransfer),
+			&roachpb.LeaseRejectedError{
+				Existing:  prevLease,
+				Requested: lease,
+				Message:   fmt.Sprintf("replica of type %s cannot hold lease", repDesc.Type),
+			}
+	}
 
 	// Requests should not set the sequence number themselves. Set the sequence
 	// number here based on whether the lease is equivalent to the one it's
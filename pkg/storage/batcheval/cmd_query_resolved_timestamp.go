@@ -0,0 +1,95 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package batcheval
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/batcheval/result"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+)
+
+func init() {
+	RegisterCommand(
+		roachpb.QueryResolvedTimestamp, declareKeysQueryResolvedTimestamp, QueryResolvedTimestamp,
+	)
+}
+
+func declareKeysQueryResolvedTimestamp(
+	_ *roachpb.RangeDescriptor, _ roachpb.Header, req roachpb.Request, spans *spanset.SpanSet,
+) {
+	// The request only reads the intents in its span. Unlike other reads, it
+	// does not consult the range's MVCC range tombstones.
+	spans.Add(spanset.SpanReadOnly, req.Header().Span())
+}
+
+// QueryResolvedTimestamp returns the timestamp below which the replica
+// evaluating the request can serve consistent reads of the request's span. It
+// is usually evaluated as an INCONSISTENT read on a follower. The replica's
+// closed timestamp bounds the resolved timestamp, as does every intent in the
+// span: the intent's transaction may still commit at the intent's timestamp,
+// so reads at or above it must wait for the intent to be resolved.
+func QueryResolvedTimestamp(
+	ctx context.Context, batch engine.ReadWriter, cArgs CommandArgs, resp roachpb.Response,
+) (result.Result, error) {
+	args := cArgs.Args.(*roachpb.QueryResolvedTimestampRequest)
+	reply := resp.(*roachpb.QueryResolvedTimestampResponse)
+
+	resolvedTS := cArgs.EvalCtx.GetClosedTimestamp(ctx)
+	intentTS, err := minIntentTimestamp(batch, args.Span())
+	if err != nil {
+		return result.Result{}, err
+	}
+	if !intentTS.IsEmpty() && intentTS.Prev().Less(resolvedTS) {
+		resolvedTS = intentTS.Prev()
+	}
+	reply.ResolvedTS = resolvedTS
+	return result.Result{}, nil
+}
+
+// minIntentTimestamp returns the smallest timestamp of the intents in the
+// span, or the zero timestamp if there are none.
+func minIntentTimestamp(reader engine.Reader, span roachpb.Span) (hlc.Timestamp, error) {
+	iter := reader.NewIterator(engine.IterOptions{UpperBound: span.EndKey})
+	defer iter.Close()
+
+	var minTS hlc.Timestamp
+	var meta enginepb.MVCCMetadata
+	for iter.Seek(engine.MakeMVCCMetadataKey(span.Key)); ; iter.NextKey() {
+		if ok, err := iter.Valid(); err != nil {
+			return hlc.Timestamp{}, err
+		} else if !ok {
+			break
+		}
+		if iter.UnsafeKey().IsValue() {
+			// The key has no metadata record, so it has no intent.
+			continue
+		}
+		if err := protoutil.Unmarshal(iter.UnsafeValue(), &meta); err != nil {
+			return hlc.Timestamp{}, err
+		}
+		if meta.Txn == nil {
+			// An inline value.
+			continue
+		}
+		if ts := hlc.Timestamp(meta.Timestamp); minTS.IsEmpty() || ts.Less(minTS) {
+			minTS = ts
+		}
+	}
+	return minTS, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package batcheval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+func TestQueryResolvedTimestamp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	db := engine.NewInMem(roachpb.Attributes{}, 10<<20)
+	defer db.Close()
+
+	ts := func(wallTime int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wallTime} }
+	v := roachpb.MakeValueFromString("v")
+	write := func(key string, ts hlc.Timestamp, txn *roachpb.Transaction) {
+		t.Helper()
+		if err := engine.MVCCPut(ctx, db, nil, roachpb.Key(key), ts, v, txn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	intent := func(key string, ts hlc.Timestamp) {
+		t.Helper()
+		write(key, ts, &roachpb.Transaction{
+			TxnMeta:       enginepb.TxnMeta{Key: roachpb.Key(key), ID: uuid.MakeV4(), Timestamp: ts},
+			OrigTimestamp: ts,
+		})
+	}
+	write("a", ts(5), nil)
+	intent("b", ts(20))
+	write("c", ts(1), nil)
+	intent("c", ts(15))
+	write("d", ts(30), nil)
+
+	for _, tc := range []struct {
+		from, to string
+		closedTS hlc.Timestamp
+		exp      hlc.Timestamp
+	}{
+		// Without intents, the closed timestamp is resolved.
+		{"a", "b", ts(50), ts(50)},
+		{"d", "e", ts(50), ts(50)},
+		// Intents hold the resolved timestamp back to just below them.
+		{"a", "c", ts(50), ts(20).Prev()},
+		{"a", "e", ts(50), ts(15).Prev()},
+		{"b", "e", ts(50), ts(15).Prev()},
+		// Intents above the closed timestamp do not matter.
+		{"a", "e", ts(10), ts(10)},
+	} {
+		t.Run(tc.from+"-"+tc.to, func(t *testing.T) {
+			var resp roachpb.QueryResolvedTimestampResponse
+			if _, err := QueryResolvedTimestamp(ctx, db, CommandArgs{
+				EvalCtx: &mockEvalCtx{closedTS: tc.closedTS},
+				Args: &roachpb.QueryResolvedTimestampRequest{
+					RequestHeader: roachpb.RequestHeader{
+						Key:    roachpb.Key(tc.from),
+						EndKey: roachpb.Key(tc.to),
+					},
+				},
+			}, &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.ResolvedTS != tc.exp {
+				t.Errorf("expected resolved timestamp %s, got %s", tc.exp, resp.ResolvedTS)
+			}
+		})
+	}
+}
@@ -31,5 +31,7 @@ func RangeStats(
 	reply := resp.(*roachpb.RangeStatsResponse)
 	reply.MVCCStats = cArgs.EvalCtx.GetMVCCStats()
 	reply.QueriesPerSecond = cArgs.EvalCtx.GetSplitQPS()
+	reply.WriteBytesPerSecond, reply.ContentionPerSecond = cArgs.EvalCtx.GetSplitLoad()
+	reply.RecentQueriesPerSecond, reply.RecentWriteBytesPerSecond = cArgs.EvalCtx.GetRecentLoad()
 	return result.Result{}, nil
 }
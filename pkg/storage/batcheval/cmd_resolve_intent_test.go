@@ -41,6 +41,7 @@ type mockEvalCtx struct {
 	qps              float64
 	abortSpan        *abortspan.AbortSpan
 	gcThreshold      hlc.Timestamp
+	closedTS         hlc.Timestamp
 	term, firstIndex uint64
 	canCreateTxnFn   func() (bool, hlc.Timestamp, roachpb.TransactionAbortedReason)
 }
@@ -75,6 +76,9 @@ func (m *mockEvalCtx) GetTxnWaitQueue() *txnwait.Queue {
 func (m *mockEvalCtx) NodeID() roachpb.NodeID {
 	panic("unimplemented")
 }
+func (m *mockEvalCtx) GetNodeLocality() roachpb.Locality {
+	panic("unimplemented")
+}
 func (m *mockEvalCtx) StoreID() roachpb.StoreID {
 	panic("unimplemented")
 }
@@ -105,6 +109,15 @@ func (m *mockEvalCtx) GetMVCCStats() enginepb.MVCCStats {
 func (m *mockEvalCtx) GetSplitQPS() float64 {
 	return m.qps
 }
+func (m *mockEvalCtx) GetSplitLoad() (float64, float64) {
+	return 0, 0
+}
+func (m *mockEvalCtx) GetRecentLoad() (float64, float64) {
+	return m.qps, 0
+}
+func (m *mockEvalCtx) GetClosedTimestamp(context.Context) hlc.Timestamp {
+	return m.closedTS
+}
 func (m *mockEvalCtx) CanCreateTxnRecord(
 	uuid.UUID, []byte, hlc.Timestamp,
 ) (bool, hlc.Timestamp, roachpb.TransactionAbortedReason) {
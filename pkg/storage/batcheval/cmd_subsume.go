@@ -51,6 +51,7 @@ func declareKeysSubsume(
 		Key:    keys.MakeRangeKeyPrefix(desc.StartKey),
 		EndKey: keys.MakeRangeKeyPrefix(desc.EndKey).PrefixEnd(),
 	})
+	declareKeysRangeTombstones(desc, spanset.SpanReadWrite, spans)
 	rangeIDPrefix := keys.MakeRangeIDReplicatedPrefix(desc.RangeID)
 	spans.Add(spanset.SpanReadWrite, roachpb.Span{
 		Key:    rangeIDPrefix,
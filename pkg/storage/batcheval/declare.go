@@ -25,13 +25,27 @@ import (
 func DefaultDeclareKeys(
 	desc *roachpb.RangeDescriptor, header roachpb.Header, req roachpb.Request, spans *spanset.SpanSet,
 ) {
-	if roachpb.IsReadOnly(req) {
+	// Locking reads declare write latches so that they are serialized with
+	// other requests that may acquire locks on the same keys.
+	if roachpb.IsReadOnly(req) && !roachpb.IsLocking(req) {
 		spans.Add(spanset.SpanReadOnly, req.Header().Span())
 	} else {
 		spans.Add(spanset.SpanReadWrite, req.Header().Span())
 	}
 }
 
+// declareKeysRangeTombstones declares an access to the MVCC range tombstones
+// of the range. Commands writing range tombstones declare a write access,
+// which serializes them with all other commands consulting the range
+// tombstones. The replica declares a read access for all other commands
+// operating on the range's user data, as long as the range has any range
+// tombstones.
+func declareKeysRangeTombstones(
+	desc *roachpb.RangeDescriptor, access spanset.SpanAccess, spans *spanset.SpanSet,
+) {
+	spans.Add(access, keys.RangeTombstoneSpan(desc.StartKey, desc.EndKey))
+}
+
 // DeclareKeysForBatch adds all keys that the batch with the provided header
 // touches to the given SpanSet. This does not include keys touched during the
 // processing of the batch's individual commands.
@@ -59,6 +59,7 @@ type EvalContext interface {
 	GetLimiters() *Limiters
 
 	NodeID() roachpb.NodeID
+	GetNodeLocality() roachpb.Locality
 	StoreID() roachpb.StoreID
 	GetRangeID() roachpb.RangeID
 
@@ -90,6 +91,23 @@ type EvalContext interface {
 	// setting is disabled.
 	GetSplitQPS() float64
 
+	// GetSplitLoad returns the rates of bytes written and of requests waiting
+	// on conflicting locks for this range, as measured for load based
+	// splitting.
+	//
+	// NOTE: This should not be used when the load based splitting cluster
+	// setting is disabled.
+	GetSplitLoad() (writeBytesPerSecond, contentionPerSecond float64)
+
+	// GetRecentLoad returns the queries/s and bytes written/s for this range,
+	// averaged over the last several minutes.
+	GetRecentLoad() (qps, writeBytesPerSecond float64)
+
+	// GetClosedTimestamp returns the timestamp below which the replica can
+	// serve consistent follower reads, as determined by the closed timestamp
+	// subsystem.
+	GetClosedTimestamp(context.Context) hlc.Timestamp
+
 	GetGCThreshold() hlc.Timestamp
 	// TODO(nvanbenschoten): Remove this in 2.3, at which point no request type
 	// will ever need to consult the threshold.
@@ -19,6 +19,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/pkg/errors"
@@ -38,6 +39,9 @@ type BufferingAdder struct {
 	// currently buffered kvs.
 	curBuf kvBuf
 
+	// notified of the spans ingested by each flush, if set.
+	recorder storagebase.IngestRecorder
+
 	flushCounts struct {
 		total      int
 		bufferSize int
@@ -68,6 +72,24 @@ func (b *BufferingAdder) SkipLocalDuplicates(skip bool) {
 	b.sink.skipDuplicates = skip
 }
 
+// DisallowShadowing configures whether the ingested keys may shadow existing
+// live values.
+func (b *BufferingAdder) DisallowShadowing(disallow bool) {
+	b.sink.disallowShadowing = disallow
+}
+
+// WriteAtRequestTimestamp configures whether the ingested keys are written at
+// the timestamp of the ingestion requests rather than that of the adder.
+func (b *BufferingAdder) WriteAtRequestTimestamp(write bool) {
+	b.sink.writeAtRequestTimestamp = write
+}
+
+// SetIngestRecorder configures a recorder which is notified of the spans
+// ingested by each flush.
+func (b *BufferingAdder) SetIngestRecorder(recorder storagebase.IngestRecorder) {
+	b.recorder = recorder
+}
+
 // Close closes the underlying SST builder.
 func (b *BufferingAdder) Close(ctx context.Context) {
 	log.VEventf(ctx, 2,
@@ -114,6 +136,19 @@ func (b *BufferingAdder) Flush(ctx context.Context) error {
 	sort.Sort(&b.curBuf)
 	mvccKey := engine.MVCCKey{Timestamp: b.timestamp}
 
+	var span roachpb.Span
+	if b.recorder != nil {
+		span = roachpb.Span{
+			Key:    append(roachpb.Key(nil), b.curBuf.Key(0)...),
+			EndKey: append(roachpb.Key(nil), b.curBuf.Key(b.curBuf.Len()-1)...).Next(),
+		}
+		if err := b.recorder.Ingesting(ctx, span); err != nil {
+			return err
+		}
+		// Drop the spans of a previous flush which failed.
+		b.sink.takeIngested()
+	}
+
 	for i := range b.curBuf.entries {
 		mvccKey.Key = b.curBuf.Key(i)
 		if err := b.sink.AddMVCCKey(ctx, mvccKey, b.curBuf.Value(i)); err != nil {
@@ -123,6 +158,11 @@ func (b *BufferingAdder) Flush(ctx context.Context) error {
 	if err := b.sink.Flush(ctx); err != nil {
 		return err
 	}
+	if b.recorder != nil {
+		if err := b.recorder.Ingested(ctx, span, b.sink.takeIngested()); err != nil {
+			return err
+		}
+	}
 
 	if log.V(3) {
 		written := b.sink.totalRows.DataSize - beforeSize
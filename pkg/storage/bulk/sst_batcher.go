@@ -21,6 +21,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/pkg/errors"
@@ -47,6 +48,13 @@ type SSTBatcher struct {
 
 	// skips duplicates (iff they are buffered together).
 	skipDuplicates bool
+	// disallowShadowing and writeAtRequestTimestamp are passed on to the
+	// AddSSTable requests, see roachpb.AddSSTableRequest.
+	disallowShadowing       bool
+	writeAtRequestTimestamp bool
+	// the spans ingested at request timestamps since the last call to
+	// takeIngested.
+	ingested []storagebase.IngestedSpan
 
 	maxSize int64
 	// rows written in the current batch.
@@ -180,7 +188,11 @@ func (b *SSTBatcher) Flush(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrapf(err, "finishing constructed sstable")
 	}
-	if err := AddSSTable(ctx, b.db, start, end, sstBytes); err != nil {
+	ingested, err := AddSSTable(
+		ctx, b.db, start, end, sstBytes, b.disallowShadowing, b.writeAtRequestTimestamp,
+	)
+	b.ingested = append(b.ingested, ingested...)
+	if err != nil {
 		return err
 	}
 	b.totalRows.Add(b.rowCounter.BulkOpSummary)
@@ -198,8 +210,27 @@ func (b *SSTBatcher) GetSummary() roachpb.BulkOpSummary {
 	return b.totalRows
 }
 
+// takeIngested returns the spans ingested at request timestamps since the
+// last call.
+func (b *SSTBatcher) takeIngested() []storagebase.IngestedSpan {
+	ingested := b.ingested
+	b.ingested = nil
+	return ingested
+}
+
 type sender interface {
-	AddSSTable(ctx context.Context, begin, end interface{}, data []byte) error
+	AddSSTable(
+		ctx context.Context,
+		begin, end interface{},
+		data []byte,
+		disallowShadowing, writeAtRequestTimestamp bool,
+	) error
+	AddSSTableAtRequestTimestamp(
+		ctx context.Context,
+		begin, end interface{},
+		data []byte,
+		disallowShadowing bool,
+	) (hlc.Timestamp, error)
 }
 
 type sstSpan struct {
@@ -210,7 +241,18 @@ type sstSpan struct {
 // AddSSTable retries db.AddSSTable if retryable errors occur, including if the
 // SST spans a split, in which case it is iterated and split into two SSTs, one
 // for each side of the split in the error, and each are retried.
-func AddSSTable(ctx context.Context, db sender, start, end roachpb.Key, sstBytes []byte) error {
+//
+// If writeAtRequestTimestamp is set, the spans of the requests which were, or
+// may have been, ingested are returned along with the timestamps at which
+// they were written, even if an error is returned.
+func AddSSTable(
+	ctx context.Context,
+	db sender,
+	start, end roachpb.Key,
+	sstBytes []byte,
+	disallowShadowing, writeAtRequestTimestamp bool,
+) ([]storagebase.IngestedSpan, error) {
+	var ingested []storagebase.IngestedSpan
 	work := []*sstSpan{{start: start, end: end, sstBytes: sstBytes}}
 	// Create an iterator that iterates over the top level SST to produce all the splits.
 	var iter engine.SimpleIterator
@@ -227,8 +269,24 @@ func AddSSTable(ctx context.Context, db sender, start, end roachpb.Key, sstBytes
 			var err error
 			for i := 0; i < maxAddSSTableRetries; i++ {
 				log.VEventf(ctx, 2, "sending %s AddSSTable [%s,%s)", sz(len(sstBytes)), start, end)
+				span := roachpb.Span{Key: item.start, EndKey: item.end}
 				// This will fail if the range has split but we'll check for that below.
-				err = db.AddSSTable(ctx, item.start, item.end, item.sstBytes)
+				if writeAtRequestTimestamp {
+					var ts hlc.Timestamp
+					ts, err = db.AddSSTableAtRequestTimestamp(
+						ctx, item.start, item.end, item.sstBytes, disallowShadowing,
+					)
+					if err == nil {
+						ingested = append(ingested, storagebase.IngestedSpan{
+							Span: span, Timestamp: ts,
+						})
+					}
+				} else {
+					err = db.AddSSTable(
+						ctx, item.start, item.end, item.sstBytes, disallowShadowing,
+						false, /* writeAtRequestTimestamp */
+					)
+				}
 				if err == nil {
 					return nil
 				}
@@ -253,19 +311,29 @@ func AddSSTable(ctx context.Context, db sender, start, end roachpb.Key, sstBytes
 				// Retry on AmbiguousResult.
 				if _, ok := err.(*roachpb.AmbiguousResultError); ok {
 					log.Warningf(ctx, "addsstable [%s,%s) attempt %d failed: %+v", start, end, i, err)
+					if writeAtRequestTimestamp {
+						// The SST may have been ingested, at an unknown timestamp.
+						ingested = append(ingested, storagebase.IngestedSpan{Span: span})
+					}
+					continue
+				}
+				// An SST written at the request timestamp can be retried at a higher
+				// one if newer versions of its keys were written concurrently.
+				if _, ok := err.(*roachpb.WriteTooOldError); ok && writeAtRequestTimestamp {
+					log.VEventf(ctx, 2, "addsstable [%s,%s) attempt %d failed: %+v", start, end, i, err)
 					continue
 				}
 			}
 			return errors.Wrapf(err, "addsstable [%s,%s)", item.start, item.end)
 		}(); err != nil {
-			return err
+			return ingested, err
 		}
 		// explicitly deallocate SST. This will not deallocate the
 		// top level SST which is kept around to iterate over.
 		item.sstBytes = nil
 	}
 
-	return nil
+	return ingested, nil
 }
 
 // createSplitSSTable is a helper for splitting up SSTs. The iterator
@@ -212,10 +212,18 @@ func runTestImport(t *testing.T, batchSize int64) {
 
 type mockSender func(span roachpb.Span) error
 
-func (m mockSender) AddSSTable(ctx context.Context, begin, end interface{}, data []byte) error {
+func (m mockSender) AddSSTable(
+	ctx context.Context, begin, end interface{}, data []byte, _, _ bool,
+) error {
 	return m(roachpb.Span{Key: begin.(roachpb.Key), EndKey: end.(roachpb.Key)})
 }
 
+func (m mockSender) AddSSTableAtRequestTimestamp(
+	ctx context.Context, begin, end interface{}, data []byte, _ bool,
+) (hlc.Timestamp, error) {
+	return hlc.Timestamp{}, m(roachpb.Span{Key: begin.(roachpb.Key), EndKey: end.(roachpb.Key)})
+}
+
 // TestAddBigSpanningSSTWithSplits tests a situation where a large
 // spanning SST is being ingested over a span with a lot of splits.
 func TestAddBigSpanningSSTWithSplits(t *testing.T) {
@@ -288,7 +296,9 @@ func TestAddBigSpanningSSTWithSplits(t *testing.T) {
 	const kb = 1 << 10
 
 	t.Logf("Adding %dkb sst spanning %d splits", len(sst)/kb, len(splits))
-	if err := bulk.AddSSTable(context.TODO(), mock, key(0), key(numKeys), sst); err != nil {
+	if _, err := bulk.AddSSTable(
+		context.TODO(), mock, key(0), key(numKeys), sst, false, false,
+	); err != nil {
 		t.Fatal(err)
 	}
 	t.Logf("Adding took %d total attempts", totalAdditionAttempts)
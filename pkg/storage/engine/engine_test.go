@@ -63,6 +63,9 @@ func runWithAllEngines(test func(e Engine, t *testing.T), t *testing.T) {
 	inMem := NewInMem(inMemAttrs, testCacheSize)
 	stopper.AddCloser(inMem)
 	test(inMem, t)
+	goLSM := NewInMemGoLSM(inMemAttrs, testCacheSize)
+	stopper.AddCloser(goLSM)
+	test(goLSM, t)
 }
 
 // TestEngineBatchCommit writes a batch containing 10K rows (all the
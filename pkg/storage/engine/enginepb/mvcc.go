@@ -130,6 +130,7 @@ func (ms *MVCCStats) Add(oms MVCCStats) {
 	ms.IntentCount += oms.IntentCount
 	ms.SysBytes += oms.SysBytes
 	ms.SysCount += oms.SysCount
+	ms.RangeTombstoneCount += oms.RangeTombstoneCount
 }
 
 // Subtract removes oms from ms. The ages will be moved forward to the larger of
@@ -154,6 +155,7 @@ func (ms *MVCCStats) Subtract(oms MVCCStats) {
 	ms.IntentCount -= oms.IntentCount
 	ms.SysBytes -= oms.SysBytes
 	ms.SysCount -= oms.SysCount
+	ms.RangeTombstoneCount -= oms.RangeTombstoneCount
 }
 
 // IsInline returns true if the value is inlined in the metadata.
@@ -0,0 +1,814 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/diskmap"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/lsm"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/pkg/errors"
+)
+
+// mvccComparer orders encoded MVCC keys by key and then by descending
+// timestamp, with the metadata key of a key sorting before its versions. It
+// must match libroach's DBComparator, whose name it shares.
+var mvccComparer = &lsm.Comparer{
+	Compare: func(a, b []byte) int {
+		keyA, tsA, okA := enginepb.SplitMVCCKey(a)
+		keyB, tsB, okB := enginepb.SplitMVCCKey(b)
+		if !okA || !okB {
+			// This should never happen unless there is some sort of corruption of
+			// the keys. The behavior matches engine/db.cc:DBComparator.
+			return bytes.Compare(a, b)
+		}
+		if c := bytes.Compare(keyA, keyB); c != 0 {
+			return c
+		}
+		if len(tsA) == 0 {
+			if len(tsB) == 0 {
+				return 0
+			}
+			return -1
+		} else if len(tsB) == 0 {
+			return 1
+		}
+		return bytes.Compare(tsB, tsA)
+	},
+	Name: "cockroach_comparator",
+}
+
+// The sstable properties holding the bounds of the timestamps of the keys in
+// an sstable. See timeBoundCollector.
+const (
+	timeBoundMinProp = "crdb.ts.min"
+	timeBoundMaxProp = "crdb.ts.max"
+)
+
+// encodeTimestamp encodes a timestamp in the format used by the timestamp
+// suffix of encoded MVCC keys.
+func encodeTimestamp(ts hlc.Timestamp) []byte {
+	var buf []byte
+	buf = encodeUint64(buf, uint64(ts.WallTime))
+	if ts.Logical != 0 {
+		buf = encodeUint32(buf, uint32(ts.Logical))
+	}
+	return buf
+}
+
+func encodeUint64(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func encodeUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+// timeBoundCollector records the minimum and maximum timestamps of the keys in
+// an sstable, which allows iterators with timestamp hints to skip sstables.
+// It is a port of libroach's TimeBoundTblPropCollector.
+type timeBoundCollector struct {
+	min, max  []byte
+	lastValue []byte
+}
+
+var _ lsm.TablePropertyCollector = &timeBoundCollector{}
+
+func (c *timeBoundCollector) Add(key []byte, kind lsm.Kind, value []byte) error {
+	_, ts, ok := enginepb.SplitMVCCKey(key)
+	if !ok {
+		return nil
+	}
+	if len(ts) > 0 {
+		c.lastValue = c.lastValue[:0]
+		c.updateBounds(ts)
+		return nil
+	}
+	c.lastValue = append(c.lastValue[:0], value...)
+	return nil
+}
+
+func (c *timeBoundCollector) Finish(userProps map[string]string) error {
+	if len(c.lastValue) > 0 {
+		// Check to see if an intent was the last key in the sstable. If it was,
+		// we need to extract the timestamp from the intent and update the bounds
+		// to include that timestamp.
+		var meta enginepb.MVCCMetadata
+		if err := protoutil.Unmarshal(c.lastValue, &meta); err != nil {
+			// We're unable to parse the MVCCMetadata. Fail open by not setting
+			// the min/max timestamp properties.
+			return nil
+		}
+		if meta.Txn != nil {
+			c.updateBounds(encodeTimestamp(hlc.Timestamp(meta.Timestamp)))
+		}
+	}
+	userProps[timeBoundMinProp] = string(c.min)
+	userProps[timeBoundMaxProp] = string(c.max)
+	return nil
+}
+
+func (c *timeBoundCollector) updateBounds(ts []byte) {
+	if len(c.max) == 0 || bytes.Compare(ts, c.max) > 0 {
+		c.max = append(c.max[:0], ts...)
+	}
+	if len(c.min) == 0 || bytes.Compare(ts, c.min) < 0 {
+		c.min = append(c.min[:0], ts...)
+	}
+}
+
+// GoLSMConfig holds all configuration parameters and knobs used in setting up
+// a new GoLSM instance.
+type GoLSMConfig struct {
+	Attrs roachpb.Attributes
+	// Dir is the data directory for this store. An empty Dir denotes an
+	// in-memory instance.
+	Dir string
+	// If true, creating the instance fails if the target directory does not hold
+	// an initialized instance.
+	//
+	// Makes no sense for in-memory instances.
+	MustExist bool
+	// MaxSizeBytes is used for calculating free space and making rebalancing
+	// decisions. Zero indicates that there is no maximum size.
+	MaxSizeBytes int64
+	// CacheSize is the size in bytes of the block cache.
+	CacheSize int64
+	// Settings instance for cluster-wide knobs.
+	Settings *cluster.Settings
+}
+
+// GoLSM is an Engine backed by the pure Go log-structured merge tree of
+// package lsm. It stores data in the same MVCC format as RocksDB, but its
+// files are not compatible with RocksDB.
+type GoLSM struct {
+	cfg GoLSMConfig
+	fs  lsm.FS
+	db  *lsm.DB
+	// auxDir is used for storing auxiliary files. Ideally it is a subdirectory of Dir.
+	auxDir string
+	closed bool
+}
+
+var _ WithSSTables = &GoLSM{}
+var _ MapProvidingEngine = &GoLSM{}
+
+// NewGoLSM allocates and returns a new GoLSM engine. If the database doesn't
+// yet exist at the specified directory, one is initialized from scratch. The
+// caller must call the engine's Close method when the engine is no longer
+// needed.
+func NewGoLSM(cfg GoLSMConfig) (*GoLSM, error) {
+	g := &GoLSM{cfg: cfg}
+	if cfg.Dir == "" {
+		if log.V(2) {
+			log.Infof(context.TODO(), "opening in memory golsm instance")
+		}
+		g.fs = lsm.NewMemFS()
+		// As for in-memory RocksDB instances, the auxiliary directory is a
+		// temporary directory on disk, while the files created through the
+		// engine are stored in memory.
+		auxDir, err := ioutil.TempDir(os.TempDir(), "cockroach-auxiliary")
+		if err != nil {
+			return nil, err
+		}
+		g.auxDir = auxDir
+	} else {
+		log.Infof(context.TODO(), "opening golsm instance at %q", cfg.Dir)
+		g.fs = lsm.DefaultFS
+		g.auxDir = filepath.Join(cfg.Dir, "auxiliary")
+	}
+
+	db, err := lsm.Open(cfg.Dir, &lsm.Options{
+		Comparer:         mvccComparer,
+		Merger:           mvccMerger,
+		FS:               g.fs,
+		ErrorIfNotExists: cfg.MustExist && cfg.Dir != "",
+		BlockCacheSize:   cfg.CacheSize,
+		TablePropertyCollectors: []func() lsm.TablePropertyCollector{
+			func() lsm.TablePropertyCollector { return &timeBoundCollector{} },
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open golsm instance")
+	}
+	g.db = db
+	if err := os.MkdirAll(g.auxDir, 0755); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return g, nil
+}
+
+// NewInMemGoLSM allocates and returns a new, opened in-memory GoLSM engine.
+// The caller must call the engine's Close method when the engine is no longer
+// needed.
+func NewInMemGoLSM(attrs roachpb.Attributes, cacheSize int64) *GoLSM {
+	// The hard-coded 512 MiB mirrors NewInMem; see
+	// https://github.com/cockroachdb/cockroach/issues/16750
+	g, err := NewGoLSM(GoLSMConfig{
+		Attrs:        attrs,
+		MaxSizeBytes: 512 << 20, /* 512 MiB */
+		CacheSize:    cacheSize,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// String formatter.
+func (g *GoLSM) String() string {
+	dir := g.cfg.Dir
+	if g.cfg.Dir == "" {
+		dir = "<in-mem>"
+	}
+	attrs := g.Attrs().String()
+	if attrs == "" {
+		attrs = "<no-attributes>"
+	}
+	return fmt.Sprintf("%s=%s", attrs, dir)
+}
+
+// Close closes the database.
+func (g *GoLSM) Close() {
+	if g.closed {
+		log.Errorf(context.TODO(), "closing unopened golsm instance")
+		return
+	}
+	if len(g.cfg.Dir) == 0 {
+		if log.V(1) {
+			log.Infof(context.TODO(), "closing in-memory golsm instance")
+		}
+		// Remove the temporary directory when the engine is in-memory.
+		if err := os.RemoveAll(g.auxDir); err != nil {
+			log.Warning(context.TODO(), err)
+		}
+	} else {
+		log.Infof(context.TODO(), "closing golsm instance at %q", g.cfg.Dir)
+	}
+	g.closed = true
+	if err := g.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+// Closed returns true if the engine is closed.
+func (g *GoLSM) Closed() bool {
+	return g.closed
+}
+
+// Attrs returns the list of attributes describing this engine.
+func (g *GoLSM) Attrs() roachpb.Attributes {
+	return g.cfg.Attrs
+}
+
+// Get returns the value for the given key, nil otherwise.
+func (g *GoLSM) Get(key MVCCKey) ([]byte, error) {
+	return golsmGet(g.db.Get, key)
+}
+
+// GetProto fetches the value at the specified key and unmarshals it.
+func (g *GoLSM) GetProto(
+	key MVCCKey, msg protoutil.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	return golsmGetProto(g.db.Get, key, msg)
+}
+
+// Iterate iterates from start to end keys, invoking f on each key/value pair.
+// See engine.Iterate for details.
+func (g *GoLSM) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)) error {
+	return golsmIterate(g.db.NewIter, g, start, end, f)
+}
+
+// NewIterator returns an iterator over this engine.
+func (g *GoLSM) NewIterator(opts IterOptions) Iterator {
+	return newGoLSMIterator(g.db.NewIter, opts, g)
+}
+
+// ApplyBatchRepr atomically applies a set of batched updates. Created by
+// calling Repr() on a batch. Using this method is equivalent to constructing
+// and committing a batch whose Repr() equals repr.
+func (g *GoLSM) ApplyBatchRepr(repr []byte, sync bool) error {
+	b := g.db.NewBatch()
+	if err := b.Apply(repr); err != nil {
+		return err
+	}
+	return b.Commit(sync)
+}
+
+// Clear removes the item from the db with the given key.
+func (g *GoLSM) Clear(key MVCCKey) error {
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	return g.db.Delete(EncodeKey(key), false /* sync */)
+}
+
+// SingleClear removes the most recent item from the db with the given key.
+func (g *GoLSM) SingleClear(key MVCCKey) error {
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	b := g.db.NewBatch()
+	b.SingleDelete(EncodeKey(key))
+	return b.Commit(false /* sync */)
+}
+
+// ClearRange removes a set of entries, from start (inclusive) to end
+// (exclusive).
+func (g *GoLSM) ClearRange(start, end MVCCKey) error {
+	return g.db.DeleteRange(EncodeKey(start), EncodeKey(end), false /* sync */)
+}
+
+// ClearIterRange removes a set of entries, from start (inclusive) to end
+// (exclusive).
+func (g *GoLSM) ClearIterRange(iter Iterator, start, end MVCCKey) error {
+	b := g.db.NewBatch()
+	if err := golsmClearIterRange(b, iter, start, end); err != nil {
+		return err
+	}
+	return b.Commit(false /* sync */)
+}
+
+// Merge implements the merge operator of libroach (see golsm_merge.go).
+func (g *GoLSM) Merge(key MVCCKey, value []byte) error {
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	return g.db.Merge(EncodeKey(key), value, false /* sync */)
+}
+
+// Put sets the given key to the value provided.
+func (g *GoLSM) Put(key MVCCKey, value []byte) error {
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	return g.db.Set(EncodeKey(key), value, false /* sync */)
+}
+
+// LogData is part of the Writer interface.
+func (g *GoLSM) LogData(data []byte) error {
+	b := g.db.NewBatch()
+	b.LogData(data)
+	return b.Commit(false /* sync */)
+}
+
+// LogLogicalOp is part of the Writer interface.
+func (g *GoLSM) LogLogicalOp(op MVCCLogicalOpType, details MVCCLogicalOpDetails) {
+	// No-op. Logical logging disabled.
+}
+
+// Capacity queries the underlying file system for disk capacity information.
+func (g *GoLSM) Capacity() (roachpb.StoreCapacity, error) {
+	return computeCapacity(g.cfg.Dir, g.cfg.MaxSizeBytes)
+}
+
+// Flush causes the engine to write all in-memory data to disk immediately.
+func (g *GoLSM) Flush() error {
+	return g.db.Flush()
+}
+
+// GetStats retrieves stats from the engine.
+func (g *GoLSM) GetStats() (*Stats, error) {
+	m := g.db.Metrics()
+	return &Stats{
+		BlockCacheHits:                 m.BlockCacheHits,
+		BlockCacheMisses:               m.BlockCacheMisses,
+		BlockCacheUsage:                m.BlockCacheSize,
+		MemtableTotalSize:              m.MemTableSize,
+		Flushes:                        m.Flushes,
+		Compactions:                    m.Compactions,
+		PendingCompactionBytesEstimate: int64(m.PendingCompactionBytes),
+		L0FileCount:                    int64(m.Levels[0].NumFiles),
+	}, nil
+}
+
+// GetEnvStats returns stats for the engine's file system. Encryption is not
+// supported by GoLSM.
+func (g *GoLSM) GetEnvStats() (*EnvStats, error) {
+	m := g.db.Metrics()
+	var stats EnvStats
+	for _, level := range m.Levels {
+		stats.TotalFiles += uint64(level.NumFiles)
+		stats.TotalBytes += level.Size
+	}
+	return &stats, nil
+}
+
+// GetSSTables retrieves metadata about this engine's live sstables.
+func (g *GoLSM) GetSSTables() SSTableInfos {
+	tables := g.db.SSTables()
+	res := make(SSTableInfos, 0, len(tables))
+	for _, t := range tables {
+		start, err := DecodeMVCCKey(t.Smallest)
+		if err != nil {
+			continue
+		}
+		end, err := DecodeMVCCKey(t.Largest)
+		if err != nil {
+			continue
+		}
+		res = append(res, SSTableInfo{
+			Level: t.Level,
+			Size:  int64(t.Size),
+			Start: start,
+			End:   end,
+		})
+	}
+	sort.Sort(res)
+	return res
+}
+
+// GetAuxiliaryDir returns the auxiliary storage path for this engine.
+func (g *GoLSM) GetAuxiliaryDir() string {
+	return g.auxDir
+}
+
+// NewBatch returns a new batch wrapping this engine.
+func (g *GoLSM) NewBatch() Batch {
+	return newGoLSMBatch(g, false /* writeOnly */)
+}
+
+// NewReadOnly returns a new ReadWriter wrapping this engine.
+func (g *GoLSM) NewReadOnly() ReadWriter {
+	return &golsmReadOnly{parent: g}
+}
+
+// NewWriteOnlyBatch returns a new write-only batch wrapping this engine.
+func (g *GoLSM) NewWriteOnlyBatch() Batch {
+	return newGoLSMBatch(g, true /* writeOnly */)
+}
+
+// NewSnapshot creates a snapshot handle from engine and returns a read-only
+// snapshot engine.
+func (g *GoLSM) NewSnapshot() Reader {
+	return &golsmSnapshot{parent: g, snap: g.db.NewSnapshot()}
+}
+
+// IngestExternalFiles atomically adds a slice of sstables written by
+// RocksDBSstFileWriter to the engine. The sstables are rewritten in the
+// format of the engine before being ingested, since it cannot read RocksDB
+// sstables directly.
+func (g *GoLSM) IngestExternalFiles(
+	ctx context.Context, paths []string, skipWritingSeqNo, allowFileModifications bool,
+) error {
+	tmpPaths := make([]string, 0, len(paths))
+	defer func() {
+		for _, p := range tmpPaths {
+			if err := g.fs.Remove(p); err != nil {
+				log.Warningf(ctx, "failed to remove %s: %+v", p, err)
+			}
+		}
+	}()
+	if err := g.fs.MkdirAll(g.auxDir, 0755); err != nil {
+		return err
+	}
+	for i, path := range paths {
+		tmpPath := filepath.Join(g.auxDir, fmt.Sprintf("ingest-%d.sst", i))
+		if err := g.rewriteSST(path, tmpPath); err != nil {
+			return errors.Wrapf(err, "rewriting %s", path)
+		}
+		tmpPaths = append(tmpPaths, tmpPath)
+	}
+	if err := g.db.Ingest(tmpPaths); err != nil {
+		return err
+	}
+	if allowFileModifications {
+		// RocksDB moves ingested files into the store. Remove the originals to
+		// match.
+		for _, path := range paths {
+			if err := g.fs.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rewriteSST copies the keys of the RocksDB sstable at src into an sstable
+// written in the format of the engine at dst.
+func (g *GoLSM) rewriteSST(src, dst string) error {
+	data, err := g.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	iter, err := NewMemSSTIterator(data, false /* verify */)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	f, err := g.fs.Create(dst)
+	if err != nil {
+		return err
+	}
+	w := lsm.NewTableWriter(f, &lsm.Options{
+		Comparer: mvccComparer,
+		Merger:   mvccMerger,
+		TablePropertyCollectors: []func() lsm.TablePropertyCollector{
+			func() lsm.TablePropertyCollector { return &timeBoundCollector{} },
+		},
+	})
+	for iter.Seek(MVCCKey{}); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			_ = w.Close()
+			return err
+		} else if !ok {
+			break
+		}
+		if err := w.Set(EncodeKey(iter.UnsafeKey()), iter.UnsafeValue()); err != nil {
+			_ = w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// PreIngestDelay may choose to block for some duration if L0 has an excessive
+// number of files in it or if PendingCompactionBytesEstimate is elevated. See
+// RocksDB.PreIngestDelay.
+func (g *GoLSM) PreIngestDelay(ctx context.Context) {
+	preIngestDelay(ctx, g, g.cfg.Settings)
+}
+
+// ApproximateDiskBytes returns the approximate on-disk size of the specified
+// key range.
+func (g *GoLSM) ApproximateDiskBytes(from, to roachpb.Key) (uint64, error) {
+	return g.db.EstimateDiskUsage(EncodeKey(MVCCKey{Key: from}), EncodeKey(MVCCKey{Key: to})), nil
+}
+
+// Compact forces compaction over the entire database.
+func (g *GoLSM) Compact() error {
+	return g.db.Compact(nil, nil)
+}
+
+// CompactRange forces compaction over a specified range of keys in the
+// database. GoLSM always compacts the range into the bottommost level.
+func (g *GoLSM) CompactRange(start, end roachpb.Key, forceBottommost bool) error {
+	var encStart, encEnd []byte
+	if len(start) > 0 {
+		encStart = EncodeKey(MakeMVCCMetadataKey(start))
+	}
+	if len(end) > 0 {
+		encEnd = EncodeKey(MakeMVCCMetadataKey(end))
+	}
+	return g.db.Compact(encStart, encEnd)
+}
+
+// WriteFile writes data to a file in this engine's file system.
+func (g *GoLSM) WriteFile(filename string, data []byte) error {
+	if err := g.fs.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	f, err := g.fs.Create(filename)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// OpenFile opens a DBFile with the given filename in this engine's file
+// system.
+func (g *GoLSM) OpenFile(filename string) (DBFile, error) {
+	if err := g.fs.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, err
+	}
+	f, err := g.fs.Create(filename)
+	if err != nil {
+		return nil, notExistErrOrDefault(err)
+	}
+	return &golsmFile{file: f}, nil
+}
+
+// ReadFile reads the content from a file with the given filename. The file
+// must have been opened through Engine.OpenFile. Otherwise an error will be
+// returned.
+func (g *GoLSM) ReadFile(filename string) ([]byte, error) {
+	f, err := g.fs.Open(filename)
+	if err != nil {
+		return nil, notExistErrOrDefault(err)
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// DeleteFile deletes the file with the given filename from this engine's file
+// system. If the file with given filename doesn't exist, return
+// os.ErrNotExist.
+func (g *GoLSM) DeleteFile(filename string) error {
+	if err := g.fs.Remove(filename); err != nil {
+		return notExistErrOrDefault(err)
+	}
+	return nil
+}
+
+// DeleteDirAndFiles deletes the directory and any files it contains but not
+// subdirectories from this engine's file system. If dir does not exist,
+// DeleteDirAndFiles returns nil (no error).
+func (g *GoLSM) DeleteDirAndFiles(dir string) error {
+	names, err := g.fs.List(dir)
+	if err != nil {
+		if notExistErrOrDefault(err) == os.ErrNotExist {
+			return nil
+		}
+		return err
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		info, err := g.fs.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			continue
+		}
+		if err := g.fs.Remove(path); err != nil {
+			return err
+		}
+	}
+	if err := g.fs.Remove(dir); err != nil && notExistErrOrDefault(err) != os.ErrNotExist {
+		return err
+	}
+	return nil
+}
+
+// LinkFile creates 'newname' as a hard link to 'oldname'.
+func (g *GoLSM) LinkFile(oldname, newname string) error {
+	if err := g.fs.Link(oldname, newname); err != nil {
+		return &os.LinkError{
+			Op:  "link",
+			Old: oldname,
+			New: newname,
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// CreateCheckpoint creates a checkpoint of the engine in the given directory
+// (which must not exist).
+func (g *GoLSM) CreateCheckpoint(dir string) error {
+	return errors.Wrap(g.db.Checkpoint(dir), "unable to take golsm checkpoint")
+}
+
+// NewSortedDiskMap implements the MapProvidingEngine interface.
+func (g *GoLSM) NewSortedDiskMap() diskmap.SortedDiskMap {
+	return NewRocksDBMap(g)
+}
+
+// NewSortedDiskMultiMap implements the MapProvidingEngine interface.
+func (g *GoLSM) NewSortedDiskMultiMap() diskmap.SortedDiskMap {
+	return NewRocksDBMultiMap(g)
+}
+
+// notExistErrOrDefault returns os.ErrNotExist if err denotes a missing file,
+// and err otherwise.
+func notExistErrOrDefault(err error) error {
+	if os.IsNotExist(errors.Cause(err)) {
+		return os.ErrNotExist
+	}
+	return err
+}
+
+// golsmFile implements the DBFile interface on top of an lsm.File.
+type golsmFile struct {
+	file lsm.File
+}
+
+var _ DBFile = &golsmFile{}
+
+// Append implements the DBFile interface.
+func (f *golsmFile) Append(data []byte) error {
+	_, err := f.file.Write(data)
+	return err
+}
+
+// Close implements the DBFile interface.
+func (f *golsmFile) Close() error {
+	return f.file.Close()
+}
+
+// Sync implements the DBFile interface.
+func (f *golsmFile) Sync() error {
+	return f.file.Sync()
+}
+
+// golsmGet returns the value for the given key using get, which is the Get
+// method of an lsm.DB or lsm.Snapshot.
+func golsmGet(get func([]byte) ([]byte, error), key MVCCKey) ([]byte, error) {
+	if len(key.Key) == 0 {
+		return nil, emptyKeyError()
+	}
+	value, err := get(EncodeKey(key))
+	if err == lsm.ErrNotFound {
+		return nil, nil
+	}
+	return value, err
+}
+
+func golsmGetProto(
+	get func([]byte) ([]byte, error), key MVCCKey, msg protoutil.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	var value []byte
+	if value, err = golsmGet(get, key); err != nil {
+		return
+	}
+	if len(value) == 0 {
+		if msg != nil {
+			msg.Reset()
+		}
+		return
+	}
+	ok = true
+	if msg != nil {
+		err = protoutil.Unmarshal(value, msg)
+	}
+	keyBytes = int64(key.EncodedSize())
+	valBytes = int64(len(value))
+	return
+}
+
+func golsmIterate(
+	newIter func(*lsm.IterOptions) *lsm.Iterator,
+	engine Reader,
+	start, end MVCCKey,
+	f func(MVCCKeyValue) (bool, error),
+) error {
+	if !start.Less(end) {
+		return nil
+	}
+	it := newGoLSMIterator(newIter, IterOptions{UpperBound: end.Key}, engine)
+	defer it.Close()
+
+	it.Seek(start)
+	for ; ; it.Next() {
+		ok, err := it.Valid()
+		if err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+		k := it.Key()
+		if !k.Less(end) {
+			break
+		}
+		if done, err := f(MVCCKeyValue{Key: k, Value: it.Value()}); done || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// golsmClearIterRange adds deletions of the keys in [start, end) found by
+// iter to b.
+func golsmClearIterRange(b *lsm.Batch, iter Iterator, start, end MVCCKey) error {
+	for iter.Seek(start); ; iter.Next() {
+		ok, err := iter.Valid()
+		if err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+		key := iter.UnsafeKey()
+		if !key.Less(end) {
+			break
+		}
+		b.Delete(EncodeKey(key))
+	}
+	return nil
+}
@@ -0,0 +1,562 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"bytes"
+
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/lsm"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+)
+
+// golsmReusableIterator wraps golsmIterator and allows reuse of an iterator
+// for the lifetime of a batch or read-only engine.
+type golsmReusableIterator struct {
+	*golsmIterator
+	inuse bool
+}
+
+// get returns the iterator, creating it with newIter on first access.
+func (r *golsmReusableIterator) get(
+	newIter func(*lsm.IterOptions) *lsm.Iterator, opts IterOptions, engine Reader,
+) Iterator {
+	if r.golsmIterator == nil {
+		r.golsmIterator = newGoLSMIterator(newIter, opts, engine)
+	} else {
+		r.golsmIterator.setOptions(opts)
+	}
+	if r.inuse {
+		panic("iterator already in use")
+	}
+	r.inuse = true
+	return r
+}
+
+func (r *golsmReusableIterator) Close() {
+	// golsmReusableIterator.Close() leaves the underlying iterator open until
+	// the associated batch is closed.
+	if !r.inuse {
+		panic("closing idle iterator")
+	}
+	r.inuse = false
+}
+
+func (r *golsmReusableIterator) destroy() {
+	if r.golsmIterator != nil {
+		r.golsmIterator.destroy()
+		r.golsmIterator = nil
+	}
+	r.inuse = false
+}
+
+// batchGet returns a function which reads the value of a key from an indexed
+// batch, for use with golsmGet.
+func batchGet(b *lsm.Batch) func([]byte) ([]byte, error) {
+	return func(key []byte) ([]byte, error) {
+		it := b.NewIterAtCurrentCount(&lsm.IterOptions{LowerBound: key})
+		if it.SeekGE(key) && bytes.Equal(it.Key(), key) {
+			value := append([]byte(nil), it.Value()...)
+			return value, it.Close()
+		}
+		if err := it.Close(); err != nil {
+			return nil, err
+		}
+		return nil, lsm.ErrNotFound
+	}
+}
+
+type golsmReadOnly struct {
+	parent     *GoLSM
+	prefixIter golsmReusableIterator
+	normalIter golsmReusableIterator
+	isClosed   bool
+}
+
+var _ ReadWriter = &golsmReadOnly{}
+
+func (r *golsmReadOnly) Close() {
+	if r.isClosed {
+		panic("closing an already-closed golsmReadOnly")
+	}
+	r.isClosed = true
+	r.prefixIter.destroy()
+	r.normalIter.destroy()
+}
+
+// Read-only batches are not committed
+func (r *golsmReadOnly) Closed() bool {
+	return r.isClosed
+}
+
+func (r *golsmReadOnly) Get(key MVCCKey) ([]byte, error) {
+	if r.isClosed {
+		panic("using a closed golsmReadOnly")
+	}
+	return r.parent.Get(key)
+}
+
+func (r *golsmReadOnly) GetProto(
+	key MVCCKey, msg protoutil.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	if r.isClosed {
+		panic("using a closed golsmReadOnly")
+	}
+	return r.parent.GetProto(key, msg)
+}
+
+func (r *golsmReadOnly) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)) error {
+	if r.isClosed {
+		panic("using a closed golsmReadOnly")
+	}
+	return golsmIterate(r.parent.db.NewIter, r, start, end, f)
+}
+
+// NewIterator returns an iterator over the underlying engine. Note that the
+// returned iterator is cached and re-used for the lifetime of the
+// golsmReadOnly. A panic will be thrown if multiple prefix or normal
+// (non-prefix) iterators are used simultaneously on the same golsmReadOnly.
+func (r *golsmReadOnly) NewIterator(opts IterOptions) Iterator {
+	if r.isClosed {
+		panic("using a closed golsmReadOnly")
+	}
+	if opts.MinTimestampHint != (hlc.Timestamp{}) {
+		// Iterators that specify timestamp bounds cannot be cached.
+		return newGoLSMIterator(r.parent.db.NewIter, opts, r)
+	}
+	iter := &r.normalIter
+	if opts.Prefix {
+		iter = &r.prefixIter
+	}
+	return iter.get(r.parent.db.NewIter, opts, r)
+}
+
+// Writer methods are not implemented for golsmReadOnly, as for
+// rocksDBReadOnly.
+
+func (r *golsmReadOnly) ApplyBatchRepr(repr []byte, sync bool) error {
+	panic("not implemented")
+}
+
+func (r *golsmReadOnly) Clear(key MVCCKey) error {
+	panic("not implemented")
+}
+
+func (r *golsmReadOnly) SingleClear(key MVCCKey) error {
+	panic("not implemented")
+}
+
+func (r *golsmReadOnly) ClearRange(start, end MVCCKey) error {
+	panic("not implemented")
+}
+
+func (r *golsmReadOnly) ClearIterRange(iter Iterator, start, end MVCCKey) error {
+	panic("not implemented")
+}
+
+func (r *golsmReadOnly) Merge(key MVCCKey, value []byte) error {
+	panic("not implemented")
+}
+
+func (r *golsmReadOnly) Put(key MVCCKey, value []byte) error {
+	panic("not implemented")
+}
+
+func (r *golsmReadOnly) LogData(data []byte) error {
+	panic("not implemented")
+}
+
+func (r *golsmReadOnly) LogLogicalOp(op MVCCLogicalOpType, details MVCCLogicalOpDetails) {
+	panic("not implemented")
+}
+
+type golsmSnapshot struct {
+	parent *GoLSM
+	snap   *lsm.Snapshot
+}
+
+var _ Reader = &golsmSnapshot{}
+
+// Close releases the snapshot.
+func (r *golsmSnapshot) Close() {
+	_ = r.snap.Close()
+	r.snap = nil
+}
+
+// Closed returns true if the snapshot is closed.
+func (r *golsmSnapshot) Closed() bool {
+	return r.snap == nil
+}
+
+// Get returns the value for the given key, nil otherwise, as of the snapshot.
+func (r *golsmSnapshot) Get(key MVCCKey) ([]byte, error) {
+	return golsmGet(r.snap.Get, key)
+}
+
+func (r *golsmSnapshot) GetProto(
+	key MVCCKey, msg protoutil.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	return golsmGetProto(r.snap.Get, key, msg)
+}
+
+// Iterate iterates over the keys between start inclusive and end exclusive,
+// invoking f() on each key/value pair as of the snapshot.
+func (r *golsmSnapshot) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)) error {
+	return golsmIterate(r.snap.NewIter, r, start, end, f)
+}
+
+// NewIterator returns a new instance of an Iterator over the engine as of the
+// snapshot.
+func (r *golsmSnapshot) NewIterator(opts IterOptions) Iterator {
+	return newGoLSMIterator(r.snap.NewIter, opts, r)
+}
+
+// golsmBatch implements Batch on top of an lsm.Batch. Readable batches use an
+// indexed lsm.Batch, which is read from together with the DB.
+type golsmBatch struct {
+	parent       *GoLSM
+	batch        *lsm.Batch
+	prefixIter   golsmReusableIterator
+	normalIter   golsmReusableIterator
+	distinct     golsmDistinctBatch
+	distinctOpen bool
+	writeOnly    bool
+	closed       bool
+	committed    bool
+}
+
+var _ Batch = &golsmBatch{}
+
+func newGoLSMBatch(parent *GoLSM, writeOnly bool) *golsmBatch {
+	r := &golsmBatch{parent: parent, writeOnly: writeOnly}
+	if writeOnly {
+		r.batch = parent.db.NewBatch()
+	} else {
+		r.batch = parent.db.NewIndexedBatch()
+	}
+	r.distinct.parent = r
+	return r
+}
+
+func (r *golsmBatch) Close() {
+	if r.closed {
+		panic("this batch was already closed")
+	}
+	r.distinct.close()
+	r.prefixIter.destroy()
+	r.normalIter.destroy()
+	r.closed = true
+}
+
+// Closed returns true if the batch is closed or committed.
+func (r *golsmBatch) Closed() bool {
+	return r.closed || r.committed
+}
+
+func (r *golsmBatch) Put(key MVCCKey, value []byte) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.batch.Set(EncodeKey(key), value)
+	return nil
+}
+
+func (r *golsmBatch) Merge(key MVCCKey, value []byte) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.batch.Merge(EncodeKey(key), value)
+	return nil
+}
+
+func (r *golsmBatch) LogData(data []byte) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.batch.LogData(data)
+	return nil
+}
+
+// ApplyBatchRepr atomically applies a set of batched updates to the current
+// batch (the receiver).
+func (r *golsmBatch) ApplyBatchRepr(repr []byte, sync bool) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	return r.batch.Apply(repr)
+}
+
+func (r *golsmBatch) Get(key MVCCKey) ([]byte, error) {
+	if r.writeOnly {
+		panic("write-only batch")
+	}
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	return golsmGet(batchGet(r.batch), key)
+}
+
+func (r *golsmBatch) GetProto(
+	key MVCCKey, msg protoutil.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	if r.writeOnly {
+		panic("write-only batch")
+	}
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	return golsmGetProto(batchGet(r.batch), key, msg)
+}
+
+func (r *golsmBatch) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)) error {
+	if r.writeOnly {
+		panic("write-only batch")
+	}
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	return golsmIterate(r.batch.NewIter, r, start, end, f)
+}
+
+func (r *golsmBatch) Clear(key MVCCKey) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.batch.Delete(EncodeKey(key))
+	return nil
+}
+
+func (r *golsmBatch) SingleClear(key MVCCKey) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.batch.SingleDelete(EncodeKey(key))
+	return nil
+}
+
+func (r *golsmBatch) ClearRange(start, end MVCCKey) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.batch.DeleteRange(EncodeKey(start), EncodeKey(end))
+	return nil
+}
+
+func (r *golsmBatch) ClearIterRange(iter Iterator, start, end MVCCKey) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	return golsmClearIterRange(r.batch, iter, start, end)
+}
+
+func (r *golsmBatch) LogLogicalOp(op MVCCLogicalOpType, details MVCCLogicalOpDetails) {
+	// No-op. Logical logging disabled.
+}
+
+// NewIterator returns an iterator over the batch and underlying engine. Note
+// that the returned iterator is cached and re-used for the lifetime of the
+// batch. A panic will be thrown if multiple prefix or normal (non-prefix)
+// iterators are used simultaneously on the same batch.
+func (r *golsmBatch) NewIterator(opts IterOptions) Iterator {
+	if r.writeOnly {
+		panic("write-only batch")
+	}
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	if opts.MinTimestampHint != (hlc.Timestamp{}) {
+		// Iterators that specify timestamp bounds cannot be cached.
+		iter := newGoLSMIterator(r.batch.NewIter, opts, r)
+		iter.forwardOnly = true
+		return iter
+	}
+	iter := &r.normalIter
+	if opts.Prefix {
+		iter = &r.prefixIter
+	}
+	res := iter.get(r.batch.NewIter, opts, r)
+	iter.forwardOnly = true
+	return res
+}
+
+func (r *golsmBatch) Commit(sync bool) error {
+	if r.Closed() {
+		panic("this batch was already committed")
+	}
+	r.distinct.flush()
+	r.distinctOpen = false
+
+	if r.batch.Empty() {
+		// Nothing was written to this batch. Fast path.
+		r.committed = true
+		return nil
+	}
+	if err := r.batch.Commit(sync); err != nil {
+		return err
+	}
+	r.committed = true
+	return nil
+}
+
+func (r *golsmBatch) Empty() bool {
+	return r.batch.Empty() && r.distinct.batch.Empty()
+}
+
+func (r *golsmBatch) Len() int {
+	r.distinct.flush()
+	return r.batch.Len()
+}
+
+func (r *golsmBatch) Repr() []byte {
+	r.distinct.flush()
+	return append([]byte(nil), r.batch.Repr()...)
+}
+
+func (r *golsmBatch) Distinct() ReadWriter {
+	if r.distinctOpen {
+		panic("distinct batch already open")
+	}
+	r.distinctOpen = true
+	return &r.distinct
+}
+
+// golsmDistinctBatch is the distinct batch of a golsmBatch. Its writes are
+// buffered separately and added to the parent batch when the distinct batch
+// is closed, so that its reads do not observe them.
+type golsmDistinctBatch struct {
+	parent     *golsmBatch
+	batch      lsm.Batch
+	prefixIter golsmReusableIterator
+	normalIter golsmReusableIterator
+}
+
+var _ ReadWriter = &golsmDistinctBatch{}
+
+func (r *golsmDistinctBatch) Close() {
+	if !r.parent.distinctOpen {
+		panic("distinct batch not open")
+	}
+	r.flush()
+	r.parent.distinctOpen = false
+}
+
+// Closed returns true if the parent batch is closed or committed.
+func (r *golsmDistinctBatch) Closed() bool {
+	return r.parent.Closed()
+}
+
+// flush adds the buffered writes of the distinct batch to the parent batch.
+func (r *golsmDistinctBatch) flush() {
+	if r.batch.Empty() {
+		return
+	}
+	if err := r.parent.batch.Apply(r.batch.Repr()); err != nil {
+		panic(err)
+	}
+	r.batch.Reset()
+}
+
+func (r *golsmDistinctBatch) newIter() func(*lsm.IterOptions) *lsm.Iterator {
+	if r.parent.writeOnly {
+		return r.parent.parent.db.NewIter
+	}
+	return r.parent.batch.NewIter
+}
+
+// NewIterator returns an iterator over the batch and underlying engine. Note
+// that the returned iterator is cached and re-used for the lifetime of the
+// batch. A panic will be thrown if multiple prefix or normal (non-prefix)
+// iterators are used simultaneously on the same batch.
+func (r *golsmDistinctBatch) NewIterator(opts IterOptions) Iterator {
+	if opts.MinTimestampHint != (hlc.Timestamp{}) {
+		// Iterators that specify timestamp bounds cannot be cached.
+		return newGoLSMIterator(r.newIter(), opts, r)
+	}
+	iter := &r.normalIter
+	if opts.Prefix {
+		iter = &r.prefixIter
+	}
+	return iter.get(r.newIter(), opts, r)
+}
+
+func (r *golsmDistinctBatch) Get(key MVCCKey) ([]byte, error) {
+	if r.parent.writeOnly {
+		return r.parent.parent.Get(key)
+	}
+	return golsmGet(batchGet(r.parent.batch), key)
+}
+
+func (r *golsmDistinctBatch) GetProto(
+	key MVCCKey, msg protoutil.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	if r.parent.writeOnly {
+		return r.parent.parent.GetProto(key, msg)
+	}
+	return golsmGetProto(batchGet(r.parent.batch), key, msg)
+}
+
+func (r *golsmDistinctBatch) Iterate(
+	start, end MVCCKey, f func(MVCCKeyValue) (bool, error),
+) error {
+	return golsmIterate(r.newIter(), r, start, end, f)
+}
+
+func (r *golsmDistinctBatch) Put(key MVCCKey, value []byte) error {
+	r.batch.Set(EncodeKey(key), value)
+	return nil
+}
+
+func (r *golsmDistinctBatch) Merge(key MVCCKey, value []byte) error {
+	r.batch.Merge(EncodeKey(key), value)
+	return nil
+}
+
+func (r *golsmDistinctBatch) LogData(data []byte) error {
+	r.batch.LogData(data)
+	return nil
+}
+
+func (r *golsmDistinctBatch) ApplyBatchRepr(repr []byte, sync bool) error {
+	return r.batch.Apply(repr)
+}
+
+func (r *golsmDistinctBatch) Clear(key MVCCKey) error {
+	r.batch.Delete(EncodeKey(key))
+	return nil
+}
+
+func (r *golsmDistinctBatch) SingleClear(key MVCCKey) error {
+	r.batch.SingleDelete(EncodeKey(key))
+	return nil
+}
+
+func (r *golsmDistinctBatch) ClearRange(start, end MVCCKey) error {
+	if !r.parent.writeOnly {
+		panic("readable batch")
+	}
+	r.batch.DeleteRange(EncodeKey(start), EncodeKey(end))
+	return nil
+}
+
+func (r *golsmDistinctBatch) ClearIterRange(iter Iterator, start, end MVCCKey) error {
+	return golsmClearIterRange(&r.batch, iter, start, end)
+}
+
+func (r *golsmDistinctBatch) LogLogicalOp(op MVCCLogicalOpType, details MVCCLogicalOpDetails) {
+	// No-op. Logical logging disabled.
+}
+
+func (r *golsmDistinctBatch) close() {
+	r.prefixIter.destroy()
+	r.normalIter.destroy()
+}
@@ -0,0 +1,469 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/lsm"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/pkg/errors"
+)
+
+// golsmIterator implements Iterator on top of an lsm.Iterator. It mirrors the
+// behavior of rocksDBIterator, including the MVCC operations which are
+// implemented in C++ for RocksDB.
+type golsmIterator struct {
+	engine Reader
+	iter   *lsm.Iterator
+	// prefix is set for prefix iterators, which are bounded to the versions
+	// of the key they were last positioned at.
+	prefix bool
+	// lowerBound and upperBound are the encoded bounds of the iterator. For
+	// prefix iterators they are the bounds requested by the user, which are
+	// intersected with the bounds of the current prefix.
+	lowerBound []byte
+	upperBound []byte
+	// forwardOnly is set for iterators over batches, which, like the
+	// RocksDB batch iterators, do not support reverse iteration.
+	forwardOnly bool
+	valid       bool
+	err         error
+	key         MVCCKey
+	stats       IteratorStats
+}
+
+var _ Iterator = &golsmIterator{}
+
+// newGoLSMIterator returns an iterator created by newIter, which is one of the
+// NewIter methods of an lsm.DB, lsm.Snapshot or lsm.Batch.
+func newGoLSMIterator(
+	newIter func(*lsm.IterOptions) *lsm.Iterator, opts IterOptions, engine Reader,
+) *golsmIterator {
+	if !opts.Prefix && len(opts.UpperBound) == 0 && len(opts.LowerBound) == 0 {
+		panic("iterator must set prefix or upper bound or lower bound")
+	}
+	r := &golsmIterator{engine: engine, prefix: opts.Prefix}
+	r.lowerBound, r.upperBound = encodeIterBound(opts.LowerBound), encodeIterBound(opts.UpperBound)
+	lsmOpts := &lsm.IterOptions{LowerBound: r.lowerBound, UpperBound: r.upperBound}
+	if opts.MinTimestampHint != (hlc.Timestamp{}) || opts.MaxTimestampHint != (hlc.Timestamp{}) {
+		lsmOpts.TableFilter = r.timeBoundFilter(opts.MinTimestampHint, opts.MaxTimestampHint, opts.WithStats)
+	}
+	r.iter = newIter(lsmOpts)
+	return r
+}
+
+// encodeIterBound returns the encoding of an iterator bound, or nil if the
+// bound is not set.
+func encodeIterBound(key roachpb.Key) []byte {
+	if len(key) == 0 {
+		return nil
+	}
+	return EncodeKey(MakeMVCCMetadataKey(key))
+}
+
+// timeBoundFilter returns a table filter which skips the sstables whose
+// timestamp bounds, collected by timeBoundCollector, do not overlap
+// [min, max]. The tables used are counted in the iterator stats if withStats
+// is set.
+func (r *golsmIterator) timeBoundFilter(
+	min, max hlc.Timestamp, withStats bool,
+) func(map[string]string) bool {
+	encMin, encMax := string(encodeTimestamp(min)), string(encodeTimestamp(max))
+	return func(userProps map[string]string) bool {
+		tblMin, tblMax := userProps[timeBoundMinProp], userProps[timeBoundMaxProp]
+		// If the timestamp range of the table overlaps with the timestamp range
+		// we want to iterate, the table might contain timestamps we care about.
+		// Tables without timestamp bounds are always used.
+		used := tblMin == "" || tblMax == "" || (encMax >= tblMin && encMin <= tblMax)
+		if used && withStats {
+			r.stats.TimeBoundNumSSTs++
+		}
+		return used
+	}
+}
+
+func (r *golsmIterator) setOptions(opts IterOptions) {
+	if opts.MinTimestampHint != (hlc.Timestamp{}) || opts.MaxTimestampHint != (hlc.Timestamp{}) {
+		panic("iterator with timestamp hints cannot be reused")
+	}
+	if !opts.Prefix && len(opts.UpperBound) == 0 && len(opts.LowerBound) == 0 {
+		panic("iterator must set prefix or upper bound or lower bound")
+	}
+	r.prefix = opts.Prefix
+	r.lowerBound, r.upperBound = encodeIterBound(opts.LowerBound), encodeIterBound(opts.UpperBound)
+	r.iter.SetBounds(r.lowerBound, r.upperBound)
+	r.clearState()
+}
+
+// setPrefix bounds a prefix iterator to the versions of key.
+func (r *golsmIterator) setPrefix(key roachpb.Key) {
+	if !r.prefix {
+		return
+	}
+	lower := EncodeKey(MakeMVCCMetadataKey(key))
+	upper := EncodeKey(MakeMVCCMetadataKey(key.Next()))
+	if r.lowerBound != nil && mvccComparer.Compare(r.lowerBound, lower) > 0 {
+		lower = r.lowerBound
+	}
+	if r.upperBound != nil && mvccComparer.Compare(r.upperBound, upper) < 0 {
+		upper = r.upperBound
+	}
+	r.iter.SetBounds(lower, upper)
+}
+
+func (r *golsmIterator) checkEngineOpen() {
+	if r.engine.Closed() {
+		panic("iterator used after backing engine closed")
+	}
+}
+
+func (r *golsmIterator) destroy() {
+	if r.iter != nil {
+		if err := r.iter.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+		r.iter = nil
+	}
+}
+
+// The following methods implement the Iterator interface.
+
+func (r *golsmIterator) Stats() IteratorStats {
+	return r.stats
+}
+
+func (r *golsmIterator) Close() {
+	r.destroy()
+}
+
+func (r *golsmIterator) Seek(key MVCCKey) {
+	r.checkEngineOpen()
+	if len(key.Key) == 0 {
+		// start=Key("") needs special treatment since we need to access
+		// start[0] in an explicit seek.
+		r.setState(r.iter.First())
+		return
+	}
+	r.setPrefix(key.Key)
+	r.setState(r.iter.SeekGE(EncodeKey(key)))
+}
+
+func (r *golsmIterator) SeekReverse(key MVCCKey) {
+	r.checkEngineOpen()
+	if len(key.Key) == 0 {
+		r.setState(r.iter.Last())
+		return
+	}
+	r.setPrefix(key.Key)
+	// Position the iterator at the last key <= the provided key.
+	encKey := EncodeKey(key)
+	if r.setState(r.iter.SeekGE(encKey)) && key.Equal(r.key) {
+		return
+	}
+	r.setState(r.iter.SeekLT(encKey))
+}
+
+func (r *golsmIterator) Valid() (bool, error) {
+	return r.valid, r.err
+}
+
+func (r *golsmIterator) Next() {
+	r.checkEngineOpen()
+	r.setState(r.iter.Next())
+}
+
+func (r *golsmIterator) Prev() {
+	r.checkEngineOpen()
+	if r.forwardOnly {
+		r.setUnsupported("Prev()")
+		return
+	}
+	r.setState(r.iter.Prev())
+}
+
+func (r *golsmIterator) NextKey() {
+	r.checkEngineOpen()
+	if !r.valid {
+		return
+	}
+	oldKey := append(roachpb.Key(nil), r.key.Key...)
+	if r.setState(r.iter.Next()) && bytes.Equal(oldKey, r.key.Key) {
+		// We're pointed at a different version of the same key. Fall back to
+		// seeking to the next key.
+		r.setState(r.iter.SeekGE(EncodeKey(MakeMVCCMetadataKey(oldKey.Next()))))
+	}
+}
+
+func (r *golsmIterator) PrevKey() {
+	r.checkEngineOpen()
+	if r.forwardOnly {
+		r.setUnsupported("PrevKey()")
+		return
+	}
+	if !r.valid {
+		return
+	}
+	oldKey := append(roachpb.Key(nil), r.key.Key...)
+	if r.setState(r.iter.Prev()) && bytes.Equal(oldKey, r.key.Key) {
+		// We're pointed at a different version of the same key. Fall back to
+		// seeking to the key preceding the metadata key of the current key.
+		r.setState(r.iter.SeekLT(EncodeKey(MakeMVCCMetadataKey(oldKey))))
+	}
+}
+
+func (r *golsmIterator) Key() MVCCKey {
+	key := r.UnsafeKey()
+	key.Key = append(roachpb.Key(nil), key.Key...)
+	return key
+}
+
+func (r *golsmIterator) Value() []byte {
+	value := r.UnsafeValue()
+	return append(make([]byte, 0, len(value)), value...)
+}
+
+func (r *golsmIterator) ValueProto(msg protoutil.Message) error {
+	value := r.UnsafeValue()
+	if len(value) == 0 {
+		return nil
+	}
+	return protoutil.Unmarshal(value, msg)
+}
+
+func (r *golsmIterator) UnsafeKey() MVCCKey {
+	if !r.valid {
+		return MVCCKey{}
+	}
+	return r.key
+}
+
+func (r *golsmIterator) UnsafeValue() []byte {
+	if !r.valid {
+		return nil
+	}
+	return r.iter.Value()
+}
+
+func (r *golsmIterator) clearState() {
+	r.valid = false
+	r.key = MVCCKey{}
+	r.err = nil
+}
+
+// setUnsupported invalidates the iterator with an error reporting that the
+// named operation is not supported.
+func (r *golsmIterator) setUnsupported(op string) {
+	r.valid, r.key = false, MVCCKey{}
+	r.err = errors.Errorf("%s not supported", op)
+}
+
+// setState updates the decoded state of the iterator after it was moved, and
+// returns whether it is valid.
+func (r *golsmIterator) setState(valid bool) bool {
+	r.valid = valid
+	r.err = r.iter.Error()
+	if r.err != nil {
+		r.valid = false
+	}
+	if !r.valid {
+		r.key = MVCCKey{}
+		return false
+	}
+	key, ts, err := enginepb.DecodeKey(r.iter.Key())
+	if err != nil {
+		r.valid, r.err = false, err
+		return false
+	}
+	r.key = MVCCKey{Key: key, Timestamp: ts}
+	return true
+}
+
+func (r *golsmIterator) ComputeStats(
+	start, end MVCCKey, nowNanos int64,
+) (enginepb.MVCCStats, error) {
+	r.clearState()
+	return ComputeStatsGo(r, start, end, nowNanos)
+}
+
+// FindSplitKey is a port of libroach's MVCCFindSplitKey.
+func (r *golsmIterator) FindSplitKey(
+	start, end, minSplitKey MVCCKey, targetSize int64,
+) (MVCCKey, error) {
+	r.clearState()
+	encStart := EncodeKey(start)
+	encEnd := EncodeKey(end)
+
+	var sizeSoFar int64
+	bestSplitKey := encStart
+	bestSplitDiff := int64(math.MaxInt64)
+	var prevKey []byte
+
+	for valid := r.iter.SeekGE(encStart); valid && mvccComparer.Compare(r.iter.Key(), encEnd) < 0; valid = r.iter.Next() {
+		key, ts, err := enginepb.DecodeKey(r.iter.Key())
+		if err != nil {
+			return MVCCKey{}, errors.New("unable to decode key")
+		}
+
+		isValid := IsValidSplitKey(key) && bytes.Compare(key, minSplitKey.Key) >= 0
+		diff := targetSize - sizeSoFar
+		if diff < 0 {
+			diff = -diff
+		}
+		if isValid && diff < bestSplitDiff {
+			bestSplitKey = append([]byte(nil), key...)
+			bestSplitDiff = diff
+		}
+		// If diff is increasing, that means we've passed the ideal split point
+		// and should return the first key that we can. Note that bestSplitKey
+		// may still be the start key if we haven't reached minSplitKey yet.
+		if diff > bestSplitDiff && len(bestSplitKey) > 0 {
+			break
+		}
+
+		isValue := ts != (hlc.Timestamp{})
+		valueSize := int64(len(r.iter.Value()))
+		if isValue && bytes.Equal(key, prevKey) {
+			sizeSoFar += mvccVersionTimestampSize + valueSize
+		} else {
+			sizeSoFar += int64(len(key)) + 1 + valueSize
+			if isValue {
+				sizeSoFar += mvccVersionTimestampSize
+			}
+		}
+		prevKey = append(prevKey[:0], key...)
+	}
+	if err := r.iter.Error(); err != nil {
+		return MVCCKey{}, err
+	}
+	if bytes.Equal(bestSplitKey, encStart) {
+		return MVCCKey{}, nil
+	}
+	return MVCCKey{Key: bestSplitKey}, nil
+}
+
+func (r *golsmIterator) MVCCGet(
+	key roachpb.Key, timestamp hlc.Timestamp, opts MVCCGetOptions,
+) (*roachpb.Value, *roachpb.Intent, error) {
+	if opts.Inconsistent && opts.Txn != nil {
+		return nil, nil, errors.Errorf("cannot allow inconsistent reads within a transaction")
+	}
+	if len(key) == 0 {
+		return nil, nil, emptyKeyError()
+	}
+
+	// Get is implemented as a scan bounded to the versions of the key.
+	r.clearState()
+	r.iter.SetBounds(EncodeKey(MakeMVCCMetadataKey(key)), EncodeKey(MakeMVCCMetadataKey(key.Next())))
+	defer r.iter.SetBounds(r.lowerBound, r.upperBound)
+	s := newMVCCScanner(r.iter, key, 1 /* maxKeys */, timestamp, opts.Txn,
+		opts.Inconsistent, false /* reverse */, opts.Tombstones, opts.IgnoreSequence)
+	s.get()
+	if err := s.scanError(); err != nil {
+		return nil, nil, err
+	}
+
+	intents, err := buildScanIntents(s.intentData())
+	if err != nil {
+		return nil, nil, err
+	}
+	if !opts.Inconsistent && len(intents) > 0 {
+		return nil, nil, &roachpb.WriteIntentError{Intents: intents}
+	}
+
+	var intent *roachpb.Intent
+	if len(intents) > 1 {
+		return nil, nil, errors.Errorf("expected 0 or 1 intents, got %d", len(intents))
+	} else if len(intents) == 1 {
+		intent = &intents[0]
+	}
+	if s.kvCount > 1 {
+		return nil, nil, errors.Errorf("expected 0 or 1 result, found %d", s.kvCount)
+	}
+	if s.kvCount == 0 {
+		return nil, intent, nil
+	}
+
+	// Extract the value from the batch data.
+	mvccKey, rawValue, _, err := MVCCScanDecodeKeyValue(s.kvData)
+	if err != nil {
+		return nil, nil, err
+	}
+	value := &roachpb.Value{
+		RawBytes:  rawValue,
+		Timestamp: mvccKey.Timestamp,
+	}
+	return value, intent, nil
+}
+
+func (r *golsmIterator) MVCCScan(
+	start, end roachpb.Key, max int64, timestamp hlc.Timestamp, opts MVCCScanOptions,
+) (kvData []byte, numKVs int64, resumeSpan *roachpb.Span, intents []roachpb.Intent, err error) {
+	if opts.Inconsistent && opts.Txn != nil {
+		return nil, 0, nil, nil, errors.Errorf("cannot allow inconsistent reads within a transaction")
+	}
+	if len(end) == 0 {
+		return nil, 0, nil, nil, emptyKeyError()
+	}
+	if max == 0 {
+		resumeSpan = &roachpb.Span{Key: start, EndKey: end}
+		return nil, 0, resumeSpan, nil, nil
+	}
+
+	r.clearState()
+	// Reverse scans start at the end key. In both directions, the scan is
+	// bounded by the bounds of the iterator.
+	scanStart := start
+	if opts.Reverse {
+		scanStart = end
+	}
+	s := newMVCCScanner(r.iter, scanStart, max, timestamp, opts.Txn,
+		opts.Inconsistent, opts.Reverse, opts.Tombstones, opts.IgnoreSequence)
+	s.scan()
+	if err := s.scanError(); err != nil {
+		return nil, 0, nil, nil, err
+	}
+
+	kvData = s.kvData
+	numKVs = s.kvCount
+
+	if resumeKey := s.resumeKey; resumeKey != nil {
+		if opts.Reverse {
+			resumeSpan = &roachpb.Span{Key: start, EndKey: resumeKey.Next()}
+		} else {
+			resumeSpan = &roachpb.Span{Key: resumeKey, EndKey: end}
+		}
+	}
+
+	intents, err = buildScanIntents(s.intentData())
+	if err != nil {
+		return nil, 0, nil, nil, err
+	}
+	if !opts.Inconsistent && len(intents) > 0 {
+		// When encountering intents during a consistent scan we still need to
+		// return the resume key.
+		return nil, 0, resumeSpan, nil, &roachpb.WriteIntentError{Intents: intents}
+	}
+
+	return kvData, numKVs, resumeSpan, intents, nil
+}
+
+func (r *golsmIterator) SetUpperBound(key roachpb.Key) {
+	r.upperBound = encodeIterBound(key)
+	r.iter.SetBounds(r.lowerBound, r.upperBound)
+	r.clearState()
+}
@@ -0,0 +1,363 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/lsm"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/pkg/errors"
+)
+
+// mvccMerger is the merge operator of GoLSM engines. It is a port of
+// libroach's DBMergeOperator (see c-deps/libroach/merge.cc): operands are
+// marshaled MVCCMetadata protos with inline values, which are either
+// concatenated or, for time series data, merged sample by sample.
+var mvccMerger = &lsm.Merger{
+	FullMerge: func(key, existing []byte, operands [][]byte) ([]byte, error) {
+		var meta enginepb.MVCCMetadata
+		if existing != nil {
+			if err := protoutil.Unmarshal(existing, &meta); err != nil {
+				return nil, errors.Wrap(err, "corrupted existing value")
+			}
+		}
+		return mergeOperands(&meta, operands, true /* fullMerge */)
+	},
+	PartialMerge: func(key []byte, operands [][]byte) ([]byte, error) {
+		var meta enginepb.MVCCMetadata
+		return mergeOperands(&meta, operands, false /* fullMerge */)
+	},
+	// This must match the name of libroach's merge operator.
+	Name: "cockroach_merge_operator",
+}
+
+func mergeOperands(meta *enginepb.MVCCMetadata, operands [][]byte, fullMerge bool) ([]byte, error) {
+	for _, operand := range operands {
+		var operandMeta enginepb.MVCCMetadata
+		if err := protoutil.Unmarshal(operand, &operandMeta); err != nil {
+			return nil, errors.Wrap(err, "corrupted operand value")
+		}
+		if err := mergeValues(meta, operandMeta, fullMerge); err != nil {
+			return nil, err
+		}
+	}
+	return protoutil.Marshal(meta)
+}
+
+// The layout of roachpb.Value.RawBytes: a 4 byte checksum followed by a tag
+// byte.
+const (
+	mergeChecksumSize = 4
+	mergeTagPos       = mergeChecksumSize
+	mergeHeaderSize   = mergeTagPos + 1
+)
+
+func isTimeSeriesData(rawBytes []byte) bool {
+	return roachpb.Value{RawBytes: rawBytes}.GetTag() == roachpb.ValueType_TIMESERIES
+}
+
+func mergeValues(left *enginepb.MVCCMetadata, right enginepb.MVCCMetadata, fullMerge bool) error {
+	if left.RawBytes != nil {
+		if right.RawBytes == nil {
+			return errors.New("inconsistent value types for merge (left = bytes, right = ?)")
+		}
+
+		// Replay Advisory: Because merge commands pass through raft, it is
+		// possible for merging values to be "replayed". Currently, the only
+		// actual use of the merge system is for time series data, which is safe
+		// against replay; however, this property is not general for all
+		// potential mergeable types.
+		if isTimeSeriesData(left.RawBytes) || isTimeSeriesData(right.RawBytes) {
+			if !isTimeSeriesData(left.RawBytes) || !isTimeSeriesData(right.RawBytes) {
+				return errors.New("inconsistent value types for merging time series data (type(left) != type(right))")
+			}
+			merged, err := mergeTimeSeriesValues(left.RawBytes, right.RawBytes, fullMerge)
+			if err != nil {
+				return err
+			}
+			left.RawBytes = merged
+			return nil
+		}
+		if len(right.RawBytes) >= mergeHeaderSize {
+			left.RawBytes = append(left.RawBytes, right.RawBytes[mergeHeaderSize:]...)
+		}
+		return nil
+	}
+
+	left.RawBytes = append([]byte{}, right.RawBytes...)
+	if right.MergeTimestamp != nil {
+		ts := *right.MergeTimestamp
+		left.MergeTimestamp = &ts
+	}
+	if fullMerge && isTimeSeriesData(left.RawBytes) {
+		consolidated, err := consolidateTimeSeriesValue(left.RawBytes)
+		if err != nil {
+			return err
+		}
+		left.RawBytes = consolidated
+	}
+	return nil
+}
+
+func unmarshalTimeSeriesValue(rawBytes []byte) (roachpb.InternalTimeSeriesData, error) {
+	var ts roachpb.InternalTimeSeriesData
+	if len(rawBytes) < mergeHeaderSize {
+		return ts, errors.New("InternalTimeSeriesData could not be parsed from bytes")
+	}
+	if err := protoutil.Unmarshal(rawBytes[mergeHeaderSize:], &ts); err != nil {
+		return ts, errors.Wrap(err, "InternalTimeSeriesData could not be parsed from bytes")
+	}
+	return ts, nil
+}
+
+// marshalTimeSeriesValue marshals a time series into the RawBytes of a
+// roachpb.Value with a zero checksum.
+func marshalTimeSeriesValue(ts *roachpb.InternalTimeSeriesData) ([]byte, error) {
+	var v roachpb.Value
+	if err := v.SetProto(ts); err != nil {
+		return nil, err
+	}
+	return v.RawBytes, nil
+}
+
+func mergeTimeSeriesValues(left, right []byte, fullMerge bool) ([]byte, error) {
+	leftTS, err := unmarshalTimeSeriesValue(left)
+	if err != nil {
+		return nil, errors.Wrap(err, "left")
+	}
+	rightTS, err := unmarshalTimeSeriesValue(right)
+	if err != nil {
+		return nil, errors.Wrap(err, "right")
+	}
+
+	// Ensure that both InternalTimeSeriesData have the same timestamp and
+	// sample_duration.
+	if leftTS.StartTimestampNanos != rightTS.StartTimestampNanos {
+		return nil, errors.New("TimeSeries merge failed due to mismatched start timestamps")
+	}
+	if leftTS.SampleDurationNanos != rightTS.SampleDurationNanos {
+		return nil, errors.New("TimeSeries merge failed due to mismatched sample durations")
+	}
+
+	// Determine if we are using row or columnar format, by checking if either
+	// format has a "last" column.
+	useColumnFormat := len(leftTS.Last) > 0 || len(rightTS.Last) > 0
+
+	// If only a partial merge, do not sort and combine - instead, just quickly
+	// merge the two values together. Values will be processed later after a
+	// full merge.
+	if !fullMerge {
+		// If using columnar format, convert both operands even in a partial
+		// merge. This is necessary to keep the order of merges stable.
+		if useColumnFormat {
+			convertToColumnar(&leftTS)
+			convertToColumnar(&rightTS)
+		}
+		appendTimeSeries(&leftTS, &rightTS)
+		return marshalTimeSeriesValue(&leftTS)
+	}
+
+	if useColumnFormat {
+		convertToColumnar(&leftTS)
+		convertToColumnar(&rightTS)
+
+		// Find the minimum offset of the right collection, and find the highest
+		// index in the left collection which is greater than or equal to that
+		// minimum. This determines how many elements of the left collection
+		// will need to be re-sorted and de-duplicated.
+		var firstUnsorted int
+		if len(rightTS.Offset) > 0 {
+			minOffset := rightTS.Offset[0]
+			for _, o := range rightTS.Offset[1:] {
+				if o < minOffset {
+					minOffset = o
+				}
+			}
+			firstUnsorted = sort.Search(len(leftTS.Offset), func(i int) bool {
+				return leftTS.Offset[i] >= minOffset
+			})
+		} else {
+			firstUnsorted = len(leftTS.Offset)
+		}
+		appendTimeSeries(&leftTS, &rightTS)
+		sortAndDeduplicateColumns(&leftTS, firstUnsorted)
+		return marshalTimeSeriesValue(&leftTS)
+	}
+
+	newTS := roachpb.InternalTimeSeriesData{
+		StartTimestampNanos: leftTS.StartTimestampNanos,
+		SampleDurationNanos: leftTS.SampleDurationNanos,
+	}
+	// Sort values in rightTS. Assume values in leftTS have been sorted.
+	sort.SliceStable(rightTS.Samples, func(i, j int) bool {
+		return rightTS.Samples[i].Offset < rightTS.Samples[j].Offset
+	})
+
+	// Merge the samples of left and right into newTS. Only the most recently
+	// merged sample with a given offset is kept.
+	l, r := leftTS.Samples, rightTS.Samples
+	for len(l) > 0 || len(r) > 0 {
+		var next int32
+		switch {
+		case len(l) == 0:
+			next = r[0].Offset
+		case len(r) == 0:
+			next = l[0].Offset
+		case l[0].Offset <= r[0].Offset:
+			next = l[0].Offset
+		default:
+			next = r[0].Offset
+		}
+		var src roachpb.InternalTimeSeriesSample
+		for len(l) > 0 && l[0].Offset == next {
+			src, l = l[0], l[1:]
+		}
+		for len(r) > 0 && r[0].Offset == next {
+			src, r = r[0], r[1:]
+		}
+		newTS.Samples = append(newTS.Samples, src)
+	}
+	return marshalTimeSeriesValue(&newTS)
+}
+
+func consolidateTimeSeriesValue(val []byte) ([]byte, error) {
+	ts, err := unmarshalTimeSeriesValue(val)
+	if err != nil {
+		return nil, err
+	}
+
+	// Detect if the value is in columnar or row format. Columnar format is
+	// detected by the presence of a non-zero-length offset field.
+	if len(ts.Offset) > 0 {
+		// It's possible that, due to partial merges, the value contains both
+		// row-format and column-format data. Convert it all to columnar.
+		convertToColumnar(&ts)
+		sortAndDeduplicateColumns(&ts, 0)
+	} else {
+		sort.SliceStable(ts.Samples, func(i, j int) bool {
+			return ts.Samples[i].Offset < ts.Samples[j].Offset
+		})
+		// Deduplicate values, keeping only the *last* sample merged with a
+		// given offset.
+		deduped := ts.Samples[:0]
+		for i, s := range ts.Samples {
+			if i+1 < len(ts.Samples) && ts.Samples[i+1].Offset == s.Offset {
+				continue
+			}
+			deduped = append(deduped, s)
+		}
+		ts.Samples = deduped
+	}
+	return marshalTimeSeriesValue(&ts)
+}
+
+// appendTimeSeries appends the samples and columns of src to dst, like
+// proto2's MergeFrom.
+func appendTimeSeries(dst, src *roachpb.InternalTimeSeriesData) {
+	dst.Samples = append(dst.Samples, src.Samples...)
+	dst.Offset = append(dst.Offset, src.Offset...)
+	dst.Last = append(dst.Last, src.Last...)
+	dst.Count = append(dst.Count, src.Count...)
+	dst.Sum = append(dst.Sum, src.Sum...)
+	dst.Max = append(dst.Max, src.Max...)
+	dst.Min = append(dst.Min, src.Min...)
+	dst.First = append(dst.First, src.First...)
+	dst.Variance = append(dst.Variance, src.Variance...)
+}
+
+// convertToColumnar converts the row-format samples of data to the columnar
+// format.
+func convertToColumnar(data *roachpb.InternalTimeSeriesData) {
+	if len(data.Samples) == 0 {
+		return
+	}
+	for _, sample := range data.Samples {
+		// While the row format contains other values (such as min and max),
+		// these were not stored in actual usage. Furthermore, the columnar
+		// format has been designed to be "sparse", with high resolutions
+		// containing values only for the "offset" and "last" columns. Thus, the
+		// other row fields are ignored.
+		data.Offset = append(data.Offset, sample.Offset)
+		data.Last = append(data.Last, sample.Sum)
+	}
+	data.Samples = nil
+}
+
+// sortAndDeduplicateColumns sorts the columns of data starting at
+// firstUnsorted by offset, keeping only the last sample merged for any given
+// offset.
+func sortAndDeduplicateColumns(data *roachpb.InternalTimeSeriesData, firstUnsorted int) {
+	// Compute the permutation of the unsorted indexes which places the offsets
+	// in sorted order.
+	order := make([]int, len(data.Offset)-firstUnsorted)
+	for i := range order {
+		order[i] = i + firstUnsorted
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return data.Offset[order[i]] < data.Offset[order[j]]
+	})
+
+	// Remove duplicates from the permutation, keeping the *last* element
+	// merged for any given offset.
+	deduped := order[:0]
+	for i, idx := range order {
+		if i+1 < len(order) && data.Offset[order[i+1]] == data.Offset[idx] {
+			continue
+		}
+		deduped = append(deduped, idx)
+	}
+
+	// Apply the permutation to all of the column arrays. Columns other than
+	// offset and last are only present at resolutions generated as rollups,
+	// which is detected by the presence of a count column.
+	rollup := len(data.Count) > 0
+	newSize := firstUnsorted + len(deduped)
+	offset := append([]int32(nil), data.Offset...)
+	last := append([]float64(nil), data.Last...)
+	var count []uint32
+	var sum, max, min, first, variance []float64
+	if rollup {
+		count = append([]uint32(nil), data.Count...)
+		sum = append([]float64(nil), data.Sum...)
+		max = append([]float64(nil), data.Max...)
+		min = append([]float64(nil), data.Min...)
+		first = append([]float64(nil), data.First...)
+		variance = append([]float64(nil), data.Variance...)
+	}
+	for i, src := range deduped {
+		dst := firstUnsorted + i
+		data.Offset[dst] = offset[src]
+		data.Last[dst] = last[src]
+		if rollup {
+			data.Count[dst] = count[src]
+			data.Sum[dst] = sum[src]
+			data.Max[dst] = max[src]
+			data.Min[dst] = min[src]
+			data.First[dst] = first[src]
+			data.Variance[dst] = variance[src]
+		}
+	}
+	data.Offset = data.Offset[:newSize]
+	data.Last = data.Last[:newSize]
+	if rollup {
+		data.Count = data.Count[:newSize]
+		data.Sum = data.Sum[:newSize]
+		data.Max = data.Max[:newSize]
+		data.Min = data.Min[:newSize]
+		data.First = data.First[:newSize]
+		data.Variance = data.Variance[:newSize]
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestGoLSMReopen(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	dir, dirCleanup := testutils.TempDir(t)
+	defer dirCleanup()
+
+	cfg := GoLSMConfig{Dir: dir, MustExist: true, Settings: cluster.MakeTestingClusterSettings()}
+	if _, err := NewGoLSM(cfg); !testutils.IsError(err, "does not exist") {
+		t.Fatalf("expected an error opening a missing store, got %v", err)
+	}
+
+	cfg.MustExist = false
+	g, err := NewGoLSM(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := mvccKey("a")
+	if err := g.Put(key, []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	g.Close()
+
+	cfg.MustExist = true
+	g, err = NewGoLSM(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if val, err := g.Get(key); err != nil {
+		t.Fatal(err)
+	} else if string(val) != "value" {
+		t.Fatalf("expected %q, got %q", "value", val)
+	}
+}
+
+func TestGoLSMTimeBound(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	g := NewInMemGoLSM(roachpb.Attributes{}, testCacheSize)
+	defer g.Close()
+
+	minTimestamp := hlc.Timestamp{WallTime: 1}
+	maxTimestamp := hlc.Timestamp{WallTime: 3}
+	times := []hlc.Timestamp{{WallTime: 2}, minTimestamp, maxTimestamp, {WallTime: 2}}
+	for i, ts := range times {
+		s := fmt.Sprintf("%02d", i)
+		if err := g.Put(MVCCKey{Key: roachpb.Key(s), Timestamp: ts}, []byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		min, max   hlc.Timestamp
+		keys, ssts int
+	}{
+		{min: maxTimestamp.Next(), max: maxTimestamp.Next().Next(), keys: 0, ssts: 0},
+		{min: minTimestamp.Prev().Prev(), max: minTimestamp.Prev(), keys: 0, ssts: 0},
+		{min: maxTimestamp, max: maxTimestamp, keys: len(times), ssts: 1},
+		{min: minTimestamp, max: minTimestamp, keys: len(times), ssts: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s-%s", tc.min, tc.max), func(t *testing.T) {
+			iter := g.NewIterator(IterOptions{
+				MinTimestampHint: tc.min,
+				MaxTimestampHint: tc.max,
+				UpperBound:       roachpb.KeyMax,
+				WithStats:        true,
+			})
+			defer iter.Close()
+			var count int
+			for iter.Seek(NilKey); ; iter.Next() {
+				if ok, err := iter.Valid(); err != nil {
+					t.Fatal(err)
+				} else if !ok {
+					break
+				}
+				count++
+			}
+			if count != tc.keys {
+				t.Fatalf("saw %d values in time bounded iterator, but expected %d", count, tc.keys)
+			}
+			if a := iter.Stats().TimeBoundNumSSTs; a != tc.ssts {
+				t.Fatalf("touched %d SSTs, expected %d", a, tc.ssts)
+			}
+		})
+	}
+}
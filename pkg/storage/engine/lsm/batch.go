@@ -0,0 +1,290 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// batchHeaderLen is the length of the batch header: an 8-byte sequence number
+// followed by a 4-byte count of the entries in the batch.
+const batchHeaderLen = 12
+
+// Batch is a sequence of writes which are committed atomically.
+//
+// The representation of a batch is identical to that of RocksDB's
+// WriteBatch:
+//
+//	batch    := seqNum:fixed64 count:fixed32 record*
+//	record   := KindSet key:varstring value:varstring
+//	          | KindMerge key:varstring value:varstring
+//	          | KindDelete key:varstring
+//	          | KindSingleDelete key:varstring
+//	          | KindRangeDelete start:varstring end:varstring
+//	          | KindLogData data:varstring
+//	varstring := len:varint32 data:uint8[len]
+//
+// LogData records are not counted and are only written to the WAL.
+//
+// An indexed batch additionally maintains a sorted index of its entries so
+// that it can be read from before being committed (see Batch.NewIter).
+type Batch struct {
+	db    *DB
+	repr  []byte
+	count uint32
+	// memTableSize approximates the memory the batch will use once applied to
+	// a memtable.
+	memTableSize int64
+
+	// The following fields are only set for indexed batches. Entries are
+	// indexed with sequence numbers batchSeqNumFlag|i, where i is the number
+	// of entries which preceded them in the batch.
+	index         *skiplist
+	rangeDels     []rangeTombstone
+	rangeDelFrags rangeDelFragments
+	rangeDelCount int
+}
+
+func newBatch(db *DB, indexed bool) *Batch {
+	b := &Batch{db: db}
+	if indexed {
+		b.index = newSkiplist(db.cmp)
+	}
+	return b
+}
+
+func (b *Batch) init() {
+	if len(b.repr) == 0 {
+		b.repr = make([]byte, batchHeaderLen, 256)
+	}
+}
+
+func (b *Batch) appendVarstring(s []byte) {
+	var buf [binary.MaxVarintLen32]byte
+	n := binary.PutUvarint(buf[:], uint64(len(s)))
+	b.repr = append(b.repr, buf[:n]...)
+	b.repr = append(b.repr, s...)
+}
+
+// record appends a record to the batch. key and value alias the batch's
+// representation so that they can be indexed.
+func (b *Batch) record(kind Kind, k, v []byte, hasValue bool) {
+	b.init()
+	b.repr = append(b.repr, byte(kind))
+	b.appendVarstring(k)
+	keyEnd := len(b.repr)
+	key := b.repr[keyEnd-len(k) : keyEnd : keyEnd]
+	var value []byte
+	if hasValue {
+		b.appendVarstring(v)
+		valueEnd := len(b.repr)
+		value = b.repr[valueEnd-len(v) : valueEnd : valueEnd]
+	}
+	b.index2(kind, key, value)
+}
+
+// index2 records a newly appended entry in the count and, for indexed
+// batches, the index.
+func (b *Batch) index2(kind Kind, key, value []byte) {
+	if kind == KindLogData {
+		return
+	}
+	seqNum := batchSeqNumFlag | uint64(b.count)
+	b.count++
+	b.memTableSize += int64(len(key)+len(value)) + skiplistNodeOverhead
+	if b.index == nil {
+		return
+	}
+	if kind == KindRangeDelete {
+		b.rangeDels = append(b.rangeDels, rangeTombstone{start: key, end: value, seqNum: seqNum})
+		return
+	}
+	b.index.add(makeInternalKey(key, seqNum, kind), value)
+}
+
+// Set adds an entry setting key to value.
+func (b *Batch) Set(key, value []byte) {
+	b.record(KindSet, key, value, true)
+}
+
+// Merge adds a merge operand for key.
+func (b *Batch) Merge(key, value []byte) {
+	b.record(KindMerge, key, value, true)
+}
+
+// Delete adds a deletion of key.
+func (b *Batch) Delete(key []byte) {
+	b.record(KindDelete, key, nil, false)
+}
+
+// SingleDelete adds a deletion of key. The LSM treats it as a regular
+// deletion.
+func (b *Batch) SingleDelete(key []byte) {
+	b.record(KindSingleDelete, key, nil, false)
+}
+
+// DeleteRange adds a deletion of all keys in [start, end).
+func (b *Batch) DeleteRange(start, end []byte) {
+	b.record(KindRangeDelete, start, end, true)
+}
+
+// LogData adds a blob which is written to the WAL but otherwise ignored.
+func (b *Batch) LogData(data []byte) {
+	b.record(KindLogData, data, nil, false)
+}
+
+// Apply appends the entries of a batch representation to the batch.
+func (b *Batch) Apply(repr []byte) error {
+	if len(repr) <= batchHeaderLen {
+		if len(repr) < batchHeaderLen {
+			return errors.Errorf("batch repr too small: %d < %d", len(repr), batchHeaderLen)
+		}
+		return nil
+	}
+	// Validate the representation before modifying the batch.
+	r := batchReader{data: repr[batchHeaderLen:]}
+	for {
+		if _, _, _, ok := r.next(); !ok {
+			break
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	b.init()
+	offset := len(b.repr)
+	b.repr = append(b.repr, repr[batchHeaderLen:]...)
+	r = batchReader{data: b.repr[offset:]}
+	for {
+		kind, key, value, ok := r.next()
+		if !ok {
+			break
+		}
+		b.index2(kind, key, value)
+	}
+	return nil
+}
+
+// Repr returns the representation of the batch. The returned slice aliases
+// the batch and must not be modified.
+func (b *Batch) Repr() []byte {
+	b.init()
+	binary.LittleEndian.PutUint32(b.repr[8:batchHeaderLen], b.count)
+	return b.repr
+}
+
+// Count returns the number of entries in the batch, excluding LogData
+// records.
+func (b *Batch) Count() uint32 {
+	return b.count
+}
+
+// Empty returns whether the batch contains no records.
+func (b *Batch) Empty() bool {
+	return len(b.repr) <= batchHeaderLen
+}
+
+// Len returns the length of the batch representation.
+func (b *Batch) Len() int {
+	if len(b.repr) == 0 {
+		return batchHeaderLen
+	}
+	return len(b.repr)
+}
+
+// Indexed returns whether the batch can be read from.
+func (b *Batch) Indexed() bool {
+	return b.index != nil
+}
+
+// Reset clears the contents of the batch. Iterators over the batch must be
+// closed before calling Reset.
+func (b *Batch) Reset() {
+	b.repr = b.repr[:0]
+	b.count = 0
+	b.memTableSize = 0
+	if b.index != nil {
+		b.index = newSkiplist(b.db.cmp)
+		b.rangeDels = nil
+		b.rangeDelFrags = nil
+		b.rangeDelCount = 0
+	}
+}
+
+// Commit applies the batch to the DB which created it.
+func (b *Batch) Commit(sync bool) error {
+	return b.db.Apply(b, sync)
+}
+
+// rangeDelFragmentsForCount returns the fragments of the batch's range
+// tombstones, recomputing them if tombstones were added.
+func (b *Batch) rangeDelFragmentsForCount() rangeDelFragments {
+	if b.rangeDelCount != len(b.rangeDels) {
+		b.rangeDelFrags = fragmentRangeTombstones(b.db.cmp, b.rangeDels)
+		b.rangeDelCount = len(b.rangeDels)
+	}
+	return b.rangeDelFrags
+}
+
+// batchReader decodes the records of a batch representation, excluding the
+// header.
+type batchReader struct {
+	data []byte
+	err  error
+}
+
+func newBatchReader(repr []byte) (batchReader, uint64, uint32, error) {
+	if len(repr) < batchHeaderLen {
+		return batchReader{}, 0, 0, errors.Errorf("batch repr too small: %d < %d", len(repr), batchHeaderLen)
+	}
+	seqNum := binary.LittleEndian.Uint64(repr[:8])
+	count := binary.LittleEndian.Uint32(repr[8:batchHeaderLen])
+	return batchReader{data: repr[batchHeaderLen:]}, seqNum, count, nil
+}
+
+func (r *batchReader) varstring() ([]byte, bool) {
+	n, l := binary.Uvarint(r.data)
+	if l <= 0 || uint64(len(r.data)-l) < n {
+		r.err = errors.New("corrupted batch: invalid varstring")
+		return nil, false
+	}
+	s := r.data[l : l+int(n) : l+int(n)]
+	r.data = r.data[l+int(n):]
+	return s, true
+}
+
+// next returns the next record in the batch. It returns false when the batch
+// is exhausted or corrupt, in which case err is set.
+func (r *batchReader) next() (kind Kind, key, value []byte, ok bool) {
+	if len(r.data) == 0 || r.err != nil {
+		return 0, nil, nil, false
+	}
+	kind = Kind(r.data[0])
+	r.data = r.data[1:]
+	if key, ok = r.varstring(); !ok {
+		return 0, nil, nil, false
+	}
+	switch kind {
+	case KindSet, KindMerge, KindRangeDelete:
+		if value, ok = r.varstring(); !ok {
+			return 0, nil, nil, false
+		}
+	case KindDelete, KindSingleDelete, KindLogData:
+	default:
+		r.err = errors.Errorf("corrupted batch: unexpected record kind %d", kind)
+		return 0, nil, nil, false
+	}
+	return kind, key, value, true
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestBatchRepr(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	b := newBatch(nil, false)
+	b.Set([]byte("a"), []byte("1"))
+	b.Merge([]byte("b"), []byte("22"))
+	b.LogData([]byte("log"))
+	b.Delete([]byte("c"))
+	b.SingleDelete([]byte("d"))
+	b.DeleteRange([]byte("e"), []byte("f"))
+
+	// The representation matches RocksDB's WriteBatch.
+	expected := []byte{
+		0, 0, 0, 0, 0, 0, 0, 0, 5, 0, 0, 0,
+		byte(KindSet), 1, 'a', 1, '1',
+		byte(KindMerge), 1, 'b', 2, '2', '2',
+		byte(KindLogData), 3, 'l', 'o', 'g',
+		byte(KindDelete), 1, 'c',
+		byte(KindSingleDelete), 1, 'd',
+		byte(KindRangeDelete), 1, 'e', 1, 'f',
+	}
+	if repr := b.Repr(); !bytes.Equal(expected, repr) {
+		t.Fatalf("expected\n%v\ngot\n%v", expected, repr)
+	}
+	if b.Count() != 5 {
+		t.Fatalf("expected 5 entries, got %d", b.Count())
+	}
+
+	c := newBatch(nil, false)
+	c.Set([]byte("z"), nil)
+	if err := c.Apply(b.Repr()); err != nil {
+		t.Fatal(err)
+	}
+	if c.Count() != 6 {
+		t.Fatalf("expected 6 entries, got %d", c.Count())
+	}
+
+	r, _, count, err := newBatchReader(c.Repr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []Kind
+	for {
+		kind, _, _, ok := r.next()
+		if !ok {
+			break
+		}
+		kinds = append(kinds, kind)
+	}
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	expectedKinds := []Kind{
+		KindSet, KindSet, KindMerge, KindLogData, KindDelete, KindSingleDelete, KindRangeDelete,
+	}
+	if count != 6 || !reflect.DeepEqual(expectedKinds, kinds) {
+		t.Fatalf("unexpected batch contents: %d %v", count, kinds)
+	}
+
+	if err := c.Apply(expected[:len(expected)-1]); err == nil {
+		t.Fatal("expected error applying a truncated batch")
+	}
+	if c.Count() != 6 {
+		t.Fatalf("failed Apply changed the batch: %d entries", c.Count())
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"container/list"
+
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+type blockCacheKey struct {
+	fileNum uint64
+	offset  uint64
+}
+
+type blockCacheEntry struct {
+	key   blockCacheKey
+	block *block
+}
+
+// blockCache is an LRU cache of decoded sstable blocks, bounded by the total
+// size of the cached blocks.
+type blockCache struct {
+	maxSize int64
+
+	mu struct {
+		syncutil.Mutex
+		size    int64
+		lru     *list.List
+		entries map[blockCacheKey]*list.Element
+		hits    int64
+		misses  int64
+	}
+}
+
+func newBlockCache(maxSize int64) *blockCache {
+	c := &blockCache{maxSize: maxSize}
+	c.mu.lru = list.New()
+	c.mu.entries = map[blockCacheKey]*list.Element{}
+	return c
+}
+
+func (c *blockCache) get(key blockCacheKey) *block {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.mu.entries[key]
+	if !ok {
+		c.mu.misses++
+		return nil
+	}
+	c.mu.hits++
+	c.mu.lru.MoveToFront(e)
+	return e.Value.(*blockCacheEntry).block
+}
+
+func (c *blockCache) add(key blockCacheKey, b *block) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.mu.entries[key]; ok {
+		return
+	}
+	c.mu.entries[key] = c.mu.lru.PushFront(&blockCacheEntry{key: key, block: b})
+	c.mu.size += b.size
+	for c.mu.size > c.maxSize && c.mu.lru.Len() > 1 {
+		c.removeLocked(c.mu.lru.Back())
+	}
+}
+
+// evictFile removes all blocks of a file from the cache.
+func (c *blockCache) evictFile(fileNum uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.mu.entries {
+		if key.fileNum == fileNum {
+			c.removeLocked(e)
+		}
+	}
+}
+
+func (c *blockCache) removeLocked(e *list.Element) {
+	entry := c.mu.lru.Remove(e).(*blockCacheEntry)
+	delete(c.mu.entries, entry.key)
+	c.mu.size -= entry.block.size
+}
+
+func (c *blockCache) metrics() (size, hits, misses int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mu.size, c.mu.hits, c.mu.misses
+}
@@ -0,0 +1,439 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"sort"
+)
+
+// compaction describes the inputs of a compaction from level into
+// level+1.
+type compaction struct {
+	level  int
+	inputs [2][]*fileMetadata
+}
+
+func (c *compaction) outputLevel() int {
+	if c.level == numLevels-1 {
+		// Manual compactions of the bottom level rewrite it in place.
+		return c.level
+	}
+	return c.level + 1
+}
+
+// maxBytesForLevel returns the target size of a level.
+func (d *DB) maxBytesForLevel(level int) float64 {
+	size := float64(d.opts.LBaseMaxBytes)
+	for i := 1; i < level; i++ {
+		size *= float64(d.opts.LevelMultiplier)
+	}
+	return size
+}
+
+// pickCompaction returns the compaction with the highest score, or nil if no
+// level needs to be compacted. The score of L0 is its number of tables
+// relative to the L0 compaction threshold; the score of other levels is
+// their size relative to their target size. If no level needs to be
+// compacted, a table holding range tombstones is pushed down a level, so
+// that the space of the data it deletes is reclaimed quickly once it reaches
+// the bottom of the tree.
+func (d *DB) pickCompaction(v *version) *compaction {
+	c := d.pickScoredCompaction(v)
+	if c == nil {
+		c = d.pickRangeDelCompaction(v)
+	}
+	return c
+}
+
+// pickRangeDelCompaction returns a compaction of the first table, above the
+// bottom level, which holds range tombstones.
+func (d *DB) pickRangeDelCompaction(v *version) *compaction {
+	for level := 0; level < numLevels-1; level++ {
+		for _, f := range v.files[level] {
+			if len(f.rangeDels) == 0 {
+				continue
+			}
+			c := &compaction{level: level, inputs: [2][]*fileMetadata{{f}}}
+			if level == 0 {
+				c.inputs[0] = v.files[0]
+			}
+			d.expandOutputs(v, c)
+			return c
+		}
+	}
+	return nil
+}
+
+// pickScoredCompaction returns the compaction of the level with the highest
+// score, or nil if no level has a score of at least 1.
+func (d *DB) pickScoredCompaction(v *version) *compaction {
+	bestLevel, bestScore := -1, 1.0
+	for level := 0; level < numLevels-1; level++ {
+		var score float64
+		if level == 0 {
+			score = float64(len(v.files[0])) / float64(d.opts.L0CompactionThreshold)
+		} else {
+			score = float64(v.levelSize(level)) / d.maxBytesForLevel(level)
+		}
+		if score >= bestScore {
+			bestLevel, bestScore = level, score
+		}
+	}
+	if bestLevel < 0 {
+		return nil
+	}
+
+	c := &compaction{level: bestLevel}
+	if bestLevel == 0 {
+		c.inputs[0] = v.files[0]
+	} else {
+		// Compact the tables of the level in a round-robin fashion, starting
+		// after the largest key of the previously compacted table.
+		files := v.files[bestLevel]
+		f := files[0]
+		if pointer := d.compactMu.pointers[bestLevel]; pointer != nil {
+			i := sort.Search(len(files), func(i int) bool { return d.cmp(files[i].smallest, pointer) > 0 })
+			if i < len(files) {
+				f = files[i]
+			}
+		}
+		c.inputs[0] = []*fileMetadata{f}
+		d.compactMu.pointers[bestLevel] = f.largest
+	}
+	d.expandOutputs(v, c)
+	return c
+}
+
+// expandOutputs adds the tables of the output level which overlap the
+// inputs.
+func (d *DB) expandOutputs(v *version, c *compaction) {
+	var smallest, largest []byte
+	for _, f := range c.inputs[0] {
+		if smallest == nil || d.cmp(f.smallest, smallest) < 0 {
+			smallest = f.smallest
+		}
+		if largest == nil || d.cmp(f.largest, largest) > 0 {
+			largest = f.largest
+		}
+	}
+	c.inputs[1] = v.overlaps(d.cmp, c.outputLevel(), smallest, largest)
+}
+
+// manualCompaction returns the compaction of the tables of a level which
+// overlap [start, end], or nil if there are none. All L0 tables are
+// compacted if any of them overlaps, and the tables of the bottom level are
+// compacted into the bottom level.
+func (d *DB) manualCompaction(v *version, level int, start, end []byte) *compaction {
+	inputs := v.overlaps(d.cmp, level, start, end)
+	if len(inputs) == 0 {
+		return nil
+	}
+	if level == 0 {
+		inputs = v.files[0]
+	}
+	c := &compaction{level: level, inputs: [2][]*fileMetadata{inputs}}
+	if level < numLevels-1 {
+		d.expandOutputs(v, c)
+	}
+	return c
+}
+
+// runCompaction writes the output tables of a compaction.
+func (d *DB) runCompaction(
+	v *version, c *compaction, snapshots []uint64,
+) ([]*fileMetadata, error) {
+	iterOpts := &IterOptions{}
+	var iters []internalIterator
+	var tombstones []rangeTombstone
+	for i, files := range c.inputs {
+		if c.level == 0 && i == 0 {
+			for _, f := range files {
+				iters = append(iters, &levelIter{cmp: d.cmp, tables: d.tables, files: []*fileMetadata{f}, opts: iterOpts})
+			}
+		} else if len(files) > 0 {
+			iters = append(iters, &levelIter{cmp: d.cmp, tables: d.tables, files: files, opts: iterOpts})
+		}
+		for _, f := range files {
+			tombstones = append(tombstones, f.rangeDels...)
+		}
+	}
+	iter := newMergingIter(d.cmp, iters)
+	defer iter.Close()
+
+	// Data can only be elided if no level below the output level holds
+	// entries in the same key range.
+	outputLevel := c.outputLevel()
+	bottommost := func(start, end []byte) bool {
+		for level := outputLevel + 1; level < numLevels; level++ {
+			files := v.files[level]
+			i := sort.Search(len(files), func(i int) bool { return files[i].containsUpper(d.cmp, start) })
+			if i < len(files) && d.cmp(files[i].smallest, end) <= 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return d.writeTables(iter, tombstones, true /* split */, snapshots, bottommost)
+}
+
+// flushMemTable writes the contents of a memtable to a single table.
+func (d *DB) flushMemTable(m *memTable, snapshots []uint64) ([]*fileMetadata, error) {
+	iter := m.newIter()
+	defer iter.Close()
+	return d.writeTables(iter, m.tombstones(), false /* split */, snapshots,
+		func(start, end []byte) bool { return false })
+}
+
+// writeTables writes the entries of iter to new tables, dropping the entries
+// which are not visible to any reader.
+//
+// Readers at a snapshot see the latest entry of a key with a sequence number
+// at or below the snapshot's. The snapshots partition the sequence numbers
+// into stripes, and only the latest entry of a key in every stripe needs to
+// be retained. Deletions in the oldest stripe are elided if nothing older
+// may exist below the output level, as are range tombstones.
+//
+// The range tombstones are clipped to the key spans of the output tables.
+func (d *DB) writeTables(
+	iter internalIterator,
+	tombstones []rangeTombstone,
+	split bool,
+	snapshots []uint64,
+	bottommost func(start, end []byte) bool,
+) (_ []*fileMetadata, retErr error) {
+	cmp := d.cmp
+	stripe := func(seqNum uint64) int {
+		return sort.Search(len(snapshots), func(i int) bool { return snapshots[i] >= seqNum })
+	}
+	frags := fragmentRangeTombstones(cmp, tombstones)
+	covered := func(key []byte, seqNum uint64) bool {
+		f := frags.find(cmp, key)
+		if f == nil {
+			return false
+		}
+		s := stripe(seqNum)
+		for _, t := range f.seqNums {
+			if t <= seqNum {
+				break
+			}
+			if stripe(t) == s {
+				return true
+			}
+		}
+		return false
+	}
+
+	var outputs []*fileMetadata
+	var firstKeys [][]byte
+	var w *tableWriter
+	defer func() {
+		if retErr == nil {
+			return
+		}
+		if w != nil {
+			_, _ = w.close()
+		}
+		for _, f := range outputs {
+			_ = d.opts.FS.Remove(makeFilename(d.dirname, fileTypeTable, f.fileNum))
+		}
+	}()
+	newOutput := func(firstKey []byte) error {
+		meta := &fileMetadata{fileNum: d.newFileNum()}
+		f, err := d.opts.FS.Create(makeFilename(d.dirname, fileTypeTable, meta.fileNum))
+		if err != nil {
+			return err
+		}
+		w = newTableWriter(f, d.opts)
+		outputs = append(outputs, meta)
+		firstKeys = append(firstKeys, firstKey)
+		return nil
+	}
+	finishOutput := func() error {
+		size, err := w.close()
+		meta := outputs[len(outputs)-1]
+		meta.size = size
+		if w.props.NumEntries > 0 {
+			meta.smallest = w.smallest.userKey
+			meta.largest = w.largest.userKey
+			meta.smallestSeqNum = w.smallestSeqNum
+			meta.largestSeqNum = w.largestSeqNum
+		}
+		w = nil
+		return err
+	}
+
+	var entries []blockEntry
+	for iter.First(); iter.Valid(); {
+		userKey := append([]byte(nil), iter.Key().userKey...)
+		entries = entries[:0]
+		for ; iter.Valid() && cmp(iter.Key().userKey, userKey) == 0; iter.Next() {
+			entries = append(entries, blockEntry{key: iter.Key(), value: iter.Value()})
+		}
+		out, err := d.compactUserKey(userKey, entries, stripe, covered, bottommost)
+		if err != nil {
+			return nil, err
+		}
+		if len(out) == 0 {
+			continue
+		}
+		if w != nil && split && int64(w.estimatedSize()) >= d.opts.TargetFileSize {
+			if err := finishOutput(); err != nil {
+				return nil, err
+			}
+		}
+		if w == nil {
+			if err := newOutput(userKey); err != nil {
+				return nil, err
+			}
+		}
+		for _, e := range out {
+			if err := w.add(e.key, e.value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	var retained []rangeTombstone
+	for _, t := range tombstones {
+		if stripe(t.seqNum) != 0 || !bottommost(t.start, t.end) {
+			retained = append(retained, t)
+		}
+	}
+	if w == nil && len(retained) > 0 {
+		// Only range tombstones remain; they need a table to hold them.
+		if err := newOutput(nil); err != nil {
+			return nil, err
+		}
+	}
+	if w != nil {
+		if err := finishOutput(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Clip the tombstones to the span of every output table: output i spans
+	// [firstKeys[i], firstKeys[i+1]), and the first and last outputs are
+	// unbounded below and above respectively.
+	for i, meta := range outputs {
+		var lo, hi []byte
+		if i > 0 {
+			lo = firstKeys[i]
+		}
+		if i+1 < len(outputs) {
+			hi = firstKeys[i+1]
+		}
+		for _, t := range retained {
+			start, end := t.start, t.end
+			if lo != nil && cmp(start, lo) < 0 {
+				start = lo
+			}
+			if hi != nil && cmp(end, hi) > 0 {
+				end = hi
+			}
+			if cmp(start, end) >= 0 {
+				continue
+			}
+			meta.rangeDels = append(meta.rangeDels, rangeTombstone{start: start, end: end, seqNum: t.seqNum})
+			if meta.smallest == nil || cmp(start, meta.smallest) < 0 {
+				meta.smallest = start
+			}
+			if meta.largest == nil || cmp(end, meta.largest) > 0 {
+				meta.largest = end
+				meta.largestExclusive = true
+			}
+			if meta.smallestSeqNum == 0 || t.seqNum < meta.smallestSeqNum {
+				meta.smallestSeqNum = t.seqNum
+			}
+			if t.seqNum > meta.largestSeqNum {
+				meta.largestSeqNum = t.seqNum
+			}
+		}
+	}
+	return outputs, nil
+}
+
+// compactUserKey returns the entries of a user key which need to be
+// retained, from newest to oldest. See writeTables.
+func (d *DB) compactUserKey(
+	key []byte,
+	entries []blockEntry,
+	stripe func(seqNum uint64) int,
+	covered func(key []byte, seqNum uint64) bool,
+	bottommost func(start, end []byte) bool,
+) ([]blockEntry, error) {
+	var out []blockEntry
+	for i := 0; i < len(entries); {
+		e := entries[i]
+		s := stripe(e.key.seqNum())
+		j := i + 1
+		for j < len(entries) && stripe(entries[j].key.seqNum()) == s {
+			j++
+		}
+		// Entries [i, j) are in the same stripe, and only the newest of them
+		// is visible to any reader (up to merges).
+		lastStripe := j == len(entries)
+		switch {
+		case covered(key, e.key.seqNum()):
+		case e.key.kind() == KindSet:
+			out = append(out, e)
+		case e.key.kind() == KindDelete || e.key.kind() == KindSingleDelete:
+			if s != 0 || !bottommost(key, key) {
+				out = append(out, e)
+			}
+		case e.key.kind() == KindMerge:
+			operands := [][]byte{e.value}
+			k := i + 1
+			for ; k < j && entries[k].key.kind() == KindMerge && !covered(key, entries[k].key.seqNum()); k++ {
+				operands = append(operands, entries[k].value)
+			}
+			for a, b := 0, len(operands)-1; a < b; a, b = a+1, b-1 {
+				operands[a], operands[b] = operands[b], operands[a]
+			}
+			merged := e
+			switch {
+			case k < j:
+				// The operands end at a value, a deletion or an entry deleted
+				// by a range tombstone.
+				var base []byte
+				if next := entries[k]; next.key.kind() == KindSet && !covered(key, next.key.seqNum()) {
+					base = next.value
+				}
+				v, err := d.opts.Merger.FullMerge(key, base, operands)
+				if err != nil {
+					return nil, err
+				}
+				merged = blockEntry{key: makeInternalKey(key, e.key.seqNum(), KindSet), value: v}
+			case lastStripe && s == 0 && bottommost(key, key):
+				v, err := d.opts.Merger.FullMerge(key, nil, operands)
+				if err != nil {
+					return nil, err
+				}
+				merged = blockEntry{key: makeInternalKey(key, e.key.seqNum(), KindSet), value: v}
+			case len(operands) > 1:
+				v, err := d.opts.Merger.PartialMerge(key, operands)
+				if err != nil {
+					return nil, err
+				}
+				merged.value = v
+			}
+			out = append(out, merged)
+		default:
+			out = append(out, e)
+		}
+		i = j
+	}
+	return out, nil
+}
@@ -0,0 +1,1113 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+// Package lsm implements a log-structured merge tree key-value store in pure
+// Go. It is a simplified relative of LevelDB and RocksDB: writes are
+// appended to a write-ahead log and buffered in memtables, which are flushed
+// to sstables in L0 and compacted down a fixed number of levels in the
+// background.
+//
+// The store supports atomic batches in RocksDB's WriteBatch format, merge
+// operators, range deletions, snapshots, ingestion of externally written
+// sstables and table property collectors, which is what the storage engine
+// needs from RocksDB.
+package lsm
+
+import (
+	"container/list"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned by Get when a key does not exist.
+var ErrNotFound = errors.New("lsm: not found")
+
+// ErrClosed is returned by operations on a closed DB.
+var ErrClosed = errors.New("lsm: closed")
+
+// DB is a key-value store. It is safe for concurrent use.
+//
+// Locks are acquired in the order commitMu, compactMu, mu. commitMu
+// serializes writes, compactMu serializes changes to the set of sstables and
+// mu protects the state read by readers.
+type DB struct {
+	dirname  string
+	opts     *Options
+	cmp      func(a, b []byte) int
+	fileLock io.Closer
+	cache    *blockCache
+	tables   *tableCache
+
+	nextFileNum uint64 // accessed atomically
+	// visibleSeqNum is the sequence number of the last published write.
+	// Entries with larger sequence numbers are ignored by readers.
+	visibleSeqNum uint64 // accessed atomically
+
+	commitMu struct {
+		syncutil.Mutex
+		// cond is signaled when a flush or compaction completes, which may
+		// unblock stalled writes.
+		cond sync.Cond
+		wal  *walWriter
+		mem  *memTable
+	}
+
+	compactMu struct {
+		syncutil.Mutex
+		manifestNum uint64
+		// pointers holds the largest key of the table most recently compacted
+		// out of each level.
+		pointers [numLevels][]byte
+	}
+
+	mu struct {
+		syncutil.Mutex
+		readState *readState
+		snapshots *list.List
+		bgErr     error
+		closed    bool
+
+		flushes     int64
+		compactions int64
+		ingestions  int64
+	}
+
+	bgCh    chan struct{}
+	closeCh chan struct{}
+	bgDone  chan struct{}
+}
+
+// readState is the state needed to read from the DB: the memtables, from
+// oldest to newest (the last one being mutable), and the current version.
+type readState struct {
+	refs    int32 // accessed atomically
+	mems    []*memTable
+	version *version
+}
+
+func (s *readState) ref() {
+	atomic.AddInt32(&s.refs, 1)
+}
+
+func (s *readState) unref() {
+	if atomic.AddInt32(&s.refs, -1) == 0 {
+		s.version.unref()
+	}
+}
+
+// Open opens the DB in the given directory, creating it if necessary.
+func Open(dirname string, opts *Options) (_ *DB, retErr error) {
+	opts = opts.EnsureDefaults()
+	fs := opts.FS
+	if err := fs.MkdirAll(dirname, 0755); err != nil {
+		return nil, err
+	}
+	fileLock, err := fs.Lock(makeFilename(dirname, fileTypeLock, 0))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if retErr != nil {
+			_ = fileLock.Close()
+		}
+	}()
+
+	d := &DB{
+		dirname:  dirname,
+		opts:     opts,
+		cmp:      opts.Comparer.Compare,
+		fileLock: fileLock,
+		cache:    newBlockCache(opts.BlockCacheSize),
+		bgCh:     make(chan struct{}, 1),
+		closeCh:  make(chan struct{}),
+		bgDone:   make(chan struct{}),
+	}
+	d.tables = newTableCache(dirname, fs, d.cmp, d.cache)
+	d.commitMu.cond.L = &d.commitMu.Mutex
+	d.mu.snapshots = list.New()
+
+	state := &versionState{nextFileNum: 1}
+	if _, err := fs.Stat(makeFilename(dirname, fileTypeCurrent, 0)); err == nil {
+		if state, _, err = readManifest(fs, dirname); err != nil {
+			return nil, err
+		}
+		if state.comparerName != opts.Comparer.Name {
+			return nil, errors.Errorf("comparer %q does not match %q used to create the DB",
+				opts.Comparer.Name, state.comparerName)
+		}
+		if state.mergerName != opts.Merger.Name {
+			return nil, errors.Errorf("merger %q does not match %q used to create the DB",
+				opts.Merger.Name, state.mergerName)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	} else if opts.ErrorIfNotExists {
+		return nil, errors.Errorf("database %q does not exist", dirname)
+	}
+
+	names, err := fs.List(dirname)
+	if err != nil {
+		return nil, err
+	}
+	d.nextFileNum = state.nextFileNum
+	var logNums []uint64
+	for _, name := range names {
+		typ, num, ok := parseFilename(name)
+		if !ok {
+			continue
+		}
+		if num >= d.nextFileNum {
+			d.nextFileNum = num + 1
+		}
+		if typ == fileTypeLog && num >= state.logNum {
+			logNums = append(logNums, num)
+		}
+	}
+	sort.Slice(logNums, func(i, j int) bool { return logNums[i] < logNums[j] })
+
+	d.visibleSeqNum = state.lastSeqNum
+	files := state.files
+	for level := range files {
+		for _, f := range files[level] {
+			if f.largestSeqNum > d.visibleSeqNum {
+				d.visibleSeqNum = f.largestSeqNum
+			}
+		}
+	}
+
+	// Replay the WALs which were not flushed, flushing each of them to L0.
+	for _, logNum := range logNums {
+		metas, err := d.replayWAL(logNum)
+		if err != nil {
+			return nil, err
+		}
+		files[0] = append(files[0], metas...)
+	}
+
+	logNum := d.newFileNum()
+	logFile, err := fs.Create(makeFilename(dirname, fileTypeLog, logNum))
+	if err != nil {
+		return nil, err
+	}
+	d.commitMu.wal = newWALWriter(logFile)
+	d.commitMu.mem = newMemTable(d.cmp, logNum)
+
+	v := newVersion(d.cmp, d.tables, files)
+	v.ref()
+	d.mu.readState = &readState{refs: 1, mems: []*memTable{d.commitMu.mem}, version: v}
+	d.compactMu.manifestNum = d.newFileNum()
+	if err := writeManifest(fs, dirname, d.compactMu.manifestNum, d.versionState(files, logNum)); err != nil {
+		_ = d.commitMu.wal.close()
+		return nil, err
+	}
+	d.deleteObsoleteFiles(v, logNum)
+
+	go d.bgLoop()
+	d.scheduleBG()
+	return d, nil
+}
+
+func (d *DB) newFileNum() uint64 {
+	return atomic.AddUint64(&d.nextFileNum, 1) - 1
+}
+
+func (d *DB) versionState(files [numLevels][]*fileMetadata, logNum uint64) *versionState {
+	return &versionState{
+		comparerName: d.opts.Comparer.Name,
+		mergerName:   d.opts.Merger.Name,
+		nextFileNum:  atomic.LoadUint64(&d.nextFileNum),
+		logNum:       logNum,
+		lastSeqNum:   atomic.LoadUint64(&d.visibleSeqNum),
+		files:        files,
+	}
+}
+
+// replayWAL applies the batches in a WAL to a new memtable and flushes it.
+func (d *DB) replayWAL(logNum uint64) ([]*fileMetadata, error) {
+	f, err := d.opts.FS.Open(makeFilename(d.dirname, fileTypeLog, logNum))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	mem := newMemTable(d.cmp, logNum)
+	if err := replayWAL(f, func(repr []byte) error {
+		_, seqNum, _, err := newBatchReader(repr)
+		if err != nil {
+			return err
+		}
+		count, err := mem.apply(repr, seqNum)
+		if err != nil {
+			return err
+		}
+		if last := seqNum + uint64(count) - 1; count > 0 && last > d.visibleSeqNum {
+			d.visibleSeqNum = last
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "replaying WAL %06d", logNum)
+	}
+	if mem.empty() {
+		return nil, nil
+	}
+	return d.flushMemTable(mem, nil /* snapshots */)
+}
+
+// deleteObsoleteFiles removes the files in the directory which are not
+// referenced by the current state.
+func (d *DB) deleteObsoleteFiles(v *version, logNum uint64) {
+	live := map[uint64]struct{}{}
+	for level := range v.files {
+		for _, f := range v.files[level] {
+			live[f.fileNum] = struct{}{}
+		}
+	}
+	names, err := d.opts.FS.List(d.dirname)
+	if err != nil {
+		return
+	}
+	for _, name := range names {
+		typ, num, ok := parseFilename(name)
+		if !ok {
+			continue
+		}
+		var obsolete bool
+		switch typ {
+		case fileTypeLog:
+			obsolete = num < logNum
+		case fileTypeTable:
+			_, isLive := live[num]
+			obsolete = !isLive
+		case fileTypeManifest:
+			obsolete = num != d.compactMu.manifestNum
+		case fileTypeTemp:
+			obsolete = true
+		}
+		if obsolete {
+			_ = d.opts.FS.Remove(filepath.Join(d.dirname, name))
+		}
+	}
+}
+
+func (d *DB) loadReadState() *readState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.mu.readState
+	s.ref()
+	return s
+}
+
+// installVersion persists a new set of tables and installs it. If flushed is
+// non-nil, the memtable is removed from the read state and its WAL is
+// deleted. compactMu must be held.
+func (d *DB) installVersion(files [numLevels][]*fileMetadata, flushed *memTable) error {
+	d.mu.Lock()
+	mems := d.mu.readState.mems
+	d.mu.Unlock()
+	if flushed != nil {
+		mems = mems[1:]
+	}
+	// Only flushes remove memtables, and they hold compactMu, so the oldest
+	// remaining memtable cannot change concurrently.
+	logNum := mems[0].logNum
+
+	manifestNum := d.newFileNum()
+	if err := writeManifest(d.opts.FS, d.dirname, manifestNum, d.versionState(files, logNum)); err != nil {
+		return err
+	}
+	_ = d.opts.FS.Remove(makeFilename(d.dirname, fileTypeManifest, d.compactMu.manifestNum))
+	d.compactMu.manifestNum = manifestNum
+
+	v := newVersion(d.cmp, d.tables, files)
+	v.ref()
+	d.mu.Lock()
+	old := d.mu.readState
+	mems = old.mems
+	if flushed != nil {
+		mems = mems[1:]
+	}
+	d.mu.readState = &readState{refs: 1, mems: mems, version: v}
+	d.mu.Unlock()
+	old.unref()
+
+	if flushed != nil {
+		_ = d.opts.FS.Remove(makeFilename(d.dirname, fileTypeLog, flushed.logNum))
+	}
+	return nil
+}
+
+// cloneFiles returns a copy of the file lists of a version.
+func cloneFiles(v *version) [numLevels][]*fileMetadata {
+	var files [numLevels][]*fileMetadata
+	for level := range v.files {
+		files[level] = append([]*fileMetadata(nil), v.files[level]...)
+	}
+	return files
+}
+
+// Apply atomically applies the contents of a batch. If sync is set, the
+// batch is durable once Apply returns.
+func (d *DB) Apply(b *Batch, sync bool) error {
+	if b.Empty() {
+		return nil
+	}
+	repr := b.Repr()
+
+	d.commitMu.Lock()
+	defer d.commitMu.Unlock()
+	if err := d.makeRoomForWrite(); err != nil {
+		return err
+	}
+	seqNum := atomic.LoadUint64(&d.visibleSeqNum) + 1
+	binary.LittleEndian.PutUint64(repr[:8], seqNum)
+	if err := d.commitMu.wal.add(repr); err != nil {
+		return d.setBGError(err)
+	}
+	var err error
+	if sync {
+		err = d.commitMu.wal.sync()
+	} else {
+		err = d.commitMu.wal.flush()
+	}
+	if err != nil {
+		return d.setBGError(err)
+	}
+	count, err := d.commitMu.mem.apply(repr, seqNum)
+	if err != nil {
+		return d.setBGError(err)
+	}
+	if count > 0 {
+		atomic.StoreUint64(&d.visibleSeqNum, seqNum+uint64(count)-1)
+	}
+	return nil
+}
+
+func (d *DB) setBGError(err error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.mu.bgErr == nil {
+		d.mu.bgErr = err
+	}
+	return err
+}
+
+// writeStatus returns the number of memtables and L0 tables, and the error
+// with which writes must fail.
+func (d *DB) writeStatus() (numMems, numL0 int, _ error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.mu.closed {
+		return 0, 0, ErrClosed
+	}
+	return len(d.mu.readState.mems), len(d.mu.readState.version.files[0]), d.mu.bgErr
+}
+
+// makeRoomForWrite rotates the memtable if it is full, stalling while too
+// many memtables or L0 tables are waiting to be flushed or compacted.
+// commitMu must be held.
+func (d *DB) makeRoomForWrite() error {
+	for {
+		numMems, numL0, err := d.writeStatus()
+		if err != nil {
+			return err
+		}
+		if d.commitMu.mem.approximateSize() < d.opts.MemTableSize {
+			if numL0 >= d.opts.L0StopWritesThreshold && !d.opts.DisableAutomaticCompactions {
+				d.scheduleBG()
+				d.commitMu.cond.Wait()
+				continue
+			}
+			return nil
+		}
+		if numMems >= d.opts.MemTableStopWritesThreshold {
+			d.scheduleBG()
+			d.commitMu.cond.Wait()
+			continue
+		}
+		if err := d.rotateMemTable(); err != nil {
+			return d.setBGError(err)
+		}
+		d.scheduleBG()
+		return nil
+	}
+}
+
+// rotateMemTable switches to a new WAL and memtable, leaving the current
+// memtable to be flushed. commitMu must be held.
+func (d *DB) rotateMemTable() error {
+	logNum := d.newFileNum()
+	f, err := d.opts.FS.Create(makeFilename(d.dirname, fileTypeLog, logNum))
+	if err != nil {
+		return err
+	}
+	if err := d.commitMu.wal.close(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	d.commitMu.wal = newWALWriter(f)
+	d.commitMu.mem = newMemTable(d.cmp, logNum)
+
+	d.mu.Lock()
+	old := d.mu.readState
+	old.version.ref()
+	d.mu.readState = &readState{
+		refs:    1,
+		mems:    append(old.mems[:len(old.mems):len(old.mems)], d.commitMu.mem),
+		version: old.version,
+	}
+	d.mu.Unlock()
+	old.unref()
+	return nil
+}
+
+func (d *DB) scheduleBG() {
+	select {
+	case d.bgCh <- struct{}{}:
+	default:
+	}
+}
+
+// bgLoop performs flushes and compactions in the background.
+func (d *DB) bgLoop() {
+	defer close(d.bgDone)
+	for {
+		select {
+		case <-d.bgCh:
+		case <-d.closeCh:
+			return
+		}
+		d.compactMu.Lock()
+		err := d.flushLocked()
+		if err == nil && !d.opts.DisableAutomaticCompactions {
+			err = d.compactLocked()
+		}
+		d.compactMu.Unlock()
+		if err != nil {
+			_ = d.setBGError(err)
+		}
+		d.commitMu.Lock()
+		d.commitMu.cond.Broadcast()
+		d.commitMu.Unlock()
+	}
+}
+
+func (d *DB) closing() bool {
+	select {
+	case <-d.closeCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// snapshotSeqNums returns the sequence numbers of the open snapshots in
+// increasing order.
+func (d *DB) snapshotSeqNums() []uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var seqNums []uint64
+	for e := d.mu.snapshots.Front(); e != nil; e = e.Next() {
+		seqNums = append(seqNums, e.Value.(*Snapshot).seqNum)
+	}
+	return seqNums
+}
+
+// flushLocked flushes the immutable memtables to L0, oldest first.
+// compactMu must be held.
+func (d *DB) flushLocked() error {
+	for {
+		s := d.loadReadState()
+		if len(s.mems) == 1 {
+			s.unref()
+			return nil
+		}
+		mem := s.mems[0]
+		metas, err := d.flushMemTable(mem, d.snapshotSeqNums())
+		if err != nil {
+			s.unref()
+			return err
+		}
+		files := cloneFiles(s.version)
+		files[0] = append(files[0], metas...)
+		s.unref()
+		if err := d.installVersion(files, mem); err != nil {
+			return err
+		}
+		d.mu.Lock()
+		d.mu.flushes++
+		d.mu.Unlock()
+	}
+}
+
+// compactLocked runs compactions until no level needs to be compacted.
+// compactMu must be held.
+func (d *DB) compactLocked() error {
+	for !d.closing() {
+		s := d.loadReadState()
+		c := d.pickCompaction(s.version)
+		var err error
+		if c != nil {
+			err = d.runAndInstallCompaction(s.version, c)
+		}
+		s.unref()
+		if c == nil || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runAndInstallCompaction runs a compaction and installs its outputs.
+// compactMu must be held.
+func (d *DB) runAndInstallCompaction(v *version, c *compaction) error {
+	var outputs []*fileMetadata
+	if len(c.inputs[0]) == 1 && len(c.inputs[1]) == 0 && c.outputLevel() != c.level {
+		// Nothing overlaps the input in the output level, so the table can be
+		// moved rather than rewritten.
+		outputs = c.inputs[0]
+	} else {
+		var err error
+		if outputs, err = d.runCompaction(v, c, d.snapshotSeqNums()); err != nil {
+			return err
+		}
+	}
+	inputs := map[*fileMetadata]struct{}{}
+	for _, files := range c.inputs {
+		for _, f := range files {
+			inputs[f] = struct{}{}
+		}
+	}
+	var files [numLevels][]*fileMetadata
+	for level := range v.files {
+		for _, f := range v.files[level] {
+			if _, ok := inputs[f]; !ok {
+				files[level] = append(files[level], f)
+			}
+		}
+	}
+	files[c.outputLevel()] = append(files[c.outputLevel()], outputs...)
+	if err := d.installVersion(files, nil); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.mu.compactions++
+	d.mu.Unlock()
+	return nil
+}
+
+// Flush flushes the memtables to L0.
+func (d *DB) Flush() error {
+	d.commitMu.Lock()
+	if _, _, err := d.writeStatus(); err != nil {
+		d.commitMu.Unlock()
+		return err
+	}
+	var err error
+	if !d.commitMu.mem.empty() {
+		err = d.rotateMemTable()
+	}
+	d.commitMu.Unlock()
+	if err != nil {
+		return d.setBGError(err)
+	}
+	d.compactMu.Lock()
+	err = d.flushLocked()
+	d.compactMu.Unlock()
+	if err == nil {
+		// The flushed tables may need to be compacted.
+		d.scheduleBG()
+	}
+	return err
+}
+
+// Compact flushes the memtables and compacts the tables overlapping
+// [start, end] down to the bottom level. A nil start or end is unbounded.
+func (d *DB) Compact(start, end []byte) error {
+	if err := d.Flush(); err != nil {
+		return err
+	}
+	d.compactMu.Lock()
+	defer d.compactMu.Unlock()
+	for level := 0; level < numLevels; level++ {
+		s := d.loadReadState()
+		c := d.manualCompaction(s.version, level, start, end)
+		var err error
+		if c != nil {
+			err = d.runAndInstallCompaction(s.version, c)
+		}
+		s.unref()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ingest adds externally written sstables (see TableWriter) to the DB. The
+// tables are linked, or copied if linking fails, into the DB's directory;
+// the source files are left in place. The entries of the ingested tables are
+// newer than all existing entries.
+func (d *DB) Ingest(paths []string) error {
+	d.commitMu.Lock()
+	defer d.commitMu.Unlock()
+	if _, _, err := d.writeStatus(); err != nil {
+		return err
+	}
+	// The ingested tables are placed in L0 with sequence numbers larger than
+	// those of all existing entries, which requires flushing the memtables.
+	if !d.commitMu.mem.empty() {
+		if err := d.rotateMemTable(); err != nil {
+			return d.setBGError(err)
+		}
+	}
+	d.compactMu.Lock()
+	defer d.compactMu.Unlock()
+	if err := d.flushLocked(); err != nil {
+		return err
+	}
+
+	seqNum := atomic.LoadUint64(&d.visibleSeqNum)
+	var metas []*fileMetadata
+	for _, path := range paths {
+		meta, err := d.ingestFile(path, seqNum+1)
+		if err != nil {
+			for _, m := range metas {
+				_ = d.opts.FS.Remove(makeFilename(d.dirname, fileTypeTable, m.fileNum))
+			}
+			return err
+		}
+		if meta != nil {
+			seqNum++
+			metas = append(metas, meta)
+		}
+	}
+	if len(metas) == 0 {
+		return nil
+	}
+
+	s := d.loadReadState()
+	files := cloneFiles(s.version)
+	s.unref()
+	files[0] = append(files[0], metas...)
+	atomic.StoreUint64(&d.visibleSeqNum, seqNum)
+	if err := d.installVersion(files, nil); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.mu.ingestions += int64(len(metas))
+	d.mu.Unlock()
+	d.scheduleBG()
+	return nil
+}
+
+// ingestFile links a table into the DB's directory and returns its
+// metadata, or nil if the table is empty.
+func (d *DB) ingestFile(path string, seqNum uint64) (*fileMetadata, error) {
+	fs := d.opts.FS
+	meta := &fileMetadata{
+		fileNum:        d.newFileNum(),
+		smallestSeqNum: seqNum,
+		largestSeqNum:  seqNum,
+		globalSeqNum:   seqNum,
+	}
+	name := makeFilename(d.dirname, fileTypeTable, meta.fileNum)
+	if err := fs.Link(path, name); err != nil {
+		if err := copyFile(fs, path, name); err != nil {
+			return nil, err
+		}
+	}
+	f, err := fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	// The table is read without going through the DB's block cache, which
+	// would otherwise cache its blocks without the global sequence number.
+	r, err := openTable(f, meta.fileNum, d.cmp, newBlockCache(1), 0)
+	if err != nil {
+		_ = fs.Remove(name)
+		return nil, err
+	}
+	defer r.close()
+	it := r.newIter()
+	if it.First(); it.Valid() {
+		meta.smallest = append([]byte(nil), it.Key().userKey...)
+	}
+	if it.Last(); it.Valid() {
+		meta.largest = append([]byte(nil), it.Key().userKey...)
+	}
+	if err := it.Error(); err != nil {
+		_ = fs.Remove(name)
+		return nil, err
+	}
+	if meta.smallest == nil {
+		_ = fs.Remove(name)
+		return nil, nil
+	}
+	info, err := fs.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	meta.size = uint64(info.Size())
+	return meta, nil
+}
+
+func copyFile(fs FS, src, dst string) error {
+	in, err := fs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := fs.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Checkpoint creates a consistent copy of the DB in dir, which must not
+// exist. The checkpoint contains at least the writes which were committed
+// before Checkpoint was called. Tables are hard linked where possible.
+func (d *DB) Checkpoint(dir string) error {
+	fs := d.opts.FS
+	if _, err := fs.Stat(dir); err == nil {
+		return errors.Errorf("checkpoint directory %s already exists", dir)
+	}
+	if err := d.Flush(); err != nil {
+		return err
+	}
+	d.compactMu.Lock()
+	defer d.compactMu.Unlock()
+	if err := fs.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	s := d.loadReadState()
+	defer s.unref()
+	files := s.version.files
+	for level := range files {
+		for _, f := range files[level] {
+			src := makeFilename(d.dirname, fileTypeTable, f.fileNum)
+			dst := makeFilename(dir, fileTypeTable, f.fileNum)
+			if err := fs.Link(src, dst); err != nil {
+				if err := copyFile(fs, src, dst); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	state := d.versionState(files, atomic.LoadUint64(&d.nextFileNum))
+	return writeManifest(fs, dir, d.newFileNum(), state)
+}
+
+// Snapshot is a consistent view of the DB at the time it was created.
+// Snapshots prevent compactions from discarding the entries they can see and
+// must be closed.
+type Snapshot struct {
+	db     *DB
+	seqNum uint64
+	elem   *list.Element
+}
+
+// NewSnapshot returns a snapshot of the current state of the DB.
+func (d *DB) NewSnapshot() *Snapshot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := &Snapshot{db: d, seqNum: atomic.LoadUint64(&d.visibleSeqNum)}
+	s.elem = d.mu.snapshots.PushBack(s)
+	return s
+}
+
+// Get returns the value of key in the snapshot.
+func (s *Snapshot) Get(key []byte) ([]byte, error) {
+	return s.db.get(key, s.seqNum)
+}
+
+// NewIter returns an iterator over the snapshot.
+func (s *Snapshot) NewIter(o *IterOptions) *Iterator {
+	return s.db.newIter(s.seqNum, s.db.loadReadState(), nil, o)
+}
+
+// Close releases the snapshot.
+func (s *Snapshot) Close() error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if s.elem != nil {
+		s.db.mu.snapshots.Remove(s.elem)
+		s.elem = nil
+	}
+	return nil
+}
+
+// NewBatch returns a write-only batch.
+func (d *DB) NewBatch() *Batch {
+	return newBatch(d, false)
+}
+
+// NewIndexedBatch returns a batch which can be read from with NewIter.
+func (d *DB) NewIndexedBatch() *Batch {
+	return newBatch(d, true)
+}
+
+// Set sets the value of a key.
+func (d *DB) Set(key, value []byte, sync bool) error {
+	b := d.NewBatch()
+	b.Set(key, value)
+	return d.Apply(b, sync)
+}
+
+// Delete deletes a key.
+func (d *DB) Delete(key []byte, sync bool) error {
+	b := d.NewBatch()
+	b.Delete(key)
+	return d.Apply(b, sync)
+}
+
+// Merge adds a merge operand to a key.
+func (d *DB) Merge(key, value []byte, sync bool) error {
+	b := d.NewBatch()
+	b.Merge(key, value)
+	return d.Apply(b, sync)
+}
+
+// DeleteRange deletes the keys in [start, end).
+func (d *DB) DeleteRange(start, end []byte, sync bool) error {
+	b := d.NewBatch()
+	b.DeleteRange(start, end)
+	return d.Apply(b, sync)
+}
+
+// Get returns the value of key. It returns ErrNotFound if the key does not
+// exist.
+func (d *DB) Get(key []byte) ([]byte, error) {
+	return d.get(key, atomic.LoadUint64(&d.visibleSeqNum))
+}
+
+func (d *DB) get(key []byte, seqNum uint64) ([]byte, error) {
+	// The upper bound cannot be derived from the key without knowing the
+	// ordering imposed by the comparer, so the key found is checked instead.
+	it := d.newIter(seqNum, d.loadReadState(), nil, &IterOptions{LowerBound: key})
+	defer it.Close()
+	if !it.SeekGE(key) || d.cmp(it.Key(), key) != 0 {
+		if err := it.Error(); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), it.Value()...), nil
+}
+
+// NewIter returns an iterator over the current state of the DB.
+func (d *DB) NewIter(o *IterOptions) *Iterator {
+	// The sequence number must be loaded before the read state: every entry
+	// published before that is then either in one of the read state's
+	// memtables or in its version.
+	seqNum := atomic.LoadUint64(&d.visibleSeqNum)
+	return d.newIter(seqNum, d.loadReadState(), nil, o)
+}
+
+// NewIter returns an iterator over the batch combined with the current state
+// of the DB. The iterator observes writes made to the batch after its
+// creation whenever it is repositioned with a seek. The batch must be
+// indexed, and must not be reset while the iterator is open.
+func (b *Batch) NewIter(o *IterOptions) *Iterator {
+	it := b.newIter(o)
+	it.refreshBatch = true
+	return it
+}
+
+// NewIterAtCurrentCount is like NewIter, but the iterator only observes the
+// writes which were made to the batch before its creation.
+func (b *Batch) NewIterAtCurrentCount(o *IterOptions) *Iterator {
+	return b.newIter(o)
+}
+
+func (b *Batch) newIter(o *IterOptions) *Iterator {
+	if b.index == nil {
+		panic("lsm: NewIter called on a batch which is not indexed")
+	}
+	d := b.db
+	seqNum := atomic.LoadUint64(&d.visibleSeqNum)
+	return d.newIter(seqNum, d.loadReadState(), b, o)
+}
+
+func (d *DB) newIter(seqNum uint64, s *readState, b *Batch, o *IterOptions) *Iterator {
+	it := &Iterator{
+		cmp:       d.cmp,
+		merger:    d.opts.Merger,
+		readState: s,
+		seqNum:    seqNum,
+		batch:     b,
+	}
+	if o != nil {
+		it.opts = *o
+	}
+	var iters []internalIterator
+	if b != nil {
+		it.batchCount = uint64(b.Count())
+		iters = append(iters, &skiplistIter{list: b.index})
+	}
+	for i := len(s.mems) - 1; i >= 0; i-- {
+		iters = append(iters, s.mems[i].newIter())
+		if frags := s.mems[i].rangeDelFragments(); len(frags) > 0 {
+			it.rangeDels = append(it.rangeDels, frags)
+		}
+	}
+	v := s.version
+	for i := len(v.files[0]) - 1; i >= 0; i-- {
+		iters = append(iters, &levelIter{cmp: d.cmp, tables: d.tables, files: v.files[0][i : i+1], opts: &it.opts})
+	}
+	for level := 1; level < numLevels; level++ {
+		if len(v.files[level]) > 0 {
+			iters = append(iters, &levelIter{cmp: d.cmp, tables: d.tables, files: v.files[level], opts: &it.opts})
+		}
+	}
+	if len(v.rangeDels) > 0 {
+		it.rangeDels = append(it.rangeDels, v.rangeDels)
+	}
+	it.iter = newMergingIter(d.cmp, iters)
+	return it
+}
+
+// LevelMetrics describes the tables of a level.
+type LevelMetrics struct {
+	NumFiles int
+	Size     uint64
+}
+
+// Metrics describes the state of a DB.
+type Metrics struct {
+	Levels       [numLevels]LevelMetrics
+	NumMemTables int
+	// MemTableSize is the approximate size of all memtables.
+	MemTableSize int64
+
+	BlockCacheSize   int64
+	BlockCacheHits   int64
+	BlockCacheMisses int64
+
+	Flushes     int64
+	Compactions int64
+	Ingestions  int64
+	// PendingCompactionBytes estimates the number of bytes by which the
+	// levels exceed their target sizes.
+	PendingCompactionBytes uint64
+}
+
+// Metrics returns the current metrics of the DB.
+func (d *DB) Metrics() Metrics {
+	var m Metrics
+	s := d.loadReadState()
+	defer s.unref()
+	for level := range s.version.files {
+		m.Levels[level].NumFiles = len(s.version.files[level])
+		m.Levels[level].Size = s.version.levelSize(level)
+		if level > 0 && level < numLevels-1 {
+			if target := uint64(d.maxBytesForLevel(level)); m.Levels[level].Size > target {
+				m.PendingCompactionBytes += m.Levels[level].Size - target
+			}
+		}
+	}
+	if m.Levels[0].NumFiles >= d.opts.L0CompactionThreshold {
+		m.PendingCompactionBytes += m.Levels[0].Size
+	}
+	m.NumMemTables = len(s.mems)
+	for _, mem := range s.mems {
+		m.MemTableSize += mem.approximateSize()
+	}
+	m.BlockCacheSize, m.BlockCacheHits, m.BlockCacheMisses = d.cache.metrics()
+	d.mu.Lock()
+	m.Flushes, m.Compactions, m.Ingestions = d.mu.flushes, d.mu.compactions, d.mu.ingestions
+	d.mu.Unlock()
+	return m
+}
+
+// EstimateDiskUsage returns the total size of the tables which overlap
+// [start, end].
+func (d *DB) EstimateDiskUsage(start, end []byte) uint64 {
+	s := d.loadReadState()
+	defer s.unref()
+	var size uint64
+	for level := range s.version.files {
+		for _, f := range s.version.overlaps(d.cmp, level, start, end) {
+			size += f.size
+		}
+	}
+	return size
+}
+
+// TableInfo describes an sstable.
+type TableInfo struct {
+	Level    int
+	FileNum  uint64
+	Size     uint64
+	Smallest []byte
+	Largest  []byte
+}
+
+// SSTables returns a description of every sstable.
+func (d *DB) SSTables() []TableInfo {
+	s := d.loadReadState()
+	defer s.unref()
+	var infos []TableInfo
+	for level := range s.version.files {
+		for _, f := range s.version.files[level] {
+			infos = append(infos, TableInfo{
+				Level:    level,
+				FileNum:  f.fileNum,
+				Size:     f.size,
+				Smallest: f.smallest,
+				Largest:  f.largest,
+			})
+		}
+	}
+	return infos
+}
+
+// Close closes the DB. Iterators and snapshots must be closed first. Writes
+// which were not synced are flushed to the WAL but not synced.
+func (d *DB) Close() error {
+	d.mu.Lock()
+	if d.mu.closed {
+		d.mu.Unlock()
+		return ErrClosed
+	}
+	d.mu.closed = true
+	d.mu.Unlock()
+
+	close(d.closeCh)
+	<-d.bgDone
+
+	d.commitMu.Lock()
+	d.commitMu.cond.Broadcast()
+	err := d.commitMu.wal.close()
+	d.commitMu.Unlock()
+
+	d.tables.close()
+	if e := d.fileLock.Close(); err == nil {
+		err = e
+	}
+	return err
+}
@@ -0,0 +1,515 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func openTestDB(t *testing.T, fs FS, opts *Options) *DB {
+	t.Helper()
+	if opts == nil {
+		opts = &Options{}
+	}
+	opts.FS = fs
+	d, err := Open("/db", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// scan returns the key/value pairs of the iterator, iterating forward or in
+// reverse.
+func scan(t *testing.T, it *Iterator, reverse bool) []string {
+	t.Helper()
+	var res []string
+	if !reverse {
+		for ok := it.First(); ok; ok = it.Next() {
+			res = append(res, fmt.Sprintf("%s=%s", it.Key(), it.Value()))
+		}
+	} else {
+		for ok := it.Last(); ok; ok = it.Prev() {
+			res = append([]string{fmt.Sprintf("%s=%s", it.Key(), it.Value())}, res...)
+		}
+	}
+	if err := it.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestDBBasic(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	for _, flush := range []bool{false, true} {
+		t.Run(fmt.Sprintf("flush=%t", flush), func(t *testing.T) {
+			d := openTestDB(t, NewMemFS(), nil)
+			defer d.Close()
+
+			b := d.NewBatch()
+			b.Set([]byte("a"), []byte("1"))
+			b.Set([]byte("b"), []byte("2"))
+			b.Set([]byte("c"), []byte("3"))
+			b.Merge([]byte("m"), []byte("x"))
+			b.Set([]byte("z"), []byte("26"))
+			if err := b.Commit(true); err != nil {
+				t.Fatal(err)
+			}
+			if err := d.Delete([]byte("b"), false); err != nil {
+				t.Fatal(err)
+			}
+			if err := d.Merge([]byte("m"), []byte("y"), false); err != nil {
+				t.Fatal(err)
+			}
+			if flush {
+				if err := d.Flush(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := d.Merge([]byte("m"), []byte("z"), false); err != nil {
+				t.Fatal(err)
+			}
+			if err := d.DeleteRange([]byte("c"), []byte("m"), false); err != nil {
+				t.Fatal(err)
+			}
+
+			if v, err := d.Get([]byte("a")); err != nil || string(v) != "1" {
+				t.Fatalf("expected 1, got %q, %v", v, err)
+			}
+			for _, k := range []string{"b", "c", "d"} {
+				if _, err := d.Get([]byte(k)); err != ErrNotFound {
+					t.Fatalf("%s: expected not found, got %v", k, err)
+				}
+			}
+
+			expected := []string{"a=1", "m=xyz", "z=26"}
+			for _, reverse := range []bool{false, true} {
+				if res := scan(t, d.NewIter(nil), reverse); !reflect.DeepEqual(expected, res) {
+					t.Fatalf("reverse=%t: expected %v, got %v", reverse, expected, res)
+				}
+			}
+			bounded := d.NewIter(&IterOptions{LowerBound: []byte("b"), UpperBound: []byte("n")})
+			if res := scan(t, bounded, true); !reflect.DeepEqual([]string{"m=xyz"}, res) {
+				t.Fatalf("unexpected bounded scan %v", res)
+			}
+
+			it := d.NewIter(nil)
+			if !it.SeekGE([]byte("b")) || string(it.Key()) != "m" {
+				t.Fatalf("unexpected SeekGE result %q", it.Key())
+			}
+			if !it.SeekLT([]byte("m")) || string(it.Key()) != "a" {
+				t.Fatalf("unexpected SeekLT result %q", it.Key())
+			}
+			if it.Prev() {
+				t.Fatalf("unexpected key %q before a", it.Key())
+			}
+			if err := it.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestDBSnapshot(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	d := openTestDB(t, NewMemFS(), &Options{DisableAutomaticCompactions: true})
+	defer d.Close()
+
+	if err := d.Set([]byte("a"), []byte("1"), false); err != nil {
+		t.Fatal(err)
+	}
+	snap := d.NewSnapshot()
+	if err := d.Set([]byte("a"), []byte("2"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.DeleteRange([]byte("a"), []byte("b"), false); err != nil {
+		t.Fatal(err)
+	}
+	// Compacting must preserve the value visible to the snapshot.
+	if err := d.Compact(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := snap.Get([]byte("a")); err != nil || string(v) != "1" {
+		t.Fatalf("expected 1, got %q, %v", v, err)
+	}
+	if _, err := d.Get([]byte("a")); err != ErrNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := snap.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Without the snapshot, a compaction drops the key and the tombstone.
+	if err := d.Compact(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if tables := d.SSTables(); len(tables) != 0 {
+		t.Fatalf("expected no tables, got %+v", tables)
+	}
+}
+
+func TestDBIndexedBatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	d := openTestDB(t, NewMemFS(), nil)
+	defer d.Close()
+
+	if err := d.Set([]byte("a"), []byte("1"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set([]byte("b"), []byte("2"), false); err != nil {
+		t.Fatal(err)
+	}
+	b := d.NewIndexedBatch()
+	b.Set([]byte("c"), []byte("3"))
+	b.Merge([]byte("a"), []byte("x"))
+
+	live := b.NewIter(nil)
+	frozen := b.NewIterAtCurrentCount(nil)
+	b.DeleteRange([]byte("b"), []byte("c"))
+
+	if res := scan(t, live, false); !reflect.DeepEqual([]string{"a=1x", "c=3"}, res) {
+		t.Fatalf("unexpected live batch scan %v", res)
+	}
+	if res := scan(t, frozen, false); !reflect.DeepEqual([]string{"a=1x", "b=2", "c=3"}, res) {
+		t.Fatalf("unexpected frozen batch scan %v", res)
+	}
+	if res := scan(t, d.NewIter(nil), false); !reflect.DeepEqual([]string{"a=1", "b=2"}, res) {
+		t.Fatalf("unexpected scan before commit %v", res)
+	}
+	if err := b.Commit(false); err != nil {
+		t.Fatal(err)
+	}
+	if res := scan(t, d.NewIter(nil), false); !reflect.DeepEqual([]string{"a=1x", "c=3"}, res) {
+		t.Fatalf("unexpected scan after commit %v", res)
+	}
+}
+
+func TestDBReopen(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := NewMemFS()
+	d := openTestDB(t, fs, nil)
+	for i := 0; i < 100; i++ {
+		if err := d.Set([]byte(fmt.Sprintf("%03d", i)), []byte(strconv.Itoa(i)), false); err != nil {
+			t.Fatal(err)
+		}
+		if i == 50 {
+			if err := d.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := d.DeleteRange([]byte("010"), []byte("090"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d = openTestDB(t, fs, nil)
+	res := scan(t, d.NewIter(nil), false)
+	if len(res) != 20 || res[0] != "000=0" || res[10] != "090=90" {
+		t.Fatalf("unexpected scan after reopen %v", res)
+	}
+	// Writes after reopening must be newer than the replayed ones.
+	if err := d.Set([]byte("050"), []byte("new"), false); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := d.Get([]byte("050")); err != nil || string(v) != "new" {
+		t.Fatalf("expected new, got %q, %v", v, err)
+	}
+	if _, err := Open("/db", &Options{FS: fs}); err == nil {
+		t.Fatal("expected the DB to be locked")
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open("/db", &Options{FS: fs, Comparer: &Comparer{Compare: bytes.Compare, Name: "other"}}); err == nil {
+		t.Fatal("expected error opening DB with a different comparer")
+	}
+}
+
+func TestDBIngest(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := NewMemFS()
+	d := openTestDB(t, fs, nil)
+	defer d.Close()
+
+	if err := d.Set([]byte("a"), []byte("old"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set([]byte("c"), []byte("old"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.MkdirAll("/ext", 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fs.Create("/ext/1.sst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewTableWriter(f, &Options{})
+	if err := w.Set([]byte("a"), []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Delete([]byte("c")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Ingest([]string{"/ext/1.sst"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set([]byte("c"), []byte("newest"), false); err != nil {
+		t.Fatal(err)
+	}
+	if res := scan(t, d.NewIter(nil), false); !reflect.DeepEqual([]string{"a=new", "c=newest"}, res) {
+		t.Fatalf("unexpected scan after ingest %v", res)
+	}
+	if err := d.Compact(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if res := scan(t, d.NewIter(nil), false); !reflect.DeepEqual([]string{"a=new", "c=newest"}, res) {
+		t.Fatalf("unexpected scan after compaction %v", res)
+	}
+}
+
+func TestDBCheckpoint(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := NewMemFS()
+	d := openTestDB(t, fs, nil)
+	defer d.Close()
+	if err := d.Set([]byte("a"), []byte("1"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Checkpoint("/checkpoint"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set([]byte("b"), []byte("2"), false); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Open("/checkpoint", &Options{FS: fs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if res := scan(t, c.NewIter(nil), false); !reflect.DeepEqual([]string{"a=1"}, res) {
+		t.Fatalf("unexpected checkpoint contents %v", res)
+	}
+}
+
+type countingCollector struct {
+	count int
+}
+
+func (c *countingCollector) Add(key []byte, kind Kind, value []byte) error {
+	c.count++
+	return nil
+}
+
+func (c *countingCollector) Finish(userProps map[string]string) error {
+	userProps["count"] = strconv.Itoa(c.count)
+	return nil
+}
+
+func TestDBTableFilter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	d := openTestDB(t, NewMemFS(), &Options{
+		DisableAutomaticCompactions: true,
+		TablePropertyCollectors: []func() TablePropertyCollector{
+			func() TablePropertyCollector { return &countingCollector{} },
+		},
+	})
+	defer d.Close()
+	for i, keys := range [][]string{{"a"}, {"b", "c"}} {
+		for _, k := range keys {
+			if err := d.Set([]byte(k), []byte(strconv.Itoa(i)), false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := d.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	it := d.NewIter(&IterOptions{TableFilter: func(userProps map[string]string) bool {
+		return userProps["count"] == "2"
+	}})
+	if res := scan(t, it, false); !reflect.DeepEqual([]string{"b=1", "c=1"}, res) {
+		t.Fatalf("unexpected filtered scan %v", res)
+	}
+}
+
+// TestDBRandomized compares the DB against a simple model under random
+// writes, flushes and compactions, with and without snapshots.
+func TestDBRandomized(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+	fs := NewMemFS()
+	opts := &Options{
+		MemTableSize:          4 << 10,
+		L0CompactionThreshold: 2,
+		LBaseMaxBytes:         16 << 10,
+		TargetFileSize:        2 << 10,
+		BlockSize:             256,
+		BlockCacheSize:        16 << 10,
+	}
+	d := openTestDB(t, fs, opts)
+	defer func() { _ = d.Close() }()
+
+	model := map[string]string{}
+	type snapshot struct {
+		snap  *Snapshot
+		model map[string]string
+	}
+	var snaps []snapshot
+	randKey := func() string { return fmt.Sprintf("%04d", rng.Intn(500)) }
+
+	check := func(s *Snapshot, model map[string]string) {
+		t.Helper()
+		var it *Iterator
+		if s != nil {
+			it = s.NewIter(nil)
+		} else {
+			it = d.NewIter(nil)
+		}
+		var expected []string
+		for k, v := range model {
+			expected = append(expected, k+"="+v)
+		}
+		sort.Strings(expected)
+		reverse := rng.Intn(2) == 0
+		if res := scan(t, it, reverse); !reflect.DeepEqual(expected, res) {
+			t.Fatalf("reverse=%t: expected %d entries, got %d:\n%v\n%v", reverse, len(expected), len(res), expected, res)
+		}
+	}
+
+	for i := 0; i < 3000; i++ {
+		b := d.NewBatch()
+		for j := rng.Intn(5); j >= 0; j-- {
+			k := randKey()
+			switch n := rng.Intn(20); {
+			case n < 10:
+				v := strconv.Itoa(i)
+				b.Set([]byte(k), []byte(v))
+				model[k] = v
+			case n < 14:
+				b.Merge([]byte(k), []byte("+"))
+				model[k] += "+"
+			case n < 19:
+				b.Delete([]byte(k))
+				delete(model, k)
+			default:
+				end := randKey()
+				if end < k {
+					k, end = end, k
+				}
+				b.DeleteRange([]byte(k), []byte(end))
+				for mk := range model {
+					if mk >= k && mk < end {
+						delete(model, mk)
+					}
+				}
+			}
+		}
+		if err := b.Commit(false); err != nil {
+			t.Fatal(err)
+		}
+
+		switch rng.Intn(200) {
+		case 0:
+			if err := d.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		case 1:
+			if err := d.Compact([]byte(randKey()), []byte(randKey())); err != nil {
+				t.Fatal(err)
+			}
+		case 2:
+			m := make(map[string]string, len(model))
+			for k, v := range model {
+				m[k] = v
+			}
+			snaps = append(snaps, snapshot{snap: d.NewSnapshot(), model: m})
+		case 3:
+			if len(snaps) > 0 {
+				s := snaps[0]
+				check(s.snap, s.model)
+				if err := s.snap.Close(); err != nil {
+					t.Fatal(err)
+				}
+				snaps = snaps[1:]
+			}
+		case 4:
+			for _, s := range snaps {
+				if err := s.snap.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			snaps = nil
+			if err := d.Close(); err != nil {
+				t.Fatal(err)
+			}
+			d = openTestDB(t, fs, opts)
+		}
+		if i%100 == 0 {
+			check(nil, model)
+		}
+	}
+	check(nil, model)
+	for _, s := range snaps {
+		check(s.snap, s.model)
+		if err := s.snap.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDBOnDisk(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tempDir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+	dir := filepath.Join(tempDir, "db")
+	d, err := Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set([]byte("a"), []byte("1"), true); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(dir, nil); err == nil {
+		t.Fatal("expected the DB to be locked")
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if d, err = Open(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if v, err := d.Get([]byte("a")); err != nil || string(v) != "1" {
+		t.Fatalf("expected 1, got %q, %v", v, err)
+	}
+}
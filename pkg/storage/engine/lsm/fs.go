@@ -0,0 +1,465 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/golang/leveldb/db"
+)
+
+// File is a file opened through an FS. Files opened with Create are
+// written sequentially; files opened with Open are read-only.
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Writer
+	io.Closer
+	Stat() (os.FileInfo, error)
+	Sync() error
+}
+
+// FS is the file system used by a DB.
+type FS interface {
+	// Create creates the named file for writing, truncating it if it exists.
+	Create(name string) (File, error)
+	// Open opens the named file for reading.
+	Open(name string) (File, error)
+	// Remove removes the named file or empty directory.
+	Remove(name string) error
+	// RemoveAll removes the named file or directory and everything it
+	// contains.
+	RemoveAll(name string) error
+	// Rename atomically renames a file, replacing newname if it exists.
+	Rename(oldname, newname string) error
+	// Link creates newname as a hard link to oldname.
+	Link(oldname, newname string) error
+	// MkdirAll creates a directory and all necessary parents.
+	MkdirAll(dir string, perm os.FileMode) error
+	// Lock acquires an exclusive lock on the named file, creating it if
+	// necessary. Closing the returned Closer releases the lock.
+	Lock(name string) (io.Closer, error)
+	// List returns the names of the entries in a directory.
+	List(dir string) ([]string, error)
+	// Stat describes the named file.
+	Stat(name string) (os.FileInfo, error)
+}
+
+// DefaultFS is an FS backed by the operating system's file system.
+var DefaultFS FS = defaultFS{}
+
+type defaultFS struct{}
+
+func (defaultFS) Create(name string) (File, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	// Make the new directory entry durable, as the file's contents will be
+	// synced without syncing the directory again.
+	if err := syncDir(filepath.Dir(name)); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (defaultFS) Open(name string) (File, error) {
+	return os.Open(name)
+}
+
+func (defaultFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (defaultFS) RemoveAll(name string) error {
+	return os.RemoveAll(name)
+}
+
+func (defaultFS) Rename(oldname, newname string) error {
+	if err := os.Rename(oldname, newname); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(newname))
+}
+
+func (defaultFS) Link(oldname, newname string) error {
+	return os.Link(oldname, newname)
+}
+
+func (defaultFS) MkdirAll(dir string, perm os.FileMode) error {
+	return os.MkdirAll(dir, perm)
+}
+
+// processLocks holds the files locked through DefaultFS by this process. File
+// locks are held per process, so they do not prevent the process itself from
+// opening a DB twice.
+var processLocks struct {
+	syncutil.Mutex
+	names map[string]struct{}
+}
+
+func (defaultFS) Lock(name string) (io.Closer, error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+	processLocks.Lock()
+	defer processLocks.Unlock()
+	if _, ok := processLocks.names[abs]; ok {
+		return nil, &os.PathError{Op: "lock", Path: name, Err: errLocked}
+	}
+	l, err := db.DefaultFileSystem.Lock(name)
+	if err != nil {
+		return nil, err
+	}
+	if processLocks.names == nil {
+		processLocks.names = map[string]struct{}{}
+	}
+	processLocks.names[abs] = struct{}{}
+	return processLock{name: abs, l: l}, nil
+}
+
+type processLock struct {
+	name string
+	l    io.Closer
+}
+
+func (l processLock) Close() error {
+	processLocks.Lock()
+	defer processLocks.Unlock()
+	delete(processLocks.names, l.name)
+	return l.l.Close()
+}
+
+func (defaultFS) List(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdirnames(-1)
+}
+
+func (defaultFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		_ = d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// NewMemFS returns an FS which stores files in memory.
+func NewMemFS() FS {
+	fs := &memFS{}
+	fs.mu.files = map[string]*memNode{}
+	fs.mu.dirs = map[string]struct{}{string(filepath.Separator): {}, ".": {}}
+	fs.mu.locks = map[string]struct{}{}
+	return fs
+}
+
+type memFS struct {
+	mu struct {
+		syncutil.Mutex
+		files map[string]*memNode
+		dirs  map[string]struct{}
+		locks map[string]struct{}
+	}
+}
+
+type memNode struct {
+	name string
+	mu   struct {
+		syncutil.RWMutex
+		data    []byte
+		modTime time.Time
+	}
+}
+
+func (fs *memFS) Create(name string) (File, error) {
+	name = filepath.Clean(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.mu.dirs[filepath.Dir(name)]; !ok {
+		return nil, &os.PathError{Op: "create", Path: name, Err: os.ErrNotExist}
+	}
+	n := &memNode{name: filepath.Base(name)}
+	n.mu.modTime = time.Now()
+	fs.mu.files[name] = n
+	return &memFile{n: n, write: true}, nil
+}
+
+func (fs *memFS) Open(name string) (File, error) {
+	name = filepath.Clean(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, ok := fs.mu.files[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return &memFile{n: n}, nil
+}
+
+func (fs *memFS) Remove(name string) error {
+	name = filepath.Clean(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.mu.files[name]; ok {
+		delete(fs.mu.files, name)
+		return nil
+	}
+	if _, ok := fs.mu.dirs[name]; ok {
+		if len(fs.listLocked(name)) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errDirNotEmpty}
+		}
+		delete(fs.mu.dirs, name)
+		return nil
+	}
+	return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+}
+
+func (fs *memFS) RemoveAll(name string) error {
+	name = filepath.Clean(name)
+	prefix := name + string(filepath.Separator)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for f := range fs.mu.files {
+		if f == name || strings.HasPrefix(f, prefix) {
+			delete(fs.mu.files, f)
+		}
+	}
+	for d := range fs.mu.dirs {
+		if d == name || strings.HasPrefix(d, prefix) {
+			delete(fs.mu.dirs, d)
+		}
+	}
+	return nil
+}
+
+func (fs *memFS) Rename(oldname, newname string) error {
+	oldname, newname = filepath.Clean(oldname), filepath.Clean(newname)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, ok := fs.mu.files[oldname]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: os.ErrNotExist}
+	}
+	if _, ok := fs.mu.dirs[filepath.Dir(newname)]; !ok {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: os.ErrNotExist}
+	}
+	delete(fs.mu.files, oldname)
+	n.name = filepath.Base(newname)
+	fs.mu.files[newname] = n
+	return nil
+}
+
+func (fs *memFS) Link(oldname, newname string) error {
+	oldname, newname = filepath.Clean(oldname), filepath.Clean(newname)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, ok := fs.mu.files[oldname]
+	if !ok {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: os.ErrNotExist}
+	}
+	if _, ok := fs.mu.files[newname]; ok {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: os.ErrExist}
+	}
+	if _, ok := fs.mu.dirs[filepath.Dir(newname)]; !ok {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: os.ErrNotExist}
+	}
+	// Hard links share their contents. Files are immutable once written, so
+	// sharing the node is sufficient.
+	fs.mu.files[newname] = n
+	return nil
+}
+
+func (fs *memFS) MkdirAll(dir string, perm os.FileMode) error {
+	dir = filepath.Clean(dir)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for {
+		if _, ok := fs.mu.files[dir]; ok {
+			return &os.PathError{Op: "mkdir", Path: dir, Err: os.ErrExist}
+		}
+		fs.mu.dirs[dir] = struct{}{}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
+
+func (fs *memFS) Lock(name string) (io.Closer, error) {
+	f, err := fs.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	name = filepath.Clean(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.mu.locks[name]; ok {
+		return nil, &os.PathError{Op: "lock", Path: name, Err: errLocked}
+	}
+	fs.mu.locks[name] = struct{}{}
+	return memLock{fs: fs, name: name, f: f}, nil
+}
+
+type memLock struct {
+	fs   *memFS
+	name string
+	f    File
+}
+
+func (l memLock) Close() error {
+	l.fs.mu.Lock()
+	defer l.fs.mu.Unlock()
+	delete(l.fs.mu.locks, l.name)
+	return l.f.Close()
+}
+
+func (fs *memFS) List(dir string) ([]string, error) {
+	dir = filepath.Clean(dir)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.mu.dirs[dir]; !ok {
+		return nil, &os.PathError{Op: "open", Path: dir, Err: os.ErrNotExist}
+	}
+	return fs.listLocked(dir), nil
+}
+
+func (fs *memFS) listLocked(dir string) []string {
+	var names []string
+	for f := range fs.mu.files {
+		if filepath.Dir(f) == dir {
+			names = append(names, filepath.Base(f))
+		}
+	}
+	for d := range fs.mu.dirs {
+		if d != dir && filepath.Dir(d) == dir {
+			names = append(names, filepath.Base(d))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (fs *memFS) Stat(name string) (os.FileInfo, error) {
+	name = filepath.Clean(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if n, ok := fs.mu.files[name]; ok {
+		return n.stat(), nil
+	}
+	if _, ok := fs.mu.dirs[name]; ok {
+		return memFileInfo{name: filepath.Base(name), dir: true}, nil
+	}
+	return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+var (
+	errDirNotEmpty = errors.New("directory not empty")
+	errLocked      = errors.New("already locked")
+)
+
+func (n *memNode) stat() os.FileInfo {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return memFileInfo{name: n.name, size: int64(len(n.mu.data)), modTime: n.mu.modTime}
+}
+
+type memFile struct {
+	n     *memNode
+	pos   int64
+	write bool
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.pos)
+	f.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	f.n.mu.RLock()
+	defer f.n.mu.RUnlock()
+	if off >= int64(len(f.n.mu.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.n.mu.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if !f.write {
+		return 0, &os.PathError{Op: "write", Path: f.n.name, Err: os.ErrPermission}
+	}
+	f.n.mu.Lock()
+	defer f.n.mu.Unlock()
+	f.n.mu.data = append(f.n.mu.data, p...)
+	f.n.mu.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Close() error {
+	return nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	return f.n.stat(), nil
+}
+
+func (f *memFile) Sync() error {
+	return nil
+}
+
+type memFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) ModTime() time.Time { return i.modTime }
+func (i memFileInfo) IsDir() bool        { return i.dir }
+func (i memFileInfo) Sys() interface{}   { return nil }
+
+func (i memFileInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
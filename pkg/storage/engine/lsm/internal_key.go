@@ -0,0 +1,142 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Kind is the kind of an entry in a batch, memtable or sstable. The values
+// match the record types of RocksDB's WriteBatch representation, so that
+// batches produced by RocksDB can be applied directly.
+type Kind uint8
+
+// The supported kinds of entries.
+const (
+	KindDelete       Kind = 0x0
+	KindSet          Kind = 0x1
+	KindMerge        Kind = 0x2
+	KindLogData      Kind = 0x3
+	KindSingleDelete Kind = 0x7
+	KindRangeDelete  Kind = 0xF
+
+	// kindMax sorts before all other kinds for the same user key and
+	// sequence number, which makes it suitable for seek keys.
+	kindMax Kind = 0xFF
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindDelete:
+		return "DEL"
+	case KindSet:
+		return "SET"
+	case KindMerge:
+		return "MERGE"
+	case KindLogData:
+		return "LOGDATA"
+	case KindSingleDelete:
+		return "SINGLEDEL"
+	case KindRangeDelete:
+		return "RANGEDEL"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", uint8(k))
+	}
+}
+
+const (
+	// seqNumMax is the largest sequence number. Sequence numbers occupy the
+	// high 56 bits of the trailer.
+	seqNumMax = uint64(1<<56 - 1)
+	// batchSeqNumFlag marks the sequence numbers of entries in an indexed
+	// batch which have not been committed yet. Such entries sort before (i.e.
+	// are newer than) all committed entries.
+	batchSeqNumFlag = uint64(1 << 55)
+	// trailerLen is the length of the encoded trailer of an internal key.
+	trailerLen = 8
+)
+
+func makeTrailer(seqNum uint64, kind Kind) uint64 {
+	return seqNum<<8 | uint64(kind)
+}
+
+// internalKey is a user key combined with the sequence number and kind of
+// the entry. Internal keys for the same user key sort by decreasing sequence
+// number, so that newer entries are encountered first.
+type internalKey struct {
+	userKey []byte
+	trailer uint64
+}
+
+func makeInternalKey(userKey []byte, seqNum uint64, kind Kind) internalKey {
+	return internalKey{userKey: userKey, trailer: makeTrailer(seqNum, kind)}
+}
+
+// makeSeekKey returns the smallest internal key for the given user key.
+func makeSeekKey(userKey []byte) internalKey {
+	return makeInternalKey(userKey, seqNumMax, kindMax)
+}
+
+func (k internalKey) seqNum() uint64 {
+	return k.trailer >> 8
+}
+
+func (k internalKey) kind() Kind {
+	return Kind(k.trailer & 0xff)
+}
+
+func (k internalKey) encodedLen() int {
+	return len(k.userKey) + trailerLen
+}
+
+// encode appends the encoded internal key to buf.
+func (k internalKey) encode(buf []byte) []byte {
+	buf = append(buf, k.userKey...)
+	var t [trailerLen]byte
+	binary.LittleEndian.PutUint64(t[:], k.trailer)
+	return append(buf, t[:]...)
+}
+
+func (k internalKey) clone() internalKey {
+	return internalKey{userKey: append([]byte(nil), k.userKey...), trailer: k.trailer}
+}
+
+func (k internalKey) String() string {
+	return fmt.Sprintf("%q#%d,%s", k.userKey, k.seqNum(), k.kind())
+}
+
+// decodeInternalKey decodes an encoded internal key. The returned user key
+// aliases buf.
+func decodeInternalKey(buf []byte) (internalKey, bool) {
+	n := len(buf) - trailerLen
+	if n < 0 {
+		return internalKey{}, false
+	}
+	return internalKey{userKey: buf[:n:n], trailer: binary.LittleEndian.Uint64(buf[n:])}, true
+}
+
+// compareInternal orders internal keys by user key and then by decreasing
+// trailer.
+func compareInternal(cmp func(a, b []byte) int, a, b internalKey) int {
+	if c := cmp(a.userKey, b.userKey); c != 0 {
+		return c
+	}
+	if a.trailer > b.trailer {
+		return -1
+	}
+	if a.trailer < b.trailer {
+		return 1
+	}
+	return 0
+}
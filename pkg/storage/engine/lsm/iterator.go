@@ -0,0 +1,581 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"container/heap"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// internalIterator iterates over internal keys in increasing order. Seeks
+// take user keys: SeekGE positions the iterator at the first entry with a
+// user key >= key, and SeekLT at the last entry with a user key < key.
+//
+// There is no reverse iteration; Iterator implements Prev using SeekLT and
+// SeekGE.
+type internalIterator interface {
+	SeekGE(key []byte)
+	SeekLT(key []byte)
+	First()
+	Last()
+	Next()
+	Valid() bool
+	Key() internalKey
+	Value() []byte
+	Error() error
+	Close() error
+}
+
+// mergingIter merges the entries of several internal iterators. Next may
+// only be called after SeekGE or First; SeekLT and Last merely position the
+// iterator at the largest entry before the seek key.
+type mergingIter struct {
+	cmp   func(a, b []byte) int
+	iters []internalIterator
+	// heap holds the indexes of the valid iterators, ordered by their
+	// current keys.
+	heap []int
+	// cur is the index of the current iterator after SeekLT or Last, or -1.
+	cur     int
+	reverse bool
+}
+
+var _ internalIterator = &mergingIter{}
+
+func newMergingIter(cmp func(a, b []byte) int, iters []internalIterator) *mergingIter {
+	return &mergingIter{cmp: cmp, iters: iters, cur: -1}
+}
+
+func (m *mergingIter) Len() int { return len(m.heap) }
+
+func (m *mergingIter) Less(i, j int) bool {
+	return compareInternal(m.cmp, m.iters[m.heap[i]].Key(), m.iters[m.heap[j]].Key()) < 0
+}
+
+func (m *mergingIter) Swap(i, j int) { m.heap[i], m.heap[j] = m.heap[j], m.heap[i] }
+
+func (m *mergingIter) Push(x interface{}) { m.heap = append(m.heap, x.(int)) }
+
+func (m *mergingIter) Pop() interface{} {
+	n := len(m.heap) - 1
+	x := m.heap[n]
+	m.heap = m.heap[:n]
+	return x
+}
+
+func (m *mergingIter) initForward() {
+	m.reverse = false
+	m.heap = m.heap[:0]
+	for i, it := range m.iters {
+		if it.Valid() {
+			m.heap = append(m.heap, i)
+		}
+	}
+	heap.Init(m)
+}
+
+func (m *mergingIter) initReverse() {
+	m.reverse = true
+	m.cur = -1
+	for i, it := range m.iters {
+		if !it.Valid() {
+			continue
+		}
+		if m.cur < 0 || compareInternal(m.cmp, it.Key(), m.iters[m.cur].Key()) > 0 {
+			m.cur = i
+		}
+	}
+}
+
+func (m *mergingIter) SeekGE(key []byte) {
+	for _, it := range m.iters {
+		it.SeekGE(key)
+	}
+	m.initForward()
+}
+
+func (m *mergingIter) SeekLT(key []byte) {
+	for _, it := range m.iters {
+		it.SeekLT(key)
+	}
+	m.initReverse()
+}
+
+func (m *mergingIter) First() {
+	for _, it := range m.iters {
+		it.First()
+	}
+	m.initForward()
+}
+
+func (m *mergingIter) Last() {
+	for _, it := range m.iters {
+		it.Last()
+	}
+	m.initReverse()
+}
+
+func (m *mergingIter) Next() {
+	if m.reverse {
+		panic("mergingIter: Next called after reverse positioning")
+	}
+	it := m.iters[m.heap[0]]
+	it.Next()
+	if it.Valid() {
+		heap.Fix(m, 0)
+	} else {
+		heap.Pop(m)
+	}
+}
+
+func (m *mergingIter) Valid() bool {
+	if m.reverse {
+		return m.cur >= 0
+	}
+	return len(m.heap) > 0
+}
+
+func (m *mergingIter) current() internalIterator {
+	if m.reverse {
+		return m.iters[m.cur]
+	}
+	return m.iters[m.heap[0]]
+}
+
+func (m *mergingIter) Key() internalKey {
+	return m.current().Key()
+}
+
+func (m *mergingIter) Value() []byte {
+	return m.current().Value()
+}
+
+func (m *mergingIter) Error() error {
+	for _, it := range m.iters {
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mergingIter) Close() error {
+	var err error
+	for _, it := range m.iters {
+		if e := it.Close(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// levelIter iterates over a sequence of non-overlapping sstables sorted by
+// key, opening tables lazily. Tables which lie entirely outside the bounds
+// or are rejected by the table filter are skipped.
+type levelIter struct {
+	cmp    func(a, b []byte) int
+	tables *tableCache
+	files  []*fileMetadata
+	// opts are the options of the Iterator, which may change its bounds.
+	opts *IterOptions
+
+	index int
+	iter  *tableIter
+	err   error
+}
+
+var _ internalIterator = &levelIter{}
+
+// skipFile returns whether a table can be skipped, and otherwise its
+// reader.
+func (l *levelIter) skipFile(f *fileMetadata) (bool, *tableReader) {
+	if upper := l.opts.UpperBound; upper != nil && l.cmp(f.smallest, upper) >= 0 {
+		return true, nil
+	}
+	if lower := l.opts.LowerBound; lower != nil && !f.containsUpper(l.cmp, lower) {
+		return true, nil
+	}
+	r, err := l.tables.get(f)
+	if err != nil {
+		l.err = err
+		return true, nil
+	}
+	if l.opts.TableFilter != nil && !l.opts.TableFilter(r.props.UserProperties) {
+		return true, nil
+	}
+	return false, r
+}
+
+// loadFile opens the first table at or after index i (in direction dir)
+// which is not skipped.
+func (l *levelIter) loadFile(i, dir int) bool {
+	l.iter = nil
+	for ; i >= 0 && i < len(l.files) && l.err == nil; i += dir {
+		if skip, r := l.skipFile(l.files[i]); !skip {
+			l.index = i
+			l.iter = r.newIter()
+			return true
+		}
+	}
+	l.index = i
+	return false
+}
+
+func (l *levelIter) skipForward() {
+	for l.iter != nil && !l.iter.Valid() {
+		if err := l.iter.Error(); err != nil {
+			l.err = err
+			l.iter = nil
+			return
+		}
+		if l.loadFile(l.index+1, +1) {
+			l.iter.First()
+		}
+	}
+}
+
+func (l *levelIter) skipBackward() {
+	for l.iter != nil && !l.iter.Valid() {
+		if err := l.iter.Error(); err != nil {
+			l.err = err
+			l.iter = nil
+			return
+		}
+		if l.loadFile(l.index-1, -1) {
+			l.iter.Last()
+		}
+	}
+}
+
+func (l *levelIter) SeekGE(key []byte) {
+	i := sort.Search(len(l.files), func(i int) bool { return l.files[i].containsUpper(l.cmp, key) })
+	if l.loadFile(i, +1) {
+		l.iter.SeekGE(key)
+		l.skipForward()
+	}
+}
+
+func (l *levelIter) SeekLT(key []byte) {
+	i := sort.Search(len(l.files), func(i int) bool { return l.cmp(l.files[i].smallest, key) >= 0 })
+	if l.loadFile(i-1, -1) {
+		l.iter.SeekLT(key)
+		l.skipBackward()
+	}
+}
+
+func (l *levelIter) First() {
+	if l.loadFile(0, +1) {
+		l.iter.First()
+		l.skipForward()
+	}
+}
+
+func (l *levelIter) Last() {
+	if l.loadFile(len(l.files)-1, -1) {
+		l.iter.Last()
+		l.skipBackward()
+	}
+}
+
+func (l *levelIter) Next() {
+	l.iter.Next()
+	l.skipForward()
+}
+
+func (l *levelIter) Valid() bool {
+	return l.iter != nil && l.iter.Valid()
+}
+
+func (l *levelIter) Key() internalKey {
+	return l.iter.Key()
+}
+
+func (l *levelIter) Value() []byte {
+	return l.iter.Value()
+}
+
+func (l *levelIter) Error() error {
+	if l.err == nil && l.iter != nil {
+		return l.iter.Error()
+	}
+	return l.err
+}
+
+func (l *levelIter) Close() error {
+	l.iter = nil
+	return l.err
+}
+
+// Iterator iterates over the user keys of a DB, a snapshot or an indexed
+// batch combined with the DB. Every key is resolved to its latest visible
+// value: deleted keys are skipped and merge operands are merged.
+//
+// An Iterator is positioned on a key after a positioning method returns
+// true. The slices returned by Key and Value are only valid until the next
+// call to a positioning method.
+type Iterator struct {
+	cmp    func(a, b []byte) int
+	merger *Merger
+	iter   internalIterator
+	opts   IterOptions
+
+	readState *readState
+	// seqNum is the sequence number up to which committed entries are
+	// visible.
+	seqNum uint64
+	// batch, if non-nil, is an indexed batch whose first batchCount entries
+	// are visible. If refreshBatch is set, batchCount is refreshed on every
+	// seek so that the iterator observes the batch's latest writes.
+	batch        *Batch
+	batchCount   uint64
+	refreshBatch bool
+	rangeDels    []rangeDelFragments
+
+	key, value []byte
+	valid      bool
+	err        error
+	operands   [][]byte
+}
+
+func (it *Iterator) visible(seqNum uint64) bool {
+	if seqNum&batchSeqNumFlag != 0 {
+		return seqNum&^batchSeqNumFlag < it.batchCount
+	}
+	return seqNum <= it.seqNum
+}
+
+// deleted returns whether an entry is covered by a visible range tombstone.
+func (it *Iterator) deleted(key []byte, seqNum uint64) bool {
+	for _, frags := range it.rangeDels {
+		if f := frags.find(it.cmp, key); f != nil && f.covers(seqNum, it.visible) {
+			return true
+		}
+	}
+	if it.batch != nil {
+		if f := it.batch.rangeDelFragmentsForCount().find(it.cmp, key); f != nil {
+			return f.covers(seqNum, it.visible)
+		}
+	}
+	return false
+}
+
+func (it *Iterator) refresh() {
+	it.err = nil
+	if it.batch != nil && it.refreshBatch {
+		it.batchCount = uint64(it.batch.Count())
+	}
+}
+
+// resolve resolves the user key at the current position of the internal
+// iterator, advancing the internal iterator past all of the key's entries.
+// It returns whether the key has a visible value.
+func (it *Iterator) resolve() bool {
+	it.key = append(it.key[:0], it.iter.Key().userKey...)
+	it.operands = it.operands[:0]
+	var base []byte
+	var found, done bool
+	for ; it.iter.Valid(); it.iter.Next() {
+		k := it.iter.Key()
+		if it.cmp(k.userKey, it.key) != 0 {
+			break
+		}
+		if done || !it.visible(k.seqNum()) {
+			continue
+		}
+		done = true
+		if it.deleted(it.key, k.seqNum()) {
+			continue
+		}
+		switch k.kind() {
+		case KindSet:
+			if len(it.operands) == 0 {
+				it.value = append(it.value[:0], it.iter.Value()...)
+				found = true
+			} else {
+				base = it.iter.Value()
+			}
+		case KindDelete, KindSingleDelete:
+		case KindMerge:
+			it.operands = append(it.operands, it.iter.Value())
+			done = false
+		default:
+			it.err = errors.Errorf("unexpected entry %s", k)
+			return false
+		}
+	}
+	if err := it.iter.Error(); err != nil {
+		it.err = err
+		return false
+	}
+	if len(it.operands) > 0 {
+		// Operands were collected from newest to oldest.
+		for i, j := 0, len(it.operands)-1; i < j; i, j = i+1, j-1 {
+			it.operands[i], it.operands[j] = it.operands[j], it.operands[i]
+		}
+		v, err := it.merger.FullMerge(it.key, base, it.operands)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.value = append(it.value[:0], v...)
+		found = true
+	}
+	return found
+}
+
+// findNextEntry resolves user keys from the current position of the
+// internal iterator until one has a visible value.
+func (it *Iterator) findNextEntry() bool {
+	for it.iter.Valid() && it.err == nil {
+		if it.opts.UpperBound != nil && it.cmp(it.iter.Key().userKey, it.opts.UpperBound) >= 0 {
+			break
+		}
+		if it.resolve() {
+			it.valid = true
+			return true
+		}
+	}
+	if it.err == nil {
+		it.err = it.iter.Error()
+	}
+	it.valid = false
+	return false
+}
+
+// findPrevEntry positions the iterator at the last user key before key (or
+// the last user key if key is nil) which has a visible value.
+func (it *Iterator) findPrevEntry(key []byte) bool {
+	for it.err == nil {
+		if key == nil {
+			it.iter.Last()
+		} else {
+			it.iter.SeekLT(key)
+		}
+		if !it.iter.Valid() {
+			break
+		}
+		prev := append([]byte(nil), it.iter.Key().userKey...)
+		if it.opts.LowerBound != nil && it.cmp(prev, it.opts.LowerBound) < 0 {
+			break
+		}
+		it.iter.SeekGE(prev)
+		if it.iter.Valid() && it.resolve() {
+			it.valid = true
+			return true
+		}
+		key = prev
+	}
+	if it.err == nil {
+		it.err = it.iter.Error()
+	}
+	it.valid = false
+	return false
+}
+
+// SeekGE positions the iterator at the first key >= key.
+func (it *Iterator) SeekGE(key []byte) bool {
+	it.refresh()
+	if it.opts.LowerBound != nil && it.cmp(key, it.opts.LowerBound) < 0 {
+		key = it.opts.LowerBound
+	}
+	it.iter.SeekGE(key)
+	return it.findNextEntry()
+}
+
+// SeekLT positions the iterator at the last key < key.
+func (it *Iterator) SeekLT(key []byte) bool {
+	it.refresh()
+	if it.opts.UpperBound != nil && it.cmp(key, it.opts.UpperBound) > 0 {
+		key = it.opts.UpperBound
+	}
+	return it.findPrevEntry(append([]byte(nil), key...))
+}
+
+// First positions the iterator at the first key.
+func (it *Iterator) First() bool {
+	if it.opts.LowerBound != nil {
+		return it.SeekGE(it.opts.LowerBound)
+	}
+	it.refresh()
+	it.iter.First()
+	return it.findNextEntry()
+}
+
+// Last positions the iterator at the last key.
+func (it *Iterator) Last() bool {
+	if it.opts.UpperBound != nil {
+		return it.SeekLT(it.opts.UpperBound)
+	}
+	it.refresh()
+	return it.findPrevEntry(nil)
+}
+
+// Next moves the iterator to the next key.
+func (it *Iterator) Next() bool {
+	if !it.valid {
+		return false
+	}
+	return it.findNextEntry()
+}
+
+// Prev moves the iterator to the previous key.
+func (it *Iterator) Prev() bool {
+	if !it.valid {
+		return false
+	}
+	return it.findPrevEntry(append([]byte(nil), it.key...))
+}
+
+// Valid returns whether the iterator is positioned on a key.
+func (it *Iterator) Valid() bool {
+	return it.valid
+}
+
+// Key returns the current key.
+func (it *Iterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value of the current key.
+func (it *Iterator) Value() []byte {
+	return it.value
+}
+
+// Error returns the error encountered by the last positioning method, if
+// any.
+func (it *Iterator) Error() error {
+	return it.err
+}
+
+// SetBounds changes the bounds of the iterator. The iterator must be
+// repositioned afterwards.
+func (it *Iterator) SetBounds(lower, upper []byte) {
+	it.opts.LowerBound = lower
+	it.opts.UpperBound = upper
+	it.valid = false
+}
+
+// Close releases the resources held by the iterator.
+func (it *Iterator) Close() error {
+	err := it.iter.Close()
+	if it.readState != nil {
+		it.readState.unref()
+		it.readState = nil
+	}
+	if err == nil {
+		err = it.err
+	}
+	return err
+}
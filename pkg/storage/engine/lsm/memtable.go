@@ -0,0 +1,123 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/pkg/errors"
+)
+
+// memTable holds recently committed writes in memory. Writes are applied by
+// a single goroutine at a time (under DB.commitMu) while readers iterate
+// concurrently; entries are only visible to readers once their sequence
+// number has been published.
+type memTable struct {
+	cmp  func(a, b []byte) int
+	list *skiplist
+	// logNum is the number of the WAL which holds the memtable's writes.
+	logNum uint64
+	size   int64 // accessed atomically
+
+	mu struct {
+		syncutil.RWMutex
+		tombstones []rangeTombstone
+		frags      rangeDelFragments
+		fragsValid bool
+	}
+}
+
+func newMemTable(cmp func(a, b []byte) int, logNum uint64) *memTable {
+	return &memTable{cmp: cmp, list: newSkiplist(cmp), logNum: logNum}
+}
+
+// apply adds the entries of a batch representation to the memtable,
+// assigning sequence numbers starting at seqNum. It returns the number of
+// sequence numbers consumed.
+func (m *memTable) apply(repr []byte, seqNum uint64) (uint32, error) {
+	// Copy the representation once; the keys and values added to the
+	// skiplist alias the copy.
+	data := append([]byte(nil), repr...)
+	r, _, _, err := newBatchReader(data)
+	if err != nil {
+		return 0, err
+	}
+	var count uint32
+	var tombstones []rangeTombstone
+	for {
+		kind, key, value, ok := r.next()
+		if !ok {
+			break
+		}
+		switch kind {
+		case KindLogData:
+			continue
+		case KindRangeDelete:
+			tombstones = append(tombstones, rangeTombstone{start: key, end: value, seqNum: seqNum})
+		default:
+			m.list.add(makeInternalKey(key, seqNum, kind), value)
+		}
+		seqNum++
+		count++
+	}
+	if r.err != nil {
+		return count, errors.Wrap(r.err, "applying batch to memtable")
+	}
+	if len(tombstones) > 0 {
+		m.mu.Lock()
+		m.mu.tombstones = append(m.mu.tombstones, tombstones...)
+		m.mu.fragsValid = false
+		m.mu.Unlock()
+	}
+	atomic.AddInt64(&m.size, int64(len(data))+int64(count)*skiplistNodeOverhead)
+	return count, nil
+}
+
+func (m *memTable) approximateSize() int64 {
+	return atomic.LoadInt64(&m.size)
+}
+
+func (m *memTable) empty() bool {
+	return m.list.head.loadNext(0) == nil && len(m.tombstones()) == 0
+}
+
+func (m *memTable) newIter() internalIterator {
+	return &skiplistIter{list: m.list}
+}
+
+// tombstones returns the memtable's range tombstones.
+func (m *memTable) tombstones() []rangeTombstone {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.mu.tombstones[:len(m.mu.tombstones):len(m.mu.tombstones)]
+}
+
+// rangeDelFragments returns the fragments of the memtable's range
+// tombstones. Tombstones whose sequence numbers have not been published are
+// included; readers filter them by visibility.
+func (m *memTable) rangeDelFragments() rangeDelFragments {
+	m.mu.RLock()
+	if m.mu.fragsValid {
+		defer m.mu.RUnlock()
+		return m.mu.frags
+	}
+	m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.mu.fragsValid {
+		m.mu.frags = fragmentRangeTombstones(m.cmp, m.mu.tombstones)
+		m.mu.fragsValid = true
+	}
+	return m.mu.frags
+}
@@ -0,0 +1,182 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import "bytes"
+
+// Comparer defines a total ordering over user keys.
+type Comparer struct {
+	// Compare returns -1, 0, or +1 depending on whether a is less than, equal
+	// to, or greater than b.
+	Compare func(a, b []byte) int
+	// Name is persisted in the manifest and checked when the database is
+	// reopened, to guard against opening a database with an incompatible
+	// ordering.
+	Name string
+}
+
+// DefaultComparer orders keys lexicographically.
+var DefaultComparer = &Comparer{
+	Compare: bytes.Compare,
+	Name:    "leveldb.BytewiseComparator",
+}
+
+// Merger combines the operands written to a key using Batch.Merge.
+//
+// Operands are always passed from oldest to newest. Merging must be
+// associative: fully merging a sequence of operands must produce the same
+// result as fully merging the partial merges of its subsequences.
+type Merger struct {
+	// FullMerge merges the operands into the existing value of the key, which
+	// is nil if the key has no value.
+	FullMerge func(key, existing []byte, operands [][]byte) ([]byte, error)
+	// PartialMerge combines a sequence of operands into a single operand.
+	PartialMerge func(key []byte, operands [][]byte) ([]byte, error)
+	// Name is persisted in the manifest, like Comparer.Name.
+	Name string
+}
+
+// DefaultMerger concatenates operands.
+var DefaultMerger = &Merger{
+	FullMerge: func(key, existing []byte, operands [][]byte) ([]byte, error) {
+		res := append([]byte(nil), existing...)
+		for _, op := range operands {
+			res = append(res, op...)
+		}
+		return res, nil
+	},
+	PartialMerge: func(key []byte, operands [][]byte) ([]byte, error) {
+		var res []byte
+		for _, op := range operands {
+			res = append(res, op...)
+		}
+		return res, nil
+	},
+	Name: "lsm.concatenate",
+}
+
+// TablePropertyCollector accumulates user properties for an sstable as it is
+// written. The properties are stored in the sstable and can be used to filter
+// tables during iteration (see IterOptions.TableFilter).
+type TablePropertyCollector interface {
+	// Add is called for every point key written to the table, in order.
+	Add(key []byte, kind Kind, value []byte) error
+	// Finish adds the collected properties to userProps.
+	Finish(userProps map[string]string) error
+}
+
+// Options configures a DB. The zero value of every field selects a default.
+type Options struct {
+	// Comparer orders user keys. Defaults to DefaultComparer.
+	Comparer *Comparer
+	// Merger combines merge operands. Defaults to DefaultMerger.
+	Merger *Merger
+	// FS is the file system the database is stored in. Defaults to DefaultFS.
+	FS FS
+	// ErrorIfNotExists causes Open to fail if the directory does not hold an
+	// existing database.
+	ErrorIfNotExists bool
+
+	// MemTableSize is the approximate size in bytes at which a memtable is
+	// flushed to an sstable. Defaults to 64 MiB.
+	MemTableSize int64
+	// MemTableStopWritesThreshold is the number of memtables, including the
+	// mutable one, at which writes are stalled until a flush completes.
+	// Defaults to 4.
+	MemTableStopWritesThreshold int
+
+	// L0CompactionThreshold is the number of L0 sstables that triggers a
+	// compaction into the base level. Defaults to 4.
+	L0CompactionThreshold int
+	// L0StopWritesThreshold is the number of L0 sstables at which writes are
+	// stalled until compactions catch up. Defaults to 20.
+	L0StopWritesThreshold int
+	// LBaseMaxBytes is the target size of L1. Each subsequent level is
+	// LevelMultiplier times larger. Defaults to 64 MiB.
+	LBaseMaxBytes int64
+	// LevelMultiplier defaults to 10.
+	LevelMultiplier int
+	// TargetFileSize is the target size of the sstables written by flushes
+	// and compactions. Defaults to 4 MiB.
+	TargetFileSize int64
+	// DisableAutomaticCompactions disables background compactions. Flushes
+	// are still performed. Intended for testing.
+	DisableAutomaticCompactions bool
+
+	// BlockSize is the target uncompressed size of sstable data blocks.
+	// Defaults to 32 KiB.
+	BlockSize int
+	// BlockCacheSize is the capacity in bytes of the cache of sstable data
+	// blocks. Defaults to 8 MiB.
+	BlockCacheSize int64
+
+	// TablePropertyCollectors are instantiated for every sstable written.
+	TablePropertyCollectors []func() TablePropertyCollector
+}
+
+// EnsureDefaults fills in the defaults of any unset options and returns the
+// options.
+func (o *Options) EnsureDefaults() *Options {
+	if o == nil {
+		o = &Options{}
+	}
+	if o.Comparer == nil {
+		o.Comparer = DefaultComparer
+	}
+	if o.Merger == nil {
+		o.Merger = DefaultMerger
+	}
+	if o.FS == nil {
+		o.FS = DefaultFS
+	}
+	if o.MemTableSize <= 0 {
+		o.MemTableSize = 64 << 20
+	}
+	if o.MemTableStopWritesThreshold <= 1 {
+		o.MemTableStopWritesThreshold = 4
+	}
+	if o.L0CompactionThreshold <= 0 {
+		o.L0CompactionThreshold = 4
+	}
+	if o.L0StopWritesThreshold <= 0 {
+		o.L0StopWritesThreshold = 20
+	}
+	if o.LBaseMaxBytes <= 0 {
+		o.LBaseMaxBytes = 64 << 20
+	}
+	if o.LevelMultiplier <= 1 {
+		o.LevelMultiplier = 10
+	}
+	if o.TargetFileSize <= 0 {
+		o.TargetFileSize = 4 << 20
+	}
+	if o.BlockSize <= 0 {
+		o.BlockSize = 32 << 10
+	}
+	if o.BlockCacheSize <= 0 {
+		o.BlockCacheSize = 8 << 20
+	}
+	return o
+}
+
+// IterOptions configures an Iterator.
+type IterOptions struct {
+	// LowerBound is the inclusive lower bound of the iterator, if non-nil.
+	LowerBound []byte
+	// UpperBound is the exclusive upper bound of the iterator, if non-nil.
+	UpperBound []byte
+	// TableFilter, if non-nil, is called with the user properties of every
+	// sstable. Tables for which it returns false are skipped by the iterator.
+	// Memtables and batches are never skipped.
+	TableFilter func(userProps map[string]string) bool
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import "sort"
+
+// rangeTombstone deletes all entries with user keys in [start, end) and
+// sequence numbers lower than seqNum.
+//
+// Range tombstones are not stored inline in sstables. Instead, the tombstones
+// which were flushed or compacted into an sstable are recorded in the
+// sstable's metadata in the manifest and held in memory.
+type rangeTombstone struct {
+	start, end []byte
+	seqNum     uint64
+}
+
+// rangeDelFragment is a key span covered by a fixed set of range tombstones.
+type rangeDelFragment struct {
+	start, end []byte
+	// seqNums are the sequence numbers of the tombstones covering the
+	// fragment, in decreasing order.
+	seqNums []uint64
+}
+
+// covers returns whether the fragment deletes an entry with the given
+// sequence number, i.e. whether a tombstone newer than the entry is visible.
+func (f *rangeDelFragment) covers(seqNum uint64, visible func(uint64) bool) bool {
+	for _, s := range f.seqNums {
+		if s <= seqNum {
+			return false
+		}
+		if visible(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeDelFragments are non-overlapping fragments sorted by start key.
+type rangeDelFragments []rangeDelFragment
+
+// fragmentRangeTombstones splits possibly overlapping range tombstones into
+// non-overlapping fragments.
+func fragmentRangeTombstones(
+	cmp func(a, b []byte) int, tombstones []rangeTombstone,
+) rangeDelFragments {
+	if len(tombstones) == 0 {
+		return nil
+	}
+	bounds := make([][]byte, 0, 2*len(tombstones))
+	for _, t := range tombstones {
+		bounds = append(bounds, t.start, t.end)
+	}
+	sort.Slice(bounds, func(i, j int) bool { return cmp(bounds[i], bounds[j]) < 0 })
+	uniq := bounds[:1]
+	for _, b := range bounds[1:] {
+		if cmp(uniq[len(uniq)-1], b) != 0 {
+			uniq = append(uniq, b)
+		}
+	}
+	bounds = uniq
+
+	sorted := append([]rangeTombstone(nil), tombstones...)
+	sort.Slice(sorted, func(i, j int) bool { return cmp(sorted[i].start, sorted[j].start) < 0 })
+
+	// Sweep over the boundaries, maintaining the set of tombstones which
+	// cover the current fragment.
+	var frags rangeDelFragments
+	var active []rangeTombstone
+	next := 0
+	for i := 0; i+1 < len(bounds); i++ {
+		start, end := bounds[i], bounds[i+1]
+		for next < len(sorted) && cmp(sorted[next].start, start) <= 0 {
+			active = append(active, sorted[next])
+			next++
+		}
+		live := active[:0]
+		for _, t := range active {
+			if cmp(t.end, start) > 0 {
+				live = append(live, t)
+			}
+		}
+		active = live
+		if len(active) == 0 {
+			continue
+		}
+		seqNums := make([]uint64, len(active))
+		for j, t := range active {
+			seqNums[j] = t.seqNum
+		}
+		sort.Slice(seqNums, func(i, j int) bool { return seqNums[i] > seqNums[j] })
+		frags = append(frags, rangeDelFragment{start: start, end: end, seqNums: seqNums})
+	}
+	return frags
+}
+
+// find returns the fragment containing key, or nil.
+func (fs rangeDelFragments) find(cmp func(a, b []byte) int, key []byte) *rangeDelFragment {
+	i := sort.Search(len(fs), func(i int) bool { return cmp(fs[i].end, key) > 0 })
+	if i < len(fs) && cmp(fs[i].start, key) <= 0 {
+		return &fs[i]
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"unsafe"
+)
+
+const (
+	skiplistMaxHeight = 12
+	// skiplistNodeOverhead approximates the memory used by a node in addition
+	// to its key and value.
+	skiplistNodeOverhead = 64
+)
+
+type skiplistNode struct {
+	key   internalKey
+	value []byte
+	// next holds the *skiplistNode successors of the node at each level.
+	next []unsafe.Pointer
+}
+
+func (n *skiplistNode) loadNext(level int) *skiplistNode {
+	return (*skiplistNode)(atomic.LoadPointer(&n.next[level]))
+}
+
+func (n *skiplistNode) storeNext(level int, next *skiplistNode) {
+	atomic.StorePointer(&n.next[level], unsafe.Pointer(next))
+}
+
+// skiplist is an ordered set of internal keys. It supports a single writer
+// concurrently with any number of readers: nodes are fully initialized before
+// being linked in, and links are updated atomically. Keys are never removed.
+type skiplist struct {
+	cmp    func(a, b []byte) int
+	head   *skiplistNode
+	height int32 // accessed atomically
+	rnd    *rand.Rand
+}
+
+func newSkiplist(cmp func(a, b []byte) int) *skiplist {
+	return &skiplist{
+		cmp:    cmp,
+		head:   &skiplistNode{next: make([]unsafe.Pointer, skiplistMaxHeight)},
+		height: 1,
+		rnd:    rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+func (s *skiplist) randomHeight() int {
+	h := 1
+	for h < skiplistMaxHeight && s.rnd.Intn(4) == 0 {
+		h++
+	}
+	return h
+}
+
+// findGreaterOrEqual returns the first node whose key is >= key. If prev is
+// non-nil, it is filled with the rightmost node before key at every level.
+func (s *skiplist) findGreaterOrEqual(key internalKey, prev []*skiplistNode) *skiplistNode {
+	x := s.head
+	level := int(atomic.LoadInt32(&s.height)) - 1
+	for {
+		next := x.loadNext(level)
+		if next != nil && compareInternal(s.cmp, next.key, key) < 0 {
+			x = next
+			continue
+		}
+		if prev != nil {
+			prev[level] = x
+		}
+		if level == 0 {
+			return next
+		}
+		level--
+	}
+}
+
+// findLessThan returns the last node whose key is < key, or nil.
+func (s *skiplist) findLessThan(key internalKey) *skiplistNode {
+	x := s.head
+	level := int(atomic.LoadInt32(&s.height)) - 1
+	for {
+		next := x.loadNext(level)
+		if next != nil && compareInternal(s.cmp, next.key, key) < 0 {
+			x = next
+			continue
+		}
+		if level == 0 {
+			if x == s.head {
+				return nil
+			}
+			return x
+		}
+		level--
+	}
+}
+
+// findLast returns the last node, or nil if the list is empty.
+func (s *skiplist) findLast() *skiplistNode {
+	x := s.head
+	level := int(atomic.LoadInt32(&s.height)) - 1
+	for {
+		if next := x.loadNext(level); next != nil {
+			x = next
+			continue
+		}
+		if level == 0 {
+			if x == s.head {
+				return nil
+			}
+			return x
+		}
+		level--
+	}
+}
+
+// add inserts a key which must not already be present. The key and value
+// are retained by the skiplist. add must not be called concurrently with
+// itself.
+func (s *skiplist) add(key internalKey, value []byte) {
+	var prev [skiplistMaxHeight]*skiplistNode
+	s.findGreaterOrEqual(key, prev[:])
+
+	h := s.randomHeight()
+	if cur := int(atomic.LoadInt32(&s.height)); h > cur {
+		for i := cur; i < h; i++ {
+			prev[i] = s.head
+		}
+		// Readers which observe the new height before the new node is linked
+		// in simply find a nil successor of the head at the new levels.
+		atomic.StoreInt32(&s.height, int32(h))
+	}
+
+	n := &skiplistNode{key: key, value: value, next: make([]unsafe.Pointer, h)}
+	for i := 0; i < h; i++ {
+		n.next[i] = prev[i].next[i]
+		prev[i].storeNext(i, n)
+	}
+}
+
+// skiplistIter iterates over a skiplist. It implements internalIterator.
+type skiplistIter struct {
+	list *skiplist
+	node *skiplistNode
+}
+
+var _ internalIterator = &skiplistIter{}
+
+func (it *skiplistIter) SeekGE(key []byte) {
+	it.node = it.list.findGreaterOrEqual(makeSeekKey(key), nil)
+}
+
+func (it *skiplistIter) SeekLT(key []byte) {
+	it.node = it.list.findLessThan(makeSeekKey(key))
+}
+
+func (it *skiplistIter) First() {
+	it.node = it.list.head.loadNext(0)
+}
+
+func (it *skiplistIter) Last() {
+	it.node = it.list.findLast()
+}
+
+func (it *skiplistIter) Next() {
+	it.node = it.node.loadNext(0)
+}
+
+func (it *skiplistIter) Valid() bool {
+	return it.node != nil
+}
+
+func (it *skiplistIter) Key() internalKey {
+	return it.node.key
+}
+
+func (it *skiplistIter) Value() []byte {
+	return it.node.value
+}
+
+func (it *skiplistIter) Error() error {
+	return nil
+}
+
+func (it *skiplistIter) Close() error {
+	it.node = nil
+	return nil
+}
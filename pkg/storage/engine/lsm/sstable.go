@@ -0,0 +1,610 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// The sstable format is deliberately simple:
+//
+//	table     := dataBlock* indexBlock propsBlock footer
+//	dataBlock := entry* checksum:fixed32
+//	entry     := keyLen:uvarint valueLen:uvarint internalKey value
+//	index     := (keyLen:uvarint lastKey offset:uvarint length:uvarint)* checksum:fixed32
+//	props     := numEntries:uvarint numDeletions:uvarint rawKeySize:uvarint
+//	             rawValueSize:uvarint numUserProps:uvarint
+//	             (name:varstring value:varstring)* checksum:fixed32
+//	footer    := indexOffset:fixed64 indexLength:fixed64
+//	             propsOffset:fixed64 propsLength:fixed64 magic:fixed64
+//
+// Checksums are CRC-32C over the preceding bytes of the block, and the index
+// holds the last internal key of every data block. Range tombstones are not
+// stored in sstables; see rangeTombstone.
+const (
+	tableFooterLen = 40
+	tableMagic     = uint64(0x6c736d7461626c65) // "lsmtable"
+	checksumLen    = 4
+)
+
+// TableProperties are the properties recorded in an sstable.
+type TableProperties struct {
+	NumEntries   uint64
+	NumDeletions uint64
+	RawKeySize   uint64
+	RawValueSize uint64
+	// UserProperties are the properties recorded by the DB's
+	// TablePropertyCollectors.
+	UserProperties map[string]string
+}
+
+type blockHandle struct {
+	offset, length uint64
+}
+
+type indexEntry struct {
+	lastKey internalKey
+	handle  blockHandle
+}
+
+// tableWriter writes an sstable. Entries must be added in increasing
+// internal key order.
+type tableWriter struct {
+	f          File
+	w          *bufio.Writer
+	cmp        func(a, b []byte) int
+	blockSize  int
+	collectors []TablePropertyCollector
+
+	offset  uint64
+	block   []byte
+	lastKey internalKey
+	index   []indexEntry
+	props   TableProperties
+
+	smallest, largest internalKey
+	smallestSeqNum    uint64
+	largestSeqNum     uint64
+	err               error
+}
+
+func newTableWriter(f File, o *Options) *tableWriter {
+	w := &tableWriter{
+		f:              f,
+		w:              bufio.NewWriterSize(f, 64<<10),
+		cmp:            o.Comparer.Compare,
+		blockSize:      o.BlockSize,
+		smallestSeqNum: seqNumMax,
+	}
+	for _, newCollector := range o.TablePropertyCollectors {
+		w.collectors = append(w.collectors, newCollector())
+	}
+	return w
+}
+
+func (w *tableWriter) add(key internalKey, value []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.props.NumEntries > 0 && compareInternal(w.cmp, w.lastKey, key) >= 0 {
+		w.err = errors.Errorf("keys must be added in strictly increasing order: %s, %s", w.lastKey, key)
+		return w.err
+	}
+	for _, c := range w.collectors {
+		if err := c.Add(key.userKey, key.kind(), value); err != nil {
+			w.err = err
+			return err
+		}
+	}
+
+	var buf [2 * binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(key.encodedLen()))
+	n += binary.PutUvarint(buf[n:], uint64(len(value)))
+	w.block = append(w.block, buf[:n]...)
+	w.block = key.encode(w.block)
+	w.block = append(w.block, value...)
+
+	w.lastKey = key.clone()
+	if w.props.NumEntries == 0 {
+		w.smallest = w.lastKey
+	}
+	w.largest = w.lastKey
+	if s := key.seqNum(); s < w.smallestSeqNum {
+		w.smallestSeqNum = s
+	}
+	if s := key.seqNum(); s > w.largestSeqNum {
+		w.largestSeqNum = s
+	}
+	w.props.NumEntries++
+	if k := key.kind(); k == KindDelete || k == KindSingleDelete {
+		w.props.NumDeletions++
+	}
+	w.props.RawKeySize += uint64(key.encodedLen())
+	w.props.RawValueSize += uint64(len(value))
+
+	if len(w.block) >= w.blockSize {
+		return w.finishDataBlock()
+	}
+	return nil
+}
+
+// writeBlock appends the checksum to a block and writes it out.
+func (w *tableWriter) writeBlock(b []byte) (blockHandle, error) {
+	var crc [checksumLen]byte
+	binary.LittleEndian.PutUint32(crc[:], crc32.Checksum(b, crcTable))
+	h := blockHandle{offset: w.offset, length: uint64(len(b) + checksumLen)}
+	if _, err := w.w.Write(b); err != nil {
+		return h, err
+	}
+	if _, err := w.w.Write(crc[:]); err != nil {
+		return h, err
+	}
+	w.offset += h.length
+	return h, nil
+}
+
+func (w *tableWriter) finishDataBlock() error {
+	if len(w.block) == 0 {
+		return nil
+	}
+	h, err := w.writeBlock(w.block)
+	if err != nil {
+		w.err = err
+		return err
+	}
+	w.index = append(w.index, indexEntry{lastKey: w.lastKey, handle: h})
+	w.block = w.block[:0]
+	return nil
+}
+
+// estimatedSize returns the approximate size of the table if it were closed
+// now.
+func (w *tableWriter) estimatedSize() uint64 {
+	return w.offset + uint64(len(w.block))
+}
+
+// close finishes the table, syncs it and closes the file. It returns the
+// size of the table.
+func (w *tableWriter) close() (uint64, error) {
+	err := w.finish()
+	if err != nil {
+		_ = w.f.Close()
+		return 0, err
+	}
+	if err := w.f.Sync(); err != nil {
+		_ = w.f.Close()
+		return 0, err
+	}
+	return w.offset, w.f.Close()
+}
+
+func (w *tableWriter) finish() error {
+	if err := w.finishDataBlock(); err != nil {
+		return err
+	}
+
+	var buf []byte
+	var tmp [binary.MaxVarintLen64]byte
+	putUvarint := func(v uint64) {
+		n := binary.PutUvarint(tmp[:], v)
+		buf = append(buf, tmp[:n]...)
+	}
+	for _, e := range w.index {
+		putUvarint(uint64(e.lastKey.encodedLen()))
+		buf = e.lastKey.encode(buf)
+		putUvarint(e.handle.offset)
+		putUvarint(e.handle.length)
+	}
+	indexHandle, err := w.writeBlock(buf)
+	if err != nil {
+		return err
+	}
+
+	w.props.UserProperties = map[string]string{}
+	for _, c := range w.collectors {
+		if err := c.Finish(w.props.UserProperties); err != nil {
+			return err
+		}
+	}
+	names := make([]string, 0, len(w.props.UserProperties))
+	for name := range w.props.UserProperties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buf = buf[:0]
+	putUvarint(w.props.NumEntries)
+	putUvarint(w.props.NumDeletions)
+	putUvarint(w.props.RawKeySize)
+	putUvarint(w.props.RawValueSize)
+	putUvarint(uint64(len(names)))
+	for _, name := range names {
+		value := w.props.UserProperties[name]
+		putUvarint(uint64(len(name)))
+		buf = append(buf, name...)
+		putUvarint(uint64(len(value)))
+		buf = append(buf, value...)
+	}
+	propsHandle, err := w.writeBlock(buf)
+	if err != nil {
+		return err
+	}
+
+	var footer [tableFooterLen]byte
+	binary.LittleEndian.PutUint64(footer[0:], indexHandle.offset)
+	binary.LittleEndian.PutUint64(footer[8:], indexHandle.length)
+	binary.LittleEndian.PutUint64(footer[16:], propsHandle.offset)
+	binary.LittleEndian.PutUint64(footer[24:], propsHandle.length)
+	binary.LittleEndian.PutUint64(footer[32:], tableMagic)
+	if _, err := w.w.Write(footer[:]); err != nil {
+		return err
+	}
+	w.offset += tableFooterLen
+	return w.w.Flush()
+}
+
+// TableWriter writes an sstable which can be ingested with DB.Ingest. Keys
+// must be added in strictly increasing order.
+type TableWriter struct {
+	w *tableWriter
+}
+
+// NewTableWriter returns a TableWriter which writes to f. The options must
+// match those of the DB the table will be ingested into.
+func NewTableWriter(f File, o *Options) *TableWriter {
+	return &TableWriter{w: newTableWriter(f, o.EnsureDefaults())}
+}
+
+// Set adds an entry setting key to value.
+func (w *TableWriter) Set(key, value []byte) error {
+	return w.w.add(makeInternalKey(key, 0, KindSet), value)
+}
+
+// Delete adds a deletion of key.
+func (w *TableWriter) Delete(key []byte) error {
+	return w.w.add(makeInternalKey(key, 0, KindDelete), nil)
+}
+
+// Merge adds a merge operand for key.
+func (w *TableWriter) Merge(key, value []byte) error {
+	return w.w.add(makeInternalKey(key, 0, KindMerge), value)
+}
+
+// EstimatedSize returns the approximate size of the table.
+func (w *TableWriter) EstimatedSize() uint64 {
+	return w.w.estimatedSize()
+}
+
+// Close finishes writing the table and closes the underlying file.
+func (w *TableWriter) Close() error {
+	_, err := w.w.close()
+	return err
+}
+
+// blockEntry is a decoded entry of a data block.
+type blockEntry struct {
+	key   internalKey
+	value []byte
+}
+
+// block is a decoded data block. Blocks are immutable once decoded and are
+// shared through the block cache.
+type block struct {
+	entries []blockEntry
+	size    int64
+}
+
+// tableReader reads an sstable. The index and properties are held in
+// memory; data blocks are read through the block cache.
+type tableReader struct {
+	f       File
+	fileNum uint64
+	cmp     func(a, b []byte) int
+	cache   *blockCache
+	// globalSeqNum, if non-zero, replaces the sequence number of every entry
+	// in the table. It is set for ingested tables, which are written with
+	// zero sequence numbers.
+	globalSeqNum uint64
+
+	index []indexEntry
+	props TableProperties
+}
+
+func openTable(
+	f File, fileNum uint64, cmp func(a, b []byte) int, cache *blockCache, globalSeqNum uint64,
+) (*tableReader, error) {
+	r := &tableReader{f: f, fileNum: fileNum, cmp: cmp, cache: cache, globalSeqNum: globalSeqNum}
+	if err := r.init(); err != nil {
+		_ = f.Close()
+		return nil, errors.Wrapf(err, "opening table %06d", fileNum)
+	}
+	return r, nil
+}
+
+func (r *tableReader) init() error {
+	info, err := r.f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < tableFooterLen {
+		return errors.Errorf("file too small to be an sstable: %d bytes", info.Size())
+	}
+	var footer [tableFooterLen]byte
+	if _, err := r.f.ReadAt(footer[:], info.Size()-tableFooterLen); err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint64(footer[32:]) != tableMagic {
+		return errors.New("bad sstable magic number")
+	}
+	indexHandle := blockHandle{binary.LittleEndian.Uint64(footer[0:]), binary.LittleEndian.Uint64(footer[8:])}
+	propsHandle := blockHandle{binary.LittleEndian.Uint64(footer[16:]), binary.LittleEndian.Uint64(footer[24:])}
+
+	buf, err := r.readBlock(indexHandle)
+	if err != nil {
+		return err
+	}
+	for len(buf) > 0 {
+		var e indexEntry
+		var keyBuf []byte
+		if keyBuf, buf, err = readVarstring(buf); err != nil {
+			return err
+		}
+		key, ok := decodeInternalKey(keyBuf)
+		if !ok {
+			return errors.New("corrupted sstable index")
+		}
+		e.lastKey = r.applyGlobalSeqNum(key)
+		if e.handle.offset, buf, err = readUvarint(buf); err != nil {
+			return err
+		}
+		if e.handle.length, buf, err = readUvarint(buf); err != nil {
+			return err
+		}
+		r.index = append(r.index, e)
+	}
+
+	if buf, err = r.readBlock(propsHandle); err != nil {
+		return err
+	}
+	p := &r.props
+	for _, v := range []*uint64{&p.NumEntries, &p.NumDeletions, &p.RawKeySize, &p.RawValueSize} {
+		if *v, buf, err = readUvarint(buf); err != nil {
+			return err
+		}
+	}
+	var n uint64
+	if n, buf, err = readUvarint(buf); err != nil {
+		return err
+	}
+	p.UserProperties = make(map[string]string, n)
+	for i := uint64(0); i < n; i++ {
+		var name, value []byte
+		if name, buf, err = readVarstring(buf); err != nil {
+			return err
+		}
+		if value, buf, err = readVarstring(buf); err != nil {
+			return err
+		}
+		p.UserProperties[string(name)] = string(value)
+	}
+	return nil
+}
+
+func (r *tableReader) applyGlobalSeqNum(key internalKey) internalKey {
+	if r.globalSeqNum != 0 {
+		key.trailer = makeTrailer(r.globalSeqNum, key.kind())
+	}
+	return key
+}
+
+// readBlock reads a block and verifies its checksum. It returns the block
+// without its checksum.
+func (r *tableReader) readBlock(h blockHandle) ([]byte, error) {
+	if h.length < checksumLen {
+		return nil, errors.Errorf("corrupted sstable block handle: %+v", h)
+	}
+	buf := make([]byte, h.length)
+	if _, err := r.f.ReadAt(buf, int64(h.offset)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	n := len(buf) - checksumLen
+	if crc32.Checksum(buf[:n], crcTable) != binary.LittleEndian.Uint32(buf[n:]) {
+		return nil, errors.Errorf("checksum mismatch in table %06d at offset %d", r.fileNum, h.offset)
+	}
+	return buf[:n], nil
+}
+
+func (r *tableReader) dataBlock(i int) (*block, error) {
+	h := r.index[i].handle
+	cacheKey := blockCacheKey{fileNum: r.fileNum, offset: h.offset}
+	if b := r.cache.get(cacheKey); b != nil {
+		return b, nil
+	}
+	buf, err := r.readBlock(h)
+	if err != nil {
+		return nil, err
+	}
+	b := &block{size: int64(h.length)}
+	for len(buf) > 0 {
+		keyLen, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return nil, errors.New("corrupted sstable block")
+		}
+		buf = buf[n:]
+		valueLen, n := binary.Uvarint(buf)
+		if n <= 0 || uint64(len(buf)-n) < keyLen+valueLen {
+			return nil, errors.New("corrupted sstable block")
+		}
+		buf = buf[n:]
+		key, ok := decodeInternalKey(buf[:keyLen])
+		if !ok {
+			return nil, errors.New("corrupted sstable block")
+		}
+		end := keyLen + valueLen
+		b.entries = append(b.entries, blockEntry{
+			key:   r.applyGlobalSeqNum(key),
+			value: buf[keyLen:end:end],
+		})
+		buf = buf[end:]
+	}
+	b.size += int64(len(b.entries)) * 48
+	r.cache.add(cacheKey, b)
+	return b, nil
+}
+
+func (r *tableReader) newIter() *tableIter {
+	return &tableIter{r: r}
+}
+
+func (r *tableReader) close() error {
+	return r.f.Close()
+}
+
+func readUvarint(buf []byte) (uint64, []byte, error) {
+	v, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return 0, nil, errors.New("corrupted sstable: invalid varint")
+	}
+	return v, buf[n:], nil
+}
+
+func readVarstring(buf []byte) ([]byte, []byte, error) {
+	n, buf, err := readUvarint(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	if uint64(len(buf)) < n {
+		return nil, nil, errors.New("corrupted sstable: invalid varstring")
+	}
+	return buf[:n:n], buf[n:], nil
+}
+
+// tableIter iterates over the entries of an sstable. It implements
+// internalIterator.
+type tableIter struct {
+	r        *tableReader
+	blockIdx int
+	block    *block
+	pos      int
+	err      error
+}
+
+var _ internalIterator = &tableIter{}
+
+// loadBlock positions the iterator at the first entry of the given block.
+func (it *tableIter) loadBlock(i int) bool {
+	it.blockIdx = i
+	it.block = nil
+	if i < 0 || i >= len(it.r.index) {
+		return false
+	}
+	b, err := it.r.dataBlock(i)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.block = b
+	it.pos = 0
+	return true
+}
+
+func (it *tableIter) SeekGE(key []byte) {
+	seekKey := makeSeekKey(key)
+	i := sort.Search(len(it.r.index), func(i int) bool {
+		return compareInternal(it.r.cmp, it.r.index[i].lastKey, seekKey) >= 0
+	})
+	if !it.loadBlock(i) {
+		return
+	}
+	entries := it.block.entries
+	it.pos = sort.Search(len(entries), func(j int) bool {
+		return compareInternal(it.r.cmp, entries[j].key, seekKey) >= 0
+	})
+	it.skipForward()
+}
+
+func (it *tableIter) SeekLT(key []byte) {
+	seekKey := makeSeekKey(key)
+	i := sort.Search(len(it.r.index), func(i int) bool {
+		return compareInternal(it.r.cmp, it.r.index[i].lastKey, seekKey) >= 0
+	})
+	if i == len(it.r.index) {
+		it.Last()
+		return
+	}
+	if !it.loadBlock(i) {
+		return
+	}
+	entries := it.block.entries
+	it.pos = sort.Search(len(entries), func(j int) bool {
+		return compareInternal(it.r.cmp, entries[j].key, seekKey) >= 0
+	}) - 1
+	if it.pos < 0 {
+		if it.loadBlock(i - 1) {
+			it.pos = len(it.block.entries) - 1
+		}
+	}
+}
+
+func (it *tableIter) First() {
+	if it.loadBlock(0) {
+		it.skipForward()
+	}
+}
+
+func (it *tableIter) Last() {
+	if it.loadBlock(len(it.r.index) - 1) {
+		it.pos = len(it.block.entries) - 1
+	}
+}
+
+func (it *tableIter) Next() {
+	it.pos++
+	it.skipForward()
+}
+
+// skipForward moves to the next block while the current block is exhausted.
+func (it *tableIter) skipForward() {
+	for it.block != nil && it.pos >= len(it.block.entries) {
+		it.loadBlock(it.blockIdx + 1)
+	}
+}
+
+func (it *tableIter) Valid() bool {
+	return it.block != nil && it.pos >= 0 && it.pos < len(it.block.entries)
+}
+
+func (it *tableIter) Key() internalKey {
+	return it.block.entries[it.pos].key
+}
+
+func (it *tableIter) Value() []byte {
+	return it.block.entries[it.pos].value
+}
+
+func (it *tableIter) Error() error {
+	return it.err
+}
+
+func (it *tableIter) Close() error {
+	it.block = nil
+	return it.err
+}
@@ -0,0 +1,108 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestTableRoundTrip(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := NewMemFS()
+	opts := (&Options{
+		BlockSize: 64,
+		TablePropertyCollectors: []func() TablePropertyCollector{
+			func() TablePropertyCollector { return &countingCollector{} },
+		},
+	}).EnsureDefaults()
+
+	f, err := fs.Create("/table")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := newTableWriter(f, opts)
+	const n = 200
+	key := func(i int) []byte { return []byte(fmt.Sprintf("%05d", i*2)) }
+	for i := 0; i < n; i++ {
+		if err := w.add(makeInternalKey(key(i), uint64(i+1), KindSet), []byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.add(makeInternalKey(key(0), 1, KindSet), nil); err == nil {
+		t.Fatal("expected error adding an out-of-order key")
+	}
+	if _, err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if f, err = fs.Open("/table"); err != nil {
+		t.Fatal(err)
+	}
+	r, err := openTable(f, 1, DefaultComparer.Compare, newBlockCache(1<<10), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.close()
+	if len(r.index) < 10 {
+		t.Fatalf("expected many blocks, got %d", len(r.index))
+	}
+	if r.props.NumEntries != n || r.props.UserProperties["count"] != fmt.Sprint(n) {
+		t.Fatalf("unexpected properties %+v", r.props)
+	}
+
+	it := r.newIter()
+	var count int
+	for it.First(); it.Valid(); it.Next() {
+		if k := it.Key(); string(k.userKey) != string(key(count)) || k.seqNum() != uint64(count+1) {
+			t.Fatalf("unexpected key %s at %d", k, count)
+		}
+		count++
+	}
+	if count != n {
+		t.Fatalf("expected %d entries, got %d", n, count)
+	}
+	for i := 0; i < n; i++ {
+		// Seek to keys which are present and to keys which fall between
+		// present keys.
+		it.SeekGE(key(i))
+		if !it.Valid() || string(it.Key().userKey) != string(key(i)) {
+			t.Fatalf("SeekGE(%s) failed", key(i))
+		}
+		between := append(key(i), 'x')
+		it.SeekLT(between)
+		if !it.Valid() || string(it.Key().userKey) != string(key(i)) {
+			t.Fatalf("SeekLT(%s) failed", between)
+		}
+	}
+	if it.SeekLT(key(0)); it.Valid() {
+		t.Fatalf("unexpected key %s before the first key", it.Key())
+	}
+	if it.SeekGE(key(n)); it.Valid() {
+		t.Fatalf("unexpected key %s after the last key", it.Key())
+	}
+
+	// Corrupting a data block is detected by its checksum.
+	node := fs.(*memFS).mu.files["/table"]
+	node.mu.data[3] ^= 0xff
+	corrupt, err := openTable(f, 2, DefaultComparer.Compare, newBlockCache(1<<10), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ci := corrupt.newIter()
+	if ci.First(); ci.Valid() || ci.Error() == nil {
+		t.Fatal("expected checksum error")
+	}
+}
@@ -0,0 +1,439 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/pkg/errors"
+)
+
+// numLevels is the number of levels in the LSM. L0 holds flushed memtables
+// and ingested sstables, which may overlap; the sstables of every other level
+// are non-overlapping.
+const numLevels = 7
+
+type fileType int
+
+const (
+	fileTypeLog fileType = iota
+	fileTypeTable
+	fileTypeManifest
+	fileTypeCurrent
+	fileTypeLock
+	fileTypeTemp
+)
+
+func makeFilename(dirname string, typ fileType, fileNum uint64) string {
+	switch typ {
+	case fileTypeLog:
+		return filepath.Join(dirname, fmt.Sprintf("%06d.log", fileNum))
+	case fileTypeTable:
+		return filepath.Join(dirname, fmt.Sprintf("%06d.sst", fileNum))
+	case fileTypeManifest:
+		return filepath.Join(dirname, fmt.Sprintf("MANIFEST-%06d", fileNum))
+	case fileTypeCurrent:
+		return filepath.Join(dirname, "CURRENT")
+	case fileTypeLock:
+		return filepath.Join(dirname, "LOCK")
+	case fileTypeTemp:
+		return filepath.Join(dirname, fmt.Sprintf("CURRENT.%06d.tmp", fileNum))
+	}
+	panic(fmt.Sprintf("unknown file type %d", typ))
+}
+
+// parseFilename returns the type and number of a file created by the DB.
+func parseFilename(name string) (fileType, uint64, bool) {
+	switch {
+	case name == "CURRENT":
+		return fileTypeCurrent, 0, true
+	case name == "LOCK":
+		return fileTypeLock, 0, true
+	case strings.HasPrefix(name, "MANIFEST-"):
+		n, err := strconv.ParseUint(name[len("MANIFEST-"):], 10, 64)
+		return fileTypeManifest, n, err == nil
+	case strings.HasPrefix(name, "CURRENT.") && strings.HasSuffix(name, ".tmp"):
+		n, err := strconv.ParseUint(name[len("CURRENT."):len(name)-len(".tmp")], 10, 64)
+		return fileTypeTemp, n, err == nil
+	case strings.HasSuffix(name, ".log"):
+		n, err := strconv.ParseUint(name[:len(name)-len(".log")], 10, 64)
+		return fileTypeLog, n, err == nil
+	case strings.HasSuffix(name, ".sst"):
+		n, err := strconv.ParseUint(name[:len(name)-len(".sst")], 10, 64)
+		return fileTypeTable, n, err == nil
+	}
+	return 0, 0, false
+}
+
+// fileMetadata describes an sstable in a version.
+type fileMetadata struct {
+	fileNum uint64
+	size    uint64
+	// smallest and largest bound the user keys of the entries and range
+	// tombstones in the table. largest is exclusive if largestExclusive is
+	// set, which happens when it is the end key of a range tombstone.
+	smallest, largest []byte
+	largestExclusive  bool
+	smallestSeqNum    uint64
+	largestSeqNum     uint64
+	// globalSeqNum is non-zero for ingested tables; see tableReader.
+	globalSeqNum uint64
+	// rangeDels are the range tombstones of the table.
+	rangeDels []rangeTombstone
+
+	refs int32 // accessed atomically
+}
+
+// overlaps returns whether the table may contain keys in [start, end]. A nil
+// start or end is unbounded.
+func (f *fileMetadata) overlaps(cmp func(a, b []byte) int, start, end []byte) bool {
+	if end != nil && cmp(f.smallest, end) > 0 {
+		return false
+	}
+	return start == nil || f.containsUpper(cmp, start)
+}
+
+// containsUpper returns whether key is not beyond the upper bound of the
+// table.
+func (f *fileMetadata) containsUpper(cmp func(a, b []byte) int, key []byte) bool {
+	c := cmp(f.largest, key)
+	return c > 0 || (c == 0 && !f.largestExclusive)
+}
+
+// version is an immutable snapshot of the sstables in each level.
+type version struct {
+	files [numLevels][]*fileMetadata
+	// rangeDels are the fragments of the range tombstones of all tables.
+	rangeDels rangeDelFragments
+
+	tables *tableCache
+	refs   int32 // accessed atomically
+}
+
+func newVersion(cmp func(a, b []byte) int, tables *tableCache, files [numLevels][]*fileMetadata) *version {
+	v := &version{files: files, tables: tables}
+	var tombstones []rangeTombstone
+	for level := range v.files {
+		sortFiles(cmp, level, v.files[level])
+		for _, f := range v.files[level] {
+			atomic.AddInt32(&f.refs, 1)
+			tombstones = append(tombstones, f.rangeDels...)
+		}
+	}
+	v.rangeDels = fragmentRangeTombstones(cmp, tombstones)
+	return v
+}
+
+// sortFiles orders L0 tables from oldest to newest and the tables of other
+// levels by key.
+func sortFiles(cmp func(a, b []byte) int, level int, files []*fileMetadata) {
+	if level == 0 {
+		sort.Slice(files, func(i, j int) bool {
+			if files[i].largestSeqNum != files[j].largestSeqNum {
+				return files[i].largestSeqNum < files[j].largestSeqNum
+			}
+			return files[i].fileNum < files[j].fileNum
+		})
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return cmp(files[i].smallest, files[j].smallest) < 0 })
+}
+
+func (v *version) ref() {
+	atomic.AddInt32(&v.refs, 1)
+}
+
+// unref releases a reference to the version. Once the last reference is
+// released, the tables which are no longer referenced by any version are
+// deleted.
+func (v *version) unref() {
+	if atomic.AddInt32(&v.refs, -1) != 0 {
+		return
+	}
+	for level := range v.files {
+		for _, f := range v.files[level] {
+			if atomic.AddInt32(&f.refs, -1) == 0 {
+				v.tables.obsolete(f.fileNum)
+			}
+		}
+	}
+}
+
+// overlaps returns the tables of a level which may contain keys in
+// [start, end].
+func (v *version) overlaps(cmp func(a, b []byte) int, level int, start, end []byte) []*fileMetadata {
+	var res []*fileMetadata
+	for _, f := range v.files[level] {
+		if f.overlaps(cmp, start, end) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+func (v *version) levelSize(level int) uint64 {
+	var size uint64
+	for _, f := range v.files[level] {
+		size += f.size
+	}
+	return size
+}
+
+// versionState is the state persisted in the manifest.
+type versionState struct {
+	comparerName string
+	mergerName   string
+	nextFileNum  uint64
+	// logNum is the number of the oldest WAL which holds writes which have
+	// not been flushed.
+	logNum     uint64
+	lastSeqNum uint64
+	files      [numLevels][]*fileMetadata
+}
+
+// encodeManifest encodes the full state of the DB. Every manifest holds a
+// single record, so that replaying it is trivial; manifests are replaced
+// rather than appended to.
+func encodeManifest(s *versionState) []byte {
+	var buf []byte
+	var tmp [binary.MaxVarintLen64]byte
+	putUvarint := func(v uint64) {
+		n := binary.PutUvarint(tmp[:], v)
+		buf = append(buf, tmp[:n]...)
+	}
+	putBytes := func(b []byte) {
+		putUvarint(uint64(len(b)))
+		buf = append(buf, b...)
+	}
+	putBytes([]byte(s.comparerName))
+	putBytes([]byte(s.mergerName))
+	putUvarint(s.nextFileNum)
+	putUvarint(s.logNum)
+	putUvarint(s.lastSeqNum)
+	for level := range s.files {
+		putUvarint(uint64(len(s.files[level])))
+		for _, f := range s.files[level] {
+			putUvarint(f.fileNum)
+			putUvarint(f.size)
+			putBytes(f.smallest)
+			putBytes(f.largest)
+			if f.largestExclusive {
+				putUvarint(1)
+			} else {
+				putUvarint(0)
+			}
+			putUvarint(f.smallestSeqNum)
+			putUvarint(f.largestSeqNum)
+			putUvarint(f.globalSeqNum)
+			putUvarint(uint64(len(f.rangeDels)))
+			for _, t := range f.rangeDels {
+				putBytes(t.start)
+				putBytes(t.end)
+				putUvarint(t.seqNum)
+			}
+		}
+	}
+	return buf
+}
+
+func decodeManifest(buf []byte) (*versionState, error) {
+	var err error
+	getUvarint := func() uint64 {
+		if err != nil {
+			return 0
+		}
+		var v uint64
+		v, buf, err = readUvarint(buf)
+		return v
+	}
+	getBytes := func() []byte {
+		if err != nil {
+			return nil
+		}
+		var b []byte
+		b, buf, err = readVarstring(buf)
+		return append([]byte(nil), b...)
+	}
+	s := &versionState{}
+	s.comparerName = string(getBytes())
+	s.mergerName = string(getBytes())
+	s.nextFileNum = getUvarint()
+	s.logNum = getUvarint()
+	s.lastSeqNum = getUvarint()
+	for level := range s.files {
+		n := getUvarint()
+		for i := uint64(0); i < n && err == nil; i++ {
+			f := &fileMetadata{}
+			f.fileNum = getUvarint()
+			f.size = getUvarint()
+			f.smallest = getBytes()
+			f.largest = getBytes()
+			f.largestExclusive = getUvarint() == 1
+			f.smallestSeqNum = getUvarint()
+			f.largestSeqNum = getUvarint()
+			f.globalSeqNum = getUvarint()
+			numRangeDels := getUvarint()
+			for j := uint64(0); j < numRangeDels && err == nil; j++ {
+				f.rangeDels = append(f.rangeDels, rangeTombstone{
+					start:  getBytes(),
+					end:    getBytes(),
+					seqNum: getUvarint(),
+				})
+			}
+			s.files[level] = append(s.files[level], f)
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "corrupted manifest")
+	}
+	return s, nil
+}
+
+// writeManifest writes the state to a new manifest and points CURRENT at it.
+func writeManifest(fs FS, dirname string, manifestNum uint64, s *versionState) error {
+	name := makeFilename(dirname, fileTypeManifest, manifestNum)
+	f, err := fs.Create(name)
+	if err != nil {
+		return err
+	}
+	w := newWALWriter(f)
+	if err := w.add(encodeManifest(s)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+	return setCurrentFile(fs, dirname, manifestNum)
+}
+
+func setCurrentFile(fs FS, dirname string, manifestNum uint64) error {
+	tmp := makeFilename(dirname, fileTypeTemp, manifestNum)
+	f, err := fs.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "MANIFEST-%06d\n", manifestNum); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return fs.Rename(tmp, makeFilename(dirname, fileTypeCurrent, 0))
+}
+
+// readManifest reads the manifest that CURRENT points at.
+func readManifest(fs FS, dirname string) (*versionState, uint64, error) {
+	f, err := fs.Open(makeFilename(dirname, fileTypeCurrent, 0))
+	if err != nil {
+		return nil, 0, err
+	}
+	var current [64]byte
+	n, err := f.ReadAt(current[:], 0)
+	_ = f.Close()
+	if n == 0 && err != nil {
+		return nil, 0, err
+	}
+	typ, manifestNum, ok := parseFilename(strings.TrimSpace(string(current[:n])))
+	if !ok || typ != fileTypeManifest {
+		return nil, 0, errors.Errorf("CURRENT does not name a manifest: %q", current[:n])
+	}
+	if f, err = fs.Open(makeFilename(dirname, fileTypeManifest, manifestNum)); err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var s *versionState
+	if err := replayWAL(f, func(payload []byte) error {
+		s, err = decodeManifest(payload)
+		return err
+	}); err != nil {
+		return nil, 0, err
+	}
+	if s == nil {
+		return nil, 0, errors.Errorf("manifest %06d is empty", manifestNum)
+	}
+	return s, manifestNum, nil
+}
+
+// tableCache holds the open readers of the DB's sstables. Readers are
+// opened on first use and closed once their table becomes obsolete.
+type tableCache struct {
+	dirname string
+	fs      FS
+	cmp     func(a, b []byte) int
+	cache   *blockCache
+
+	mu struct {
+		syncutil.Mutex
+		readers map[uint64]*tableReader
+	}
+}
+
+func newTableCache(dirname string, fs FS, cmp func(a, b []byte) int, cache *blockCache) *tableCache {
+	c := &tableCache{dirname: dirname, fs: fs, cmp: cmp, cache: cache}
+	c.mu.readers = map[uint64]*tableReader{}
+	return c
+}
+
+func (c *tableCache) get(f *fileMetadata) (*tableReader, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r, ok := c.mu.readers[f.fileNum]; ok {
+		return r, nil
+	}
+	file, err := c.fs.Open(makeFilename(c.dirname, fileTypeTable, f.fileNum))
+	if err != nil {
+		return nil, err
+	}
+	r, err := openTable(file, f.fileNum, c.cmp, c.cache, f.globalSeqNum)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.readers[f.fileNum] = r
+	return r, nil
+}
+
+// obsolete closes the reader of a table which is no longer referenced and
+// deletes the table.
+func (c *tableCache) obsolete(fileNum uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r, ok := c.mu.readers[fileNum]; ok {
+		_ = r.close()
+		delete(c.mu.readers, fileNum)
+	}
+	c.cache.evictFile(fileNum)
+	// A table whose removal fails is removed when the DB is next opened.
+	_ = c.fs.Remove(makeFilename(c.dirname, fileTypeTable, fileNum))
+}
+
+func (c *tableCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for fileNum, r := range c.mu.readers {
+		_ = r.close()
+		delete(c.mu.readers, fileNum)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package lsm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// walHeaderLen is the length of the header preceding each WAL record: a
+// CRC-32C of the payload followed by the payload length.
+const walHeaderLen = 8
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// walWriter appends batch representations to a write-ahead log.
+type walWriter struct {
+	f File
+	w *bufio.Writer
+}
+
+func newWALWriter(f File) *walWriter {
+	return &walWriter{f: f, w: bufio.NewWriterSize(f, 64<<10)}
+}
+
+// add appends a record. The record is durable once sync returns.
+func (w *walWriter) add(payload []byte) error {
+	var hdr [walHeaderLen]byte
+	binary.LittleEndian.PutUint32(hdr[0:4], crc32.Checksum(payload, crcTable))
+	binary.LittleEndian.PutUint32(hdr[4:8], uint32(len(payload)))
+	if _, err := w.w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.w.Write(payload)
+	return err
+}
+
+func (w *walWriter) flush() error {
+	return w.w.Flush()
+}
+
+func (w *walWriter) sync() error {
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+func (w *walWriter) close() error {
+	if err := w.sync(); err != nil {
+		_ = w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
+
+// replayWAL calls fn with every intact record in the log. A torn or corrupt
+// record ends the replay: records are only acknowledged after being synced,
+// so such a record belongs to a write which was never acknowledged.
+func replayWAL(f File, fn func(payload []byte) error) error {
+	r := bufio.NewReaderSize(f, 64<<10)
+	var hdr [walHeaderLen]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil
+		}
+		checksum := binary.LittleEndian.Uint32(hdr[0:4])
+		payload := make([]byte, binary.LittleEndian.Uint32(hdr[4:8]))
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil
+		}
+		if crc32.Checksum(payload, crcTable) != checksum {
+			return nil
+		}
+		if err := fn(payload); err != nil {
+			return err
+		}
+	}
+}
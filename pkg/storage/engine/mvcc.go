@@ -662,7 +662,9 @@ type MVCCGetOptions struct {
 //
 // In tombstones mode, if the most recent value is a deletion tombstone, the
 // result will be a non-nil roachpb.Value whose RawBytes field is nil.
-// Otherwise, a deletion tombstone results in a nil roachpb.Value.
+// Otherwise, a deletion tombstone results in a nil roachpb.Value. A value
+// deleted by an MVCC range tombstone is treated like a deletion tombstone at
+// the range tombstone's timestamp.
 //
 // In inconsistent mode, if an intent is encountered, it will be placed in the
 // dedicated return parameter. By contrast, in consistent mode, an intent will
@@ -681,7 +683,18 @@ func MVCCGet(
 	iter := eng.NewIterator(IterOptions{Prefix: true})
 	value, intent, err := iter.MVCCGet(key, timestamp, opts)
 	iter.Close()
-	return value, intent, err
+	if err != nil || value == nil {
+		return value, intent, err
+	}
+	tombs, err := readRangeTombstones(eng, key, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	value, err = tombs.filterValue(key, value, timestamp, opts.Txn, opts.Tombstones)
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, intent, nil
 }
 
 // MVCCGetAsTxn constructs a temporary transaction from the given transaction
@@ -945,6 +958,8 @@ type putBuffer struct {
 	newMeta enginepb.MVCCMetadata
 	ts      hlc.LegacyTimestamp
 	tmpbuf  []byte
+	// tombs are the MVCC range tombstones covering the key being written.
+	tombs rangeTombstones
 }
 
 var putBufferPool = sync.Pool{
@@ -1131,6 +1146,9 @@ func maybeGetValue(
 			ctx, iter, metaKey, readTS, true /* consistent */, safeValue, txn, getBuf); err != nil {
 			return nil, err
 		}
+		if exVal, err = buf.tombs.filterValue(metaKey.Key, exVal, readTS, txn, false); err != nil {
+			return nil, err
+		}
 	}
 	return valueFn(exVal)
 }
@@ -1217,6 +1235,9 @@ func replayTransactionalWrite(
 				ctx, iter, metaKey, timestamp, false /* consistent */, unsafeValue, nil /* txn */, getBuf); err != nil {
 				return err
 			}
+			if exVal, err = buf.tombs.filterValue(metaKey.Key, exVal, timestamp, nil, false); err != nil {
+				return err
+			}
 			value, err = valueFn(exVal)
 			if err != nil {
 				return err
@@ -1340,6 +1361,20 @@ func mvccPutInternal(
 
 	timestamp = hlc.Timestamp{} // prevent accidental use below
 
+	// Load the MVCC range tombstones covering the key. A write below a range
+	// tombstone is treated like a write below a committed value. Blind writes
+	// don't read anything and are only used for keys which the caller knows
+	// have not been written before.
+	var tombTimestamp hlc.Timestamp
+	if reader, isReader := engine.(Reader); isReader && iter != nil && !isSysLocal(key) {
+		if buf.tombs, err = readRangeTombstones(reader, key, nil); err != nil {
+			return err
+		}
+		if c := buf.tombs.covering(key); len(c) > 0 {
+			tombTimestamp = c[0].Timestamp
+		}
+	}
+
 	// Determine what the logical operation is. Are we writing an intent
 	// or a value directly?
 	logicalOp := MVCCWriteValueOpType
@@ -1358,6 +1393,8 @@ func mvccPutInternal(
 		// There is existing metadata for this key; ensure our write is permitted.
 		meta = &buf.meta
 		metaTimestamp := hlc.Timestamp(meta.Timestamp)
+		latestTimestamp := metaTimestamp
+		latestTimestamp.Forward(tombTimestamp)
 
 		if meta.Txn != nil {
 			// There is an uncommitted write intent.
@@ -1388,6 +1425,13 @@ func mvccPutInternal(
 			if err != nil {
 				return err
 			}
+			// The existing value is only covered by a range tombstone if it was
+			// written by an earlier epoch of the transaction, in which case it
+			// is not the intent itself.
+			existingVal, err = buf.tombs.filterValue(key, existingVal, readTimestamp, nil, false)
+			if err != nil {
+				return err
+			}
 			// It's possible that the existing value is nil if the intent on the key
 			// has a lower epoch. We don't have to deal with this as a special case
 			// because in this case, the value isn't written to the intent history.
@@ -1461,9 +1505,9 @@ func mvccPutInternal(
 			} else {
 				buf.newMeta.IntentHistory = nil
 			}
-		} else if !metaTimestamp.Less(readTimestamp) {
+		} else if !latestTimestamp.Less(readTimestamp) {
 			// This is the case where we're trying to write under a committed
-			// value. Obviously we can't do that, but we can increment our
+			// value (or a range tombstone). Obviously we can't do that, but we can increment our
 			// timestamp to one logical tick past the existing value and go on
 			// to write, but then also return a write-too-old error indicating
 			// what the timestamp ended up being. This timestamp can then be
@@ -1483,7 +1527,7 @@ func mvccPutInternal(
 			// by ensuring that they propagate WriteTooOld errors immediately
 			// instead of allowing their transactions to continue and be retried
 			// before committing.
-			writeTimestamp.Forward(metaTimestamp.Next())
+			writeTimestamp.Forward(latestTimestamp.Next())
 			maybeTooOldErr = &roachpb.WriteTooOldError{
 				Timestamp: readTimestamp, ActualTimestamp: writeTimestamp,
 			}
@@ -1515,6 +1559,14 @@ func mvccPutInternal(
 	} else {
 		// There is no existing value for this key. Even if the new value is
 		// nil write a deletion tombstone for the key.
+		if tombTimestamp != (hlc.Timestamp{}) && !tombTimestamp.Less(readTimestamp) {
+			// The key is covered by a range tombstone at or above our read
+			// timestamp. See the case of a committed value above.
+			writeTimestamp.Forward(tombTimestamp.Next())
+			maybeTooOldErr = &roachpb.WriteTooOldError{
+				Timestamp: readTimestamp, ActualTimestamp: writeTimestamp,
+			}
+		}
 		if valueFn != nil {
 			value, err = valueFn(nil)
 			if err != nil {
@@ -1569,6 +1621,15 @@ func mvccPutInternal(
 
 	// Update MVCC stats.
 	if ms != nil {
+		if meta != nil && meta.Txn == nil && !meta.Deleted {
+			// If the previous version was deleted by a range tombstone, it is now
+			// shadowed by the new version instead. updateStatsOnPut assumes that
+			// it was live, so undo the range tombstone's effect first.
+			if t, deleted := buf.tombs.oldestAbove(key, hlc.Timestamp(meta.Timestamp)); deleted {
+				ms.Add(updateStatsOnRangeTombstone(
+					origMetaKeySize, origMetaValSize, meta, t.WallTime, true /* undo */))
+			}
+		}
 		ms.Add(updateStatsOnPut(key, prevValSize, origMetaKeySize, origMetaValSize,
 			metaKeySize, metaValSize, meta, newMeta))
 	}
@@ -1905,17 +1966,64 @@ func MVCCDeleteRange(
 	return keys, resumeSpan, int64(len(kvs)), err
 }
 
+// mvccScanUsingIter calls Iterator.MVCCScan and applies the MVCC range
+// tombstones overlapping the scanned span to its result. Keys deleted by range
+// tombstones don't count towards max: if any are dropped from a batch of
+// results, the scan continues from the batch's resume span until max keys
+// have been returned or the span is exhausted.
+func mvccScanUsingIter(
+	reader Reader,
+	iter Iterator,
+	key, endKey roachpb.Key,
+	max int64,
+	timestamp hlc.Timestamp,
+	opts MVCCScanOptions,
+) ([]byte, int64, *roachpb.Span, []roachpb.Intent, error) {
+	kvData, numKVs, resumeSpan, intents, err := iter.MVCCScan(key, endKey, max, timestamp, opts)
+	if err != nil || numKVs == 0 {
+		return kvData, numKVs, resumeSpan, intents, err
+	}
+	tombs, err := readRangeTombstones(reader, key, endKey)
+	if err != nil || len(tombs) == 0 {
+		return kvData, numKVs, resumeSpan, intents, err
+	}
+	var res []byte
+	var n int64
+	for {
+		filtered, numFiltered, err := tombs.filterScan(
+			kvData, numKVs, timestamp, opts.Txn, opts.Tombstones)
+		if err != nil {
+			return nil, 0, nil, nil, err
+		}
+		res = append(res, filtered...)
+		n += numFiltered
+		if resumeSpan == nil || n >= max {
+			break
+		}
+		var moreIntents []roachpb.Intent
+		kvData, numKVs, resumeSpan, moreIntents, err = iter.MVCCScan(
+			resumeSpan.Key, resumeSpan.EndKey, max-n, timestamp, opts)
+		if err != nil {
+			return nil, 0, nil, nil, err
+		}
+		intents = append(intents, moreIntents...)
+	}
+	return res, n, resumeSpan, intents, nil
+}
+
 // mvccScanToKvs converts the raw key/value pairs returned by Iterator.MVCCScan
 // into a slice of roachpb.KeyValues.
 func mvccScanToKvs(
 	ctx context.Context,
+	reader Reader,
 	iter Iterator,
 	key, endKey roachpb.Key,
 	max int64,
 	timestamp hlc.Timestamp,
 	opts MVCCScanOptions,
 ) ([]roachpb.KeyValue, *roachpb.Span, []roachpb.Intent, error) {
-	kvData, numKVs, resumeSpan, intents, err := iter.MVCCScan(key, endKey, max, timestamp, opts)
+	kvData, numKVs, resumeSpan, intents, err := mvccScanUsingIter(
+		reader, iter, key, endKey, max, timestamp, opts)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -2004,7 +2112,8 @@ type MVCCScanOptions struct {
 // In tombstones mode, if the most recent value for a key is a deletion
 // tombstone, the scan result will contain a roachpb.KeyValue for that key whose
 // RawBytes field is nil. Otherwise, the key-value pair will be omitted from the
-// result entirely.
+// result entirely. Values deleted by MVCC range tombstones are treated like
+// deletion tombstones at the range tombstone's timestamp.
 //
 // When scanning inconsistently, any encountered intents will be placed in the
 // dedicated result parameter. By contrast, when scanning consistently, any
@@ -2025,7 +2134,7 @@ func MVCCScan(
 ) ([]roachpb.KeyValue, *roachpb.Span, []roachpb.Intent, error) {
 	iter := engine.NewIterator(IterOptions{LowerBound: key, UpperBound: endKey})
 	defer iter.Close()
-	return mvccScanToKvs(ctx, iter, key, endKey, max, timestamp, opts)
+	return mvccScanToKvs(ctx, engine, iter, key, endKey, max, timestamp, opts)
 }
 
 // MVCCScanToBytes is like MVCCScan, but it returns the results in a byte array.
@@ -2039,7 +2148,7 @@ func MVCCScanToBytes(
 ) ([]byte, int64, *roachpb.Span, []roachpb.Intent, error) {
 	iter := engine.NewIterator(IterOptions{LowerBound: key, UpperBound: endKey})
 	defer iter.Close()
-	return iter.MVCCScan(key, endKey, max, timestamp, opts)
+	return mvccScanUsingIter(engine, iter, key, endKey, max, timestamp, opts)
 }
 
 // MVCCIterate iterates over the key range [start,end). At each step of the
@@ -2063,7 +2172,7 @@ func MVCCIterate(
 	for {
 		const maxKeysPerScan = 1000
 		kvs, resume, newIntents, err := mvccScanToKvs(
-			ctx, iter, key, endKey, maxKeysPerScan, timestamp, opts)
+			ctx, engine, iter, key, endKey, maxKeysPerScan, timestamp, opts)
 		if err != nil {
 			switch tErr := err.(type) {
 			case *roachpb.WriteIntentError:
@@ -2462,8 +2571,21 @@ func mvccResolveWriteIntent(
 
 	// Update stat counters with older version.
 	if ms != nil {
+		restoredTimestamp := unsafeNextKey.Timestamp
 		ms.Add(updateStatsOnAbort(intent.Key, origMetaKeySize, origMetaValSize,
-			metaKeySize, metaValSize, meta, &buf.newMeta, unsafeNextKey.Timestamp.WallTime))
+			metaKeySize, metaValSize, meta, &buf.newMeta, restoredTimestamp.WallTime))
+		// If the restored version was deleted by a range tombstone below the
+		// aborted intent, it is no longer live.
+		if !buf.newMeta.Deleted && !isSysLocal(intent.Key) {
+			tombs, err := readRangeTombstones(engine, intent.Key, nil)
+			if err != nil {
+				return false, err
+			}
+			if t, deleted := tombs.oldestAbove(intent.Key, restoredTimestamp); deleted {
+				ms.Add(updateStatsOnRangeTombstone(
+					metaKeySize, metaValSize, &buf.newMeta, t.WallTime, false /* undo */))
+			}
+		}
 	}
 
 	return true, nil
@@ -2610,6 +2732,23 @@ func MVCCGarbageCollect(
 		}
 		inlinedValue := meta.IsInline()
 		implicitMeta := iter.UnsafeKey().IsValue()
+		// nonLiveNanos is the time at which the latest version became non-live.
+		// If it's a value deleted by a range tombstone, that's the range
+		// tombstone's timestamp.
+		nonLiveNanos := meta.Timestamp.WallTime
+		rangeDeleted := false
+		if !meta.Deleted && !inlinedValue && meta.Txn == nil &&
+			!gcKey.Timestamp.Less(hlc.Timestamp(meta.Timestamp)) {
+			tombs, err := readRangeTombstones(engine, gcKey.Key, nil)
+			if err != nil {
+				return err
+			}
+			t, ok := tombs.oldestAbove(gcKey.Key, hlc.Timestamp(meta.Timestamp))
+			if ok && !gcKey.Timestamp.Less(t) {
+				nonLiveNanos = t.WallTime
+				rangeDeleted = true
+			}
+		}
 		// First, check whether all values of the key are being deleted.
 		//
 		// Note that we naively can't terminate GC'ing keys loop early if we
@@ -2620,10 +2759,10 @@ func MVCCGarbageCollect(
 		// sure each individual GCRequest does bounded work.
 		if !gcKey.Timestamp.Less(hlc.Timestamp(meta.Timestamp)) {
 			// For version keys, don't allow GC'ing the meta key if it's
-			// not marked deleted. However, for inline values we allow it;
-			// they are internal and GCing them directly saves the extra
-			// deletion step.
-			if !meta.Deleted && !inlinedValue {
+			// not marked deleted or deleted by a range tombstone. However, for
+			// inline values we allow it; they are internal and GCing them
+			// directly saves the extra deletion step.
+			if !meta.Deleted && !rangeDeleted && !inlinedValue {
 				return errors.Errorf("request to GC non-deleted, latest value of %q", gcKey.Key)
 			}
 			if meta.Txn != nil {
@@ -2634,7 +2773,7 @@ func MVCCGarbageCollect(
 					updateStatsForInline(ms, gcKey.Key, metaKeySize, metaValSize, 0, 0)
 					ms.AgeTo(timestamp.WallTime)
 				} else {
-					ms.Add(updateStatsOnGC(gcKey.Key, metaKeySize, metaValSize, meta, meta.Timestamp.WallTime))
+					ms.Add(updateStatsOnGC(gcKey.Key, metaKeySize, metaValSize, meta, nonLiveNanos))
 				}
 			}
 			if !implicitMeta {
@@ -2663,6 +2802,9 @@ func MVCCGarbageCollect(
 		// and better commented version of this logic.
 
 		prevNanos := timestamp.WallTime
+		if rangeDeleted {
+			prevNanos = nonLiveNanos
+		}
 		for ; ; iter.Next() {
 			if ok, err := iter.Valid(); err != nil {
 				return err
@@ -0,0 +1,316 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/pkg/errors"
+)
+
+// UpdateSSTTimestamps returns a copy of the given SST with the timestamps of
+// all its keys set to the given timestamp. The SST must not contain inline
+// values or more than one version of a key.
+func UpdateSSTTimestamps(data []byte, timestamp hlc.Timestamp) ([]byte, error) {
+	if timestamp == (hlc.Timestamp{}) {
+		return nil, errors.Errorf("cannot rewrite sstable timestamps to an empty timestamp")
+	}
+	iter, err := NewMemSSTIterator(data, false /* verify */)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	sst, err := MakeRocksDBSstFileWriter()
+	if err != nil {
+		return nil, err
+	}
+	defer sst.Close()
+
+	var prevKey roachpb.Key
+	for iter.Seek(MVCCKey{Key: keys.MinKey}); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return nil, err
+		} else if !ok {
+			break
+		}
+		unsafeKey := iter.UnsafeKey()
+		if !unsafeKey.IsValue() {
+			return nil, errors.Errorf("cannot rewrite timestamp of inline key %s", unsafeKey.Key)
+		}
+		if prevKey != nil && prevKey.Equal(unsafeKey.Key) {
+			return nil, errors.Errorf("cannot rewrite timestamps of multiple versions of key %s",
+				unsafeKey.Key)
+		}
+		prevKey = append(prevKey[:0], unsafeKey.Key...)
+		if err := sst.Add(MVCCKeyValue{
+			Key:   MVCCKey{Key: unsafeKey.Key, Timestamp: timestamp},
+			Value: iter.UnsafeValue(),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return sst.Finish()
+}
+
+// CheckSSTConflicts checks whether ingesting the given SST, whose keys are in
+// the span [start, end), would shadow existing data, which is only allowed for
+// deleted keys and for live values identical to the ingested ones. The latter
+// makes ingesting the same SST more than once, e.g. on retries, idempotent.
+// Only the newest version of each key in the SST is checked.
+//
+// An error is returned for the first key colliding with an existing live
+// value. Otherwise, a WriteIntentError is returned if there are intents on the
+// keys of the SST, and a WriteTooOldError if any of them has a version, or is
+// covered by a range tombstone, at or above the timestamp of its ingested
+// version.
+func CheckSSTConflicts(reader Reader, data []byte, start, end roachpb.Key) error {
+	tombs, err := readRangeTombstones(reader, start, end)
+	if err != nil {
+		return err
+	}
+
+	sstIter, err := NewMemSSTIterator(data, false /* verify */)
+	if err != nil {
+		return err
+	}
+	defer sstIter.Close()
+	iter := reader.NewIterator(IterOptions{LowerBound: start, UpperBound: end})
+	defer iter.Close()
+
+	var intents []roachpb.Intent
+	var writeTS, existingTS hlc.Timestamp
+	var meta enginepb.MVCCMetadata
+	var prevKey roachpb.Key
+	for sstIter.Seek(MVCCKey{Key: start}); ; sstIter.Next() {
+		if ok, err := sstIter.Valid(); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+		sstKey := sstIter.UnsafeKey()
+		if prevKey != nil && prevKey.Equal(sstKey.Key) {
+			// An older version of the key, which is shadowed by the newer one.
+			continue
+		}
+		prevKey = append(prevKey[:0], sstKey.Key...)
+		if !sstKey.IsValue() {
+			return errors.Errorf("cannot check inline key %s for shadowing", sstKey.Key)
+		}
+		for _, t := range tombs.covering(sstKey.Key) {
+			if !t.Timestamp.Less(sstKey.Timestamp) {
+				writeTS.Forward(sstKey.Timestamp)
+				existingTS.Forward(t.Timestamp)
+			}
+		}
+
+		iter.Seek(MakeMVCCMetadataKey(sstKey.Key))
+		ok, err := iter.Valid()
+		if err != nil {
+			return err
+		} else if !ok || !iter.UnsafeKey().Key.Equal(sstKey.Key) {
+			continue
+		}
+		if !iter.UnsafeKey().IsValue() {
+			if err := iter.ValueProto(&meta); err != nil {
+				return err
+			}
+			if meta.IsInline() {
+				return errors.Errorf("ingested key collides with an existing one: %s", sstKey.Key)
+			}
+			if meta.Txn != nil {
+				intents = append(intents, roachpb.Intent{
+					Span:   roachpb.Span{Key: append(roachpb.Key(nil), sstKey.Key...)},
+					Status: roachpb.PENDING,
+					Txn:    *meta.Txn,
+				})
+				continue
+			}
+			// Move on to the version the metadata describes.
+			iter.Next()
+			if ok, err := iter.Valid(); err != nil {
+				return err
+			} else if !ok || !iter.UnsafeKey().Key.Equal(sstKey.Key) {
+				continue
+			}
+		}
+
+		existing := iter.UnsafeKey()
+		if !existing.Timestamp.Less(sstKey.Timestamp) {
+			writeTS.Forward(sstKey.Timestamp)
+			existingTS.Forward(existing.Timestamp)
+			continue
+		}
+		value := iter.UnsafeValue()
+		if len(value) == 0 {
+			continue
+		}
+		if t, ok := tombs.deletedAt(sstKey.Key, sstKey.Timestamp); ok && existing.Timestamp.Less(t) {
+			continue
+		}
+		if !bytes.Equal(value, sstIter.UnsafeValue()) {
+			return errors.Errorf("ingested key collides with an existing one: %s", sstKey.Key)
+		}
+	}
+	if len(intents) > 0 {
+		return &roachpb.WriteIntentError{Intents: intents}
+	}
+	if existingTS != (hlc.Timestamp{}) {
+		return &roachpb.WriteTooOldError{Timestamp: writeTS, ActualTimestamp: existingTS.Next()}
+	}
+	return nil
+}
+
+// MVCCDeleteRangeInsertedAfter deletes the keys in the span [key, endKey)
+// which were inserted after the given insertedAfter timestamp, by writing a
+// deletion tombstone at the given timestamp for each of them. A key was
+// inserted if its latest version is a live value written after insertedAfter
+// and it had no live value at insertedAfter; keys that existed at
+// insertedAfter are left untouched, even if they were overwritten since. If
+// insertedUntil is set, keys whose latest version was written above it are
+// left untouched as well, so that the keys written within a known range of
+// timestamps can be deleted without touching those written concurrently by
+// others. Inline values are ignored.
+//
+// At most max keys are deleted if max is positive, in which case a resume
+// span is returned for the remainder of the span. Such deletions can't be
+// executed transactionally. A WriteIntentError is returned if there are
+// intents in the scanned span, and a WriteTooOldError if any key in it has a
+// version at or above the timestamp. In both cases, nothing is written.
+func MVCCDeleteRangeInsertedAfter(
+	ctx context.Context,
+	rw ReadWriter,
+	ms *enginepb.MVCCStats,
+	key, endKey roachpb.Key,
+	max int64,
+	timestamp, insertedAfter, insertedUntil hlc.Timestamp,
+) (*roachpb.Span, int64, error) {
+	if !insertedAfter.Less(timestamp) {
+		return nil, 0, errors.Errorf("cannot delete keys inserted after %s at %s",
+			insertedAfter, timestamp)
+	}
+	if !insertedUntil.IsEmpty() && !insertedAfter.Less(insertedUntil) {
+		return nil, 0, errors.Errorf("cannot delete keys inserted after %s until %s",
+			insertedAfter, insertedUntil)
+	}
+	tombs, err := readRangeTombstones(rw, key, endKey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The iterator used to find the live value of a key at insertedAfter, if
+	// any.
+	prevIter := rw.NewIterator(IterOptions{LowerBound: key, UpperBound: endKey})
+	defer prevIter.Close()
+	liveAt := func(key roachpb.Key, ts hlc.Timestamp) (bool, error) {
+		prevIter.Seek(MVCCKey{Key: key, Timestamp: ts})
+		if ok, err := prevIter.Valid(); err != nil || !ok {
+			return false, err
+		}
+		unsafeKey := prevIter.UnsafeKey()
+		if !unsafeKey.Key.Equal(key) || len(prevIter.UnsafeValue()) == 0 {
+			return false, nil
+		}
+		if t, ok := tombs.deletedAt(key, ts); ok && unsafeKey.Timestamp.Less(t) {
+			return false, nil
+		}
+		return true, nil
+	}
+
+	var toDelete []roachpb.Key
+	var resumeSpan *roachpb.Span
+	var intents []roachpb.Intent
+	var existingTS hlc.Timestamp
+	iter := rw.NewIterator(IterOptions{LowerBound: key, UpperBound: endKey})
+	defer iter.Close()
+	var meta enginepb.MVCCMetadata
+	for iter.Seek(MakeMVCCMetadataKey(key)); ; iter.NextKey() {
+		if ok, err := iter.Valid(); err != nil {
+			return nil, 0, err
+		} else if !ok {
+			break
+		}
+		unsafeKey := iter.UnsafeKey()
+		if unsafeKey.IsValue() {
+			meta.Reset()
+			meta.Deleted = len(iter.UnsafeValue()) == 0
+			meta.Timestamp = hlc.LegacyTimestamp(unsafeKey.Timestamp)
+		} else {
+			if err := iter.ValueProto(&meta); err != nil {
+				return nil, 0, err
+			}
+			if meta.IsInline() {
+				continue
+			}
+			if meta.Txn != nil {
+				intents = append(intents, roachpb.Intent{
+					Span:   roachpb.Span{Key: append(roachpb.Key(nil), unsafeKey.Key...)},
+					Status: roachpb.PENDING,
+					Txn:    *meta.Txn,
+				})
+				continue
+			}
+		}
+		metaTS := hlc.Timestamp(meta.Timestamp)
+		if !metaTS.Less(timestamp) {
+			existingTS.Forward(metaTS)
+			continue
+		}
+		if meta.Deleted || !insertedAfter.Less(metaTS) {
+			continue
+		}
+		if !insertedUntil.IsEmpty() && insertedUntil.Less(metaTS) {
+			continue
+		}
+		if _, ok := tombs.newestIn(unsafeKey.Key, metaTS, timestamp); ok {
+			continue
+		}
+		k := append(roachpb.Key(nil), unsafeKey.Key...)
+		if live, err := liveAt(k, insertedAfter); err != nil {
+			return nil, 0, err
+		} else if live {
+			continue
+		}
+		if max > 0 && int64(len(toDelete)) == max {
+			resumeSpan = &roachpb.Span{Key: k, EndKey: endKey}
+			break
+		}
+		toDelete = append(toDelete, k)
+	}
+	if len(intents) > 0 {
+		return nil, 0, &roachpb.WriteIntentError{Intents: intents}
+	}
+	if existingTS != (hlc.Timestamp{}) {
+		return nil, 0, &roachpb.WriteTooOldError{
+			Timestamp: timestamp, ActualTimestamp: existingTS.Next(),
+		}
+	}
+
+	buf := newPutBuffer()
+	defer buf.release()
+	putIter := rw.NewIterator(IterOptions{Prefix: true})
+	defer putIter.Close()
+	for _, k := range toDelete {
+		if err := mvccPutInternal(
+			ctx, rw, putIter, ms, k, timestamp, nil /* value */, nil /* txn */, buf, nil, /* valueFn */
+		); err != nil {
+			return nil, 0, err
+		}
+	}
+	return resumeSpan, int64(len(toDelete)), nil
+}
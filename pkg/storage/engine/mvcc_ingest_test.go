@@ -0,0 +1,277 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func makeTestSST(t *testing.T, kvs ...MVCCKeyValue) []byte {
+	t.Helper()
+	sst, err := MakeRocksDBSstFileWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sst.Close()
+	for _, kv := range kvs {
+		if err := sst.Add(kv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := sst.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestUpdateSSTTimestamps(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := func(wt int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wt} }
+	kv := func(key string, wt int64, value roachpb.Value) MVCCKeyValue {
+		k := MVCCKey{Key: roachpb.Key(key), Timestamp: ts(wt)}
+		return MVCCKeyValue{Key: k, Value: value.RawBytes}
+	}
+
+	data, err := UpdateSSTTimestamps(makeTestSST(t, kv("a", 1, value1), kv("b", 2, value2)), ts(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	iter, err := NewMemSSTIterator(data, true /* verify */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iter.Close()
+	var got []MVCCKeyValue
+	for iter.Seek(MVCCKey{Key: keys.MinKey}); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			break
+		}
+		key := iter.UnsafeKey()
+		key.Key = append(roachpb.Key(nil), key.Key...)
+		got = append(got, MVCCKeyValue{Key: key, Value: append([]byte(nil), iter.UnsafeValue()...)})
+	}
+	exp := []MVCCKeyValue{kv("a", 5, value1), kv("b", 5, value2)}
+	if len(got) != len(exp) {
+		t.Fatalf("expected %d keys, got %d", len(exp), len(got))
+	}
+	for i := range exp {
+		if !got[i].Key.Equal(exp[i].Key) || !bytes.Equal(got[i].Value, exp[i].Value) {
+			t.Errorf("%d: expected %s, got %s", i, exp[i].Key, got[i].Key)
+		}
+	}
+
+	if _, err := UpdateSSTTimestamps(
+		makeTestSST(t, kv("a", 2, value1), kv("a", 1, value2)), ts(5),
+	); !testutils.IsError(err, "multiple versions") {
+		t.Fatalf("expected multiple versions error, got %v", err)
+	}
+}
+
+func TestCheckSSTConflicts(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	engine := createTestEngine()
+	defer engine.Close()
+
+	ts := func(wt int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wt} }
+	put := func(key string, wt int64, value roachpb.Value, txn *roachpb.Transaction) {
+		t.Helper()
+		if err := MVCCPut(ctx, engine, nil, roachpb.Key(key), ts(wt), value, txn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	put("a", 1, value1, nil)
+	put("b", 1, value1, nil)
+	if err := MVCCDelete(ctx, engine, nil, roachpb.Key("b"), ts(2), nil); err != nil {
+		t.Fatal(err)
+	}
+	put("c", 3, value2, nil)
+	put("d", 1, value1, makeTxn(*txn1, ts(1)))
+	put("f", 1, value1, nil)
+	if err := MVCCDeleteRangeUsingTombstone(
+		ctx, engine, nil, roachpb.Key("f"), roachpb.Key("g"), ts(2),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name  string
+		key   string
+		wt    int64
+		value roachpb.Value
+		// expErr is either a regexp for the expected error, or one of the
+		// sentinels below.
+		expErr string
+	}{
+		{name: "new key", key: "g", wt: 2, value: value1},
+		{name: "shadows deletion", key: "b", wt: 3, value: value2},
+		{name: "shadows range tombstone", key: "f", wt: 3, value: value2},
+		{name: "identical value", key: "a", wt: 2, value: value1},
+		{name: "collision", key: "a", wt: 2, value: value2, expErr: "collides with an existing one"},
+		{name: "newer version", key: "c", wt: 2, value: value2, expErr: "write too old"},
+		{name: "newer range tombstone", key: "f", wt: 2, value: value2, expErr: "write too old"},
+		{name: "intent", key: "d", wt: 2, value: value2, expErr: "write intent"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := makeTestSST(t, MVCCKeyValue{
+				Key:   MVCCKey{Key: roachpb.Key(tc.key), Timestamp: ts(tc.wt)},
+				Value: tc.value.RawBytes,
+			})
+			err := CheckSSTConflicts(engine, data, roachpb.Key("a"), roachpb.Key("z"))
+			switch tc.expErr {
+			case "":
+				if err != nil {
+					t.Fatal(err)
+				}
+			case "write too old":
+				if _, ok := err.(*roachpb.WriteTooOldError); !ok {
+					t.Fatalf("expected write too old error, got %v", err)
+				}
+			case "write intent":
+				if _, ok := err.(*roachpb.WriteIntentError); !ok {
+					t.Fatalf("expected write intent error, got %v", err)
+				}
+			default:
+				if !testutils.IsError(err, tc.expErr) {
+					t.Fatalf("expected %q, got %v", tc.expErr, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMVCCDeleteRangeInsertedAfter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	engine := createTestEngine()
+	defer engine.Close()
+
+	ts := func(wt int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wt} }
+	put := func(key string, wt int64, value roachpb.Value, txn *roachpb.Transaction) {
+		t.Helper()
+		if err := MVCCPut(ctx, engine, nil, roachpb.Key(key), ts(wt), value, txn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	del := func(key string, wt int64) {
+		t.Helper()
+		if err := MVCCDelete(ctx, engine, nil, roachpb.Key(key), ts(wt), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get := func(key string, wt int64) *roachpb.Value {
+		t.Helper()
+		value, _, err := MVCCGet(ctx, engine, roachpb.Key(key), ts(wt), MVCCGetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	// The keys are inserted after 2 and until 3 unless noted otherwise.
+	put("a", 1, value1, nil) // existed, but updated since
+	put("a", 3, value2, nil)
+	put("b", 3, value1, nil)
+	put("c", 1, value1, nil) // deleted at 2 and then reinserted
+	del("c", 2)
+	put("c", 3, value1, nil)
+	put("cc", 4, value1, nil) // inserted after 3
+	put("d", 1, value1, nil)  // existed
+	put("e", 3, value1, nil)  // already deleted again
+	del("e", 4)
+	put("f", 3, value1, nil)
+
+	// Delete the inserted keys one at a time, then the remaining ones.
+	var ms enginepb.MVCCStats
+	resumeSpan, num, err := MVCCDeleteRangeInsertedAfter(
+		ctx, engine, &ms, roachpb.Key("a"), roachpb.Key("g"), 1, ts(5), ts(2), ts(3),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num != 1 || resumeSpan == nil || !resumeSpan.Key.Equal(roachpb.Key("c")) {
+		t.Fatalf("expected 1 key deleted and resume span at c, got %d and %v", num, resumeSpan)
+	}
+	resumeSpan, num, err = MVCCDeleteRangeInsertedAfter(
+		ctx, engine, &ms, resumeSpan.Key, resumeSpan.EndKey, 0, ts(5), ts(2), ts(3),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num != 2 || resumeSpan != nil {
+		t.Fatalf("expected 2 keys deleted and no resume span, got %d and %v", num, resumeSpan)
+	}
+
+	for _, key := range []string{"b", "c", "e", "f"} {
+		if v := get(key, 5); v != nil {
+			t.Errorf("expected %s to be deleted, got %v", key, v)
+		}
+	}
+	if v := get("a", 5); v == nil || !bytes.Equal(v.RawBytes, value2.RawBytes) {
+		t.Errorf("expected a to be kept, got %v", v)
+	}
+	for _, key := range []string{"cc", "d"} {
+		if v := get(key, 5); v == nil || !bytes.Equal(v.RawBytes, value1.RawBytes) {
+			t.Errorf("expected %s to be kept, got %v", key, v)
+		}
+	}
+	if v := get("b", 4); v == nil {
+		t.Error("expected b to be visible below the deletion")
+	}
+
+	// Without an upper bound, the keys inserted after 3 are deleted as well.
+	if _, num, err := MVCCDeleteRangeInsertedAfter(
+		ctx, engine, &ms, roachpb.Key("a"), roachpb.Key("g"), 0, ts(6), ts(2), hlc.Timestamp{},
+	); err != nil {
+		t.Fatal(err)
+	} else if num != 1 {
+		t.Fatalf("expected 1 key deleted, got %d", num)
+	}
+	if v := get("cc", 6); v != nil {
+		t.Errorf("expected cc to be deleted, got %v", v)
+	}
+
+	// Newer versions and intents prevent the deletion.
+	put("g", 7, value1, nil)
+	if _, _, err := MVCCDeleteRangeInsertedAfter(
+		ctx, engine, nil, roachpb.Key("g"), roachpb.Key("h"), 0, ts(6), ts(2), hlc.Timestamp{},
+	); err == nil {
+		t.Fatal("expected write too old error")
+	} else if _, ok := err.(*roachpb.WriteTooOldError); !ok {
+		t.Fatalf("expected write too old error, got %v", err)
+	}
+	put("h", 3, value1, makeTxn(*txn1, ts(3)))
+	if _, _, err := MVCCDeleteRangeInsertedAfter(
+		ctx, engine, nil, roachpb.Key("h"), roachpb.Key("i"), 0, ts(6), ts(2), hlc.Timestamp{},
+	); err == nil {
+		t.Fatal("expected write intent error")
+	} else if _, ok := err.(*roachpb.WriteIntentError); !ok {
+		t.Fatalf("expected write intent error, got %v", err)
+	}
+}
@@ -0,0 +1,871 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/pkg/errors"
+)
+
+// MVCC range tombstones delete all versions of the keys in a span at or below
+// their timestamp with a single write, independent of the number of keys in
+// the span. They are stored as inline values in a range-local index (see
+// keys.RangeTombstoneKey), keyed by their start key and timestamp. The index
+// is kept fragmented: two fragments either cover exactly the same span, in
+// which case they are stacked by timestamp, or they don't overlap at all.
+// Fragments never extend past the end of the range containing their start
+// key; splits fragment the tombstones straddling the split key.
+//
+// Range tombstones are applied by the Go MVCC layer on top of the point
+// versions returned by the storage engine. A version at timestamp t is
+// deleted if the key is covered by a range tombstone at timestamp T with
+// t < T <= the read timestamp. Inline values are never covered.
+//
+// For the purpose of MVCCStats, a range tombstone only affects the latest
+// version of each key it covers: if that version is a live value, it becomes
+// non-live (and starts accruing GCBytesAge) at the timestamp of the oldest
+// range tombstone covering it. Older versions continue to accrue GCBytesAge
+// from the time at which they were shadowed by a newer version.
+
+// rangeTombstones is a set of MVCC range tombstone fragments, sorted by start
+// key and then by descending timestamp.
+type rangeTombstones []enginepb.MVCCRangeTombstone
+
+// covering returns the fragments covering the given key, ordered by descending
+// timestamp.
+func (r rangeTombstones) covering(key roachpb.Key) rangeTombstones {
+	i := sort.Search(len(r), func(i int) bool {
+		return key.Compare(r[i].StartKey) < 0
+	})
+	if i == 0 || key.Compare(r[i-1].EndKey) >= 0 {
+		return nil
+	}
+	j := i - 1
+	for j > 0 && bytes.Equal(r[j-1].StartKey, r[i-1].StartKey) {
+		j--
+	}
+	return r[j:i]
+}
+
+// deletedAt returns the timestamp of the newest range tombstone covering the
+// key at or below the given timestamp.
+func (r rangeTombstones) deletedAt(key roachpb.Key, ts hlc.Timestamp) (hlc.Timestamp, bool) {
+	for _, t := range r.covering(key) {
+		if !ts.Less(t.Timestamp) {
+			return t.Timestamp, true
+		}
+	}
+	return hlc.Timestamp{}, false
+}
+
+// oldestAbove returns the timestamp of the oldest range tombstone covering the
+// key above the given timestamp. This is the timestamp at which a version of
+// the key at the given timestamp was deleted, unless it was shadowed by a
+// newer version first.
+func (r rangeTombstones) oldestAbove(key roachpb.Key, ts hlc.Timestamp) (hlc.Timestamp, bool) {
+	c := r.covering(key)
+	for i := len(c) - 1; i >= 0; i-- {
+		if ts.Less(c[i].Timestamp) {
+			return c[i].Timestamp, true
+		}
+	}
+	return hlc.Timestamp{}, false
+}
+
+// newestIn returns the timestamp of the newest range tombstone covering the
+// key in the interval (from, to].
+func (r rangeTombstones) newestIn(key roachpb.Key, from, to hlc.Timestamp) (hlc.Timestamp, bool) {
+	for _, t := range r.covering(key) {
+		if from.Less(t.Timestamp) && !to.Less(t.Timestamp) {
+			return t.Timestamp, true
+		}
+	}
+	return hlc.Timestamp{}, false
+}
+
+// filterValue applies the range tombstones to a value read for the given key
+// at the given timestamp, as described for MVCCGet. A nil value is returned if
+// the value is deleted, unless tombstones is set, in which case a deletion at
+// the timestamp of the range tombstone is returned. A transactional read
+// returns an uncertainty error if the key is covered by a range tombstone in
+// the transaction's uncertainty interval.
+func (r rangeTombstones) filterValue(
+	key roachpb.Key,
+	value *roachpb.Value,
+	timestamp hlc.Timestamp,
+	txn *roachpb.Transaction,
+	tombstones bool,
+) (*roachpb.Value, error) {
+	if len(r) == 0 || value == nil || len(value.RawBytes) == 0 || value.Timestamp == (hlc.Timestamp{}) {
+		return value, nil
+	}
+	if txn != nil && timestamp.Less(txn.MaxTimestamp) && !timestamp.Less(value.Timestamp) {
+		if t, ok := r.newestIn(key, timestamp, txn.MaxTimestamp); ok {
+			return nil, roachpb.NewReadWithinUncertaintyIntervalError(timestamp, t, txn)
+		}
+	}
+	t, ok := r.deletedAt(key, timestamp)
+	if !ok || !value.Timestamp.Less(t) {
+		return value, nil
+	}
+	if tombstones {
+		return &roachpb.Value{Timestamp: t}, nil
+	}
+	return nil, nil
+}
+
+// filterScan applies the range tombstones to the key/value pairs returned by
+// Iterator.MVCCScan, as described for filterValue.
+func (r rangeTombstones) filterScan(
+	kvData []byte,
+	numKVs int64,
+	timestamp hlc.Timestamp,
+	txn *roachpb.Transaction,
+	tombstones bool,
+) ([]byte, int64, error) {
+	if len(r) == 0 {
+		return kvData, numKVs, nil
+	}
+	res := make([]byte, 0, len(kvData))
+	var n int64
+	repr := kvData
+	for i := int64(0); i < numKVs; i++ {
+		entry := repr
+		key, rawBytes, rest, err := MVCCScanDecodeKeyValue(repr)
+		if err != nil {
+			return nil, 0, err
+		}
+		repr = rest
+		entry = entry[:len(entry)-len(rest)]
+
+		value := roachpb.Value{RawBytes: rawBytes, Timestamp: key.Timestamp}
+		filtered, err := r.filterValue(key.Key, &value, timestamp, txn, tombstones)
+		if err != nil {
+			return nil, 0, err
+		}
+		if filtered == &value {
+			res = append(res, entry...)
+		} else if filtered != nil {
+			res = appendScanKV(res, MVCCKey{Key: key.Key, Timestamp: filtered.Timestamp}, nil)
+		} else {
+			continue
+		}
+		n++
+	}
+	return res, n, nil
+}
+
+// appendScanKV appends a key/value pair to a buffer in the format returned by
+// Iterator.MVCCScan.
+func appendScanKV(buf []byte, key MVCCKey, value []byte) []byte {
+	encKey := EncodeKey(key)
+	var lenBuf [8]byte
+	binary.LittleEndian.PutUint32(lenBuf[0:4], uint32(len(value)))
+	binary.LittleEndian.PutUint32(lenBuf[4:8], uint32(len(encKey)))
+	buf = append(buf, lenBuf[:]...)
+	buf = append(buf, encKey...)
+	return append(buf, value...)
+}
+
+// decodeRangeTombstone decodes the range tombstone at the iterator's position.
+func decodeRangeTombstone(iter Iterator, t *enginepb.MVCCRangeTombstone) error {
+	var meta enginepb.MVCCMetadata
+	if err := iter.ValueProto(&meta); err != nil {
+		return err
+	}
+	value := roachpb.Value{RawBytes: meta.RawBytes}
+	return value.GetProto(t)
+}
+
+// readRangeTombstones returns the MVCC range tombstone fragments overlapping
+// the span [start, end), or the key start if end is empty. Range tombstones
+// only cover global keys.
+func readRangeTombstones(reader Reader, start, end roachpb.Key) (rangeTombstones, error) {
+	switch reader.(type) {
+	case noRangeTombstonesReadWriter, noRangeTombstonesBatch:
+		return nil, nil
+	}
+	if len(end) == 0 {
+		end = start.Next()
+	}
+	if start.Compare(keys.LocalMax) < 0 {
+		start = keys.LocalMax
+	}
+	if start.Compare(end) >= 0 {
+		return nil, nil
+	}
+
+	iter := reader.NewIterator(IterOptions{UpperBound: keys.LocalRangeTombstoneMax})
+	defer iter.Close()
+
+	// The fragment containing the start key, if any, starts at or before it.
+	// Since fragments don't overlap, it's the fragment preceding the start key
+	// in the index.
+	scanStart := start
+	iter.SeekReverse(MakeMVCCMetadataKey(keys.RangeTombstoneKeyPrefix(roachpb.RKey(start))))
+	if ok, err := iter.Valid(); err != nil {
+		return nil, err
+	} else if ok && bytes.HasPrefix(iter.UnsafeKey().Key, keys.LocalRangeTombstonePrefix) {
+		var t enginepb.MVCCRangeTombstone
+		if err := decodeRangeTombstone(iter, &t); err != nil {
+			return nil, err
+		}
+		if start.Compare(t.EndKey) < 0 {
+			scanStart = t.StartKey
+		}
+	}
+
+	var r rangeTombstones
+	endKey := MakeMVCCMetadataKey(keys.RangeTombstoneKeyPrefix(roachpb.RKey(end)))
+	for iter.Seek(MakeMVCCMetadataKey(keys.RangeTombstoneKeyPrefix(roachpb.RKey(scanStart)))); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return nil, err
+		} else if !ok || !iter.UnsafeKey().Less(endKey) {
+			break
+		}
+		var t enginepb.MVCCRangeTombstone
+		if err := decodeRangeTombstone(iter, &t); err != nil {
+			return nil, err
+		}
+		r = append(r, t)
+	}
+	return r, nil
+}
+
+// noRangeTombstonesReadWriter is a ReadWriter whose global keys are known not
+// to be covered by any MVCC range tombstones.
+type noRangeTombstonesReadWriter struct {
+	ReadWriter
+}
+
+// noRangeTombstonesBatch is the Batch counterpart of
+// noRangeTombstonesReadWriter.
+type noRangeTombstonesBatch struct {
+	Batch
+}
+
+// Distinct implements the Batch interface.
+func (b noRangeTombstonesBatch) Distinct() ReadWriter {
+	return noRangeTombstonesReadWriter{b.Batch.Distinct()}
+}
+
+// NewReadWriterWithoutRangeTombstones returns a ReadWriter on which the MVCC
+// operations don't consult the MVCC range tombstone index, which saves a seek
+// per operation. It must only be used by callers which know that there are no
+// range tombstones covering the keys they access, and which don't write any.
+func NewReadWriterWithoutRangeTombstones(rw ReadWriter) ReadWriter {
+	return noRangeTombstonesReadWriter{rw}
+}
+
+// NewBatchWithoutRangeTombstones is like NewReadWriterWithoutRangeTombstones,
+// but for a Batch.
+func NewBatchWithoutRangeTombstones(b Batch) Batch {
+	return noRangeTombstonesBatch{b}
+}
+
+// MVCCGetRangeTombstones returns the MVCC range tombstone fragments
+// overlapping the span [start, end), sorted by start key and descending
+// timestamp.
+func MVCCGetRangeTombstones(
+	reader Reader, start, end roachpb.Key,
+) ([]enginepb.MVCCRangeTombstone, error) {
+	return readRangeTombstones(reader, start, end)
+}
+
+// updateStatsOnRangeTombstone returns the stats delta for the latest version
+// of a key, a live value, becoming non-live at the given time because it is
+// deleted by a range tombstone. If undo is set, the delta for the opposite
+// change is returned instead, which is used when the deleted version becomes
+// shadowed by a newer version, which is then responsible for it becoming
+// non-live.
+func updateStatsOnRangeTombstone(
+	metaKeySize, metaValSize int64, meta *enginepb.MVCCMetadata, nowNanos int64, undo bool,
+) enginepb.MVCCStats {
+	var ms enginepb.MVCCStats
+	ms.AgeTo(nowNanos)
+	liveBytes := meta.KeyBytes + meta.ValBytes + metaKeySize + metaValSize
+	if undo {
+		ms.LiveBytes += liveBytes
+		ms.LiveCount++
+	} else {
+		ms.LiveBytes -= liveBytes
+		ms.LiveCount--
+	}
+	return ms
+}
+
+// MVCCDeleteRangeUsingTombstone deletes all keys in the span [key, endKey) at
+// the given timestamp by writing an MVCC range tombstone. Unlike
+// MVCCDeleteRange, the cost of the write doesn't depend on the number of keys
+// in the span. The span must contain only global keys and must not extend
+// past the end of the range containing its start key.
+//
+// Range tombstones can't be written transactionally. A WriteIntentError is
+// returned if there are intents in the span, and a WriteTooOldError if any
+// key in the span has a version, or is covered by a range tombstone, at or
+// above the timestamp. In both cases, nothing is written. Inline values in
+// the span are ignored.
+func MVCCDeleteRangeUsingTombstone(
+	ctx context.Context,
+	rw ReadWriter,
+	ms *enginepb.MVCCStats,
+	key, endKey roachpb.Key,
+	timestamp hlc.Timestamp,
+) error {
+	if timestamp == (hlc.Timestamp{}) {
+		return errors.Errorf("cannot write range tombstone without a timestamp")
+	}
+	if key.Compare(keys.LocalMax) < 0 {
+		return errors.Errorf("cannot write range tombstone over local keys in [%s,%s)", key, endKey)
+	}
+	if key.Compare(endKey) >= 0 {
+		return errors.Errorf("invalid range tombstone span [%s,%s)", key, endKey)
+	}
+
+	tombs, err := readRangeTombstones(rw, key, endKey)
+	if err != nil {
+		return err
+	}
+	var existingTS hlc.Timestamp
+	for _, t := range tombs {
+		if !t.Timestamp.Less(timestamp) {
+			existingTS.Forward(t.Timestamp)
+		}
+	}
+
+	// Check for conflicting versions and compute the effect of the range
+	// tombstone on the stats of the keys it deletes.
+	var intents []roachpb.Intent
+	var statsDelta enginepb.MVCCStats
+	iter := rw.NewIterator(IterOptions{LowerBound: key, UpperBound: endKey})
+	defer iter.Close()
+	var meta enginepb.MVCCMetadata
+	for iter.Seek(MakeMVCCMetadataKey(key)); ; iter.NextKey() {
+		if ok, err := iter.Valid(); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+		unsafeKey := iter.UnsafeKey()
+		var metaKeySize, metaValSize int64
+		if unsafeKey.IsValue() {
+			meta.Reset()
+			meta.KeyBytes = mvccVersionTimestampSize
+			meta.ValBytes = int64(len(iter.UnsafeValue()))
+			meta.Deleted = meta.ValBytes == 0
+			meta.Timestamp = hlc.LegacyTimestamp(unsafeKey.Timestamp)
+			metaKeySize = int64(unsafeKey.EncodedSize()) - meta.KeyBytes
+		} else {
+			if err := iter.ValueProto(&meta); err != nil {
+				return err
+			}
+			if meta.IsInline() {
+				continue
+			}
+			if meta.Txn != nil {
+				intents = append(intents, roachpb.Intent{
+					Span:   roachpb.Span{Key: append(roachpb.Key(nil), unsafeKey.Key...)},
+					Status: roachpb.PENDING,
+					Txn:    *meta.Txn,
+				})
+				continue
+			}
+			metaKeySize = int64(unsafeKey.EncodedSize())
+			metaValSize = int64(len(iter.UnsafeValue()))
+		}
+		metaTS := hlc.Timestamp(meta.Timestamp)
+		if !metaTS.Less(timestamp) {
+			existingTS.Forward(metaTS)
+			continue
+		}
+		if meta.Deleted {
+			continue
+		}
+		if _, ok := tombs.newestIn(unsafeKey.Key, metaTS, timestamp); ok {
+			// Already deleted by an older range tombstone.
+			continue
+		}
+		statsDelta.Add(updateStatsOnRangeTombstone(
+			metaKeySize, metaValSize, &meta, timestamp.WallTime, false /* undo */))
+	}
+	if len(intents) > 0 {
+		return &roachpb.WriteIntentError{Intents: intents}
+	}
+	if existingTS != (hlc.Timestamp{}) {
+		return &roachpb.WriteTooOldError{Timestamp: timestamp, ActualTimestamp: existingTS.Next()}
+	}
+
+	if err := putRangeTombstones(ctx, rw, ms, tombs.add(key, endKey, timestamp)); err != nil {
+		return err
+	}
+	if ms != nil {
+		ms.Add(statsDelta)
+	}
+	rw.LogLogicalOp(MVCCDeleteRangeOpType, MVCCLogicalOpDetails{
+		Key:       key,
+		EndKey:    endKey,
+		Timestamp: timestamp,
+		Safe:      true,
+	})
+	return nil
+}
+
+// rangeTombstoneFragment is a set of range tombstones covering the same span,
+// used while refragmenting the range tombstone index.
+type rangeTombstoneFragment struct {
+	startKey, endKey roachpb.Key
+	timestamps       []hlc.Timestamp // in descending order
+	// rewrite is set if all range tombstones in the fragment need to be
+	// written, because the fragment is new or its span changed. Otherwise,
+	// only the first timestamp is new.
+	rewrite bool
+}
+
+// fragments groups the range tombstones by span.
+func (r rangeTombstones) fragments() []rangeTombstoneFragment {
+	var frags []rangeTombstoneFragment
+	for _, t := range r {
+		if n := len(frags); n > 0 && frags[n-1].startKey.Equal(t.StartKey) {
+			frags[n-1].timestamps = append(frags[n-1].timestamps, t.Timestamp)
+			continue
+		}
+		frags = append(frags, rangeTombstoneFragment{
+			startKey:   t.StartKey,
+			endKey:     t.EndKey,
+			timestamps: []hlc.Timestamp{t.Timestamp},
+		})
+	}
+	return frags
+}
+
+// splitFragments splits the fragments straddling the given key in two. Only
+// the fragments which need to be written to the index are returned.
+func splitFragments(frags []rangeTombstoneFragment, key roachpb.Key) []rangeTombstoneFragment {
+	var res []rangeTombstoneFragment
+	for _, f := range frags {
+		if f.startKey.Compare(key) < 0 && key.Compare(f.endKey) < 0 {
+			left, right := f, f
+			left.endKey, right.startKey = key, key
+			left.rewrite, right.rewrite = true, true
+			res = append(res, left, right)
+			continue
+		}
+		res = append(res, f)
+	}
+	return res
+}
+
+// add returns the fragments that need to be written to the index to add a
+// range tombstone over [key, endKey) at the given timestamp, which must be
+// newer than all the existing range tombstones overlapping the span.
+func (r rangeTombstones) add(
+	key, endKey roachpb.Key, timestamp hlc.Timestamp,
+) []rangeTombstoneFragment {
+	frags := splitFragments(splitFragments(r.fragments(), key), endKey)
+	var res []rangeTombstoneFragment
+	cur := key
+	for _, f := range frags {
+		if f.startKey.Compare(key) < 0 || endKey.Compare(f.endKey) < 0 {
+			// Outside of the new range tombstone. The fragment was split.
+			if f.rewrite {
+				res = append(res, f)
+			}
+			continue
+		}
+		if cur.Compare(f.startKey) < 0 {
+			res = append(res, rangeTombstoneFragment{
+				startKey:   cur,
+				endKey:     f.startKey,
+				timestamps: []hlc.Timestamp{timestamp},
+				rewrite:    true,
+			})
+		}
+		f.timestamps = append([]hlc.Timestamp{timestamp}, f.timestamps...)
+		res = append(res, f)
+		cur = f.endKey
+	}
+	if cur.Compare(endKey) < 0 {
+		res = append(res, rangeTombstoneFragment{
+			startKey:   cur,
+			endKey:     endKey,
+			timestamps: []hlc.Timestamp{timestamp},
+			rewrite:    true,
+		})
+	}
+	return res
+}
+
+// putRangeTombstones writes the given fragments to the range tombstone index.
+func putRangeTombstones(
+	ctx context.Context, rw ReadWriter, ms *enginepb.MVCCStats, frags []rangeTombstoneFragment,
+) error {
+	for _, f := range frags {
+		timestamps := f.timestamps
+		if !f.rewrite {
+			timestamps = timestamps[:1]
+		}
+		for _, ts := range timestamps {
+			t := enginepb.MVCCRangeTombstone{StartKey: f.startKey, EndKey: f.endKey, Timestamp: ts}
+			if err := writeRangeTombstone(
+				ctx, rw, ms, keys.RangeTombstoneKey(f.startKey, ts), &t,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeRangeTombstone stores the given range tombstone under the given key of
+// the range tombstone index or, if it is nil, removes the key from the index.
+// The stats account for the change in the number of range tombstones stored
+// in the index.
+func writeRangeTombstone(
+	ctx context.Context,
+	rw ReadWriter,
+	ms *enginepb.MVCCStats,
+	key roachpb.Key,
+	t *enginepb.MVCCRangeTombstone,
+) error {
+	var delta *enginepb.MVCCStats
+	if ms != nil {
+		delta = &enginepb.MVCCStats{}
+	}
+	var err error
+	if t != nil {
+		err = MVCCPutProto(ctx, rw, delta, key, hlc.Timestamp{}, nil, t)
+	} else {
+		err = MVCCDelete(ctx, rw, delta, key, hlc.Timestamp{}, nil)
+	}
+	if err != nil || ms == nil {
+		return err
+	}
+	// The keys of the index are range-local, so adding or removing one
+	// changes SysCount.
+	delta.RangeTombstoneCount = delta.SysCount
+	ms.Add(*delta)
+	return nil
+}
+
+// MVCCSplitRangeTombstones splits the MVCC range tombstones straddling the
+// given key, which maintains the invariant that range tombstones don't extend
+// past the end of the range containing their start key when a range is split
+// at the key.
+func MVCCSplitRangeTombstones(
+	ctx context.Context, rw ReadWriter, ms *enginepb.MVCCStats, key roachpb.Key,
+) error {
+	tombs, err := readRangeTombstones(rw, key, nil)
+	if err != nil || len(tombs) == 0 {
+		return err
+	}
+	var frags []rangeTombstoneFragment
+	for _, f := range splitFragments(tombs.fragments(), key) {
+		if f.rewrite {
+			frags = append(frags, f)
+		}
+	}
+	return putRangeTombstones(ctx, rw, ms, frags)
+}
+
+// MVCCClearRangeTombstones removes the MVCC range tombstones covering the span
+// [key, endKey) from the span. Range tombstones partially overlapping the span
+// are truncated. Unlike MVCCGarbageCollectRangeTombstones, this makes the
+// versions covered by the removed range tombstones visible again, so it must
+// only be used when these versions are removed as well, for example by
+// ClearRange.
+func MVCCClearRangeTombstones(
+	ctx context.Context, rw ReadWriter, ms *enginepb.MVCCStats, key, endKey roachpb.Key,
+) error {
+	tombs, err := readRangeTombstones(rw, key, endKey)
+	if err != nil || len(tombs) == 0 {
+		return err
+	}
+	frags := splitFragments(splitFragments(tombs.fragments(), key), endKey)
+	for _, f := range frags {
+		if f.startKey.Compare(key) >= 0 && f.endKey.Compare(endKey) <= 0 {
+			for _, ts := range f.timestamps {
+				if err := writeRangeTombstone(
+					ctx, rw, ms, keys.RangeTombstoneKey(f.startKey, ts), nil,
+				); err != nil {
+					return err
+				}
+			}
+		} else if f.rewrite {
+			if err := putRangeTombstones(ctx, rw, ms, []rangeTombstoneFragment{f}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MVCCGarbageCollectRangeTombstones removes the MVCC range tombstones starting
+// in [key, endKey) at or below the given timestamp. Range tombstones still
+// covering versions are kept, since removing them would make these versions
+// visible again, so the versions must be garbage collected first.
+func MVCCGarbageCollectRangeTombstones(
+	ctx context.Context,
+	rw ReadWriter,
+	ms *enginepb.MVCCStats,
+	key, endKey roachpb.Key,
+	timestamp hlc.Timestamp,
+) error {
+	tombs, err := readRangeTombstones(rw, key, endKey)
+	if err != nil {
+		return err
+	}
+	for _, t := range tombs {
+		if key.Compare(t.StartKey) > 0 || timestamp.Less(t.Timestamp) {
+			continue
+		}
+		if covers, err := rangeTombstoneCoversVersions(rw, t); err != nil {
+			return err
+		} else if covers {
+			continue
+		}
+		if err := writeRangeTombstone(
+			ctx, rw, ms, keys.RangeTombstoneKey(t.StartKey, t.Timestamp), nil,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rangeTombstoneCoversVersions returns whether there are any versions at or
+// below the timestamp of the given range tombstone in its span.
+func rangeTombstoneCoversVersions(reader Reader, t enginepb.MVCCRangeTombstone) (bool, error) {
+	iter := reader.NewIterator(IterOptions{LowerBound: t.StartKey, UpperBound: t.EndKey})
+	defer iter.Close()
+	for iter.Seek(MakeMVCCMetadataKey(t.StartKey)); ; {
+		if ok, err := iter.Valid(); err != nil || !ok {
+			return false, err
+		}
+		unsafeKey := iter.UnsafeKey()
+		switch {
+		case !unsafeKey.IsValue():
+			iter.Next()
+		case t.Timestamp.Less(unsafeKey.Timestamp):
+			// Skip the versions of the key above the range tombstone.
+			key := append(roachpb.Key(nil), unsafeKey.Key...)
+			iter.Seek(MVCCKey{Key: key, Timestamp: t.Timestamp})
+		default:
+			return true, nil
+		}
+	}
+}
+
+// ComputeRangeTombstoneStats returns the correction to the stats computed by
+// ComputeStats for the span [start, end) that accounts for the MVCC range
+// tombstones covering the span: the latest version of each key deleted by a
+// range tombstone is not live. The stats of the range tombstone index itself
+// are part of the range-local stats.
+func ComputeRangeTombstoneStats(
+	reader Reader, start, end roachpb.Key, nowNanos int64,
+) (enginepb.MVCCStats, error) {
+	ms := enginepb.MVCCStats{LastUpdateNanos: nowNanos}
+	tombs, err := readRangeTombstones(reader, start, end)
+	if err != nil || len(tombs) == 0 {
+		return ms, err
+	}
+	iter := reader.NewIterator(IterOptions{LowerBound: start, UpperBound: end})
+	defer iter.Close()
+	for iter.Seek(MakeMVCCMetadataKey(start)); ; iter.NextKey() {
+		if ok, err := iter.Valid(); err != nil {
+			return enginepb.MVCCStats{}, err
+		} else if !ok {
+			break
+		}
+		unsafeKey := iter.UnsafeKey()
+		if !unsafeKey.IsValue() {
+			// An intent or an inline value, neither of which can be covered.
+			continue
+		}
+		valSize := int64(len(iter.UnsafeValue()))
+		if valSize == 0 {
+			continue
+		}
+		t, ok := tombs.oldestAbove(unsafeKey.Key, unsafeKey.Timestamp)
+		if !ok {
+			continue
+		}
+		// See ComputeStatsGo for the accounting of the implicit meta key.
+		liveBytes := int64(len(unsafeKey.Key)) + 1 + mvccVersionTimestampSize + valSize
+		ms.LiveBytes -= liveBytes
+		ms.LiveCount--
+		ms.GCBytesAge += liveBytes * (nowNanos/1e9 - t.WallTime/1e9)
+	}
+	return ms, nil
+}
+
+// rangeTombstoneDeletions returns a deletion at the timestamp of each of the
+// given range tombstones for each key in [start, end) it deletes, i.e. each key
+// in its span with a version below it, sorted by key and descending timestamp.
+func rangeTombstoneDeletions(
+	reader Reader, r rangeTombstones, start, end roachpb.Key,
+) ([]MVCCKey, error) {
+	var deletions []MVCCKey
+	for _, f := range splitFragments(splitFragments(r.fragments(), start), end) {
+		if f.startKey.Compare(start) < 0 || f.endKey.Compare(end) > 0 {
+			continue
+		}
+		iter := reader.NewIterator(IterOptions{LowerBound: f.startKey, UpperBound: f.endKey})
+		var key roachpb.Key
+		var oldest hlc.Timestamp
+		addDeletions := func() {
+			for _, ts := range f.timestamps {
+				if oldest.Less(ts) {
+					deletions = append(deletions, MVCCKey{Key: key, Timestamp: ts})
+				}
+			}
+		}
+		for iter.Seek(MakeMVCCMetadataKey(f.startKey)); ; iter.Next() {
+			if ok, err := iter.Valid(); err != nil {
+				iter.Close()
+				return nil, err
+			} else if !ok {
+				break
+			}
+			unsafeKey := iter.UnsafeKey()
+			if !unsafeKey.IsValue() {
+				continue
+			}
+			if !unsafeKey.Key.Equal(key) {
+				if key != nil {
+					addDeletions()
+				}
+				key = append(roachpb.Key(nil), unsafeKey.Key...)
+			}
+			oldest = unsafeKey.Timestamp
+		}
+		if key != nil {
+			addDeletions()
+		}
+		iter.Close()
+	}
+	return deletions, nil
+}
+
+// exportRangeTombstones applies the MVCC range tombstones in the keyrange
+// [start.Key, end.Key) to the SSTable exported by ExportToSst, as if each of
+// them was a deletion of the keys it deletes at its timestamp. Returns the
+// bytes and the size of the data of the rewritten SSTable, or the original
+// ones if there are no range tombstones to apply.
+func exportRangeTombstones(
+	e Reader, data []byte, dataSize int64, start, end MVCCKey, exportAllRevisions bool,
+) ([]byte, int64, error) {
+	tombs, err := readRangeTombstones(e, start.Key, end.Key)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Range tombstones below the interval still delete the latest values of
+	// a full export, which doesn't include deletions.
+	fullLatest := start.Timestamp.IsEmpty() && !exportAllRevisions
+	var r rangeTombstones
+	for _, t := range tombs {
+		if !end.Timestamp.Less(t.Timestamp) && (fullLatest || start.Timestamp.Less(t.Timestamp)) {
+			r = append(r, t)
+		}
+	}
+	if len(r) == 0 {
+		return data, dataSize, nil
+	}
+
+	var kvs []MVCCKeyValue
+	if len(data) > 0 {
+		iter, err := NewMemSSTIterator(data, false /* verify */)
+		if err != nil {
+			return nil, 0, err
+		}
+		for iter.Seek(MVCCKey{Key: start.Key}); ; iter.Next() {
+			if ok, err := iter.Valid(); err != nil {
+				iter.Close()
+				return nil, 0, err
+			} else if !ok {
+				break
+			}
+			kv := MVCCKeyValue{Key: iter.UnsafeKey(), Value: iter.UnsafeValue()}
+			kv.Key.Key = append(roachpb.Key(nil), kv.Key.Key...)
+			kv.Value = append([]byte(nil), kv.Value...)
+			kvs = append(kvs, kv)
+		}
+		iter.Close()
+	}
+
+	if fullLatest {
+		filtered := kvs[:0]
+		for _, kv := range kvs {
+			if t, ok := r.deletedAt(kv.Key.Key, end.Timestamp); !ok || !kv.Key.Timestamp.Less(t) {
+				filtered = append(filtered, kv)
+			}
+		}
+		kvs = filtered
+	} else {
+		deletions, err := rangeTombstoneDeletions(e, r, start.Key, end.Key)
+		if err != nil {
+			return nil, 0, err
+		}
+		if exportAllRevisions {
+			for _, d := range deletions {
+				kvs = append(kvs, MVCCKeyValue{Key: d})
+			}
+		} else {
+			// Only the newest deletion of each key is exported, unless the
+			// exported value is newer.
+			latest := make(map[string]int, len(kvs))
+			for i, kv := range kvs {
+				latest[string(kv.Key.Key)] = i
+			}
+			for i, d := range deletions {
+				if i > 0 && deletions[i-1].Key.Equal(d.Key) {
+					continue
+				}
+				if j, ok := latest[string(d.Key)]; !ok {
+					kvs = append(kvs, MVCCKeyValue{Key: d})
+				} else if kvs[j].Key.Timestamp.Less(d.Timestamp) {
+					kvs[j] = MVCCKeyValue{Key: d}
+				}
+			}
+		}
+		sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key.Less(kvs[j].Key) })
+	}
+
+	if len(kvs) == 0 {
+		return nil, 0, nil
+	}
+	sst, err := MakeRocksDBSstFileWriter()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer sst.Close()
+	dataSize = 0
+	for _, kv := range kvs {
+		if err := sst.Add(kv); err != nil {
+			return nil, 0, err
+		}
+		dataSize += int64(kv.Key.EncodedSize() + len(kv.Value))
+	}
+	data, err = sst.Finish()
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, dataSize, nil
+}
@@ -0,0 +1,318 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/kr/pretty"
+)
+
+func TestRangeTombstonesAdd(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := func(wt int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wt} }
+	tomb := func(start, end string, wt int64) enginepb.MVCCRangeTombstone {
+		return enginepb.MVCCRangeTombstone{StartKey: []byte(start), EndKey: []byte(end), Timestamp: ts(wt)}
+	}
+	frag := func(start, end string, rewrite bool, wts ...int64) rangeTombstoneFragment {
+		f := rangeTombstoneFragment{startKey: roachpb.Key(start), endKey: roachpb.Key(end), rewrite: rewrite}
+		for _, wt := range wts {
+			f.timestamps = append(f.timestamps, ts(wt))
+		}
+		return f
+	}
+
+	testCases := []struct {
+		name       string
+		existing   rangeTombstones
+		start, end string
+		exp        []rangeTombstoneFragment
+	}{
+		{
+			name:  "empty",
+			start: "a", end: "c",
+			exp: []rangeTombstoneFragment{frag("a", "c", true, 5)},
+		},
+		{
+			name:     "same span",
+			existing: rangeTombstones{tomb("a", "c", 3), tomb("a", "c", 1)},
+			start:    "a", end: "c",
+			exp: []rangeTombstoneFragment{frag("a", "c", false, 5, 3, 1)},
+		},
+		{
+			name:     "within existing",
+			existing: rangeTombstones{tomb("a", "e", 3)},
+			start:    "b", end: "d",
+			exp: []rangeTombstoneFragment{
+				frag("a", "b", true, 3),
+				frag("b", "d", true, 5, 3),
+				frag("d", "e", true, 3),
+			},
+		},
+		{
+			name:     "gaps",
+			existing: rangeTombstones{tomb("b", "c", 3), tomb("d", "f", 2)},
+			start:    "a", end: "e",
+			exp: []rangeTombstoneFragment{
+				frag("a", "b", true, 5),
+				frag("b", "c", false, 5, 3),
+				frag("c", "d", true, 5),
+				frag("d", "e", true, 5, 2),
+				frag("e", "f", true, 2),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			frags := tc.existing.add(roachpb.Key(tc.start), roachpb.Key(tc.end), ts(5))
+			if !reflect.DeepEqual(frags, tc.exp) {
+				t.Fatalf("unexpected fragments:\n%s", pretty.Diff(tc.exp, frags))
+			}
+		})
+	}
+}
+
+func TestMVCCDeleteRangeUsingTombstone(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	engine := createTestEngine()
+	defer engine.Close()
+
+	ts := func(wt int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wt * 1e9} }
+	keyA, keyB, keyC := roachpb.Key("a"), roachpb.Key("b"), roachpb.Key("c")
+
+	var ms enginepb.MVCCStats
+	assertStats := func(nowNanos int64) {
+		t.Helper()
+		iter := engine.NewIterator(IterOptions{UpperBound: roachpb.KeyMax})
+		defer iter.Close()
+		expMS, err := ComputeStatsGo(iter, MVCCKey{}, MVCCKey{Key: roachpb.KeyMax}, nowNanos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tombMS, err := ComputeRangeTombstoneStats(engine, keys.LocalMax, roachpb.KeyMax, nowNanos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expMS.Add(tombMS)
+		tombs, err := MVCCGetRangeTombstones(engine, keys.LocalMax, roachpb.KeyMax)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expMS.RangeTombstoneCount = int64(len(tombs))
+		actMS := ms
+		actMS.AgeTo(nowNanos)
+		if !actMS.Equal(expMS) {
+			t.Fatalf("stats mismatch:\n%s", pretty.Diff(expMS, actMS))
+		}
+	}
+	get := func(key roachpb.Key, wt int64) *roachpb.Value {
+		t.Helper()
+		value, _, err := MVCCGet(ctx, engine, key, ts(wt), MVCCGetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	for _, key := range []roachpb.Key{keyA, keyB} {
+		if err := MVCCPut(ctx, engine, &ms, key, ts(1), value1, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := MVCCPut(ctx, engine, &ms, keyC, ts(3), value2, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete [a, d) at 2, which must not affect c.
+	if err := MVCCDeleteRangeUsingTombstone(ctx, engine, &ms, keyA, roachpb.Key("d"), ts(2)); err == nil {
+		t.Fatal("expected write too old error")
+	} else if _, ok := err.(*roachpb.WriteTooOldError); !ok {
+		t.Fatalf("expected write too old error, got %v", err)
+	}
+	if err := MVCCDeleteRangeUsingTombstone(ctx, engine, &ms, keyA, keyC, ts(2)); err != nil {
+		t.Fatal(err)
+	}
+	assertStats(ts(4).WallTime)
+
+	if v := get(keyA, 1); v == nil {
+		t.Fatal("expected value below range tombstone to be visible")
+	}
+	for _, key := range []roachpb.Key{keyA, keyB} {
+		if v := get(key, 2); v != nil {
+			t.Fatalf("expected %s to be deleted, got %v", key, v)
+		}
+	}
+	if v, _, err := MVCCGet(ctx, engine, keyA, ts(2), MVCCGetOptions{Tombstones: true}); err != nil {
+		t.Fatal(err)
+	} else if v == nil || v.RawBytes != nil || v.Timestamp != ts(2) {
+		t.Fatalf("expected deletion at %s, got %v", ts(2), v)
+	}
+	kvs, _, _, err := MVCCScan(ctx, engine, keyA, roachpb.KeyMax, 10, ts(3), MVCCScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != 1 || !kvs[0].Key.Equal(keyC) {
+		t.Fatalf("expected only %s, got %v", keyC, kvs)
+	}
+	// Deleted keys don't count towards the scan's key limit.
+	kvs, resumeSpan, _, err := MVCCScan(ctx, engine, keyA, roachpb.KeyMax, 1, ts(3), MVCCScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != 1 || !kvs[0].Key.Equal(keyC) || resumeSpan != nil {
+		t.Fatalf("expected only %s without a resume span, got %v, %v", keyC, kvs, resumeSpan)
+	}
+
+	// Writes below the range tombstone are too old, writes above it shadow
+	// the deleted versions.
+	func() {
+		batch := engine.NewBatch()
+		defer batch.Close()
+		if err := MVCCPut(ctx, batch, nil, keyB, ts(2), value3, nil); err == nil {
+			t.Fatal("expected write too old error")
+		} else if _, ok := err.(*roachpb.WriteTooOldError); !ok {
+			t.Fatalf("expected write too old error, got %v", err)
+		}
+	}()
+	if err := MVCCPut(ctx, engine, &ms, keyA, ts(4), value4, nil); err != nil {
+		t.Fatal(err)
+	}
+	assertStats(ts(5).WallTime)
+	if v := get(keyA, 4); v == nil {
+		t.Fatal("expected value above range tombstone to be visible")
+	}
+
+	// The range tombstone can't be removed while it covers versions, but it
+	// can once they are garbage collected.
+	if err := MVCCGarbageCollectRangeTombstones(ctx, engine, &ms, keyA, roachpb.KeyMax, ts(2)); err != nil {
+		t.Fatal(err)
+	}
+	if tombs, err := MVCCGetRangeTombstones(engine, keyA, roachpb.KeyMax); err != nil {
+		t.Fatal(err)
+	} else if len(tombs) != 1 {
+		t.Fatalf("expected range tombstone to be kept, got %v", tombs)
+	}
+	gcKeys := []roachpb.GCRequest_GCKey{{Key: keyA, Timestamp: ts(2)}, {Key: keyB, Timestamp: ts(2)}}
+	if err := MVCCGarbageCollect(ctx, engine, &ms, gcKeys, ts(6)); err != nil {
+		t.Fatal(err)
+	}
+	if err := MVCCGarbageCollectRangeTombstones(ctx, engine, &ms, keyA, roachpb.KeyMax, ts(2)); err != nil {
+		t.Fatal(err)
+	}
+	assertStats(ts(6).WallTime)
+	if tombs, err := MVCCGetRangeTombstones(engine, keyA, roachpb.KeyMax); err != nil {
+		t.Fatal(err)
+	} else if len(tombs) != 0 {
+		t.Fatalf("expected range tombstone to be removed, got %v", tombs)
+	}
+	if v := get(keyB, 5); v != nil {
+		t.Fatalf("expected %s to be deleted, got %v", keyB, v)
+	}
+}
+
+func TestExportRangeTombstones(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	engine := createTestEngine()
+	defer engine.Close()
+
+	ts := func(wt int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wt} }
+	keyA, keyB, keyC := roachpb.Key("a"), roachpb.Key("b"), roachpb.Key("c")
+	for _, key := range []roachpb.Key{keyA, keyB} {
+		if err := MVCCPut(ctx, engine, nil, key, ts(1), value1, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := MVCCPut(ctx, engine, nil, keyC, ts(3), value2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := MVCCDeleteRangeUsingTombstone(ctx, engine, nil, keyA, keyC, ts(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name         string
+		startTS      hlc.Timestamp
+		allRevisions bool
+		exp          []MVCCKey
+	}{
+		{
+			name: "latest",
+			exp:  []MVCCKey{{Key: keyC, Timestamp: ts(3)}},
+		},
+		{
+			name:    "latest incremental",
+			startTS: ts(1),
+			exp: []MVCCKey{
+				{Key: keyA, Timestamp: ts(2)},
+				{Key: keyB, Timestamp: ts(2)},
+				{Key: keyC, Timestamp: ts(3)},
+			},
+		},
+		{
+			name:         "all revisions",
+			allRevisions: true,
+			exp: []MVCCKey{
+				{Key: keyA, Timestamp: ts(2)},
+				{Key: keyA, Timestamp: ts(1)},
+				{Key: keyB, Timestamp: ts(2)},
+				{Key: keyB, Timestamp: ts(1)},
+				{Key: keyC, Timestamp: ts(3)},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			start := MVCCKey{Key: keyA, Timestamp: tc.startTS}
+			end := MVCCKey{Key: roachpb.Key("d"), Timestamp: ts(4)}
+			data, _, err := ExportToSst(
+				ctx, engine, start, end, tc.allRevisions, IterOptions{UpperBound: end.Key},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			iter, err := NewMemSSTIterator(data, false /* verify */)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer iter.Close()
+			var keys []MVCCKey
+			for iter.Seek(MVCCKey{Key: keyA}); ; iter.Next() {
+				if ok, err := iter.Valid(); err != nil {
+					t.Fatal(err)
+				} else if !ok {
+					break
+				}
+				key := iter.UnsafeKey()
+				keys = append(keys, MVCCKey{Key: append(roachpb.Key(nil), key.Key...), Timestamp: key.Timestamp})
+				if (len(iter.UnsafeValue()) == 0) != key.Timestamp.Equal(ts(2)) {
+					t.Fatalf("expected only the versions at %s to be deletions, got %s", ts(2), key)
+				}
+			}
+			if !reflect.DeepEqual(keys, tc.exp) {
+				t.Fatalf("unexpected exported keys:\n%s", pretty.Diff(tc.exp, keys))
+			}
+		})
+	}
+}
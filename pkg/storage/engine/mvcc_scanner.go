@@ -0,0 +1,616 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/lsm"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/pkg/errors"
+)
+
+// maxItersBeforeSeek is the number of calls to iter.{Next,Prev}() to perform
+// when looking for the next/prev key or a particular version before calling
+// iter.Seek(). The scanner adapts the number between 1 and
+// maxItersBeforeSeek, in the same way as libroach's mvccScanner.
+const maxItersBeforeSeek = 10
+
+// mvccScanner implements MVCCGet and MVCCScan on top of an lsm.Iterator. It
+// is a port of libroach's mvccScanner (see c-deps/libroach/mvcc.h) and must
+// be kept in sync with it: the results, including the resume key and the
+// intents, are identical to those returned by RocksDB.
+//
+// The MVCC data is sorted by key and descending timestamp, with the
+// metadata key of a key (holding an intent or an inline value) sorting
+// before its versions. The scanner is bounded by the bounds of the iterator;
+// for gets the iterator is bounded to the versions of the key.
+type mvccScanner struct {
+	iter *lsm.Iterator
+
+	start            roachpb.Key
+	reverse          bool
+	maxKeys          int64
+	ts               hlc.Timestamp
+	txn              *roachpb.Transaction
+	txnEpoch         enginepb.TxnEpoch
+	txnSequence      enginepb.TxnSeq
+	txnMaxTimestamp  hlc.Timestamp
+	inconsistent     bool
+	tombstones       bool
+	ignoreSequence   bool
+	checkUncertainty bool
+
+	kvData  []byte
+	kvCount int64
+	intents RocksDBBatchBuilder
+	err     error
+	// uncertaintyTS is set to the timestamp of the value which caused an
+	// uncertainty error, if any.
+	uncertaintyTS hlc.Timestamp
+	resumeKey     roachpb.Key
+
+	meta enginepb.MVCCMetadata
+	// curRawKey, curKey and curValue describe the current entry. They alias
+	// the iterator or, if peeked is set, savedBuf.
+	curRawKey []byte
+	curKey    []byte
+	curValue  []byte
+	curTS     hlc.Timestamp
+	keyBuf    []byte
+	savedBuf  []byte
+	// peeked is set when the iterator has been moved to the entry preceding
+	// the current entry (see iterPeekPrev).
+	peeked          bool
+	itersBeforeSeek int
+}
+
+func newMVCCScanner(
+	iter *lsm.Iterator,
+	start roachpb.Key,
+	maxKeys int64,
+	ts hlc.Timestamp,
+	txn *roachpb.Transaction,
+	inconsistent, reverse, tombstones, ignoreSequence bool,
+) *mvccScanner {
+	s := &mvccScanner{
+		iter:            iter,
+		start:           start,
+		reverse:         reverse,
+		maxKeys:         maxKeys,
+		ts:              ts,
+		txn:             txn,
+		inconsistent:    inconsistent,
+		tombstones:      tombstones,
+		ignoreSequence:  ignoreSequence,
+		itersBeforeSeek: maxItersBeforeSeek / 2,
+	}
+	if txn != nil {
+		s.txnEpoch = txn.Epoch
+		s.txnSequence = txn.Sequence
+		s.txnMaxTimestamp = txn.MaxTimestamp
+		s.checkUncertainty = ts.Less(txn.MaxTimestamp)
+	}
+	return s
+}
+
+// get performs a get of the start key.
+func (s *mvccScanner) get() {
+	if !s.iterSeek(EncodeKey(MakeMVCCMetadataKey(s.start))) {
+		return
+	}
+	s.getAndAdvance()
+}
+
+// scan performs a scan from the start key, setting resumeKey if the scan
+// hit maxKeys before exhausting the iterator.
+func (s *mvccScanner) scan() {
+	seekKey := EncodeKey(MakeMVCCMetadataKey(s.start))
+	if s.reverse {
+		if !s.iterSeekReverse(seekKey) {
+			return
+		}
+	} else {
+		if !s.iterSeek(seekKey) {
+			return
+		}
+	}
+
+	for s.getAndAdvance() {
+	}
+
+	if s.kvCount == s.maxKeys && s.advanceKey() {
+		s.resumeKey = append(roachpb.Key(nil), s.curKey...)
+	}
+}
+
+// intentData returns the intents encountered by the scan in the batch
+// representation decoded by buildScanIntents.
+func (s *mvccScanner) intentData() []byte {
+	if s.intents.count == 0 {
+		return nil
+	}
+	return s.intents.Finish()
+}
+
+// scanError returns the error encountered by the scanner, if any, converting
+// an uncertainty error into a ReadWithinUncertaintyIntervalError.
+func (s *mvccScanner) scanError() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.uncertaintyTS != (hlc.Timestamp{}) {
+		return roachpb.NewReadWithinUncertaintyIntervalError(s.ts, s.uncertaintyTS, s.txn)
+	}
+	return nil
+}
+
+func (s *mvccScanner) getFromIntentHistory() bool {
+	history := s.meta.IntentHistory
+	// Look for the intent with the sequence number less than or equal to the
+	// read sequence. To do so, search for the first intent with a sequence
+	// greater than the read sequence and then step back.
+	up := sort.Search(len(history), func(i int) bool {
+		return history[i].Sequence > s.txnSequence
+	})
+	if up == 0 {
+		// It is possible that no intent exists such that the sequence is less
+		// than the read sequence. In this case, we cannot read a value from the
+		// intent history.
+		return false
+	}
+	value := history[up-1].Value
+	if len(value) > 0 || s.tombstones {
+		s.addKV(s.curRawKey, value)
+	}
+	return true
+}
+
+func (s *mvccScanner) uncertaintyError(ts hlc.Timestamp) bool {
+	s.uncertaintyTS = ts
+	s.kvData = nil
+	s.kvCount = 0
+	s.intents.reset()
+	return false
+}
+
+func (s *mvccScanner) setError(err error) bool {
+	s.err = err
+	return false
+}
+
+func (s *mvccScanner) getAndAdvance() bool {
+	if s.curTS != (hlc.Timestamp{}) {
+		if !s.ts.Less(s.curTS) {
+			// 1. Fast path: there is no intent and our read timestamp is newer
+			// than the most recent version's timestamp.
+			return s.addAndAdvance(s.curValue)
+		}
+
+		if s.checkUncertainty {
+			// 2. Our txn's read timestamp is less than the max timestamp seen by
+			// the txn. We need to check for clock uncertainty errors.
+			if !s.txnMaxTimestamp.Less(s.curTS) {
+				return s.uncertaintyError(s.curTS)
+			}
+			// Delegate to seekVersion to return a clock uncertainty error if
+			// there are any more versions above txnMaxTimestamp.
+			return s.seekVersion(s.txnMaxTimestamp, true)
+		}
+
+		// 3. Our txn's read timestamp is greater than or equal to the max
+		// timestamp seen by the txn so clock uncertainty checks are
+		// unnecessary. We need to seek to the desired version of the value
+		// (i.e. one with a timestamp earlier than our read timestamp).
+		return s.seekVersion(s.ts, false)
+	}
+
+	if len(s.curValue) == 0 {
+		return s.setError(errors.Errorf("zero-length mvcc metadata"))
+	}
+
+	s.meta.Reset()
+	if err := protoutil.Unmarshal(s.curValue, &s.meta); err != nil {
+		return s.setError(errors.Errorf("unable to decode MVCCMetadata"))
+	}
+
+	if s.meta.RawBytes != nil {
+		// 4. Emit immediately if the value is inline.
+		return s.addAndAdvance(s.meta.RawBytes)
+	}
+
+	if s.meta.Txn == nil {
+		return s.setError(errors.Errorf("intent without transaction"))
+	}
+
+	ownIntent := s.txn != nil && s.meta.Txn.ID == s.txn.ID
+	metaTS := hlc.Timestamp(s.meta.Timestamp)
+	// metaTS is the timestamp of an intent value, which we may or may not end
+	// up ignoring, depending on factors codified below. If we do ignore the
+	// intent then we want to read at a lower timestamp that's strictly below
+	// the intent timestamp (to skip the intent), but also does not exceed our
+	// read timestamp (to avoid erroneously picking up future committed
+	// values); this timestamp is prevTS.
+	prevTS := s.ts
+	if !s.ts.Less(metaTS) {
+		prevTS = metaTS.Prev()
+	}
+	if s.ts.Less(metaTS) && !ownIntent {
+		// 5. The key contains an intent, but we're reading before the intent.
+		// Seek to the desired version. Note that if we own the intent (i.e.
+		// we're reading transactionally) we want to read the intent regardless
+		// of our read timestamp and fall into case 8 below.
+		return s.seekVersion(s.ts, false)
+	}
+
+	if s.inconsistent {
+		// 6. The key contains an intent and we're doing an inconsistent read at
+		// a timestamp newer than the intent. We ignore the intent by insisting
+		// that the timestamp we're reading at is a historical timestamp < the
+		// intent timestamp. However, we return the intent separately; the
+		// caller may want to resolve it.
+		if s.kvCount == s.maxKeys {
+			// We've already retrieved the desired number of keys and now we're
+			// adding the resume key. We don't want to add the intent here as the
+			// intents should only correspond to KVs that lie before the resume
+			// key.
+			return false
+		}
+		s.intents.Put(MakeMVCCMetadataKey(s.curKey), s.curValue)
+		return s.seekVersion(prevTS, false)
+	}
+
+	if !ownIntent {
+		// 7. The key contains an intent which was not written by our
+		// transaction and our read timestamp is newer than that of the intent.
+		// Note that this will trigger an error in the caller. We continue
+		// scanning so that we can return all of the intents in the scan range.
+		s.intents.Put(MakeMVCCMetadataKey(s.curKey), s.curValue)
+		return s.advanceKey()
+	}
+
+	if s.txnEpoch == s.meta.Txn.Epoch {
+		if s.ignoreSequence || s.txnSequence >= s.meta.Txn.Sequence {
+			// 8. We're reading our own txn's intent at an equal or higher
+			// sequence. Note that we read at the intent timestamp, not at our
+			// read timestamp as the intent timestamp may have been pushed forward
+			// by another transaction. Txn's always need to read their own writes.
+			return s.seekVersion(metaTS, false)
+		}
+		// 9. We're reading our own txn's intent at a lower sequence than is
+		// currently present in the intent. If there exists a value in the
+		// intent history that has a sequence number equal to or less than the
+		// read sequence, read that value.
+		if s.getFromIntentHistory() {
+			return s.advanceKey()
+		}
+		// 10. If no value in the intent history has a sequence number equal to
+		// or less than the read, we must ignore the intents laid down by the
+		// transaction all together. We ignore the intent by insisting that the
+		// timestamp we're reading at is a historical timestamp < the intent
+		// timestamp.
+		return s.seekVersion(prevTS, false)
+	}
+
+	if s.txnEpoch < s.meta.Txn.Epoch {
+		// 11. We're reading our own txn's intent but the current txn has an
+		// earlier epoch than the intent. Return an error so that the earlier
+		// incarnation of our transaction aborts (presumably this is some
+		// operation that was retried).
+		return s.setError(errors.Errorf("failed to read with epoch %d due to a write intent with epoch %d",
+			s.txnEpoch, s.meta.Txn.Epoch))
+	}
+
+	// 12. We're reading our own txn's intent but the current txn has a later
+	// epoch than the intent. This can happen if the txn was restarted and an
+	// earlier iteration wrote the value we're now reading. In this case, we
+	// ignore the intent and read the previous value as if the transaction were
+	// starting fresh.
+	return s.seekVersion(prevTS, false)
+}
+
+// nextKey advances the iterator to point to the next MVCC key greater than
+// curKey. Returns false if the iterator is exhausted or an error occurs.
+func (s *mvccScanner) nextKey() bool {
+	s.keyBuf = append(s.keyBuf[:0], s.curKey...)
+
+	for i := 0; i < s.itersBeforeSeek; i++ {
+		if !s.iterNext() {
+			return false
+		}
+		if !bytes.Equal(s.curKey, s.keyBuf) {
+			s.incrementItersBeforeSeek()
+			return true
+		}
+	}
+
+	// We're pointed at a different version of the same key. Fall back to
+	// seeking to the next key.
+	s.decrementItersBeforeSeek()
+	s.keyBuf = append(s.keyBuf, 0)
+	return s.iterSeek(EncodeKey(MakeMVCCMetadataKey(s.keyBuf)))
+}
+
+// backwardLatestVersion backs up the iterator to the latest version for the
+// specified key. The parameter i is used to maintain the iteration count
+// between the loop here and the caller (usually prevKey). Returns false if
+// an error occurred.
+func (s *mvccScanner) backwardLatestVersion(key []byte, i int) bool {
+	s.keyBuf = append(s.keyBuf[:0], key...)
+
+	for ; i < s.itersBeforeSeek; i++ {
+		peekedKey, ok := s.iterPeekPrev()
+		if !ok {
+			return false
+		}
+		if !bytes.Equal(peekedKey, s.keyBuf) {
+			// The key changed which means the current key is the latest version.
+			s.incrementItersBeforeSeek()
+			return true
+		}
+		if !s.iterPrev() {
+			return false
+		}
+	}
+
+	s.decrementItersBeforeSeek()
+	return s.iterSeek(EncodeKey(MakeMVCCMetadataKey(s.keyBuf)))
+}
+
+// prevKey backs up the iterator to point to the prev MVCC key less than the
+// specified key. Returns false if the iterator is exhausted or an error
+// occurs.
+func (s *mvccScanner) prevKey(key []byte) bool {
+	s.keyBuf = append(s.keyBuf[:0], key...)
+
+	for i := 0; i < s.itersBeforeSeek; i++ {
+		peekedKey, ok := s.iterPeekPrev()
+		if !ok {
+			return false
+		}
+		if !bytes.Equal(peekedKey, s.keyBuf) {
+			return s.backwardLatestVersion(peekedKey, i+1)
+		}
+		if !s.iterPrev() {
+			return false
+		}
+	}
+
+	s.decrementItersBeforeSeek()
+	return s.iterSeekReverse(EncodeKey(MakeMVCCMetadataKey(s.keyBuf)))
+}
+
+// advanceKey advances the iterator to point to the next MVCC key. Returns
+// false if the iterator is exhausted or an error occurs.
+func (s *mvccScanner) advanceKey() bool {
+	if s.reverse {
+		return s.prevKey(append([]byte(nil), s.curKey...))
+	}
+	return s.nextKey()
+}
+
+func (s *mvccScanner) advanceKeyAtEnd() bool {
+	if s.reverse {
+		// Iterating to the next key might have caused the iterator to reach the
+		// end of the key space. If that happens, back up to the very last key.
+		s.peeked = false
+		s.iter.Last()
+		if !s.updateCurrent() {
+			return false
+		}
+		return s.advanceKey()
+	}
+	// We've reached the end of the iterator and there is nothing left to do.
+	return false
+}
+
+func (s *mvccScanner) advanceKeyAtNewKey(key []byte) bool {
+	if s.reverse {
+		// We've advanced to the next key but need to move back to the previous
+		// key.
+		return s.prevKey(key)
+	}
+	// We're already at the new key so there is nothing to do.
+	return true
+}
+
+func (s *mvccScanner) addKV(key, value []byte) {
+	var lenBuf [8]byte
+	binary.LittleEndian.PutUint32(lenBuf[0:4], uint32(len(value)))
+	binary.LittleEndian.PutUint32(lenBuf[4:8], uint32(len(key)))
+	s.kvData = append(s.kvData, lenBuf[:]...)
+	s.kvData = append(s.kvData, key...)
+	s.kvData = append(s.kvData, value...)
+	s.kvCount++
+}
+
+func (s *mvccScanner) addAndAdvance(value []byte) bool {
+	// Don't include deleted versions (len(value) == 0), unless we've been
+	// instructed to include tombstones in the results.
+	if len(value) > 0 || s.tombstones {
+		s.addKV(s.curRawKey, value)
+		if s.kvCount == s.maxKeys {
+			return false
+		}
+	}
+	return s.advanceKey()
+}
+
+// seekVersion advances the iterator to point to an MVCC version for the
+// specified key that is earlier than ts. Returns false if the iterator is
+// exhausted or an error occurs. On success, advances the iterator to the
+// next key.
+//
+// If checkUncertainty is true, then observing any version of the desired
+// key with a timestamp larger than our read timestamp results in an
+// uncertainty error.
+func (s *mvccScanner) seekVersion(ts hlc.Timestamp, checkUncertainty bool) bool {
+	s.keyBuf = append(s.keyBuf[:0], s.curKey...)
+
+	for i := 0; i < s.itersBeforeSeek; i++ {
+		if !s.iterNext() {
+			return s.advanceKeyAtEnd()
+		}
+		if !bytes.Equal(s.curKey, s.keyBuf) {
+			s.incrementItersBeforeSeek()
+			return s.advanceKeyAtNewKey(s.keyBuf)
+		}
+		if !ts.Less(s.curTS) {
+			s.incrementItersBeforeSeek()
+			if checkUncertainty && s.ts.Less(s.curTS) {
+				return s.uncertaintyError(s.curTS)
+			}
+			return s.addAndAdvance(s.curValue)
+		}
+	}
+
+	s.decrementItersBeforeSeek()
+	if !s.iterSeek(EncodeKey(MVCCKey{Key: s.keyBuf, Timestamp: ts})) {
+		return s.advanceKeyAtEnd()
+	}
+	if !bytes.Equal(s.curKey, s.keyBuf) {
+		return s.advanceKeyAtNewKey(s.keyBuf)
+	}
+	if !ts.Less(s.curTS) {
+		if checkUncertainty && s.ts.Less(s.curTS) {
+			return s.uncertaintyError(s.curTS)
+		}
+		return s.addAndAdvance(s.curValue)
+	}
+	return s.advanceKey()
+}
+
+func (s *mvccScanner) incrementItersBeforeSeek() {
+	if s.itersBeforeSeek < maxItersBeforeSeek {
+		s.itersBeforeSeek++
+	}
+}
+
+func (s *mvccScanner) decrementItersBeforeSeek() {
+	if s.itersBeforeSeek > 1 {
+		s.itersBeforeSeek--
+	}
+}
+
+// updateCurrent updates the current entry from the iterator.
+func (s *mvccScanner) updateCurrent() bool {
+	if !s.iter.Valid() {
+		if err := s.iter.Error(); err != nil {
+			return s.setError(err)
+		}
+		return false
+	}
+	s.curRawKey = s.iter.Key()
+	s.curValue = s.iter.Value()
+	return s.decodeCurrent()
+}
+
+func (s *mvccScanner) decodeCurrent() bool {
+	key, ts, err := enginepb.DecodeKey(s.curRawKey)
+	if err != nil {
+		return s.setError(errors.Errorf("failed to split mvcc key"))
+	}
+	s.curKey, s.curTS = key, ts
+	return true
+}
+
+// iterSeek positions the iterator at the first key that is greater than or
+// equal to key.
+func (s *mvccScanner) iterSeek(key []byte) bool {
+	s.peeked = false
+	s.iter.SeekGE(key)
+	return s.updateCurrent()
+}
+
+// iterSeekReverse positions the iterator at the latest version of the last
+// key that is less than key.
+func (s *mvccScanner) iterSeekReverse(key []byte) bool {
+	s.peeked = false
+	s.iter.SeekLT(key)
+	if !s.updateCurrent() {
+		return false
+	}
+	if s.curTS == (hlc.Timestamp{}) {
+		// We landed on an intent or inline value.
+		return true
+	}
+	// We landed on a versioned value, we need to back up to find the latest
+	// version.
+	return s.backwardLatestVersion(append([]byte(nil), s.curKey...), 0)
+}
+
+func (s *mvccScanner) iterNext() bool {
+	if s.reverse && s.peeked {
+		// If we had peeked at the previous entry, we need to advance the
+		// iterator twice to get to the real next entry.
+		s.peeked = false
+		if !s.iter.Next() {
+			return s.updateCurrent()
+		}
+	}
+	s.iter.Next()
+	return s.updateCurrent()
+}
+
+func (s *mvccScanner) iterPrev() bool {
+	if s.peeked {
+		s.peeked = false
+		return s.updateCurrent()
+	}
+	s.iter.Prev()
+	return s.updateCurrent()
+}
+
+// iterPeekPrev "peeks" at the key of the entry before the current iterator
+// position. The current entry is saved so that it remains the current
+// entry.
+func (s *mvccScanner) iterPeekPrev() ([]byte, bool) {
+	if !s.peeked {
+		s.peeked = true
+		// We need to save a copy of the current entry and adjust curRawKey,
+		// curKey and curValue to point to the saved data.
+		s.savedBuf = append(s.savedBuf[:0], s.curRawKey...)
+		s.savedBuf = append(s.savedBuf, s.curValue...)
+		s.curRawKey = s.savedBuf[:len(s.curRawKey)]
+		s.curValue = s.savedBuf[len(s.curRawKey):]
+		if !s.decodeCurrent() {
+			return nil, false
+		}
+
+		// With the current entry saved we can move the iterator to the
+		// previous entry.
+		if !s.iter.Prev() {
+			if err := s.iter.Error(); err != nil {
+				return nil, s.setError(err)
+			}
+			// Peeking at the previous key should never leave the iterator
+			// invalid. Instead, we seek back to the first key and set the peeked
+			// key to the empty key. Note that this prevents using reverse scan to
+			// scan to the empty key.
+			s.peeked = false
+			s.iter.First()
+			return nil, s.updateCurrent()
+		}
+	}
+
+	peekedKey, _, ok := enginepb.SplitMVCCKey(s.iter.Key())
+	if !ok {
+		return nil, s.setError(errors.Errorf("failed to split mvcc key"))
+	}
+	return peekedKey, true
+}
@@ -876,16 +876,21 @@ func (r *RocksDB) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)
 
 // Capacity queries the underlying file system for disk capacity information.
 func (r *RocksDB) Capacity() (roachpb.StoreCapacity, error) {
+	return computeCapacity(r.cfg.Dir, r.cfg.MaxSizeBytes)
+}
+
+// computeCapacity returns the capacity of a store in dir, which is limited to
+// maxSizeBytes if non-zero. An empty dir denotes an in-memory store.
+func computeCapacity(dir string, maxSizeBytes int64) (roachpb.StoreCapacity, error) {
 	fileSystemUsage := gosigar.FileSystemUsage{}
-	dir := r.cfg.Dir
 	if dir == "" {
 		// This is an in-memory instance. Pretend we're empty since we
 		// don't know better and only use this for testing. Using any
 		// part of the actual file system here can throw off allocator
 		// rebalancing in a hard-to-trace manner. See #7050.
 		return roachpb.StoreCapacity{
-			Capacity:  r.cfg.MaxSizeBytes,
-			Available: r.cfg.MaxSizeBytes,
+			Capacity:  maxSizeBytes,
+			Available: maxSizeBytes,
 		}, nil
 	}
 	if err := fileSystemUsage.Get(dir); err != nil {
@@ -903,10 +908,10 @@ func (r *RocksDB) Capacity() (roachpb.StoreCapacity, error) {
 	fsuTotal := int64(fileSystemUsage.Total)
 	fsuAvail := int64(fileSystemUsage.Avail)
 
-	// Find the total size of all the files in the r.dir and all its
+	// Find the total size of all the files in the dir and all its
 	// subdirectories.
 	var totalUsedBytes int64
-	if errOuter := filepath.Walk(r.cfg.Dir, func(path string, info os.FileInfo, err error) error {
+	if errOuter := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			// This can happen if rocksdb removes files out from under us - just keep
 			// going to get the best estimate we can.
@@ -932,7 +937,7 @@ func (r *RocksDB) Capacity() (roachpb.StoreCapacity, error) {
 	// If no size limitation have been placed on the store size or if the
 	// limitation is greater than what's available, just return the actual
 	// totals.
-	if r.cfg.MaxSizeBytes == 0 || r.cfg.MaxSizeBytes >= fsuTotal || r.cfg.Dir == "" {
+	if maxSizeBytes == 0 || maxSizeBytes >= fsuTotal || dir == "" {
 		return roachpb.StoreCapacity{
 			Capacity:  fsuTotal,
 			Available: fsuAvail,
@@ -940,7 +945,7 @@ func (r *RocksDB) Capacity() (roachpb.StoreCapacity, error) {
 		}, nil
 	}
 
-	available := r.cfg.MaxSizeBytes - totalUsedBytes
+	available := maxSizeBytes - totalUsedBytes
 	if available > fsuAvail {
 		available = fsuAvail
 	}
@@ -949,7 +954,7 @@ func (r *RocksDB) Capacity() (roachpb.StoreCapacity, error) {
 	}
 
 	return roachpb.StoreCapacity{
-		Capacity:  r.cfg.MaxSizeBytes,
+		Capacity:  maxSizeBytes,
 		Available: available,
 		Used:      totalUsedBytes,
 	}, nil
@@ -3020,15 +3025,21 @@ func (r *RocksDB) setAuxiliaryDir(d string) error {
 // hitting its configured (via settings) maximum delay. If the pending
 // compaction limit is exceeded, it waits for the maximum delay.
 func (r *RocksDB) PreIngestDelay(ctx context.Context) {
-	if r.cfg.Settings == nil {
+	preIngestDelay(ctx, r, r.cfg.Settings)
+}
+
+// preIngestDelay implements Engine.PreIngestDelay for engines whose stats
+// report the L0 file count and pending compaction bytes.
+func preIngestDelay(ctx context.Context, eng Engine, settings *cluster.Settings) {
+	if settings == nil {
 		return
 	}
-	stats, err := r.GetStats()
+	stats, err := eng.GetStats()
 	if err != nil {
 		log.Warningf(ctx, "failed to read stats: %+v", err)
 		return
 	}
-	targetDelay := calculatePreIngestDelay(r.cfg, stats)
+	targetDelay := calculatePreIngestDelay(settings, stats)
 
 	if targetDelay == 0 {
 		return
@@ -3041,10 +3052,10 @@ func (r *RocksDB) PreIngestDelay(ctx context.Context) {
 	}
 }
 
-func calculatePreIngestDelay(cfg RocksDBConfig, stats *Stats) time.Duration {
-	maxDelay := ingestDelayTime.Get(&cfg.Settings.SV)
-	l0Filelimit := ingestDelayL0Threshold.Get(&cfg.Settings.SV)
-	compactionLimit := ingestDelayPendingLimit.Get(&cfg.Settings.SV)
+func calculatePreIngestDelay(settings *cluster.Settings, stats *Stats) time.Duration {
+	maxDelay := ingestDelayTime.Get(&settings.SV)
+	l0Filelimit := ingestDelayL0Threshold.Get(&settings.SV)
+	compactionLimit := ingestDelayPendingLimit.Get(&settings.SV)
 
 	if stats.PendingCompactionBytesEstimate >= compactionLimit {
 		return maxDelay
@@ -3192,8 +3203,10 @@ func MVCCScanDecodeKeyValue(repr []byte) (key MVCCKey, value []byte, orepr []byt
 // interval (start.Timestamp, end.Timestamp]. Passing exportAllRevisions exports
 // every revision of a key for the interval, otherwise only the latest value
 // within the interval is exported. Deletions are included if all revisions are
-// requested or if the start.Timestamp is non-zero. Returns the bytes of an
-// SSTable containing the exported keys, the size of exported data, or an error.
+// requested or if the start.Timestamp is non-zero. The keys deleted by MVCC
+// range tombstones are exported as if they were deleted individually. Returns
+// the bytes of an SSTable containing the exported keys, the size of exported
+// data, or an error.
 func ExportToSst(
 	ctx context.Context, e Reader, start, end MVCCKey, exportAllRevisions bool, io IterOptions,
 ) ([]byte, int64, error) {
@@ -3228,7 +3241,9 @@ func ExportToSst(
 		return nil, 0, err
 	}
 
-	return cStringToGoBytes(data), int64(dataSize), nil
+	return exportRangeTombstones(
+		e, cStringToGoBytes(data), int64(dataSize), start, end, exportAllRevisions,
+	)
 }
 
 func notFoundErrOrDefault(err error) error {
@@ -1758,6 +1758,6 @@ func TestIngestDelayLimit(t *testing.T) {
 		{max, Stats{L0FileCount: 25, PendingCompactionBytesEstimate: 80 << 30}},
 		{max, Stats{L0FileCount: 35, PendingCompactionBytesEstimate: 20 << 30}},
 	} {
-		require.Equal(t, tc.exp, calculatePreIngestDelay(cfg, &tc.stats))
+		require.Equal(t, tc.exp, calculatePreIngestDelay(cfg.Settings, &tc.stats))
 	}
 }
@@ -28,6 +28,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/abortspan"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/rditer"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
@@ -173,9 +174,37 @@ func makeGCQueueScore(
 	if (gcThreshold != hlc.Timestamp{}) {
 		r.LikelyLastGC = time.Duration(now.WallTime - gcThreshold.Add(r.TTL.Nanoseconds(), 0).WallTime)
 	}
+	// If a protected timestamp prevents the GC threshold from advancing, only
+	// old intents can make processing the replica worthwhile. The cache is not
+	// refreshed here; process will do that if the replica is queued.
+	if protectedTS, _ := repl.earliestProtectedTimestamp(ctx, desc); (protectedTS != hlc.Timestamp{}) &&
+		!gcThreshold.Less(protectedTS.Prev()) {
+		r.ShouldQueue = r.FuzzFactor*r.IntentScore > gcIntentScoreThreshold
+		r.FinalScore = r.FuzzFactor * r.IntentScore
+	}
 	return r
 }
 
+// earliestProtectedTimestamp returns the earliest timestamp protected by a
+// record in the store's protected timestamp cache which overlaps the
+// replica's span, or the zero timestamp if there is none. It also returns the
+// timestamp as of which the cache is current.
+func (r *Replica) earliestProtectedTimestamp(
+	ctx context.Context, desc *roachpb.RangeDescriptor,
+) (protectedTS, asOf hlc.Timestamp) {
+	cache := r.store.cfg.ProtectedTimestampCache
+	if cache == nil {
+		return hlc.Timestamp{}, hlc.MaxTimestamp
+	}
+	asOf = cache.Iterate(ctx, desc.StartKey.AsRawKey(), desc.EndKey.AsRawKey(),
+		func(rec *ptpb.Record) {
+			if (protectedTS == hlc.Timestamp{}) || rec.Timestamp.Less(protectedTS) {
+				protectedTS = rec.Timestamp
+			}
+		})
+	return protectedTS, asOf
+}
+
 // makeGCQueueScoreImpl is used to compute when to trigger the GC Queue. It's
 // important that we don't queue a replica before a relevant amount of data is
 // actually deletable, or the queue might run in a tight loop. To this end, we
@@ -476,6 +505,9 @@ func (NoopGCer) SetGCThreshold(context.Context, GCThreshold) error { return nil
 // GC implements storage.GCer.
 func (NoopGCer) GC(context.Context, []roachpb.GCRequest_GCKey) error { return nil }
 
+// GCRangeTombstones implements storage.GCer.
+func (NoopGCer) GCRangeTombstones(context.Context, hlc.Timestamp) error { return nil }
+
 type replicaGCer struct {
 	repl  *Replica
 	count int32 // update atomically
@@ -526,6 +558,12 @@ func (r *replicaGCer) GC(ctx context.Context, keys []roachpb.GCRequest_GCKey) er
 	return r.send(ctx, req)
 }
 
+func (r *replicaGCer) GCRangeTombstones(ctx context.Context, threshold hlc.Timestamp) error {
+	req := r.template()
+	req.RangeTombstoneThreshold = threshold
+	return r.send(ctx, req)
+}
+
 // process iterates through all keys in a replica's range, calling the garbage
 // collector for each key and associated set of values. GC'd keys are batched
 // into GC calls. Extant intents are resolved if intents are older than
@@ -561,7 +599,36 @@ func (gcq *gcQueue) process(ctx context.Context, repl *Replica, sysCfg *config.S
 	// Lookup the descriptor and GC policy for the zone containing this key range.
 	desc, zone := repl.DescAndZone()
 
-	info, err := RunGC(ctx, desc, snap, now, *zone.GC, &replicaGCer{repl: repl},
+	// Make sure the protected timestamp cache is current as of the new GC
+	// threshold. Any record written before the data it protects expired is
+	// then visible to us and holds back the threshold. The cache must also be
+	// current as of the view of the records against which records have been
+	// verified on this replica.
+	gcThreshold := engine.MakeGarbageCollector(now, *zone.GC).Threshold
+	if cache := repl.store.cfg.ProtectedTimestampCache; cache != nil {
+		refreshTo := gcThreshold
+		repl.protectedTimestampMu.Lock()
+		refreshTo.Forward(repl.protectedTimestampMu.minStateReadTimestamp)
+		repl.protectedTimestampMu.Unlock()
+		if err := cache.Refresh(ctx, refreshTo); err != nil {
+			return errors.Wrap(err, "failed to refresh protected timestamps")
+		}
+	}
+	protectedTS, asOf := repl.earliestProtectedTimestamp(ctx, desc)
+	if (asOf == hlc.Timestamp{}) {
+		log.VEventf(ctx, 1, "skipping replica %s: protected timestamps not yet read", repl)
+		return nil
+	}
+	if (protectedTS != hlc.Timestamp{}) && !gcThreshold.Less(protectedTS) {
+		gcThreshold = protectedTS.Prev()
+	}
+	if !repl.markPendingGC(asOf, gcThreshold) {
+		log.VEventf(ctx, 1, "skipping replica %s: protected timestamps read at %s are stale",
+			repl, asOf)
+		return nil
+	}
+
+	info, err := RunGC(ctx, desc, snap, now, protectedTS, *zone.GC, &replicaGCer{repl: repl},
 		func(ctx context.Context, intents []roachpb.Intent) error {
 			intentCount, err := repl.store.intentResolver.CleanupIntents(ctx, intents, now, roachpb.PUSH_ABORT)
 			if err == nil {
@@ -672,6 +739,9 @@ type GCThreshold struct {
 type GCer interface {
 	SetGCThreshold(context.Context, GCThreshold) error
 	GC(context.Context, []roachpb.GCRequest_GCKey) error
+	// GCRangeTombstones removes the MVCC range tombstones at or below the
+	// threshold once the versions they cover have been garbage collected.
+	GCRangeTombstones(context.Context, hlc.Timestamp) error
 }
 
 // RunGC runs garbage collection for the specified descriptor on the
@@ -679,12 +749,15 @@ type GCer interface {
 // to run garbage collection once on all implicated spans,
 // cleanupIntentsFn to resolve intents synchronously, and
 // cleanupTxnIntentsAsyncFn to asynchronously cleanup intents and
-// associated transaction record on success.
+// associated transaction record on success. If protectedTS is not zero,
+// the GC threshold is kept below it so that reads at protectedTS remain
+// possible.
 func RunGC(
 	ctx context.Context,
 	desc *roachpb.RangeDescriptor,
 	snap engine.Reader,
 	now hlc.Timestamp,
+	protectedTS hlc.Timestamp,
 	policy config.GCPolicy,
 	gcer GCer,
 	cleanupIntentsFn cleanupIntentsFunc,
@@ -703,6 +776,9 @@ func RunGC(
 	txnExp := now.Add(-storagebase.TxnCleanupThreshold.Nanoseconds(), 0)
 
 	gc := engine.MakeGarbageCollector(now, policy)
+	if (protectedTS != hlc.Timestamp{}) && !gc.Threshold.Less(protectedTS) {
+		gc.Threshold = protectedTS.Prev()
+	}
 	infoMu.Threshold = gc.Threshold
 	infoMu.TxnSpanGCThreshold = txnExp
 
@@ -713,6 +789,14 @@ func RunGC(
 		return GCInfo{}, errors.Wrap(err, "failed to set GC thresholds")
 	}
 
+	// The versions deleted by range tombstones are garbage collected as if
+	// they were deleted by a deletion tombstone at the range tombstone's
+	// timestamp.
+	rangeTombs, err := engine.MVCCGetRangeTombstones(snap, desc.StartKey.AsRawKey(), desc.EndKey.AsRawKey())
+	if err != nil {
+		return GCInfo{}, err
+	}
+
 	var batchGCKeys []roachpb.GCRequest_GCKey
 	var batchGCKeysBytes int64
 	var expBaseKey roachpb.Key
@@ -765,6 +849,7 @@ func RunGC(
 					// With an active intent, GC ignores MVCC metadata & intent value.
 					startIdx = 2
 				}
+				rangeTombs = addRangeTombstoneVersions(rangeTombs, expBaseKey, &keys, &vals, startIdx)
 				// See if any values may be GC'd.
 				if idx, gcTS := gc.Filter(keys[startIdx:], vals[startIdx:]); gcTS != (hlc.Timestamp{}) {
 					// Batch keys after the total size of version keys exceeds
@@ -853,6 +938,11 @@ func RunGC(
 			return GCInfo{}, err
 		}
 	}
+	if len(rangeTombs) > 0 {
+		if err := gcer.GCRangeTombstones(ctx, gc.Threshold); err != nil {
+			return GCInfo{}, err
+		}
+	}
 
 	// From now on, all newly added keys are range-local.
 
@@ -893,6 +983,39 @@ func RunGC(
 	return infoMu.GCInfo, nil
 }
 
+// addRangeTombstoneVersions inserts a deletion tombstone at the timestamp of
+// each of the range tombstones covering the given key into its versions,
+// starting at startIdx. The range tombstones are sorted by start key, and keys
+// must be passed in ascending order; the range tombstones preceding the key
+// are dropped from the returned slice.
+func addRangeTombstoneVersions(
+	rangeTombs []enginepb.MVCCRangeTombstone,
+	key roachpb.Key,
+	keys *[]engine.MVCCKey,
+	vals *[][]byte,
+	startIdx int,
+) []enginepb.MVCCRangeTombstone {
+	for len(rangeTombs) > 0 && key.Compare(rangeTombs[0].EndKey) >= 0 {
+		rangeTombs = rangeTombs[1:]
+	}
+	for _, t := range rangeTombs {
+		if key.Compare(t.StartKey) < 0 {
+			break
+		}
+		i := startIdx
+		for i < len(*keys) && t.Timestamp.Less((*keys)[i].Timestamp) {
+			i++
+		}
+		*keys = append(*keys, engine.MVCCKey{})
+		copy((*keys)[i+1:], (*keys)[i:])
+		(*keys)[i] = engine.MVCCKey{Key: key, Timestamp: t.Timestamp}
+		*vals = append(*vals, nil)
+		copy((*vals)[i+1:], (*vals)[i:])
+		(*vals)[i] = nil
+	}
+	return rangeTombs
+}
+
 // timer returns a constant duration to space out GC processing
 // for successive queued replicas.
 func (*gcQueue) timer(_ time.Duration) time.Duration {
@@ -26,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
@@ -35,6 +36,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/kr/pretty"
 	"github.com/pkg/errors"
@@ -504,7 +506,7 @@ func TestGCQueueProcess(t *testing.T) {
 		}
 
 		now := tc.Clock().Now()
-		return RunGC(ctx, desc, snap, now, *zone.GC,
+		return RunGC(ctx, desc, snap, now, hlc.Timestamp{} /* protectedTS */, *zone.GC,
 			NoopGCer{},
 			func(ctx context.Context, intents []roachpb.Intent) error {
 				return nil
@@ -584,6 +586,86 @@ func TestGCQueueProcess(t *testing.T) {
 	})
 }
 
+// fakeProtectedTimestampCache is a protectedts.Cache which holds a fixed set
+// of records.
+type fakeProtectedTimestampCache struct {
+	records []ptpb.Record
+
+	mu struct {
+		syncutil.Mutex
+		asOf hlc.Timestamp
+	}
+}
+
+func (c *fakeProtectedTimestampCache) Iterate(
+	_ context.Context, from, to roachpb.Key, fn func(*ptpb.Record),
+) hlc.Timestamp {
+	sp := roachpb.Span{Key: from, EndKey: to}
+	for i := range c.records {
+		for _, s := range c.records[i].Spans {
+			if s.Overlaps(sp) {
+				fn(&c.records[i])
+				break
+			}
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mu.asOf
+}
+
+func (c *fakeProtectedTimestampCache) Refresh(_ context.Context, asOf hlc.Timestamp) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mu.asOf.Forward(asOf)
+	return nil
+}
+
+// TestGCQueueProtectedTimestamp verifies that the GC queue does not advance
+// the GC threshold to or past a protected timestamp, and that it refreshes the
+// protected timestamp cache up to the new threshold before processing.
+func TestGCQueueProtectedTimestamp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	manual := hlc.NewManualClock(123)
+	tsc := TestStoreConfig(hlc.NewClock(manual.UnixNano, time.Nanosecond))
+	manual.Set(3 * 24 * time.Hour.Nanoseconds())
+	protected := hlc.Timestamp{WallTime: manual.UnixNano() - 48*time.Hour.Nanoseconds()}
+	cache := &fakeProtectedTimestampCache{
+		records: []ptpb.Record{{
+			ID:        uuid.MakeV4(),
+			Timestamp: protected,
+			Spans:     []roachpb.Span{{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")}},
+		}},
+	}
+	tsc.ProtectedTimestampCache = cache
+	tc := testContext{manualClock: manual}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc.StartWithStoreConfig(t, stopper, tsc)
+
+	cfg := tc.gossip.GetSystemConfig()
+	if cfg == nil {
+		t.Fatal("config not set")
+	}
+	gcQ := newGCQueue(tc.store, tc.gossip)
+	if err := gcQ.process(ctx, tc.repl, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if threshold := tc.repl.GetGCThreshold(); threshold != protected.Prev() {
+		t.Fatalf("expected GC threshold %s; got %s", protected.Prev(), threshold)
+	}
+	_, zone := tc.repl.DescAndZone()
+	ttl := time.Duration(zone.GC.TTLSeconds) * time.Second
+	cache.mu.Lock()
+	asOf := cache.mu.asOf
+	cache.mu.Unlock()
+	if expected := (hlc.Timestamp{WallTime: manual.UnixNano() - ttl.Nanoseconds()}); asOf.Less(expected) {
+		t.Fatalf("expected cache to be refreshed to at least %s; got %s", expected, asOf)
+	}
+}
+
 func TestGCQueueTransactionTable(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	ctx := context.Background()
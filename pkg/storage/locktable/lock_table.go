@@ -0,0 +1,588 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+/*
+Package locktable provides an in-memory table of the locks held by
+transactions on the keys of a range, along with wait-queues of the requests
+blocked on those locks.
+
+Before the lock table, a request that ran into a conflicting intent during
+evaluation immediately pushed the transaction that wrote it and then retried.
+Every blocked request pushed independently, so a popular key produced a
+thundering herd of pushes and requests were unblocked in an arbitrary order.
+
+With the lock table, a request consults the table after acquiring latches and
+before evaluating. If one of the keys it accesses is locked by another
+transaction, the request releases its latches and waits in a FIFO queue on the
+lock. When the lock is released, the first waiting writer is granted a
+reservation on the key, which it holds until it acquires the lock itself or
+finishes, and all waiting readers are let through. Waiters only push the lock
+holder once they have waited for a while, which is required to detect
+deadlocks between transactions.
+
+The table tracks two kinds of locks:
+
+  - Replicated locks are write intents. They are durable, so the table does
+    not need to know about all of them. Instead, it learns of them lazily
+    when a request runs into one during evaluation. Once a lock is tracked,
+    it is updated as the holding transaction rewrites it and removed when
+    the intent is resolved.
+  - Unreplicated locks only exist in the table. They are acquired by
+    locking reads (e.g. those performed by SELECT FOR UPDATE) and block
+    other writers and locking readers, but not non-locking readers.
+
+Unreplicated locks are best-effort. They are dropped, without notifying their
+holders, whenever the table is cleared: when the range lease is transferred or
+otherwise changes hands, and on both sides of a split or merge. A transaction
+may therefore lose an unreplicated lock before it commits, in which case a
+conflicting write can slip in between its locking read and its own write. This
+does not affect correctness, as the transaction's write is then pushed above
+the conflicting one and its read fails to refresh, but the transaction loses
+the protection from retries that the lock was meant to provide.
+
+The table is only meaningful on the leaseholder, which evaluates all requests
+for the range.
+*/
+package locktable
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/google/btree"
+)
+
+// Durability is the durability of a lock.
+type Durability int
+
+const (
+	// Replicated locks are write intents, which are stored in the replicated
+	// keyspace and survive lease transfers and restarts.
+	Replicated Durability = iota
+	// Unreplicated locks are only held in the leaseholder's lock table.
+	Unreplicated
+)
+
+func (d Durability) String() string {
+	switch d {
+	case Replicated:
+		return "replicated"
+	case Unreplicated:
+		return "unreplicated"
+	default:
+		return fmt.Sprintf("Durability(%d)", d)
+	}
+}
+
+// Request describes the keys a request accesses for the purpose of scanning
+// the lock table.
+type Request struct {
+	// Txn is the transaction performing the request, or nil if the request is
+	// not transactional. A transaction never conflicts with its own locks.
+	Txn *enginepb.TxnMeta
+	// Timestamp is the timestamp below which the request observes writes.
+	// Non-locking reads only conflict with replicated locks at or below this
+	// timestamp.
+	Timestamp hlc.Timestamp
+	// ReadSpans are the spans read by the request without locking.
+	ReadSpans []roachpb.Span
+	// LockSpans are the spans written or locked by the request.
+	LockSpans []roachpb.Span
+}
+
+// WaitState describes a lock that a request is waiting on.
+type WaitState struct {
+	// Key is the locked key.
+	Key roachpb.Key
+	// Holder is the transaction holding the lock. It is nil if the lock is not
+	// held but reserved by another request ahead in the queue.
+	Holder *enginepb.TxnMeta
+	// Durability is the durability of the lock, if it is held.
+	Durability Durability
+	// Locking is set if the request waits to write or lock the key, as opposed
+	// to waiting to read it.
+	Locking bool
+}
+
+// Guard tracks a request's position in the wait-queues of the lock table. It
+// is returned by Table.ScanAndEnqueue and must be passed back to subsequent
+// scans performed by the same request and eventually to Table.Dequeue.
+//
+// All fields apart from the signal channel are protected by the mutex of the
+// Table.
+type Guard struct {
+	req Request
+	// signal is notified whenever the state of the lock that the request is
+	// waiting on changes.
+	signal chan struct{}
+	// waitingOn is the lock whose queue the request is in, if any. A request
+	// waits in at most one queue at a time.
+	waitingOn *lockState
+	// waitingToLock is set if the request waits on waitingOn as a writer or
+	// locking reader.
+	waitingToLock bool
+	// reserved are the locks which the request holds reservations for.
+	reserved []*lockState
+}
+
+func (g *Guard) notify() {
+	select {
+	case g.signal <- struct{}{}:
+	default:
+	}
+}
+
+// NewStateChan returns a channel that receives a notification whenever the
+// lock that the request is waiting on is updated, released or reserved. The
+// request should then release its latches and scan the lock table again.
+func (g *Guard) NewStateChan() <-chan struct{} {
+	return g.signal
+}
+
+func (g *Guard) isTxn(txn *enginepb.TxnMeta) bool {
+	return g.req.Txn != nil && txn != nil && g.req.Txn.ID == txn.ID
+}
+
+// lockState is the state of a single locked or reserved key.
+type lockState struct {
+	key roachpb.Key
+
+	// holder is the transaction holding the lock, or nil if the lock is not
+	// held.
+	holder     *enginepb.TxnMeta
+	durability Durability
+
+	// reservation is the request that was granted the lock after it was
+	// released and which has not yet acquired it. A key is never both held
+	// and reserved.
+	reservation *Guard
+
+	// queue contains the requests waiting on the lock in the order in which
+	// they arrived.
+	queue []*Guard
+}
+
+var _ btree.Item = &lockState{}
+
+// Less implements the btree.Item interface.
+func (l *lockState) Less(than btree.Item) bool {
+	return l.key.Compare(than.(*lockState).key) < 0
+}
+
+// conflicts returns whether the lock prevents the request from proceeding.
+func (l *lockState) conflicts(g *Guard, locking bool) bool {
+	if l.holder != nil {
+		if g.isTxn(l.holder) {
+			return false
+		}
+		if !locking {
+			// Non-locking reads ignore unreplicated locks and intents above
+			// their timestamp.
+			return l.durability == Replicated && !g.req.Timestamp.Less(l.holder.Timestamp)
+		}
+		return true
+	}
+	if !locking || l.reservation == nil || l.reservation == g {
+		return false
+	}
+	return !g.isTxn(l.reservation.req.Txn)
+}
+
+func (l *lockState) waitState(locking bool) WaitState {
+	ws := WaitState{Key: l.key, Durability: l.durability, Locking: locking}
+	if l.holder != nil {
+		// Copy the holder, which is updated under the table's mutex.
+		holder := *l.holder
+		ws.Holder = &holder
+	}
+	return ws
+}
+
+// Table is the lock table of a range. Table's zero value can be used
+// directly. It is safe for concurrent use by multiple goroutines.
+type Table struct {
+	// numLocks is accessed atomically and allows requests to skip the mutex
+	// when the table is empty, which is the common case.
+	numLocks int64
+
+	mu struct {
+		syncutil.Mutex
+		locks *btree.BTree
+	}
+}
+
+// Len returns the number of keys which are locked or reserved.
+func (t *Table) Len() int {
+	return int(atomic.LoadInt64(&t.numLocks))
+}
+
+func (t *Table) getLocked(key roachpb.Key) *lockState {
+	if t.mu.locks == nil {
+		return nil
+	}
+	if item := t.mu.locks.Get(&lockState{key: key}); item != nil {
+		return item.(*lockState)
+	}
+	return nil
+}
+
+func (t *Table) getOrCreateLocked(key roachpb.Key) *lockState {
+	if l := t.getLocked(key); l != nil {
+		return l
+	}
+	if t.mu.locks == nil {
+		t.mu.locks = btree.New(16 /* degree */)
+	}
+	l := &lockState{key: append(roachpb.Key(nil), key...)}
+	t.mu.locks.ReplaceOrInsert(l)
+	atomic.AddInt64(&t.numLocks, 1)
+	return l
+}
+
+// maybeRemoveLocked removes the lock from the table if it is no longer held,
+// reserved or waited on.
+func (t *Table) maybeRemoveLocked(l *lockState) {
+	if l.holder == nil && l.reservation == nil && len(l.queue) == 0 {
+		t.mu.locks.Delete(l)
+		atomic.AddInt64(&t.numLocks, -1)
+	}
+}
+
+// forEachLocked invokes fn on each lock overlapping the span until fn returns
+// false. fn must not add locks to or remove locks from the table.
+func (t *Table) forEachLocked(span roachpb.Span, fn func(*lockState) bool) {
+	if t.mu.locks == nil {
+		return
+	}
+	if len(span.EndKey) == 0 {
+		if l := t.getLocked(span.Key); l != nil {
+			fn(l)
+		}
+		return
+	}
+	t.mu.locks.AscendRange(&lockState{key: span.Key}, &lockState{key: span.EndKey},
+		func(item btree.Item) bool {
+			return fn(item.(*lockState))
+		})
+}
+
+// locksInSpanLocked returns the locks overlapping the span.
+func (t *Table) locksInSpanLocked(span roachpb.Span) []*lockState {
+	var locks []*lockState
+	t.forEachLocked(span, func(l *lockState) bool {
+		locks = append(locks, l)
+		return true
+	})
+	return locks
+}
+
+// findConflictLocked returns the first lock in the spans which conflicts with
+// the request, or nil if there is none.
+func (t *Table) findConflictLocked(g *Guard, spans []roachpb.Span, locking bool) *lockState {
+	var conflict *lockState
+	for _, span := range spans {
+		t.forEachLocked(span, func(l *lockState) bool {
+			if l.conflicts(g, locking) {
+				conflict = l
+			}
+			return conflict == nil
+		})
+		if conflict != nil {
+			break
+		}
+	}
+	return conflict
+}
+
+// ScanAndEnqueue scans the lock table for locks that conflict with the
+// request. It must be called while holding the request's latches.
+//
+// The first time a request scans the table it passes a nil Guard. The
+// returned Guard must be passed to subsequent scans by the same request and
+// to Dequeue once the request has finished. A nil Guard is returned if the
+// table was empty.
+//
+// If a conflicting lock is found, the request is added to the lock's
+// wait-queue (if it is not already part of it) and true is returned along
+// with a description of the lock. The request must then release its latches,
+// wait for a notification on the Guard's NewStateChan, possibly pushing the
+// lock holder in the meantime, and scan again. Any reservations that the
+// request held are given up so that requests waiting on them are not blocked
+// by a request which is itself waiting.
+//
+// If no conflicting lock is found, the request is removed from any queue it
+// was waiting in and reserves all unheld locks that it accesses as a writer,
+// so that the requests queued on them keep waiting until it acquires the
+// locks or finishes.
+func (t *Table) ScanAndEnqueue(req Request, g *Guard) (*Guard, WaitState, bool) {
+	if g == nil && t.Len() == 0 {
+		return nil, WaitState{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if g == nil {
+		g = &Guard{signal: make(chan struct{}, 1)}
+	}
+	g.req = req
+
+	conflict, locking := t.findConflictLocked(g, req.LockSpans, true /* locking */), true
+	if conflict == nil {
+		conflict, locking = t.findConflictLocked(g, req.ReadSpans, false /* locking */), false
+	}
+
+	if conflict != nil {
+		t.releaseReservationsLocked(g)
+		if g.waitingOn != conflict {
+			t.removeFromQueueLocked(g)
+			conflict.queue = append(conflict.queue, g)
+			g.waitingOn = conflict
+		}
+		g.waitingToLock = locking
+		return g, conflict.waitState(locking), true
+	}
+
+	t.removeFromQueueLocked(g)
+	for _, span := range req.LockSpans {
+		for _, l := range t.locksInSpanLocked(span) {
+			if l.holder == nil && l.reservation == nil {
+				l.reservation = g
+				g.reserved = append(g.reserved, l)
+			}
+		}
+	}
+	return g, WaitState{}, false
+}
+
+// Dequeue removes the request from the lock table once it has finished. Any
+// reservations still held by the request are passed on to the next waiter.
+func (t *Table) Dequeue(g *Guard) {
+	if g == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.removeFromQueueLocked(g)
+	t.releaseReservationsLocked(g)
+}
+
+func (t *Table) removeFromQueueLocked(g *Guard) {
+	l := g.waitingOn
+	if l == nil {
+		return
+	}
+	for i, qg := range l.queue {
+		if qg == g {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			break
+		}
+	}
+	g.waitingOn = nil
+	t.maybeRemoveLocked(l)
+}
+
+func (t *Table) releaseReservationsLocked(g *Guard) {
+	for _, l := range g.reserved {
+		if l.reservation == g {
+			l.reservation = nil
+			t.grantLocked(l)
+		}
+	}
+	g.reserved = nil
+}
+
+// grantLocked is called when a lock is neither held nor reserved anymore. It
+// lets all waiting readers proceed, reserves the lock for the first waiting
+// writer and notifies the remaining writers, which are now waiting on the
+// reservation. The lock is removed if nobody is waiting on it.
+func (t *Table) grantLocked(l *lockState) {
+	queue := l.queue
+	l.queue = l.queue[:0]
+	for _, g := range queue {
+		if !g.waitingToLock {
+			g.waitingOn = nil
+		} else if l.reservation == nil {
+			g.waitingOn = nil
+			l.reservation = g
+			g.reserved = append(g.reserved, l)
+		} else {
+			l.queue = append(l.queue, g)
+		}
+		g.notify()
+	}
+	t.maybeRemoveLocked(l)
+}
+
+// releaseLocked releases a held lock.
+func (t *Table) releaseLocked(l *lockState) {
+	l.holder = nil
+	t.grantLocked(l)
+}
+
+// setHolderLocked makes the transaction the holder of the lock, taking over
+// any reservation, and notifies the waiters so that they learn about the new
+// holder.
+func (t *Table) setHolderLocked(l *lockState, txn *enginepb.TxnMeta, dur Durability) {
+	if l.reservation != nil {
+		res := l.reservation
+		for i, rl := range res.reserved {
+			if rl == l {
+				res.reserved = append(res.reserved[:i], res.reserved[i+1:]...)
+				break
+			}
+		}
+		l.reservation = nil
+	}
+	holder := *txn
+	l.holder = &holder
+	l.durability = dur
+	for _, g := range l.queue {
+		g.notify()
+	}
+}
+
+// AcquireLock records that the transaction acquired a lock on the key. It must
+// be called while holding the latches of the request that acquired the lock.
+//
+// Unreplicated locks are always added to the table. Replicated locks (i.e.
+// intents) are only recorded if the key is already tracked by the table,
+// because the table learns about the remaining intents lazily when requests
+// run into them.
+func (t *Table) AcquireLock(txn *enginepb.TxnMeta, key roachpb.Key, dur Durability) {
+	if dur == Replicated && t.Len() == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var l *lockState
+	if dur == Replicated {
+		if l = t.getLocked(key); l == nil {
+			return
+		}
+	} else {
+		l = t.getOrCreateLocked(key)
+	}
+	if l.holder != nil && l.holder.ID == txn.ID {
+		// The transaction already holds the lock. Upgrade its durability and
+		// update its timestamp.
+		if dur == Replicated {
+			l.durability = Replicated
+		}
+		if l.holder.Epoch < txn.Epoch {
+			l.holder.Epoch = txn.Epoch
+		}
+		l.holder.Timestamp.Forward(txn.Timestamp)
+		return
+	}
+	t.setHolderLocked(l, txn, dur)
+}
+
+// AddDiscoveredLock adds an intent that a request ran into during evaluation
+// to the table. The request is expected to scan the table again, at which
+// point it will wait on the intent's lock.
+func (t *Table) AddDiscoveredLock(intent roachpb.Intent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l := t.getOrCreateLocked(intent.Key)
+	if l.holder != nil && l.holder.ID == intent.Txn.ID {
+		l.durability = Replicated
+		l.holder.Timestamp.Forward(intent.Txn.Timestamp)
+		return
+	}
+	t.setHolderLocked(l, &intent.Txn, Replicated)
+}
+
+// UpdateLocks updates the locks held by the intent's transaction in the
+// intent's span to reflect that the transaction was committed, aborted or
+// pushed. Locks of finalized transactions are released, as are unreplicated
+// locks acquired in a previous epoch. The remaining locks are updated to the
+// transaction's new timestamp, which may allow waiting readers to proceed.
+func (t *Table) UpdateLocks(intent roachpb.Intent) {
+	if t.Len() == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, l := range t.locksInSpanLocked(intent.Span) {
+		if l.holder == nil || l.holder.ID != intent.Txn.ID {
+			continue
+		}
+		if intent.Status.IsFinalized() ||
+			(l.durability == Unreplicated && l.holder.Epoch < intent.Txn.Epoch) {
+			t.releaseLocked(l)
+			continue
+		}
+		if l.holder.Timestamp.Less(intent.Txn.Timestamp) {
+			l.holder.Timestamp = intent.Txn.Timestamp
+			for _, g := range l.queue {
+				g.notify()
+			}
+		}
+	}
+}
+
+// Clear removes all locks from the table and notifies all waiting requests.
+// It is called when the table can no longer be trusted to reflect the locks
+// in the range, for instance because the lease changed hands or the range
+// was split or merged. Unreplicated locks are dropped and not recreated
+// anywhere.
+func (t *Table) Clear() {
+	if t.Len() == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.mu.locks.Ascend(func(item btree.Item) bool {
+		l := item.(*lockState)
+		for _, g := range l.queue {
+			g.waitingOn = nil
+			g.notify()
+		}
+		if res := l.reservation; res != nil {
+			res.reserved = nil
+		}
+		return true
+	})
+	t.mu.locks.Clear(false /* addNodesToFreelist */)
+	atomic.StoreInt64(&t.numLocks, 0)
+}
+
+// String returns a human-readable representation of the table, listing every
+// tracked key along with its holder, reservation and waiters.
+func (t *Table) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.mu.locks == nil || t.mu.locks.Len() == 0 {
+		return "empty"
+	}
+	var buf []byte
+	t.mu.locks.Ascend(func(item btree.Item) bool {
+		l := item.(*lockState)
+		buf = append(buf, l.key.String()...)
+		if l.holder != nil {
+			buf = append(buf, fmt.Sprintf(" held by %s (%s) @ %s",
+				l.holder.ID.Short(), l.durability, l.holder.Timestamp)...)
+		}
+		if l.reservation != nil {
+			buf = append(buf, " reserved"...)
+		}
+		if len(l.queue) > 0 {
+			buf = append(buf, fmt.Sprintf(" waiters: %d", len(l.queue))...)
+		}
+		buf = append(buf, '\n')
+		return true
+	})
+	return string(buf[:len(buf)-1])
+}
@@ -0,0 +1,300 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package locktable
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTxn(ts int64) *enginepb.TxnMeta {
+	return &enginepb.TxnMeta{ID: uuid.MakeV4(), Timestamp: hlc.Timestamp{WallTime: ts}}
+}
+
+func pointSpan(key string) roachpb.Span {
+	return roachpb.Span{Key: roachpb.Key(key)}
+}
+
+func writeReq(txn *enginepb.TxnMeta, keys ...string) Request {
+	req := Request{Txn: txn}
+	if txn != nil {
+		req.Timestamp = txn.Timestamp
+	}
+	for _, k := range keys {
+		req.LockSpans = append(req.LockSpans, pointSpan(k))
+	}
+	return req
+}
+
+func readReq(txn *enginepb.TxnMeta, ts int64, span roachpb.Span) Request {
+	return Request{Txn: txn, Timestamp: hlc.Timestamp{WallTime: ts}, ReadSpans: []roachpb.Span{span}}
+}
+
+func intent(txn *enginepb.TxnMeta, key string, status roachpb.TransactionStatus) roachpb.Intent {
+	return roachpb.Intent{Span: pointSpan(key), Txn: *txn, Status: status}
+}
+
+// requireNoWait scans the table and asserts that the request does not wait.
+func requireNoWait(t *testing.T, lt *Table, req Request, g *Guard) *Guard {
+	t.Helper()
+	g, _, wait := lt.ScanAndEnqueue(req, g)
+	require.False(t, wait, "unexpected wait:\n%s", lt)
+	return g
+}
+
+// requireWait scans the table and asserts that the request waits on the key.
+func requireWait(
+	t *testing.T, lt *Table, req Request, g *Guard, key string, holder *enginepb.TxnMeta,
+) *Guard {
+	t.Helper()
+	g, ws, wait := lt.ScanAndEnqueue(req, g)
+	require.True(t, wait, "expected wait on %s:\n%s", key, lt)
+	require.Equal(t, roachpb.Key(key), ws.Key)
+	if holder == nil {
+		require.Nil(t, ws.Holder)
+	} else {
+		require.NotNil(t, ws.Holder)
+		require.Equal(t, holder.ID, ws.Holder.ID)
+	}
+	return g
+}
+
+func requireNotified(t *testing.T, g *Guard) {
+	t.Helper()
+	select {
+	case <-g.NewStateChan():
+	default:
+		t.Fatal("expected notification")
+	}
+}
+
+func requireNotNotified(t *testing.T, g *Guard) {
+	t.Helper()
+	select {
+	case <-g.NewStateChan():
+		t.Fatal("unexpected notification")
+	default:
+	}
+}
+
+func TestLockTableEmpty(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var lt Table
+
+	g, _, wait := lt.ScanAndEnqueue(writeReq(makeTxn(10), "a"), nil)
+	require.Nil(t, g)
+	require.False(t, wait)
+	lt.Dequeue(g)
+
+	// Replicated locks are not tracked unless they have been discovered.
+	lt.AcquireLock(makeTxn(10), roachpb.Key("a"), Replicated)
+	require.Equal(t, 0, lt.Len())
+	require.Equal(t, "empty", lt.String())
+}
+
+func TestLockTableConflicts(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var lt Table
+	holder := makeTxn(10)
+	lt.AddDiscoveredLock(intent(holder, "b", roachpb.PENDING))
+	require.Equal(t, 1, lt.Len())
+
+	// The holder does not conflict with its own lock.
+	lt.Dequeue(requireNoWait(t, &lt, writeReq(holder, "b"), nil))
+
+	// Writers to other keys don't conflict.
+	lt.Dequeue(requireNoWait(t, &lt, writeReq(makeTxn(20), "a", "c"), nil))
+
+	// Reads below the intent don't conflict, reads at or above it do.
+	span := roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("c")}
+	lt.Dequeue(requireNoWait(t, &lt, readReq(makeTxn(5), 5, span), nil))
+	g := requireWait(t, &lt, readReq(nil /* txn */, 10, span), nil, "b", holder)
+	lt.Dequeue(g)
+
+	// Non-transactional writers conflict.
+	g = requireWait(t, &lt, writeReq(nil /* txn */, "b"), nil, "b", holder)
+	lt.Dequeue(g)
+
+	// Once nobody waits anymore, the lock is still tracked until released.
+	require.Equal(t, 1, lt.Len())
+	lt.UpdateLocks(intent(holder, "b", roachpb.COMMITTED))
+	require.Equal(t, 0, lt.Len())
+}
+
+func TestLockTableQueueing(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var lt Table
+	holder := makeTxn(10)
+	lt.AddDiscoveredLock(intent(holder, "a", roachpb.PENDING))
+
+	txn1, txn2 := makeTxn(20), makeTxn(20)
+	req1, req2 := writeReq(txn1, "a"), writeReq(txn2, "a")
+	reqR := readReq(makeTxn(30), 30, pointSpan("a"))
+	g1 := requireWait(t, &lt, req1, nil, "a", holder)
+	g2 := requireWait(t, &lt, req2, nil, "a", holder)
+	gR := requireWait(t, &lt, reqR, nil, "a", holder)
+
+	// Scanning again does not lose the position in the queue.
+	g2 = requireWait(t, &lt, req2, g2, "a", holder)
+
+	// When the lock is released, the reader is let through and the first
+	// writer is granted a reservation. The second writer now waits on the
+	// reservation.
+	lt.UpdateLocks(intent(holder, "a", roachpb.ABORTED))
+	requireNotified(t, g1)
+	requireNotified(t, g2)
+	requireNotified(t, gR)
+	gR = requireNoWait(t, &lt, reqR, gR)
+	lt.Dequeue(gR)
+	g1 = requireNoWait(t, &lt, req1, g1)
+	g2 = requireWait(t, &lt, req2, g2, "a", nil /* holder */)
+
+	// The first writer writes its intent, turning the reservation into a lock.
+	lt.AcquireLock(txn1, roachpb.Key("a"), Replicated)
+	requireNotified(t, g2)
+	lt.Dequeue(g1)
+	g2 = requireWait(t, &lt, req2, g2, "a", txn1)
+	requireNotNotified(t, g2)
+
+	// When the first writer's transaction commits, the second writer gets the
+	// reservation. If it finishes without acquiring the lock, the key is no
+	// longer tracked.
+	lt.UpdateLocks(roachpb.Intent{
+		Span:   roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")},
+		Txn:    *txn1,
+		Status: roachpb.COMMITTED,
+	})
+	requireNotified(t, g2)
+	g2 = requireNoWait(t, &lt, req2, g2)
+	require.Equal(t, 1, lt.Len())
+	lt.Dequeue(g2)
+	require.Equal(t, 0, lt.Len())
+}
+
+func TestLockTableWaitingReleasesReservations(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var lt Table
+	holderA, holderB := makeTxn(10), makeTxn(10)
+	lt.AddDiscoveredLock(intent(holderA, "a", roachpb.PENDING))
+	lt.AddDiscoveredLock(intent(holderB, "b", roachpb.PENDING))
+
+	txn1, txn2 := makeTxn(20), makeTxn(20)
+	req1, req2 := writeReq(txn1, "a", "b"), writeReq(txn2, "a")
+	g1 := requireWait(t, &lt, req1, nil, "a", holderA)
+	g2 := requireWait(t, &lt, req2, nil, "a", holderA)
+
+	// The first writer is granted the reservation on "a" but then has to wait
+	// on "b". It gives up its reservation on "a", which passes to the second
+	// writer.
+	lt.UpdateLocks(intent(holderA, "a", roachpb.COMMITTED))
+	requireNotified(t, g1)
+	requireNotified(t, g2)
+	g1 = requireWait(t, &lt, req1, g1, "b", holderB)
+	requireNotified(t, g2)
+	g2 = requireNoWait(t, &lt, req2, g2)
+	lt.Dequeue(g2)
+	lt.Dequeue(g1)
+	require.Equal(t, 1, lt.Len())
+}
+
+func TestLockTableUnreplicatedLocks(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var lt Table
+	holder := makeTxn(10)
+	lt.AcquireLock(holder, roachpb.Key("a"), Unreplicated)
+	require.Equal(t, 1, lt.Len())
+
+	// Non-locking reads ignore unreplicated locks, writers and locking reads
+	// don't.
+	lt.Dequeue(requireNoWait(t, &lt, readReq(makeTxn(20), 20, pointSpan("a")), nil))
+	lockingRead := Request{
+		Txn:       makeTxn(20),
+		Timestamp: hlc.Timestamp{WallTime: 20},
+		LockSpans: []roachpb.Span{{Key: roachpb.Key("a"), EndKey: roachpb.Key("z")}},
+	}
+	g := requireWait(t, &lt, lockingRead, nil, "a", holder)
+
+	// Pushing the holder's timestamp does not release the lock.
+	pushed := *holder
+	pushed.Timestamp = hlc.Timestamp{WallTime: 30}
+	lt.UpdateLocks(intent(&pushed, "a", roachpb.PENDING))
+	requireNotified(t, g)
+	g = requireWait(t, &lt, lockingRead, g, "a", holder)
+
+	// Writing an intent upgrades the lock to a replicated one.
+	lt.AcquireLock(holder, roachpb.Key("a"), Replicated)
+	g2 := requireWait(t, &lt, readReq(makeTxn(40), 40, pointSpan("a")), nil, "a", holder)
+	lt.Dequeue(g2)
+
+	// A new epoch releases the unreplicated locks of previous epochs, but not
+	// the intents.
+	lt.AcquireLock(holder, roachpb.Key("b"), Unreplicated)
+	restarted := *holder
+	restarted.Epoch++
+	lt.UpdateLocks(roachpb.Intent{
+		Span: roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("c")},
+		Txn:  restarted,
+	})
+	require.Equal(t, 1, lt.Len())
+	requireNotNotified(t, g)
+	lt.UpdateLocks(roachpb.Intent{
+		Span:   roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("c")},
+		Txn:    restarted,
+		Status: roachpb.ABORTED,
+	})
+	requireNotified(t, g)
+	g = requireNoWait(t, &lt, lockingRead, g)
+	lt.Dequeue(g)
+	require.Equal(t, 0, lt.Len())
+}
+
+func TestLockTablePushedReaders(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var lt Table
+	holder := makeTxn(10)
+	lt.AddDiscoveredLock(intent(holder, "a", roachpb.PENDING))
+
+	req := readReq(makeTxn(20), 20, pointSpan("a"))
+	g := requireWait(t, &lt, req, nil, "a", holder)
+
+	// Once the holder is pushed above the reader, the reader proceeds.
+	pushed := *holder
+	pushed.Timestamp = hlc.Timestamp{WallTime: 21}
+	lt.UpdateLocks(intent(&pushed, "a", roachpb.PENDING))
+	requireNotified(t, g)
+	g = requireNoWait(t, &lt, req, g)
+	lt.Dequeue(g)
+	require.Equal(t, 1, lt.Len())
+}
+
+func TestLockTableClear(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var lt Table
+	holder := makeTxn(10)
+	lt.AddDiscoveredLock(intent(holder, "a", roachpb.PENDING))
+	lt.AcquireLock(holder, roachpb.Key("b"), Unreplicated)
+	req := writeReq(makeTxn(20), "a", "b")
+	g := requireWait(t, &lt, req, nil, "a", holder)
+
+	lt.Clear()
+	require.Equal(t, 0, lt.Len())
+	requireNotified(t, g)
+	g = requireNoWait(t, &lt, req, g)
+	lt.Dequeue(g)
+}
@@ -158,7 +158,7 @@ func (s *Store) logChange(
 	var logType storagepb.RangeLogEventType
 	var info storagepb.RangeLogEvent_Info
 	switch changeType {
-	case roachpb.ADD_REPLICA:
+	case roachpb.ADD_REPLICA, roachpb.ADD_NON_VOTER, roachpb.PROMOTE_NON_VOTER:
 		logType = storagepb.RangeLogEventType_add
 		info = storagepb.RangeLogEvent_Info{
 			AddedReplica: &replica,
@@ -29,6 +29,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -42,6 +43,11 @@ const (
 	// onto the left-hand range, even when the ranges are collocated. This is
 	// expensive, so limit to one merge at a time.
 	mergeQueueConcurrency = 1
+
+	// coldRangeLoadFraction is the fraction of the load based split thresholds
+	// that a range's recent average load must stay below for the range to be
+	// considered cold.
+	coldRangeLoadFraction = 0.1
 )
 
 // MergeQueueInterval is a setting that controls how often the merge queue waits
@@ -56,14 +62,33 @@ var MergeQueueInterval = func() *settings.DurationSetting {
 	return s
 }()
 
+// ColdRangeMergeSizeFraction is a setting that allows ranges above the minimum
+// size threshold to be merged if they have been cold recently. This cleans up
+// after load based splitting, which can leave tables that were once hot (for
+// instance during a backfill) split into many small ranges.
+var ColdRangeMergeSizeFraction = settings.RegisterValidatedFloatSetting(
+	"kv.range_merge.cold_range_size_fraction",
+	"fraction of the max range size below which adjacent ranges that have seen little recent load are merged "+
+		"even if they are above the min range size; 0 disables such merges",
+	0.4,
+	func(v float64) error {
+		if v < 0 || v >= 0.5 {
+			return errors.Errorf("cannot set to a value outside of [0, 0.5): %f", v)
+		}
+		return nil
+	},
+)
+
 // mergeQueue manages a queue of ranges slated to be merged with their right-
 // hand neighbor.
 //
-// A range will only be queued if it is beneath the minimum size threshold. Once
-// queued, the size of the right-hand neighbor will additionally be checked;
-// merges can only proceed if a) the right-hand neighbor is beneath the minimum
-// size threshold, and b) the merged range would not need to be immediately
-// split, e.g. because the new range would exceed the maximum size threshold.
+// A range will only be queued if it is beneath the minimum size threshold, or
+// if it is cold (see ColdRangeMergeSizeFraction). Once queued, the size of the
+// right-hand neighbor will additionally be checked; merges can only proceed if
+// a) the right-hand neighbor is beneath the minimum size threshold, or both
+// ranges are cold, and b) the merged range would not need to be immediately
+// split, e.g. because the new range would exceed the maximum size threshold or
+// the combined load would exceed a load based splitting threshold.
 //
 // Note that the merge queue is not capable of initiating all possible merges.
 // Consider the example below:
@@ -167,11 +192,16 @@ func (mq *mergeQueue) shouldQueue(
 	}
 
 	sizeRatio := float64(repl.GetMVCCStats().Total()) / float64(repl.GetMinBytes())
-	if math.IsNaN(sizeRatio) || sizeRatio >= 1 {
-		// This range is above the minimum size threshold. It does not need to be
-		// merged.
+	if math.IsNaN(sizeRatio) {
 		return false, 0
 	}
+	if sizeRatio >= 1 {
+		// This range is above the minimum size threshold. It only needs to be
+		// merged if it's cold, in which case it goes behind all the ranges that
+		// are below the threshold.
+		qps, writeBytes := repl.GetRecentLoad()
+		return mq.isColdMergeCandidate(repl, repl.GetMVCCStats(), qps, writeBytes), 0
+	}
 
 	// Invert sizeRatio to compute the priority so that smaller ranges are merged
 	// before larger ranges.
@@ -187,25 +217,43 @@ func (rangeMergePurgatoryError) purgatoryErrorMarker() {}
 
 var _ purgatoryError = rangeMergePurgatoryError{}
 
+// isColdMergeCandidate returns whether a range of the given size and recent
+// load is both small enough and cold enough to be merged even though it is
+// above the minimum size threshold. The size limit guarantees that merging two
+// such ranges doesn't produce a range that needs to be split by size.
+func (mq *mergeQueue) isColdMergeCandidate(
+	repl *Replica, stats enginepb.MVCCStats, recentQPS, recentWriteBytes float64,
+) bool {
+	sv := &mq.store.ClusterSettings().SV
+	fraction := ColdRangeMergeSizeFraction.Get(sv)
+	if fraction <= 0 {
+		return false
+	}
+	if float64(stats.Total()) >= fraction*float64(repl.GetMaxBytes()) {
+		return false
+	}
+	return recentQPS < coldRangeLoadFraction*float64(SplitByLoadQPSThreshold.Get(sv)) &&
+		recentWriteBytes < coldRangeLoadFraction*float64(SplitByLoadWriteBytesThreshold.Get(sv))
+}
+
 func (mq *mergeQueue) requestRangeStats(
 	ctx context.Context, key roachpb.Key,
-) (roachpb.RangeDescriptor, enginepb.MVCCStats, float64, error) {
+) (roachpb.RangeDescriptor, *roachpb.RangeStatsResponse, error) {
 	res, pErr := client.SendWrappedWith(ctx, mq.db.NonTransactionalSender(), roachpb.Header{
 		ReturnRangeInfo: true,
 	}, &roachpb.RangeStatsRequest{
 		RequestHeader: roachpb.RequestHeader{Key: key},
 	})
 	if pErr != nil {
-		return roachpb.RangeDescriptor{}, enginepb.MVCCStats{}, 0, pErr.GoError()
+		return roachpb.RangeDescriptor{}, nil, pErr.GoError()
 	}
 	rangeInfos := res.Header().RangeInfos
 	if len(rangeInfos) != 1 {
-		return roachpb.RangeDescriptor{}, enginepb.MVCCStats{}, 0, fmt.Errorf(
+		return roachpb.RangeDescriptor{}, nil, fmt.Errorf(
 			"mergeQueue.requestRangeStats: response had %d range infos but exactly one was expected",
 			len(rangeInfos))
 	}
-	return rangeInfos[0].Desc, res.(*roachpb.RangeStatsResponse).MVCCStats,
-		res.(*roachpb.RangeStatsResponse).QueriesPerSecond, nil
+	return rangeInfos[0].Desc, res.(*roachpb.RangeStatsResponse), nil
 }
 
 func (mq *mergeQueue) process(
@@ -224,21 +272,40 @@ func (mq *mergeQueue) process(
 
 	lhsStats := lhsRepl.GetMVCCStats()
 	minBytes := lhsRepl.GetMinBytes()
-	if lhsStats.Total() >= minBytes {
+	lhsRecentQPS, lhsRecentWriteBytes := lhsRepl.GetRecentLoad()
+	lhsCold := mq.isColdMergeCandidate(lhsRepl, lhsStats, lhsRecentQPS, lhsRecentWriteBytes)
+	if lhsStats.Total() >= minBytes && !lhsCold {
 		log.VEventf(ctx, 2, "skipping merge: LHS meets minimum size threshold %d with %d bytes",
 			minBytes, lhsStats.Total())
 		return nil
 	}
 
 	lhsQPS := lhsRepl.GetSplitQPS()
-	rhsDesc, rhsStats, rhsQPS, err := mq.requestRangeStats(ctx, lhsDesc.EndKey.AsRawKey())
+	lhsWriteBytes, lhsContention := lhsRepl.GetSplitLoad()
+	rhsDesc, rhsRes, err := mq.requestRangeStats(ctx, lhsDesc.EndKey.AsRawKey())
 	if err != nil {
 		return err
 	}
+	rhsStats := rhsRes.MVCCStats
+	rhsQPS := rhsRes.QueriesPerSecond
 	if rhsStats.Total() >= minBytes {
-		log.VEventf(ctx, 2, "skipping merge: RHS meets minimum size threshold %d with %d bytes",
-			minBytes, lhsStats.Total())
-		return nil
+		rhsCold := mq.isColdMergeCandidate(
+			lhsRepl, rhsStats, rhsRes.RecentQueriesPerSecond, rhsRes.RecentWriteBytesPerSecond)
+		if !lhsCold || !rhsCold {
+			log.VEventf(ctx, 2, "skipping merge: RHS meets minimum size threshold %d with %d bytes",
+				minBytes, rhsStats.Total())
+			return nil
+		}
+		log.VEventf(ctx, 2, "merging cold ranges above the minimum size threshold")
+	} else if lhsStats.Total() >= minBytes {
+		// The LHS is cold, but its neighbor has to be cold too.
+		if !mq.isColdMergeCandidate(
+			lhsRepl, rhsStats, rhsRes.RecentQueriesPerSecond, rhsRes.RecentWriteBytesPerSecond,
+		) {
+			log.VEventf(ctx, 2, "skipping merge: LHS meets minimum size threshold %d with %d bytes "+
+				"and RHS has seen recent load", minBytes, lhsStats.Total())
+			return nil
+		}
 	}
 
 	mergedDesc := &roachpb.RangeDescriptor{
@@ -248,16 +315,20 @@ func (mq *mergeQueue) process(
 	mergedStats := lhsStats
 	mergedStats.Add(rhsStats)
 
-	var mergedQPS float64
+	var mergedQPS, mergedWriteBytes, mergedContention float64
 	if lhsRepl.SplitByLoadEnabled() {
 		mergedQPS = lhsQPS + rhsQPS
+		mergedWriteBytes = lhsWriteBytes + rhsRes.WriteBytesPerSecond
+		mergedContention = lhsContention + rhsRes.ContentionPerSecond
 	}
 
 	// Check if the merged range would need to be split, if so, skip merge.
 	// Use a lower threshold for load based splitting so we don't find ourselves
 	// in a situation where we keep merging ranges that would be split soon after
 	// by a small increase in load.
-	loadBasedSplitPossible := lhsRepl.SplitByLoadQPSThreshold() < 2*mergedQPS
+	loadBasedSplitPossible := lhsRepl.SplitByLoadQPSThreshold() < 2*mergedQPS ||
+		lhsRepl.SplitByLoadWriteBytesThreshold() < 2*mergedWriteBytes ||
+		lhsRepl.SplitByLoadContentionThreshold() < 2*mergedContention
 	if ok, _ := shouldSplitRange(mergedDesc, mergedStats, lhsRepl.GetMaxBytes(), sysCfg); ok || loadBasedSplitPossible {
 		log.VEventf(ctx, 2,
 			"skipping merge to avoid thrashing: merged range %s may split "+
@@ -39,6 +39,8 @@ func TestMergeQueueShouldQueue(t *testing.T) {
 
 	mq := newMergeQueue(testCtx.store, testCtx.store.DB(), testCtx.gossip)
 	storagebase.MergeQueueEnabled.Override(&testCtx.store.ClusterSettings().SV, true)
+	// Cold ranges above the minimum size are covered separately below.
+	ColdRangeMergeSizeFraction.Override(&testCtx.store.ClusterSettings().SV, 0)
 
 	tableKey := func(i uint32) []byte {
 		return keys.MakeTablePrefix(keys.MaxReservedDescID + i)
@@ -172,21 +174,57 @@ func TestMergeQueueShouldQueue(t *testing.T) {
 		},
 	}
 
-	for _, tc := range testCases {
-		t.Run("", func(t *testing.T) {
-			repl := &Replica{}
-			repl.mu.state.Desc = &roachpb.RangeDescriptor{StartKey: tc.startKey, EndKey: tc.endKey}
-			repl.mu.state.Stats = &enginepb.MVCCStats{KeyBytes: tc.bytes}
-			zoneConfig := config.DefaultZoneConfigRef()
-			zoneConfig.RangeMinBytes = proto.Int64(tc.minBytes)
-			repl.SetZoneConfig(zoneConfig)
-			shouldQ, priority := mq.shouldQueue(ctx, hlc.Timestamp{}, repl, config.NewSystemConfig(zoneConfig))
-			if tc.expShouldQ != shouldQ {
-				t.Errorf("incorrect shouldQ: expected %v but got %v", tc.expShouldQ, shouldQ)
-			}
-			if tc.expPriority != priority {
-				t.Errorf("incorrect priority: expected %v but got %v", tc.expPriority, priority)
-			}
-		})
+	runTestCases := func(t *testing.T, testCases []testCase) {
+		for _, tc := range testCases {
+			t.Run("", func(t *testing.T) {
+				repl := &Replica{}
+				repl.mu.state.Desc = &roachpb.RangeDescriptor{StartKey: tc.startKey, EndKey: tc.endKey}
+				repl.mu.state.Stats = &enginepb.MVCCStats{KeyBytes: tc.bytes}
+				zoneConfig := config.DefaultZoneConfigRef()
+				zoneConfig.RangeMinBytes = proto.Int64(tc.minBytes)
+				zoneConfig.RangeMaxBytes = proto.Int64(10 * tc.minBytes)
+				repl.SetZoneConfig(zoneConfig)
+				shouldQ, priority := mq.shouldQueue(ctx, hlc.Timestamp{}, repl, config.NewSystemConfig(zoneConfig))
+				if tc.expShouldQ != shouldQ {
+					t.Errorf("incorrect shouldQ: expected %v but got %v", tc.expShouldQ, shouldQ)
+				}
+				if tc.expPriority != priority {
+					t.Errorf("incorrect priority: expected %v but got %v", tc.expPriority, priority)
+				}
+			})
+		}
 	}
+	runTestCases(t, testCases)
+
+	// With cold range merging enabled, an interior range above the minimum
+	// byte threshold that has seen no recent load is mergeable as long as it is
+	// below the configured fraction of the maximum byte threshold (10KiB here).
+	ColdRangeMergeSizeFraction.Override(&testCtx.store.ClusterSettings().SV, 0.4)
+	runTestCases(t, []testCase{
+		{
+			startKey:    tableKey(1),
+			endKey:      append(tableKey(1), 'a'),
+			minBytes:    1024,
+			bytes:       2048,
+			expShouldQ:  true,
+			expPriority: 0,
+		},
+		{
+			startKey:    tableKey(1),
+			endKey:      append(tableKey(1), 'a'),
+			minBytes:    1024,
+			bytes:       5120,
+			expShouldQ:  false,
+			expPriority: 0,
+		},
+		// Ranges below the minimum byte threshold are still merged first.
+		{
+			startKey:    tableKey(1),
+			endKey:      append(tableKey(1), 'a'),
+			minBytes:    1024,
+			bytes:       768,
+			expShouldQ:  true,
+			expPriority: 0.25,
+		},
+	})
 }
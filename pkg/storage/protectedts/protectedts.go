@@ -0,0 +1,108 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+// Package protectedts houses the interfaces of the protected timestamp
+// subsystem, which allows long-running readers such as backups and changefeeds
+// to keep the GC queue from removing data they still need to read.
+//
+// A protected timestamp record protects a set of spans at a timestamp. Records
+// are written to and removed from the system.protected_ts_records table
+// transactionally, usually in the same transaction which updates the job that
+// owns them. Each store keeps a Cache of the records which it refreshes
+// periodically. The GC queue consults the cache before processing a range and
+// does not advance the range's GC threshold to or past the timestamp of any
+// record overlapping the range. Before setting a new GC threshold, the GC
+// queue makes sure that the cache has been refreshed after that threshold.
+// Consequently, a record is guaranteed to protect its timestamp if that
+// timestamp had not yet expired under the GC TTL when the record was
+// written. Whether it had is established by verifying the record after the
+// transaction which wrote it commits. Reads of data which has already been
+// garbage collected fail as before.
+package protectedts
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+// ErrNotExists is returned when a record does not exist.
+var ErrNotExists = errors.New("protected timestamp record does not exist")
+
+// ErrExists is returned by Protect when a record with the same ID already
+// exists.
+var ErrExists = errors.New("protected timestamp record already exists")
+
+// Provider is the central coordinator of the protected timestamp subsystem.
+// It is a Storage for the records, a Cache of them and a Verifier of them.
+type Provider interface {
+	Storage
+	Cache
+	Verifier
+
+	// Start starts refreshing the cache in the background.
+	Start(context.Context, *stop.Stopper) error
+}
+
+// Storage provides transactional access to the protected timestamp records.
+// All methods must be called with a non-nil transaction.
+type Storage interface {
+	// Protect writes a new record. It returns ErrExists if a record with the
+	// same ID already exists.
+	Protect(ctx context.Context, txn *client.Txn, r *ptpb.Record) error
+
+	// GetRecord retrieves the record with the given ID. It returns
+	// ErrNotExists if the record does not exist.
+	GetRecord(ctx context.Context, txn *client.Txn, id uuid.UUID) (*ptpb.Record, error)
+
+	// UpdateTimestamp changes the timestamp protected by the record with the
+	// given ID. It returns ErrNotExists if the record does not exist.
+	UpdateTimestamp(ctx context.Context, txn *client.Txn, id uuid.UUID, ts hlc.Timestamp) error
+
+	// Release removes the record with the given ID. It returns ErrNotExists
+	// if the record does not exist.
+	Release(ctx context.Context, txn *client.Txn, id uuid.UUID) error
+
+	// GetRecords retrieves all of the records.
+	GetRecords(ctx context.Context, txn *client.Txn) ([]ptpb.Record, error)
+}
+
+// Verifier verifies that records protect their timestamps.
+type Verifier interface {
+	// Verify makes sure that the record with the given ID applies to every
+	// range which overlaps its spans: each such range has not garbage
+	// collected data at or above the record's timestamp and will not do so
+	// while the record exists. It must be called after the transaction which
+	// wrote the record has committed. It returns an error if the record does
+	// not exist or cannot be verified.
+	Verify(ctx context.Context, id uuid.UUID) error
+}
+
+// Cache is a periodically refreshed view of the protected timestamp records.
+// It is consulted by the GC queue. The methods are safe for concurrent use.
+type Cache interface {
+	// Iterate calls fn with every record whose spans overlap [from, to). It
+	// returns the timestamp as of which the cache's view of the records is
+	// current, which is the zero timestamp if the cache has not been populated
+	// yet. fn must not retain the record.
+	Iterate(ctx context.Context, from, to roachpb.Key, fn func(*ptpb.Record)) (asOf hlc.Timestamp)
+
+	// Refresh makes sure that the cache's view of the records is current as
+	// of at least asOf, reading the records if necessary.
+	Refresh(ctx context.Context, asOf hlc.Timestamp) error
+}
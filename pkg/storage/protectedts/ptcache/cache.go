@@ -0,0 +1,142 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+// Package ptcache implements protectedts.Cache by periodically reading all of
+// the protected timestamp records.
+package ptcache
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil/singleflight"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// Cache implements protectedts.Cache. The set of records is expected to be
+// small, so it is simply kept in a slice.
+type Cache struct {
+	db       *client.DB
+	storage  protectedts.Storage
+	settings *cluster.Settings
+	sf       singleflight.Group
+
+	mu struct {
+		syncutil.RWMutex
+		// asOf is the timestamp at which records were read.
+		asOf    hlc.Timestamp
+		records []ptpb.Record
+	}
+}
+
+var _ protectedts.Cache = (*Cache)(nil)
+
+// New creates a new Cache. It reads the records from storage.
+func New(settings *cluster.Settings, db *client.DB, storage protectedts.Storage) *Cache {
+	return &Cache{db: db, storage: storage, settings: settings}
+}
+
+// Start starts a task which refreshes the cache every
+// protectedts.PollInterval.
+func (c *Cache) Start(ctx context.Context, stopper *stop.Stopper) error {
+	return stopper.RunAsyncTask(ctx, "protectedts-cache", func(ctx context.Context) {
+		ctx, cancel := stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		timer.Reset(0)
+		for {
+			select {
+			case <-timer.C:
+				timer.Read = true
+				if err := c.Refresh(ctx, c.db.Clock().Now()); err != nil {
+					log.Warningf(ctx, "failed to refresh protected timestamp records: %v", err)
+				}
+				timer.Reset(protectedts.PollInterval.Get(&c.settings.SV))
+			case <-stopper.ShouldQuiesce():
+				return
+			}
+		}
+	})
+}
+
+// Iterate is part of the protectedts.Cache interface.
+func (c *Cache) Iterate(
+	_ context.Context, from, to roachpb.Key, fn func(*ptpb.Record),
+) hlc.Timestamp {
+	sp := roachpb.Span{Key: from, EndKey: to}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for i := range c.mu.records {
+		r := &c.mu.records[i]
+		for _, s := range r.Spans {
+			if s.Overlaps(sp) {
+				fn(r)
+				break
+			}
+		}
+	}
+	return c.mu.asOf
+}
+
+// Refresh is part of the protectedts.Cache interface.
+func (c *Cache) Refresh(ctx context.Context, asOf hlc.Timestamp) error {
+	for !asOf.Less(c.getAsOf()) {
+		// A refresh which was already in flight may have read the records
+		// before asOf, in which case we loop around and start another one.
+		if _, _, err := c.sf.Do("refresh", func() (interface{}, error) {
+			return nil, c.doRefresh(ctx)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cache) getAsOf() hlc.Timestamp {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.mu.asOf
+}
+
+// doRefresh reads all of the records at the current time.
+func (c *Cache) doRefresh(ctx context.Context) error {
+	readAt := c.db.Clock().Now()
+	var records []ptpb.Record
+	// Records cannot exist before the cluster version which introduced them is
+	// active, and the table holding them may not have been created yet.
+	if c.settings.Version.IsActive(cluster.VersionProtectedTimestamps) {
+		if err := c.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+			txn.SetFixedTimestamp(ctx, readAt)
+			var err error
+			records, err = c.storage.GetRecords(ctx, txn)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mu.asOf.Less(readAt) {
+		c.mu.asOf = readAt
+		c.mu.records = records
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+// Package ptprovider encapsulates the concrete implementation of the
+// protectedts.Provider.
+package ptprovider
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptcache"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptstorage"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptverifier"
+)
+
+// Config configures the Provider.
+type Config struct {
+	Settings         *cluster.Settings
+	DB               *client.DB
+	InternalExecutor sqlutil.InternalExecutor
+}
+
+type provider struct {
+	protectedts.Storage
+	*ptcache.Cache
+	protectedts.Verifier
+}
+
+// New creates a new protectedts.Provider.
+func New(cfg Config) protectedts.Provider {
+	storage := ptstorage.New(cfg.Settings, cfg.InternalExecutor)
+	return &provider{
+		Storage:  storage,
+		Cache:    ptcache.New(cfg.Settings, cfg.DB, storage),
+		Verifier: ptverifier.New(cfg.DB, storage),
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+// Package ptstorage implements protectedts.Storage on top of the
+// system.protected_ts_records table.
+package ptstorage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+)
+
+type storage struct {
+	settings *cluster.Settings
+	ex       sqlutil.InternalExecutor
+}
+
+var _ protectedts.Storage = (*storage)(nil)
+
+// New creates a new Storage.
+func New(settings *cluster.Settings, ex sqlutil.InternalExecutor) protectedts.Storage {
+	return &storage{settings: settings, ex: ex}
+}
+
+const (
+	protectQuery = `
+INSERT INTO system.protected_ts_records (id, ts, meta_type, meta, spans)
+VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING`
+
+	getRecordQuery = `
+SELECT id, ts, meta_type, meta, spans FROM system.protected_ts_records WHERE id = $1`
+
+	getRecordsQuery = `
+SELECT id, ts, meta_type, meta, spans FROM system.protected_ts_records`
+
+	updateTimestampQuery = `
+UPDATE system.protected_ts_records SET ts = $2 WHERE id = $1`
+
+	releaseQuery = `
+DELETE FROM system.protected_ts_records WHERE id = $1`
+)
+
+func (s *storage) Protect(ctx context.Context, txn *client.Txn, r *ptpb.Record) error {
+	if err := s.checkTxn(txn); err != nil {
+		return err
+	}
+	if !s.settings.Version.IsActive(cluster.VersionProtectedTimestamps) {
+		return errors.New("protected timestamps are not supported until the cluster upgrade is finalized")
+	}
+	if err := validateRecord(r); err != nil {
+		return err
+	}
+	spans, err := protoutil.Marshal(&ptpb.Spans{Spans: r.Spans})
+	if err != nil {
+		return errors.Wrap(err, "encoding spans")
+	}
+	n, err := s.ex.Exec(ctx, "protectedts-protect", txn, protectQuery,
+		tree.NewDUuid(tree.DUuid{UUID: r.ID}),
+		tree.TimestampToDecimal(r.Timestamp),
+		r.MetaType,
+		r.Meta,
+		spans,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "writing protected timestamp record %s", r.ID)
+	}
+	if n == 0 {
+		return protectedts.ErrExists
+	}
+	return nil
+}
+
+func (s *storage) GetRecord(
+	ctx context.Context, txn *client.Txn, id uuid.UUID,
+) (*ptpb.Record, error) {
+	if err := s.checkTxn(txn); err != nil {
+		return nil, err
+	}
+	row, err := s.ex.QueryRow(ctx, "protectedts-get-record", txn, getRecordQuery,
+		tree.NewDUuid(tree.DUuid{UUID: id}))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading protected timestamp record %s", id)
+	}
+	if row == nil {
+		return nil, protectedts.ErrNotExists
+	}
+	var r ptpb.Record
+	if err := rowToRecord(row, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *storage) UpdateTimestamp(
+	ctx context.Context, txn *client.Txn, id uuid.UUID, ts hlc.Timestamp,
+) error {
+	if err := s.checkTxn(txn); err != nil {
+		return err
+	}
+	if ts == (hlc.Timestamp{}) {
+		return errors.New("cannot protect the zero timestamp")
+	}
+	n, err := s.ex.Exec(ctx, "protectedts-update-timestamp", txn, updateTimestampQuery,
+		tree.NewDUuid(tree.DUuid{UUID: id}), tree.TimestampToDecimal(ts))
+	if err != nil {
+		return errors.Wrapf(err, "updating protected timestamp record %s", id)
+	}
+	if n == 0 {
+		return protectedts.ErrNotExists
+	}
+	return nil
+}
+
+func (s *storage) Release(ctx context.Context, txn *client.Txn, id uuid.UUID) error {
+	if err := s.checkTxn(txn); err != nil {
+		return err
+	}
+	n, err := s.ex.Exec(ctx, "protectedts-release", txn, releaseQuery,
+		tree.NewDUuid(tree.DUuid{UUID: id}))
+	if err != nil {
+		return errors.Wrapf(err, "releasing protected timestamp record %s", id)
+	}
+	if n == 0 {
+		return protectedts.ErrNotExists
+	}
+	return nil
+}
+
+func (s *storage) GetRecords(ctx context.Context, txn *client.Txn) ([]ptpb.Record, error) {
+	if err := s.checkTxn(txn); err != nil {
+		return nil, err
+	}
+	rows, err := s.ex.Query(ctx, "protectedts-get-records", txn, getRecordsQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading protected timestamp records")
+	}
+	records := make([]ptpb.Record, len(rows))
+	for i, row := range rows {
+		if err := rowToRecord(row, &records[i]); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+func (s *storage) checkTxn(txn *client.Txn) error {
+	if txn == nil {
+		return errors.AssertionFailedf("protected timestamp records must be accessed in a transaction")
+	}
+	return nil
+}
+
+func validateRecord(r *ptpb.Record) error {
+	if r.ID == uuid.Nil {
+		return errors.New("protected timestamp record must have an ID")
+	}
+	if r.Timestamp == (hlc.Timestamp{}) {
+		return errors.New("cannot protect the zero timestamp")
+	}
+	if len(r.Spans) == 0 {
+		return errors.New("protected timestamp record must protect at least one span")
+	}
+	return nil
+}
+
+// rowToRecord decodes a row of system.protected_ts_records into r.
+func rowToRecord(row tree.Datums, r *ptpb.Record) error {
+	r.ID = row[0].(*tree.DUuid).UUID
+	ts, err := tree.DecimalToHLC(&row[1].(*tree.DDecimal).Decimal)
+	if err != nil {
+		return errors.Wrapf(err, "decoding timestamp of protected timestamp record %s", r.ID)
+	}
+	r.Timestamp = ts
+	r.MetaType = string(*row[2].(*tree.DString))
+	if meta := row[3]; meta != tree.DNull {
+		r.Meta = []byte(*meta.(*tree.DBytes))
+	}
+	var spans ptpb.Spans
+	if err := protoutil.Unmarshal([]byte(*row[4].(*tree.DBytes)), &spans); err != nil {
+		return errors.Wrapf(err, "decoding spans of protected timestamp record %s", r.ID)
+	}
+	r.Spans = spans.Spans
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package ptstorage_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptstorage"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+)
+
+func TestStorage(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	s, _, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	pts := ptstorage.New(s.ClusterSettings(), s.InternalExecutor().(sqlutil.InternalExecutor))
+	txn := func(fn func(context.Context, *client.Txn) error) error {
+		return kvDB.Txn(ctx, fn)
+	}
+
+	rec := ptpb.Record{
+		ID:        uuid.MakeV4(),
+		Timestamp: s.Clock().Now(),
+		MetaType:  "test",
+		Meta:      []byte("meta"),
+		Spans:     []roachpb.Span{{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")}},
+	}
+	if err := txn(func(ctx context.Context, txn *client.Txn) error {
+		return pts.Protect(ctx, txn, &rec)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn(func(ctx context.Context, txn *client.Txn) error {
+		return pts.Protect(ctx, txn, &rec)
+	}); !errors.Is(err, protectedts.ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+
+	// Updating the timestamp is visible through both GetRecord and GetRecords.
+	ts := rec.Timestamp.Next()
+	if err := txn(func(ctx context.Context, txn *client.Txn) error {
+		return pts.UpdateTimestamp(ctx, txn, rec.ID, ts)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	rec.Timestamp = ts
+	if err := txn(func(ctx context.Context, txn *client.Txn) error {
+		got, err := pts.GetRecord(ctx, txn, rec.ID)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(got, &rec) {
+			return errors.Errorf("expected %v, got %v", rec, got)
+		}
+		records, err := pts.GetRecords(ctx, txn)
+		if err != nil {
+			return err
+		}
+		if len(records) != 1 || !reflect.DeepEqual(&records[0], &rec) {
+			return errors.Errorf("expected [%v], got %v", rec, records)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := txn(func(ctx context.Context, txn *client.Txn) error {
+		return pts.Release(ctx, txn, rec.ID)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range []func(context.Context, *client.Txn) error{
+		func(ctx context.Context, txn *client.Txn) error {
+			_, err := pts.GetRecord(ctx, txn, rec.ID)
+			return err
+		},
+		func(ctx context.Context, txn *client.Txn) error {
+			return pts.UpdateTimestamp(ctx, txn, rec.ID, ts)
+		},
+		func(ctx context.Context, txn *client.Txn) error {
+			return pts.Release(ctx, txn, rec.ID)
+		},
+	} {
+		if err := txn(fn); !errors.Is(err, protectedts.ErrNotExists) {
+			t.Fatalf("expected ErrNotExists, got %v", err)
+		}
+	}
+}
+
+func TestStorageValidation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	s, _, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	pts := ptstorage.New(s.ClusterSettings(), s.InternalExecutor().(sqlutil.InternalExecutor))
+	spans := []roachpb.Span{{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")}}
+	for _, tc := range []struct {
+		rec ptpb.Record
+		err string
+	}{
+		{ptpb.Record{Timestamp: hlc.Timestamp{WallTime: 1}, Spans: spans}, "must have an ID"},
+		{ptpb.Record{ID: uuid.MakeV4(), Spans: spans}, "cannot protect the zero timestamp"},
+		{ptpb.Record{ID: uuid.MakeV4(), Timestamp: hlc.Timestamp{WallTime: 1}}, "at least one span"},
+	} {
+		err := kvDB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+			return pts.Protect(ctx, txn, &tc.rec)
+		})
+		if !testutils.IsError(err, tc.err) {
+			t.Errorf("expected error %q, got %v", tc.err, err)
+		}
+	}
+	if err := pts.Protect(ctx, nil /* txn */, &ptpb.Record{}); err == nil {
+		t.Fatal("expected an error without a transaction")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+// Package ptverifier implements protectedts.Verifier by sending an
+// AdminVerifyProtectedTimestampRequest to every range of a record.
+package ptverifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+)
+
+type verifier struct {
+	db *client.DB
+	s  protectedts.Storage
+}
+
+var _ protectedts.Verifier = (*verifier)(nil)
+
+// New creates a new Verifier which reads records from s.
+func New(db *client.DB, s protectedts.Storage) protectedts.Verifier {
+	return &verifier{db: db, s: s}
+}
+
+func (v *verifier) Verify(ctx context.Context, id uuid.UUID) error {
+	// Read the record, noting a timestamp at which it is known to exist. The
+	// replicas make sure that their view of the records is at least as recent.
+	var r *ptpb.Record
+	var aliveAt hlc.Timestamp
+	if err := v.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		var err error
+		r, err = v.s.GetRecord(ctx, txn, id)
+		aliveAt = txn.OrigTimestamp()
+		return err
+	}); err != nil {
+		return errors.Wrapf(err, "failed to read protected timestamp record %s", id)
+	}
+
+	// Verify the record on all of its ranges in a single batch. The
+	// DistSender splits each request up by range.
+	spans, _ := roachpb.MergeSpans(append([]roachpb.Span(nil), r.Spans...))
+	var b client.Batch
+	for _, sp := range spans {
+		b.AddRawRequest(&roachpb.AdminVerifyProtectedTimestampRequest{
+			RequestHeader: roachpb.RequestHeaderFromSpan(sp),
+			Protected:     r.Timestamp,
+			RecordID:      r.ID,
+			RecordAliveAt: aliveAt,
+		})
+	}
+	if err := v.db.Run(ctx, &b); err != nil {
+		return errors.Wrapf(err, "failed to verify protected timestamp record %s", id)
+	}
+	var failed []roachpb.RangeDescriptor
+	for _, ru := range b.RawResponse().Responses {
+		resp := ru.GetInner().(*roachpb.AdminVerifyProtectedTimestampResponse)
+		failed = append(failed, resp.FailedRanges...)
+	}
+	if len(failed) > 0 {
+		var buf strings.Builder
+		for i := range failed {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			fmt.Fprintf(&buf, "r%d", failed[i].RangeID)
+		}
+		return errors.Errorf(
+			"failed to verify protected timestamp record %s at %s on ranges %s: "+
+				"the data may already have been garbage collected",
+			id, r.Timestamp, buf.String())
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package ptverifier_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptstorage"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptverifier"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+)
+
+func TestVerifier(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	s, _, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	pts := ptstorage.New(s.ClusterSettings(), s.InternalExecutor().(sqlutil.InternalExecutor))
+	v := ptverifier.New(kvDB, pts)
+	protect := func(rec *ptpb.Record) {
+		t.Helper()
+		if err := kvDB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+			return pts.Protect(ctx, txn, rec)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Split so that the record spans several ranges.
+	for _, k := range []string{"b", "c"} {
+		if err := kvDB.AdminSplit(ctx, k, k, hlc.MaxTimestamp /* expirationTime */); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec := ptpb.Record{
+		ID:        uuid.MakeV4(),
+		Timestamp: s.Clock().Now(),
+		MetaType:  "test",
+		Spans: []roachpb.Span{
+			{Key: roachpb.Key("a"), EndKey: roachpb.Key("c")},
+			{Key: roachpb.Key("b"), EndKey: roachpb.Key("d")},
+		},
+	}
+	protect(&rec)
+	if err := v.Verify(ctx, rec.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// A record whose timestamp is not above the GC threshold of one of its
+	// ranges fails verification.
+	gcThreshold := s.Clock().Now()
+	if _, pErr := client.SendWrapped(ctx, s.DistSender(), &roachpb.GCRequest{
+		RequestHeader: roachpb.RequestHeader{Key: roachpb.Key("c"), EndKey: roachpb.Key("d")},
+		Threshold:     gcThreshold,
+	}); pErr != nil {
+		t.Fatal(pErr)
+	}
+	gcRec := ptpb.Record{
+		ID:        uuid.MakeV4(),
+		Timestamp: gcThreshold,
+		MetaType:  "test",
+		Spans:     []roachpb.Span{{Key: roachpb.Key("a"), EndKey: roachpb.Key("d")}},
+	}
+	protect(&gcRec)
+	if err := v.Verify(ctx, gcRec.ID); !testutils.IsError(err, "failed to verify") {
+		t.Fatalf("expected a verification failure, got %v", err)
+	}
+
+	if err := v.Verify(ctx, uuid.MakeV4()); !errors.Is(err, protectedts.ErrNotExists) {
+		t.Fatalf("expected ErrNotExists, got %v", err)
+	}
+}
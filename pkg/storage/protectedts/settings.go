@@ -0,0 +1,27 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package protectedts
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+)
+
+// PollInterval is the interval at which the Cache refreshes its view of the
+// protected timestamp records in the background.
+var PollInterval = settings.RegisterNonNegativeDurationSetting(
+	"kv.protectedts.poll_interval",
+	"the interval at which the protected timestamp records are polled",
+	2*time.Minute,
+)
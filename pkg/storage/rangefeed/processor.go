@@ -15,6 +15,7 @@ package rangefeed
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -111,6 +112,10 @@ type Processor struct {
 	Config
 	reg registry
 	rts resolvedTimestamp
+	// pendingWithDiff is the number of registrations that requested diffs and
+	// have been handed to the Processor goroutine but not yet added to the
+	// registry. Accessed atomically.
+	pendingWithDiff int32
 
 	regC     chan registration
 	unregC   chan *registration
@@ -207,6 +212,9 @@ func (p *Processor) Start(stopper *stop.Stopper, rtsIter engine.SimpleIterator)
 
 				// Add the new registration to the registry.
 				p.reg.Register(&r)
+				if r.withDiff {
+					atomic.AddInt32(&p.pendingWithDiff, -1)
+				}
 
 				// Immediately publish a checkpoint event to the registry. This will be
 				// the first event published to this registration after its initial
@@ -340,7 +348,9 @@ func (p *Processor) sendStop(pErr *roachpb.Error) {
 // provided an error when the registration closes.
 //
 // The optionally provided "catch-up" iterator is used to read changes from the
-// engine which occurred after the provided start timestamp.
+// engine which occurred after the provided start timestamp, along with the
+// MVCC range tombstones overlapping the span. If withDiff is true, values
+// published to the registration will include the previous value of their key.
 //
 // If the method returns false, the processor will have been stopped, so calling
 // Stop is not necessary.
@@ -350,6 +360,8 @@ func (p *Processor) Register(
 	span roachpb.RSpan,
 	startTS hlc.Timestamp,
 	catchupIter engine.SimpleIterator,
+	catchupRangeTombs []enginepb.MVCCRangeTombstone,
+	withDiff bool,
 	stream Stream,
 	errC chan<- *roachpb.Error,
 ) bool {
@@ -359,17 +371,35 @@ func (p *Processor) Register(
 	p.syncEventC()
 
 	r := newRegistration(
-		span.AsRawSpanWithNoLocals(), startTS, catchupIter, p.Config.EventChanCap,
-		p.Metrics, stream, errC,
+		span.AsRawSpanWithNoLocals(), startTS, catchupIter, catchupRangeTombs, withDiff,
+		p.Config.EventChanCap, p.Metrics, stream, errC,
 	)
+	// Count the registration before handing it to the Processor goroutine so
+	// that NeedPrevVal reflects it as soon as this method returns.
+	if withDiff {
+		atomic.AddInt32(&p.pendingWithDiff, 1)
+	}
 	select {
 	case p.regC <- r:
 		return true
 	case <-p.stoppedC:
+		if withDiff {
+			atomic.AddInt32(&p.pendingWithDiff, -1)
+		}
 		return false
 	}
 }
 
+// NeedPrevVal returns whether any registration requested diffs, in which case
+// the logical ops passed to ConsumeLogicalOps must include the previous value
+// of each key that they write. Safe to call on nil Processor.
+func (p *Processor) NeedPrevVal() bool {
+	if p == nil {
+		return false
+	}
+	return atomic.LoadInt32(&p.pendingWithDiff) > 0 || p.reg.NumWithDiff() > 0
+}
+
 // Len returns the number of registrations attached to the processor.
 func (p *Processor) Len() int {
 	if p == nil {
@@ -502,7 +532,7 @@ func (p *Processor) consumeLogicalOps(ctx context.Context, ops []enginepb.MVCCLo
 		switch t := op.GetValue().(type) {
 		case *enginepb.MVCCWriteValueOp:
 			// Publish the new value directly.
-			p.publishValue(ctx, t.Key, t.Timestamp, t.Value)
+			p.publishValue(ctx, t.Key, t.Timestamp, t.Value, t.PrevValue)
 
 		case *enginepb.MVCCWriteIntentOp:
 			// No updates to publish.
@@ -512,7 +542,7 @@ func (p *Processor) consumeLogicalOps(ctx context.Context, ops []enginepb.MVCCLo
 
 		case *enginepb.MVCCCommitIntentOp:
 			// Publish the newly committed value.
-			p.publishValue(ctx, t.Key, t.Timestamp, t.Value)
+			p.publishValue(ctx, t.Key, t.Timestamp, t.Value, t.PrevValue)
 
 		case *enginepb.MVCCAbortIntentOp:
 			// No updates to publish.
@@ -520,6 +550,10 @@ func (p *Processor) consumeLogicalOps(ctx context.Context, ops []enginepb.MVCCLo
 		case *enginepb.MVCCAbortTxnOp:
 			// No updates to publish.
 
+		case *enginepb.MVCCDeleteRangeOp:
+			// Publish the range deletion.
+			p.publishDeleteRange(ctx, t.Key, t.EndKey, t.Timestamp)
+
 		default:
 			panic(fmt.Sprintf("unknown logical op %T", t))
 		}
@@ -545,7 +579,7 @@ func (p *Processor) initResolvedTS(ctx context.Context) {
 }
 
 func (p *Processor) publishValue(
-	ctx context.Context, key roachpb.Key, timestamp hlc.Timestamp, value []byte,
+	ctx context.Context, key roachpb.Key, timestamp hlc.Timestamp, value, prevValue []byte,
 ) {
 	if !p.Span.ContainsKey(roachpb.RKey(key)) {
 		log.Fatalf(ctx, "key %v not in Processor's key range %v", key, p.Span)
@@ -559,6 +593,25 @@ func (p *Processor) publishValue(
 			RawBytes:  value,
 			Timestamp: timestamp,
 		},
+		PrevValue: roachpb.Value{
+			RawBytes: prevValue,
+		},
+	})
+	p.reg.PublishToOverlapping(span, &event)
+}
+
+func (p *Processor) publishDeleteRange(
+	ctx context.Context, key, endKey roachpb.Key, timestamp hlc.Timestamp,
+) {
+	span := roachpb.Span{Key: key, EndKey: endKey}
+	if !p.Span.ContainsKeyRange(roachpb.RKey(key), roachpb.RKey(endKey)) {
+		log.Fatalf(ctx, "span %v not in Processor's key range %v", span, p.Span)
+	}
+
+	var event roachpb.RangeFeedEvent
+	event.MustSetValue(&roachpb.RangeFeedDeleteRange{
+		Span:      span,
+		Timestamp: timestamp,
 	})
 	p.reg.PublishToOverlapping(span, &event)
 }
@@ -112,6 +112,14 @@ func rangeFeedValue(key roachpb.Key, val roachpb.Value) *roachpb.RangeFeedEvent
 	})
 }
 
+func rangeFeedValueWithPrev(key roachpb.Key, val, prev roachpb.Value) *roachpb.RangeFeedEvent {
+	return makeRangeFeedEvent(&roachpb.RangeFeedValue{
+		Key:       key,
+		Value:     val,
+		PrevValue: prev,
+	})
+}
+
 func rangeFeedCheckpoint(span roachpb.Span, ts hlc.Timestamp) *roachpb.RangeFeedEvent {
 	return makeRangeFeedEvent(&roachpb.RangeFeedCheckpoint{
 		Span:       span,
@@ -181,7 +189,9 @@ func TestProcessorBasic(t *testing.T) {
 	r1OK := p.Register(
 		roachpb.RSpan{Key: roachpb.RKey("a"), EndKey: roachpb.RKey("m")},
 		hlc.Timestamp{WallTime: 1},
-		nil, /* catchUpIter */
+		nil,   /* catchUpIter */
+		nil,   /* catchupRangeTombs */
+		false, /* withDiff */
 		r1Stream,
 		r1ErrC,
 	)
@@ -296,7 +306,9 @@ func TestProcessorBasic(t *testing.T) {
 	r2OK := p.Register(
 		roachpb.RSpan{Key: roachpb.RKey("c"), EndKey: roachpb.RKey("z")},
 		hlc.Timestamp{WallTime: 1},
-		nil, /* catchUpIter */
+		nil,   /* catchUpIter */
+		nil,   /* catchupRangeTombs */
+		false, /* withDiff */
 		r2Stream,
 		r2ErrC,
 	)
@@ -366,7 +378,9 @@ func TestProcessorBasic(t *testing.T) {
 	r3OK := p.Register(
 		roachpb.RSpan{Key: roachpb.RKey("c"), EndKey: roachpb.RKey("z")},
 		hlc.Timestamp{WallTime: 1},
-		nil, /* catchUpIter */
+		nil,   /* catchUpIter */
+		nil,   /* catchupRangeTombs */
+		false, /* withDiff */
 		r3Stream,
 		r3ErrC,
 	)
@@ -379,6 +393,7 @@ func TestNilProcessor(t *testing.T) {
 
 	// All of the following should be no-ops.
 	require.Equal(t, 0, p.Len())
+	require.False(t, p.NeedPrevVal())
 	require.NotPanics(t, func() { p.Stop() })
 	require.NotPanics(t, func() { p.StopWithErr(nil) })
 	require.NotPanics(t, func() { p.ConsumeLogicalOps() })
@@ -389,7 +404,60 @@ func TestNilProcessor(t *testing.T) {
 	// The following should panic because they are not safe
 	// to call on a nil Processor.
 	require.Panics(t, func() { p.Start(stop.NewStopper(), nil) })
-	require.Panics(t, func() { p.Register(roachpb.RSpan{}, hlc.Timestamp{}, nil, nil, nil) })
+	require.Panics(t, func() { p.Register(roachpb.RSpan{}, hlc.Timestamp{}, nil, nil, false, nil, nil) })
+}
+
+func TestProcessorWithDiff(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	p, stopper := newTestProcessor(nil /* rtsIter */)
+	defer stopper.Stop(context.Background())
+
+	// Add a registration that doesn't request diffs and one that does.
+	r1Stream := newTestStream()
+	p.Register(
+		roachpb.RSpan{Key: roachpb.RKey("a"), EndKey: roachpb.RKey("m")},
+		hlc.Timestamp{WallTime: 1},
+		nil,   /* catchUpIter */
+		nil,   /* catchupRangeTombs */
+		false, /* withDiff */
+		r1Stream,
+		make(chan *roachpb.Error, 1),
+	)
+	require.False(t, p.NeedPrevVal())
+	r2Stream := newTestStream()
+	p.Register(
+		roachpb.RSpan{Key: roachpb.RKey("a"), EndKey: roachpb.RKey("m")},
+		hlc.Timestamp{WallTime: 1},
+		nil,  /* catchUpIter */
+		nil,  /* catchupRangeTombs */
+		true, /* withDiff */
+		r2Stream,
+		make(chan *roachpb.Error, 1),
+	)
+	require.True(t, p.NeedPrevVal())
+	p.syncEventAndRegistrations()
+	r1Stream.Events() // discard initial checkpoint
+	r2Stream.Events() // discard initial checkpoint
+
+	// Only the registration that requested diffs sees the previous value.
+	p.ConsumeLogicalOps(makeLogicalOp(&enginepb.MVCCWriteValueOp{
+		Key:       roachpb.Key("c"),
+		Timestamp: hlc.Timestamp{WallTime: 5},
+		Value:     []byte("val"),
+		PrevValue: []byte("prev"),
+	}))
+	p.syncEventAndRegistrations()
+	val := roachpb.Value{RawBytes: []byte("val"), Timestamp: hlc.Timestamp{WallTime: 5}}
+	require.Equal(t,
+		[]*roachpb.RangeFeedEvent{rangeFeedValue(roachpb.Key("c"), val)},
+		r1Stream.Events(),
+	)
+	require.Equal(t,
+		[]*roachpb.RangeFeedEvent{
+			rangeFeedValueWithPrev(roachpb.Key("c"), val, roachpb.Value{RawBytes: []byte("prev")}),
+		},
+		r2Stream.Events(),
+	)
 }
 
 func TestProcessorSlowConsumer(t *testing.T) {
@@ -406,7 +474,9 @@ func TestProcessorSlowConsumer(t *testing.T) {
 	p.Register(
 		roachpb.RSpan{Key: roachpb.RKey("a"), EndKey: roachpb.RKey("m")},
 		hlc.Timestamp{WallTime: 1},
-		nil, /* catchUpIter */
+		nil,   /* catchUpIter */
+		nil,   /* catchupRangeTombs */
+		false, /* withDiff */
 		r1Stream,
 		r1ErrC,
 	)
@@ -415,7 +485,9 @@ func TestProcessorSlowConsumer(t *testing.T) {
 	p.Register(
 		roachpb.RSpan{Key: roachpb.RKey("a"), EndKey: roachpb.RKey("z")},
 		hlc.Timestamp{WallTime: 1},
-		nil, /* catchUpIter */
+		nil,   /* catchUpIter */
+		nil,   /* catchupRangeTombs */
+		false, /* withDiff */
 		r2Stream,
 		r2ErrC,
 	)
@@ -534,7 +606,9 @@ func TestProcessorInitializeResolvedTimestamp(t *testing.T) {
 	p.Register(
 		roachpb.RSpan{Key: roachpb.RKey("a"), EndKey: roachpb.RKey("m")},
 		hlc.Timestamp{WallTime: 1},
-		nil, /* catchUpIter */
+		nil,   /* catchUpIter */
+		nil,   /* catchupRangeTombs */
+		false, /* withDiff */
 		r1Stream,
 		make(chan *roachpb.Error, 1),
 	)
@@ -768,7 +842,7 @@ func TestProcessorConcurrentStop(t *testing.T) {
 			runtime.Gosched()
 			s := newTestStream()
 			errC := make(chan<- *roachpb.Error, 1)
-			p.Register(p.Span, hlc.Timestamp{}, nil, s, errC)
+			p.Register(p.Span, hlc.Timestamp{}, nil, nil, false, s, errC)
 		}()
 		go func() {
 			defer wg.Done()
@@ -837,7 +911,7 @@ func TestProcessorRegistrationObservesOnlyNewEvents(t *testing.T) {
 			s := newTestStream()
 			regs[s] = firstIdx
 			errC := make(chan *roachpb.Error, 1)
-			p.Register(p.Span, hlc.Timestamp{}, nil, s, errC)
+			p.Register(p.Span, hlc.Timestamp{}, nil, nil, false, s, errC)
 			regDone <- struct{}{}
 		}
 	}()
@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -55,10 +56,12 @@ type Stream interface {
 // has finished.
 type registration struct {
 	// Input.
-	span             roachpb.Span
-	catchupIter      engine.SimpleIterator
-	catchupTimestamp hlc.Timestamp
-	metrics          *Metrics
+	span              roachpb.Span
+	catchupIter       engine.SimpleIterator
+	catchupRangeTombs []enginepb.MVCCRangeTombstone
+	catchupTimestamp  hlc.Timestamp
+	withDiff          bool
+	metrics           *Metrics
 
 	// Output.
 	stream Stream
@@ -88,19 +91,23 @@ func newRegistration(
 	span roachpb.Span,
 	startTS hlc.Timestamp,
 	catchupIter engine.SimpleIterator,
+	catchupRangeTombs []enginepb.MVCCRangeTombstone,
+	withDiff bool,
 	bufferSz int,
 	metrics *Metrics,
 	stream Stream,
 	errC chan<- *roachpb.Error,
 ) registration {
 	r := registration{
-		span:             span,
-		catchupIter:      catchupIter,
-		metrics:          metrics,
-		stream:           stream,
-		errC:             errC,
-		buf:              make(chan *roachpb.RangeFeedEvent, bufferSz),
-		catchupTimestamp: startTS,
+		span:              span,
+		catchupIter:       catchupIter,
+		catchupRangeTombs: catchupRangeTombs,
+		withDiff:          withDiff,
+		metrics:           metrics,
+		stream:            stream,
+		errC:              errC,
+		buf:               make(chan *roachpb.RangeFeedEvent, bufferSz),
+		catchupTimestamp:  startTS,
 	}
 	r.mu.Locker = &syncutil.Mutex{}
 	r.mu.caughtUp = true
@@ -128,6 +135,52 @@ func (r *registration) publish(event *roachpb.RangeFeedEvent) {
 	}
 }
 
+// maybeStripEvent determines whether the event contains excess information not
+// applicable to the registration. If so, it makes a copy of the event and
+// strips it down to what the registration requested: the previous value of a
+// RangeFeedValue is removed if the registration did not request diffs, and the
+// span of a RangeFeedDeleteRange is truncated to the span of the registration.
+// Other events are returned unchanged.
+func (r *registration) maybeStripEvent(event *roachpb.RangeFeedEvent) *roachpb.RangeFeedEvent {
+	switch t := event.GetValue().(type) {
+	case *roachpb.RangeFeedValue:
+		if r.withDiff || !t.PrevValue.IsPresent() {
+			return event
+		}
+		var stripped roachpb.RangeFeedEvent
+		stripped.MustSetValue(&roachpb.RangeFeedValue{
+			Key:   t.Key,
+			Value: t.Value,
+		})
+		return &stripped
+	case *roachpb.RangeFeedDeleteRange:
+		if r.span.Contains(t.Span) {
+			return event
+		}
+		return r.truncateDeleteRange(t)
+	default:
+		return event
+	}
+}
+
+// truncateDeleteRange truncates the span of a RangeFeedDeleteRange event to the
+// span of the registration.
+func (r *registration) truncateDeleteRange(t *roachpb.RangeFeedDeleteRange) *roachpb.RangeFeedEvent {
+	span := t.Span
+	if span.Key.Compare(r.span.Key) < 0 {
+		span.Key = r.span.Key
+	}
+	if r.span.EndKey.Compare(span.EndKey) < 0 {
+		span.EndKey = r.span.EndKey
+	}
+	var truncated roachpb.RangeFeedEvent
+	truncated.MustSetValue(&roachpb.RangeFeedDeleteRange{
+		Span:      span,
+		Timestamp: t.Timestamp,
+	})
+	return &truncated
+}
+
 // disconnect cancels the output loop context for the registration and passes an
 // error to the output error stream for the registration. This also sets the
 // disconnected flag on the registration, preventing it from being disconnected
@@ -203,7 +256,10 @@ func (r *registration) runOutputLoop(ctx context.Context) {
 // runCatchupScan starts a catchup scan which will output entries for all
 // recorded changes in the replica that are newer than the catchupTimeStamp.
 // This uses the iterator provided when the registration was originally created;
-// after the scan completes, the iterator will be closed.
+// after the scan completes, the iterator will be closed. The deletions of keys
+// by the provided range tombstones are output as deletions of the individual
+// keys. If the registration requested diffs, each value is output along with
+// the version of the key that it overwrote.
 func (r *registration) runCatchupScan() error {
 	if r.catchupIter == nil {
 		return nil
@@ -225,6 +281,18 @@ func (r *registration) runCatchupScan() error {
 	// the encountered values in reverse.
 	reorderBuf := make([]roachpb.RangeFeedEvent, 0, 5)
 	var lastKey []byte
+	// Whether the last buffered event is still waiting for the previous value
+	// of its key, which is the next version encountered by the iterator.
+	var needPrevVal bool
+	setPrevVal := func(key, val []byte) {
+		if needPrevVal && bytes.Equal(key, lastKey) {
+			last := reorderBuf[len(reorderBuf)-1].GetValue().(*roachpb.RangeFeedValue)
+			if len(val) > 0 {
+				last.PrevValue.RawBytes = val
+			}
+		}
+		needPrevVal = false
+	}
 	outputEvents := func() error {
 		for i := len(reorderBuf) - 1; i >= 0; i-- {
 			e := reorderBuf[i]
@@ -233,6 +301,65 @@ func (r *registration) runCatchupScan() error {
 			}
 		}
 		reorderBuf = reorderBuf[:0]
+		needPrevVal = false
+		return nil
+	}
+	addEvent := func(key, val []byte, ts hlc.Timestamp) error {
+		// Output values in order
+		if !bytes.Equal(key, lastKey) {
+			if err := outputEvents(); err != nil {
+				return err
+			}
+			lastKey = key
+		}
+		setPrevVal(key, val)
+
+		var event roachpb.RangeFeedEvent
+		event.MustSetValue(&roachpb.RangeFeedValue{
+			Key: key,
+			Value: roachpb.Value{
+				RawBytes:  val,
+				Timestamp: ts,
+			},
+		})
+		reorderBuf = append(reorderBuf, event)
+		needPrevVal = r.withDiff
+		return nil
+	}
+
+	// The range tombstones covering the current key, newest first, and the
+	// number of them which have been output for the current key. A range
+	// tombstone is output as a deletion of the key once a version below it is
+	// encountered.
+	rangeTombs := r.catchupRangeTombs
+	var coveringTombs []enginepb.MVCCRangeTombstone
+	var tombKey []byte
+	outputRangeTombs := func(key []byte, ts hlc.Timestamp) error {
+		if !bytes.Equal(key, tombKey) {
+			a, tombKey = a.Copy(key, 0)
+			for len(rangeTombs) > 0 && bytes.Compare(key, rangeTombs[0].EndKey) >= 0 {
+				rangeTombs = rangeTombs[1:]
+			}
+			coveringTombs = rangeTombs[:0]
+			for len(coveringTombs) < len(rangeTombs) &&
+				bytes.Compare(rangeTombs[len(coveringTombs)].StartKey, key) <= 0 {
+				coveringTombs = rangeTombs[:len(coveringTombs)+1]
+			}
+		}
+		for len(coveringTombs) > 0 && ts.Less(coveringTombs[0].Timestamp) {
+			if r.catchupTimestamp.Less(coveringTombs[0].Timestamp) {
+				if err := addEvent(tombKey, nil, coveringTombs[0].Timestamp); err != nil {
+					return err
+				}
+			} else {
+				// The key was deleted at or before the registration's starting
+				// timestamp, so the previous value of the oldest version that
+				// was output for the key is the deletion, not the version
+				// below the range tombstone.
+				setPrevVal(tombKey, nil)
+			}
+			coveringTombs = coveringTombs[1:]
+		}
 		return nil
 	}
 
@@ -272,34 +399,31 @@ func (r *registration) runCatchupScan() error {
 			// filter on the registration's starting timestamp. Instead, we
 			// return all inline writes.
 			unsafeVal = meta.RawBytes
-		} else if !r.catchupTimestamp.Less(unsafeKey.Timestamp) {
-			// At or before the registration's exclusive starting timestamp.
-			// Ignore.
-			continue
+		} else {
+			if len(r.catchupRangeTombs) > 0 {
+				if err := outputRangeTombs(unsafeKey.Key, unsafeKey.Timestamp); err != nil {
+					return err
+				}
+			}
+			if !r.catchupTimestamp.Less(unsafeKey.Timestamp) {
+				// At or before the registration's exclusive starting timestamp.
+				// Ignore, other than as the previous value of the oldest version
+				// that was output for the key.
+				if needPrevVal {
+					var val []byte
+					a, val = a.Copy(unsafeVal, 0)
+					setPrevVal(unsafeKey.Key, val)
+				}
+				continue
+			}
 		}
 
 		var key, val []byte
 		a, key = a.Copy(unsafeKey.Key, 0)
 		a, val = a.Copy(unsafeVal, 0)
-		ts := unsafeKey.Timestamp
-
-		// Output values in order
-		if !bytes.Equal(key, lastKey) {
-			if err := outputEvents(); err != nil {
-				return err
-			}
-			lastKey = key
+		if err := addEvent(key, val, unsafeKey.Timestamp); err != nil {
+			return err
 		}
-
-		var event roachpb.RangeFeedEvent
-		event.MustSetValue(&roachpb.RangeFeedValue{
-			Key: key,
-			Value: roachpb.Value{
-				RawBytes:  val,
-				Timestamp: ts,
-			},
-		})
-		reorderBuf = append(reorderBuf, event)
 	}
 
 	// Output events for the last key encountered.
@@ -324,6 +448,10 @@ func (r registration) String() string {
 type registry struct {
 	tree    interval.Tree // *registration items
 	idAlloc int64
+	// numWithDiff is the number of registrations in the tree that requested
+	// diffs. It is only written by the Processor goroutine, but is accessed
+	// atomically so that it can be read from other goroutines.
+	numWithDiff int32
 }
 
 func makeRegistry() registry {
@@ -344,6 +472,29 @@ func (reg *registry) Register(r *registration) {
 	if err := reg.tree.Insert(r, false /* fast */); err != nil {
 		panic(err)
 	}
+	if r.withDiff {
+		reg.updateNumWithDiff()
+	}
+}
+
+// NumWithDiff returns the number of registrations in the registry that
+// requested diffs. Safe to call from any goroutine.
+func (reg *registry) NumWithDiff() int {
+	return int(atomic.LoadInt32(&reg.numWithDiff))
+}
+
+// updateNumWithDiff recomputes the number of registrations in the registry that
+// requested diffs. Registrations are only removed rarely, so this walks the
+// entire tree instead of keeping track of which registrations were removed.
+func (reg *registry) updateNumWithDiff() {
+	var n int32
+	reg.tree.Do(func(i interval.Interface) (done bool) {
+		if i.(*registration).withDiff {
+			n++
+		}
+		return false
+	})
+	atomic.StoreInt32(&reg.numWithDiff, n)
 }
 
 func (reg *registry) nextID() int64 {
@@ -369,6 +520,10 @@ func (reg *registry) PublishToOverlapping(span roachpb.Span, event *roachpb.Rang
 		// TODO(dan): It's unclear if this is the right contract, it's certainly
 		// surprising. Revisit this once RangeFeed has more users.
 		minTS = hlc.MaxTimestamp
+	case *roachpb.RangeFeedDeleteRange:
+		// Only publish range deletions to registrations with starting
+		// timestamps equal to or greater than the deletion's timestamp.
+		minTS = t.Timestamp
 	default:
 		panic(fmt.Sprintf("unexpected RangeFeedEvent variant: %v", event))
 	}
@@ -378,7 +533,7 @@ func (reg *registry) PublishToOverlapping(span roachpb.Span, event *roachpb.Rang
 		// than the registration's starting timestamp.
 
 		if r.catchupTimestamp.Less(minTS) {
-			r.publish(event)
+			r.publish(r.maybeStripEvent(event))
 		}
 		return false, nil
 	})
@@ -391,6 +546,9 @@ func (reg *registry) Unregister(r *registration) {
 	if err := reg.tree.Delete(r, false /* fast */); err != nil {
 		panic(err)
 	}
+	if r.withDiff {
+		reg.updateNumWithDiff()
+	}
 }
 
 // Disconnect disconnects all registrations that overlap the specified span with
@@ -447,6 +605,9 @@ func (reg *registry) forOverlappingRegs(
 		}
 		reg.tree.AdjustRanges()
 	}
+	if len(toDelete) > 0 && reg.NumWithDiff() > 0 {
+		reg.updateNumWithDiff()
+	}
 }
 
 // Wait for this registration to completely process its internal buffer.
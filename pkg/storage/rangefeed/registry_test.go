@@ -20,6 +20,7 @@ import (
 	_ "github.com/cockroachdb/cockroach/pkg/keys" // hook up pretty printer
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
@@ -107,6 +108,8 @@ func newTestRegistration(
 			span,
 			ts,
 			catchup,
+			nil,   /* catchupRangeTombs */
+			false, /* withDiff */
 			5,
 			NewMetrics(),
 			s,
@@ -266,6 +269,44 @@ func TestRegistrationCatchUpScan(t *testing.T) {
 	require.Equal(t, expEvents, r.Events())
 }
 
+func TestRegistrationCatchUpScanRangeTombstones(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	iter := newTestIterator([]engine.MVCCKeyValue{
+		makeKV("a", "val1", 10),
+		makeKV("a", "val2", 2),
+		makeKV("b", "val3", 3),
+		makeKV("c", "val4", 1),
+		makeKV("d", "val5", 6),
+	})
+	r := newTestRegistration(roachpb.Span{
+		Key:    roachpb.Key("a"),
+		EndKey: roachpb.Key("z"),
+	}, hlc.Timestamp{WallTime: 4}, iter)
+	r.catchupRangeTombs = []enginepb.MVCCRangeTombstone{
+		{StartKey: roachpb.Key("a"), EndKey: roachpb.Key("c"), Timestamp: hlc.Timestamp{WallTime: 5}},
+		{StartKey: roachpb.Key("d"), EndKey: roachpb.Key("e"), Timestamp: hlc.Timestamp{WallTime: 3}},
+	}
+	require.NoError(t, r.runCatchupScan())
+
+	// The range tombstone deleting a and b is output as a deletion of each of
+	// them, ordered with respect to their other versions. The range tombstone
+	// below the registration's starting timestamp is not output.
+	expEvents := []*roachpb.RangeFeedEvent{
+		rangeFeedValue(roachpb.Key("a"), roachpb.Value{Timestamp: hlc.Timestamp{WallTime: 5}}),
+		rangeFeedValue(
+			roachpb.Key("a"),
+			roachpb.Value{RawBytes: []byte("val1"), Timestamp: hlc.Timestamp{WallTime: 10}},
+		),
+		rangeFeedValue(roachpb.Key("b"), roachpb.Value{Timestamp: hlc.Timestamp{WallTime: 5}}),
+		rangeFeedValue(
+			roachpb.Key("d"),
+			roachpb.Value{RawBytes: []byte("val5"), Timestamp: hlc.Timestamp{WallTime: 6}},
+		),
+	}
+	require.Equal(t, expEvents, r.Events())
+}
+
 func TestRegistryBasic(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -386,6 +427,37 @@ func TestRegistryPublishBeneathStartTimestamp(t *testing.T) {
 	<-r.errC
 }
 
+func TestRegistryPublishDeleteRange(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	reg := makeRegistry()
+	rAB := newTestRegistration(spAB, hlc.Timestamp{}, nil)
+	rBC := newTestRegistration(spBC, hlc.Timestamp{WallTime: 5}, nil)
+	go rAB.runOutputLoop(context.Background())
+	go rBC.runOutputLoop(context.Background())
+	defer rAB.disconnect(nil)
+	defer rBC.disconnect(nil)
+	reg.Register(&rAB.registration)
+	reg.Register(&rBC.registration)
+
+	// The range deletion is truncated to the span of each registration, and
+	// isn't published to registrations starting at or above its timestamp.
+	ev := new(roachpb.RangeFeedEvent)
+	ev.MustSetValue(&roachpb.RangeFeedDeleteRange{
+		Span:      roachpb.Span{Key: roachpb.Key("a1"), EndKey: keyD},
+		Timestamp: hlc.Timestamp{WallTime: 4},
+	})
+	reg.PublishToOverlapping(roachpb.Span{Key: roachpb.Key("a1"), EndKey: keyD}, ev)
+	require.NoError(t, reg.waitForCaughtUp(all))
+	expEvent := new(roachpb.RangeFeedEvent)
+	expEvent.MustSetValue(&roachpb.RangeFeedDeleteRange{
+		Span:      roachpb.Span{Key: roachpb.Key("a1"), EndKey: keyB},
+		Timestamp: hlc.Timestamp{WallTime: 4},
+	})
+	require.Equal(t, []*roachpb.RangeFeedEvent{expEvent}, rAB.Events())
+	require.Len(t, rBC.Events(), 0)
+}
+
 func TestRegistrationString(t *testing.T) {
 	testCases := []struct {
 		r   registration
@@ -422,3 +494,118 @@ func TestRegistrationString(t *testing.T) {
 		require.Equal(t, tc.exp, tc.r.String())
 	}
 }
+
+func TestRegistrationCatchUpScanWithDiff(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	iter := newTestIterator([]engine.MVCCKeyValue{
+		makeKV("a", "val1", 10),
+		makeKV("a", "val2", 8),
+		makeKV("a", "val3", 3),
+		makeKV("a", "val4", 2),
+		makeKV("b", "val5", 7),
+		makeKV("c", "val6", 9),
+		makeKV("c", "", 3),
+	})
+	r := newTestRegistration(roachpb.Span{
+		Key:    roachpb.Key("a"),
+		EndKey: roachpb.Key("z"),
+	}, hlc.Timestamp{WallTime: 4}, iter)
+	r.withDiff = true
+	require.NoError(t, r.runCatchupScan())
+
+	// Each value is output with the version it overwrote, including versions
+	// beneath the registration's starting timestamp. Deletions are not output
+	// as previous values.
+	expEvents := []*roachpb.RangeFeedEvent{
+		rangeFeedValueWithPrev(
+			roachpb.Key("a"),
+			roachpb.Value{RawBytes: []byte("val2"), Timestamp: hlc.Timestamp{WallTime: 8}},
+			roachpb.Value{RawBytes: []byte("val3")},
+		),
+		rangeFeedValueWithPrev(
+			roachpb.Key("a"),
+			roachpb.Value{RawBytes: []byte("val1"), Timestamp: hlc.Timestamp{WallTime: 10}},
+			roachpb.Value{RawBytes: []byte("val2")},
+		),
+		rangeFeedValue(
+			roachpb.Key("b"),
+			roachpb.Value{RawBytes: []byte("val5"), Timestamp: hlc.Timestamp{WallTime: 7}},
+		),
+		rangeFeedValue(
+			roachpb.Key("c"),
+			roachpb.Value{RawBytes: []byte("val6"), Timestamp: hlc.Timestamp{WallTime: 9}},
+		),
+	}
+	require.Equal(t, expEvents, r.Events())
+}
+
+func TestRegistrationCatchUpScanRangeTombstonesWithDiff(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	iter := newTestIterator([]engine.MVCCKeyValue{
+		makeKV("a", "val1", 10),
+		makeKV("a", "val2", 2),
+		makeKV("d", "val3", 6),
+		makeKV("d", "val4", 1),
+	})
+	r := newTestRegistration(roachpb.Span{
+		Key:    roachpb.Key("a"),
+		EndKey: roachpb.Key("z"),
+	}, hlc.Timestamp{WallTime: 4}, iter)
+	r.catchupRangeTombs = []enginepb.MVCCRangeTombstone{
+		{StartKey: roachpb.Key("a"), EndKey: roachpb.Key("b"), Timestamp: hlc.Timestamp{WallTime: 5}},
+		{StartKey: roachpb.Key("d"), EndKey: roachpb.Key("e"), Timestamp: hlc.Timestamp{WallTime: 3}},
+	}
+	r.withDiff = true
+	require.NoError(t, r.runCatchupScan())
+
+	// A version overwriting a key deleted by a range tombstone has no previous
+	// value, whether or not the range tombstone was output.
+	expEvents := []*roachpb.RangeFeedEvent{
+		rangeFeedValueWithPrev(
+			roachpb.Key("a"),
+			roachpb.Value{Timestamp: hlc.Timestamp{WallTime: 5}},
+			roachpb.Value{RawBytes: []byte("val2")},
+		),
+		rangeFeedValue(
+			roachpb.Key("a"),
+			roachpb.Value{RawBytes: []byte("val1"), Timestamp: hlc.Timestamp{WallTime: 10}},
+		),
+		rangeFeedValue(
+			roachpb.Key("d"),
+			roachpb.Value{RawBytes: []byte("val3"), Timestamp: hlc.Timestamp{WallTime: 6}},
+		),
+	}
+	require.Equal(t, expEvents, r.Events())
+}
+
+func TestRegistryPublishWithDiff(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	reg := makeRegistry()
+	rAB := newTestRegistration(spAB, hlc.Timestamp{}, nil)
+	rAC := newTestRegistration(spAC, hlc.Timestamp{}, nil)
+	rAC.withDiff = true
+	go rAB.runOutputLoop(context.Background())
+	go rAC.runOutputLoop(context.Background())
+	defer rAB.disconnect(nil)
+	defer rAC.disconnect(nil)
+	reg.Register(&rAB.registration)
+	require.Equal(t, 0, reg.NumWithDiff())
+	reg.Register(&rAC.registration)
+	require.Equal(t, 1, reg.NumWithDiff())
+
+	// The previous value is only published to registrations that requested
+	// diffs.
+	val := roachpb.Value{RawBytes: []byte("val"), Timestamp: hlc.Timestamp{WallTime: 1}}
+	prev := roachpb.Value{RawBytes: []byte("prev")}
+	ev := rangeFeedValueWithPrev(roachpb.Key("a"), val, prev)
+	reg.PublishToOverlapping(spAB, ev)
+	require.NoError(t, reg.waitForCaughtUp(all))
+	require.Equal(t, []*roachpb.RangeFeedEvent{rangeFeedValue(roachpb.Key("a"), val)}, rAB.Events())
+	require.Equal(t, []*roachpb.RangeFeedEvent{ev}, rAC.Events())
+
+	reg.Disconnect(spAC)
+	require.Equal(t, 0, reg.NumWithDiff())
+}
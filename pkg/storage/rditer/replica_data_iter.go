@@ -54,7 +54,7 @@ func MakeReplicatedKeyRanges(d *roachpb.RangeDescriptor) []KeyRange {
 	return makeReplicaKeyRanges(d, keys.MakeRangeIDReplicatedPrefix)
 }
 
-// makeReplicaKeyRanges returns a slice of 3 key ranges. The last key range in
+// makeReplicaKeyRanges returns a slice of 4 key ranges. The last key range in
 // the returned slice corresponds to the actual range data (i.e. not the range
 // metadata).
 func makeReplicaKeyRanges(
@@ -77,6 +77,10 @@ func makeReplicaKeyRanges(
 			Start: engine.MakeMVCCMetadataKey(keys.MakeRangeKeyPrefix(d.StartKey)),
 			End:   engine.MakeMVCCMetadataKey(keys.MakeRangeKeyPrefix(d.EndKey)),
 		},
+		{
+			Start: engine.MakeMVCCMetadataKey(keys.RangeTombstoneKeyPrefix(d.StartKey)),
+			End:   engine.MakeMVCCMetadataKey(keys.RangeTombstoneKeyPrefix(d.EndKey)),
+		},
 		{
 			Start: engine.MakeMVCCMetadataKey(dataStartKey),
 			End:   engine.MakeMVCCMetadataKey(d.EndKey.AsRawKey()),
@@ -20,7 +20,8 @@ import (
 
 // ComputeStatsForRange computes the stats for a given range by
 // iterating over all key ranges for the given range that should
-// be accounted for in its stats.
+// be accounted for in its stats, including the effect of the MVCC
+// range tombstones on the range's user data.
 func ComputeStatsForRange(
 	d *roachpb.RangeDescriptor, e engine.Reader, nowNanos int64,
 ) (enginepb.MVCCStats, error) {
@@ -28,12 +29,23 @@ func ComputeStatsForRange(
 	defer iter.Close()
 
 	ms := enginepb.MVCCStats{}
-	for _, keyRange := range MakeReplicatedKeyRanges(d) {
+	keyRanges := MakeReplicatedKeyRanges(d)
+	for i, keyRange := range keyRanges {
 		msDelta, err := iter.ComputeStats(keyRange.Start, keyRange.End, nowNanos)
 		if err != nil {
 			return enginepb.MVCCStats{}, err
 		}
+		if i == len(keyRanges)-2 {
+			// The range tombstone index, each key of which is a range tombstone.
+			msDelta.RangeTombstoneCount = msDelta.SysCount
+		}
 		ms.Add(msDelta)
 	}
+	userKeys := keyRanges[len(keyRanges)-1]
+	msDelta, err := engine.ComputeRangeTombstoneStats(e, userKeys.Start.Key, userKeys.End.Key, nowNanos)
+	if err != nil {
+		return enginepb.MVCCStats{}, err
+	}
+	ms.Add(msDelta)
 	return ms, nil
 }
@@ -33,6 +33,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/closedts/ctpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/locktable"
 	"github.com/cockroachdb/cockroach/pkg/storage/rangefeed"
 	"github.com/cockroachdb/cockroach/pkg/storage/spanlatch"
 	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
@@ -166,6 +167,15 @@ type Replica struct {
 	// writeStats tracks the number of keys written by applied raft commands
 	// in order to aid in replica rebalancing decisions.
 	writeStats *replicaStats
+	// writeBytesStats tracks the number of bytes written by applied raft
+	// commands. Unlike writeStats, it reflects the disk bandwidth consumed by
+	// the replica, which matters for ingest-heavy ranges that write large
+	// batches at a modest rate.
+	writeBytesStats *replicaStats
+	// cpuStats tracks the nanoseconds spent evaluating requests on this
+	// replica. Wall time of evaluation is used as a proxy for CPU since Go
+	// offers no cheap per-goroutine CPU accounting.
+	cpuStats *replicaStats
 
 	// creatingReplica is set when a replica is created as uninitialized
 	// via a raft message.
@@ -209,6 +219,23 @@ type Replica struct {
 	// the rest (e.g. RangeDescriptor, transaction record, Lease, ...).
 	latchMgr spanlatch.Manager
 
+	// Tracks the locks held on the range's keys and the requests waiting on
+	// them. Only populated on the leaseholder.
+	lockTable locktable.Table
+
+	// protectedTimestampMu coordinates the verification of protected timestamp
+	// records with the GC queue. See protectedTimestampRecordApplies.
+	protectedTimestampMu struct {
+		syncutil.Mutex
+		// minStateReadTimestamp is the timestamp as of which the protected
+		// timestamp cache must be current for the GC queue to advance the GC
+		// threshold. It is advanced when a record is verified.
+		minStateReadTimestamp hlc.Timestamp
+		// pendingGCThreshold is the GC threshold which the GC queue is about to
+		// set, if any.
+		pendingGCThreshold hlc.Timestamp
+	}
+
 	mu struct {
 		// Protects all fields in the mu struct.
 		syncutil.RWMutex
@@ -447,6 +474,11 @@ type Replica struct {
 
 	// loadBasedSplitter keeps information about load-based splitting.
 	loadBasedSplitter split.Decider
+	// writeBasedSplitter and contentionBasedSplitter are like
+	// loadBasedSplitter, but measure bytes written and requests waiting on
+	// conflicting locks, respectively.
+	writeBasedSplitter      split.Decider
+	contentionBasedSplitter split.Decider
 
 	unreachablesMu struct {
 		syncutil.Mutex
@@ -657,6 +689,11 @@ func (r *Replica) NodeID() roachpb.NodeID {
 	return r.store.nodeDesc.NodeID
 }
 
+// GetNodeLocality returns the locality of the node this replica belongs to.
+func (r *Replica) GetNodeLocality() roachpb.Locality {
+	return r.store.nodeDesc.Locality
+}
+
 // ClusterSettings returns the node's ClusterSettings.
 func (r *Replica) ClusterSettings() *cluster.Settings {
 	return r.store.cfg.Settings
@@ -809,6 +846,28 @@ func (r *Replica) GetSplitQPS() float64 {
 	return r.loadBasedSplitter.LastQPS(timeutil.Now())
 }
 
+// GetSplitLoad returns the Replica's rates of bytes written and of requests
+// waiting on conflicting locks, as measured for load based splitting.
+//
+// NOTE: Like GetSplitQPS, this only works when the load based splitting
+// cluster setting is enabled.
+func (r *Replica) GetSplitLoad() (writeBytesPerSecond, contentionPerSecond float64) {
+	now := timeutil.Now()
+	return r.writeBasedSplitter.LastQPS(now), r.contentionBasedSplitter.LastQPS(now)
+}
+
+// GetRecentLoad returns the Replica's queries and bytes written per second,
+// averaged over the last several minutes.
+func (r *Replica) GetRecentLoad() (qps, writeBytesPerSecond float64) {
+	if r.leaseholderStats != nil {
+		qps, _ = r.leaseholderStats.avgQPS()
+	}
+	if r.writeBytesStats != nil {
+		writeBytesPerSecond, _ = r.writeBytesStats.avgQPS()
+	}
+	return qps, writeBytesPerSecond
+}
+
 // ContainsKey returns whether this range contains the specified key.
 //
 // TODO(bdarnell): This is not the same as RangeDescriptor.ContainsKey.
@@ -876,7 +935,8 @@ func (r *Replica) RaftStatus() *raft.Status {
 
 func (r *Replica) raftStatusRLocked() *raft.Status {
 	if rg := r.mu.internalRaftGroup; rg != nil {
-		return rg.Status()
+		s := rg.Status()
+		return &s
 	}
 	return nil
 }
@@ -1031,24 +1091,35 @@ func (r *Replica) checkBatchRequest(ba roachpb.BatchRequest, isReadOnly bool) er
 type endCmds struct {
 	repl *Replica
 	lg   *spanlatch.Guard
+	ltg  *locktable.Guard
 	ba   roachpb.BatchRequest
 }
 
 // done releases the latches acquired by the command and updates
-// the timestamp cache using the final timestamp of each command.
-func (ec *endCmds) done(br *roachpb.BatchResponse, pErr *roachpb.Error) {
+// the timestamp cache and the lock table using the final timestamp
+// of each command. An error is returned if the locks acquired by
+// the command could not be determined from its response, in which
+// case the response must not be returned to the client.
+func (ec *endCmds) done(br *roachpb.BatchResponse, pErr *roachpb.Error) error {
 	// Update the timestamp cache if the request is not being re-evaluated. Each
 	// request is considered in turn; only those marked as affecting the cache are
 	// processed. Inconsistent reads are excluded.
+	var err error
 	if ec.ba.ReadConsistency == roachpb.CONSISTENT {
 		ec.repl.updateTimestampCache(&ec.ba, br, pErr)
+		err = ec.repl.updateLockTable(&ec.ba, br, pErr)
 	}
+	// Leave the lock table's wait-queues, passing on any reservations which the
+	// request did not turn into locks. Must be done BEFORE the latches are
+	// released.
+	ec.repl.lockTable.Dequeue(ec.ltg)
 
 	// Release the latches acquired by the request back to the spanlatch
 	// manager. Must be done AFTER the timestamp cache is updated.
 	if ec.lg != nil {
 		ec.repl.latchMgr.Release(ec.lg)
 	}
+	return err
 }
 
 func (r *Replica) collectSpans(ba *roachpb.BatchRequest) (*spanset.SpanSet, error) {
@@ -1085,6 +1156,15 @@ func (r *Replica) collectSpans(ba *roachpb.BatchRequest) (*spanset.SpanSet, erro
 		}
 	}
 
+	// Commands operating on the range's user data consult its MVCC range
+	// tombstones, but only if the range has any. Most ranges don't, so they
+	// don't pay for the latch.
+	if r.mayHaveRangeTombstones() &&
+		(len(spans.GetSpans(spanset.SpanReadOnly, spanset.SpanGlobal)) > 0 ||
+			len(spans.GetSpans(spanset.SpanReadWrite, spanset.SpanGlobal)) > 0) {
+		spans.Add(spanset.SpanReadOnly, keys.RangeTombstoneSpan(desc.StartKey, desc.EndKey))
+	}
+
 	// Commands may create a large number of duplicate spans. De-duplicate
 	// them to reduce the number of spans we pass to the spanlatch manager.
 	spans.SortAndDedup()
@@ -1097,100 +1177,173 @@ func (r *Replica) collectSpans(ba *roachpb.BatchRequest) (*spanset.SpanSet, erro
 	return spans, nil
 }
 
+// mayHaveRangeTombstones returns whether the range may contain any MVCC range
+// tombstones, which are only written once the cluster version allows it and
+// are counted in the range's MVCCStats.
+func (r *Replica) mayHaveRangeTombstones() bool {
+	if !r.ClusterSettings().Version.IsActive(cluster.VersionMVCCRangeTombstones) {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mu.state.Stats.RangeTombstoneCount > 0
+}
+
+// canSkipRangeTombstones returns whether the evaluation of a batch with the
+// given declared spans, whose latches have been acquired, doesn't need to
+// consult the range's MVCC range tombstones. See
+// engine.NewReadWriterWithoutRangeTombstones.
+//
+// If the batch didn't declare the range tombstones because the range had none
+// at the time, but the range has some now, they were written by commands that
+// the batch was serialized with through the latches of its keys if they cover
+// any of these keys. The batch then consults the range tombstones without a
+// latch, which is safe since range tombstones covering its keys can't change
+// while it holds the latches of the keys. The access is added to the declared
+// spans to pass the assertions of race builds.
+func (r *Replica) canSkipRangeTombstones(spans *spanset.SpanSet) bool {
+	desc := r.Desc()
+	tombSpan := keys.RangeTombstoneSpan(desc.StartKey, desc.EndKey)
+	if spans.CheckAllowed(spanset.SpanReadOnly, tombSpan) == nil {
+		return false
+	}
+	if !r.mayHaveRangeTombstones() {
+		return true
+	}
+	spans.Add(spanset.SpanReadOnly, tombSpan)
+	return false
+}
+
+// acquireLatches acquires latches for the request's declared spans, waiting
+// for any overlapping, already-executing commands to complete. It returns an
+// error if the request must wait for an in-progress merge.
+func (r *Replica) acquireLatches(
+	ctx context.Context, ba *roachpb.BatchRequest, spans *spanset.SpanSet,
+) (*spanlatch.Guard, error) {
+	// Check for context cancellation before acquiring latches.
+	if err := ctx.Err(); err != nil {
+		log.VEventf(ctx, 2, "%s before acquiring latches: %s", err, ba.Summary())
+		return nil, errors.Wrap(err, "aborted before acquiring latches")
+	}
+
+	var beforeLatch time.Time
+	if log.ExpensiveLogEnabled(ctx, 2) {
+		beforeLatch = timeutil.Now()
+	}
+
+	// Acquire latches for all the request's declared spans to ensure
+	// protected access and to avoid interacting requests from operating at
+	// the same time. The latches will be held for the duration of request.
+	lg, err := r.latchMgr.Acquire(ctx, spans, ba.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	if !beforeLatch.IsZero() {
+		dur := timeutil.Since(beforeLatch)
+		log.VEventf(ctx, 2, "waited %s to acquire latches", dur)
+	}
+
+	if filter := r.store.cfg.TestingKnobs.TestingLatchFilter; filter != nil {
+		if pErr := filter(*ba); pErr != nil {
+			r.latchMgr.Release(lg)
+			return nil, pErr.GoError()
+		}
+	}
+
+	if r.getMergeCompleteCh() != nil && !ba.IsSingleSubsumeRequest() {
+		// The replica is being merged into its left-hand neighbor. This request
+		// cannot proceed until the merge completes, signaled by the closing of
+		// the channel.
+		//
+		// It is very important that this check occur after we have acquired latches
+		// from the spanlatch manager. Only after we release these latches are we
+		// guaranteed that we're not racing with a Subsume command. (Subsume
+		// commands declare a conflict with all other commands.)
+		//
+		// Note that Subsume commands are exempt from waiting on the mergeComplete
+		// channel. This is necessary to avoid deadlock. While normally a Subsume
+		// request will trigger the installation of a mergeComplete channel after
+		// it is executed, it may sometimes execute after the mergeComplete
+		// channel has been installed. Consider the case where the RHS replica
+		// acquires a new lease after the merge transaction deletes its local
+		// range descriptor but before the Subsume command is sent. The lease
+		// acquisition request will notice the intent on the local range
+		// descriptor and install a mergeComplete channel. If the forthcoming
+		// Subsume blocked on that channel, the merge transaction would deadlock.
+		//
+		// This exclusion admits a small race condition. If a Subsume request is
+		// sent to the right-hand side of a merge, outside of a merge transaction,
+		// after the merge has committed but before the RHS has noticed that the
+		// merge has committed, the request may return stale data. Since the merge
+		// has committed, the LHS may have processed writes to the keyspace
+		// previously owned by the RHS that the RHS is unaware of. This window
+		// closes quickly, as the RHS will soon notice the merge transaction has
+		// committed and mark itself as destroyed, which prevents it from serving
+		// all traffic, including Subsume requests.
+		//
+		// In our current, careful usage of Subsume, this race condition is
+		// irrelevant. Subsume is only sent from within a merge transaction, and
+		// merge transactions read the RHS descriptor at the beginning of the
+		// transaction to verify that it has not already been merged away.
+		//
+		// We can't wait for the merge to complete here, though. The replica might
+		// need to respond to a Subsume request in order for the merge to
+		// complete, and blocking here would force that Subsume request to sit in
+		// hold its latches forever, deadlocking the merge. Instead, we release
+		// the latches we acquired above and return a MergeInProgressError.
+		// The store will catch that error and resubmit the request after
+		// mergeCompleteCh closes. See #27442 for the full context.
+		log.Event(ctx, "waiting on in-progress merge")
+		r.latchMgr.Release(lg)
+		return nil, &roachpb.MergeInProgressError{}
+	}
+	return lg, nil
+}
+
 // beginCmds waits for any in-flight, conflicting commands to complete. This
 // includes merges in their critical phase or overlapping, already-executing
 // commands.
 //
 // More specifically, after waiting for in-flight merges, beginCmds acquires
 // latches for the request based on keys affected by the batched commands.
-// This gates subsequent commands with overlapping keys or key ranges. It
-// returns a cleanup function to be called when the commands are done and can be
-// removed from the queue, and whose returned error is to be used in place of
-// the supplied error.
+// This gates subsequent commands with overlapping keys or key ranges. It then
+// waits for any locks held by other transactions on the keys accessed by the
+// request to be released. It returns a cleanup function to be called when the
+// commands are done and can be removed from the queue, and whose returned error
+// is to be used in place of the supplied error.
 func (r *Replica) beginCmds(
 	ctx context.Context, ba *roachpb.BatchRequest, spans *spanset.SpanSet,
 ) (*endCmds, error) {
 	// Only acquire latches for consistent operations.
 	var lg *spanlatch.Guard
+	var ltg *locktable.Guard
 	if ba.ReadConsistency == roachpb.CONSISTENT {
-		// Check for context cancellation before acquiring latches.
-		if err := ctx.Err(); err != nil {
-			log.VEventf(ctx, 2, "%s before acquiring latches: %s", err, ba.Summary())
-			return nil, errors.Wrap(err, "aborted before acquiring latches")
-		}
-
-		var beforeLatch time.Time
-		if log.ExpensiveLogEnabled(ctx, 2) {
-			beforeLatch = timeutil.Now()
-		}
-
-		// Acquire latches for all the request's declared spans to ensure
-		// protected access and to avoid interacting requests from operating at
-		// the same time. The latches will be held for the duration of request.
-		var err error
-		lg, err = r.latchMgr.Acquire(ctx, spans, ba.Timestamp)
-		if err != nil {
-			return nil, err
-		}
-
-		if !beforeLatch.IsZero() {
-			dur := timeutil.Since(beforeLatch)
-			log.VEventf(ctx, 2, "waited %s to acquire latches", dur)
-		}
-
-		if filter := r.store.cfg.TestingKnobs.TestingLatchFilter; filter != nil {
-			if pErr := filter(*ba); pErr != nil {
-				r.latchMgr.Release(lg)
-				return nil, pErr.GoError()
+		ltReq, waitsOnLocks := r.lockTableRequest(ba)
+		for {
+			var err error
+			if lg, err = r.acquireLatches(ctx, ba, spans); err != nil {
+				r.lockTable.Dequeue(ltg)
+				return nil, err
+			}
+			if !waitsOnLocks || !r.lockTableEnabled() {
+				break
 			}
-		}
 
-		if r.getMergeCompleteCh() != nil && !ba.IsSingleSubsumeRequest() {
-			// The replica is being merged into its left-hand neighbor. This request
-			// cannot proceed until the merge completes, signaled by the closing of
-			// the channel.
-			//
-			// It is very important that this check occur after we have acquired latches
-			// from the spanlatch manager. Only after we release these latches are we
-			// guaranteed that we're not racing with a Subsume command. (Subsume
-			// commands declare a conflict with all other commands.)
-			//
-			// Note that Subsume commands are exempt from waiting on the mergeComplete
-			// channel. This is necessary to avoid deadlock. While normally a Subsume
-			// request will trigger the installation of a mergeComplete channel after
-			// it is executed, it may sometimes execute after the mergeComplete
-			// channel has been installed. Consider the case where the RHS replica
-			// acquires a new lease after the merge transaction deletes its local
-			// range descriptor but before the Subsume command is sent. The lease
-			// acquisition request will notice the intent on the local range
-			// descriptor and install a mergeComplete channel. If the forthcoming
-			// Subsume blocked on that channel, the merge transaction would deadlock.
-			//
-			// This exclusion admits a small race condition. If a Subsume request is
-			// sent to the right-hand side of a merge, outside of a merge transaction,
-			// after the merge has committed but before the RHS has noticed that the
-			// merge has committed, the request may return stale data. Since the merge
-			// has committed, the LHS may have processed writes to the keyspace
-			// previously owned by the RHS that the RHS is unaware of. This window
-			// closes quickly, as the RHS will soon notice the merge transaction has
-			// committed and mark itself as destroyed, which prevents it from serving
-			// all traffic, including Subsume requests.
-			//
-			// In our current, careful usage of Subsume, this race condition is
-			// irrelevant. Subsume is only sent from within a merge transaction, and
-			// merge transactions read the RHS descriptor at the beginning of the
-			// transaction to verify that it has not already been merged away.
-			//
-			// We can't wait for the merge to complete here, though. The replica might
-			// need to respond to a Subsume request in order for the merge to
-			// complete, and blocking here would force that Subsume request to sit in
-			// hold its latches forever, deadlocking the merge. Instead, we release
-			// the latches we acquired above and return a MergeInProgressError.
-			// The store will catch that error and resubmit the request after
-			// mergeCompleteCh closes. See #27442 for the full context.
-			log.Event(ctx, "waiting on in-progress merge")
+			// Now that the latches are held, check whether any of the keys that
+			// the request accesses are locked by other transactions. If so,
+			// release the latches, wait for the lock to be released and try
+			// again.
+			var ws locktable.WaitState
+			var wait bool
+			if ltg, ws, wait = r.lockTable.ScanAndEnqueue(ltReq, ltg); !wait {
+				break
+			}
 			r.latchMgr.Release(lg)
-			return nil, &roachpb.MergeInProgressError{}
+			if pErr := r.waitOnLock(ctx, ba, ltg, ws); pErr != nil {
+				r.lockTable.Dequeue(ltg)
+				return nil, pErr.GoError()
+			}
 		}
 	} else {
 		log.Event(ctx, "operation accepts inconsistent results")
@@ -1209,6 +1362,7 @@ func (r *Replica) beginCmds(
 	ec := &endCmds{
 		repl: r,
 		lg:   lg,
+		ltg:  ltg,
 		ba:   *ba,
 	}
 	return ec, nil
@@ -1317,6 +1471,11 @@ func (r *Replica) executeAdminBatch(
 		pErr = roachpb.NewError(err)
 		resp = &reply
 
+	case *roachpb.AdminVerifyProtectedTimestampRequest:
+		reply, err := r.adminVerifyProtectedTimestamp(ctx, *tArgs)
+		pErr = roachpb.NewError(err)
+		resp = &reply
+
 	default:
 		return nil, roachpb.NewErrorf("unrecognized admin command: %T", args)
 	}
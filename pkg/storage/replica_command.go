@@ -39,6 +39,7 @@ import (
 	"github.com/pkg/errors"
 	"go.etcd.io/etcd/raft"
 	"go.etcd.io/etcd/raft/raftpb"
+	"go.etcd.io/etcd/raft/tracker"
 )
 
 // AdminSplit divides the range into into two ranges using args.SplitKey.
@@ -115,17 +116,17 @@ func splitSnapshotWarningStr(rangeID roachpb.RangeID, status *raft.Status) strin
 				// https://github.com/etcd-io/etcd/pull/10279
 				continue
 			}
-			if pr.State == raft.ProgressStateReplicate {
+			if pr.State == tracker.StateReplicate {
 				// This follower is in good working order.
 				continue
 			}
 			s += fmt.Sprintf("; r%d/%d is ", rangeID, replicaID)
 			switch pr.State {
-			case raft.ProgressStateSnapshot:
+			case tracker.StateSnapshot:
 				// If the Raft snapshot queue is backed up, replicas can spend
 				// minutes or worse until they are caught up.
 				s += "waiting for a Raft snapshot"
-			case raft.ProgressStateProbe:
+			case tracker.StateProbe:
 				// Assuming the split has already been delayed for a little bit,
 				// seeing a follower that is probing hints at some problem with
 				// Raft or Raft message delivery. (Of course it's possible that
@@ -792,6 +793,13 @@ func (r *Replica) changeReplicas(
 	if desc == nil {
 		return nil, errors.Errorf("%s: the current RangeDescriptor must not be nil", r)
 	}
+	// A previous atomic replication change may have been interrupted after
+	// entering its joint configuration. Raft refuses further configuration
+	// changes until the joint configuration is left, so do that first.
+	desc, err := r.maybeLeaveAtomicChangeReplicas(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
 	repDesc := roachpb.ReplicaDescriptor{
 		NodeID:  target.NodeID,
 		StoreID: target.StoreID,
@@ -809,12 +817,16 @@ func (r *Replica) changeReplicas(
 		}
 	}
 
-	rangeID := desc.RangeID
 	updatedDesc := *desc
 	updatedDesc.SetReplicas(desc.Replicas().DeepCopy())
 
 	switch changeType {
-	case roachpb.ADD_REPLICA:
+	case roachpb.ADD_REPLICA, roachpb.ADD_NON_VOTER:
+		if changeType == roachpb.ADD_NON_VOTER &&
+			!r.store.ClusterSettings().Version.IsActive(cluster.VersionNonVoters) {
+			return nil, errors.Errorf("%s: adding non-voting replicas requires all nodes to be upgraded to %s",
+				r, cluster.VersionByKey(cluster.VersionNonVoters))
+		}
 		// If the replica exists on the remote node, no matter in which store,
 		// abort the replica add.
 		if nodeUsed {
@@ -851,9 +863,22 @@ func (r *Replica) changeReplicas(
 		}
 
 		repDesc.ReplicaID = updatedDesc.NextReplicaID
+		if changeType == roachpb.ADD_NON_VOTER {
+			repDesc.Type = roachpb.ReplicaType_NON_VOTER
+		}
 		updatedDesc.NextReplicaID++
 		updatedDesc.AddReplica(repDesc)
 
+	case roachpb.PROMOTE_NON_VOTER:
+		// The non-voter already has the range's data and keeps its replica ID,
+		// so no snapshot is needed; raft turns the learner into a voter.
+		if repDescIdx == -1 || repDesc.Type != roachpb.ReplicaType_NON_VOTER {
+			return nil, errors.Errorf("%s: unable to promote replica %v which is not a non-voter", r, repDesc)
+		}
+		updatedDesc.RemoveReplica(repDesc.NodeID, repDesc.StoreID)
+		repDesc.Type = roachpb.ReplicaType_VOTER
+		updatedDesc.AddReplica(repDesc)
+
 	case roachpb.REMOVE_REPLICA:
 		// If that exact node-store combination does not have the replica,
 		// abort the removal.
@@ -865,6 +890,230 @@ func (r *Replica) changeReplicas(
 		}
 	}
 
+	crt := roachpb.ChangeReplicasTrigger{
+		ChangeType:      changeType,
+		Replica:         repDesc,
+		UpdatedReplicas: updatedDesc.Replicas().Unwrap(),
+		NextReplicaID:   updatedDesc.NextReplicaID,
+	}
+	logChanges := func(txn *client.Txn) error {
+		return r.store.logChange(ctx, txn, changeType, repDesc, updatedDesc, reason, details)
+	}
+	if err := r.execChangeReplicasTxn(ctx, desc, &updatedDesc, crt, logChanges); err != nil {
+		return nil, err
+	}
+	return &updatedDesc, nil
+}
+
+// ChangeReplicasAtomic carries out the given replication changes as a single
+// atomic step. Unlike a sequence of calls to ChangeReplicas, which adds or
+// removes one replica at a time and briefly leaves the range with one replica
+// too few or too many, the changes take effect in one raft configuration
+// change.
+//
+// Adding and removing voters atomically requires raft's joint consensus: the
+// range first enters a joint configuration, in which both the old and the new
+// set of voters must agree on every decision, and then leaves it for the new
+// configuration. Each of the two steps is a transaction on the range
+// descriptor like the one described on ChangeReplicas. While the range is in
+// the joint configuration, added voters are of type VOTER_INCOMING and
+// removed voters of type VOTER_OUTGOING. Should the process be interrupted
+// between the two steps, the joint configuration is left by the replicate
+// queue or by the next replication change of the range.
+//
+// Only additions (ADD_REPLICA, ADD_NON_VOTER) and removals (REMOVE_REPLICA)
+// are supported. Changes that don't involve voters don't affect the quorum and
+// are carried out one by one.
+func (r *Replica) ChangeReplicasAtomic(
+	ctx context.Context,
+	desc *roachpb.RangeDescriptor,
+	reason storagepb.RangeLogEventReason,
+	details string,
+	chgs []roachpb.ReplicationChange,
+) (updatedDesc *roachpb.RangeDescriptor, _ error) {
+	return r.changeReplicasAtomic(ctx, desc, SnapshotRequest_REBALANCE, reason, details, chgs)
+}
+
+func (r *Replica) changeReplicasAtomic(
+	ctx context.Context,
+	desc *roachpb.RangeDescriptor,
+	priority SnapshotRequest_Priority,
+	reason storagepb.RangeLogEventReason,
+	details string,
+	chgs []roachpb.ReplicationChange,
+) (_ *roachpb.RangeDescriptor, _ error) {
+	if desc == nil {
+		return nil, errors.Errorf("%s: the current RangeDescriptor must not be nil", r)
+	}
+	if len(chgs) == 0 {
+		return desc, nil
+	}
+	if len(chgs) == 1 {
+		return r.changeReplicas(ctx, chgs[0].ChangeType, chgs[0].Target, desc, priority, reason, details)
+	}
+	if !r.store.ClusterSettings().Version.IsActive(cluster.VersionAtomicChangeReplicas) {
+		return nil, errors.Errorf("%s: atomic replication changes require all nodes to be upgraded to %s",
+			r, cluster.VersionByKey(cluster.VersionAtomicChangeReplicas))
+	}
+	desc, err := r.maybeLeaveAtomicChangeReplicas(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the changes against the current descriptor before sending any
+	// snapshots, and determine whether any of them affects the voters.
+	var involvesVoters bool
+	usedNodes := make(map[roachpb.NodeID]struct{})
+	for _, rDesc := range desc.Replicas().All() {
+		usedNodes[rDesc.NodeID] = struct{}{}
+	}
+	for _, chg := range chgs {
+		switch chg.ChangeType {
+		case roachpb.ADD_REPLICA, roachpb.ADD_NON_VOTER:
+			if _, ok := usedNodes[chg.Target.NodeID]; ok {
+				return nil, errors.Errorf("%s: unable to add replica on %v; node already has a replica", r, chg.Target)
+			}
+			usedNodes[chg.Target.NodeID] = struct{}{}
+			involvesVoters = involvesVoters || chg.ChangeType == roachpb.ADD_REPLICA
+		case roachpb.REMOVE_REPLICA:
+			rDesc, ok := desc.GetReplicaDescriptor(chg.Target.StoreID)
+			if !ok || rDesc.NodeID != chg.Target.NodeID {
+				return nil, errors.Errorf("%s: unable to remove replica on %v which is not present", r, chg.Target)
+			}
+			if rDesc.StoreID == r.store.StoreID() {
+				return nil, errors.Errorf("%s: unable to remove the leaseholder %v atomically", r, rDesc)
+			}
+			involvesVoters = involvesVoters || rDesc.Type.IsVoter()
+		default:
+			return nil, errors.Errorf("%s: unsupported change %s in atomic replication change", r, chg.ChangeType)
+		}
+	}
+	if !involvesVoters {
+		for _, chg := range chgs {
+			if desc, err = r.changeReplicas(
+				ctx, chg.ChangeType, chg.Target, desc, priority, reason, details,
+			); err != nil {
+				return nil, err
+			}
+		}
+		return desc, nil
+	}
+
+	updatedDesc := *desc
+	updatedDesc.SetReplicas(desc.Replicas().DeepCopy())
+	crt := roachpb.ChangeReplicasTrigger{}
+	for _, chg := range chgs {
+		if chg.ChangeType == roachpb.REMOVE_REPLICA {
+			continue
+		}
+		repDesc := roachpb.ReplicaDescriptor{
+			NodeID:  chg.Target.NodeID,
+			StoreID: chg.Target.StoreID,
+		}
+		// See changeReplicas for why the preemptive snapshot is addressed to a
+		// replica without a replica ID.
+		if err := r.sendSnapshot(ctx, repDesc, SnapshotRequest_PREEMPTIVE, priority); err != nil {
+			return nil, err
+		}
+		repDesc.ReplicaID = updatedDesc.NextReplicaID
+		repDesc.Type = roachpb.ReplicaType_VOTER_INCOMING
+		if chg.ChangeType == roachpb.ADD_NON_VOTER {
+			repDesc.Type = roachpb.ReplicaType_NON_VOTER
+		}
+		updatedDesc.NextReplicaID++
+		updatedDesc.AddReplica(repDesc)
+		crt.InternalAddedReplicas = append(crt.InternalAddedReplicas, repDesc)
+	}
+	for _, chg := range chgs {
+		if chg.ChangeType != roachpb.REMOVE_REPLICA {
+			continue
+		}
+		repDesc, _ := updatedDesc.RemoveReplica(chg.Target.NodeID, chg.Target.StoreID)
+		if repDesc.Type.IsVoter() {
+			// Removed voters remain part of the outgoing configuration until the
+			// joint configuration is left.
+			repDesc.Type = roachpb.ReplicaType_VOTER_OUTGOING
+			updatedDesc.AddReplica(repDesc)
+		}
+		crt.InternalRemovedReplicas = append(crt.InternalRemovedReplicas, repDesc)
+	}
+	crt.UpdatedReplicas = updatedDesc.Replicas().Unwrap()
+	crt.NextReplicaID = updatedDesc.NextReplicaID
+
+	logChanges := func(txn *client.Txn) error {
+		for _, rDesc := range crt.InternalAddedReplicas {
+			changeType := roachpb.ADD_REPLICA
+			if rDesc.Type == roachpb.ReplicaType_NON_VOTER {
+				changeType = roachpb.ADD_NON_VOTER
+			}
+			if err := r.store.logChange(
+				ctx, txn, changeType, rDesc, updatedDesc, reason, details,
+			); err != nil {
+				return err
+			}
+		}
+		for _, rDesc := range crt.InternalRemovedReplicas {
+			if err := r.store.logChange(
+				ctx, txn, roachpb.REMOVE_REPLICA, rDesc, updatedDesc, reason, details,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := r.execChangeReplicasTxn(ctx, desc, &updatedDesc, crt, logChanges); err != nil {
+		return nil, err
+	}
+	return r.maybeLeaveAtomicChangeReplicas(ctx, &updatedDesc)
+}
+
+// maybeLeaveAtomicChangeReplicas leaves the joint configuration described by
+// desc, if any, by turning incoming voters into voters and dropping outgoing
+// voters. It returns the updated descriptor, or desc itself if the range is
+// not in a joint configuration.
+func (r *Replica) maybeLeaveAtomicChangeReplicas(
+	ctx context.Context, desc *roachpb.RangeDescriptor,
+) (*roachpb.RangeDescriptor, error) {
+	if !desc.Replicas().InAtomicReplicationChange() {
+		return desc, nil
+	}
+	var replicas []roachpb.ReplicaDescriptor
+	for _, rDesc := range desc.Replicas().All() {
+		switch rDesc.Type {
+		case roachpb.ReplicaType_VOTER_INCOMING:
+			rDesc.Type = roachpb.ReplicaType_VOTER
+		case roachpb.ReplicaType_VOTER_OUTGOING:
+			continue
+		}
+		replicas = append(replicas, rDesc)
+	}
+	updatedDesc := *desc
+	updatedDesc.SetReplicas(roachpb.MakeReplicaDescriptors(replicas))
+
+	log.Eventf(ctx, "leaving joint configuration of %s", desc)
+	crt := roachpb.ChangeReplicasTrigger{
+		UpdatedReplicas: updatedDesc.Replicas().Unwrap(),
+		NextReplicaID:   updatedDesc.NextReplicaID,
+		LeaveJoint:      true,
+	}
+	// The replicas were logged as removed when entering the joint
+	// configuration, so there is nothing to log here.
+	noLog := func(*client.Txn) error { return nil }
+	if err := r.execChangeReplicasTxn(ctx, desc, &updatedDesc, crt, noLog); err != nil {
+		return nil, err
+	}
+	return &updatedDesc, nil
+}
+
+// execChangeReplicasTxn runs the transaction which replaces desc with
+// updatedDesc and carries the given ChangeReplicasTrigger. logChanges is
+// invoked within the transaction to record the change in the range event log.
+func (r *Replica) execChangeReplicasTxn(
+	ctx context.Context,
+	desc, updatedDesc *roachpb.RangeDescriptor,
+	crt roachpb.ChangeReplicasTrigger,
+	logChanges func(*client.Txn) error,
+) error {
 	descKey := keys.RangeDescriptorKey(desc.StartKey)
 
 	if err := r.store.DB().Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
@@ -879,15 +1128,14 @@ func (r *Replica) changeReplicas(
 		if err := txn.GetProto(ctx, descKey, oldDesc); err != nil {
 			return err
 		}
-		log.Infof(ctx, "change replicas (%v %s): read existing descriptor %s",
-			changeType, repDesc, oldDesc)
+		log.Infof(ctx, "change replicas (%s): read existing descriptor %s", crt, oldDesc)
 
 		{
 			b := txn.NewBatch()
 
 			// Important: the range descriptor must be the first thing touched in the transaction
 			// so the transaction record is co-located with the range being modified.
-			if err := updateRangeDescriptor(b, descKey, desc, &updatedDesc); err != nil {
+			if err := updateRangeDescriptor(b, descKey, desc, updatedDesc); err != nil {
 				return err
 			}
 
@@ -898,9 +1146,7 @@ func (r *Replica) changeReplicas(
 		}
 
 		// Log replica change into range event log.
-		if err := r.store.logChange(
-			ctx, txn, changeType, repDesc, updatedDesc, reason, details,
-		); err != nil {
+		if err := logChanges(txn); err != nil {
 			return err
 		}
 
@@ -909,7 +1155,7 @@ func (r *Replica) changeReplicas(
 		b := txn.NewBatch()
 
 		// Update range descriptor addressing record(s).
-		if err := updateRangeAddressing(b, &updatedDesc); err != nil {
+		if err := updateRangeAddressing(b, updatedDesc); err != nil {
 			return err
 		}
 
@@ -919,12 +1165,7 @@ func (r *Replica) changeReplicas(
 				// TODO(benesch): this trigger should just specify the updated
 				// descriptor, like the split and merge triggers, so that the receiver
 				// doesn't need to reconstruct the range descriptor update.
-				ChangeReplicasTrigger: &roachpb.ChangeReplicasTrigger{
-					ChangeType:      changeType,
-					Replica:         repDesc,
-					UpdatedReplicas: updatedDesc.Replicas().Unwrap(),
-					NextReplicaID:   updatedDesc.NextReplicaID,
-				},
+				ChangeReplicasTrigger: &crt,
 			},
 		})
 		if err := txn.Run(ctx, b); err != nil {
@@ -944,10 +1185,10 @@ func (r *Replica) changeReplicas(
 		if msg, ok := maybeDescriptorChangedError(desc, err); ok {
 			err = &benignError{errors.New(msg)}
 		}
-		return nil, errors.Wrapf(err, "change replicas of r%d failed", rangeID)
+		return errors.Wrapf(err, "change replicas of r%d failed", desc.RangeID)
 	}
 	log.Event(ctx, "txn complete")
-	return &updatedDesc, nil
+	return nil
 }
 
 // sendSnapshot sends a snapshot of the replica state to the specified
@@ -503,7 +503,8 @@ func (r *Replica) sha512(
 	// In statsOnly mode, we hash only the RangeAppliedState. In regular mode, hash
 	// all of the replicated key space.
 	if !statsOnly {
-		for _, span := range rditer.MakeReplicatedKeyRanges(&desc) {
+		keyRanges := rditer.MakeReplicatedKeyRanges(&desc)
+		for _, span := range keyRanges {
 			spanMS, err := engine.ComputeStatsGo(
 				iter, span.Start, span.End, 0 /* nowNanos */, visitor,
 			)
@@ -512,6 +513,14 @@ func (r *Replica) sha512(
 			}
 			ms.Add(spanMS)
 		}
+		userKeys := keyRanges[len(keyRanges)-1]
+		tombMS, err := engine.ComputeRangeTombstoneStats(
+			snap, userKeys.Start.Key, userKeys.End.Key, 0, /* nowNanos */
+		)
+		if err != nil {
+			return nil, err
+		}
+		ms.Add(tombMS)
 	}
 
 	var result replicaHash
@@ -85,6 +85,11 @@ func (rec *SpanSetReplicaEvalContext) NodeID() roachpb.NodeID {
 	return rec.i.NodeID()
 }
 
+// GetNodeLocality returns the node locality.
+func (rec *SpanSetReplicaEvalContext) GetNodeLocality() roachpb.Locality {
+	return rec.i.GetNodeLocality()
+}
+
 // Engine returns the engine.
 func (rec *SpanSetReplicaEvalContext) Engine() engine.Engine {
 	return rec.i.Engine()
@@ -141,6 +146,22 @@ func (rec SpanSetReplicaEvalContext) GetSplitQPS() float64 {
 	return rec.i.GetSplitQPS()
 }
 
+// GetSplitLoad returns the Replica's write and contention rates for splitting
+// purposes.
+func (rec SpanSetReplicaEvalContext) GetSplitLoad() (float64, float64) {
+	return rec.i.GetSplitLoad()
+}
+
+// GetRecentLoad returns the Replica's recent average QPS and write rate.
+func (rec SpanSetReplicaEvalContext) GetRecentLoad() (float64, float64) {
+	return rec.i.GetRecentLoad()
+}
+
+// GetClosedTimestamp returns the Replica's closed timestamp.
+func (rec SpanSetReplicaEvalContext) GetClosedTimestamp(ctx context.Context) hlc.Timestamp {
+	return rec.i.GetClosedTimestamp(ctx)
+}
+
 // CanCreateTxnRecord determines whether a transaction record can be created
 // for the provided transaction information. See Replica.CanCreateTxnRecord
 // for details about its arguments, return values, and preconditions.
@@ -53,6 +53,12 @@ func newReplica(rangeID roachpb.RangeID, store *Store) *Replica {
 	split.Init(&r.loadBasedSplitter, rand.Intn, func() float64 {
 		return float64(SplitByLoadQPSThreshold.Get(&store.cfg.Settings.SV))
 	})
+	split.Init(&r.writeBasedSplitter, rand.Intn, func() float64 {
+		return float64(SplitByLoadWriteBytesThreshold.Get(&store.cfg.Settings.SV))
+	})
+	split.Init(&r.contentionBasedSplitter, rand.Intn, func() float64 {
+		return float64(SplitByLoadContentionThreshold.Get(&store.cfg.Settings.SV))
+	})
 
 	if leaseHistoryMaxEntries > 0 {
 		r.leaseHistory = newLeaseHistory()
@@ -63,6 +69,8 @@ func newReplica(rangeID roachpb.RangeID, store *Store) *Replica {
 	// Pass nil for the localityOracle because we intentionally don't track the
 	// origin locality of write load.
 	r.writeStats = newReplicaStats(store.Clock(), nil)
+	r.writeBytesStats = newReplicaStats(store.Clock(), nil)
+	r.cpuStats = newReplicaStats(store.Clock(), nil)
 
 	// Init rangeStr with the range ID.
 	r.rangeStr.store(0, &roachpb.RangeDescriptor{RangeID: rangeID})
@@ -0,0 +1,278 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/intentresolver"
+	"github.com/cockroachdb/cockroach/pkg/storage/locktable"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/pkg/errors"
+)
+
+// LockTableEnabled wraps "kv.lock_table.enabled".
+var LockTableEnabled = settings.RegisterBoolSetting(
+	"kv.lock_table.enabled",
+	"if enabled, requests which conflict with locks wait in a per-range lock table "+
+		"instead of immediately pushing the lock holder",
+	false,
+)
+
+// LockTableDeadlockDetectionPushDelay wraps
+// "kv.lock_table.deadlock_detection_push_delay".
+var LockTableDeadlockDetectionPushDelay = settings.RegisterNonNegativeDurationSetting(
+	"kv.lock_table.deadlock_detection_push_delay",
+	"the amount of time a request waits on a lock before pushing the lock holder, "+
+		"which is required to detect deadlocks between transactions",
+	100*time.Millisecond,
+)
+
+// lockTableEnabled returns whether requests wait in the lock table.
+func (r *Replica) lockTableEnabled() bool {
+	return LockTableEnabled.Get(&r.store.cfg.Settings.SV)
+}
+
+// waitsOnLocks returns whether the request waits on conflicting locks in the
+// lock table before being evaluated. Requests which manipulate transaction
+// records or resolve intents must not wait on locks, as they are required for
+// the locks to be released.
+func waitsOnLocks(req roachpb.Request) bool {
+	switch req.Method() {
+	case roachpb.Get, roachpb.Scan, roachpb.ReverseScan:
+		return true
+	default:
+		return roachpb.IsTransactionWrite(req)
+	}
+}
+
+// lockTableRequest returns the lock table request corresponding to the batch.
+// It returns false if none of the batch's requests wait on locks.
+func (r *Replica) lockTableRequest(ba *roachpb.BatchRequest) (locktable.Request, bool) {
+	var req locktable.Request
+	for _, union := range ba.Requests {
+		arg := union.GetInner()
+		if !waitsOnLocks(arg) {
+			continue
+		}
+		if roachpb.IsTransactionWrite(arg) || roachpb.IsLocking(arg) {
+			req.LockSpans = append(req.LockSpans, arg.Header().Span())
+		} else {
+			req.ReadSpans = append(req.ReadSpans, arg.Header().Span())
+		}
+	}
+	if len(req.LockSpans) == 0 && len(req.ReadSpans) == 0 {
+		return locktable.Request{}, false
+	}
+	req.Timestamp = ba.Timestamp
+	if ba.Txn != nil {
+		req.Txn = &ba.Txn.TxnMeta
+		// Reads observe intents within their uncertainty interval.
+		req.Timestamp.Forward(ba.Txn.MaxTimestamp)
+	}
+	return req, true
+}
+
+// waitOnLock waits for the state of the lock that the request is queued on to
+// change, at which point the request should scan the lock table again. If the
+// lock is held by a transaction and does not change state within the deadlock
+// detection delay, the holder is pushed. Pushes wait in the txnWaitQueue of
+// the holder's transaction record, which detects dependency cycles between
+// transactions.
+func (r *Replica) waitOnLock(
+	ctx context.Context, ba *roachpb.BatchRequest, g *locktable.Guard, ws locktable.WaitState,
+) *roachpb.Error {
+	r.recordContention(ctx, ws.Key)
+	var pushTimer timeutil.Timer
+	defer pushTimer.Stop()
+	if ws.Holder != nil {
+		log.VEventf(ctx, 2, "waiting on %s lock on %s held by %s",
+			ws.Durability, ws.Key, ws.Holder.ID.Short())
+		pushTimer.Reset(LockTableDeadlockDetectionPushDelay.Get(&r.store.cfg.Settings.SV))
+	} else {
+		log.VEventf(ctx, 2, "waiting on reservation of %s", ws.Key)
+	}
+
+	select {
+	case <-g.NewStateChan():
+		return nil
+	case <-pushTimer.C:
+		pushTimer.Read = true
+		return r.pushLockHolder(ctx, ba, ws)
+	case <-ctx.Done():
+		return roachpb.NewError(errors.Wrap(ctx.Err(), "aborted while waiting on lock"))
+	case <-r.store.stopper.ShouldQuiesce():
+		return roachpb.NewError(&roachpb.NodeUnavailableError{})
+	}
+}
+
+// pushLockHolder pushes the transaction holding the lock that the request is
+// waiting on. Writers and locking readers abort the holder while non-locking
+// readers push its timestamp above their own. Once the push succeeds, the
+// lock is updated to reflect the holder's new state and, if it is an intent,
+// the intent is resolved.
+func (r *Replica) pushLockHolder(
+	ctx context.Context, ba *roachpb.BatchRequest, ws locktable.WaitState,
+) *roachpb.Error {
+	pushType := roachpb.PUSH_TIMESTAMP
+	if ws.Locking {
+		pushType = roachpb.PUSH_ABORT
+	}
+	h := ba.Header
+	if h.Txn != nil {
+		// Push the holder above the timestamp at which the request observes
+		// intents so that it no longer conflicts with the lock. See the
+		// corresponding logic in Store.Send for the reason to go all the way
+		// up to the observed timestamp.
+		h.Timestamp.Forward(h.Txn.MaxTimestamp)
+		if obsTS, ok := h.Txn.GetObservedTimestamp(ba.Replica.NodeID); ok {
+			h.Timestamp.Forward(obsTS)
+		}
+		h.Txn = h.Txn.Clone()
+	}
+
+	pushTxns := map[uuid.UUID]enginepb.TxnMeta{ws.Holder.ID: *ws.Holder}
+	pushedTxns, pErr := r.store.intentResolver.MaybePushTransactions(
+		ctx, pushTxns, h, pushType, false, /* skipIfInFlight */
+	)
+	if pErr != nil {
+		return pErr
+	}
+	pushee, ok := pushedTxns[ws.Holder.ID]
+	if !ok {
+		return nil
+	}
+	intent := roachpb.Intent{
+		Span:   roachpb.Span{Key: ws.Key},
+		Txn:    pushee.TxnMeta,
+		Status: pushee.Status,
+	}
+	if ws.Durability == locktable.Replicated {
+		// As in IntentResolver.ProcessWriteIntentError, we always poison.
+		if err := r.store.intentResolver.ResolveIntents(ctx, []roachpb.Intent{intent},
+			intentresolver.ResolveOptions{Wait: false, Poison: true}); err != nil {
+			return roachpb.NewError(err)
+		}
+	}
+	r.lockTable.UpdateLocks(intent)
+	return nil
+}
+
+// addDiscoveredLocks adds the intents that the request at the given index of
+// the batch ran into during evaluation to the lock table. It returns false if
+// the request does not wait on locks in the lock table, in which case the
+// conflict must be handled by pushing the intents' transactions directly.
+func (r *Replica) addDiscoveredLocks(
+	ba *roachpb.BatchRequest, index int32, wiErr *roachpb.WriteIntentError,
+) bool {
+	if !r.lockTableEnabled() || ba.ReadConsistency != roachpb.CONSISTENT ||
+		!waitsOnLocks(ba.Requests[index].GetInner()) {
+		return false
+	}
+	for _, intent := range wiErr.Intents {
+		if len(intent.EndKey) != 0 || intent.Status != roachpb.PENDING {
+			return false
+		}
+	}
+	for _, intent := range wiErr.Intents {
+		r.lockTable.AddDiscoveredLock(intent)
+	}
+	return true
+}
+
+// updateLockTable updates the lock table with the locks acquired and released
+// by the batch. It is called while the batch's latches are still held. Locks
+// are only acquired while the lock table is enabled, but they are always
+// released so that none are leaked when the setting is turned off.
+func (r *Replica) updateLockTable(
+	ba *roachpb.BatchRequest, br *roachpb.BatchResponse, pErr *roachpb.Error,
+) error {
+	if pErr != nil {
+		return nil
+	}
+	txn := ba.Txn
+	if br.Txn != nil {
+		txn = br.Txn
+	}
+	for i, union := range ba.Requests {
+		req := union.GetInner()
+		resp := br.Responses[i].GetInner()
+		switch t := req.(type) {
+		case *roachpb.ResolveIntentRequest:
+			r.lockTable.UpdateLocks(roachpb.Intent{Span: t.Span(), Txn: t.IntentTxn, Status: t.Status})
+		case *roachpb.ResolveIntentRangeRequest:
+			// Only release the locks in the part of the span that was resolved.
+			if span, ok := roachpb.ActualSpan(req, resp); ok {
+				r.lockTable.UpdateLocks(roachpb.Intent{Span: span, Txn: t.IntentTxn, Status: t.Status})
+			}
+		case *roachpb.EndTransactionRequest:
+			if txn != nil && txn.Status.IsFinalized() {
+				for _, span := range t.IntentSpans {
+					r.lockTable.UpdateLocks(roachpb.Intent{Span: span, Txn: txn.TxnMeta, Status: txn.Status})
+				}
+			}
+		default:
+			if txn == nil || txn.Status != roachpb.PENDING || !r.lockTableEnabled() {
+				continue
+			}
+			if roachpb.IsLocking(req) {
+				if err := forEachReturnedKey(resp, req.Header().Key, func(key roachpb.Key) {
+					r.lockTable.AcquireLock(&txn.TxnMeta, key, locktable.Unreplicated)
+				}); err != nil {
+					return errors.Wrapf(err, "unable to decode %s response", req.Method())
+				}
+			} else if roachpb.IsTransactionWrite(req) && !roachpb.IsRange(req) {
+				r.lockTable.AcquireLock(&txn.TxnMeta, req.Header().Key, locktable.Replicated)
+			}
+		}
+	}
+	return nil
+}
+
+// forEachReturnedKey invokes fn on each key returned by a read response. The
+// key of the request is passed for GetResponses, which don't include it.
+func forEachReturnedKey(resp roachpb.Response, reqKey roachpb.Key, fn func(roachpb.Key)) error {
+	var rows []roachpb.KeyValue
+	var batchResponses [][]byte
+	switch t := resp.(type) {
+	case *roachpb.GetResponse:
+		if t.Value != nil {
+			fn(reqKey)
+		}
+		return nil
+	case *roachpb.ScanResponse:
+		rows, batchResponses = t.Rows, t.BatchResponses
+	case *roachpb.ReverseScanResponse:
+		rows, batchResponses = t.Rows, t.BatchResponses
+	}
+	for _, kv := range rows {
+		fn(kv.Key)
+	}
+	for _, repr := range batchResponses {
+		for len(repr) > 0 {
+			var key []byte
+			var err error
+			if key, _, repr, err = enginepb.ScanDecodeKeyValueNoTS(repr); err != nil {
+				return err
+			}
+			fn(key)
+		}
+	}
+	return nil
+}
@@ -14,12 +14,14 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/config"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"go.etcd.io/etcd/raft"
 )
 
@@ -119,7 +121,7 @@ func calcReplicaMetrics(
 	m.Ticking = ticking
 
 	m.RangeCounter, m.Unavailable, m.Underreplicated, m.Overreplicated =
-		calcRangeCounter(storeID, desc, livenessMap, *zone.NumReplicas, clusterNodes)
+		calcRangeCounter(storeID, desc, livenessMap, zone.GetNumVoters(), *zone.NumReplicas, clusterNodes)
 
 	// The raft leader computes the number of raft entries that replicas are
 	// behind.
@@ -155,7 +157,7 @@ func calcRangeCounter(
 	storeID roachpb.StoreID,
 	desc *roachpb.RangeDescriptor,
 	livenessMap IsLiveMap,
-	numReplicas int32,
+	numVoters, numReplicas int32,
 	clusterNodes int,
 ) (rangeCounter, unavailable, underreplicated, overreplicated bool) {
 	for _, rd := range desc.Replicas().Unwrap() {
@@ -167,14 +169,16 @@ func calcRangeCounter(
 	// We also compute an estimated per-range count of under-replicated and
 	// unavailable ranges for each range based on the liveness table.
 	if rangeCounter {
-		liveReplicas := calcLiveReplicas(desc, livenessMap)
-		if liveReplicas < desc.Replicas().QuorumSize() {
+		liveVoters := calcLiveReplicas(desc.Replicas().Voters(), livenessMap)
+		if liveVoters < desc.Replicas().QuorumSize() {
 			unavailable = true
 		}
-		needed := GetNeededReplicas(numReplicas, clusterNodes)
-		if needed > liveReplicas {
+		neededVoters := GetNeededReplicas(numVoters, clusterNodes)
+		liveNonVoters := calcLiveReplicas(desc.Replicas().NonVoters(), livenessMap)
+		neededNonVoters := GetNeededNonVoters(neededVoters, int(numReplicas-numVoters), clusterNodes)
+		if neededVoters > liveVoters || neededNonVoters > liveNonVoters {
 			underreplicated = true
-		} else if needed < liveReplicas {
+		} else if neededVoters < liveVoters || neededNonVoters < liveNonVoters {
 			overreplicated = true
 		}
 	}
@@ -183,9 +187,9 @@ func calcRangeCounter(
 
 // calcLiveReplicas returns a count of the live replicas; a live replica is
 // determined by checking its node in the provided liveness map.
-func calcLiveReplicas(desc *roachpb.RangeDescriptor, livenessMap IsLiveMap) int {
+func calcLiveReplicas(repls []roachpb.ReplicaDescriptor, livenessMap IsLiveMap) int {
 	var live int
-	for _, rd := range desc.Replicas().Unwrap() {
+	for _, rd := range repls {
 		if livenessMap[rd.NodeID].IsLive {
 			live++
 		}
@@ -235,6 +239,27 @@ func (r *Replica) WritesPerSecond() float64 {
 	return wps
 }
 
+// WriteBytesPerSecond returns the range's average bytes written per second,
+// as measured by the size of the WriteBatches applied by Raft.
+func (r *Replica) WriteBytesPerSecond() float64 {
+	wbps, _ := r.writeBytesStats.avgQPS()
+	return wbps
+}
+
+// CPUPerSecond returns the average nanoseconds per second spent evaluating
+// requests on the range. Evaluation time is measured as wall time, which makes
+// it an upper bound on the CPU actually consumed.
+func (r *Replica) CPUPerSecond() float64 {
+	cpu, _ := r.cpuStats.avgQPS()
+	return cpu
+}
+
+// recordEvalCPU records the time spent evaluating a request that started at
+// the given time. It is meant to be deferred around request evaluation.
+func (r *Replica) recordEvalCPU(start time.Time) {
+	r.cpuStats.recordCount(float64(timeutil.Since(start).Nanoseconds()), 0 /* nodeID */)
+}
+
 // needsSplitBySize returns true if the size of the range requires it
 // to be split.
 func (r *Replica) needsSplitBySize() bool {
@@ -113,7 +113,9 @@ type ProposalData struct {
 // counted on to invoke endCmds itself.)
 func (proposal *ProposalData) finishApplication(pr proposalResult) {
 	if proposal.endCmds != nil {
-		proposal.endCmds.done(pr.Reply, pr.Err)
+		if err := proposal.endCmds.done(pr.Reply, pr.Err); err != nil {
+			pr.Reply, pr.Err = nil, roachpb.NewError(err)
+		}
 		proposal.endCmds = nil
 	}
 	if proposal.sp != nil {
@@ -342,6 +344,13 @@ func (r *Replica) leasePostApply(ctx context.Context, newLease roachpb.Lease, pe
 		r.txnWaitQueue.Clear(true /* disable */)
 	}
 
+	if leaseChangingHands {
+		// The lock table only reflects the locks known to the previous
+		// lease holder. Clear it, releasing any unreplicated locks and
+		// letting waiters rediscover the intents they conflict with.
+		r.lockTable.Clear()
+	}
+
 	// If we're the current raft leader, may want to transfer the leadership to
 	// the new leaseholder. Note that this condition is also checked periodically
 	// when ticking the replica.
@@ -745,8 +754,14 @@ func (r *Replica) handleReplicatedEvalResult(
 	}
 
 	if change := rResult.ChangeReplicas; change != nil {
-		if change.ChangeType == roachpb.REMOVE_REPLICA &&
-			r.store.StoreID() == change.Replica.StoreID {
+		removed := true
+		for _, rDesc := range change.UpdatedReplicas {
+			if rDesc.StoreID == r.store.StoreID() {
+				removed = false
+				break
+			}
+		}
+		if removed {
 			// This wants to run as late as possible, maximizing the chances
 			// that the other nodes have finished this command as well (since
 			// processing the removal from the queue looks up the Range at the
@@ -954,6 +969,9 @@ func (r *Replica) evaluateProposal(
 		res.WriteBatch = &storagepb.WriteBatch{
 			Data: batch.Repr(),
 		}
+		r.recordWriteLoad(ctx, len(res.WriteBatch.Data), func() roachpb.Span {
+			return spans.BoundarySpan(spanset.SpanGlobal)
+		})
 
 		// Set the proposal's replicated result, which contains metadata and
 		// side-effects that are to be replicated to all replicas.
@@ -0,0 +1,107 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package storage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/pkg/errors"
+)
+
+// adminVerifyProtectedTimestamp verifies that the protected timestamp record
+// described by args applies to the replica. The replica's descriptor is
+// returned among the failed ranges if it does not.
+func (r *Replica) adminVerifyProtectedTimestamp(
+	ctx context.Context, args roachpb.AdminVerifyProtectedTimestampRequest,
+) (roachpb.AdminVerifyProtectedTimestampResponse, error) {
+	var resp roachpb.AdminVerifyProtectedTimestampResponse
+	applies, err := r.protectedTimestampRecordApplies(ctx, &args)
+	if err != nil {
+		return resp, err
+	}
+	if !applies {
+		resp.FailedRanges = append(resp.FailedRanges, *r.Desc())
+	}
+	return resp, nil
+}
+
+// protectedTimestampRecordApplies returns whether the protected timestamp
+// record described by args applies to the replica, that is, whether the GC
+// queue is guaranteed to keep the replica's GC threshold below the protected
+// timestamp for as long as the record exists.
+//
+// The record must be present in the store's protected timestamp cache once
+// the cache is current as of args.RecordAliveAt, and neither the replica's GC
+// threshold nor a threshold which the GC queue is about to set may have
+// reached the protected timestamp. If the record applies, the timestamp as of
+// which the cache was read becomes the replica's minStateReadTimestamp, so
+// that the GC queue does not subsequently advance the GC threshold based on a
+// view of the records which does not contain the record. See markPendingGC.
+func (r *Replica) protectedTimestampRecordApplies(
+	ctx context.Context, args *roachpb.AdminVerifyProtectedTimestampRequest,
+) (bool, error) {
+	cache := r.store.cfg.ProtectedTimestampCache
+	if cache == nil {
+		return false, nil
+	}
+	// Fail early if the data has already been garbage collected.
+	if !r.GetGCThreshold().Less(args.Protected) {
+		return false, nil
+	}
+	if err := cache.Refresh(ctx, args.RecordAliveAt); err != nil {
+		return false, errors.Wrap(err, "failed to refresh protected timestamps")
+	}
+	desc := r.Desc()
+	var seen bool
+	asOf := cache.Iterate(ctx, desc.StartKey.AsRawKey(), desc.EndKey.AsRawKey(),
+		func(rec *ptpb.Record) {
+			if rec.ID == args.RecordID {
+				seen = true
+			}
+		})
+	if !seen {
+		// The cache is current as of a time at which the record existed, so it
+		// must have been removed since.
+		log.VEventf(ctx, 1, "protected timestamp record %s not found", args.RecordID)
+		return false, nil
+	}
+
+	r.protectedTimestampMu.Lock()
+	defer r.protectedTimestampMu.Unlock()
+	gcThreshold := r.GetGCThreshold()
+	gcThreshold.Forward(r.protectedTimestampMu.pendingGCThreshold)
+	if !gcThreshold.Less(args.Protected) {
+		return false, nil
+	}
+	r.protectedTimestampMu.minStateReadTimestamp.Forward(asOf)
+	return true, nil
+}
+
+// markPendingGC is called by the GC queue before it sets the replica's GC
+// threshold to newThreshold, based on a view of the protected timestamp
+// records which is current as of readAt. It returns false if a record has
+// been verified based on a more recent view of the records, in which case the
+// GC queue must not set the threshold.
+func (r *Replica) markPendingGC(readAt, newThreshold hlc.Timestamp) bool {
+	r.protectedTimestampMu.Lock()
+	defer r.protectedTimestampMu.Unlock()
+	if readAt.Less(r.protectedTimestampMu.minStateReadTimestamp) {
+		return false
+	}
+	r.protectedTimestampMu.pendingGCThreshold.Forward(newThreshold)
+	return true
+}
@@ -43,6 +43,7 @@ import (
 	"github.com/pkg/errors"
 	"go.etcd.io/etcd/raft"
 	"go.etcd.io/etcd/raft/raftpb"
+	"go.etcd.io/etcd/raft/tracker"
 )
 
 // insertProposalLocked assigns a MaxLeaseIndex to a proposal and adds
@@ -402,9 +403,11 @@ func defaultSubmitProposalLocked(r *Replica, p *ProposalData) error {
 		// leases can stay in such a state for a very long time when using epoch-
 		// based range leases). This shouldn't happen often, but has been seen
 		// before (#12591).
-		if crt.ChangeType == roachpb.REMOVE_REPLICA && crt.Replica.ReplicaID == r.mu.replicaID {
-			log.Errorf(p.ctx, "received invalid ChangeReplicasTrigger %s to remove self (leaseholder)", crt)
-			return errors.Errorf("%s: received invalid ChangeReplicasTrigger %s to remove self (leaseholder)", r, crt)
+		for _, rDesc := range crt.Removed() {
+			if rDesc.ReplicaID == r.mu.replicaID {
+				log.Errorf(p.ctx, "received invalid ChangeReplicasTrigger %s to remove self (leaseholder)", crt)
+				return errors.Errorf("%s: received invalid ChangeReplicasTrigger %s to remove self (leaseholder)", r, crt)
+			}
 		}
 
 		confChangeCtx := ConfChangeContext{
@@ -422,11 +425,7 @@ func defaultSubmitProposalLocked(r *Replica, p *ProposalData) error {
 			// leader if we were quiesced.
 			r.unquiesceLocked()
 			return false, /* unquiesceAndWakeLeader */
-				raftGroup.ProposeConfChange(raftpb.ConfChange{
-					Type:    changeTypeInternalToRaft[crt.ChangeType],
-					NodeID:  uint64(crt.Replica.ReplicaID),
-					Context: encodedCtx,
-				})
+				raftGroup.ProposeConfChange(confChangeForTrigger(&crt.ChangeReplicasTrigger, encodedCtx))
 		})
 	}
 
@@ -836,14 +835,28 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 			}
 			r.mu.Unlock()
 
-		case raftpb.EntryConfChange:
-			var cc raftpb.ConfChange
-			if err := protoutil.Unmarshal(e.Data, &cc); err != nil {
-				const expl = "while unmarshaling ConfChange"
-				return stats, expl, errors.Wrap(err, expl)
+		case raftpb.EntryConfChange, raftpb.EntryConfChangeV2:
+			// Atomic replication changes are proposed as ConfChangeV2, all other
+			// replication changes as ConfChange.
+			var cc raftpb.ConfChangeI
+			var ccContext []byte
+			if e.Type == raftpb.EntryConfChange {
+				var ccv1 raftpb.ConfChange
+				if err := protoutil.Unmarshal(e.Data, &ccv1); err != nil {
+					const expl = "while unmarshaling ConfChange"
+					return stats, expl, errors.Wrap(err, expl)
+				}
+				cc, ccContext = ccv1, ccv1.Context
+			} else {
+				var ccv2 raftpb.ConfChangeV2
+				if err := protoutil.Unmarshal(e.Data, &ccv2); err != nil {
+					const expl = "while unmarshaling ConfChangeV2"
+					return stats, expl, errors.Wrap(err, expl)
+				}
+				cc, ccContext = ccv2, ccv2.Context
 			}
 			var ccCtx ConfChangeContext
-			if err := protoutil.Unmarshal(cc.Context, &ccCtx); err != nil {
+			if err := protoutil.Unmarshal(ccContext, &ccCtx); err != nil {
 				const expl = "while unmarshaling ConfChangeContext"
 				return stats, expl, errors.Wrap(err, expl)
 
@@ -854,12 +867,7 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 				return stats, expl, errors.Wrap(err, expl)
 			}
 			commandID := storagebase.CmdIDKey(ccCtx.CommandID)
-			if changedRepl := r.processRaftCommand(
-				ctx, commandID, e.Term, e.Index, command,
-			); !changedRepl {
-				// If we did not apply the config change, tell raft that the config change was aborted.
-				cc = raftpb.ConfChange{}
-			}
+			changedRepl := r.processRaftCommand(ctx, commandID, e.Term, e.Index, command)
 			stats.processed++
 
 			r.mu.Lock()
@@ -871,6 +879,11 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 			}
 			r.mu.Unlock()
 
+			if !changedRepl {
+				// The command was rejected, so the config change must not be
+				// applied either. Raft does not need to be told about it.
+				break
+			}
 			if err := r.withRaftGroup(true, func(raftGroup *raft.RawNode) (bool, error) {
 				raftGroup.ApplyConfChange(cc)
 				return true, nil
@@ -1204,8 +1217,8 @@ func (r *Replica) sendRaftMessage(ctx context.Context, msg raftpb.Message) {
 		// below for more context:
 		_ = maybeDropMsgApp
 		// NB: this code is allocation free.
-		r.mu.internalRaftGroup.WithProgress(func(id uint64, _ raft.ProgressType, pr raft.Progress) {
-			if id == msg.To && pr.State == raft.ProgressStateProbe {
+		r.mu.internalRaftGroup.WithProgress(func(id uint64, _ raft.ProgressType, pr tracker.Progress) {
+			if id == msg.To && pr.State == tracker.StateProbe {
 				// It is moderately expensive to attach a full key to the message, but note that
 				// a probing follower will only be appended to once per heartbeat interval (i.e.
 				// on the order of seconds). See:
@@ -1558,7 +1571,7 @@ func (r *Replica) withRaftGroupLocked(
 			r.mu.state.RaftAppliedIndex,
 			r.store.cfg,
 			&raftLogger{ctx: ctx},
-		), nil)
+		))
 		if err != nil {
 			return err
 		}
@@ -1605,7 +1618,7 @@ func shouldCampaignOnWake(
 	leaseStatus storagepb.LeaseStatus,
 	lease roachpb.Lease,
 	storeID roachpb.StoreID,
-	raftStatus raft.Status,
+	raftStatus raft.BasicStatus,
 ) bool {
 	// When waking up a range, campaign unless we know that another
 	// node holds a valid lease (this is most important after a split,
@@ -1633,8 +1646,8 @@ func (r *Replica) maybeCampaignOnWakeLocked(ctx context.Context) {
 	}
 
 	leaseStatus := r.leaseStatus(*r.mu.state.Lease, r.store.Clock().Now(), r.mu.minLeaseProposedTS)
-	raftStatus := r.mu.internalRaftGroup.Status()
-	if shouldCampaignOnWake(leaseStatus, *r.mu.state.Lease, r.store.StoreID(), *raftStatus) {
+	raftStatus := r.mu.internalRaftGroup.BasicStatus()
+	if shouldCampaignOnWake(leaseStatus, *r.mu.state.Lease, r.store.StoreID(), raftStatus) {
 		log.VEventf(ctx, 3, "campaigning")
 		if err := r.mu.internalRaftGroup.Campaign(); err != nil {
 			log.VEventf(ctx, 1, "failed to campaign: %s", err)
@@ -1663,10 +1676,10 @@ func (m lastUpdateTimesMap) update(replicaID roachpb.ReplicaID, now time.Time) {
 // a suitable pattern of quiesce and unquiesce operations (and this in turn
 // can interfere with Raft log truncations).
 func (m lastUpdateTimesMap) updateOnUnquiesce(
-	descs []roachpb.ReplicaDescriptor, prs map[uint64]raft.Progress, now time.Time,
+	descs []roachpb.ReplicaDescriptor, prs map[uint64]tracker.Progress, now time.Time,
 ) {
 	for _, desc := range descs {
-		if prs[uint64(desc.ReplicaID)].State == raft.ProgressStateReplicate {
+		if prs[uint64(desc.ReplicaID)].State == tracker.StateReplicate {
 			m.update(desc.ReplicaID, now)
 		}
 	}
@@ -1880,6 +1893,10 @@ func (r *Replica) processRaftCommand(
 			if copied {
 				r.store.metrics.AddSSTableApplicationCopies.Inc(1)
 			}
+			// The ingested SSTable bypasses the WriteBatch, so its size is
+			// recorded here rather than in applyRaftCommand.
+			r.writeBytesStats.recordCount(
+				float64(len(raftCmd.ReplicatedEvalResult.AddSSTable.Data)), 0 /* nodeID */)
 			raftCmd.ReplicatedEvalResult.AddSSTable = nil
 		}
 
@@ -2267,6 +2284,7 @@ func (r *Replica) applyRaftCommand(
 		} else {
 			r.writeStats.recordCount(float64(mutationCount), 0 /* nodeID */)
 		}
+		r.writeBytesStats.recordCount(float64(len(writeBatch.Data)), 0 /* nodeID */)
 	}
 
 	r.mu.Lock()
@@ -19,7 +19,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/stretchr/testify/assert"
-	"go.etcd.io/etcd/raft"
+	"go.etcd.io/etcd/raft/raftpb"
+	"go.etcd.io/etcd/raft/tracker"
 )
 
 func TestLastUpdateTimesMap(t *testing.T) {
@@ -39,14 +40,14 @@ func TestLastUpdateTimesMap(t *testing.T) {
 
 	t4 := t3.Add(time.Second)
 	descs = append(descs, []roachpb.ReplicaDescriptor{{ReplicaID: 5}, {ReplicaID: 6}}...)
-	prs := map[uint64]raft.Progress{
-		1: {State: raft.ProgressStateReplicate}, // should be updated
+	prs := map[uint64]tracker.Progress{
+		1: {State: tracker.StateReplicate}, // should be updated
 		// 2 is missing because why not
-		3: {State: raft.ProgressStateProbe},     // should be ignored
-		4: {State: raft.ProgressStateSnapshot},  // should be ignored
-		5: {State: raft.ProgressStateProbe},     // should be ignored
-		6: {State: raft.ProgressStateReplicate}, // should be added
-		7: {State: raft.ProgressStateReplicate}, // ignored, not in descs
+		3: {State: tracker.StateProbe},     // should be ignored
+		4: {State: tracker.StateSnapshot},  // should be ignored
+		5: {State: tracker.StateProbe},     // should be ignored
+		6: {State: tracker.StateReplicate}, // should be added
+		7: {State: tracker.StateReplicate}, // ignored, not in descs
 	}
 	m.updateOnUnquiesce(descs, prs, t4)
 	assert.EqualValues(t, map[roachpb.ReplicaID]time.Time{
@@ -57,3 +58,64 @@ func TestLastUpdateTimesMap(t *testing.T) {
 		6: t4,
 	}, m)
 }
+
+func TestConfChangeForTrigger(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := []byte("ctx")
+	added := roachpb.ReplicaDescriptor{NodeID: 4, StoreID: 4, ReplicaID: 4, Type: roachpb.ReplicaType_VOTER_INCOMING}
+	removed := roachpb.ReplicaDescriptor{NodeID: 3, StoreID: 3, ReplicaID: 3, Type: roachpb.ReplicaType_VOTER_OUTGOING}
+
+	// A single change keeps using the legacy conf change.
+	cc := confChangeForTrigger(&roachpb.ChangeReplicasTrigger{
+		ChangeType: roachpb.ADD_REPLICA,
+		Replica:    roachpb.ReplicaDescriptor{ReplicaID: 4},
+	}, ctx)
+	assert.Equal(t, raftpb.ConfChange{Type: raftpb.ConfChangeAddNode, NodeID: 4, Context: ctx}, cc)
+
+	// An atomic change enters an explicit joint configuration.
+	cc = confChangeForTrigger(&roachpb.ChangeReplicasTrigger{
+		InternalAddedReplicas:   []roachpb.ReplicaDescriptor{added},
+		InternalRemovedReplicas: []roachpb.ReplicaDescriptor{removed},
+	}, ctx)
+	assert.Equal(t, raftpb.ConfChangeV2{
+		Transition: raftpb.ConfChangeTransitionJointExplicit,
+		Changes: []raftpb.ConfChangeSingle{
+			{Type: raftpb.ConfChangeRemoveNode, NodeID: 3},
+			{Type: raftpb.ConfChangeAddNode, NodeID: 4},
+		},
+		Context: ctx,
+	}, cc)
+
+	// Leaving the joint configuration is an empty V2 conf change.
+	cc = confChangeForTrigger(&roachpb.ChangeReplicasTrigger{LeaveJoint: true}, ctx)
+	assert.Equal(t, raftpb.ConfChangeV2{Context: ctx}, cc)
+}
+
+func TestConfStateFromDesc(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	desc := roachpb.RangeDescriptor{
+		InternalReplicas: []roachpb.ReplicaDescriptor{
+			{NodeID: 1, StoreID: 1, ReplicaID: 1},
+			{NodeID: 2, StoreID: 2, ReplicaID: 2},
+			{NodeID: 3, StoreID: 3, ReplicaID: 3},
+			{NodeID: 5, StoreID: 5, ReplicaID: 5, Type: roachpb.ReplicaType_LEARNER},
+		},
+	}
+	assert.Equal(t, raftpb.ConfState{
+		Voters:   []uint64{1, 2, 3},
+		Learners: []uint64{5},
+	}, confStateFromDesc(&desc))
+
+	// Swap replica 3 for replica 4 in a joint configuration.
+	desc.InternalReplicas[2].Type = roachpb.ReplicaType_VOTER_OUTGOING
+	desc.InternalReplicas = append(desc.InternalReplicas, roachpb.ReplicaDescriptor{
+		NodeID: 4, StoreID: 4, ReplicaID: 4, Type: roachpb.ReplicaType_VOTER_INCOMING,
+	})
+	assert.Equal(t, raftpb.ConfState{
+		Voters:         []uint64{1, 2, 4},
+		VotersOutgoing: []uint64{1, 2, 3},
+		Learners:       []uint64{5},
+	}, confStateFromDesc(&desc))
+}
@@ -67,14 +67,38 @@ func (r *replicaRaftStorage) InitialState() (raftpb.HardState, raftpb.ConfState,
 	if raft.IsEmptyHardState(hs) || err != nil {
 		return raftpb.HardState{}, raftpb.ConfState{}, err
 	}
-	var cs raftpb.ConfState
-	for _, rep := range r.mu.state.Desc.Replicas().Unwrap() {
-		cs.Nodes = append(cs.Nodes, uint64(rep.ReplicaID))
-	}
+	cs := confStateFromDesc(r.mu.state.Desc)
 
 	return hs, cs, nil
 }
 
+// confStateFromDesc synthesizes the raft ConfState for the replicas in desc.
+// Voters become raft voters; learners and non-voters become raft learners.
+// When the range is in a joint configuration, incoming voters are voters of
+// the incoming configuration only and outgoing voters are voters of the
+// outgoing configuration only.
+func confStateFromDesc(desc *roachpb.RangeDescriptor) raftpb.ConfState {
+	var cs raftpb.ConfState
+	joint := desc.Replicas().InAtomicReplicationChange()
+	for _, rep := range desc.Replicas().All() {
+		id := uint64(rep.ReplicaID)
+		switch rep.Type {
+		case roachpb.ReplicaType_VOTER:
+			cs.Voters = append(cs.Voters, id)
+			if joint {
+				cs.VotersOutgoing = append(cs.VotersOutgoing, id)
+			}
+		case roachpb.ReplicaType_VOTER_INCOMING:
+			cs.Voters = append(cs.Voters, id)
+		case roachpb.ReplicaType_VOTER_OUTGOING:
+			cs.VotersOutgoing = append(cs.VotersOutgoing, id)
+		default:
+			cs.Learners = append(cs.Learners, id)
+		}
+	}
+	return cs
+}
+
 // Entries implements the raft.Storage interface. Note that maxBytes is advisory
 // and this method will always return at least one entry even if it exceeds
 // maxBytes. Sideloaded proposals count towards maxBytes with their payloads inlined.
@@ -537,10 +561,7 @@ func snapshot(
 	}
 
 	// Synthesize our raftpb.ConfState from desc.
-	var cs raftpb.ConfState
-	for _, rep := range desc.Replicas().Unwrap() {
-		cs.Nodes = append(cs.Nodes, uint64(rep.ReplicaID))
-	}
+	cs := confStateFromDesc(&desc)
 
 	term, err := term(ctx, rsl, snap, rangeID, eCache, appliedIndex)
 	if err != nil {
@@ -199,7 +199,14 @@ func (r *Replica) RangeFeed(
 
 	// Register the stream with a catch-up iterator.
 	var catchUpIter engine.SimpleIterator
+	var catchUpRangeTombs []enginepb.MVCCRangeTombstone
 	if usingCatchupIter {
+		var err error
+		catchUpRangeTombs, err = engine.MVCCGetRangeTombstones(r.Engine(), args.Span.Key, args.Span.EndKey)
+		if err != nil {
+			r.raftMu.Unlock()
+			return roachpb.NewError(err)
+		}
 		innerIter := r.Engine().NewIterator(engine.IterOptions{
 			UpperBound: args.Span.EndKey,
 			// RangeFeed originally intended to use the time-bound iterator
@@ -219,7 +226,7 @@ func (r *Replica) RangeFeed(
 		iterSemRelease = nil
 	}
 	p := r.registerWithRangefeedRaftMuLocked(
-		ctx, rspan, args.Timestamp, catchUpIter, lockedStream, errC,
+		ctx, rspan, args.Timestamp, catchUpIter, catchUpRangeTombs, args.WithDiff, lockedStream, errC,
 	)
 	r.raftMu.Unlock()
 
@@ -277,6 +284,8 @@ func (r *Replica) registerWithRangefeedRaftMuLocked(
 	span roachpb.RSpan,
 	startTS hlc.Timestamp,
 	catchupIter engine.SimpleIterator,
+	catchupRangeTombs []enginepb.MVCCRangeTombstone,
+	withDiff bool,
 	stream rangefeed.Stream,
 	errC chan<- *roachpb.Error,
 ) *rangefeed.Processor {
@@ -286,7 +295,7 @@ func (r *Replica) registerWithRangefeedRaftMuLocked(
 	r.rangefeedMu.RLock()
 	p := r.rangefeedMu.proc
 	if p != nil {
-		reg := p.Register(span, startTS, catchupIter, stream, errC)
+		reg := p.Register(span, startTS, catchupIter, catchupRangeTombs, withDiff, stream, errC)
 		r.rangefeedMu.RUnlock()
 		if reg {
 			// Registered successfully with an existing processor.
@@ -333,7 +342,7 @@ func (r *Replica) registerWithRangefeedRaftMuLocked(
 	// any other goroutines are able to stop the processor. In other words,
 	// this ensures that the only time the registration fails is during
 	// server shutdown.
-	reg := p.Register(span, startTS, catchupIter, stream, errC)
+	reg := p.Register(span, startTS, catchupIter, catchupRangeTombs, withDiff, stream, errC)
 	if !reg {
 		catchupIter.Close() // clean up
 		select {
@@ -432,20 +441,23 @@ func (r *Replica) handleLogicalOpLogRaftMuLocked(ctx context.Context, ops *stora
 
 	// When reading straight from the Raft log, some logical ops will not be
 	// fully populated. Read from the engine (under raftMu) to populate all
-	// fields.
+	// fields. The previous values of keys are only read if a registration
+	// requested them.
+	needPrevVal := p.NeedPrevVal()
 	for _, op := range ops.Ops {
 		var key []byte
 		var ts hlc.Timestamp
-		var valPtr *[]byte
+		var valPtr, prevValPtr *[]byte
 		switch t := op.GetValue().(type) {
 		case *enginepb.MVCCWriteValueOp:
-			key, ts, valPtr = t.Key, t.Timestamp, &t.Value
+			key, ts, valPtr, prevValPtr = t.Key, t.Timestamp, &t.Value, &t.PrevValue
 		case *enginepb.MVCCCommitIntentOp:
-			key, ts, valPtr = t.Key, t.Timestamp, &t.Value
+			key, ts, valPtr, prevValPtr = t.Key, t.Timestamp, &t.Value, &t.PrevValue
 		case *enginepb.MVCCWriteIntentOp,
 			*enginepb.MVCCUpdateIntentOp,
 			*enginepb.MVCCAbortIntentOp,
-			*enginepb.MVCCAbortTxnOp:
+			*enginepb.MVCCAbortTxnOp,
+			*enginepb.MVCCDeleteRangeOp:
 			// Nothing to do.
 			continue
 		default:
@@ -466,6 +478,25 @@ func (r *Replica) handleLogicalOpLogRaftMuLocked(ctx context.Context, ops *stora
 			return
 		}
 		*valPtr = val.RawBytes
+
+		if !needPrevVal {
+			continue
+		}
+		// Read the previous value of the key from the Engine. The version
+		// immediately beneath the logical op's timestamp is the one that it
+		// overwrote. A deleted key has no previous value.
+		prevVal, _, err := engine.MVCCGet(
+			ctx, r.Engine(), key, ts.Prev(), engine.MVCCGetOptions{Inconsistent: true},
+		)
+		if err != nil {
+			r.disconnectRangefeedWithErr(p, roachpb.NewErrorf(
+				"error consuming %T for key %v @ ts %v: %v", op, key, ts, err,
+			))
+			return
+		}
+		if prevVal != nil {
+			*prevValPtr = prevVal.RawBytes
+		}
 	}
 
 	// Pass the ops to the rangefeed processor.
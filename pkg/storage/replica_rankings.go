@@ -14,6 +14,7 @@ package storage
 
 import (
 	"container/heap"
+	"fmt"
 
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 )
@@ -23,20 +24,71 @@ const (
 	numTopReplicasToTrack = 128
 )
 
+// loadDimension identifies one of the kinds of load that replicas are ranked
+// by and that the store rebalancer tries to balance across stores.
+type loadDimension int
+
+const (
+	// qpsDimension is the rate of BatchRequests served by a leaseholder.
+	qpsDimension loadDimension = iota
+	// writeBytesDimension is the rate of bytes written by applied raft
+	// commands, which approximates the disk bandwidth consumed by a replica.
+	writeBytesDimension
+	// cpuDimension is the rate of nanoseconds spent evaluating requests.
+	cpuDimension
+	numLoadDimensions
+)
+
+func (d loadDimension) String() string {
+	switch d {
+	case qpsDimension:
+		return "queries-per-second"
+	case writeBytesDimension:
+		return "write-bytes-per-second"
+	case cpuDimension:
+		return "cpu-nanos-per-second"
+	default:
+		return fmt.Sprintf("loadDimension(%d)", int(d))
+	}
+}
+
+// leaseholderOnly returns whether all of the load along the dimension is
+// incurred by the leaseholder, in which case moving the lease is enough to
+// move the load. Writes are applied by every replica, so write bytes can only
+// be moved by rebalancing replicas.
+func (d loadDimension) leaseholderOnly() bool {
+	return d != writeBytesDimension
+}
+
 type replicaWithStats struct {
-	repl *Replica
-	qps  float64
-	// TODO(a-robinson): Include writes-per-second and logicalBytes of storage?
+	repl       *Replica
+	qps        float64
+	writeBytes float64
+	cpu        float64
+}
+
+// load returns the replica's load along the given dimension.
+func (r replicaWithStats) load(dim loadDimension) float64 {
+	switch dim {
+	case qpsDimension:
+		return r.qps
+	case writeBytesDimension:
+		return r.writeBytes
+	case cpuDimension:
+		return r.cpu
+	default:
+		panic(fmt.Sprintf("unknown load dimension %d", dim))
+	}
 }
 
 // replicaRankings maintains top-k orderings of the replicas in a store along
-// different dimensions of concern, such as QPS, keys written per second, and
-// disk used.
+// different dimensions of concern, such as QPS, bytes written per second, and
+// request CPU.
 type replicaRankings struct {
 	mu struct {
 		syncutil.Mutex
-		qpsAccumulator *rrAccumulator
-		byQPS          []replicaWithStats
+		accumulator *rrAccumulator
+		byDim       [numLoadDimensions][]replicaWithStats
 	}
 }
 
@@ -46,25 +98,34 @@ func newReplicaRankings() *replicaRankings {
 
 func (rr *replicaRankings) newAccumulator() *rrAccumulator {
 	res := &rrAccumulator{}
-	res.qps.val = func(r replicaWithStats) float64 { return r.qps }
+	for i := range res.dims {
+		dim := loadDimension(i)
+		res.dims[i].val = func(r replicaWithStats) float64 { return r.load(dim) }
+	}
 	return res
 }
 
 func (rr *replicaRankings) update(acc *rrAccumulator) {
 	rr.mu.Lock()
-	rr.mu.qpsAccumulator = acc
+	rr.mu.accumulator = acc
 	rr.mu.Unlock()
 }
 
 func (rr *replicaRankings) topQPS() []replicaWithStats {
+	return rr.top(qpsDimension)
+}
+
+// top returns the hottest replicas along the given dimension, ordered from
+// hottest to coldest.
+func (rr *replicaRankings) top(dim loadDimension) []replicaWithStats {
 	rr.mu.Lock()
 	defer rr.mu.Unlock()
 	// If we have a new set of data, consume it. Otherwise, just return the most
 	// recently consumed data.
-	if rr.mu.qpsAccumulator.qps.Len() > 0 {
-		rr.mu.byQPS = consumeAccumulator(&rr.mu.qpsAccumulator.qps)
+	if rr.mu.accumulator != nil && rr.mu.accumulator.dims[dim].Len() > 0 {
+		rr.mu.byDim[dim] = consumeAccumulator(&rr.mu.accumulator.dims[dim])
 	}
-	return rr.mu.byQPS
+	return rr.mu.byDim[dim]
 }
 
 // rrAccumulator is used to update the replicas tracked by replicaRankings.
@@ -76,21 +137,24 @@ func (rr *replicaRankings) topQPS() []replicaWithStats {
 // prevents concurrent loaders of data from messing with each other -- the last
 // `update`d accumulator will win.
 type rrAccumulator struct {
-	qps rrPriorityQueue
+	dims [numLoadDimensions]rrPriorityQueue
 }
 
 func (a *rrAccumulator) addReplica(repl replicaWithStats) {
-	// If the heap isn't full, just push the new replica and return.
-	if a.qps.Len() < numTopReplicasToTrack {
-		heap.Push(&a.qps, repl)
-		return
-	}
-
-	// Otherwise, conditionally push if the new replica is more deserving than
-	// the current tip of the heap.
-	if repl.qps > a.qps.entries[0].qps {
-		heap.Pop(&a.qps)
-		heap.Push(&a.qps, repl)
+	for i := range a.dims {
+		pq := &a.dims[i]
+		// If the heap isn't full, just push the new replica.
+		if pq.Len() < numTopReplicasToTrack {
+			heap.Push(pq, repl)
+			continue
+		}
+
+		// Otherwise, conditionally push if the new replica is more deserving
+		// than the current tip of the heap.
+		if pq.val(repl) > pq.val(pq.entries[0]) {
+			heap.Pop(pq)
+			heap.Push(pq, repl)
+		}
 	}
 }
 
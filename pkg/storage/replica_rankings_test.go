@@ -73,3 +73,36 @@ func TestReplicaRankings(t *testing.T) {
 		}
 	}
 }
+
+func TestReplicaRankingsByDimension(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rr := newReplicaRankings()
+	acc := rr.newAccumulator()
+	// Replica i has increasing QPS, decreasing bytes written and constant CPU
+	// except for a single CPU-heavy replica in the middle.
+	const numReplicas = 5
+	for i := 0; i < numReplicas; i++ {
+		cpu := 1.0
+		if i == 2 {
+			cpu = 100
+		}
+		acc.addReplica(replicaWithStats{
+			repl:       &Replica{RangeID: roachpb.RangeID(i)},
+			qps:        float64(i),
+			writeBytes: float64(numReplicas - i),
+			cpu:        cpu,
+		})
+	}
+	rr.update(acc)
+
+	if repls := rr.top(qpsDimension); repls[0].repl.RangeID != numReplicas-1 {
+		t.Errorf("expected r%d to have the most qps, got %v", numReplicas-1, repls)
+	}
+	if repls := rr.top(writeBytesDimension); repls[0].repl.RangeID != 0 {
+		t.Errorf("expected r0 to write the most bytes, got %v", repls)
+	}
+	if repls := rr.top(cpuDimension); repls[0].repl.RangeID != 2 {
+		t.Errorf("expected r2 to use the most cpu, got %v", repls)
+	}
+}
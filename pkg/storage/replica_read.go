@@ -18,11 +18,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/batcheval/result"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
 // executeReadOnlyBatch updates the read timestamp cache and waits for any
@@ -66,7 +68,9 @@ func (r *Replica) executeReadOnlyBatch(
 	// timestamp cache update is synchronized. This is wrapped to delay
 	// pErr evaluation to its value when returning.
 	defer func() {
-		endCmds.done(br, pErr)
+		if err := endCmds.done(br, pErr); err != nil {
+			br, pErr = nil, roachpb.NewError(err)
+		}
 	}()
 
 	// TODO(nvanbenschoten): Can this be moved into Replica.requestCanProceed?
@@ -89,11 +93,17 @@ func (r *Replica) executeReadOnlyBatch(
 	var result result.Result
 	rec := NewReplicaEvalContext(r, spans)
 	readOnly := r.store.Engine().NewReadOnly()
+	skipRangeTombstones := r.canSkipRangeTombstones(spans)
 	if util.RaceEnabled {
 		readOnly = spanset.NewReadWriter(readOnly, spans)
 	}
+	if skipRangeTombstones {
+		readOnly = engine.NewReadWriterWithoutRangeTombstones(readOnly)
+	}
 	defer readOnly.Close()
+	evalStart := timeutil.Now()
 	br, result, pErr = evaluateBatch(ctx, storagebase.CmdIDKey(""), readOnly, rec, nil, ba, true /* readOnly */)
+	r.recordEvalCPU(evalStart)
 
 	// A merge is (likely) about to be carried out, and this replica
 	// needs to block all traffic until the merge either commits or
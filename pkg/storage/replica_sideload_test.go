@@ -26,6 +26,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -712,6 +713,7 @@ func testRaftSSTableSideloadingProposal(t *testing.T, engineInMem, mockSideloade
 
 	ts := hlc.Timestamp{Logical: 1}
 
+	tc.repl.writeBytesStats.resetRequestCounts()
 	if err := ProposeAddSSTable(ctx, key, val, ts, tc.store); err != nil {
 		t.Fatal(err)
 	}
@@ -755,6 +757,14 @@ func testRaftSSTableSideloadingProposal(t *testing.T, engineInMem, mockSideloade
 		if n := tc.store.metrics.AddSSTableApplications.Count(); n == 0 {
 			t.Fatalf("expected metric to show at least one AddSSTable application, but got %d", n)
 		}
+		// The ingested SSTable counts towards the replica's write bytes. Its
+		// size is well above that of the command's WriteBatch alone. The
+		// clock is advanced so that the rate is computed over a non-empty
+		// interval.
+		tc.manualClock.Increment(int64(time.Second))
+		if rate, dur := tc.repl.writeBytesStats.avgQPS(); rate*dur.Seconds() < 4*entrySize {
+			t.Fatalf("expected at least %d write bytes, but got %.0f", 4*entrySize, rate*dur.Seconds())
+		}
 		// We usually don't see copies because we hardlink and ingest the original SST. However, this
 		// depends on luck and the file system, so don't try to assert it. We should, however, see
 		// no more than one.
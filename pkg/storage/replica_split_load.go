@@ -13,8 +13,13 @@
 package storage
 
 import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/split"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
 // SplitByLoadEnabled wraps "kv.range_split.by_load_enabled".
@@ -31,6 +36,20 @@ var SplitByLoadQPSThreshold = settings.RegisterIntSetting(
 	250, // 250 req/s
 )
 
+// SplitByLoadWriteBytesThreshold wraps "kv.range_split.load_write_bytes_threshold".
+var SplitByLoadWriteBytesThreshold = settings.RegisterByteSizeSetting(
+	"kv.range_split.load_write_bytes_threshold",
+	"the bytes written per second over which the range becomes a candidate for load based splitting",
+	8<<20, // 8 MiB/s
+)
+
+// SplitByLoadContentionThreshold wraps "kv.range_split.load_contention_threshold".
+var SplitByLoadContentionThreshold = settings.RegisterIntSetting(
+	"kv.range_split.load_contention_threshold",
+	"the rate of requests per second waiting on conflicting locks over which the range becomes a candidate for load based splitting",
+	50, // 50 conflicts/s
+)
+
 // SplitByLoadQPSThreshold returns the QPS request rate for a given replica.
 func (r *Replica) SplitByLoadQPSThreshold() float64 {
 	return float64(SplitByLoadQPSThreshold.Get(&r.store.cfg.Settings.SV))
@@ -44,3 +63,69 @@ func (r *Replica) SplitByLoadEnabled() bool {
 		r.store.ClusterSettings().Version.IsActive(cluster.VersionLoadSplits) &&
 		!r.store.TestingKnobs().DisableLoadBasedSplitting
 }
+
+// SplitByLoadWriteBytesThreshold returns the rate of bytes written per second
+// over which the replica becomes a candidate for load based splitting.
+func (r *Replica) SplitByLoadWriteBytesThreshold() float64 {
+	return float64(SplitByLoadWriteBytesThreshold.Get(&r.store.cfg.Settings.SV))
+}
+
+// SplitByLoadContentionThreshold returns the rate of lock conflicts per second
+// over which the replica becomes a candidate for load based splitting.
+func (r *Replica) SplitByLoadContentionThreshold() float64 {
+	return float64(SplitByLoadContentionThreshold.Get(&r.store.cfg.Settings.SV))
+}
+
+// loadSplitter is a split.Decider along with a description of the load it
+// measures, for use in log messages.
+type loadSplitter struct {
+	decider *split.Decider
+	unit    string
+}
+
+// loadSplitters returns the deciders that may suggest load based splits of the
+// range: one measuring requests, one measuring bytes written and one measuring
+// requests that had to wait on conflicting locks.
+func (r *Replica) loadSplitters() []loadSplitter {
+	return []loadSplitter{
+		{decider: &r.loadBasedSplitter, unit: "qps"},
+		{decider: &r.writeBasedSplitter, unit: "write bytes/sec"},
+		{decider: &r.contentionBasedSplitter, unit: "lock conflicts/sec"},
+	}
+}
+
+// recordWriteLoad notes that a write batch of the given size was proposed for
+// the given span, and queues the range for splitting if the bytes written to it
+// have stayed above the threshold long enough to pick a split key.
+func (r *Replica) recordWriteLoad(ctx context.Context, writeBytes int, span func() roachpb.Span) {
+	if !r.SplitByLoadEnabled() {
+		return
+	}
+	if r.writeBasedSplitter.Record(timeutil.Now(), writeBytes, span) {
+		r.store.splitQueue.MaybeAddAsync(ctx, r, r.store.Clock().Now())
+	}
+}
+
+// recordContention notes that a request had to wait on a conflicting lock on
+// key, and queues the range for splitting if conflicts have stayed above the
+// threshold long enough to pick a split key. Splitting can't help contention
+// on a single key, but spreading several contended keys across ranges does
+// spread the work of queueing and pushing across leaseholders.
+func (r *Replica) recordContention(ctx context.Context, key roachpb.Key) {
+	if !r.SplitByLoadEnabled() {
+		return
+	}
+	if r.contentionBasedSplitter.Record(timeutil.Now(), 1, func() roachpb.Span {
+		return roachpb.Span{Key: key}
+	}) {
+		r.store.splitQueue.MaybeAddAsync(ctx, r, r.store.Clock().Now())
+	}
+}
+
+// resetLoadSplitters deactivates any current attempt at determining a load
+// based split key, for instance because the bounds of the range changed.
+func (r *Replica) resetLoadSplitters() {
+	for _, ls := range r.loadSplitters() {
+		ls.decider.Reset()
+	}
+}
@@ -99,7 +99,9 @@ func (r *Replica) executeWriteBatch(
 	// wrapped to delay pErr evaluation to its value when returning.
 	defer func() {
 		if endCmds != nil {
-			endCmds.done(br, pErr)
+			if err := endCmds.done(br, pErr); err != nil {
+				br, pErr = nil, roachpb.NewError(err)
+			}
 		}
 	}()
 
@@ -273,6 +275,7 @@ and the following Raft status: %+v`,
 func (r *Replica) evaluateWriteBatch(
 	ctx context.Context, idKey storagebase.CmdIDKey, ba roachpb.BatchRequest, spans *spanset.SpanSet,
 ) (engine.Batch, enginepb.MVCCStats, *roachpb.BatchResponse, result.Result, *roachpb.Error) {
+	defer r.recordEvalCPU(timeutil.Now())
 	ms := enginepb.MVCCStats{}
 	// If not transactional or there are indications that the batch's txn will
 	// require restart or retry, execute as normal.
@@ -379,6 +382,7 @@ func (r *Replica) evaluateWriteBatchWithLocalRetries(
 	spans *spanset.SpanSet,
 	canRetry bool,
 ) (batch engine.Batch, br *roachpb.BatchResponse, res result.Result, pErr *roachpb.Error) {
+	skipRangeTombstones := r.canSkipRangeTombstones(spans)
 	for retries := 0; ; retries++ {
 		if batch != nil {
 			batch.Close()
@@ -424,6 +428,9 @@ func (r *Replica) evaluateWriteBatchWithLocalRetries(
 		if util.RaceEnabled {
 			batch = spanset.NewBatch(batch, spans)
 		}
+		if skipRangeTombstones {
+			batch = engine.NewBatchWithoutRangeTombstones(batch)
+		}
 
 		br, res, pErr = evaluateBatch(ctx, idKey, batch, rec, ms, ba, false /* readOnly */)
 		// If we can retry, set a higher batch timestamp and continue.
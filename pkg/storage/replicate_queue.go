@@ -23,6 +23,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/config"
 	"github.com/cockroachdb/cockroach/pkg/gossip"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -52,6 +54,15 @@ const (
 	newReplicaGracePeriod = 5 * time.Minute
 )
 
+// useAtomicReplicationChanges determines whether the replicate queue carries
+// out rebalances as atomic swaps, which add and remove a replica in a single
+// step, instead of adding a replica and removing one in a separate step.
+var useAtomicReplicationChanges = settings.RegisterBoolSetting(
+	"kv.atomic_replication_changes.enabled",
+	"use atomic replication changes to swap replicas when rebalancing",
+	true,
+)
+
 var (
 	metaReplicateQueueAddReplicaCount = metric.Metadata{
 		Name:        "queue.replicate.addreplica",
@@ -77,6 +88,12 @@ var (
 		Measurement: "Replica Additions",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaReplicateQueuePromoteNonVoterCount = metric.Metadata{
+		Name:        "queue.replicate.promotenonvoter",
+		Help:        "Number of non-voting replica promotions attempted by the replicate queue",
+		Measurement: "Replica Promotions",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaReplicateQueueTransferLeaseCount = metric.Metadata{
 		Name:        "queue.replicate.transferlease",
 		Help:        "Number of range lease transfers attempted by the replicate queue",
@@ -110,6 +127,7 @@ type ReplicateQueueMetrics struct {
 	RemoveReplicaCount     *metric.Counter
 	RemoveDeadReplicaCount *metric.Counter
 	RebalanceReplicaCount  *metric.Counter
+	PromoteNonVoterCount   *metric.Counter
 	TransferLeaseCount     *metric.Counter
 }
 
@@ -119,6 +137,7 @@ func makeReplicateQueueMetrics() ReplicateQueueMetrics {
 		RemoveReplicaCount:     metric.NewCounter(metaReplicateQueueRemoveReplicaCount),
 		RemoveDeadReplicaCount: metric.NewCounter(metaReplicateQueueRemoveDeadReplicaCount),
 		RebalanceReplicaCount:  metric.NewCounter(metaReplicateQueueRebalanceReplicaCount),
+		PromoteNonVoterCount:   metric.NewCounter(metaReplicateQueuePromoteNonVoterCount),
 		TransferLeaseCount:     metric.NewCounter(metaReplicateQueueTransferLeaseCount),
 	}
 }
@@ -217,11 +236,16 @@ func (rq *replicateQueue) shouldQueue(
 	}
 
 	if !rq.store.TestingKnobs().DisableReplicaRebalancing {
-		target, _ := rq.allocator.RebalanceTarget(ctx, zone, repl.RaftStatus(), rangeInfo, storeFilterThrottled)
+		target, _, _ := rq.allocator.RebalanceTarget(ctx, zone, repl.RaftStatus(), rangeInfo, storeFilterThrottled)
 		if target != nil {
 			log.VEventf(ctx, 2, "rebalance target found, enqueuing")
 			return true, 0
 		}
+		target, _, _ = rq.allocator.RebalanceNonVoterTarget(ctx, zone, repl.RaftStatus(), rangeInfo, storeFilterThrottled)
+		if target != nil {
+			log.VEventf(ctx, 2, "non-voter rebalance target found, enqueuing")
+			return true, 0
+		}
 		log.VEventf(ctx, 2, "no rebalance target found, not enqueuing")
 	}
 
@@ -229,7 +253,7 @@ func (rq *replicateQueue) shouldQueue(
 	if lease, _ := repl.GetLease(); repl.IsLeaseValid(lease, now) {
 		if rq.canTransferLease() &&
 			rq.allocator.ShouldTransferLease(
-				ctx, zone, desc.Replicas().Unwrap(), lease.Replica.StoreID, desc.RangeID, repl.leaseholderStats) {
+				ctx, zone, desc.Replicas().Voters(), lease.Replica.StoreID, desc.RangeID, repl.leaseholderStats) {
 			log.VEventf(ctx, 2, "lease transfer needed, enqueuing")
 			return true, 0
 		}
@@ -292,9 +316,15 @@ func (rq *replicateQueue) processOneChange(
 	desc, zone := repl.DescAndZone()
 
 	// Avoid taking action if the range has too many dead replicas to make
-	// quorum.
+	// quorum. Only voters count towards the quorum.
 	liveReplicas, deadReplicas := rq.allocator.storePool.liveAndDeadReplicas(
-		desc.RangeID, desc.Replicas().Unwrap())
+		desc.RangeID, desc.Replicas().Voters())
+	liveNonVoters, deadNonVoters := rq.allocator.storePool.liveAndDeadReplicas(
+		desc.RangeID, desc.Replicas().NonVoters())
+	// A node can only hold a single replica of the range, so new replicas must
+	// steer clear of the non-voters as well.
+	liveVotersAndNonVoters := append(
+		append([]roachpb.ReplicaDescriptor(nil), liveReplicas...), liveNonVoters...)
 	{
 		quorum := desc.Replicas().QuorumSize()
 		if lr := len(liveReplicas); lr < quorum {
@@ -309,11 +339,49 @@ func (rq *replicateQueue) processOneChange(
 	switch action {
 	case AllocatorNoop:
 		break
+	case AllocatorFinalizeAtomicReplicationChange:
+		// A previous atomic replication change left the range in a joint
+		// configuration. Leave it before considering any other change.
+		if dryRun {
+			return false, nil
+		}
+		if _, err := repl.maybeLeaveAtomicChangeReplicas(ctx, desc); err != nil {
+			return false, err
+		}
+		return true, nil
 	case AllocatorAdd:
+		// A missing voter is preferably made up for by promoting one of the
+		// range's non-voters, which already has a copy of the data.
+		if promoteReplica, details, ok := rq.allocator.PromoteTarget(
+			ctx, zone, liveReplicas, liveNonVoters, rangeInfo,
+		); ok {
+			rq.metrics.PromoteNonVoterCount.Inc(1)
+			log.VEventf(ctx, 1, "promoting non-voter %+v due to under-replication: %s",
+				promoteReplica, rangeRaftProgress(repl.RaftStatus(), desc.Replicas().Unwrap()))
+			target := roachpb.ReplicationTarget{
+				NodeID:  promoteReplica.NodeID,
+				StoreID: promoteReplica.StoreID,
+			}
+			if err := rq.addReplica(
+				ctx,
+				repl,
+				roachpb.PROMOTE_NON_VOTER,
+				target,
+				desc,
+				SnapshotRequest_RECOVERY,
+				storagepb.ReasonRangeUnderReplicated,
+				details,
+				dryRun,
+			); err != nil {
+				return false, err
+			}
+			break
+		}
+
 		newStore, details, err := rq.allocator.AllocateTarget(
 			ctx,
 			zone,
-			liveReplicas, // only include liveReplicas, since deadReplicas should soon be removed
+			liveVotersAndNonVoters, // only include live replicas, since dead replicas should soon be removed
 			rangeInfo,
 		)
 		if err != nil {
@@ -325,8 +393,8 @@ func (rq *replicateQueue) processOneChange(
 		}
 
 		clusterNodes := rq.allocator.storePool.ClusterNodeCount()
-		need := GetNeededReplicas(*zone.NumReplicas, clusterNodes)
-		willHave := len(desc.Replicas().Unwrap()) + 1
+		need := GetNeededReplicas(zone.GetNumVoters(), clusterNodes)
+		willHave := len(desc.Replicas().Voters()) + 1
 
 		// Only up-replicate if there are suitable allocation targets such
 		// that, either the replication goal is met, or it is possible to get to the
@@ -364,6 +432,7 @@ func (rq *replicateQueue) processOneChange(
 		if err := rq.addReplica(
 			ctx,
 			repl,
+			roachpb.ADD_REPLICA,
 			newReplica,
 			desc,
 			SnapshotRequest_RECOVERY,
@@ -399,7 +468,7 @@ func (rq *replicateQueue) processOneChange(
 				// If we've lost raft leadership, we're unlikely to regain it so give up immediately.
 				return false, &benignError{errors.Errorf("not raft leader while range needs removal")}
 			}
-			candidates = filterUnremovableReplicas(raftStatus, desc.Replicas().Unwrap(), lastReplAdded)
+			candidates = filterUnremovableReplicas(raftStatus, desc.Replicas().Voters(), lastReplAdded)
 			log.VEventf(ctx, 3, "filtered unremovable replicas from %v to get %v as candidates for removal: %s",
 				desc.Replicas(), candidates, rangeRaftProgress(raftStatus, desc.Replicas().Unwrap()))
 			if len(candidates) > 0 {
@@ -479,7 +548,7 @@ func (rq *replicateQueue) processOneChange(
 		}
 	case AllocatorRemoveDecommissioning:
 		decommissioningReplicas := rq.allocator.storePool.decommissioningReplicas(
-			desc.RangeID, desc.Replicas().Unwrap())
+			desc.RangeID, desc.Replicas().Voters())
 		if len(decommissioningReplicas) == 0 {
 			log.VEventf(ctx, 1, "range of replica %s was identified as having decommissioning replicas, "+
 				"but no decommissioning replicas were found", repl)
@@ -538,12 +607,84 @@ func (rq *replicateQueue) processOneChange(
 		); err != nil {
 			return false, err
 		}
+	case AllocatorAddNonVoter:
+		newStore, details, err := rq.allocator.AllocateTarget(
+			ctx,
+			zone,
+			liveVotersAndNonVoters,
+			rangeInfo,
+		)
+		if err != nil {
+			return false, err
+		}
+		newReplica := roachpb.ReplicationTarget{
+			NodeID:  newStore.Node.NodeID,
+			StoreID: newStore.StoreID,
+		}
+		rq.metrics.AddReplicaCount.Inc(1)
+		log.VEventf(ctx, 1, "adding non-voter %+v due to under-replication: %s",
+			newReplica, rangeRaftProgress(repl.RaftStatus(), desc.Replicas().Unwrap()))
+		if err := rq.addReplica(
+			ctx,
+			repl,
+			roachpb.ADD_NON_VOTER,
+			newReplica,
+			desc,
+			SnapshotRequest_RECOVERY,
+			storagepb.ReasonRangeUnderReplicated,
+			details,
+			dryRun,
+		); err != nil {
+			return false, err
+		}
+	case AllocatorRemoveNonVoter:
+		// Dead and decommissioning non-voters are removed first. The leaseholder
+		// is always a voter, so there is no need to transfer the lease away.
+		var removeReplica roachpb.ReplicaDescriptor
+		var reason storagepb.RangeLogEventReason
+		var details string
+		decommissioningNonVoters := rq.allocator.storePool.decommissioningReplicas(
+			desc.RangeID, desc.Replicas().NonVoters())
+		switch {
+		case len(deadNonVoters) > 0:
+			removeReplica, reason = deadNonVoters[0], storagepb.ReasonStoreDead
+			rq.metrics.RemoveDeadReplicaCount.Inc(1)
+		case len(decommissioningNonVoters) > 0:
+			removeReplica, reason = decommissioningNonVoters[0], storagepb.ReasonStoreDecommissioning
+			rq.metrics.RemoveReplicaCount.Inc(1)
+		default:
+			var err error
+			removeReplica, details, err = rq.allocator.RemoveTarget(
+				ctx, zone, desc.Replicas().NonVoters(), rangeInfo)
+			if err != nil {
+				return false, err
+			}
+			reason = storagepb.ReasonRangeOverReplicated
+			rq.metrics.RemoveReplicaCount.Inc(1)
+		}
+		log.VEventf(ctx, 1, "removing non-voter %+v (%s)", removeReplica, reason)
+		target := roachpb.ReplicationTarget{
+			NodeID:  removeReplica.NodeID,
+			StoreID: removeReplica.StoreID,
+		}
+		if err := rq.removeReplica(
+			ctx, repl, target, desc, reason, details, dryRun,
+		); err != nil {
+			return false, err
+		}
 	case AllocatorConsiderRebalance:
 		// The Noop case will result if this replica was queued in order to
-		// rebalance. Attempt to find a rebalancing target.
+		// rebalance. Attempt to find a rebalancing target, first for the voters
+		// and then for the non-voters.
 		if !rq.store.TestingKnobs().DisableReplicaRebalancing {
-			rebalanceStore, details := rq.allocator.RebalanceTarget(
+			changeType := roachpb.ADD_REPLICA
+			rebalanceStore, removeReplica, details := rq.allocator.RebalanceTarget(
 				ctx, zone, repl.RaftStatus(), rangeInfo, storeFilterThrottled)
+			if rebalanceStore == nil {
+				changeType = roachpb.ADD_NON_VOTER
+				rebalanceStore, removeReplica, details = rq.allocator.RebalanceNonVoterTarget(
+					ctx, zone, repl.RaftStatus(), rangeInfo, storeFilterThrottled)
+			}
 			if rebalanceStore == nil {
 				log.VEventf(ctx, 1, "no suitable rebalance target")
 			} else {
@@ -552,18 +693,43 @@ func (rq *replicateQueue) processOneChange(
 					StoreID: rebalanceStore.StoreID,
 				}
 				rq.metrics.RebalanceReplicaCount.Inc(1)
-				log.VEventf(ctx, 1, "rebalancing to %+v: %s",
-					rebalanceReplica, rangeRaftProgress(repl.RaftStatus(), desc.Replicas().Unwrap()))
-				if err := rq.addReplica(
-					ctx,
-					repl,
-					rebalanceReplica,
-					desc,
-					SnapshotRequest_REBALANCE,
-					storagepb.ReasonRebalance,
-					details,
-					dryRun,
-				); err != nil {
+				var err error
+				if rq.canSwapReplicas(repl, removeReplica) {
+					// Add the new replica and remove the one it replaces in a single
+					// step, so that the range never has more or fewer replicas than
+					// configured.
+					rq.metrics.RemoveReplicaCount.Inc(1)
+					log.VEventf(ctx, 1, "rebalancing (%s) to %+v, replacing %+v: %s",
+						changeType, rebalanceReplica, removeReplica,
+						rangeRaftProgress(repl.RaftStatus(), desc.Replicas().Unwrap()))
+					err = rq.swapReplicas(
+						ctx,
+						repl,
+						changeType,
+						rebalanceReplica,
+						roachpb.ReplicationTarget{NodeID: removeReplica.NodeID, StoreID: removeReplica.StoreID},
+						desc,
+						SnapshotRequest_REBALANCE,
+						storagepb.ReasonRebalance,
+						details,
+						dryRun,
+					)
+				} else {
+					log.VEventf(ctx, 1, "rebalancing (%s) to %+v: %s",
+						changeType, rebalanceReplica, rangeRaftProgress(repl.RaftStatus(), desc.Replicas().Unwrap()))
+					err = rq.addReplica(
+						ctx,
+						repl,
+						changeType,
+						rebalanceReplica,
+						desc,
+						SnapshotRequest_REBALANCE,
+						storagepb.ReasonRebalance,
+						details,
+						dryRun,
+					)
+				}
+				if err != nil {
 					return false, err
 				}
 				return true, nil
@@ -614,7 +780,8 @@ func (rq *replicateQueue) findTargetAndTransferLease(
 	zone *config.ZoneConfig,
 	opts transferLeaseOptions,
 ) (bool, error) {
-	candidates := filterBehindReplicas(repl.RaftStatus(), desc.Replicas().Unwrap())
+	// Only voters can hold the lease.
+	candidates := filterBehindReplicas(repl.RaftStatus(), desc.Replicas().Voters())
 	target := rq.allocator.TransferLeaseTarget(
 		ctx,
 		zone,
@@ -639,12 +806,16 @@ func (rq *replicateQueue) findTargetAndTransferLease(
 	if qpsMeasurementDur < MinStatsDuration {
 		avgQPS = 0
 	}
-	err := rq.transferLease(ctx, repl, target, avgQPS)
+	avgCPU, cpuMeasurementDur := repl.cpuStats.avgQPS()
+	if cpuMeasurementDur < MinStatsDuration {
+		avgCPU = 0
+	}
+	err := rq.transferLease(ctx, repl, target, avgQPS, avgCPU)
 	return err == nil, err
 }
 
 func (rq *replicateQueue) transferLease(
-	ctx context.Context, repl *Replica, target roachpb.ReplicaDescriptor, rangeQPS float64,
+	ctx context.Context, repl *Replica, target roachpb.ReplicaDescriptor, rangeQPS, rangeCPU float64,
 ) error {
 	rq.metrics.TransferLeaseCount.Inc(1)
 	log.VEventf(ctx, 1, "transferring lease to s%d", target.StoreID)
@@ -653,13 +824,14 @@ func (rq *replicateQueue) transferLease(
 	}
 	rq.lastLeaseTransfer.Store(timeutil.Now())
 	rq.allocator.storePool.updateLocalStoresAfterLeaseTransfer(
-		repl.store.StoreID(), target.StoreID, rangeQPS)
+		repl.store.StoreID(), target.StoreID, rangeQPS, rangeCPU)
 	return nil
 }
 
 func (rq *replicateQueue) addReplica(
 	ctx context.Context,
 	repl *Replica,
+	changeType roachpb.ReplicaChangeType,
 	target roachpb.ReplicationTarget,
 	desc *roachpb.RangeDescriptor,
 	priority SnapshotRequest_Priority,
@@ -670,11 +842,11 @@ func (rq *replicateQueue) addReplica(
 	if dryRun {
 		return nil
 	}
-	if _, err := repl.changeReplicas(ctx, roachpb.ADD_REPLICA, target, desc, priority, reason, details); err != nil {
+	if _, err := repl.changeReplicas(ctx, changeType, target, desc, priority, reason, details); err != nil {
 		return err
 	}
 	rangeInfo := rangeInfoForRepl(repl, desc)
-	rq.allocator.storePool.updateLocalStoreAfterRebalance(target.StoreID, rangeInfo, roachpb.ADD_REPLICA)
+	rq.allocator.storePool.updateLocalStoreAfterRebalance(target.StoreID, rangeInfo, changeType)
 	return nil
 }
 
@@ -698,6 +870,46 @@ func (rq *replicateQueue) removeReplica(
 	return nil
 }
 
+// canSwapReplicas returns whether a rebalance that removes the given replica
+// can be carried out as a single atomic replication change. The leaseholder
+// can't be swapped out this way, as it needs to transfer its lease away before
+// it is removed.
+func (rq *replicateQueue) canSwapReplicas(repl *Replica, removeReplica roachpb.ReplicaDescriptor) bool {
+	st := rq.store.ClusterSettings()
+	return useAtomicReplicationChanges.Get(&st.SV) &&
+		st.Version.IsActive(cluster.VersionAtomicChangeReplicas) &&
+		removeReplica.StoreID != 0 && removeReplica.StoreID != repl.store.StoreID()
+}
+
+// swapReplicas adds a replica on addTarget and removes the one on
+// removeTarget in a single atomic replication change.
+func (rq *replicateQueue) swapReplicas(
+	ctx context.Context,
+	repl *Replica,
+	changeType roachpb.ReplicaChangeType,
+	addTarget, removeTarget roachpb.ReplicationTarget,
+	desc *roachpb.RangeDescriptor,
+	priority SnapshotRequest_Priority,
+	reason storagepb.RangeLogEventReason,
+	details string,
+	dryRun bool,
+) error {
+	if dryRun {
+		return nil
+	}
+	chgs := []roachpb.ReplicationChange{
+		{ChangeType: changeType, Target: addTarget},
+		{ChangeType: roachpb.REMOVE_REPLICA, Target: removeTarget},
+	}
+	if _, err := repl.changeReplicasAtomic(ctx, desc, priority, reason, details, chgs); err != nil {
+		return err
+	}
+	rangeInfo := rangeInfoForRepl(repl, desc)
+	rq.allocator.storePool.updateLocalStoreAfterRebalance(addTarget.StoreID, rangeInfo, changeType)
+	rq.allocator.storePool.updateLocalStoreAfterRebalance(removeTarget.StoreID, rangeInfo, roachpb.REMOVE_REPLICA)
+	return nil
+}
+
 func (rq *replicateQueue) canTransferLease() bool {
 	if lastLeaseTransfer := rq.lastLeaseTransfer.Load(); lastLeaseTransfer != nil {
 		return timeutil.Since(lastLeaseTransfer.(time.Time)) > minLeaseTransferInterval
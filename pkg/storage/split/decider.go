@@ -37,6 +37,10 @@ const minSplitSuggestionInterval = time.Minute
 // to carry out a split. When the split is initiated, it can obtain the suggested
 // split point from MaybeSplitKey (which may have disappeared either due to a drop
 // in qps or a change in the workload).
+//
+// While the Decider talks about qps throughout, the operations it counts need
+// not be requests; a Decider may equally be fed bytes written or lock
+// conflicts, with the threshold expressed in the same unit per second.
 type Decider struct {
 	intn         func(n int) int // supplied to Init
 	qpsThreshold func() float64  // supplied to Init
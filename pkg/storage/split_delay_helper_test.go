@@ -21,6 +21,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/stretchr/testify/assert"
 	"go.etcd.io/etcd/raft"
+	"go.etcd.io/etcd/raft/tracker"
 )
 
 type testSplitDelayHelper struct {
@@ -86,8 +87,8 @@ func TestSplitDelayToAvoidSnapshot(t *testing.T) {
 			numAttempts: 5,
 			rangeID:     1,
 			raftStatus: &raft.Status{
-				Progress: map[uint64]raft.Progress{
-					2: {State: raft.ProgressStateProbe},
+				Progress: map[uint64]tracker.Progress{
+					2: {State: tracker.StateProbe},
 				},
 			},
 		}
@@ -98,23 +99,27 @@ func TestSplitDelayToAvoidSnapshot(t *testing.T) {
 		assert.Equal(t, 1, h.emptyProposed)
 	})
 
-	for _, state := range []raft.ProgressStateType{raft.ProgressStateProbe, raft.ProgressStateSnapshot} {
+	for _, state := range []tracker.StateType{tracker.StateProbe, tracker.StateSnapshot} {
 		t.Run(state.String(), func(t *testing.T) {
 			h := &testSplitDelayHelper{
 				numAttempts: 5,
 				rangeID:     1,
 				raftStatus: &raft.Status{
-					Progress: map[uint64]raft.Progress{
-						2: {State: state, RecentActive: true, Paused: true /* unifies string output below */},
+					Progress: map[uint64]tracker.Progress{
+						2: {
+							State:        state,
+							RecentActive: true,
+							ProbeSent:    true, // unifies string output below
+							Inflights:    tracker.NewInflights(1),
+						},
 						// Healthy follower just for kicks.
-						3: {State: raft.ProgressStateReplicate},
+						3: {State: tracker.StateReplicate},
 					},
 				},
 			}
 			s := maybeDelaySplitToAvoidSnapshot(ctx, h)
-			assert.Equal(t, "; replica r1/2 not caught up: next = 0, match = 0, state = "+
-				state.String()+
-				", waiting = true, pendingSnapshot = 0; delayed split for 5.0s to avoid Raft snapshot (without success)", s)
+			assert.Equal(t, "; replica r1/2 not caught up: "+state.String()+
+				" match=0 next=0 paused; delayed split for 5.0s to avoid Raft snapshot (without success)", s)
 			assert.Equal(t, 5, h.slept)
 			assert.Equal(t, 5, h.emptyProposed)
 		})
@@ -125,8 +130,8 @@ func TestSplitDelayToAvoidSnapshot(t *testing.T) {
 			numAttempts: 5,
 			rangeID:     1,
 			raftStatus: &raft.Status{
-				Progress: map[uint64]raft.Progress{
-					2: {State: raft.ProgressStateReplicate}, // intentionally not recently active
+				Progress: map[uint64]tracker.Progress{
+					2: {State: tracker.StateReplicate}, // intentionally not recently active
 				},
 			},
 		}
@@ -141,8 +146,8 @@ func TestSplitDelayToAvoidSnapshot(t *testing.T) {
 			numAttempts: 5,
 			rangeID:     1,
 			raftStatus: &raft.Status{
-				Progress: map[uint64]raft.Progress{
-					2: {State: raft.ProgressStateProbe, RecentActive: true},
+				Progress: map[uint64]tracker.Progress{
+					2: {State: tracker.StateProbe, RecentActive: true},
 				},
 			},
 		}
@@ -150,7 +155,7 @@ func TestSplitDelayToAvoidSnapshot(t *testing.T) {
 		h.sleep = func() {
 			if h.slept == 2 {
 				pr := h.raftStatus.Progress[2]
-				pr.State = raft.ProgressStateReplicate
+				pr.State = tracker.StateReplicate
 				h.raftStatus.Progress[2] = pr
 			}
 		}
@@ -116,8 +116,12 @@ func (sq *splitQueue) shouldQueue(
 		repl.GetMaxBytes(), sysCfg)
 
 	if !shouldQ && repl.SplitByLoadEnabled() {
-		if splitKey := repl.loadBasedSplitter.MaybeSplitKey(timeutil.Now()); splitKey != nil {
-			shouldQ, priority = true, 1.0 // default priority
+		now := timeutil.Now()
+		for _, ls := range repl.loadSplitters() {
+			if splitKey := ls.decider.MaybeSplitKey(now); splitKey != nil {
+				shouldQ, priority = true, 1.0 // default priority
+				break
+			}
 		}
 	}
 
@@ -192,14 +196,19 @@ func (sq *splitQueue) processAttempt(
 	}
 
 	now := timeutil.Now()
-	if splitByLoadKey := r.loadBasedSplitter.MaybeSplitKey(now); splitByLoadKey != nil {
+	for _, ls := range r.loadSplitters() {
+		splitByLoadKey := ls.decider.MaybeSplitKey(now)
+		if splitByLoadKey == nil {
+			continue
+		}
 		batchHandledQPS := r.QueriesPerSecond()
 		raftAppliedQPS := r.WritesPerSecond()
-		splitQPS := r.loadBasedSplitter.LastQPS(now)
+		splitLoad := ls.decider.LastQPS(now)
 		reason := fmt.Sprintf(
-			"load at key %s (%.2f splitQPS, %.2f batches/sec, %.2f raft mutations/sec)",
+			"load at key %s (%.2f split %s, %.2f batches/sec, %.2f raft mutations/sec)",
 			splitByLoadKey,
-			splitQPS,
+			splitLoad,
+			ls.unit,
 			batchHandledQPS,
 			raftAppliedQPS,
 		)
@@ -217,8 +226,8 @@ func (sq *splitQueue) processAttempt(
 		); pErr != nil {
 			return errors.Wrapf(pErr, "unable to split %s at key %q", r, splitByLoadKey)
 		}
-		// Reset the splitter now that the bounds of the range changed.
-		r.loadBasedSplitter.Reset()
+		// Reset the splitters now that the bounds of the range changed.
+		r.resetLoadSplitters()
 		return nil
 	}
 	return nil
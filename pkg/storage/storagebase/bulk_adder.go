@@ -42,6 +42,43 @@ type BulkAdder interface {
 	// sorted batch. Once a batch is flushed – explicitly or automatically – local
 	// duplicate detection does not apply.
 	SkipLocalDuplicates(bool)
+	// DisallowShadowing configures whether the ingestion of a key fails if it
+	// would shadow an existing live value, unless the value is identical.
+	DisallowShadowing(bool)
+	// WriteAtRequestTimestamp configures whether keys are ingested at the
+	// timestamp of the ingestion requests, which is above that of any read of
+	// the keys, rather than at the timestamp of the adder.
+	WriteAtRequestTimestamp(bool)
+	// SetIngestRecorder configures a recorder which is notified of the spans
+	// ingested by the adder, along with the timestamps at which their keys
+	// were written. Only meaningful when writing at request timestamps.
+	SetIngestRecorder(IngestRecorder)
+}
+
+// IngestRecorder is notified of the spans ingested by a BulkAdder, e.g. so
+// that the keys ingested into them can be found again if the ingestion has to
+// be rolled back.
+type IngestRecorder interface {
+	// Ingesting is called with the span of the keys about to be ingested,
+	// before any of them are. The keys may be ingested at any timestamp from
+	// then on, until a subsequent call to Ingested for the span narrows it
+	// down. No further keys are ingested if an error is returned.
+	Ingesting(ctx context.Context, span roachpb.Span) error
+	// Ingested is called once the keys in the span passed to the preceding
+	// call to Ingesting have all been ingested, with the spans of the
+	// ingestion requests and the timestamps at which they were written. It is
+	// not called if the ingestion fails.
+	Ingested(ctx context.Context, span roachpb.Span, ingested []IngestedSpan) error
+}
+
+// IngestedSpan is a span ingested by a single request, e.g. an AddSSTable.
+type IngestedSpan struct {
+	Span roachpb.Span
+	// Timestamp is the timestamp at which the keys in the span were written.
+	// It is empty if the result of the request was ambiguous, in which case
+	// the keys may have been written at any timestamp since the request was
+	// sent.
+	Timestamp hlc.Timestamp
 }
 
 // DuplicateKeyError represents a failed attempt to ingest the same key twice
@@ -37,6 +37,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
+	"github.com/cockroachdb/cockroach/pkg/storage/admission"
 	"github.com/cockroachdb/cockroach/pkg/storage/batcheval"
 	"github.com/cockroachdb/cockroach/pkg/storage/closedts/container"
 	"github.com/cockroachdb/cockroach/pkg/storage/closedts/ctpb"
@@ -45,6 +46,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/storage/idalloc"
 	"github.com/cockroachdb/cockroach/pkg/storage/intentresolver"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/storage/raftentry"
 	"github.com/cockroachdb/cockroach/pkg/storage/stateloader"
 	"github.com/cockroachdb/cockroach/pkg/storage/tscache"
@@ -97,8 +99,54 @@ const (
 )
 
 var changeTypeInternalToRaft = map[roachpb.ReplicaChangeType]raftpb.ConfChangeType{
-	roachpb.ADD_REPLICA:    raftpb.ConfChangeAddNode,
-	roachpb.REMOVE_REPLICA: raftpb.ConfChangeRemoveNode,
+	roachpb.ADD_REPLICA:       raftpb.ConfChangeAddNode,
+	roachpb.REMOVE_REPLICA:    raftpb.ConfChangeRemoveNode,
+	roachpb.ADD_NON_VOTER:     raftpb.ConfChangeAddLearnerNode,
+	roachpb.PROMOTE_NON_VOTER: raftpb.ConfChangeAddNode,
+}
+
+// confChangeForTrigger returns the raft configuration change that carries out
+// the given ChangeReplicasTrigger. Changes of a single replica are proposed as
+// a ConfChange, which all versions understand. Atomic replication changes
+// enter a joint configuration using a ConfChangeV2 that raft doesn't leave on
+// its own; it is left by a subsequent trigger, so that the range descriptor
+// always reflects the joint configuration.
+func confChangeForTrigger(
+	crt *roachpb.ChangeReplicasTrigger, encodedCtx []byte,
+) raftpb.ConfChangeI {
+	if crt.LeaveJoint {
+		// An empty ConfChangeV2 instructs raft to leave the joint configuration.
+		return raftpb.ConfChangeV2{Context: encodedCtx}
+	}
+	if !crt.IsAtomic() {
+		return raftpb.ConfChange{
+			Type:    changeTypeInternalToRaft[crt.ChangeType],
+			NodeID:  uint64(crt.Replica.ReplicaID),
+			Context: encodedCtx,
+		}
+	}
+	var changes []raftpb.ConfChangeSingle
+	for _, rDesc := range crt.Removed() {
+		changes = append(changes, raftpb.ConfChangeSingle{
+			Type:   raftpb.ConfChangeRemoveNode,
+			NodeID: uint64(rDesc.ReplicaID),
+		})
+	}
+	for _, rDesc := range crt.Added() {
+		typ := raftpb.ConfChangeAddNode
+		if !rDesc.Type.IsVoter() {
+			typ = raftpb.ConfChangeAddLearnerNode
+		}
+		changes = append(changes, raftpb.ConfChangeSingle{
+			Type:   typ,
+			NodeID: uint64(rDesc.ReplicaID),
+		})
+	}
+	return raftpb.ConfChangeV2{
+		Transition: raftpb.ConfChangeTransitionJointExplicit,
+		Changes:    changes,
+		Context:    encodedCtx,
+	}
 }
 
 var storeSchedulerConcurrency = envutil.EnvOrDefaultInt(
@@ -410,6 +458,7 @@ type Store struct {
 	raftEntryCache     *raftentry.Cache
 	limiters           batcheval.Limiters
 	txnWaitMetrics     *txnwait.Metrics
+	admissionQueue     *admission.WorkQueue
 
 	// gossipRangeCountdown and leaseRangeCountdown are countdowns of
 	// changes to range and leaseholder counts, after which the store
@@ -634,6 +683,11 @@ type StoreConfig struct {
 
 	ClosedTimestamp *container.Container
 
+	// ProtectedTimestampCache is consulted by the GC queue to avoid removing
+	// data which is protected by a protected timestamp record. It may be nil,
+	// in which case no data is protected.
+	ProtectedTimestampCache protectedts.Cache
+
 	// SQLExecutor is used by the store to execute SQL statements.
 	SQLExecutor sqlutil.InternalExecutor
 
@@ -833,6 +887,9 @@ func NewStore(
 	s.txnWaitMetrics = txnwait.NewMetrics(cfg.HistogramWindowInterval)
 	s.metrics.registry.AddMetricStruct(s.txnWaitMetrics)
 
+	s.admissionQueue = admission.NewWorkQueue(cfg.Settings)
+	s.metrics.registry.AddMetricStruct(s.admissionQueue.Metrics())
+
 	s.compactor = compactor.NewCompactor(
 		s.cfg.Settings,
 		s.engine.(engine.WithSSTables),
@@ -1436,6 +1493,9 @@ func (s *Store) Start(ctx context.Context, stopper *stop.Stopper) error {
 	// Connect rangefeeds to closed timestamp updates.
 	s.startClosedTimestampRangefeedSubscriber(ctx)
 
+	// Keep the admission queue informed of the health of the storage engine.
+	s.startAdmissionHealthPoller(ctx)
+
 	if s.replicateQueue != nil {
 		s.storeRebalancer = NewStoreRebalancer(
 			s.cfg.AmbientCtx, s.cfg.Settings, s.replicateQueue, s.replRankings)
@@ -2242,6 +2302,12 @@ func (s *Store) SplitRange(
 	// txnWaitQueue after we clear it.
 	leftRepl.txnWaitQueue.Clear(false /* disable */)
 
+	// Likewise, clear the LHS lock table. Requests waiting on locks in the
+	// RHS will be redirected, and the remaining ones will rediscover the
+	// intents that they conflict with. Unreplicated locks on either side of
+	// the split are dropped.
+	leftRepl.lockTable.Clear()
+
 	// The rangefeed processor will no longer be provided logical ops for
 	// its entire range, so it needs to be shut down and all registrations
 	// need to retry.
@@ -2256,6 +2322,8 @@ func (s *Store) SplitRange(
 	// spans that are now owned by the new range.
 	leftRepl.leaseholderStats.resetRequestCounts()
 	leftRepl.writeStats.splitRequestCounts(rightRepl.writeStats)
+	leftRepl.writeBytesStats.splitRequestCounts(rightRepl.writeBytesStats)
+	leftRepl.cpuStats.splitRequestCounts(rightRepl.cpuStats)
 
 	if err := s.addReplicaInternalLocked(rightRepl); err != nil {
 		return errors.Errorf("unable to add replica %v: %s", rightRepl, err)
@@ -2340,10 +2408,19 @@ func (s *Store) MergeRange(
 		// logic that depends on them.
 		leftRepl.writeStats.resetRequestCounts()
 	}
+	if leftRepl.writeBytesStats != nil {
+		leftRepl.writeBytesStats.resetRequestCounts()
+	}
+	if leftRepl.cpuStats != nil {
+		leftRepl.cpuStats.resetRequestCounts()
+	}
 
 	// Clear the wait queue to redirect the queued transactions to the
 	// left-hand replica, if necessary.
 	rightRepl.txnWaitQueue.Clear(true /* disable */)
+	// The unreplicated locks on the right-hand side are dropped rather than
+	// moved to the left-hand replica's lock table.
+	rightRepl.lockTable.Clear()
 
 	leftLease, _ := leftRepl.GetLease()
 	rightLease, _ := rightRepl.GetLease()
@@ -2657,6 +2734,8 @@ func (s *Store) Capacity(useCached bool) (roachpb.StoreCapacity, error) {
 	var logicalBytes int64
 	var totalQueriesPerSecond float64
 	var totalWritesPerSecond float64
+	var totalWriteBytesPerSecond float64
+	var totalCPUPerSecond float64
 	replicaCount := s.metrics.ReplicaCount.Value()
 	bytesPerReplica := make([]float64, 0, replicaCount)
 	writesPerReplica := make([]float64, 0, replicaCount)
@@ -2683,9 +2762,21 @@ func (s *Store) Capacity(useCached bool) (roachpb.StoreCapacity, error) {
 			totalWritesPerSecond += wps
 			writesPerReplica = append(writesPerReplica, wps)
 		}
+		var writeBytes float64
+		if wbps, dur := r.writeBytesStats.avgQPS(); dur >= MinStatsDuration {
+			writeBytes = wbps
+			totalWriteBytesPerSecond += wbps
+		}
+		var cpu float64
+		if cpuPerSecond, dur := r.cpuStats.avgQPS(); dur >= MinStatsDuration {
+			cpu = cpuPerSecond
+			totalCPUPerSecond += cpuPerSecond
+		}
 		rankingsAccumulator.addReplica(replicaWithStats{
-			repl: r,
-			qps:  qps,
+			repl:       r,
+			qps:        qps,
+			writeBytes: writeBytes,
+			cpu:        cpu,
 		})
 		return true
 	})
@@ -2694,6 +2785,8 @@ func (s *Store) Capacity(useCached bool) (roachpb.StoreCapacity, error) {
 	capacity.LogicalBytes = logicalBytes
 	capacity.QueriesPerSecond = totalQueriesPerSecond
 	capacity.WritesPerSecond = totalWritesPerSecond
+	capacity.WriteBytesPerSecond = totalWriteBytesPerSecond
+	capacity.CPUPerSecond = totalCPUPerSecond
 	capacity.BytesPerReplica = roachpb.PercentilesFromData(bytesPerReplica)
 	capacity.WritesPerReplica = roachpb.PercentilesFromData(writesPerReplica)
 	s.recordNewPerSecondStats(totalQueriesPerSecond, totalWritesPerSecond)
@@ -2786,6 +2879,14 @@ func (s *Store) Send(
 		}
 	}
 
+	// Throttle the batch, according to its priority, if the storage engine is
+	// overloaded.
+	if pri, ok := admissionPriority(&ba); ok {
+		if err := s.admissionQueue.Admit(ctx, pri); err != nil {
+			return nil, roachpb.NewError(err)
+		}
+	}
+
 	// Limit the number of concurrent AddSSTable requests, since they're expensive
 	// and block all other writes to the same span.
 	if ba.IsSingleAddSSTableRequest() {
@@ -3025,8 +3126,18 @@ func (s *Store) Send(
 			pErr = nil
 
 		case *roachpb.WriteIntentError:
-			// Process and resolve write intent error. We do this here because
-			// this is the code path with the requesting client waiting.
+			// If the request waits in the lock table, add the conflicting intents
+			// to the table and retry the command. The command will find the
+			// intents' locks when it scans the lock table and wait on them.
+			if pErr.Index != nil && repl.addDiscoveredLocks(&ba, pErr.Index.Index, t) {
+				pErr = nil
+				break
+			}
+			// Otherwise, process and resolve write intent error. We do this here
+			// because this is the code path with the requesting client waiting.
+			if len(t.Intents) > 0 {
+				repl.recordContention(ctx, t.Intents[0].Key)
+			}
 			if pErr.Index != nil {
 				var pushType roachpb.PushTxnType
 				if ba.IsWrite() {
@@ -3495,7 +3606,7 @@ func (s *Store) processRaftSnapshotRequest(
 					appliedIndex,
 					r.store.cfg,
 					&raftLogger{ctx: ctx},
-				), nil)
+				))
 			if err != nil {
 				return roachpb.NewError(err)
 			}
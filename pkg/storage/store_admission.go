@@ -0,0 +1,106 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// admissionHealthInterval is the interval at which the store informs its
+// admission queue of the health of its storage engine. While the engine is
+// overloaded, this is also the granularity at which the queue admits work.
+const admissionHealthInterval = 250 * time.Millisecond
+
+// startAdmissionHealthPoller starts a goroutine which periodically reads the
+// storage engine's stats and passes them on to the store's admission queue.
+func (s *Store) startAdmissionHealthPoller(ctx context.Context) {
+	s.stopper.RunWorker(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(admissionHealthInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				stats, err := s.engine.GetStats()
+				if err != nil {
+					log.Warningf(ctx, "failed to read engine stats for admission control: %+v", err)
+					continue
+				}
+				s.admissionQueue.UpdateHealth(timeutil.Now(), admission.EngineHealth{
+					L0FileCount:                    stats.L0FileCount,
+					PendingCompactionBytesEstimate: stats.PendingCompactionBytesEstimate,
+				})
+			case <-s.stopper.ShouldStop():
+				return
+			}
+		}
+	})
+}
+
+// admissionPriority returns the priority with which the batch is admitted by
+// the store's admission queue. If the second return value is false, the batch
+// bypasses admission control altogether: requests to system ranges (such as
+// node liveness and meta ranges) and requests maintaining the range itself
+// must not be throttled, since the engine can't recover from overload without
+// them.
+//
+// Background work, such as bulk ingestion and garbage collection, is admitted
+// at low priority, as are batches sent with the minimum user priority. Requests
+// which other work is likely to be waiting on, such as transaction heartbeats,
+// pushes, commits and intent resolution, are admitted at high priority, as are
+// batches sent with the maximum user priority.
+func admissionPriority(ba *roachpb.BatchRequest) (admission.WorkPriority, bool) {
+	if len(ba.Requests) == 0 {
+		return 0, false
+	}
+	if ba.Requests[0].GetInner().Header().Key.Compare(keys.UserTableDataMin) < 0 {
+		return 0, false
+	}
+
+	pri := admission.NormalPri
+	switch ba.UserPriority {
+	case roachpb.MinUserPriority:
+		pri = admission.LowPri
+	case roachpb.MaxUserPriority:
+		pri = admission.HighPri
+	}
+
+	allBackground := true
+	for _, union := range ba.Requests {
+		switch union.GetInner().(type) {
+		case *roachpb.RequestLeaseRequest, *roachpb.TransferLeaseRequest,
+			*roachpb.SubsumeRequest, *roachpb.ComputeChecksumRequest:
+			return 0, false
+		case *roachpb.PushTxnRequest, *roachpb.QueryTxnRequest,
+			*roachpb.HeartbeatTxnRequest, *roachpb.RecoverTxnRequest,
+			*roachpb.EndTransactionRequest, *roachpb.ResolveIntentRequest,
+			*roachpb.ResolveIntentRangeRequest:
+			return admission.HighPri, true
+		case *roachpb.AddSSTableRequest, *roachpb.GCRequest,
+			*roachpb.ExportRequest, *roachpb.ImportRequest,
+			*roachpb.ClearRangeRequest:
+		default:
+			allBackground = false
+		}
+	}
+	if allBackground {
+		return admission.LowPri, true
+	}
+	return pri, true
+}
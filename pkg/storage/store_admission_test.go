@@ -0,0 +1,102 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestAdmissionPriority(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	userKey := roachpb.Key(keys.MakeTablePrefix(keys.MinUserDescID))
+	userSpan := roachpb.RequestHeader{Key: userKey}
+	testCases := []struct {
+		name     string
+		reqs     []roachpb.Request
+		userPri  roachpb.UserPriority
+		expPri   admission.WorkPriority
+		expAdmit bool
+	}{
+		{
+			name:     "liveness",
+			reqs:     []roachpb.Request{&roachpb.PutRequest{RequestHeader: roachpb.RequestHeader{Key: keys.NodeLivenessKey(1)}}},
+			expAdmit: false,
+		},
+		{
+			name:     "lease",
+			reqs:     []roachpb.Request{&roachpb.RequestLeaseRequest{RequestHeader: userSpan}},
+			expAdmit: false,
+		},
+		{
+			name:     "get",
+			reqs:     []roachpb.Request{&roachpb.GetRequest{RequestHeader: userSpan}},
+			expPri:   admission.NormalPri,
+			expAdmit: true,
+		},
+		{
+			name:     "get with min user priority",
+			reqs:     []roachpb.Request{&roachpb.GetRequest{RequestHeader: userSpan}},
+			userPri:  roachpb.MinUserPriority,
+			expPri:   admission.LowPri,
+			expAdmit: true,
+		},
+		{
+			name:     "get with max user priority",
+			reqs:     []roachpb.Request{&roachpb.GetRequest{RequestHeader: userSpan}},
+			userPri:  roachpb.MaxUserPriority,
+			expPri:   admission.HighPri,
+			expAdmit: true,
+		},
+		{
+			name: "put and commit",
+			reqs: []roachpb.Request{
+				&roachpb.PutRequest{RequestHeader: userSpan},
+				&roachpb.EndTransactionRequest{RequestHeader: userSpan},
+			},
+			expPri:   admission.HighPri,
+			expAdmit: true,
+		},
+		{
+			name:     "add sstable",
+			reqs:     []roachpb.Request{&roachpb.AddSSTableRequest{RequestHeader: userSpan}},
+			expPri:   admission.LowPri,
+			expAdmit: true,
+		},
+		{
+			name:     "gc",
+			reqs:     []roachpb.Request{&roachpb.GCRequest{RequestHeader: userSpan}},
+			expPri:   admission.LowPri,
+			expAdmit: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ba roachpb.BatchRequest
+			ba.UserPriority = tc.userPri
+			ba.Add(tc.reqs...)
+			pri, admit := admissionPriority(&ba)
+			if admit != tc.expAdmit {
+				t.Fatalf("expected admit=%t, got %t", tc.expAdmit, admit)
+			}
+			if admit && pri != tc.expPri {
+				t.Fatalf("expected priority %s, got %s", tc.expPri, pri)
+			}
+		})
+	}
+}
@@ -323,10 +323,11 @@ func (sp *StorePool) updateLocalStoreAfterRebalance(
 		return
 	}
 	switch changeType {
-	case roachpb.ADD_REPLICA:
+	case roachpb.ADD_REPLICA, roachpb.ADD_NON_VOTER:
 		detail.desc.Capacity.RangeCount++
 		detail.desc.Capacity.LogicalBytes += rangeInfo.LogicalBytes
 		detail.desc.Capacity.WritesPerSecond += rangeInfo.WritesPerSecond
+		detail.desc.Capacity.WriteBytesPerSecond += rangeInfo.WriteBytesPerSecond
 	case roachpb.REMOVE_REPLICA:
 		detail.desc.Capacity.RangeCount--
 		if detail.desc.Capacity.LogicalBytes <= rangeInfo.LogicalBytes {
@@ -339,6 +340,11 @@ func (sp *StorePool) updateLocalStoreAfterRebalance(
 		} else {
 			detail.desc.Capacity.WritesPerSecond -= rangeInfo.WritesPerSecond
 		}
+		if detail.desc.Capacity.WriteBytesPerSecond <= rangeInfo.WriteBytesPerSecond {
+			detail.desc.Capacity.WriteBytesPerSecond = 0
+		} else {
+			detail.desc.Capacity.WriteBytesPerSecond -= rangeInfo.WriteBytesPerSecond
+		}
 	}
 	sp.detailsMu.storeDetails[storeID] = &detail
 }
@@ -346,7 +352,7 @@ func (sp *StorePool) updateLocalStoreAfterRebalance(
 // updateLocalStoresAfterLeaseTransfer is used to update the local copies of the
 // involved store descriptors immediately after a lease transfer.
 func (sp *StorePool) updateLocalStoresAfterLeaseTransfer(
-	from roachpb.StoreID, to roachpb.StoreID, rangeQPS float64,
+	from roachpb.StoreID, to roachpb.StoreID, rangeQPS, rangeCPU float64,
 ) {
 	sp.detailsMu.Lock()
 	defer sp.detailsMu.Unlock()
@@ -359,6 +365,11 @@ func (sp *StorePool) updateLocalStoresAfterLeaseTransfer(
 		} else {
 			fromDetail.desc.Capacity.QueriesPerSecond -= rangeQPS
 		}
+		if fromDetail.desc.Capacity.CPUPerSecond < rangeCPU {
+			fromDetail.desc.Capacity.CPUPerSecond = 0
+		} else {
+			fromDetail.desc.Capacity.CPUPerSecond -= rangeCPU
+		}
 		sp.detailsMu.storeDetails[from] = &fromDetail
 	}
 
@@ -366,6 +377,7 @@ func (sp *StorePool) updateLocalStoresAfterLeaseTransfer(
 	if toDetail.desc != nil {
 		toDetail.desc.Capacity.LeaseCount++
 		toDetail.desc.Capacity.QueriesPerSecond += rangeQPS
+		toDetail.desc.Capacity.CPUPerSecond += rangeCPU
 		sp.detailsMu.storeDetails[to] = &toDetail
 	}
 }
@@ -506,6 +518,14 @@ type StoreList struct {
 	// candidateWritesPerSecond tracks writes-per-second stats for stores that are
 	// eligible to be rebalance targets.
 	candidateWritesPerSecond stat
+
+	// candidateWriteBytesPerSecond tracks write-bytes-per-second stats for
+	// stores that are eligible to be rebalance targets.
+	candidateWriteBytesPerSecond stat
+
+	// candidateCPUPerSecond tracks request CPU stats for stores that are
+	// eligible to be rebalance targets.
+	candidateCPUPerSecond stat
 }
 
 // Generates a new store list based on the passed in descriptors. It will
@@ -520,28 +540,51 @@ func makeStoreList(descriptors []roachpb.StoreDescriptor) StoreList {
 		sl.candidateLogicalBytes.update(float64(desc.Capacity.LogicalBytes))
 		sl.candidateQueriesPerSecond.update(desc.Capacity.QueriesPerSecond)
 		sl.candidateWritesPerSecond.update(desc.Capacity.WritesPerSecond)
+		sl.candidateWriteBytesPerSecond.update(desc.Capacity.WriteBytesPerSecond)
+		sl.candidateCPUPerSecond.update(desc.Capacity.CPUPerSecond)
 	}
 	return sl
 }
 
+// candidateLoad returns the load stats along the given dimension for the
+// stores that are eligible to be rebalance targets.
+func (sl StoreList) candidateLoad(dim loadDimension) stat {
+	switch dim {
+	case qpsDimension:
+		return sl.candidateQueriesPerSecond
+	case writeBytesDimension:
+		return sl.candidateWriteBytesPerSecond
+	case cpuDimension:
+		return sl.candidateCPUPerSecond
+	default:
+		panic(fmt.Sprintf("unknown load dimension %d", dim))
+	}
+}
+
 func (sl StoreList) String() string {
 	var buf bytes.Buffer
 	fmt.Fprintf(&buf,
-		"  candidate: avg-ranges=%v avg-leases=%v avg-disk-usage=%v avg-queries-per-second=%v",
+		"  candidate: avg-ranges=%v avg-leases=%v avg-disk-usage=%v avg-queries-per-second=%v"+
+			" avg-write-bytes-per-second=%v avg-cpu-per-second=%v",
 		sl.candidateRanges.mean,
 		sl.candidateLeases.mean,
 		humanizeutil.IBytes(int64(sl.candidateLogicalBytes.mean)),
-		sl.candidateQueriesPerSecond.mean)
+		sl.candidateQueriesPerSecond.mean,
+		humanizeutil.IBytes(int64(sl.candidateWriteBytesPerSecond.mean)),
+		time.Duration(sl.candidateCPUPerSecond.mean))
 	if len(sl.stores) > 0 {
 		fmt.Fprintf(&buf, "\n")
 	} else {
 		fmt.Fprintf(&buf, " <no candidates>")
 	}
 	for _, desc := range sl.stores {
-		fmt.Fprintf(&buf, "  %d: ranges=%d leases=%d disk-usage=%s queries-per-second=%.2f\n",
+		fmt.Fprintf(&buf, "  %d: ranges=%d leases=%d disk-usage=%s queries-per-second=%.2f"+
+			" write-bytes-per-second=%s cpu-per-second=%s\n",
 			desc.StoreID, desc.Capacity.RangeCount,
 			desc.Capacity.LeaseCount, humanizeutil.IBytes(desc.Capacity.LogicalBytes),
-			desc.Capacity.QueriesPerSecond)
+			desc.Capacity.QueriesPerSecond,
+			humanizeutil.IBytes(int64(desc.Capacity.WriteBytesPerSecond)),
+			time.Duration(desc.Capacity.CPUPerSecond))
 	}
 	return buf.String()
 }
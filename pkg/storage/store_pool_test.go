@@ -402,26 +402,30 @@ func TestStorePoolUpdateLocalStore(t *testing.T) {
 			StoreID: 1,
 			Node:    roachpb.NodeDescriptor{NodeID: 1},
 			Capacity: roachpb.StoreCapacity{
-				Capacity:         100,
-				Available:        50,
-				RangeCount:       5,
-				LeaseCount:       1,
-				LogicalBytes:     30,
-				QueriesPerSecond: 100,
-				WritesPerSecond:  30,
+				Capacity:            100,
+				Available:           50,
+				RangeCount:          5,
+				LeaseCount:          1,
+				LogicalBytes:        30,
+				QueriesPerSecond:    100,
+				WritesPerSecond:     30,
+				WriteBytesPerSecond: 3000,
+				CPUPerSecond:        1000,
 			},
 		},
 		{
 			StoreID: 2,
 			Node:    roachpb.NodeDescriptor{NodeID: 2},
 			Capacity: roachpb.StoreCapacity{
-				Capacity:         100,
-				Available:        55,
-				RangeCount:       4,
-				LeaseCount:       2,
-				LogicalBytes:     25,
-				QueriesPerSecond: 50,
-				WritesPerSecond:  25,
+				Capacity:            100,
+				Available:           55,
+				RangeCount:          4,
+				LeaseCount:          2,
+				LogicalBytes:        25,
+				QueriesPerSecond:    50,
+				WritesPerSecond:     25,
+				WriteBytesPerSecond: 2500,
+				CPUPerSecond:        500,
 			},
 		},
 	}
@@ -441,6 +445,8 @@ func TestStorePoolUpdateLocalStore(t *testing.T) {
 	manual.Increment(int64(MinStatsDuration + time.Second))
 	replica.leaseholderStats = rs
 	replica.writeStats = rs
+	replica.writeBytesStats = rs
+	replica.cpuStats = rs
 
 	rangeDesc := &roachpb.RangeDescriptor{
 		RangeID: replica.RangeID,
@@ -455,6 +461,8 @@ func TestStorePoolUpdateLocalStore(t *testing.T) {
 	}
 	QPS, _ := replica.leaseholderStats.avgQPS()
 	WPS, _ := replica.writeStats.avgQPS()
+	WBPS, _ := replica.writeBytesStats.avgQPS()
+	CPU, _ := replica.cpuStats.avgQPS()
 	if expectedRangeCount := int32(6); desc.Capacity.RangeCount != expectedRangeCount {
 		t.Errorf("expected RangeCount %d, but got %d", expectedRangeCount, desc.Capacity.RangeCount)
 	}
@@ -467,6 +475,9 @@ func TestStorePoolUpdateLocalStore(t *testing.T) {
 	if expectedWPS := 30 + WPS; desc.Capacity.WritesPerSecond != expectedWPS {
 		t.Errorf("expected WritesPerSecond %f, but got %f", expectedWPS, desc.Capacity.WritesPerSecond)
 	}
+	if expectedWBPS := 3000 + WBPS; desc.Capacity.WriteBytesPerSecond != expectedWBPS {
+		t.Errorf("expected WriteBytesPerSecond %f, but got %f", expectedWBPS, desc.Capacity.WriteBytesPerSecond)
+	}
 
 	sp.updateLocalStoreAfterRebalance(roachpb.StoreID(2), rangeInfo, roachpb.REMOVE_REPLICA)
 	desc, ok = sp.getStoreDescriptor(roachpb.StoreID(2))
@@ -485,8 +496,12 @@ func TestStorePoolUpdateLocalStore(t *testing.T) {
 	if expectedWPS := 25 - WPS; desc.Capacity.WritesPerSecond != expectedWPS {
 		t.Errorf("expected WritesPerSecond %f, but got %f", expectedWPS, desc.Capacity.WritesPerSecond)
 	}
+	if expectedWBPS := 2500 - WBPS; desc.Capacity.WriteBytesPerSecond != expectedWBPS {
+		t.Errorf("expected WriteBytesPerSecond %f, but got %f", expectedWBPS, desc.Capacity.WriteBytesPerSecond)
+	}
 
-	sp.updateLocalStoresAfterLeaseTransfer(roachpb.StoreID(1), roachpb.StoreID(2), rangeInfo.QueriesPerSecond)
+	sp.updateLocalStoresAfterLeaseTransfer(
+		roachpb.StoreID(1), roachpb.StoreID(2), rangeInfo.QueriesPerSecond, rangeInfo.CPUPerSecond)
 	desc, ok = sp.getStoreDescriptor(roachpb.StoreID(1))
 	if !ok {
 		t.Fatalf("couldn't find StoreDescriptor for Store ID %d", 1)
@@ -497,6 +512,9 @@ func TestStorePoolUpdateLocalStore(t *testing.T) {
 	if expectedQPS := 100 - QPS; desc.Capacity.QueriesPerSecond != expectedQPS {
 		t.Errorf("expected QueriesPerSecond %f, but got %f", expectedQPS, desc.Capacity.QueriesPerSecond)
 	}
+	if expectedCPU := 1000 - CPU; desc.Capacity.CPUPerSecond != expectedCPU {
+		t.Errorf("expected CPUPerSecond %f, but got %f", expectedCPU, desc.Capacity.CPUPerSecond)
+	}
 	desc, ok = sp.getStoreDescriptor(roachpb.StoreID(2))
 	if !ok {
 		t.Fatalf("couldn't find StoreDescriptor for Store ID %d", 2)
@@ -507,6 +525,9 @@ func TestStorePoolUpdateLocalStore(t *testing.T) {
 	if expectedQPS := 50 + QPS; desc.Capacity.QueriesPerSecond != expectedQPS {
 		t.Errorf("expected QueriesPerSecond %f, but got %f", expectedQPS, desc.Capacity.QueriesPerSecond)
 	}
+	if expectedCPU := 500 + CPU; desc.Capacity.CPUPerSecond != expectedCPU {
+		t.Errorf("expected CPUPerSecond %f, but got %f", expectedCPU, desc.Capacity.CPUPerSecond)
+	}
 }
 
 // TestStorePoolUpdateLocalStoreBeforeGossip verifies that an attempt to update
@@ -42,6 +42,15 @@ const (
 	// by less than this amount even if the amount is greater than the percentage
 	// threshold. This avoids too many lease transfers in lightly loaded clusters.
 	minQPSThresholdDifference = 100
+
+	// minWriteBytesThresholdDifference is the analog of
+	// minQPSThresholdDifference for bytes written per second.
+	minWriteBytesThresholdDifference = 1 << 20 // 1 MiB/s
+
+	// minCPUThresholdDifference is the analog of minQPSThresholdDifference for
+	// request CPU, measured in nanoseconds per second. It corresponds to a
+	// tenth of a core.
+	minCPUThresholdDifference = float64(100 * time.Millisecond)
 )
 
 var (
@@ -77,7 +86,7 @@ func makeStoreRebalancerMetrics() StoreRebalancerMetrics {
 // If disabled, rebalancing is done purely based on replica count.
 var LoadBasedRebalancingMode = settings.RegisterEnumSetting(
 	"kv.allocator.load_based_rebalancing",
-	"whether to rebalance based on the distribution of QPS across stores",
+	"whether to rebalance based on the distribution of load (QPS, bytes written and request CPU) across stores",
 	"leases and replicas",
 	map[int64]string{
 		int64(LBRebalancingOff):               "off",
@@ -97,6 +106,23 @@ var qpsRebalanceThreshold = settings.RegisterNonNegativeFloatSetting(
 	0.25,
 )
 
+// writeBytesRebalanceThreshold is much like qpsRebalanceThreshold, but for the
+// number of bytes written per second, which approximates the disk bandwidth
+// consumed by a store's replicas.
+var writeBytesRebalanceThreshold = settings.RegisterNonNegativeFloatSetting(
+	"kv.allocator.write_bytes_rebalance_threshold",
+	"minimum fraction away from the mean a store's bytes written per second can be before it is considered overfull or underfull; 0 disables write-based rebalancing",
+	0.25,
+)
+
+// cpuRebalanceThreshold is much like qpsRebalanceThreshold, but for the time
+// spent evaluating requests.
+var cpuRebalanceThreshold = settings.RegisterNonNegativeFloatSetting(
+	"kv.allocator.cpu_rebalance_threshold",
+	"minimum fraction away from the mean a store's request CPU can be before it is considered overfull or underfull; 0 disables CPU-based rebalancing",
+	0.25,
+)
+
 // LBRebalancingMode controls if and when we do store-level rebalancing
 // based on load.
 type LBRebalancingMode int64
@@ -106,10 +132,10 @@ const (
 	// based on load statistics.
 	LBRebalancingOff LBRebalancingMode = iota
 	// LBRebalancingLeasesOnly means that we rebalance leases based on
-	// store-level QPS imbalances.
+	// store-level load imbalances.
 	LBRebalancingLeasesOnly
 	// LBRebalancingLeasesAndReplicas means that we rebalance both leases and
-	// replicas based on store-level QPS imbalances.
+	// replicas based on store-level load imbalances.
 	LBRebalancingLeasesAndReplicas
 )
 
@@ -200,17 +226,82 @@ func (sr *StoreRebalancer) Start(ctx context.Context, stopper *stop.Stopper) {
 	})
 }
 
+// dimThresholds holds the band that the store rebalancer tries to keep a
+// store's load along one dimension within.
+type dimThresholds struct {
+	enabled bool
+	mean    float64
+	min     float64
+	max     float64
+}
+
+// loadThresholds holds the rebalancing thresholds for every load dimension.
+type loadThresholds [numLoadDimensions]dimThresholds
+
+// loadRebalanceThresholds returns the configured threshold fraction for each
+// load dimension. A zero fraction disables rebalancing on that dimension.
+func (sr *StoreRebalancer) loadRebalanceThresholds() [numLoadDimensions]float64 {
+	var fractions [numLoadDimensions]float64
+	fractions[qpsDimension] = qpsRebalanceThreshold.Get(&sr.st.SV)
+	fractions[writeBytesDimension] = writeBytesRebalanceThreshold.Get(&sr.st.SV)
+	fractions[cpuDimension] = cpuRebalanceThreshold.Get(&sr.st.SV)
+	return fractions
+}
+
+// loadThresholds computes the thresholds for each load dimension relative to
+// the mean load of the stores in storeList.
+func (sr *StoreRebalancer) loadThresholds(storeList StoreList) loadThresholds {
+	minDifferences := [numLoadDimensions]float64{
+		qpsDimension:        minQPSThresholdDifference,
+		writeBytesDimension: minWriteBytesThresholdDifference,
+		cpuDimension:        minCPUThresholdDifference,
+	}
+	var thresholds loadThresholds
+	for i, fraction := range sr.loadRebalanceThresholds() {
+		mean := storeList.candidateLoad(loadDimension(i)).mean
+		thresholds[i] = dimThresholds{
+			enabled: fraction > 0,
+			mean:    mean,
+			min:     math.Min(mean*(1-fraction), mean-minDifferences[i]),
+			max:     math.Max(mean*(1+fraction), mean+minDifferences[i]),
+		}
+	}
+	return thresholds
+}
+
+// mostOverloadedDimension returns the enabled dimension along which the
+// store's load exceeds the max threshold by the largest factor, or false if
+// the store isn't overloaded along any dimension.
+func mostOverloadedDimension(
+	sc roachpb.StoreCapacity, thresholds loadThresholds,
+) (loadDimension, bool) {
+	var worst loadDimension
+	var worstRatio float64
+	for i := range thresholds {
+		dim := loadDimension(i)
+		t := thresholds[dim]
+		if !t.enabled {
+			continue
+		}
+		if load := storeLoad(sc, dim); load > t.max {
+			if ratio := load / t.max; ratio > worstRatio {
+				worst, worstRatio = dim, ratio
+			}
+		}
+	}
+	return worst, worstRatio > 0
+}
+
+// addLeaseLoad adjusts the load recorded in sc by the load that the replica
+// incurs as leaseholder, scaled by sign.
+func addLeaseLoad(sc *roachpb.StoreCapacity, replWithStats replicaWithStats, sign float64) {
+	sc.QueriesPerSecond += sign * replWithStats.qps
+	sc.CPUPerSecond += sign * replWithStats.cpu
+}
+
 func (sr *StoreRebalancer) rebalanceStore(
 	ctx context.Context, mode LBRebalancingMode, storeList StoreList,
 ) {
-	qpsThresholdFraction := qpsRebalanceThreshold.Get(&sr.st.SV)
-
-	// First check if we should transfer leases away to better balance QPS.
-	qpsMinThreshold := math.Min(storeList.candidateQueriesPerSecond.mean*(1-qpsThresholdFraction),
-		storeList.candidateQueriesPerSecond.mean-minQPSThresholdDifference)
-	qpsMaxThreshold := math.Max(storeList.candidateQueriesPerSecond.mean*(1+qpsThresholdFraction),
-		storeList.candidateQueriesPerSecond.mean+minQPSThresholdDifference)
-
 	var localDesc *roachpb.StoreDescriptor
 	for i := range storeList.stores {
 		if storeList.stores[i].StoreID == sr.rq.store.StoreID() {
@@ -222,89 +313,104 @@ func (sr *StoreRebalancer) rebalanceStore(
 		return
 	}
 
-	if !(localDesc.Capacity.QueriesPerSecond > qpsMaxThreshold) {
-		log.VEventf(ctx, 1, "local QPS %.2f is below max threshold %.2f (mean=%.2f); no rebalancing needed",
-			localDesc.Capacity.QueriesPerSecond, qpsMaxThreshold, storeList.candidateQueriesPerSecond.mean)
+	// Only the dimension along which the store is the most overloaded is
+	// balanced each time around. The others are kept in check by refusing
+	// moves that would overload the targets along them, and are picked up
+	// again on the next run if they remain out of balance.
+	thresholds := sr.loadThresholds(storeList)
+	dim, overloaded := mostOverloadedDimension(localDesc.Capacity, thresholds)
+	if !overloaded {
+		log.VEventf(ctx, 1, "local load is below max thresholds (qps=%.2f/%.2f, "+
+			"write-bytes=%.2f/%.2f, cpu=%.2f/%.2f); no rebalancing needed",
+			localDesc.Capacity.QueriesPerSecond, thresholds[qpsDimension].max,
+			localDesc.Capacity.WriteBytesPerSecond, thresholds[writeBytesDimension].max,
+			localDesc.Capacity.CPUPerSecond, thresholds[cpuDimension].max)
 		return
 	}
+	maxThreshold := thresholds[dim].max
+	localLoad := func() float64 { return storeLoad(localDesc.Capacity, dim) }
 
 	var replicasToMaybeRebalance []replicaWithStats
 	storeMap := storeListToMap(storeList)
+	hottestRanges := sr.replRankings.top(dim)
 
-	log.Infof(ctx,
-		"considering load-based lease transfers for s%d with %.2f qps (mean=%.2f, upperThreshold=%.2f)",
-		localDesc.StoreID, localDesc.Capacity.QueriesPerSecond, storeList.candidateQueriesPerSecond.mean, qpsMaxThreshold)
-
-	hottestRanges := sr.replRankings.topQPS()
-	for localDesc.Capacity.QueriesPerSecond > qpsMaxThreshold {
-		replWithStats, target, considerForRebalance := sr.chooseLeaseToTransfer(
-			ctx, &hottestRanges, localDesc, storeList, storeMap, qpsMinThreshold, qpsMaxThreshold)
-		replicasToMaybeRebalance = append(replicasToMaybeRebalance, considerForRebalance...)
-		if replWithStats.repl == nil {
-			break
-		}
+	// First check if we should transfer leases away to better balance load.
+	// Leases only carry the load that is incurred by the leaseholder alone.
+	if dim.leaseholderOnly() {
+		log.Infof(ctx,
+			"considering load-based lease transfers for s%d with %.2f %s (mean=%.2f, upperThreshold=%.2f)",
+			localDesc.StoreID, localLoad(), dim, thresholds[dim].mean, maxThreshold)
+
+		for localLoad() > maxThreshold {
+			replWithStats, target, considerForRebalance := sr.chooseLeaseToTransfer(
+				ctx, &hottestRanges, localDesc, storeList, storeMap, dim, thresholds)
+			replicasToMaybeRebalance = append(replicasToMaybeRebalance, considerForRebalance...)
+			if replWithStats.repl == nil {
+				break
+			}
 
-		log.VEventf(ctx, 1, "transferring r%d (%.2f qps) to s%d to better balance load",
-			replWithStats.repl.RangeID, replWithStats.qps, target.StoreID)
-		if err := contextutil.RunWithTimeout(ctx, "transfer lease", sr.rq.processTimeout, func(ctx context.Context) error {
-			return sr.rq.transferLease(ctx, replWithStats.repl, target, replWithStats.qps)
-		}); err != nil {
-			log.Errorf(ctx, "unable to transfer lease to s%d: %v", target.StoreID, err)
-			continue
+			log.VEventf(ctx, 1, "transferring r%d (%.2f %s) to s%d to better balance load",
+				replWithStats.repl.RangeID, replWithStats.load(dim), dim, target.StoreID)
+			if err := contextutil.RunWithTimeout(ctx, "transfer lease", sr.rq.processTimeout, func(ctx context.Context) error {
+				return sr.rq.transferLease(ctx, replWithStats.repl, target, replWithStats.qps, replWithStats.cpu)
+			}); err != nil {
+				log.Errorf(ctx, "unable to transfer lease to s%d: %v", target.StoreID, err)
+				continue
+			}
+			sr.metrics.LeaseTransferCount.Inc(1)
+
+			// Finally, update our local copies of the descriptors so that if
+			// additional transfers are needed we'll be making the decisions with more
+			// up-to-date info. The StorePool copies are updated by transferLease.
+			localDesc.Capacity.LeaseCount--
+			addLeaseLoad(&localDesc.Capacity, replWithStats, -1)
+			if otherDesc := storeMap[target.StoreID]; otherDesc != nil {
+				otherDesc.Capacity.LeaseCount++
+				addLeaseLoad(&otherDesc.Capacity, replWithStats, 1)
+			}
 		}
-		sr.metrics.LeaseTransferCount.Inc(1)
 
-		// Finally, update our local copies of the descriptors so that if
-		// additional transfers are needed we'll be making the decisions with more
-		// up-to-date info. The StorePool copies are updated by transferLease.
-		localDesc.Capacity.LeaseCount--
-		localDesc.Capacity.QueriesPerSecond -= replWithStats.qps
-		if otherDesc := storeMap[target.StoreID]; otherDesc != nil {
-			otherDesc.Capacity.LeaseCount++
-			otherDesc.Capacity.QueriesPerSecond += replWithStats.qps
+		if !(localLoad() > maxThreshold) {
+			log.Infof(ctx,
+				"load-based lease transfers successfully brought s%d down to %.2f %s (mean=%.2f, upperThreshold=%.2f)",
+				localDesc.StoreID, localLoad(), dim, thresholds[dim].mean, maxThreshold)
+			return
 		}
 	}
 
-	if !(localDesc.Capacity.QueriesPerSecond > qpsMaxThreshold) {
-		log.Infof(ctx,
-			"load-based lease transfers successfully brought s%d down to %.2f qps (mean=%.2f, upperThreshold=%.2f)",
-			localDesc.StoreID, localDesc.Capacity.QueriesPerSecond, storeList.candidateQueriesPerSecond.mean, qpsMaxThreshold)
-		return
-	}
-
 	if mode != LBRebalancingLeasesAndReplicas {
 		log.Infof(ctx,
-			"ran out of leases worth transferring and qps (%.2f) is still above desired threshold (%.2f)",
-			localDesc.Capacity.QueriesPerSecond, qpsMaxThreshold)
+			"ran out of leases worth transferring and %s (%.2f) is still above desired threshold (%.2f)",
+			dim, localLoad(), maxThreshold)
 		return
 	}
 	log.Infof(ctx,
-		"ran out of leases worth transferring and qps (%.2f) is still above desired threshold (%.2f); considering load-based replica rebalances",
-		localDesc.Capacity.QueriesPerSecond, qpsMaxThreshold)
+		"ran out of leases worth transferring and %s (%.2f) is still above desired threshold (%.2f); considering load-based replica rebalances",
+		dim, localLoad(), maxThreshold)
 
 	// Re-combine replicasToMaybeRebalance with what remains of hottestRanges so
 	// that we'll reconsider them for replica rebalancing.
 	replicasToMaybeRebalance = append(replicasToMaybeRebalance, hottestRanges...)
 
-	for localDesc.Capacity.QueriesPerSecond > qpsMaxThreshold {
+	for localLoad() > maxThreshold {
 		replWithStats, targets := sr.chooseReplicaToRebalance(
 			ctx,
 			&replicasToMaybeRebalance,
 			localDesc,
 			storeList,
 			storeMap,
-			qpsMinThreshold,
-			qpsMaxThreshold)
+			dim,
+			thresholds)
 		if replWithStats.repl == nil {
 			log.Infof(ctx,
-				"ran out of replicas worth transferring and qps (%.2f) is still above desired threshold (%.2f); will check again soon",
-				localDesc.Capacity.QueriesPerSecond, qpsMaxThreshold)
+				"ran out of replicas worth transferring and %s (%.2f) is still above desired threshold (%.2f); will check again soon",
+				dim, localLoad(), maxThreshold)
 			return
 		}
 
 		descBeforeRebalance := replWithStats.repl.Desc()
-		log.VEventf(ctx, 1, "rebalancing r%d (%.2f qps) from %v to %v to better balance load",
-			replWithStats.repl.RangeID, replWithStats.qps, descBeforeRebalance.Replicas(), targets)
+		log.VEventf(ctx, 1, "rebalancing r%d (%.2f %s) from %v to %v to better balance load",
+			replWithStats.repl.RangeID, replWithStats.load(dim), dim, descBeforeRebalance.Replicas(), targets)
 		if err := contextutil.RunWithTimeout(ctx, "relocate range", sr.rq.processTimeout, func(ctx context.Context) error {
 			return sr.rq.store.AdminRelocateRange(ctx, *descBeforeRebalance, targets)
 		}); err != nil {
@@ -324,24 +430,26 @@ func (sr *StoreRebalancer) rebalanceStore(
 		for i := range replicasBeforeRebalance {
 			if storeDesc := storeMap[replicasBeforeRebalance[i].StoreID]; storeDesc != nil {
 				storeDesc.Capacity.RangeCount--
+				storeDesc.Capacity.WriteBytesPerSecond -= replWithStats.writeBytes
 			}
 		}
 		localDesc.Capacity.LeaseCount--
-		localDesc.Capacity.QueriesPerSecond -= replWithStats.qps
+		addLeaseLoad(&localDesc.Capacity, replWithStats, -1)
 		for i := range targets {
 			if storeDesc := storeMap[targets[i].StoreID]; storeDesc != nil {
 				storeDesc.Capacity.RangeCount++
+				storeDesc.Capacity.WriteBytesPerSecond += replWithStats.writeBytes
 				if i == 0 {
 					storeDesc.Capacity.LeaseCount++
-					storeDesc.Capacity.QueriesPerSecond += replWithStats.qps
+					addLeaseLoad(&storeDesc.Capacity, replWithStats, 1)
 				}
 			}
 		}
 	}
 
 	log.Infof(ctx,
-		"load-based replica transfers successfully brought s%d down to %.2f qps (mean=%.2f, upperThreshold=%.2f)",
-		localDesc.StoreID, localDesc.Capacity.QueriesPerSecond, storeList.candidateQueriesPerSecond.mean, qpsMaxThreshold)
+		"load-based replica transfers successfully brought s%d down to %.2f %s (mean=%.2f, upperThreshold=%.2f)",
+		localDesc.StoreID, localLoad(), dim, thresholds[dim].mean, maxThreshold)
 }
 
 // TODO(a-robinson): Should we take the number of leases on each store into
@@ -352,8 +460,8 @@ func (sr *StoreRebalancer) chooseLeaseToTransfer(
 	localDesc *roachpb.StoreDescriptor,
 	storeList StoreList,
 	storeMap map[roachpb.StoreID]*roachpb.StoreDescriptor,
-	minQPS float64,
-	maxQPS float64,
+	dim loadDimension,
+	thresholds loadThresholds,
 ) (replicaWithStats, roachpb.ReplicaDescriptor, []replicaWithStats) {
 	var considerForRebalance []replicaWithStats
 	now := sr.rq.store.Clock().Now()
@@ -369,37 +477,39 @@ func (sr *StoreRebalancer) chooseLeaseToTransfer(
 			return replicaWithStats{}, roachpb.ReplicaDescriptor{}, considerForRebalance
 		}
 
-		if shouldNotMoveAway(ctx, replWithStats, localDesc, now, minQPS) {
+		if shouldNotMoveAway(ctx, replWithStats, localDesc, now, dim, thresholds[dim].min) {
 			continue
 		}
 
-		// Don't bother moving leases whose QPS is below some small fraction of the
-		// store's QPS (unless the store has extra leases to spare anyway). It's
-		// just unnecessary churn with no benefit to move leases responsible for,
-		// for example, 1 qps on a store with 5000 qps.
-		const minQPSFraction = .001
-		if replWithStats.qps < localDesc.Capacity.QueriesPerSecond*minQPSFraction &&
+		// Don't bother moving leases whose load is below some small fraction of
+		// the store's load (unless the store has extra leases to spare anyway).
+		// It's just unnecessary churn with no benefit to move leases responsible
+		// for, for example, 1 qps on a store with 5000 qps.
+		const minLoadFraction = .001
+		if replWithStats.load(dim) < storeLoad(localDesc.Capacity, dim)*minLoadFraction &&
 			float64(localDesc.Capacity.LeaseCount) <= storeList.candidateLeases.mean {
-			log.VEventf(ctx, 5, "r%d's %.2f qps is too little to matter relative to s%d's %.2f total qps",
-				replWithStats.repl.RangeID, replWithStats.qps, localDesc.StoreID, localDesc.Capacity.QueriesPerSecond)
+			log.VEventf(ctx, 5, "r%d's %.2f %s is too little to matter relative to s%d's %.2f total",
+				replWithStats.repl.RangeID, replWithStats.load(dim), dim, localDesc.StoreID,
+				storeLoad(localDesc.Capacity, dim))
 			continue
 		}
 
 		desc, zone := replWithStats.repl.DescAndZone()
-		log.VEventf(ctx, 3, "considering lease transfer for r%d with %.2f qps",
-			desc.RangeID, replWithStats.qps)
+		log.VEventf(ctx, 3, "considering lease transfer for r%d with %.2f %s",
+			desc.RangeID, replWithStats.load(dim), dim)
 
-		// Check all the other replicas in order of increasing qps.
-		replicas := desc.Replicas().DeepCopy().Unwrap()
+		// Check all the other voters in order of increasing load. Non-voters
+		// cannot hold the lease.
+		replicas := desc.Replicas().DeepCopy().Voters()
 		sort.Slice(replicas, func(i, j int) bool {
-			var iQPS, jQPS float64
+			var iLoad, jLoad float64
 			if desc := storeMap[replicas[i].StoreID]; desc != nil {
-				iQPS = desc.Capacity.QueriesPerSecond
+				iLoad = storeLoad(desc.Capacity, dim)
 			}
 			if desc := storeMap[replicas[j].StoreID]; desc != nil {
-				jQPS = desc.Capacity.QueriesPerSecond
+				jLoad = storeLoad(desc.Capacity, dim)
 			}
-			return iQPS < jQPS
+			return iLoad < jLoad
 		})
 
 		var raftStatus *raft.Status
@@ -409,8 +519,9 @@ func (sr *StoreRebalancer) chooseLeaseToTransfer(
 				continue
 			}
 
-			meanQPS := storeList.candidateQueriesPerSecond.mean
-			if shouldNotMoveTo(ctx, storeMap, replWithStats, candidate.StoreID, meanQPS, minQPS, maxQPS) {
+			if shouldNotMoveTo(
+				ctx, storeMap, replWithStats, candidate.StoreID, dim, thresholds, true, /* leaseOnly */
+			) {
 				continue
 			}
 
@@ -423,7 +534,7 @@ func (sr *StoreRebalancer) chooseLeaseToTransfer(
 				continue
 			}
 
-			preferred := sr.rq.allocator.preferredLeaseholders(zone, desc.Replicas().Unwrap())
+			preferred := sr.rq.allocator.preferredLeaseholders(zone, desc.Replicas().Voters())
 			if len(preferred) > 0 && !storeHasReplica(candidate.StoreID, preferred) {
 				log.VEventf(ctx, 3, "s%d not a preferred leaseholder for r%d; preferred: %v",
 					candidate.StoreID, desc.RangeID, preferred)
@@ -436,7 +547,7 @@ func (sr *StoreRebalancer) chooseLeaseToTransfer(
 				filteredStoreList,
 				*localDesc,
 				candidate.StoreID,
-				desc.Replicas().Unwrap(),
+				desc.Replicas().Voters(),
 				replWithStats.repl.leaseholderStats,
 			) {
 				log.VEventf(ctx, 3, "r%d is on s%d due to follow-the-workload; skipping",
@@ -459,8 +570,8 @@ func (sr *StoreRebalancer) chooseReplicaToRebalance(
 	localDesc *roachpb.StoreDescriptor,
 	storeList StoreList,
 	storeMap map[roachpb.StoreID]*roachpb.StoreDescriptor,
-	minQPS float64,
-	maxQPS float64,
+	dim loadDimension,
+	thresholds loadThresholds,
 ) (replicaWithStats, []roachpb.ReplicationTarget) {
 	now := sr.rq.store.Clock().Now()
 	for {
@@ -474,46 +585,50 @@ func (sr *StoreRebalancer) chooseReplicaToRebalance(
 			return replicaWithStats{}, nil
 		}
 
-		if shouldNotMoveAway(ctx, replWithStats, localDesc, now, minQPS) {
+		if shouldNotMoveAway(ctx, replWithStats, localDesc, now, dim, thresholds[dim].min) {
 			continue
 		}
 
-		// Don't bother moving ranges whose QPS is below some small fraction of the
-		// store's QPS (unless the store has extra ranges to spare anyway). It's
-		// just unnecessary churn with no benefit to move ranges responsible for,
-		// for example, 1 qps on a store with 5000 qps.
-		const minQPSFraction = .001
-		if replWithStats.qps < localDesc.Capacity.QueriesPerSecond*minQPSFraction &&
+		// Don't bother moving ranges whose load is below some small fraction of
+		// the store's load (unless the store has extra ranges to spare anyway).
+		// It's just unnecessary churn with no benefit to move ranges responsible
+		// for, for example, 1 qps on a store with 5000 qps.
+		const minLoadFraction = .001
+		if replWithStats.load(dim) < storeLoad(localDesc.Capacity, dim)*minLoadFraction &&
 			float64(localDesc.Capacity.RangeCount) <= storeList.candidateRanges.mean {
-			log.VEventf(ctx, 5, "r%d's %.2f qps is too little to matter relative to s%d's %.2f total qps",
-				replWithStats.repl.RangeID, replWithStats.qps, localDesc.StoreID, localDesc.Capacity.QueriesPerSecond)
+			log.VEventf(ctx, 5, "r%d's %.2f %s is too little to matter relative to s%d's %.2f total",
+				replWithStats.repl.RangeID, replWithStats.load(dim), dim, localDesc.StoreID,
+				storeLoad(localDesc.Capacity, dim))
 			continue
 		}
 
 		desc, zone := replWithStats.repl.DescAndZone()
-		log.VEventf(ctx, 3, "considering replica rebalance for r%d with %.2f qps",
-			desc.RangeID, replWithStats.qps)
+		log.VEventf(ctx, 3, "considering replica rebalance for r%d with %.2f %s",
+			desc.RangeID, replWithStats.load(dim), dim)
 
+		// Only the voters are moved around; the non-voters, which do not serve
+		// the leaseholder's load, are left in place.
 		clusterNodes := sr.rq.allocator.storePool.ClusterNodeCount()
-		desiredReplicas := GetNeededReplicas(*zone.NumReplicas, clusterNodes)
+		desiredReplicas := GetNeededReplicas(zone.GetNumVoters(), clusterNodes)
 		targets := make([]roachpb.ReplicationTarget, 0, desiredReplicas)
 		targetReplicas := make([]roachpb.ReplicaDescriptor, 0, desiredReplicas)
+		nonVoters := desc.Replicas().NonVoters()
 
 		// Check the range's existing diversity score, since we want to ensure we
-		// don't hurt locality diversity just to improve QPS.
-		curDiversity := rangeDiversityScore(sr.rq.allocator.storePool.getLocalities(desc.Replicas().Unwrap()))
+		// don't hurt locality diversity just to improve load.
+		curDiversity := rangeDiversityScore(sr.rq.allocator.storePool.getLocalities(desc.Replicas().Voters()))
 
-		// Check the existing replicas, keeping around those that aren't overloaded.
-		replicas := desc.Replicas().Unwrap()
+		// Check the existing voters, keeping around those that aren't overloaded.
+		replicas := desc.Replicas().Voters()
 		for i := range replicas {
 			if replicas[i].StoreID == localDesc.StoreID {
 				continue
 			}
-			// Keep the replica in the range if we don't know its QPS or if its QPS
+			// Keep the replica in the range if we don't know its load or if its load
 			// is below the upper threshold. Punishing stores not in our store map
 			// could cause mass evictions if the storePool gets out of sync.
 			storeDesc, ok := storeMap[replicas[i].StoreID]
-			if !ok || storeDesc.Capacity.QueriesPerSecond < maxQPS {
+			if !ok || storeLoad(storeDesc.Capacity, dim) < thresholds[dim].max {
 				targets = append(targets, roachpb.ReplicationTarget{
 					NodeID:  replicas[i].NodeID,
 					StoreID: replicas[i].StoreID,
@@ -527,19 +642,24 @@ func (sr *StoreRebalancer) chooseReplicaToRebalance(
 
 		// Then pick out which new stores to add the remaining replicas to.
 		rangeInfo := rangeInfoForRepl(replWithStats.repl, desc)
-		// Make sure to use the same qps measurement throughout everything we do.
+		// Make sure to use the same load measurements throughout everything we do.
 		rangeInfo.QueriesPerSecond = replWithStats.qps
+		rangeInfo.WriteBytesPerSecond = replWithStats.writeBytes
+		rangeInfo.CPUPerSecond = replWithStats.cpu
 		options := sr.rq.allocator.scorerOptions()
-		options.qpsRebalanceThreshold = qpsRebalanceThreshold.Get(&sr.st.SV)
+		options.loadRebalanceThresholds = sr.loadRebalanceThresholds()
 		for len(targets) < desiredReplicas {
 			// Use the preexisting AllocateTarget logic to ensure that considerations
 			// such as zone constraints, locality diversity, and full disk come
 			// into play.
+			//
+			// The non-voters are passed along as existing replicas since a node
+			// can only hold a single replica of the range.
 			target, _ := sr.rq.allocator.allocateTargetFromList(
 				ctx,
 				storeList,
 				zone,
-				targetReplicas,
+				append(targetReplicas[:len(targetReplicas):len(targetReplicas)], nonVoters...),
 				rangeInfo,
 				options,
 			)
@@ -549,8 +669,9 @@ func (sr *StoreRebalancer) chooseReplicaToRebalance(
 				break
 			}
 
-			meanQPS := storeList.candidateQueriesPerSecond.mean
-			if shouldNotMoveTo(ctx, storeMap, replWithStats, target.StoreID, meanQPS, minQPS, maxQPS) {
+			if shouldNotMoveTo(
+				ctx, storeMap, replWithStats, target.StoreID, dim, thresholds, false, /* leaseOnly */
+			) {
 				break
 			}
 
@@ -584,10 +705,16 @@ func (sr *StoreRebalancer) chooseReplicaToRebalance(
 			continue
 		}
 
-		// Pick the replica with the least QPS to be leaseholder;
-		// RelocateRange transfers the lease to the first provided target.
+		// Pick the replica with the least load to be leaseholder; RelocateRange
+		// transfers the lease to the first provided target. Write load isn't
+		// carried by the lease, so QPS is used to place it when rebalancing on
+		// writes.
+		leaseDim := dim
+		if !leaseDim.leaseholderOnly() {
+			leaseDim = qpsDimension
+		}
 		newLeaseIdx := 0
-		newLeaseQPS := math.MaxFloat64
+		newLeaseLoad := math.MaxFloat64
 		var raftStatus *raft.Status
 		for i := 0; i < len(targets); i++ {
 			// Ensure we don't transfer the lease to an existing replica that is behind
@@ -608,12 +735,19 @@ func (sr *StoreRebalancer) chooseReplicaToRebalance(
 			}
 
 			storeDesc, ok := storeMap[targets[i].StoreID]
-			if ok && storeDesc.Capacity.QueriesPerSecond < newLeaseQPS {
+			if ok && storeLoad(storeDesc.Capacity, leaseDim) < newLeaseLoad {
 				newLeaseIdx = i
-				newLeaseQPS = storeDesc.Capacity.QueriesPerSecond
+				newLeaseLoad = storeLoad(storeDesc.Capacity, leaseDim)
 			}
 		}
 		targets[0], targets[newLeaseIdx] = targets[newLeaseIdx], targets[0]
+		// Keep the non-voters so that relocating the range doesn't remove them.
+		for _, nonVoter := range nonVoters {
+			targets = append(targets, roachpb.ReplicationTarget{
+				NodeID:  nonVoter.NodeID,
+				StoreID: nonVoter.StoreID,
+			})
+		}
 		return replWithStats, targets
 	}
 }
@@ -623,28 +757,35 @@ func shouldNotMoveAway(
 	replWithStats replicaWithStats,
 	localDesc *roachpb.StoreDescriptor,
 	now hlc.Timestamp,
-	minQPS float64,
+	dim loadDimension,
+	minLoad float64,
 ) bool {
 	if !replWithStats.repl.OwnsValidLease(now) {
 		log.VEventf(ctx, 3, "store doesn't own the lease for r%d", replWithStats.repl.RangeID)
 		return true
 	}
-	if localDesc.Capacity.QueriesPerSecond-replWithStats.qps < minQPS {
-		log.VEventf(ctx, 3, "moving r%d's %.2f qps would bring s%d below the min threshold (%.2f)",
-			replWithStats.repl.RangeID, replWithStats.qps, localDesc.StoreID, minQPS)
+	if storeLoad(localDesc.Capacity, dim)-replWithStats.load(dim) < minLoad {
+		log.VEventf(ctx, 3, "moving r%d's %.2f %s would bring s%d below the min threshold (%.2f)",
+			replWithStats.repl.RangeID, replWithStats.load(dim), dim, localDesc.StoreID, minLoad)
 		return true
 	}
 	return false
 }
 
+// shouldNotMoveTo returns whether moving the replica's load to the candidate
+// store would make the balance worse. Along the dimension being balanced, the
+// candidate must not end up over the mean (or the max threshold, if it's
+// currently underfull). Along every other enabled dimension, the candidate
+// must not end up overfull. If leaseOnly is set, only the load carried by the
+// lease is moving.
 func shouldNotMoveTo(
 	ctx context.Context,
 	storeMap map[roachpb.StoreID]*roachpb.StoreDescriptor,
 	replWithStats replicaWithStats,
 	candidateStore roachpb.StoreID,
-	meanQPS float64,
-	minQPS float64,
-	maxQPS float64,
+	dim loadDimension,
+	thresholds loadThresholds,
+	leaseOnly bool,
 ) bool {
 	storeDesc, ok := storeMap[candidateStore]
 	if !ok {
@@ -652,21 +793,38 @@ func shouldNotMoveTo(
 		return true
 	}
 
-	newCandidateQPS := storeDesc.Capacity.QueriesPerSecond + replWithStats.qps
-	if storeDesc.Capacity.QueriesPerSecond < minQPS {
-		if newCandidateQPS > maxQPS {
+	t := thresholds[dim]
+	candidateLoad := storeLoad(storeDesc.Capacity, dim)
+	newCandidateLoad := candidateLoad + replWithStats.load(dim)
+	if candidateLoad < t.min {
+		if newCandidateLoad > t.max {
 			log.VEventf(ctx, 3,
-				"r%d's %.2f qps would push s%d over the max threshold (%.2f) with %.2f qps afterwards",
-				replWithStats.repl.RangeID, replWithStats.qps, candidateStore, maxQPS, newCandidateQPS)
+				"r%d's %.2f %s would push s%d over the max threshold (%.2f) with %.2f afterwards",
+				replWithStats.repl.RangeID, replWithStats.load(dim), dim, candidateStore, t.max, newCandidateLoad)
 			return true
 		}
-	} else if newCandidateQPS > meanQPS {
+	} else if newCandidateLoad > t.mean {
 		log.VEventf(ctx, 3,
-			"r%d's %.2f qps would push s%d over the mean (%.2f) with %.2f qps afterwards",
-			replWithStats.repl.RangeID, replWithStats.qps, candidateStore, meanQPS, newCandidateQPS)
+			"r%d's %.2f %s would push s%d over the mean (%.2f) with %.2f afterwards",
+			replWithStats.repl.RangeID, replWithStats.load(dim), dim, candidateStore, t.mean, newCandidateLoad)
 		return true
 	}
 
+	for i := range thresholds {
+		other := loadDimension(i)
+		if other == dim || !thresholds[other].enabled || (leaseOnly && !other.leaseholderOnly()) {
+			continue
+		}
+		newLoad := storeLoad(storeDesc.Capacity, other) + replWithStats.load(other)
+		if replWithStats.load(other) > 0 && newLoad > thresholds[other].max {
+			log.VEventf(ctx, 3,
+				"r%d's %.2f %s would push s%d over the max threshold (%.2f) with %.2f afterwards",
+				replWithStats.repl.RangeID, replWithStats.load(other), other, candidateStore,
+				thresholds[other].max, newLoad)
+			return true
+		}
+	}
+
 	return false
 }
 
@@ -26,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/gogo/protobuf/proto"
 	"go.etcd.io/etcd/raft"
+	"go.etcd.io/etcd/raft/tracker"
 )
 
 var (
@@ -99,6 +100,8 @@ func loadRanges(rr *replicaRankings, s *Store, ranges []testRange) {
 		repl.mu.state.Stats = &enginepb.MVCCStats{}
 		repl.leaseholderStats = newReplicaStats(s.Clock(), nil)
 		repl.writeStats = newReplicaStats(s.Clock(), nil)
+		repl.writeBytesStats = newReplicaStats(s.Clock(), nil)
+		repl.cpuStats = newReplicaStats(s.Clock(), nil)
 		acc.addReplica(replicaWithStats{
 			repl: repl,
 			qps:  r.qps,
@@ -131,20 +134,22 @@ func TestChooseLeaseToTransfer(t *testing.T) {
 	rr := newReplicaRankings()
 
 	sr := NewStoreRebalancer(cfg.AmbientCtx, cfg.Settings, rq, rr)
+	thresholds := sr.loadThresholds(storeList)
+	thresholds[qpsDimension].min, thresholds[qpsDimension].max = minQPS, maxQPS
 
 	// Rather than trying to populate every Replica with a real raft group in
 	// order to pass replicaIsBehind checks, fake out the function for getting
 	// raft status with one that always returns all replicas as up to date.
 	sr.getRaftStatusFn = func(r *Replica) *raft.Status {
 		status := &raft.Status{
-			Progress: make(map[uint64]raft.Progress),
+			Progress: make(map[uint64]tracker.Progress),
 		}
 		status.Lead = uint64(r.ReplicaID())
 		status.Commit = 1
 		for _, replica := range r.Desc().InternalReplicas {
-			status.Progress[uint64(replica.ReplicaID)] = raft.Progress{
+			status.Progress[uint64(replica.ReplicaID)] = tracker.Progress{
 				Match: 1,
-				State: raft.ProgressStateReplicate,
+				State: tracker.StateReplicate,
 			}
 		}
 		return status
@@ -182,7 +187,7 @@ func TestChooseLeaseToTransfer(t *testing.T) {
 		loadRanges(rr, s, []testRange{{storeIDs: tc.storeIDs, qps: tc.qps}})
 		hottestRanges := rr.topQPS()
 		_, target, _ := sr.chooseLeaseToTransfer(
-			ctx, &hottestRanges, &localDesc, storeList, storeMap, minQPS, maxQPS)
+			ctx, &hottestRanges, &localDesc, storeList, storeMap, qpsDimension, thresholds)
 		if target.StoreID != tc.expectTarget {
 			t.Errorf("got target store %d for range with replicas %v and %f qps; want %d",
 				target.StoreID, tc.storeIDs, tc.qps, tc.expectTarget)
@@ -214,20 +219,22 @@ func TestChooseReplicaToRebalance(t *testing.T) {
 	rr := newReplicaRankings()
 
 	sr := NewStoreRebalancer(cfg.AmbientCtx, cfg.Settings, rq, rr)
+	thresholds := sr.loadThresholds(storeList)
+	thresholds[qpsDimension].min, thresholds[qpsDimension].max = minQPS, maxQPS
 
 	// Rather than trying to populate every Replica with a real raft group in
 	// order to pass replicaIsBehind checks, fake out the function for getting
 	// raft status with one that always returns all replicas as up to date.
 	sr.getRaftStatusFn = func(r *Replica) *raft.Status {
 		status := &raft.Status{
-			Progress: make(map[uint64]raft.Progress),
+			Progress: make(map[uint64]tracker.Progress),
 		}
 		status.Lead = uint64(r.ReplicaID())
 		status.Commit = 1
 		for _, replica := range r.Desc().InternalReplicas {
-			status.Progress[uint64(replica.ReplicaID)] = raft.Progress{
+			status.Progress[uint64(replica.ReplicaID)] = tracker.Progress{
 				Match: 1,
-				State: raft.ProgressStateReplicate,
+				State: tracker.StateReplicate,
 			}
 		}
 		return status
@@ -267,7 +274,7 @@ func TestChooseReplicaToRebalance(t *testing.T) {
 			loadRanges(rr, s, []testRange{{storeIDs: tc.storeIDs, qps: tc.qps}})
 			hottestRanges := rr.topQPS()
 			_, targets := sr.chooseReplicaToRebalance(
-				ctx, &hottestRanges, &localDesc, storeList, storeMap, minQPS, maxQPS)
+				ctx, &hottestRanges, &localDesc, storeList, storeMap, qpsDimension, thresholds)
 
 			if len(targets) != len(tc.expectTargets) {
 				t.Fatalf("chooseReplicaToRebalance(existing=%v, qps=%f) got %v; want %v",
@@ -322,6 +329,8 @@ func TestNoLeaseTransferToBehindReplicas(t *testing.T) {
 	rr := newReplicaRankings()
 
 	sr := NewStoreRebalancer(cfg.AmbientCtx, cfg.Settings, rq, rr)
+	thresholds := sr.loadThresholds(storeList)
+	thresholds[qpsDimension].min, thresholds[qpsDimension].max = minQPS, maxQPS
 
 	// Load in a range with replicas on an overfull node, a slightly underfull
 	// node, and a very underfull node.
@@ -333,7 +342,7 @@ func TestNoLeaseTransferToBehindReplicas(t *testing.T) {
 	// are caught up). We thus shouldn't transfer a lease to s5.
 	sr.getRaftStatusFn = func(r *Replica) *raft.Status {
 		status := &raft.Status{
-			Progress: make(map[uint64]raft.Progress),
+			Progress: make(map[uint64]tracker.Progress),
 		}
 		status.Lead = uint64(r.ReplicaID())
 		status.Commit = 1
@@ -342,16 +351,16 @@ func TestNoLeaseTransferToBehindReplicas(t *testing.T) {
 			if replica.StoreID == roachpb.StoreID(5) {
 				match = 0
 			}
-			status.Progress[uint64(replica.ReplicaID)] = raft.Progress{
+			status.Progress[uint64(replica.ReplicaID)] = tracker.Progress{
 				Match: match,
-				State: raft.ProgressStateReplicate,
+				State: tracker.StateReplicate,
 			}
 		}
 		return status
 	}
 
 	_, target, _ := sr.chooseLeaseToTransfer(
-		ctx, &hottestRanges, &localDesc, storeList, storeMap, minQPS, maxQPS)
+		ctx, &hottestRanges, &localDesc, storeList, storeMap, qpsDimension, thresholds)
 	expectTarget := roachpb.StoreID(4)
 	if target.StoreID != expectTarget {
 		t.Errorf("got target store s%d for range with RaftStatus %v; want s%d",
@@ -366,7 +375,7 @@ func TestNoLeaseTransferToBehindReplicas(t *testing.T) {
 	repl = hottestRanges[0].repl
 
 	_, targets := sr.chooseReplicaToRebalance(
-		ctx, &hottestRanges, &localDesc, storeList, storeMap, minQPS, maxQPS)
+		ctx, &hottestRanges, &localDesc, storeList, storeMap, qpsDimension, thresholds)
 	expectTargets := []roachpb.ReplicationTarget{
 		{NodeID: 4, StoreID: 4}, {NodeID: 5, StoreID: 5}, {NodeID: 3, StoreID: 3},
 	}